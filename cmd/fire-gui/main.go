@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"runtime"
 	"time"
 
@@ -14,7 +15,13 @@ import (
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/mscrnt/project_fire/internal/version"
+	"github.com/mscrnt/project_fire/pkg/config"
 	"github.com/mscrnt/project_fire/pkg/gui"
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/mscrnt/project_fire/pkg/inventory"
+	"github.com/mscrnt/project_fire/pkg/logging"
+	"github.com/mscrnt/project_fire/pkg/sensors"
+	"github.com/mscrnt/project_fire/pkg/session"
 	"github.com/mscrnt/project_fire/pkg/telemetry"
 )
 
@@ -23,6 +30,9 @@ var (
 	buildVersion string
 	buildCommit  string
 	buildTime    string
+
+	logger = logging.For("gui")
+	uiLog  = logging.For("ui")
 )
 
 func main() {
@@ -36,9 +46,17 @@ func run() int {
 	telemetryEnabled := flag.Bool("telemetry", true, "Enable anonymous telemetry for hardware compatibility")
 	telemetryEndpoint := flag.String("telemetry-endpoint", "", "Custom telemetry endpoint")
 	noSplash := flag.Bool("no-splash", false, "Skip startup splash screen")
+	startInTray := flag.Bool("tray", false, "Start in the system tray with the window hidden")
 	enableDebugServer := flag.Bool("debug-server", false, "Enable debug HTTP server on port 8888")
+	logLevel := flag.String("log-level", logging.DefaultLevel, logging.LevelUsage)
+	logFile := flag.String("log-file", gui.LogFile, logging.FileUsage)
 	flag.Parse()
 
+	// Log to the console until the previous session's log is archived
+	if _, err := logging.Configure(*logLevel, "", os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
 	// Set app version for telemetry
 	appVersion := version.GetVersion(buildVersion, buildCommit, buildTime)
 	if appVersion == "dev-" || appVersion == "-" {
@@ -46,8 +64,32 @@ func run() int {
 	}
 	telemetry.SetAppVersion(appVersion)
 
+	// Flags override the settings shared with bench
+	settings, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	// Speak the language bench does: the setting, else the locale
+	language := settings.Language
+	if language == "" {
+		language = i18n.Detect()
+	}
+	i18n.SetLanguage(language)
+
+	enabled, endpoint := *telemetryEnabled, *telemetryEndpoint
+	if !flagSet("telemetry") && settings.Telemetry.Enabled != nil {
+		enabled = *settings.Telemetry.Enabled
+	}
+	if endpoint == "" {
+		endpoint = settings.Telemetry.Endpoint
+	}
+	sensors.Configure(sensors.Options{
+		NoBMC:  settings.Sensors.BMC != nil && !*settings.Sensors.BMC,
+		WebURL: settings.Sensors.LHMURL,
+	})
+
 	// Initialize telemetry
-	telemetry.Initialize(*telemetryEndpoint, "", *telemetryEnabled)
+	telemetry.Initialize(endpoint, "", enabled)
 
 	// Set up panic handler
 	defer func() {
@@ -69,6 +111,12 @@ func run() int {
 		return 0
 	}
 
+	// A report or session passed on the command line (e.g. by double-clicking
+	// it) opens in a standalone viewer, alongside a running dashboard if any
+	if flag.NArg() > 0 && session.IsSessionPath(flag.Arg(0)) {
+		return viewSessionFile(flag.Arg(0))
+	}
+
 	// Check for single instance
 	if !gui.CheckSingleInstance() {
 		fmt.Println("F.I.R.E. GUI is already running!")
@@ -93,24 +141,29 @@ func run() int {
 	if *clearLogs {
 		gui.ClearLogs()
 	}
+	gui.LogFile = *logFile
+	if closer, err := logging.Configure(*logLevel, *logFile, os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	} else {
+		defer func() { _ = closer.Close() }()
+	}
 
 	// Set up fmt import
 	fmt.Println("Starting F.I.R.E. GUI...")
 	fmt.Printf("Starting at: %s\n", time.Now().Format("2006-01-02 15:04:05"))
-	fmt.Printf("Admin mode: %v\n", gui.IsRunningAsAdmin())
+	fmt.Printf("Admin mode: %v\n", inventory.IsRunningAsAdmin())
 
 	// Initialize debug server if enabled
 	if *enableDebugServer {
 		debugSrv := gui.NewDebugServer(8888)
 		gui.GlobalDebugServer = debugSrv
 		go debugSrv.Start()
-		gui.DebugLog("INFO", "Debug server started on port 8888")
+		logger.Info("Debug server started on port 8888")
 	}
-	gui.DebugLog("INFO", "Starting F.I.R.E. GUI...")
-	gui.DebugLog("INFO", fmt.Sprintf("Admin mode: %v", gui.IsRunningAsAdmin()))
+	logger.Info("Starting F.I.R.E. GUI...", "version", appVersion, "admin", inventory.IsRunningAsAdmin())
 
 	// Add checkpoint
-	gui.DebugCheckpoint("startup")
+	logger.Debug("Checkpoint", "name", "startup")
 
 	// Fix locale issue in WSL/minimal environments
 	// Set a minimal but valid locale that Fyne will accept
@@ -121,13 +174,13 @@ func run() int {
 		_ = os.Setenv("LC_ALL", "en_US.UTF-8")
 	}
 
-	gui.DebugLog("INFO", "Creating Fyne application...")
+	logger.Info("Creating Fyne application...")
 	// Create the application
 	myApp := app.NewWithID("com.fire.testbench")
 	myApp.SetIcon(theme.ComputerIcon()) // TODO: Use custom icon
 
-	// Apply FIRE theme
-	myApp.Settings().SetTheme(gui.FireDarkTheme{})
+	// Apply the theme of the settings
+	myApp.Settings().SetTheme(gui.SettingsTheme())
 
 	// Create main window immediately
 	window := myApp.NewWindow("F.I.R.E. System Monitor")
@@ -135,18 +188,23 @@ func run() int {
 	window.CenterOnScreen()
 
 	// Check admin status
-	isAdmin := gui.IsRunningAsAdmin()
+	isAdmin := inventory.IsRunningAsAdmin()
 	if !isAdmin {
-		gui.DebugLog("WARNING", "Not running as Administrator - some features will be limited")
+		logger.Warn("Not running as Administrator - some features will be limited")
 	} else {
-		gui.DebugLog("INFO", "Running with Administrator privileges")
+		logger.Info("Running with Administrator privileges")
 	}
 
-	var cache *gui.StaticCache
+	var (
+		cache  *gui.StaticCache
+		hidden bool // Started in the tray
+	)
 
-	if *noSplash {
+	// The settings can turn the loading screen off as well; it is skipped
+	// when starting in the tray
+	if *noSplash || *startInTray || (settings.GUI.Splash != nil && !*settings.GUI.Splash) {
 		// No loading screen - create GUI immediately with empty cache
-		gui.DebugLog("INFO", "Skipping loading screen...")
+		logger.Info("Skipping loading screen...")
 		fireGUI := gui.CreateFireGUI(myApp, nil)
 		window.SetContent(fireGUI.Content())
 
@@ -156,14 +214,14 @@ func run() int {
 		}
 
 		// Set close handler
-		window.SetCloseIntercept(func() {
-			gui.DebugLog("INFO", "Window close requested")
-			fireGUI.GetDashboard().Stop()
-			myApp.Quit()
-		})
+		inTray := fireGUI.SetupTray(window, *startInTray)
+		hidden = inTray && *startInTray
+		setCloseHandler(myApp, window, fireGUI, inTray)
 
 		// Start monitoring
 		fireGUI.GetDashboard().Start()
+		fireGUI.WatchAlerts()
+		fireGUI.ShowStartPage()
 
 		// Show admin warning after window loads
 		go func() {
@@ -177,7 +235,7 @@ func run() int {
 		}()
 	} else {
 		// Create loading overlay
-		gui.DebugLog("INFO", "Creating loading overlay...")
+		logger.Info("Creating loading overlay...")
 		loadingOverlay, loadingLabel, progressBar := gui.CreateLoadingOverlay()
 		window.SetContent(loadingOverlay)
 
@@ -189,7 +247,7 @@ func run() int {
 
 		// Start background loader
 		go func() {
-			gui.DebugLog("INFO", "Starting component loading in background...")
+			logger.Info("Starting component loading in background...")
 			cache = gui.LoadComponentsAsync(updates)
 			close(updates)
 		}()
@@ -198,14 +256,14 @@ func run() int {
 		go func() {
 			// Process progress updates
 			for u := range updates {
-				gui.DebugLog("LOADING_UI", fmt.Sprintf("Progress update: Step %d/%d - %s", u.Step, u.Total, u.Text))
+				uiLog.Debug("Progress update", "step", u.Step, "total", u.Total, "text", u.Text)
 				fyne.Do(func() {
 					// Update RichText with larger font
 					loadingLabel.ParseMarkdown("### " + u.Text)
 					progressValue := float64(u.Step) / float64(u.Total)
 					progressBar.SetValue(progressValue)
 					progressBar.Refresh() // Trigger gradient update
-					gui.DebugLog("LOADING_UI", fmt.Sprintf("Progress bar set to: %.2f%%", progressValue*100))
+					uiLog.Debug("Progress bar set", "value", progressValue)
 				})
 			}
 
@@ -214,7 +272,7 @@ func run() int {
 
 			// Swap in the real UI
 			fyne.Do(func() {
-				gui.DebugLog("INFO", "Loading complete, creating main GUI...")
+				logger.Info("Loading complete, creating main GUI...")
 
 				// Create the full GUI with cached data
 				fireGUI := gui.CreateFireGUI(myApp, cache)
@@ -228,17 +286,14 @@ func run() int {
 				}
 
 				// Set close handler
-				window.SetCloseIntercept(func() {
-					gui.DebugLog("INFO", "Window close requested")
-					fireGUI.GetDashboard().Stop()
-					myApp.Quit()
-				})
+				setCloseHandler(myApp, window, fireGUI, fireGUI.SetupTray(window, false))
 
 				// Start dashboard monitoring
 				fireGUI.GetDashboard().Start()
+				fireGUI.WatchAlerts()
 
-				// Show first navigation page
-				fireGUI.Navigation().ShowPage(0)
+				// Show the page the settings open on
+				fireGUI.ShowStartPage()
 
 				// Show admin warning if needed
 				if !isAdmin {
@@ -249,7 +304,7 @@ func run() int {
 					})
 				}
 
-				gui.DebugLog("INFO", "Main GUI ready")
+				logger.Info("Main GUI ready")
 				fmt.Println("✅ F.I.R.E. GUI ready")
 			})
 		}()
@@ -258,24 +313,69 @@ func run() int {
 	// Register debug callbacks if debug server is enabled
 	if gui.GlobalDebugServer != nil {
 		gui.GlobalDebugServer.RegisterCallback("test", func() {
-			gui.DebugLog("INFO", "Test callback executed!")
+			logger.Info("Test callback executed!")
 		})
 
 		gui.GlobalDebugServer.RegisterCallback("update_dashboard", func() {
 			// Will be set once GUI is created
-			gui.DebugLog("INFO", "Dashboard update requested")
+			logger.Info("Dashboard update requested")
 		})
 	}
 
-	gui.DebugCheckpoint("pre-run")
-	gui.DebugLog("INFO", "Starting main event loop...")
+	logger.Debug("Checkpoint", "name", "pre-run")
+	logger.Info("Starting main event loop...")
 
 	// This is the ONLY event loop - everything else uses Show()
-	window.ShowAndRun()
+	if hidden {
+		myApp.Run()
+	} else {
+		window.ShowAndRun()
+	}
 
-	gui.DebugLog("INFO", "ShowAndRun returned - GUI window closed")
-	gui.DebugLog("INFO", "GUI exited normally")
+	logger.Info("ShowAndRun returned - GUI window closed")
+	logger.Info("GUI exited normally")
 	fmt.Println("🚪 GUI exited normally")
 
 	return 0
 }
+
+// setCloseHandler quits when the window is closed, or hides the window
+// when the GUI keeps running in the tray
+func setCloseHandler(myApp fyne.App, window fyne.Window, fireGUI *gui.FireGUI, inTray bool) {
+	window.SetCloseIntercept(func() {
+		if inTray {
+			logger.Info("Window hidden to the tray")
+			window.Hide()
+			return
+		}
+		logger.Info("Window close requested")
+		fireGUI.GetDashboard().Stop()
+		myApp.Quit()
+	})
+}
+
+// flagSet reports whether a flag was given on the command line
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// viewSessionFile opens a .firereport or .firesession file in the viewer
+func viewSessionFile(path string) int {
+	f, err := session.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open %s: %v\n", path, err)
+		return 1
+	}
+
+	myApp := app.NewWithID("com.fire.testbench.viewer")
+	myApp.Settings().SetTheme(gui.SettingsTheme())
+	gui.ShowSessionViewer(myApp, f, filepath.Base(path))
+	myApp.Run()
+	return 0
+}
@@ -12,13 +12,14 @@ import (
 	"time"
 
 	"github.com/mscrnt/project_fire/pkg/agent"
+	"github.com/mscrnt/project_fire/pkg/i18n"
 	"github.com/spf13/cobra"
 )
 
 func agentCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "agent",
-		Short: "Remote diagnostic agent",
+		Short: i18n.T("cmd.agent"),
 		Long:  "Manage the F.I.R.E. remote diagnostic agent for system monitoring",
 	}
 
@@ -35,6 +36,9 @@ func agentServeCmd() *cobra.Command {
 		keyFile  string
 		caFile   string
 		logFile  string
+
+		smartInterval time.Duration
+		sinkURLs      []string
 	)
 
 	cmd := &cobra.Command{
@@ -47,6 +51,23 @@ The agent exposes the following endpoints:
   /logs     - Application logs (with optional tail parameter)
   /sensors  - Hardware sensors (temperature, fans)
   /health   - Health check endpoint
+  /results  - Test results, paged (run, metric, since, until, limit, cursor)
+  /results/series - A metric downsampled to avg/min/max per bucket
+              (metric, run, since, until, and width or points)
+  /plan     - POST a test plan to run it, GET its progress (used by bench fleet)
+
+While serving, the agent saves a SMART snapshot of every drive to the results
+database each --smart-interval, so bench show can list what changed on the
+drives (reallocated sectors, media errors, data written) between runs.
+
+The agent also applies the retention policy of the settings
+("database.retention") daily, or at "database.retention.interval": samples
+older than "samples" (default 90d) are averaged per "resolution" (default
+1m), and runs older than "runs" are deleted. See bench db prune.
+
+With --sink, or "sink" in the settings file, the agent streams the
+dashboard readings of the machine to InfluxDB, PostgreSQL, TimescaleDB or
+an MQTT broker while it serves, at the interval set there (default 5s).
 
 Examples:
   # Start with default settings (requires cert files)
@@ -55,6 +76,9 @@ Examples:
   # Start on custom port with logging
   bench agent serve --port 2223 --cert server.pem --key server.key --ca ca.pem --log agent.log
 
+  # Stream the machine's sensors to InfluxDB while serving
+  bench agent serve --cert server.pem --key server.key --ca ca.pem --sink influxdb://metrics.lab:8086/fire
+
   # Using environment variables
   export FIRE_AGENT_PORT=2223
   export FIRE_AGENT_CERT=server.pem
@@ -85,6 +109,19 @@ Examples:
 				KeyFile:  keyFile,
 				CAFile:   caFile,
 				LogFile:  logFile,
+
+				SMARTInterval: smartInterval,
+			}
+			if cfg, err := getDBConfig(); err == nil {
+				config.Retention = cfg.Retention
+			}
+			hookConfig, err := getHooksConfig()
+			if err != nil {
+				return err
+			}
+			config.Hooks = hookConfig
+			if config.Limits, err = getSafetyLimits(); err != nil {
+				return err
 			}
 
 			// Create server
@@ -93,6 +130,22 @@ Examples:
 				return fmt.Errorf("failed to create server: %w", err)
 			}
 
+			// Serve test results when the database is available
+			if database, err := openDatabase(); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: /results disabled: %v\n", err)
+			} else {
+				defer func() { _ = database.Close() }()
+				server.SetDatabase(database)
+			}
+
+			sinks, err := openSinks(context.Background(), sinkURLs)
+			if err != nil {
+				return err
+			}
+			defer closeSinks(sinks)
+			stopStream := sinks.Stream(context.Background(), hostTags("agent"))
+			defer stopStream()
+
 			// Setup signal handling
 			sigChan := make(chan os.Signal, 1)
 			signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
@@ -106,7 +159,7 @@ Examples:
 			fmt.Printf("Agent server started on port %d with mTLS\n", port)
 			fmt.Printf("Certificate: %s\n", certFile)
 			fmt.Printf("CA: %s\n", caFile)
-			fmt.Println("\nPress Ctrl+C to stop...")
+			fmt.Printf("\n%s\n", i18n.T("prompt.stop"))
 
 			// Wait for signal or error
 			select {
@@ -131,6 +184,8 @@ Examples:
 	cmd.Flags().StringVar(&keyFile, "key", "", "Server private key file (required)")
 	cmd.Flags().StringVar(&caFile, "ca", "", "CA certificate file for client verification (required)")
 	cmd.Flags().StringVar(&logFile, "log", "", "Log file path (optional)")
+	cmd.Flags().DurationVar(&smartInterval, "smart-interval", agent.DefaultSMARTInterval, "Time between SMART snapshots of every drive (0 disables them)")
+	cmd.Flags().StringArrayVar(&sinkURLs, "sink", nil, sinkFlagUsage)
 
 	return cmd
 }
@@ -156,6 +211,9 @@ Available endpoints:
   logs     - Application logs
   sensors  - Hardware sensors
   health   - Health check
+  results  - Test results, paged
+  results/series - A metric downsampled per time bucket
+  plan     - Progress of the last test plan
 
 Examples:
   # Get system information
@@ -166,6 +224,10 @@ Examples:
   bench agent connect --host server.local --endpoint "logs?tail=50" \
     --cert client.pem --key client.key --ca ca.pem
 
+  # Get a multi-hour run's temperature as 200 points
+  bench agent connect --host server.local --endpoint "results/series?run=42&metric=cpu_temp&points=200" \
+    --cert client.pem --key client.key --ca ca.pem
+
   # Pretty print JSON output
   bench agent connect --host 192.168.1.100 --endpoint sysinfo \
     --cert client.pem --key client.key --ca ca.pem --pretty`,
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/alerts"
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/spf13/cobra"
+)
+
+func alertsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "alerts",
+		Short: i18n.T("cmd.alerts"),
+		Long: `Manage the rules that raise alerts when a reading crosses a threshold, and
+where alerts are sent.
+
+A rule names a metric as 'bench monitor' reports it (cpu_temp, cpu_power,
+vrm_temp, gpu0_temp, gpu0_hotspot, fan_cpu_fan, ...), a comparator, a
+threshold and optionally how long the reading must stay past it. Patterns
+such as gpu*_temp match every GPU. Rules are checked by 'bench monitor' and
+while the GUI is open; fired and resolved alerts go to desktop
+notifications (GUI), email and webhooks, and are kept in the database as
+alert history.
+
+Rules and channels are stored in ~/.fire/alerts.json, or the file named by
+FIRE_ALERTS, and can also be edited under Settings in the GUI.`,
+	}
+
+	cmd.AddCommand(alertsListCmd())
+	cmd.AddCommand(alertsSetCmd())
+	cmd.AddCommand(alertsRemoveCmd())
+	cmd.AddCommand(alertsChannelsCmd())
+	cmd.AddCommand(alertsTestCmd())
+	cmd.AddCommand(alertsHistoryCmd())
+
+	return cmd
+}
+
+func alertsListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List alert rules and channels",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			cfg, err := alerts.Load(alerts.DefaultPath())
+			if err != nil {
+				return err
+			}
+			if len(cfg.Rules) == 0 {
+				fmt.Println(i18n.T("alerts.no_rules"))
+			} else {
+				fmt.Printf("%-24s %-36s %s\n", i18n.T("table.rule"), i18n.T("table.condition"), i18n.T("table.state"))
+				fmt.Println(strings.Repeat("-", 70))
+				for _, r := range cfg.Rules {
+					state := i18n.T("alerts.enabled")
+					if r.Disabled {
+						state = i18n.T("alerts.disabled")
+					}
+					fmt.Printf("%-24s %-36s %s\n", r.Name, r.String(), state)
+				}
+			}
+
+			fmt.Println()
+			fmt.Println(i18n.T("alerts.desktop", cfg.Channels.Desktop))
+			if e := cfg.Channels.Email; e != nil {
+				fmt.Println(i18n.T("alerts.email", strings.Join(e.To, ", "), e.Host))
+			}
+			for _, w := range cfg.Channels.Webhooks {
+				fmt.Println(i18n.T("alerts.webhook", w.URL))
+			}
+			return nil
+		},
+	}
+}
+
+func alertsSetCmd() *cobra.Command {
+	var (
+		metric     string
+		comparator string
+		threshold  float64
+		holdFor    time.Duration
+		disabled   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "set <name>",
+		Short: "Add or replace an alert rule",
+		Long: `Add an alert rule, or replace the rule with the same name.
+
+Examples:
+  # CPU above 95 °C for 30 seconds
+  bench alerts set "CPU hot" --metric cpu_temp --above 95 --for 30s
+
+  # Any GPU hot spot at 105 °C or more
+  bench alerts set "GPU hot spot" --metric 'gpu*_hotspot' --comparator '>=' --threshold 105
+
+  # A CPU fan slowing below 300 RPM
+  bench alerts set "CPU fan" --metric fan_cpu_fan --below 300 --for 10s`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch {
+			case cmd.Flags().Changed("above"):
+				comparator = string(alerts.Above)
+				threshold, _ = cmd.Flags().GetFloat64("above")
+			case cmd.Flags().Changed("below"):
+				comparator = string(alerts.Below)
+				threshold, _ = cmd.Flags().GetFloat64("below")
+			case !cmd.Flags().Changed("threshold"):
+				return i18n.Errorf("alerts.threshold_required")
+			}
+			parsed, err := alerts.ParseComparator(comparator)
+			if err != nil {
+				return err
+			}
+			rule := alerts.Rule{
+				Name:       args[0],
+				Metric:     metric,
+				Comparator: parsed,
+				Threshold:  threshold,
+				Disabled:   disabled,
+			}
+			if holdFor > 0 {
+				rule.For = holdFor.String()
+			}
+
+			path := alerts.DefaultPath()
+			cfg, err := alerts.Load(path)
+			if err != nil {
+				return err
+			}
+			verb := "alerts.added"
+			if i := cfg.Rule(rule.Name); i >= 0 {
+				cfg.Rules[i] = rule
+				verb = "alerts.replaced"
+			} else {
+				cfg.Rules = append(cfg.Rules, rule)
+			}
+			if err := alerts.Save(path, cfg); err != nil {
+				return i18n.Errorf("alerts.save_failed", err)
+			}
+			fmt.Println(i18n.T(verb, rule.Name, rule))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&metric, "metric", "", "Metric name or pattern, e.g. cpu_temp or gpu*_temp")
+	cmd.Flags().StringVar(&comparator, "comparator", string(alerts.Above), "Comparison with the threshold: >, >=, < or <=")
+	cmd.Flags().Float64Var(&threshold, "threshold", 0, "Threshold the reading is compared with")
+	cmd.Flags().Float64("above", 0, "Alert when the reading is above this value")
+	cmd.Flags().Float64("below", 0, "Alert when the reading is below this value")
+	cmd.Flags().DurationVar(&holdFor, "for", 0, "How long the condition must hold before alerting (0 = straight away)")
+	cmd.Flags().BoolVar(&disabled, "disabled", false, "Save the rule without checking it")
+	cmd.MarkFlagsMutuallyExclusive("above", "below", "threshold")
+	_ = cmd.MarkFlagRequired("metric")
+
+	return cmd
+}
+
+func alertsRemoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <name>",
+		Short: "Remove an alert rule",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			path := alerts.DefaultPath()
+			cfg, err := alerts.Load(path)
+			if err != nil {
+				return err
+			}
+			i := cfg.Rule(args[0])
+			if i < 0 {
+				return i18n.Errorf("alerts.no_rule", args[0])
+			}
+			cfg.Rules = append(cfg.Rules[:i], cfg.Rules[i+1:]...)
+			if err := alerts.Save(path, cfg); err != nil {
+				return i18n.Errorf("alerts.save_failed", err)
+			}
+			fmt.Println(i18n.T("alerts.removed", args[0]))
+			return nil
+		},
+	}
+}
+
+func alertsChannelsCmd() *cobra.Command {
+	var (
+		desktop  bool
+		email    alerts.EmailConfig
+		noEmail  bool
+		webhooks []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "channels",
+		Short: "Set where alerts are sent",
+		Long: `Set where alerts are sent. Only the flags given are changed.
+
+The SMTP password is read from FIRE_SMTP_PASSWORD so it stays out of the
+shell history; the alerts file is only readable by its owner.
+
+Examples:
+  # Mail alerts through a relay that needs no login
+  bench alerts channels --email-host smtp.lab.local --email-port 25 \
+    --email-from fire@lab.local --email-to ops@lab.local
+
+  # Post alerts to a chat webhook and stop desktop notifications
+  bench alerts channels --webhook https://hooks.slack.com/services/T000/B000/XXXX --desktop=false
+
+  # Stop mailing alerts
+  bench alerts channels --no-email`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			path := alerts.DefaultPath()
+			cfg, err := alerts.Load(path)
+			if err != nil {
+				return err
+			}
+			flags := cmd.Flags()
+			if flags.Changed("desktop") {
+				cfg.Channels.Desktop = desktop
+			}
+			if flags.Changed("webhook") {
+				cfg.Channels.Webhooks = nil
+				for _, u := range webhooks {
+					if u != "" {
+						cfg.Channels.Webhooks = append(cfg.Channels.Webhooks, alerts.Webhook{URL: u})
+					}
+				}
+			}
+			if noEmail {
+				cfg.Channels.Email = nil
+			} else if flags.Changed("email-host") || flags.Changed("email-port") || flags.Changed("email-user") ||
+				flags.Changed("email-from") || flags.Changed("email-to") || flags.Changed("email-tls") {
+				current := alerts.EmailConfig{}
+				if cfg.Channels.Email != nil {
+					current = *cfg.Channels.Email
+				}
+				if flags.Changed("email-host") {
+					current.Host = email.Host
+				}
+				if flags.Changed("email-port") {
+					current.Port = email.Port
+				}
+				if flags.Changed("email-user") {
+					current.Username = email.Username
+				}
+				if flags.Changed("email-from") {
+					current.From = email.From
+				}
+				if flags.Changed("email-to") {
+					current.To = email.To
+				}
+				if flags.Changed("email-tls") {
+					current.TLS = email.TLS
+				}
+				if password := os.Getenv("FIRE_SMTP_PASSWORD"); password != "" {
+					current.Password = password
+				}
+				cfg.Channels.Email = &current
+			}
+			if err := alerts.Save(path, cfg); err != nil {
+				return i18n.Errorf("alerts.save_failed", err)
+			}
+			fmt.Println(i18n.T("alerts.channels_saved", path))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&desktop, "desktop", true, "Show desktop notifications while the GUI is open")
+	cmd.Flags().StringVar(&email.Host, "email-host", "", "SMTP server")
+	cmd.Flags().IntVar(&email.Port, "email-port", 0, "SMTP port (default 465 with TLS, otherwise 587)")
+	cmd.Flags().StringVar((*string)(&email.TLS), "email-tls", "", "Connection security: tls, starttls or none (default TLS on port 465, otherwise STARTTLS when offered)")
+	cmd.Flags().StringVar(&email.Username, "email-user", "", "SMTP login, with the password in FIRE_SMTP_PASSWORD")
+	cmd.Flags().StringVar(&email.From, "email-from", "", "Sender address")
+	cmd.Flags().StringSliceVar(&email.To, "email-to", nil, "Recipient addresses")
+	cmd.Flags().BoolVar(&noEmail, "no-email", false, "Stop mailing alerts")
+	cmd.Flags().StringSliceVar(&webhooks, "webhook", nil, "URLs alerts are posted to as JSON (replaces the list; empty clears it)")
+	cmd.MarkFlagsMutuallyExclusive("no-email", "email-host")
+
+	return cmd
+}
+
+func alertsTestCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "test",
+		Short: "Send a test alert to the email and webhook channels",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			cfg, err := alerts.Load(alerts.DefaultPath())
+			if err != nil {
+				return err
+			}
+			notifiers := cfg.Channels.Notifiers()
+			if len(notifiers) == 0 {
+				fmt.Println(i18n.T("alerts.no_channels"))
+				return nil
+			}
+			event := alerts.TestEvent(time.Now())
+			failed := 0
+			for _, n := range notifiers {
+				ctx, cancel := context.WithTimeout(context.Background(), alerts.DefaultTimeout)
+				err := n.Notify(ctx, event)
+				cancel()
+				if err != nil {
+					failed++
+					fmt.Println(i18n.T("alerts.test_failed", err))
+					continue
+				}
+				fmt.Println(i18n.T("alerts.test_ok", describeNotifier(n)))
+			}
+			if failed > 0 {
+				return i18n.Errorf("alerts.channels_failed", failed, len(notifiers))
+			}
+			return nil
+		},
+	}
+}
+
+// describeNotifier names a channel in 'bench alerts test' output
+func describeNotifier(n alerts.Notifier) string {
+	switch n := n.(type) {
+	case *alerts.EmailNotifier:
+		return "email to " + strings.Join(n.Config.To, ", ")
+	case *alerts.WebhookNotifier:
+		return "webhook " + n.Webhook.URL
+	}
+	return "channel"
+}
+
+func alertsHistoryCmd() *cobra.Command {
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show alerts that fired and resolved",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			database, err := openDatabase()
+			if err != nil {
+				return i18n.Errorf("error.open_database", err)
+			}
+			defer func() { _ = database.Close() }()
+
+			events, err := database.ListAlertEvents(limit)
+			if err != nil {
+				return err
+			}
+			if len(events) == 0 {
+				fmt.Println(i18n.T("alerts.none"))
+				return nil
+			}
+			fmt.Printf("%-20s %-9s %-8s %-20s %-16s %s\n", i18n.T("table.time"), i18n.T("table.state"), i18n.T("table.source"), i18n.T("table.rule"), i18n.T("table.metric"), i18n.T("table.value"))
+			fmt.Println(strings.Repeat("-", 86))
+			for _, e := range events {
+				fmt.Printf("%-20s %-9s %-8s %-20s %-16s %s\n",
+					e.Time.Local().Format("2006-01-02 15:04:05"), e.State, e.Source, e.Rule, e.Metric,
+					strconv.FormatFloat(e.Value, 'f', 1, 64))
+				if e.Error != "" {
+					fmt.Println(i18n.T("alerts.not_delivered", "", e.Error))
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVarP(&limit, "limit", "n", 50, "Number of alerts to show (0 = all)")
+
+	return cmd
+}
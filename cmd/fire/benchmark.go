@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/mscrnt/project_fire/pkg/benchmark"
+	"github.com/mscrnt/project_fire/pkg/changelog"
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/spf13/cobra"
+)
+
+func benchmarkCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "benchmark",
+		Short: i18n.T("cmd.benchmark"),
+		Long: `Run short, standardized workloads and score them: CPU single- and
+multi-core, memory bandwidth, sequential and 4K random storage reads, and the
+GPU where a GPU test is available. Each workload runs with fixed parameters,
+so scores compare across runs and machines. A reference desktop (8-core CPU,
+dual-channel DDR4, SATA SSD) scores 1000 points on each; the overall score
+is the geometric mean of the others.
+
+Suites are stored as "benchmark" runs next to the runs of their workloads.
+Scores are compared with the other suites recorded on the same hardware
+model in the database, which holds every machine's runs once they are
+synced to a central server (see bench sync).
+
+Examples:
+  # Run the suite (about a minute and a half)
+  bench benchmark run
+
+  # Only the CPU workloads, sharing the scores through telemetry
+  bench benchmark run --only cpu_single,cpu_multi --share
+
+  # Show the scores of this machine over time
+  bench benchmark history`,
+	}
+
+	cmd.AddCommand(benchmarkRunCmd())
+	cmd.AddCommand(benchmarkHistoryCmd())
+
+	return cmd
+}
+
+func benchmarkRunCmd() *cobra.Command {
+	var (
+		only  []string
+		share bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "Run the benchmark suite and show how it ranks",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			workloads, err := selectWorkloads(only)
+			if err != nil {
+				return err
+			}
+
+			database, err := openDatabase()
+			if err != nil {
+				return i18n.Errorf("error.open_database", err)
+			}
+			defer func() { _ = database.Close() }()
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			runner := benchmark.NewRunner(database, log.New(os.Stderr, "[benchmark] ", log.LstdFlags))
+			runner.SetShare(share)
+			runner.SetProgress(func(w benchmark.Workload, done bool, scores map[string]float64, err error) {
+				switch {
+				case !done:
+					fmt.Println(i18n.T("benchmark.running", w.Title, w.Duration))
+				case err != nil:
+					fmt.Printf("  %s: %v\n", w.Title, err)
+				}
+			})
+			suite, err := runner.Run(ctx, workloads)
+			if suite == nil {
+				return err
+			}
+			for _, w := range workloads {
+				if reason, ok := suite.Skipped[w.Name]; ok && suite.Runs[w.Name] == 0 {
+					fmt.Println(i18n.T("benchmark.skipped", w.Title, reason))
+				}
+			}
+			if err != nil {
+				return err
+			}
+
+			ranks, err := benchmark.Compare(database, suite)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("\n%s\n\n", i18n.T("benchmark.recorded", suite.Run.ID))
+			printBenchmarkScores(suite, ranks)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&only, "only", nil, "Workloads to run (default all): "+strings.Join(workloadNames(), ", "))
+	cmd.Flags().BoolVar(&share, "share", false, "Send the scores and hardware model through telemetry for comparison with other machines")
+
+	return cmd
+}
+
+func benchmarkHistoryCmd() *cobra.Command {
+	var (
+		machine string
+		all     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show the benchmark scores of this machine over time",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			database, err := openDatabase()
+			if err != nil {
+				return i18n.Errorf("error.open_database", err)
+			}
+			defer func() { _ = database.Close() }()
+
+			if machine == "" && !all {
+				machine = changelog.Machine()
+			}
+			suites, err := benchmark.History(database, machine)
+			if err != nil {
+				return err
+			}
+			if len(suites) == 0 {
+				fmt.Println(i18n.T("benchmark.none"))
+				return nil
+			}
+
+			names := benchmark.ScoreNames()
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprint(w, strings.Join([]string{i18n.T("table.run"), i18n.T("table.date"), i18n.T("table.machine")}, "\t"))
+			for _, name := range names {
+				fmt.Fprintf(w, "\t%s", strings.ToUpper(name))
+			}
+			fmt.Fprintln(w)
+			for _, s := range suites {
+				fmt.Fprintf(w, "%d\t%s\t%s", s.Run.ID, s.Run.StartTime.Format("2006-01-02 15:04"), s.Run.Machine)
+				for _, name := range names {
+					if v, ok := s.Scores[name]; ok {
+						fmt.Fprintf(w, "\t%.0f", v)
+					} else {
+						fmt.Fprint(w, "\t-")
+					}
+				}
+				fmt.Fprintln(w)
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&machine, "machine", "", "Machine to show (default this one)")
+	cmd.Flags().BoolVar(&all, "all", false, "Show the runs of every machine")
+
+	return cmd
+}
+
+// selectWorkloads returns the workloads named, or all of them
+func selectWorkloads(names []string) ([]benchmark.Workload, error) {
+	if len(names) == 0 {
+		return benchmark.Workloads, nil
+	}
+	var workloads []benchmark.Workload
+	for _, name := range names {
+		found := false
+		for _, w := range benchmark.Workloads {
+			if w.Name == strings.TrimSpace(name) {
+				workloads = append(workloads, w)
+				found = true
+			}
+		}
+		if !found {
+			return nil, i18n.Errorf("benchmark.unknown_workload", name, strings.Join(workloadNames(), ", "))
+		}
+	}
+	return workloads, nil
+}
+
+// workloadNames returns the names of the suite's workloads
+func workloadNames() []string {
+	names := make([]string, len(benchmark.Workloads))
+	for i, w := range benchmark.Workloads {
+		names[i] = w.Name
+	}
+	return names
+}
+
+// printBenchmarkScores prints a suite's scores with their rank among the
+// runs on the same hardware model
+func printBenchmarkScores(s *benchmark.Suite, ranks map[string]benchmark.Rank) {
+	titles := benchmark.Titles()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join([]string{i18n.T("table.score"), i18n.T("table.points"), i18n.T("table.same_model")}, "\t"))
+	for _, name := range benchmark.ScoreNames() {
+		v, ok := s.Scores[name]
+		if !ok {
+			continue
+		}
+		compared := "-"
+		if r, ok := ranks[name]; ok {
+			compared = i18n.T("benchmark.rank", r.Percentile, r.Count, r.Median)
+		}
+		fmt.Fprintf(w, "%s\t%.0f\t%s\n", titles[name], v, compared)
+	}
+	_ = w.Flush()
+	if s.Run.Model == "" {
+		fmt.Printf("\n%s\n", i18n.T("benchmark.model_unknown"))
+	} else if len(ranks) == 0 {
+		fmt.Printf("\n%s\n", i18n.T("benchmark.no_peers", s.Run.Model))
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mscrnt/project_fire/pkg/benchmode"
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/spf13/cobra"
+)
+
+func benchmodeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "benchmode",
+		Short: i18n.T("cmd.benchmode"),
+		Long: `Benchmark mode ("bench test --benchmark-mode") locks GPU clocks and selects
+the performance power plan for the duration of a run, then restores the
+previous settings.`,
+	}
+
+	cmd.AddCommand(benchmodeRestoreCmd())
+
+	return cmd
+}
+
+func benchmodeRestoreCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "restore",
+		Short: "Restore settings left by an interrupted run",
+		Long: `Restore the GPU clocks and power plan changed by a benchmark mode run that
+was interrupted before it could restore them itself. The next benchmark mode
+run also does this automatically.`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			changes, err := benchmode.RestorePending(getBenchmodeStatePath())
+			if err != nil {
+				return fmt.Errorf("failed to restore settings: %w", err)
+			}
+			if len(changes) == 0 {
+				fmt.Println("Nothing to restore")
+				return nil
+			}
+			for _, c := range changes {
+				fmt.Printf("Restored: %s\n", c)
+			}
+			return nil
+		},
+	}
+}
@@ -1,26 +1,38 @@
 package main
 
 import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/mscrnt/project_fire/pkg/cert"
+	"github.com/mscrnt/project_fire/pkg/changelog"
 	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/mscrnt/project_fire/pkg/inventory"
+	"github.com/mscrnt/project_fire/pkg/report"
+	"github.com/mscrnt/project_fire/pkg/schema"
+	"github.com/mscrnt/project_fire/pkg/session"
+	"github.com/mscrnt/project_fire/pkg/testplan"
 	"github.com/spf13/cobra"
 )
 
 func certCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "cert",
-		Short: "Certificate management",
+		Short: i18n.T("cmd.cert"),
 		Long:  "Issue and verify certificates for test results",
 	}
 
 	cmd.AddCommand(certInitCmd())
 	cmd.AddCommand(certIssueCmd())
 	cmd.AddCommand(certVerifyCmd())
+	cmd.AddCommand(certBurnInCmd())
 
 	return cmd
 }
@@ -37,7 +49,9 @@ func certInitCmd() *cobra.Command {
 		Long: `Initialize a certificate authority (CA) for signing test certificates.
 
 This command creates a self-signed CA certificate and private key that will be
-used to sign individual test result certificates.
+used to sign individual test result certificates, and the Ed25519 key burn-in
+certificates are signed with. Running it again on a CA that has no signing key
+yet adds the key and keeps the CA.
 
 Examples:
   # Initialize CA in default location
@@ -53,41 +67,65 @@ Examples:
 			if caPath == "" {
 				homeDir, err := os.UserHomeDir()
 				if err != nil {
-					return fmt.Errorf("failed to get home directory: %w", err)
+					return i18n.Errorf("error.home_dir", err)
 				}
 				caPath = filepath.Join(homeDir, ".fire", "ca")
 			}
 
 			// Create directory
 			if err := os.MkdirAll(caPath, 0o700); err != nil {
-				return fmt.Errorf("failed to create CA directory: %w", err)
+				return i18n.Errorf("cert.ca_dir_failed", err)
 			}
 
 			certPath := filepath.Join(caPath, "ca.crt")
 			keyPath := filepath.Join(caPath, "ca.key")
+			signingKeyPath := filepath.Join(caPath, cert.SigningKeyFile)
+			signingPubPath := filepath.Join(caPath, cert.SigningPubFile)
+
+			// Check if CA already exists. A CA created before burn-in
+			// certificates only gets the signing key added.
+			_, err := os.Stat(certPath)
+			caExists := err == nil
+			_, err = os.Stat(signingKeyPath)
+			signingExists := err == nil
+			if !force && caExists && signingExists {
+				return i18n.Errorf("cert.ca_exists", certPath)
+			}
 
-			// Check if CA already exists
-			if !force {
-				if _, err := os.Stat(certPath); err == nil {
-					return fmt.Errorf("CA certificate already exists at %s (use --force to overwrite)", certPath)
+			if force || !caExists {
+				// Create new CA
+				issuer, err := cert.NewCertificateIssuer()
+				if err != nil {
+					return i18n.Errorf("cert.ca_create_failed", err)
 				}
-			}
 
-			// Create new CA
-			issuer, err := cert.NewCertificateIssuer()
-			if err != nil {
-				return fmt.Errorf("failed to create CA: %w", err)
+				// Save CA files
+				if err := issuer.SaveCA(certPath, keyPath); err != nil {
+					return i18n.Errorf("cert.ca_save_failed", err)
+				}
+
+				fmt.Println(i18n.T("cert.ca_initialized"))
+				fmt.Println(i18n.T("cert.ca_certificate", certPath))
+				fmt.Println(i18n.T("cert.ca_key", keyPath))
 			}
 
-			// Save CA files
-			if err := issuer.SaveCA(certPath, keyPath); err != nil {
-				return fmt.Errorf("failed to save CA: %w", err)
+			if force || !signingExists {
+				// Create the key burn-in certificates are signed with
+				key, err := cert.GenerateSigningKey()
+				if err != nil {
+					return err
+				}
+				if err := cert.SaveSigningKey(key, signingKeyPath, signingPubPath); err != nil {
+					return err
+				}
+
+				fmt.Println(i18n.T("cert.signing_key", signingKeyPath))
+				fmt.Println(i18n.T("cert.public_key", signingPubPath))
+				fmt.Println(i18n.T("cert.fingerprint", cert.Fingerprint(key.Public().(ed25519.PublicKey))))
+				fmt.Printf("\n%s\n", i18n.T("cert.publish_fingerprint"))
 			}
 
-			fmt.Println("Certificate Authority initialized successfully")
-			fmt.Printf("CA Certificate: %s\n", certPath)
-			fmt.Printf("CA Private Key: %s\n", keyPath)
-			fmt.Println("\nIMPORTANT: Keep the private key secure and backed up!")
+			fmt.Printf("\n%s\n", i18n.T("cert.keep_keys_secure"))
 
 			return nil
 		},
@@ -132,14 +170,14 @@ Examples:
 		RunE: func(_ *cobra.Command, _ []string) error {
 			// Validate inputs
 			if !latest && runID == 0 {
-				return fmt.Errorf("either --latest or --run must be specified")
+				return i18n.Errorf("cert.run_required")
 			}
 
 			// Default CA path
 			if caPath == "" {
 				homeDir, err := os.UserHomeDir()
 				if err != nil {
-					return fmt.Errorf("failed to get home directory: %w", err)
+					return i18n.Errorf("error.home_dir", err)
 				}
 				caPath = filepath.Join(homeDir, ".fire", "ca")
 			}
@@ -150,14 +188,13 @@ Examples:
 
 			issuer, err := cert.LoadCA(certPath, keyPath)
 			if err != nil {
-				return fmt.Errorf("failed to load CA (run 'bench cert init' first): %w", err)
+				return i18n.Errorf("cert.load_ca_failed", err)
 			}
 
 			// Open database
-			dbPath := getDBPath()
-			database, err := db.Open(dbPath)
+			database, err := openDatabase()
 			if err != nil {
-				return fmt.Errorf("failed to open database: %w", err)
+				return i18n.Errorf("error.open_database", err)
 			}
 			defer func() { _ = database.Close() }()
 
@@ -168,10 +205,10 @@ Examples:
 					Limit:  1,
 				})
 				if err != nil {
-					return fmt.Errorf("failed to list runs: %w", err)
+					return i18n.Errorf("error.list_runs", err)
 				}
 				if len(runs) == 0 {
-					return fmt.Errorf("no runs found")
+					return i18n.Errorf("cert.no_runs")
 				}
 				runID = runs[0].ID
 			}
@@ -179,18 +216,18 @@ Examples:
 			// Get run and results
 			run, err := database.GetRun(runID)
 			if err != nil {
-				return fmt.Errorf("run %d not found", runID)
+				return i18n.Errorf("error.run_id_not_found", runID)
 			}
 
 			results, err := database.GetResults(runID)
 			if err != nil {
-				return fmt.Errorf("failed to get results: %w", err)
+				return i18n.Errorf("error.get_results", err)
 			}
 
 			// Issue certificate
 			certificate, err := issuer.IssueCertificate(run, results)
 			if err != nil {
-				return fmt.Errorf("failed to issue certificate: %w", err)
+				return i18n.Errorf("cert.issue_failed", err)
 			}
 
 			// Generate output filenames if not specified
@@ -201,24 +238,24 @@ Examples:
 
 			// Save certificate
 			if err := certificate.Save(output, keyOutput); err != nil {
-				return fmt.Errorf("failed to save certificate: %w", err)
+				return i18n.Errorf("cert.save_failed", err)
 			}
 
 			// Display information
-			fmt.Printf("Certificate issued for run #%d\n", runID)
-			fmt.Printf("Plugin: %s\n", run.Plugin)
-			fmt.Printf("Status: %s\n", formatStatus(run.Success))
-			fmt.Printf("Certificate: %s\n", output)
+			fmt.Println(i18n.T("cert.issued", runID))
+			fmt.Println(i18n.T("show.plugin", run.Plugin))
+			fmt.Println(i18n.T("cert.status", formatStatus(run.Success)))
+			fmt.Println(i18n.T("cert.certificate", output))
 			if keyOutput != "" {
-				fmt.Printf("Private Key: %s\n", keyOutput)
+				fmt.Println(i18n.T("cert.private_key", keyOutput))
 			}
 
 			// Show certificate details
-			fmt.Printf("\nCertificate Details:\n")
-			fmt.Printf("  Subject: %s\n", certificate.Subject)
-			fmt.Printf("  Serial: %s\n", certificate.SerialNumber)
-			fmt.Printf("  Valid From: %s\n", certificate.NotBefore.Format("2006-01-02 15:04:05"))
-			fmt.Printf("  Valid Until: %s\n", certificate.NotAfter.Format("2006-01-02 15:04:05"))
+			fmt.Printf("\n%s\n", i18n.T("cert.details"))
+			fmt.Println(i18n.T("cert.subject", certificate.Subject))
+			fmt.Println(i18n.T("cert.serial", certificate.SerialNumber))
+			fmt.Println(i18n.T("cert.valid_from", certificate.NotBefore.Format("2006-01-02 15:04:05")))
+			fmt.Println(i18n.T("cert.valid_until", certificate.NotAfter.Format("2006-01-02 15:04:05")))
 
 			return nil
 		},
@@ -236,7 +273,9 @@ Examples:
 
 func certVerifyCmd() *cobra.Command {
 	var (
-		caPath string
+		caPath  string
+		pubKey  string
+		jsonOut bool
 	)
 
 	cmd := &cobra.Command{
@@ -247,21 +286,45 @@ func certVerifyCmd() *cobra.Command {
 This command verifies the certificate signature against the CA and extracts
 the embedded test information.
 
+Burn-in certificates (the .json file written by bench cert burnin) are
+checked against the Ed25519 key they were signed with: the check fails if
+anything in the file was changed after signing. Compare the fingerprint shown
+with the one the issuer published, or pass the issuer's public key with
+--pubkey to require it. No CA or database is needed, so customers can run
+this on their own machines.
+
 Examples:
   # Verify a certificate
   bench cert verify test-cert.pem
 
   # Verify with custom CA path
-  bench cert verify test-cert.pem --ca-path /path/to/ca`,
+  bench cert verify test-cert.pem --ca-path /path/to/ca
+
+  # Print the result as JSON following the published certificate schema
+  bench cert verify test-cert.pem --json
+
+  # Verify a burn-in certificate signed by a known shop
+  bench cert verify burnin.json --pubkey shop-signing.pub`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(_ *cobra.Command, args []string) error {
 			certFile := args[0]
 
+			data, err := os.ReadFile(certFile) // #nosec G304 -- certFile is the certificate the user asked to verify
+			if err != nil {
+				return i18n.Errorf("cert.read_failed", err)
+			}
+			if name, err := schema.Detect(data); err == nil && name == schema.BurnIn {
+				if jsonOut {
+					return i18n.Errorf("cert.json_x509_only")
+				}
+				return verifyBurnIn(data, pubKey)
+			}
+
 			// Default CA path
 			if caPath == "" {
 				homeDir, err := os.UserHomeDir()
 				if err != nil {
-					return fmt.Errorf("failed to get home directory: %w", err)
+					return i18n.Errorf("error.home_dir", err)
 				}
 				caPath = filepath.Join(homeDir, ".fire", "ca")
 			}
@@ -270,11 +333,19 @@ Examples:
 			caCertPath := filepath.Join(caPath, "ca.crt")
 			result, err := cert.VerifyCertificateFile(certFile, caCertPath)
 			if err != nil {
-				return fmt.Errorf("failed to verify certificate: %w", err)
+				return i18n.Errorf("cert.verify_failed", err)
 			}
 
 			// Display result
-			fmt.Println(cert.FormatVerifyResult(result))
+			if jsonOut {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(result.Summary()); err != nil {
+					return i18n.Errorf("cert.encode_failed", err)
+				}
+			} else {
+				fmt.Println(cert.FormatVerifyResult(result))
+			}
 
 			// Exit with error code if invalid
 			if !result.Valid {
@@ -286,6 +357,275 @@ Examples:
 	}
 
 	cmd.Flags().StringVar(&caPath, "ca-path", "", "Path to CA directory")
+	cmd.Flags().StringVar(&pubKey, "pubkey", "", "Public key burn-in certificates must be signed with")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Print the result as JSON")
 
 	return cmd
 }
+
+// verifyBurnIn checks a burn-in certificate file and prints what it
+// attests, exiting with status 1 when it does not hold
+func verifyBurnIn(data []byte, pubKeyPath string) error {
+	var trusted ed25519.PublicKey
+	if pubKeyPath != "" {
+		key, err := cert.LoadPublicKey(pubKeyPath)
+		if err != nil {
+			return err
+		}
+		trusted = key
+	}
+
+	result, err := cert.VerifyBurnIn(data, trusted)
+	if err != nil {
+		return i18n.Errorf("cert.verify_failed", err)
+	}
+	fmt.Println(cert.FormatBurnInResult(result))
+
+	if !result.Valid {
+		os.Exit(1)
+	}
+	return nil
+}
+
+func certBurnInCmd() *cobra.Command {
+	var (
+		runIDs    []int64
+		latest    bool
+		plugin    string
+		planRunID int64
+		issuer    string
+		customer  string
+		reference string
+		output    string
+		noPDF     bool
+		caPath    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "burnin",
+		Short: "Issue a signed burn-in certificate for a customer",
+		Long: `Issue a burn-in certificate attesting to the tests a machine went through.
+
+The certificate lists the hardware tested, the test plan followed with the
+outcome of each stage, and every run with its duration and results. It is
+written twice: as a JSON file signed with the bench's Ed25519 key (created by
+bench cert init), which anyone can check with bench cert verify, and as a
+printable PDF for the customer showing the same details with the serial and
+signature. When the PDF cannot be printed an HTML page is written instead.
+
+The hardware is read when the certificate is issued, so issue it on the
+machine that was tested; certificates for runs recorded on another machine
+leave the hardware out.
+
+Examples:
+  # Certify every run of a test plan
+  bench cert burnin --plan-run 7 --customer "Acme Corp" --reference "Order 1042"
+
+  # Certify specific runs
+  bench cert burnin --run 41 --run 42 --issuer "Example Systems"
+
+  # Certify the latest memory run, JSON only
+  bench cert burnin --latest -p memory --no-pdf -o burnin`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if len(runIDs) == 0 && !latest && planRunID == 0 {
+				return i18n.Errorf("cert.burnin_run_required")
+			}
+
+			// Default CA path
+			if caPath == "" {
+				homeDir, err := os.UserHomeDir()
+				if err != nil {
+					return i18n.Errorf("error.home_dir", err)
+				}
+				caPath = filepath.Join(homeDir, ".fire", "ca")
+			}
+			key, err := cert.LoadSigningKey(filepath.Join(caPath, cert.SigningKeyFile))
+			if err != nil {
+				return i18n.Errorf("cert.load_signing_key_failed", err)
+			}
+
+			database, err := openDatabase()
+			if err != nil {
+				return i18n.Errorf("error.open_database", err)
+			}
+			defer func() { _ = database.Close() }()
+
+			// The plan's runs come first, in stage order
+			var plan *cert.Plan
+			if planRunID != 0 {
+				var planRuns []int64
+				plan, planRuns, err = loadBurnInPlan(database, planRunID)
+				if err != nil {
+					return err
+				}
+				runIDs = append(planRuns, runIDs...)
+			}
+			if latest {
+				runs, err := database.ListRuns(db.RunFilter{Plugin: plugin, Limit: 1})
+				if err != nil {
+					return i18n.Errorf("error.list_runs", err)
+				}
+				if len(runs) == 0 {
+					return i18n.Errorf("cert.no_runs")
+				}
+				runIDs = append(runIDs, runs[0].ID)
+			}
+
+			var (
+				records  []session.RunRecord
+				seen     = make(map[int64]bool)
+				lastEnd  time.Time
+				hardware *inventory.Snapshot
+			)
+			for _, id := range runIDs {
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+				run, err := database.GetRun(id)
+				if err != nil {
+					return i18n.Errorf("error.run_id_not_found", id)
+				}
+				results, err := database.GetResults(id)
+				if err != nil {
+					return i18n.Errorf("error.get_results", err)
+				}
+				if run.Machine == "" {
+					// Runs recorded before machines were stored ran here
+					run.Machine = changelog.Machine()
+				}
+				if run.EndTime != nil && run.EndTime.After(lastEnd) {
+					lastEnd = *run.EndTime
+				}
+				// The hardware recorded with the runs, the last run's if it changed
+				snapshot, err := inventory.ForRun(database, id)
+				if err != nil {
+					return err
+				}
+				if snapshot != nil {
+					hardware = snapshot
+				}
+				records = append(records, session.RunRecord{Run: run, Results: results})
+			}
+
+			burnIn, err := cert.NewBurnIn(records, plan)
+			if err != nil {
+				return err
+			}
+			if issuer != "" {
+				burnIn.Issuer = issuer
+			}
+			burnIn.Customer = customer
+			burnIn.Reference = reference
+			switch {
+			case hardware != nil:
+				burnIn.System, burnIn.Components = hardware.System, hardware.Components
+			case burnIn.Machine == changelog.Machine():
+				// Runs recorded before inventories were stored
+				if lastEnd.IsZero() {
+					lastEnd = time.Now()
+				}
+				burnIn.System, burnIn.Components = cert.Hardware(context.Background(), database, lastEnd)
+			default:
+				fmt.Fprintln(os.Stderr, i18n.T("cert.other_machine", burnIn.Machine))
+			}
+
+			data, sig, err := burnIn.Sign(key)
+			if err != nil {
+				return err
+			}
+
+			// Generate output filenames if not specified
+			if output == "" {
+				output = fmt.Sprintf("fire_burnin_%s_%s", burnIn.Machine, time.Now().Format("20060102_150405"))
+			}
+			output = strings.TrimSuffix(output, filepath.Ext(output))
+			jsonPath := output + ".json"
+			if err := os.WriteFile(jsonPath, data, 0o644); err != nil { // #nosec G306 -- the certificate is meant to be handed out
+				return i18n.Errorf("cert.write_failed", err)
+			}
+
+			fmt.Println(i18n.T("cert.burnin_issued", burnIn.Serial, burnIn.Machine))
+			fmt.Println(i18n.T("cert.runs", len(burnIn.Runs)))
+			fmt.Println(i18n.T("cert.status", formatStatus(burnIn.Passed)))
+			fmt.Println(i18n.T("cert.certificate", jsonPath))
+			if !noPDF {
+				printed, err := writeBurnInPage(burnIn, sig, output, filepath.Base(jsonPath))
+				if err != nil {
+					return err
+				}
+				fmt.Println(i18n.T("cert.printable", printed))
+			}
+			fmt.Println(i18n.T("cert.signed_by", sig.Fingerprint))
+
+			return nil
+		},
+	}
+
+	cmd.Flags().Int64SliceVar(&runIDs, "run", nil, "Run ID to certify (repeatable)")
+	cmd.Flags().BoolVar(&latest, "latest", false, "Certify the latest run")
+	cmd.Flags().StringVarP(&plugin, "plugin", "p", "", "Filter by plugin when using --latest")
+	cmd.Flags().Int64Var(&planRunID, "plan-run", 0, "Certify a test plan run and all its runs")
+	cmd.Flags().StringVar(&issuer, "issuer", "", "Name of the shop issuing the certificate (default \""+cert.DefaultIssuer+"\")")
+	cmd.Flags().StringVar(&customer, "customer", "", "Customer the machine was tested for")
+	cmd.Flags().StringVar(&reference, "reference", "", "Order or ticket number")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Output file name, without extension")
+	cmd.Flags().BoolVar(&noPDF, "no-pdf", false, "Write only the signed JSON certificate")
+	cmd.Flags().StringVar(&caPath, "ca-path", "", "Path to CA directory")
+
+	return cmd
+}
+
+// loadBurnInPlan returns a plan run as certified, with the runs its stages
+// started
+func loadBurnInPlan(database *db.DB, id int64) (*cert.Plan, []int64, error) {
+	store := testplan.NewStore(database)
+	planRun, err := store.GetRun(id)
+	if err != nil {
+		return nil, nil, i18n.Errorf("cert.plan_run_not_found", id)
+	}
+	stages, err := store.Stages(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plan := &cert.Plan{Name: planRun.Name, RunID: planRun.ID, Passed: planRun.Success, Error: planRun.Error}
+	var runIDs []int64
+	for _, stage := range stages {
+		plan.Stages = append(plan.Stages, cert.Stage{
+			Name:            stage.Name,
+			Kind:            stage.Kind,
+			Status:          stage.Status,
+			Message:         stage.Message,
+			DurationSeconds: stage.Duration().Seconds(),
+		})
+		runIDs = append(runIDs, stage.RunIDs...)
+	}
+	if len(runIDs) == 0 {
+		return nil, nil, i18n.Errorf("cert.plan_run_empty", id)
+	}
+	return plan, runIDs, nil
+}
+
+// writeBurnInPage writes the printable copy of a certificate as a PDF, or
+// as HTML when the PDF cannot be printed, and returns its path
+func writeBurnInPage(burnIn *cert.BurnIn, sig cert.Signature, output, jsonFile string) (string, error) {
+	var page strings.Builder
+	if err := burnIn.WriteHTML(&page, sig, jsonFile); err != nil {
+		return "", i18n.Errorf("cert.render_failed", err)
+	}
+
+	options := report.DefaultPDFOptions()
+	pdfPath := output + ".pdf"
+	err := report.HTMLToPDF(page.String(), pdfPath, &options)
+	if err == nil {
+		return pdfPath, nil
+	}
+	fmt.Fprintln(os.Stderr, i18n.T("cert.pdf_fallback", err))
+
+	htmlPath := output + ".html"
+	if err := os.WriteFile(htmlPath, []byte(page.String()), 0o644); err != nil { // #nosec G306 -- the certificate is meant to be handed out
+		return "", i18n.Errorf("cert.page_write_failed", err)
+	}
+	return htmlPath, nil
+}
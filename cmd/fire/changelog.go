@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/changelog"
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/spf13/cobra"
+)
+
+func changelogCmd() *cobra.Command {
+	var (
+		machine string
+		all     bool
+		metrics []string
+		window  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "changelog",
+		Short: i18n.T("cmd.changelog"),
+		Long: `Show the BIOS, GPU driver, OS build and kernel versions seen on this machine
+and when each of them changed.
+
+Versions are recorded at the start of every test run, by the scheduler and when
+the GUI starts, and once more when this command runs. The first version seen
+of each component is listed as its baseline.
+
+With --metric, the average of the metric in the window before and after each
+change is compared, so a claim like "it got slower after the driver update"
+can be checked against the results in the database.
+
+Examples:
+  # List the changes on this machine
+  bench changelog
+
+  # Compare CPU scores two weeks either side of each change
+  bench changelog --metric operations_per_second --window 14d
+
+  # List the changes of every machine in the database
+  bench changelog --all`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			width, err := parseDuration(window)
+			if err != nil || width <= 0 {
+				return i18n.Errorf("changelog.invalid_window", window)
+			}
+
+			database, err := openDatabase()
+			if err != nil {
+				return i18n.Errorf("error.open_database", err)
+			}
+			defer func() { _ = database.Close() }()
+
+			if _, err := changelog.Record(context.Background(), database); err != nil {
+				return err
+			}
+
+			if all {
+				machine = ""
+			} else if machine == "" {
+				machine = changelog.Machine()
+			}
+			changes, err := database.ListVersionChanges(machine)
+			if err != nil {
+				return err
+			}
+			if len(changes) == 0 {
+				fmt.Println(i18n.T("changelog.none"))
+				return nil
+			}
+
+			printVersionChanges(changes, all)
+
+			for _, metric := range metrics {
+				buckets, err := database.MetricSeries(db.SeriesFilter{Metric: metric, Width: 24 * time.Hour})
+				if err != nil {
+					return err
+				}
+				fmt.Printf("\n%s\n", i18n.T("changelog.impact", metric, window))
+				printImpacts(changelog.Impacts(changes, buckets, width))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&machine, "machine", "", "Machine to list (default: this machine)")
+	cmd.Flags().BoolVar(&all, "all", false, "List the changes of every machine")
+	cmd.Flags().StringSliceVar(&metrics, "metric", nil, "Metric to compare before and after each change (repeatable)")
+	cmd.Flags().StringVar(&window, "window", "14d", "Period before and after each change to compare (e.g. 7d, 48h)")
+
+	return cmd
+}
+
+// printVersionChanges lists changes oldest first, with the machine when
+// several are shown
+func printVersionChanges(changes []*db.VersionChange, showMachine bool) {
+	if showMachine {
+		fmt.Printf("%-20s %-20s %-12s %s\n", i18n.T("table.detected"), i18n.T("table.machine"), i18n.T("table.component"), i18n.T("table.change"))
+	} else {
+		fmt.Printf("%-20s %-12s %s\n", i18n.T("table.detected"), i18n.T("table.component"), i18n.T("table.change"))
+	}
+	fmt.Println(strings.Repeat("-", 80))
+	for _, c := range changes {
+		change := c.OldVersion + " -> " + c.NewVersion
+		if c.Baseline() {
+			change = c.NewVersion + " (baseline)"
+		}
+		detected := c.DetectedAt.Local().Format("2006-01-02 15:04:05")
+		if showMachine {
+			fmt.Printf("%-20s %-20s %-12s %s\n", detected, c.Machine, changelog.ComponentLabel(c.Component), change)
+		} else {
+			fmt.Printf("%-20s %-12s %s\n", detected, changelog.ComponentLabel(c.Component), change)
+		}
+	}
+}
+
+// printImpacts compares a metric's average before and after each change
+func printImpacts(impacts []changelog.Impact) {
+	if len(impacts) == 0 {
+		fmt.Println(i18n.T("changelog.no_changes"))
+		return
+	}
+	fmt.Printf("%-12s %-12s %-14s %-14s %s\n", i18n.T("table.date"), i18n.T("table.component"), i18n.T("table.before"), i18n.T("table.after"), i18n.T("table.change"))
+	fmt.Println(strings.Repeat("-", 70))
+	for _, impact := range impacts {
+		before, after, change := "-", "-", "-"
+		if impact.Before.Count > 0 {
+			before = fmt.Sprintf("%.2f (%d)", impact.Before.Avg, impact.Before.Count)
+		}
+		if impact.After.Count > 0 {
+			after = fmt.Sprintf("%.2f (%d)", impact.After.Avg, impact.After.Count)
+		}
+		if pct, ok := impact.ChangePct(); ok {
+			change = fmt.Sprintf("%+.1f%%", pct)
+		}
+		fmt.Printf("%-12s %-12s %-14s %-14s %s\n", impact.Change.DetectedAt.Local().Format("2006-01-02"),
+			changelog.ComponentLabel(impact.Change.Component), before, after, change)
+	}
+}
@@ -1,30 +1,212 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/benchmode"
+	"github.com/mscrnt/project_fire/pkg/config"
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/dbsync"
+	"github.com/mscrnt/project_fire/pkg/hooks"
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/mscrnt/project_fire/pkg/journal"
+	"github.com/mscrnt/project_fire/pkg/mail"
+	"github.com/mscrnt/project_fire/pkg/safety"
+	"github.com/mscrnt/project_fire/pkg/sensors"
+	"github.com/mscrnt/project_fire/pkg/testplan"
+	"github.com/mscrnt/project_fire/pkg/units"
 )
 
-// getDBPath returns the path to the F.I.R.E. database file
-func getDBPath() string {
-	// Check environment variable first
-	if dbPath := os.Getenv("FIRE_DB_PATH"); dbPath != "" {
-		return dbPath
-	}
+// getSettingsPath returns the path to the config file
+func getSettingsPath() string {
+	return config.Path()
+}
+
+// getBenchmodeStatePath returns where benchmark mode records the settings it changed
+func getBenchmodeStatePath() string {
+	return benchmode.DefaultStatePath()
+}
 
-	// Default to user's home directory
+// getSpecsPath returns the path to the saved spec sheet
+func getSpecsPath() string {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		// Fallback to current directory
-		return "fire.db"
+		return "specs.json"
+	}
+	return filepath.Join(homeDir, ".fire", "specs.json")
+}
+
+// setupLanguage selects the language of CLI output from the settings file,
+// falling back to the locale environment (LANGUAGE, LC_ALL, LC_MESSAGES, LANG)
+func setupLanguage() {
+	lang := i18n.Detect()
+	if settings, err := loadSettings(); err == nil && settings.Language != "" {
+		lang = settings.Language
+	}
+	i18n.SetLanguage(lang)
+}
+
+// setupSensors selects the sensor backends from the settings
+func setupSensors() {
+	settings, err := loadSettings()
+	if err != nil {
+		return
+	}
+	sensors.Configure(sensors.Options{
+		NoBMC:  settings.Sensors.BMC != nil && !*settings.Sensors.BMC,
+		WebURL: settings.Sensors.LHMURL,
+	})
+}
+
+// loadSettings returns the layered settings: defaults, the legacy settings
+// file, the config file and the environment. Flags are applied on top by the
+// commands.
+func loadSettings() (config.Settings, error) {
+	return config.Load()
+}
+
+// displayUnits returns the units readings are printed in
+func displayUnits() units.Prefs {
+	settings, err := loadSettings()
+	if err != nil {
+		return units.Default()
+	}
+	return settings.Units
+}
+
+// getDBConfig resolves the storage backend from the settings. SQLite at
+// ~/.fire/fire.db is used when nothing is configured.
+func getDBConfig() (db.Config, error) {
+	settings, err := loadSettings()
+	if err != nil {
+		return db.Config{}, err
+	}
+	cfg := settings.Database
+
+	driver, err := db.ParseDriver(string(cfg.Driver))
+	if err != nil {
+		return cfg, err
+	}
+	cfg.Driver = driver
+
+	if cfg.Driver == db.DriverSQLite {
+		if cfg.Path == "" {
+			cfg.Path = config.DefaultDBPath()
+		}
+		// Create the .fire directory if it doesn't exist
+		_ = os.MkdirAll(filepath.Dir(cfg.Path), 0o750)
+	}
+
+	return cfg, nil
+}
+
+// openDatabase opens the configured results database
+func openDatabase() (*db.DB, error) {
+	cfg, err := getDBConfig()
+	if err != nil {
+		return nil, err
+	}
+	database, err := db.OpenConfig(cfg)
+	if err != nil {
+		return nil, err
 	}
+	recoverRuns(database)
+	return database, nil
+}
+
+// recoverRuns marks the runs and plan runs that a crash, power loss or
+// restart cut short as interrupted, and says so
+func recoverRuns(database *db.DB) {
+	runs, err := journal.Recover(database)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not recover interrupted runs: %v\n", err)
+	}
+	for _, run := range runs {
+		fmt.Fprintf(os.Stderr, "Run %d (%s) was interrupted; last heartbeat at %s\n",
+			run.ID, run.Plugin, run.EndTime.Format("2006-01-02 15:04:05"))
+	}
+
+	plans, err := testplan.Recover(database)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not recover interrupted plan runs: %v\n", err)
+	}
+	for _, run := range plans {
+		fmt.Fprintf(os.Stderr, "Plan run %d (%s) was interrupted; continue it with 'bench plan resume %d'\n",
+			run.ID, run.Name, run.ID)
+	}
+}
+
+// getSyncConfig resolves the central server that local runs are pushed to.
+// ok is false when no sync target is configured.
+func getSyncConfig() (target db.Config, interval time.Duration, ok bool, err error) {
+	settings, err := loadSettings()
+	if err != nil {
+		return target, 0, false, err
+	}
+	target = settings.Sync.Target
+
+	// Environment overrides the settings file
+	if dsn := os.Getenv("FIRE_SYNC_DSN"); dsn != "" {
+		target = db.Config{Driver: db.DriverPostgres, DSN: dsn}
+	}
+	if target.DSN == "" && target.Path == "" {
+		return target, 0, false, nil
+	}
+
+	driver, err := db.ParseDriver(string(target.Driver))
+	if err != nil {
+		return target, 0, false, err
+	}
+	target.Driver = driver
 
-	// Create .fire directory if it doesn't exist
-	fireDir := filepath.Join(homeDir, ".fire")
-	if err := os.MkdirAll(fireDir, 0o750); err == nil {
-		return filepath.Join(fireDir, "fire.db")
+	interval = dbsync.DefaultInterval
+	if settings.Sync.Interval != "" {
+		interval, err = time.ParseDuration(settings.Sync.Interval)
+		if err != nil {
+			return target, 0, false, fmt.Errorf("invalid sync interval: %w", err)
+		}
 	}
 
-	// Fallback to current directory
-	return "fire.db"
+	return target, interval, true, nil
+}
+
+// getHooksConfig returns the pre-run and post-run hooks from the settings file
+func getHooksConfig() (hooks.Config, error) {
+	settings, err := loadSettings()
+	if err != nil {
+		return hooks.Config{}, err
+	}
+	if err := settings.Hooks.Validate(); err != nil {
+		return hooks.Config{}, err
+	}
+	return settings.Hooks, nil
+}
+
+// getSafetyLimits returns the readings at which every test is stopped
+func getSafetyLimits() (safety.Limits, error) {
+	settings, err := loadSettings()
+	if err != nil {
+		return safety.Limits{}, err
+	}
+	return settings.Safety, nil
+}
+
+// getSMTPConfig returns the server run reports are mailed through. The
+// password may be given in FIRE_SMTP_PASSWORD instead of the config file.
+func getSMTPConfig() (mail.Config, error) {
+	settings, err := loadSettings()
+	if err != nil {
+		return mail.Config{}, err
+	}
+	cfg := settings.SMTP
+	if cfg.Host == "" {
+		return cfg, fmt.Errorf("no SMTP server configured; add \"smtp\" to %s", getSettingsPath())
+	}
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("invalid SMTP settings: %w", err)
+	}
+	return cfg, nil
 }
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mscrnt/project_fire/pkg/compare"
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/spf13/cobra"
+)
+
+func compareCmd() *cobra.Command {
+	var (
+		threshold float64
+		report    string
+		fail      bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "compare <run> <run> [run...]",
+		Short: i18n.T("cmd.compare"),
+		Long: `Compare the results of runs with the first run given, the baseline, side by
+side. Metrics sampled during a run, such as temperatures and clocks, show
+their average and 95th percentile; the average is the sustained value.
+
+A metric that got worse than the baseline by more than --threshold percent
+is flagged as a regression. Which way is worse is told from the metric: lower
+is better for temperatures, latencies and errors, higher for scores,
+throughput and clocks. Metrics such as power or fan speed are not flagged.
+
+Examples:
+  # Re-test a machine after a repair and compare with the run before it
+  bench compare 42 57
+
+  # Compare three runs, flagging changes beyond 10%
+  bench compare 42 57 61 --threshold 10
+
+  # Write an HTML diff report, and fail in scripts when anything got worse
+  bench compare 42 57 --report diff.html --fail-on-regression`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if threshold < 0 {
+				return fmt.Errorf("--threshold must not be negative")
+			}
+
+			database, err := openDatabase()
+			if err != nil {
+				return i18n.Errorf("error.open_database", err)
+			}
+			defer func() { _ = database.Close() }()
+
+			runs := make([]*db.Run, len(args))
+			for i, ref := range args {
+				if runs[i], err = database.ResolveRun(ref); err != nil {
+					return i18n.Errorf("error.run_not_found", ref)
+				}
+			}
+
+			c, err := compare.Runs(database, runs, threshold)
+			if err != nil {
+				return err
+			}
+			c.Units = displayUnits()
+			if err := printComparison(c); err != nil {
+				return err
+			}
+
+			if report != "" {
+				f, err := os.Create(report) // #nosec G304 -- path given by the user
+				if err != nil {
+					return err
+				}
+				if err := c.WriteHTML(f); err != nil {
+					_ = f.Close()
+					return err
+				}
+				if err := f.Close(); err != nil {
+					return err
+				}
+				fmt.Printf("\n%s\n", i18n.T("report.written", report))
+			}
+
+			if n := len(c.Regressions()); fail && n > 0 {
+				return fmt.Errorf("%d metrics regressed beyond %.1f%%", n, threshold)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().Float64Var(&threshold, "threshold", compare.DefaultThreshold, "Change in percent beyond which a worse metric is a regression")
+	cmd.Flags().StringVar(&report, "report", "", "Write the comparison as HTML to this file")
+	cmd.Flags().BoolVar(&fail, "fail-on-regression", false, "Exit with an error when any metric regressed")
+
+	return cmd
+}
+
+// printComparison prints the runs, a table of their metrics and the
+// regressions
+func printComparison(c *compare.Comparison) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join([]string{i18n.T("table.run"), i18n.T("table.plugin"), i18n.T("table.started"), i18n.T("table.machine"), i18n.T("table.status")}, "\t"))
+	for i, run := range c.Runs {
+		id := fmt.Sprintf("#%d", run.ID)
+		if i == 0 {
+			id += " (baseline)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", id, run.Plugin, run.StartTime.Format("2006-01-02 15:04"), run.Machine, formatRunStatus(run))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(c.Rows) == 0 {
+		fmt.Printf("\n%s\n", i18n.T("compare.no_results"))
+		return nil
+	}
+
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprint(w, i18n.T("table.metric"))
+	for _, run := range c.Runs {
+		fmt.Fprintf(w, "\t#%d", run.ID)
+	}
+	fmt.Fprintln(w)
+	for _, row := range c.Rows {
+		fmt.Fprint(w, row.Metric)
+		for i, s := range row.Summaries {
+			cell := c.Value(row, i)
+			if i > 0 && s != nil && row.Summaries[0] != nil {
+				cell += " (" + row.Changes[i].String() + ")"
+				switch row.Changes[i].Verdict {
+				case compare.Worse:
+					cell += " WORSE"
+				case compare.Better:
+					cell += " better"
+				}
+			}
+			fmt.Fprintf(w, "\t%s", cell)
+		}
+		fmt.Fprintln(w)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	regressions := c.Regressions()
+	if len(regressions) == 0 {
+		fmt.Printf("\n%s\n", i18n.T("compare.no_regressions", c.Threshold))
+		return nil
+	}
+	fmt.Printf("\n%s\n", i18n.T("compare.regressions", len(regressions), c.Threshold))
+	for _, row := range regressions {
+		var worse []string
+		for i, ch := range row.Changes {
+			if ch.Verdict == compare.Worse {
+				worse = append(worse, fmt.Sprintf("#%d %s", c.Runs[i].ID, ch))
+			}
+		}
+		fmt.Printf("  %s: %s\n", row.Metric, strings.Join(worse, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mscrnt/project_fire/pkg/config"
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/mscrnt/project_fire/pkg/plugin"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func configCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: i18n.T("cmd.config"),
+		Long: `Show, change and check the settings used by bench and the GUI.
+
+Settings come in layers, each overriding the ones before it:
+
+  1. Built-in defaults
+  2. ~/.fire/settings.json, the settings file of earlier releases (FIRE_SETTINGS)
+  3. The config file, ~/.config/fire/config.yaml (FIRE_CONFIG; see bench config path)
+  4. Environment variables such as FIRE_DB_PATH (see bench config list)
+  5. Command-line flags
+
+Keys are written with dots. The sections are database, sync, hooks, power,
+smtp, notify, sink, language, telemetry, sensors, gui, units and plugins,
+which holds the default duration, threads and config of each plugin.
+
+'bench config set' writes to the config file, keeping its comments. Values
+are converted to the type of the key: true or false for switches, a
+comma-separated list for lists and YAML for whole sections.
+
+Examples:
+  # Show every setting and where it comes from
+  bench config
+
+  # Keep the database somewhere else
+  bench config set database.path /data/fire/fire.db
+
+  # Run the CPU test for 10 minutes on 8 threads unless flags say otherwise
+  bench config set plugins.cpu.duration 10m
+  bench config set plugins.cpu.threads 8
+
+  # Show temperatures in Fahrenheit and data rates in MiB/s everywhere
+  bench config set units.temperature F
+  bench config set units.data_rate MiB/s
+
+  # Turn telemetry off and use the light theme in the GUI
+  bench config set telemetry.enabled false
+  bench config set gui.theme light
+
+  # Check the settings after editing the file by hand
+  bench config validate`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return listConfig()
+		},
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "Show every setting, its value and where it comes from",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return listConfig()
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "get <key>",
+		Short: "Print the value of a setting or section",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			v, ok, err := config.Get(args[0])
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return i18n.Errorf("config.not_set", args[0])
+			}
+			switch value := v.Value.(type) {
+			case map[string]interface{}, []interface{}:
+				enc := yaml.NewEncoder(os.Stdout)
+				enc.SetIndent(2)
+				if err := enc.Encode(value); err != nil {
+					return err
+				}
+				return enc.Close()
+			default:
+				fmt.Println(formatConfigValue(value))
+			}
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a setting in the config file",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if err := config.Set(args[0], args[1]); err != nil {
+				return err
+			}
+			fmt.Println(i18n.T("config.set", args[0], config.Path()))
+			warnConfigOverride(args[0])
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "unset <key>",
+		Short: "Remove a setting from the config file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			removed, err := config.Unset(args[0])
+			if err != nil {
+				return err
+			}
+			if !removed {
+				fmt.Println(i18n.T("config.not_set_in", args[0], config.Path()))
+				return nil
+			}
+			fmt.Println(i18n.T("config.removed", args[0], config.Path()))
+			warnConfigOverride(args[0])
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "validate",
+		Short: "Check the settings of every layer",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			problems, err := config.Validate()
+			if err != nil {
+				return err
+			}
+			errs := make([]string, 0, len(problems))
+			for _, p := range problems {
+				errs = append(errs, p.Error())
+			}
+			errs = append(errs, checkPluginDefaults()...)
+			if len(errs) > 0 {
+				for _, e := range errs {
+					fmt.Printf("  %s\n", e)
+				}
+				return i18n.Errorf("config.problems", len(errs))
+			}
+			fmt.Println(i18n.T("config.valid"))
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "path",
+		Short: "Print the path of the config file",
+		Args:  cobra.NoArgs,
+		Run: func(_ *cobra.Command, _ []string) {
+			fmt.Println(config.Path())
+		},
+	})
+
+	return cmd
+}
+
+// listConfig prints every setting with its source, followed by the
+// environment variables that set them
+func listConfig() error {
+	values, err := config.Values()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join([]string{i18n.T("table.key"), i18n.T("table.value"), i18n.T("table.source")}, "\t"))
+	for _, v := range values {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", v.Key, redactConfigValue(v.Key, v.Value), v.Source)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%s\n", i18n.T("config.file", config.Path()))
+	fmt.Println(i18n.T("config.legacy_file", config.LegacyPath()))
+	fmt.Printf("\n%s\n", i18n.T("config.environment"))
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, e := range config.Env {
+		fmt.Fprintf(w, "  %s\t%s\n", e.Name, e.Key)
+	}
+	return w.Flush()
+}
+
+// warnConfigOverride tells when an environment variable hides what the
+// config file says about a key
+func warnConfigOverride(key string) {
+	for _, e := range config.Env {
+		if e.Key == key && os.Getenv(e.Name) != "" {
+			fmt.Println(i18n.T("config.env_override", e.Name))
+		}
+	}
+}
+
+// checkPluginDefaults checks the plugin defaults against the plugins
+func checkPluginDefaults() []string {
+	settings, err := loadSettings()
+	if err != nil {
+		return []string{err.Error()}
+	}
+	names := make([]string, 0, len(settings.Plugins))
+	for name := range settings.Plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var problems []string
+	for _, name := range names {
+		p, err := plugin.Get(name)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("plugins.%s: unknown plugin (use one of %s)", name, strings.Join(plugin.List(), ", ")))
+			continue
+		}
+		params, err := settings.PluginParams(p)
+		if err == nil {
+			err = p.ValidateParams(params)
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("plugins.%s: %v", name, err))
+		}
+	}
+	return problems
+}
+
+// formatConfigValue formats a setting for the terminal
+func formatConfigValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
+
+// redactConfigValue hides passwords, tokens and the passwords of URLs
+func redactConfigValue(key string, v interface{}) string {
+	last := key[strings.LastIndex(key, ".")+1:]
+	if last == "password" || last == "token" {
+		return "********"
+	}
+	switch v := v.(type) {
+	case string:
+		if u, err := url.Parse(v); err == nil && u.User != nil {
+			return u.Redacted()
+		}
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			if s, ok := item.(string); ok {
+				item = redactConfigValue(key, s)
+			}
+			items[i] = item
+		}
+		return formatConfigValue(items)
+	}
+	return formatConfigValue(v)
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/cooling"
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/spf13/cobra"
+)
+
+func coolingCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cooling",
+		Short: i18n.T("cmd.cooling"),
+		Long: `Measure whether a repaste or cooler swap helped: record a "before" session,
+do the work, record an "after" session with exactly the same load, and
+compare the two.
+
+Each session runs a thermal load (the cpu plugin for 15 minutes by default)
+while recording the temperatures, package power and fan speeds bench monitor
+reads. The comparison skips each session's warmup (the first third of the
+load unless --warmup is given) and compares temperatures at equal power, so
+a CPU that boosts higher once it runs cooler is not mistaken for a worse
+cooler. Give the room temperature with --ambient in both sessions to take a
+warmer or cooler room out of the result.
+
+Examples:
+  # Before the repaste: 20 minutes of CPU load, room at 22.5 °C
+  bench cooling before rig-07 --duration 20m --ambient 22.5
+
+  # After the repaste: the same load again
+  bench cooling after rig-07 --ambient 23
+
+  # Compare the latest before and after sessions
+  bench cooling compare rig-07 --report rig-07-repaste.html`,
+	}
+
+	cmd.AddCommand(coolingBeforeCmd())
+	cmd.AddCommand(coolingAfterCmd())
+	cmd.AddCommand(coolingCompareCmd())
+	cmd.AddCommand(coolingListCmd())
+
+	return cmd
+}
+
+func coolingBeforeCmd() *cobra.Command {
+	var (
+		s      cooling.Session
+		config map[string]string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "before <label>",
+		Short: "Record the session before the cooling change",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			s.Label = args[0]
+			s.Phase = cooling.PhaseBefore
+			if len(config) > 0 {
+				s.Config = make(map[string]interface{}, len(config))
+				for k, v := range config {
+					s.Config[k] = parseConfigValue(v)
+				}
+			}
+			return recordCooling(&s)
+		},
+	}
+
+	cmd.Flags().StringVarP(&s.Load, "load", "l", cooling.DefaultLoad, "Plugin that heats the machine")
+	cmd.Flags().DurationVarP(&s.Duration, "duration", "d", cooling.DefaultDuration, "How long the load runs")
+	cmd.Flags().DurationVar(&s.Warmup, "warmup", 0, "Start of the load left out of the comparison (default a third of the duration)")
+	cmd.Flags().IntVarP(&s.Threads, "threads", "t", 0, "Load threads (0 = the plugin's default)")
+	cmd.Flags().StringToStringVarP(&config, "config", "c", map[string]string{}, "Load configuration (key=value)")
+	cmd.Flags().DurationVarP(&s.Interval, "interval", "i", cooling.DefaultInterval, "Time between readings")
+	cmd.Flags().Float64Var(&s.Ambient, "ambient", 0, "Room temperature in °C")
+
+	return cmd
+}
+
+func coolingAfterCmd() *cobra.Command {
+	var ambient float64
+
+	cmd := &cobra.Command{
+		Use:   "after <label>",
+		Short: "Repeat the before session's load after the cooling change",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			database, err := openDatabase()
+			if err != nil {
+				return i18n.Errorf("error.open_database", err)
+			}
+			before, err := cooling.Latest(database, args[0], cooling.PhaseBefore)
+			_ = database.Close()
+			if err != nil {
+				return i18n.Errorf("cooling.no_before", err, args[0])
+			}
+
+			s := cooling.Repeat(before)
+			s.Ambient = ambient
+			fmt.Println(i18n.T("cooling.repeating", before.Run.ID, before.Run.StartTime.Format("2006-01-02 15:04")))
+			if err := recordCooling(s); err != nil {
+				return err
+			}
+			fmt.Println(i18n.T("cooling.compare_hint", s.Label))
+			return nil
+		},
+	}
+
+	cmd.Flags().Float64Var(&ambient, "ambient", 0, "Room temperature in °C")
+
+	return cmd
+}
+
+// recordCooling runs a session until its load ends or Ctrl+C
+func recordCooling(s *cooling.Session) error {
+	database, err := openDatabase()
+	if err != nil {
+		return i18n.Errorf("error.open_database", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Println(i18n.T("cooling.recording", s.Phase, s.Label, s.Load, s.Duration))
+	recorder := cooling.NewRecorder(database, log.New(os.Stderr, "[cooling] ", log.LstdFlags))
+	if err := recorder.Record(ctx, s); err != nil {
+		return err
+	}
+	fmt.Println(i18n.T("cooling.recorded", s.Phase, s.Run.ID))
+	return nil
+}
+
+func coolingCompareCmd() *cobra.Command {
+	var (
+		report   string
+		beforeID int64
+		afterID  int64
+	)
+
+	cmd := &cobra.Command{
+		Use:   "compare <label>",
+		Short: "Compare the before and after sessions",
+		Long: `Compare the latest before and after sessions of a label, or the sessions
+given with --before and --after, and optionally write the comparison as an
+HTML report.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			database, err := openDatabase()
+			if err != nil {
+				return i18n.Errorf("error.open_database", err)
+			}
+			defer func() { _ = database.Close() }()
+
+			session := func(id int64, phase string) (*cooling.Session, error) {
+				if id == 0 {
+					return cooling.Latest(database, args[0], phase)
+				}
+				run, err := database.GetRun(id)
+				if err != nil {
+					return nil, err
+				}
+				return cooling.FromRun(run)
+			}
+			before, err := session(beforeID, cooling.PhaseBefore)
+			if err != nil {
+				return err
+			}
+			after, err := session(afterID, cooling.PhaseAfter)
+			if err != nil {
+				return err
+			}
+
+			c, err := cooling.Compare(database, before, after)
+			if err != nil {
+				return err
+			}
+			printCoolingComparison(c)
+
+			if report != "" {
+				f, err := os.Create(report) // #nosec G304 -- path given by the user
+				if err != nil {
+					return err
+				}
+				if err := c.WriteHTML(f); err != nil {
+					_ = f.Close()
+					return err
+				}
+				if err := f.Close(); err != nil {
+					return err
+				}
+				fmt.Printf("\n%s\n", i18n.T("report.written", report))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&report, "report", "", "Write the comparison as HTML to this file")
+	cmd.Flags().Int64Var(&beforeID, "before", 0, "Run ID of the before session (default the latest)")
+	cmd.Flags().Int64Var(&afterID, "after", 0, "Run ID of the after session (default the latest)")
+
+	return cmd
+}
+
+// printCoolingComparison prints the comparison as tables
+func printCoolingComparison(c *cooling.Comparison) {
+	fmt.Printf("%s\n\n", i18n.T("cooling.comparison",
+		c.Label, c.Before.Run.ID, c.Before.Run.StartTime.Format("2006-01-02 15:04"),
+		c.After.Run.ID, c.After.Run.StartTime.Format("2006-01-02 15:04")))
+
+	if len(c.Sensors) > 0 {
+		fmt.Printf("%-10s %-10s %-10s %-12s %-12s %s\n", i18n.T("table.component"), i18n.T("table.before"), i18n.T("table.after"), i18n.T("table.power_before"), i18n.T("table.power_after"), i18n.T("table.delta"))
+		fmt.Println(strings.Repeat("-", 76))
+	}
+	watts := func(v float64) string {
+		if v == 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%.1f W", v)
+	}
+	for _, d := range c.Sensors {
+		delta := fmt.Sprintf("%+.1f °C", d.Delta)
+		if d.Matched {
+			delta += fmt.Sprintf(" at %.0f W", d.AtPower)
+		}
+		fmt.Printf("%-10s %-10s %-10s %-12s %-12s %s\n", d.Name,
+			fmt.Sprintf("%.1f °C", d.BeforeTemp), fmt.Sprintf("%.1f °C", d.AfterTemp),
+			watts(d.BeforePower), watts(d.AfterPower), delta)
+	}
+
+	if len(c.Fans) > 0 {
+		fmt.Printf("\n%-24s %-10s %-10s %s\n", i18n.T("table.fan"), i18n.T("table.before"), i18n.T("table.after"), i18n.T("table.change"))
+		fmt.Println(strings.Repeat("-", 60))
+		for _, f := range c.Fans {
+			fmt.Printf("%-24s %-10.0f %-10.0f %+.0f %s (%+.1f%%)\n", f.Metric, f.Before, f.After, f.Change(), f.Unit, f.Percent())
+		}
+	}
+
+	if len(c.Load) > 0 {
+		fmt.Printf("\n%s\n", i18n.T("cooling.load_results"))
+		for _, m := range c.Load {
+			fmt.Printf("  %s: %.2f -> %.2f %s (%+.1f%%)\n", m.Metric, m.Before, m.After, m.Unit, m.Percent())
+		}
+	}
+
+	fmt.Println()
+	for _, d := range c.Sensors {
+		fmt.Println(d.Describe())
+	}
+	for _, n := range c.Notes {
+		fmt.Println(i18n.T("cooling.note", n))
+	}
+}
+
+func coolingListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list [label]",
+		Short: "List recorded sessions",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			database, err := openDatabase()
+			if err != nil {
+				return i18n.Errorf("error.open_database", err)
+			}
+			defer func() { _ = database.Close() }()
+
+			label := ""
+			if len(args) > 0 {
+				label = args[0]
+			}
+			sessions, err := cooling.List(database, label)
+			if err != nil {
+				return err
+			}
+			if len(sessions) == 0 {
+				fmt.Println(i18n.T("cooling.no_sessions"))
+				return nil
+			}
+
+			fmt.Printf("%-6s %-20s %-7s %-17s %-22s %s\n", i18n.T("table.run"), i18n.T("table.label"), i18n.T("table.phase"), i18n.T("table.started"), i18n.T("table.load"), i18n.T("table.result"))
+			fmt.Println(strings.Repeat("-", 84))
+			for _, s := range sessions {
+				result := "running"
+				if s.Run.EndTime != nil {
+					result = "ok"
+					if !s.Run.Success {
+						result = s.Run.Error
+					}
+				}
+				fmt.Printf("%-6d %-20s %-7s %-17s %-22s %s\n", s.Run.ID, truncate(s.Label, 20), s.Phase,
+					s.Run.StartTime.Format("2006-01-02 15:04"), fmt.Sprintf("%s %s", s.Load, s.Duration.Round(time.Second)), result)
+			}
+			return nil
+		},
+	}
+}
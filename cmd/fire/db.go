@@ -0,0 +1,496 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/spf13/cobra"
+)
+
+func dbCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: i18n.T("cmd.db"),
+		Long: `Check, migrate, back up, restore and prune the results database.
+
+The schema is changed by numbered migrations, applied in order when the
+database is opened. A SQLite database is backed up automatically before
+pending migrations are applied, unless "database.migrate_backup" is false, and
+the scheduler daemon ("bench schedule start") checks its integrity daily and
+keeps weekly snapshots. Backups are stored in a "backups" directory next to
+the database.`,
+	}
+
+	cmd.AddCommand(dbStatusCmd())
+	cmd.AddCommand(dbMigrateCmd())
+	cmd.AddCommand(dbCheckCmd())
+	cmd.AddCommand(dbBackupCmd())
+	cmd.AddCommand(dbBackupsCmd())
+	cmd.AddCommand(dbRestoreCmd())
+	cmd.AddCommand(dbPruneCmd())
+	cmd.AddCommand(dbVacuumCmd())
+
+	return cmd
+}
+
+// sqlitePath returns the configured SQLite database file
+func sqlitePath() (string, error) {
+	cfg, err := getDBConfig()
+	if err != nil {
+		return "", err
+	}
+	if cfg.Driver != db.DriverSQLite {
+		return "", db.ErrBackupUnsupported
+	}
+	return cfg.Path, nil
+}
+
+func dbStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "List the schema migrations and whether each is applied",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			cfg, err := getDBConfig()
+			if err != nil {
+				return err
+			}
+			database, err := db.OpenUnmigrated(cfg)
+			if err != nil {
+				return i18n.Errorf("error.open_database", err)
+			}
+			defer func() { _ = database.Close() }()
+
+			status, err := database.Migrations()
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("%s\n\n", i18n.T("db.database", describeDatabase(database)))
+			fmt.Printf("%-8s %-20s %s\n", i18n.T("table.version"), i18n.T("table.applied"), i18n.T("table.description"))
+			fmt.Println(strings.Repeat("-", 70))
+			pending := 0
+			for _, s := range status {
+				applied := i18n.T("db.pending")
+				if s.AppliedAt != nil {
+					applied = s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+				} else {
+					pending++
+				}
+				fmt.Printf("%-8d %-20s %s\n", s.Version, applied, s.Description)
+			}
+
+			fmt.Println()
+			if pending == 0 {
+				fmt.Println(i18n.T("db.up_to_date", db.SchemaVersion()))
+			} else {
+				fmt.Println(i18n.T("db.migrations_pending", pending))
+			}
+			return nil
+		},
+	}
+}
+
+func dbMigrateCmd() *cobra.Command {
+	var (
+		backup bool
+		dryRun bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply the pending schema migrations",
+		Long: `Apply the pending schema migrations in order. bench and the GUI do this
+whenever they open the database; this command does it on its own, such as to
+upgrade a PostgreSQL server before the agents that write to it.
+
+A SQLite database is backed up first unless --backup=false is given; the
+default follows "database.migrate_backup".
+
+Examples:
+  # See what would change
+  bench db migrate --dry-run
+
+  # Migrate without a backup
+  bench db migrate --backup=false`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg, err := getDBConfig()
+			if err != nil {
+				return err
+			}
+			if !cmd.Flags().Changed("backup") {
+				backup = cfg.BackupBeforeMigrate()
+			}
+			database, err := db.OpenUnmigrated(cfg)
+			if err != nil {
+				return i18n.Errorf("error.open_database", err)
+			}
+			defer func() { _ = database.Close() }()
+
+			pending, err := database.Pending()
+			if err != nil {
+				return err
+			}
+			if len(pending) == 0 {
+				fmt.Println(i18n.T("db.up_to_date", db.SchemaVersion()))
+				return nil
+			}
+			if dryRun {
+				for _, m := range pending {
+					fmt.Println(i18n.T("db.would_apply", m.Version, m.Description))
+				}
+				return nil
+			}
+
+			if backup && database.Driver() == db.DriverSQLite {
+				saved, err := database.Backup(db.BackupPreMigrate)
+				if err != nil {
+					return fmt.Errorf("failed to back up database before migration: %w", err)
+				}
+				fmt.Println(i18n.T("db.backup_saved", saved.Path, formatSize(saved.Size)))
+				if err := db.PruneBackups(db.BackupDir(database.Path()), db.BackupPreMigrate, db.KeepPreMigrate); err != nil {
+					fmt.Fprintln(os.Stderr, i18n.T("db.prune_backups_failed", err))
+				}
+			}
+
+			done, err := database.Migrate()
+			for _, m := range done {
+				fmt.Println(i18n.T("db.applied", m.Version, m.Description))
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Println(i18n.T("db.up_to_date", db.SchemaVersion()))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&backup, "backup", true, "Back up a SQLite database first")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "List the pending migrations without applying them")
+
+	return cmd
+}
+
+func dbCheckCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "check",
+		Short: "Run an integrity check",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			path, err := sqlitePath()
+			if err != nil {
+				return err
+			}
+			problems, err := db.CheckFile(path)
+			if err != nil {
+				return err
+			}
+			if len(problems) == 0 {
+				fmt.Println(i18n.T("db.check_ok", path))
+				return nil
+			}
+			for _, p := range problems {
+				fmt.Println(p)
+			}
+			return i18n.Errorf("db.check_failed", path, len(problems))
+		},
+	}
+}
+
+func dbBackupCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "backup",
+		Short: "Back up the database now",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if _, err := sqlitePath(); err != nil {
+				return err
+			}
+			database, err := openDatabase()
+			if err != nil {
+				return i18n.Errorf("error.open_database", err)
+			}
+			defer func() { _ = database.Close() }()
+
+			backup, err := database.Backup(db.BackupManual)
+			if err != nil {
+				return err
+			}
+			fmt.Println(i18n.T("db.backup_saved", backup.Path, formatSize(backup.Size)))
+			return nil
+		},
+	}
+}
+
+func dbBackupsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "backups",
+		Short: "List database backups",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			path, err := sqlitePath()
+			if err != nil {
+				return err
+			}
+			backups, err := db.ListBackups(db.BackupDir(path))
+			if err != nil {
+				return err
+			}
+			if len(backups) == 0 {
+				fmt.Println(i18n.T("db.no_backups"))
+				return nil
+			}
+
+			fmt.Printf("%-20s %-12s %-10s %s\n", i18n.T("table.date"), i18n.T("table.reason"), i18n.T("table.size"), i18n.T("table.file"))
+			fmt.Println(strings.Repeat("-", 80))
+			for _, b := range backups {
+				fmt.Printf("%-20s %-12s %-10s %s\n", b.Time.Format("2006-01-02 15:04:05"), b.Reason, formatSize(b.Size), b.Path)
+			}
+			return nil
+		},
+	}
+}
+
+func dbRestoreCmd() *cobra.Command {
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "restore [backup]",
+		Short: "Replace the database with a backup",
+		Long: `Replace the database with a backup file, or the newest backup when none is
+given. The backup is checked for corruption first, and the current database is
+kept as a pre-restore backup. Stop the scheduler and close the GUI before
+restoring.
+
+Examples:
+  # Restore the newest backup
+  bench db restore
+
+  # Restore a specific backup without asking
+  bench db restore ~/.fire/backups/fire-20260104-020000-weekly.db --yes`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			path, err := sqlitePath()
+			if err != nil {
+				return err
+			}
+
+			var backup string
+			if len(args) == 1 {
+				backup = args[0]
+			} else {
+				backups, err := db.ListBackups(db.BackupDir(path))
+				if err != nil {
+					return err
+				}
+				if len(backups) == 0 {
+					return i18n.Errorf("db.no_backups_in", db.BackupDir(path))
+				}
+				backup = backups[0].Path
+			}
+
+			if !yes {
+				fmt.Print(i18n.T("db.confirm_restore", path, backup) + " ")
+				var confirm string
+				if _, err := fmt.Scanln(&confirm); err != nil {
+					// Treat any error as a "no" response
+					confirm = "n"
+				}
+				if !strings.EqualFold(confirm, "y") {
+					fmt.Println(i18n.T("prompt.cancelled"))
+					return nil
+				}
+			}
+
+			safety, err := db.Restore(path, backup)
+			if err != nil {
+				return err
+			}
+			if safety.Path != "" {
+				fmt.Println(i18n.T("db.previous_saved", safety.Path))
+			}
+			fmt.Println(i18n.T("db.restored", path, backup))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Restore without asking for confirmation")
+
+	return cmd
+}
+
+func dbPruneCmd() *cobra.Command {
+	var (
+		olderThan  string
+		downsample string
+		resolution string
+		dryRun     bool
+		yes        bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete old runs and downsample old samples",
+		Long: `Delete runs older than --older-than with their results, and the plan runs,
+annotations, alert events and SMART and inventory snapshots recorded before
+then. Runs still in progress, and the newest SMART snapshot of every drive and
+inventory of every machine, are kept.
+
+Samples recorded before --downsample-older-than, such as the readings of
+bench monitor, are averaged into one result per --resolution.
+
+Without either flag the retention policy of the settings is applied
+("database.retention"), which bench agent serve also applies daily:
+
+  bench config set database.retention.runs 365d
+  bench config set database.retention.samples 30d
+
+Pruning leaves free pages in the database file; run bench db vacuum to return
+them to the file system.
+
+Examples:
+  # See what a year's retention would delete
+  bench db prune --older-than 365d --dry-run
+
+  # Delete runs older than 90 days without asking
+  bench db prune --older-than 90d --yes`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg, err := getDBConfig()
+			if err != nil {
+				return err
+			}
+
+			policy := cfg.Retention
+			if cmd.Flags().Changed("older-than") || cmd.Flags().Changed("downsample-older-than") {
+				policy = db.Retention{Runs: olderThan, Samples: downsample}
+			}
+			if cmd.Flags().Changed("resolution") {
+				policy.Resolution = resolution
+			}
+			if !policy.Enabled() {
+				return fmt.Errorf("nothing to prune: give --older-than or set database.retention in the settings")
+			}
+			opts, err := policy.Options(time.Now())
+			if err != nil {
+				return err
+			}
+
+			database, err := openDatabase()
+			if err != nil {
+				return i18n.Errorf("error.open_database", err)
+			}
+			defer func() { _ = database.Close() }()
+
+			opts.DryRun = true
+			result, err := database.Prune(opts)
+			if err != nil {
+				return err
+			}
+			printPruneResult(result, true)
+			if dryRun || result == (db.PruneResult{}) {
+				return nil
+			}
+
+			if !yes {
+				fmt.Print(i18n.T("db.confirm_prune") + " ")
+				var confirm string
+				if _, err := fmt.Scanln(&confirm); err != nil {
+					// Treat any error as a "no" response
+					confirm = "n"
+				}
+				if !strings.EqualFold(confirm, "y") {
+					fmt.Println(i18n.T("prompt.cancelled"))
+					return nil
+				}
+			}
+
+			opts.DryRun = false
+			if result, err = database.Prune(opts); err != nil {
+				return err
+			}
+			printPruneResult(result, false)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&olderThan, "older-than", "", "Delete runs that started longer ago than this, e.g. 90d")
+	cmd.Flags().StringVar(&downsample, "downsample-older-than", "", "Average samples recorded longer ago than this, e.g. 30d")
+	cmd.Flags().StringVar(&resolution, "resolution", "", "Bucket width samples are averaged into (default 1m)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be pruned")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Prune without asking for confirmation")
+
+	return cmd
+}
+
+// printPruneResult prints what was, or would be, pruned
+func printPruneResult(r db.PruneResult, dryRun bool) {
+	deleted, averaged := "db.deleted", "db.averaged"
+	if dryRun {
+		deleted, averaged = "db.would_delete", "db.would_average"
+	}
+	if r == (db.PruneResult{}) {
+		fmt.Println(i18n.T("db.nothing_to_prune"))
+		return
+	}
+	if r.Runs+r.PlanRuns+r.Events+r.Snapshots > 0 {
+		fmt.Println(i18n.T(deleted, r.Runs, r.Results, r.PlanRuns, r.Events, r.Snapshots))
+	}
+	if r.Samples > 0 {
+		fmt.Println(i18n.T(averaged, r.Samples, r.Buckets))
+	}
+}
+
+func dbVacuumCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "vacuum",
+		Short: "Return the space of deleted data to the file system",
+		Long: `Rebuild the database so the space freed by bench db prune is returned to the
+file system. A SQLite database needs as much free disk space as it takes up
+while this runs, and other writers wait until it is done. PostgreSQL databases
+run VACUUM ANALYZE.`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			database, err := openDatabase()
+			if err != nil {
+				return i18n.Errorf("error.open_database", err)
+			}
+			defer func() { _ = database.Close() }()
+
+			before, err := database.Size()
+			if err != nil {
+				return err
+			}
+			if err := database.Vacuum(); err != nil {
+				return err
+			}
+			after, err := database.Size()
+			if err != nil {
+				return err
+			}
+			fmt.Println(i18n.T("db.vacuumed", describeDatabase(database), formatSize(before), formatSize(after)))
+			return nil
+		},
+	}
+}
+
+// describeDatabase names the database for display; the DSN of a PostgreSQL
+// database may hold its password
+func describeDatabase(database *db.DB) string {
+	if database.Driver() == db.DriverPostgres {
+		return "PostgreSQL"
+	}
+	return database.Path()
+}
+
+// formatSize formats a file size for display
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -2,29 +2,86 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
-	"strconv"
 	"strings"
 
 	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/export"
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/mscrnt/project_fire/pkg/inventory"
+	"github.com/mscrnt/project_fire/pkg/session"
+	"github.com/mscrnt/project_fire/pkg/smart"
+	"github.com/mscrnt/project_fire/pkg/snippet"
 	"github.com/spf13/cobra"
 )
 
 var (
-	exportRunID  int64
+	exportRun    string
 	exportOutput string
 	exportAll    bool
+	exportFormat string
 )
 
+// snippetRunLimit is how many recent runs are searched for the latest run of
+// each plugin when no run is given
+const snippetRunLimit = 50
+
 func exportCmd() *cobra.Command {
+	var (
+		format string
+		runs   []string
+		all    bool
+		output string
+	)
+
 	cmd := &cobra.Command{
 		Use:   "export",
-		Short: "Export test results",
-		Long:  "Export test results in various formats",
+		Short: i18n.T("cmd.export"),
+		Long: `Export test results in various formats.
+
+With --format, the given runs are written as:
+  json         the report of a single run, as bench export json writes it
+  csv          the results, one row per metric, as bench export csv writes it
+  junit        JUnit XML with a test case per run, for Jenkins and other CI servers
+  ndjson       one JSON record per line for each run, result and sensor sample
+  samples-csv  the sensor samples recorded while the runs were going
+
+Sensor samples come from the history the GUI records, at the finest
+resolution still kept for the run's age. NDJSON lines follow the record
+schema (bench schema show record) and JUnit test suites carry the same
+format and schema version in their properties.
+
+Examples:
+  # JUnit results of a burn-in for Jenkins
+  bench export --format junit --run 41,42,43 --out burnin.xml
+
+  # Every run as NDJSON for an ingestion pipeline
+  bench export --format ndjson --all --out runs.ndjson
+
+  # Temperatures and clocks during run 42
+  bench export --format samples-csv --run 42 --out run42-samples.csv`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if format == "" {
+				return cmd.Help()
+			}
+			f, err := export.ParseFormat(format)
+			if err != nil {
+				return err
+			}
+			return runExport(f, runs, all, output)
+		},
 	}
 
+	cmd.Flags().StringVarP(&format, "format", "f", "", "Output format: json, csv, junit, ndjson or samples-csv")
+	cmd.Flags().StringSliceVar(&runs, "run", nil, "Run IDs or UUIDs to export, comma-separated or repeated")
+	cmd.Flags().BoolVar(&all, "all", false, "Export all runs")
+	cmd.Flags().StringVarP(&output, "out", "o", "", "Output file (default: stdout)")
+
 	cmd.AddCommand(exportCSVCmd())
 	cmd.AddCommand(exportJSONCmd())
+	cmd.AddCommand(exportSnippetCmd())
 
 	return cmd
 }
@@ -42,12 +99,15 @@ Examples:
   # Export specific run to stdout
   bench export csv --run 42
 
+  # Export a run by UUID (stable across machines)
+  bench export csv --run 0190f4c2-8a6e-7b3d-9c1a-2f4e6d8b0a1c
+
   # Export all runs
   bench export csv --all --out all-results.csv`,
 		RunE: runExportCSV,
 	}
 
-	cmd.Flags().Int64Var(&exportRunID, "run", 0, "Run ID to export")
+	cmd.Flags().StringVar(&exportRun, "run", "", "Run ID or UUID to export")
 	cmd.Flags().StringVarP(&exportOutput, "out", "o", "", "Output file (default: stdout)")
 	cmd.Flags().BoolVar(&exportAll, "all", false, "Export all runs")
 
@@ -65,11 +125,15 @@ Examples:
   bench export json --run 42 --out results.json
 
   # Export specific run to stdout
-  bench export json --run 42`,
+  bench export json --run 42
+
+  # Export a run that can be opened with "bench report view" or by
+  # double-clicking it where F.I.R.E. is installed
+  bench export json --run 42 --out run42.firereport`,
 		RunE: runExportJSON,
 	}
 
-	cmd.Flags().Int64Var(&exportRunID, "run", 0, "Run ID to export")
+	cmd.Flags().StringVar(&exportRun, "run", "", "Run ID or UUID to export")
 	cmd.Flags().StringVarP(&exportOutput, "out", "o", "", "Output file (default: stdout)")
 
 	return cmd
@@ -77,15 +141,14 @@ Examples:
 
 func runExportCSV(_ *cobra.Command, _ []string) error {
 	// Validate flags
-	if !exportAll && exportRunID == 0 {
+	if !exportAll && exportRun == "" {
 		return fmt.Errorf("either --run or --all must be specified")
 	}
 
 	// Open database
-	dbPath := getDBPath()
-	database, err := db.Open(dbPath)
+	database, err := openDatabase()
 	if err != nil {
-		return fmt.Errorf("failed to open database: %w", err)
+		return i18n.Errorf("error.open_database", err)
 	}
 	defer func() { _ = database.Close() }()
 
@@ -111,15 +174,16 @@ func runExportCSV(_ *cobra.Command, _ []string) error {
 		}
 	} else {
 		// Check if run exists
-		if _, err := database.GetRun(exportRunID); err != nil {
-			return fmt.Errorf("run %d not found", exportRunID)
+		run, err := database.ResolveRun(exportRun)
+		if err != nil {
+			return fmt.Errorf("run %s not found", exportRun)
 		}
 
-		if err := database.ExportCSV(out, exportRunID); err != nil {
+		if err := database.ExportCSV(out, run.ID); err != nil {
 			return fmt.Errorf("failed to export CSV: %w", err)
 		}
 		if exportOutput != "" {
-			fmt.Printf("Exported run %d to %s\n", exportRunID, exportOutput)
+			fmt.Printf("Exported run %d to %s\n", run.ID, exportOutput)
 		}
 	}
 
@@ -128,21 +192,21 @@ func runExportCSV(_ *cobra.Command, _ []string) error {
 
 func runExportJSON(_ *cobra.Command, _ []string) error {
 	// Validate flags
-	if exportRunID == 0 {
+	if exportRun == "" {
 		return fmt.Errorf("--run must be specified")
 	}
 
 	// Open database
-	dbPath := getDBPath()
-	database, err := db.Open(dbPath)
+	database, err := openDatabase()
 	if err != nil {
-		return fmt.Errorf("failed to open database: %w", err)
+		return i18n.Errorf("error.open_database", err)
 	}
 	defer func() { _ = database.Close() }()
 
 	// Check if run exists
-	if _, err := database.GetRun(exportRunID); err != nil {
-		return fmt.Errorf("run %d not found", exportRunID)
+	run, err := database.ResolveRun(exportRun)
+	if err != nil {
+		return fmt.Errorf("run %s not found", exportRun)
 	}
 
 	// Prepare output writer
@@ -157,15 +221,218 @@ func runExportJSON(_ *cobra.Command, _ []string) error {
 		defer func() { _ = out.Close() }()
 	}
 
-	// Export data
-	if err := database.ExportJSON(out, exportRunID); err != nil {
+	if err := writeReport(out, database, run); err != nil {
 		return fmt.Errorf("failed to export JSON: %w", err)
 	}
 
 	if exportOutput != "" {
-		fmt.Printf("Exported run %d to %s\n", exportRunID, exportOutput)
+		fmt.Printf("Exported run %d to %s\n", run.ID, exportOutput)
+	}
+
+	return nil
+}
+
+// runExport writes the runs in the given format
+func runExport(format export.Format, ids []string, all bool, output string) error {
+	if all == (len(ids) > 0) {
+		return fmt.Errorf("either --run or --all must be specified")
+	}
+	if format == export.JSON && (all || len(ids) > 1) {
+		return fmt.Errorf("--format json exports a single run")
+	}
+
+	// Open database
+	database, err := openDatabase()
+	if err != nil {
+		return i18n.Errorf("error.open_database", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	var runs []*db.Run
+	if all {
+		if runs, err = database.ListRuns(db.RunFilter{}); err != nil {
+			return fmt.Errorf("failed to list runs: %w", err)
+		}
+		// Oldest first, as the runs happened
+		for i, j := 0, len(runs)-1; i < j; i, j = i+1, j-1 {
+			runs[i], runs[j] = runs[j], runs[i]
+		}
+	} else {
+		for _, id := range ids {
+			run, err := database.ResolveRun(id)
+			if err != nil {
+				return fmt.Errorf("run %s not found", id)
+			}
+			runs = append(runs, run)
+		}
+	}
+
+	// Prepare output writer
+	var out *os.File
+	if output == "" {
+		out = os.Stdout
+	} else {
+		out, err = os.Create(output) // #nosec G304 -- output is a user-specified output file path from command line flag
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer func() { _ = out.Close() }()
+	}
+
+	switch format {
+	case export.JSON:
+		err = writeReport(out, database, runs[0])
+	case export.CSV:
+		if all {
+			err = database.ExportAllCSV(out)
+			break
+		}
+		for i, run := range runs {
+			if i > 0 {
+				// Each run repeats the header; skip all but the first
+				var buf strings.Builder
+				if err = database.ExportCSV(&buf, run.ID); err != nil {
+					break
+				}
+				_, body, _ := strings.Cut(buf.String(), "\n")
+				_, err = out.WriteString(body)
+			} else {
+				err = database.ExportCSV(out, run.ID)
+			}
+			if err != nil {
+				break
+			}
+		}
+	default:
+		samples := format == export.NDJSON || format == export.SamplesCSV
+		loaded := make([]*export.Run, 0, len(runs))
+		for _, run := range runs {
+			r, err := export.Load(database, run, samples)
+			if err != nil {
+				return err
+			}
+			loaded = append(loaded, r)
+		}
+		switch format {
+		case export.JUnit:
+			err = export.WriteJUnit(out, loaded)
+		case export.NDJSON:
+			err = export.WriteNDJSON(out, loaded)
+		case export.SamplesCSV:
+			err = export.WriteSamplesCSV(out, loaded)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to export %s: %w", format, err)
+	}
+
+	if output != "" {
+		if len(runs) == 1 {
+			fmt.Printf("Exported run %d to %s\n", runs[0].ID, output)
+		} else {
+			fmt.Printf("Exported %d runs to %s\n", len(runs), output)
+		}
+	}
+	return nil
+}
+
+// writeReport writes a run as a report with the versioned file header, so
+// that the GUI, "bench report view" and third-party tools can check it
+// against the published report schema
+func writeReport(w io.Writer, database *db.DB, run *db.Run) error {
+	results, err := database.GetResults(run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get results: %w", err)
+	}
+	file := session.NewReport(run, results)
+	snapshot, err := inventory.ForRun(database, run.ID)
+	if err != nil {
+		return err
+	}
+	if snapshot != nil {
+		file.Components = snapshot.Components
+	}
+	return session.Write(w, file)
+}
+
+func exportSnippetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snippet",
+		Short: "Export a system summary and scores for forum posts",
+		Long: `Export the system specs and benchmark scores as Markdown (Reddit) or BBCode
+(overclocking forums), ready to paste into a post. Without --run, the latest
+successful run of each plugin is included. The hostname is never included.
+
+Examples:
+  # Markdown summary of the latest runs
+  bench export snippet
+
+  # BBCode for a single run, copied to the clipboard on Windows
+  bench export snippet --format bbcode --run 42 | clip`,
+		RunE: runExportSnippet,
+	}
+
+	cmd.Flags().StringVarP(&exportFormat, "format", "f", "markdown", "Markup format: markdown or bbcode")
+	cmd.Flags().StringVar(&exportRun, "run", "", "Run ID or UUID to include (default: latest run of each plugin)")
+	cmd.Flags().StringVarP(&exportOutput, "out", "o", "", "Output file (default: stdout)")
+
+	return cmd
+}
+
+func runExportSnippet(_ *cobra.Command, _ []string) error {
+	format, err := snippet.ParseFormat(exportFormat)
+	if err != nil {
+		return err
+	}
+
+	// Open database
+	database, err := openDatabase()
+	if err != nil {
+		return i18n.Errorf("error.open_database", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	var runs []*db.Run
+	if exportRun != "" {
+		run, err := database.ResolveRun(exportRun)
+		if err != nil {
+			return fmt.Errorf("run %s not found", exportRun)
+		}
+		runs = append(runs, run)
+	} else {
+		success := true
+		runs, err = database.ListRuns(db.RunFilter{Success: &success, Limit: snippetRunLimit})
+		if err != nil {
+			return fmt.Errorf("failed to list runs: %w", err)
+		}
 	}
 
+	var records []session.RunRecord
+	for _, run := range runs {
+		results, err := database.GetResults(run.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get results: %w", err)
+		}
+		records = append(records, session.RunRecord{Run: run, Results: results})
+	}
+
+	s := &snippet.Snippet{
+		Title: "F.I.R.E. System Summary",
+		Specs: snippet.SystemSpecs(),
+		Runs:  records,
+	}
+	if exportRun == "" {
+		s.Runs = snippet.LatestRuns(records)
+	}
+
+	if exportOutput == "" {
+		fmt.Print(s.Render(format))
+		return nil
+	}
+	if err := os.WriteFile(exportOutput, []byte(s.Render(format)), 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", exportOutput, err)
+	}
+	fmt.Printf("Exported snippet to %s\n", exportOutput)
 	return nil
 }
 
@@ -176,11 +443,13 @@ func listCmd() *cobra.Command {
 		listLimit   int
 		listSuccess bool
 		listFailed  bool
+		listTags    []string
+		listSearch  string
 	)
 
 	cmd := &cobra.Command{
 		Use:   "list",
-		Short: "List test runs",
+		Short: i18n.T("cmd.list"),
 		Long: `List test runs from the database.
 
 Examples:
@@ -194,19 +463,34 @@ Examples:
   bench list --failed
 
   # List last 10 runs
-  bench list --limit 10`,
+  bench list --limit 10
+
+  # List the runs of a customer's retail build
+  bench list --tag customer=acme --tag build=retail
+
+  # List runs with a tag, whatever its value
+  bench list --tag rma
+
+  # List runs whose tags, notes or error mention the PSU
+  bench list --search psu`,
 		RunE: func(_ *cobra.Command, _ []string) error {
 			// Open database
-			dbPath := getDBPath()
-			database, err := db.Open(dbPath)
+			database, err := openDatabase()
 			if err != nil {
-				return fmt.Errorf("failed to open database: %w", err)
+				return i18n.Errorf("error.open_database", err)
 			}
 			defer func() { _ = database.Close() }()
 
+			tags, err := db.ParseTags(listTags)
+			if err != nil {
+				return err
+			}
+
 			// Build filter
 			filter := db.RunFilter{
 				Plugin: listPlugin,
+				Tags:   tags,
+				Search: listSearch,
 				Limit:  listLimit,
 			}
 
@@ -221,41 +505,45 @@ Examples:
 			// Get runs
 			runs, err := database.ListRuns(filter)
 			if err != nil {
-				return fmt.Errorf("failed to list runs: %w", err)
+				return i18n.Errorf("error.list_runs", err)
 			}
 
 			if len(runs) == 0 {
-				fmt.Println("No runs found")
+				fmt.Println(i18n.T("list.no_runs"))
 				return nil
 			}
 
 			// Display runs
-			fmt.Printf("%-6s %-15s %-20s %-20s %-10s %-8s\n",
-				"ID", "Plugin", "Start Time", "End Time", "Duration", "Status")
-			fmt.Println(strings.Repeat("-", 80))
+			fmt.Printf("%-6s %-15s %-20s %-20s %-10s %-11s %s\n",
+				i18n.T("column.id"), i18n.T("column.plugin"), i18n.T("column.start_time"),
+				i18n.T("column.end_time"), i18n.T("column.duration"), i18n.T("column.status"), i18n.T("column.tags"))
+			fmt.Println(strings.Repeat("-", 100))
 
 			for _, run := range runs {
-				endTime := "running"
+				endTime := i18n.T("status.running_lower")
 				duration := "-"
-				status := "running"
+				status := i18n.T("status.running_lower")
 
 				if run.EndTime != nil {
 					endTime = run.EndTime.Format("2006-01-02 15:04:05")
 					duration = fmt.Sprintf("%.1fs", run.Duration().Seconds())
 					if run.Success {
-						status = "success"
+						status = i18n.T("status.success_lower")
+					} else if run.Interrupted {
+						status = i18n.T("status.interrupted_lower")
 					} else {
-						status = "failed"
+						status = i18n.T("status.failed_lower")
 					}
 				}
 
-				fmt.Printf("%-6d %-15s %-20s %-20s %-10s %-8s\n",
+				fmt.Printf("%-6d %-15s %-20s %-20s %-10s %-11s %s\n",
 					run.ID,
 					run.Plugin,
 					run.StartTime.Format("2006-01-02 15:04:05"),
 					endTime,
 					duration,
 					status,
+					db.FormatTags(run.Tags),
 				)
 			}
 
@@ -267,6 +555,8 @@ Examples:
 	cmd.Flags().IntVarP(&listLimit, "limit", "n", 50, "Maximum number of runs to show")
 	cmd.Flags().BoolVar(&listSuccess, "success", false, "Show only successful runs")
 	cmd.Flags().BoolVar(&listFailed, "failed", false, "Show only failed runs")
+	cmd.Flags().StringArrayVar(&listTags, "tag", nil, "Show only runs tagged name=value, or with tag name whatever its value (repeatable)")
+	cmd.Flags().StringVar(&listSearch, "search", "", "Show only runs whose tags, notes or error contain this text")
 
 	return cmd
 }
@@ -274,66 +564,69 @@ Examples:
 // Helper command to show run details
 func showCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "show [run-id]",
-		Short: "Show detailed run information",
+		Use:   "show [run-id|uuid]",
+		Short: i18n.T("cmd.show"),
 		Long: `Show detailed information about a specific test run.
 
 Examples:
   # Show run details
   bench show 42
 
+  # Show run by UUID
+  bench show 0190f4c2-8a6e-7b3d-9c1a-2f4e6d8b0a1c
+
   # Show run with full output
   bench show 42 -v`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// Parse run ID
-			runID, err := strconv.ParseInt(args[0], 10, 64)
-			if err != nil {
-				return fmt.Errorf("invalid run ID: %s", args[0])
-			}
-
 			// Open database
-			dbPath := getDBPath()
-			database, err := db.Open(dbPath)
+			database, err := openDatabase()
 			if err != nil {
-				return fmt.Errorf("failed to open database: %w", err)
+				return i18n.Errorf("error.open_database", err)
 			}
 			defer func() { _ = database.Close() }()
 
 			// Get run
-			run, err := database.GetRun(runID)
+			run, err := database.ResolveRun(args[0])
 			if err != nil {
-				return fmt.Errorf("run %d not found", runID)
+				return i18n.Errorf("error.run_not_found", args[0])
 			}
 
 			// Get results
-			results, err := database.GetResults(runID)
+			results, err := database.GetResults(run.ID)
 			if err != nil {
-				return fmt.Errorf("failed to get results: %w", err)
+				return i18n.Errorf("error.get_results", err)
 			}
 
 			// Display run information
-			fmt.Printf("Run ID: %d\n", run.ID)
-			fmt.Printf("Plugin: %s\n", run.Plugin)
-			fmt.Printf("Start Time: %s\n", run.StartTime.Format("2006-01-02 15:04:05"))
+			fmt.Println(i18n.T("show.run_id", run.ID))
+			fmt.Println(i18n.T("show.uuid", run.UUID))
+			fmt.Println(i18n.T("show.plugin", run.Plugin))
+			if run.Environment != "" {
+				fmt.Println(i18n.T("show.environment", run.Environment))
+			}
+			if len(run.Tags) > 0 {
+				fmt.Println(i18n.T("show.tags", db.FormatTags(run.Tags)))
+			}
+			fmt.Println(i18n.T("show.start_time", run.StartTime.Format("2006-01-02 15:04:05")))
 
 			if run.EndTime != nil {
-				fmt.Printf("End Time: %s\n", run.EndTime.Format("2006-01-02 15:04:05"))
-				fmt.Printf("Duration: %.2f seconds\n", run.Duration().Seconds())
+				fmt.Println(i18n.T("show.end_time", run.EndTime.Format("2006-01-02 15:04:05")))
+				fmt.Println(i18n.T("show.duration", run.Duration().Seconds()))
 			} else {
-				fmt.Printf("End Time: (still running)\n")
+				fmt.Println(i18n.T("show.still_running"))
 			}
 
-			fmt.Printf("Success: %v\n", run.Success)
-			fmt.Printf("Exit Code: %d\n", run.ExitCode)
+			fmt.Println(i18n.T("show.success", run.Success))
+			fmt.Println(i18n.T("show.exit_code", run.ExitCode))
 
 			if run.Error != "" {
-				fmt.Printf("Error: %s\n", run.Error)
+				fmt.Println(i18n.T("show.error", run.Error))
 			}
 
 			// Display parameters
 			if len(run.Params) > 0 {
-				fmt.Printf("\nParameters:\n")
+				fmt.Printf("\n%s\n", i18n.T("show.parameters"))
 				for k, v := range run.Params {
 					fmt.Printf("  %s: %v\n", k, v)
 				}
@@ -341,24 +634,59 @@ Examples:
 
 			// Display results
 			if len(results) > 0 {
-				fmt.Printf("\nResults:\n")
+				fmt.Printf("\n%s\n", i18n.T("show.results"))
+				prefs := displayUnits()
 				for _, result := range results {
-					if result.Unit != "" {
-						fmt.Printf("  %s: %.6f %s\n", result.Metric, result.Value, result.Unit)
+					if value, unit := prefs.Convert(result.Value, result.Unit); unit != "" {
+						fmt.Printf("  %s: %.6f %s\n", result.Metric, value, unit)
 					} else {
 						fmt.Printf("  %s: %.6f\n", result.Metric, result.Value)
 					}
 				}
 			}
 
+			if notes, err := database.RunNotes(run.ID); err == nil && len(notes) > 0 {
+				fmt.Printf("\n%s\n", i18n.T("show.notes"))
+				for _, n := range notes {
+					fmt.Printf("  %s  %s\n", n.CreatedAt.Local().Format("2006-01-02 15:04:05"), n.Text)
+				}
+			}
+
+			// Power events during the run help explain failures
+			if annotations, err := database.RunAnnotations(run); err == nil && len(annotations) > 0 {
+				fmt.Printf("\n%s\n", i18n.T("show.power_events"))
+				for _, a := range annotations {
+					fmt.Printf("  %s  %s\n", a.Time.Local().Format("2006-01-02 15:04:05"), a.Message)
+				}
+			}
+
+			// SMART counters that changed since the previous run, from the
+			// snapshots bench agent serve saves
+			if drives, err := smart.RunChanges(database, run); err == nil && len(drives) > 0 {
+				fmt.Printf("\n%s\n", i18n.T("show.drive_health"))
+				for _, d := range drives {
+					fmt.Printf("  %s  %s - %s\n", d.Drive, d.From.Local().Format("2006-01-02 15:04"), d.To.Local().Format("2006-01-02 15:04"))
+					if len(d.Changes) == 0 {
+						fmt.Printf("     %s\n", i18n.T("show.drive_unchanged"))
+					}
+					for _, c := range d.Changes {
+						mark := " "
+						if c.Worse {
+							mark = "!"
+						}
+						fmt.Printf("   %s %s\n", mark, c)
+					}
+				}
+			}
+
 			// Display output if verbose
 			verbose, _ := cmd.Flags().GetBool("verbose")
 			if verbose {
 				if run.Stdout != "" {
-					fmt.Printf("\nStandard Output:\n%s\n", run.Stdout)
+					fmt.Printf("\n%s\n%s\n", i18n.T("show.stdout"), run.Stdout)
 				}
 				if run.Stderr != "" {
-					fmt.Printf("\nStandard Error:\n%s\n", run.Stderr)
+					fmt.Printf("\n%s\n%s\n", i18n.T("show.stderr"), run.Stderr)
 				}
 			}
 
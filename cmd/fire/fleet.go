@@ -0,0 +1,565 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/agent"
+	"github.com/mscrnt/project_fire/pkg/changelog"
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/fleet"
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/mscrnt/project_fire/pkg/testplan"
+	"github.com/spf13/cobra"
+)
+
+// fleetFlags are shared by the fleet subcommands
+type fleetFlags struct {
+	path     string
+	certFile string
+	keyFile  string
+	caFile   string
+	agents   []string
+}
+
+// load reads the registry, with the certificate flags and FIRE_CLIENT_*
+// variables taking precedence over the stored certificate
+func (f *fleetFlags) load() (*fleet.Registry, error) {
+	r, err := fleet.Load(f.path)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range []struct {
+		dst       *string
+		flag, env string
+	}{
+		{&r.CertFile, f.certFile, "FIRE_CLIENT_CERT"},
+		{&r.KeyFile, f.keyFile, "FIRE_CLIENT_KEY"},
+		{&r.CAFile, f.caFile, "FIRE_CLIENT_CA"},
+	} {
+		if o.flag != "" {
+			*o.dst = o.flag
+		} else if v := os.Getenv(o.env); v != "" {
+			*o.dst = v
+		}
+	}
+	return r, nil
+}
+
+func fleetCmd() *cobra.Command {
+	flags := &fleetFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "fleet",
+		Short: i18n.T("cmd.fleet"),
+		Long: `Run the same test plan on many machines at once through their agents
+(bench agent serve), and collect every machine's results into the local
+database for one pass/fail summary and a combined report.
+
+A plan is a JSON file naming the tests to run in order:
+
+  {
+    "name": "burn-in",
+    "steps": [
+      {"plugin": "cpu", "duration": "2h", "threads": 0},
+      {"plugin": "memory", "duration": "1h", "config": {"size_mb": "4096"}},
+      {"plugin": "disk", "duration": "30m"}
+    ]
+  }
+
+Agents are registered once with a name and address. The client certificate
+given to 'bench fleet add' (or FIRE_CLIENT_CERT, FIRE_CLIENT_KEY and
+FIRE_CLIENT_CA) is kept for the whole fleet.
+
+Examples:
+  # Register the machines of a batch
+  bench fleet add rig-01 10.0.0.11 --cert client.pem --key client.key --ca ca.pem
+  bench fleet add rig-02 10.0.0.12:2223
+
+  # Start the plan everywhere, wait for it and write the combined report
+  bench fleet run burn-in.yaml --wait --report batch-42.html
+
+  # Check progress, then collect the results later
+  bench fleet status
+  bench fleet collect --report batch-42.html
+
+  # Summarise the last month across the machines' own databases
+  bench fleet report rig-01.db rig-02.db rig-03.db --since 30d`,
+	}
+
+	cmd.PersistentFlags().StringVar(&flags.path, "fleet-file", fleet.DefaultPath(), "Registry of agents")
+	cmd.PersistentFlags().StringVar(&flags.certFile, "cert", "", "Client certificate file")
+	cmd.PersistentFlags().StringVar(&flags.keyFile, "key", "", "Client private key file")
+	cmd.PersistentFlags().StringVar(&flags.caFile, "ca", "", "CA certificate file for agent verification")
+
+	cmd.AddCommand(fleetAddCmd(flags))
+	cmd.AddCommand(fleetRemoveCmd(flags))
+	cmd.AddCommand(fleetListCmd(flags))
+	cmd.AddCommand(fleetRunCmd(flags))
+	cmd.AddCommand(fleetStatusCmd(flags))
+	cmd.AddCommand(fleetCollectCmd(flags))
+	cmd.AddCommand(fleetReportCmd())
+
+	return cmd
+}
+
+func fleetAddCmd(flags *fleetFlags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "add <name> <host[:port]>",
+		Short: "Register an agent",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(_ *cobra.Command, args []string) error {
+			host, port := args[1], 2223
+			if h, p, err := net.SplitHostPort(args[1]); err == nil {
+				n, err := strconv.Atoi(p)
+				if err != nil || n <= 0 || n > 65535 {
+					return fmt.Errorf("invalid port %q", p)
+				}
+				host, port = h, n
+			}
+
+			r, err := flags.load()
+			if err != nil {
+				return err
+			}
+			r.Add(fleet.Member{Name: args[0], Host: host, Port: port})
+			if err := fleet.Save(flags.path, r); err != nil {
+				return err
+			}
+			fmt.Println(i18n.T("fleet.registered", args[0], host, port, len(r.Members)))
+			return nil
+		},
+	}
+}
+
+func fleetRemoveCmd(flags *fleetFlags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <name>",
+		Short: "Unregister an agent",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			r, err := fleet.Load(flags.path)
+			if err != nil {
+				return err
+			}
+			if !r.Remove(args[0]) {
+				return fmt.Errorf("agent %q is not registered", args[0])
+			}
+			return fleet.Save(flags.path, r)
+		},
+	}
+}
+
+func fleetListCmd(flags *fleetFlags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the agents and what they are running",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			r, err := flags.load()
+			if err != nil {
+				return err
+			}
+			if len(r.Members) == 0 {
+				fmt.Println(i18n.T("fleet.no_agents"))
+				return nil
+			}
+
+			outcomes := fleet.Status(r.Members, func(m fleet.Member) (fleet.Agent, error) { return r.Connect(m) })
+			fmt.Printf("%-16s %-24s %-14s %s\n", i18n.T("table.name"), i18n.T("table.address"), i18n.T("table.status"), i18n.T("table.plan"))
+			fmt.Println(strings.Repeat("-", 70))
+			for _, o := range outcomes {
+				plan := ""
+				switch {
+				case o.Err != nil:
+					plan = o.Err.Error()
+				case o.Job != nil:
+					plan = o.Job.Plan.Name
+				}
+				fmt.Printf("%-16s %-24s %-14s %s\n", o.Member.Name, o.Member.Address(), o.Status(), plan)
+			}
+			return nil
+		},
+	}
+}
+
+func fleetRunCmd(flags *fleetFlags) *cobra.Command {
+	var (
+		wait     bool
+		interval time.Duration
+		report   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "run <plan.yaml>",
+		Short: "Start a test plan on the agents",
+		Long: `Start a test plan, in the YAML or JSON format of bench plan run, on the
+agents. The agents run it unattended, so the plan cannot have prompt
+stages or pin the fans.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			plan, err := testplan.Load(args[0])
+			if err != nil {
+				return err
+			}
+			if err := agent.CheckPlan(plan); err != nil {
+				return fmt.Errorf("%s: %w", args[0], err)
+			}
+
+			r, err := flags.load()
+			if err != nil {
+				return err
+			}
+			members, err := r.Select(flags.agents)
+			if err != nil {
+				return err
+			}
+			connect := func(m fleet.Member) (fleet.Agent, error) { return r.Connect(m) }
+
+			outcomes := fleet.Push(members, connect, plan)
+			started := 0
+			for _, o := range outcomes {
+				if o.Err != nil {
+					fmt.Fprintf(os.Stderr, "%s: %v\n", o.Member.Name, o.Err)
+					continue
+				}
+				started++
+				fmt.Println(i18n.T("fleet.job_started", o.Member.Name, o.Job.ID))
+			}
+			fmt.Println(i18n.T("fleet.plan_started", plan.Name, started, len(members)))
+			if !wait {
+				return nil
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			fleet.Wait(ctx, members, connect, interval, func(outcomes []fleet.Outcome) {
+				parts := make([]string, len(outcomes))
+				for i, o := range outcomes {
+					parts[i] = o.Member.Name + " " + o.Status()
+				}
+				fmt.Printf("[%s] %s\n", time.Now().Format("15:04:05"), strings.Join(parts, ", "))
+			})
+			if ctx.Err() != nil {
+				fmt.Println(i18n.T("fleet.stopped_waiting"))
+				return nil
+			}
+			return collectFleet(members, connect, report)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&flags.agents, "agents", nil, "Agents to use (default all)")
+	cmd.Flags().BoolVar(&wait, "wait", false, "Wait for the plan to finish, then collect the results")
+	cmd.Flags().DurationVar(&interval, "poll", 30*time.Second, "How often to check progress while waiting")
+	cmd.Flags().StringVar(&report, "report", "", "Write the combined HTML report to this file after collecting")
+
+	return cmd
+}
+
+func fleetStatusCmd(flags *fleetFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the progress of the plan on each agent",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			r, err := flags.load()
+			if err != nil {
+				return err
+			}
+			members, err := r.Select(flags.agents)
+			if err != nil {
+				return err
+			}
+			outcomes := fleet.Status(members, func(m fleet.Member) (fleet.Agent, error) { return r.Connect(m) })
+			printOutcomes(outcomes)
+			return nil
+		},
+	}
+	cmd.Flags().StringSliceVar(&flags.agents, "agents", nil, "Agents to check (default all)")
+	return cmd
+}
+
+func fleetCollectCmd(flags *fleetFlags) *cobra.Command {
+	var report string
+
+	cmd := &cobra.Command{
+		Use:   "collect",
+		Short: "Import the agents' results and summarise pass/fail",
+		Long: `Import the runs of each agent's last plan into the local database, print
+the pass/fail summary and optionally write the combined HTML report. Runs
+are matched by UUID, so collecting again only adds runs finished since.`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			r, err := flags.load()
+			if err != nil {
+				return err
+			}
+			members, err := r.Select(flags.agents)
+			if err != nil {
+				return err
+			}
+			return collectFleet(members, func(m fleet.Member) (fleet.Agent, error) { return r.Connect(m) }, report)
+		},
+	}
+	cmd.Flags().StringSliceVar(&flags.agents, "agents", nil, "Agents to collect from (default all)")
+	cmd.Flags().StringVar(&report, "report", "", "Write the combined HTML report to this file")
+	return cmd
+}
+
+// collectFleet imports the agents' runs, prints the summary and writes the
+// report when asked
+func collectFleet(members []fleet.Member, connect fleet.ConnectFunc, reportPath string) error {
+	database, err := openDatabase()
+	if err != nil {
+		return i18n.Errorf("error.open_database", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	outcomes := fleet.Collect(database, members, connect)
+	printOutcomes(outcomes)
+
+	report, err := fleet.NewReport(database, outcomes)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\n%s\n", i18n.T("fleet.totals", report.Passed, report.Failed))
+
+	if reportPath != "" {
+		if err := writeFleetReport(report, reportPath); err != nil {
+			return err
+		}
+		fmt.Println(i18n.T("report.written", reportPath))
+	}
+	return nil
+}
+
+func writeFleetReport(report *fleet.Report, path string) error {
+	f, err := os.Create(path) // #nosec G304 -- path given by the user
+	if err != nil {
+		return err
+	}
+	if err := report.WriteHTML(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func fleetReportCmd() *cobra.Command {
+	var (
+		since    string
+		plugin   string
+		jsonOut  bool
+		htmlPath string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "report [database...]",
+		Short: "Summarise pass rates, failures and thermals across machines",
+		Long: `Combine the runs of many machines into one summary: pass rates overall and
+per test, the most common failures, average temperatures by hardware model,
+and the machines that stand out from the rest.
+
+Each argument is a machine's SQLite database, or a postgres:// DSN of a
+central results server that machines sync to. Without arguments the local
+database is read, which holds the runs gathered by bench fleet collect.
+
+Runs name the machine and hardware model they were recorded on. Older runs
+are attributed to the agent they were collected from, or to the database
+file they were read from.
+
+A machine is an outlier when, over at least 3 runs, its pass rate is more
+than 25 points below the fleet's, or when it runs more than 8 °C hotter
+than the median of at least 3 machines of the same model.
+
+Examples:
+  # Summarise the runs collected from the agents
+  bench fleet report
+
+  # Combine the databases copied from each machine over the last month
+  bench fleet report rig-01.db rig-02.db rig-03.db --since 30d
+
+  # Read a central server and write an HTML page for management
+  bench fleet report postgres://fire@results.lab/fire --html fleet.html`,
+		RunE: func(_ *cobra.Command, args []string) error {
+			filter := db.RunFilter{Plugin: plugin}
+			if since != "" {
+				duration, err := parseDuration(since)
+				if err != nil {
+					return i18n.Errorf("error.invalid_duration", err)
+				}
+				from := time.Now().Add(-duration)
+				filter.StartTime = &from
+			}
+
+			var records []fleet.Record
+			if len(args) == 0 {
+				database, err := openDatabase()
+				if err != nil {
+					return i18n.Errorf("error.open_database", err)
+				}
+				records, err = fleet.LoadRecords(database, filter, changelog.Machine())
+				_ = database.Close()
+				if err != nil {
+					return err
+				}
+			}
+			for _, arg := range args {
+				loaded, err := loadFleetSource(arg, filter)
+				if err != nil {
+					return fmt.Errorf("%s: %w", arg, err)
+				}
+				records = append(records, loaded...)
+			}
+			if len(records) == 0 {
+				fmt.Println(i18n.T("list.no_runs"))
+				return nil
+			}
+
+			summary := fleet.Summarize(records)
+			if htmlPath != "" {
+				if err := writeFleetSummary(summary, htmlPath); err != nil {
+					return err
+				}
+			}
+			if jsonOut {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(summary)
+			}
+			printFleetSummary(summary)
+			if htmlPath != "" {
+				fmt.Printf("\n%s\n", i18n.T("report.written", htmlPath))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "", "Only include runs since duration (e.g., 24h, 30d)")
+	cmd.Flags().StringVarP(&plugin, "plugin", "p", "", "Only include runs of this test")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Print the summary as JSON")
+	cmd.Flags().StringVar(&htmlPath, "html", "", "Write the summary as an HTML page to this file")
+
+	return cmd
+}
+
+// loadFleetSource reads the runs of one machine database or central server.
+// Runs in a machine's database that name no machine are attributed to the
+// file's name.
+func loadFleetSource(source string, filter db.RunFilter) ([]fleet.Record, error) {
+	cfg := db.Config{Driver: db.DriverSQLite, Path: source}
+	machine := strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+	if strings.HasPrefix(source, "postgres://") || strings.HasPrefix(source, "postgresql://") {
+		cfg = db.Config{Driver: db.DriverPostgres, DSN: source}
+		machine = "unattributed"
+	} else if _, err := os.Stat(source); err != nil {
+		return nil, err // Opening would create an empty database
+	}
+
+	database, err := db.OpenConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = database.Close() }()
+	return fleet.LoadRecords(database, filter, machine)
+}
+
+func writeFleetSummary(summary *fleet.Summary, path string) error {
+	f, err := os.Create(path) // #nosec G304 -- path given by the user
+	if err != nil {
+		return err
+	}
+	if err := summary.WriteHTML(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// printFleetSummary prints the summary as tables
+func printFleetSummary(s *fleet.Summary) {
+	fmt.Println(i18n.T("fleet.summary",
+		s.Runs, len(s.Machines), s.From.Format("2006-01-02"), s.To.Format("2006-01-02"), s.Passed, s.Failed(), s.PassRate()))
+
+	fmt.Printf("\n%-16s %-8s %-8s %s\n", i18n.T("table.test"), i18n.T("table.runs"), i18n.T("table.failed"), i18n.T("table.pass_rate"))
+	fmt.Println(strings.Repeat("-", 50))
+	for _, p := range s.Plugins {
+		fmt.Printf("%-16s %-8d %-8d %.1f%%\n", p.Plugin, p.Runs, p.Failed(), p.PassRate())
+	}
+
+	if len(s.Failures) > 0 {
+		fmt.Printf("\n%-12s %-6s %-40s %s\n", i18n.T("table.test"), i18n.T("table.runs"), i18n.T("table.failure"), i18n.T("table.machines"))
+		fmt.Println(strings.Repeat("-", 90))
+		for _, f := range s.Failures {
+			fmt.Printf("%-12s %-6d %-40s %s\n", f.Plugin, f.Count, f.Error, strings.Join(f.Machines, ", "))
+		}
+	}
+
+	fmt.Printf("\n%-32s %-9s %-6s %-10s %s\n", i18n.T("table.model"), i18n.T("table.machines"), i18n.T("table.runs"), i18n.T("table.pass_rate"), i18n.T("table.average_thermals"))
+	fmt.Println(strings.Repeat("-", 90))
+	for _, m := range s.Models {
+		fmt.Printf("%-32s %-9d %-6d %-10s %s\n", m.Model, m.Machines, m.Runs, fmt.Sprintf("%.1f%%", m.PassRate()), describeThermals(m.Thermals))
+	}
+
+	fmt.Printf("\n%-16s %-32s %-6s %-7s %s\n", i18n.T("table.machine"), i18n.T("table.model"), i18n.T("table.runs"), i18n.T("table.failed"), i18n.T("table.average_thermals"))
+	fmt.Println(strings.Repeat("-", 90))
+	for _, m := range s.Machines {
+		fmt.Printf("%-16s %-32s %-6d %-7d %s\n", m.Name, m.Model, m.Runs, m.Failed(), describeThermals(m.Thermals))
+	}
+
+	if len(s.Outliers) == 0 {
+		fmt.Printf("\n%s\n", i18n.T("fleet.no_outliers"))
+		return
+	}
+	fmt.Printf("\n%s\n", i18n.T("fleet.outliers"))
+	for _, o := range s.Outliers {
+		fmt.Printf("  %s (%s): %s\n", o.Machine, o.Model, o.Reason)
+	}
+}
+
+// describeThermals lists average temperatures, e.g. "cpu_temp_peak 78.5 °C"
+func describeThermals(thermals []fleet.Thermal) string {
+	prefs := displayUnits()
+	parts := make([]string, len(thermals))
+	for i, t := range thermals {
+		parts[i] = t.Metric + " " + prefs.Format(t.Avg, "°C", "%.1f ")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// printOutcomes lists each agent's plan state and its runs
+func printOutcomes(outcomes []fleet.Outcome) {
+	fmt.Printf("%-16s %-14s %-8s %s\n", i18n.T("table.agent"), i18n.T("table.status"), i18n.T("table.runs"), i18n.T("table.details"))
+	fmt.Println(strings.Repeat("-", 70))
+	for _, o := range outcomes {
+		runs, details := "-", ""
+		switch {
+		case o.Err != nil:
+			details = o.Err.Error()
+		case o.Job != nil:
+			runs = strconv.Itoa(len(o.Job.Runs))
+			details = describeFleetRuns(o.Job.Runs)
+			if o.Job.Error != "" {
+				details = o.Job.Error
+			}
+		}
+		fmt.Printf("%-16s %-14s %-8s %s\n", o.Member.Name, o.Status(), runs, details)
+	}
+}
+
+// describeFleetRuns lists the failed runs of a job, e.g. "memory failed: ..."
+func describeFleetRuns(runs []*db.Run) string {
+	var failed []string
+	for _, run := range runs {
+		if run.EndTime != nil && !run.Success {
+			failed = append(failed, fmt.Sprintf("%s failed: %s", run.Plugin, run.Error))
+		}
+	}
+	return strings.Join(failed, "; ")
+}
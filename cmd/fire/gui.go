@@ -7,13 +7,15 @@ import (
 	"path/filepath"
 	"runtime"
 
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/mscrnt/project_fire/pkg/safeexec"
 	"github.com/spf13/cobra"
 )
 
 func guiCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "gui",
-		Short: "Launch the graphical user interface",
+		Short: i18n.T("cmd.gui"),
 		Long: `Launch the F.I.R.E. graphical user interface.
 
 The GUI provides:
@@ -25,7 +27,7 @@ The GUI provides:
 - Certificate management
 
 Note: The GUI requires a graphical environment (X11, Wayland, or Windows/macOS desktop).`,
-		RunE: func(_ *cobra.Command, _ []string) error {
+		RunE: func(cmd *cobra.Command, _ []string) error {
 			// Check if we're in a GUI environment
 			if !hasGUIEnvironment() {
 				return fmt.Errorf("GUI environment not detected. The GUI requires a graphical desktop environment")
@@ -44,14 +46,14 @@ Note: The GUI requires a graphical environment (X11, Wayland, or Windows/macOS d
 				dir := filepath.Dir(execPath)
 				guiPath := filepath.Join(dir, guiBinary)
 				if _, err := os.Stat(guiPath); err == nil {
-					return runGUI(guiPath)
+					return runGUI(guiPath, logArgs(cmd))
 				}
 			}
 
 			// Check in PATH
 			guiPath, err := exec.LookPath(guiBinary)
 			if err == nil {
-				return runGUI(guiPath)
+				return runGUI(guiPath, logArgs(cmd))
 			}
 
 			// GUI not found
@@ -79,9 +81,21 @@ func hasGUIEnvironment() bool {
 	}
 }
 
+// logArgs passes the logging flags given to bench on to the GUI
+func logArgs(cmd *cobra.Command) []string {
+	var args []string
+	if cmd.Flags().Changed("log-level") {
+		args = append(args, "-log-level", logLevel)
+	}
+	if cmd.Flags().Changed("log-file") {
+		args = append(args, "-log-file", logFile)
+	}
+	return args
+}
+
 // runGUI launches the GUI binary
-func runGUI(path string) error {
-	cmd := exec.Command(path)
+func runGUI(path string, args []string) error {
+	cmd := safeexec.Command(path, args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
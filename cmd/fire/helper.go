@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/helper"
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/spf13/cobra"
+)
+
+func helperCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "helper",
+		Short: i18n.T("cmd.helper"),
+		Long: `Manage the F.I.R.E. privileged helper.
+
+The helper runs as root/Administrator (typically as a system service) and
+exposes a small read-only API over a local socket for SMART data, DMI/SPD
+tables and CPU thermal registers. The GUI and CLI can then run without
+elevated privileges.`,
+	}
+
+	cmd.AddCommand(helperServeCmd())
+	cmd.AddCommand(helperStatusCmd())
+
+	return cmd
+}
+
+func helperServeCmd() *cobra.Command {
+	var (
+		socketPath string
+		group      string
+		mode       uint32
+		logFile    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run the privileged helper",
+		Long: `Run the privileged helper in the foreground.
+
+Examples:
+  # Allow members of the "fire" group to use the helper
+  sudo bench helper serve --group fire
+
+  # Custom socket path
+  sudo bench helper serve --socket /run/fire/helper.sock --log /var/log/fire-helper.log`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			config := helper.DefaultConfig()
+			if socketPath != "" {
+				config.SocketPath = socketPath
+			}
+			config.Group = group
+			config.SocketMode = os.FileMode(mode)
+			config.LogFile = logFile
+
+			server, err := helper.NewServer(config)
+			if err != nil {
+				return fmt.Errorf("failed to create helper: %w", err)
+			}
+
+			// Installed as a Windows service the helper answers the service
+			// control manager instead of waiting for signals
+			if runningAsService() {
+				return runHelperService(server)
+			}
+
+			// Setup signal handling
+			sigChan := make(chan os.Signal, 1)
+			signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+			errChan := make(chan error, 1)
+			go func() {
+				errChan <- server.Start()
+			}()
+
+			fmt.Println(i18n.T("helper.listening", config.SocketPath))
+			fmt.Println(i18n.T("prompt.stop"))
+
+			select {
+			case sig := <-sigChan:
+				fmt.Printf("\nReceived signal: %v\n", sig)
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				if err := server.Shutdown(ctx); err != nil {
+					return fmt.Errorf("shutdown error: %w", err)
+				}
+				return nil
+
+			case err := <-errChan:
+				return err
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&socketPath, "socket", "", "Socket path (default: platform specific, or FIRE_HELPER_SOCKET)")
+	cmd.Flags().StringVar(&group, "group", "", "Group allowed to connect to the socket")
+	cmd.Flags().Uint32Var(&mode, "mode", 0o660, "Socket permissions")
+	cmd.Flags().StringVar(&logFile, "log", "", "Log file path (optional)")
+
+	return cmd
+}
+
+func helperStatusCmd() *cobra.Command {
+	var socketPath string
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Check whether the privileged helper is reachable",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			client := helper.NewClient(socketPath)
+			if !client.Available() {
+				return fmt.Errorf("helper not reachable at %s", client.SocketPath())
+			}
+			fmt.Printf("Helper is running at %s\n", client.SocketPath())
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&socketPath, "socket", "", "Socket path (default: platform specific, or FIRE_HELPER_SOCKET)")
+
+	return cmd
+}
@@ -0,0 +1,22 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/mscrnt/project_fire/pkg/helper"
+)
+
+// runningAsService reports whether the process was started by the Windows
+// service control manager. On other platforms the helper runs in the
+// foreground under systemd or launchd.
+func runningAsService() bool {
+	return false
+}
+
+// runHelperService is only used on Windows
+func runHelperService(_ *helper.Server) error {
+	return fmt.Errorf("service mode is only supported on Windows")
+}
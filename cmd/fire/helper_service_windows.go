@@ -0,0 +1,66 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/helper"
+	"golang.org/x/sys/windows/svc"
+)
+
+// helperServiceName is the service name registered by the installers
+const helperServiceName = "FIREHelper"
+
+// runningAsService reports whether the process was started by the Windows
+// service control manager
+func runningAsService() bool {
+	ok, err := svc.IsWindowsService()
+	return err == nil && ok
+}
+
+// runHelperService runs the helper under the service control manager until
+// the service is stopped
+func runHelperService(server *helper.Server) error {
+	return svc.Run(helperServiceName, &helperService{server: server})
+}
+
+// helperService adapts the helper server to the service control manager
+type helperService struct {
+	server *helper.Server
+}
+
+// Execute implements svc.Handler
+func (s *helperService) Execute(_ []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- s.server.Start()
+	}()
+
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case err := <-errChan:
+			if err != nil {
+				return true, 1
+			}
+			return false, 0
+
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				_ = s.server.Shutdown(ctx)
+				cancel()
+				return false, 0
+			}
+		}
+	}
+}
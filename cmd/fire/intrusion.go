@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/mscrnt/project_fire/pkg/intrusion"
+	"github.com/spf13/cobra"
+)
+
+func intrusionCmd() *cobra.Command {
+	var clearAlarms bool
+
+	cmd := &cobra.Command{
+		Use:   "intrusion",
+		Short: i18n.T("cmd.intrusion"),
+		Long: `Show the chassis intrusion alarms the Super I/O (through hwmon on Linux) and the
+BMC (through ipmitool) report, and clear them.
+
+To check the switch before shipping: remove the side panel and run
+'bench intrusion' to see the alarm set, refit the panel and run
+'bench intrusion --clear', which fails if the alarm sets again. Clearing needs
+root; alarms latched by a BMC are cleared from its interface.
+
+The command exits with an error while an alarm is set, so it can gate a
+build script.
+
+Examples:
+  # Show the alarms
+  bench intrusion
+
+  # Clear them once the case is closed
+  sudo bench intrusion --clear`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			sensors, err := intrusion.Read(context.Background())
+			if err != nil {
+				return err
+			}
+
+			if clearAlarms {
+				for i, s := range sensors {
+					if !s.Triggered {
+						continue
+					}
+					if err := intrusion.Clear(s); err != nil {
+						fmt.Printf("%s: %v\n", s.Name, err)
+						continue
+					}
+					sensors[i].Triggered = false
+					fmt.Printf("%s: alarm cleared\n", s.Name)
+				}
+			}
+
+			fmt.Printf("%-28s %-8s %s\n", "SENSOR", "SOURCE", "STATE")
+			fmt.Println(strings.Repeat("-", 50))
+			for _, s := range sensors {
+				fmt.Printf("%-28s %-8s %s\n", s.Name, s.Source, s.Status())
+			}
+
+			if open := intrusion.Triggered(sensors); len(open) > 0 {
+				return errors.New("chassis intrusion alarm is set")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&clearAlarms, "clear", false, "Clear set alarms and check they stay clear")
+
+	return cmd
+}
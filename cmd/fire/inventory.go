@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/mscrnt/project_fire/pkg/inventory"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func inventoryCmd() *cobra.Command {
+	var (
+		format string
+		runID  int64
+	)
+
+	cmd := &cobra.Command{
+		Use:   "inventory",
+		Short: i18n.T("cmd.inventory"),
+		Long: `Show the CPU, motherboard, memory modules, GPUs, storage devices and fans of
+this machine, collected the same way as the GUI's hardware list.
+
+A snapshot of the inventory is stored with every test run, so the hardware a
+run was tested on can be shown later with --run and appears in its report.
+The JSON output follows the inventory schema (bench schema show inventory).
+
+Examples:
+  # Show the hardware of this machine
+  bench inventory
+
+  # Save it for an asset database
+  bench inventory --format json > inventory.json
+
+  # Show the hardware run 42 was tested on
+  bench inventory --run 42 --format yaml`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if format != "table" && format != "json" && format != "yaml" {
+				return fmt.Errorf("unknown format %q (use table, json or yaml)", format)
+			}
+
+			var snapshot *inventory.Snapshot
+			if runID > 0 {
+				database, err := openDatabase()
+				if err != nil {
+					return i18n.Errorf("error.open_database", err)
+				}
+				defer func() { _ = database.Close() }()
+
+				snapshot, err = inventory.ForRun(database, runID)
+				if err != nil {
+					return err
+				}
+				if snapshot == nil {
+					return fmt.Errorf("no inventory recorded for run %d", runID)
+				}
+			} else {
+				snapshot = inventory.Take()
+			}
+
+			switch format {
+			case "json":
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(snapshot)
+			case "yaml":
+				enc := yaml.NewEncoder(os.Stdout)
+				enc.SetIndent(2)
+				if err := enc.Encode(snapshot); err != nil {
+					return err
+				}
+				return enc.Close()
+			}
+			printInventory(snapshot)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "table", "Output format: table, json or yaml")
+	cmd.Flags().Int64Var(&runID, "run", 0, "Show the inventory recorded for this run instead of this machine")
+
+	return cmd
+}
+
+// printInventory lists the components with their details indented below
+func printInventory(s *inventory.Snapshot) {
+	fmt.Printf("Machine: %s\n", s.Machine)
+	if s.Model != "" {
+		fmt.Printf("Model:   %s\n", s.Model)
+	}
+	fmt.Printf("Taken:   %s\n", s.TakenAt.Local().Format("2006-01-02 15:04:05"))
+	for _, key := range sortedKeys(s.System) {
+		fmt.Printf("  %-20s %s\n", key, s.System[key])
+	}
+
+	if len(s.Components) == 0 {
+		fmt.Println("\nNo components found")
+		return
+	}
+	fmt.Printf("\n%-12s %s\n", "TYPE", "NAME")
+	fmt.Println(strings.Repeat("-", 80))
+	for _, c := range s.Components {
+		fmt.Printf("%-12s %s\n", c.Type, c.Name)
+		for _, key := range sortedKeys(c.Details) {
+			// Lane and link warnings span several lines
+			value := strings.ReplaceAll(c.Details[key], "\n", "\n"+strings.Repeat(" ", 23))
+			fmt.Printf("  %-20s %s\n", key, value)
+		}
+	}
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
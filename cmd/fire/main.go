@@ -2,10 +2,14 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"runtime"
+	"strings"
 
 	"github.com/mscrnt/project_fire/internal/version"
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/mscrnt/project_fire/pkg/logging"
 	"github.com/mscrnt/project_fire/pkg/telemetry"
 	"github.com/spf13/cobra"
 )
@@ -19,21 +23,45 @@ var (
 	// Telemetry flags
 	telemetryEnabled  bool
 	telemetryEndpoint string
+
+	// Logging flags, passed on to the GUI by bench gui
+	logLevel  string
+	logFile   string
+	logCloser io.Closer
 )
 
 func main() {
+	// Help texts are translated when the commands are built
+	setupLanguage()
+	setupSensors()
+
 	rootCmd := &cobra.Command{
-		Use:   "bench",
-		Short: "F.I.R.E. - Full Intensity Rigorous Evaluation",
-		Long: `F.I.R.E. is a comprehensive PC test bench for burn-in tests, 
-endurance stress testing, and benchmark analysis.`,
+		Use:     "bench",
+		Short:   "F.I.R.E. - Full Intensity Rigorous Evaluation",
+		Long:    i18n.T("help.root"),
 		Version: version.GetVersion(buildVersion, buildCommit, buildTime),
-		PersistentPreRun: func(_ *cobra.Command, _ []string) {
+		PersistentPreRun: func(cmd *cobra.Command, _ []string) {
+			closer, err := logging.Configure(logLevel, logFile, os.Stderr)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			} else {
+				logCloser = closer
+			}
+
 			// Set app version for telemetry
 			telemetry.SetAppVersion(version.GetVersion(buildVersion, buildCommit, buildTime))
 
-			// Initialize telemetry based on flags
-			telemetry.Initialize(telemetryEndpoint, "", telemetryEnabled)
+			// Initialize telemetry based on flags, falling back to the settings
+			enabled, endpoint := telemetryEnabled, telemetryEndpoint
+			if settings, err := loadSettings(); err == nil {
+				if !cmd.Flags().Changed("telemetry") && settings.Telemetry.Enabled != nil {
+					enabled = *settings.Telemetry.Enabled
+				}
+				if endpoint == "" {
+					endpoint = settings.Telemetry.Endpoint
+				}
+			}
+			telemetry.Initialize(endpoint, "", enabled)
 
 			// Set up panic handler
 			defer func() {
@@ -49,6 +77,9 @@ endurance stress testing, and benchmark analysis.`,
 		PersistentPostRun: func(_ *cobra.Command, _ []string) {
 			// Ensure telemetry is flushed on normal exit
 			telemetry.Shutdown()
+			if logCloser != nil {
+				_ = logCloser.Close()
+			}
 		},
 	}
 
@@ -56,6 +87,10 @@ endurance stress testing, and benchmark analysis.`,
 	rootCmd.PersistentFlags().BoolVar(&telemetryEnabled, "telemetry", true, "Enable anonymous telemetry for hardware compatibility")
 	rootCmd.PersistentFlags().StringVar(&telemetryEndpoint, "telemetry-endpoint", "", "Custom telemetry endpoint (default: https://firelogs.mscrnt.com/logs)")
 
+	// Add logging flags
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", logging.DefaultLevel, logging.LevelUsage)
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", logging.FileUsage)
+
 	// Add commands
 	rootCmd.AddCommand(versionCmd())
 	rootCmd.AddCommand(createTestCmd())
@@ -63,21 +98,63 @@ endurance stress testing, and benchmark analysis.`,
 	rootCmd.AddCommand(exportCmd())
 	rootCmd.AddCommand(listCmd())
 	rootCmd.AddCommand(showCmd())
+	rootCmd.AddCommand(compareCmd())
+	rootCmd.AddCommand(noteCmd())
 	rootCmd.AddCommand(scheduleCmd())
 	rootCmd.AddCommand(reportCmd())
 	rootCmd.AddCommand(certCmd())
+	rootCmd.AddCommand(syncCmd())
+	rootCmd.AddCommand(helperCmd())
+	rootCmd.AddCommand(benchmodeCmd())
+	rootCmd.AddCommand(benchmarkCmd())
+	rootCmd.AddCommand(topCmd())
+	rootCmd.AddCommand(specCmd())
+	rootCmd.AddCommand(dbCmd())
+	rootCmd.AddCommand(powerCmd())
+	rootCmd.AddCommand(changelogCmd())
+	rootCmd.AddCommand(monitorCmd())
+	rootCmd.AddCommand(alertsCmd())
+	rootCmd.AddCommand(inventoryCmd())
+	rootCmd.AddCommand(intrusionCmd())
+	rootCmd.AddCommand(fleetCmd())
+	rootCmd.AddCommand(writeCacheCmd())
+	rootCmd.AddCommand(planCmd())
+	rootCmd.AddCommand(coolingCmd())
+	rootCmd.AddCommand(selftestCmd())
+	rootCmd.AddCommand(schemaCmd())
+	rootCmd.AddCommand(configCmd())
 	rootCmd.AddCommand(guiCmd())
+	localizeUsage(rootCmd)
 
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintln(os.Stderr, i18n.T("error.prefix", err))
 		os.Exit(1)
 	}
 }
 
+// localizeUsage translates the headings of cobra's usage template and its
+// error prefix. Child commands inherit both from the root.
+func localizeUsage(rootCmd *cobra.Command) {
+	// Longer headings first, so "Global Flags:" is not caught by "Flags:"
+	replacer := strings.NewReplacer(
+		"Global Flags:", i18n.T("help.global_flags"),
+		"Flags:", i18n.T("help.flags"),
+		"Usage:", i18n.T("help.usage"),
+		"Aliases:", i18n.T("help.aliases"),
+		"Examples:", i18n.T("help.examples"),
+		"Available Commands:", i18n.T("help.commands"),
+		"Additional Commands:", i18n.T("help.additional_commands"),
+		"Additional help topics:", i18n.T("help.topics"),
+		`Use "{{.CommandPath}} [command] --help" for more information about a command.`, i18n.T("help.more"),
+	)
+	rootCmd.SetUsageTemplate(replacer.Replace(rootCmd.UsageTemplate()))
+	rootCmd.SetErrPrefix(i18n.T("error.label"))
+}
+
 func versionCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "version",
-		Short: "Print version information",
+		Short: i18n.T("cmd.version"),
 		Run: func(_ *cobra.Command, _ []string) {
 			fmt.Println(version.GetDetailedVersion(buildVersion, buildCommit, buildTime))
 		},
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/spf13/cobra"
+)
+
+func noteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "note <run-id|uuid> [text]",
+		Short: i18n.T("cmd.note"),
+		Long: `Add a free-form note to a run, such as the repair made before it, or list
+the notes of a run when no text is given. Notes are shown by bench show, in
+reports and in the GUI history, and bench list --search finds them.
+
+Examples:
+  # Note a repair
+  bench note 42 "replaced PSU"
+
+  # List the notes of run 42
+  bench note 42`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			database, err := openDatabase()
+			if err != nil {
+				return i18n.Errorf("error.open_database", err)
+			}
+			defer func() { _ = database.Close() }()
+
+			run, err := database.ResolveRun(args[0])
+			if err != nil {
+				return i18n.Errorf("error.run_not_found", args[0])
+			}
+
+			text := strings.TrimSpace(strings.Join(args[1:], " "))
+			if text != "" {
+				if _, err := database.AddRunNote(run.ID, text); err != nil {
+					return err
+				}
+				fmt.Printf("Note added to run #%d\n", run.ID)
+				return nil
+			}
+
+			notes, err := database.RunNotes(run.ID)
+			if err != nil {
+				return err
+			}
+			if len(notes) == 0 {
+				fmt.Printf("Run #%d has no notes\n", run.ID)
+				return nil
+			}
+			for _, n := range notes {
+				fmt.Printf("%s  %s\n", n.CreatedAt.Local().Format("2006-01-02 15:04:05"), n.Text)
+			}
+			return nil
+		},
+	}
+
+	return cmd
+}
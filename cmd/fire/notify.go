@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/mscrnt/project_fire/pkg/notify"
+	"github.com/mscrnt/project_fire/pkg/testrun"
+)
+
+// getNotifyTargets returns who hears about every run: the "notify" section
+// of the settings file merged with n, the targets of the run itself. It
+// returns nil when nobody is to be told.
+func getNotifyTargets(n *notify.Notify) (*notify.Notify, error) {
+	settings, err := loadSettings()
+	if err != nil {
+		return nil, err
+	}
+	if global := settings.Notify; global != nil {
+		if err := global.Validate(); err != nil {
+			return nil, i18n.Errorf("notify.invalid_settings", getSettingsPath(), err)
+		}
+	}
+	return notify.Merge(settings.Notify, n), nil
+}
+
+// checkNotify checks the notify settings and, when reports are mailed, the
+// mail server, so a mistake shows before a long test rather than after it.
+// It returns the targets the run will be reported to.
+func checkNotify(n *notify.Notify) (*notify.Notify, error) {
+	targets, err := getNotifyTargets(n)
+	if err != nil || targets == nil || len(targets.Email) == 0 {
+		return targets, err
+	}
+	if _, err := getSMTPConfig(); err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
+
+// sendRunNotifications mails the report of a finished run and posts it to
+// the webhooks of n and of the settings file. schedule names the schedule
+// that started the run, if any.
+func sendRunNotifications(ctx context.Context, database *db.DB, run *db.Run, n *notify.Notify, schedule string) (*notify.Notify, error) {
+	targets, err := getNotifyTargets(n)
+	if err != nil || targets == nil || !targets.Wants(run.Success) {
+		return nil, err
+	}
+
+	notifier := &testrun.Notifier{
+		Database: database,
+		Units:    displayUnits(),
+		Logger:   log.New(os.Stderr, "Warning: ", 0),
+	}
+	if len(targets.Email) > 0 {
+		cfg, err := getSMTPConfig()
+		if err != nil {
+			return targets, err
+		}
+		notifier.SMTP = &cfg
+	}
+	return targets, notifier.Send(ctx, run, targets, schedule)
+}
+
+// notifyRun sends the notifications of a run finished from the command
+// line and reports the outcome
+func notifyRun(database *db.DB, run *db.Run, n *notify.Notify) {
+	targets, err := sendRunNotifications(context.Background(), database, run, n, "")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, i18n.T("notify.failed", err))
+		return
+	}
+	if targets != nil {
+		fmt.Println(i18n.T("notify.sent", targets))
+	}
+}
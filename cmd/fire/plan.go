@@ -0,0 +1,516 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/fancontrol"
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/mscrnt/project_fire/pkg/testplan"
+	"github.com/spf13/cobra"
+)
+
+func planCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: i18n.T("cmd.plan"),
+		Long: `Run a burn-in as a sequence of stages described in a YAML or JSON file,
+and keep the result of every stage in the database.
+
+A stage runs one plugin, or several at once with "plugins", for a duration
+with its own threads and config; pauses, ending early once "until" holds; or
+waits for the operator to answer a prompt. Abort thresholds, on the plan or
+on a stage, stop the stage as soon as a reading crosses them. Readings are
+named as bench monitor stores them (cpu_temp, memory_temp, gpu0_temp,
+fan_cpu_fan, ...).
+
+  name: burn-in
+  interval: 5s              # how often readings are checked
+  abort:
+    - cpu_temp > 95
+  stages:
+    - name: CPU
+      plugin: cpu
+      duration: 30m
+    - name: Memory
+      plugin: memory
+      duration: 30m
+      config:
+        size_mb: 4096
+    - name: Mixed
+      plugins: [cpu, memory]
+      duration: 2h
+      fans: 100             # pin the fans, needs --fan-control
+      abort:
+        - memory_temp > 85
+    - name: Cooldown
+      pause: 15m
+      until: cpu_temp < 45
+
+A stage that fails or is aborted skips the rest of the plan unless
+continue_on_failure is set.
+
+A plan run cut short by a crash, power loss or restart is marked interrupted
+the next time bench starts. 'bench plan resume' continues it, running the
+stage it was in again from the start; with "resume: true" in the plan, it
+picks the latest such run by itself, e.g. from a startup script.
+
+A stage with "fans" pins every controllable fan at that duty cycle (hwmon pwm
+channels and the ThinkPad fan on Linux, Supermicro and Dell BMCs through
+ipmitool) and hands them back to their curve when it ends. Plans only change
+fans when run with --fan-control as root, or from an elevated prompt on
+Windows; 'bench plan validate' lists the fans that would be pinned.
+
+Examples:
+  # Check a plan without running it
+  bench plan validate burn-in.yaml
+
+  # Run it
+  bench plan run burn-in.yaml
+
+  # Run a plan that pins the fans
+  sudo bench plan run --fan-control burn-in.yaml
+
+  # Continue plan run 3 after a power loss
+  bench plan resume 3
+
+  # Review past plan runs
+  bench plan list
+  bench plan show 3`,
+	}
+
+	cmd.AddCommand(planRunCmd())
+	cmd.AddCommand(planResumeCmd())
+	cmd.AddCommand(planValidateCmd())
+	cmd.AddCommand(planListCmd())
+	cmd.AddCommand(planShowCmd())
+
+	return cmd
+}
+
+func planRunCmd() *cobra.Command {
+	var fanControl bool
+
+	cmd := &cobra.Command{
+		Use:   "run <plan.yaml>",
+		Short: "Run the stages of a plan",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			plan, err := testplan.Load(args[0])
+			if err != nil {
+				return err
+			}
+			if err := checkFanControl(plan, args[0], fanControl); err != nil {
+				return err
+			}
+
+			database, err := openDatabase()
+			if err != nil {
+				return i18n.Errorf("error.open_database", err)
+			}
+			defer func() { _ = database.Close() }()
+
+			return executePlan(database, plan, fanControl, func(ctx context.Context, runner *testplan.Runner) (*testplan.PlanRun, error) {
+				if length := plan.Length(); length > 0 {
+					fmt.Println(i18n.T("plan.running", len(plan.Stages), length))
+				}
+				// An absolute path finds the plan again on resume, while
+				// an unnamed plan is listed as it was given
+				file, err := filepath.Abs(args[0])
+				if err != nil {
+					file = args[0]
+				}
+				if plan.Name == "" {
+					plan.Name = args[0]
+				}
+				return runner.Run(ctx, plan, file)
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&fanControl, "fan-control", false, "Let stages pin the fans (needs root or an elevated prompt)")
+
+	return cmd
+}
+
+func planResumeCmd() *cobra.Command {
+	var fanControl bool
+
+	cmd := &cobra.Command{
+		Use:   "resume [id]",
+		Short: "Continue a plan run that was interrupted",
+		Long: `Continue a plan run that a crash, power loss or restart cut short, from the
+stage it was in. That stage runs again from its start; the stages before it
+keep their results. The plan is read again from the file it was run from.
+
+Without an ID, the latest interrupted run of a plan with "resume: true" on
+this machine is continued, and nothing happens when there is none, so the
+command can run at every startup.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			database, err := openDatabase()
+			if err != nil {
+				return i18n.Errorf("error.open_database", err)
+			}
+			defer func() { _ = database.Close() }()
+
+			store := testplan.NewStore(database)
+			var run *testplan.PlanRun
+			if len(args) == 1 {
+				id, err := strconv.ParseInt(args[0], 10, 64)
+				if err != nil {
+					return i18n.Errorf("plan.invalid_id", args[0])
+				}
+				if run, err = store.GetRun(id); err != nil {
+					return err
+				}
+				if !run.Interrupted {
+					return i18n.Errorf("plan.not_interrupted", run.ID)
+				}
+			} else {
+				if run, err = store.LastResumable(db.Hostname()); err != nil {
+					return err
+				}
+				if run == nil {
+					fmt.Println(i18n.T("plan.nothing_to_resume"))
+					return nil
+				}
+			}
+
+			plan, err := testplan.Load(run.File)
+			if err != nil {
+				return i18n.Errorf("plan.load_failed", run.ID, err)
+			}
+			if err := checkFanControl(plan, run.File, fanControl); err != nil {
+				return err
+			}
+
+			return executePlan(database, plan, fanControl, func(ctx context.Context, runner *testplan.Runner) (*testplan.PlanRun, error) {
+				fmt.Println(i18n.T("plan.resuming", run.ID, run.Name))
+				return runner.Resume(ctx, plan, run)
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&fanControl, "fan-control", false, "Let stages pin the fans (needs root or an elevated prompt)")
+
+	return cmd
+}
+
+// checkFanControl refuses to run a plan that pins the fans unless that was
+// allowed and is possible, and slows them only with a temperature limit
+func checkFanControl(plan *testplan.Plan, file string, fanControl bool) error {
+	if !plan.PinsFans() {
+		return nil
+	}
+	if !fanControl {
+		return i18n.Errorf("plan.pins_fans", file)
+	}
+	if !fancontrol.Privileged() {
+		return fancontrol.ErrNotPrivileged
+	}
+	limits, err := getSafetyLimits()
+	if err != nil {
+		return err
+	}
+	if err := plan.CheckFans(limits); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return nil
+}
+
+// executePlan runs or resumes a plan with start, printing each stage, until
+// it ends or is stopped with Ctrl+C
+func executePlan(database *db.DB, plan *testplan.Plan, fanControl bool, start func(context.Context, *testplan.Runner) (*testplan.PlanRun, error)) error {
+	hookConfig, err := getHooksConfig()
+	if err != nil {
+		return err
+	}
+	limits, err := getSafetyLimits()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	runner := testplan.NewRunner(database, log.New(os.Stderr, "[plan] ", log.LstdFlags))
+	runner.SetHooks(hookConfig)
+	runner.SetLimits(limits)
+	runner.SetPrompt(promptOperator)
+	if fanControl {
+		runner.SetFans(pinFans)
+	}
+	total := len(plan.Stages)
+	runner.SetProgress(func(stage testplan.Stage, result *testplan.StageResult) {
+		printStageProgress(stage, result, total)
+	})
+
+	run, err := start(ctx, runner)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	if ctx.Err() != nil {
+		fmt.Println(i18n.T("plan.stopped", run.ID))
+	}
+	if !run.Success {
+		return i18n.Errorf("plan.failed", run.ID, run.Error)
+	}
+	fmt.Println(i18n.T("plan.passed", run.ID, run.EndTime.Sub(run.StartTime).Round(time.Second)))
+	return nil
+}
+
+func planValidateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate <plan.yaml>",
+		Short: "Check a plan file and list its stages",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			plan, err := testplan.Load(args[0])
+			if err != nil {
+				return err
+			}
+			for i, stage := range plan.Stages {
+				line := fmt.Sprintf("%d. %s", i+1, stage.Title())
+				if length := stage.Length(); length > 0 && stage.Kind() == testplan.KindTest {
+					line += " " + i18n.T("plan.for", length)
+				}
+				thresholds := append(append([]testplan.Threshold(nil), plan.Abort...), stage.Abort...)
+				if len(thresholds) > 0 && stage.Kind() != testplan.KindPrompt {
+					parts := make([]string, len(thresholds))
+					for j, t := range thresholds {
+						parts[j] = t.String()
+					}
+					line += ", " + i18n.T("plan.abort_if", strings.Join(parts, " "+i18n.T("plan.or")+" "))
+				}
+				if stage.Until != nil {
+					line += ", " + i18n.T("plan.until", stage.Until)
+				}
+				if stage.Fans > 0 {
+					line += ", " + i18n.T("plan.fans_at", stage.Fans)
+				}
+				fmt.Println(line)
+			}
+			if plan.PinsFans() {
+				fmt.Println()
+				printControllableFans()
+			}
+			if length := plan.Length(); length > 0 {
+				fmt.Printf("\n%s\n", i18n.T("plan.valid_length", args[0], len(plan.Stages), length))
+			} else {
+				fmt.Printf("\n%s\n", i18n.T("plan.valid", args[0], len(plan.Stages)))
+			}
+			return nil
+		},
+	}
+}
+
+func planListCmd() *cobra.Command {
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List plan runs",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			database, err := openDatabase()
+			if err != nil {
+				return i18n.Errorf("error.open_database", err)
+			}
+			defer func() { _ = database.Close() }()
+
+			runs, err := testplan.NewStore(database).ListRuns(limit)
+			if err != nil {
+				return err
+			}
+			if len(runs) == 0 {
+				fmt.Println(i18n.T("plan.no_runs"))
+				return nil
+			}
+
+			fmt.Printf("%-4s %-24s %-17s %-10s %-8s\n", i18n.T("column.id"), i18n.T("column.name"), i18n.T("column.started"), i18n.T("column.duration"), i18n.T("column.result"))
+			fmt.Println(strings.Repeat("-", 68))
+			for _, run := range runs {
+				duration, result := "-", testplan.StatusRunning
+				if run.EndTime != nil {
+					duration = run.EndTime.Sub(run.StartTime).Round(time.Second).String()
+					result = testplan.StatusFailed
+					if run.Success {
+						result = testplan.StatusPassed
+					} else if run.Interrupted {
+						result = testplan.StatusInterrupted
+					}
+				}
+				fmt.Printf("%-4d %-24s %-17s %-10s %-8s\n",
+					run.ID, truncate(run.Name, 24), run.StartTime.Format("2006-01-02 15:04"), duration, stageStatus(result))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVarP(&limit, "limit", "n", 20, "Number of plan runs to show (0 for all)")
+
+	return cmd
+}
+
+func planShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <id>",
+		Short: "Show the stages of a plan run",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			id, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				return i18n.Errorf("plan.invalid_id", args[0])
+			}
+
+			database, err := openDatabase()
+			if err != nil {
+				return i18n.Errorf("error.open_database", err)
+			}
+			defer func() { _ = database.Close() }()
+
+			store := testplan.NewStore(database)
+			run, err := store.GetRun(id)
+			if err != nil {
+				return err
+			}
+			stages, err := store.Stages(id)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(i18n.T("plan.show_run", run.ID, run.Name, run.File))
+			fmt.Println(i18n.T("plan.started", run.StartTime.Format("2006-01-02 15:04:05")))
+			if run.EndTime != nil {
+				fmt.Println(i18n.T("plan.duration", run.EndTime.Sub(run.StartTime).Round(time.Second)))
+				fmt.Println(i18n.T("show.success", run.Success))
+			}
+			if run.Error != "" {
+				fmt.Println(i18n.T("show.error", run.Error))
+			}
+			if run.Interrupted {
+				fmt.Println(i18n.T("plan.resume_hint", run.ID))
+			}
+
+			fmt.Printf("\n%-3s %-20s %-7s %-10s %-10s %-12s %s\n", "#", i18n.T("column.stage"), i18n.T("column.kind"), i18n.T("column.status"), i18n.T("column.duration"), i18n.T("column.runs"), i18n.T("column.peaks"))
+			fmt.Println(strings.Repeat("-", 90))
+			for _, s := range stages {
+				runIDs := make([]string, len(s.RunIDs))
+				for i, runID := range s.RunIDs {
+					runIDs[i] = strconv.FormatInt(runID, 10)
+				}
+				fmt.Printf("%-3d %-20s %-7s %-10s %-10s %-12s %s\n",
+					s.Position, truncate(s.Name, 20), s.Kind, stageStatus(s.Status),
+					s.Duration().Round(time.Second), strings.Join(runIDs, ","), formatPeaks(s.Peaks))
+				if s.Message != "" {
+					fmt.Printf("    %s\n", s.Message)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// printStageProgress prints a line as each stage of a plan starts and ends
+func printStageProgress(stage testplan.Stage, result *testplan.StageResult, total int) {
+	now := time.Now().Format("15:04:05")
+	if result.Status == testplan.StatusRunning {
+		line := fmt.Sprintf("[%s] %s", now, i18n.T("plan.stage_started", result.Position, total, stage.Title()))
+		if length := stage.Length(); length > 0 && stage.Kind() == testplan.KindTest {
+			line += " " + i18n.T("plan.for", length)
+		}
+		fmt.Println(line)
+		return
+	}
+
+	line := fmt.Sprintf("[%s] %s", now, i18n.T("plan.stage_ended", result.Position, total, stageStatus(result.Status)))
+	if result.StartTime != nil {
+		line += " " + i18n.T("plan.after", result.Duration().Round(time.Second))
+	}
+	if result.Message != "" {
+		line += ": " + result.Message
+	}
+	if peaks := formatPeaks(result.Peaks); peaks != "" {
+		line += " (" + i18n.T("plan.peak", peaks) + ")"
+	}
+	fmt.Println(line)
+}
+
+// stageStatus returns the translated name of a stage or plan run status
+func stageStatus(status string) string {
+	return i18n.T("plan.status_" + status)
+}
+
+// formatPeaks lists the peak temperatures of a stage, e.g. "cpu_temp 82.0"
+func formatPeaks(peaks map[string]interface{}) string {
+	var parts []string
+	for name, value := range peaks {
+		if v, ok := value.(float64); ok && strings.HasSuffix(name, "_temp") {
+			parts = append(parts, fmt.Sprintf("%s %.1f", name, v))
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+// pinFans pins the fans for a plan stage and lists what changed
+func pinFans(ctx context.Context, duty int, guarded bool) (func() error, error) {
+	pinned, err := fancontrol.Pin(ctx, duty, guarded)
+	if err != nil {
+		return nil, err
+	}
+	for _, fan := range pinned.Fans {
+		fmt.Println(i18n.T("plan.fan_pinned", fan.Name, duty, fan.Mode))
+	}
+	return pinned.Restore, nil
+}
+
+// printControllableFans lists the fans a plan would pin
+func printControllableFans() {
+	fans, err := fancontrol.List(context.Background())
+	if err != nil {
+		fmt.Println(i18n.T("plan.fans_error", err))
+		return
+	}
+	fmt.Println(i18n.T("plan.fans_to_pin"))
+	for _, fan := range fans {
+		fmt.Printf("  %s (%s, %s)\n", fan.Name, fan.Source, fan.Mode)
+	}
+}
+
+// promptOperator shows a prompt stage's message and waits for Enter
+func promptOperator(ctx context.Context, message string) error {
+	fmt.Printf("%s\n%s ", message, i18n.T("plan.prompt"))
+	answer := make(chan string, 1)
+	go func() {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			// No operator on stdin
+			line = "abort"
+		}
+		answer <- strings.TrimSpace(line)
+	}()
+	select {
+	case a := <-answer:
+		if strings.EqualFold(a, "abort") {
+			return i18n.Errorf("plan.operator_stopped")
+		}
+		return nil
+	case <-ctx.Done():
+		fmt.Println()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/mscrnt/project_fire/pkg/power"
+	"github.com/mscrnt/project_fire/pkg/testrun"
+	"github.com/spf13/cobra"
+)
+
+func powerCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "power",
+		Short: i18n.T("cmd.power"),
+		Long: `Show the state of the machine's battery and UPS, and the power events recorded
+during tests.
+
+A UPS is read through Network UPS Tools ("nut:<ups>[@host]") or apcupsd
+("apcupsd[:host:port]"), configured as "power.ups" in the settings file or with
+FIRE_UPS. When its battery runs low, running tests are stopped, their results
+are saved and flushed to disk, and the scheduler daemon exits.`,
+	}
+
+	cmd.AddCommand(powerStatusCmd())
+	cmd.AddCommand(powerEventsCmd())
+
+	return cmd
+}
+
+func powerStatusCmd() *cobra.Command {
+	var ups string
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show battery charge, runtime and UPS load",
+		Long: `Show battery charge, runtime and UPS load.
+
+Examples:
+  # Show the configured sources
+  bench power status
+
+  # Query a UPS served by apcupsd on another machine
+  bench power status --ups apcupsd:10.0.0.5:3551`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			sources, _, err := getPowerConfig(ups)
+			if err != nil {
+				return err
+			}
+			if len(sources) == 0 {
+				fmt.Println("No battery or UPS found. Configure a UPS with --ups or power.ups in the settings file.")
+				return nil
+			}
+
+			fmt.Printf("%-28s %-12s %-8s %-10s %-6s\n", "SOURCE", "STATE", "CHARGE", "RUNTIME", "LOAD")
+			fmt.Println(strings.Repeat("-", 68))
+			for _, source := range sources {
+				supply, err := source.Read()
+				if err != nil {
+					fmt.Printf("%-28s %v\n", source.Name, err)
+					continue
+				}
+				state := "line power"
+				if supply.OnBattery {
+					state = "on battery"
+				}
+				if supply.Critical {
+					state = "critical"
+				} else if supply.Low {
+					state = "low"
+				}
+				charge, runtime, load := "-", "-", "-"
+				if supply.Charge >= 0 {
+					charge = fmt.Sprintf("%.0f%%", supply.Charge)
+				}
+				if supply.Runtime > 0 {
+					runtime = supply.Runtime.Round(time.Second).String()
+				}
+				if supply.Load >= 0 {
+					load = fmt.Sprintf("%.0f%%", supply.Load)
+				}
+				fmt.Printf("%-28s %-12s %-8s %-10s %-6s\n", source.Name, state, charge, runtime, load)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&ups, "ups", "", "UPS to query (nut:<ups>[@host] or apcupsd[:host:port])")
+
+	return cmd
+}
+
+func powerEventsCmd() *cobra.Command {
+	var since time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "List recorded power events",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			database, err := openDatabase()
+			if err != nil {
+				return i18n.Errorf("error.open_database", err)
+			}
+			defer func() { _ = database.Close() }()
+
+			annotations, err := database.ListAnnotations(time.Now().Add(-since), time.Time{})
+			if err != nil {
+				return err
+			}
+			if len(annotations) == 0 {
+				fmt.Printf("No power events in the last %s\n", since)
+				return nil
+			}
+
+			fmt.Printf("%-20s %-6s %-24s %s\n", "TIME", "RUN", "SOURCE", "EVENT")
+			fmt.Println(strings.Repeat("-", 80))
+			for _, a := range annotations {
+				run := "-"
+				if a.RunID != nil {
+					run = fmt.Sprintf("%d", *a.RunID)
+				}
+				fmt.Printf("%-20s %-6s %-24s %s\n", a.Time.Local().Format("2006-01-02 15:04:05"), run, a.Source, a.Message)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&since, "since", 7*24*time.Hour, "How far back to list events")
+
+	return cmd
+}
+
+// getPowerConfig returns the power sources to watch: the system battery or AC
+// adapter if there is one, and the UPS named by ups or, failing that,
+// power.ups in the settings (or FIRE_UPS)
+func getPowerConfig(ups string) (sources []power.Source, pauseOnBattery bool, err error) {
+	settings, err := loadSettings()
+	if err != nil {
+		return nil, false, err
+	}
+	if ups == "" {
+		ups = settings.Power.UPS
+	}
+	sources, err = testrun.PowerSources(ups)
+	if err != nil {
+		return nil, false, err
+	}
+	return sources, settings.Power.PauseOnBattery, nil
+}
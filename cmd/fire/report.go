@@ -3,25 +3,31 @@ package main
 import (
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/i18n"
 	"github.com/mscrnt/project_fire/pkg/report"
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+	"github.com/mscrnt/project_fire/pkg/specsheet"
 	"github.com/spf13/cobra"
 )
 
 func reportCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "report",
-		Short: "Generate test reports",
+		Short: i18n.T("cmd.report"),
 		Long:  "Generate HTML and PDF reports from test results",
 	}
 
 	cmd.AddCommand(reportGenerateCmd())
 	cmd.AddCommand(reportListCmd())
+	cmd.AddCommand(reportViewCmd())
 
 	return cmd
 }
@@ -35,6 +41,8 @@ func reportGenerateCmd() *cobra.Command {
 		plugin    string
 		landscape bool
 		pageSize  string
+		specsFile string
+		noSpecs   bool
 	)
 
 	cmd := &cobra.Command{
@@ -53,7 +61,10 @@ Examples:
   bench report generate --latest --plugin cpu
 
   # Generate landscape PDF with custom page size
-  bench report generate --run 10 --format pdf --landscape --page-size A4`,
+  bench report generate --run 10 --format pdf --landscape --page-size A4
+
+  # Compare with the advertised specs of a build (see "bench spec")
+  bench report generate --latest --specs order-1042.json`,
 		RunE: func(_ *cobra.Command, _ []string) error {
 			// Validate inputs
 			if !latest && runID == 0 {
@@ -65,10 +76,9 @@ Examples:
 			}
 
 			// Open database
-			dbPath := getDBPath()
-			database, err := db.Open(dbPath)
+			database, err := openDatabase()
 			if err != nil {
-				return fmt.Errorf("failed to open database: %w", err)
+				return i18n.Errorf("error.open_database", err)
 			}
 			defer func() { _ = database.Close() }()
 
@@ -95,6 +105,21 @@ Examples:
 
 			// Create report generator
 			generator := report.NewGenerator(database)
+			generator.SetUnits(displayUnits())
+
+			// Compare with the advertised specs, from --specs or the saved sheet
+			if !noSpecs {
+				sheet, err := loadSpecSheet()
+				if specsFile != "" {
+					sheet, err = specsheet.Load(specsFile)
+				}
+				if err != nil {
+					return err
+				}
+				if sheet != nil {
+					generator.SetSpecSheet(sheet)
+				}
+			}
 
 			// Generate output filename if not specified
 			if output == "" {
@@ -151,7 +176,7 @@ Examples:
 			fmt.Printf("Generated %s report for run #%d\n", strings.ToUpper(format), runID)
 			fmt.Printf("Plugin: %s\n", run.Plugin)
 			fmt.Printf("Date: %s\n", run.StartTime.Format("2006-01-02 15:04:05"))
-			fmt.Printf("Status: %s\n", formatStatus(run.Success))
+			fmt.Printf("Status: %s\n", formatRunStatus(run))
 			fmt.Printf("Output: %s\n", absPath)
 
 			return nil
@@ -165,10 +190,75 @@ Examples:
 	cmd.Flags().StringVarP(&plugin, "plugin", "p", "", "Filter by plugin when using --latest")
 	cmd.Flags().BoolVar(&landscape, "landscape", false, "Generate PDF in landscape mode")
 	cmd.Flags().StringVar(&pageSize, "page-size", "LETTER", "PDF page size (A3, A4, LETTER, LEGAL)")
+	cmd.Flags().StringVar(&specsFile, "specs", "", "Spec sheet to compare with (default: the one saved with 'bench spec')")
+	cmd.Flags().BoolVar(&noSpecs, "no-specs", false, "Leave out the spec comparison")
 
 	return cmd
 }
 
+func reportViewCmd() *cobra.Command {
+	var noOpen bool
+
+	cmd := &cobra.Command{
+		Use:   "view [file]",
+		Short: "View an exported run",
+		Long: `Render a run exported with "bench export json" as an HTML report and open
+it in the default browser. The installers associate .firereport files with
+this command.
+
+Examples:
+  # Export a run and view it on another machine
+  bench export json --run 42 --out run42.firereport
+  bench report view run42.firereport`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			in, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to open %s: %w", args[0], err)
+			}
+			defer func() { _ = in.Close() }()
+
+			html, err := report.GenerateHTMLFromExport(in)
+			if err != nil {
+				return err
+			}
+
+			base := strings.TrimSuffix(filepath.Base(args[0]), filepath.Ext(args[0]))
+			output := filepath.Join(os.TempDir(), fmt.Sprintf("fire_report_%s.html", base))
+			if err := os.WriteFile(output, []byte(html), 0o600); err != nil {
+				return fmt.Errorf("failed to write HTML file: %w", err)
+			}
+
+			fmt.Printf("Report written to %s\n", output)
+			if noOpen {
+				return nil
+			}
+			return openInBrowser(output)
+		},
+	}
+
+	cmd.Flags().BoolVar(&noOpen, "no-open", false, "Only write the HTML report, do not open it")
+
+	return cmd
+}
+
+// openInBrowser opens a file with the platform's default handler
+func openInBrowser(path string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = safeexec.Command("rundll32", "url.dll,FileProtocolHandler", path)
+	case "darwin":
+		cmd = safeexec.Command("open", path)
+	default:
+		cmd = safeexec.Command("xdg-open", path)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open browser: %w", err)
+	}
+	return nil
+}
+
 func reportListCmd() *cobra.Command {
 	var (
 		plugin  string
@@ -197,10 +287,9 @@ Examples:
   bench report list --since 24h`,
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			// Open database
-			dbPath := getDBPath()
-			database, err := db.Open(dbPath)
+			database, err := openDatabase()
 			if err != nil {
-				return fmt.Errorf("failed to open database: %w", err)
+				return i18n.Errorf("error.open_database", err)
 			}
 			defer func() { _ = database.Close() }()
 
@@ -223,7 +312,7 @@ Examples:
 			if since != "" {
 				duration, err := parseDuration(since)
 				if err != nil {
-					return fmt.Errorf("invalid duration: %w", err)
+					return i18n.Errorf("error.invalid_duration", err)
 				}
 				sinceTime := time.Now().Add(-duration)
 				filter.StartTime = &sinceTime
@@ -232,21 +321,22 @@ Examples:
 			// List runs
 			runs, err := database.ListRuns(filter)
 			if err != nil {
-				return fmt.Errorf("failed to list runs: %w", err)
+				return i18n.Errorf("error.list_runs", err)
 			}
 
 			if len(runs) == 0 {
-				fmt.Println("No runs found")
+				fmt.Println(i18n.T("list.no_runs"))
 				return nil
 			}
 
 			// Display runs
 			fmt.Printf("%-6s %-15s %-20s %-20s %-8s %-10s\n",
-				"ID", "Plugin", "Start Time", "End Time", "Status", "Duration")
+				i18n.T("column.id"), i18n.T("column.plugin"), i18n.T("column.start_time"),
+				i18n.T("column.end_time"), i18n.T("column.status"), i18n.T("column.duration"))
 			fmt.Println(strings.Repeat("-", 85))
 
 			for _, run := range runs {
-				endTime := "Running"
+				endTime := i18n.T("status.running")
 				duration := "N/A"
 				if run.EndTime != nil {
 					endTime = run.EndTime.Format("2006-01-02 15:04:05")
@@ -258,12 +348,12 @@ Examples:
 					run.Plugin,
 					run.StartTime.Format("2006-01-02 15:04:05"),
 					endTime,
-					formatStatus(run.Success),
+					formatRunStatus(run),
 					duration,
 				)
 			}
 
-			fmt.Printf("\nTotal: %d runs\n", len(runs))
+			fmt.Printf("\n%s\n", i18n.T("list.total", len(runs)))
 
 			return nil
 		},
@@ -281,9 +371,18 @@ Examples:
 // Helper functions
 func formatStatus(success bool) string {
 	if success {
-		return "PASSED"
+		return i18n.T("status.passed")
+	}
+	return i18n.T("status.failed")
+}
+
+// formatRunStatus formats a run's outcome, telling interrupted runs from
+// failed ones
+func formatRunStatus(run *db.Run) string {
+	if run.Interrupted {
+		return i18n.T("status.interrupted")
 	}
-	return "FAILED"
+	return formatStatus(run.Success)
 }
 
 func formatDuration(d time.Duration) string {
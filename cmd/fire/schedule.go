@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -11,17 +12,26 @@ import (
 	"time"
 
 	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/dbsync"
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/mscrnt/project_fire/pkg/notify"
 	"github.com/mscrnt/project_fire/pkg/plugin"
-	_ "github.com/mscrnt/project_fire/pkg/plugin/cpu"    // Register CPU plugin
-	_ "github.com/mscrnt/project_fire/pkg/plugin/memory" // Register Memory plugin
+	_ "github.com/mscrnt/project_fire/pkg/plugin/cpu"     // Register CPU plugin
+	_ "github.com/mscrnt/project_fire/pkg/plugin/disk"    // Register Disk plugin
+	_ "github.com/mscrnt/project_fire/pkg/plugin/memory"  // Register Memory plugin
+	_ "github.com/mscrnt/project_fire/pkg/plugin/network" // Register Network plugin
+	_ "github.com/mscrnt/project_fire/pkg/plugin/trim"    // Register TRIM plugin
+	"github.com/mscrnt/project_fire/pkg/power"
 	"github.com/mscrnt/project_fire/pkg/schedule"
+	"github.com/mscrnt/project_fire/pkg/service"
+	"github.com/mscrnt/project_fire/pkg/testrun"
 	"github.com/spf13/cobra"
 )
 
 func scheduleCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "schedule",
-		Short: "Manage test schedules",
+		Short: i18n.T("cmd.schedule"),
 		Long:  "Create, manage, and run scheduled tests",
 	}
 
@@ -32,6 +42,9 @@ func scheduleCmd() *cobra.Command {
 	cmd.AddCommand(scheduleDisableCmd())
 	cmd.AddCommand(scheduleStartCmd())
 	cmd.AddCommand(scheduleShowCmd())
+	cmd.AddCommand(scheduleStatusCmd())
+	cmd.AddCommand(scheduleInstallServiceCmd())
+	cmd.AddCommand(scheduleRemoveServiceCmd())
 
 	return cmd
 }
@@ -44,6 +57,10 @@ func scheduleAddCmd() *cobra.Command {
 		pluginName  string
 		config      map[string]string
 		enabled     bool
+		resume      bool
+		notifySpecs []string
+		notifyFmt   string
+		notifyFail  bool
 	)
 
 	cmd := &cobra.Command{
@@ -68,7 +85,18 @@ Examples:
   bench schedule add --name "Daily Memory" --cron "0 2 * * *" --plugin memory --config size_mb=2048
 
   # Run stress test every Monday at 3:30 AM
-  bench schedule add --name "Weekly Stress" --cron "30 3 * * 1" --plugin cpu --config threads=8`,
+  bench schedule add --name "Weekly Stress" --cron "30 3 * * 1" --plugin cpu --config threads=8
+
+  # Mail the report of each nightly run to the lab as a PDF
+  bench schedule add --name "Nightly" --cron "0 2 * * *" --plugin cpu \
+    --notify email=ops@example.com --notify-format pdf
+
+  # Post each weekly burn-in to the team's Discord channel with the report
+  bench schedule add --name "Weekly Burn-in" --cron "0 20 * * 5" --plugin cpu --config duration=8h \
+    --notify discord=https://discord.com/api/webhooks/123/abc
+
+  # Start a burn-in again if a power loss or restart cuts it short
+  bench schedule add --name "Burn-in" --cron "0 20 * * 5" --plugin cpu --config duration=8h --resume`,
 		RunE: func(_ *cobra.Command, _ []string) error {
 			// Validate inputs
 			if name == "" {
@@ -86,9 +114,16 @@ Examples:
 				return fmt.Errorf("plugin %s not found", pluginName)
 			}
 
+			schedNotify, err := notify.ParseNotify(notifySpecs, notifyFmt, notifyFail)
+			if err != nil {
+				return err
+			}
+			if _, err := checkNotify(schedNotify); err != nil {
+				return err
+			}
+
 			// Open database
-			dbPath := getDBPath()
-			database, err := db.Open(dbPath)
+			database, err := openDatabase()
 			if err != nil {
 				return fmt.Errorf("failed to open database: %w", err)
 			}
@@ -119,18 +154,23 @@ Examples:
 				CronExpr:    cronExpr,
 				Plugin:      pluginName,
 				Params:      params,
+				Notify:      schedNotify,
 				Enabled:     enabled,
+				Resume:      resume,
 			}
 
 			if err := store.Create(sched); err != nil {
 				return fmt.Errorf("failed to create schedule: %w", err)
 			}
 
-			fmt.Printf("Created schedule '%s' (ID: %d)\n", sched.Name, sched.ID)
-			fmt.Printf("Cron: %s\n", sched.CronExpr)
-			fmt.Printf("Plugin: %s\n", sched.Plugin)
+			fmt.Println(i18n.T("schedule.created", sched.Name, sched.ID))
+			fmt.Println(i18n.T("schedule.cron", sched.CronExpr))
+			fmt.Println(i18n.T("show.plugin", sched.Plugin))
+			if sched.Notify != nil {
+				fmt.Println(i18n.T("schedule.notify", sched.Notify))
+			}
 			if sched.NextRunTime != nil {
-				fmt.Printf("Next run: %s\n", sched.NextRunTime.Format("2006-01-02 15:04:05"))
+				fmt.Println(i18n.T("schedule.next_run", sched.NextRunTime.Format("2006-01-02 15:04:05")))
 			}
 
 			return nil
@@ -143,6 +183,10 @@ Examples:
 	cmd.Flags().StringVarP(&pluginName, "plugin", "p", "", "Plugin to run (required)")
 	cmd.Flags().StringToStringVarP(&config, "config", "c", map[string]string{}, "Plugin configuration")
 	cmd.Flags().BoolVar(&enabled, "enabled", true, "Enable schedule immediately")
+	cmd.Flags().BoolVar(&resume, "resume", false, "Run again as soon as the scheduler starts when a run was interrupted, e.g. by a power loss")
+	cmd.Flags().StringArrayVar(&notifySpecs, "notify", nil, "Send the report after each run: email=ADDRESS, webhook=URL, slack=URL or discord=URL (repeatable; email needs \"smtp\" in the settings file)")
+	cmd.Flags().StringVar(&notifyFmt, "notify-format", notify.FormatHTML, "Format of the attached report: html or pdf")
+	cmd.Flags().BoolVar(&notifyFail, "notify-failures", false, "Only notify when a run fails")
 
 	if err := cmd.MarkFlagRequired("name"); err != nil {
 		// Log the error but don't fail - this is a development-time check
@@ -179,8 +223,7 @@ Examples:
   bench schedule list --all`,
 		RunE: func(_ *cobra.Command, _ []string) error {
 			// Open database
-			dbPath := getDBPath()
-			database, err := db.Open(dbPath)
+			database, err := openDatabase()
 			if err != nil {
 				return fmt.Errorf("failed to open database: %w", err)
 			}
@@ -206,20 +249,20 @@ Examples:
 			}
 
 			if len(schedules) == 0 {
-				fmt.Println("No schedules found")
+				fmt.Println(i18n.T("schedule.none"))
 				return nil
 			}
 
 			// Display schedules
 			fmt.Printf("%-4s %-20s %-15s %-20s %-8s %-20s\n",
-				"ID", "Name", "Plugin", "Cron", "Enabled", "Next Run")
+				i18n.T("column.id"), i18n.T("column.name"), i18n.T("column.plugin"), i18n.T("column.cron"), i18n.T("column.enabled"), i18n.T("column.next_run"))
 			fmt.Println(strings.Repeat("-", 90))
 
 			for _, sched := range schedules {
 				nextRun := "N/A"
 				if sched.NextRunTime != nil {
 					if sched.IsOverdue() {
-						nextRun = fmt.Sprintf("%s (overdue)", sched.NextRunTime.Format("2006-01-02 15:04"))
+						nextRun = fmt.Sprintf("%s (%s)", sched.NextRunTime.Format("2006-01-02 15:04"), i18n.T("schedule.overdue"))
 					} else {
 						nextRun = sched.NextRunTime.Format("2006-01-02 15:04")
 					}
@@ -257,8 +300,7 @@ Examples:
 		Args: cobra.ExactArgs(1),
 		RunE: func(_ *cobra.Command, args []string) error {
 			// Open database
-			dbPath := getDBPath()
-			database, err := db.Open(dbPath)
+			database, err := openDatabase()
 			if err != nil {
 				return fmt.Errorf("failed to open database: %w", err)
 			}
@@ -283,14 +325,14 @@ Examples:
 			}
 
 			// Confirm deletion
-			fmt.Printf("Delete schedule '%s' (ID: %d)? [y/N] ", sched.Name, sched.ID)
+			fmt.Print(i18n.T("schedule.confirm_delete", sched.Name, sched.ID) + " ")
 			var confirm string
 			if _, err := fmt.Scanln(&confirm); err != nil {
 				// Treat any error as a "no" response
 				confirm = "n"
 			}
 			if !strings.EqualFold(confirm, "y") {
-				fmt.Println("Cancelled")
+				fmt.Println(i18n.T("prompt.cancelled"))
 				return nil
 			}
 
@@ -299,7 +341,7 @@ Examples:
 				return fmt.Errorf("failed to delete schedule: %w", err)
 			}
 
-			fmt.Printf("Deleted schedule '%s'\n", sched.Name)
+			fmt.Println(i18n.T("schedule.deleted", sched.Name))
 			return nil
 		},
 	}
@@ -333,8 +375,7 @@ func scheduleDisableCmd() *cobra.Command {
 
 func toggleSchedule(identifier string, enable bool) error {
 	// Open database
-	dbPath := getDBPath()
-	database, err := db.Open(dbPath)
+	database, err := openDatabase()
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
@@ -362,12 +403,12 @@ func toggleSchedule(identifier string, enable bool) error {
 		if err := store.Enable(sched.ID); err != nil {
 			return fmt.Errorf("failed to enable schedule: %w", err)
 		}
-		fmt.Printf("Enabled schedule '%s'\n", sched.Name)
+		fmt.Println(i18n.T("schedule.enabled", sched.Name))
 	} else {
 		if err := store.Disable(sched.ID); err != nil {
 			return fmt.Errorf("failed to disable schedule: %w", err)
 		}
-		fmt.Printf("Disabled schedule '%s'\n", sched.Name)
+		fmt.Println(i18n.T("schedule.disabled", sched.Name))
 	}
 
 	return nil
@@ -377,6 +418,8 @@ func scheduleStartCmd() *cobra.Command {
 	var (
 		checkInterval time.Duration
 		logFile       string
+		wake          bool
+		wakeLead      time.Duration
 	)
 
 	cmd := &cobra.Command{
@@ -385,11 +428,20 @@ func scheduleStartCmd() *cobra.Command {
 		Long: `Start the scheduler daemon to run tests automatically.
 
 The scheduler will:
-- Load all enabled schedules
+- Load all enabled schedules, picking up changes made from the CLI or GUI
+  at each check
 - Execute tests according to their cron expressions
 - Save results to the database
+- Keep the machine awake while a test runs
+- Check the database daily and keep weekly snapshots (see "bench db")
 - Continue running until interrupted
 
+To run the scheduler at boot instead, see bench schedule install-service.
+
+With --wake the scheduler also programs a hardware wake (rtcwake on Linux,
+a wake-timer task on Windows, pmset on macOS) before each scheduled run, so
+the machine can sleep between test windows. This needs root/Administrator.
+
 Examples:
   # Start scheduler in foreground
   bench schedule start
@@ -398,7 +450,10 @@ Examples:
   bench schedule start --check-interval 30s
 
   # Start with log file
-  bench schedule start --log scheduler.log`,
+  bench schedule start --log scheduler.log
+
+  # Wake from sleep 5 minutes before each scheduled test
+  sudo bench schedule start --wake --wake-lead 5m`,
 		RunE: func(_ *cobra.Command, _ []string) error {
 			// Setup logging
 			logger := log.New(os.Stdout, "[scheduler] ", log.LstdFlags)
@@ -411,51 +466,194 @@ Examples:
 				logger = log.New(f, "[scheduler] ", log.LstdFlags)
 			}
 
-			// Open database
-			dbPath := getDBPath()
-			database, err := db.Open(dbPath)
-			if err != nil {
-				return fmt.Errorf("failed to open database: %w", err)
+			// Installed as a Windows service the scheduler answers the
+			// service control manager and logs to the event log
+			if service.IsService() {
+				if logFile == "" {
+					w, err := service.EventLog(scheduleServiceName)
+					if err != nil {
+						return err
+					}
+					defer func() { _ = w.Close() }()
+					logger = log.New(w, "", 0)
+				}
+				return service.Run(scheduleServiceName, func(stop <-chan struct{}) error {
+					return runScheduler(logger, checkInterval, wake, wakeLead, stop)
+				})
 			}
-			defer func() { _ = database.Close() }()
+			return runScheduler(logger, checkInterval, wake, wakeLead, nil)
+		},
+	}
+
+	cmd.Flags().DurationVar(&checkInterval, "check-interval", 60*time.Second, "Interval to check for overdue schedules")
+	cmd.Flags().StringVar(&logFile, "log", "", "Log file path (default: stdout)")
+	cmd.Flags().BoolVar(&wake, "wake", false, "Wake the machine from sleep before each scheduled run")
+	cmd.Flags().DurationVar(&wakeLead, "wake-lead", 2*time.Minute, "How long before a scheduled run to wake the machine")
+
+	return cmd
+}
+
+// runScheduler runs the scheduler until it is interrupted, the UPS battery
+// runs low or stop is closed by the service control manager
+func runScheduler(logger *log.Logger, checkInterval time.Duration, wake bool, wakeLead time.Duration, stop <-chan struct{}) error {
+	// Open database
+	database, err := openDatabase()
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	hookConfig, err := getHooksConfig()
+	if err != nil {
+		return err
+	}
+	limits, err := getSafetyLimits()
+	if err != nil {
+		return err
+	}
+
+	// Create and start runner
+	runner := schedule.NewRunner(database, logger)
+	runner.SetHooks(hookConfig)
+	runner.SetLimits(limits)
+	runner.SetOnFinish(func(ctx context.Context, sched *schedule.Schedule, run *db.Run) {
+		targets, err := sendRunNotifications(ctx, database, run, sched.Notify, sched.Name)
+		if err != nil {
+			logger.Printf("Could not send every notification of run %d: %v", run.ID, err)
+			return
+		}
+		if targets != nil {
+			logger.Printf("Notified %s of run %d", targets, run.ID)
+		}
+	})
+	if wake {
+		runner.SetWake(wakeLead)
+	}
+	if err := runner.Start(); err != nil {
+		return fmt.Errorf("failed to start scheduler: %w", err)
+	}
+
+	// Push completed runs to the central server when configured
+	if target, interval, ok, err := getSyncConfig(); err != nil {
+		logger.Printf("Sync disabled: %v", err)
+	} else if ok {
+		worker, err := dbsync.NewWorker(database, target, logger)
+		if err != nil {
+			logger.Printf("Sync disabled: %v", err)
+		} else {
+			syncCtx, stopSync := context.WithCancel(context.Background())
+			defer stopSync()
+			go worker.Run(syncCtx, interval)
+			logger.Printf("Sync to central server enabled (every %s)", interval)
+		}
+	}
 
-			// Create and start runner
-			runner := schedule.NewRunner(database, logger)
-			if err := runner.Start(); err != nil {
-				return fmt.Errorf("failed to start scheduler: %w", err)
+	// Record power events and hold tests while a UPS is on battery
+	powerSources, pauseOnBattery, err := getPowerConfig("")
+	if err != nil {
+		logger.Printf("Power event logging disabled: %v", err)
+	}
+	powerCtx, stopPower := context.WithCancel(context.Background())
+	defer stopPower()
+	upsLow := make(chan power.Event, 1)
+	monitor := testrun.WatchPower(powerCtx, database, powerSources, 0, logger, func(event power.Event) {
+		logger.Printf("Power event: %s", event.Message)
+		switch {
+		case event.UPSLow():
+			select {
+			case upsLow <- event:
+			default:
 			}
+		case pauseOnBattery && event.UPS && event.Kind == power.EventOnBattery:
+			runner.StopRuns(power.ErrUPSOnBattery)
+		}
+	})
+	if monitor != nil && pauseOnBattery {
+		runner.SetPowerMonitor(monitor)
+	}
 
-			// Setup signal handling
-			sigChan := make(chan os.Signal, 1)
-			signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	// Check the database daily and keep weekly snapshots
+	maintCtx, stopMaint := context.WithCancel(context.Background())
+	defer stopMaint()
+	go database.RunMaintenance(maintCtx, logger)
 
-			// Run check for overdue schedules periodically
-			ticker := time.NewTicker(checkInterval)
-			defer ticker.Stop()
+	// Setup signal handling
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
-			fmt.Println("Scheduler started. Press Ctrl+C to stop.")
-			logger.Println("Scheduler daemon started")
+	// Run check for overdue schedules periodically
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
 
-			// Main loop
-			for {
-				select {
-				case <-sigChan:
-					logger.Println("Received shutdown signal")
-					runner.Stop()
-					return nil
+	fmt.Println(i18n.T("schedule.started"))
+	logger.Println("Scheduler daemon started")
 
-				case <-ticker.C:
-					if err := runner.CheckDue(); err != nil {
-						logger.Printf("Error checking due schedules: %v", err)
-					}
+	// Main loop
+	for {
+		select {
+		case <-sigChan:
+			logger.Println("Received shutdown signal")
+			runner.Stop()
+			return nil
+
+		case event := <-upsLow:
+			// Save what the running tests measured before the UPS shuts off
+			logger.Println(i18n.T("schedule.ups_low", event.Message))
+			runner.StopRuns(fmt.Errorf("%w: %s", power.ErrUPSBatteryLow, event.Message))
+			runner.Stop()
+			if err := database.Flush(); err != nil {
+				logger.Printf("Failed to flush database: %v", err)
+			}
+			return nil
+
+		case <-ticker.C:
+			if err := runner.Reload(); err != nil {
+				logger.Printf("Error reloading schedules: %v", err)
+			}
+			if err := runner.CheckDue(); err != nil {
+				logger.Printf("Error checking due schedules: %v", err)
+			}
+		}
+	}
+}
+
+func scheduleStatusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show whether the scheduler daemon is running",
+		Long: `Show the scheduler daemons recorded in the database: the machine, the
+process and when it started. A daemon on another machine sharing the
+database counts as running while it keeps recording heartbeats.`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			database, err := openDatabase()
+			if err != nil {
+				return fmt.Errorf("failed to open database: %w", err)
+			}
+			defer func() { _ = database.Close() }()
+
+			daemons, err := schedule.NewStore(database).Daemons()
+			if err != nil {
+				return err
+			}
+			now := time.Now()
+			running := 0
+			for _, d := range daemons {
+				if !d.Running(now) {
+					continue
 				}
+				running++
+				fmt.Println(i18n.T("schedule.daemon_running",
+					d.Machine, d.PID, d.StartedAt.Local().Format("2006-01-02 15:04:05"),
+					d.Heartbeat.Local().Format("15:04:05")))
+			}
+			if running == 0 {
+				fmt.Println(i18n.T("schedule.daemon_stopped"))
 			}
+			return nil
 		},
 	}
 
-	cmd.Flags().DurationVar(&checkInterval, "check-interval", 60*time.Second, "Interval to check for overdue schedules")
-	cmd.Flags().StringVar(&logFile, "log", "", "Log file path (default: stdout)")
-
 	return cmd
 }
 
@@ -466,8 +664,7 @@ func scheduleShowCmd() *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 		RunE: func(_ *cobra.Command, args []string) error {
 			// Open database
-			dbPath := getDBPath()
-			database, err := db.Open(dbPath)
+			database, err := openDatabase()
 			if err != nil {
 				return fmt.Errorf("failed to open database: %w", err)
 			}
@@ -491,40 +688,60 @@ func scheduleShowCmd() *cobra.Command {
 			}
 
 			// Display details
-			fmt.Printf("Schedule: %s (ID: %d)\n", sched.Name, sched.ID)
+			fmt.Println(i18n.T("schedule.name", sched.Name, sched.ID))
 			if sched.Description != "" {
-				fmt.Printf("Description: %s\n", sched.Description)
+				fmt.Println(i18n.T("schedule.description", sched.Description))
+			}
+			fmt.Println(i18n.T("show.plugin", sched.Plugin))
+			fmt.Println(i18n.T("schedule.cron_expression", sched.CronExpr))
+			fmt.Println(i18n.T("schedule.enabled_value", sched.Enabled))
+			if sched.Resume {
+				fmt.Println(i18n.T("schedule.resume"))
 			}
-			fmt.Printf("Plugin: %s\n", sched.Plugin)
-			fmt.Printf("Cron Expression: %s\n", sched.CronExpr)
-			fmt.Printf("Enabled: %v\n", sched.Enabled)
-			fmt.Printf("Created: %s\n", sched.CreatedAt.Format("2006-01-02 15:04:05"))
-			fmt.Printf("Updated: %s\n", sched.UpdatedAt.Format("2006-01-02 15:04:05"))
+			if sched.Notify != nil {
+				fmt.Println(i18n.T("schedule.notify", sched.Notify))
+			}
+			fmt.Println(i18n.T("schedule.created_at", sched.CreatedAt.Format("2006-01-02 15:04:05")))
+			fmt.Println(i18n.T("schedule.updated_at", sched.UpdatedAt.Format("2006-01-02 15:04:05")))
 
 			if sched.LastRunTime != nil {
-				fmt.Printf("\nLast Run: %s\n", sched.LastRunTime.Format("2006-01-02 15:04:05"))
+				fmt.Printf("\n%s\n", i18n.T("schedule.last_run", sched.LastRunTime.Format("2006-01-02 15:04:05")))
 				if sched.LastRunID != nil {
-					fmt.Printf("Last Run ID: %d\n", *sched.LastRunID)
+					fmt.Println(i18n.T("schedule.last_run_id", *sched.LastRunID))
 				}
 			} else {
-				fmt.Printf("\nLast Run: Never\n")
+				fmt.Printf("\n%s\n", i18n.T("schedule.never_run"))
 			}
 
 			if sched.NextRunTime != nil {
-				fmt.Printf("Next Run: %s", sched.NextRunTime.Format("2006-01-02 15:04:05"))
+				fmt.Print(i18n.T("schedule.next_run", sched.NextRunTime.Format("2006-01-02 15:04:05")))
 				if sched.IsOverdue() {
-					fmt.Printf(" (OVERDUE)")
+					fmt.Printf(" (%s)", strings.ToUpper(i18n.T("schedule.overdue")))
 				}
 				fmt.Println()
 			}
 
 			if len(sched.Params) > 0 {
-				fmt.Printf("\nParameters:\n")
+				fmt.Printf("\n%s\n", i18n.T("show.parameters"))
 				for k, v := range sched.Params {
 					fmt.Printf("  %s: %v\n", k, v)
 				}
 			}
 
+			history, err := store.History(sched.ID, 10)
+			if err == nil && len(history) > 0 {
+				fmt.Printf("\n%s\n", i18n.T("schedule.recent_runs"))
+				for _, run := range history {
+					status := i18n.T("status.passed")
+					if run.Interrupted {
+						status = i18n.T("status.interrupted")
+					} else if !run.Success {
+						status = i18n.T("status.failed")
+					}
+					fmt.Printf("  #%d  %s  %s\n", run.ID, run.StartTime.Format("2006-01-02 15:04:05"), status)
+				}
+			}
+
 			return nil
 		},
 	}
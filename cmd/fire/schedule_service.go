@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/config"
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/mscrnt/project_fire/pkg/service"
+	"github.com/spf13/cobra"
+)
+
+// scheduleServiceName is the scheduler's systemd unit, or its Windows service
+// next to FIREHelper
+var scheduleServiceName = func() string {
+	if runtime.GOOS == "windows" {
+		return "FIREScheduler"
+	}
+	return "fire-scheduler"
+}()
+
+func scheduleInstallServiceCmd() *cobra.Command {
+	var (
+		checkInterval time.Duration
+		logFile       string
+		wake          bool
+		wakeLead      time.Duration
+		user          string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "install-service",
+		Short: "Run the scheduler as a service started at boot",
+		Long: `Install the scheduler daemon (bench schedule start) as a service of the
+operating system, so scheduled tests keep running after a reboot without
+anyone logged in, and start it.
+
+On Linux this writes the systemd unit /etc/systemd/system/fire-scheduler.service
+and enables it; its output is in the journal (journalctl -u fire-scheduler).
+On Windows it registers the FIREScheduler service, started automatically and
+restarted after a failure, which logs to the Application event log. Both need
+root or Administrator rights.
+
+The service uses the config file and database this command sees, so run it
+as the user whose schedules it should run, e.g. with sudo. On Linux the unit
+runs as the user who ran sudo, or as root with --wake.
+
+Examples:
+  # Run the schedules of the current user at boot
+  sudo bench schedule install-service
+
+  # Wake from sleep before each scheduled test
+  sudo bench schedule install-service --wake --wake-lead 5m`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			exe, err := os.Executable()
+			if err != nil {
+				return i18n.Errorf("schedule.no_executable", err)
+			}
+			if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+				exe = resolved
+			}
+
+			args := []string{"schedule", "start", "--check-interval", checkInterval.String()}
+			if wake {
+				args = append(args, "--wake", "--wake-lead", wakeLead.String())
+			}
+			if logFile != "" {
+				path, err := filepath.Abs(logFile)
+				if err != nil {
+					return err
+				}
+				args = append(args, "--log", path)
+			}
+
+			// Pin the settings and database, as the service runs under
+			// another account with its own home directory
+			env, err := scheduleServiceEnv()
+			if err != nil {
+				return err
+			}
+
+			if !cmd.Flags().Changed("user") && !wake {
+				user = os.Getenv("SUDO_USER")
+			}
+
+			err = service.Install(service.Config{
+				Name:        scheduleServiceName,
+				DisplayName: "F.I.R.E. Scheduler",
+				Description: "Runs the scheduled F.I.R.E. tests",
+				Executable:  exe,
+				Args:        args,
+				Env:         env,
+				User:        user,
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Println(i18n.T("schedule.service_installed", scheduleServiceName))
+			fmt.Println(i18n.T("schedule.service_check"))
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&checkInterval, "check-interval", 60*time.Second, "Interval to check for overdue schedules")
+	cmd.Flags().StringVar(&logFile, "log", "", "Log file path (default: the journal or the event log)")
+	cmd.Flags().BoolVar(&wake, "wake", false, "Wake the machine from sleep before each scheduled run")
+	cmd.Flags().DurationVar(&wakeLead, "wake-lead", 2*time.Minute, "How long before a scheduled run to wake the machine")
+	cmd.Flags().StringVar(&user, "user", "", "User the systemd unit runs as (default: the user running sudo, or root with --wake)")
+
+	return cmd
+}
+
+// scheduleServiceEnv returns the environment pointing the service at the
+// config file and the SQLite database in use
+func scheduleServiceEnv() ([]string, error) {
+	path, err := filepath.Abs(config.Path())
+	if err != nil {
+		return nil, err
+	}
+	env := []string{"FIRE_CONFIG=" + path}
+
+	cfg, err := getDBConfig()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Driver == db.DriverSQLite {
+		path, err := filepath.Abs(cfg.Path)
+		if err != nil {
+			return nil, err
+		}
+		env = append(env, "FIRE_DB_PATH="+path)
+	}
+	return env, nil
+}
+
+func scheduleRemoveServiceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remove-service",
+		Short: "Stop and remove the scheduler service",
+		Long: `Stop the scheduler service installed by bench schedule install-service and
+remove it. The schedules stay in the database.`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := service.Remove(scheduleServiceName); err != nil {
+				return err
+			}
+			fmt.Println(i18n.T("schedule.service_removed", scheduleServiceName))
+			return nil
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/mscrnt/project_fire/pkg/schema"
+	"github.com/spf13/cobra"
+)
+
+func schemaCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: i18n.T("cmd.schema"),
+		Long: `List, print and check against the JSON Schemas of the files F.I.R.E. exports.
+
+Reports (bench export json), sessions (.firesession), certificate
+summaries (bench cert verify --json), burn-in certificates (bench cert
+burnin), hardware inventories (bench inventory --format json) and every
+line of bench export --format ndjson carry a format and a schema_version
+field. The version is raised only when a field
+is removed or changes meaning, so tools built against a schema keep working
+across releases that only add fields.
+
+Examples:
+  # List the schemas
+  bench schema
+
+  # Print the report schema
+  bench schema show report > report.schema.json
+
+  # Check an exported file
+  bench schema validate run42.firereport
+
+  # Check every line of an NDJSON export
+  bench schema validate runs.ndjson`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			for _, name := range schema.Names() {
+				fmt.Println(name)
+			}
+			return nil
+		},
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "show <name>",
+		Short: "Print a JSON Schema",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			data, err := schema.Get(args[0])
+			if err != nil {
+				return err
+			}
+			_, err = os.Stdout.Write(data)
+			return err
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "validate <file>",
+		Short: "Check an exported file against the schema its format field names",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			data, err := os.ReadFile(args[0]) // #nosec G304 -- user-specified file to check
+			if err != nil {
+				return fmt.Errorf("failed to read file: %w", err)
+			}
+			if ext := strings.ToLower(filepath.Ext(args[0])); ext == ".ndjson" || ext == ".jsonl" {
+				return validateLines(args[0], data)
+			}
+			name, err := schema.Detect(data)
+			if err != nil {
+				return err
+			}
+			if err := schema.Validate(name, data); err != nil {
+				var invalid *schema.Error
+				if !errors.As(err, &invalid) {
+					return err
+				}
+				// The error shows only the first problems
+				for _, problem := range invalid.Problems {
+					fmt.Println(problem)
+				}
+				return fmt.Errorf("%s does not match the %s schema", args[0], name)
+			}
+			fmt.Printf("%s matches the %s schema\n", args[0], name)
+			return nil
+		},
+	})
+
+	return cmd
+}
+
+// validateLines checks every line of a newline-delimited JSON file, such as
+// bench export --format ndjson writes, against the schema it names
+func validateLines(path string, data []byte) error {
+	failed := 0
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		name, err := schema.Detect([]byte(line))
+		if err == nil {
+			err = schema.Validate(name, []byte(line))
+		}
+		if err != nil {
+			fmt.Printf("line %d: %v\n", i+1, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d lines of %s do not match their schema", failed, path)
+	}
+	fmt.Printf("%s matches the schemas its lines name\n", path)
+	return nil
+}
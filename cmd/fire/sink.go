@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/changelog"
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/mscrnt/project_fire/pkg/sink"
+	"github.com/mscrnt/project_fire/pkg/testrun"
+)
+
+// sinkFlagUsage describes the --sink flag of the commands that stream
+const sinkFlagUsage = "Stream samples to a time-series database or MQTT broker: influxdb://host:8086/db, postgres://..., timescale://... or mqtt://host/prefix (repeatable; adds to \"sink\" in the settings file)"
+
+// getSinkURLs returns the sinks of the settings file followed by urls, the
+// --sink flags, without repeats
+func getSinkURLs(urls []string) ([]string, time.Duration, error) {
+	settings, err := loadSettings()
+	if err != nil {
+		return nil, 0, err
+	}
+	interval := sink.DefaultInterval
+	if settings.Sink.Interval != "" {
+		interval, err = time.ParseDuration(settings.Sink.Interval)
+		if err != nil || interval <= 0 {
+			return nil, 0, i18n.Errorf("sink.invalid_interval", settings.Sink.Interval, getSettingsPath())
+		}
+	}
+	return testrun.SinkURLs(settings.Sink.URLs, urls), interval, nil
+}
+
+// openSinks connects to the sinks of the settings file and urls. It
+// returns nil when none are configured.
+func openSinks(ctx context.Context, urls []string) (*testrun.Sinks, error) {
+	all, interval, err := getSinkURLs(urls)
+	if err != nil || len(all) == 0 {
+		return nil, err
+	}
+
+	sinks, err := testrun.OpenSinks(ctx, all, interval, func(format string, args ...interface{}) {
+		fmt.Fprintln(os.Stderr, i18n.T("warning.generic", fmt.Sprintf(format, args...)))
+	})
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintln(os.Stderr, i18n.T("sink.streaming", interval, strings.Join(sinks.Names(), ", ")))
+	return sinks, nil
+}
+
+// closeSinks writes the queued points and reports points that were lost
+func closeSinks(sinks *testrun.Sinks) {
+	dropped, err := sinks.Close()
+	if dropped > 0 {
+		fmt.Fprintln(os.Stderr, i18n.T("sink.dropped", dropped))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, i18n.T("warning.generic", err))
+	}
+}
+
+// hostTags tags samples that belong to no run with the machine and source
+func hostTags(source string) map[string]string {
+	return map[string]string{"machine": changelog.Machine(), "source": source}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/mscrnt/project_fire/pkg/specsheet"
+	"github.com/spf13/cobra"
+)
+
+func specCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "spec",
+		Short: i18n.T("cmd.spec"),
+		Long: `Record the specs a build was sold with, such as the advertised boost clock,
+the rated memory speed or the claimed SSD speeds. Reports generated with
+"bench report generate" then include a measured-vs-advertised table with a
+pass/fail result for each spec.
+
+Known specs:
+  ` + strings.Join(specsheet.PresetKeys(), "\n  "),
+	}
+
+	cmd.AddCommand(specSetCmd())
+	cmd.AddCommand(specImportCmd())
+	cmd.AddCommand(specShowCmd())
+	cmd.AddCommand(specClearCmd())
+
+	return cmd
+}
+
+func specSetCmd() *cobra.Command {
+	var (
+		name      string
+		tolerance float64
+	)
+
+	cmd := &cobra.Command{
+		Use:   "set <spec=value>...",
+		Short: "Set advertised values",
+		Long: `Set advertised values in the saved spec sheet. A spec is one of the known
+specs listed by "bench spec --help", or plugin/metric for any other metric.
+
+Examples:
+  # A workstation sold with a 5.7 GHz CPU, DDR5-6000 and a 7000 MB/s SSD
+  bench spec set cpu_boost_clock_mhz=5700 memory_speed_mts=6000 ssd_seq_read_mbps=7000
+
+  # Allow a 3% shortfall and name the sheet after the order
+  bench spec set --name "Order 1042" --tolerance 3 ssd_seq_write_mbps=6000
+
+  # A metric without a preset
+  bench spec set disk/random_read_latency_us=80`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sheet, err := loadSpecSheet()
+			if err != nil {
+				return err
+			}
+			if sheet == nil {
+				sheet = &specsheet.Sheet{}
+			}
+			if cmd.Flags().Changed("name") {
+				sheet.Name = name
+			}
+			if cmd.Flags().Changed("tolerance") {
+				sheet.Tolerance = &tolerance
+			}
+
+			for _, arg := range args {
+				spec, err := parseSpecArg(arg)
+				if err != nil {
+					return err
+				}
+				if err := sheet.Set(spec); err != nil {
+					return err
+				}
+			}
+			if err := sheet.Validate(); err != nil {
+				return err
+			}
+			if err := specsheet.Save(getSpecsPath(), sheet); err != nil {
+				return fmt.Errorf("failed to save spec sheet: %w", err)
+			}
+			fmt.Printf("Saved %d specs to %s\n", len(sheet.Specs), getSpecsPath())
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "Name of the build, shown in reports")
+	cmd.Flags().Float64Var(&tolerance, "tolerance", specsheet.DefaultTolerance, "Allowed shortfall in percent")
+
+	return cmd
+}
+
+// parseSpecArg parses "key=value" or "plugin/metric=value"
+func parseSpecArg(arg string) (specsheet.Spec, error) {
+	key, value, ok := strings.Cut(arg, "=")
+	if !ok {
+		return specsheet.Spec{}, fmt.Errorf("invalid spec %q, expected spec=value", arg)
+	}
+	expected, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return specsheet.Spec{}, fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	key = strings.TrimSpace(key)
+	if plugin, metric, ok := strings.Cut(key, "/"); ok {
+		return specsheet.Spec{Plugin: plugin, Metric: metric, Expected: expected}, nil
+	}
+	return specsheet.Spec{Key: key, Expected: expected}, nil
+}
+
+func specImportCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import <file>",
+		Short: "Replace the spec sheet with one from a file",
+		Long: `Replace the saved spec sheet with a JSON file, for example one exported from
+an order system.
+
+Example file:
+  {
+    "name": "Order 1042 - Workstation",
+    "tolerance_pct": 5,
+    "specs": [
+      {"key": "cpu_boost_clock_mhz", "expected": 5700},
+      {"key": "ssd_seq_read_mbps", "expected": 7000, "tolerance_pct": 10},
+      {"name": "SSD latency", "plugin": "disk", "metric": "random_read_latency_us",
+       "expected": 80, "unit": "us", "lower_is_better": true}
+    ]
+  }`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			sheet, err := specsheet.Load(args[0])
+			if err != nil {
+				return err
+			}
+			if err := specsheet.Save(getSpecsPath(), sheet); err != nil {
+				return fmt.Errorf("failed to save spec sheet: %w", err)
+			}
+			fmt.Printf("Imported %d specs to %s\n", len(sheet.Specs), getSpecsPath())
+			return nil
+		},
+	}
+}
+
+func specShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show",
+		Short: "Compare the spec sheet with the latest results",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			sheet, err := loadSpecSheet()
+			if err != nil {
+				return err
+			}
+			if sheet == nil || len(sheet.Specs) == 0 {
+				fmt.Println("No spec sheet. Use 'bench spec set' or 'bench spec import' to add one.")
+				return nil
+			}
+
+			database, err := openDatabase()
+			if err != nil {
+				return i18n.Errorf("error.open_database", err)
+			}
+			defer func() { _ = database.Close() }()
+
+			if sheet.Name != "" {
+				fmt.Printf("%s\n\n", sheet.Name)
+			}
+			fmt.Printf("%-24s %-16s %-16s %-8s %-8s %-12s\n",
+				"SPEC", "ADVERTISED", "MEASURED", "MARGIN", "RUN", "RESULT")
+			fmt.Println(strings.Repeat("-", 89))
+			for _, row := range specsheet.Compare(sheet, specsheet.FromDatabase(database, nil, nil)) {
+				measured, margin, run := "-", "-", "-"
+				if row.Found {
+					measured = fmt.Sprintf("%.0f %s", row.Measured.Value, row.Spec.Unit)
+					margin = fmt.Sprintf("%+.1f%%", row.Margin)
+					run = fmt.Sprintf("#%d", row.Measured.RunID)
+				}
+				fmt.Printf("%-24s %-16s %-16s %-8s %-8s %-12s\n",
+					row.Spec.Name, fmt.Sprintf("%.0f %s", row.Spec.Expected, row.Spec.Unit), measured, margin, run, row.Status())
+			}
+			return nil
+		},
+	}
+}
+
+func specClearCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "clear",
+		Short: "Remove the saved spec sheet",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := os.Remove(getSpecsPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("failed to remove spec sheet: %w", err)
+			}
+			fmt.Println("Spec sheet removed")
+			return nil
+		},
+	}
+}
+
+// loadSpecSheet loads the saved spec sheet, returning nil if there is none
+func loadSpecSheet() (*specsheet.Sheet, error) {
+	sheet, err := specsheet.Load(getSpecsPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return sheet, err
+}
@@ -3,30 +3,56 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/mscrnt/project_fire/pkg/benchmode"
 	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/mscrnt/project_fire/pkg/notify"
 	"github.com/mscrnt/project_fire/pkg/plugin"
-	_ "github.com/mscrnt/project_fire/pkg/plugin/cpu"    // Register CPU plugin
-	_ "github.com/mscrnt/project_fire/pkg/plugin/memory" // Register Memory plugin
+	_ "github.com/mscrnt/project_fire/pkg/plugin/cpu"     // Register CPU plugin
+	_ "github.com/mscrnt/project_fire/pkg/plugin/disk"    // Register Disk plugin
+	_ "github.com/mscrnt/project_fire/pkg/plugin/memory"  // Register Memory plugin
+	_ "github.com/mscrnt/project_fire/pkg/plugin/network" // Register Network plugin
+	_ "github.com/mscrnt/project_fire/pkg/plugin/trim"    // Register TRIM plugin
+	"github.com/mscrnt/project_fire/pkg/safety"
+	"github.com/mscrnt/project_fire/pkg/sensorcheck"
+	"github.com/mscrnt/project_fire/pkg/sink"
+	"github.com/mscrnt/project_fire/pkg/testrun"
 	"github.com/spf13/cobra"
 )
 
 var (
-	testPlugin   string
-	testDuration time.Duration
-	testThreads  int
-	testConfig   map[string]string
-	testDryRun   bool
-	testList     bool
+	testPlugin     string
+	testDuration   time.Duration
+	testThreads    int
+	testConfig     map[string]string
+	testDryRun     bool
+	testList       bool
+	testSleep      bool
+	testBenchMode  bool
+	testGPUClock   int
+	testUPS        string
+	testUPSPause   bool
+	testTPMQuote   bool
+	testLimits     safety.Limits
+	testCheck      bool
+	testNotify     []string
+	testNotifyFmt  string
+	testNotifyFail bool
+	testSinks      []string
+	testTags       []string
 )
 
 func createTestCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "test [plugin]",
-		Short: "Run a system test",
+		Short: i18n.T("cmd.test"),
 		Long: `Execute various system tests including CPU, memory, disk, and GPU stress tests.
 
 Examples:
@@ -39,6 +65,41 @@ Examples:
   # Run memory test with 2GB allocation
   bench test memory --config size_mb=2048
 
+  # Benchmark the drive holding /data with a 1GB test file
+  bench test disk --config path=/data --config size_mb=1024
+
+  # Measure filesystem overhead against an unmounted partition (destroys its data)
+  bench test disk --config mode=both --config raw_device=/dev/sdb2 --config confirm_raw=true
+
+  # Saturate the link to a machine running: bench test network --config mode=server
+  bench test network --threads 8 --config target=10.0.0.2
+
+  # Lock GPU clocks and the power plan for comparable results
+  bench test cpu --benchmark-mode
+
+  # Record UPS events during the test and stop it if the UPS goes on battery
+  bench test cpu --ups nut:ups@localhost --pause-on-ups-battery
+
+  # Record the TPM details and a signed quote of the boot PCRs with the run
+  bench test cpu --tpm-quote
+
+  # Unattended burn-in: stop if the CPU reaches 95 °C, a GPU 90 °C or the
+  # CPU and GPUs together draw 600 W
+  bench test cpu --duration 8h --abort-temp-cpu 95 --abort-temp-gpu 90 --abort-power 600
+
+  # Mail the PDF report to the lab when the test finishes or fails
+  bench test memory --duration 4h --notify email=ops@example.com --notify-format pdf
+
+  # Tell the team's Slack channel only if the burn-in fails
+  bench test cpu --duration 8h --notify slack=https://hooks.slack.com/services/T000/B000/XXXX --notify-failures
+
+  # Stream temperatures, clocks and power during the test, and the results
+  # at its end, to InfluxDB
+  bench test cpu --duration 1h --sink influxdb://metrics.lab:8086/fire
+
+  # Tag the run with the customer and build it was tested for
+  bench test cpu --tag customer=acme --tag build=retail
+
   # Dry run to see what would be executed
   bench test cpu --dry-run`,
 		Args: cobra.MaximumNArgs(1),
@@ -51,11 +112,26 @@ Examples:
 	cmd.Flags().StringToStringVarP(&testConfig, "config", "c", map[string]string{}, "Plugin configuration (key=value)")
 	cmd.Flags().BoolVar(&testDryRun, "dry-run", false, "Show what would be executed without running")
 	cmd.Flags().BoolVarP(&testList, "list", "l", false, "List available plugins")
+	cmd.Flags().BoolVar(&testSleep, "allow-sleep", false, "Let the system sleep or lock the screen during the test")
+	cmd.Flags().BoolVar(&testBenchMode, "benchmark-mode", false, "Lock GPU clocks and select the performance power plan during the test")
+	cmd.Flags().IntVar(&testGPUClock, "gpu-clock", 0, "GPU clock in MHz for benchmark mode (0 = default application clock)")
+	cmd.Flags().StringVar(&testUPS, "ups", "", "UPS to watch for power events (nut:<ups>[@host] or apcupsd[:host:port])")
+	cmd.Flags().BoolVar(&testUPSPause, "pause-on-ups-battery", false, "Stop the test if a UPS switches to battery power")
+	cmd.Flags().BoolVar(&testTPMQuote, "tpm-quote", false, "Store the TPM details and a quote of the boot PCRs as run artifacts (needs tpm2-tools)")
+	cmd.Flags().Float64Var(&testLimits.CPUTempC, "abort-temp-cpu", 0, "Stop the test when the CPU reaches this temperature in °C (0 = off)")
+	cmd.Flags().Float64Var(&testLimits.GPUTempC, "abort-temp-gpu", 0, "Stop the test when any GPU reaches this temperature in °C (0 = off)")
+	cmd.Flags().Float64Var(&testLimits.PowerW, "abort-power", 0, "Stop the test when the CPU and GPUs together draw this many watts (0 = off)")
+	cmd.Flags().StringArrayVar(&testNotify, "notify", nil, "Send the report when the test finishes or fails: email=ADDRESS, webhook=URL, slack=URL or discord=URL (repeatable; email needs \"smtp\" in the settings file)")
+	cmd.Flags().StringVar(&testNotifyFmt, "notify-format", notify.FormatHTML, "Format of the attached report: html or pdf")
+	cmd.Flags().BoolVar(&testNotifyFail, "notify-failures", false, "Only notify when the test fails")
+	cmd.Flags().StringArrayVar(&testSinks, "sink", nil, sinkFlagUsage)
+	cmd.Flags().StringArrayVar(&testTags, "tag", nil, "Tag the run as name=value, e.g. customer=acme (repeatable)")
+	cmd.Flags().BoolVar(&testCheck, "check-sensors", false, "Check that the sensors respond to a 30 second load first, and do not start the test if they fail (see bench selftest)")
 
 	return cmd
 }
 
-func runTest(_ *cobra.Command, args []string) error {
+func runTest(cmd *cobra.Command, args []string) error {
 	// Handle list flag
 	if testList {
 		return listPlugins()
@@ -68,7 +144,7 @@ func runTest(_ *cobra.Command, args []string) error {
 	}
 
 	if pluginName == "" {
-		return fmt.Errorf("plugin name required")
+		return i18n.Errorf("error.plugin_required")
 	}
 
 	// Get plugin from registry
@@ -80,33 +156,61 @@ func runTest(_ *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Prepare parameters
-	params := p.DefaultParams()
-	params.Duration = testDuration
+	// Prepare parameters: the plugin's defaults, those of the settings, then
+	// the flags
+	settings, err := loadSettings()
+	if err != nil {
+		return err
+	}
+	params, err := settings.PluginParams(p)
+	if err != nil {
+		return err
+	}
+	if settings.Plugins[p.Name()].Duration == "" || cmd.Flags().Changed("duration") {
+		params.Duration = testDuration
+	}
 	if testThreads > 0 {
 		params.Threads = testThreads
 	}
 
+	// The limit flags override those of the settings
+	limits := settings.Safety
+	if cmd.Flags().Changed("abort-temp-cpu") {
+		limits.CPUTempC = testLimits.CPUTempC
+	}
+	if cmd.Flags().Changed("abort-temp-gpu") {
+		limits.GPUTempC = testLimits.GPUTempC
+	}
+	if cmd.Flags().Changed("abort-power") {
+		limits.PowerW = testLimits.PowerW
+	}
+
 	// Apply config overrides
 	if params.Config == nil {
 		params.Config = make(map[string]interface{})
 	}
 	for k, v := range testConfig {
-		// Try to parse as number
-		if n, err := json.Number(v).Int64(); err == nil {
-			params.Config[k] = int(n)
-		} else if f, err := json.Number(v).Float64(); err == nil {
-			params.Config[k] = f
-		} else if v == "true" || v == "false" {
-			params.Config[k] = v == "true"
-		} else {
-			params.Config[k] = v
-		}
+		params.Config[k] = parseConfigValue(v)
 	}
 
 	// Validate parameters
 	if err := p.ValidateParams(params); err != nil {
-		return fmt.Errorf("invalid parameters: %w", err)
+		return i18n.Errorf("error.invalid_params", err)
+	}
+
+	tags, err := db.ParseTags(testTags)
+	if err != nil {
+		return err
+	}
+
+	// Check the notify and mail settings now rather than after a long test
+	runNotify, err := notify.ParseNotify(testNotify, testNotifyFmt, testNotifyFail)
+	if err != nil {
+		return err
+	}
+	targets, err := checkNotify(runNotify)
+	if err != nil {
+		return err
 	}
 
 	// Dry run mode
@@ -115,6 +219,27 @@ func runTest(_ *cobra.Command, args []string) error {
 		fmt.Printf("Description: %s\n", p.Description())
 		fmt.Printf("Duration: %s\n", params.Duration)
 		fmt.Printf("Threads: %d\n", params.Threads)
+		if testBenchMode {
+			fmt.Printf("Benchmark mode: enabled\n")
+		}
+		if limits.Enabled() {
+			fmt.Printf("Safety limits: %s\n", limits)
+		}
+		if testCheck {
+			fmt.Printf("Sensor check: enabled\n")
+		}
+		if targets != nil {
+			fmt.Printf("Notify: %s\n", targets)
+		}
+		if len(tags) > 0 {
+			fmt.Printf("Tags: %s\n", db.FormatTags(tags))
+		}
+		if urls, _, err := getSinkURLs(testSinks); err == nil && len(urls) > 0 {
+			for i, u := range urls {
+				urls[i] = sink.Redact(u)
+			}
+			fmt.Printf("Sinks: %s\n", strings.Join(urls, ", "))
+		}
 		fmt.Printf("Config:\n")
 		for k, v := range params.Config {
 			fmt.Printf("  %s: %v\n", k, v)
@@ -122,80 +247,99 @@ func runTest(_ *cobra.Command, args []string) error {
 		return nil
 	}
 
-	// Open database
-	dbPath := getDBPath()
-	database, err := db.Open(dbPath)
+	hookConfig, err := getHooksConfig()
 	if err != nil {
-		return fmt.Errorf("failed to open database: %w", err)
+		return err
 	}
-	defer func() { _ = database.Close() }()
 
-	// Create run record
-	run, err := database.CreateRun(pluginName, db.JSONData(params.Config))
+	powerSources, pauseOnBattery, err := getPowerConfig(testUPS)
 	if err != nil {
-		return fmt.Errorf("failed to create run record: %w", err)
+		return err
 	}
+	pauseOnBattery = pauseOnBattery || testUPSPause
 
-	fmt.Printf("Starting test: %s (run ID: %d)\n", p.Name(), run.ID)
-	fmt.Printf("Duration: %s, Threads: %d\n", params.Duration, params.Threads)
-
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), params.Duration+30*time.Second)
-	defer cancel()
-
-	// Run the test
-	startTime := time.Now()
-	result, err := p.Run(ctx, params)
-	endTime := time.Now()
-
-	// Update run record
-	run.EndTime = &endTime
-	run.Success = result.Success
-	run.Error = result.Error
-	run.Stdout = result.Stdout
-	run.Stderr = result.Stderr
-	if err != nil {
-		run.ExitCode = 1
-		if run.Error == "" {
-			run.Error = err.Error()
+	// Dead or frozen sensors would leave the safety limits and throttle
+	// detection blind for the whole run
+	if testCheck {
+		if err := checkSensors(context.Background(), sensorcheck.Options{}); err != nil {
+			return err
 		}
+		fmt.Println()
 	}
 
-	if err := database.UpdateRun(run); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to update run record: %v\n", err)
+	// Connect to the sinks before the run, so a wrong URL fails straight away
+	sinks, err := openSinks(context.Background(), testSinks)
+	if err != nil {
+		return err
 	}
+	defer closeSinks(sinks)
 
-	// Save metrics to database
-	unitsMap := make(map[string]string)
-	if len(result.Metrics) > 0 {
-		// Try to get units from plugin info
-		if infoPlugin, ok := p.(interface{ Info() plugin.Info }); ok {
-			info := infoPlugin.Info()
-			for _, metric := range info.Metrics {
-				unitsMap[metric.Name] = metric.Unit
-			}
-		}
+	// Open database
+	database, err := openDatabase()
+	if err != nil {
+		return i18n.Errorf("error.open_database", err)
+	}
+	defer func() { _ = database.Close() }()
 
-		if err := database.CreateResults(run.ID, result.Metrics, unitsMap); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to save metrics: %v\n", err)
-		}
+	opts := testrun.Options{
+		Source:         "cli",
+		Tags:           tags,
+		Hooks:          hookConfig,
+		Limits:         limits,
+		Power:          powerSources,
+		PauseOnBattery: pauseOnBattery,
+		Sinks:          sinks,
+		TPMQuote:       testTPMQuote,
+		AllowSleep:     testSleep,
+		Output:         os.Stdout,
+		Logger:         log.New(os.Stderr, "Warning: ", 0),
+		Started: func(run *db.Run) {
+			fmt.Println(i18n.T("test.starting", p.Name(), run.ID))
+			fmt.Println(i18n.T("test.duration_threads", params.Duration, params.Threads))
+		},
+		Finished: func(run *db.Run) {
+			notifyRun(database, run, runNotify)
+		},
+	}
+	if testBenchMode {
+		opts.BenchMode = &benchmode.Options{GPUClockMHz: testGPUClock, StatePath: getBenchmodeStatePath()}
 	}
+	outcome, err := testrun.Run(context.Background(), database, p, params, opts)
+	if errors.Is(err, testrun.ErrAborted) {
+		return i18n.Errorf("error.test_aborted", outcome.Err)
+	}
+	if err != nil {
+		return err
+	}
+	result, unitsMap := outcome.Result, outcome.Units
+	throttling, eccErrors := outcome.Throttling, outcome.ECC
 
 	// Display results
-	fmt.Printf("\nTest completed in %s\n", endTime.Sub(startTime))
-	fmt.Printf("Success: %v\n", result.Success)
+	fmt.Printf("\n%s\n", i18n.T("test.completed", outcome.Duration))
+	fmt.Println(i18n.T("test.success", result.Success))
 
 	if result.Error != "" {
-		fmt.Printf("Error: %s\n", result.Error)
+		fmt.Println(i18n.T("test.error", result.Error))
+	}
+	if throttling.Samples > 0 {
+		fmt.Println(throttling.Summary())
+		for _, e := range throttling.Events {
+			fmt.Printf("  %s %s\n", e.Start.Format("15:04:05"), e.Describe(throttling.Limits))
+		}
+	}
+	// A memory burn-in without ECC counters misses its most important signal
+	if eccErrors.Available() || pluginName == "memory" {
+		fmt.Println(eccErrors.Summary())
+		for _, e := range eccErrors.Events {
+			fmt.Printf("  %s %s\n", e.Time.Format("15:04:05"), e.Errors.Describe())
+		}
 	}
 
 	if len(result.Metrics) > 0 {
-		fmt.Printf("\nMetrics:\n")
+		fmt.Printf("\n%s\n", i18n.T("test.metrics"))
+		prefs := displayUnits()
 		for name, value := range result.Metrics {
-			unit := ""
-			if u, ok := unitsMap[name]; ok {
-				unit = u
-			}
+			value, unit := prefs.Convert(value, unitsMap[name])
 			if unit != "" {
 				fmt.Printf("  %s: %.2f %s\n", name, value, unit)
 			} else {
@@ -205,28 +349,24 @@ func runTest(_ *cobra.Command, args []string) error {
 	}
 
 	if len(result.Details) > 0 {
-		fmt.Printf("\nDetails:\n")
+		fmt.Printf("\n%s\n", i18n.T("test.details"))
 		for k, v := range result.Details {
 			fmt.Printf("  %s: %v\n", k, v)
 		}
 	}
 
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return outcome.Err
 }
 
 func listPlugins() error {
 	plugins := plugin.List()
 
 	if len(plugins) == 0 {
-		fmt.Println("No plugins registered")
+		fmt.Println(i18n.T("test.no_plugins"))
 		return nil
 	}
 
-	fmt.Println("Available plugins:")
+	fmt.Println(i18n.T("test.available_plugins"))
 	for _, name := range plugins {
 		p, err := plugin.Get(name)
 		if err != nil {
@@ -237,3 +377,18 @@ func listPlugins() error {
 
 	return nil
 }
+
+// parseConfigValue types a --config value: integers, floats and booleans are
+// converted, anything else stays a string
+func parseConfigValue(v string) interface{} {
+	if n, err := json.Number(v).Int64(); err == nil {
+		return int(n)
+	}
+	if f, err := json.Number(v).Float64(); err == nil {
+		return f
+	}
+	if v == "true" || v == "false" {
+		return v == "true"
+	}
+	return v
+}
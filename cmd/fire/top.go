@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/mscrnt/project_fire/pkg/procs"
+	"github.com/spf13/cobra"
+)
+
+// clearScreen moves the cursor home and clears the terminal
+const clearScreen = "\033[H\033[2J"
+
+func topCmd() *cobra.Command {
+	var (
+		sortKey  string
+		limit    int
+		interval time.Duration
+		once     bool
+		asJSON   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "top",
+		Short: i18n.T("cmd.top"),
+		Long: `List the processes using the most CPU, memory, disk I/O or GPU, refreshed
+until Ctrl+C. Run it next to a stress test to check that the load comes from
+the test and nothing else is interfering: the footer sums the CPU used by
+every other process.
+
+CPU is a share of all logical CPUs, so a process keeping every core busy
+shows 100%. Disk I/O is the process's reads and writes in MB/s. GPU is the
+process's share of the NVIDIA cards' shaders, read through nvidia-smi, and
+shows "-" elsewhere. Rates cover the time since the previous refresh, so
+the first table comes after one interval.
+
+Examples:
+  # Watch the top CPU users every 2 seconds
+  bench top
+
+  # The 10 largest processes by memory, printed once
+  bench top --sort memory -n 10 --once
+
+  # One JSON line per refresh, for logging next to a burn-in
+  bench top --sort gpu --json > processes.jsonl`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if !procs.ValidSortKey(sortKey) {
+				return fmt.Errorf("unknown sort key %q (use %s)", sortKey, strings.Join(procs.SortKeys, ", "))
+			}
+			if interval <= 0 {
+				return fmt.Errorf("interval must be positive, got %s", interval)
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			sampler := procs.NewSampler()
+			if _, err := sampler.Sample(ctx); err != nil {
+				return err
+			}
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-ticker.C:
+				}
+				sample, err := sampler.Sample(ctx)
+				if err != nil {
+					return err
+				}
+				top := procs.Top(sample.Processes, sortKey, limit)
+				switch {
+				case asJSON:
+					if err := json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+						"time": sample.Time, "sort": sortKey, "others_cpu_pct": procs.Others(sample.Processes), "processes": top,
+					}); err != nil {
+						return err
+					}
+				default:
+					if !once {
+						fmt.Print(clearScreen)
+					}
+					printProcesses(os.Stdout, sample, top, sortKey)
+				}
+				if once {
+					return nil
+				}
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&sortKey, "sort", procs.ByCPU, "Sort by "+strings.Join(procs.SortKeys, ", "))
+	cmd.Flags().IntVarP(&limit, "limit", "n", 15, "Number of processes to show (0 for all)")
+	cmd.Flags().DurationVar(&interval, "interval", 2*time.Second, "Time between refreshes")
+	cmd.Flags().BoolVar(&once, "once", false, "Print one table and exit")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print one JSON object per refresh instead of a table")
+
+	return cmd
+}
+
+// printProcesses prints the top processes of a sample as a table. This
+// process is marked with an asterisk.
+func printProcesses(w io.Writer, sample *procs.Sample, top []procs.Process, sortKey string) {
+	fmt.Fprintf(w, "%s  sorted by %s, %d processes\n\n", sample.Time.Format("15:04:05"), sortKey, len(sample.Processes))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "PID\tCPU %\tMEMORY MB\tMEM %\tREAD MB/s\tWRITE MB/s\tGPU %\t \tNAME\tUSER\t")
+	for _, p := range top {
+		gpu := "-"
+		if sample.GPUAvailable {
+			gpu = fmt.Sprintf("%.0f", p.GPU)
+		}
+		mark := " "
+		if p.Self {
+			mark = "*"
+		}
+		fmt.Fprintf(tw, "%d\t%.1f\t%.0f\t%.1f\t%.1f\t%.1f\t%s\t%s\t%s\t%s\t\n",
+			p.PID, p.CPU, p.MemoryMB, p.MemoryPct, p.ReadMBps, p.WriteMBps, gpu, mark, p.Name, p.User)
+	}
+	_ = tw.Flush()
+	fmt.Fprintf(w, "\nOther processes use %.1f%% of the CPU (* marks this process)\n", procs.Others(sample.Processes))
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/mscrnt/project_fire/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+func writeCacheCmd() *cobra.Command {
+	var (
+		enable  bool
+		disable bool
+		yes     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "writecache <device>...",
+		Short: i18n.T("cmd.writecache"),
+		Long: `Show whether the volatile write cache of each drive is enabled and whether the
+drive has power-loss protection, and turn the cache on or off.
+
+Both change how write benchmarks should be read: with the cache disabled a
+drive writes far below its rating, and with it enabled on a drive without
+power-loss protection, writes the drive has acknowledged are lost if power
+fails before they are flushed. Power-loss protection is reported by Windows;
+on Linux it is inferred from the model number of known data center SSDs.
+
+Changing the cache needs root (Administrator on Windows), asks for
+confirmation unless --yes is given, and uses the SCSI disk driver for SATA
+and SAS drives and nvme-cli for NVMe drives on Linux, and the disk class
+driver on Windows. Many drives return to their default at the next power
+cycle.
+
+Examples:
+  # Show the cache of two drives
+  bench writecache /dev/sda /dev/nvme0n1
+
+  # Disable the cache before a burn-in that must survive power loss
+  sudo bench writecache /dev/sda --disable
+
+  # The same on Windows, by drive letter or physical drive
+  bench writecache D: --disable`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if enable && disable {
+				return i18n.Errorf("writecache.enable_and_disable")
+			}
+
+			caches := make([]*storage.WriteCache, 0, len(args))
+			for _, device := range args {
+				wc, err := storage.ReadWriteCache(device, "")
+				if err != nil {
+					return err
+				}
+				caches = append(caches, wc)
+			}
+
+			if enable || disable {
+				if !yes && !confirmWriteCache(caches, enable) {
+					fmt.Println(i18n.T("prompt.cancelled"))
+					return nil
+				}
+				for i, wc := range caches {
+					if err := storage.SetWriteCache(wc.Device, enable); err != nil {
+						return err
+					}
+					if updated, err := storage.ReadWriteCache(wc.Device, wc.Model); err == nil {
+						caches[i] = updated
+					}
+				}
+			}
+
+			fmt.Printf("%-16s %-32s %-10s %s\n", i18n.T("table.device"), i18n.T("table.model"), i18n.T("table.cache"), i18n.T("table.power_loss_protection"))
+			fmt.Println(strings.Repeat("-", 84))
+			for _, wc := range caches {
+				plp := wc.PLP
+				if wc.PLPSource == "model" {
+					plp += " " + i18n.T("writecache.from_model")
+				}
+				fmt.Printf("%-16s %-32s %-10s %s\n", wc.Device, wc.Model, wc.Cache, plp)
+			}
+			fmt.Println()
+			for _, wc := range caches {
+				for _, w := range wc.Warnings {
+					fmt.Println(i18n.T("writecache.warning", wc.Device, w))
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&enable, "enable", false, "Enable the write cache")
+	cmd.Flags().BoolVar(&disable, "disable", false, "Disable the write cache")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Change the cache without asking for confirmation")
+
+	return cmd
+}
+
+// confirmWriteCache explains what the change costs and asks to go ahead
+func confirmWriteCache(caches []*storage.WriteCache, enable bool) bool {
+	if enable {
+		for _, wc := range caches {
+			if wc.PLP != storage.PLPYes {
+				fmt.Println(i18n.T("writecache.no_plp", wc.Device))
+			}
+		}
+		fmt.Print(i18n.T("writecache.confirm_enable") + " ")
+	} else {
+		fmt.Println(i18n.T("writecache.disable_cost"))
+		fmt.Print(i18n.T("writecache.confirm_disable") + " ")
+	}
+	var confirm string
+	if _, err := fmt.Scanln(&confirm); err != nil {
+		// Treat any error as a "no" response
+		confirm = "n"
+	}
+	return strings.EqualFold(confirm, "y")
+}
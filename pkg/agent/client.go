@@ -1,12 +1,28 @@
 package agent
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/testplan"
 )
 
+// StatusError is returned when the agent answers with an error status
+type StatusError struct {
+	Code int
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("server returned status %d: %s", e.Code, e.Body)
+}
+
 // Client represents an agent client
 type Client struct {
 	config     ClientConfig
@@ -41,28 +57,102 @@ func NewClient(config *ClientConfig) (*Client, error) {
 
 // Connect connects to the specified endpoint and returns the response
 func (c *Client) Connect() ([]byte, error) {
+	return c.do(http.MethodGet, c.config.Endpoint, nil)
+}
+
+// do sends a request to an endpoint and returns the response body
+func (c *Client) do(method, endpoint string, body []byte) ([]byte, error) {
 	// Build URL
-	url := fmt.Sprintf("https://%s:%d/%s", c.config.Host, c.config.Port, c.config.Endpoint)
+	url := fmt.Sprintf("https://%s:%d/%s", c.config.Host, c.config.Port, endpoint)
+
+	var reader io.Reader = http.NoBody
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 
 	// Make request
-	resp, err := c.httpClient.Get(url)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	// Read response
-	body, err := io.ReadAll(resp.Body)
+	data, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	// Check status code
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("server returned status %d: %s", resp.StatusCode, string(body))
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return nil, &StatusError{Code: resp.StatusCode, Body: string(data)}
+	}
+
+	return data, nil
+}
+
+// SubmitPlan starts a plan on the agent
+func (c *Client) SubmitPlan(plan *testplan.Plan) (*Job, error) {
+	body, err := json.Marshal(plan)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.do(http.MethodPost, "plan", body)
+	if err != nil {
+		return nil, err
+	}
+	job := &Job{}
+	if err := json.Unmarshal(data, job); err != nil {
+		return nil, fmt.Errorf("invalid job response: %w", err)
+	}
+	return job, nil
+}
+
+// Job returns the plan the agent is running, or ran last. It returns nil
+// when the agent has not run a plan.
+func (c *Client) Job() (*Job, error) {
+	data, err := c.do(http.MethodGet, "plan", nil)
+	var status *StatusError
+	if errors.As(err, &status) && status.Code == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
 	}
+	job := &Job{}
+	if err := json.Unmarshal(data, job); err != nil {
+		return nil, fmt.Errorf("invalid job response: %w", err)
+	}
+	return job, nil
+}
 
-	return body, nil
+// RunResults returns every result of a run on the agent, following the
+// pages of /results
+func (c *Client) RunResults(runID int64) ([]*db.Result, error) {
+	var results []*db.Result
+	var cursor int64
+	for {
+		data, err := c.do(http.MethodGet, fmt.Sprintf("results?run=%d&cursor=%d", runID, cursor), nil)
+		if err != nil {
+			return nil, err
+		}
+		var page db.ResultPage
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("invalid results response: %w", err)
+		}
+		results = append(results, page.Results...)
+		if page.NextCursor == 0 {
+			return results, nil
+		}
+		cursor = page.NextCursor
+	}
 }
 
 // CheckHealth checks if the agent is healthy
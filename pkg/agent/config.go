@@ -5,6 +5,11 @@ import (
 	"crypto/x509"
 	"fmt"
 	"os"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/hooks"
+	"github.com/mscrnt/project_fire/pkg/safety"
 )
 
 // Config contains configuration for the agent server
@@ -14,12 +19,27 @@ type Config struct {
 	KeyFile  string // Server private key file
 	CAFile   string // CA certificate file for client verification
 	LogFile  string // Optional log file path
+
+	// SMARTInterval is the time between SMART snapshots of every drive,
+	// saved to the results database; 0 disables them
+	SMARTInterval time.Duration
+
+	// Retention is how long the results database keeps data, applied every
+	// Retention.Every(); an empty policy keeps everything
+	Retention db.Retention
+
+	// Hooks run before and after every test of a plan sent to /plan
+	Hooks hooks.Config
+
+	// Limits are the readings at which every test of a plan is stopped
+	Limits safety.Limits
 }
 
 // DefaultConfig returns default agent configuration
 func DefaultConfig() Config {
 	return Config{
-		Port: 2223,
+		Port:          2223,
+		SMARTInterval: DefaultSMARTInterval,
 	}
 }
 
@@ -4,7 +4,6 @@ package agent
 import (
 	"bufio"
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"os"
 	"runtime"
@@ -12,6 +11,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/mscrnt/project_fire/pkg/security"
+	"github.com/mscrnt/project_fire/pkg/sensors"
 	"github.com/shirou/gopsutil/v3/cpu"
 	"github.com/shirou/gopsutil/v3/disk"
 	"github.com/shirou/gopsutil/v3/host"
@@ -21,12 +22,13 @@ import (
 
 // SysInfo contains system information
 type SysInfo struct {
-	Timestamp time.Time     `json:"timestamp"`
-	Host      HostInfo      `json:"host"`
-	CPU       CPUInfo       `json:"cpu"`
-	Memory    MemoryInfo    `json:"memory"`
-	Disk      []DiskInfo    `json:"disk"`
-	Network   []NetworkInfo `json:"network"`
+	Timestamp time.Time        `json:"timestamp"`
+	Host      HostInfo         `json:"host"`
+	CPU       CPUInfo          `json:"cpu"`
+	Memory    MemoryInfo       `json:"memory"`
+	Disk      []DiskInfo       `json:"disk"`
+	Network   []NetworkInfo    `json:"network"`
+	Security  *security.Status `json:"security"`
 }
 
 // HostInfo contains host information
@@ -131,6 +133,9 @@ func sysinfoHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Get disk encryption, Secure Boot and TPM state
+	info.Security = security.Collect()
+
 	// Get disk info
 	if partitions, err := disk.Partitions(false); err == nil {
 		for _, partition := range partitions {
@@ -245,30 +250,65 @@ func logsHandler(w http.ResponseWriter, r *http.Request) {
 
 // SensorsInfo contains sensor data
 type SensorsInfo struct {
-	Timestamp   time.Time         `json:"timestamp"`
-	Temperature []TemperatureInfo `json:"temperature"`
-	Fans        []FanInfo         `json:"fans"`
-	GPU         []GPUInfo         `json:"gpu,omitempty"`
+	Timestamp          time.Time         `json:"timestamp"`
+	Provider           string            `json:"provider"` // Sensor backend, e.g. "hwmon"
+	CPUTemperature     float64           `json:"cpu_temperature_c,omitempty"`
+	PackagePower       float64           `json:"package_power_w,omitempty"`
+	PowerSource        string            `json:"power_source,omitempty"`      // e.g. "intel-rapl"
+	PowerUnavailable   string            `json:"power_unavailable,omitempty"` // Why package power cannot be read
+	CPUVoltage         float64           `json:"cpu_voltage_v,omitempty"`
+	CoreVoltages       []float64         `json:"core_voltages_v,omitempty"`
+	VoltageSource      string            `json:"voltage_source,omitempty"`      // e.g. "msr"
+	VoltageUnavailable string            `json:"voltage_unavailable,omitempty"` // Why the CPU voltage cannot be read
+	CoreClocks         []float64         `json:"core_clocks_mhz,omitempty"`
+	VRMTemperature     float64           `json:"vrm_temperature_c,omitempty"`
+	Temperature        []TemperatureInfo `json:"temperature"`
+	Fans               []FanInfo         `json:"fans"`
+	Voltages           []VoltageInfo     `json:"voltages,omitempty"`
+	SystemPower        float64           `json:"system_power_w,omitempty"` // Power supplies' input power, from the BMC
+	Power              []PowerInfo       `json:"power,omitempty"`          // Power sensors of the BMC
+	GPU                []GPUInfo         `json:"gpu,omitempty"`
 }
 
 // TemperatureInfo contains temperature sensor data
 type TemperatureInfo struct {
 	Name        string  `json:"name"`
+	Source      string  `json:"source,omitempty"`
 	Temperature float64 `json:"temperature_c"`
 	Critical    float64 `json:"critical_c,omitempty"`
 }
 
 // FanInfo contains fan sensor data
 type FanInfo struct {
-	Name  string `json:"name"`
-	Speed int    `json:"speed_rpm"`
+	Name   string `json:"name"`
+	Source string `json:"source,omitempty"`
+	Speed  int    `json:"speed_rpm"`
+}
+
+// VoltageInfo contains voltage sensor data
+type VoltageInfo struct {
+	Name    string  `json:"name"`
+	Source  string  `json:"source,omitempty"`
+	Voltage float64 `json:"voltage_v"`
+}
+
+// PowerInfo contains power sensor data
+type PowerInfo struct {
+	Name   string  `json:"name"`
+	Source string  `json:"source,omitempty"`
+	Power  float64 `json:"power_w"`
 }
 
 // GPUInfo contains GPU sensor data
 type GPUInfo struct {
 	Index       int     `json:"index"`
 	Name        string  `json:"name"`
+	Source      string  `json:"source,omitempty"`
 	Temperature float64 `json:"temperature_c"`
+	Hotspot     float64 `json:"hotspot_c,omitempty"`
+	CoreClock   float64 `json:"core_clock_mhz,omitempty"`
+	CoreVoltage float64 `json:"core_voltage_v,omitempty"`
+	Power       float64 `json:"power_w,omitempty"`
 	MemoryUsed  uint64  `json:"memory_used"`
 	MemoryTotal uint64  `json:"memory_total"`
 	Utilization int     `json:"utilization_percent"`
@@ -289,67 +329,48 @@ func sensorsHandler(w http.ResponseWriter, r *http.Request) {
 		GPU:         []GPUInfo{},
 	}
 
-	// Try to get CPU temperature (Linux-specific)
-	if runtime.GOOS == "linux" {
-		// Check thermal zones
-		thermalDir := "/sys/class/thermal"
-		if entries, err := os.ReadDir(thermalDir); err == nil {
-			for _, entry := range entries {
-				if strings.HasPrefix(entry.Name(), "thermal_zone") {
-					zonePath := fmt.Sprintf("%s/%s", thermalDir, entry.Name())
-
-					// Read temperature
-					if tempData, err := os.ReadFile(fmt.Sprintf("%s/temp", zonePath)); err == nil {
-						if temp, err := strconv.Atoi(strings.TrimSpace(string(tempData))); err == nil {
-							// Read type
-							typeData, _ := os.ReadFile(fmt.Sprintf("%s/type", zonePath))
-							typeName := strings.TrimSpace(string(typeData))
-							if typeName == "" {
-								typeName = entry.Name()
-							}
-
-							info.Temperature = append(info.Temperature, TemperatureInfo{
-								Name:        typeName,
-								Temperature: float64(temp) / 1000.0, // Convert millidegrees to degrees
-							})
-						}
-					}
-				}
-			}
+	// The same sensors the dashboard shows
+	provider := sensors.Default()
+	info.Provider = provider.Name()
+	if snapshot, err := provider.Read(r.Context()); err == nil {
+		info.CPUTemperature = snapshot.CPUTemp
+		info.PackagePower = snapshot.PackagePower
+		info.PowerSource = snapshot.PowerSource
+		info.PowerUnavailable = snapshot.PowerUnavailable
+		info.CPUVoltage = snapshot.CPUVoltage
+		info.CoreVoltages = snapshot.CoreVoltages
+		info.VoltageSource = snapshot.VoltageSource
+		info.VoltageUnavailable = snapshot.VoltageUnavailable
+		info.CoreClocks = snapshot.CoreClocks
+		info.VRMTemperature = snapshot.VRMTemp
+		for _, t := range snapshot.Temperatures {
+			info.Temperature = append(info.Temperature, TemperatureInfo{Name: t.Name, Source: t.Source, Temperature: t.Value, Critical: t.Critical})
 		}
-
-		// Check hwmon for fan speeds
-		hwmonDir := "/sys/class/hwmon"
-		if entries, err := os.ReadDir(hwmonDir); err == nil {
-			for _, entry := range entries {
-				hwmonPath := fmt.Sprintf("%s/%s", hwmonDir, entry.Name())
-
-				// Look for fan inputs
-				for i := 1; i <= 10; i++ {
-					fanPath := fmt.Sprintf("%s/fan%d_input", hwmonPath, i)
-					if fanData, err := os.ReadFile(fanPath); err == nil { // nolint:gosec // G304 - path from fixed sysfs location
-						if speed, err := strconv.Atoi(strings.TrimSpace(string(fanData))); err == nil {
-							// Try to get fan label
-							labelPath := fmt.Sprintf("%s/fan%d_label", hwmonPath, i)
-							label := fmt.Sprintf("Fan %d", i)
-							if labelData, err := os.ReadFile(labelPath); err == nil { // nolint:gosec // G304 - path from fixed sysfs location
-								label = strings.TrimSpace(string(labelData))
-							}
-
-							info.Fans = append(info.Fans, FanInfo{
-								Name:  label,
-								Speed: speed,
-							})
-						}
-					}
-				}
-			}
+		for _, f := range snapshot.Fans {
+			info.Fans = append(info.Fans, FanInfo{Name: f.Name, Source: f.Source, Speed: int(f.Value)})
+		}
+		for _, v := range snapshot.Voltages {
+			info.Voltages = append(info.Voltages, VoltageInfo{Name: v.Name, Source: v.Source, Voltage: v.Value})
+		}
+		info.SystemPower = snapshot.SystemPower
+		for _, p := range snapshot.Power {
+			info.Power = append(info.Power, PowerInfo{Name: p.Name, Source: p.Source, Power: p.Value})
+		}
+		// GPUs are read by hardware monitors such as LibreHardwareMonitor
+		for i, g := range snapshot.GPUs {
+			info.GPU = append(info.GPU, GPUInfo{
+				Index:       i,
+				Name:        g.Name,
+				Source:      info.Provider,
+				Temperature: g.Temp,
+				Hotspot:     g.Hotspot,
+				CoreClock:   g.CoreClock,
+				CoreVoltage: g.CoreVoltage,
+				Power:       g.Power,
+			})
 		}
 	}
 
-	// Note: GPU sensor support would require NVML bindings
-	// This is a placeholder that could be extended with proper GPU support
-
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(info); err != nil {
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
@@ -0,0 +1,248 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/testplan"
+)
+
+// maxPlanSize caps the body of a plan request
+const maxPlanSize = 1 << 20
+
+// Job states
+const (
+	JobRunning = "running"
+	JobDone    = "done"
+)
+
+// CheckPlan refuses plans the agent cannot run unattended: a prompt stage
+// needs an operator at the console, and the agent never pins the fans
+func CheckPlan(p *testplan.Plan) error {
+	for i, stage := range p.Stages {
+		if stage.Kind() == testplan.KindPrompt {
+			return fmt.Errorf("stage %d: prompts need an operator at the console", i+1)
+		}
+		if stage.Fans > 0 {
+			return fmt.Errorf("stage %d: the agent does not pin fans", i+1)
+		}
+	}
+	return nil
+}
+
+// Job is a test plan run by the agent. Stages holds each stage started so
+// far and Runs the run record of each test the ended stages started, in
+// plan order.
+type Job struct {
+	ID       string                  `json:"id"`
+	Plan     *testplan.Plan          `json:"plan"`
+	State    string                  `json:"state"` // JobRunning or JobDone
+	Started  time.Time               `json:"started"`
+	Finished *time.Time              `json:"finished,omitempty"`
+	Run      *testplan.PlanRun       `json:"run,omitempty"` // Set once the plan ends
+	Stages   []*testplan.StageResult `json:"stages"`
+	Runs     []*db.Run               `json:"runs"`
+	Error    string                  `json:"error,omitempty"` // Why the plan did not pass
+}
+
+// Passed reports whether the job finished with every stage passing
+func (j *Job) Passed() bool {
+	return j.State == JobDone && j.Error == "" && j.Run != nil && j.Run.Success
+}
+
+// StagesDone returns how many stages of the plan have ended
+func (j *Job) StagesDone() int {
+	done := 0
+	for _, stage := range j.Stages {
+		if stage.Status != testplan.StatusRunning {
+			done++
+		}
+	}
+	return done
+}
+
+// planRunner runs one plan at a time on the agent
+type planRunner struct {
+	mu     sync.Mutex
+	job    *Job
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// planHandler starts a plan (POST) or reports the current or last one (GET)
+func (s *Server) planHandler(w http.ResponseWriter, r *http.Request) {
+	if s.database == nil {
+		http.Error(w, "Results database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		job := s.plans.snapshot()
+		if job == nil {
+			http.Error(w, "No plan has been run", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, job)
+
+	case http.MethodPost:
+		data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPlanSize))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid plan: %v", err), http.StatusBadRequest)
+			return
+		}
+		// Parse validates every stage, including the parameters of its
+		// plugins, so a bad plan is refused before anything runs
+		plan, err := testplan.Parse(data, !strings.Contains(r.Header.Get("Content-Type"), "yaml"))
+		if err == nil {
+			err = CheckPlan(plan)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		job, err := s.startPlan(plan)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		s.logger.Printf("Started plan %q (%d stages) as job %s", plan.Name, len(plan.Stages), job.ID)
+		w.WriteHeader(http.StatusAccepted)
+		writeJSON(w, job)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// startPlan runs plan in the background unless another plan is running
+func (s *Server) startPlan(plan *testplan.Plan) (*Job, error) {
+	p := &s.plans
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.job != nil && p.job.State == JobRunning {
+		return nil, fmt.Errorf("job %s is still running plan %q", p.job.ID, p.job.Plan.Name)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.job = &Job{
+		ID:      db.NewRunUUID(),
+		Plan:    plan,
+		State:   JobRunning,
+		Started: time.Now(),
+		Stages:  []*testplan.StageResult{},
+		Runs:    []*db.Run{},
+	}
+	p.cancel = cancel
+	p.done = make(chan struct{})
+	go s.runPlan(ctx, p.job, p.done)
+	return p.copyJob(), nil
+}
+
+// runPlan runs the stages of a job like bench plan run does, without an
+// operator or fan control
+func (s *Server) runPlan(ctx context.Context, job *Job, done chan struct{}) {
+	defer close(done)
+
+	runner := testplan.NewRunner(s.database, s.logger)
+	runner.SetHooks(s.config.Hooks)
+	runner.SetLimits(s.config.Limits)
+	runner.SetProgress(func(_ testplan.Stage, result *testplan.StageResult) {
+		s.recordStage(job, result)
+	})
+	run, err := runner.Run(ctx, job.Plan, "agent job "+job.ID)
+
+	s.plans.mu.Lock()
+	finished := time.Now()
+	job.State = JobDone
+	job.Finished = &finished
+	job.Run = run
+	switch {
+	case err != nil:
+		job.Error = err.Error()
+	case ctx.Err() != nil:
+		job.Error = "agent shut down before the plan finished"
+	case !run.Success:
+		job.Error = run.Error
+	}
+	s.plans.mu.Unlock()
+	s.logger.Printf("Job %s finished", job.ID)
+}
+
+// recordStage keeps a copy of a stage as it starts and ends. An ended
+// stage adds the records of the runs it started to the job.
+func (s *Server) recordStage(job *Job, result *testplan.StageResult) {
+	stage := *result
+	stage.RunIDs = append([]int64(nil), result.RunIDs...)
+	var runs []*db.Run
+	if stage.Status != testplan.StatusRunning {
+		for _, id := range stage.RunIDs {
+			run, err := s.database.GetRun(id)
+			if err != nil {
+				s.logger.Printf("Job %s stage %d: %v", job.ID, stage.Position, err)
+				continue
+			}
+			runs = append(runs, run)
+		}
+	}
+
+	s.plans.mu.Lock()
+	defer s.plans.mu.Unlock()
+	if n := len(job.Stages); n > 0 && job.Stages[n-1].Position == stage.Position {
+		job.Stages[n-1] = &stage
+	} else {
+		job.Stages = append(job.Stages, &stage)
+	}
+	job.Runs = append(job.Runs, runs...)
+}
+
+// snapshot returns a copy of the current job, or nil
+func (p *planRunner) snapshot() *Job {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.copyJob()
+}
+
+// copyJob copies the job so it can be encoded while the plan goes on. Stages
+// and runs are replaced rather than changed, so they are shared. The caller
+// holds p.mu.
+func (p *planRunner) copyJob() *Job {
+	if p.job == nil {
+		return nil
+	}
+	job := *p.job
+	job.Stages = append([]*testplan.StageResult(nil), p.job.Stages...)
+	job.Runs = append([]*db.Run(nil), p.job.Runs...)
+	return &job
+}
+
+// stop cancels the running plan and waits for its step to be recorded
+func (p *planRunner) stop(ctx context.Context) {
+	p.mu.Lock()
+	cancel := p.cancel
+	p.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	p.wait(ctx)
+}
+
+// wait blocks until the running plan finishes or ctx ends
+func (p *planRunner) wait(ctx context.Context) {
+	p.mu.Lock()
+	done := p.done
+	p.mu.Unlock()
+	if done == nil {
+		return
+	}
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,110 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/plugin"
+)
+
+// planTestPlugin passes unless its config sets fail=true, and rejects an
+// unknown mode
+type planTestPlugin struct{}
+
+func (planTestPlugin) Name() string        { return "plan-test" }
+func (planTestPlugin) Description() string { return "Test plugin for plans" }
+func (planTestPlugin) DefaultParams() plugin.Params {
+	return plugin.Params{Duration: time.Second, Threads: 1}
+}
+func (planTestPlugin) ValidateParams(params plugin.Params) error {
+	if mode, ok := params.Config["mode"]; ok && mode != "fast" {
+		return fmt.Errorf("unknown mode %v", mode)
+	}
+	return nil
+}
+func (planTestPlugin) Run(_ context.Context, params plugin.Params) (plugin.Result, error) {
+	if params.Config["fail"] == true {
+		return plugin.Result{Error: "stability check failed"}, errors.New("stability check failed")
+	}
+	return plugin.Result{Success: true, Metrics: map[string]float64{"score": float64(params.Threads)}}, nil
+}
+
+func init() {
+	_ = plugin.Register(planTestPlugin{})
+}
+
+func TestPlanHandler(t *testing.T) {
+	t.Setenv("FIRE_ARTIFACTS", t.TempDir())
+	s, _ := newResultsServer(t)
+
+	rr := httptest.NewRecorder()
+	s.planHandler(rr, httptest.NewRequest("GET", "/plan", http.NoBody))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 before any plan, got %d", rr.Code)
+	}
+
+	for _, bad := range []string{
+		`{"name":"bad","stages":[{"plugin":"missing"}]}`,
+		`{"name":"bad","stages":[{"plugin":"plan-test","config":{"mode":"slow"}}]}`,
+		`{"name":"bad","stages":[{"prompt":"Check the fans"}]}`,
+		`{"name":"bad","stages":[{"plugin":"plan-test","fans":100}]}`,
+	} {
+		rr = httptest.NewRecorder()
+		s.planHandler(rr, httptest.NewRequest("POST", "/plan", strings.NewReader(bad)))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got %d", bad, rr.Code)
+		}
+	}
+
+	body := `{"name":"burn-in","continue_on_failure":true,"stages":[
+		{"plugin":"plan-test","threads":4},
+		{"plugin":"plan-test","config":{"fail":true}},
+		{"pause":"10ms"},
+		{"plugin":"plan-test","duration":"2s"}]}`
+	rr = httptest.NewRecorder()
+	s.planHandler(rr, httptest.NewRequest("POST", "/plan", strings.NewReader(body)))
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var job Job
+	if err := json.Unmarshal(rr.Body.Bytes(), &job); err != nil {
+		t.Fatal(err)
+	}
+	if job.ID == "" || job.State != JobRunning {
+		t.Errorf("expected a running job, got %+v", job)
+	}
+
+	s.plans.wait(context.Background())
+	rr = httptest.NewRecorder()
+	s.planHandler(rr, httptest.NewRequest("GET", "/plan", http.NoBody))
+	if err := json.Unmarshal(rr.Body.Bytes(), &job); err != nil {
+		t.Fatal(err)
+	}
+	if job.State != JobDone || len(job.Stages) != 4 || len(job.Runs) != 3 || job.StagesDone() != 4 {
+		t.Fatalf("expected a finished job with 4 stages and 3 runs, got %+v", job)
+	}
+	if job.Run == nil || job.Run.Success || !strings.Contains(job.Error, "stage 2") {
+		t.Errorf("expected the plan run to fail at stage 2, got %+v, %q", job.Run, job.Error)
+	}
+	if !job.Runs[0].Success || job.Runs[1].Success || job.Runs[1].Error != "stability check failed" {
+		t.Errorf("expected the second step to fail, got %+v %+v", job.Runs[0], job.Runs[1])
+	}
+	if job.Passed() {
+		t.Error("expected the job not to pass")
+	}
+
+	results, err := s.database.GetResults(job.Runs[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Value != 4 {
+		t.Errorf("expected the score of the first step, got %+v", results)
+	}
+}
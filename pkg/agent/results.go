@@ -0,0 +1,200 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+// maxSeriesPoints caps the buckets a series request may ask for
+const maxSeriesPoints = 10000
+
+// SeriesResponse is a downsampled metric returned by /results/series
+type SeriesResponse struct {
+	Metric       string      `json:"metric"`
+	WidthSeconds int64       `json:"width_seconds"`
+	Buckets      []db.Bucket `json:"buckets"`
+}
+
+// SetDatabase serves results from database on /results and /results/series.
+// Without a database those endpoints return 503.
+func (s *Server) SetDatabase(database *db.DB) {
+	s.database = database
+}
+
+// resultsHandler returns one page of results.
+//
+// Query parameters: run, metric, since and until (RFC 3339), limit, cursor.
+func (s *Server) resultsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.database == nil {
+		http.Error(w, "Results database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	q := r.URL.Query()
+	filter, err := parseResultFilter(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var cursor int64
+	if v := q.Get("cursor"); v != "" {
+		if cursor, err = strconv.ParseInt(v, 10, 64); err != nil || cursor < 0 {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil || filter.Limit <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := s.database.PageResults(filter, cursor)
+	if err != nil {
+		s.logger.Printf("Failed to page results: %v", err)
+		http.Error(w, "Failed to query results", http.StatusInternalServerError)
+		return
+	}
+	if page.Results == nil {
+		page.Results = []*db.Result{}
+	}
+	writeJSON(w, page)
+}
+
+// seriesHandler returns a metric downsampled to avg/min/max per bucket.
+//
+// Query parameters: metric (required), run, since and until (RFC 3339), and
+// either width (a duration such as 30s) or points. points needs a time range,
+// which defaults to the run's start and end.
+func (s *Server) seriesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.database == nil {
+		http.Error(w, "Results database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	q := r.URL.Query()
+	filter, err := parseResultFilter(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.Metric == "" {
+		http.Error(w, "metric is required", http.StatusBadRequest)
+		return
+	}
+	series := db.SeriesFilter{RunID: filter.RunID, Metric: filter.Metric, Since: filter.Since, Until: filter.Until}
+
+	switch {
+	case q.Get("width") != "":
+		if series.Width, err = time.ParseDuration(q.Get("width")); err != nil || series.Width <= 0 {
+			http.Error(w, "Invalid width", http.StatusBadRequest)
+			return
+		}
+	case q.Get("points") != "":
+		points, err := strconv.Atoi(q.Get("points"))
+		if err != nil || points <= 0 || points > maxSeriesPoints {
+			http.Error(w, fmt.Sprintf("points must be between 1 and %d", maxSeriesPoints), http.StatusBadRequest)
+			return
+		}
+		since, until, err := s.seriesRange(series)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		series.Width = db.BucketWidth(since, until, points)
+	default:
+		http.Error(w, "width or points is required", http.StatusBadRequest)
+		return
+	}
+
+	buckets, err := s.database.MetricSeries(series)
+	if err != nil {
+		s.logger.Printf("Failed to query metric series: %v", err)
+		http.Error(w, "Failed to query results", http.StatusInternalServerError)
+		return
+	}
+	if buckets == nil {
+		buckets = []db.Bucket{}
+	}
+	writeJSON(w, SeriesResponse{
+		Metric:       series.Metric,
+		WidthSeconds: int64((series.Width + time.Second - 1) / time.Second), // As rounded by MetricSeries
+		Buckets:      buckets,
+	})
+}
+
+// seriesRange resolves the time range to split into points, falling back to
+// the run's start and end
+func (s *Server) seriesRange(series db.SeriesFilter) (since, until time.Time, err error) {
+	if series.Since != nil {
+		since = *series.Since
+	}
+	if series.Until != nil {
+		until = *series.Until
+	}
+	if series.RunID != nil && (since.IsZero() || until.IsZero()) {
+		run, err := s.database.GetRun(*series.RunID)
+		if err != nil {
+			return since, until, fmt.Errorf("run %d not found", *series.RunID)
+		}
+		if since.IsZero() {
+			since = run.StartTime
+		}
+		if until.IsZero() {
+			until = time.Now()
+			if run.EndTime != nil {
+				until = *run.EndTime
+			}
+		}
+	}
+	if since.IsZero() || until.IsZero() {
+		return since, until, errors.New("points needs since and until, or a run")
+	}
+	return since, until, nil
+}
+
+// parseResultFilter reads the run, metric, since and until parameters
+func parseResultFilter(q url.Values) (db.ResultFilter, error) {
+	filter := db.ResultFilter{Metric: q.Get("metric")}
+	if v := q.Get("run"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("invalid run %q", v)
+		}
+		filter.RunID = &id
+	}
+	for name, dst := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s %q, expected RFC 3339", name, v)
+			}
+			*dst = &t
+		}
+	}
+	return filter, nil
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,98 @@
+package agent
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+func newResultsServer(t *testing.T) (*Server, int64) {
+	t.Helper()
+	database, err := db.Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+
+	run, err := database.CreateRun("cpu", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 60; i++ {
+		if _, err := database.Exec(
+			`INSERT INTO results (run_id, metric, value, unit, created_at) VALUES (?, ?, ?, ?, ?)`,
+			run.ID, "temp_c", float64(i), "C", start.Add(time.Duration(i)*time.Second),
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := &Server{logger: log.New(io.Discard, "", 0)}
+	s.SetDatabase(database)
+	return s, run.ID
+}
+
+func TestResultsHandlerPages(t *testing.T) {
+	s, _ := newResultsServer(t)
+
+	rr := httptest.NewRecorder()
+	s.resultsHandler(rr, httptest.NewRequest("GET", "/results?metric=temp_c&limit=25&cursor=0", http.NoBody))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var page db.ResultPage
+	if err := json.Unmarshal(rr.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Results) != 25 || page.NextCursor == 0 {
+		t.Errorf("expected a full page with a cursor, got %d results, cursor %d", len(page.Results), page.NextCursor)
+	}
+
+	rr = httptest.NewRecorder()
+	s.resultsHandler(rr, httptest.NewRequest("GET", "/results?since=yesterday", http.NoBody))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid time, got %d", rr.Code)
+	}
+}
+
+func TestSeriesHandler(t *testing.T) {
+	s, runID := newResultsServer(t)
+
+	rr := httptest.NewRecorder()
+	url := "/results/series?metric=temp_c&width=30s&run=" + strconv.FormatInt(runID, 10)
+	s.seriesHandler(rr, httptest.NewRequest("GET", url, http.NoBody))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var series SeriesResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &series); err != nil {
+		t.Fatal(err)
+	}
+	if series.WidthSeconds != 30 || len(series.Buckets) != 2 || series.Buckets[1].Max != 59 {
+		t.Errorf("expected two 30s buckets, got %+v", series)
+	}
+
+	rr = httptest.NewRecorder()
+	s.seriesHandler(rr, httptest.NewRequest("GET", "/results/series?metric=temp_c&points=10", http.NoBody))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for points without a range, got %d", rr.Code)
+	}
+}
+
+func TestResultsHandlerWithoutDatabase(t *testing.T) {
+	s := &Server{logger: log.New(io.Discard, "", 0)}
+	rr := httptest.NewRecorder()
+	s.resultsHandler(rr, httptest.NewRequest("GET", "/results", http.NoBody))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,27 @@
+package agent
+
+import "time"
+
+// startPruning applies the retention policy now and then every
+// Retention.Every() until Shutdown, so an agent left running for months does
+// not fill the disk with samples. It does nothing without a database or
+// policy.
+func (s *Server) startPruning() {
+	if s.database == nil || !s.config.Retention.Enabled() {
+		return
+	}
+	s.pruning = startTask(s.config.Retention.Every(), func() {
+		opts, err := s.config.Retention.Options(time.Now())
+		if err != nil {
+			s.logger.Printf("Retention policy: %v", err)
+			return
+		}
+		result, err := s.database.Prune(opts)
+		if err != nil {
+			s.logger.Printf("Pruning: %v", err)
+			return
+		}
+		s.logger.Printf("Pruned %d runs, %d plan runs, %d events and %d snapshots; averaged %d samples into %d buckets",
+			result.Runs, result.PlanRuns, result.Events, result.Snapshots, result.Samples, result.Buckets)
+	})
+}
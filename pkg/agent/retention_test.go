@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"context"
+	"io"
+	"log"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+func TestPruning(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = database.Close() }()
+
+	run, err := database.CreateRun("cpu", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := time.Now().Add(-48 * time.Hour)
+	if _, err := database.Exec(`UPDATE runs SET start_time = ?, end_time = ? WHERE id = ?`, end.Add(-time.Hour), end, run.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{
+		config:   Config{Retention: db.Retention{Runs: "1d", Interval: "10ms"}},
+		logger:   log.New(io.Discard, "", 0),
+		database: database,
+	}
+	s.startPruning()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := database.GetRun(run.ID); err != nil {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	s.pruning.stop(context.Background())
+	if _, err := database.GetRun(run.ID); err == nil {
+		t.Error("expected the agent to prune the old run")
+	}
+}
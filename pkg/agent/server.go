@@ -8,6 +8,9 @@ import (
 	"net/http"
 	"os"
 	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/smart"
 )
 
 // Server represents the agent server
@@ -15,6 +18,10 @@ type Server struct {
 	config     Config
 	httpServer *http.Server
 	logger     *log.Logger
+	database   *db.DB // Serves /results and runs /plan; nil disables them
+	plans      planRunner
+	smart      task // Saves SMART snapshots
+	pruning    task // Applies the retention policy
 }
 
 // NewServer creates a new agent server
@@ -45,6 +52,9 @@ func NewServer(config Config) (*Server, error) {
 	mux.HandleFunc("/logs", server.loggingMiddleware(logsHandler))
 	mux.HandleFunc("/sensors", server.loggingMiddleware(sensorsHandler))
 	mux.HandleFunc("/health", server.loggingMiddleware(healthHandler))
+	mux.HandleFunc("/results", server.loggingMiddleware(server.resultsHandler))
+	mux.HandleFunc("/results/series", server.loggingMiddleware(server.seriesHandler))
+	mux.HandleFunc("/plan", server.loggingMiddleware(server.planHandler))
 
 	// Load TLS config
 	tlsConfig, err := config.LoadTLSConfig()
@@ -68,6 +78,8 @@ func NewServer(config Config) (*Server, error) {
 // Start starts the agent server
 func (s *Server) Start() error {
 	s.logger.Printf("Starting agent server on port %d with mTLS", s.config.Port)
+	s.startSMART(smart.Record)
+	s.startPruning()
 
 	// Note: We use ListenAndServeTLS with empty cert/key paths because
 	// the certificates are already loaded in the TLS config
@@ -79,10 +91,53 @@ func (s *Server) Start() error {
 	return nil
 }
 
-// Shutdown gracefully shuts down the server
+// Shutdown gracefully shuts down the server, stopping a running plan once
+// its current step is recorded, the SMART snapshots and pruning
 func (s *Server) Shutdown(ctx context.Context) error {
 	s.logger.Println("Shutting down agent server...")
-	return s.httpServer.Shutdown(ctx)
+	err := s.httpServer.Shutdown(ctx)
+	s.plans.stop(ctx)
+	s.smart.stop(ctx)
+	s.pruning.stop(ctx)
+	return err
+}
+
+// task runs in the background while the agent serves
+type task struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// startTask calls fn now and then every interval until the task is stopped
+func startTask(interval time.Duration, fn func()) task {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			fn()
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return task{cancel: cancel, done: done}
+}
+
+// stop ends the task, waiting for a call in progress until ctx ends
+func (t *task) stop(ctx context.Context) {
+	if t.cancel == nil {
+		return
+	}
+	t.cancel()
+	select {
+	case <-t.done:
+	case <-ctx.Done():
+	}
 }
 
 // loggingMiddleware logs incoming requests
@@ -0,0 +1,30 @@
+package agent
+
+import (
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/smart"
+)
+
+// DefaultSMARTInterval is the time between SMART snapshots of every drive
+const DefaultSMARTInterval = time.Hour
+
+// smartRecordFunc saves a snapshot of every drive
+type smartRecordFunc func(database *db.DB, at time.Time) ([]*smart.Data, error)
+
+// startSMART snapshots the drives now and then every interval until
+// Shutdown, so bench show can tell what changed on the drives between runs.
+// It does nothing without a database or interval.
+func (s *Server) startSMART(record smartRecordFunc) {
+	if s.database == nil || s.config.SMARTInterval <= 0 {
+		return
+	}
+	s.smart = startTask(s.config.SMARTInterval, func() {
+		saved, err := record(s.database, time.Now())
+		if err != nil {
+			s.logger.Printf("SMART snapshot: %v", err)
+		}
+		s.logger.Printf("Saved SMART snapshots of %d drives", len(saved))
+	})
+}
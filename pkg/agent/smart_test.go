@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"context"
+	"io"
+	"log"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/smart"
+)
+
+func TestSMARTSnapshots(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = database.Close() }()
+
+	s := &Server{config: Config{SMARTInterval: 10 * time.Millisecond}, logger: log.New(io.Discard, "", 0)}
+	var calls atomic.Int32
+	record := func(database *db.DB, at time.Time) ([]*smart.Data, error) {
+		calls.Add(1)
+		d := &smart.Data{Device: "/dev/nvme0n1", Available: true}
+		return []*smart.Data{d}, smart.Save(database, d, at)
+	}
+
+	// Without a database there is nowhere to save them
+	s.startSMART(record)
+	s.smart.stop(context.Background())
+	if calls.Load() != 0 {
+		t.Fatal("expected no snapshots without a database")
+	}
+
+	s.SetDatabase(database)
+	s.startSMART(record)
+	deadline := time.Now().Add(5 * time.Second)
+	for calls.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	s.smart.stop(context.Background())
+	n := calls.Load()
+	if n < 3 {
+		t.Fatalf("expected a snapshot every interval, got %d", n)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if calls.Load() != n {
+		t.Error("expected no snapshots after stopping")
+	}
+
+	snapshots, err := database.ListSMARTSnapshots(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != int(n) {
+		t.Errorf("expected %d saved snapshots, got %d", n, len(snapshots))
+	}
+}
@@ -0,0 +1,256 @@
+// Package alerts raises alerts when sensor readings cross user-defined
+// thresholds.
+//
+// A rule names a metric the way bench monitor does (cpu_temp, vrm_temp,
+// gpu0_hotspot, fan_cpu_fan, ...; a pattern such as gpu*_temp matches every
+// GPU), a comparator, a threshold and how long the reading must stay past
+// it. An Engine checks the rules against every set of readings: an alert
+// fires once its condition has held for the rule's duration and resolves
+// when the condition clears. Alerts go to the configured channels, desktop
+// notifications in the GUI, email and webhooks, and are kept in the
+// database as alert history.
+//
+// Rules and channels are stored together as JSON in ~/.fire/alerts.json, or
+// $FIRE_ALERTS, so the GUI and bench monitor share them.
+package alerts
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/mail"
+)
+
+// Comparator is how a reading is compared with a rule's threshold
+type Comparator string
+
+// Comparators
+const (
+	Above   Comparator = ">"
+	AtLeast Comparator = ">="
+	Below   Comparator = "<"
+	AtMost  Comparator = "<="
+)
+
+// Comparators lists every comparator, for selection in the GUI
+var Comparators = []Comparator{Above, AtLeast, Below, AtMost}
+
+// ParseComparator accepts a comparator symbol or its name, e.g. ">" or
+// "above"
+func ParseComparator(s string) (Comparator, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case ">", "above", "gt":
+		return Above, nil
+	case ">=", "gte", "ge":
+		return AtLeast, nil
+	case "<", "below", "lt":
+		return Below, nil
+	case "<=", "lte", "le":
+		return AtMost, nil
+	}
+	return "", fmt.Errorf("unknown comparator %q, expected >, >=, < or <=", s)
+}
+
+// Holds reports whether value compared with threshold meets the condition
+func (c Comparator) Holds(value, threshold float64) bool {
+	switch c {
+	case Above:
+		return value > threshold
+	case AtLeast:
+		return value >= threshold
+	case Below:
+		return value < threshold
+	case AtMost:
+		return value <= threshold
+	}
+	return false
+}
+
+// Rule raises an alert when a metric stays past a threshold
+type Rule struct {
+	Name       string     `json:"name"`
+	Metric     string     `json:"metric"` // Metric name or pattern, e.g. "cpu_temp" or "gpu*_temp"
+	Comparator Comparator `json:"comparator"`
+	Threshold  float64    `json:"threshold"`
+	For        string     `json:"for,omitempty"` // How long the condition must hold, e.g. "30s"; empty fires on the first reading
+	Disabled   bool       `json:"disabled,omitempty"`
+}
+
+// Validate checks that the rule can be evaluated
+func (r Rule) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return errors.New("rule name is required")
+	}
+	if strings.TrimSpace(r.Metric) == "" {
+		return fmt.Errorf("rule %q has no metric", r.Name)
+	}
+	if _, err := path.Match(r.Metric, ""); err != nil {
+		return fmt.Errorf("rule %q has an invalid metric pattern %q: %w", r.Name, r.Metric, err)
+	}
+	switch r.Comparator {
+	case Above, AtLeast, Below, AtMost:
+	default:
+		return fmt.Errorf("rule %q has an unknown comparator %q, expected >, >=, < or <=", r.Name, r.Comparator)
+	}
+	if _, err := r.Duration(); err != nil {
+		return fmt.Errorf("rule %q: %w", r.Name, err)
+	}
+	return nil
+}
+
+// Duration returns how long the condition must hold before the alert fires
+func (r Rule) Duration() (time.Duration, error) {
+	if r.For == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(r.For)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: %w", r.For, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", r.For)
+	}
+	return d, nil
+}
+
+// Matches reports whether the rule applies to a metric
+func (r Rule) Matches(metric string) bool {
+	ok, err := path.Match(r.Metric, metric)
+	return err == nil && ok
+}
+
+// String describes the rule's condition, e.g. "cpu_temp > 90 for 30s"
+func (r Rule) String() string {
+	s := fmt.Sprintf("%s %s %s", r.Metric, r.Comparator, strconv.FormatFloat(r.Threshold, 'f', -1, 64))
+	if r.For != "" {
+		s += " for " + r.For
+	}
+	return s
+}
+
+// EmailConfig is the SMTP server and recipients alerts are mailed to
+type EmailConfig struct {
+	mail.Config
+	To []string `json:"to"`
+}
+
+// Webhook is a URL every alert is posted to as JSON
+type Webhook struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Channels are where alerts are sent
+type Channels struct {
+	Desktop  bool         `json:"desktop"` // Desktop notifications while the GUI is open
+	Email    *EmailConfig `json:"email,omitempty"`
+	Webhooks []Webhook    `json:"webhooks,omitempty"`
+}
+
+// Config holds the alert rules and channels
+type Config struct {
+	Rules    []Rule   `json:"rules"`
+	Channels Channels `json:"channels"`
+}
+
+// Validate checks every rule and channel
+func (c *Config) Validate() error {
+	seen := make(map[string]bool)
+	for _, r := range c.Rules {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+		key := strings.ToLower(r.Name)
+		if seen[key] {
+			return fmt.Errorf("duplicate rule %q", r.Name)
+		}
+		seen[key] = true
+	}
+	if e := c.Channels.Email; e != nil {
+		if err := e.Config.Validate(); err != nil {
+			return fmt.Errorf("email alerts: %w", err)
+		}
+		if len(e.To) == 0 {
+			return errors.New("email alerts need at least one recipient")
+		}
+	}
+	for _, w := range c.Channels.Webhooks {
+		u, err := url.Parse(w.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid webhook URL %q", w.URL)
+		}
+	}
+	return nil
+}
+
+// Rule returns the position of the named rule, or -1. Names are matched
+// case-insensitively.
+func (c *Config) Rule(name string) int {
+	for i, r := range c.Rules {
+		if strings.EqualFold(r.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// DefaultPath returns where the alert configuration is stored
+func DefaultPath() string {
+	if path := os.Getenv("FIRE_ALERTS"); path != "" {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "alerts.json"
+	}
+	return filepath.Join(homeDir, ".fire", "alerts.json")
+}
+
+// Load reads the alert configuration. A missing file yields no rules, with
+// desktop notifications on.
+func Load(path string) (*Config, error) {
+	cfg := &Config{Channels: Channels{Desktop: true}}
+	data, err := os.ReadFile(path) // #nosec G304 -- alert configuration in the user's config directory
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return cfg, nil
+	case err != nil:
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse alerts %s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid alerts %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Save writes the configuration as indented JSON. The file may hold an SMTP
+// password, so only the user can read it.
+func Save(path string, cfg *Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	// Comparators are kept readable rather than escaped as HTML
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(cfg); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o600)
+}
@@ -0,0 +1,140 @@
+package alerts
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/mail"
+)
+
+func TestEngine(t *testing.T) {
+	start := time.Date(2026, 3, 1, 22, 0, 0, 0, time.UTC)
+	engine := NewEngine([]Rule{
+		{Name: "CPU hot", Metric: "cpu_temp", Comparator: Above, Threshold: 90, For: "30s"},
+		{Name: "GPU hot", Metric: "gpu*_temp", Comparator: AtLeast, Threshold: 80},
+		{Name: "Off", Metric: "cpu_temp", Comparator: Above, Threshold: 0, Disabled: true},
+	})
+
+	steps := []struct {
+		after  time.Duration
+		values map[string]float64
+		want   []string
+	}{
+		{0, map[string]float64{"cpu_temp": 92, "gpu0_temp": 70, "gpu1_temp": 80}, []string{"GPU hot gpu1_temp firing"}},
+		{20 * time.Second, map[string]float64{"cpu_temp": 95, "gpu1_temp": 85}, nil},
+		{30 * time.Second, map[string]float64{"cpu_temp": 94}, []string{"CPU hot cpu_temp firing"}},
+		{40 * time.Second, map[string]float64{"cpu_temp": 96}, nil},
+		{50 * time.Second, map[string]float64{"cpu_temp": 70, "gpu1_temp": 60}, []string{"CPU hot cpu_temp resolved", "GPU hot gpu1_temp resolved"}},
+		{60 * time.Second, map[string]float64{"cpu_temp": 91}, nil},
+	}
+	for i, step := range steps {
+		var got []string
+		for _, e := range engine.Evaluate(start.Add(step.after), step.values) {
+			got = append(got, e.Rule.Name+" "+e.Metric+" "+string(e.State))
+		}
+		if strings.Join(got, ", ") != strings.Join(step.want, ", ") {
+			t.Errorf("step %d: expected %v, got %v", i, step.want, got)
+		}
+	}
+	if engine.Firing() != 0 {
+		t.Errorf("expected nothing firing, got %d", engine.Firing())
+	}
+}
+
+func TestEngineSetRules(t *testing.T) {
+	start := time.Date(2026, 3, 1, 22, 0, 0, 0, time.UTC)
+	hot := Rule{Name: "CPU hot", Metric: "cpu_temp", Comparator: Above, Threshold: 90}
+	engine := NewEngine([]Rule{hot})
+	if events := engine.Evaluate(start, map[string]float64{"cpu_temp": 95}); len(events) != 1 {
+		t.Fatalf("expected the alert to fire, got %+v", events)
+	}
+
+	engine.SetRules([]Rule{hot, {Name: "Fan stopped", Metric: "fan_*", Comparator: Below, Threshold: 300}})
+	if events := engine.Evaluate(start.Add(time.Second), map[string]float64{"cpu_temp": 95}); len(events) != 0 {
+		t.Errorf("expected an unchanged rule to keep firing without a new event, got %+v", events)
+	}
+
+	hot.Threshold = 99
+	engine.SetRules([]Rule{hot})
+	if engine.Firing() != 0 {
+		t.Errorf("expected an edited rule to start over, got %d firing", engine.Firing())
+	}
+}
+
+func TestEventMessage(t *testing.T) {
+	e := Event{
+		Rule:   Rule{Name: "GPU hot", Metric: "gpu*_temp", Comparator: Above, Threshold: 83.5, For: "1m"},
+		Metric: "gpu1_temp",
+		Value:  86.04,
+		State:  Firing,
+	}
+	if msg := e.Message(); msg != "GPU hot: gpu1_temp is 86.0 (gpu1_temp > 83.5 for 1m)" {
+		t.Errorf("unexpected message %q", msg)
+	}
+	e.State = Resolved
+	if msg := e.Message(); msg != "GPU hot resolved: gpu1_temp is back to 86.0" {
+		t.Errorf("unexpected message %q", msg)
+	}
+}
+
+func TestParseComparator(t *testing.T) {
+	for input, want := range map[string]Comparator{">": Above, "above": Above, ">=": AtLeast, "BELOW": Below, "le": AtMost} {
+		got, err := ParseComparator(input)
+		if err != nil || got != want {
+			t.Errorf("ParseComparator(%q) = %q, %v; expected %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseComparator("="); err == nil {
+		t.Error("expected an error for an unknown comparator")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	for name, cfg := range map[string]Config{
+		"no name":        {Rules: []Rule{{Metric: "cpu_temp", Comparator: Above}}},
+		"bad pattern":    {Rules: []Rule{{Name: "a", Metric: "gpu[", Comparator: Above}}},
+		"bad comparator": {Rules: []Rule{{Name: "a", Metric: "cpu_temp", Comparator: "above"}}},
+		"bad duration":   {Rules: []Rule{{Name: "a", Metric: "cpu_temp", Comparator: Above, For: "soon"}}},
+		"duplicate": {Rules: []Rule{
+			{Name: "a", Metric: "cpu_temp", Comparator: Above},
+			{Name: "A", Metric: "vrm_temp", Comparator: Above},
+		}},
+		"no recipient": {Channels: Channels{Email: &EmailConfig{Config: mail.Config{Host: "smtp.example.com", From: "fire@example.com"}}}},
+		"bad webhook":  {Channels: Channels{Webhooks: []Webhook{{URL: "ftp://example.com/hook"}}}},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestLoadSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Rules) != 0 || !cfg.Channels.Desktop {
+		t.Errorf("expected no rules with desktop notifications, got %+v", cfg)
+	}
+
+	cfg.Rules = append(cfg.Rules, Rule{Name: "VRM hot", Metric: "vrm_temp", Comparator: Above, Threshold: 100, For: "10s"})
+	cfg.Channels.Webhooks = []Webhook{{URL: "https://hooks.example.com/fire"}}
+	if err := Save(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Rules) != 1 || loaded.Rules[0] != cfg.Rules[0] || loaded.Rule("vrm HOT") != 0 || len(loaded.Channels.Webhooks) != 1 {
+		t.Errorf("unexpected configuration %+v", loaded)
+	}
+
+	cfg.Rules = append(cfg.Rules, Rule{Name: "vrm hot", Metric: "vrm_temp", Comparator: Above})
+	if err := Save(path, cfg); err == nil {
+		t.Error("expected an invalid configuration not to be saved")
+	}
+}
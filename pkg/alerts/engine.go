@@ -0,0 +1,155 @@
+package alerts
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// State is whether an alert has fired or cleared
+type State string
+
+// Alert states
+const (
+	Firing   State = "firing"
+	Resolved State = "resolved"
+)
+
+// Event is an alert that fired or resolved for one metric
+type Event struct {
+	Rule   Rule
+	Metric string
+	Value  float64
+	State  State
+	Time   time.Time
+}
+
+// Message describes the event, e.g. "CPU hot: cpu_temp is 93.5 (cpu_temp >
+// 90 for 30s)"
+func (e Event) Message() string {
+	value := strconv.FormatFloat(e.Value, 'f', 1, 64)
+	if e.State == Resolved {
+		return fmt.Sprintf("%s resolved: %s is back to %s", e.Rule.Name, e.Metric, value)
+	}
+	rule := e.Rule
+	rule.Metric = e.Metric
+	return fmt.Sprintf("%s: %s is %s (%s)", e.Rule.Name, e.Metric, value, rule)
+}
+
+// condition tracks a rule on one metric while its condition holds
+type condition struct {
+	since  time.Time
+	firing bool
+}
+
+type conditionKey struct {
+	rule   string
+	metric string
+}
+
+// Engine evaluates rules against readings. It remembers since when each
+// condition has held, so it is not safe for concurrent use.
+type Engine struct {
+	rules      []Rule
+	conditions map[conditionKey]*condition
+}
+
+// NewEngine returns an engine evaluating rules. Disabled rules are skipped.
+func NewEngine(rules []Rule) *Engine {
+	e := &Engine{conditions: make(map[conditionKey]*condition)}
+	e.SetRules(rules)
+	return e
+}
+
+// SetRules replaces the rules, e.g. after the configuration was edited.
+// Rules that did not change keep their pending and firing alerts.
+func (e *Engine) SetRules(rules []Rule) {
+	unchanged := make(map[string]bool)
+	for _, old := range e.rules {
+		for _, r := range rules {
+			if r == old {
+				unchanged[r.Name] = true
+			}
+		}
+	}
+	for key := range e.conditions {
+		if !unchanged[key.rule] {
+			delete(e.conditions, key)
+		}
+	}
+	e.rules = nil
+	for _, r := range rules {
+		if !r.Disabled {
+			e.rules = append(e.rules, r)
+		}
+	}
+}
+
+// Evaluate checks one set of readings taken at now. It returns an event for
+// each alert that fired, because its condition has held for the rule's
+// duration, and for each that resolved, because its condition cleared.
+// Metrics missing from values keep their state, as the sensor may only have
+// failed to read.
+func (e *Engine) Evaluate(now time.Time, values map[string]float64) []Event {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var events []Event
+	for _, r := range e.rules {
+		wait, err := r.Duration()
+		if err != nil {
+			continue
+		}
+		for _, metric := range names {
+			if !r.Matches(metric) {
+				continue
+			}
+			value := values[metric]
+			key := conditionKey{r.Name, metric}
+			c := e.conditions[key]
+
+			if !r.Comparator.Holds(value, r.Threshold) {
+				if c != nil && c.firing {
+					events = append(events, Event{Rule: r, Metric: metric, Value: value, State: Resolved, Time: now})
+				}
+				delete(e.conditions, key)
+				continue
+			}
+			if c == nil {
+				c = &condition{since: now}
+				e.conditions[key] = c
+			}
+			if !c.firing && now.Sub(c.since) >= wait {
+				c.firing = true
+				events = append(events, Event{Rule: r, Metric: metric, Value: value, State: Firing, Time: now})
+			}
+		}
+	}
+	return events
+}
+
+// Firing returns the number of alerts currently firing
+func (e *Engine) Firing() int {
+	n := 0
+	for _, c := range e.conditions {
+		if c.firing {
+			n++
+		}
+	}
+	return n
+}
+
+// TestEvent returns a firing event for checking that the channels deliver
+func TestEvent(now time.Time) Event {
+	return Event{
+		Rule:   Rule{Name: "Test alert", Metric: "cpu_temp", Comparator: Above, Threshold: 90},
+		Metric: "cpu_temp",
+		Value:  91,
+		State:  Firing,
+		Time:   now,
+	}
+}
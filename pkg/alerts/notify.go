@@ -0,0 +1,193 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/mail"
+)
+
+// DefaultTimeout limits how long one channel may take to accept an alert
+const DefaultTimeout = 10 * time.Second
+
+// Notifier sends alerts to one channel
+type Notifier interface {
+	Notify(ctx context.Context, e Event) error
+}
+
+// NotifierFunc adapts a function, such as the GUI's desktop notification,
+// to a Notifier
+type NotifierFunc func(ctx context.Context, e Event) error
+
+// Notify calls f
+func (f NotifierFunc) Notify(ctx context.Context, e Event) error {
+	return f(ctx, e)
+}
+
+// Notifiers returns a notifier for the email and webhook channels. Desktop
+// notifications are left to the GUI.
+func (c Channels) Notifiers() []Notifier {
+	var notifiers []Notifier
+	if c.Email != nil {
+		notifiers = append(notifiers, &EmailNotifier{Config: *c.Email})
+	}
+	for _, w := range c.Webhooks {
+		notifiers = append(notifiers, &WebhookNotifier{Webhook: w})
+	}
+	return notifiers
+}
+
+// hostname names the machine in emails and webhook payloads
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return name
+}
+
+// Payload is the JSON body posted to webhooks. Text repeats the message
+// under the key Slack and Mattermost incoming webhooks display.
+type Payload struct {
+	Rule       string    `json:"rule"`
+	Metric     string    `json:"metric"`
+	Value      float64   `json:"value"`
+	Comparator string    `json:"comparator"`
+	Threshold  float64   `json:"threshold"`
+	State      State     `json:"state"`
+	Time       time.Time `json:"time"`
+	Host       string    `json:"host"`
+	Text       string    `json:"text"`
+}
+
+// WebhookNotifier posts alerts as JSON to a URL
+type WebhookNotifier struct {
+	Webhook Webhook
+	Client  *http.Client // Defaults to http.DefaultClient
+}
+
+// Notify posts the event and fails unless the server answers 2xx
+func (w *WebhookNotifier) Notify(ctx context.Context, e Event) error {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	encoder.SetEscapeHTML(false)
+	err := encoder.Encode(Payload{
+		Rule:       e.Rule.Name,
+		Metric:     e.Metric,
+		Value:      e.Value,
+		Comparator: string(e.Rule.Comparator),
+		Threshold:  e.Rule.Threshold,
+		State:      e.State,
+		Time:       e.Time.UTC(),
+		Host:       hostname(),
+		Text:       "[" + hostname() + "] " + e.Message(),
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.Webhook.URL, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range w.Webhook.Headers {
+		req.Header.Set(name, value)
+	}
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook %s: %w", req.URL.Host, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s returned %s", req.URL.Host, resp.Status)
+	}
+	return nil
+}
+
+// EmailNotifier mails alerts through an SMTP server
+type EmailNotifier struct {
+	Config EmailConfig
+}
+
+// Notify mails the event to every recipient
+func (n *EmailNotifier) Notify(ctx context.Context, e Event) error {
+	subject, body := emailContent(e, hostname())
+	return mail.Send(ctx, n.Config.Config, mail.Message{To: n.Config.To, Subject: subject, Body: body})
+}
+
+// emailContent formats the event as the subject and plain text body of an
+// email
+func emailContent(e Event, host string) (string, string) {
+	subject := fmt.Sprintf("[F.I.R.E. %s] %s", host, e.Rule.Name)
+	if e.State == Resolved {
+		subject += " resolved"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", e.Message())
+	fmt.Fprintf(&b, "Host:      %s\n", host)
+	fmt.Fprintf(&b, "Rule:      %s\n", e.Rule)
+	fmt.Fprintf(&b, "Metric:    %s\n", e.Metric)
+	fmt.Fprintf(&b, "Value:     %s\n", strconv.FormatFloat(e.Value, 'f', -1, 64))
+	fmt.Fprintf(&b, "State:     %s\n", e.State)
+	fmt.Fprintf(&b, "Time:      %s\n", e.Time.Format(time.RFC3339))
+	return subject, b.String()
+}
+
+// Dispatcher sends alerts to every notifier and keeps them in the alert
+// history
+type Dispatcher struct {
+	Notifiers []Notifier
+	DB        *db.DB        // Alert history; nil keeps none
+	Source    string        // Recorded with each event, e.g. "gui" or "monitor"
+	Timeout   time.Duration // Per notifier; defaults to DefaultTimeout
+}
+
+// Dispatch sends the event to every notifier and records it along with any
+// channel that failed. It returns the delivery and recording errors joined.
+func (d *Dispatcher) Dispatch(ctx context.Context, e Event) error {
+	timeout := d.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	var delivery []error
+	for _, n := range d.Notifiers {
+		notifyCtx, cancel := context.WithTimeout(ctx, timeout)
+		if err := n.Notify(notifyCtx, e); err != nil {
+			delivery = append(delivery, err)
+		}
+		cancel()
+	}
+	deliveryErr := errors.Join(delivery...)
+
+	if d.DB == nil {
+		return deliveryErr
+	}
+	record := &db.AlertEvent{
+		Time:       e.Time,
+		Rule:       e.Rule.Name,
+		Metric:     e.Metric,
+		Value:      e.Value,
+		Threshold:  e.Rule.Threshold,
+		Comparator: string(e.Rule.Comparator),
+		State:      string(e.State),
+		Message:    e.Message(),
+		Source:     d.Source,
+	}
+	if deliveryErr != nil {
+		record.Error = strings.ReplaceAll(deliveryErr.Error(), "\n", "; ")
+	}
+	return errors.Join(deliveryErr, d.DB.CreateAlertEvent(record))
+}
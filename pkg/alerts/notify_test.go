@@ -0,0 +1,98 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	var got Payload
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	n := &WebhookNotifier{Webhook: Webhook{URL: server.URL, Headers: map[string]string{"Authorization": "Bearer token"}}}
+	e := TestEvent(time.Date(2026, 3, 1, 22, 0, 0, 0, time.UTC))
+	if err := n.Notify(context.Background(), e); err != nil {
+		t.Fatal(err)
+	}
+	if got.Rule != "Test alert" || got.Metric != "cpu_temp" || got.Value != 91 || got.State != Firing || got.Comparator != ">" {
+		t.Errorf("unexpected payload %+v", got)
+	}
+	if !strings.HasSuffix(got.Text, e.Message()) || auth != "Bearer token" {
+		t.Errorf("expected the message and headers, got %q and %q", got.Text, auth)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	n.Webhook.URL = failing.URL
+	if err := n.Notify(context.Background(), e); err == nil {
+		t.Error("expected an error for a 500 response")
+	}
+}
+
+func TestEmailContent(t *testing.T) {
+	e := TestEvent(time.Date(2026, 3, 1, 22, 0, 0, 0, time.UTC))
+	subject, body := emailContent(e, "bench-01")
+	if subject != "[F.I.R.E. bench-01] Test alert" {
+		t.Errorf("unexpected subject %q", subject)
+	}
+	for _, want := range []string{e.Message() + "\n\n", "Host:      bench-01\n", "Rule:      cpu_temp > 90\n"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in\n%s", want, body)
+		}
+	}
+
+	e.State = Resolved
+	if subject, _ := emailContent(e, "bench-01"); subject != "[F.I.R.E. bench-01] Test alert resolved" {
+		t.Errorf("unexpected subject %q", subject)
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = database.Close() }()
+
+	var delivered int
+	d := &Dispatcher{
+		Notifiers: []Notifier{
+			NotifierFunc(func(context.Context, Event) error { delivered++; return nil }),
+			NotifierFunc(func(context.Context, Event) error { return errors.New("smtp: connection refused") }),
+		},
+		DB:     database,
+		Source: "monitor",
+	}
+	if err := d.Dispatch(context.Background(), TestEvent(time.Now())); err == nil {
+		t.Error("expected the failed channel to be reported")
+	}
+	if delivered != 1 {
+		t.Errorf("expected every channel to be tried, got %d", delivered)
+	}
+
+	events, err := database.ListAlertEvents(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Source != "monitor" || events[0].Error != "smtp: connection refused" || events[0].State != "firing" {
+		t.Errorf("expected the event in the alert history, got %+v", events)
+	}
+}
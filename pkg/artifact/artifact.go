@@ -0,0 +1,187 @@
+// Package artifact stores files attached to test runs, such as charts, logs
+// and error dumps.
+//
+// Each run's artifacts live in their own directory, named after the run ID,
+// under ~/.fire/artifacts (or $FIRE_ARTIFACTS). Plugins attach files through
+// plugin.Result.Artifacts; post-run hooks can write into the directory named
+// by FIRE_RUN_ARTIFACTS.
+package artifact
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/plugin"
+)
+
+// Kind is how an artifact can be previewed
+type Kind string
+
+// Artifact kinds
+const (
+	KindImage Kind = "image"
+	KindLog   Kind = "log"
+	KindOther Kind = "other"
+)
+
+// ErrorDumpName is the artifact written for failed runs with error output
+const ErrorDumpName = "error.log"
+
+// Artifact is a file attached to a run
+type Artifact struct {
+	Name    string
+	Path    string
+	Size    int64
+	ModTime time.Time
+	Kind    Kind
+}
+
+// DefaultRoot returns the directory holding the artifacts of all runs
+func DefaultRoot() string {
+	if root := os.Getenv("FIRE_ARTIFACTS"); root != "" {
+		return root
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "artifacts"
+	}
+	return filepath.Join(homeDir, ".fire", "artifacts")
+}
+
+// RunDir returns the artifact directory of a run
+func RunDir(root string, runID int64) string {
+	return filepath.Join(root, strconv.FormatInt(runID, 10))
+}
+
+// KindOf classifies a file by its extension
+func KindOf(name string) Kind {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".png", ".jpg", ".jpeg", ".svg":
+		return KindImage
+	case ".log", ".txt", ".out", ".err", ".json", ".csv", ".xml", ".yaml", ".yml":
+		return KindLog
+	default:
+		return KindOther
+	}
+}
+
+// List returns the artifacts of a run sorted by name. A run without
+// artifacts yields an empty list.
+func List(root string, runID int64) ([]Artifact, error) {
+	dir := RunDir(root, runID)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifacts of run %d: %w", runID, err)
+	}
+
+	var artifacts []Artifact
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		artifacts = append(artifacts, Artifact{
+			Name:    entry.Name(),
+			Path:    filepath.Join(dir, entry.Name()),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+			Kind:    KindOf(entry.Name()),
+		})
+	}
+	sort.Slice(artifacts, func(i, j int) bool {
+		return artifacts[i].Name < artifacts[j].Name
+	})
+	return artifacts, nil
+}
+
+// Runs returns the IDs of runs that have artifacts, newest first
+func Runs(root string) ([]int64, error) {
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifacts: %w", err)
+	}
+
+	var ids []int64
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		id, err := strconv.ParseInt(entry.Name(), 10, 64)
+		if err != nil {
+			continue
+		}
+		if artifacts, _ := List(root, id); len(artifacts) > 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] > ids[j] })
+	return ids, nil
+}
+
+// Save writes an artifact for a run and returns its path. The name must be
+// a plain file name.
+func Save(root string, runID int64, name string, data []byte) (string, error) {
+	if name == "" || name != filepath.Base(name) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid artifact name %q", name)
+	}
+	dir := RunDir(root, runID)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", fmt.Errorf("failed to save artifact %s: %w", name, err)
+	}
+	return path, nil
+}
+
+// SaveResult stores the artifacts attached to a plugin result. Failed runs
+// with error output also get an error dump.
+func SaveResult(root string, runID int64, result plugin.Result) error {
+	files := make(map[string][]byte, len(result.Artifacts)+1)
+	for name, data := range result.Artifacts {
+		files[name] = data
+	}
+	if !result.Success && (result.Error != "" || result.Stderr != "") {
+		if _, ok := files[ErrorDumpName]; !ok {
+			files[ErrorDumpName] = []byte(errorDump(result))
+		}
+	}
+
+	var firstErr error
+	for name, data := range files {
+		if _, err := Save(root, runID, name, data); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// errorDump combines the error message and stderr of a failed run
+func errorDump(result plugin.Result) string {
+	var b strings.Builder
+	if result.Error != "" {
+		fmt.Fprintf(&b, "ERROR: %s\n", result.Error)
+	}
+	if result.Stderr != "" {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(result.Stderr)
+	}
+	return b.String()
+}
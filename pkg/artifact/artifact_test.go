@@ -0,0 +1,117 @@
+package artifact
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mscrnt/project_fire/pkg/plugin"
+)
+
+func TestSaveAndList(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"temps.png", "run.log", "dump.bin"} {
+		if _, err := Save(root, 5, name, []byte("data")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	artifacts, err := List(root, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(artifacts) != 3 {
+		t.Fatalf("expected 3 artifacts, got %d", len(artifacts))
+	}
+	kinds := map[string]Kind{"dump.bin": KindOther, "run.log": KindLog, "temps.png": KindImage}
+	for _, a := range artifacts {
+		if a.Kind != kinds[a.Name] {
+			t.Errorf("expected %s to be %s, got %s", a.Name, kinds[a.Name], a.Kind)
+		}
+		if a.Size != 4 {
+			t.Errorf("expected size 4 for %s, got %d", a.Name, a.Size)
+		}
+	}
+	if artifacts[0].Name != "dump.bin" {
+		t.Errorf("expected artifacts sorted by name, got %s first", artifacts[0].Name)
+	}
+
+	if artifacts, err := List(root, 6); err != nil || len(artifacts) != 0 {
+		t.Errorf("expected no artifacts for run 6, got %v, %v", artifacts, err)
+	}
+}
+
+func TestSaveRejectsPaths(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"", "..", "../escape.log", "sub/file.log"} {
+		if _, err := Save(root, 1, name, nil); err == nil {
+			t.Errorf("expected error for name %q", name)
+		}
+	}
+}
+
+func TestRuns(t *testing.T) {
+	root := t.TempDir()
+	_, _ = Save(root, 3, "a.log", nil)
+	_, _ = Save(root, 12, "b.log", nil)
+	_ = os.MkdirAll(RunDir(root, 20), 0o750) // Empty directory is ignored
+	_ = os.MkdirAll(root+"/notes", 0o750)
+
+	ids, err := Runs(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != 12 || ids[1] != 3 {
+		t.Errorf("expected [12 3], got %v", ids)
+	}
+}
+
+func TestSaveResultErrorDump(t *testing.T) {
+	root := t.TempDir()
+	result := plugin.Result{
+		Success:   false,
+		Error:     "memory mismatch at 0x1000",
+		Stderr:    "pattern 0xAA failed\n",
+		Artifacts: map[string][]byte{"chart.png": []byte("png")},
+	}
+	if err := SaveResult(root, 9, result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	artifacts, _ := List(root, 9)
+	if len(artifacts) != 2 {
+		t.Fatalf("expected chart and error dump, got %v", artifacts)
+	}
+	dump, err := os.ReadFile(RunDir(root, 9) + "/" + ErrorDumpName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(dump), "memory mismatch") || !strings.Contains(string(dump), "pattern 0xAA") {
+		t.Errorf("unexpected error dump %q", dump)
+	}
+
+	// Successful runs only keep the plugin's own artifacts
+	if err := SaveResult(root, 10, plugin.Result{Success: true, Stderr: "noise"}); err != nil {
+		t.Fatal(err)
+	}
+	if artifacts, _ := List(root, 10); len(artifacts) != 0 {
+		t.Errorf("expected no artifacts for a passing run, got %v", artifacts)
+	}
+}
+
+func TestLineLevel(t *testing.T) {
+	tests := map[string]Level{
+		"2025-01-02 10:00:00 [ERROR] read failed": LevelError,
+		"WARN: CPU temperature 92C":               LevelWarning,
+		"[INFO] starting stress test":             LevelInfo,
+		"debug: sampling every 1s":                LevelDebug,
+		"errors=0 warnings=0":                     LevelPlain,
+		"Test passed, no warnings":                LevelInfo,
+		"plain output":                            LevelPlain,
+	}
+	for line, expected := range tests {
+		if got := LineLevel(line); got != expected {
+			t.Errorf("expected level %d for %q, got %d", expected, line, got)
+		}
+	}
+}
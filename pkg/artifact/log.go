@@ -0,0 +1,43 @@
+package artifact
+
+import "strings"
+
+// Level is the severity of a log line, used to highlight it
+type Level int
+
+// Log levels, from least to most severe
+const (
+	LevelPlain Level = iota
+	LevelDebug
+	LevelInfo
+	LevelWarning
+	LevelError
+)
+
+// levelWords are matched as whole words, most severe first
+var levelWords = []struct {
+	level Level
+	words []string
+}{
+	{LevelError, []string{"error", "err", "fatal", "panic", "fail", "failed", "failure", "critical", "crit"}},
+	{LevelWarning, []string{"warning", "warn"}},
+	{LevelInfo, []string{"info", "pass", "passed", "ok", "success"}},
+	{LevelDebug, []string{"debug", "trace"}},
+}
+
+// LineLevel returns the most severe level word found in a log line
+func LineLevel(line string) Level {
+	fields := strings.FieldsFunc(strings.ToLower(line), func(r rune) bool {
+		return (r < 'a' || r > 'z') && (r < '0' || r > '9')
+	})
+	for _, lw := range levelWords {
+		for _, field := range fields {
+			for _, word := range lw.words {
+				if field == word {
+					return lw.level
+				}
+			}
+		}
+	}
+	return LevelPlain
+}
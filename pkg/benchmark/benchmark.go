@@ -0,0 +1,499 @@
+// Package benchmark runs a suite of short, standardized workloads and turns
+// their results into scores that compare across runs and machines.
+//
+// Every workload runs a test plugin with fixed parameters, so a score only
+// moves when the hardware, firmware or cooling does. A score is the
+// workload's metric relative to a reference machine, which scores 1000
+// points, and the overall score is the geometric mean of the others. A suite
+// is recorded as a "benchmark" run whose results are the scores, next to the
+// runs of its workloads, so bench list shows both.
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/artifact"
+	"github.com/mscrnt/project_fire/pkg/changelog"
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/ecc"
+	"github.com/mscrnt/project_fire/pkg/inventory"
+	"github.com/mscrnt/project_fire/pkg/ipmi"
+	"github.com/mscrnt/project_fire/pkg/journal"
+	"github.com/mscrnt/project_fire/pkg/plugin"
+	"github.com/mscrnt/project_fire/pkg/power"
+	"github.com/mscrnt/project_fire/pkg/telemetry"
+	"github.com/mscrnt/project_fire/pkg/throttle"
+	"github.com/mscrnt/project_fire/pkg/virt"
+)
+
+// RunPlugin is the plugin name suites are recorded under
+const RunPlugin = "benchmark"
+
+// Version numbers the workloads and references; scores of other versions
+// are not compared
+const Version = 1
+
+// Score names and unit
+const (
+	Overall   = "overall"
+	ScoreUnit = "points"
+)
+
+// ReferencePoints is the score of the reference machine
+const ReferencePoints = 1000
+
+// Score is computed from one metric of a workload
+type Score struct {
+	Name      string  // e.g. cpu_single
+	Title     string  // e.g. "CPU single-core"
+	Metric    string  // The plugin metric it is computed from
+	Reference float64 // The metric on the reference machine
+}
+
+// Workload runs a plugin with fixed parameters
+type Workload struct {
+	Name     string
+	Title    string
+	Plugin   string
+	Duration time.Duration
+	Threads  int // 0 runs a thread per CPU
+	Config   map[string]interface{}
+	Scores   []Score
+}
+
+// Workloads are the suite. The reference is a desktop with an 8-core CPU,
+// dual-channel DDR4 and a SATA SSD.
+var Workloads = []Workload{
+	{
+		Name: "cpu_single", Title: "CPU single-core", Plugin: "cpu",
+		Duration: 15 * time.Second, Threads: 1,
+		Config: map[string]interface{}{"method": "native"},
+		Scores: []Score{{Name: "cpu_single", Title: "CPU single-core", Metric: "operations_per_second", Reference: 1e6}},
+	},
+	{
+		Name: "cpu_multi", Title: "CPU multi-core", Plugin: "cpu",
+		Duration: 15 * time.Second,
+		Config:   map[string]interface{}{"method": "native"},
+		Scores:   []Score{{Name: "cpu_multi", Title: "CPU multi-core", Metric: "operations_per_second", Reference: 8e6}},
+	},
+	{
+		Name: "memory", Title: "Memory bandwidth", Plugin: "memory",
+		Duration: 15 * time.Second,
+		Config:   map[string]interface{}{"method": "native", "size_mb": 512, "tests": "all"},
+		Scores:   []Score{{Name: "memory_bandwidth", Title: "Memory bandwidth", Metric: "bandwidth_mb_per_sec", Reference: 10000}},
+	},
+	{
+		Name: "storage", Title: "Storage", Plugin: "disk",
+		Duration: 20 * time.Second,
+		Config: map[string]interface{}{
+			"mode": "file", "size_mb": 256, "block_kb": 1024, "random_block_kb": 4,
+			"queue_depth": 1, "pattern": "auto", "cache": "direct", "smart": false,
+		},
+		Scores: []Score{
+			{Name: "storage_seq", Title: "Storage sequential", Metric: "seq_read_mb_per_sec", Reference: 500},
+			{Name: "storage_4k", Title: "Storage 4K random", Metric: "random_read_iops", Reference: 10000},
+		},
+	},
+	{
+		Name: "gpu", Title: "GPU", Plugin: "gpu",
+		Duration: 15 * time.Second,
+		Scores:   []Score{{Name: "gpu", Title: "GPU", Metric: "score", Reference: 1000}},
+	},
+}
+
+// Titles returns the title of every score, keyed by score name
+func Titles() map[string]string {
+	titles := map[string]string{Overall: "Overall"}
+	for _, w := range Workloads {
+		for _, s := range w.Scores {
+			titles[s.Name] = s.Title
+		}
+	}
+	return titles
+}
+
+// ScoreNames returns the score names in suite order, overall first
+func ScoreNames() []string {
+	names := []string{Overall}
+	for _, w := range Workloads {
+		for _, s := range w.Scores {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
+
+// Compute scores a workload's metrics. Scores whose metric is missing or
+// not positive are left out.
+func (w Workload) Compute(metrics map[string]float64) map[string]float64 {
+	scores := make(map[string]float64)
+	for _, s := range w.Scores {
+		if v, ok := metrics[s.Metric]; ok && v > 0 && s.Reference > 0 {
+			scores[s.Name] = v / s.Reference * ReferencePoints
+		}
+	}
+	return scores
+}
+
+// overall is the geometric mean of the scores
+func overall(scores map[string]float64) float64 {
+	var sum float64
+	n := 0
+	for name, v := range scores {
+		if name == Overall || v <= 0 {
+			continue
+		}
+		sum += math.Log(v)
+		n++
+	}
+	if n == 0 {
+		return 0
+	}
+	return math.Exp(sum / float64(n))
+}
+
+// Suite is one recorded run of the workloads. Runs and Skipped are only
+// known for a suite just run; the run's output lists them.
+type Suite struct {
+	Run     *db.Run
+	Scores  map[string]float64
+	Runs    map[string]int64  // Run ID of each workload
+	Skipped map[string]string // Why a workload did not run or score
+}
+
+// summary lists the workload runs and skipped workloads, for the run's
+// output
+func (s *Suite) summary(workloads []Workload) string {
+	var b strings.Builder
+	for _, w := range workloads {
+		if reason, ok := s.Skipped[w.Name]; ok {
+			fmt.Fprintf(&b, "%s: skipped (%s)\n", w.Name, reason)
+		} else if id, ok := s.Runs[w.Name]; ok {
+			fmt.Fprintf(&b, "%s: run %d\n", w.Name, id)
+		}
+	}
+	return b.String()
+}
+
+// FromRun reads a suite back from its run and results
+func FromRun(database *db.DB, run *db.Run) (*Suite, error) {
+	if run.Plugin != RunPlugin {
+		return nil, fmt.Errorf("run %d is a %s run, not a benchmark", run.ID, run.Plugin)
+	}
+	// The version is a float once read back from the database
+	if fmt.Sprint(run.Params["version"]) != fmt.Sprint(Version) {
+		return nil, fmt.Errorf("run %d was scored by benchmark version %v", run.ID, run.Params["version"])
+	}
+	s := &Suite{Run: run, Scores: make(map[string]float64)}
+	results, err := database.GetResults(run.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range results {
+		s.Scores[r.Metric] = r.Value
+	}
+	return s, nil
+}
+
+// History returns the completed suites of a machine, or of every machine
+// when machine is empty, oldest first
+func History(database *db.DB, machine string) ([]*Suite, error) {
+	runs, err := database.ListRuns(db.RunFilter{Plugin: RunPlugin})
+	if err != nil {
+		return nil, err
+	}
+	var suites []*Suite
+	for i := len(runs) - 1; i >= 0; i-- {
+		run := runs[i]
+		if !run.Success || (machine != "" && run.Machine != machine) {
+			continue
+		}
+		s, err := FromRun(database, run)
+		if err != nil {
+			continue
+		}
+		suites = append(suites, s)
+	}
+	return suites, nil
+}
+
+// Rank places a score among the suites of other runs on the same hardware
+// model
+type Rank struct {
+	Score      float64
+	Percentile float64 // Share of the other runs scoring lower, 0-100
+	Median     float64
+	Count      int // Other runs compared against
+}
+
+// Compare ranks each score of a suite against the other suites recorded on
+// the same hardware model. Scores no other run has are left out.
+func Compare(database *db.DB, s *Suite) (map[string]Rank, error) {
+	ranks := make(map[string]Rank)
+	if s.Run.Model == "" {
+		return ranks, nil
+	}
+	suites, err := History(database, "")
+	if err != nil {
+		return nil, err
+	}
+	others := make(map[string][]float64)
+	for _, other := range suites {
+		if other.Run.ID == s.Run.ID || other.Run.Model != s.Run.Model {
+			continue
+		}
+		for name, v := range other.Scores {
+			others[name] = append(others[name], v)
+		}
+	}
+	for name, score := range s.Scores {
+		if values := others[name]; len(values) > 0 {
+			ranks[name] = rank(score, values)
+		}
+	}
+	return ranks, nil
+}
+
+// rank places score among values; ties count half
+func rank(score float64, values []float64) Rank {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	var below float64
+	for _, v := range sorted {
+		switch {
+		case v < score:
+			below++
+		case v == score:
+			below += 0.5
+		}
+	}
+	median := sorted[len(sorted)/2]
+	if len(sorted)%2 == 0 {
+		median = (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+	}
+	return Rank{Score: score, Percentile: below / float64(len(sorted)) * 100, Median: median, Count: len(sorted)}
+}
+
+// ProgressFunc is called when a workload starts, with a nil error and no
+// scores, and again when it ends
+type ProgressFunc func(w Workload, done bool, scores map[string]float64, err error)
+
+// Runner runs suites and records them in the results database
+type Runner struct {
+	database *db.DB
+	logger   *log.Logger
+	progress ProgressFunc
+	share    bool
+}
+
+// NewRunner creates a suite runner
+func NewRunner(database *db.DB, logger *log.Logger) *Runner {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Runner{database: database, logger: logger}
+}
+
+// SetProgress sets a function told about each workload as it starts and ends
+func (r *Runner) SetProgress(progress ProgressFunc) {
+	r.progress = progress
+}
+
+// SetShare sends the scores with the hardware model to the telemetry
+// backend, when telemetry is enabled, so they can be compared with other
+// machines of the model
+func (r *Runner) SetShare(share bool) {
+	r.share = share
+}
+
+// Run runs the workloads in order and records the suite. Workloads whose
+// plugin is not available, such as the GPU on machines without a GPU test,
+// are skipped; a workload that fails leaves its scores out. Cancelling ctx
+// stops the suite, which is then recorded as failed.
+func (r *Runner) Run(ctx context.Context, workloads []Workload) (*Suite, error) {
+	names := make([]interface{}, len(workloads))
+	for i, w := range workloads {
+		names[i] = w.Name
+	}
+	run, err := r.database.CreateRun(RunPlugin, db.JSONData{"version": Version, "workloads": names})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create run record: %w", err)
+	}
+	stopJournal := journal.Start(r.database, run)
+	defer stopJournal()
+	run.Environment = virt.Detect().Label()
+	changelog.Identify(run)
+
+	releaseSleep, err := power.Inhibit("Running the benchmark suite")
+	if err != nil {
+		r.logger.Printf("Could not prevent sleep: %v", err)
+	}
+	defer releaseSleep()
+
+	s := &Suite{Run: run, Scores: make(map[string]float64), Runs: make(map[string]int64), Skipped: make(map[string]string)}
+	for _, w := range workloads {
+		if ctx.Err() != nil {
+			s.Skipped[w.Name] = "suite stopped"
+			continue
+		}
+		p, err := plugin.Get(w.Plugin)
+		if err != nil {
+			s.Skipped[w.Name] = fmt.Sprintf("no %s test on this machine", w.Plugin)
+			continue
+		}
+		r.report(w, false, nil, nil)
+		scores, err := r.runWorkload(ctx, p, w, s)
+		if err != nil {
+			s.Skipped[w.Name] = err.Error()
+		} else if len(scores) == 0 {
+			s.Skipped[w.Name] = "no score: the test did not report its metric"
+			err = errors.New(s.Skipped[w.Name])
+		}
+		for name, v := range scores {
+			s.Scores[name] = v
+		}
+		r.report(w, true, scores, err)
+	}
+	if v := overall(s.Scores); v > 0 {
+		s.Scores[Overall] = v
+	}
+
+	endTime := time.Now()
+	run.EndTime = &endTime
+	run.Stdout = s.summary(workloads)
+	run.Success = ctx.Err() == nil && len(s.Scores) > 0
+	switch {
+	case ctx.Err() != nil:
+		run.ExitCode = 1
+		run.Error = "benchmark stopped"
+	case len(s.Scores) == 0:
+		run.ExitCode = 1
+		run.Error = "no workload produced a score"
+	}
+	if err := r.database.UpdateRun(run); err != nil {
+		return s, fmt.Errorf("failed to update run record: %w", err)
+	}
+	if len(s.Scores) > 0 {
+		units := make(map[string]string, len(s.Scores))
+		for name := range s.Scores {
+			units[name] = ScoreUnit
+		}
+		if err := r.database.CreateResults(run.ID, s.Scores, units); err != nil {
+			return s, fmt.Errorf("failed to save scores: %w", err)
+		}
+	}
+	if r.share && run.Success {
+		details := map[string]interface{}{"version": Version, "model": run.Model, "environment": run.Environment}
+		for name, v := range s.Scores {
+			details[name] = math.Round(v)
+		}
+		telemetry.RecordEvent("benchmark", details)
+	}
+	if !run.Success {
+		return s, errors.New(run.Error)
+	}
+	return s, nil
+}
+
+// runWorkload runs one workload and records it like bench test does
+func (r *Runner) runWorkload(ctx context.Context, p plugin.TestPlugin, w Workload, s *Suite) (map[string]float64, error) {
+	params := p.DefaultParams()
+	params.Duration = w.Duration
+	params.Threads = w.Threads
+	params.Config = make(map[string]interface{}, len(w.Config))
+	for k, v := range w.Config {
+		params.Config[k] = v
+	}
+	if err := p.ValidateParams(params); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	run, err := r.database.CreateRun(w.Plugin, db.JSONData(params.Config))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create run record: %w", err)
+	}
+	stopJournal := journal.Start(r.database, run)
+	defer stopJournal()
+	run.Environment = s.Run.Environment
+	run.Machine, run.Model = s.Run.Machine, s.Run.Model
+	if err := inventory.Record(r.database, run.ID); err != nil {
+		r.logger.Printf("Failed to record hardware inventory of run %d: %v", run.ID, err)
+	}
+	s.Runs[w.Name] = run.ID
+
+	runCtx, cancel := context.WithTimeout(ctx, params.Duration+30*time.Second)
+	defer cancel()
+	watch := throttle.Start(runCtx, throttle.SensorSampler(), throttle.DefaultInterval)
+	eccWatch := ecc.Start(runCtx, ecc.Read, ecc.DefaultInterval)
+	selWatch := ipmi.WatchSEL(runCtx, ipmi.ReadSEL)
+	res, err := p.Run(runCtx, params)
+	throttling := watch.Stop()
+	eccErrors := eccWatch.Stop()
+	selEntries := selWatch.Stop()
+
+	endTime := time.Now()
+	run.EndTime = &endTime
+	run.Success = res.Success
+	run.Error = res.Error
+	run.Stdout = res.Stdout
+	run.Stderr = res.Stderr
+	if err != nil {
+		run.ExitCode = 1
+		if run.Error == "" {
+			run.Error = err.Error()
+		}
+	}
+	if ctx.Err() != nil {
+		run.Success = false
+		run.ExitCode = 1
+		run.Error = "benchmark stopped"
+	}
+	if err := r.database.UpdateRun(run); err != nil {
+		r.logger.Printf("Failed to update run %d: %v", run.ID, err)
+	}
+
+	if len(res.Metrics) > 0 {
+		units := plugin.MetricUnits(p, res.Metrics)
+		if err := r.database.CreateResults(run.ID, res.Metrics, units); err != nil {
+			r.logger.Printf("Failed to save metrics of run %d: %v", run.ID, err)
+		}
+	}
+	if err := throttling.Save(r.database, run.ID); err != nil {
+		r.logger.Printf("Failed to save throttle events of run %d: %v", run.ID, err)
+	}
+	if err := eccErrors.Save(r.database, run.ID); err != nil {
+		r.logger.Printf("Failed to save ECC errors of run %d: %v", run.ID, err)
+	}
+	if err := selEntries.Save(r.database, run.ID); err != nil {
+		r.logger.Printf("Failed to save BMC event log entries of run %d: %v", run.ID, err)
+	}
+	if err := artifact.SaveResult(artifact.DefaultRoot(), run.ID, res); err != nil {
+		r.logger.Printf("Failed to save artifacts of run %d: %v", run.ID, err)
+	}
+
+	if !run.Success {
+		if run.Error == "" {
+			run.Error = "test did not pass"
+		}
+		return nil, errors.New(run.Error)
+	}
+	// Throttling makes the score a measure of the cooling, not the hardware
+	if throttling.Throttled() {
+		r.logger.Printf("%s throttled during run %d; its score may be low", w.Title, run.ID)
+	}
+	return w.Compute(res.Metrics), nil
+}
+
+// report passes a workload's progress to the progress function
+func (r *Runner) report(w Workload, done bool, scores map[string]float64, err error) {
+	if r.progress != nil {
+		r.progress(w, done, scores, err)
+	}
+}
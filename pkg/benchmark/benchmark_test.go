@@ -0,0 +1,112 @@
+package benchmark
+
+import (
+	"context"
+	"io"
+	"log"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/plugin"
+)
+
+// benchTestPlugin reports its thread count times 100 as its metric
+type benchTestPlugin struct{}
+
+func (benchTestPlugin) Name() string        { return "bench-test" }
+func (benchTestPlugin) Description() string { return "Test workload for the benchmark suite" }
+func (benchTestPlugin) DefaultParams() plugin.Params {
+	return plugin.Params{Duration: time.Millisecond}
+}
+func (benchTestPlugin) ValidateParams(plugin.Params) error { return nil }
+func (benchTestPlugin) Run(_ context.Context, params plugin.Params) (plugin.Result, error) {
+	return plugin.Result{Success: true, Metrics: map[string]float64{"ops": float64(params.Threads) * 100}}, nil
+}
+
+func init() {
+	_ = plugin.Register(benchTestPlugin{})
+}
+
+func openTestDB(t *testing.T) *db.DB {
+	t.Helper()
+	t.Setenv("FIRE_ARTIFACTS", t.TempDir())
+	database, err := db.Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+	return database
+}
+
+func TestRun(t *testing.T) {
+	database := openTestDB(t)
+	workloads := []Workload{
+		{Name: "one", Plugin: "bench-test", Threads: 1, Scores: []Score{{Name: "one", Metric: "ops", Reference: 100}}},
+		{Name: "four", Plugin: "bench-test", Threads: 4, Scores: []Score{{Name: "four", Metric: "ops", Reference: 100}}},
+		{Name: "gpu", Plugin: "no-such-plugin", Scores: []Score{{Name: "gpu", Metric: "score", Reference: 1}}},
+	}
+
+	s, err := NewRunner(database, log.New(io.Discard, "", 0)).Run(context.Background(), workloads)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Scores["one"] != 1000 || s.Scores["four"] != 4000 || math.Abs(s.Scores[Overall]-2000) > 1e-6 {
+		t.Errorf("unexpected scores %v", s.Scores)
+	}
+	if _, ok := s.Skipped["gpu"]; !ok || len(s.Runs) != 2 {
+		t.Errorf("expected the GPU skipped and two workload runs, got %v %v", s.Skipped, s.Runs)
+	}
+
+	history, err := History(database, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].Scores["four"] != 4000 {
+		t.Fatalf("expected the suite in the history, got %+v", history)
+	}
+	if !strings.Contains(history[0].Run.Stdout, "gpu: skipped") {
+		t.Errorf("expected the skipped workload in the output, got %q", history[0].Run.Stdout)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	database := openTestDB(t)
+	record := func(model string, score float64) *db.Run {
+		t.Helper()
+		run, err := database.CreateRun(RunPlugin, db.JSONData{"version": Version})
+		if err != nil {
+			t.Fatal(err)
+		}
+		end := time.Now()
+		run.EndTime, run.Success, run.Model = &end, true, model
+		if err := database.UpdateRun(run); err != nil {
+			t.Fatal(err)
+		}
+		if err := database.CreateResults(run.ID, map[string]float64{Overall: score}, map[string]string{Overall: ScoreUnit}); err != nil {
+			t.Fatal(err)
+		}
+		return run
+	}
+	for _, score := range []float64{800, 900, 1000, 1100} {
+		record("Board A", score)
+	}
+	record("Board B", 5000)
+	run := record("Board A", 1000)
+
+	s, err := FromRun(database, run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ranks, err := Compare(database, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := ranks[Overall]
+	if r.Count != 4 || r.Percentile != 62.5 || r.Median != 950 {
+		t.Errorf("expected 1000 above 2.5 of 4 runs with a median of 950, got %+v", r)
+	}
+}
@@ -0,0 +1,227 @@
+// Package benchmode pins GPU clocks and the OS power plan for the duration of
+// a run, so comparative benchmarks are not skewed by boost behaviour.
+//
+// Every change is recorded in a state file before the next one is made. If a
+// run crashes with benchmark mode active, RestorePending puts the previous
+// settings back from that file.
+package benchmode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// Change kinds
+const (
+	KindNVIDIAClocks = "nvidia-clocks"
+	KindAMDGPULevel  = "amdgpu-performance-level"
+	KindCPUGovernor  = "cpu-governor"
+	KindPowerProfile = "power-profile"
+	KindPowerScheme  = "power-scheme"
+)
+
+// Options configures benchmark mode
+type Options struct {
+	// GPUClockMHz locks NVIDIA graphics clocks to this frequency. Zero uses
+	// each card's default application clock, which is below boost.
+	GPUClockMHz int
+	// StatePath records the applied changes until they are restored
+	StatePath string
+}
+
+// DefaultStatePath returns where bench and the GUI record the settings
+// benchmark mode changed, ~/.fire/benchmode.json
+func DefaultStatePath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "benchmode.json"
+	}
+	return filepath.Join(homeDir, ".fire", "benchmode.json")
+}
+
+// Change is a setting modified by benchmark mode
+type Change struct {
+	Kind     string `json:"kind"`
+	Target   string `json:"target,omitempty"`   // GPU index or sysfs path
+	Previous string `json:"previous,omitempty"` // Value restored afterwards
+	Applied  string `json:"applied"`
+}
+
+// String describes the change for the console
+func (c Change) String() string {
+	switch c.Kind {
+	case KindNVIDIAClocks:
+		return fmt.Sprintf("NVIDIA GPU %s graphics clock locked to %s", c.Target, c.Applied)
+	case KindAMDGPULevel:
+		return fmt.Sprintf("AMD GPU %s performance level %s (was %s)", c.Target, c.Applied, c.Previous)
+	case KindCPUGovernor:
+		return fmt.Sprintf("CPU frequency governor set to %s", c.Applied)
+	case KindPowerProfile:
+		return fmt.Sprintf("Power profile set to %s (was %s)", c.Applied, c.Previous)
+	case KindPowerScheme:
+		return fmt.Sprintf("Power plan set to %s", c.Applied)
+	default:
+		return fmt.Sprintf("%s set to %s", c.Kind, c.Applied)
+	}
+}
+
+// Session holds the changes made by Enable
+type Session struct {
+	statePath string
+	changes   []Change
+}
+
+// Changes returns the settings that were modified
+func (s *Session) Changes() []Change {
+	return s.changes
+}
+
+// step applies one group of settings
+type step func(opts Options) ([]Change, error)
+
+// steps are applied in order and restored in reverse
+var steps = []step{lockNVIDIAClocks, setAMDGPULevel, setPowerPlan}
+
+// Enable applies benchmark mode. Settings left over from a crashed run are
+// restored first. Steps that are unsupported or fail are skipped; their
+// errors are returned alongside the session, which is never nil.
+func Enable(opts Options) (*Session, []error) {
+	var errs []error
+	if _, err := RestorePending(opts.StatePath); err != nil {
+		errs = append(errs, fmt.Errorf("failed to restore settings from a previous run: %w", err))
+	}
+
+	s := &Session{statePath: opts.StatePath}
+	for _, apply := range steps {
+		changes, err := apply(opts)
+		s.changes = append(s.changes, changes...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if len(changes) > 0 {
+			if err := saveState(s.statePath, s.changes); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return s, errs
+}
+
+// Restore puts back every setting changed by Enable
+func (s *Session) Restore() error {
+	if err := restoreAll(s.changes); err != nil {
+		return err
+	}
+	s.changes = nil
+	return removeState(s.statePath)
+}
+
+// RestorePending restores settings recorded in the state file by a run that
+// did not finish. It returns the restored changes.
+func RestorePending(statePath string) ([]Change, error) {
+	changes, err := loadState(statePath)
+	if err != nil || len(changes) == 0 {
+		return nil, err
+	}
+	if err := restoreAll(changes); err != nil {
+		return nil, err
+	}
+	return changes, removeState(statePath)
+}
+
+// restoreChange undoes a single change; replaced in tests
+var restoreChange = func(c Change) error {
+	switch c.Kind {
+	case KindNVIDIAClocks:
+		return resetNVIDIAClocks(c)
+	case KindAMDGPULevel:
+		return writeSysfs(c.Target, c.Previous)
+	default:
+		return restorePowerPlan(c)
+	}
+}
+
+// restoreAll undoes changes in reverse order, continuing past failures
+func restoreAll(changes []Change) error {
+	var errs []error
+	for i := len(changes) - 1; i >= 0; i-- {
+		if err := restoreChange(changes[i]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", changes[i], err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func loadState(path string) ([]Change, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path) // #nosec G304 -- state file in the F.I.R.E. directory
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var changes []Change
+	if err := json.Unmarshal(data, &changes); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return changes, nil
+}
+
+func saveState(path string, changes []Change) error {
+	if path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(changes, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+func removeState(path string) error {
+	if path == "" {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// command runs a tool and includes its output in the error
+func command(name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	output, err := safeexec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %w: %s", name, err, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
+
+// readSysfs returns the trimmed content of a sysfs attribute
+func readSysfs(path string) (string, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- paths come from sysfs globs
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func writeSysfs(path, value string) error {
+	return os.WriteFile(path, []byte(value), 0o644) // #nosec G306 -- sysfs attribute, mode is ignored
+}
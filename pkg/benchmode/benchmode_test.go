@@ -0,0 +1,117 @@
+package benchmode
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseNVIDIAClocks(t *testing.T) {
+	output := "0, 1755\n1, [N/A]\nNo devices were found\n"
+	clocks := parseNVIDIAClocks(output)
+	if len(clocks) != 2 || clocks[0] != 1755 || clocks[1] != 0 {
+		t.Errorf("unexpected clocks %v", clocks)
+	}
+}
+
+func fakeSteps(t *testing.T, applied ...Change) *[]Change {
+	t.Helper()
+	restored := new([]Change)
+	origSteps, origRestore := steps, restoreChange
+	steps = []step{func(Options) ([]Change, error) { return applied, nil }}
+	restoreChange = func(c Change) error {
+		*restored = append(*restored, c)
+		return nil
+	}
+	t.Cleanup(func() { steps, restoreChange = origSteps, origRestore })
+	return restored
+}
+
+func TestEnableRestore(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "benchmode.json")
+	first := Change{Kind: KindNVIDIAClocks, Target: "0", Applied: "1500 MHz"}
+	second := Change{Kind: KindPowerProfile, Previous: "balanced", Applied: "performance"}
+	restored := fakeSteps(t, first, second)
+
+	s, errs := Enable(Options{StatePath: statePath})
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(s.Changes()) != 2 {
+		t.Fatalf("expected 2 changes, got %d", len(s.Changes()))
+	}
+	if _, err := os.Stat(statePath); err != nil {
+		t.Fatalf("expected state file: %v", err)
+	}
+
+	if err := s.Restore(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*restored) != 2 || (*restored)[0] != second || (*restored)[1] != first {
+		t.Errorf("expected changes restored in reverse order, got %v", *restored)
+	}
+	if _, err := os.Stat(statePath); !os.IsNotExist(err) {
+		t.Error("expected state file to be removed")
+	}
+}
+
+func TestRestorePending(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "benchmode.json")
+	pending := Change{Kind: KindPowerScheme, Previous: "381b4222-f694-41f0-9685-ff5bb260df2e", Applied: "High performance"}
+	if err := saveState(statePath, []Change{pending}); err != nil {
+		t.Fatal(err)
+	}
+	restored := fakeSteps(t)
+
+	changes, err := RestorePending(statePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(changes) != 1 || changes[0] != pending || len(*restored) != 1 {
+		t.Errorf("expected pending change to be restored, got %v", changes)
+	}
+
+	// Nothing left to restore
+	changes, err = RestorePending(statePath)
+	if err != nil || len(changes) != 0 {
+		t.Errorf("expected nothing to restore, got %v, %v", changes, err)
+	}
+}
+
+func TestSetAMDGPULevel(t *testing.T) {
+	root := t.TempDir()
+	orig := sysfsRoot
+	sysfsRoot = root
+	defer func() { sysfsRoot = orig }()
+
+	write := func(card, vendor string) string {
+		device := filepath.Join(root, "sys/class/drm", card, "device")
+		if err := os.MkdirAll(device, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		level := filepath.Join(device, "power_dpm_force_performance_level")
+		_ = os.WriteFile(filepath.Join(device, "vendor"), []byte(vendor+"\n"), 0o644)
+		_ = os.WriteFile(level, []byte("auto\n"), 0o644)
+		return level
+	}
+	amd := write("card0", "0x1002")
+	write("card1", "0x8086")
+
+	changes, err := setAMDGPULevel(Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Target != amd || changes[0].Previous != "auto" {
+		t.Fatalf("unexpected changes %v", changes)
+	}
+	if level, _ := readSysfs(amd); level != "profile_standard" {
+		t.Errorf("expected profile_standard, got %q", level)
+	}
+
+	if err := restoreChange(changes[0]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if level, _ := readSysfs(amd); level != "auto" {
+		t.Errorf("expected level to be restored, got %q", level)
+	}
+}
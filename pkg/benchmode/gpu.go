@@ -0,0 +1,97 @@
+package benchmode
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sysfsRoot is prefixed to sysfs paths; replaced in tests
+var sysfsRoot = "/"
+
+// lockNVIDIAClocks locks the graphics clock of every NVIDIA GPU
+func lockNVIDIAClocks(opts Options) ([]Change, error) {
+	if _, err := exec.LookPath("nvidia-smi"); err != nil {
+		return nil, nil
+	}
+	output, err := command("nvidia-smi", "--query-gpu=index,clocks.default_applications.graphics", "--format=csv,noheader,nounits")
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for index, defaultClock := range parseNVIDIAClocks(output) {
+		clock := opts.GPUClockMHz
+		if clock == 0 {
+			clock = defaultClock
+		}
+		if clock == 0 {
+			continue
+		}
+		lock := fmt.Sprintf("%d,%d", clock, clock)
+		if _, err := command("nvidia-smi", "-i", strconv.Itoa(index), "-lgc", lock); err != nil {
+			return changes, fmt.Errorf("failed to lock clocks of NVIDIA GPU %d: %w", index, err)
+		}
+		changes = append(changes, Change{
+			Kind:    KindNVIDIAClocks,
+			Target:  strconv.Itoa(index),
+			Applied: fmt.Sprintf("%d MHz", clock),
+		})
+	}
+	return changes, nil
+}
+
+// parseNVIDIAClocks parses "index, clock" lines into the default application
+// clock of each GPU, in index order. Cards without one report 0.
+func parseNVIDIAClocks(output string) []int {
+	var clocks []int
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		parts := strings.Split(line, ",")
+		if len(parts) < 2 {
+			continue
+		}
+		if _, err := strconv.Atoi(strings.TrimSpace(parts[0])); err != nil {
+			continue
+		}
+		clock, _ := strconv.Atoi(strings.TrimSpace(parts[1]))
+		clocks = append(clocks, clock)
+	}
+	return clocks
+}
+
+// resetNVIDIAClocks removes a clock lock. nvidia-smi cannot report an
+// earlier lock, so the card returns to its default boost behaviour.
+func resetNVIDIAClocks(c Change) error {
+	_, err := command("nvidia-smi", "-i", c.Target, "-rgc")
+	return err
+}
+
+// setAMDGPULevel switches amdgpu cards to the profile_standard performance
+// level, which holds clocks at a fixed profiling frequency
+func setAMDGPULevel(_ Options) ([]Change, error) {
+	levels, _ := filepath.Glob(filepath.Join(sysfsRoot, "sys/class/drm/card[0-9]*/device/power_dpm_force_performance_level"))
+
+	var changes []Change
+	for _, path := range levels {
+		device := filepath.Dir(path)
+		if vendor, _ := readSysfs(filepath.Join(device, "vendor")); vendor != "0x1002" {
+			continue
+		}
+		previous, err := readSysfs(path)
+		if err != nil {
+			continue
+		}
+		if err := writeSysfs(path, "profile_standard"); err != nil {
+			return changes, fmt.Errorf("failed to set AMD GPU performance level: %w", err)
+		}
+		changes = append(changes, Change{
+			Kind:     KindAMDGPULevel,
+			Target:   path,
+			Previous: previous,
+			Applied:  "profile_standard",
+		})
+	}
+	return changes, nil
+}
@@ -0,0 +1,84 @@
+//go:build linux
+// +build linux
+
+package benchmode
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// setPowerPlan selects the performance power profile, or the performance
+// cpufreq governor on systems without power-profiles-daemon
+func setPowerPlan(_ Options) ([]Change, error) {
+	if _, err := exec.LookPath("powerprofilesctl"); err == nil {
+		if previous, err := command("powerprofilesctl", "get"); err == nil {
+			previous = strings.TrimSpace(previous)
+			if _, err := command("powerprofilesctl", "set", "performance"); err == nil {
+				return []Change{{Kind: KindPowerProfile, Previous: previous, Applied: "performance"}}, nil
+			}
+		}
+	}
+	return setGovernor()
+}
+
+// setGovernor sets every CPU to the performance governor. The previous
+// governors are kept per CPU in Previous as JSON.
+func setGovernor() ([]Change, error) {
+	paths, _ := filepath.Glob(filepath.Join(sysfsRoot, "sys/devices/system/cpu/cpu[0-9]*/cpufreq/scaling_governor"))
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	available, _ := readSysfs(filepath.Join(filepath.Dir(paths[0]), "scaling_available_governors"))
+	if !strings.Contains(" "+available+" ", " performance ") {
+		return nil, nil
+	}
+
+	previous := make(map[string]string)
+	var err error
+	for _, path := range paths {
+		governor, readErr := readSysfs(path)
+		if readErr != nil {
+			continue
+		}
+		if err = writeSysfs(path, "performance"); err != nil {
+			break
+		}
+		previous[path] = governor
+	}
+	if len(previous) == 0 {
+		return nil, fmt.Errorf("failed to set CPU governor: %w", err)
+	}
+
+	data, _ := json.Marshal(previous)
+	change := Change{Kind: KindCPUGovernor, Previous: string(data), Applied: "performance"}
+	if err != nil {
+		return []Change{change}, fmt.Errorf("failed to set CPU governor: %w", err)
+	}
+	return []Change{change}, nil
+}
+
+func restorePowerPlan(c Change) error {
+	switch c.Kind {
+	case KindPowerProfile:
+		_, err := command("powerprofilesctl", "set", c.Previous)
+		return err
+	case KindCPUGovernor:
+		var previous map[string]string
+		if err := json.Unmarshal([]byte(c.Previous), &previous); err != nil {
+			return err
+		}
+		var firstErr error
+		for path, governor := range previous {
+			if err := writeSysfs(path, governor); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	default:
+		return fmt.Errorf("cannot restore %s on this system", c.Kind)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package benchmode
+
+import "fmt"
+
+func setPowerPlan(_ Options) ([]Change, error) {
+	return nil, nil
+}
+
+func restorePowerPlan(c Change) error {
+	return fmt.Errorf("cannot restore %s on this system", c.Kind)
+}
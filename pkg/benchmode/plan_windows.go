@@ -0,0 +1,36 @@
+//go:build windows
+// +build windows
+
+package benchmode
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// schemePattern matches the GUID in powercfg /getactivescheme output
+var schemePattern = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+// setPowerPlan activates the High performance power plan
+func setPowerPlan(_ Options) ([]Change, error) {
+	output, err := command("powercfg", "/getactivescheme")
+	if err != nil {
+		return nil, err
+	}
+	previous := schemePattern.FindString(output)
+	if previous == "" {
+		return nil, fmt.Errorf("could not read the active power plan")
+	}
+	if _, err := command("powercfg", "/setactive", "SCHEME_MIN"); err != nil {
+		return nil, err
+	}
+	return []Change{{Kind: KindPowerScheme, Previous: previous, Applied: "High performance"}}, nil
+}
+
+func restorePowerPlan(c Change) error {
+	if c.Kind != KindPowerScheme {
+		return fmt.Errorf("cannot restore %s on this system", c.Kind)
+	}
+	_, err := command("powercfg", "/setactive", c.Previous)
+	return err
+}
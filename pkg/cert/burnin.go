@@ -0,0 +1,389 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/schema"
+	"github.com/mscrnt/project_fire/pkg/session"
+	"github.com/mscrnt/project_fire/pkg/throttle"
+)
+
+// BurnInFormat identifies a signed burn-in certificate, which follows the
+// burnin schema published in pkg/schema
+const BurnInFormat = "fire-burnin"
+
+// BurnInVersion is the schema version of the burn-in certificate
+const BurnInVersion = 1
+
+// SignatureAlgorithm is the only algorithm burn-in certificates are signed with
+const SignatureAlgorithm = "Ed25519"
+
+// DefaultIssuer names the issuer when the shop does not give its own name
+const DefaultIssuer = "F.I.R.E. Test Bench"
+
+// BurnIn is what a burn-in certificate attests: the hardware that was
+// tested, the plan it followed and every run with its results
+type BurnIn struct {
+	Serial    string    `json:"serial"`
+	IssuedAt  time.Time `json:"issued_at"`
+	Issuer    string    `json:"issuer"`
+	Customer  string    `json:"customer,omitempty"`
+	Reference string    `json:"reference,omitempty"` // e.g. an order or ticket number
+
+	Machine    string              `json:"machine"`
+	Model      string              `json:"model,omitempty"`
+	System     map[string]string   `json:"system,omitempty"`
+	Components []session.Component `json:"components,omitempty"`
+
+	Plan *Plan               `json:"plan,omitempty"`
+	Runs []session.RunRecord `json:"runs"`
+
+	DurationSeconds float64 `json:"duration_seconds"` // Time spent in the runs
+	Passed          bool    `json:"passed"`
+}
+
+// Plan is the test plan a burn-in followed
+type Plan struct {
+	Name   string  `json:"name"`
+	RunID  int64   `json:"run_id"` // The plan run in the issuer's database
+	Passed bool    `json:"passed"`
+	Error  string  `json:"error,omitempty"`
+	Stages []Stage `json:"stages"`
+}
+
+// Stage is one stage of the plan and how it ended
+type Stage struct {
+	Name            string  `json:"name"`
+	Kind            string  `json:"kind"`
+	Status          string  `json:"status"`
+	Message         string  `json:"message,omitempty"`
+	DurationSeconds float64 `json:"duration_seconds"`
+}
+
+// NewBurnIn creates a burn-in certificate for runs on one machine, and the
+// plan that started them if any. It passes when every run and the plan
+// passed. The serial is random; the issuer and customer are left to the
+// caller.
+func NewBurnIn(runs []session.RunRecord, plan *Plan) (*BurnIn, error) {
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("no runs to certify")
+	}
+	serial := make([]byte, 16)
+	if _, err := rand.Read(serial); err != nil {
+		return nil, fmt.Errorf("failed to generate serial: %w", err)
+	}
+
+	b := &BurnIn{
+		Serial:   strings.ToUpper(hex.EncodeToString(serial)),
+		IssuedAt: time.Now().UTC().Truncate(time.Second),
+		Issuer:   DefaultIssuer,
+		Machine:  runs[0].Run.Machine,
+		Model:    runs[0].Run.Model,
+		Plan:     plan,
+		Runs:     runs,
+		Passed:   plan == nil || plan.Passed,
+	}
+	for _, r := range runs {
+		if r.Run.Machine != b.Machine {
+			return nil, fmt.Errorf("run %d was recorded on %s, not %s; a certificate covers one machine", r.Run.ID, r.Run.Machine, b.Machine)
+		}
+		b.Passed = b.Passed && r.Run.Success
+		if r.Run.EndTime != nil {
+			b.DurationSeconds += r.Run.EndTime.Sub(r.Run.StartTime).Seconds()
+		}
+	}
+	return b, nil
+}
+
+// Status returns PASSED or FAILED
+func (b *BurnIn) Status() string {
+	if b.Passed {
+		return "PASSED"
+	}
+	return "FAILED"
+}
+
+// Signature signs the certificate content
+type Signature struct {
+	Algorithm   string `json:"algorithm"`   // Always SignatureAlgorithm
+	PublicKey   string `json:"public_key"`  // Base64 of the raw Ed25519 key
+	Fingerprint string `json:"fingerprint"` // Fingerprint of the public key
+	Value       string `json:"value"`       // Base64 signature of the compact certificate JSON
+}
+
+// SignedBurnIn is the content of a burn-in certificate file. The signature
+// covers the certificate as compact JSON, so reindenting the file does not
+// break it but changing any value does.
+type SignedBurnIn struct {
+	Format        string          `json:"format"`
+	SchemaVersion int             `json:"schema_version"`
+	Certificate   json.RawMessage `json:"certificate"`
+	Signature     Signature       `json:"signature"`
+}
+
+// Sign signs the certificate with key and returns the certificate file
+func (b *BurnIn) Sign(key ed25519.PrivateKey) ([]byte, Signature, error) {
+	content, err := json.Marshal(b)
+	if err != nil {
+		return nil, Signature{}, fmt.Errorf("failed to encode certificate: %w", err)
+	}
+	pub := key.Public().(ed25519.PublicKey)
+	sig := Signature{
+		Algorithm:   SignatureAlgorithm,
+		PublicKey:   base64.StdEncoding.EncodeToString(pub),
+		Fingerprint: Fingerprint(pub),
+		Value:       base64.StdEncoding.EncodeToString(ed25519.Sign(key, content)),
+	}
+	data, err := json.MarshalIndent(SignedBurnIn{
+		Format:        BurnInFormat,
+		SchemaVersion: BurnInVersion,
+		Certificate:   content,
+		Signature:     sig,
+	}, "", "  ")
+	if err != nil {
+		return nil, Signature{}, fmt.Errorf("failed to encode certificate: %w", err)
+	}
+	return data, sig, nil
+}
+
+// BurnInResult is the result of verifying a burn-in certificate
+type BurnInResult struct {
+	Valid       bool
+	Error       string
+	Fingerprint string // Key the certificate was signed with
+	Trusted     bool   // The key is the one the verifier was given
+	Certificate *BurnIn
+}
+
+// VerifyBurnIn checks that a burn-in certificate file is signed by the key
+// it carries and was not changed since. When trusted is given, the
+// certificate must also be signed by that key; otherwise the fingerprint
+// should be compared with the one the issuer published. An error is
+// returned only when the file is not a burn-in certificate at all.
+func VerifyBurnIn(data []byte, trusted ed25519.PublicKey) (*BurnInResult, error) {
+	if err := schema.Validate(schema.BurnIn, data); err != nil {
+		return nil, err
+	}
+	var signed SignedBurnIn
+	if err := json.Unmarshal(data, &signed); err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	if signed.SchemaVersion > BurnInVersion {
+		return nil, fmt.Errorf("certificate schema version %d is newer than this version of bench reads (%d)", signed.SchemaVersion, BurnInVersion)
+	}
+
+	result := &BurnInResult{Certificate: &BurnIn{}}
+	if err := json.Unmarshal(signed.Certificate, result.Certificate); err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	result.Error = checkSignature(signed, trusted, result)
+	result.Valid = result.Error == ""
+	return result, nil
+}
+
+// checkSignature returns why the signature does not hold, or ""
+func checkSignature(signed SignedBurnIn, trusted ed25519.PublicKey, result *BurnInResult) string {
+	if signed.Signature.Algorithm != SignatureAlgorithm {
+		return fmt.Sprintf("unsupported signature algorithm %q", signed.Signature.Algorithm)
+	}
+	pub, err := base64.StdEncoding.DecodeString(signed.Signature.PublicKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return "invalid public key"
+	}
+	result.Fingerprint = Fingerprint(pub)
+	sig, err := base64.StdEncoding.DecodeString(signed.Signature.Value)
+	if err != nil {
+		return "invalid signature encoding"
+	}
+
+	var content bytes.Buffer
+	if err := json.Compact(&content, signed.Certificate); err != nil {
+		return "invalid certificate content"
+	}
+	if !ed25519.Verify(pub, content.Bytes(), sig) {
+		return "signature does not match the content; the certificate was changed after it was signed"
+	}
+	if trusted != nil {
+		if !bytes.Equal(trusted, pub) {
+			return fmt.Sprintf("signed by key %s, not the trusted key %s", result.Fingerprint, Fingerprint(trusted))
+		}
+		result.Trusted = true
+	}
+	return ""
+}
+
+// FormatBurnInResult formats a burn-in verification result for display
+func FormatBurnInResult(result *BurnInResult) string {
+	var sb strings.Builder
+	c := result.Certificate
+
+	sb.WriteString("Burn-in Certificate Verification Result\n")
+	sb.WriteString("=======================================\n\n")
+
+	if result.Valid {
+		sb.WriteString("Status: VALID ✓\n")
+	} else {
+		sb.WriteString("Status: INVALID ✗\n")
+		sb.WriteString(fmt.Sprintf("Error: %s\n", result.Error))
+	}
+	if result.Fingerprint != "" {
+		sb.WriteString(fmt.Sprintf("Signed by: %s\n", result.Fingerprint))
+	}
+	if result.Valid && !result.Trusted {
+		sb.WriteString("Compare this fingerprint with the one published by the issuer, or pass their key with --pubkey.\n")
+	}
+
+	sb.WriteString("\nCertificate Details:\n")
+	sb.WriteString(fmt.Sprintf("  Serial: %s\n", c.Serial))
+	sb.WriteString(fmt.Sprintf("  Issuer: %s\n", c.Issuer))
+	sb.WriteString(fmt.Sprintf("  Issued: %s\n", c.IssuedAt.Format(time.RFC1123)))
+	if c.Customer != "" {
+		sb.WriteString(fmt.Sprintf("  Customer: %s\n", c.Customer))
+	}
+	if c.Reference != "" {
+		sb.WriteString(fmt.Sprintf("  Reference: %s\n", c.Reference))
+	}
+	sb.WriteString(fmt.Sprintf("  Machine: %s\n", describeMachine(c.Machine, c.Model)))
+	sb.WriteString(fmt.Sprintf("  Result: %s\n", c.Status()))
+	sb.WriteString(fmt.Sprintf("  Duration: %s\n", formatSeconds(c.DurationSeconds)))
+
+	if len(c.Components) > 0 {
+		sb.WriteString("\nHardware:\n")
+		for _, comp := range c.Components {
+			sb.WriteString(fmt.Sprintf("  %s: %s\n", comp.Type, comp.Name))
+		}
+	}
+
+	if c.Plan != nil {
+		sb.WriteString(fmt.Sprintf("\nTest Plan: %s\n", c.Plan.Name))
+		for _, stage := range c.Plan.Stages {
+			sb.WriteString(fmt.Sprintf("  %-24s %-10s %s\n", stage.Name, strings.ToUpper(stage.Status), formatSeconds(stage.DurationSeconds)))
+		}
+	}
+
+	sb.WriteString("\nRuns:\n")
+	for _, r := range c.Runs {
+		sb.WriteString(fmt.Sprintf("  #%-6d %-12s %-7s %s\n", r.Run.ID, r.Run.Plugin, runStatus(r), runDuration(r)))
+	}
+
+	return sb.String()
+}
+
+// describeMachine names the machine with its model, if known
+func describeMachine(machine, model string) string {
+	if model == "" {
+		return machine
+	}
+	return fmt.Sprintf("%s (%s)", machine, model)
+}
+
+// runStatus returns PASSED or FAILED for a run
+func runStatus(r session.RunRecord) string {
+	if r.Run.Success {
+		return "PASSED"
+	}
+	return "FAILED"
+}
+
+// runDuration returns how long a run took, or "-" if it did not end
+func runDuration(r session.RunRecord) string {
+	if r.Run.EndTime == nil {
+		return "-"
+	}
+	return formatSeconds(r.Run.EndTime.Sub(r.Run.StartTime).Seconds())
+}
+
+// formatSeconds formats a duration given in seconds, e.g. "8h0m0s"
+func formatSeconds(seconds float64) string {
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+// burnInPage is the data of the printable certificate
+type burnInPage struct {
+	*BurnIn
+	Signature Signature
+	File      string
+}
+
+// WriteHTML writes the certificate as a printable page, which is turned
+// into the PDF handed to the customer. The page itself is not signed: it
+// shows the serial and key fingerprint and refers to the JSON file, which
+// is what bench cert verify checks.
+func (b *BurnIn) WriteHTML(w io.Writer, sig Signature, file string) error {
+	return burnInTemplate.Execute(w, burnInPage{BurnIn: b, Signature: sig, File: file})
+}
+
+var burnInTemplate = template.Must(template.New("burnin").Funcs(template.FuncMap{
+	"seconds":   formatSeconds,
+	"runStatus": runStatus,
+	"duration":  runDuration,
+	"throttling": func(r session.RunRecord) string {
+		throttled, seconds, ok := throttle.FromResults(r.Results)
+		switch {
+		case !ok:
+			return ""
+		case throttled:
+			return fmt.Sprintf("throttled for %.0f s", seconds)
+		}
+		return "no throttling"
+	},
+	"value": func(v float64) string { return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", v), "0"), ".") },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Burn-in Certificate {{.Serial}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+h1 { margin-bottom: 0; }
+.subtitle { color: #666; margin-top: 0.2em; }
+.verdict { font-size: 1.6em; margin: 0.8em 0; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 5px 9px; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+.pass { color: #1a7f37; font-weight: bold; }
+.fail { color: #cf222e; font-weight: bold; }
+.metrics { font-family: monospace; font-size: 0.85em; }
+.signature { font-family: monospace; font-size: 0.8em; word-break: break-all; color: #444; }
+</style>
+</head>
+<body>
+<h1>Burn-in Certificate</h1>
+<p class="subtitle">Issued by {{.Issuer}} on {{.IssuedAt.Format "2006-01-02 15:04 MST"}} &middot; Serial {{.Serial}}</p>
+<p class="verdict">{{.Machine}}{{if .Model}} ({{.Model}}){{end}}: <span class="{{if .Passed}}pass{{else}}fail{{end}}">{{.Status}}</span> after {{seconds .DurationSeconds}} of testing</p>
+<table>
+{{if .Customer}}<tr><th>Customer</th><td>{{.Customer}}</td></tr>{{end}}
+{{if .Reference}}<tr><th>Reference</th><td>{{.Reference}}</td></tr>{{end}}
+{{range $k, $v := .System}}<tr><th>{{$k}}</th><td>{{$v}}</td></tr>
+{{end}}</table>
+{{if .Components}}<h2>Hardware</h2>
+<table>
+<tr><th>Component</th><th>Name</th><th>Details</th></tr>
+{{range .Components}}<tr><td>{{.Type}}</td><td>{{.Name}}</td><td class="metrics">{{range $k, $v := .Details}}{{$k}}: {{$v}}<br>{{end}}</td></tr>
+{{end}}</table>
+{{end}}{{if .Plan}}<h2>Test Plan: {{.Plan.Name}}</h2>
+<table>
+<tr><th>Stage</th><th>Kind</th><th>Status</th><th>Duration</th><th>Notes</th></tr>
+{{range .Plan.Stages}}<tr><td>{{.Name}}</td><td>{{.Kind}}</td><td class="{{if eq .Status "passed"}}pass{{else}}fail{{end}}">{{.Status}}</td><td>{{seconds .DurationSeconds}}</td><td>{{.Message}}</td></tr>
+{{end}}</table>
+{{end}}<h2>Runs</h2>
+<table>
+<tr><th>Run</th><th>Test</th><th>Started</th><th>Duration</th><th>Status</th><th>Results</th></tr>
+{{range .Runs}}<tr><td>#{{.Run.ID}}</td><td>{{.Run.Plugin}}</td><td>{{.Run.StartTime.Format "2006-01-02 15:04"}}</td><td>{{duration .}}</td><td class="{{if .Run.Success}}pass{{else}}fail{{end}}">{{runStatus .}}{{with throttling .}}<br><small>{{.}}</small>{{end}}{{if .Run.Error}}<br><small>{{.Run.Error}}</small>{{end}}</td><td class="metrics">{{range .Results}}{{.Metric}}: {{value .Value}} {{.Unit}}<br>{{end}}</td></tr>
+{{end}}</table>
+<h2>Verification</h2>
+<p>This page is a copy of the signed certificate {{if .File}}<code>{{.File}}</code>{{else}}file{{end}}. Check that file with <code>bench cert verify</code>; it fails if any value was changed after signing.</p>
+<p class="signature">Key: {{.Signature.Fingerprint}}<br>Signature ({{.Signature.Algorithm}}): {{.Signature.Value}}</p>
+</body>
+</html>
+`))
@@ -0,0 +1,135 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"html"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/session"
+)
+
+func testBurnIn(t *testing.T) *BurnIn {
+	t.Helper()
+	start := time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)
+	end := start.Add(8 * time.Hour)
+	runs := []session.RunRecord{
+		{
+			Run:     &db.Run{ID: 41, Plugin: "cpu", Machine: "rig-01", StartTime: start, EndTime: &end, Success: true},
+			Results: []*db.Result{{RunID: 41, Metric: "cpu_temp_peak", Value: 81.5, Unit: "°C"}},
+		},
+		{
+			Run: &db.Run{ID: 42, Plugin: "memory", Machine: "rig-01", StartTime: end, EndTime: &end, Success: true},
+		},
+	}
+	plan := &Plan{Name: "Workstation burn-in", RunID: 7, Passed: true, Stages: []Stage{{Name: "CPU", Kind: "plugin", Status: "passed", DurationSeconds: 28800}}}
+	b, err := NewBurnIn(runs, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Customer = "Acme <Corp>"
+	b.Components = []session.Component{{Type: "CPU", Name: "Test CPU", Details: map[string]string{"Cores": "8"}}}
+	return b
+}
+
+func TestBurnInSignVerify(t *testing.T) {
+	key, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := testBurnIn(t)
+	if !b.Passed || b.DurationSeconds != 8*3600 || b.Machine != "rig-01" {
+		t.Fatalf("unexpected certificate %+v", b)
+	}
+	data, sig, err := b.Sign(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := VerifyBurnIn(data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid || result.Trusted || result.Fingerprint != sig.Fingerprint || result.Certificate.Customer != "Acme <Corp>" {
+		t.Errorf("expected a valid, untrusted certificate, got %+v", result)
+	}
+
+	// Reformatting the file keeps the signature
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		t.Fatal(err)
+	}
+	if result, err := VerifyBurnIn(compact.Bytes(), key.Public().(ed25519.PublicKey)); err != nil || !result.Valid || !result.Trusted {
+		t.Errorf("expected the compacted file to verify with the trusted key, got %+v, %v", result, err)
+	}
+
+	// Changing a value breaks it
+	tampered := bytes.Replace(data, []byte(`81.5`), []byte(`71.5`), 1)
+	if bytes.Equal(tampered, data) {
+		t.Fatal("expected the test to change the certificate")
+	}
+	if result, err := VerifyBurnIn(tampered, nil); err != nil || result.Valid || !strings.Contains(result.Error, "changed after it was signed") {
+		t.Errorf("expected the changed certificate to fail, got %+v, %v", result, err)
+	}
+
+	// Another key fails when a trusted key is given
+	other, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result, err := VerifyBurnIn(data, other.Public().(ed25519.PublicKey)); err != nil || result.Valid || !strings.Contains(result.Error, "not the trusted key") {
+		t.Errorf("expected a different key to fail, got %+v, %v", result, err)
+	}
+
+	if _, err := VerifyBurnIn([]byte(`{"format": "fire-burnin", "schema_version": 1}`), nil); err == nil {
+		t.Error("expected a file without a certificate to be rejected")
+	}
+
+	var page bytes.Buffer
+	if err := b.WriteHTML(&page, sig, "burnin.json"); err != nil {
+		t.Fatal(err)
+	}
+	// The fingerprint is base64, whose "+" the template escapes
+	if !strings.Contains(page.String(), "Acme &lt;Corp&gt;") || !strings.Contains(html.UnescapeString(page.String()), sig.Fingerprint) {
+		t.Error("expected the page to show the escaped customer and the key fingerprint")
+	}
+}
+
+func TestBurnInOneMachine(t *testing.T) {
+	start := time.Now()
+	runs := []session.RunRecord{
+		{Run: &db.Run{ID: 1, Plugin: "cpu", Machine: "rig-01", StartTime: start}},
+		{Run: &db.Run{ID: 2, Plugin: "cpu", Machine: "rig-02", StartTime: start}},
+	}
+	if _, err := NewBurnIn(runs, nil); err == nil {
+		t.Error("expected runs from two machines to be rejected")
+	}
+	if _, err := NewBurnIn(nil, nil); err == nil {
+		t.Error("expected no runs to be rejected")
+	}
+}
+
+func TestSigningKeyFiles(t *testing.T) {
+	dir := t.TempDir()
+	key, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath, pubPath := filepath.Join(dir, SigningKeyFile), filepath.Join(dir, SigningPubFile)
+	if err := SaveSigningKey(key, keyPath, pubPath); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSigningKey(keyPath)
+	if err != nil || !loaded.Equal(key) {
+		t.Fatalf("expected the saved key back, got %v", err)
+	}
+	pub, err := LoadPublicKey(pubPath)
+	if err != nil || Fingerprint(pub) != Fingerprint(key.Public().(ed25519.PublicKey)) || !strings.HasPrefix(Fingerprint(pub), "SHA256:") {
+		t.Fatalf("expected the saved public key back, got %v", err)
+	}
+}
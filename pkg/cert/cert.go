@@ -1,4 +1,13 @@
-// Package cert provides certificate generation and management for mTLS communication.
+// Package cert provides certificate generation and management for mTLS
+// communication, and the certificates that attest to test results: X.509
+// certificates for single runs, and signed burn-in certificates handed to
+// customers.
+//
+// A burn-in certificate is a JSON document with the hardware tested, the
+// test plan it followed and every run with its results, signed with the
+// shop's Ed25519 key. Anyone holding the file can check with VerifyBurnIn
+// that it was not changed after signing, and compare the key's fingerprint
+// with the one the shop publishes.
 package cert
 
 import (
@@ -10,9 +19,11 @@ import (
 	"fmt"
 	"math/big"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/throttle"
 )
 
 // CertificateIssuer handles certificate generation for test results
@@ -232,13 +243,30 @@ func (i *CertificateIssuer) buildExtensions(run *db.Run, results []*db.Result) [
 		})
 	}
 
+	// Add throttling extension for runs that were watched
+	if throttled, seconds, ok := throttle.FromResults(results); ok {
+		throttleValue := "NONE"
+		if throttled {
+			throttleValue = fmt.Sprintf("THROTTLED %.0f", seconds)
+		}
+		extensions = append(extensions, pkix.Extension{
+			Id:    []int{1, 3, 6, 1, 4, 1, 99999, 1, 4}, // Custom OID for throttling
+			Value: []byte(throttleValue),
+		})
+	}
+
 	// Add key metrics
-	for i, result := range results {
-		if i >= 5 { // Limit to 5 key metrics
+	n := 0
+	for _, result := range results {
+		if n >= 5 { // Limit to 5 key metrics
 			break
 		}
+		if strings.HasPrefix(result.Metric, "throttle") {
+			continue
+		}
+		n++
 		extensions = append(extensions, pkix.Extension{
-			Id:    []int{1, 3, 6, 1, 4, 1, 99999, 2, i + 1}, // Custom OID for metrics
+			Id:    []int{1, 3, 6, 1, 4, 1, 99999, 2, n}, // Custom OID for metrics
 			Value: []byte(fmt.Sprintf("%s:%f %s", result.Metric, result.Value, result.Unit)),
 		})
 	}
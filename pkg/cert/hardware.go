@@ -0,0 +1,127 @@
+package cert
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/host"
+	"github.com/shirou/gopsutil/v3/mem"
+
+	"github.com/mscrnt/project_fire/pkg/changelog"
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/session"
+	"github.com/mscrnt/project_fire/pkg/smart"
+	"github.com/mscrnt/project_fire/pkg/smbios"
+)
+
+// Hardware describes the machine bench runs on for a burn-in certificate:
+// host details, and the system, board, CPU, memory and drives as
+// components. Drives come from the SMART snapshots saved by the end of the
+// runs, so they are the drives that were tested. Anything that cannot be
+// read is left out.
+func Hardware(ctx context.Context, database *db.DB, at time.Time) (map[string]string, []session.Component) {
+	system := map[string]string{
+		"Hostname":     changelog.Machine(),
+		"Architecture": runtime.GOARCH,
+	}
+	versions := changelog.Versions(ctx)
+	if platform := versions[changelog.ComponentOS]; platform != "" {
+		system["OS"] = platform
+	}
+	if kernel := versions[changelog.ComponentKernel]; kernel != "" {
+		system["Kernel"] = kernel
+	}
+	if info, err := host.InfoWithContext(ctx); err == nil && info.HostID != "" {
+		system["Host ID"] = info.HostID
+	}
+
+	var components []session.Component
+	var tables *smbios.Info
+	if table, err := smbios.Read(); err == nil {
+		tables = table.Decode()
+	}
+	if tables != nil {
+		if name := join(tables.System.Manufacturer, tables.System.Product); name != "" {
+			components = append(components, component("System", name, map[string]string{
+				"Serial": tables.System.SerialNumber,
+				"SKU":    tables.System.SKU,
+			}))
+		}
+		if name := join(tables.Baseboard.Manufacturer, tables.Baseboard.Product); name != "" {
+			components = append(components, component("Motherboard", name, map[string]string{
+				"Version": tables.Baseboard.Version,
+				"Serial":  tables.Baseboard.SerialNumber,
+				"BIOS":    join(tables.BIOS.Vendor, tables.BIOS.Version, tables.BIOS.ReleaseDate),
+			}))
+		}
+	} else if bios := versions[changelog.ComponentBIOS]; bios != "" {
+		components = append(components, component("BIOS", bios, nil))
+	}
+
+	if cpus, err := cpu.InfoWithContext(ctx); err == nil && len(cpus) > 0 {
+		details := map[string]string{}
+		if cores, err := cpu.CountsWithContext(ctx, false); err == nil {
+			details["Cores"] = fmt.Sprint(cores)
+		}
+		if threads, err := cpu.CountsWithContext(ctx, true); err == nil {
+			details["Threads"] = fmt.Sprint(threads)
+		}
+		if cpus[0].Mhz > 0 {
+			details["Clock"] = fmt.Sprintf("%.0f MHz", cpus[0].Mhz)
+		}
+		components = append(components, component("CPU", strings.TrimSpace(cpus[0].ModelName), details))
+	}
+
+	if vm, err := mem.VirtualMemoryWithContext(ctx); err == nil {
+		details := map[string]string{}
+		if tables != nil && tables.MemorySlots() > 0 {
+			details["Slots"] = fmt.Sprint(tables.MemorySlots())
+		}
+		components = append(components, component("Memory", fmt.Sprintf("%.1f GB", float64(vm.Total)/(1<<30)), details))
+	}
+
+	if driver := versions[changelog.ComponentGPUDriver]; driver != "" {
+		components = append(components, component("GPU Driver", driver, nil))
+	}
+
+	if database != nil {
+		if snapshots, err := database.LatestSMARTSnapshots(at); err == nil {
+			for _, s := range snapshots {
+				details := map[string]string{"Device": s.Device, "Serial": s.Serial}
+				if d, err := smart.FromSnapshot(s); err == nil {
+					details["Firmware"] = d.Firmware
+					details["Health"] = d.HealthStatus
+				}
+				name := s.Model
+				if name == "" {
+					name = s.Device
+				}
+				components = append(components, component("Storage", name, details))
+			}
+		}
+	}
+
+	return system, components
+}
+
+// component creates a component, leaving out empty details
+func component(typ, name string, details map[string]string) session.Component {
+	for k, v := range details {
+		if strings.TrimSpace(v) == "" {
+			delete(details, k)
+		}
+	}
+	if len(details) == 0 {
+		details = nil
+	}
+	return session.Component{Type: typ, Name: name, Details: details}
+}
+
+// join joins the non-empty parts with spaces
+func join(parts ...string) string {
+	return strings.Join(strings.Fields(strings.Join(parts, " ")), " ")
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/pem"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // VerifyResult contains the result of certificate verification
@@ -15,11 +17,63 @@ type VerifyResult struct {
 	Plugin      string
 	Status      string
 	Duration    string
+	Throttling  string // Empty when the run was not watched for throttling
 	Metrics     map[string]string
 	Error       string
 	Certificate *x509.Certificate
 }
 
+// SummaryFormat identifies a certificate summary, which follows the
+// certificate schema published in pkg/schema
+const SummaryFormat = "fire-certificate"
+
+// SummaryVersion is the schema version of the summary
+const SummaryVersion = 1
+
+// Summary is the machine-readable form of a verification result, written by
+// "bench cert verify --json"
+type Summary struct {
+	Format          string            `json:"format"`
+	SchemaVersion   int               `json:"schema_version"`
+	Valid           bool              `json:"valid"`
+	Error           string            `json:"error,omitempty"`
+	Subject         string            `json:"subject"`
+	Issuer          string            `json:"issuer"`
+	Serial          string            `json:"serial"`
+	NotBefore       time.Time         `json:"not_before"`
+	NotAfter        time.Time         `json:"not_after"`
+	RunID           string            `json:"run_id,omitempty"`
+	Plugin          string            `json:"plugin,omitempty"`
+	Status          string            `json:"status,omitempty"`
+	DurationSeconds *float64          `json:"duration_seconds,omitempty"`
+	Throttling      string            `json:"throttling,omitempty"`
+	Metrics         map[string]string `json:"metrics,omitempty"`
+}
+
+// Summary returns the result in its machine-readable form
+func (r *VerifyResult) Summary() *Summary {
+	s := &Summary{
+		Format:        SummaryFormat,
+		SchemaVersion: SummaryVersion,
+		Valid:         r.Valid,
+		Error:         r.Error,
+		Subject:       r.Certificate.Subject.String(),
+		Issuer:        r.Certificate.Issuer.String(),
+		Serial:        r.Certificate.SerialNumber.String(),
+		NotBefore:     r.Certificate.NotBefore,
+		NotAfter:      r.Certificate.NotAfter,
+		RunID:         r.RunID,
+		Plugin:        r.Plugin,
+		Status:        r.Status,
+		Throttling:    r.Throttling,
+		Metrics:       r.Metrics,
+	}
+	if seconds, err := strconv.ParseFloat(strings.TrimSuffix(r.Duration, " seconds"), 64); err == nil {
+		s.DurationSeconds = &seconds
+	}
+	return s
+}
+
 // VerifyCertificateFile verifies a certificate file and extracts test data
 func VerifyCertificateFile(certPath, caCertPath string) (*VerifyResult, error) {
 	// Load certificate
@@ -92,6 +146,11 @@ func VerifyCertificateFile(certPath, caCertPath string) (*VerifyResult, error) {
 			result.Plugin = value
 		case "1.3.6.1.4.1.99999.1.3": // Duration
 			result.Duration = value + " seconds"
+		case "1.3.6.1.4.1.99999.1.4": // Throttling
+			result.Throttling = "none"
+			if seconds, ok := strings.CutPrefix(value, "THROTTLED "); ok {
+				result.Throttling = "throttled for " + seconds + " seconds"
+			}
 		default:
 			// Check if it's a metric extension
 			if strings.HasPrefix(oidString, "1.3.6.1.4.1.99999.2.") {
@@ -143,6 +202,9 @@ func FormatVerifyResult(result *VerifyResult) string {
 		sb.WriteString(fmt.Sprintf("  Plugin: %s\n", result.Plugin))
 		sb.WriteString(fmt.Sprintf("  Status: %s\n", result.Status))
 		sb.WriteString(fmt.Sprintf("  Duration: %s\n", result.Duration))
+		if result.Throttling != "" {
+			sb.WriteString(fmt.Sprintf("  Throttling: %s\n", result.Throttling))
+		}
 
 		if len(result.Metrics) > 0 {
 			sb.WriteString("\nMetrics:\n")
@@ -0,0 +1,165 @@
+// Package changelog tracks the firmware, driver and OS versions of a machine
+// across test sessions.
+//
+// Each time versions are recorded, those that differ from the last ones seen
+// are stored in the database with the time they were detected. Impacts puts
+// a metric's trend before and after each change side by side, so a claim
+// such as "it got slower after the driver update" can be checked against the
+// results.
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/host"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+// Tracked components
+const (
+	ComponentBIOS      = "bios"
+	ComponentGPUDriver = "gpu_driver"
+	ComponentOS        = "os"
+	ComponentKernel    = "kernel"
+)
+
+// componentLabels names the tracked components for display
+var componentLabels = map[string]string{
+	ComponentBIOS:      "BIOS",
+	ComponentGPUDriver: "GPU Driver",
+	ComponentOS:        "OS Build",
+	ComponentKernel:    "Kernel",
+}
+
+// ComponentLabel returns the display name of a component
+func ComponentLabel(component string) string {
+	if label, ok := componentLabels[component]; ok {
+		return label
+	}
+	return component
+}
+
+// Describe summarizes a change in one line, e.g. "GPU Driver: 550.54 -> 555.42"
+func Describe(c *db.VersionChange) string {
+	if c.Baseline() {
+		return fmt.Sprintf("%s: %s", ComponentLabel(c.Component), c.NewVersion)
+	}
+	return fmt.Sprintf("%s: %s -> %s", ComponentLabel(c.Component), c.OldVersion, c.NewVersion)
+}
+
+// Machine returns the name versions are recorded under, the hostname
+func Machine() string {
+	return db.Hostname()
+}
+
+var (
+	modelOnce sync.Once
+	model     string
+)
+
+// Model returns the hardware model of the machine, e.g. "Dell Inc.
+// PowerEdge R650", or "" if it cannot be read. It is read once per process.
+func Model() string {
+	modelOnce.Do(func() {
+		model = strings.Join(strings.Fields(systemModel(context.Background())), " ")
+	})
+	return model
+}
+
+// Identify records on a run the machine and hardware model it runs on, so
+// runs from many machines can be told apart once they share a database
+func Identify(run *db.Run) {
+	run.Machine = Machine()
+	run.Model = Model()
+}
+
+// Versions reads the current version of each component. Components that
+// cannot be read on this system are left out.
+func Versions(ctx context.Context) map[string]string {
+	versions := firmwareVersions(ctx)
+	if info, err := host.InfoWithContext(ctx); err == nil {
+		if platform := strings.TrimSpace(info.Platform + " " + info.PlatformVersion); platform != "" {
+			versions[ComponentOS] = platform
+		}
+		if info.KernelVersion != "" {
+			versions[ComponentKernel] = info.KernelVersion
+		}
+	}
+	for component, version := range versions {
+		if version == "" {
+			delete(versions, component)
+		}
+	}
+	return versions
+}
+
+// Record reads the current versions and stores those that changed since the
+// last time they were recorded on this machine
+func Record(ctx context.Context, database *db.DB) ([]*db.VersionChange, error) {
+	return database.RecordVersions(Machine(), Versions(ctx), time.Now())
+}
+
+// Window summarizes a metric over a period next to a change
+type Window struct {
+	Avg   float64 `json:"avg"`
+	Count int     `json:"count"` // Number of results
+}
+
+// Impact compares a metric before and after a version change
+type Impact struct {
+	Change *db.VersionChange `json:"change"`
+	Before Window            `json:"before"`
+	After  Window            `json:"after"`
+}
+
+// ChangePct returns the change of the average in percent, and false when
+// either side has no results to compare
+func (i Impact) ChangePct() (float64, bool) {
+	if i.Before.Count == 0 || i.After.Count == 0 || i.Before.Avg == 0 {
+		return 0, false
+	}
+	return (i.After.Avg - i.Before.Avg) / i.Before.Avg * 100, true
+}
+
+// Impacts compares the buckets of a metric series in the window before and
+// after each change. Baseline entries, which record the first version seen
+// rather than an update, are skipped.
+func Impacts(changes []*db.VersionChange, buckets []db.Bucket, window time.Duration) []Impact {
+	var impacts []Impact
+	for _, c := range changes {
+		if c.Baseline() {
+			continue
+		}
+		impacts = append(impacts, Impact{
+			Change: c,
+			Before: summarize(buckets, c.DetectedAt.Add(-window), c.DetectedAt),
+			After:  summarize(buckets, c.DetectedAt, c.DetectedAt.Add(window)),
+		})
+	}
+	return impacts
+}
+
+// summarize averages the buckets starting in [from, to), weighting each by
+// its number of results
+func summarize(buckets []db.Bucket, from, to time.Time) Window {
+	var (
+		w   Window
+		sum float64
+	)
+	for _, b := range buckets {
+		if b.Start.Before(from) || !b.Start.Before(to) {
+			continue
+		}
+		sum += b.Avg * float64(b.Count)
+		w.Count += b.Count
+	}
+	if w.Count > 0 {
+		w.Avg = sum / float64(w.Count)
+	}
+	return w
+}
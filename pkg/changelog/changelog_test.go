@@ -0,0 +1,52 @@
+package changelog
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+func TestImpacts(t *testing.T) {
+	update := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	changes := []*db.VersionChange{
+		{Component: ComponentGPUDriver, NewVersion: "NVIDIA 550.54", DetectedAt: update.Add(-30 * 24 * time.Hour)},
+		{Component: ComponentGPUDriver, OldVersion: "NVIDIA 550.54", NewVersion: "NVIDIA 555.42", DetectedAt: update},
+	}
+	day := 24 * time.Hour
+	buckets := []db.Bucket{
+		{Start: update.Add(-20 * day), Count: 5, Avg: 50},  // Outside the window
+		{Start: update.Add(-3 * day), Count: 3, Avg: 100},  // Before
+		{Start: update.Add(-1 * day), Count: 1, Avg: 120},  // Before
+		{Start: update.Add(2 * day), Count: 2, Avg: 90},    // After
+		{Start: update.Add(15 * day), Count: 10, Avg: 200}, // Outside the window
+	}
+
+	impacts := Impacts(changes, buckets, 7*day)
+	if len(impacts) != 1 {
+		t.Fatalf("expected the baseline to be skipped, got %d impacts", len(impacts))
+	}
+	impact := impacts[0]
+	if impact.Before.Count != 4 || impact.Before.Avg != 105 {
+		t.Errorf("expected a weighted average of 105 over 4 results before, got %+v", impact.Before)
+	}
+	if impact.After.Count != 2 || impact.After.Avg != 90 {
+		t.Errorf("expected 90 over 2 results after, got %+v", impact.After)
+	}
+	pct, ok := impact.ChangePct()
+	if !ok || math.Abs(pct-(-100.0/7)) > 1e-9 {
+		t.Errorf("expected a change of about -14.3%%, got %v (%v)", pct, ok)
+	}
+
+	// No results on one side leaves nothing to compare
+	if _, ok := Impacts(changes, buckets[:1], 7*day)[0].ChangePct(); ok {
+		t.Error("expected no change without results")
+	}
+}
+
+func TestComponentLabel(t *testing.T) {
+	if ComponentLabel(ComponentGPUDriver) != "GPU Driver" || ComponentLabel("nic_firmware") != "nic_firmware" {
+		t.Error("expected known components to have labels and others to keep their key")
+	}
+}
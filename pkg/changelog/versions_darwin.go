@@ -0,0 +1,39 @@
+//go:build darwin
+// +build darwin
+
+package changelog
+
+import (
+	"bufio"
+	"context"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// firmwareVersions reads the system firmware version. macOS ships GPU
+// drivers with the OS, so they are covered by the OS build.
+func firmwareVersions(ctx context.Context) map[string]string {
+	versions := make(map[string]string)
+	out, err := safeexec.CommandContext(ctx, "system_profiler", "SPHardwareDataType").Output()
+	if err != nil {
+		return versions
+	}
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if ok && (key == "System Firmware Version" || key == "Boot ROM Version") {
+			versions[ComponentBIOS] = strings.TrimSpace(value)
+		}
+	}
+	return versions
+}
+
+// systemModel returns the model identifier, e.g. "Mac14,3"
+func systemModel(ctx context.Context) string {
+	out, err := safeexec.CommandContext(ctx, "sysctl", "-n", "hw.model").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,79 @@
+//go:build linux
+// +build linux
+
+package changelog
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// firmwareVersions reads the BIOS version from DMI and the NVIDIA driver
+// version from the loaded kernel module
+func firmwareVersions(ctx context.Context) map[string]string {
+	versions := make(map[string]string)
+
+	// Some vendors keep the version string across releases, so the date is
+	// included to catch those updates too
+	if version := readSysfs("/sys/class/dmi/id/bios_version"); version != "" {
+		if date := readSysfs("/sys/class/dmi/id/bios_date"); date != "" {
+			version += " (" + date + ")"
+		}
+		versions[ComponentBIOS] = version
+	}
+
+	if version := readSysfs("/sys/module/nvidia/version"); version != "" {
+		versions[ComponentGPUDriver] = "NVIDIA " + version
+	} else if path, err := exec.LookPath("nvidia-smi"); err == nil {
+		out, err := safeexec.CommandContext(ctx, path, "--query-gpu=driver_version", "--format=csv,noheader").Output()
+		if err == nil {
+			if lines := strings.Fields(string(out)); len(lines) > 0 {
+				versions[ComponentGPUDriver] = "NVIDIA " + lines[0]
+			}
+		}
+	}
+	return versions
+}
+
+// systemModel reads the system vendor and product name from DMI. Boards
+// that leave the product unset report the board name instead.
+func systemModel(_ context.Context) string {
+	product := readSysfs("/sys/class/dmi/id/product_name")
+	if product == "" || isPlaceholder(product) {
+		product = readSysfs("/sys/class/dmi/id/board_name")
+	}
+	if product == "" {
+		return ""
+	}
+	vendor := readSysfs("/sys/class/dmi/id/sys_vendor")
+	if vendor == "" || isPlaceholder(vendor) {
+		vendor = readSysfs("/sys/class/dmi/id/board_vendor")
+	}
+	if vendor == "" || isPlaceholder(vendor) || strings.HasPrefix(product, vendor) {
+		return product
+	}
+	return vendor + " " + product
+}
+
+// isPlaceholder reports whether a DMI string was left at a vendor default
+func isPlaceholder(s string) bool {
+	switch strings.ToLower(s) {
+	case "to be filled by o.e.m.", "system product name", "system manufacturer", "default string", "not applicable", "none":
+		return true
+	}
+	return false
+}
+
+// readSysfs returns the trimmed content of a sysfs attribute, or "" if it
+// cannot be read
+func readSysfs(path string) string {
+	data, err := os.ReadFile(path) // #nosec G304 -- fixed sysfs paths
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
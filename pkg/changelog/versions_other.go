@@ -0,0 +1,14 @@
+//go:build !linux && !windows && !darwin
+// +build !linux,!windows,!darwin
+
+package changelog
+
+import "context"
+
+func firmwareVersions(_ context.Context) map[string]string {
+	return make(map[string]string)
+}
+
+func systemModel(_ context.Context) string {
+	return ""
+}
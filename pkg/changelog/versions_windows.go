@@ -0,0 +1,65 @@
+//go:build windows
+// +build windows
+
+package changelog
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/StackExchange/wmi"
+)
+
+type win32BIOS struct {
+	SMBIOSBIOSVersion string
+	Manufacturer      string
+}
+
+type win32ComputerSystem struct {
+	Manufacturer string
+	Model        string
+}
+
+type win32VideoController struct {
+	Name          string
+	DriverVersion string
+}
+
+// firmwareVersions reads the BIOS and display driver versions through WMI
+func firmwareVersions(_ context.Context) map[string]string {
+	versions := make(map[string]string)
+
+	var bios []win32BIOS
+	if err := wmi.Query("SELECT SMBIOSBIOSVersion, Manufacturer FROM Win32_BIOS", &bios); err == nil && len(bios) > 0 {
+		versions[ComponentBIOS] = strings.TrimSpace(bios[0].SMBIOSBIOSVersion)
+	}
+
+	// Each adapter's driver is listed, skipping the fallback display driver
+	var controllers []win32VideoController
+	if err := wmi.Query("SELECT Name, DriverVersion FROM Win32_VideoController", &controllers); err == nil {
+		var drivers []string
+		for _, c := range controllers {
+			if c.DriverVersion == "" || strings.Contains(c.Name, "Microsoft Basic") {
+				continue
+			}
+			drivers = append(drivers, strings.TrimSpace(c.Name)+" "+c.DriverVersion)
+		}
+		sort.Strings(drivers)
+		versions[ComponentGPUDriver] = strings.Join(drivers, ", ")
+	}
+	return versions
+}
+
+// systemModel reads the manufacturer and model of the computer through WMI
+func systemModel(_ context.Context) string {
+	var systems []win32ComputerSystem
+	if err := wmi.Query("SELECT Manufacturer, Model FROM Win32_ComputerSystem", &systems); err != nil || len(systems) == 0 {
+		return ""
+	}
+	vendor, product := strings.TrimSpace(systems[0].Manufacturer), strings.TrimSpace(systems[0].Model)
+	if vendor == "" || strings.HasPrefix(product, vendor) {
+		return product
+	}
+	return vendor + " " + product
+}
@@ -0,0 +1,267 @@
+// Package compare puts the results of several runs side by side, such as a
+// machine's runs before and after a repair, and flags the metrics that got
+// worse than the first run by more than a threshold.
+package compare
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/units"
+)
+
+// DefaultThreshold is the change in percent beyond which a worse metric is a
+// regression
+const DefaultThreshold = 5.0
+
+// Direction says which way a metric improves
+type Direction int
+
+// Directions
+const (
+	Neutral        Direction = iota // Neither way is better, e.g. power or fan speed
+	HigherIsBetter                  // Scores, throughput and clocks
+	LowerIsBetter                   // Temperatures, latencies and errors
+)
+
+// Words of metric names, split at underscores, that tell which way a metric
+// improves. Lower is checked first, so allocation_time_ms is a time.
+var (
+	lowerWords  = []string{"temp", "temperature", "latency", "error", "errors", "failed", "failures", "throttle", "throttled", "time", "ms", "us", "ns"}
+	higherWords = []string{"score", "ops", "iops", "bandwidth", "throughput", "clock", "mhz", "mbps", "mts", "passed", "pass", "gain"}
+	lowerUnits  = []string{"°C", "°F", "ms", "us", "µs", "ns"}
+	higherUnits = []string{"points", "MB/s", "MiB/s", "GB/s", "ops/s", "IOPS", "MHz", "GHz"}
+)
+
+// DirectionOf guesses which way a metric improves from its name and unit
+func DirectionOf(metric, unit string) Direction {
+	words := strings.Split(strings.ToLower(metric), "_")
+	has := func(list []string) bool {
+		for _, w := range words {
+			for _, l := range list {
+				if w == l {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	switch {
+	case contains(lowerUnits, unit) || has(lowerWords):
+		return LowerIsBetter
+	case contains(higherUnits, unit) || has(higherWords) || strings.Contains(strings.ToLower(metric), "per_sec"):
+		return HigherIsBetter
+	}
+	return Neutral
+}
+
+// Summary summarizes the values a run recorded for a metric. A benchmark
+// records its metrics once; a monitored or stability run samples them, and
+// their average is the sustained value, e.g. the sustained clock.
+type Summary struct {
+	Count int
+	Avg   float64
+	P95   float64
+	Min   float64
+	Max   float64
+}
+
+// summarize returns the summary of values, which it sorts
+func summarize(values []float64) *Summary {
+	sort.Float64s(values)
+	s := &Summary{Count: len(values), Min: values[0], Max: values[len(values)-1]}
+	for _, v := range values {
+		s.Avg += v
+	}
+	s.Avg /= float64(len(values))
+	// Nearest rank
+	s.P95 = values[int(math.Ceil(0.95*float64(len(values))))-1]
+	return s
+}
+
+// Verdict is how a metric changed from the baseline
+type Verdict int
+
+// Verdicts
+const (
+	Within Verdict = iota // Within the threshold, or a neutral metric
+	Better
+	Worse // A regression
+)
+
+// Change is how a run's average of a metric differs from the baseline's
+type Change struct {
+	Percent float64 // ±Inf when the baseline was zero
+	Verdict Verdict
+}
+
+// String formats the change, e.g. "+6.3%"
+func (c Change) String() string {
+	if math.IsInf(c.Percent, 0) {
+		return "from 0"
+	}
+	return fmt.Sprintf("%+.1f%%", c.Percent)
+}
+
+// Row compares one metric across the runs
+type Row struct {
+	Metric    string
+	Unit      string
+	Direction Direction
+
+	// Summaries has one entry per run, nil where the run did not record the
+	// metric. Changes has one per run as well; the baseline's, and those of
+	// runs where either lacks the metric, are zero.
+	Summaries []*Summary
+	Changes   []Change
+}
+
+// Regressed reports whether any run got worse than the baseline
+func (r Row) Regressed() bool {
+	for _, c := range r.Changes {
+		if c.Verdict == Worse {
+			return true
+		}
+	}
+	return false
+}
+
+// Comparison compares runs with the first of them, the baseline
+type Comparison struct {
+	Generated time.Time
+	Runs      []*db.Run
+	Threshold float64 // Percent
+	Rows      []Row   // By metric
+
+	// Units are the units values are shown in; changes are computed from
+	// the recorded values
+	Units units.Prefs
+}
+
+// Runs loads the results of runs and compares them with the first
+func Runs(database *db.DB, runs []*db.Run, threshold float64) (*Comparison, error) {
+	if len(runs) < 2 {
+		return nil, fmt.Errorf("need at least two runs to compare, got %d", len(runs))
+	}
+	results := make([][]*db.Result, len(runs))
+	for i, run := range runs {
+		r, err := database.GetResults(run.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get results of run %d: %w", run.ID, err)
+		}
+		results[i] = r
+	}
+	return New(runs, results, threshold), nil
+}
+
+// New compares the results of runs, one slice per run, with the first run's
+func New(runs []*db.Run, results [][]*db.Result, threshold float64) *Comparison {
+	c := &Comparison{Generated: time.Now(), Runs: runs, Threshold: threshold, Units: units.Default()}
+
+	values := make(map[string][][]float64)
+	unitOf := make(map[string]string)
+	for i, rs := range results {
+		for _, r := range rs {
+			if values[r.Metric] == nil {
+				values[r.Metric] = make([][]float64, len(runs))
+			}
+			values[r.Metric][i] = append(values[r.Metric][i], r.Value)
+			if unitOf[r.Metric] == "" {
+				unitOf[r.Metric] = r.Unit
+			}
+		}
+	}
+
+	metrics := make([]string, 0, len(values))
+	for m := range values {
+		metrics = append(metrics, m)
+	}
+	sort.Strings(metrics)
+
+	for _, m := range metrics {
+		row := Row{
+			Metric:    m,
+			Unit:      unitOf[m],
+			Direction: DirectionOf(m, unitOf[m]),
+			Summaries: make([]*Summary, len(runs)),
+			Changes:   make([]Change, len(runs)),
+		}
+		for i, v := range values[m] {
+			if len(v) > 0 {
+				row.Summaries[i] = summarize(v)
+			}
+		}
+		if base := row.Summaries[0]; base != nil {
+			for i := 1; i < len(runs); i++ {
+				if s := row.Summaries[i]; s != nil {
+					row.Changes[i] = change(base.Avg, s.Avg, row.Direction, threshold)
+				}
+			}
+		}
+		c.Rows = append(c.Rows, row)
+	}
+	return c
+}
+
+// change compares a value with the baseline's
+func change(base, value float64, dir Direction, threshold float64) Change {
+	var c Change
+	switch {
+	case base != 0:
+		c.Percent = (value - base) / math.Abs(base) * 100
+	case value != 0:
+		c.Percent = math.Inf(int(math.Copysign(1, value)))
+	}
+	if dir == Neutral || math.Abs(c.Percent) <= threshold {
+		return c
+	}
+	if (c.Percent > 0) == (dir == HigherIsBetter) {
+		c.Verdict = Better
+	} else {
+		c.Verdict = Worse
+	}
+	return c
+}
+
+// Regressions returns the rows of the metrics that got worse
+func (c *Comparison) Regressions() []Row {
+	var rows []Row
+	for _, r := range c.Rows {
+		if r.Regressed() {
+			rows = append(rows, r)
+		}
+	}
+	return rows
+}
+
+// Value formats the summary of a row's metric for run i in the display
+// units: the value of a metric recorded once, or the average and 95th
+// percentile of a sampled one
+func (c *Comparison) Value(r Row, i int) string {
+	s := r.Summaries[i]
+	if s == nil {
+		return "-"
+	}
+	avg, unit := c.Units.Convert(s.Avg, r.Unit)
+	if unit != "" {
+		unit = " " + unit
+	}
+	if s.Count == 1 {
+		return fmt.Sprintf("%.2f%s", avg, unit)
+	}
+	p95, _ := c.Units.Convert(s.P95, r.Unit)
+	return fmt.Sprintf("avg %.1f / p95 %.1f%s", avg, p95, unit)
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package compare
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+func TestDirectionOf(t *testing.T) {
+	for _, tc := range []struct {
+		metric, unit string
+		want         Direction
+	}{
+		{"cpu_temp", "°C", LowerIsBetter},
+		{"random_read_latency_p99_us", "", LowerIsBetter},
+		{"allocation_time_ms", "", LowerIsBetter},
+		{"bit_errors", "", LowerIsBetter},
+		{"operations_per_second", "", HigherIsBetter},
+		{"cpu_clock", "MHz", HigherIsBetter},
+		{"overall", "points", HigherIsBetter},
+		{"disk_sda_read", "MB/s", HigherIsBetter},
+		{"cpu_power", "W", Neutral},
+		{"cpu_usage", "%", Neutral},
+	} {
+		if got := DirectionOf(tc.metric, tc.unit); got != tc.want {
+			t.Errorf("DirectionOf(%q, %q) = %d; want %d", tc.metric, tc.unit, got, tc.want)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	runs := []*db.Run{{ID: 1, Plugin: "cpu"}, {ID: 2, Plugin: "cpu"}, {ID: 3, Plugin: "cpu"}}
+	results := func(values map[string][]float64, units map[string]string) []*db.Result {
+		var out []*db.Result
+		for m, vs := range values {
+			for _, v := range vs {
+				out = append(out, &db.Result{Metric: m, Value: v, Unit: units[m]})
+			}
+		}
+		return out
+	}
+	units := map[string]string{"cpu_temp": "°C", "operations_per_second": "ops/s", "cpu_power": "W"}
+	c := New(runs, [][]*db.Result{
+		results(map[string][]float64{"cpu_temp": {60, 60, 60, 80}, "operations_per_second": {1000}, "cpu_power": {100}, "bit_errors": {0}}, units),
+		results(map[string][]float64{"cpu_temp": {66, 66, 66, 86}, "operations_per_second": {1030}, "cpu_power": {150}, "bit_errors": {2}}, units),
+		results(map[string][]float64{"cpu_temp": {55}, "operations_per_second": {900}}, units),
+	}, DefaultThreshold)
+
+	rows := make(map[string]Row)
+	for _, r := range c.Rows {
+		rows[r.Metric] = r
+	}
+	temp := rows["cpu_temp"]
+	if s := temp.Summaries[0]; s.Count != 4 || s.Avg != 65 || s.P95 != 80 || s.Min != 60 {
+		t.Errorf("expected the samples summarized, got %+v", s)
+	}
+	for _, tc := range []struct {
+		metric string
+		run    int
+		want   Verdict
+	}{
+		{"cpu_temp", 1, Worse},
+		{"cpu_temp", 2, Better},
+		{"operations_per_second", 1, Within},
+		{"operations_per_second", 2, Worse},
+		{"cpu_power", 1, Within},
+		{"bit_errors", 1, Worse},
+	} {
+		if got := rows[tc.metric].Changes[tc.run]; got.Verdict != tc.want {
+			t.Errorf("expected %s of run %d to be %d, got %+v", tc.metric, tc.run+1, tc.want, got)
+		}
+	}
+	if rows["cpu_power"].Summaries[2] != nil {
+		t.Error("expected no summary for a metric the run did not record")
+	}
+
+	var regressed []string
+	for _, r := range c.Regressions() {
+		regressed = append(regressed, r.Metric)
+	}
+	if got := strings.Join(regressed, ","); got != "bit_errors,cpu_temp,operations_per_second" {
+		t.Errorf("expected three regressions, got %s", got)
+	}
+
+	var buf bytes.Buffer
+	if err := c.WriteHTML(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `avg 71.0 / p95 86.0 °C <span class="change worse">`) {
+		t.Errorf("expected the report to mark the warmer run, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,57 @@
+package compare
+
+import (
+	"html/template"
+	"io"
+)
+
+var reportTemplate = template.Must(template.New("compare").Funcs(template.FuncMap{
+	"verdictClass": func(v Verdict) string {
+		switch v {
+		case Better:
+			return "change better"
+		case Worse:
+			return "change worse"
+		}
+		return "change"
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>F.I.R.E. Run Comparison</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 6px 10px; text-align: left; }
+th { background: #f4f4f4; }
+tr.regressed td:first-child { border-left: 4px solid #cf222e; }
+.change { font-size: 0.9em; }
+.better { color: #1a7f37; font-weight: bold; }
+.worse { color: #cf222e; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Run Comparison</h1>
+<p>Generated {{.Generated.Format "2006-01-02 15:04:05"}}. Changes are from the average of run #{{(index .Runs 0).ID}}; a metric that got worse by more than {{printf "%.1f" .Threshold}}% is a regression.</p>
+<table>
+<tr><th>Run</th><th>Plugin</th><th>Started</th><th>Machine</th><th>Result</th></tr>
+{{range $i, $run := .Runs}}<tr><td>#{{$run.ID}}{{if eq $i 0}} (baseline){{end}}</td><td>{{$run.Plugin}}</td><td>{{$run.StartTime.Format "2006-01-02 15:04"}}</td><td>{{$run.Machine}}</td><td>{{if $run.Success}}passed{{else if $run.EndTime}}failed{{else}}running{{end}}</td></tr>
+{{end}}</table>
+{{with .Regressions}}<h2>Regressions</h2>
+<ul>
+{{range .}}<li>{{.Metric}}</li>
+{{end}}</ul>
+{{end}}<h2>Metrics</h2>
+<table>
+<tr><th>Metric</th>{{range .Runs}}<th>#{{.ID}}</th>{{end}}</tr>
+{{range $row := .Rows}}<tr{{if $row.Regressed}} class="regressed"{{end}}><td>{{$row.Metric}}</td>{{range $i, $s := $row.Summaries}}<td>{{$.Value $row $i}}{{if and $i $s (index $row.Summaries 0)}} <span class="{{verdictClass (index $row.Changes $i).Verdict}}">{{index $row.Changes $i}}</span>{{end}}</td>{{end}}</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// WriteHTML writes the comparison as a standalone HTML page
+func (c *Comparison) WriteHTML(w io.Writer) error {
+	return reportTemplate.Execute(w, c)
+}
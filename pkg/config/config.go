@@ -0,0 +1,432 @@
+// Package config loads the settings shared by bench and the GUI.
+//
+// Settings come in layers, each overriding the ones before it:
+//
+//  1. Built-in defaults
+//  2. ~/.fire/settings.json, the settings file of earlier releases (FIRE_SETTINGS)
+//  3. The config file, config.yaml in the fire directory of the user's config
+//     directory, e.g. ~/.config/fire/config.yaml (FIRE_CONFIG)
+//  4. The environment variables listed in Env
+//
+// Command-line flags override all of them; the commands apply those. Keys
+// are written with dots, e.g. "database.path" or "plugins.cpu.threads".
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/hooks"
+	"github.com/mscrnt/project_fire/pkg/mail"
+	"github.com/mscrnt/project_fire/pkg/notify"
+	"github.com/mscrnt/project_fire/pkg/safety"
+	"github.com/mscrnt/project_fire/pkg/units"
+	"gopkg.in/yaml.v3"
+)
+
+// Settings are the settings of bench and the GUI
+type Settings struct {
+	Database  db.Config         `json:"database"`
+	Sync      Sync              `json:"sync"`
+	Hooks     hooks.Config      `json:"hooks"`
+	Power     Power             `json:"power"`
+	Safety    safety.Limits     `json:"safety"`   // Readings at which every test is stopped
+	SMTP      mail.Config       `json:"smtp"`     // Server run reports are mailed through
+	Notify    *notify.Notify    `json:"notify"`   // Who hears about every run
+	Sink      Sink              `json:"sink"`     // Time-series databases samples are streamed to
+	Language  string            `json:"language"` // e.g. "de"; defaults to LANG
+	Telemetry Telemetry         `json:"telemetry"`
+	Sensors   Sensors           `json:"sensors"`
+	GUI       GUI               `json:"gui"`
+	Units     units.Prefs       `json:"units"`   // Units readings are shown in
+	Plugins   map[string]Plugin `json:"plugins"` // Default parameters by plugin name
+}
+
+// Sync configures pushing local runs to a central server
+type Sync struct {
+	Target   db.Config `json:"target"`
+	Interval string    `json:"interval"` // e.g. "5m"
+}
+
+// Sink configures streaming samples and results to time-series databases
+type Sink struct {
+	URLs     []string `json:"urls"`     // e.g. "influxdb://metrics:8086/fire"
+	Interval string   `json:"interval"` // Time between samples, e.g. "5s"
+}
+
+// Power configures power event logging
+type Power struct {
+	UPS            string `json:"ups"`                  // e.g. "nut:ups@localhost" or "apcupsd"
+	PauseOnBattery bool   `json:"pause_on_ups_battery"` // Stop tests while a UPS is on battery
+}
+
+// Telemetry configures anonymous hardware compatibility reporting
+type Telemetry struct {
+	Enabled  *bool  `json:"enabled"`
+	Endpoint string `json:"endpoint"` // Empty for the default endpoint
+}
+
+// Sensors configures the sensor backends
+type Sensors struct {
+	BMC    *bool  `json:"bmc"`     // Read the BMC through ipmitool
+	LHMURL string `json:"lhm_url"` // LibreHardwareMonitor's web server on Windows
+}
+
+// GUI configures the graphical interface
+type GUI struct {
+	Theme          string `json:"theme"`           // One of Themes
+	UpdateInterval string `json:"update_interval"` // Time between dashboard updates, e.g. "1s"
+	StartPage      string `json:"start_page"`      // One of StartPages
+	Splash         *bool  `json:"splash"`          // Show the loading screen while hardware is detected
+	Tray           bool   `json:"tray"`            // Keep running in the system tray when the window is closed
+}
+
+// Plugin holds the default parameters of a plugin, used unless a run sets
+// them
+type Plugin struct {
+	Duration string                 `json:"duration"` // e.g. "10m"
+	Threads  int                    `json:"threads"`
+	Config   map[string]interface{} `json:"config"` // Plugin-specific, as with --config
+}
+
+// GUI themes
+const (
+	ThemeDark   = "dark"   // The F.I.R.E. dark theme
+	ThemeLight  = "light"  // Fyne's light theme
+	ThemeSystem = "system" // Light or dark, following the system
+)
+
+// Themes are the themes the GUI offers
+var Themes = []string{ThemeDark, ThemeLight, ThemeSystem}
+
+// StartPages are the pages the GUI can open on, in sidebar order after
+// StartLast
+var StartPages = []string{StartLast, "system-info", "stability-test", "schedules", "benchmarks", "monitoring", "history", "settings"}
+
+// StartLast opens the GUI on the page the operator's profile was left on
+const StartLast = "last"
+
+// DefaultUpdateInterval and MinUpdateInterval bound how often the GUI
+// reads the sensors
+const (
+	DefaultUpdateInterval = time.Second
+	MinUpdateInterval     = 250 * time.Millisecond
+)
+
+// Interval returns the time between dashboard updates
+func (g GUI) Interval() time.Duration {
+	d, err := time.ParseDuration(g.UpdateInterval)
+	if err != nil || d < MinUpdateInterval {
+		return DefaultUpdateInterval
+	}
+	return d
+}
+
+// Env maps environment variables to the keys they set
+var Env = []struct{ Name, Key string }{
+	{"FIRE_DB_PATH", "database.path"},
+	{"FIRE_DB_DRIVER", "database.driver"},
+	{"FIRE_DB_DSN", "database.dsn"},
+	{"FIRE_SMTP_PASSWORD", "smtp.password"},
+	{"FIRE_UPS", "power.ups"},
+	{"FIRE_LANGUAGE", "language"},
+	{"FIRE_TELEMETRY_ENDPOINT", "telemetry.endpoint"},
+	{"FIRE_LHM_URL", "sensors.lhm_url"},
+	{"FIRE_THEME", "gui.theme"},
+}
+
+// SourceDefault is the source of the built-in defaults
+const SourceDefault = "default"
+
+// Path returns the path of the config file
+func Path() string {
+	if path := os.Getenv("FIRE_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "config.yaml"
+	}
+	return filepath.Join(dir, "fire", "config.yaml")
+}
+
+// LegacyPath returns the path of the settings file of earlier releases
+func LegacyPath() string {
+	if path := os.Getenv("FIRE_SETTINGS"); path != "" {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "settings.json"
+	}
+	return filepath.Join(homeDir, ".fire", "settings.json")
+}
+
+// DefaultDBPath returns where the SQLite database is kept unless configured
+func DefaultDBPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "fire.db"
+	}
+	return filepath.Join(homeDir, ".fire", "fire.db")
+}
+
+// Load returns the settings of every layer merged. Keys it does not know
+// are ignored; Validate reports them.
+func Load() (Settings, error) {
+	var settings Settings
+	layers, err := readLayers(Path())
+	if err != nil {
+		return settings, err
+	}
+	merged, _ := merge(layers)
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return settings, err
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return settings, fmt.Errorf("invalid settings: %w", err)
+	}
+	return settings, nil
+}
+
+// Value is a setting and the layer it comes from
+type Value struct {
+	Key    string
+	Value  interface{}
+	Source string // SourceDefault, a file path or an environment variable
+}
+
+// Values returns every setting that is set, by key
+func Values() ([]Value, error) {
+	layers, err := readLayers(Path())
+	if err != nil {
+		return nil, err
+	}
+	merged, sources := merge(layers)
+	var values []Value
+	flatten(merged, "", func(key string, v interface{}) {
+		values = append(values, Value{Key: key, Value: v, Source: sources[key]})
+	})
+	sort.Slice(values, func(i, j int) bool { return values[i].Key < values[j].Key })
+	return values, nil
+}
+
+// Get returns the value of a key and the layer it comes from. A key naming
+// a section returns the whole section. ok is false when it is not set.
+func Get(key string) (value Value, ok bool, err error) {
+	if _, err := keyType(key); err != nil {
+		return value, false, err
+	}
+	layers, err := readLayers(Path())
+	if err != nil {
+		return value, false, err
+	}
+	merged, sources := merge(layers)
+	var v interface{} = merged
+	for _, part := range strings.Split(key, ".") {
+		m, isMap := v.(map[string]interface{})
+		if !isMap {
+			return value, false, nil
+		}
+		if v, ok = m[part]; !ok {
+			return value, false, nil
+		}
+	}
+	source := sources[key]
+	if _, isMap := v.(map[string]interface{}); isMap {
+		source = ""
+	}
+	return Value{Key: key, Value: v, Source: source}, true, nil
+}
+
+// layer is the settings of one source, decoded as JSON would be
+type layer struct {
+	name   string
+	values map[string]interface{}
+}
+
+// readLayers reads the defaults, the legacy file, the config file at path
+// and the environment
+func readLayers(path string) ([]layer, error) {
+	file, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return buildLayers(path, file)
+}
+
+// buildLayers reads the layers around the values of the config file
+func buildLayers(path string, file map[string]interface{}) ([]layer, error) {
+	defaults := map[string]interface{}{
+		"database": map[string]interface{}{
+			"driver":         string(db.DriverSQLite),
+			"path":           DefaultDBPath(),
+			"migrate_backup": true,
+			"retention": map[string]interface{}{
+				"samples":    "90d",
+				"resolution": "1m",
+				"interval":   "24h",
+			},
+		},
+		"telemetry": map[string]interface{}{"enabled": true},
+		"sensors":   map[string]interface{}{"bmc": true},
+		"gui": map[string]interface{}{
+			"theme":           ThemeDark,
+			"update_interval": DefaultUpdateInterval.String(),
+			"start_page":      StartLast,
+			"splash":          true,
+			"tray":            false,
+		},
+		"units": map[string]interface{}{
+			"temperature": units.Celsius,
+			"data_rate":   units.MBps,
+			"frequency":   units.Auto,
+		},
+	}
+	layers := []layer{{name: SourceDefault, values: defaults}}
+
+	legacy, err := readFile(LegacyPath())
+	if err != nil {
+		return nil, err
+	}
+	if legacy != nil {
+		layers = append(layers, layer{name: LegacyPath(), values: legacy})
+	}
+	if file != nil {
+		layers = append(layers, layer{name: path, values: file})
+	}
+
+	for _, e := range Env {
+		v := os.Getenv(e.Name)
+		if v == "" {
+			continue
+		}
+		env := make(map[string]interface{})
+		setPath(env, strings.Split(e.Key, "."), v)
+		layers = append(layers, layer{name: e.Name, values: env})
+	}
+	// Kept from before the telemetry settings
+	if os.Getenv("FIRE_TELEMETRY_DISABLED") == "true" {
+		layers = append(layers, layer{name: "FIRE_TELEMETRY_DISABLED", values: map[string]interface{}{
+			"telemetry": map[string]interface{}{"enabled": false},
+		}})
+	}
+	return layers, nil
+}
+
+// readFile decodes a JSON or YAML settings file. A missing file yields nil.
+func readFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- the user's settings file
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read settings: %w", err)
+	}
+	var values map[string]interface{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &values)
+	} else {
+		var raw interface{}
+		if err = yaml.Unmarshal(data, &raw); err == nil {
+			var ok bool
+			if values, ok = normalize(raw).(map[string]interface{}); !ok && raw != nil {
+				err = errors.New("expected a mapping of settings")
+			}
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// normalize converts decoded YAML to the types decoded JSON has
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = normalize(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[fmt.Sprint(k)] = normalize(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalize(item)
+		}
+		return v
+	case int:
+		return float64(v)
+	default:
+		return v
+	}
+}
+
+// merge merges the layers in order and returns the layer every key came from
+func merge(layers []layer) (map[string]interface{}, map[string]string) {
+	merged := make(map[string]interface{})
+	sources := make(map[string]string)
+	for _, l := range layers {
+		mergeInto(merged, l.values)
+		flatten(l.values, "", func(key string, _ interface{}) {
+			sources[key] = l.name
+		})
+	}
+	return merged, sources
+}
+
+func mergeInto(dst, src map[string]interface{}) {
+	for k, v := range src {
+		if m, ok := v.(map[string]interface{}); ok {
+			if existing, ok := dst[k].(map[string]interface{}); ok {
+				mergeInto(existing, m)
+				continue
+			}
+			copied := make(map[string]interface{}, len(m))
+			mergeInto(copied, m)
+			v = copied
+		}
+		dst[k] = v
+	}
+}
+
+// flatten calls fn for every value below m that is not a mapping
+func flatten(m map[string]interface{}, prefix string, fn func(key string, v interface{})) {
+	for k, v := range m {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if sub, ok := v.(map[string]interface{}); ok && len(sub) > 0 {
+			flatten(sub, key, fn)
+			continue
+		}
+		fn(key, v)
+	}
+}
+
+// setPath sets a value in nested maps, creating them as needed
+func setPath(m map[string]interface{}, path []string, v interface{}) {
+	for _, part := range path[:len(path)-1] {
+		sub, ok := m[part].(map[string]interface{})
+		if !ok {
+			sub = make(map[string]interface{})
+			m[part] = sub
+		}
+		m = sub
+	}
+	m[path[len(path)-1]] = v
+}
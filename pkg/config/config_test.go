@@ -0,0 +1,200 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/plugin"
+)
+
+// setup points the settings at files in a temporary directory
+func setup(t *testing.T, legacy, file string) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	for _, e := range Env {
+		t.Setenv(e.Name, "")
+	}
+	t.Setenv("FIRE_TELEMETRY_DISABLED", "")
+	t.Setenv("FIRE_SETTINGS", filepath.Join(dir, "settings.json"))
+	t.Setenv("FIRE_CONFIG", filepath.Join(dir, "fire", "config.yaml"))
+	if legacy != "" {
+		if err := os.WriteFile(LegacyPath(), []byte(legacy), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if file != "" {
+		if err := os.MkdirAll(filepath.Dir(Path()), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(Path(), []byte(file), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadLayers(t *testing.T) {
+	dir := setup(t, `{"language": "de", "database": {"path": "/legacy.db"}, "sink": {"interval": "10s"}}`, `
+language: fr
+gui:
+  theme: light
+safety:
+  cpu_temp: 95
+plugins:
+  cpu:
+    threads: 8
+`)
+	t.Setenv("FIRE_DB_PATH", "/env.db")
+
+	s, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Database.Path != "/env.db" || s.Database.Driver != "sqlite" {
+		t.Errorf("expected the environment over the files, got %+v", s.Database)
+	}
+	if s.Language != "fr" || s.Sink.Interval != "10s" || s.GUI.Theme != ThemeLight || s.Plugins["cpu"].Threads != 8 {
+		t.Errorf("expected the config file over the legacy file, got %+v", s)
+	}
+	if s.Safety.CPUTempC != 95 || s.Safety.PowerW != 0 {
+		t.Errorf("expected the CPU safety limit, got %+v", s.Safety)
+	}
+	if s.Telemetry.Enabled == nil || !*s.Telemetry.Enabled {
+		t.Error("expected telemetry on by default")
+	}
+	if s.GUI.Interval() != DefaultUpdateInterval || s.GUI.StartPage != StartLast {
+		t.Errorf("expected the default GUI settings, got %+v", s.GUI)
+	}
+
+	v, ok, err := Get("database.path")
+	if err != nil || !ok || v.Source != "FIRE_DB_PATH" {
+		t.Errorf("expected database.path from FIRE_DB_PATH, got %+v %v", v, err)
+	}
+	v, _, _ = Get("sink.interval")
+	if v.Source != filepath.Join(dir, "settings.json") {
+		t.Errorf("expected sink.interval from the legacy file, got %q", v.Source)
+	}
+	if _, ok, _ := Get("smtp.host"); ok {
+		t.Error("expected smtp.host not to be set")
+	}
+	if _, _, err := Get("gui.colour"); err == nil {
+		t.Error("expected an unknown key to be rejected")
+	}
+}
+
+func TestSet(t *testing.T) {
+	setup(t, "", "# Bench settings\ngui:\n  theme: dark # dark or light\n")
+
+	for key, value := range map[string]string{
+		"gui.theme":                 "light",
+		"telemetry.enabled":         "false",
+		"plugins.cpu.threads":       "4",
+		"plugins.cpu.duration":      "10m",
+		"plugins.cpu.config.method": "native",
+		"sink.urls":                 "influxdb://metrics:8086/fire, mqtt://broker/fire",
+	} {
+		if err := Set(key, value); err != nil {
+			t.Fatalf("set %s: %v", key, err)
+		}
+	}
+	data, err := os.ReadFile(Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "# Bench settings") || !strings.Contains(string(data), "theme: light # dark or light") {
+		t.Errorf("expected the comments kept, got\n%s", data)
+	}
+
+	s, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.GUI.Theme != ThemeLight || *s.Telemetry.Enabled || len(s.Sink.URLs) != 2 || s.Plugins["cpu"].Threads != 4 {
+		t.Errorf("unexpected settings %+v", s)
+	}
+
+	for key, value := range map[string]string{
+		"gui.theme":           "purple",
+		"telemetry.enabled":   "maybe",
+		"plugins.cpu.threads": "many",
+		"gui.colour":          "red",
+		"language.code":       "de",
+	} {
+		if err := Set(key, value); err == nil {
+			t.Errorf("expected %s=%s to be rejected", key, value)
+		}
+	}
+
+	if removed, err := Unset("plugins.cpu.config.method"); err != nil || !removed {
+		t.Fatalf("expected the key removed, got %v %v", removed, err)
+	}
+	if removed, _ := Unset("plugins.cpu.config.method"); removed {
+		t.Error("expected nothing to remove the second time")
+	}
+	if _, ok, _ := Get("plugins.cpu.config"); ok {
+		t.Error("expected the empty section removed")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	setup(t, `{"bogus": 1}`, `
+database:
+  driver: postgres
+sink:
+  interval: soon
+gui:
+  theme: purple
+  update_interval: 100ms
+units:
+  temperature: K
+safety:
+  power: -1
+plugins:
+  cpu:
+    threads: "eight"
+`)
+	problems, err := Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"bogus", "database.dsn", "gui.theme", "gui.update_interval", "plugins.cpu.threads", "safety", "sink.interval", "units.temperature"}
+	var got []string
+	for _, p := range problems {
+		got = append(got, p.Key)
+		if p.Source == "" {
+			t.Errorf("expected a source for %s", p.Key)
+		}
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected problems with %v, got %v", want, problems)
+	}
+}
+
+// stub is a plugin with fixed defaults
+type stub struct{}
+
+func (stub) Name() string                       { return "stub" }
+func (stub) Description() string                { return "" }
+func (stub) ValidateParams(plugin.Params) error { return nil }
+func (stub) Run(context.Context, plugin.Params) (plugin.Result, error) {
+	return plugin.Result{}, nil
+}
+func (stub) DefaultParams() plugin.Params {
+	return plugin.Params{Duration: time.Minute, Config: map[string]interface{}{"size_mb": 512, "mode": "a"}}
+}
+
+func TestPluginParams(t *testing.T) {
+	s := Settings{Plugins: map[string]Plugin{"stub": {Duration: "5m", Threads: 2, Config: map[string]interface{}{"size_mb": float64(2048)}}}}
+	params, err := s.PluginParams(stub{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.Duration != 5*time.Minute || params.Threads != 2 || params.Config["size_mb"] != 2048 || params.Config["mode"] != "a" {
+		t.Errorf("unexpected params %+v", params)
+	}
+}
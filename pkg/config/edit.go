@@ -0,0 +1,254 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Set sets a key in the config file, converting the value to the key's
+// type: "true" for a switch, "a,b" for a list and YAML for a section. The
+// file keeps its comments and order. A value the key does not accept is
+// not written.
+func Set(key, value string) error {
+	t, err := keyType(key)
+	if err != nil {
+		return err
+	}
+	v, err := parseValue(t, value)
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	var node yaml.Node
+	if err := node.Encode(v); err != nil {
+		return err
+	}
+
+	path := Path()
+	doc, err := readDocument(path)
+	if err != nil {
+		return err
+	}
+	setNode(doc.Content[0], strings.Split(key, "."), &node)
+	return writeDocument(path, doc, key)
+}
+
+// Unset removes a key from the config file, so the layers below it apply.
+// removed is false when the file does not set it.
+func Unset(key string) (removed bool, err error) {
+	if _, err := keyType(key); err != nil {
+		return false, err
+	}
+	path := Path()
+	doc, err := readDocument(path)
+	if err != nil {
+		return false, err
+	}
+	if !removeNode(doc.Content[0], strings.Split(key, ".")) {
+		return false, nil
+	}
+	return true, writeDocument(path, doc, "")
+}
+
+// readDocument reads the config file as a YAML document holding a mapping.
+// A missing or empty file yields an empty one.
+func readDocument(path string) (*yaml.Node, error) {
+	empty := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	data, err := os.ReadFile(path) // #nosec G304 -- the user's config file
+	if errors.Is(err, os.ErrNotExist) {
+		return empty, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if doc.Kind == 0 || len(doc.Content) == 0 {
+		return empty, nil
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("failed to parse %s: expected a mapping of settings", path)
+	}
+	return &doc, nil
+}
+
+// writeDocument checks the settings the document would give and writes it.
+// Problems with key, or below it, stop the write; others were there before.
+func writeDocument(path string, doc *yaml.Node, key string) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_ = enc.Close()
+
+	if key != "" {
+		var raw interface{}
+		if err := yaml.Unmarshal(buf.Bytes(), &raw); err != nil {
+			return err
+		}
+		file, _ := normalize(raw).(map[string]interface{})
+		layers, err := buildLayers(path, file)
+		if err != nil {
+			return err
+		}
+		for _, p := range validate(layers) {
+			if p.Key == key || strings.HasPrefix(p.Key, key+".") || strings.HasPrefix(key, p.Key+".") {
+				return p
+			}
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
+// setNode sets the value at path below a mapping, adding mappings on the
+// way
+func setNode(m *yaml.Node, path []string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			// Keep a comment written next to the old value
+			value.LineComment = m.Content[i+1].LineComment
+			m.Content[i+1] = value
+			return
+		}
+		if m.Content[i+1].Kind != yaml.MappingNode {
+			m.Content[i+1] = &yaml.Node{Kind: yaml.MappingNode}
+		}
+		setNode(m.Content[i+1], path[1:], value)
+		return
+	}
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Value: path[0]}
+	if len(path) == 1 {
+		m.Content = append(m.Content, keyNode, value)
+		return
+	}
+	sub := &yaml.Node{Kind: yaml.MappingNode}
+	m.Content = append(m.Content, keyNode, sub)
+	setNode(sub, path[1:], value)
+}
+
+// removeNode removes the value at path below a mapping, and the mappings
+// left empty
+func removeNode(m *yaml.Node, path []string) bool {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value != path[0] {
+			continue
+		}
+		if len(path) > 1 {
+			if m.Content[i+1].Kind != yaml.MappingNode || !removeNode(m.Content[i+1], path[1:]) {
+				return false
+			}
+			if len(m.Content[i+1].Content) > 0 {
+				return true
+			}
+		}
+		m.Content = append(m.Content[:i], m.Content[i+2:]...)
+		return true
+	}
+	return false
+}
+
+// keyType returns the type of the setting a key names
+func keyType(key string) (reflect.Type, error) {
+	t := reflect.TypeOf(Settings{})
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if part == "" {
+			return nil, fmt.Errorf("invalid key %q", key)
+		}
+		switch t.Kind() {
+		case reflect.Struct:
+			field, ok := fieldByTag(t, part)
+			if !ok {
+				return nil, fmt.Errorf("unknown setting %q", strings.Join(parts[:i+1], "."))
+			}
+			t = field.Type
+		case reflect.Map:
+			t = t.Elem()
+		case reflect.Interface:
+			return t, nil
+		default:
+			return nil, fmt.Errorf("unknown setting %q: %s is not a section", key, strings.Join(parts[:i], "."))
+		}
+	}
+	return t, nil
+}
+
+// fieldByTag finds the struct field with a JSON name
+func fieldByTag(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("json"), ",")[0]
+		if tag == name && tag != "-" {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// parseValue converts a value given on the command line to a type
+func parseValue(t reflect.Type, value string) (interface{}, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return value, nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("expected true or false, got %q", value)
+		}
+		return b, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("expected a whole number, got %q", value)
+		}
+		return n, nil
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected a number, got %q", value)
+		}
+		return f, nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.String && !strings.HasPrefix(strings.TrimSpace(value), "[") {
+			items := []string{}
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			return items, nil
+		}
+	}
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(value), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,229 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/mscrnt/project_fire/pkg/plugin"
+	"github.com/mscrnt/project_fire/pkg/units"
+)
+
+// Problem is a setting that is not valid
+type Problem struct {
+	Key    string
+	Source string // The layer that set it
+	Err    error
+}
+
+func (p Problem) Error() string {
+	if p.Source == "" {
+		return fmt.Sprintf("%s: %v", p.Key, p.Err)
+	}
+	return fmt.Sprintf("%s: %v (%s)", p.Key, p.Err, p.Source)
+}
+
+// Validate checks the settings of every layer: keys that are not settings,
+// values of the wrong type and values the setting does not accept
+func Validate() ([]Problem, error) {
+	layers, err := readLayers(Path())
+	if err != nil {
+		return nil, err
+	}
+	return validate(layers), nil
+}
+
+func validate(layers []layer) []Problem {
+	merged, sources := merge(layers)
+	var problems []Problem
+	add := func(key string, err error) {
+		problems = append(problems, Problem{Key: key, Source: sourceOf(sources, key), Err: err})
+	}
+
+	flatten(merged, "", func(key string, _ interface{}) {
+		if _, err := keyType(key); err != nil {
+			add(key, errors.New("unknown setting"))
+		}
+	})
+
+	var s Settings
+	data, err := json.Marshal(merged)
+	if err == nil {
+		err = json.Unmarshal(data, &s)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		add(typeErr.Field, fmt.Errorf("expected %s, got %s", typeErr.Type, typeErr.Value))
+	} else if err != nil {
+		add("", err)
+	}
+
+	if driver, err := db.ParseDriver(string(s.Database.Driver)); err != nil {
+		add("database.driver", err)
+	} else if driver == db.DriverPostgres && s.Database.DSN == "" {
+		add("database.dsn", errors.New("a PostgreSQL database needs a DSN"))
+	}
+	if s.Sync.Target.DSN != "" || s.Sync.Target.Path != "" {
+		if _, err := db.ParseDriver(string(s.Sync.Target.Driver)); err != nil {
+			add("sync.target.driver", err)
+		}
+	}
+	checkDuration(add, "sync.interval", s.Sync.Interval)
+	if err := s.Hooks.Validate(); err != nil {
+		add("hooks", err)
+	}
+	if s.Safety.CPUTempC < 0 || s.Safety.GPUTempC < 0 || s.Safety.PowerW < 0 {
+		add("safety", errors.New("limits must not be negative"))
+	}
+	if s.SMTP.Host != "" {
+		if err := s.SMTP.Validate(); err != nil {
+			add("smtp", err)
+		}
+	}
+	if s.Notify != nil {
+		if err := s.Notify.Validate(); err != nil {
+			add("notify", err)
+		}
+	}
+	for _, u := range s.Sink.URLs {
+		if parsed, err := url.Parse(u); err != nil || parsed.Scheme == "" {
+			add("sink.urls", fmt.Errorf("invalid sink URL %q", u))
+		}
+	}
+	checkDuration(add, "sink.interval", s.Sink.Interval)
+	if s.Language != "" && !contains(i18n.Languages(), i18n.Normalize(s.Language)) {
+		add("language", fmt.Errorf("no translation for %q (use one of %s)", s.Language, strings.Join(i18n.Languages(), ", ")))
+	}
+	checkURL(add, "telemetry.endpoint", s.Telemetry.Endpoint)
+	checkURL(add, "sensors.lhm_url", s.Sensors.LHMURL)
+	if s.GUI.Theme != "" && !contains(Themes, s.GUI.Theme) {
+		add("gui.theme", fmt.Errorf("unknown theme %q (use one of %s)", s.GUI.Theme, strings.Join(Themes, ", ")))
+	}
+	checkDuration(add, "gui.update_interval", s.GUI.UpdateInterval)
+	if d, err := time.ParseDuration(s.GUI.UpdateInterval); err == nil && d > 0 && d < MinUpdateInterval {
+		add("gui.update_interval", fmt.Errorf("must be at least %s", MinUpdateInterval))
+	}
+	if s.GUI.StartPage != "" && !contains(StartPages, s.GUI.StartPage) {
+		add("gui.start_page", fmt.Errorf("unknown page %q (use one of %s)", s.GUI.StartPage, strings.Join(StartPages, ", ")))
+	}
+	for _, u := range []struct {
+		key, value string
+		choices    []string
+	}{
+		{"units.temperature", s.Units.Temperature, units.Temperatures},
+		{"units.data_rate", s.Units.DataRate, units.DataRates},
+		{"units.frequency", s.Units.Frequency, units.Frequencies},
+	} {
+		if u.value != "" && !contains(u.choices, u.value) {
+			add(u.key, fmt.Errorf("unknown unit %q (use one of %s)", u.value, strings.Join(u.choices, ", ")))
+		}
+	}
+
+	names := make([]string, 0, len(s.Plugins))
+	for name := range s.Plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := s.Plugins[name]
+		key := "plugins." + name
+		checkDuration(add, key+".duration", p.Duration)
+		if p.Threads < 0 {
+			add(key+".threads", errors.New("must not be negative"))
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Key < problems[j].Key })
+	return problems
+}
+
+// PluginParams returns the default parameters of a plugin with the defaults
+// of the settings applied
+func (s Settings) PluginParams(p plugin.TestPlugin) (plugin.Params, error) {
+	params := p.DefaultParams()
+	defaults, ok := s.Plugins[p.Name()]
+	if !ok {
+		return params, nil
+	}
+	if defaults.Duration != "" {
+		d, err := time.ParseDuration(defaults.Duration)
+		if err != nil {
+			return params, fmt.Errorf("invalid plugins.%s.duration: %w", p.Name(), err)
+		}
+		params.Duration = d
+	}
+	if defaults.Threads > 0 {
+		params.Threads = defaults.Threads
+	}
+	if len(defaults.Config) > 0 && params.Config == nil {
+		params.Config = make(map[string]interface{})
+	}
+	for k, v := range defaults.Config {
+		// Whole numbers are ints, as with --config
+		if f, ok := v.(float64); ok && f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+			v = int(f)
+		}
+		params.Config[k] = v
+	}
+	return params, nil
+}
+
+// sourceOf returns the layer a key, the first key below it or, for a key
+// that is not set, its section comes from
+func sourceOf(sources map[string]string, key string) string {
+	for prefix := key; prefix != ""; {
+		if source, ok := sources[prefix]; ok {
+			return source
+		}
+		var below []string
+		for k := range sources {
+			if strings.HasPrefix(k, prefix+".") {
+				below = append(below, k)
+			}
+		}
+		if len(below) > 0 {
+			sort.Strings(below)
+			return sources[below[0]]
+		}
+		i := strings.LastIndex(prefix, ".")
+		if i < 0 {
+			break
+		}
+		prefix = prefix[:i]
+	}
+	return ""
+}
+
+func checkDuration(add func(string, error), key, value string) {
+	if value == "" {
+		return
+	}
+	if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+		add(key, fmt.Errorf("invalid duration %q (e.g. 30s, 5m or 1h)", value))
+	}
+}
+
+func checkURL(add func(string, error), key, value string) {
+	if value == "" {
+		return
+	}
+	if u, err := url.Parse(value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		add(key, fmt.Errorf("invalid URL %q (use http:// or https://)", value))
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,373 @@
+package cooling
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+// PowerBin is the width in watts of the power bands readings are matched in
+const PowerBin = 5.0
+
+// gpuTemp matches the temperature readings of the GPUs, e.g. gpu0_temp
+var gpuTemp = regexp.MustCompile(`^(gpu\d+)_temp$`)
+
+// Comparison puts an after session next to its before session
+type Comparison struct {
+	Label     string
+	Generated time.Time
+	Before    *Session
+	After     *Session
+	Sensors   []SensorDelta
+	Fans      []MetricDelta
+	Load      []MetricDelta // The load's own results, e.g. its score
+	Notes     []string
+}
+
+// SensorDelta compares the steady-state temperature of one component
+type SensorDelta struct {
+	Name        string // CPU, GPU 0, ...
+	TempMetric  string
+	PowerMetric string
+
+	BeforeTemp, AfterTemp   float64 // Mean over the steady state
+	BeforePower, AfterPower float64 // Zero when power was not read
+
+	// Delta is the change in temperature, negative when the after session
+	// ran cooler. When Matched it is taken at equal power, around AtPower.
+	Delta   float64
+	AtPower float64
+	Matched bool
+}
+
+// MetricDelta compares the mean of one metric
+type MetricDelta struct {
+	Metric string
+	Unit   string
+	Before float64
+	After  float64
+}
+
+// Change returns the difference after minus before
+func (m MetricDelta) Change() float64 {
+	return m.After - m.Before
+}
+
+// Percent returns the change relative to before
+func (m MetricDelta) Percent() float64 {
+	if m.Before == 0 {
+		return 0
+	}
+	return (m.After - m.Before) / m.Before * 100
+}
+
+// steady holds a session's readings after its warmup, one map per sample
+type steady struct {
+	samples []map[string]float64
+	units   map[string]string
+}
+
+// readSteady loads the readings a session stored, dropping those taken in
+// the warmup. Readings stored by one sample share their time.
+func readSteady(database *db.DB, s *Session) (*steady, error) {
+	results, err := database.GetResults(s.Run.ID)
+	if err != nil {
+		return nil, err
+	}
+	bySample := make(map[int64]map[string]float64)
+	units := make(map[string]string)
+	for _, r := range results {
+		key := r.CreatedAt.Unix()
+		if bySample[key] == nil {
+			bySample[key] = make(map[string]float64)
+		}
+		bySample[key][r.Metric] = r.Value
+		units[r.Metric] = r.Unit
+	}
+	times := make([]int64, 0, len(bySample))
+	for t := range bySample {
+		times = append(times, t)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+
+	st := &steady{units: units}
+	for _, t := range times {
+		if time.Duration(t-times[0])*time.Second < s.Warmup {
+			continue
+		}
+		st.samples = append(st.samples, bySample[t])
+	}
+	if len(st.samples) == 0 {
+		return nil, fmt.Errorf("run %d has no readings after its %s warmup", s.Run.ID, s.Warmup)
+	}
+	return st, nil
+}
+
+// mean averages a metric over the samples that have it
+func (st *steady) mean(metric string) (float64, bool) {
+	sum, n := 0.0, 0
+	for _, s := range st.samples {
+		if v, ok := s[metric]; ok {
+			sum += v
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return sum / float64(n), true
+}
+
+// pairs returns the (power, temperature) of every sample with both
+func (st *steady) pairs(powerMetric, tempMetric string) [][2]float64 {
+	var out [][2]float64
+	for _, s := range st.samples {
+		p, okP := s[powerMetric]
+		t, okT := s[tempMetric]
+		if okP && okT {
+			out = append(out, [2]float64{p, t})
+		}
+	}
+	return out
+}
+
+// Compare compares two sessions recorded with the same load
+func Compare(database *db.DB, before, after *Session) (*Comparison, error) {
+	if before.Load != after.Load || before.Duration != after.Duration || before.Threads != after.Threads {
+		return nil, fmt.Errorf("sessions ran different loads: %s and %s", before.describeLoad(), after.describeLoad())
+	}
+	b, err := readSteady(database, before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := readSteady(database, after)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Comparison{Label: after.Label, Generated: time.Now(), Before: before, After: after}
+
+	// The CPU and every GPU read in both sessions
+	components := [][3]string{{"CPU", "cpu_temp", "cpu_power"}}
+	var gpus []string
+	for metric := range a.units {
+		if m := gpuTemp.FindStringSubmatch(metric); m != nil {
+			gpus = append(gpus, m[1])
+		}
+	}
+	sort.Strings(gpus)
+	for _, gpu := range gpus {
+		components = append(components, [3]string{"GPU " + strings.TrimPrefix(gpu, "gpu"), gpu + "_temp", gpu + "_power"})
+	}
+
+	ambientKnown := before.Ambient != 0 && after.Ambient != 0
+	for _, comp := range components {
+		d := SensorDelta{Name: comp[0], TempMetric: comp[1], PowerMetric: comp[2]}
+		var okB, okA bool
+		if d.BeforeTemp, okB = b.mean(d.TempMetric); !okB {
+			continue
+		}
+		if d.AfterTemp, okA = a.mean(d.TempMetric); !okA {
+			continue
+		}
+		d.BeforePower, _ = b.mean(d.PowerMetric)
+		d.AfterPower, _ = a.mean(d.PowerMetric)
+
+		d.Delta = d.AfterTemp - d.BeforeTemp
+		if delta, at, ok := matchedDelta(b.pairs(d.PowerMetric, d.TempMetric), a.pairs(d.PowerMetric, d.TempMetric)); ok {
+			d.Delta, d.AtPower, d.Matched = delta, at, true
+		} else if d.BeforePower > 0 && d.AfterPower > 0 {
+			c.Notes = append(c.Notes, fmt.Sprintf("%s power did not overlap (%.0f W before, %.0f W after): the temperatures are compared as measured", d.Name, d.BeforePower, d.AfterPower))
+		} else {
+			c.Notes = append(c.Notes, fmt.Sprintf("%s power was not read: the temperatures are compared as measured", d.Name))
+		}
+		if ambientKnown {
+			d.Delta -= after.Ambient - before.Ambient
+		}
+		c.Sensors = append(c.Sensors, d)
+	}
+	if len(c.Sensors) == 0 {
+		c.Notes = append(c.Notes, "No temperature was read in both sessions")
+	}
+	if ambientKnown {
+		c.Notes = append(c.Notes, fmt.Sprintf("Temperatures are corrected for the room: %.1f °C before, %.1f °C after", before.Ambient, after.Ambient))
+	} else {
+		c.Notes = append(c.Notes, "Room temperature was not given for both sessions (--ambient), so a warmer or cooler room shows up in the deltas")
+	}
+
+	for metric := range b.units {
+		if !strings.HasPrefix(metric, "fan_") {
+			continue
+		}
+		before, okB := b.mean(metric)
+		after, okA := a.mean(metric)
+		if okB && okA {
+			c.Fans = append(c.Fans, MetricDelta{Metric: metric, Unit: b.units[metric], Before: before, After: after})
+		}
+	}
+	sort.Slice(c.Fans, func(i, j int) bool { return c.Fans[i].Metric < c.Fans[j].Metric })
+
+	load, err := loadDeltas(database, before.LoadRunID, after.LoadRunID)
+	if err != nil {
+		return nil, err
+	}
+	c.Load = load
+	return c, nil
+}
+
+// matchedDelta compares temperatures at equal power: samples are grouped in
+// PowerBin watt bands, and the differences of the bands both sessions
+// reached are averaged, weighted by the samples they share. It returns the
+// delta, the mean power it was taken at and whether any band matched.
+func matchedDelta(before, after [][2]float64) (delta, at float64, ok bool) {
+	type band struct{ power, temp, n float64 }
+	bands := func(pairs [][2]float64) map[int]*band {
+		out := make(map[int]*band)
+		for _, p := range pairs {
+			k := int(math.Floor(p[0] / PowerBin))
+			if out[k] == nil {
+				out[k] = &band{}
+			}
+			out[k].power += p[0]
+			out[k].temp += p[1]
+			out[k].n++
+		}
+		return out
+	}
+	b, a := bands(before), bands(after)
+
+	var weight float64
+	for k, bb := range b {
+		ab, found := a[k]
+		if !found {
+			continue
+		}
+		w := math.Min(bb.n, ab.n)
+		delta += w * (ab.temp/ab.n - bb.temp/bb.n)
+		at += w * (bb.power/bb.n + ab.power/ab.n) / 2
+		weight += w
+	}
+	if weight == 0 {
+		return 0, 0, false
+	}
+	return delta / weight, at / weight, true
+}
+
+// loadDeltas compares the results of the two load runs
+func loadDeltas(database *db.DB, beforeID, afterID int64) ([]MetricDelta, error) {
+	if beforeID == 0 || afterID == 0 {
+		return nil, nil
+	}
+	before, err := database.GetResults(beforeID)
+	if err != nil {
+		return nil, err
+	}
+	after, err := database.GetResults(afterID)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]float64, len(after))
+	for _, r := range after {
+		values[r.Metric] = r.Value
+	}
+	var deltas []MetricDelta
+	for _, r := range before {
+		if v, ok := values[r.Metric]; ok {
+			deltas = append(deltas, MetricDelta{Metric: r.Metric, Unit: r.Unit, Before: r.Value, After: v})
+		}
+	}
+	return deltas, nil
+}
+
+// describeLoad names the load of a session, e.g. "cpu for 15m0s on 8 threads"
+func (s *Session) describeLoad() string {
+	d := fmt.Sprintf("%s for %s", s.Load, s.Duration)
+	if s.Threads > 0 {
+		d += fmt.Sprintf(" on %d threads", s.Threads)
+	}
+	return d
+}
+
+// Describe summarizes the change of one component, e.g. "CPU ran 6.2 °C
+// cooler at 142 W"
+func (d SensorDelta) Describe() string {
+	direction := "cooler"
+	if d.Delta > 0 {
+		direction = "warmer"
+	}
+	s := fmt.Sprintf("%s ran %.1f °C %s", d.Name, math.Abs(d.Delta), direction)
+	if d.Matched {
+		s += fmt.Sprintf(" at %.0f W", d.AtPower)
+	}
+	return s
+}
+
+// Improved reports whether every component ran cooler
+func (c *Comparison) Improved() bool {
+	for _, d := range c.Sensors {
+		if d.Delta >= 0 {
+			return false
+		}
+	}
+	return len(c.Sensors) > 0
+}
+
+var reportTemplate = template.Must(template.New("cooling").Funcs(template.FuncMap{
+	"f1":     func(v float64) string { return fmt.Sprintf("%.1f", v) },
+	"signed": func(v float64) string { return fmt.Sprintf("%+.1f", v) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>F.I.R.E. Cooling Comparison - {{.Label}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 6px 10px; text-align: left; }
+th { background: #f4f4f4; }
+.better { color: #1a7f37; font-weight: bold; }
+.worse { color: #cf222e; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Cooling Comparison: {{.Label}}</h1>
+<p>Generated {{.Generated.Format "2006-01-02 15:04:05"}}. Load: {{.Before.Load}} for {{.Before.Duration}}, compared after a {{.Before.Warmup}} warmup.</p>
+<table>
+<tr><th></th><th>Before</th><th>After</th></tr>
+<tr><td>Session</td><td>run #{{.Before.Run.ID}}, {{.Before.Run.StartTime.Format "2006-01-02 15:04"}}</td><td>run #{{.After.Run.ID}}, {{.After.Run.StartTime.Format "2006-01-02 15:04"}}</td></tr>
+<tr><td>Room</td><td>{{if .Before.Ambient}}{{f1 .Before.Ambient}} °C{{else}}-{{end}}</td><td>{{if .After.Ambient}}{{f1 .After.Ambient}} °C{{else}}-{{end}}</td></tr>
+</table>
+<h2>Temperatures</h2>
+<table>
+<tr><th>Component</th><th>Before</th><th>After</th><th>Power before</th><th>Power after</th><th>Delta</th></tr>
+{{range .Sensors}}<tr><td>{{.Name}}</td><td>{{f1 .BeforeTemp}} °C</td><td>{{f1 .AfterTemp}} °C</td><td>{{if .BeforePower}}{{f1 .BeforePower}} W{{else}}-{{end}}</td><td>{{if .AfterPower}}{{f1 .AfterPower}} W{{else}}-{{end}}</td><td class="{{if lt .Delta 0.0}}better{{else}}worse{{end}}">{{signed .Delta}} °C{{if .Matched}} at {{printf "%.0f" .AtPower}} W{{end}}</td></tr>
+{{end}}</table>
+{{if .Fans}}<h2>Fans</h2>
+<table>
+<tr><th>Fan</th><th>Before</th><th>After</th><th>Change</th></tr>
+{{range .Fans}}<tr><td>{{.Metric}}</td><td>{{printf "%.0f" .Before}} {{.Unit}}</td><td>{{printf "%.0f" .After}} {{.Unit}}</td><td>{{printf "%+.0f" .Change}} {{.Unit}} ({{signed .Percent}}%)</td></tr>
+{{end}}</table>
+{{end}}{{if .Load}}<h2>Load Results</h2>
+<table>
+<tr><th>Metric</th><th>Before</th><th>After</th><th>Change</th></tr>
+{{range .Load}}<tr><td>{{.Metric}}</td><td>{{printf "%.2f" .Before}} {{.Unit}}</td><td>{{printf "%.2f" .After}} {{.Unit}}</td><td>{{signed .Percent}}%</td></tr>
+{{end}}</table>
+{{end}}{{if .Notes}}<h2>Notes</h2>
+<ul>
+{{range .Notes}}<li>{{.}}</li>
+{{end}}</ul>
+{{end}}</body>
+</html>
+`))
+
+// WriteHTML writes the comparison as a standalone HTML page
+func (c *Comparison) WriteHTML(w io.Writer) error {
+	return reportTemplate.Execute(w, c)
+}
@@ -0,0 +1,381 @@
+// Package cooling compares a machine's cooling before and after a repaste or
+// cooler swap.
+//
+// A session runs a thermal load, such as the cpu plugin for 15 minutes,
+// while recording the readings bench monitor takes. The "before" session
+// fixes the load; the "after" session repeats it exactly. Compare then puts
+// the steady-state temperatures side by side at equal package power, along
+// with the fan speeds and the load's own score, so a technician no longer
+// has to line the numbers up in a spreadsheet.
+package cooling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/artifact"
+	"github.com/mscrnt/project_fire/pkg/changelog"
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/ecc"
+	"github.com/mscrnt/project_fire/pkg/inventory"
+	"github.com/mscrnt/project_fire/pkg/ipmi"
+	"github.com/mscrnt/project_fire/pkg/journal"
+	"github.com/mscrnt/project_fire/pkg/monitor"
+	"github.com/mscrnt/project_fire/pkg/plugin"
+	"github.com/mscrnt/project_fire/pkg/power"
+	"github.com/mscrnt/project_fire/pkg/throttle"
+	"github.com/mscrnt/project_fire/pkg/virt"
+)
+
+// RunPlugin is the plugin name session runs are recorded under
+const RunPlugin = "cooling"
+
+// Session phases
+const (
+	PhaseBefore = "before"
+	PhaseAfter  = "after"
+)
+
+// Defaults for a before session
+const (
+	DefaultLoad     = "cpu"
+	DefaultDuration = 15 * time.Minute
+	DefaultInterval = 2 * time.Second
+)
+
+// Session is one recorded thermal load. The readings are stored as results
+// of Run; the load's own run is LoadRunID.
+type Session struct {
+	Run       *db.Run
+	Label     string
+	Phase     string
+	Load      string
+	Duration  time.Duration
+	Warmup    time.Duration // Readings this far into the load are not compared
+	Threads   int
+	Config    map[string]interface{}
+	Interval  time.Duration
+	Ambient   float64 // Room temperature in °C, 0 when not given
+	LoadRunID int64
+}
+
+// SampleFunc takes one set of readings with their units
+type SampleFunc func(ctx context.Context) (values map[string]float64, units map[string]string, err error)
+
+// MonitorSampler reads the sensors through a monitor poller
+func MonitorSampler() SampleFunc {
+	poller := monitor.NewPoller()
+	return func(ctx context.Context) (map[string]float64, map[string]string, error) {
+		s, err := poller.Sample(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		values, units := s.Metrics()
+		return values, units, nil
+	}
+}
+
+// params stores the session settings with its run
+func (s *Session) params() db.JSONData {
+	p := db.JSONData{
+		"label":    s.Label,
+		"phase":    s.Phase,
+		"load":     s.Load,
+		"duration": s.Duration.String(),
+		"warmup":   s.Warmup.String(),
+		"threads":  s.Threads,
+		"interval": s.Interval.String(),
+	}
+	if len(s.Config) > 0 {
+		p["config"] = s.Config
+	}
+	if s.Ambient != 0 {
+		p["ambient"] = s.Ambient
+	}
+	if s.LoadRunID != 0 {
+		p["load_run_id"] = s.LoadRunID
+	}
+	return p
+}
+
+// FromRun reads a session back from its run
+func FromRun(run *db.Run) (*Session, error) {
+	if run.Plugin != RunPlugin {
+		return nil, fmt.Errorf("run %d is a %s run, not a cooling session", run.ID, run.Plugin)
+	}
+	s := &Session{Run: run}
+	s.Label, _ = run.Params["label"].(string)
+	s.Phase, _ = run.Params["phase"].(string)
+	s.Load, _ = run.Params["load"].(string)
+	if config, ok := run.Params["config"].(map[string]interface{}); ok {
+		// JSON turned integers into floats; give the load back what it was given
+		s.Config = make(map[string]interface{}, len(config))
+		for k, v := range config {
+			if f, ok := v.(float64); ok && f == math.Trunc(f) {
+				v = int(f)
+			}
+			s.Config[k] = v
+		}
+	}
+	s.Ambient, _ = run.Params["ambient"].(float64)
+	if n, ok := run.Params["threads"].(float64); ok {
+		s.Threads = int(n)
+	}
+	if n, ok := run.Params["load_run_id"].(float64); ok {
+		s.LoadRunID = int64(n)
+	}
+	for key, d := range map[string]*time.Duration{"duration": &s.Duration, "warmup": &s.Warmup, "interval": &s.Interval} {
+		if v, ok := run.Params[key].(string); ok {
+			*d, _ = time.ParseDuration(v)
+		}
+	}
+	if s.Label == "" || s.Load == "" {
+		return nil, fmt.Errorf("run %d has no cooling session settings", run.ID)
+	}
+	return s, nil
+}
+
+// Latest returns the newest completed session with the label and phase
+func Latest(database *db.DB, label, phase string) (*Session, error) {
+	sessions, err := List(database, label)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range sessions {
+		if s.Phase == phase && s.Run.Success {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("no %s session recorded for %q", phase, label)
+}
+
+// List returns the sessions with the label, or every session when label is
+// empty, newest first
+func List(database *db.DB, label string) ([]*Session, error) {
+	runs, err := database.ListRuns(db.RunFilter{Plugin: RunPlugin})
+	if err != nil {
+		return nil, err
+	}
+	var sessions []*Session
+	for _, run := range runs {
+		s, err := FromRun(run)
+		if err != nil || (label != "" && s.Label != label) {
+			continue
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+// Repeat returns an after session running exactly the load of before
+func Repeat(before *Session) *Session {
+	return &Session{
+		Label:    before.Label,
+		Phase:    PhaseAfter,
+		Load:     before.Load,
+		Duration: before.Duration,
+		Warmup:   before.Warmup,
+		Threads:  before.Threads,
+		Config:   before.Config,
+		Interval: before.Interval,
+	}
+}
+
+// Recorder runs sessions and records them in the results database
+type Recorder struct {
+	database *db.DB
+	logger   *log.Logger
+	sample   SampleFunc
+}
+
+// NewRecorder creates a recorder reading the same sensors as bench monitor
+func NewRecorder(database *db.DB, logger *log.Logger) *Recorder {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Recorder{database: database, logger: logger, sample: MonitorSampler()}
+}
+
+// SetSampler replaces the readings the recorder takes
+func (r *Recorder) SetSampler(sample SampleFunc) {
+	r.sample = sample
+}
+
+// Record runs the session's load and stores a reading every interval until
+// it ends. The load is recorded as a run of its own, like bench test does.
+// Missing settings get the defaults; a zero warmup skips the first third of
+// the load.
+func (r *Recorder) Record(ctx context.Context, s *Session) error {
+	if s.Label == "" {
+		return errors.New("a session needs a label, such as the machine's asset tag")
+	}
+	if s.Phase != PhaseBefore && s.Phase != PhaseAfter {
+		return fmt.Errorf("unknown phase %q", s.Phase)
+	}
+	if s.Load == "" {
+		s.Load = DefaultLoad
+	}
+	if s.Duration <= 0 {
+		s.Duration = DefaultDuration
+	}
+	if s.Warmup <= 0 {
+		s.Warmup = s.Duration / 3
+	}
+	if s.Warmup >= s.Duration {
+		return fmt.Errorf("warmup %s leaves nothing of the %s load to compare", s.Warmup, s.Duration)
+	}
+	if s.Interval <= 0 {
+		s.Interval = DefaultInterval
+	}
+
+	p, err := plugin.Get(s.Load)
+	if err != nil {
+		return err
+	}
+	params := p.DefaultParams()
+	params.Duration = s.Duration
+	if s.Threads > 0 {
+		params.Threads = s.Threads
+	}
+	if params.Config == nil {
+		params.Config = make(map[string]interface{})
+	}
+	for k, v := range s.Config {
+		params.Config[k] = v
+	}
+	if err := p.ValidateParams(params); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	releaseSleep, err := power.Inhibit(fmt.Sprintf("Recording %s cooling session for %s", s.Phase, s.Label))
+	if err != nil {
+		r.logger.Printf("Could not prevent sleep: %v", err)
+	}
+	defer releaseSleep()
+
+	loadRun, err := r.database.CreateRun(s.Load, db.JSONData(params.Config))
+	if err != nil {
+		return fmt.Errorf("failed to create run record: %w", err)
+	}
+	stopLoadJournal := journal.Start(r.database, loadRun)
+	defer stopLoadJournal()
+	loadRun.Environment = virt.Detect().Label()
+	changelog.Identify(loadRun)
+	if err := inventory.Record(r.database, loadRun.ID); err != nil {
+		r.logger.Printf("Failed to record hardware inventory of run %d: %v", loadRun.ID, err)
+	}
+	s.LoadRunID = loadRun.ID
+
+	run, err := r.database.CreateRun(RunPlugin, s.params())
+	if err != nil {
+		return fmt.Errorf("failed to create run record: %w", err)
+	}
+	stopJournal := journal.Start(r.database, run)
+	defer stopJournal()
+	run.Environment = loadRun.Environment
+	run.Machine, run.Model = loadRun.Machine, loadRun.Model
+	s.Run = run
+
+	// Sample until the load ends
+	sampleCtx, stopSampling := context.WithCancel(ctx)
+	sampled := make(chan int, 1)
+	go func() {
+		sampled <- r.record(sampleCtx, run.ID, s.Interval)
+	}()
+
+	loadCtx, cancel := context.WithTimeout(ctx, params.Duration+30*time.Second)
+	watch := throttle.Start(loadCtx, throttle.SensorSampler(), throttle.DefaultInterval)
+	eccWatch := ecc.Start(loadCtx, ecc.Read, ecc.DefaultInterval)
+	selWatch := ipmi.WatchSEL(loadCtx, ipmi.ReadSEL)
+	result, loadErr := p.Run(loadCtx, params)
+	throttling := watch.Stop()
+	eccErrors := eccWatch.Stop()
+	selEntries := selWatch.Stop()
+	cancel()
+	stopSampling()
+	samples := <-sampled
+
+	endTime := time.Now()
+	loadRun.EndTime = &endTime
+	loadRun.Success = result.Success
+	loadRun.Error = result.Error
+	loadRun.Stdout = result.Stdout
+	loadRun.Stderr = result.Stderr
+	if loadErr != nil {
+		loadRun.ExitCode = 1
+		if loadRun.Error == "" {
+			loadRun.Error = loadErr.Error()
+		}
+	}
+	if err := r.database.UpdateRun(loadRun); err != nil {
+		r.logger.Printf("Failed to update run %d: %v", loadRun.ID, err)
+	}
+	if len(result.Metrics) > 0 {
+		units := plugin.MetricUnits(p, result.Metrics)
+		if err := r.database.CreateResults(loadRun.ID, result.Metrics, units); err != nil {
+			r.logger.Printf("Failed to save metrics of run %d: %v", loadRun.ID, err)
+		}
+	}
+	if err := throttling.Save(r.database, loadRun.ID); err != nil {
+		r.logger.Printf("Failed to save throttle events of run %d: %v", loadRun.ID, err)
+	}
+	if err := eccErrors.Save(r.database, loadRun.ID); err != nil {
+		r.logger.Printf("Failed to save ECC errors of run %d: %v", loadRun.ID, err)
+	}
+	if err := selEntries.Save(r.database, loadRun.ID); err != nil {
+		r.logger.Printf("Failed to save BMC event log entries of run %d: %v", loadRun.ID, err)
+	}
+	if err := artifact.SaveResult(artifact.DefaultRoot(), loadRun.ID, result); err != nil {
+		r.logger.Printf("Failed to save artifacts of run %d: %v", loadRun.ID, err)
+	}
+
+	// The session only counts when the load ran to the end
+	run.EndTime = &endTime
+	run.Success = loadRun.Success && ctx.Err() == nil
+	switch {
+	case ctx.Err() != nil:
+		run.ExitCode = 1
+		run.Error = "session interrupted"
+	case !loadRun.Success:
+		run.ExitCode = 1
+		run.Error = fmt.Sprintf("load failed: %s", loadRun.Error)
+	case samples == 0:
+		run.Success = false
+		run.ExitCode = 1
+		run.Error = "no readings were taken"
+	}
+	if err := r.database.UpdateRun(run); err != nil {
+		return fmt.Errorf("failed to update run record: %w", err)
+	}
+	if !run.Success {
+		return errors.New(run.Error)
+	}
+	return nil
+}
+
+// record stores a reading every interval until ctx ends and returns how
+// many it stored
+func (r *Recorder) record(ctx context.Context, runID int64, interval time.Duration) int {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	samples := 0
+	for {
+		values, units, err := r.sample(ctx)
+		if err == nil && len(values) > 0 {
+			if err := r.database.CreateResults(runID, values, units); err != nil {
+				r.logger.Printf("Failed to save readings: %v", err)
+			} else {
+				samples++
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return samples
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package cooling
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/plugin"
+)
+
+// coolingTestPlugin runs for its duration and scores its thread count
+type coolingTestPlugin struct{}
+
+func (coolingTestPlugin) Name() string        { return "cooling-test" }
+func (coolingTestPlugin) Description() string { return "Test load for cooling sessions" }
+func (coolingTestPlugin) DefaultParams() plugin.Params {
+	return plugin.Params{Duration: time.Second, Threads: 1}
+}
+func (coolingTestPlugin) ValidateParams(plugin.Params) error { return nil }
+func (coolingTestPlugin) Run(ctx context.Context, params plugin.Params) (plugin.Result, error) {
+	select {
+	case <-time.After(params.Duration):
+	case <-ctx.Done():
+		return plugin.Result{Error: "stopped"}, ctx.Err()
+	}
+	return plugin.Result{Success: true, Metrics: map[string]float64{"score": float64(params.Threads) * 100}}, nil
+}
+
+func init() {
+	_ = plugin.Register(coolingTestPlugin{})
+}
+
+func openTestDB(t *testing.T) *db.DB {
+	t.Helper()
+	t.Setenv("FIRE_ARTIFACTS", t.TempDir())
+	database, err := db.Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+	return database
+}
+
+func TestRecord(t *testing.T) {
+	database := openTestDB(t)
+	r := NewRecorder(database, log.New(io.Discard, "", 0))
+	r.SetSampler(func(context.Context) (map[string]float64, map[string]string, error) {
+		return map[string]float64{"cpu_temp": 70}, map[string]string{"cpu_temp": "°C"}, nil
+	})
+
+	before := &Session{
+		Label:    "rig-07",
+		Phase:    PhaseBefore,
+		Load:     "cooling-test",
+		Duration: 50 * time.Millisecond,
+		Threads:  4,
+		Config:   map[string]interface{}{"size_mb": 512},
+		Interval: 10 * time.Millisecond,
+		Ambient:  22.5,
+	}
+	if err := r.Record(context.Background(), before); err != nil {
+		t.Fatal(err)
+	}
+	if before.Warmup != before.Duration/3 {
+		t.Errorf("expected the default warmup to be a third of the load, got %s", before.Warmup)
+	}
+
+	latest, err := Latest(database, "rig-07", PhaseBefore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.Run.ID != before.Run.ID || latest.LoadRunID != before.LoadRunID || !latest.Run.Success {
+		t.Errorf("expected the recorded session back, got %+v", latest)
+	}
+	if latest.Threads != 4 || latest.Ambient != 22.5 || latest.Duration != before.Duration || latest.Warmup != before.Warmup {
+		t.Errorf("expected the settings to survive the database, got %+v", latest)
+	}
+	if v, ok := latest.Config["size_mb"].(int); !ok || v != 512 {
+		t.Errorf("expected integer config values to come back as ints, got %#v", latest.Config["size_mb"])
+	}
+
+	after := Repeat(latest)
+	if after.Phase != PhaseAfter || after.Load != before.Load || after.Threads != 4 || after.Ambient != 0 {
+		t.Errorf("expected the after session to repeat the load only, got %+v", after)
+	}
+	if _, err := Latest(database, "rig-07", PhaseAfter); err == nil {
+		t.Error("expected no after session yet")
+	}
+
+	results, err := database.GetResults(before.LoadRunID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Value != 400 {
+		t.Errorf("expected the load's score to be stored with its run, got %+v", results)
+	}
+}
+
+// addSession stores a session with one reading per second
+func addSession(t *testing.T, database *db.DB, s *Session, samples []map[string]float64) {
+	t.Helper()
+	run, err := database.CreateRun(RunPlugin, s.params())
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := time.Now()
+	run.EndTime = &end
+	run.Success = true
+	if err := database.UpdateRun(run); err != nil {
+		t.Fatal(err)
+	}
+	s.Run = run
+
+	start := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	for i, sample := range samples {
+		for metric, value := range sample {
+			if _, err := database.Exec(
+				`INSERT INTO results (run_id, metric, value, unit, created_at) VALUES (?, ?, ?, ?, ?)`,
+				run.ID, metric, value, "", start.Add(time.Duration(i)*time.Second),
+			); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	database := openTestDB(t)
+	session := func(phase string, ambient float64) *Session {
+		return &Session{Label: "rig-07", Phase: phase, Load: "cpu", Duration: time.Minute, Warmup: 2 * time.Second, Ambient: ambient}
+	}
+
+	// The first two readings are warmup. After the repaste the CPU draws
+	// more power at the same load, so only the 140-145 W band is shared.
+	before := session(PhaseBefore, 22)
+	addSession(t, database, before, []map[string]float64{
+		{"cpu_temp": 50, "cpu_power": 60, "fan_cpu": 900},
+		{"cpu_temp": 60, "cpu_power": 100, "fan_cpu": 1200},
+		{"cpu_temp": 90, "cpu_power": 141, "fan_cpu": 2000, "gpu0_temp": 70},
+		{"cpu_temp": 92, "cpu_power": 143, "fan_cpu": 2000, "gpu0_temp": 72},
+		{"cpu_temp": 85, "cpu_power": 131, "fan_cpu": 2000, "gpu0_temp": 71},
+	})
+	after := session(PhaseAfter, 24)
+	addSession(t, database, after, []map[string]float64{
+		{"cpu_temp": 40, "cpu_power": 60, "fan_cpu": 800},
+		{"cpu_temp": 50, "cpu_power": 100, "fan_cpu": 1000},
+		{"cpu_temp": 84, "cpu_power": 142, "fan_cpu": 1600, "gpu0_temp": 70},
+		{"cpu_temp": 86, "cpu_power": 144, "fan_cpu": 1600, "gpu0_temp": 70},
+		{"cpu_temp": 90, "cpu_power": 151, "fan_cpu": 1600, "gpu0_temp": 70},
+	})
+
+	c, err := Compare(database, before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Sensors) != 2 {
+		t.Fatalf("expected the CPU and GPU 0 to be compared, got %+v", c.Sensors)
+	}
+
+	cpu := c.Sensors[0]
+	if !cpu.Matched || math.Abs(cpu.AtPower-142.5) > 0.01 {
+		t.Errorf("expected the CPU to be compared at about 142.5 W, got %+v", cpu)
+	}
+	// 85 vs 91 °C in the shared band, less the 2 °C warmer room
+	if math.Abs(cpu.Delta-(-8)) > 0.01 {
+		t.Errorf("expected a delta of -8 °C, got %.2f", cpu.Delta)
+	}
+	if got := cpu.Describe(); got != "CPU ran 8.0 °C cooler at 142 W" {
+		t.Errorf("unexpected description %q", got)
+	}
+
+	gpu := c.Sensors[1]
+	if gpu.Name != "GPU 0" || gpu.Matched || math.Abs(gpu.Delta-(-3)) > 0.01 {
+		t.Errorf("expected the GPU to be compared as measured, got %+v", gpu)
+	}
+	if !c.Improved() {
+		t.Error("expected the comparison to show an improvement")
+	}
+
+	if len(c.Fans) != 1 || c.Fans[0].Before != 2000 || c.Fans[0].After != 1600 || c.Fans[0].Percent() != -20 {
+		t.Errorf("unexpected fan comparison: %+v", c.Fans)
+	}
+
+	var html bytes.Buffer
+	if err := c.WriteHTML(&html); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Cooling Comparison: rig-07", "-8.0 °C at 142 W", "GPU 0", "fan_cpu", "corrected for the room"} {
+		if !strings.Contains(html.String(), want) {
+			t.Errorf("expected the report to contain %q", want)
+		}
+	}
+
+	other := session(PhaseAfter, 0)
+	other.Load = "memory"
+	if _, err := Compare(database, before, other); err == nil {
+		t.Error("expected sessions with different loads to be refused")
+	}
+}
+
+func TestMatchedDelta(t *testing.T) {
+	if _, _, ok := matchedDelta([][2]float64{{100, 80}}, [][2]float64{{200, 60}}); ok {
+		t.Error("expected no match when the power never overlaps")
+	}
+	delta, at, ok := matchedDelta(
+		[][2]float64{{101, 80}, {102, 82}, {121, 90}},
+		[][2]float64{{103, 75}, {122, 88}, {123, 86}},
+	)
+	// 100-105 W: 75 vs 81, one shared sample; 120-125 W: 87 vs 90, one
+	if !ok || math.Abs(delta-(-4.5)) > 0.01 || math.Abs(at-112) > 0.01 {
+		t.Errorf("unexpected matched delta %.2f at %.2f W", delta, at)
+	}
+}
@@ -0,0 +1,227 @@
+// Package cpucores combines the readings of each logical CPU: utilization,
+// effective clock and the temperature of its core, with the temperatures of
+// AMD's core complex dies (CCDs).
+//
+// The topology places each logical CPU on its physical core and, on hybrid
+// Intel processors, says whether that is a performance (P) or an efficiency
+// (E) core. It is read from sysfs on Linux and from the CPU sets on Windows.
+// Temperatures come from the sensor backend, which names them differently
+// per platform: coretemp's "Core N" on Linux, "CPU Core #N" in
+// LibreHardwareMonitor, and "TccdN" or "CCDN (Tdie)" for the dies.
+package cpucores
+
+import (
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/sensors"
+)
+
+// Kind is the kind of core on a hybrid processor
+type Kind string
+
+// Kind constants; processors with one kind of core leave it empty
+const (
+	Performance Kind = "P"
+	Efficiency  Kind = "E"
+)
+
+// Topology places a logical CPU
+type Topology struct {
+	CPU     int  // Logical CPU number, as in the per-CPU utilization
+	Package int  // Socket
+	Core    int  // Physical core id within the package, shared by its threads
+	Kind    Kind // Empty unless the processor is hybrid
+}
+
+// CPU holds the readings of one logical CPU. Readings the platform does not
+// expose are zero.
+type CPU struct {
+	Topology
+	Usage float64 // Percent
+	Clock float64 // MHz
+	Temp  float64 // °C of its core
+}
+
+// Readings holds every logical CPU and the CCD temperatures
+type Readings struct {
+	CPUs []CPU
+	CCDs []sensors.Reading // e.g. "CCD1", where the backend reports them
+}
+
+// Hybrid reports whether the CPUs include performance and efficiency cores
+func (r *Readings) Hybrid() bool {
+	for _, c := range r.CPUs {
+		if c.Kind != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Label names a logical CPU, e.g. "CPU 3" or "CPU 17 (E)" on hybrid
+// processors
+func (c CPU) Label() string {
+	if c.Kind != "" {
+		return "CPU " + strconv.Itoa(c.CPU) + " (" + string(c.Kind) + ")"
+	}
+	return "CPU " + strconv.Itoa(c.CPU)
+}
+
+// ReadTopology returns the topology of every logical CPU in order. Where the
+// platform cannot say, each CPU is its own core.
+func ReadTopology() []Topology {
+	if topology := readTopology(); len(topology) > 0 {
+		return topology
+	}
+	topology := make([]Topology, runtime.NumCPU())
+	for i := range topology {
+		topology[i] = Topology{CPU: i, Core: i}
+	}
+	return topology
+}
+
+// Combine puts the per-CPU utilization and a sensor snapshot on the
+// topology. Core clocks are matched per logical CPU when the backend reports
+// one per CPU (cpufreq), and per physical core otherwise (LibreHardwareMonitor).
+func Combine(topology []Topology, usage []float64, snapshot *sensors.Snapshot) *Readings {
+	r := &Readings{CPUs: make([]CPU, len(topology))}
+	ranks := coreRanks(topology)
+	var temps coreTemps
+	if snapshot != nil {
+		temps = parseTemps(snapshot.Temperatures)
+		r.CCDs = temps.ccds
+	}
+
+	for i, t := range topology {
+		c := CPU{Topology: t}
+		if t.CPU < len(usage) {
+			c.Usage = usage[t.CPU]
+		}
+		if snapshot != nil {
+			rank := ranks[coreKey{t.Package, t.Core}]
+			switch clocks := snapshot.CoreClocks; {
+			case len(clocks) == len(topology) && t.CPU < len(clocks):
+				c.Clock = clocks[t.CPU]
+			case len(clocks) == len(ranks) && rank.all < len(clocks):
+				c.Clock = clocks[rank.all]
+			}
+			c.Temp = temps.of(t, rank)
+		}
+		r.CPUs[i] = c
+	}
+	return r
+}
+
+// coreKey identifies a physical core
+type coreKey struct{ pkg, core int }
+
+// coreRank is a core's position among all cores, and among the cores of its
+// kind, in order of their first logical CPU
+type coreRank struct{ all, kind int }
+
+// coreRanks numbers the physical cores the way the hardware monitor does:
+// "Core #1" is the core of the lowest logical CPU
+func coreRanks(topology []Topology) map[coreKey]coreRank {
+	sorted := append([]Topology(nil), topology...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].CPU < sorted[j].CPU })
+
+	ranks := make(map[coreKey]coreRank)
+	perKind := make(map[Kind]int)
+	for _, t := range sorted {
+		key := coreKey{t.Package, t.Core}
+		if _, ok := ranks[key]; ok {
+			continue
+		}
+		ranks[key] = coreRank{all: len(ranks), kind: perKind[t.Kind]}
+		perKind[t.Kind]++
+	}
+	return ranks
+}
+
+// coreTemps holds the per-core temperatures found in a snapshot
+type coreTemps struct {
+	byID   map[int]float64          // coretemp's "Core N", N being the core id
+	byRank map[int]float64          // "CPU Core #N", N counting from 1
+	byKind map[Kind]map[int]float64 // "P-Core #N" and "E-Core #N"
+	ccds   []sensors.Reading
+}
+
+// of returns the temperature of a logical CPU's core, or 0
+func (t coreTemps) of(topology Topology, rank coreRank) float64 {
+	if v, ok := t.byID[topology.Core]; ok && topology.Package == 0 {
+		return v
+	}
+	if v, ok := t.byKind[topology.Kind][rank.kind]; ok {
+		return v
+	}
+	return t.byRank[rank.all]
+}
+
+// parseTemps sorts the per-core and per-die temperatures out of the
+// snapshot's readings
+func parseTemps(readings []sensors.Reading) coreTemps {
+	t := coreTemps{
+		byID:   make(map[int]float64),
+		byRank: make(map[int]float64),
+		byKind: map[Kind]map[int]float64{Performance: {}, Efficiency: {}},
+	}
+	for _, r := range readings {
+		name := strings.TrimPrefix(r.Name, "CPU ")
+		switch {
+		case r.Source == "coretemp" && strings.HasPrefix(name, "Core "):
+			if n, err := strconv.Atoi(strings.TrimPrefix(name, "Core ")); err == nil {
+				if _, seen := t.byID[n]; !seen {
+					t.byID[n] = r.Value
+				}
+			}
+		case strings.HasPrefix(name, "Core #"):
+			if n, ok := coreNumber(name, "Core #"); ok {
+				t.byRank[n-1] = r.Value
+			}
+		case strings.HasPrefix(name, "P-Core #"):
+			if n, ok := coreNumber(name, "P-Core #"); ok {
+				t.byKind[Performance][n-1] = r.Value
+			}
+		case strings.HasPrefix(name, "E-Core #"):
+			if n, ok := coreNumber(name, "E-Core #"); ok {
+				t.byKind[Efficiency][n-1] = r.Value
+			}
+		case strings.HasPrefix(name, "Tccd"):
+			if n, ok := number(name, "Tccd"); ok {
+				t.ccds = append(t.ccds, sensors.Reading{Name: "CCD" + strconv.Itoa(n), Source: r.Source, Value: r.Value})
+			}
+		case strings.HasPrefix(name, "CCD"):
+			if n, ok := number(strings.Replace(name, "CCD #", "CCD", 1), "CCD"); ok {
+				t.ccds = append(t.ccds, sensors.Reading{Name: "CCD" + strconv.Itoa(n), Source: r.Source, Value: r.Value})
+			}
+		}
+	}
+	sort.SliceStable(t.ccds, func(i, j int) bool {
+		a, _ := number(t.ccds[i].Name, "CCD")
+		b, _ := number(t.ccds[j].Name, "CCD")
+		return a < b
+	})
+	return t
+}
+
+// coreNumber returns N for a core's own temperature named prefix+N, and not
+// for derived readings such as "Core #1 Distance to TjMax"
+func coreNumber(name, prefix string) (int, bool) {
+	n, err := strconv.Atoi(strings.TrimPrefix(name, prefix))
+	return n, err == nil && n > 0
+}
+
+// number returns the number following prefix in name, e.g. 1 for "CCD1
+// (Tdie)" with the prefix "CCD"
+func number(name, prefix string) (int, bool) {
+	rest := strings.TrimPrefix(name, prefix)
+	end := 0
+	for end < len(rest) && rest[end] >= '0' && rest[end] <= '9' {
+		end++
+	}
+	n, err := strconv.Atoi(rest[:end])
+	return n, err == nil
+}
@@ -0,0 +1,92 @@
+package cpucores
+
+import (
+	"testing"
+
+	"github.com/mscrnt/project_fire/pkg/sensors"
+)
+
+func TestCombineHybridCoretemp(t *testing.T) {
+	// Two hyperthreaded P-cores (ids 0 and 4) and two E-cores (ids 8 and 9)
+	topology := []Topology{
+		{CPU: 0, Core: 0, Kind: Performance},
+		{CPU: 1, Core: 0, Kind: Performance},
+		{CPU: 2, Core: 4, Kind: Performance},
+		{CPU: 3, Core: 4, Kind: Performance},
+		{CPU: 4, Core: 8, Kind: Efficiency},
+		{CPU: 5, Core: 9, Kind: Efficiency},
+	}
+	snapshot := &sensors.Snapshot{
+		CoreClocks: []float64{5200, 5100, 4800, 4700, 3900, 3800},
+		Temperatures: []sensors.Reading{
+			{Name: "Package id 0", Source: "coretemp", Value: 80},
+			{Name: "Core 0", Source: "coretemp", Value: 78},
+			{Name: "Core 4", Source: "coretemp", Value: 74},
+			{Name: "Core 8", Source: "coretemp", Value: 61},
+			{Name: "Core 9", Source: "coretemp", Value: 60},
+		},
+	}
+	r := Combine(topology, []float64{90, 10, 50, 50, 100, 0}, snapshot)
+
+	if !r.Hybrid() {
+		t.Error("expected a hybrid processor")
+	}
+	want := []CPU{
+		{topology[0], 90, 5200, 78},
+		{topology[1], 10, 5100, 78},
+		{topology[2], 50, 4800, 74},
+		{topology[3], 50, 4700, 74},
+		{topology[4], 100, 3900, 61},
+		{topology[5], 0, 3800, 60},
+	}
+	for i, c := range r.CPUs {
+		if c != want[i] {
+			t.Errorf("CPU %d: got %+v, want %+v", i, c, want[i])
+		}
+	}
+	if label := r.CPUs[4].Label(); label != "CPU 4 (E)" {
+		t.Errorf("unexpected label %q", label)
+	}
+}
+
+func TestCombineHardwareMonitor(t *testing.T) {
+	// LibreHardwareMonitor reports clocks and temperatures per physical core
+	topology := []Topology{
+		{CPU: 0, Core: 0}, {CPU: 1, Core: 0},
+		{CPU: 2, Core: 2}, {CPU: 3, Core: 2},
+	}
+	snapshot := &sensors.Snapshot{
+		CoreClocks: []float64{4600, 4400},
+		Temperatures: []sensors.Reading{
+			{Name: "CPU Core #1", Value: 70},
+			{Name: "CPU Core #2", Value: 66},
+			{Name: "CPU Core #1 Distance to TjMax", Value: 30},
+			{Name: "CCD2 (Tdie)", Value: 58},
+			{Name: "CCD1 (Tdie)", Value: 64},
+		},
+	}
+	r := Combine(topology, nil, snapshot)
+
+	if r.Hybrid() {
+		t.Error("expected one kind of core")
+	}
+	if c := r.CPUs[1]; c.Clock != 4600 || c.Temp != 70 {
+		t.Errorf("expected CPU 1 on core #1, got %+v", c)
+	}
+	if c := r.CPUs[3]; c.Clock != 4400 || c.Temp != 66 {
+		t.Errorf("expected CPU 3 on core #2, got %+v", c)
+	}
+	if len(r.CCDs) != 2 || r.CCDs[0].Name != "CCD1" || r.CCDs[0].Value != 64 {
+		t.Errorf("expected the CCDs in order, got %+v", r.CCDs)
+	}
+}
+
+func TestCombineK10temp(t *testing.T) {
+	r := Combine([]Topology{{CPU: 0}}, []float64{12}, &sensors.Snapshot{Temperatures: []sensors.Reading{
+		{Name: "Tctl", Source: "k10temp", Value: 70},
+		{Name: "Tccd1", Source: "k10temp", Value: 65},
+	}})
+	if len(r.CCDs) != 1 || r.CCDs[0].Name != "CCD1" || r.CPUs[0].Temp != 0 {
+		t.Errorf("expected CCD1 and no core temperature, got %+v", r)
+	}
+}
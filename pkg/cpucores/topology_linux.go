@@ -0,0 +1,73 @@
+//go:build linux
+// +build linux
+
+package cpucores
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sysfsRoot is the root sysfs is read under, replaced in tests
+var sysfsRoot = "/"
+
+// readTopology reads each CPU's package and core id, and the kind of core
+// from the hybrid PMUs: cpu_core lists the P-cores and cpu_atom the E-cores
+func readTopology() []Topology {
+	dirs, _ := filepath.Glob(filepath.Join(sysfsRoot, "sys/devices/system/cpu/cpu[0-9]*"))
+	kinds := make(map[int]Kind)
+	for pmu, kind := range map[string]Kind{"cpu_core": Performance, "cpu_atom": Efficiency} {
+		for _, cpu := range parseCPUList(readString(filepath.Join(sysfsRoot, "sys/devices", pmu, "cpus"))) {
+			kinds[cpu] = kind
+		}
+	}
+
+	var topology []Topology
+	for _, dir := range dirs {
+		cpu, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(dir), "cpu"))
+		if err != nil {
+			continue
+		}
+		core, err := strconv.Atoi(readString(filepath.Join(dir, "topology/core_id")))
+		if err != nil {
+			continue // Offline
+		}
+		pkg, _ := strconv.Atoi(readString(filepath.Join(dir, "topology/physical_package_id")))
+		topology = append(topology, Topology{CPU: cpu, Package: pkg, Core: core, Kind: kinds[cpu]})
+	}
+	sort.Slice(topology, func(i, j int) bool { return topology[i].CPU < topology[j].CPU })
+	return topology
+}
+
+// parseCPUList parses a kernel CPU list such as "0-7,16-23"
+func parseCPUList(list string) []int {
+	var cpus []int
+	for _, part := range strings.Split(list, ",") {
+		first, last, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		from, err := strconv.Atoi(first)
+		if err != nil {
+			continue
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.Atoi(last); err != nil {
+				continue
+			}
+		}
+		for cpu := from; cpu <= to; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus
+}
+
+func readString(path string) string {
+	data, err := os.ReadFile(path) // #nosec G304 -- sysfs path
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,59 @@
+//go:build linux
+// +build linux
+
+package cpucores
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadTopology(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"sys/devices/cpu_core/cpus":                                 "0-1",
+		"sys/devices/cpu_atom/cpus":                                 "2",
+		"sys/devices/system/cpu/cpu0/topology/core_id":              "0",
+		"sys/devices/system/cpu/cpu1/topology/core_id":              "0",
+		"sys/devices/system/cpu/cpu2/topology/core_id":              "8",
+		"sys/devices/system/cpu/cpu10/topology/core_id":             "9",
+		"sys/devices/system/cpu/cpu10/topology/physical_package_id": "1",
+	}
+	for path, content := range files {
+		full := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// An offline CPU has no topology
+	if err := os.MkdirAll(filepath.Join(root, "sys/devices/system/cpu/cpu3"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	old := sysfsRoot
+	sysfsRoot = root
+	t.Cleanup(func() { sysfsRoot = old })
+
+	want := []Topology{
+		{CPU: 0, Core: 0, Kind: Performance},
+		{CPU: 1, Core: 0, Kind: Performance},
+		{CPU: 2, Core: 8, Kind: Efficiency},
+		{CPU: 10, Package: 1, Core: 9},
+	}
+	if got := readTopology(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseCPUList(t *testing.T) {
+	if got := parseCPUList("0-3,8,10-11"); !reflect.DeepEqual(got, []int{0, 1, 2, 3, 8, 10, 11}) {
+		t.Errorf("unexpected CPUs %v", got)
+	}
+	if got := parseCPUList(""); got != nil {
+		t.Errorf("expected no CPUs, got %v", got)
+	}
+}
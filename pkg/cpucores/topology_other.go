@@ -0,0 +1,9 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package cpucores
+
+// readTopology is not available on this platform
+func readTopology() []Topology {
+	return nil
+}
@@ -0,0 +1,82 @@
+//go:build windows
+// +build windows
+
+package cpucores
+
+import (
+	"encoding/binary"
+	"sort"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	kernel32                       = windows.NewLazySystemDLL("kernel32.dll")
+	procGetSystemCPUSetInformation = kernel32.NewProc("GetSystemCpuSetInformation")
+)
+
+// cpuSetInformationType is CpuSetInformation, the only entry type
+const cpuSetInformationType = 0
+
+// readTopology reads the system's CPU sets. Each logical processor has one,
+// naming its core and efficiency class; on hybrid processors the P-cores
+// have a higher class than the E-cores.
+func readTopology() []Topology {
+	if procGetSystemCPUSetInformation.Find() != nil {
+		return nil // Before Windows 10
+	}
+	var length uint32
+	_, _, _ = procGetSystemCPUSetInformation.Call(0, 0, uintptr(unsafe.Pointer(&length)), 0, 0)
+	if length == 0 {
+		return nil
+	}
+	buf := make([]byte, length)
+	ok, _, _ := procGetSystemCPUSetInformation.Call(uintptr(unsafe.Pointer(&buf[0])), uintptr(length), uintptr(unsafe.Pointer(&length)), 0, 0)
+	if ok == 0 {
+		return nil
+	}
+	return parseCPUSets(buf[:length])
+}
+
+// parseCPUSets decodes SYSTEM_CPU_SET_INFORMATION entries
+func parseCPUSets(buf []byte) []Topology {
+	type cpuSet struct {
+		Topology
+		class uint8
+	}
+	var sets []cpuSet
+	minClass, maxClass := uint8(255), uint8(0)
+	for len(buf) >= 32 {
+		size := binary.LittleEndian.Uint32(buf[0:4])
+		if size < 32 || int(size) > len(buf) {
+			break
+		}
+		if binary.LittleEndian.Uint32(buf[4:8]) == cpuSetInformationType {
+			group := int(binary.LittleEndian.Uint16(buf[12:14]))
+			s := cpuSet{
+				Topology: Topology{
+					CPU:  group*64 + int(buf[14]), // LogicalProcessorIndex
+					Core: group*64 + int(buf[15]), // CoreIndex
+				},
+				class: buf[18], // EfficiencyClass
+			}
+			minClass, maxClass = min(minClass, s.class), max(maxClass, s.class)
+			sets = append(sets, s)
+		}
+		buf = buf[size:]
+	}
+
+	topology := make([]Topology, len(sets))
+	for i, s := range sets {
+		if minClass != maxClass {
+			s.Kind = Efficiency
+			if s.class == maxClass {
+				s.Kind = Performance
+			}
+		}
+		topology[i] = s.Topology
+	}
+	sort.Slice(topology, func(i, j int) bool { return topology[i].CPU < topology[j].CPU })
+	return topology
+}
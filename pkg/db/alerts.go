@@ -0,0 +1,65 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// AlertEvent is an alert that fired or resolved, kept as alert history
+type AlertEvent struct {
+	ID         int64     `json:"id"`
+	Time       time.Time `json:"time"`
+	Rule       string    `json:"rule"`
+	Metric     string    `json:"metric"`
+	Value      float64   `json:"value"`
+	Threshold  float64   `json:"threshold"`
+	Comparator string    `json:"comparator"`
+	State      string    `json:"state"` // "firing" or "resolved"
+	Message    string    `json:"message"`
+	Source     string    `json:"source"`          // e.g. "gui" or "monitor"
+	Error      string    `json:"error,omitempty"` // Why a channel did not get the alert
+}
+
+// CreateAlertEvent records an alert event and sets its ID
+func (db *DB) CreateAlertEvent(e *AlertEvent) error {
+	id, err := db.Insert(
+		`INSERT INTO alert_events (event_time, rule, metric, value, threshold, comparator, state, message, source, delivery_error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.Time.UTC(), e.Rule, e.Metric, e.Value, e.Threshold, e.Comparator, e.State, e.Message, e.Source, e.Error,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create alert event: %w", err)
+	}
+	e.ID = id
+	return nil
+}
+
+// ListAlertEvents returns the latest alert events, newest first. A limit of
+// zero returns them all.
+func (db *DB) ListAlertEvents(limit int) ([]*AlertEvent, error) {
+	query := `SELECT id, event_time, rule, metric, value, threshold, comparator, state,
+		COALESCE(message, ''), source, COALESCE(delivery_error, '')
+		FROM alert_events ORDER BY event_time DESC, id DESC`
+	var args []interface{}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list alert events: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var events []*AlertEvent
+	for rows.Next() {
+		e := &AlertEvent{}
+		if err := rows.Scan(&e.ID, &e.Time, &e.Rule, &e.Metric, &e.Value, &e.Threshold, &e.Comparator, &e.State,
+			&e.Message, &e.Source, &e.Error); err != nil {
+			return nil, fmt.Errorf("failed to scan alert event: %w", err)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
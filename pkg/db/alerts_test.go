@@ -0,0 +1,49 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAlertEvents(t *testing.T) {
+	database, err := Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = database.Close() }()
+
+	fired := time.Date(2026, 3, 1, 22, 0, 0, 0, time.UTC)
+	for _, e := range []*AlertEvent{
+		{Time: fired, Rule: "CPU hot", Metric: "cpu_temp", Value: 93.5, Threshold: 90, Comparator: ">", State: "firing",
+			Message: "CPU hot: cpu_temp is 93.5", Source: "monitor", Error: "smtp: connection refused"},
+		{Time: fired.Add(time.Minute), Rule: "CPU hot", Metric: "cpu_temp", Value: 71, Threshold: 90, Comparator: ">", State: "resolved",
+			Source: "monitor"},
+	} {
+		if err := database.CreateAlertEvent(e); err != nil {
+			t.Fatal(err)
+		}
+		if e.ID == 0 {
+			t.Fatal("expected an alert event ID")
+		}
+	}
+
+	events, err := database.ListAlertEvents(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].State != "resolved" || events[1].State != "firing" {
+		t.Fatalf("expected the newest event first, got %+v", events)
+	}
+	if e := events[1]; e.Value != 93.5 || e.Error != "smtp: connection refused" || !e.Time.Equal(fired) {
+		t.Errorf("unexpected event %+v", e)
+	}
+
+	events, err = database.ListAlertEvents(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].State != "resolved" {
+		t.Errorf("expected only the latest event, got %+v", events)
+	}
+}
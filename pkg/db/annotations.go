@@ -0,0 +1,76 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// Annotation is an event recorded alongside test runs, such as a power
+// outage, that helps explain their results
+type Annotation struct {
+	ID      int64     `json:"id"`
+	RunID   *int64    `json:"run_id,omitempty"` // Run in progress when recorded, if known
+	Time    time.Time `json:"time"`
+	Source  string    `json:"source"` // e.g. "system" or "nut:ups@localhost"
+	Kind    string    `json:"kind"`   // e.g. "on_battery"
+	Message string    `json:"message"`
+}
+
+// CreateAnnotation records an annotation and sets its ID. A run it belongs to
+// is synced again so the annotation reaches the central server.
+func (db *DB) CreateAnnotation(a *Annotation) error {
+	id, err := db.Insert(
+		`INSERT INTO annotations (run_id, event_time, source, kind, message) VALUES (?, ?, ?, ?, ?)`,
+		a.RunID, a.Time.UTC(), a.Source, a.Kind, a.Message,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create annotation: %w", err)
+	}
+	a.ID = id
+	if a.RunID != nil {
+		return db.MarkRunUnsynced(*a.RunID)
+	}
+	return nil
+}
+
+// ListAnnotations returns the annotations recorded between since and until,
+// oldest first. A zero time leaves that end of the range open.
+func (db *DB) ListAnnotations(since, until time.Time) ([]*Annotation, error) {
+	query := `SELECT id, run_id, event_time, source, kind, COALESCE(message, '') FROM annotations WHERE 1=1`
+	var args []interface{}
+	if !since.IsZero() {
+		query += " AND " + db.epochColumn("event_time") + " >= ?"
+		args = append(args, since.Unix())
+	}
+	if !until.IsZero() {
+		query += " AND " + db.epochColumn("event_time") + " <= ?"
+		args = append(args, until.Unix())
+	}
+	query += " ORDER BY event_time, id"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list annotations: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var annotations []*Annotation
+	for rows.Next() {
+		a := &Annotation{}
+		if err := rows.Scan(&a.ID, &a.RunID, &a.Time, &a.Source, &a.Kind, &a.Message); err != nil {
+			return nil, fmt.Errorf("failed to scan annotation: %w", err)
+		}
+		annotations = append(annotations, a)
+	}
+	return annotations, rows.Err()
+}
+
+// RunAnnotations returns the annotations recorded while a run was in
+// progress, from its start to its end (or now if it has not ended)
+func (db *DB) RunAnnotations(run *Run) ([]*Annotation, error) {
+	until := time.Now()
+	if run.EndTime != nil {
+		until = *run.EndTime
+	}
+	return db.ListAnnotations(run.StartTime, until)
+}
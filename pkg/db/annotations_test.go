@@ -0,0 +1,60 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRunAnnotations(t *testing.T) {
+	database, err := Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = database.Close() }()
+
+	run, err := database.CreateRun("cpu", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 3, 1, 22, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	run.StartTime = start
+	run.EndTime = &end
+
+	for _, a := range []*Annotation{
+		{Time: start.Add(-time.Minute), Source: "system", Kind: "on_battery", Message: "before the run"},
+		{Time: start.Add(10 * time.Minute), RunID: &run.ID, Source: "nut:ups@localhost", Kind: "on_battery", Message: "UPS on battery"},
+		{Time: start.Add(12 * time.Minute), Source: "nut:ups@localhost", Kind: "power_restored", Message: "power restored"},
+		{Time: end.Add(time.Minute), Source: "system", Kind: "power_restored", Message: "after the run"},
+	} {
+		if err := database.CreateAnnotation(a); err != nil {
+			t.Fatal(err)
+		}
+		if a.ID == 0 {
+			t.Fatal("expected an annotation ID")
+		}
+	}
+
+	annotations, err := database.RunAnnotations(run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(annotations) != 2 {
+		t.Fatalf("expected 2 annotations during the run, got %d", len(annotations))
+	}
+	if annotations[0].Message != "UPS on battery" || annotations[0].RunID == nil || *annotations[0].RunID != run.ID {
+		t.Errorf("expected the linked UPS event first, got %+v", annotations[0])
+	}
+	if annotations[1].RunID != nil || !annotations[1].Time.Equal(start.Add(12*time.Minute)) {
+		t.Errorf("expected an unlinked event matched by time, got %+v", annotations[1])
+	}
+
+	all, err := database.ListAnnotations(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 4 {
+		t.Errorf("expected 4 annotations, got %d", len(all))
+	}
+}
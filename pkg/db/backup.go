@@ -0,0 +1,311 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Backup reasons, recorded in the backup file name
+const (
+	BackupManual     = "manual"
+	BackupWeekly     = "weekly"
+	BackupPreMigrate = "pre-migrate"
+	BackupPreRestore = "pre-restore"
+)
+
+// ErrBackupUnsupported is returned for backends that are backed up by their server
+var ErrBackupUnsupported = errors.New("backups are only supported for SQLite databases")
+
+// backupTimeFormat is the timestamp in backup file names
+const backupTimeFormat = "20060102-150405"
+
+// backupName matches <db name>-<timestamp>-<reason>.db
+var backupName = regexp.MustCompile(`-(\d{8}-\d{6})-([a-z-]+)\.db$`)
+
+// BackupFile is a database backup on disk
+type BackupFile struct {
+	Path   string
+	Reason string
+	Time   time.Time
+	Size   int64
+}
+
+// BackupDir returns the directory holding backups of the SQLite database at dbPath
+func BackupDir(dbPath string) string {
+	return filepath.Join(filepath.Dir(dbPath), "backups")
+}
+
+// backupPath names a backup of dbPath taken at t
+func backupPath(dbPath, reason string, t time.Time) string {
+	base := strings.TrimSuffix(filepath.Base(dbPath), filepath.Ext(dbPath))
+	return filepath.Join(BackupDir(dbPath), fmt.Sprintf("%s-%s-%s.db", base, t.Format(backupTimeFormat), reason))
+}
+
+// newBackupPath names a backup of dbPath taken now. Names have a resolution
+// of a second, so a second backup for the same reason within one second is
+// named a second later rather than replacing the first.
+func newBackupPath(dbPath, reason string) (string, time.Time) {
+	now := time.Now()
+	path := backupPath(dbPath, reason, now)
+	for {
+		if _, err := os.Stat(path); err != nil {
+			return path, now
+		}
+		now = now.Add(time.Second)
+		path = backupPath(dbPath, reason, now)
+	}
+}
+
+// Backup writes a consistent copy of the database to its backup directory.
+// The copy is taken with VACUUM INTO, so it is safe while the database is in use.
+func (db *DB) Backup(reason string) (BackupFile, error) {
+	if db.driver != DriverSQLite {
+		return BackupFile{}, ErrBackupUnsupported
+	}
+
+	dir := BackupDir(db.path)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return BackupFile{}, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	path, now := newBackupPath(db.path, reason)
+	if _, err := db.conn.Exec(`VACUUM INTO ?`, path); err != nil {
+		return BackupFile{}, fmt.Errorf("failed to back up database: %w", err)
+	}
+
+	backup := BackupFile{Path: path, Reason: reason, Time: now}
+	if info, err := os.Stat(path); err == nil {
+		backup.Size = info.Size()
+	}
+	return backup, nil
+}
+
+// backupBeforeMigrate backs up an existing SQLite database that Migrate is
+// about to change
+func (db *DB) backupBeforeMigrate() error {
+	if db.driver != DriverSQLite {
+		return nil
+	}
+	pending, err := db.Pending()
+	if err != nil || len(pending) == 0 {
+		return err
+	}
+
+	// A new, empty database has nothing to protect
+	var tables int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table'`).Scan(&tables); err != nil {
+		return err
+	}
+	if tables == 0 {
+		return nil
+	}
+
+	if _, err := db.Backup(BackupPreMigrate); err != nil {
+		return err
+	}
+	return PruneBackups(BackupDir(db.path), BackupPreMigrate, KeepPreMigrate)
+}
+
+// userVersion reads the schema version stored in the SQLite header
+func (db *DB) userVersion() (int, error) {
+	var version int
+	err := db.conn.QueryRow(`PRAGMA user_version`).Scan(&version)
+	return version, err
+}
+
+// setUserVersion records the schema version after a successful migration
+func (db *DB) setUserVersion(version int) error {
+	_, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", version))
+	return err
+}
+
+// IntegrityCheck runs PRAGMA integrity_check and returns the problems found,
+// or nil if the database is intact
+func (db *DB) IntegrityCheck() ([]string, error) {
+	if db.driver != DriverSQLite {
+		return nil, ErrBackupUnsupported
+	}
+	return integrityCheck(db.conn)
+}
+
+// integrityCheck runs PRAGMA integrity_check on a connection
+func integrityCheck(conn *sql.DB) ([]string, error) {
+	rows, err := conn.Query(`PRAGMA integrity_check`)
+	if err != nil {
+		return nil, fmt.Errorf("integrity check failed: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var problems []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		if line != "ok" {
+			problems = append(problems, line)
+		}
+	}
+	return problems, rows.Err()
+}
+
+// CheckFile runs an integrity check on a SQLite file without migrating or
+// otherwise changing it
+func CheckFile(path string) ([]string, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	conn, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer func() { _ = conn.Close() }()
+	return integrityCheck(conn)
+}
+
+// ListBackups returns the backups in dir, newest first
+func ListBackups(dir string) ([]BackupFile, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []BackupFile
+	for _, entry := range entries {
+		m := backupName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || m == nil {
+			continue
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, m[1], time.Local)
+		if err != nil {
+			continue
+		}
+		backup := BackupFile{Path: filepath.Join(dir, entry.Name()), Reason: m[2], Time: t}
+		if info, err := entry.Info(); err == nil {
+			backup.Size = info.Size()
+		}
+		backups = append(backups, backup)
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Time.After(backups[j].Time)
+	})
+	return backups, nil
+}
+
+// PruneBackups deletes all but the newest keep backups made for reason
+func PruneBackups(dir, reason string, keep int) error {
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	kept := 0
+	for _, b := range backups {
+		if b.Reason != reason {
+			continue
+		}
+		if kept < keep {
+			kept++
+			continue
+		}
+		if err := os.Remove(b.Path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Restore replaces the SQLite database at dbPath with a backup. The database
+// must not be open. The backup is checked first, and the current database is
+// kept as a pre-restore backup, copied as-is if it is too damaged to vacuum.
+func Restore(dbPath, backupPath string) (BackupFile, error) {
+	problems, err := CheckFile(backupPath)
+	if err != nil {
+		return BackupFile{}, fmt.Errorf("failed to check backup: %w", err)
+	}
+	if len(problems) > 0 {
+		return BackupFile{}, fmt.Errorf("backup %s is damaged: %s", backupPath, problems[0])
+	}
+
+	var safety BackupFile
+	if _, err := os.Stat(dbPath); err == nil {
+		safety, err = preRestoreBackup(dbPath)
+		if err != nil {
+			return BackupFile{}, fmt.Errorf("failed to save the current database: %w", err)
+		}
+	}
+
+	// Write next to the database and rename, so a failure leaves it untouched
+	tmp := dbPath + ".restore"
+	if err := copyFile(backupPath, tmp); err != nil {
+		_ = os.Remove(tmp)
+		return safety, fmt.Errorf("failed to copy backup: %w", err)
+	}
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			_ = os.Remove(tmp)
+			return safety, fmt.Errorf("failed to remove %s: %w", dbPath+suffix, err)
+		}
+	}
+	if err := os.Rename(tmp, dbPath); err != nil {
+		_ = os.Remove(tmp)
+		return safety, fmt.Errorf("failed to replace database: %w", err)
+	}
+	return safety, nil
+}
+
+// preRestoreBackup saves the database about to be replaced by a restore
+func preRestoreBackup(dbPath string) (BackupFile, error) {
+	if conn, err := sql.Open("sqlite3", dbPath); err == nil {
+		current := &DB{conn: conn, path: dbPath, driver: DriverSQLite}
+		backup, err := current.Backup(BackupPreRestore)
+		_ = conn.Close()
+		if err == nil {
+			return backup, nil
+		}
+	}
+
+	dir := BackupDir(dbPath)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return BackupFile{}, err
+	}
+	path, now := newBackupPath(dbPath, BackupPreRestore)
+	if err := copyFile(dbPath, path); err != nil {
+		return BackupFile{}, err
+	}
+	return BackupFile{Path: path, Reason: BackupPreRestore, Time: now}, nil
+}
+
+// copyFile copies src to dst, syncing dst to disk
+func copyFile(src, dst string) error {
+	in, err := os.Open(src) // #nosec G304 -- backup chosen by the user
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600) // #nosec G304 -- path under the database directory
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
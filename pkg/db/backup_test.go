@@ -0,0 +1,163 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBackupBeforeMigrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fire.db")
+	database, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backups, _ := ListBackups(BackupDir(path)); len(backups) != 0 {
+		t.Errorf("expected no backup of a new database, got %d", len(backups))
+	}
+
+	// Pretend the database predates the newest migration
+	if _, err := database.Exec(`DELETE FROM schema_migrations WHERE version = ?`, SchemaVersion()); err != nil {
+		t.Fatal(err)
+	}
+	_ = database.Close()
+
+	database, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = database.Close() }()
+
+	backups, err := ListBackups(BackupDir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 || backups[0].Reason != BackupPreMigrate {
+		t.Fatalf("expected one pre-migrate backup, got %+v", backups)
+	}
+	if version, _ := database.userVersion(); version != SchemaVersion() {
+		t.Errorf("expected schema version %d, got %d", SchemaVersion(), version)
+	}
+}
+
+func TestPruneBackups(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.Local)
+	for i := 0; i < 4; i++ {
+		for _, reason := range []string{BackupWeekly, BackupManual} {
+			name := backupPath(filepath.Join(dir, "fire.db"), reason, start.Add(time.Duration(i)*time.Hour))
+			if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(name, nil, 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	backupDir := BackupDir(filepath.Join(dir, "fire.db"))
+	if err := PruneBackups(backupDir, BackupWeekly, 2); err != nil {
+		t.Fatal(err)
+	}
+	backups, err := ListBackups(backupDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	weekly := 0
+	for _, b := range backups {
+		if b.Reason == BackupWeekly {
+			weekly++
+			if b.Time.Before(start.Add(2 * time.Hour)) {
+				t.Errorf("expected oldest weekly backups to be pruned, found %s", b.Path)
+			}
+		}
+	}
+	if weekly != 2 || len(backups) != 6 {
+		t.Errorf("expected 2 weekly and 4 manual backups, got %d of %d", weekly, len(backups))
+	}
+}
+
+func TestBackupRestore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fire.db")
+	database, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.CreateRun("cpu", nil); err != nil {
+		t.Fatal(err)
+	}
+	backup, err := database.Backup(BackupManual)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems, err := CheckFile(backup.Path); err != nil || len(problems) > 0 {
+		t.Fatalf("expected intact backup, got %v, %v", problems, err)
+	}
+
+	// Runs added after the backup are gone once it is restored
+	if _, err := database.CreateRun("memory", nil); err != nil {
+		t.Fatal(err)
+	}
+	_ = database.Close()
+
+	safety, err := Restore(path, backup.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if safety.Reason != BackupPreRestore {
+		t.Errorf("expected pre-restore backup, got %+v", safety)
+	}
+
+	database, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = database.Close() }()
+	runs, err := database.ListRuns(RunFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].Plugin != "cpu" {
+		t.Errorf("expected only the cpu run after restore, got %d runs", len(runs))
+	}
+}
+
+func TestRestoreRejectsDamagedBackup(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.db")
+	if err := os.WriteFile(bad, []byte("not a database"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Restore(filepath.Join(dir, "fire.db"), bad); err == nil {
+		t.Error("expected error restoring a damaged backup")
+	}
+}
+
+func TestMaintainTakesWeeklySnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fire.db")
+	database, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = database.Close() }()
+
+	now := time.Now()
+	result, err := database.Maintain(now, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Checked || len(result.Problems) > 0 || result.Snapshot == nil {
+		t.Fatalf("expected check and snapshot, got %+v", result)
+	}
+
+	// A recent snapshot and check mean nothing is due
+	result, err = database.Maintain(now.Add(time.Hour), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Checked || result.Snapshot != nil {
+		t.Errorf("expected nothing due, got %+v", result)
+	}
+}
@@ -13,20 +13,67 @@ import (
 
 // DB wraps the SQL database connection
 type DB struct {
-	conn *sql.DB
-	path string
+	conn   *sql.DB
+	path   string
+	driver Driver
+	writes *writer // Runs the writes to a SQLite database; nil for PostgreSQL
 }
 
 // Open creates or opens a SQLite database
 func Open(path string) (*DB, error) {
+	return OpenConfig(Config{Driver: DriverSQLite, Path: path})
+}
+
+// OpenConfig opens the storage backend described by cfg and applies the
+// pending migrations. A SQLite database is backed up first unless cfg turns
+// that off.
+func OpenConfig(cfg Config) (*DB, error) {
+	db, err := OpenUnmigrated(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep a copy of older databases before their schema is changed
+	if cfg.BackupBeforeMigrate() {
+		if err := db.backupBeforeMigrate(); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("failed to back up database before migration: %w", err)
+		}
+	}
+
+	if _, err := db.Migrate(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+	return db, nil
+}
+
+// OpenUnmigrated opens the storage backend described by cfg without
+// applying pending migrations, such as to list them
+func OpenUnmigrated(cfg Config) (*DB, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	if cfg.Driver == DriverPostgres {
+		return openPostgres(cfg.DSN)
+	}
+	return openSQLite(cfg.Path)
+}
+
+// openSQLite creates or opens a SQLite database file
+func openSQLite(path string) (*DB, error) {
 	// Create directory if it doesn't exist
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0o750); err != nil {
 		return nil, fmt.Errorf("failed to create database directory: %w", err)
 	}
 
-	// Open database connection
-	conn, err := sql.Open("sqlite3", path+"?_journal_mode=WAL")
+	// Open database connection. The write-ahead log lets readers go on while
+	// another process writes; write transactions take the lock as they begin,
+	// so they wait for it rather than fail halfway.
+	dsn := fmt.Sprintf("%s?_journal_mode=WAL&_busy_timeout=%d&_txlock=immediate", path, BusyTimeout.Milliseconds())
+	conn, err := sql.Open("sqlite3", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -37,142 +84,173 @@ func Open(path string) (*DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	db := &DB{
-		conn: conn,
-		path: path,
+	return &DB{
+		conn:   conn,
+		path:   path,
+		driver: DriverSQLite,
+		writes: newWriter(),
+	}, nil
+}
+
+// openPostgres connects to a central PostgreSQL results server
+func openPostgres(dsn string) (*DB, error) {
+	conn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	// Run migrations
-	if err := db.Migrate(); err != nil {
+	// Test connection
+	if err := conn.Ping(); err != nil {
 		_ = conn.Close()
-		return nil, fmt.Errorf("failed to migrate database: %w", err)
+		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return db, nil
+	return &DB{
+		conn:   conn,
+		path:   dsn,
+		driver: DriverPostgres,
+	}, nil
 }
 
-// Close closes the database connection
+// Close closes the database connection once the queued writes are done
 func (db *DB) Close() error {
+	if db.writes != nil {
+		db.writes.close()
+	}
 	return db.conn.Close()
 }
 
+// Flush writes the SQLite write-ahead log back into the database file, so
+// recorded results survive the machine losing power. PostgreSQL commits are
+// already durable on the server.
+func (db *DB) Flush() error {
+	if db.driver != DriverSQLite {
+		return nil
+	}
+	if _, err := db.Exec(`PRAGMA wal_checkpoint(TRUNCATE)`); err != nil {
+		return fmt.Errorf("failed to flush database: %w", err)
+	}
+	return nil
+}
+
 // Conn returns the underlying database connection
 func (db *DB) Conn() *sql.DB {
 	return db.conn
 }
 
-// Path returns the database file path
+// Path returns the database file path (or DSN for PostgreSQL)
 func (db *DB) Path() string {
 	return db.path
 }
 
-// Migrate creates or updates the database schema
-func (db *DB) Migrate() error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS runs (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		plugin TEXT NOT NULL,
-		params TEXT,
-		start_time DATETIME NOT NULL,
-		end_time DATETIME,
-		exit_code INTEGER DEFAULT 0,
-		success BOOLEAN DEFAULT 0,
-		error TEXT,
-		stdout TEXT,
-		stderr TEXT,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE TABLE IF NOT EXISTS results (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		run_id INTEGER NOT NULL,
-		metric TEXT NOT NULL,
-		value REAL NOT NULL,
-		unit TEXT,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (run_id) REFERENCES runs(id) ON DELETE CASCADE
-	);
-
-	CREATE TABLE IF NOT EXISTS schedules (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT NOT NULL UNIQUE,
-		description TEXT,
-		cron_expr TEXT NOT NULL,
-		plugin TEXT NOT NULL,
-		params TEXT,
-		enabled BOOLEAN DEFAULT 1,
-		last_run_id INTEGER,
-		last_run_time DATETIME,
-		next_run_time DATETIME,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (last_run_id) REFERENCES runs(id) ON DELETE SET NULL
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_runs_plugin ON runs(plugin);
-	CREATE INDEX IF NOT EXISTS idx_runs_start_time ON runs(start_time);
-	CREATE INDEX IF NOT EXISTS idx_runs_success ON runs(success);
-	CREATE INDEX IF NOT EXISTS idx_results_run_id ON results(run_id);
-	CREATE INDEX IF NOT EXISTS idx_results_metric ON results(metric);
-	CREATE INDEX IF NOT EXISTS idx_schedules_enabled ON schedules(enabled);
-	CREATE INDEX IF NOT EXISTS idx_schedules_next_run ON schedules(next_run_time);
-	
-	-- Trigger to update updated_at timestamp
-	CREATE TRIGGER IF NOT EXISTS update_runs_timestamp 
-	AFTER UPDATE ON runs
-	BEGIN
-		UPDATE runs SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
-	END;
-
-	CREATE TRIGGER IF NOT EXISTS update_schedules_timestamp 
-	AFTER UPDATE ON schedules
-	BEGIN
-		UPDATE schedules SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
-	END;
-	`
-
-	_, err := db.conn.Exec(schema)
-	return err
+// Driver returns the storage backend in use
+func (db *DB) Driver() Driver {
+	return db.driver
 }
 
-// CreateRun creates a new test run record
+// Exec executes a statement written with '?' placeholders, on the writer of
+// a SQLite database
+func (db *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	var result sql.Result
+	err := db.write(func() error {
+		var err error
+		result, err = db.conn.Exec(rebind(db.driver, query), args...)
+		return err
+	})
+	return result, err
+}
+
+// Query runs a query written with '?' placeholders
+func (db *DB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return db.conn.Query(rebind(db.driver, query), args...)
+}
+
+// QueryRow runs a single-row query written with '?' placeholders
+func (db *DB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return db.conn.QueryRow(rebind(db.driver, query), args...)
+}
+
+// Insert executes an INSERT statement and returns the new row ID.
+// PostgreSQL has no LastInsertId, so the ID is read back via RETURNING.
+func (db *DB) Insert(query string, args ...interface{}) (int64, error) {
+	if db.driver == DriverPostgres {
+		var id int64
+		if err := db.QueryRow(query+" RETURNING id", args...).Scan(&id); err != nil {
+			return 0, err
+		}
+		return id, nil
+	}
+
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// backfillRunUUIDs assigns a UUID to every run that lacks one
+func (db *DB) backfillRunUUIDs() error {
+	rows, err := db.Query(`SELECT id FROM runs WHERE uuid IS NULL OR uuid = ''`)
+	if err != nil {
+		return err
+	}
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	_ = rows.Close()
+
+	for _, id := range ids {
+		if _, err := db.Exec(`UPDATE runs SET uuid = ? WHERE id = ?`, NewRunUUID(), id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateRun creates a new test run record, journaled as running in this
+// process
 func (db *DB) CreateRun(plugin string, params JSONData) (*Run, error) {
 	run := &Run{
+		UUID:      NewRunUUID(),
 		Plugin:    plugin,
 		Params:    params,
 		StartTime: time.Now(),
+		Machine:   Hostname(),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
-	result, err := db.conn.Exec(
-		`INSERT INTO runs (plugin, params, start_time, created_at, updated_at) 
-		 VALUES (?, ?, ?, ?, ?)`,
-		run.Plugin, run.Params, run.StartTime, run.CreatedAt, run.UpdatedAt,
+	// The process and machine journal the run, so it can be told apart
+	// from one cut short by a crash or power loss
+	id, err := db.Insert(
+		`INSERT INTO runs (uuid, plugin, params, start_time, machine, pid, heartbeat_at, created_at, updated_at) 
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		run.UUID, run.Plugin, run.Params, run.StartTime, run.Machine, os.Getpid(), run.StartTime, run.CreatedAt, run.UpdatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create run: %w", err)
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get last insert id: %w", err)
-	}
-
 	run.ID = id
 	return run, nil
 }
 
 // UpdateRun updates a test run record
 func (db *DB) UpdateRun(run *Run) error {
-	_, err := db.conn.Exec(
+	_, err := db.Exec(
 		`UPDATE runs SET 
 		 end_time = ?, exit_code = ?, success = ?, error = ?, 
-		 stdout = ?, stderr = ?, updated_at = ?
+		 stdout = ?, stderr = ?, environment = ?, machine = ?, model = ?, updated_at = ?
 		 WHERE id = ?`,
 		run.EndTime, run.ExitCode, run.Success, run.Error,
-		run.Stdout, run.Stderr, time.Now(), run.ID,
+		run.Stdout, run.Stderr, run.Environment, run.Machine, run.Model, time.Now(), run.ID,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update run: %w", err)
@@ -183,15 +261,16 @@ func (db *DB) UpdateRun(run *Run) error {
 // GetRun retrieves a run by ID
 func (db *DB) GetRun(id int64) (*Run, error) {
 	run := &Run{}
-	err := db.conn.QueryRow(
-		`SELECT id, plugin, params, start_time, end_time, exit_code, 
-		 success, error, stdout, stderr, created_at, updated_at
+	err := db.QueryRow(
+		`SELECT id, uuid, plugin, params, start_time, end_time, exit_code, 
+		 success, COALESCE(error, ''), COALESCE(stdout, ''), COALESCE(stderr, ''), COALESCE(environment, ''),
+		 COALESCE(machine, ''), COALESCE(model, ''), COALESCE(interrupted, FALSE), created_at, updated_at
 		 FROM runs WHERE id = ?`,
 		id,
 	).Scan(
-		&run.ID, &run.Plugin, &run.Params, &run.StartTime, &run.EndTime,
+		&run.ID, &run.UUID, &run.Plugin, &run.Params, &run.StartTime, &run.EndTime,
 		&run.ExitCode, &run.Success, &run.Error, &run.Stdout, &run.Stderr,
-		&run.CreatedAt, &run.UpdatedAt,
+		&run.Environment, &run.Machine, &run.Model, &run.Interrupted, &run.CreatedAt, &run.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("run not found")
@@ -199,13 +278,17 @@ func (db *DB) GetRun(id int64) (*Run, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get run: %w", err)
 	}
+	if run.Tags, err = db.RunTags(run.ID); err != nil {
+		return nil, err
+	}
 	return run, nil
 }
 
 // ListRuns retrieves runs based on filters
 func (db *DB) ListRuns(filter RunFilter) ([]*Run, error) {
-	query := `SELECT id, plugin, params, start_time, end_time, exit_code, 
-	          success, error, stdout, stderr, created_at, updated_at
+	query := `SELECT id, uuid, plugin, params, start_time, end_time, exit_code, 
+	          success, COALESCE(error, ''), COALESCE(stdout, ''), COALESCE(stderr, ''), COALESCE(environment, ''),
+	          COALESCE(machine, ''), COALESCE(model, ''), COALESCE(interrupted, FALSE), created_at, updated_at
 	          FROM runs WHERE 1=1`
 	args := []interface{}{}
 
@@ -229,6 +312,18 @@ func (db *DB) ListRuns(filter RunFilter) ([]*Run, error) {
 		args = append(args, filter.Success)
 	}
 
+	if len(filter.Tags) > 0 {
+		cond, condArgs := tagFilter(filter.Tags)
+		query += cond
+		args = append(args, condArgs...)
+	}
+
+	if filter.Search != "" {
+		cond, condArgs := searchFilter(filter.Search)
+		query += cond
+		args = append(args, condArgs...)
+	}
+
 	query += " ORDER BY start_time DESC"
 
 	if filter.Limit > 0 {
@@ -241,7 +336,7 @@ func (db *DB) ListRuns(filter RunFilter) ([]*Run, error) {
 		}
 	}
 
-	rows, err := db.conn.Query(query, args...)
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list runs: %w", err)
 	}
@@ -251,22 +346,48 @@ func (db *DB) ListRuns(filter RunFilter) ([]*Run, error) {
 	for rows.Next() {
 		run := &Run{}
 		err := rows.Scan(
-			&run.ID, &run.Plugin, &run.Params, &run.StartTime, &run.EndTime,
+			&run.ID, &run.UUID, &run.Plugin, &run.Params, &run.StartTime, &run.EndTime,
 			&run.ExitCode, &run.Success, &run.Error, &run.Stdout, &run.Stderr,
-			&run.CreatedAt, &run.UpdatedAt,
+			&run.Environment, &run.Machine, &run.Model, &run.Interrupted, &run.CreatedAt, &run.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan run: %w", err)
 		}
 		runs = append(runs, run)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list runs: %w", err)
+	}
+	_ = rows.Close()
 
+	if err := db.attachTags(runs); err != nil {
+		return nil, err
+	}
 	return runs, nil
 }
 
+// RunPlugins returns the names of the plugins that have runs, sorted
+func (db *DB) RunPlugins() ([]string, error) {
+	rows, err := db.Query(`SELECT DISTINCT plugin FROM runs ORDER BY plugin`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list plugins: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var plugins []string
+	for rows.Next() {
+		var plugin string
+		if err := rows.Scan(&plugin); err != nil {
+			return nil, fmt.Errorf("failed to scan plugin: %w", err)
+		}
+		plugins = append(plugins, plugin)
+	}
+	return plugins, rows.Err()
+}
+
 // CreateResult creates a new result record
 func (db *DB) CreateResult(runID int64, metric string, value float64, unit string) error {
-	_, err := db.conn.Exec(
+	_, err := db.Exec(
 		`INSERT INTO results (run_id, metric, value, unit) VALUES (?, ?, ?, ?)`,
 		runID, metric, value, unit,
 	)
@@ -278,40 +399,28 @@ func (db *DB) CreateResult(runID int64, metric string, value float64, unit strin
 
 // CreateResults creates multiple result records in a transaction
 func (db *DB) CreateResults(runID int64, metrics map[string]float64, units map[string]string) error {
-	tx, err := db.conn.Begin()
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer func() {
-		// Only rollback if we haven't committed
-		_ = tx.Rollback()
-	}()
-
-	stmt, err := tx.Prepare(
-		`INSERT INTO results (run_id, metric, value, unit) VALUES (?, ?, ?, ?)`,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to prepare statement: %w", err)
-	}
-	defer func() { _ = stmt.Close() }()
-
-	for metric, value := range metrics {
-		unit := units[metric]
-		if _, err := stmt.Exec(runID, metric, value, unit); err != nil {
-			return fmt.Errorf("failed to insert result %s: %w", metric, err)
+	return db.transaction(func(tx *sql.Tx) error {
+		stmt, err := tx.Prepare(rebind(db.driver,
+			`INSERT INTO results (run_id, metric, value, unit) VALUES (?, ?, ?, ?)`,
+		))
+		if err != nil {
+			return fmt.Errorf("failed to prepare statement: %w", err)
 		}
-	}
+		defer func() { _ = stmt.Close() }()
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
-	return nil
+		for metric, value := range metrics {
+			unit := units[metric]
+			if _, err := stmt.Exec(runID, metric, value, unit); err != nil {
+				return fmt.Errorf("failed to insert result %s: %w", metric, err)
+			}
+		}
+		return nil
+	})
 }
 
 // GetResults retrieves results for a run
 func (db *DB) GetResults(runID int64) ([]*Result, error) {
-	rows, err := db.conn.Query(
+	rows, err := db.Query(
 		`SELECT id, run_id, metric, value, unit, created_at
 		 FROM results WHERE run_id = ? ORDER BY metric`,
 		runID,
@@ -353,6 +462,8 @@ func (db *DB) ListResults(filter ResultFilter) ([]*Result, error) {
 		args = append(args, filter.Metric)
 	}
 
+	query, args = db.timeRange(query, args, filter.Since, filter.Until)
+
 	query += " ORDER BY created_at DESC"
 
 	if filter.Limit > 0 {
@@ -365,7 +476,7 @@ func (db *DB) ListResults(filter ResultFilter) ([]*Result, error) {
 		}
 	}
 
-	rows, err := db.conn.Query(query, args...)
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list results: %w", err)
 	}
@@ -0,0 +1,198 @@
+package db
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRebind(t *testing.T) {
+	query := "SELECT * FROM runs WHERE plugin = ? AND error = '?' AND id > ?"
+
+	if got := rebind(DriverSQLite, query); got != query {
+		t.Errorf("sqlite query should be unchanged, got %q", got)
+	}
+
+	want := "SELECT * FROM runs WHERE plugin = $1 AND error = '?' AND id > $2"
+	if got := rebind(DriverPostgres, query); got != want {
+		t.Errorf("postgres rebind: got %q want %q", got, want)
+	}
+
+	// The dollar-quoted trigger function must reach PostgreSQL as written
+	if got := rebind(DriverPostgres, postgresTimestampTriggers); got != postgresTimestampTriggers {
+		t.Errorf("postgres triggers should be unchanged, got %q", got)
+	}
+}
+
+func TestParseDriver(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Driver
+		wantErr bool
+	}{
+		{"", DriverSQLite, false},
+		{"sqlite3", DriverSQLite, false},
+		{"PostgreSQL", DriverPostgres, false},
+		{"mysql", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDriver(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDriver(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseDriver(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSQLiteRunRoundTrip(t *testing.T) {
+	database, err := Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	run, err := database.CreateRun("cpu", JSONData{"threads": 4})
+	if err != nil {
+		t.Fatalf("failed to create run: %v", err)
+	}
+
+	if err := database.CreateResults(run.ID, map[string]float64{"operations": 42}, map[string]string{"operations": "ops"}); err != nil {
+		t.Fatalf("failed to create results: %v", err)
+	}
+
+	results, err := database.GetResults(run.ID)
+	if err != nil {
+		t.Fatalf("failed to get results: %v", err)
+	}
+	if len(results) != 1 || results[0].Value != 42 {
+		t.Errorf("unexpected results: %+v", results)
+	}
+
+	if _, err := database.CreateRun("memory", nil); err != nil {
+		t.Fatalf("failed to create run: %v", err)
+	}
+	if plugins, err := database.RunPlugins(); err != nil || len(plugins) != 2 || plugins[0] != "cpu" || plugins[1] != "memory" {
+		t.Errorf("expected plugins [cpu memory], got %v (%v)", plugins, err)
+	}
+
+	if err := database.Flush(); err != nil {
+		t.Errorf("failed to flush database: %v", err)
+	}
+}
+
+func TestImportRunIsIdempotent(t *testing.T) {
+	dir := t.TempDir()
+	local, err := Open(filepath.Join(dir, "local.db"))
+	if err != nil {
+		t.Fatalf("failed to open local database: %v", err)
+	}
+	defer func() { _ = local.Close() }()
+
+	central, err := Open(filepath.Join(dir, "central.db"))
+	if err != nil {
+		t.Fatalf("failed to open central database: %v", err)
+	}
+	defer func() { _ = central.Close() }()
+
+	run, err := local.CreateRun("memory", JSONData{})
+	if err != nil {
+		t.Fatalf("failed to create run: %v", err)
+	}
+	now := time.Now()
+	run.EndTime = &now
+	run.Success = true
+	run.Environment = "Virtual machine (kvm)"
+	run.Machine, run.Model = "rig-01", "Dell Inc. PowerEdge R650"
+	if err := local.UpdateRun(run); err != nil {
+		t.Fatalf("failed to update run: %v", err)
+	}
+	if err := local.CreateResult(run.ID, "allocated_mb", 512, "MB"); err != nil {
+		t.Fatalf("failed to create result: %v", err)
+	}
+
+	pending, err := local.ListUnsyncedRuns(0)
+	if err != nil || len(pending) != 1 {
+		t.Fatalf("expected 1 unsynced run, got %d (err %v)", len(pending), err)
+	}
+	results, _ := local.GetResults(run.ID)
+
+	for i, wantImported := range []bool{true, false} {
+		_, imported, err := central.ImportRun(&RunRecord{Run: pending[0], Results: results})
+		if err != nil {
+			t.Fatalf("import %d failed: %v", i, err)
+		}
+		if imported != wantImported {
+			t.Errorf("import %d: imported = %v, want %v", i, imported, wantImported)
+		}
+	}
+
+	if err := local.MarkRunSynced(run.ID); err != nil {
+		t.Fatalf("failed to mark run synced: %v", err)
+	}
+	if pending, _ := local.ListUnsyncedRuns(0); len(pending) != 0 {
+		t.Errorf("expected no unsynced runs, got %d", len(pending))
+	}
+
+	remote, err := central.GetRunByUUID(run.UUID)
+	if err != nil {
+		t.Fatalf("run missing on central database: %v", err)
+	}
+	if remote.Environment != run.Environment {
+		t.Errorf("expected environment %q on central database, got %q", run.Environment, remote.Environment)
+	}
+	if remote.Machine != run.Machine || remote.Model != run.Model {
+		t.Errorf("expected machine %q (%q) on central database, got %q (%q)", run.Machine, run.Model, remote.Machine, remote.Model)
+	}
+	if got, _ := central.GetResults(remote.ID); len(got) != 1 {
+		t.Errorf("expected 1 result on central database, got %d", len(got))
+	}
+}
+
+func TestNewRunUUID(t *testing.T) {
+	earlier := newUUIDv7(time.UnixMilli(1_700_000_000_000))
+	later := newUUIDv7(time.UnixMilli(1_700_000_000_001))
+
+	for _, id := range []string{earlier, later, NewRunUUID()} {
+		if !IsUUID(id) {
+			t.Errorf("%q is not a canonical UUID", id)
+		}
+		if id[14] != '7' {
+			t.Errorf("%q is not a version 7 UUID", id)
+		}
+	}
+	if earlier >= later {
+		t.Errorf("UUIDs should sort by creation time: %s >= %s", earlier, later)
+	}
+}
+
+func TestResolveRun(t *testing.T) {
+	database, err := Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	run, err := database.CreateRun("cpu", JSONData{})
+	if err != nil {
+		t.Fatalf("failed to create run: %v", err)
+	}
+
+	for _, ref := range []string{strconv.FormatInt(run.ID, 10), run.UUID, strings.ToUpper(run.UUID)} {
+		got, err := database.ResolveRun(ref)
+		if err != nil {
+			t.Fatalf("ResolveRun(%q) failed: %v", ref, err)
+		}
+		if got.ID != run.ID {
+			t.Errorf("ResolveRun(%q) = run %d, want %d", ref, got.ID, run.ID)
+		}
+	}
+
+	if _, err := database.ResolveRun("not-a-run"); err == nil {
+		t.Error("expected error for invalid reference")
+	}
+}
@@ -0,0 +1,531 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	_ "github.com/lib/pq" // PostgreSQL driver
+)
+
+// Driver identifies a storage backend
+type Driver string
+
+// Driver constants define the supported storage backends.
+const (
+	DriverSQLite   Driver = "sqlite"
+	DriverPostgres Driver = "postgres"
+)
+
+// Config describes how to connect to a storage backend
+type Config struct {
+	Driver Driver `json:"driver"` // sqlite (default) or postgres
+	Path   string `json:"path"`   // SQLite database file
+	DSN    string `json:"dsn"`    // PostgreSQL connection string
+
+	// MigrateBackup backs up a SQLite database before a migration changes
+	// its schema; on unless set to false
+	MigrateBackup *bool `json:"migrate_backup,omitempty"`
+
+	// Retention limits how long results are kept
+	Retention Retention `json:"retention"`
+}
+
+// BackupBeforeMigrate reports whether a SQLite database is backed up before
+// pending migrations are applied
+func (c Config) BackupBeforeMigrate() bool {
+	return c.MigrateBackup == nil || *c.MigrateBackup
+}
+
+// Validate checks if the configuration is valid
+func (c Config) Validate() error {
+	switch c.Driver {
+	case "", DriverSQLite:
+		if c.Path == "" {
+			return fmt.Errorf("sqlite database path is required")
+		}
+	case DriverPostgres:
+		if c.DSN == "" {
+			return fmt.Errorf("postgres DSN is required")
+		}
+	default:
+		return fmt.Errorf("unsupported database driver: %s", c.Driver)
+	}
+	return nil
+}
+
+// ParseDriver converts a driver name into a Driver
+func ParseDriver(name string) (Driver, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "sqlite", "sqlite3":
+		return DriverSQLite, nil
+	case "postgres", "postgresql", "pg":
+		return DriverPostgres, nil
+	default:
+		return "", fmt.Errorf("unsupported database driver: %s", name)
+	}
+}
+
+// rebind converts '?' placeholders into the placeholder style of the driver.
+// Queries are written once in SQLite style and rewritten for PostgreSQL.
+func rebind(driver Driver, query string) string {
+	if driver != DriverPostgres {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n := 0
+	inQuote := false
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			inQuote = !inQuote
+			b.WriteRune(r)
+		case r == '?' && !inQuote:
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// column describes a column a migration adds to an existing table
+type column struct {
+	table      string
+	name       string
+	definition string
+}
+
+// ensureColumn adds a column to a table if it does not exist yet
+func (db *DB) ensureColumn(table, name, definition string) error {
+	if db.driver == DriverPostgres {
+		_, err := db.conn.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s", table, name, definition))
+		return err
+	}
+
+	rows, err := db.conn.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var (
+			cid       int
+			colName   string
+			colType   string
+			notNull   int
+			dfltValue interface{}
+			pk        int
+		)
+		if err := rows.Scan(&cid, &colName, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return err
+		}
+		if colName == name {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	_ = rows.Close()
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, name, definition))
+	return err
+}
+
+// schemaFor returns the base schema for a driver. Both schemas define the
+// same tables, columns and indexes so data can move between backends.
+func schemaFor(driver Driver) string {
+	if driver == DriverPostgres {
+		return postgresSchema
+	}
+	return sqliteSchema
+}
+
+const sqliteSchema = `
+	CREATE TABLE IF NOT EXISTS runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		plugin TEXT NOT NULL,
+		params TEXT,
+		start_time DATETIME NOT NULL,
+		end_time DATETIME,
+		exit_code INTEGER DEFAULT 0,
+		success BOOLEAN DEFAULT 0,
+		error TEXT,
+		stdout TEXT,
+		stderr TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS results (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		run_id INTEGER NOT NULL,
+		metric TEXT NOT NULL,
+		value REAL NOT NULL,
+		unit TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (run_id) REFERENCES runs(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS schedules (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		description TEXT,
+		cron_expr TEXT NOT NULL,
+		plugin TEXT NOT NULL,
+		params TEXT,
+		enabled BOOLEAN DEFAULT 1,
+		last_run_id INTEGER,
+		last_run_time DATETIME,
+		next_run_time DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (last_run_id) REFERENCES runs(id) ON DELETE SET NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS schedule_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		schedule_id INTEGER NOT NULL,
+		run_id INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (schedule_id) REFERENCES schedules(id) ON DELETE CASCADE,
+		FOREIGN KEY (run_id) REFERENCES runs(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS plan_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		file TEXT,
+		start_time DATETIME NOT NULL,
+		end_time DATETIME,
+		success BOOLEAN DEFAULT 0,
+		error TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS plan_stages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		plan_run_id INTEGER NOT NULL,
+		position INTEGER NOT NULL,
+		name TEXT,
+		kind TEXT NOT NULL,
+		status TEXT NOT NULL,
+		message TEXT,
+		start_time DATETIME,
+		end_time DATETIME,
+		peaks TEXT,
+		FOREIGN KEY (plan_run_id) REFERENCES plan_runs(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS plan_stage_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		stage_id INTEGER NOT NULL,
+		run_id INTEGER NOT NULL,
+		FOREIGN KEY (stage_id) REFERENCES plan_stages(id) ON DELETE CASCADE,
+		FOREIGN KEY (run_id) REFERENCES runs(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS annotations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		run_id INTEGER,
+		event_time DATETIME NOT NULL,
+		source TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		message TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (run_id) REFERENCES runs(id) ON DELETE SET NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS version_changes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		machine TEXT NOT NULL,
+		component TEXT NOT NULL,
+		old_version TEXT,
+		new_version TEXT NOT NULL,
+		detected_at DATETIME NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS smart_snapshots (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		device TEXT NOT NULL,
+		serial TEXT,
+		model TEXT,
+		taken_at DATETIME NOT NULL,
+		data TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Ring buffers of sensor readings, see HistoryTiers
+	CREATE TABLE IF NOT EXISTS sensor_history (
+		resolution INTEGER NOT NULL, -- Seconds per bucket
+		metric TEXT NOT NULL,
+		slot INTEGER NOT NULL,
+		bucket INTEGER NOT NULL, -- Unix time the bucket starts
+		value_min REAL NOT NULL,
+		value_max REAL NOT NULL,
+		value_sum REAL NOT NULL,
+		samples INTEGER NOT NULL,
+		PRIMARY KEY (resolution, metric, slot)
+	);
+
+	-- Alerts fired and resolved by the rules in pkg/alerts
+	CREATE TABLE IF NOT EXISTS alert_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_time DATETIME NOT NULL,
+		rule TEXT NOT NULL,
+		metric TEXT NOT NULL,
+		value REAL NOT NULL,
+		threshold REAL NOT NULL,
+		comparator TEXT NOT NULL,
+		state TEXT NOT NULL,
+		message TEXT,
+		source TEXT NOT NULL,
+		delivery_error TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Hardware inventories from pkg/inventory, each stored once and shared by
+	-- the runs tested on that hardware
+	CREATE TABLE IF NOT EXISTS inventory_snapshots (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		machine TEXT NOT NULL,
+		hash TEXT NOT NULL UNIQUE,
+		taken_at DATETIME NOT NULL,
+		data TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS run_inventory (
+		run_id INTEGER PRIMARY KEY,
+		snapshot_id INTEGER NOT NULL,
+		FOREIGN KEY (run_id) REFERENCES runs(id) ON DELETE CASCADE,
+		FOREIGN KEY (snapshot_id) REFERENCES inventory_snapshots(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_runs_plugin ON runs(plugin);
+	CREATE INDEX IF NOT EXISTS idx_runs_start_time ON runs(start_time);
+	CREATE INDEX IF NOT EXISTS idx_runs_success ON runs(success);
+	CREATE INDEX IF NOT EXISTS idx_results_run_id ON results(run_id);
+	CREATE INDEX IF NOT EXISTS idx_results_metric ON results(metric);
+	CREATE INDEX IF NOT EXISTS idx_schedules_enabled ON schedules(enabled);
+	CREATE INDEX IF NOT EXISTS idx_schedules_next_run ON schedules(next_run_time);
+	CREATE INDEX IF NOT EXISTS idx_schedule_runs_schedule ON schedule_runs(schedule_id);
+	CREATE INDEX IF NOT EXISTS idx_annotations_time ON annotations(event_time);
+	CREATE INDEX IF NOT EXISTS idx_plan_runs_start_time ON plan_runs(start_time);
+	CREATE INDEX IF NOT EXISTS idx_plan_stages_plan_run ON plan_stages(plan_run_id);
+	CREATE INDEX IF NOT EXISTS idx_plan_stage_runs_stage ON plan_stage_runs(stage_id);
+	CREATE INDEX IF NOT EXISTS idx_version_changes_machine ON version_changes(machine, component, detected_at);
+	CREATE INDEX IF NOT EXISTS idx_smart_snapshots_device ON smart_snapshots(device, serial, taken_at);
+	CREATE INDEX IF NOT EXISTS idx_alert_events_time ON alert_events(event_time);
+
+	-- Trigger to update updated_at timestamp
+	CREATE TRIGGER IF NOT EXISTS update_runs_timestamp
+	AFTER UPDATE ON runs
+	BEGIN
+		UPDATE runs SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS update_schedules_timestamp
+	AFTER UPDATE ON schedules
+	BEGIN
+		UPDATE schedules SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+	END;
+	`
+
+const postgresSchema = `
+	CREATE TABLE IF NOT EXISTS runs (
+		id BIGSERIAL PRIMARY KEY,
+		plugin TEXT NOT NULL,
+		params TEXT,
+		start_time TIMESTAMPTZ NOT NULL,
+		end_time TIMESTAMPTZ,
+		exit_code INTEGER DEFAULT 0,
+		success BOOLEAN DEFAULT FALSE,
+		error TEXT,
+		stdout TEXT,
+		stderr TEXT,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS results (
+		id BIGSERIAL PRIMARY KEY,
+		run_id BIGINT NOT NULL REFERENCES runs(id) ON DELETE CASCADE,
+		metric TEXT NOT NULL,
+		value DOUBLE PRECISION NOT NULL,
+		unit TEXT,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS schedules (
+		id BIGSERIAL PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE,
+		description TEXT,
+		cron_expr TEXT NOT NULL,
+		plugin TEXT NOT NULL,
+		params TEXT,
+		enabled BOOLEAN DEFAULT TRUE,
+		last_run_id BIGINT REFERENCES runs(id) ON DELETE SET NULL,
+		last_run_time TIMESTAMPTZ,
+		next_run_time TIMESTAMPTZ,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS schedule_runs (
+		id BIGSERIAL PRIMARY KEY,
+		schedule_id BIGINT NOT NULL REFERENCES schedules(id) ON DELETE CASCADE,
+		run_id BIGINT NOT NULL REFERENCES runs(id) ON DELETE CASCADE,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS plan_runs (
+		id BIGSERIAL PRIMARY KEY,
+		name TEXT NOT NULL,
+		file TEXT,
+		start_time TIMESTAMPTZ NOT NULL,
+		end_time TIMESTAMPTZ,
+		success BOOLEAN DEFAULT FALSE,
+		error TEXT,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS plan_stages (
+		id BIGSERIAL PRIMARY KEY,
+		plan_run_id BIGINT NOT NULL REFERENCES plan_runs(id) ON DELETE CASCADE,
+		position INTEGER NOT NULL,
+		name TEXT,
+		kind TEXT NOT NULL,
+		status TEXT NOT NULL,
+		message TEXT,
+		start_time TIMESTAMPTZ,
+		end_time TIMESTAMPTZ,
+		peaks TEXT
+	);
+
+	CREATE TABLE IF NOT EXISTS plan_stage_runs (
+		id BIGSERIAL PRIMARY KEY,
+		stage_id BIGINT NOT NULL REFERENCES plan_stages(id) ON DELETE CASCADE,
+		run_id BIGINT NOT NULL REFERENCES runs(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS annotations (
+		id BIGSERIAL PRIMARY KEY,
+		run_id BIGINT REFERENCES runs(id) ON DELETE SET NULL,
+		event_time TIMESTAMPTZ NOT NULL,
+		source TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		message TEXT,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS version_changes (
+		id BIGSERIAL PRIMARY KEY,
+		machine TEXT NOT NULL,
+		component TEXT NOT NULL,
+		old_version TEXT,
+		new_version TEXT NOT NULL,
+		detected_at TIMESTAMPTZ NOT NULL,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS smart_snapshots (
+		id BIGSERIAL PRIMARY KEY,
+		device TEXT NOT NULL,
+		serial TEXT,
+		model TEXT,
+		taken_at TIMESTAMPTZ NOT NULL,
+		data TEXT NOT NULL,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Ring buffers of sensor readings, see HistoryTiers
+	CREATE TABLE IF NOT EXISTS sensor_history (
+		resolution INTEGER NOT NULL, -- Seconds per bucket
+		metric TEXT NOT NULL,
+		slot INTEGER NOT NULL,
+		bucket BIGINT NOT NULL, -- Unix time the bucket starts
+		value_min DOUBLE PRECISION NOT NULL,
+		value_max DOUBLE PRECISION NOT NULL,
+		value_sum DOUBLE PRECISION NOT NULL,
+		samples INTEGER NOT NULL,
+		PRIMARY KEY (resolution, metric, slot)
+	);
+
+	-- Alerts fired and resolved by the rules in pkg/alerts
+	CREATE TABLE IF NOT EXISTS alert_events (
+		id BIGSERIAL PRIMARY KEY,
+		event_time TIMESTAMPTZ NOT NULL,
+		rule TEXT NOT NULL,
+		metric TEXT NOT NULL,
+		value DOUBLE PRECISION NOT NULL,
+		threshold DOUBLE PRECISION NOT NULL,
+		comparator TEXT NOT NULL,
+		state TEXT NOT NULL,
+		message TEXT,
+		source TEXT NOT NULL,
+		delivery_error TEXT,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Hardware inventories from pkg/inventory, each stored once and shared by
+	-- the runs tested on that hardware
+	CREATE TABLE IF NOT EXISTS inventory_snapshots (
+		id BIGSERIAL PRIMARY KEY,
+		machine TEXT NOT NULL,
+		hash TEXT NOT NULL UNIQUE,
+		taken_at TIMESTAMPTZ NOT NULL,
+		data TEXT NOT NULL,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS run_inventory (
+		run_id BIGINT PRIMARY KEY REFERENCES runs(id) ON DELETE CASCADE,
+		snapshot_id BIGINT NOT NULL REFERENCES inventory_snapshots(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_runs_plugin ON runs(plugin);
+	CREATE INDEX IF NOT EXISTS idx_runs_start_time ON runs(start_time);
+	CREATE INDEX IF NOT EXISTS idx_runs_success ON runs(success);
+	CREATE INDEX IF NOT EXISTS idx_results_run_id ON results(run_id);
+	CREATE INDEX IF NOT EXISTS idx_results_metric ON results(metric);
+	CREATE INDEX IF NOT EXISTS idx_schedules_enabled ON schedules(enabled);
+	CREATE INDEX IF NOT EXISTS idx_schedules_next_run ON schedules(next_run_time);
+	CREATE INDEX IF NOT EXISTS idx_schedule_runs_schedule ON schedule_runs(schedule_id);
+	CREATE INDEX IF NOT EXISTS idx_annotations_time ON annotations(event_time);
+	CREATE INDEX IF NOT EXISTS idx_plan_runs_start_time ON plan_runs(start_time);
+	CREATE INDEX IF NOT EXISTS idx_plan_stages_plan_run ON plan_stages(plan_run_id);
+	CREATE INDEX IF NOT EXISTS idx_plan_stage_runs_stage ON plan_stage_runs(stage_id);
+	CREATE INDEX IF NOT EXISTS idx_version_changes_machine ON version_changes(machine, component, detected_at);
+	CREATE INDEX IF NOT EXISTS idx_smart_snapshots_device ON smart_snapshots(device, serial, taken_at);
+	CREATE INDEX IF NOT EXISTS idx_alert_events_time ON alert_events(event_time);
+	`
+
+// postgresTimestampTriggers keep updated_at current on PostgreSQL, as the
+// triggers of the SQLite schema do. The column is set before the row is
+// written, so the update does not fire the trigger again.
+const postgresTimestampTriggers = `
+	CREATE OR REPLACE FUNCTION set_updated_at() RETURNS TRIGGER AS $$
+	BEGIN
+		NEW.updated_at = CURRENT_TIMESTAMP;
+		RETURN NEW;
+	END;
+	$$ LANGUAGE plpgsql;
+
+	DROP TRIGGER IF EXISTS update_runs_timestamp ON runs;
+	CREATE TRIGGER update_runs_timestamp
+	BEFORE UPDATE ON runs
+	FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+	DROP TRIGGER IF EXISTS update_schedules_timestamp ON schedules;
+	CREATE TRIGGER update_schedules_timestamp
+	BEFORE UPDATE ON schedules
+	FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+	`
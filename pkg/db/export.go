@@ -28,7 +28,7 @@ func (db *DB) ExportCSV(w io.Writer, runID int64) error {
 
 	// Write headers
 	headers := []string{
-		"Run ID", "Plugin", "Start Time", "End Time", "Duration (s)",
+		"Run ID", "Run UUID", "Plugin", "Start Time", "End Time", "Duration (s)",
 		"Success", "Exit Code", "Metric", "Value", "Unit",
 	}
 	if err := csvWriter.Write(headers); err != nil {
@@ -45,6 +45,7 @@ func (db *DB) ExportCSV(w io.Writer, runID int64) error {
 	for _, result := range results {
 		row := []string{
 			strconv.FormatInt(run.ID, 10),
+			run.UUID,
 			run.Plugin,
 			run.StartTime.Format("2006-01-02 15:04:05"),
 			"",
@@ -57,7 +58,7 @@ func (db *DB) ExportCSV(w io.Writer, runID int64) error {
 		}
 
 		if run.EndTime != nil {
-			row[3] = run.EndTime.Format("2006-01-02 15:04:05")
+			row[4] = run.EndTime.Format("2006-01-02 15:04:05")
 		}
 
 		if err := csvWriter.Write(row); err != nil {
@@ -115,7 +116,7 @@ func (db *DB) ExportAllCSV(w io.Writer) error {
 
 	// Write headers
 	headers := []string{
-		"Run ID", "Plugin", "Start Time", "End Time", "Duration (s)",
+		"Run ID", "Run UUID", "Plugin", "Start Time", "End Time", "Duration (s)",
 		"Success", "Exit Code", "Metric", "Value", "Unit",
 	}
 	if err := csvWriter.Write(headers); err != nil {
@@ -139,6 +140,7 @@ func (db *DB) ExportAllCSV(w io.Writer) error {
 		for _, result := range results {
 			row := []string{
 				strconv.FormatInt(run.ID, 10),
+				run.UUID,
 				run.Plugin,
 				run.StartTime.Format("2006-01-02 15:04:05"),
 				"",
@@ -151,7 +153,7 @@ func (db *DB) ExportAllCSV(w io.Writer) error {
 			}
 
 			if run.EndTime != nil {
-				row[3] = run.EndTime.Format("2006-01-02 15:04:05")
+				row[4] = run.EndTime.Format("2006-01-02 15:04:05")
 			}
 
 			if err := csvWriter.Write(row); err != nil {
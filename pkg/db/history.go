@@ -0,0 +1,177 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// HistoryTier is one ring buffer of sensor history. Each metric gets Slots
+// buckets of Resolution; a new bucket takes the slot of the one Slots
+// buckets before it, so the table never grows beyond a fixed size.
+type HistoryTier struct {
+	Resolution time.Duration
+	Slots      int
+}
+
+// Retention is how far back the tier reaches
+func (t HistoryTier) Retention() time.Duration {
+	return t.Resolution * time.Duration(t.Slots)
+}
+
+// HistoryTiers are the ring buffers every sensor sample is added to, finest
+// first: 10 seconds for an hour, 2 minutes for a day and 15 minutes for a
+// week
+var HistoryTiers = []HistoryTier{
+	{Resolution: 10 * time.Second, Slots: 360},
+	{Resolution: 2 * time.Minute, Slots: 720},
+	{Resolution: 15 * time.Minute, Slots: 672},
+}
+
+// HistoryTierFor returns the finest tier that reaches back span, or the
+// coarsest tier for longer spans
+func HistoryTierFor(span time.Duration) HistoryTier {
+	for _, t := range HistoryTiers {
+		if t.Retention() >= span {
+			return t
+		}
+	}
+	return HistoryTiers[len(HistoryTiers)-1]
+}
+
+// SensorSample is one reading of a sensor, e.g. "CPU Temp (°C)"
+type SensorSample struct {
+	Time   time.Time
+	Metric string
+	Value  float64
+}
+
+// RecordSensorSamples adds samples to every history tier. A sample in the
+// same bucket as the slot's current one is merged into it; one in a later
+// bucket replaces it.
+func (db *DB) RecordSensorSamples(samples []SensorSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	return db.transaction(func(tx *sql.Tx) error {
+		stmt, err := tx.Prepare(rebind(db.driver,
+			`INSERT INTO sensor_history (resolution, metric, slot, bucket, value_min, value_max, value_sum, samples)
+			VALUES (?, ?, ?, ?, ?, ?, ?, 1)
+			ON CONFLICT (resolution, metric, slot) DO UPDATE SET
+				value_min = CASE WHEN sensor_history.bucket <> excluded.bucket OR excluded.value_min < sensor_history.value_min
+					THEN excluded.value_min ELSE sensor_history.value_min END,
+				value_max = CASE WHEN sensor_history.bucket <> excluded.bucket OR excluded.value_max > sensor_history.value_max
+					THEN excluded.value_max ELSE sensor_history.value_max END,
+				value_sum = CASE WHEN sensor_history.bucket = excluded.bucket
+					THEN sensor_history.value_sum + excluded.value_sum ELSE excluded.value_sum END,
+				samples = CASE WHEN sensor_history.bucket = excluded.bucket
+					THEN sensor_history.samples + 1 ELSE 1 END,
+				bucket = excluded.bucket
+			WHERE excluded.bucket >= sensor_history.bucket`,
+		))
+		if err != nil {
+			return fmt.Errorf("failed to prepare statement: %w", err)
+		}
+		defer func() { _ = stmt.Close() }()
+
+		for _, s := range samples {
+			for _, tier := range HistoryTiers {
+				resolution := int64(tier.Resolution / time.Second)
+				index := s.Time.Unix() / resolution
+				slot := index % int64(tier.Slots)
+				if _, err := stmt.Exec(resolution, s.Metric, slot, index*resolution, s.Value, s.Value, s.Value); err != nil {
+					return fmt.Errorf("failed to record %s: %w", s.Metric, err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// SensorHistory returns the buckets of a metric between since and until,
+// oldest first, from the finest tier that covers the span. The finer tiers
+// only reach back from the latest sample, so spans should end about now.
+func (db *DB) SensorHistory(metric string, since, until time.Time) (HistoryTier, []Bucket, error) {
+	tier := HistoryTierFor(until.Sub(since))
+	buckets, err := db.SensorHistoryTier(tier, metric, since, until)
+	return tier, buckets, err
+}
+
+// SensorHistoryTier returns the buckets of a metric between since and until
+// from one tier, oldest first
+func (db *DB) SensorHistoryTier(tier HistoryTier, metric string, since, until time.Time) ([]Bucket, error) {
+	rows, err := db.Query(
+		`SELECT bucket, samples, value_sum, value_min, value_max FROM sensor_history
+		WHERE resolution = ? AND metric = ? AND bucket >= ? AND bucket <= ?
+		ORDER BY bucket`,
+		int64(tier.Resolution/time.Second), metric, since.Unix()-int64(tier.Resolution/time.Second), until.Unix(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sensor history: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var buckets []Bucket
+	for rows.Next() {
+		var (
+			start int64
+			sum   float64
+			b     Bucket
+		)
+		if err := rows.Scan(&start, &b.Count, &sum, &b.Min, &b.Max); err != nil {
+			return nil, fmt.Errorf("failed to scan sensor history: %w", err)
+		}
+		b.Start = time.Unix(start, 0).UTC()
+		if b.Count > 0 {
+			b.Avg = sum / float64(b.Count)
+		}
+		buckets = append(buckets, b)
+	}
+	return buckets, rows.Err()
+}
+
+// SensorHistoryMetrics lists the metrics with recorded history
+func (db *DB) SensorHistoryMetrics() ([]string, error) {
+	rows, err := db.Query(`SELECT DISTINCT metric FROM sensor_history ORDER BY metric`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sensor history metrics: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var metrics []string
+	for rows.Next() {
+		var metric string
+		if err := rows.Scan(&metric); err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics, rows.Err()
+}
+
+// WriteSensorHistoryCSV writes the buckets of a metric as CSV, one row per
+// bucket with its start time in UTC
+func WriteSensorHistoryCSV(w io.Writer, metric string, buckets []Bucket) error {
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write([]string{"Time", "Metric", "Samples", "Average", "Min", "Max"}); err != nil {
+		return fmt.Errorf("failed to write headers: %w", err)
+	}
+	for _, b := range buckets {
+		row := []string{
+			b.Start.UTC().Format(time.RFC3339),
+			metric,
+			strconv.Itoa(b.Count),
+			fmt.Sprintf("%.6f", b.Avg),
+			fmt.Sprintf("%.6f", b.Min),
+			fmt.Sprintf("%.6f", b.Max),
+		}
+		if err := csvWriter.Write(row); err != nil {
+			return fmt.Errorf("failed to write row: %w", err)
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSensorHistory(t *testing.T) {
+	database, err := Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = database.Close() }()
+
+	now := time.Now().Truncate(10 * time.Second)
+	var samples []SensorSample
+	for i, v := range []float64{60, 70, 80} {
+		samples = append(samples, SensorSample{Time: now.Add(-30*time.Second + time.Duration(i)*time.Second), Metric: "CPU Temp (°C)", Value: v})
+	}
+	samples = append(samples, SensorSample{Time: now.Add(-10 * time.Second), Metric: "CPU Temp (°C)", Value: 90})
+	if err := database.RecordSensorSamples(samples); err != nil {
+		t.Fatal(err)
+	}
+
+	tier, buckets, err := database.SensorHistory("CPU Temp (°C)", now.Add(-time.Hour), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tier.Resolution != 10*time.Second || len(buckets) != 2 {
+		t.Fatalf("expected 2 buckets of 10s, got %v and %+v", tier.Resolution, buckets)
+	}
+	if b := buckets[0]; b.Count != 3 || b.Avg != 70 || b.Min != 60 || b.Max != 80 {
+		t.Errorf("expected the first three samples merged, got %+v", b)
+	}
+
+	// A sample a full ring later takes over the slot
+	later := samples[0]
+	later.Time = later.Time.Add(HistoryTiers[0].Retention())
+	later.Value = 50
+	if err := database.RecordSensorSamples([]SensorSample{later}); err != nil {
+		t.Fatal(err)
+	}
+	_, buckets, err = database.SensorHistory("CPU Temp (°C)", now.Add(-time.Hour), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buckets) != 1 || buckets[0].Avg != 90 {
+		t.Errorf("expected the overwritten bucket to be gone, got %+v", buckets)
+	}
+
+	// The day tier still holds all four samples in one bucket or two
+	_, buckets, err = database.SensorHistory("CPU Temp (°C)", now.Add(-24*time.Hour), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for _, b := range buckets {
+		count += b.Count
+	}
+	if count != 4 {
+		t.Errorf("expected 4 samples in the day tier, got %+v", buckets)
+	}
+
+	metrics, err := database.SensorHistoryMetrics()
+	if err != nil || len(metrics) != 1 || metrics[0] != "CPU Temp (°C)" {
+		t.Errorf("unexpected metrics %v (%v)", metrics, err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSensorHistoryCSV(&buf, "CPU Temp (°C)", buckets); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != len(buckets)+1 || !strings.HasPrefix(lines[0], "Time,Metric") {
+		t.Errorf("unexpected CSV %q", buf.String())
+	}
+}
+
+func TestHistoryTierFor(t *testing.T) {
+	for span, want := range map[time.Duration]time.Duration{
+		time.Hour:           10 * time.Second,
+		24 * time.Hour:      2 * time.Minute,
+		7 * 24 * time.Hour:  15 * time.Minute,
+		30 * 24 * time.Hour: 15 * time.Minute,
+	} {
+		if got := HistoryTierFor(span).Resolution; got != want {
+			t.Errorf("%v: expected %v, got %v", span, want, got)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// InventorySnapshot is the hardware inventory of a machine, stored once for
+// every run tested on the same hardware
+type InventorySnapshot struct {
+	ID      int64     `json:"id"`
+	Machine string    `json:"machine"`
+	Hash    string    `json:"hash"` // Identifies the hardware, see SaveInventorySnapshot
+	TakenAt time.Time `json:"taken_at"`
+	Data    string    `json:"data"` // The inventory as JSON
+}
+
+const inventorySnapshotColumns = `s.id, s.machine, s.hash, s.taken_at, s.data`
+
+// SaveInventorySnapshot stores a snapshot and sets its ID. A snapshot with
+// the same hash is stored only once, so its ID and time are those of the
+// first snapshot taken of that hardware.
+func (db *DB) SaveInventorySnapshot(s *InventorySnapshot) error {
+	existing, err := db.queryInventorySnapshot(`SELECT `+inventorySnapshotColumns+` FROM inventory_snapshots s WHERE s.hash = ?`, s.Hash)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		*s = *existing
+		return nil
+	}
+
+	id, err := db.Insert(
+		`INSERT INTO inventory_snapshots (machine, hash, taken_at, data) VALUES (?, ?, ?, ?)`,
+		s.Machine, s.Hash, s.TakenAt.UTC(), s.Data,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create inventory snapshot: %w", err)
+	}
+	s.ID = id
+	return nil
+}
+
+// SetRunInventory records the inventory snapshot of the hardware a run was
+// tested on
+func (db *DB) SetRunInventory(runID, snapshotID int64) error {
+	_, err := db.Exec(
+		`INSERT INTO run_inventory (run_id, snapshot_id) VALUES (?, ?)
+		ON CONFLICT (run_id) DO UPDATE SET snapshot_id = excluded.snapshot_id`,
+		runID, snapshotID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set run inventory: %w", err)
+	}
+	return nil
+}
+
+// RunInventory returns the inventory snapshot recorded for a run, or nil
+// when the run has none
+func (db *DB) RunInventory(runID int64) (*InventorySnapshot, error) {
+	return db.queryInventorySnapshot(`SELECT `+inventorySnapshotColumns+` FROM inventory_snapshots s
+		JOIN run_inventory r ON r.snapshot_id = s.id WHERE r.run_id = ?`, runID)
+}
+
+func (db *DB) queryInventorySnapshot(query string, args ...interface{}) (*InventorySnapshot, error) {
+	s := &InventorySnapshot{}
+	err := db.QueryRow(query, args...).Scan(&s.ID, &s.Machine, &s.Hash, &s.TakenAt, &s.Data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inventory snapshot: %w", err)
+	}
+	return s, nil
+}
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestInventorySnapshots(t *testing.T) {
+	database, err := Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = database.Close() }()
+
+	first, err := database.CreateRun("cpu", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := database.CreateRun("memory", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, err := database.RunInventory(first.ID); err != nil || s != nil {
+		t.Fatalf("expected no inventory before one is set, got %+v, %v", s, err)
+	}
+
+	taken := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	s := &InventorySnapshot{Machine: "bench1", Hash: "abc", TakenAt: taken, Data: `{"components":[]}`}
+	if err := database.SaveInventorySnapshot(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.SetRunInventory(first.ID, s.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// The same hardware is stored once
+	again := &InventorySnapshot{Machine: "bench1", Hash: "abc", TakenAt: taken.Add(time.Hour), Data: `{"components":[]}`}
+	if err := database.SaveInventorySnapshot(again); err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != s.ID || !again.TakenAt.Equal(taken) {
+		t.Errorf("expected the first snapshot back, got %+v", again)
+	}
+	if err := database.SetRunInventory(second.ID, again.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := database.RunInventory(second.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.ID != s.ID || got.Machine != "bench1" || got.Data != s.Data {
+		t.Errorf("unexpected run inventory %+v", got)
+	}
+}
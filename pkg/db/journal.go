@@ -0,0 +1,81 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// OpenRun is a run or plan run that has not ended, with the process that
+// journaled it
+type OpenRun struct {
+	ID        int64
+	Name      string // The plugin of a run, the name of a plan run
+	PID       int
+	StartTime time.Time
+	Heartbeat *time.Time // Last time its process recorded it alive
+}
+
+// LastSeen returns the last time the run was known to be running
+func (r *OpenRun) LastSeen() time.Time {
+	if r.Heartbeat != nil && r.Heartbeat.After(r.StartTime) {
+		return *r.Heartbeat
+	}
+	return r.StartTime
+}
+
+// Hostname returns the name runs are journaled under, "localhost" if the
+// machine's name cannot be read
+func Hostname() string {
+	name, err := os.Hostname()
+	if err != nil || name == "" {
+		return "localhost"
+	}
+	return name
+}
+
+// Heartbeat records that a run is still in progress
+func (db *DB) Heartbeat(runID int64, at time.Time) error {
+	if _, err := db.Exec(`UPDATE runs SET heartbeat_at = ? WHERE id = ?`, at, runID); err != nil {
+		return fmt.Errorf("failed to record heartbeat: %w", err)
+	}
+	return nil
+}
+
+// OpenRuns returns the journaled runs of a machine that have not ended,
+// oldest first
+func (db *DB) OpenRuns(machine string) ([]*OpenRun, error) {
+	rows, err := db.Query(
+		`SELECT id, plugin, pid, start_time, heartbeat_at FROM runs
+		 WHERE end_time IS NULL AND pid IS NOT NULL AND machine = ?
+		 ORDER BY start_time`,
+		machine,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open runs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var runs []*OpenRun
+	for rows.Next() {
+		run := &OpenRun{}
+		if err := rows.Scan(&run.ID, &run.Name, &run.PID, &run.StartTime, &run.Heartbeat); err != nil {
+			return nil, fmt.Errorf("failed to scan open run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// MarkInterrupted ends a run that was cut short at the given time
+func (db *DB) MarkInterrupted(runID int64, at time.Time, message string) error {
+	_, err := db.Exec(
+		`UPDATE runs SET end_time = ?, exit_code = 1, success = ?, error = ?, interrupted = ?
+		 WHERE id = ? AND end_time IS NULL`,
+		at, false, message, true, runID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark run interrupted: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Maintenance schedule for SQLite databases
+const (
+	MaintenanceInterval    = time.Hour          // How often RunMaintenance checks what is due
+	IntegrityCheckInterval = 24 * time.Hour     // How often the integrity check runs
+	SnapshotInterval       = 7 * 24 * time.Hour // Age of the newest weekly snapshot before another is taken
+)
+
+// Backups kept by rotation; manual and pre-restore backups are never pruned
+const (
+	KeepWeekly     = 8
+	KeepPreMigrate = 5
+)
+
+// MaintenanceResult describes one maintenance pass
+type MaintenanceResult struct {
+	Checked  bool     // The integrity check ran
+	Problems []string // Problems reported by the integrity check
+	Snapshot *BackupFile
+}
+
+// Maintain runs the integrity check if lastCheck is older than
+// IntegrityCheckInterval and takes a weekly snapshot if the newest one is
+// older than SnapshotInterval. No snapshot is taken of a database that fails
+// its check, so rotation never replaces good snapshots with damaged ones.
+func (db *DB) Maintain(now, lastCheck time.Time) (MaintenanceResult, error) {
+	var result MaintenanceResult
+	if db.driver != DriverSQLite {
+		return result, ErrBackupUnsupported
+	}
+
+	if now.Sub(lastCheck) >= IntegrityCheckInterval {
+		problems, err := db.IntegrityCheck()
+		if err != nil {
+			return result, err
+		}
+		result.Checked = true
+		result.Problems = problems
+		if len(problems) > 0 {
+			return result, nil
+		}
+	}
+
+	dir := BackupDir(db.path)
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return result, err
+	}
+	for _, b := range backups {
+		if b.Reason == BackupWeekly && now.Sub(b.Time) < SnapshotInterval {
+			return result, nil
+		}
+	}
+
+	snapshot, err := db.Backup(BackupWeekly)
+	if err != nil {
+		return result, err
+	}
+	result.Snapshot = &snapshot
+	return result, PruneBackups(dir, BackupWeekly, KeepWeekly)
+}
+
+// RunMaintenance runs Maintain immediately and then every MaintenanceInterval
+// until ctx is canceled. Problems are logged; a damaged database is reported
+// on every check until it is restored.
+func (db *DB) RunMaintenance(ctx context.Context, logger *log.Logger) {
+	if logger == nil {
+		logger = log.Default()
+	}
+	if db.driver != DriverSQLite {
+		return
+	}
+
+	ticker := time.NewTicker(MaintenanceInterval)
+	defer ticker.Stop()
+
+	var lastCheck time.Time
+	for {
+		now := time.Now()
+		result, err := db.Maintain(now, lastCheck)
+		switch {
+		case err != nil:
+			logger.Printf("Database maintenance failed: %v", err)
+		case len(result.Problems) > 0:
+			logger.Printf("DATABASE INTEGRITY CHECK FAILED (%d problems, first: %s); restore a backup with 'bench db restore'",
+				len(result.Problems), result.Problems[0])
+		}
+		if result.Checked {
+			lastCheck = now
+		}
+		if result.Snapshot != nil {
+			logger.Printf("Saved weekly database snapshot %s", result.Snapshot.Path)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
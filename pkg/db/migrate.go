@@ -0,0 +1,271 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// Migration is a numbered change to the schema. Migrations are applied in
+// order, once each, and recorded in the schema_migrations table.
+type Migration struct {
+	Version     int
+	Description string
+	up          func(db *DB) error
+}
+
+// MigrationStatus is a migration with when it was applied, nil while it is
+// pending
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// migrations change the schema, oldest first. A schema change is a new
+// migration at the end; versions are never renumbered. Every migration must
+// be safe to apply to a schema that already has it, since databases created
+// before schema_migrations existed have no record of what they contain.
+var migrations = []Migration{
+	{1, "Base schema", func(db *DB) error {
+		_, err := db.Exec(schemaFor(db.driver))
+		return err
+	}},
+	{2, "Identify runs by UUID", func(db *DB) error {
+		if err := db.ensureColumn("runs", "uuid", "TEXT"); err != nil {
+			return err
+		}
+		// Give runs created before UUIDs existed an identity
+		if err := db.backfillRunUUIDs(); err != nil {
+			return fmt.Errorf("failed to backfill run UUIDs: %w", err)
+		}
+		_, err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_runs_uuid ON runs(uuid)`)
+		return err
+	}},
+	{3, "Record when runs were synced", addColumns(
+		column{"runs", "synced_at", "TIMESTAMP"},
+	)},
+	{4, "Record the environment of runs", addColumns(
+		column{"runs", "environment", "TEXT"},
+	)},
+	{5, "Record the machine and model of runs", addColumns(
+		column{"runs", "machine", "TEXT"},
+		column{"runs", "model", "TEXT"},
+	)},
+	{6, "Notify recipients of schedules", addColumns(
+		column{"schedules", "notify", "TEXT"},
+	)},
+	{7, "Journal runs in progress", addColumns(
+		column{"runs", "pid", "INTEGER"},
+		column{"runs", "heartbeat_at", "TIMESTAMP"},
+		column{"runs", "interrupted", "BOOLEAN DEFAULT FALSE"},
+		column{"plan_runs", "machine", "TEXT"},
+		column{"plan_runs", "pid", "INTEGER"},
+		column{"plan_runs", "heartbeat_at", "TIMESTAMP"},
+		column{"plan_runs", "interrupted", "BOOLEAN DEFAULT FALSE"},
+		column{"plan_runs", "resume", "BOOLEAN DEFAULT FALSE"},
+		column{"schedules", "resume", "BOOLEAN DEFAULT FALSE"},
+	)},
+	{8, "Count the samples of downsampled results", addColumns(
+		column{"results", "samples", "INTEGER"},
+	)},
+	{9, "Tag runs and add notes to them", createTables(`
+		CREATE TABLE IF NOT EXISTS run_tags (
+			run_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			value TEXT,
+			PRIMARY KEY (run_id, name)
+		);
+		CREATE INDEX IF NOT EXISTS idx_run_tags_name ON run_tags(name, value);
+		CREATE TABLE IF NOT EXISTS run_notes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			run_id INTEGER NOT NULL,
+			text TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_run_notes_run_id ON run_notes(run_id)`, `
+		CREATE TABLE IF NOT EXISTS run_tags (
+			run_id BIGINT NOT NULL REFERENCES runs(id) ON DELETE CASCADE,
+			name TEXT NOT NULL,
+			value TEXT,
+			PRIMARY KEY (run_id, name)
+		);
+		CREATE INDEX IF NOT EXISTS idx_run_tags_name ON run_tags(name, value);
+		CREATE TABLE IF NOT EXISTS run_notes (
+			id BIGSERIAL PRIMARY KEY,
+			run_id BIGINT NOT NULL REFERENCES runs(id) ON DELETE CASCADE,
+			text TEXT NOT NULL,
+			created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_run_notes_run_id ON run_notes(run_id)`,
+	)},
+	{10, "Record the running schedulers", createTables(`
+		CREATE TABLE IF NOT EXISTS schedulers (
+			machine TEXT PRIMARY KEY,
+			pid INTEGER NOT NULL,
+			started_at DATETIME NOT NULL,
+			heartbeat_at DATETIME NOT NULL
+		)`, `
+		CREATE TABLE IF NOT EXISTS schedulers (
+			machine TEXT PRIMARY KEY,
+			pid INTEGER NOT NULL,
+			started_at TIMESTAMPTZ NOT NULL,
+			heartbeat_at TIMESTAMPTZ NOT NULL
+		)`,
+	)},
+	{11, "Keep updated_at current on PostgreSQL", func(db *DB) error {
+		// SQLite databases have the triggers of the base schema
+		if db.driver != DriverPostgres {
+			return nil
+		}
+		_, err := db.Exec(postgresTimestampTriggers)
+		return err
+	}},
+}
+
+// SchemaVersion returns the version of the newest migration
+func SchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// addColumns returns a migration adding columns that do not exist yet
+func addColumns(columns ...column) func(db *DB) error {
+	return func(db *DB) error {
+		for _, col := range columns {
+			if err := db.ensureColumn(col.table, col.name, col.definition); err != nil {
+				return fmt.Errorf("failed to add column %s.%s: %w", col.table, col.name, err)
+			}
+		}
+		return nil
+	}
+}
+
+// createTables returns a migration creating tables that do not exist yet,
+// with the statements of each driver
+func createTables(sqlite, postgres string) func(db *DB) error {
+	return func(db *DB) error {
+		ddl := sqlite
+		if db.driver == DriverPostgres {
+			ddl = postgres
+		}
+		_, err := db.Exec(ddl)
+		return err
+	}
+}
+
+// migrationsTable records the applied migrations
+const migrationsTable = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		description TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`
+
+// hasMigrationsTable reports whether the database records its migrations
+func (db *DB) hasMigrationsTable() (bool, error) {
+	query := `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`
+	if db.driver == DriverPostgres {
+		query = `SELECT COUNT(*) FROM information_schema.tables
+		 WHERE table_schema = current_schema() AND table_name = 'schema_migrations'`
+	}
+	var n int
+	if err := db.conn.QueryRow(query).Scan(&n); err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// Migrations returns every migration with when it was applied. It does not
+// change the database.
+func (db *DB) Migrations() ([]MigrationStatus, error) {
+	applied := make(map[int]time.Time)
+	ok, err := db.hasMigrationsTable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	if ok {
+		rows, err := db.Query(`SELECT version, applied_at FROM schema_migrations`)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+		}
+		defer func() { _ = rows.Close() }()
+		for rows.Next() {
+			var (
+				version int
+				at      time.Time
+			)
+			if err := rows.Scan(&version, &at); err != nil {
+				return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+			}
+			applied[version] = at
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	status := make([]MigrationStatus, len(migrations))
+	for i, m := range migrations {
+		status[i].Migration = m
+		if at, ok := applied[m.Version]; ok {
+			status[i].AppliedAt = &at
+		}
+	}
+	return status, nil
+}
+
+// Pending returns the migrations that have not been applied, in order
+func (db *DB) Pending() ([]Migration, error) {
+	status, err := db.Migrations()
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, s := range status {
+		if s.AppliedAt == nil {
+			pending = append(pending, s.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// Migrate applies the pending migrations in order and returns them. It stops
+// at the first that fails; those before it stay applied.
+func (db *DB) Migrate() ([]Migration, error) {
+	pending, err := db.Pending()
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) == 0 {
+		return nil, nil
+	}
+	if _, err := db.Exec(migrationsTable); err != nil {
+		return nil, fmt.Errorf("failed to create the migrations table: %w", err)
+	}
+
+	var done []Migration
+	for _, m := range pending {
+		if err := m.up(db); err != nil {
+			return done, fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Description, err)
+		}
+		// Another process may have applied it at the same time
+		var recorded int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM schema_migrations WHERE version = ?`, m.Version).Scan(&recorded); err != nil {
+			return done, fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+		}
+		if recorded == 0 {
+			if _, err := db.Exec(
+				`INSERT INTO schema_migrations (version, description, applied_at) VALUES (?, ?, ?)`,
+				m.Version, m.Description, time.Now(),
+			); err != nil {
+				return done, fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+			}
+		}
+		done = append(done, m)
+	}
+
+	if db.driver == DriverSQLite {
+		if err := db.setUserVersion(SchemaVersion()); err != nil {
+			return done, fmt.Errorf("failed to record schema version: %w", err)
+		}
+	}
+	return done, nil
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateLegacyDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fire.db")
+
+	// A database from before versioned migrations: the base schema and a
+	// run, without schema_migrations
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec(sqliteSchema); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec(`INSERT INTO runs (plugin, start_time) VALUES ('cpu', CURRENT_TIMESTAMP)`); err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.Close()
+
+	database, err := OpenUnmigrated(Config{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending, err := database.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != len(migrations) {
+		t.Errorf("expected every migration to be pending, got %d of %d", len(pending), len(migrations))
+	}
+	_ = database.Close()
+
+	database, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = database.Close() }()
+
+	status, err := database.Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range status {
+		if s.AppliedAt == nil {
+			t.Errorf("expected migration %d (%s) to be applied", s.Version, s.Description)
+		}
+	}
+	run, err := database.GetRun(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.UUID == "" {
+		t.Error("expected the run to get a UUID")
+	}
+	if backups, _ := ListBackups(BackupDir(path)); len(backups) != 1 {
+		t.Errorf("expected a backup before migrating, got %d", len(backups))
+	}
+
+	if done, err := database.Migrate(); err != nil || len(done) != 0 {
+		t.Errorf("expected nothing left to migrate, got %d (%v)", len(done), err)
+	}
+}
+
+func TestMigrateWithoutBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fire.db")
+	database, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.Exec(`DELETE FROM schema_migrations WHERE version = ?`, SchemaVersion()); err != nil {
+		t.Fatal(err)
+	}
+	_ = database.Close()
+
+	off := false
+	database, err = OpenConfig(Config{Path: path, MigrateBackup: &off})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = database.Close() }()
+	if backups, _ := ListBackups(BackupDir(path)); len(backups) != 0 {
+		t.Errorf("expected no backup, got %d", len(backups))
+	}
+	if pending, _ := database.Pending(); len(pending) != 0 {
+		t.Errorf("expected the migration to be applied, got %d pending", len(pending))
+	}
+}
+
+func TestMigrationVersions(t *testing.T) {
+	for i, m := range migrations {
+		if m.Version != i+1 || m.Description == "" {
+			t.Errorf("migration %d: expected version %d with a description, got %d %q", i, i+1, m.Version, m.Description)
+		}
+	}
+}
@@ -9,18 +9,24 @@ import (
 
 // Run represents a test execution record
 type Run struct {
-	ID        int64      `json:"id"`
-	Plugin    string     `json:"plugin"`
-	Params    JSONData   `json:"params"`
-	StartTime time.Time  `json:"start_time"`
-	EndTime   *time.Time `json:"end_time"`
-	ExitCode  int        `json:"exit_code"`
-	Success   bool       `json:"success"`
-	Error     string     `json:"error,omitempty"`
-	Stdout    string     `json:"stdout,omitempty"`
-	Stderr    string     `json:"stderr,omitempty"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
+	ID          int64             `json:"id"`
+	UUID        string            `json:"uuid"`
+	Plugin      string            `json:"plugin"`
+	Params      JSONData          `json:"params"`
+	StartTime   time.Time         `json:"start_time"`
+	EndTime     *time.Time        `json:"end_time"`
+	ExitCode    int               `json:"exit_code"`
+	Success     bool              `json:"success"`
+	Error       string            `json:"error,omitempty"`
+	Stdout      string            `json:"stdout,omitempty"`
+	Stderr      string            `json:"stderr,omitempty"`
+	Environment string            `json:"environment,omitempty"` // VM, WSL or container label; empty on bare metal
+	Machine     string            `json:"machine,omitempty"`     // Hostname of the machine the run was recorded on
+	Model       string            `json:"model,omitempty"`       // Hardware model, e.g. "Dell Inc. PowerEdge R650"
+	Interrupted bool              `json:"interrupted,omitempty"` // Cut short by a crash, power loss or restart
+	Tags        map[string]string `json:"tags,omitempty"`        // Labels such as customer=acme or build=retail
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
 }
 
 // Result represents a metric result from a test run
@@ -41,7 +47,12 @@ func (j JSONData) Value() (driver.Value, error) {
 	if j == nil {
 		return nil, nil
 	}
-	return json.Marshal(j)
+	data, err := json.Marshal(j)
+	if err != nil {
+		return nil, err
+	}
+	// Store as text so the column reads the same on every backend
+	return string(data), nil
 }
 
 // Scan implements the sql.Scanner interface
@@ -73,6 +84,8 @@ const (
 	RunStatusRunning  RunStatus = "running"
 	RunStatusComplete RunStatus = "complete"
 	RunStatusFailed   RunStatus = "failed"
+
+	RunStatusInterrupted RunStatus = "interrupted"
 )
 
 // GetStatus returns the status of a run
@@ -87,6 +100,9 @@ func (r *Run) GetStatus() RunStatus {
 	if r.Success {
 		return RunStatusComplete
 	}
+	if r.Interrupted {
+		return RunStatusInterrupted
+	}
 	return RunStatusFailed
 }
 
@@ -104,6 +120,8 @@ type RunFilter struct {
 	StartTime *time.Time
 	EndTime   *time.Time
 	Success   *bool
+	Tags      map[string]string // Runs with every tag; an empty value matches any
+	Search    string            // Runs whose tags, notes or error contain this, ignoring case
 	Limit     int
 	Offset    int
 }
@@ -112,6 +130,8 @@ type RunFilter struct {
 type ResultFilter struct {
 	RunID  *int64
 	Metric string
+	Since  *time.Time // Results recorded at or after Since
+	Until  *time.Time // Results recorded before Until
 	Limit  int
 	Offset int
 }
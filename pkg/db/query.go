@@ -0,0 +1,201 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// Page sizes for PageResults
+const (
+	DefaultPageSize = 500
+	MaxPageSize     = 5000
+)
+
+// ResultPage is one page of results in the order they were recorded
+type ResultPage struct {
+	Results []*Result `json:"results"`
+	// NextCursor continues after this page; 0 when there are no more results
+	NextCursor int64 `json:"next_cursor,omitempty"`
+}
+
+// Bucket summarizes the values of a metric recorded in one time bucket
+type Bucket struct {
+	Start time.Time `json:"start"`
+	Count int       `json:"count"`
+	Avg   float64   `json:"avg"`
+	Min   float64   `json:"min"`
+	Max   float64   `json:"max"`
+}
+
+// SeriesFilter selects a metric to downsample
+type SeriesFilter struct {
+	RunID  *int64
+	Metric string // Required
+	Since  *time.Time
+	Until  *time.Time
+	Width  time.Duration // Bucket width, rounded up to whole seconds
+}
+
+// epochColumn returns the expression for a timestamp column in Unix seconds,
+// which compares and buckets the same way on both backends
+func (db *DB) epochColumn(column string) string {
+	if db.driver == DriverPostgres {
+		return fmt.Sprintf("CAST(EXTRACT(EPOCH FROM %s) AS BIGINT)", column)
+	}
+	return fmt.Sprintf("CAST(strftime('%%s', %s) AS INTEGER)", column)
+}
+
+// timeRange adds created_at bounds to a results query
+func (db *DB) timeRange(query string, args []interface{}, since, until *time.Time) (string, []interface{}) {
+	if since != nil {
+		query += " AND " + db.epochColumn("created_at") + " >= ?"
+		args = append(args, since.Unix())
+	}
+	if until != nil {
+		query += " AND " + db.epochColumn("created_at") + " < ?"
+		args = append(args, until.Unix())
+	}
+	return query, args
+}
+
+// PageResults returns up to filter.Limit results (DefaultPageSize if unset,
+// at most MaxPageSize) recorded after the result identified by cursor, in
+// the order they were recorded. Pass 0 for the first page and the page's
+// NextCursor for the next. filter.Offset is ignored.
+func (db *DB) PageResults(filter ResultFilter, cursor int64) (ResultPage, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+
+	query := `SELECT id, run_id, metric, value, unit, created_at
+	          FROM results WHERE id > ?`
+	args := []interface{}{cursor}
+
+	if filter.RunID != nil {
+		query += " AND run_id = ?"
+		args = append(args, *filter.RunID)
+	}
+	if filter.Metric != "" {
+		query += " AND metric = ?"
+		args = append(args, filter.Metric)
+	}
+	query, args = db.timeRange(query, args, filter.Since, filter.Until)
+
+	// One extra row tells whether another page follows
+	query += " ORDER BY id LIMIT ?"
+	args = append(args, limit+1)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return ResultPage{}, fmt.Errorf("failed to page results: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var page ResultPage
+	for rows.Next() {
+		result := &Result{}
+		if err := rows.Scan(
+			&result.ID, &result.RunID, &result.Metric,
+			&result.Value, &result.Unit, &result.CreatedAt,
+		); err != nil {
+			return ResultPage{}, fmt.Errorf("failed to scan result: %w", err)
+		}
+		page.Results = append(page.Results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return ResultPage{}, fmt.Errorf("failed to page results: %w", err)
+	}
+
+	if len(page.Results) > limit {
+		page.Results = page.Results[:limit]
+		page.NextCursor = page.Results[limit-1].ID
+	}
+	return page, nil
+}
+
+// MetricSeries downsamples a metric into buckets of filter.Width, returning
+// the average, minimum and maximum of each non-empty bucket in time order.
+// The aggregation runs in the database, so long recordings are never loaded
+// into memory.
+func (db *DB) MetricSeries(filter SeriesFilter) ([]Bucket, error) {
+	if filter.Metric == "" {
+		return nil, fmt.Errorf("metric is required")
+	}
+	width := int64((filter.Width + time.Second - 1) / time.Second)
+	if width < 1 {
+		width = 1
+	}
+
+	bucket := fmt.Sprintf("(%s / ?) * ?", db.epochColumn("created_at"))
+	query := `SELECT ` + bucket + ` AS bucket, COUNT(*), AVG(value), MIN(value), MAX(value)
+	          FROM results WHERE metric = ?`
+	args := []interface{}{width, width, filter.Metric}
+
+	if filter.RunID != nil {
+		query += " AND run_id = ?"
+		args = append(args, *filter.RunID)
+	}
+	query, args = db.timeRange(query, args, filter.Since, filter.Until)
+	query += " GROUP BY bucket ORDER BY bucket"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metric series: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var buckets []Bucket
+	for rows.Next() {
+		var (
+			start int64
+			b     Bucket
+		)
+		if err := rows.Scan(&start, &b.Count, &b.Avg, &b.Min, &b.Max); err != nil {
+			return nil, fmt.Errorf("failed to scan bucket: %w", err)
+		}
+		b.Start = time.Unix(start, 0)
+		buckets = append(buckets, b)
+	}
+	return buckets, rows.Err()
+}
+
+// MetricNames returns the name of every metric that has results, in name
+// order
+func (db *DB) MetricNames() ([]string, error) {
+	rows, err := db.Query(`SELECT DISTINCT metric FROM results ORDER BY metric`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list metrics: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan metric: %w", err)
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// BucketWidth returns a bucket width that splits since..until into about
+// points buckets, at least one second wide. Buckets are aligned to the Unix
+// epoch, so the range may touch one more.
+func BucketWidth(since, until time.Time, points int) time.Duration {
+	if points <= 0 || !until.After(since) {
+		return time.Second
+	}
+	width := until.Sub(since) / time.Duration(points)
+	if rem := width % time.Second; rem != 0 {
+		width += time.Second - rem
+	}
+	if width < time.Second {
+		width = time.Second
+	}
+	return width
+}
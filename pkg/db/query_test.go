@@ -0,0 +1,139 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// seedSamples records a metric once per second for n seconds from start
+func seedSamples(t *testing.T, database *DB, runID int64, start time.Time, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if _, err := database.Exec(
+			`INSERT INTO results (run_id, metric, value, unit, created_at) VALUES (?, ?, ?, ?, ?)`,
+			runID, "temp_c", float64(i), "C", start.Add(time.Duration(i)*time.Second),
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPageResults(t *testing.T) {
+	database, err := Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = database.Close() }()
+
+	run, err := database.CreateRun("cpu", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	seedSamples(t, database, run.ID, start, 25)
+
+	var (
+		cursor int64
+		seen   []float64
+		pages  int
+	)
+	for {
+		page, err := database.PageResults(ResultFilter{RunID: &run.ID, Limit: 10}, cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages++
+		for _, r := range page.Results {
+			seen = append(seen, r.Value)
+		}
+		if page.NextCursor == 0 {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	if pages != 3 || len(seen) != 25 {
+		t.Fatalf("expected 25 results in 3 pages, got %d in %d", len(seen), pages)
+	}
+	for i, v := range seen {
+		if v != float64(i) {
+			t.Fatalf("expected results in recorded order, got %v at %d", v, i)
+		}
+	}
+
+	// Time range in another zone selects the same instants
+	since := start.Add(5 * time.Second).In(time.FixedZone("UTC+2", 2*3600))
+	until := start.Add(10 * time.Second)
+	page, err := database.PageResults(ResultFilter{RunID: &run.ID, Since: &since, Until: &until}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Results) != 5 || page.Results[0].Value != 5 || page.NextCursor != 0 {
+		t.Errorf("expected values 5-9, got %d results", len(page.Results))
+	}
+
+	results, err := database.ListResults(ResultFilter{Since: &since, Until: &until})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 5 {
+		t.Errorf("expected ListResults to honour the time range, got %d results", len(results))
+	}
+}
+
+func TestMetricSeries(t *testing.T) {
+	database, err := Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = database.Close() }()
+
+	run, err := database.CreateRun("cpu", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	seedSamples(t, database, run.ID, start, 30)
+
+	buckets, err := database.MetricSeries(SeriesFilter{RunID: &run.ID, Metric: "temp_c", Width: 10 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buckets) != 3 {
+		t.Fatalf("expected 3 buckets, got %d", len(buckets))
+	}
+	first := buckets[0]
+	if first.Count != 10 || first.Min != 0 || first.Max != 9 || first.Avg != 4.5 || !first.Start.Equal(start) {
+		t.Errorf("unexpected first bucket %+v", first)
+	}
+	if buckets[2].Min != 20 || buckets[2].Max != 29 {
+		t.Errorf("unexpected last bucket %+v", buckets[2])
+	}
+
+	if _, err := database.MetricSeries(SeriesFilter{}); err == nil {
+		t.Error("expected error without a metric")
+	}
+
+	if names, err := database.MetricNames(); err != nil || len(names) != 1 || names[0] != "temp_c" {
+		t.Errorf("expected the seeded metric name, got %v (%v)", names, err)
+	}
+}
+
+func TestBucketWidth(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		span   time.Duration
+		points int
+		want   time.Duration
+	}{
+		{4 * time.Hour, 240, time.Minute},
+		{10 * time.Second, 3, 4 * time.Second},
+		{time.Second, 100, time.Second},
+		{time.Hour, 0, time.Second},
+	}
+	for _, tt := range tests {
+		if got := BucketWidth(start, start.Add(tt.span), tt.points); got != tt.want {
+			t.Errorf("BucketWidth(%s, %d) = %s, expected %s", tt.span, tt.points, got, tt.want)
+		}
+	}
+}
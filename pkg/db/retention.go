@@ -0,0 +1,279 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Retention is how long results are kept, with ages written like "90d" or
+// "12h". An empty age keeps that data forever.
+type Retention struct {
+	Runs       string `json:"runs"`       // Delete runs, and what was recorded with them, older than this
+	Samples    string `json:"samples"`    // Average samples older than this into buckets of Resolution
+	Resolution string `json:"resolution"` // Bucket width samples are averaged into, default 1m
+	Interval   string `json:"interval"`   // How often the agent applies the policy, default 24h
+}
+
+// Retention defaults
+const (
+	DefaultSampleResolution = time.Minute
+	DefaultPruneInterval    = 24 * time.Hour
+)
+
+// ParseAge reads an age in days such as "90d", or a duration such as "12h"
+func ParseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q: use e.g. 90d or 12h", s)
+	}
+	return d, nil
+}
+
+// Enabled reports whether the policy removes anything
+func (r Retention) Enabled() bool {
+	return r.Runs != "" || r.Samples != ""
+}
+
+// Every returns how often the policy is applied
+func (r Retention) Every() time.Duration {
+	d, err := ParseAge(r.Interval)
+	if err != nil || d <= 0 {
+		return DefaultPruneInterval
+	}
+	return d
+}
+
+// Options returns what the policy removes as of now
+func (r Retention) Options(now time.Time) (PruneOptions, error) {
+	opts := PruneOptions{Resolution: DefaultSampleResolution}
+	if r.Runs != "" {
+		age, err := ParseAge(r.Runs)
+		if err != nil {
+			return opts, fmt.Errorf("retention.runs: %w", err)
+		}
+		opts.Before = now.Add(-age)
+	}
+	if r.Samples != "" {
+		age, err := ParseAge(r.Samples)
+		if err != nil {
+			return opts, fmt.Errorf("retention.samples: %w", err)
+		}
+		opts.DownsampleBefore = now.Add(-age)
+	}
+	if r.Resolution != "" {
+		d, err := ParseAge(r.Resolution)
+		if err != nil {
+			return opts, fmt.Errorf("retention.resolution: %w", err)
+		}
+		opts.Resolution = d
+	}
+	return opts, nil
+}
+
+// PruneOptions say what Prune removes. A zero time removes nothing of that
+// kind.
+type PruneOptions struct {
+	// Before deletes the runs and plan runs that started before it, with
+	// their results, and the events and snapshots recorded before it.
+	// Runs that have not ended are kept, as is the newest SMART snapshot of
+	// every drive and inventory of every machine.
+	Before time.Time
+
+	// DownsampleBefore averages the results a run recorded more than once
+	// per Resolution before it, e.g. by bench monitor, into one result per
+	// bucket
+	DownsampleBefore time.Time
+	Resolution       time.Duration
+
+	DryRun bool // Count what would be removed, but keep it
+}
+
+// PruneResult counts what Prune removed
+type PruneResult struct {
+	Runs      int64 // Runs deleted
+	Results   int64 // Results deleted with their runs
+	PlanRuns  int64 // Plan runs deleted
+	Events    int64 // Annotations and alert events deleted
+	Snapshots int64 // SMART and inventory snapshots deleted
+	Samples   int64 // Results averaged into buckets
+	Buckets   int64 // Buckets they were averaged into
+}
+
+// Prune removes old data in one transaction, so an interrupted prune
+// removes nothing. Space is only returned to the file system by Vacuum.
+func (db *DB) Prune(opts PruneOptions) (PruneResult, error) {
+	var result PruneResult
+	err := db.write(func() error {
+		tx, err := db.conn.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		if !opts.Before.IsZero() {
+			if err := db.pruneBefore(tx, opts.Before.Unix(), &result); err != nil {
+				return err
+			}
+		}
+		if !opts.DownsampleBefore.IsZero() {
+			if err := db.downsample(tx, opts.DownsampleBefore, opts.Resolution, &result); err != nil {
+				return err
+			}
+		}
+
+		if opts.DryRun {
+			return nil
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// pruneBefore deletes the runs, plan runs, events and snapshots older than
+// the Unix time before. SQLite does not enforce foreign keys, so rows that
+// refer to deleted runs are removed or unlinked here.
+func (db *DB) pruneBefore(tx *sql.Tx, before int64, result *PruneResult) error {
+	runs := `SELECT id FROM runs WHERE end_time IS NOT NULL AND ` + db.epochColumn("start_time") + ` < ?`
+	plans := `SELECT id FROM plan_runs WHERE end_time IS NOT NULL AND ` + db.epochColumn("start_time") + ` < ?`
+
+	steps := []struct {
+		query string
+		count *int64
+	}{
+		{`DELETE FROM results WHERE run_id IN (` + runs + `)`, &result.Results},
+		{`DELETE FROM schedule_runs WHERE run_id IN (` + runs + `)`, nil},
+		{`DELETE FROM plan_stage_runs WHERE run_id IN (` + runs + `)`, nil},
+		{`DELETE FROM run_inventory WHERE run_id IN (` + runs + `)`, nil},
+		{`DELETE FROM run_tags WHERE run_id IN (` + runs + `)`, nil},
+		{`DELETE FROM run_notes WHERE run_id IN (` + runs + `)`, nil},
+		{`UPDATE annotations SET run_id = NULL WHERE run_id IN (` + runs + `)`, nil},
+		{`UPDATE schedules SET last_run_id = NULL WHERE last_run_id IN (` + runs + `)`, nil},
+		{`DELETE FROM runs WHERE id IN (` + runs + `)`, &result.Runs},
+
+		{`DELETE FROM plan_stage_runs WHERE stage_id IN (SELECT id FROM plan_stages WHERE plan_run_id IN (` + plans + `))`, nil},
+		{`DELETE FROM plan_stages WHERE plan_run_id IN (` + plans + `)`, nil},
+		{`DELETE FROM plan_runs WHERE id IN (` + plans + `)`, &result.PlanRuns},
+
+		{`DELETE FROM annotations WHERE ` + db.epochColumn("event_time") + ` < ?`, &result.Events},
+		{`DELETE FROM alert_events WHERE ` + db.epochColumn("event_time") + ` < ?`, &result.Events},
+
+		{`DELETE FROM smart_snapshots WHERE ` + db.epochColumn("taken_at") + ` < ?
+		  AND id NOT IN (SELECT MAX(id) FROM smart_snapshots GROUP BY device)`, &result.Snapshots},
+		{`DELETE FROM inventory_snapshots WHERE ` + db.epochColumn("taken_at") + ` < ?
+		  AND id NOT IN (SELECT snapshot_id FROM run_inventory)
+		  AND id NOT IN (SELECT MAX(id) FROM inventory_snapshots GROUP BY machine)`, &result.Snapshots},
+	}
+
+	for _, step := range steps {
+		res, err := tx.Exec(rebind(db.driver, step.query), before)
+		if err != nil {
+			return fmt.Errorf("failed to prune: %w", err)
+		}
+		if step.count != nil {
+			n, _ := res.RowsAffected()
+			*step.count += n
+		}
+	}
+	return nil
+}
+
+// downsample replaces the raw results recorded before a time with their
+// average per run, metric and bucket of resolution. Results stored only
+// once per bucket are kept as they are; either way they are marked, so
+// later passes skip them.
+func (db *DB) downsample(tx *sql.Tx, before time.Time, resolution time.Duration, result *PruneResult) error {
+	width := int64(resolution / time.Second)
+	if width < 1 {
+		width = 1
+	}
+	// Whole buckets only, so no bucket is averaged twice
+	cutoff := before.Unix() / width * width
+
+	created := db.epochColumn("created_at")
+	bucketOf := func(column string) string {
+		return fmt.Sprintf("(%s / %d) * %d", db.epochColumn(column), width, width)
+	}
+	bucket := bucketOf("created_at")
+	bucketTime := fmt.Sprintf("datetime(%s, 'unixepoch')", bucket)
+	if db.driver == DriverPostgres {
+		bucketTime = fmt.Sprintf("to_timestamp(%s)", bucket)
+	}
+
+	res, err := tx.Exec(rebind(db.driver,
+		`INSERT INTO results (run_id, metric, value, unit, samples, created_at)
+		 SELECT run_id, metric, AVG(value), MAX(unit), COUNT(*), `+bucketTime+`
+		 FROM results WHERE samples IS NULL AND `+created+` < ?
+		 GROUP BY run_id, metric, `+bucket+`
+		 HAVING COUNT(*) > 1`,
+	), cutoff)
+	if err != nil {
+		return fmt.Errorf("failed to downsample results: %w", err)
+	}
+	result.Buckets, _ = res.RowsAffected()
+
+	res, err = tx.Exec(rebind(db.driver,
+		`DELETE FROM results WHERE samples IS NULL AND `+created+` < ?
+		 AND EXISTS (
+			SELECT 1 FROM results b WHERE b.samples > 1
+			AND b.run_id = results.run_id AND b.metric = results.metric
+			AND `+db.epochColumn("b.created_at")+` = `+bucketOf("results.created_at")+`
+		 )`,
+	), cutoff)
+	if err != nil {
+		return fmt.Errorf("failed to delete downsampled results: %w", err)
+	}
+	result.Samples, _ = res.RowsAffected()
+
+	if _, err := tx.Exec(rebind(db.driver,
+		`UPDATE results SET samples = 1 WHERE samples IS NULL AND `+created+` < ?`,
+	), cutoff); err != nil {
+		return fmt.Errorf("failed to mark downsampled results: %w", err)
+	}
+	return nil
+}
+
+// Size returns the bytes the database takes up: the SQLite file and its
+// write-ahead log, or the PostgreSQL database
+func (db *DB) Size() (int64, error) {
+	if db.driver == DriverPostgres {
+		var size int64
+		err := db.conn.QueryRow(`SELECT pg_database_size(current_database())`).Scan(&size)
+		return size, err
+	}
+	var size int64
+	for _, path := range []string{db.path, db.path + "-wal"} {
+		if info, err := os.Stat(path); err == nil {
+			size += info.Size()
+		}
+	}
+	return size, nil
+}
+
+// Vacuum rebuilds the database, returning the space of deleted rows to the
+// file system. It needs as much free space as the database takes up, and
+// blocks writers until it is done.
+func (db *DB) Vacuum() error {
+	query := `VACUUM`
+	if db.driver == DriverPostgres {
+		query = `VACUUM ANALYZE`
+	}
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf("failed to vacuum database: %w", err)
+	}
+	return db.Flush()
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	for s, want := range map[string]time.Duration{"90d": 90 * 24 * time.Hour, "12h": 12 * time.Hour, " 0d ": 0} {
+		if got, err := ParseAge(s); err != nil || got != want {
+			t.Errorf("ParseAge(%q) = %v, %v; want %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "d", "-1d", "ninety days"} {
+		if _, err := ParseAge(s); err == nil {
+			t.Errorf("expected ParseAge(%q) to fail", s)
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	database, err := Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = database.Close() }()
+
+	now := time.Now()
+	old, err := database.CreateRun("cpu", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := now.Add(-100 * 24 * time.Hour)
+	start := end.Add(-time.Hour)
+	if _, err := database.Exec(`UPDATE runs SET start_time = ?, end_time = ? WHERE id = ?`, start, end, old.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.CreateResult(old.ID, "score", 42, ""); err != nil {
+		t.Fatal(err)
+	}
+	oldID := old.ID
+	if err := database.CreateAnnotation(&Annotation{RunID: &oldID, Time: end, Source: "test", Kind: "note"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Still running, however old
+	open, err := database.CreateRun("memory", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.Exec(`UPDATE runs SET start_time = ? WHERE id = ?`, start, open.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	recent, err := database.CreateRun("monitor", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two minutes of samples a month ago and two today
+	month := now.Add(-30 * 24 * time.Hour).Truncate(time.Minute)
+	seedSamples(t, database, recent.ID, month, 120)
+	seedSamples(t, database, recent.ID, now.Add(-5*time.Minute), 120)
+
+	opts := PruneOptions{
+		Before:           now.Add(-90 * 24 * time.Hour),
+		DownsampleBefore: now.Add(-7 * 24 * time.Hour),
+		Resolution:       time.Minute,
+		DryRun:           true,
+	}
+	want := PruneResult{Runs: 1, Results: 1, Events: 1, Samples: 120, Buckets: 2}
+	result, err := database.Prune(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != want {
+		t.Errorf("expected dry run to count %+v, got %+v", want, result)
+	}
+	if _, err := database.GetRun(old.ID); err != nil {
+		t.Errorf("expected dry run to keep the run: %v", err)
+	}
+
+	opts.DryRun = false
+	if result, err = database.Prune(opts); err != nil {
+		t.Fatal(err)
+	}
+	if result != want {
+		t.Errorf("expected prune to remove %+v, got %+v", want, result)
+	}
+	if _, err := database.GetRun(old.ID); err == nil {
+		t.Error("expected the old run to be deleted")
+	}
+	if _, err := database.GetRun(open.ID); err != nil {
+		t.Errorf("expected the open run to be kept: %v", err)
+	}
+
+	results, err := database.GetResults(recent.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2+120 {
+		t.Fatalf("expected 2 buckets and 120 recent samples, got %d results", len(results))
+	}
+	buckets, err := database.MetricSeries(SeriesFilter{RunID: &recent.ID, Metric: "temp_c", Until: &opts.DownsampleBefore, Width: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buckets) != 2 || buckets[0].Avg != 29.5 || buckets[1].Avg != 89.5 || !buckets[0].Start.Equal(month) {
+		t.Errorf("expected the averages of each minute, got %+v", buckets)
+	}
+
+	// Everything old is downsampled already
+	if result, err = database.Prune(opts); err != nil || result != (PruneResult{}) {
+		t.Errorf("expected nothing left to prune, got %+v (%v)", result, err)
+	}
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// SMARTSnapshot is the SMART data of one drive at one time, kept so changes
+// between runs can be shown
+type SMARTSnapshot struct {
+	ID      int64     `json:"id"`
+	Device  string    `json:"device"` // e.g. /dev/nvme0n1
+	Serial  string    `json:"serial,omitempty"`
+	Model   string    `json:"model,omitempty"`
+	TakenAt time.Time `json:"taken_at"`
+	Data    string    `json:"data"` // The SMART data as JSON
+}
+
+const smartSnapshotColumns = `id, device, COALESCE(serial, ''), COALESCE(model, ''), taken_at, data`
+
+// CreateSMARTSnapshot records a snapshot and sets its ID
+func (db *DB) CreateSMARTSnapshot(s *SMARTSnapshot) error {
+	id, err := db.Insert(
+		`INSERT INTO smart_snapshots (device, serial, model, taken_at, data) VALUES (?, ?, ?, ?, ?)`,
+		s.Device, s.Serial, s.Model, s.TakenAt.UTC(), s.Data,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create SMART snapshot: %w", err)
+	}
+	s.ID = id
+	return nil
+}
+
+// ListSMARTSnapshots returns the snapshots taken between since and until,
+// oldest first. A zero time leaves that end of the range open.
+func (db *DB) ListSMARTSnapshots(since, until time.Time) ([]*SMARTSnapshot, error) {
+	query := `SELECT ` + smartSnapshotColumns + ` FROM smart_snapshots WHERE 1=1`
+	var args []interface{}
+	if !since.IsZero() {
+		query += " AND " + db.epochColumn("taken_at") + " >= ?"
+		args = append(args, since.Unix())
+	}
+	if !until.IsZero() {
+		query += " AND " + db.epochColumn("taken_at") + " <= ?"
+		args = append(args, until.Unix())
+	}
+	query += " ORDER BY taken_at, id"
+	return db.querySMARTSnapshots(query, args...)
+}
+
+// LatestSMARTSnapshots returns the last snapshot of every drive taken at or
+// before at, ordered by device. A drive is told apart by its device and
+// serial number, so a drive swapped into the same slot is a new drive.
+func (db *DB) LatestSMARTSnapshots(at time.Time) ([]*SMARTSnapshot, error) {
+	taken := db.epochColumn("taken_at")
+	query := `SELECT ` + smartSnapshotColumns + ` FROM smart_snapshots s
+		WHERE id = (SELECT id FROM smart_snapshots
+			WHERE device = s.device AND COALESCE(serial, '') = COALESCE(s.serial, '') AND ` + taken + ` <= ?
+			ORDER BY taken_at DESC, id DESC LIMIT 1)
+		ORDER BY device, serial`
+	return db.querySMARTSnapshots(query, at.Unix())
+}
+
+func (db *DB) querySMARTSnapshots(query string, args ...interface{}) ([]*SMARTSnapshot, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list SMART snapshots: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var snapshots []*SMARTSnapshot
+	for rows.Next() {
+		s := &SMARTSnapshot{}
+		if err := rows.Scan(&s.ID, &s.Device, &s.Serial, &s.Model, &s.TakenAt, &s.Data); err != nil {
+			return nil, fmt.Errorf("failed to scan SMART snapshot: %w", err)
+		}
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, rows.Err()
+}
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"database/sql"
+	"io"
+)
+
+// Store is the storage API used by the CLI, scheduler and GUI. DB implements
+// it for every supported backend, so callers never depend on the driver.
+type Store interface {
+	// Runs
+	CreateRun(plugin string, params JSONData) (*Run, error)
+	UpdateRun(run *Run) error
+	GetRun(id int64) (*Run, error)
+	GetRunByUUID(uuid string) (*Run, error)
+	ResolveRun(ref string) (*Run, error)
+	ListRuns(filter RunFilter) ([]*Run, error)
+
+	// Results
+	CreateResult(runID int64, metric string, value float64, unit string) error
+	CreateResults(runID int64, metrics map[string]float64, units map[string]string) error
+	GetResults(runID int64) ([]*Result, error)
+	ListResults(filter ResultFilter) ([]*Result, error)
+
+	// Exports
+	ExportCSV(w io.Writer, runID int64) error
+	ExportJSON(w io.Writer, runID int64) error
+	ExportAllCSV(w io.Writer) error
+
+	// Raw access for packages that own their own tables (e.g. schedules)
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Insert(query string, args ...interface{}) (int64, error)
+
+	Driver() Driver
+	Path() string
+	Close() error
+}
+
+// Ensure DB implements Store
+var _ Store = (*DB)(nil)
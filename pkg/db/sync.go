@@ -0,0 +1,268 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetRunByUUID retrieves a run by its globally unique identifier
+func (db *DB) GetRunByUUID(uuid string) (*Run, error) {
+	run := &Run{}
+	err := db.QueryRow(
+		`SELECT id, uuid, plugin, params, start_time, end_time, exit_code, 
+		 success, COALESCE(error, ''), COALESCE(stdout, ''), COALESCE(stderr, ''), COALESCE(environment, ''),
+		 COALESCE(machine, ''), COALESCE(model, ''), COALESCE(interrupted, FALSE), created_at, updated_at
+		 FROM runs WHERE uuid = ?`,
+		uuid,
+	).Scan(
+		&run.ID, &run.UUID, &run.Plugin, &run.Params, &run.StartTime, &run.EndTime,
+		&run.ExitCode, &run.Success, &run.Error, &run.Stdout, &run.Stderr,
+		&run.Environment, &run.Machine, &run.Model, &run.Interrupted, &run.CreatedAt, &run.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("run not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get run: %w", err)
+	}
+	if run.Tags, err = db.RunTags(run.ID); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// ListUnsyncedRuns returns completed runs that have not been pushed to the
+// central server yet, oldest first
+func (db *DB) ListUnsyncedRuns(limit int) ([]*Run, error) {
+	query := `SELECT id FROM runs 
+	          WHERE end_time IS NOT NULL AND synced_at IS NULL
+	          ORDER BY start_time`
+	args := []interface{}{}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unsynced runs: %w", err)
+	}
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("failed to scan run id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	_ = rows.Close()
+
+	runs := make([]*Run, 0, len(ids))
+	for _, id := range ids {
+		run, err := db.GetRun(id)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// MarkRunSynced records that a run has been pushed to the central server
+func (db *DB) MarkRunSynced(id int64) error {
+	_, err := db.Exec(`UPDATE runs SET synced_at = ? WHERE id = ?`, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to mark run synced: %w", err)
+	}
+	return nil
+}
+
+// RunRecord is a run with the rows recorded for it, as copied from one
+// database to another
+type RunRecord struct {
+	Run         *Run
+	Results     []*Result
+	Notes       []*Note
+	Annotations []*Annotation
+}
+
+// LoadRunRecord reads the results and notes of a run, and the annotations
+// recorded for it or while it was in progress that belong to no other run
+func (db *DB) LoadRunRecord(run *Run) (*RunRecord, error) {
+	rec := &RunRecord{Run: run}
+	var err error
+	if rec.Results, err = db.GetResults(run.ID); err != nil {
+		return nil, err
+	}
+	if rec.Notes, err = db.RunNotes(run.ID); err != nil {
+		return nil, err
+	}
+	annotations, err := db.RunAnnotations(run)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range annotations {
+		if a.RunID == nil || *a.RunID == run.ID {
+			rec.Annotations = append(rec.Annotations, a)
+		}
+	}
+	return rec, nil
+}
+
+// MarkRunUnsynced makes the sync worker push a run again, e.g. after it was
+// tagged or got a note
+func (db *DB) MarkRunUnsynced(id int64) error {
+	_, err := db.Exec(`UPDATE runs SET synced_at = NULL WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark run unsynced: %w", err)
+	}
+	return nil
+}
+
+// ImportRun stores a run that was recorded on another machine with its
+// results, tags, notes and annotations. Runs are matched by UUID: if the run
+// already exists it is not written again and imported is false, but the notes
+// and annotations it is missing are added and its tags take the imported
+// values. Repeated
+// imports are harmless and pick up what was added since the last one.
+func (db *DB) ImportRun(rec *RunRecord) (id int64, imported bool, err error) {
+	run := rec.Run
+	if run.UUID == "" {
+		return 0, false, fmt.Errorf("run has no UUID")
+	}
+
+	var have *RunRecord
+	if existing, err := db.GetRunByUUID(run.UUID); err == nil {
+		id = existing.ID
+		if have, err = db.LoadRunRecord(existing); err != nil {
+			return 0, false, err
+		}
+	}
+	missing := rec.missing(have)
+
+	err = db.transaction(func(tx *sql.Tx) error {
+		if have == nil {
+			query := rebind(db.driver,
+				`INSERT INTO runs (uuid, plugin, params, start_time, end_time, exit_code, 
+				 success, error, stdout, stderr, environment, machine, model, interrupted, created_at, updated_at)
+				 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+			args := []interface{}{
+				run.UUID, run.Plugin, run.Params, run.StartTime, run.EndTime, run.ExitCode,
+				run.Success, run.Error, run.Stdout, run.Stderr, run.Environment, run.Machine, run.Model, run.Interrupted,
+				run.CreatedAt, run.UpdatedAt,
+			}
+
+			var err error
+			if db.driver == DriverPostgres {
+				err = tx.QueryRow(query+" RETURNING id", args...).Scan(&id)
+			} else {
+				var res sql.Result
+				res, err = tx.Exec(query, args...)
+				if err == nil {
+					id, err = res.LastInsertId()
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("failed to insert run: %w", err)
+			}
+			imported = true
+		}
+		return db.insertRunRows(tx, id, missing)
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	return id, imported, nil
+}
+
+// insertRunRows stores the results, notes and annotations of rec for run id
+// and sets its tags
+func (db *DB) insertRunRows(tx *sql.Tx, id int64, rec *RunRecord) error {
+	stmt, err := tx.Prepare(rebind(db.driver,
+		`INSERT INTO results (run_id, metric, value, unit, created_at) VALUES (?, ?, ?, ?, ?)`,
+	))
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for _, result := range rec.Results {
+		if _, err := stmt.Exec(id, result.Metric, result.Value, result.Unit, result.CreatedAt); err != nil {
+			return fmt.Errorf("failed to insert result %s: %w", result.Metric, err)
+		}
+	}
+
+	for name, value := range rec.Run.Tags {
+		if _, err := tx.Exec(rebind(db.driver, `DELETE FROM run_tags WHERE run_id = ? AND name = ?`), id, name); err != nil {
+			return fmt.Errorf("failed to insert tag %s: %w", name, err)
+		}
+		if _, err := tx.Exec(rebind(db.driver, `INSERT INTO run_tags (run_id, name, value) VALUES (?, ?, ?)`), id, name, value); err != nil {
+			return fmt.Errorf("failed to insert tag %s: %w", name, err)
+		}
+	}
+
+	for _, note := range rec.Notes {
+		if _, err := tx.Exec(rebind(db.driver, `INSERT INTO run_notes (run_id, text, created_at) VALUES (?, ?, ?)`),
+			id, note.Text, note.CreatedAt); err != nil {
+			return fmt.Errorf("failed to insert note: %w", err)
+		}
+	}
+
+	for _, a := range rec.Annotations {
+		if _, err := tx.Exec(rebind(db.driver, `INSERT INTO annotations (run_id, event_time, source, kind, message) VALUES (?, ?, ?, ?, ?)`),
+			id, a.Time.UTC(), a.Source, a.Kind, a.Message); err != nil {
+			return fmt.Errorf("failed to insert annotation %s: %w", a.Kind, err)
+		}
+	}
+	return nil
+}
+
+// missing returns the rows of rec that have does not have; a nil have has
+// none. Results are complete when a run is first pushed and may have been
+// downsampled since, so they are only copied to a run that has none. Notes
+// and annotations are matched by content and time, in microseconds as
+// PostgreSQL stores them, and counted so identical rows are not collapsed.
+func (rec *RunRecord) missing(have *RunRecord) *RunRecord {
+	if have == nil {
+		return rec
+	}
+	out := &RunRecord{Run: rec.Run}
+	if len(have.Results) == 0 {
+		out.Results = rec.Results
+	}
+
+	notes := make(map[string]int)
+	for _, n := range have.Notes {
+		notes[rowKey(n.CreatedAt, n.Text)]++
+	}
+	for _, n := range rec.Notes {
+		if key := rowKey(n.CreatedAt, n.Text); notes[key] > 0 {
+			notes[key]--
+		} else {
+			out.Notes = append(out.Notes, n)
+		}
+	}
+
+	annotations := make(map[string]int)
+	for _, a := range have.Annotations {
+		annotations[rowKey(a.Time, a.Source, a.Kind, a.Message)]++
+	}
+	for _, a := range rec.Annotations {
+		if key := rowKey(a.Time, a.Source, a.Kind, a.Message); annotations[key] > 0 {
+			annotations[key]--
+		} else {
+			out.Annotations = append(out.Annotations, a)
+		}
+	}
+	return out
+}
+
+// rowKey identifies a row by its fields and time
+func rowKey(t time.Time, fields ...string) string {
+	return strings.Join(append(fields, strconv.FormatInt(t.UnixMicro(), 10)), "\x00")
+}
@@ -0,0 +1,210 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Note is a free-form remark on a run, such as "replaced PSU"
+type Note struct {
+	ID        int64     `json:"id"`
+	RunID     int64     `json:"run_id"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ParseTags reads tags written as name=value, e.g. customer=acme. A tag
+// without "=" has an empty value.
+func ParseTags(tags []string) (map[string]string, error) {
+	parsed := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		name, value, _ := strings.Cut(tag, "=")
+		name = strings.TrimSpace(name)
+		if name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("invalid tag %q: use name=value", tag)
+		}
+		parsed[name] = strings.TrimSpace(value)
+	}
+	return parsed, nil
+}
+
+// FormatTags writes tags as name=value, sorted by name
+func FormatTags(tags map[string]string) string {
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		if tags[name] != "" {
+			names[i] += "=" + tags[name]
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+// SetRunTags tags a run, replacing the values of tags it already has
+func (db *DB) SetRunTags(runID int64, tags map[string]string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	return db.transaction(func(tx *sql.Tx) error {
+		for name, value := range tags {
+			if _, err := tx.Exec(rebind(db.driver, `DELETE FROM run_tags WHERE run_id = ? AND name = ?`), runID, name); err != nil {
+				return fmt.Errorf("failed to tag run: %w", err)
+			}
+			if _, err := tx.Exec(rebind(db.driver, `INSERT INTO run_tags (run_id, name, value) VALUES (?, ?, ?)`), runID, name, value); err != nil {
+				return fmt.Errorf("failed to tag run: %w", err)
+			}
+		}
+		// Push the tags to the central server again
+		if _, err := tx.Exec(rebind(db.driver, `UPDATE runs SET synced_at = NULL WHERE id = ?`), runID); err != nil {
+			return fmt.Errorf("failed to tag run: %w", err)
+		}
+		return nil
+	})
+}
+
+// RemoveRunTags removes tags from a run by name
+func (db *DB) RemoveRunTags(runID int64, names ...string) error {
+	for _, name := range names {
+		if _, err := db.Exec(`DELETE FROM run_tags WHERE run_id = ? AND name = ?`, runID, name); err != nil {
+			return fmt.Errorf("failed to remove tag %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// RunTags returns the tags of a run, nil if it has none
+func (db *DB) RunTags(runID int64) (map[string]string, error) {
+	tags, err := db.runTags([]int64{runID})
+	if err != nil {
+		return nil, err
+	}
+	return tags[runID], nil
+}
+
+// runTags returns the tags of runs by run ID
+func (db *DB) runTags(ids []int64) (map[int64]map[string]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := db.Query(
+		`SELECT run_id, name, COALESCE(value, '') FROM run_tags WHERE run_id IN (?`+strings.Repeat(", ?", len(ids)-1)+`)`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get run tags: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	tags := make(map[int64]map[string]string)
+	for rows.Next() {
+		var (
+			id          int64
+			name, value string
+		)
+		if err := rows.Scan(&id, &name, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan run tag: %w", err)
+		}
+		if tags[id] == nil {
+			tags[id] = make(map[string]string)
+		}
+		tags[id][name] = value
+	}
+	return tags, rows.Err()
+}
+
+// attachTags sets the tags of runs
+func (db *DB) attachTags(runs []*Run) error {
+	ids := make([]int64, len(runs))
+	for i, run := range runs {
+		ids[i] = run.ID
+	}
+	tags, err := db.runTags(ids)
+	if err != nil {
+		return err
+	}
+	for _, run := range runs {
+		run.Tags = tags[run.ID]
+	}
+	return nil
+}
+
+// tagFilter returns the conditions matching runs that have every tag. A
+// tag with an empty value matches any value.
+func tagFilter(tags map[string]string) (string, []interface{}) {
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var (
+		query string
+		args  []interface{}
+	)
+	for _, name := range names {
+		query += " AND EXISTS (SELECT 1 FROM run_tags t WHERE t.run_id = runs.id AND t.name = ?"
+		args = append(args, name)
+		if tags[name] != "" {
+			query += " AND t.value = ?"
+			args = append(args, tags[name])
+		}
+		query += ")"
+	}
+	return query, args
+}
+
+// searchFilter returns the condition matching runs whose tags, notes or
+// error contain text, ignoring case
+func searchFilter(text string) (string, []interface{}) {
+	pattern := "%" + strings.ToLower(text) + "%"
+	query := ` AND (LOWER(COALESCE(runs.error, '')) LIKE ?
+		OR EXISTS (SELECT 1 FROM run_tags t WHERE t.run_id = runs.id AND (LOWER(t.name) LIKE ? OR LOWER(t.value) LIKE ?))
+		OR EXISTS (SELECT 1 FROM run_notes n WHERE n.run_id = runs.id AND LOWER(n.text) LIKE ?))`
+	return query, []interface{}{pattern, pattern, pattern, pattern}
+}
+
+// AddRunNote adds a note to a run
+func (db *DB) AddRunNote(runID int64, text string) (*Note, error) {
+	note := &Note{RunID: runID, Text: text, CreatedAt: time.Now()}
+	id, err := db.Insert(
+		`INSERT INTO run_notes (run_id, text, created_at) VALUES (?, ?, ?)`,
+		note.RunID, note.Text, note.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add note: %w", err)
+	}
+	note.ID = id
+	return note, db.MarkRunUnsynced(runID)
+}
+
+// RunNotes returns the notes of a run, oldest first
+func (db *DB) RunNotes(runID int64) ([]*Note, error) {
+	rows, err := db.Query(
+		`SELECT id, run_id, text, created_at FROM run_notes WHERE run_id = ? ORDER BY created_at, id`,
+		runID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notes: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var notes []*Note
+	for rows.Next() {
+		n := &Note{}
+		if err := rows.Scan(&n.ID, &n.RunID, &n.Text, &n.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan note: %w", err)
+		}
+		notes = append(notes, n)
+	}
+	return notes, rows.Err()
+}
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	tags, err := ParseTags([]string{"customer=acme", "build = retail", "rma"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"customer": "acme", "build": "retail", "rma": ""}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("expected %v, got %v", want, tags)
+	}
+	if got := FormatTags(tags); got != "build=retail customer=acme rma" {
+		t.Errorf("expected the tags sorted by name, got %q", got)
+	}
+	for _, tag := range []string{"", "=acme", "two words=x"} {
+		if _, err := ParseTags([]string{tag}); err == nil {
+			t.Errorf("expected ParseTags(%q) to fail", tag)
+		}
+	}
+}
+
+func TestRunTags(t *testing.T) {
+	database, err := Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = database.Close() }()
+
+	acme, err := database.CreateRun("cpu", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.SetRunTags(acme.ID, map[string]string{"customer": "acme", "build": "beta"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.SetRunTags(acme.ID, map[string]string{"build": "retail"}); err != nil {
+		t.Fatal(err)
+	}
+	other, err := database.CreateRun("cpu", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.SetRunTags(other.ID, map[string]string{"customer": "globex"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.AddRunNote(other.ID, "Replaced PSU"); err != nil {
+		t.Fatal(err)
+	}
+
+	run, err := database.GetRun(acme.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"customer": "acme", "build": "retail"}; !reflect.DeepEqual(run.Tags, want) {
+		t.Errorf("expected tags %v, got %v", want, run.Tags)
+	}
+
+	ids := func(filter RunFilter) []int64 {
+		t.Helper()
+		runs, err := database.ListRuns(filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []int64
+		for _, r := range runs {
+			ids = append(ids, r.ID)
+		}
+		return ids
+	}
+	for _, tc := range []struct {
+		filter RunFilter
+		want   []int64
+	}{
+		{RunFilter{Tags: map[string]string{"customer": "acme"}}, []int64{acme.ID}},
+		{RunFilter{Tags: map[string]string{"customer": "acme", "build": "beta"}}, nil},
+		{RunFilter{Tags: map[string]string{"customer": ""}}, []int64{acme.ID, other.ID}},
+		{RunFilter{Search: "psu"}, []int64{other.ID}},
+		{RunFilter{Search: "RETAIL"}, []int64{acme.ID}},
+	} {
+		got := ids(tc.filter)
+		if len(got) != len(tc.want) {
+			t.Errorf("expected %+v to match runs %v, got %v", tc.filter, tc.want, got)
+			continue
+		}
+		for _, id := range tc.want {
+			found := false
+			for _, g := range got {
+				found = found || g == id
+			}
+			if !found {
+				t.Errorf("expected %+v to match runs %v, got %v", tc.filter, tc.want, got)
+			}
+		}
+	}
+
+	notes, err := database.RunNotes(other.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 1 || notes[0].Text != "Replaced PSU" {
+		t.Errorf("expected the note, got %+v", notes)
+	}
+
+	if err := database.RemoveRunTags(acme.ID, "build"); err != nil {
+		t.Fatal(err)
+	}
+	if tags, err := database.RunTags(acme.ID); err != nil || len(tags) != 1 {
+		t.Errorf("expected one tag left, got %v (%v)", tags, err)
+	}
+}
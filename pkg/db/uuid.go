@@ -0,0 +1,55 @@
+package db
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// uuidPattern matches the canonical textual UUID form
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// NewRunUUID returns a time-ordered (version 7) UUID identifying a run across
+// machines. UUIDs generated later sort after earlier ones.
+func NewRunUUID() string {
+	return newUUIDv7(time.Now())
+}
+
+// newUUIDv7 builds a version 7 UUID from a millisecond timestamp and random bits
+func newUUIDv7(t time.Time) string {
+	var b [16]byte
+	if _, err := rand.Read(b[6:]); err != nil {
+		// crypto/rand never fails on supported platforms
+		panic(fmt.Sprintf("failed to read random bytes: %v", err))
+	}
+
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(t.UnixMilli())) // #nosec G115 -- timestamps after 1970 are positive
+	copy(b[0:6], ts[2:8])
+
+	b[6] = (b[6] & 0x0f) | 0x70 // version 7
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 9562 variant
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// IsUUID reports whether s is a UUID in canonical textual form
+func IsUUID(s string) bool {
+	return uuidPattern.MatchString(s)
+}
+
+// ResolveRun finds a run by numeric ID or UUID. UUIDs match in any case,
+// as they are stored in lower case.
+func (db *DB) ResolveRun(ref string) (*Run, error) {
+	if id, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		return db.GetRun(id)
+	}
+	if IsUUID(ref) {
+		return db.GetRunByUUID(strings.ToLower(ref))
+	}
+	return nil, fmt.Errorf("invalid run reference: %s", ref)
+}
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// VersionChange records a firmware, driver or OS version seen on a machine
+// that differs from the one seen before. The first version seen for a
+// component is recorded with an empty OldVersion as its baseline.
+type VersionChange struct {
+	ID         int64     `json:"id"`
+	Machine    string    `json:"machine"`
+	Component  string    `json:"component"` // e.g. "bios" or "gpu_driver"
+	OldVersion string    `json:"old_version,omitempty"`
+	NewVersion string    `json:"new_version"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// Baseline reports whether the change is the first version seen for its
+// component rather than an update
+func (c *VersionChange) Baseline() bool {
+	return c.OldVersion == ""
+}
+
+// RecordVersions compares the versions seen on machine at time at with the
+// latest recorded for each component, and records those that changed. It
+// returns the new changes in component order. Empty versions are ignored, so
+// a component that could not be read is not mistaken for an update.
+func (db *DB) RecordVersions(machine string, versions map[string]string, at time.Time) ([]*VersionChange, error) {
+	components := make([]string, 0, len(versions))
+	for component, version := range versions {
+		if version != "" {
+			components = append(components, component)
+		}
+	}
+	sort.Strings(components)
+
+	var changes []*VersionChange
+	for _, component := range components {
+		current, err := db.latestVersion(machine, component)
+		if err != nil {
+			return changes, err
+		}
+		if current == versions[component] {
+			continue
+		}
+
+		c := &VersionChange{
+			Machine:    machine,
+			Component:  component,
+			OldVersion: current,
+			NewVersion: versions[component],
+			DetectedAt: at,
+		}
+		id, err := db.Insert(
+			`INSERT INTO version_changes (machine, component, old_version, new_version, detected_at) VALUES (?, ?, ?, ?, ?)`,
+			c.Machine, c.Component, c.OldVersion, c.NewVersion, c.DetectedAt.UTC(),
+		)
+		if err != nil {
+			return changes, fmt.Errorf("failed to record version change: %w", err)
+		}
+		c.ID = id
+		changes = append(changes, c)
+	}
+	return changes, nil
+}
+
+// latestVersion returns the most recently recorded version of a component on
+// machine, or "" if none has been recorded
+func (db *DB) latestVersion(machine, component string) (string, error) {
+	var version string
+	err := db.QueryRow(
+		`SELECT new_version FROM version_changes WHERE machine = ? AND component = ? ORDER BY detected_at DESC, id DESC LIMIT 1`,
+		machine, component,
+	).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get latest version: %w", err)
+	}
+	return version, nil
+}
+
+// ListVersionChanges returns the version changes recorded for machine, oldest
+// first. An empty machine lists the changes of every machine.
+func (db *DB) ListVersionChanges(machine string) ([]*VersionChange, error) {
+	query := `SELECT id, machine, component, COALESCE(old_version, ''), new_version, detected_at FROM version_changes`
+	var args []interface{}
+	if machine != "" {
+		query += " WHERE machine = ?"
+		args = append(args, machine)
+	}
+	query += " ORDER BY detected_at, id"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list version changes: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var changes []*VersionChange
+	for rows.Next() {
+		c := &VersionChange{}
+		if err := rows.Scan(&c.ID, &c.Machine, &c.Component, &c.OldVersion, &c.NewVersion, &c.DetectedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan version change: %w", err)
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
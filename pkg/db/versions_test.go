@@ -0,0 +1,60 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordVersions(t *testing.T) {
+	database, err := Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = database.Close() }()
+
+	day := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	changes, err := database.RecordVersions("bench1", map[string]string{"bios": "F10", "gpu_driver": "550.54", "os": ""}, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || !changes[0].Baseline() || changes[0].Component != "bios" {
+		t.Fatalf("expected 2 baseline versions, got %+v", changes)
+	}
+
+	// Unchanged versions record nothing
+	changes, err = database.RecordVersions("bench1", map[string]string{"bios": "F10", "gpu_driver": "550.54"}, day.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("expected no changes, got %+v", changes)
+	}
+
+	changes, err = database.RecordVersions("bench1", map[string]string{"bios": "F10", "gpu_driver": "555.42"}, day.Add(48*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].OldVersion != "550.54" || changes[0].NewVersion != "555.42" || changes[0].Baseline() {
+		t.Errorf("expected a driver update, got %+v", changes)
+	}
+
+	if _, err := database.RecordVersions("bench2", map[string]string{"bios": "1.2"}, day); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := database.ListVersionChanges("bench1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 || list[2].NewVersion != "555.42" || !list[2].DetectedAt.Equal(day.Add(48*time.Hour)) {
+		t.Errorf("expected 3 changes for bench1 ending with the update, got %+v", list)
+	}
+	all, err := database.ListVersionChanges("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 4 {
+		t.Errorf("expected 4 changes across machines, got %d", len(all))
+	}
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BusyTimeout is how long a SQLite write waits for the lock held by another
+// process, such as the scheduler, the agent, the GUI or another bench
+// command, before it fails with "database is locked"
+const BusyTimeout = 10 * time.Second
+
+// errClosed is returned for writes queued after Close
+var errClosed = errors.New("database is closed")
+
+// writer runs the writes to a SQLite database one at a time on a single
+// goroutine, in the order they were queued. SQLite allows one writer at a
+// time, so writes from goroutines of one process no longer race each other
+// for the lock, and only wait for other processes, up to BusyTimeout.
+type writer struct {
+	jobs    chan func()
+	done    chan struct{} // Closed to stop the writer
+	stopped chan struct{} // Closed once it stopped
+	once    sync.Once
+}
+
+func newWriter() *writer {
+	w := &writer{jobs: make(chan func()), done: make(chan struct{}), stopped: make(chan struct{})}
+	go w.run()
+	return w
+}
+
+func (w *writer) run() {
+	defer close(w.stopped)
+	for {
+		select {
+		case job := <-w.jobs:
+			job()
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// do queues fn and waits for it to run
+func (w *writer) do(fn func() error) error {
+	result := make(chan error, 1)
+	select {
+	case w.jobs <- func() { result <- fn() }:
+		return <-result
+	case <-w.done:
+		return errClosed
+	}
+}
+
+// close stops the writer, waiting for the write in progress, if any
+func (w *writer) close() {
+	w.once.Do(func() { close(w.done) })
+	<-w.stopped
+}
+
+// write runs fn on the writer of a SQLite database. PostgreSQL handles
+// concurrent writers itself, so fn runs directly. fn must not call write
+// again, which would wait for itself.
+func (db *DB) write(fn func() error) error {
+	if db.writes == nil {
+		return fn()
+	}
+	return db.writes.do(fn)
+}
+
+// transaction runs fn in a transaction on the writer, committing it if fn
+// succeeds
+func (db *DB) transaction(fn func(tx *sql.Tx) error) error {
+	return db.write(func() error {
+		tx, err := db.conn.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer func() {
+			// Only rollback if we haven't committed
+			_ = tx.Rollback()
+		}()
+
+		if err := fn(tx); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fire.db")
+	// Two handles on one file stand in for the GUI and a scheduled bench run
+	gui, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = gui.Close() }()
+	cli, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cli.Close() }()
+
+	const writers, writes = 8, 25
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*writers*writes*2)
+	for _, database := range []*DB{gui, cli} {
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func(database *DB) {
+				defer wg.Done()
+				for j := 0; j < writes; j++ {
+					run, err := database.CreateRun("cpu", nil)
+					if err != nil {
+						errs <- err
+						continue
+					}
+					errs <- database.CreateResults(run.ID, map[string]float64{"a": 1, "b": 2}, nil)
+					// A transaction that reads before it writes must hold the
+					// lock from the start, or it fails once another writer
+					// commits in between
+					errs <- database.transaction(func(tx *sql.Tx) error {
+						var n int
+						if err := tx.QueryRow(`SELECT COUNT(*) FROM results WHERE run_id = ?`, run.ID).Scan(&n); err != nil {
+							return err
+						}
+						_, err := tx.Exec(`INSERT INTO results (run_id, metric, value) VALUES (?, ?, ?)`, run.ID, "count", n)
+						return err
+					})
+				}
+			}(database)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("expected writers to wait their turn, got %v", err)
+		}
+	}
+
+	var results int
+	if err := gui.QueryRow(`SELECT COUNT(*) FROM results`).Scan(&results); err != nil {
+		t.Fatal(err)
+	}
+	if results != 2*writers*writes*3 {
+		t.Errorf("expected %d results, got %d", 2*writers*writes*3, results)
+	}
+}
+
+func TestWriteAfterClose(t *testing.T) {
+	database, err := Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.Exec(`DELETE FROM runs`); !errors.Is(err, errClosed) {
+		t.Errorf("expected writes after Close to fail, got %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+// Package dbsync pushes locally recorded runs to a central results server.
+//
+// Agents always write to their local database first so tests keep working
+// when the network is down. The worker drains completed runs that have not
+// been synced yet and imports them into the central database with their
+// results, tags, notes and annotations, matching runs by UUID so a run is
+// never stored twice. Tagging a run or adding a note queues it again.
+package dbsync
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+// DefaultInterval is how often the worker retries when running continuously
+const DefaultInterval = 5 * time.Minute
+
+// batchSize limits the number of runs pushed per sync pass
+const batchSize = 100
+
+// Worker pushes unsynced local runs to a remote database
+type Worker struct {
+	local  *db.DB
+	remote db.Config
+	logger *log.Logger
+}
+
+// NewWorker creates a new sync worker
+func NewWorker(local *db.DB, remote db.Config, logger *log.Logger) (*Worker, error) {
+	if err := remote.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid sync target: %w", err)
+	}
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Worker{local: local, remote: remote, logger: logger}, nil
+}
+
+// SyncOnce pushes all pending runs and returns how many were marked synced.
+// Runs already present on the server get the tags, notes and annotations
+// added since they were last pushed.
+func (w *Worker) SyncOnce() (int, error) {
+	pending, err := w.local.ListUnsyncedRuns(batchSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	remote, err := db.OpenConfig(w.remote)
+	if err != nil {
+		return 0, fmt.Errorf("central server unreachable: %w", err)
+	}
+	defer func() { _ = remote.Close() }()
+
+	synced := 0
+	for _, run := range pending {
+		rec, err := w.local.LoadRunRecord(run)
+		if err != nil {
+			return synced, fmt.Errorf("failed to load run %d: %w", run.ID, err)
+		}
+
+		remoteID, imported, err := remote.ImportRun(rec)
+		if err != nil {
+			return synced, fmt.Errorf("failed to push run %s: %w", run.UUID, err)
+		}
+
+		if err := w.local.MarkRunSynced(run.ID); err != nil {
+			return synced, err
+		}
+		synced++
+
+		if imported {
+			w.logger.Printf("Pushed run %d (%s) as remote run %d", run.ID, run.UUID, remoteID)
+		} else {
+			w.logger.Printf("Merged run %d (%s) into remote run %d", run.ID, run.UUID, remoteID)
+		}
+	}
+
+	return synced, nil
+}
+
+// Run syncs immediately and then every interval until ctx is canceled.
+// Errors are logged and retried on the next tick.
+func (w *Worker) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := w.SyncOnce(); err != nil {
+			w.logger.Printf("Sync failed, will retry in %s: %v", interval, err)
+		} else if n > 0 {
+			w.logger.Printf("Synced %d runs", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
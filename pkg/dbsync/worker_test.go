@@ -0,0 +1,147 @@
+package dbsync
+
+import (
+	"io"
+	"log"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+func openTestDB(t *testing.T, path string) *db.DB {
+	t.Helper()
+	database, err := db.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+	return database
+}
+
+// count returns the result of a COUNT query
+func count(t *testing.T, database *db.DB, query string, args ...interface{}) int {
+	t.Helper()
+	var n int
+	if err := database.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestSyncOnce(t *testing.T) {
+	dir := t.TempDir()
+	local := openTestDB(t, filepath.Join(dir, "local.db"))
+	remotePath := filepath.Join(dir, "central.db")
+
+	finished, err := local.CreateRun("cpu", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := local.CreateResults(finished.ID, map[string]float64{"score": 42, "cpu_temp_peak": 81}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := local.SetRunTags(finished.ID, map[string]string{"customer": "acme"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := local.AddRunNote(finished.ID, "replaced PSU"); err != nil {
+		t.Fatal(err)
+	}
+	if err := local.CreateAnnotation(&db.Annotation{RunID: &finished.ID, Time: finished.StartTime, Source: "system", Kind: "on_battery", Message: "UPS on battery"}); err != nil {
+		t.Fatal(err)
+	}
+	end := time.Now()
+	finished.EndTime = &end
+	finished.Success = true
+	if err := local.UpdateRun(finished); err != nil {
+		t.Fatal(err)
+	}
+	running, err := local.CreateRun("memory", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	worker, err := NewWorker(local, db.Config{Path: remotePath}, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := worker.SyncOnce(); err != nil || n != 1 {
+		t.Fatalf("expected the finished run to be synced, got %d, %v", n, err)
+	}
+
+	const synced = `SELECT COUNT(*) FROM runs WHERE id = ? AND synced_at IS NOT NULL`
+	if count(t, local, synced, finished.ID) != 1 {
+		t.Error("expected the finished run to be marked synced")
+	}
+	if count(t, local, synced, running.ID) != 0 {
+		t.Error("expected the running run to be left for a later pass")
+	}
+	if n, err := worker.SyncOnce(); err != nil || n != 0 {
+		t.Errorf("expected nothing left to sync, got %d, %v", n, err)
+	}
+
+	// A run whose mark was lost is pushed again without being stored twice
+	if _, err := local.Exec(`UPDATE runs SET synced_at = NULL WHERE id = ?`, finished.ID); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := worker.SyncOnce(); err != nil || n != 1 {
+		t.Fatalf("expected the run to be marked synced again, got %d, %v", n, err)
+	}
+
+	remote := openTestDB(t, remotePath)
+	if n := count(t, remote, `SELECT COUNT(*) FROM runs`); n != 1 {
+		t.Fatalf("expected one run on the server, got %d", n)
+	}
+	run, err := remote.GetRunByUUID(finished.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !run.Success || run.EndTime == nil || run.Plugin != "cpu" {
+		t.Errorf("expected the finished run to be copied, got %+v", run)
+	}
+	results, err := remote.GetResults(run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Errorf("expected the results to be copied once, got %+v", results)
+	}
+	if run.Tags["customer"] != "acme" {
+		t.Errorf("expected the tags to be copied, got %v", run.Tags)
+	}
+	if n := count(t, remote, `SELECT COUNT(*) FROM annotations WHERE run_id = ? AND kind = 'on_battery'`, run.ID); n != 1 {
+		t.Errorf("expected the annotation to be copied once, got %d", n)
+	}
+
+	// Tags and notes added after the run was pushed queue it again and are
+	// merged into the run on the server
+	if err := local.SetRunTags(finished.ID, map[string]string{"customer": "globex", "rack": "7"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := local.AddRunNote(finished.ID, "reseated DIMM"); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := worker.SyncOnce(); err != nil || n != 1 {
+		t.Fatalf("expected the edited run to be synced again, got %d, %v", n, err)
+	}
+	if run, err = remote.GetRunByUUID(finished.UUID); err != nil {
+		t.Fatal(err)
+	}
+	if run.Tags["customer"] != "globex" || run.Tags["rack"] != "7" {
+		t.Errorf("expected the tags to be merged, got %v", run.Tags)
+	}
+	notes, err := remote.RunNotes(run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 2 || notes[0].Text != "replaced PSU" || notes[1].Text != "reseated DIMM" {
+		t.Errorf("expected both notes once, got %+v", notes)
+	}
+	if n := count(t, remote, `SELECT COUNT(*) FROM results WHERE run_id = ?`, run.ID); n != 2 {
+		t.Errorf("expected the results not to be copied again, got %d", n)
+	}
+	if n := count(t, remote, `SELECT COUNT(*) FROM annotations WHERE run_id = ?`, run.ID); n != 1 {
+		t.Errorf("expected the annotation not to be copied again, got %d", n)
+	}
+}
@@ -0,0 +1,263 @@
+// Package ecc reads the error counters of ECC memory, so a burn-in can tell
+// whether the memory corrected errors, or failed to, while it ran.
+//
+// On Linux the counters come from the EDAC driver in
+// /sys/devices/system/edac/mc, per memory controller and DIMM. Windows keeps
+// no counters; the corrected and uncorrected errors WHEA logged in the
+// System event log are counted instead. A Watch reads the counters before
+// the run, during it and after it. The increase is saved with the run as
+// results (ecc_corrected and ecc_uncorrected) and every reading that found
+// new errors as an "ecc" annotation, so the report lists when they happened.
+package ecc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+// DefaultInterval is the time between readings during a run
+const DefaultInterval = 10 * time.Second
+
+// AnnotationKind is the kind of the annotations new errors are saved as
+const AnnotationKind = "ecc"
+
+// Metric names saved with the run
+const (
+	MetricCorrected   = "ecc_corrected"
+	MetricUncorrected = "ecc_uncorrected"
+)
+
+// ErrUnavailable is returned when the machine reports no ECC error counters:
+// its memory has no ECC or no EDAC driver is loaded for its controller
+var ErrUnavailable = errors.New("no ECC error counters found")
+
+// Location is the count of one memory controller or DIMM
+type Location struct {
+	Name        string `json:"name"`            // e.g. "mc0" or "mc0/dimm2"
+	Label       string `json:"label,omitempty"` // Slot name the firmware gives, e.g. "DIMM_A1"
+	Corrected   int64  `json:"corrected"`
+	Uncorrected int64  `json:"uncorrected"`
+}
+
+// String names the location by its slot when known, e.g. "mc0/dimm2 (DIMM_A1)"
+func (l Location) String() string {
+	if l.Label == "" {
+		return l.Name
+	}
+	return fmt.Sprintf("%s (%s)", l.Name, l.Label)
+}
+
+// Counts is one reading of the error counters
+type Counts struct {
+	Source      string     `json:"source"` // "edac" or "whea"
+	Corrected   int64      `json:"corrected"`
+	Uncorrected int64      `json:"uncorrected"`
+	Locations   []Location `json:"locations,omitempty"`
+}
+
+// Read returns the error counters of the machine, or ErrUnavailable
+func Read(ctx context.Context) (*Counts, error) {
+	return read(ctx)
+}
+
+// Since returns the errors counted after before. Counters that went down
+// were reset, e.g. by a reboot or driver reload, and count from zero.
+func (c *Counts) Since(before *Counts) *Counts {
+	delta := func(after, before int64) int64 {
+		if after < before {
+			return after
+		}
+		return after - before
+	}
+	d := &Counts{
+		Source:      c.Source,
+		Corrected:   delta(c.Corrected, before.Corrected),
+		Uncorrected: delta(c.Uncorrected, before.Uncorrected),
+	}
+	previous := make(map[string]Location, len(before.Locations))
+	for _, l := range before.Locations {
+		previous[l.Name] = l
+	}
+	for _, l := range c.Locations {
+		p := previous[l.Name]
+		l.Corrected = delta(l.Corrected, p.Corrected)
+		l.Uncorrected = delta(l.Uncorrected, p.Uncorrected)
+		if l.Corrected > 0 || l.Uncorrected > 0 {
+			d.Locations = append(d.Locations, l)
+		}
+	}
+	return d
+}
+
+// Describe explains the errors, e.g. "2 corrected ECC errors on mc0/dimm1
+// (DIMM_A2)"
+func (c *Counts) Describe() string {
+	var parts []string
+	if c.Corrected > 0 {
+		parts = append(parts, fmt.Sprintf("%d corrected", c.Corrected))
+	}
+	if c.Uncorrected > 0 {
+		parts = append(parts, fmt.Sprintf("%d uncorrected", c.Uncorrected))
+	}
+	if len(parts) == 0 {
+		return "No ECC errors"
+	}
+	s := strings.Join(parts, " and ") + " ECC errors"
+	if c.Corrected+c.Uncorrected == 1 {
+		s = strings.TrimSuffix(s, "s")
+	}
+	if len(c.Locations) > 0 {
+		names := make([]string, len(c.Locations))
+		for i, l := range c.Locations {
+			names[i] = l.String()
+		}
+		s += " on " + strings.Join(names, ", ")
+	}
+	return s
+}
+
+// Event is a reading during the run that found new errors
+type Event struct {
+	Time   time.Time
+	Errors *Counts // Errors counted since the previous reading
+}
+
+// Report is what a watch counted over a run
+type Report struct {
+	Before *Counts // nil when the counters could not be read
+	After  *Counts
+	Events []Event
+}
+
+// Available reports whether the counters were read before and after the run
+func (r *Report) Available() bool {
+	return r.Before != nil && r.After != nil
+}
+
+// Errors returns the errors counted during the run
+func (r *Report) Errors() *Counts {
+	if !r.Available() {
+		return &Counts{}
+	}
+	return r.After.Since(r.Before)
+}
+
+// Summary describes the report in one line
+func (r *Report) Summary() string {
+	if !r.Available() {
+		return "ECC errors not counted: " + ErrUnavailable.Error()
+	}
+	return r.Errors().Describe()
+}
+
+// Metrics returns the results saved with the run
+func (r *Report) Metrics() (values map[string]float64, units map[string]string) {
+	e := r.Errors()
+	values = map[string]float64{
+		MetricCorrected:   float64(e.Corrected),
+		MetricUncorrected: float64(e.Uncorrected),
+	}
+	return values, map[string]string{}
+}
+
+// Save stores the report with the run: the error counts as results and each
+// reading that found new errors as an annotation
+func (r *Report) Save(database *db.DB, runID int64) error {
+	if !r.Available() {
+		return nil
+	}
+	values, units := r.Metrics()
+	if err := database.CreateResults(runID, values, units); err != nil {
+		return err
+	}
+	for _, e := range r.Events {
+		a := &db.Annotation{RunID: &runID, Time: e.Time, Source: "memory", Kind: AnnotationKind, Message: e.Errors.Describe()}
+		if err := database.CreateAnnotation(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FromResults reads the error counts back from a run's results. ok is false
+// when the run was not watched or the machine has no counters.
+func FromResults(results []*db.Result) (corrected, uncorrected int64, ok bool) {
+	for _, r := range results {
+		switch r.Metric {
+		case MetricCorrected:
+			corrected, ok = int64(r.Value), true
+		case MetricUncorrected:
+			uncorrected = int64(r.Value)
+		}
+	}
+	return corrected, uncorrected, ok
+}
+
+// ReadFunc takes one reading
+type ReadFunc func(ctx context.Context) (*Counts, error)
+
+// Watch reads the counters in the background until stopped
+type Watch struct {
+	cancel context.CancelFunc
+	done   chan *Report
+}
+
+// Start reads the counters once before returning, so errors that predate
+// the run are not blamed on it, then every interval until ctx ends or Stop
+// is called. A machine without counters gives a report that is not
+// Available.
+func Start(ctx context.Context, read ReadFunc, interval time.Duration) *Watch {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	before, err := read(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	w := &Watch{cancel: cancel, done: make(chan *Report, 1)}
+	if err != nil {
+		w.done <- &Report{}
+		return w
+	}
+
+	go func() {
+		r := &Report{Before: before}
+		last := before
+		check := func(ctx context.Context) {
+			c, err := read(ctx)
+			if err != nil {
+				return
+			}
+			if d := c.Since(last); d.Corrected > 0 || d.Uncorrected > 0 {
+				r.Events = append(r.Events, Event{Time: time.Now(), Errors: d})
+			}
+			last = c
+			r.After = c
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				// The final reading is taken even though the run has ended
+				final, cancel := context.WithTimeout(context.WithoutCancel(ctx), DefaultInterval)
+				check(final)
+				cancel()
+				w.done <- r
+				return
+			case <-ticker.C:
+			}
+			check(ctx)
+		}
+	}()
+	return w
+}
+
+// Stop ends the watch and returns what it counted
+func (w *Watch) Stop() *Report {
+	w.cancel()
+	return <-w.done
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package ecc
+
+import "context"
+
+// read finds no counters: only Linux (EDAC) and Windows (WHEA) report them
+func read(_ context.Context) (*Counts, error) {
+	return nil, ErrUnavailable
+}
@@ -0,0 +1,86 @@
+package ecc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSince(t *testing.T) {
+	before := &Counts{Corrected: 5, Uncorrected: 0, Locations: []Location{
+		{Name: "mc0/dimm0", Corrected: 5},
+		{Name: "mc0/dimm1"},
+	}}
+	after := &Counts{Corrected: 8, Uncorrected: 1, Locations: []Location{
+		{Name: "mc0/dimm0", Corrected: 5},
+		{Name: "mc0/dimm1", Label: "DIMM_A2", Corrected: 3, Uncorrected: 1},
+	}}
+
+	d := after.Since(before)
+	if d.Corrected != 3 || d.Uncorrected != 1 {
+		t.Errorf("expected 3 corrected and 1 uncorrected, got %+v", d)
+	}
+	if len(d.Locations) != 1 || d.Locations[0].Name != "mc0/dimm1" {
+		t.Errorf("expected only the DIMM with new errors, got %+v", d.Locations)
+	}
+	if got := d.Describe(); got != "3 corrected and 1 uncorrected ECC errors on mc0/dimm1 (DIMM_A2)" {
+		t.Errorf("unexpected description %q", got)
+	}
+
+	// A counter that went down was reset and counts from zero
+	if d := (&Counts{Corrected: 2}).Since(before); d.Corrected != 2 {
+		t.Errorf("expected a reset counter to count from zero, got %d", d.Corrected)
+	}
+	if got := (&Counts{Corrected: 1}).Describe(); got != "1 corrected ECC error" {
+		t.Errorf("unexpected description %q", got)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	var mu sync.Mutex
+	corrected := int64(4)
+	read := func(context.Context) (*Counts, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return &Counts{Source: "edac", Corrected: corrected}, nil
+	}
+
+	w := Start(context.Background(), read, 10*time.Millisecond)
+	mu.Lock()
+	corrected = 6
+	mu.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	r := w.Stop()
+
+	if !r.Available() || r.Errors().Corrected != 2 {
+		t.Fatalf("expected 2 corrected errors during the run, got %+v", r)
+	}
+	if len(r.Events) != 1 || r.Events[0].Errors.Corrected != 2 {
+		t.Errorf("expected one event with 2 errors, got %+v", r.Events)
+	}
+	if values, _ := r.Metrics(); values[MetricCorrected] != 2 || values[MetricUncorrected] != 0 {
+		t.Errorf("unexpected metrics %v", values)
+	}
+
+	// Without counters the report is not available and nothing is saved
+	w = Start(context.Background(), func(context.Context) (*Counts, error) { return nil, ErrUnavailable }, time.Millisecond)
+	if r := w.Stop(); r.Available() || r.Save(nil, 1) != nil {
+		t.Errorf("expected an unavailable report, got %+v", r)
+	}
+}
+
+func TestParseWHEA(t *testing.T) {
+	c, err := parseWHEA("ecc 6\r\n47 3\r\n19 2\r\n18 1\r\n1 40\r\n")
+	if err != nil {
+		t.Fatalf("parseWHEA failed: %v", err)
+	}
+	if c.Corrected != 5 || c.Uncorrected != 1 || c.Source != "whea" {
+		t.Errorf("expected 5 corrected and 1 uncorrected, got %+v", c)
+	}
+
+	if _, err := parseWHEA("ecc 3\r\n47 3\r\n"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected memory without ECC to be unavailable, got %v", err)
+	}
+}
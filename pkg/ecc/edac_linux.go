@@ -0,0 +1,104 @@
+//go:build linux
+// +build linux
+
+package ecc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sysfsRoot is where sysfs is looked up, replaced in tests
+var sysfsRoot = "/"
+
+// read sums the EDAC counters of every memory controller. Each controller
+// lists its DIMMs as dimm* directories, or as csrow* chip-select rows on
+// older drivers.
+func read(_ context.Context) (*Counts, error) {
+	controllers, _ := filepath.Glob(filepath.Join(sysfsRoot, "sys/devices/system/edac/mc/mc*"))
+	sort.Strings(controllers)
+
+	c := &Counts{Source: "edac"}
+	found := false
+	for _, mc := range controllers {
+		ce, errCE := readCount(filepath.Join(mc, "ce_count"))
+		ue, errUE := readCount(filepath.Join(mc, "ue_count"))
+		if errCE != nil || errUE != nil {
+			continue
+		}
+		found = true
+		c.Corrected += ce
+		c.Uncorrected += ue
+
+		name := filepath.Base(mc)
+		dimms := dimmLocations(mc, name)
+		if len(dimms) == 0 {
+			dimms = []Location{{Name: name, Corrected: ce, Uncorrected: ue}}
+		}
+		c.Locations = append(c.Locations, dimms...)
+	}
+	if !found {
+		return nil, ErrUnavailable
+	}
+	return c, nil
+}
+
+// dimmLocations reads the per-DIMM counters of a controller
+func dimmLocations(mc, name string) []Location {
+	var locations []Location
+	dimms, _ := filepath.Glob(filepath.Join(mc, "dimm*"))
+	sort.Strings(dimms)
+	for _, dimm := range dimms {
+		ce, errCE := readCount(filepath.Join(dimm, "dimm_ce_count"))
+		ue, errUE := readCount(filepath.Join(dimm, "dimm_ue_count"))
+		if errCE != nil || errUE != nil {
+			continue
+		}
+		locations = append(locations, Location{
+			Name:        name + "/" + filepath.Base(dimm),
+			Label:       readString(filepath.Join(dimm, "dimm_label")),
+			Corrected:   ce,
+			Uncorrected: ue,
+		})
+	}
+	if len(locations) > 0 {
+		return locations
+	}
+
+	rows, _ := filepath.Glob(filepath.Join(mc, "csrow*"))
+	sort.Strings(rows)
+	for _, row := range rows {
+		ce, errCE := readCount(filepath.Join(row, "ce_count"))
+		ue, errUE := readCount(filepath.Join(row, "ue_count"))
+		if errCE != nil || errUE != nil {
+			continue
+		}
+		locations = append(locations, Location{
+			Name:        name + "/" + filepath.Base(row),
+			Label:       readString(filepath.Join(row, "ch0_dimm_label")),
+			Corrected:   ce,
+			Uncorrected: ue,
+		})
+	}
+	return locations
+}
+
+func readCount(path string) (int64, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- sysfs path built from a glob
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
+
+func readString(path string) string {
+	data, err := os.ReadFile(path) // #nosec G304 -- sysfs path built from a glob
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
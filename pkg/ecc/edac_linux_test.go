@@ -0,0 +1,58 @@
+package ecc
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadEDAC(t *testing.T) {
+	root := t.TempDir()
+	old := sysfsRoot
+	sysfsRoot = root
+	t.Cleanup(func() { sysfsRoot = old })
+
+	if _, err := read(context.Background()); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected ErrUnavailable without EDAC, got %v", err)
+	}
+
+	mc := filepath.Join(root, "sys/devices/system/edac/mc")
+	for path, content := range map[string]string{
+		"mc0/ce_count":              "3\n",
+		"mc0/ue_count":              "0\n",
+		"mc0/dimm0/dimm_ce_count":   "1\n",
+		"mc0/dimm0/dimm_ue_count":   "0\n",
+		"mc0/dimm0/dimm_label":      "DIMM_A1\n",
+		"mc0/dimm1/dimm_ce_count":   "2\n",
+		"mc0/dimm1/dimm_ue_count":   "0\n",
+		"mc1/ce_count":              "0\n",
+		"mc1/ue_count":              "1\n",
+		"mc1/csrow0/ce_count":       "0\n",
+		"mc1/csrow0/ue_count":       "1\n",
+		"mc1/csrow0/ch0_dimm_label": "CPU1_DIMM_B1\n",
+	} {
+		file := filepath.Join(mc, path)
+		if err := os.MkdirAll(filepath.Dir(file), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, err := read(context.Background())
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if c.Corrected != 3 || c.Uncorrected != 1 {
+		t.Errorf("expected 3 corrected and 1 uncorrected, got %+v", c)
+	}
+	if len(c.Locations) != 3 {
+		t.Fatalf("expected 2 DIMMs and 1 chip-select row, got %+v", c.Locations)
+	}
+	if c.Locations[0].String() != "mc0/dimm0 (DIMM_A1)" || c.Locations[2].Name != "mc1/csrow0" || c.Locations[2].Uncorrected != 1 {
+		t.Errorf("unexpected locations %+v", c.Locations)
+	}
+}
@@ -0,0 +1,56 @@
+package ecc
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+)
+
+// WHEA-Logger event IDs counted as memory errors. WHEA logs errors per
+// event rather than per DIMM, and corrected machine checks include the
+// ECC corrections many platforms report that way.
+var (
+	wheaCorrected   = map[int]bool{19: true, 47: true} // Corrected machine check, corrected memory error
+	wheaUncorrected = map[int]bool{18: true, 20: true} // Fatal machine check, fatal hardware error
+)
+
+// eccModes are the Win32_PhysicalMemoryArray MemoryErrorCorrection values
+// that mean ECC: single-bit ECC, multi-bit ECC and CRC
+var eccModes = map[int]bool{5: true, 6: true, 7: true}
+
+// parseWHEA reads the output of wheaScript: an "ecc <mode>" line with the
+// error correction of the memory, then an "<event ID> <count>" line for
+// every WHEA-Logger event ID in the System log
+func parseWHEA(output string) (*Counts, error) {
+	c := &Counts{Source: "whea"}
+	ecc := false
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		n, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		if fields[0] == "ecc" {
+			ecc = ecc || eccModes[n]
+			continue
+		}
+		id, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		switch {
+		case wheaCorrected[id]:
+			c.Corrected += int64(n)
+		case wheaUncorrected[id]:
+			c.Uncorrected += int64(n)
+		}
+	}
+	if !ecc {
+		return nil, ErrUnavailable
+	}
+	return c, nil
+}
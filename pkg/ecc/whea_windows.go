@@ -0,0 +1,30 @@
+//go:build windows
+// +build windows
+
+package ecc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// wheaScript prints the error correction of each memory array and the number
+// of WHEA-Logger events in the System log by event ID
+const wheaScript = `Get-CimInstance Win32_PhysicalMemoryArray | ForEach-Object { "ecc $($_.MemoryErrorCorrection)" }; ` +
+	`Get-WinEvent -FilterHashtable @{LogName='System'; ProviderName='Microsoft-Windows-WHEA-Logger'} -ErrorAction SilentlyContinue | ` +
+	`Group-Object Id | ForEach-Object { "$($_.Name) $($_.Count)" }`
+
+// read counts the memory errors WHEA logged
+func read(ctx context.Context) (*Counts, error) {
+	cmd := safeexec.PowerShell(wheaScript)
+	output, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("failed to read WHEA events: %w", err)
+	}
+	return parseWHEA(string(output))
+}
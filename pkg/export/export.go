@@ -0,0 +1,158 @@
+// Package export writes test runs in the formats other tools ingest: JUnit
+// XML for CI servers such as Jenkins, newline-delimited JSON for ingestion
+// pipelines and CSV of the sensor samples recorded while a run was going.
+//
+// The JSON report and the CSV of results stay with pkg/session and pkg/db;
+// bench export selects any of them with --format. Every NDJSON line and every
+// JUnit test suite carries the format it follows and SchemaVersion, raised
+// only when a field is removed or changes meaning, as with the schemas in
+// pkg/schema.
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+// Format is an export format
+type Format string
+
+// Export formats
+const (
+	JSON       Format = "json"        // Report of one run, see session.NewReport
+	CSV        Format = "csv"         // Results, one row per metric
+	JUnit      Format = "junit"       // JUnit XML, one test case per run
+	NDJSON     Format = "ndjson"      // One JSON record per line
+	SamplesCSV Format = "samples-csv" // Sensor samples taken during the runs
+)
+
+// Formats lists the export formats in the order they are documented
+var Formats = []Format{JSON, CSV, JUnit, NDJSON, SamplesCSV}
+
+// SchemaVersion is the version of the JUnit and NDJSON layouts
+const SchemaVersion = 1
+
+// ParseFormat returns the format named by s
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case JSON, CSV, JUnit, NDJSON, SamplesCSV:
+		return f, nil
+	case "xml":
+		return JUnit, nil
+	case "jsonl":
+		return NDJSON, nil
+	}
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = string(f)
+	}
+	return "", fmt.Errorf("unknown export format %q (use %s)", s, strings.Join(names, ", "))
+}
+
+// Run is a test run to export with its results and, when loaded with them,
+// the sensor samples recorded while it ran
+type Run struct {
+	Run     *db.Run
+	Results []*db.Result
+	Samples []Sample
+}
+
+// Sample is the sensor readings of one metric over one history bucket
+type Sample struct {
+	Time    time.Time // Start of the bucket
+	Metric  string    // Display name including the unit, e.g. "CPU Temp (°C)"
+	Count   int       // Readings in the bucket
+	Average float64
+	Min     float64
+	Max     float64
+}
+
+// Load reads the results of a run and, with samples, the sensor history
+// recorded while it ran
+func Load(database *db.DB, run *db.Run, samples bool) (*Run, error) {
+	results, err := database.GetResults(run.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get results of run %d: %w", run.ID, err)
+	}
+	r := &Run{Run: run, Results: results}
+	if samples {
+		if r.Samples, err = Samples(database, run); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Samples returns the sensor history recorded between the start and end of
+// a run, sorted by time and metric. It comes from the finest history tier
+// that still reaches back to the start, so samples of older runs are
+// coarser, and runs older than a week have none.
+func Samples(database *db.DB, run *db.Run) ([]Sample, error) {
+	until := time.Now()
+	if run.EndTime != nil {
+		until = *run.EndTime
+	}
+	tier := db.HistoryTierFor(time.Since(run.StartTime))
+	if time.Since(run.StartTime) > tier.Retention() {
+		return nil, nil
+	}
+
+	metrics, err := database.SensorHistoryMetrics()
+	if err != nil {
+		return nil, err
+	}
+	var samples []Sample
+	for _, metric := range metrics {
+		buckets, err := database.SensorHistoryTier(tier, metric, run.StartTime, until)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range buckets {
+			samples = append(samples, Sample{Time: b.Start, Metric: metric, Count: b.Count, Average: b.Avg, Min: b.Min, Max: b.Max})
+		}
+	}
+	sort.SliceStable(samples, func(i, j int) bool {
+		if !samples[i].Time.Equal(samples[j].Time) {
+			return samples[i].Time.Before(samples[j].Time)
+		}
+		return samples[i].Metric < samples[j].Metric
+	})
+	return samples, nil
+}
+
+// WriteSamplesCSV writes the samples of the runs as CSV, one row per metric
+// and bucket with its start time in UTC
+func WriteSamplesCSV(w io.Writer, runs []*Run) error {
+	csvWriter := csv.NewWriter(w)
+	headers := []string{"Run ID", "Run UUID", "Plugin", "Time", "Metric", "Samples", "Average", "Min", "Max"}
+	if err := csvWriter.Write(headers); err != nil {
+		return fmt.Errorf("failed to write headers: %w", err)
+	}
+	for _, r := range runs {
+		for _, s := range r.Samples {
+			row := []string{
+				strconv.FormatInt(r.Run.ID, 10),
+				r.Run.UUID,
+				r.Run.Plugin,
+				s.Time.UTC().Format(time.RFC3339),
+				s.Metric,
+				strconv.Itoa(s.Count),
+				fmt.Sprintf("%.6f", s.Average),
+				fmt.Sprintf("%.6f", s.Min),
+				fmt.Sprintf("%.6f", s.Max),
+			}
+			if err := csvWriter.Write(row); err != nil {
+				return fmt.Errorf("failed to write row: %w", err)
+			}
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
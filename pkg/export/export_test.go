@@ -0,0 +1,149 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/schema"
+)
+
+func testRuns() []*Run {
+	start := time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)
+	end := start.Add(90 * time.Second)
+	return []*Run{
+		{
+			Run:     &db.Run{ID: 41, UUID: "uuid-41", Plugin: "cpu", Machine: "rig-01", StartTime: start, EndTime: &end, Success: true, Stdout: "done"},
+			Results: []*db.Result{{RunID: 41, Metric: "cpu_temp_peak", Value: 81.5, Unit: "°C"}},
+			Samples: []Sample{{Time: start, Metric: "CPU Temp (°C)", Count: 10, Average: 0, Min: 0, Max: 1}},
+		},
+		{
+			Run: &db.Run{ID: 42, Plugin: "memory", Machine: "rig-01", StartTime: end, EndTime: &end, ExitCode: 2, Error: "bit flip at 0x1000", Stderr: "<fail>"},
+		},
+		{
+			Run: &db.Run{ID: 43, Plugin: "cpu", Machine: "rig-02", StartTime: end},
+		},
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for input, want := range map[string]Format{"JUnit": JUnit, "xml": JUnit, "jsonl": NDJSON, " samples-csv ": SamplesCSV, "json": JSON} {
+		if got, err := ParseFormat(input); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v; expected %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseFormat("yaml"); err == nil || !strings.Contains(err.Error(), "samples-csv") {
+		t.Errorf("expected an unknown format to list the formats, got %v", err)
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteJUnit(&out, testRuns()); err != nil {
+		t.Fatal(err)
+	}
+	var doc junitSuites
+	if err := xml.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatalf("expected valid XML, got %v:\n%s", err, out.String())
+	}
+	if doc.Tests != 3 || doc.Failures != 1 || doc.Skipped != 1 || len(doc.Suites) != 2 {
+		t.Fatalf("unexpected totals %+v", doc)
+	}
+	cpu, memory := doc.Suites[0], doc.Suites[1]
+	if cpu.Name != "cpu" || cpu.Tests != 2 || cpu.Skipped != 1 || cpu.Hostname != "" || cpu.Time != "90.000" {
+		t.Errorf("unexpected cpu suite %+v", cpu)
+	}
+	if memory.Hostname != "rig-01" || memory.Failures != 1 || memory.Properties[0].Value != JUnitFormat {
+		t.Errorf("unexpected memory suite %+v", memory)
+	}
+	if c := cpu.Cases[0]; c.Failure != nil || !strings.Contains(c.SystemOut, "cpu_temp_peak = 81.5 °C") || !strings.HasSuffix(c.SystemOut, "done") {
+		t.Errorf("expected the passed run with its metrics, got %+v", c)
+	}
+	if f := memory.Cases[0].Failure; f == nil || f.Message != "bit flip at 0x1000" || f.Text != "<fail>" {
+		t.Errorf("expected the failure with its error, got %+v", f)
+	}
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteNDJSON(&out, testRuns()); err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		name, err := schema.Detect(line)
+		if err != nil || name != schema.Record {
+			t.Fatalf("expected a record, got %q, %v", name, err)
+		}
+		if err := schema.Validate(name, line); err != nil {
+			t.Errorf("%s: %v", line, err)
+		}
+		if strings.Contains(string(line), `"type":"sample"`) && !strings.Contains(string(line), `"value":0,`) {
+			t.Errorf("expected a zero average to be written, got %s", line)
+		}
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatal(err)
+		}
+		types = append(types, record.Type)
+	}
+	if want := []string{RecordRun, RecordResult, RecordSample, RecordRun, RecordRun}; !reflect.DeepEqual(types, want) {
+		t.Errorf("expected records %v, got %v", want, types)
+	}
+}
+
+func TestSamples(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = database.Close() }()
+
+	now := time.Now().Truncate(10 * time.Second)
+	start := now.Add(-time.Minute)
+	end := now.Add(-20 * time.Second)
+	if err := database.RecordSensorSamples([]db.SensorSample{
+		{Time: start.Add(-30 * time.Second), Metric: "CPU Temp (°C)", Value: 40},
+		{Time: start.Add(5 * time.Second), Metric: "CPU Temp (°C)", Value: 80},
+		{Time: start.Add(6 * time.Second), Metric: "CPU Clock (MHz)", Value: 4800},
+		{Time: end.Add(30 * time.Second), Metric: "CPU Temp (°C)", Value: 50},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	run := &db.Run{ID: 7, UUID: "uuid-7", Plugin: "cpu", StartTime: start, EndTime: &end}
+	samples, err := Samples(database, run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 2 || samples[0].Metric != "CPU Clock (MHz)" || samples[1].Average != 80 {
+		t.Fatalf("expected the two samples taken during the run, got %+v", samples)
+	}
+
+	var out bytes.Buffer
+	if err := WriteSamplesCSV(&out, []*Run{{Run: run, Samples: samples}}); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[1][1] != "uuid-7" || rows[2][4] != "CPU Temp (°C)" || rows[2][6] != "80.000000" {
+		t.Errorf("unexpected CSV %v", rows)
+	}
+
+	old := &db.Run{ID: 1, Plugin: "cpu", StartTime: now.Add(-30 * 24 * time.Hour)}
+	if samples, err := Samples(database, old); err != nil || samples != nil {
+		t.Errorf("expected no samples for a run older than the history, got %v, %v", samples, err)
+	}
+}
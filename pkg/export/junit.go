@@ -0,0 +1,172 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// JUnitFormat identifies the layout of the JUnit output in the properties of
+// every test suite
+const JUnitFormat = "fire-junit"
+
+// junitTimeLayout is the timestamp format of the JUnit schema, without a
+// zone; times are written in UTC
+const junitTimeLayout = "2006-01-02T15:04:05"
+
+type junitSuites struct {
+	XMLName  xml.Name      `xml:"testsuites"`
+	Name     string        `xml:"name,attr"`
+	Tests    int           `xml:"tests,attr"`
+	Failures int           `xml:"failures,attr"`
+	Skipped  int           `xml:"skipped,attr"`
+	Time     string        `xml:"time,attr"`
+	Suites   []*junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr,omitempty"`
+	Hostname   string          `xml:"hostname,attr,omitempty"`
+	Properties []junitProperty `xml:"properties>property"`
+	Cases      []*junitCase    `xml:"testcase"`
+
+	seconds float64
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitCase struct {
+	Name       string          `xml:"name,attr"`
+	Classname  string          `xml:"classname,attr"`
+	Time       string          `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Failure    *junitFailure   `xml:"failure,omitempty"`
+	Skipped    *junitSkipped   `xml:"skipped,omitempty"`
+	SystemOut  string          `xml:"system-out,omitempty"`
+	SystemErr  string          `xml:"system-err,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// WriteJUnit writes the runs as JUnit XML: a test suite per plugin and a test
+// case per run, failed when the run failed and skipped while it is still
+// going. The metrics of a run are test case properties and are repeated in
+// its output, where every CI server shows them.
+func WriteJUnit(w io.Writer, runs []*Run) error {
+	doc := &junitSuites{Name: "F.I.R.E."}
+	suites := map[string]*junitSuite{}
+	var total float64
+	for _, r := range runs {
+		run := r.Run
+		suite := suites[run.Plugin]
+		if suite == nil {
+			suite = &junitSuite{
+				Name:      run.Plugin,
+				Timestamp: run.StartTime.UTC().Format(junitTimeLayout),
+				Hostname:  run.Machine,
+				Properties: []junitProperty{
+					{Name: "fire.format", Value: JUnitFormat},
+					{Name: "fire.schema_version", Value: fmt.Sprint(SchemaVersion)},
+				},
+			}
+			suites[run.Plugin] = suite
+			doc.Suites = append(doc.Suites, suite)
+		}
+		if suite.Hostname != run.Machine {
+			// Runs of several machines; the case properties tell them apart
+			suite.Hostname = ""
+		}
+
+		seconds := run.Duration().Seconds()
+		c := &junitCase{
+			Name:      fmt.Sprintf("%s run %d", run.Plugin, run.ID),
+			Classname: "fire." + run.Plugin,
+			Time:      formatSeconds(seconds),
+			SystemErr: run.Stderr,
+		}
+		for _, p := range []junitProperty{
+			{Name: "run.id", Value: fmt.Sprint(run.ID)},
+			{Name: "run.uuid", Value: run.UUID},
+			{Name: "machine", Value: run.Machine},
+			{Name: "model", Value: run.Model},
+			{Name: "environment", Value: run.Environment},
+		} {
+			if p.Value != "" {
+				c.Properties = append(c.Properties, p)
+			}
+		}
+		var out strings.Builder
+		for _, result := range r.Results {
+			value := strings.TrimSpace(strconv.FormatFloat(result.Value, 'f', -1, 64) + " " + result.Unit)
+			c.Properties = append(c.Properties, junitProperty{Name: "metric." + result.Metric, Value: value})
+			fmt.Fprintf(&out, "%s = %s\n", result.Metric, value)
+		}
+		if run.Stdout != "" {
+			if out.Len() > 0 {
+				out.WriteString("\n")
+			}
+			out.WriteString(run.Stdout)
+		}
+		c.SystemOut = out.String()
+
+		switch {
+		case run.EndTime == nil:
+			c.Skipped = &junitSkipped{Message: "run has not finished"}
+			suite.Skipped++
+			doc.Skipped++
+		case !run.Success:
+			message := run.Error
+			if message == "" {
+				message = fmt.Sprintf("exited with code %d", run.ExitCode)
+			}
+			c.Failure = &junitFailure{Message: message, Type: fmt.Sprintf("exit code %d", run.ExitCode), Text: run.Stderr}
+			suite.Failures++
+			doc.Failures++
+		}
+
+		suite.Cases = append(suite.Cases, c)
+		suite.Tests++
+		suite.seconds += seconds
+		suite.Time = formatSeconds(suite.seconds)
+		doc.Tests++
+		total += seconds
+	}
+	doc.Time = formatSeconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write JUnit XML: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write JUnit XML: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("failed to write JUnit XML: %w", err)
+	}
+	return nil
+}
+
+// formatSeconds formats a duration in seconds as JUnit expects it
+func formatSeconds(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
@@ -0,0 +1,108 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+// RecordFormat identifies an NDJSON record, see the record schema
+const RecordFormat = "fire-record"
+
+// Record types
+const (
+	RecordRun    = "run"
+	RecordResult = "result"
+	RecordSample = "sample"
+)
+
+// Record is one line of NDJSON output. Every record names its run, so lines
+// can be loaded into a table on their own; run records carry the whole run
+// and result and sample records one metric each.
+type Record struct {
+	Format        string    `json:"format"`
+	SchemaVersion int       `json:"schema_version"`
+	Type          string    `json:"type"`
+	Time          time.Time `json:"time"` // Run start, result time or sample bucket start
+	RunID         int64     `json:"run_id"`
+	RunUUID       string    `json:"run_uuid,omitempty"`
+	Plugin        string    `json:"plugin"`
+	Machine       string    `json:"machine,omitempty"`
+
+	Run *db.Run `json:"run,omitempty"`
+
+	Metric  string   `json:"metric,omitempty"`
+	Value   *float64 `json:"value,omitempty"` // Result value or sample average
+	Unit    string   `json:"unit,omitempty"`
+	Samples int      `json:"samples,omitempty"`
+	Min     *float64 `json:"min,omitempty"`
+	Max     *float64 `json:"max,omitempty"`
+}
+
+// Records returns the records of a run: the run, then its results, then its
+// samples
+func Records(r *Run) []Record {
+	run := r.Run
+	base := Record{
+		Format:        RecordFormat,
+		SchemaVersion: SchemaVersion,
+		RunID:         run.ID,
+		RunUUID:       run.UUID,
+		Plugin:        run.Plugin,
+		Machine:       run.Machine,
+	}
+
+	record := base
+	record.Type = RecordRun
+	record.Time = run.StartTime
+	record.Run = run
+	records := []Record{record}
+
+	for _, result := range r.Results {
+		record := base
+		record.Type = RecordResult
+		record.Time = result.CreatedAt
+		if record.Time.IsZero() {
+			record.Time = run.StartTime
+		}
+		record.Metric = result.Metric
+		record.Value = float(result.Value)
+		record.Unit = result.Unit
+		records = append(records, record)
+	}
+
+	for _, s := range r.Samples {
+		record := base
+		record.Type = RecordSample
+		record.Time = s.Time
+		record.Metric = s.Metric
+		record.Value = float(s.Average)
+		record.Samples = s.Count
+		record.Min = float(s.Min)
+		record.Max = float(s.Max)
+		records = append(records, record)
+	}
+	return records
+}
+
+// WriteNDJSON writes the records of the runs, one compact JSON document per
+// line
+func WriteNDJSON(w io.Writer, runs []*Run) error {
+	enc := json.NewEncoder(w)
+	for _, r := range runs {
+		for _, record := range Records(r) {
+			if err := enc.Encode(record); err != nil {
+				return fmt.Errorf("failed to write NDJSON: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// float returns a pointer to v, so that zero values are still written
+func float(v float64) *float64 {
+	return &v
+}
@@ -0,0 +1,177 @@
+package fancontrol
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// IPMI manufacturer IDs of the BMCs whose fan commands are known. Fan
+// control is an OEM extension, so other BMCs are left alone.
+const (
+	manufacturerDell       = 674
+	manufacturerSupermicro = 10876
+)
+
+// Supermicro fan modes, as read and set with raw 0x30 0x45
+var supermicroModes = map[int]string{
+	0: "standard",
+	1: "full",
+	2: "optimal",
+	3: "pue",
+	4: "heavy io",
+}
+
+// Supermicro fan zones: the CPU zone and the peripheral zone
+var supermicroZones = []string{"0x00", "0x01"}
+
+// runIPMI runs ipmitool with args and returns its output, replaced in tests
+var runIPMI = func(ctx context.Context, args ...string) (string, error) {
+	out, err := safeexec.CommandContext(ctx, "ipmitool", args...).Output()
+	return string(out), err
+}
+
+// bmc drives the fans through the BMC's OEM commands
+type bmc struct {
+	manufacturer int
+	mode         string // Fan mode before pinning
+}
+
+// findBMC returns the BMC when ipmitool can reach one that is known to
+// take fan commands
+func findBMC(ctx context.Context) backend {
+	if _, err := exec.LookPath("ipmitool"); err != nil {
+		return nil
+	}
+	out, err := runIPMI(ctx, "mc", "info")
+	if err != nil {
+		return nil // No BMC, or no access to /dev/ipmi0
+	}
+	b := &bmc{manufacturer: parseManufacturer(out)}
+	switch b.manufacturer {
+	case manufacturerSupermicro:
+		mode, err := b.supermicroMode(ctx)
+		if err != nil {
+			return nil
+		}
+		b.mode = mode
+	case manufacturerDell:
+		// Dell BMCs cannot report whether the fans are under manual control
+		b.mode = "auto"
+	default:
+		return nil
+	}
+	return b
+}
+
+func (b *bmc) name() string {
+	if b.manufacturer == manufacturerDell {
+		return "Dell BMC"
+	}
+	return "Supermicro BMC"
+}
+
+func (b *bmc) fans() []Fan {
+	return []Fan{{Name: b.name(), Source: SourceBMC, Mode: b.mode}}
+}
+
+func (b *bmc) pin(ctx context.Context, duty int) (func(context.Context) error, error) {
+	if b.manufacturer == manufacturerDell {
+		// Take the fans off the automatic curve, then set all of them
+		if _, err := runIPMI(ctx, "raw", "0x30", "0x30", "0x01", "0x00"); err != nil {
+			return nil, fmt.Errorf("failed to take manual control of the %s fans: %w", b.name(), err)
+		}
+		restore := func(ctx context.Context) error {
+			if _, err := runIPMI(ctx, "raw", "0x30", "0x30", "0x01", "0x01"); err != nil {
+				return fmt.Errorf("failed to restore automatic control of the %s fans: %w", b.name(), err)
+			}
+			return nil
+		}
+		if _, err := runIPMI(ctx, "raw", "0x30", "0x30", "0x02", "0xff", hexByte(duty)); err != nil {
+			return nil, joinRestore(fmt.Errorf("failed to set the %s fans: %w", b.name(), err), restore)
+		}
+		return restore, nil
+	}
+
+	// Full mode runs every fan at 100% and lets the zones be set below that
+	previous := "0x00"
+	for code, mode := range supermicroModes {
+		if mode == b.mode {
+			previous = hexByte(code)
+		}
+	}
+	if _, err := runIPMI(ctx, "raw", "0x30", "0x45", "0x01", "0x01"); err != nil {
+		return nil, fmt.Errorf("failed to set the %s fans to full: %w", b.name(), err)
+	}
+	restore := func(ctx context.Context) error {
+		if _, err := runIPMI(ctx, "raw", "0x30", "0x45", "0x01", previous); err != nil {
+			return fmt.Errorf("failed to restore the %s fan mode %s: %w", b.name(), b.mode, err)
+		}
+		return nil
+	}
+	if duty < 100 {
+		for _, zone := range supermicroZones {
+			if _, err := runIPMI(ctx, "raw", "0x30", "0x70", "0x66", "0x01", zone, hexByte(duty)); err != nil {
+				return nil, joinRestore(fmt.Errorf("failed to set %s fan zone %s: %w", b.name(), zone, err), restore)
+			}
+		}
+	}
+	return restore, nil
+}
+
+// supermicroMode reads the current fan mode
+func (b *bmc) supermicroMode(ctx context.Context) (string, error) {
+	out, err := runIPMI(ctx, "raw", "0x30", "0x45", "0x00")
+	if err != nil {
+		return "", err
+	}
+	code, err := strconv.ParseInt(strings.TrimSpace(out), 16, 0)
+	if err != nil {
+		return "", fmt.Errorf("unexpected fan mode %q", strings.TrimSpace(out))
+	}
+	mode, ok := supermicroModes[int(code)]
+	if !ok {
+		return "", fmt.Errorf("unknown fan mode %d", code)
+	}
+	return mode, nil
+}
+
+// parseManufacturer reads the manufacturer ID from `ipmitool mc info`, e.g.
+//
+//	Manufacturer ID           : 10876
+func parseManufacturer(out string) int {
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if !found || strings.TrimSpace(key) != "Manufacturer ID" {
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return 0
+		}
+		return id
+	}
+	return 0
+}
+
+// hexByte formats v as an ipmitool raw byte, e.g. 0x64
+func hexByte(v int) string {
+	return fmt.Sprintf("0x%02x", v)
+}
+
+// joinRestore restores a partly pinned interface and adds any failure to err
+func joinRestore(err error, restore func(context.Context) error) error {
+	ctx, cancel := restoreContext()
+	defer cancel()
+	if restoreErr := restore(ctx); restoreErr != nil {
+		return errors.Join(err, restoreErr)
+	}
+	return err
+}
@@ -0,0 +1,149 @@
+// Package fancontrol sets fan duty cycles, so a burn-in can run with the
+// fans pinned at full speed and hand them back to their curve afterwards.
+//
+// Fans are driven through hwmon pwm channels (pwmN and pwmN_enable) and the
+// ThinkPad embedded controller (/proc/acpi/ibm/fan) on Linux, and through
+// the BMC with ipmitool on Supermicro and Dell servers on any platform.
+// Windows has no fan interface of its own, so desktop boards there need
+// their vendor's tool.
+//
+// Changing fans needs root, or an elevated prompt on Windows. Pin saves each
+// fan's setting before changing it and Restore writes it back; a process
+// killed in between leaves the fans pinned until the next reboot, which is
+// the safe direction for a test machine.
+package fancontrol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MinDuty is the lowest duty cycle Pin accepts, in percent. Lower settings
+// can stall a fan under load.
+const MinDuty = 20
+
+// FullDuty pins the fans at full speed, the one duty cycle that can never
+// cool less than the fans' own curve
+const FullDuty = 100
+
+// restoreTimeout bounds handing the fans back
+const restoreTimeout = 30 * time.Second
+
+// Fan sources
+const (
+	SourceHwmon = "hwmon"
+	SourceEC    = "ec"
+	SourceBMC   = "bmc"
+)
+
+// ErrNotFound is returned when no fan can be controlled
+var ErrNotFound = errors.New("no controllable fans found")
+
+// ErrUnguarded is returned when fans would be pinned below full speed with
+// nothing to stop the load if the machine overheats
+var ErrUnguarded = errors.New("pinning fans below 100% needs a temperature limit that stops the load")
+
+// ErrNotPrivileged is returned when the process may not change fans
+var ErrNotPrivileged = errors.New("fan control needs root, or an elevated prompt on Windows")
+
+// Fan is a fan, or a group of fans, whose duty cycle can be set
+type Fan struct {
+	Name   string `json:"name"`   // e.g. "nct6798 pwm2", "thinkpad fan" or "Supermicro BMC"
+	Source string `json:"source"` // SourceHwmon, SourceEC or SourceBMC
+	Mode   string `json:"mode"`   // Current setting, e.g. "auto", "manual 40%" or "standard"
+}
+
+// backend drives the fans of one interface
+type backend interface {
+	fans() []Fan
+	// pin sets the fans to duty percent and returns how to restore them
+	pin(ctx context.Context, duty int) (restore func(context.Context) error, err error)
+}
+
+// backends returns every fan interface found on this machine
+func backends(ctx context.Context) []backend {
+	found := platformBackends()
+	if b := findBMC(ctx); b != nil {
+		found = append(found, b)
+	}
+	return found
+}
+
+// List returns the fans that can be controlled. Reading them does not need
+// root.
+func List(ctx context.Context) ([]Fan, error) {
+	var fans []Fan
+	for _, b := range backends(ctx) {
+		fans = append(fans, b.fans()...)
+	}
+	if len(fans) == 0 {
+		return nil, ErrNotFound
+	}
+	return fans, nil
+}
+
+// Pinned holds fans set by Pin until they are restored
+type Pinned struct {
+	Duty int
+	Fans []Fan // With the mode each had before Pin
+
+	restores []func(context.Context) error
+}
+
+// Pin sets every controllable fan to duty percent. A duty below FullDuty
+// can cool less than the fans' curve would under load, so it is refused
+// unless guarded, meaning the caller stops the load at a temperature limit.
+// When one interface fails the ones already changed are restored.
+func Pin(ctx context.Context, duty int, guarded bool) (*Pinned, error) {
+	if duty < MinDuty || duty > FullDuty {
+		return nil, fmt.Errorf("fan duty must be between %d and %d%%, got %d", MinDuty, FullDuty, duty)
+	}
+	if duty < FullDuty && !guarded {
+		return nil, ErrUnguarded
+	}
+	if !Privileged() {
+		return nil, ErrNotPrivileged
+	}
+	found := backends(ctx)
+	if len(found) == 0 {
+		return nil, ErrNotFound
+	}
+
+	p := &Pinned{Duty: duty}
+	for _, b := range found {
+		restore, err := b.pin(ctx, duty)
+		if err != nil {
+			if restoreErr := p.Restore(); restoreErr != nil {
+				err = errors.Join(err, restoreErr)
+			}
+			return nil, err
+		}
+		p.Fans = append(p.Fans, b.fans()...)
+		p.restores = append(p.restores, restore)
+	}
+	return p, nil
+}
+
+// restoreContext bounds handing fans back without depending on a caller's
+// context that may already be cancelled
+func restoreContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), restoreTimeout)
+}
+
+// Restore hands the fans back to the settings they had before Pin, in
+// reverse order. It runs on its own deadline so it also works after a
+// cancelled test, and does nothing the second time.
+func (p *Pinned) Restore() error {
+	ctx, cancel := restoreContext()
+	defer cancel()
+	var errs []error
+	for i := len(p.restores) - 1; i >= 0; i-- {
+		if err := p.restores[i](ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	p.restores = nil
+	return errors.Join(errs...)
+}
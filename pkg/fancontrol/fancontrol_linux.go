@@ -0,0 +1,207 @@
+//go:build linux
+// +build linux
+
+package fancontrol
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sysfsRoot is where sysfs and procfs are looked up, replaced in tests
+var sysfsRoot = "/"
+
+// pwmChannel matches the duty cycle file of a hwmon pwm channel
+var pwmChannel = regexp.MustCompile(`^pwm\d+$`)
+
+// hwmon pwmN_enable values: 0 runs the fan at full speed, 1 is manual and
+// higher values select the chip's automatic modes
+const (
+	pwmFullSpeed = 0
+	pwmManual    = 1
+)
+
+// platformBackends returns the pwm channels of the hwmon chips and the
+// ThinkPad fan
+func platformBackends() []backend {
+	var found []backend
+	for _, p := range hwmonChannels() {
+		found = append(found, p)
+	}
+	if t := findThinkPad(); t != nil {
+		found = append(found, t)
+	}
+	return found
+}
+
+// hwmonPWM is one pwm channel of a hwmon chip
+type hwmonPWM struct {
+	name   string // e.g. "nct6798 pwm2"
+	path   string // pwmN, the duty cycle from 0 to 255
+	enable int    // pwmN_enable before pinning
+	value  int    // pwmN before pinning
+}
+
+// hwmonChannels lists the pwm channels whose mode can be switched
+func hwmonChannels() []*hwmonPWM {
+	paths, _ := filepath.Glob(filepath.Join(sysfsRoot, "sys/class/hwmon/hwmon*/pwm*"))
+	sort.Strings(paths)
+
+	var channels []*hwmonPWM
+	for _, path := range paths {
+		if !pwmChannel.MatchString(filepath.Base(path)) {
+			continue // pwmN_enable, pwmN_mode, pwmN_auto_point...
+		}
+		enable, err := readInt(path + "_enable")
+		if err != nil {
+			continue // A channel without a mode switch cannot be handed back
+		}
+		value, err := readInt(path)
+		if err != nil {
+			continue
+		}
+		name := filepath.Base(path)
+		if chip := readString(filepath.Join(filepath.Dir(path), "name")); chip != "" {
+			name = chip + " " + name
+		}
+		channels = append(channels, &hwmonPWM{name: name, path: path, enable: enable, value: value})
+	}
+	return channels
+}
+
+func (p *hwmonPWM) fans() []Fan {
+	var mode string
+	switch p.enable {
+	case pwmFullSpeed:
+		mode = "full"
+	case pwmManual:
+		mode = fmt.Sprintf("manual %.0f%%", float64(p.value)*100/255)
+	default:
+		mode = "auto"
+	}
+	return []Fan{{Name: p.name, Source: SourceHwmon, Mode: mode}}
+}
+
+func (p *hwmonPWM) pin(_ context.Context, duty int) (func(context.Context) error, error) {
+	if err := writeSysfs(p.path+"_enable", pwmManual); err != nil {
+		return nil, fmt.Errorf("failed to take manual control of %s: %w", p.name, err)
+	}
+	restore := func(context.Context) error {
+		// The old duty cycle first, so a manual channel keeps its setting
+		// and an automatic one starts its curve from where it was
+		if err := writeSysfs(p.path, p.value); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", p.name, err)
+		}
+		if err := writeSysfs(p.path+"_enable", p.enable); err != nil {
+			return fmt.Errorf("failed to restore the mode of %s: %w", p.name, err)
+		}
+		return nil
+	}
+	value := int(math.Round(float64(duty) * 255 / 100))
+	if err := writeSysfs(p.path, value); err != nil {
+		return nil, joinRestore(fmt.Errorf("failed to set %s: %w", p.name, err), restore)
+	}
+	return restore, nil
+}
+
+// thinkPad is the fan of a ThinkPad, driven through the embedded controller
+// by thinkpad_acpi. The driver only takes commands when loaded with
+// fan_control=1.
+type thinkPad struct {
+	path  string
+	level string // auto, full-speed, disengaged or 0-7 before pinning
+}
+
+// findThinkPad returns the ThinkPad fan when thinkpad_acpi lets it be set
+func findThinkPad() *thinkPad {
+	path := filepath.Join(sysfsRoot, "proc/acpi/ibm/fan")
+	data, err := os.ReadFile(path) // #nosec G304 -- procfs path
+	if err != nil {
+		return nil
+	}
+	level, controllable := parseThinkPadFan(string(data))
+	if level == "" || !controllable {
+		return nil
+	}
+	return &thinkPad{path: path, level: level}
+}
+
+func (t *thinkPad) fans() []Fan {
+	return []Fan{{Name: "thinkpad fan", Source: SourceEC, Mode: t.level}}
+}
+
+func (t *thinkPad) pin(_ context.Context, duty int) (func(context.Context) error, error) {
+	// Level 7 is the fastest the EC regulates; full-speed is the same fan
+	// running at the top of its range
+	level := "full-speed"
+	if duty < 100 {
+		level = strconv.Itoa(int(math.Round(float64(duty) * 7 / 100)))
+	}
+	if err := t.setLevel(level); err != nil {
+		return nil, err
+	}
+	return func(context.Context) error { return t.setLevel(t.level) }, nil
+}
+
+func (t *thinkPad) setLevel(level string) error {
+	if err := os.WriteFile(t.path, []byte("level "+level), 0o600); err != nil {
+		return fmt.Errorf("failed to set the thinkpad fan to level %s: %w", level, err)
+	}
+	return nil
+}
+
+// parseThinkPadFan reads the level from /proc/acpi/ibm/fan and whether the
+// driver accepts commands, which it lists only when fan control is enabled:
+//
+//	status:		enabled
+//	speed:		2650
+//	level:		auto
+//	commands:	level <level> (<level> is 0-7, auto, disengaged, full-speed)
+func parseThinkPadFan(content string) (level string, controllable bool) {
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "level":
+			level = value
+		case "commands":
+			if strings.HasPrefix(value, "level") {
+				controllable = true
+			}
+		}
+	}
+	return level, controllable
+}
+
+func writeSysfs(path string, value int) error {
+	err := os.WriteFile(path, []byte(strconv.Itoa(value)), 0o600)
+	if errors.Is(err, os.ErrPermission) {
+		return fmt.Errorf("%w: %v", ErrNotPrivileged, err)
+	}
+	return err
+}
+
+func readInt(path string) (int, error) {
+	return strconv.Atoi(readString(path))
+}
+
+func readString(path string) string {
+	data, err := os.ReadFile(path) // #nosec G304 -- sysfs path
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
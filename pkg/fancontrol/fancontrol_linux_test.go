@@ -0,0 +1,103 @@
+package fancontrol
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestHwmonPin(t *testing.T) {
+	root := t.TempDir()
+	old := sysfsRoot
+	sysfsRoot = root
+	t.Cleanup(func() { sysfsRoot = old })
+
+	chip := filepath.Join(root, "sys/class/hwmon/hwmon3")
+	writeFiles(t, chip, map[string]string{
+		"name":        "nct6798\n",
+		"pwm1":        "102\n",
+		"pwm1_enable": "5\n",
+		"pwm1_mode":   "1\n",
+		"pwm2":        "255\n",
+		"pwm2_enable": "1\n",
+		"pwm3":        "80\n", // No mode switch
+		"fan1_input":  "1200\n",
+	})
+
+	channels := hwmonChannels()
+	if len(channels) != 2 {
+		t.Fatalf("expected 2 pwm channels, got %+v", channels)
+	}
+	if f := channels[0].fans()[0]; f.Name != "nct6798 pwm1" || f.Mode != "auto" {
+		t.Errorf("unexpected fan %+v", f)
+	}
+	if f := channels[1].fans()[0]; f.Mode != "manual 100%" {
+		t.Errorf("expected pwm2 under manual control at 100%%, got %+v", f)
+	}
+
+	restore, err := channels[0].pin(context.Background(), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := readInt(filepath.Join(chip, "pwm1")); v != 255 {
+		t.Errorf("expected pwm1 at 255, got %d", v)
+	}
+	if v, _ := readInt(filepath.Join(chip, "pwm1_enable")); v != pwmManual {
+		t.Errorf("expected pwm1 under manual control, got %d", v)
+	}
+	if err := restore(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := readInt(filepath.Join(chip, "pwm1")); v != 102 {
+		t.Errorf("expected pwm1 restored to 102, got %d", v)
+	}
+	if v, _ := readInt(filepath.Join(chip, "pwm1_enable")); v != 5 {
+		t.Errorf("expected pwm1 back on its curve, got %d", v)
+	}
+}
+
+func TestThinkPad(t *testing.T) {
+	root := t.TempDir()
+	old := sysfsRoot
+	sysfsRoot = root
+	t.Cleanup(func() { sysfsRoot = old })
+
+	dir := filepath.Join(root, "proc/acpi/ibm")
+	writeFiles(t, dir, map[string]string{"fan": "status:\t\tenabled\nspeed:\t\t2650\nlevel:\t\tauto\n"})
+	if findThinkPad() != nil {
+		t.Error("expected the fan to be left alone without fan_control=1")
+	}
+
+	writeFiles(t, dir, map[string]string{"fan": "status:\t\tenabled\nspeed:\t\t2650\nlevel:\t\tauto\n" +
+		"commands:\tlevel <level> (<level> is 0-7, auto, disengaged, full-speed)\n"})
+	fan := findThinkPad()
+	if fan == nil || fan.level != "auto" {
+		t.Fatalf("expected a controllable fan at level auto, got %+v", fan)
+	}
+	restore, err := fan.pin(context.Background(), 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(filepath.Join(dir, "fan")); got != "level 4" {
+		t.Errorf("expected level 4, got %q", got)
+	}
+	if err := restore(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(filepath.Join(dir, "fan")); got != "level auto" {
+		t.Errorf("expected level auto, got %q", got)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package fancontrol
+
+// platformBackends finds no fans of its own: only Linux exposes them, so
+// elsewhere fans are reached through a BMC
+func platformBackends() []backend {
+	return nil
+}
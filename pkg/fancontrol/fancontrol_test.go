@@ -0,0 +1,88 @@
+package fancontrol
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseManufacturer(t *testing.T) {
+	out := `Device ID                 : 32
+Firmware Revision         : 1.73
+IPMI Version              : 2.0
+Manufacturer ID           : 10876
+Manufacturer Name         : Supermicro
+`
+	if id := parseManufacturer(out); id != manufacturerSupermicro {
+		t.Errorf("expected manufacturer %d, got %d", manufacturerSupermicro, id)
+	}
+	if id := parseManufacturer("Could not open device at /dev/ipmi0"); id != 0 {
+		t.Errorf("expected no manufacturer, got %d", id)
+	}
+}
+
+// fakeIPMI records ipmitool calls and fails those starting with fail
+func fakeIPMI(t *testing.T, fail string) *[]string {
+	t.Helper()
+	var calls []string
+	old := runIPMI
+	runIPMI = func(_ context.Context, args ...string) (string, error) {
+		call := strings.Join(args, " ")
+		calls = append(calls, call)
+		if fail != "" && strings.HasPrefix(call, fail) {
+			return "", errors.New("completion code 0xc1")
+		}
+		return "", nil
+	}
+	t.Cleanup(func() { runIPMI = old })
+	return &calls
+}
+
+func TestBMCPin(t *testing.T) {
+	calls := fakeIPMI(t, "")
+	b := &bmc{manufacturer: manufacturerSupermicro, mode: "optimal"}
+	restore, err := b.pin(context.Background(), 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := restore(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"raw 0x30 0x45 0x01 0x01",
+		"raw 0x30 0x70 0x66 0x01 0x00 0x3c",
+		"raw 0x30 0x70 0x66 0x01 0x01 0x3c",
+		"raw 0x30 0x45 0x01 0x02",
+	}
+	if !reflect.DeepEqual(*calls, want) {
+		t.Errorf("expected full mode, both zones at 60%% and optimal mode back, got %q", *calls)
+	}
+
+	// A Dell BMC that refuses the duty cycle goes back to automatic control
+	calls = fakeIPMI(t, "raw 0x30 0x30 0x02")
+	b = &bmc{manufacturer: manufacturerDell, mode: "auto"}
+	if _, err := b.pin(context.Background(), 100); err == nil {
+		t.Fatal("expected the duty cycle to fail")
+	}
+	want = []string{
+		"raw 0x30 0x30 0x01 0x00",
+		"raw 0x30 0x30 0x02 0xff 0x64",
+		"raw 0x30 0x30 0x01 0x01",
+	}
+	if !reflect.DeepEqual(*calls, want) {
+		t.Errorf("expected automatic control restored, got %q", *calls)
+	}
+}
+
+func TestPinDuty(t *testing.T) {
+	for _, duty := range []int{0, MinDuty - 1, 101} {
+		if _, err := Pin(context.Background(), duty, true); err == nil || errors.Is(err, ErrNotPrivileged) {
+			t.Errorf("expected duty %d to be rejected, got %v", duty, err)
+		}
+	}
+	if _, err := Pin(context.Background(), 60, false); !errors.Is(err, ErrUnguarded) {
+		t.Errorf("expected an unguarded duty below 100%% to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+// +build !windows
+
+package fancontrol
+
+import "os"
+
+// Privileged reports whether the process may change fans, which takes root
+func Privileged() bool {
+	return os.Geteuid() == 0
+}
@@ -0,0 +1,12 @@
+//go:build windows
+// +build windows
+
+package fancontrol
+
+import "golang.org/x/sys/windows"
+
+// Privileged reports whether the process may change fans, which takes an
+// elevated prompt
+func Privileged() bool {
+	return windows.GetCurrentProcessToken().IsElevated()
+}
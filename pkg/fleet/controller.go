@@ -0,0 +1,172 @@
+package fleet
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/agent"
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/testplan"
+)
+
+// Agent is what the controller needs from an agent. *agent.Client
+// implements it.
+type Agent interface {
+	SubmitPlan(plan *testplan.Plan) (*agent.Job, error)
+	Job() (*agent.Job, error)
+	RunResults(runID int64) ([]*db.Result, error)
+}
+
+// ConnectFunc returns the client of an agent
+type ConnectFunc func(Member) (Agent, error)
+
+// Outcome is the state of the plan on one agent
+type Outcome struct {
+	Member Member
+	Job    *agent.Job // nil when the agent could not be reached or has run no plan
+	Err    error
+	RunIDs []int64 // Local IDs of the runs imported by Collect, in plan order
+}
+
+// Done reports whether the agent has finished, or could not be reached
+func (o Outcome) Done() bool {
+	return o.Err != nil || o.Job == nil || o.Job.State == agent.JobDone
+}
+
+// Passed reports whether every stage of the plan passed on the agent
+func (o Outcome) Passed() bool {
+	return o.Err == nil && o.Job != nil && o.Job.Passed()
+}
+
+// Status describes the outcome in a word
+func (o Outcome) Status() string {
+	switch {
+	case o.Err != nil:
+		return "ERROR"
+	case o.Job == nil:
+		return "IDLE"
+	case o.Job.State != agent.JobDone:
+		return fmt.Sprintf("RUNNING %d/%d", o.Job.StagesDone(), len(o.Job.Plan.Stages))
+	case o.Passed():
+		return "PASS"
+	default:
+		return "FAIL"
+	}
+}
+
+// Push starts the plan on every member at once
+func Push(members []Member, connect ConnectFunc, plan *testplan.Plan) []Outcome {
+	return forEach(members, connect, func(a Agent, o *Outcome) {
+		o.Job, o.Err = a.SubmitPlan(plan)
+	})
+}
+
+// Status returns the plan each member is running, or ran last
+func Status(members []Member, connect ConnectFunc) []Outcome {
+	return forEach(members, connect, func(a Agent, o *Outcome) {
+		o.Job, o.Err = a.Job()
+	})
+}
+
+// Wait polls the members every interval until each has finished its plan or
+// ctx ends, calling progress with every poll's outcomes
+func Wait(ctx context.Context, members []Member, connect ConnectFunc, interval time.Duration, progress func([]Outcome)) []Outcome {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		outcomes := Status(members, connect)
+		if progress != nil {
+			progress(outcomes)
+		}
+		done := true
+		for _, o := range outcomes {
+			done = done && o.Done()
+		}
+		if done {
+			return outcomes
+		}
+		select {
+		case <-ctx.Done():
+			return outcomes
+		case <-ticker.C:
+		}
+	}
+}
+
+// Collect imports the finished runs of each member's last plan into
+// database, with their results. Runs are matched by UUID, so collecting
+// twice stores nothing new. Each imported run is annotated with the machine
+// it came from.
+func Collect(database *db.DB, members []Member, connect ConnectFunc) []Outcome {
+	outcomes := Status(members, connect)
+	for i := range outcomes {
+		o := &outcomes[i]
+		if o.Err != nil || o.Job == nil {
+			continue
+		}
+		c, err := connect(o.Member)
+		if err != nil {
+			o.Err = err
+			continue
+		}
+		for _, run := range o.Job.Runs {
+			if run.EndTime == nil {
+				continue // Still running
+			}
+			id, err := importRun(database, c, o.Member, o.Job, run)
+			if err != nil {
+				o.Err = fmt.Errorf("run %s: %w", run.UUID, err)
+				break
+			}
+			o.RunIDs = append(o.RunIDs, id)
+		}
+	}
+	return outcomes
+}
+
+// importRun copies one run of an agent into database
+func importRun(database *db.DB, c Agent, m Member, job *agent.Job, run *db.Run) (int64, error) {
+	if existing, err := database.GetRunByUUID(run.UUID); err == nil {
+		return existing.ID, nil
+	}
+	results, err := c.RunResults(run.ID)
+	if err != nil {
+		return 0, err
+	}
+	id, imported, err := database.ImportRun(&db.RunRecord{Run: run, Results: results})
+	if err != nil || !imported {
+		return id, err
+	}
+	err = database.CreateAnnotation(&db.Annotation{
+		RunID:   &id,
+		Time:    run.StartTime,
+		Source:  "fleet:" + m.Name,
+		Kind:    "fleet_import",
+		Message: fmt.Sprintf("Run %d of plan %q on %s (%s), job %s", run.ID, job.Plan.Name, m.Name, m.Address(), job.ID),
+	})
+	return id, err
+}
+
+// forEach calls fn with a client for every member concurrently and returns
+// the outcomes in member order
+func forEach(members []Member, connect ConnectFunc, fn func(Agent, *Outcome)) []Outcome {
+	outcomes := make([]Outcome, len(members))
+	var wg sync.WaitGroup
+	for i, m := range members {
+		outcomes[i].Member = m
+		wg.Add(1)
+		go func(o *Outcome) {
+			defer wg.Done()
+			c, err := connect(o.Member)
+			if err != nil {
+				o.Err = err
+				return
+			}
+			fn(c, o)
+		}(&outcomes[i])
+	}
+	wg.Wait()
+	return outcomes
+}
@@ -0,0 +1,151 @@
+// Package fleet drives the agents of many machines from one controller. It
+// keeps the list of registered agents, pushes a test plan to all of them at
+// once, and imports the runs they record into the local database so a batch
+// of machines gets one pass/fail summary and one combined report.
+//
+// Agents are reached over the same mTLS connection as bench agent connect,
+// with one client certificate for the whole fleet.
+package fleet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/agent"
+)
+
+// Member is one registered agent
+type Member struct {
+	Name  string    `json:"name"`
+	Host  string    `json:"host"`
+	Port  int       `json:"port"`
+	Added time.Time `json:"added"`
+}
+
+// Address returns host:port
+func (m Member) Address() string {
+	return fmt.Sprintf("%s:%d", m.Host, m.Port)
+}
+
+// Registry is the list of agents and the client certificate to reach them
+type Registry struct {
+	CertFile string   `json:"cert_file,omitempty"`
+	KeyFile  string   `json:"key_file,omitempty"`
+	CAFile   string   `json:"ca_file,omitempty"`
+	Members  []Member `json:"members"`
+}
+
+// DefaultPath returns where the registry is stored
+func DefaultPath() string {
+	if path := os.Getenv("FIRE_FLEET"); path != "" {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "fleet.json"
+	}
+	return filepath.Join(homeDir, ".fire", "fleet.json")
+}
+
+// Load reads the registry. A missing file yields an empty registry.
+func Load(path string) (*Registry, error) {
+	r := &Registry{}
+	data, err := os.ReadFile(path) // #nosec G304 -- registry in the user's config directory
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(data, r); err != nil {
+			return nil, fmt.Errorf("failed to parse fleet %s: %w", path, err)
+		}
+	}
+	return r, nil
+}
+
+// Save writes the registry as indented JSON
+func Save(path string, r *Registry) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// Add registers an agent, replacing one with the same name
+func (r *Registry) Add(m Member) {
+	if m.Added.IsZero() {
+		m.Added = time.Now()
+	}
+	if i := r.index(m.Name); i >= 0 {
+		r.Members[i] = m
+		return
+	}
+	r.Members = append(r.Members, m)
+	sort.Slice(r.Members, func(i, j int) bool { return r.Members[i].Name < r.Members[j].Name })
+}
+
+// Remove unregisters the named agent and reports whether it was registered
+func (r *Registry) Remove(name string) bool {
+	i := r.index(name)
+	if i < 0 {
+		return false
+	}
+	r.Members = append(r.Members[:i], r.Members[i+1:]...)
+	return true
+}
+
+// Select returns the named agents, or every agent when names is empty
+func (r *Registry) Select(names []string) ([]Member, error) {
+	if len(names) == 0 {
+		if len(r.Members) == 0 {
+			return nil, errors.New("no agents registered, add one with bench fleet add")
+		}
+		return r.Members, nil
+	}
+	members := make([]Member, 0, len(names))
+	for _, name := range names {
+		i := r.index(name)
+		if i < 0 {
+			return nil, fmt.Errorf("agent %q is not registered", name)
+		}
+		members = append(members, r.Members[i])
+	}
+	return members, nil
+}
+
+// Connect returns a client for an agent
+func (r *Registry) Connect(m Member) (Agent, error) {
+	c, err := agent.NewClient(&agent.ClientConfig{
+		Host:     m.Host,
+		Port:     m.Port,
+		CertFile: r.CertFile,
+		KeyFile:  r.KeyFile,
+		CAFile:   r.CAFile,
+		Endpoint: "health",
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// index returns the position of the named agent, or -1. Names are matched
+// ignoring case.
+func (r *Registry) index(name string) int {
+	for i, m := range r.Members {
+		if strings.EqualFold(m.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,253 @@
+package fleet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/agent"
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/testplan"
+)
+
+func TestRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fleet.json")
+	r, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Select(nil); err == nil {
+		t.Error("expected an error selecting from an empty fleet")
+	}
+
+	r.Add(Member{Name: "rig-02", Host: "10.0.0.2", Port: 2223})
+	r.Add(Member{Name: "rig-01", Host: "10.0.0.1", Port: 2223})
+	r.Add(Member{Name: "RIG-02", Host: "10.0.0.20", Port: 2224})
+	if err := Save(path, r); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Members) != 2 || r.Members[0].Name != "rig-01" || r.Members[1].Address() != "10.0.0.20:2224" {
+		t.Fatalf("unexpected members %+v", r.Members)
+	}
+	if _, err := r.Select([]string{"rig-03"}); err == nil {
+		t.Error("expected an error selecting an unknown agent")
+	}
+	if !r.Remove("rig-01") || r.Remove("rig-01") {
+		t.Error("expected rig-01 to be removed once")
+	}
+}
+
+// fakeAgent runs plans instantly: each stage passes unless its plugin is
+// "fail"
+type fakeAgent struct {
+	mu      sync.Mutex
+	job     *agent.Job
+	results map[int64][]*db.Result
+	nextID  int64
+}
+
+func (a *fakeAgent) SubmitPlan(plan *testplan.Plan) (*agent.Job, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.job = &agent.Job{ID: db.NewRunUUID(), Plan: plan, State: agent.JobRunning, Started: time.Now()}
+	a.results = make(map[int64][]*db.Result)
+	return a.job, nil
+}
+
+func (a *fakeAgent) Job() (*agent.Job, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.job == nil {
+		return nil, errors.New("server returned status 404: No plan has been run")
+	}
+	// Each poll finishes one stage
+	if a.job.State == agent.JobRunning {
+		stage := a.job.Plan.Stages[len(a.job.Stages)]
+		a.nextID++
+		end := time.Now()
+		run := &db.Run{ID: a.nextID, UUID: db.NewRunUUID(), Plugin: stage.Plugin, StartTime: end, EndTime: &end, Success: stage.Plugin != "fail"}
+		status := testplan.StatusPassed
+		if !run.Success {
+			status = testplan.StatusFailed
+			a.job.Error = "stage failed"
+		}
+		a.job.Stages = append(a.job.Stages, &testplan.StageResult{Position: len(a.job.Stages) + 1, Status: status, RunIDs: []int64{run.ID}})
+		a.job.Runs = append(a.job.Runs, run)
+		a.results[run.ID] = []*db.Result{{Metric: "score", Value: 100, Unit: "points", CreatedAt: end}}
+		if len(a.job.Stages) == len(a.job.Plan.Stages) {
+			a.job.State = agent.JobDone
+			a.job.Finished = &end
+			a.job.Run = &testplan.PlanRun{Name: a.job.Plan.Name, Success: a.job.Error == ""}
+		}
+	}
+	job := *a.job
+	return &job, nil
+}
+
+func (a *fakeAgent) RunResults(runID int64) ([]*db.Result, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.results[runID], nil
+}
+
+func TestPushWaitCollect(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+
+	members := []Member{{Name: "rig-01", Host: "10.0.0.1", Port: 2223}, {Name: "rig-02", Host: "10.0.0.2", Port: 2223}, {Name: "rig-03", Host: "10.0.0.3", Port: 2223}}
+	agents := map[string]*fakeAgent{"rig-01": {}, "rig-02": {}}
+	connect := func(m Member) (Agent, error) {
+		if a, ok := agents[m.Name]; ok {
+			return a, nil
+		}
+		return nil, errors.New("connection refused")
+	}
+
+	outcomes := Push(members, connect, &testplan.Plan{Name: "burn-in", Stages: []testplan.Stage{{Plugin: "cpu"}, {Plugin: "memory"}}})
+	if outcomes[0].Err != nil || outcomes[2].Err == nil {
+		t.Fatalf("expected rig-03 alone to fail, got %+v", outcomes)
+	}
+	// rig-02 gets a failing plan instead
+	if _, err := agents["rig-02"].SubmitPlan(&testplan.Plan{Name: "burn-in", Stages: []testplan.Stage{{Plugin: "cpu"}, {Plugin: "fail"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	polls := 0
+	outcomes = Wait(context.Background(), members, connect, time.Millisecond, func([]Outcome) { polls++ })
+	if polls != 2 {
+		t.Errorf("expected 2 polls to finish 2 stages, got %d", polls)
+	}
+	if s := outcomes[0].Status(); s != "PASS" {
+		t.Errorf("expected rig-01 to pass, got %s", s)
+	}
+	if s := outcomes[1].Status(); s != "FAIL" {
+		t.Errorf("expected rig-02 to fail, got %s", s)
+	}
+
+	outcomes = Collect(database, members, connect)
+	if len(outcomes[0].RunIDs) != 2 || len(outcomes[1].RunIDs) != 2 || outcomes[0].Err != nil {
+		t.Fatalf("expected 2 imported runs per machine, got %+v", outcomes)
+	}
+	again := Collect(database, members, connect)
+	if again[0].RunIDs[0] != outcomes[0].RunIDs[0] {
+		t.Error("expected collecting twice to reuse the imported runs")
+	}
+	runs, err := database.ListRuns(db.RunFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 4 {
+		t.Errorf("expected 4 runs in the database, got %d", len(runs))
+	}
+
+	report, err := NewReport(database, outcomes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Plan != "burn-in" || report.Passed != 1 || report.Failed != 2 {
+		t.Errorf("unexpected totals %+v", report)
+	}
+	if m := report.Machines[0]; len(m.Steps) != 2 || m.Steps[0].Metrics[0] != "score: 100 points" {
+		t.Errorf("unexpected steps %+v", m.Steps)
+	}
+	var html bytes.Buffer
+	if err := report.WriteHTML(&html); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Fleet Report: burn-in", "rig-03", "connection refused", `class="fail">FAIL`, "score: 100 points"} {
+		if !strings.Contains(html.String(), want) {
+			t.Errorf("expected the report to contain %q", want)
+		}
+	}
+
+	records, err := LoadRecords(database, db.RunFilter{}, "controller")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 || records[0].Machine != "rig-01" && records[0].Machine != "rig-02" {
+		t.Errorf("expected the imported runs to be attributed to their agents, got %+v", records)
+	}
+}
+
+// record returns a finished run on machine with one cpu_temp_peak result
+func record(machine, model, plugin string, passed bool, errMsg string, temp float64) Record {
+	end := time.Now()
+	return Record{
+		Machine: machine,
+		Run:     &db.Run{Plugin: plugin, Model: model, StartTime: end.Add(-time.Hour), EndTime: &end, Success: passed, Error: errMsg},
+		Results: []*db.Result{
+			{Metric: "cpu_temp_peak", Value: temp, Unit: "°C"},
+			{Metric: "smart_temp_delta_c", Value: 10, Unit: "°C"},
+		},
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	const r650 = "Dell Inc. PowerEdge R650"
+	var records []Record
+	for _, name := range []string{"rig-01", "rig-02", "rig-03"} {
+		for i := 0; i < 4; i++ {
+			records = append(records, record(name, r650, "cpu", true, "", 70))
+		}
+	}
+	// rig-04 runs hot and fails its memory test with varying addresses
+	records = append(records,
+		record("rig-04", r650, "cpu", true, "", 85),
+		record("rig-04", r650, "memory", false, "3 bit errors at 0x7f3a00", 86),
+		record("rig-04", r650, "memory", false, "12 bit errors at 0x1000\nmore detail", 87),
+		record("rig-05", "", "cpu", true, "", 60),
+		record("rig-01", r650, "memory", false, "1 bit errors at 0xff", 70),
+	)
+
+	s := Summarize(records)
+	if s.Runs != 17 || s.Passed != 14 || s.Failed() != 3 {
+		t.Fatalf("unexpected totals %+v", s.Tally)
+	}
+	if len(s.Plugins) != 2 || s.Plugins[1].Plugin != "memory" || s.Plugins[1].PassRate() != 0 {
+		t.Errorf("unexpected per-test stats %+v", s.Plugins)
+	}
+	if len(s.Failures) != 1 || s.Failures[0].Error != "# bit errors at #" || s.Failures[0].Count != 3 ||
+		strings.Join(s.Failures[0].Machines, ",") != "rig-01,rig-04" {
+		t.Errorf("expected the memory failures grouped into one mode, got %+v", s.Failures)
+	}
+	if len(s.Models) != 2 || s.Models[0].Model != r650 || s.Models[0].Machines != 4 || s.Models[1].Model != UnknownModel {
+		t.Fatalf("unexpected models %+v", s.Models)
+	}
+	if th := s.Models[0].Thermals; len(th) != 1 || th[0].Metric != "cpu_temp_peak" || th[0].Max != 87 {
+		t.Errorf("expected only the peak temperature averaged, got %+v", th)
+	}
+
+	var reasons []string
+	for _, o := range s.Outliers {
+		if o.Machine != "rig-04" {
+			t.Errorf("unexpected outlier %+v", o)
+		}
+		reasons = append(reasons, o.Reason)
+	}
+	if len(reasons) != 2 || !strings.HasPrefix(reasons[0], "pass rate 33%") || !strings.HasPrefix(reasons[1], "cpu_temp_peak averages 86.0 °C") {
+		t.Errorf("expected rig-04 flagged for its pass rate and temperature, got %q", reasons)
+	}
+
+	var html bytes.Buffer
+	if err := s.WriteHTML(&html); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Fleet Summary", "# bit errors at #", r650, "82.4%"} {
+		if !strings.Contains(html.String(), want) {
+			t.Errorf("expected the summary to contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package fleet
+
+import (
+	"html/template"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+// Report is the combined result of a plan across the fleet
+type Report struct {
+	Plan      string
+	Generated time.Time
+	Passed    int
+	Failed    int
+	Machines  []MachineReport
+}
+
+// MachineReport is the result of the plan on one machine
+type MachineReport struct {
+	Name   string
+	Host   string
+	Status string
+	Passed bool
+	Error  string
+	Steps  []StepReport
+}
+
+// StepReport is one run of the plan on one machine
+type StepReport struct {
+	Plugin  string
+	RunID   int64 // In the local database
+	Passed  bool
+	Error   string
+	Metrics []string // e.g. "score: 1234.5 ops/s"
+}
+
+// NewReport builds the report of the outcomes Collect returned, reading the
+// results of the imported runs from database
+func NewReport(database *db.DB, outcomes []Outcome) (*Report, error) {
+	r := &Report{Generated: time.Now()}
+	for _, o := range outcomes {
+		m := MachineReport{Name: o.Member.Name, Host: o.Member.Address(), Status: o.Status(), Passed: o.Passed()}
+		if o.Err != nil {
+			m.Error = o.Err.Error()
+		}
+		if o.Job != nil {
+			if r.Plan == "" {
+				r.Plan = o.Job.Plan.Name
+			}
+			if m.Error == "" {
+				m.Error = o.Job.Error
+			}
+		}
+		for _, id := range o.RunIDs {
+			run, err := database.GetRun(id)
+			if err != nil {
+				return nil, err
+			}
+			results, err := database.GetResults(id)
+			if err != nil {
+				return nil, err
+			}
+			m.Steps = append(m.Steps, StepReport{
+				Plugin:  run.Plugin,
+				RunID:   id,
+				Passed:  run.Success,
+				Error:   run.Error,
+				Metrics: formatResults(results),
+			})
+		}
+		if m.Passed {
+			r.Passed++
+		} else {
+			r.Failed++
+		}
+		r.Machines = append(r.Machines, m)
+	}
+	return r, nil
+}
+
+// formatResults lists the results of a run by metric name
+func formatResults(results []*db.Result) []string {
+	sort.Slice(results, func(i, j int) bool { return results[i].Metric < results[j].Metric })
+	lines := make([]string, 0, len(results))
+	for _, res := range results {
+		line := res.Metric + ": " + strconv.FormatFloat(math.Round(res.Value*100)/100, 'f', -1, 64)
+		if res.Unit != "" {
+			line += " " + res.Unit
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+var reportTemplate = template.Must(template.New("fleet").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>F.I.R.E. Fleet Report - {{.Plan}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 6px 10px; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+.pass { color: #1a7f37; font-weight: bold; }
+.fail { color: #cf222e; font-weight: bold; }
+.metrics { font-family: monospace; font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>Fleet Report: {{.Plan}}</h1>
+<p>Generated {{.Generated.Format "2006-01-02 15:04:05"}}: <span class="pass">{{.Passed}} passed</span>, <span class="fail">{{.Failed}} failed</span> of {{len .Machines}} machines</p>
+<table>
+<tr><th>Machine</th><th>Address</th><th>Status</th><th>Error</th></tr>
+{{range .Machines}}<tr><td>{{.Name}}</td><td>{{.Host}}</td><td class="{{if .Passed}}pass{{else}}fail{{end}}">{{.Status}}</td><td>{{.Error}}</td></tr>
+{{end}}</table>
+{{range .Machines}}{{if .Steps}}<h2>{{.Name}}</h2>
+<table>
+<tr><th>Test</th><th>Run</th><th>Result</th><th>Metrics</th></tr>
+{{range .Steps}}<tr><td>{{.Plugin}}</td><td>#{{.RunID}}</td><td class="{{if .Passed}}pass">PASS{{else}}fail">FAIL{{if .Error}}: {{.Error}}{{end}}{{end}}</td><td class="metrics">{{range .Metrics}}{{.}}<br>{{end}}</td></tr>
+{{end}}</table>
+{{end}}{{end}}</body>
+</html>
+`))
+
+// WriteHTML writes the report as a standalone HTML page
+func (r *Report) WriteHTML(w io.Writer) error {
+	return reportTemplate.Execute(w, r)
+}
+
+var summaryTemplate = template.Must(template.New("summary").Funcs(template.FuncMap{
+	"rate": func(t Tally) string { return strconv.FormatFloat(t.PassRate(), 'f', 1, 64) + "%" },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>F.I.R.E. Fleet Summary</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 6px 10px; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+.pass { color: #1a7f37; font-weight: bold; }
+.fail { color: #cf222e; font-weight: bold; }
+.metrics { font-family: monospace; font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>Fleet Summary</h1>
+<p>Generated {{.Generated.Format "2006-01-02 15:04:05"}} from {{.Runs}} runs on {{len .Machines}} machines between {{.From.Format "2006-01-02"}} and {{.To.Format "2006-01-02"}}: <span class="pass">{{.Passed}} passed</span>, <span class="fail">{{.Failed}} failed</span> ({{rate .Tally}})</p>
+{{if .Outliers}}<h2>Outliers</h2>
+<table>
+<tr><th>Machine</th><th>Model</th><th>Reason</th></tr>
+{{range .Outliers}}<tr><td>{{.Machine}}</td><td>{{.Model}}</td><td class="fail">{{.Reason}}</td></tr>
+{{end}}</table>
+{{end}}<h2>Tests</h2>
+<table>
+<tr><th>Test</th><th>Runs</th><th>Failed</th><th>Pass rate</th></tr>
+{{range .Plugins}}<tr><td>{{.Plugin}}</td><td>{{.Runs}}</td><td>{{.Failed}}</td><td>{{rate .Tally}}</td></tr>
+{{end}}</table>
+{{if .Failures}}<h2>Common Failures</h2>
+<table>
+<tr><th>Test</th><th>Failure</th><th>Runs</th><th>Machines</th></tr>
+{{range .Failures}}<tr><td>{{.Plugin}}</td><td title="{{.Example}}">{{.Error}}</td><td>{{.Count}}</td><td>{{range $i, $m := .Machines}}{{if $i}}, {{end}}{{$m}}{{end}}</td></tr>
+{{end}}</table>
+{{end}}<h2>Hardware Models</h2>
+<table>
+<tr><th>Model</th><th>Machines</th><th>Runs</th><th>Pass rate</th><th>Average thermals</th></tr>
+{{range .Models}}<tr><td>{{.Model}}</td><td>{{.Machines}}</td><td>{{.Runs}}</td><td>{{rate .Tally}}</td><td class="metrics">{{range .Thermals}}{{.Metric}}: {{.Avg}} °C (max {{.Max}})<br>{{end}}</td></tr>
+{{end}}</table>
+<h2>Machines</h2>
+<table>
+<tr><th>Machine</th><th>Model</th><th>Runs</th><th>Failed</th><th>Pass rate</th><th>Last run</th><th>Average thermals</th></tr>
+{{range .Machines}}<tr><td>{{.Name}}</td><td>{{.Model}}</td><td>{{.Runs}}</td><td>{{.Failed}}</td><td class="{{if .Failed}}fail{{else}}pass{{end}}">{{rate .Tally}}</td><td>{{.LastRun.Format "2006-01-02 15:04"}}</td><td class="metrics">{{range .Thermals}}{{.Metric}}: {{.Avg}} °C<br>{{end}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// WriteHTML writes the summary as a standalone HTML page
+func (s *Summary) WriteHTML(w io.Writer) error {
+	return summaryTemplate.Execute(w, s)
+}
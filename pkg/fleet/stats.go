@@ -0,0 +1,422 @@
+package fleet
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+// Outlier detection settings
+const (
+	// OutlierMinRuns is the fewest runs a machine needs before its pass
+	// rate is compared with the fleet's
+	OutlierMinRuns = 3
+	// OutlierPassRateDrop is how many points a machine's pass rate may fall
+	// below the fleet's before it is flagged
+	OutlierPassRateDrop = 25.0
+	// OutlierMinModelMachines is the fewest machines of a model needed to
+	// compare their temperatures
+	OutlierMinModelMachines = 3
+	// OutlierTempC is how far in °C a machine may run above the median of
+	// its model before it is flagged
+	OutlierTempC = 8.0
+)
+
+// UnknownModel labels machines whose hardware model was not recorded
+const UnknownModel = "Unknown"
+
+// Record is a finished run and its results, attributed to a machine
+type Record struct {
+	Machine string
+	Run     *db.Run
+	Results []*db.Result
+}
+
+// LoadRecords reads the finished runs in filter from database. Runs
+// imported by Collect are attributed to the agent they came from, other runs
+// to the machine they recorded, and runs that recorded none to machine.
+func LoadRecords(database *db.DB, filter db.RunFilter, machine string) ([]Record, error) {
+	runs, err := database.ListRuns(filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, nil
+	}
+
+	// Runs recorded before machines were stored still carry their agent's
+	// name in the import annotation
+	agents := make(map[int64]string)
+	from := runs[len(runs)-1].StartTime
+	if filter.StartTime != nil {
+		from = *filter.StartTime
+	}
+	annotations, err := database.ListAnnotations(from, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range annotations {
+		if a.RunID != nil && a.Kind == "fleet_import" {
+			agents[*a.RunID] = strings.TrimPrefix(a.Source, "fleet:")
+		}
+	}
+
+	records := make([]Record, 0, len(runs))
+	for _, run := range runs {
+		if run.EndTime == nil {
+			continue
+		}
+		results, err := database.GetResults(run.ID)
+		if err != nil {
+			return nil, err
+		}
+		r := Record{Machine: agents[run.ID], Run: run, Results: results}
+		if r.Machine == "" {
+			r.Machine = run.Machine
+		}
+		if r.Machine == "" {
+			r.Machine = machine
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// Tally counts runs and how many passed
+type Tally struct {
+	Runs   int `json:"runs"`
+	Passed int `json:"passed"`
+}
+
+// Failed returns the number of runs that failed
+func (t Tally) Failed() int {
+	return t.Runs - t.Passed
+}
+
+// PassRate returns the share of runs that passed in percent
+func (t Tally) PassRate() float64 {
+	if t.Runs == 0 {
+		return 0
+	}
+	return float64(t.Passed) / float64(t.Runs) * 100
+}
+
+func (t *Tally) add(passed bool) {
+	t.Runs++
+	if passed {
+		t.Passed++
+	}
+}
+
+// Thermal summarizes one temperature metric, e.g. cpu_temp_peak
+type Thermal struct {
+	Metric string  `json:"metric"`
+	Avg    float64 `json:"avg"`
+	Max    float64 `json:"max"`
+	Runs   int     `json:"runs"`
+}
+
+// MachineStats is the record of one machine
+type MachineStats struct {
+	Name     string    `json:"name"`
+	Model    string    `json:"model"`
+	LastRun  time.Time `json:"last_run"`
+	Thermals []Thermal `json:"thermals,omitempty"`
+	Tally
+}
+
+// ModelStats is the record of every machine of one hardware model
+type ModelStats struct {
+	Model    string    `json:"model"`
+	Machines int       `json:"machines"`
+	Thermals []Thermal `json:"thermals,omitempty"`
+	Tally
+}
+
+// PluginStats is the record of one test across the fleet
+type PluginStats struct {
+	Plugin string `json:"plugin"`
+	Tally
+}
+
+// FailureMode is a failure seen on one or more machines. Errors that only
+// differ in numbers, such as addresses or counts, are grouped together.
+type FailureMode struct {
+	Plugin   string   `json:"plugin"`
+	Error    string   `json:"error"`
+	Example  string   `json:"example"`
+	Count    int      `json:"count"`
+	Machines []string `json:"machines"`
+}
+
+// Outlier is a machine that stands out from the rest of the fleet
+type Outlier struct {
+	Machine string `json:"machine"`
+	Model   string `json:"model"`
+	Reason  string `json:"reason"`
+}
+
+// Summary is the combined record of the runs of many machines
+type Summary struct {
+	Generated time.Time      `json:"generated"`
+	From      time.Time      `json:"from"`
+	To        time.Time      `json:"to"`
+	Machines  []MachineStats `json:"machines"`
+	Models    []ModelStats   `json:"models"`
+	Plugins   []PluginStats  `json:"plugins"`
+	Failures  []FailureMode  `json:"failures"`
+	Outliers  []Outlier      `json:"outliers"`
+	Tally
+}
+
+// thermalSum accumulates one temperature metric
+type thermalSum struct {
+	sum, max float64
+	runs     int
+}
+
+func (t *thermalSum) add(v float64) {
+	t.sum += v
+	t.max = math.Max(t.max, v)
+	t.runs++
+}
+
+// Summarize combines records from any number of machines and databases
+func Summarize(records []Record) *Summary {
+	s := &Summary{Generated: time.Now()}
+
+	type machineAcc struct {
+		stats     MachineStats
+		modelTime time.Time
+		thermals  map[string]*thermalSum
+	}
+	machines := make(map[string]*machineAcc)
+	plugins := make(map[string]*PluginStats)
+	failures := make(map[string]*FailureMode)
+
+	for _, r := range records {
+		run := r.Run
+		s.add(run.Success)
+		if s.From.IsZero() || run.StartTime.Before(s.From) {
+			s.From = run.StartTime
+		}
+		if run.EndTime.After(s.To) {
+			s.To = *run.EndTime
+		}
+
+		m, ok := machines[r.Machine]
+		if !ok {
+			m = &machineAcc{stats: MachineStats{Name: r.Machine}, thermals: make(map[string]*thermalSum)}
+			machines[r.Machine] = m
+		}
+		m.stats.add(run.Success)
+		if run.StartTime.After(m.stats.LastRun) {
+			m.stats.LastRun = run.StartTime
+		}
+		// The latest run that recorded a model names it, so a machine whose
+		// board was swapped moves to its new model
+		if run.Model != "" && !run.StartTime.Before(m.modelTime) {
+			m.stats.Model, m.modelTime = run.Model, run.StartTime
+		}
+		for _, res := range r.Results {
+			if isThermal(res) {
+				t, ok := m.thermals[res.Metric]
+				if !ok {
+					t = &thermalSum{}
+					m.thermals[res.Metric] = t
+				}
+				t.add(res.Value)
+			}
+		}
+
+		p, ok := plugins[run.Plugin]
+		if !ok {
+			p = &PluginStats{Plugin: run.Plugin}
+			plugins[run.Plugin] = p
+		}
+		p.add(run.Success)
+
+		if !run.Success {
+			msg := normalizeError(run.Error)
+			key := run.Plugin + "\x00" + msg
+			f, ok := failures[key]
+			if !ok {
+				f = &FailureMode{Plugin: run.Plugin, Error: msg, Example: firstLine(run.Error)}
+				failures[key] = f
+			}
+			f.Count++
+			if !slices.Contains(f.Machines, r.Machine) {
+				f.Machines = append(f.Machines, r.Machine)
+			}
+		}
+	}
+
+	// Machines, with each model's runs and temperatures pooled
+	models := make(map[string]*ModelStats)
+	modelThermals := make(map[string]map[string]*thermalSum)
+	for _, m := range machines {
+		if m.stats.Model == "" {
+			m.stats.Model = UnknownModel
+		}
+		model, ok := models[m.stats.Model]
+		if !ok {
+			model = &ModelStats{Model: m.stats.Model}
+			models[m.stats.Model] = model
+			modelThermals[m.stats.Model] = make(map[string]*thermalSum)
+		}
+		model.Machines++
+		model.Runs += m.stats.Runs
+		model.Passed += m.stats.Passed
+		for metric, t := range m.thermals {
+			pooled, ok := modelThermals[m.stats.Model][metric]
+			if !ok {
+				pooled = &thermalSum{}
+				modelThermals[m.stats.Model][metric] = pooled
+			}
+			pooled.sum += t.sum
+			pooled.runs += t.runs
+			pooled.max = math.Max(pooled.max, t.max)
+		}
+		m.stats.Thermals = thermals(m.thermals)
+		s.Machines = append(s.Machines, m.stats)
+	}
+	sort.Slice(s.Machines, func(i, j int) bool { return s.Machines[i].Name < s.Machines[j].Name })
+
+	for name, model := range models {
+		model.Thermals = thermals(modelThermals[name])
+		s.Models = append(s.Models, *model)
+	}
+	sort.Slice(s.Models, func(i, j int) bool {
+		if s.Models[i].Machines != s.Models[j].Machines {
+			return s.Models[i].Machines > s.Models[j].Machines
+		}
+		return s.Models[i].Model < s.Models[j].Model
+	})
+
+	for _, p := range plugins {
+		s.Plugins = append(s.Plugins, *p)
+	}
+	sort.Slice(s.Plugins, func(i, j int) bool { return s.Plugins[i].Plugin < s.Plugins[j].Plugin })
+
+	for _, f := range failures {
+		sort.Strings(f.Machines)
+		s.Failures = append(s.Failures, *f)
+	}
+	sort.Slice(s.Failures, func(i, j int) bool {
+		if s.Failures[i].Count != s.Failures[j].Count {
+			return s.Failures[i].Count > s.Failures[j].Count
+		}
+		if s.Failures[i].Plugin != s.Failures[j].Plugin {
+			return s.Failures[i].Plugin < s.Failures[j].Plugin
+		}
+		return s.Failures[i].Error < s.Failures[j].Error
+	})
+
+	s.Outliers = findOutliers(s)
+	return s
+}
+
+// findOutliers flags machines that fail far more often than the fleet, or
+// run far hotter than the other machines of their model
+func findOutliers(s *Summary) []Outlier {
+	var outliers []Outlier
+	for _, m := range s.Machines {
+		if m.Runs >= OutlierMinRuns && m.PassRate() < s.PassRate()-OutlierPassRateDrop {
+			outliers = append(outliers, Outlier{
+				Machine: m.Name,
+				Model:   m.Model,
+				Reason:  fmt.Sprintf("pass rate %.0f%%, fleet %.0f%%", m.PassRate(), s.PassRate()),
+			})
+		}
+	}
+
+	// Median of each model's machine averages per metric
+	medians := make(map[string]map[string]float64)
+	byModel := make(map[string]map[string][]float64)
+	for _, m := range s.Machines {
+		if byModel[m.Model] == nil {
+			byModel[m.Model] = make(map[string][]float64)
+		}
+		for _, t := range m.Thermals {
+			byModel[m.Model][t.Metric] = append(byModel[m.Model][t.Metric], t.Avg)
+		}
+	}
+	for model, metrics := range byModel {
+		medians[model] = make(map[string]float64)
+		for metric, values := range metrics {
+			if len(values) >= OutlierMinModelMachines {
+				medians[model][metric] = median(values)
+			}
+		}
+	}
+	for _, m := range s.Machines {
+		if m.Model == UnknownModel {
+			continue
+		}
+		for _, t := range m.Thermals {
+			med, ok := medians[m.Model][t.Metric]
+			if ok && t.Avg-med > OutlierTempC {
+				outliers = append(outliers, Outlier{
+					Machine: m.Name,
+					Model:   m.Model,
+					Reason:  fmt.Sprintf("%s averages %.1f °C, %.1f °C above the model's median", t.Metric, t.Avg, t.Avg-med),
+				})
+			}
+		}
+	}
+	return outliers
+}
+
+// isThermal reports whether a result is a temperature. Deltas measure a
+// rise rather than how hot the machine ran, so they are left out.
+func isThermal(r *db.Result) bool {
+	return r.Unit == "°C" && !strings.Contains(r.Metric, "delta")
+}
+
+// thermals averages the accumulated metrics, in metric order
+func thermals(sums map[string]*thermalSum) []Thermal {
+	list := make([]Thermal, 0, len(sums))
+	for metric, t := range sums {
+		list = append(list, Thermal{Metric: metric, Avg: math.Round(t.sum/float64(t.runs)*10) / 10, Max: t.max, Runs: t.runs})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Metric < list[j].Metric })
+	return list
+}
+
+// errorNumbers matches the parts of an error that vary between machines
+var errorNumbers = regexp.MustCompile(`0[xX][0-9a-fA-F]+|\d+(\.\d+)?`)
+
+// normalizeError reduces an error to its first line with the numbers
+// replaced, e.g. "3 bit errors at 0x7f00" becomes "# bit errors at #"
+func normalizeError(err string) string {
+	msg := errorNumbers.ReplaceAllString(firstLine(err), "#")
+	if msg == "" {
+		return "(no error message)"
+	}
+	return msg
+}
+
+// firstLine returns the first line of s, shortened to 160 characters
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	if r := []rune(line); len(r) > 160 {
+		line = string(r[:157]) + "..."
+	}
+	return line
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
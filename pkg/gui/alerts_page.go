@@ -0,0 +1,622 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/mscrnt/project_fire/pkg/alerts"
+	"github.com/mscrnt/project_fire/pkg/logging"
+	"github.com/mscrnt/project_fire/pkg/mail"
+	"github.com/mscrnt/project_fire/pkg/safety"
+)
+
+// alertHistoryLimit is how many alert events the history tab shows
+const alertHistoryLimit = 100
+
+// alertCheckInterval is how often the dashboard checks the alert rules
+const alertCheckInterval = safety.DefaultInterval
+
+// alertMetricSuggestions are offered in the rule form; any metric bench
+// monitor reports can be typed
+var alertMetricSuggestions = []string{
+	"cpu_temp", "cpu_power", "cpu_usage", "cpu_clock", "cpu_voltage",
+	"vrm_temp", "memory_usage", "memory_temp", "system_power",
+	"gpu*_temp", "gpu*_hotspot", "gpu*_power", "gpu*_usage", "fan_*",
+}
+
+// mailTLSModes are the choices for securing the SMTP connection
+var mailTLSModes = []struct {
+	label string
+	mode  mail.TLSMode
+}{
+	{"Automatic (TLS on port 465, else STARTTLS if offered)", ""},
+	{"TLS", mail.ImplicitTLS},
+	{"STARTTLS (required)", mail.StartTLS},
+	{"None (trusted relay)", mail.NoTLS},
+}
+
+// AlertsPage is the Settings page for the alert rules, the channels alerts
+// are sent to and the alert history
+type AlertsPage struct {
+	path   string
+	dbPath string
+	window fyne.Window
+
+	content  fyne.CanvasObject
+	list     *widget.List
+	details  *fyne.Container
+	channels *fyne.Container
+	history  *fyne.Container
+	cfg      *alerts.Config
+	selected string
+}
+
+// NewAlertsPage creates the alerts page for the configuration in
+// alerts.DefaultPath and the history in the database at dbPath
+func NewAlertsPage(dbPath string, window fyne.Window) *AlertsPage {
+	p := &AlertsPage{path: alerts.DefaultPath(), dbPath: dbPath, window: window}
+	p.build()
+	p.Refresh()
+	return p
+}
+
+// Content returns the alerts page content
+func (p *AlertsPage) Content() fyne.CanvasObject {
+	return p.content
+}
+
+// build creates the page layout
+func (p *AlertsPage) build() {
+	title := widget.NewLabelWithStyle("Alerts", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	newBtn := widget.NewButtonWithIcon("New Rule", theme.ContentAddIcon(), p.NewRule)
+	newBtn.Importance = widget.HighImportance
+	testBtn := widget.NewButtonWithIcon("Send Test Alert", theme.MailSendIcon(), p.sendTest)
+	refreshBtn := widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), p.Refresh)
+
+	note := widget.NewLabel("Rules are checked while F.I.R.E. is open and by bench monitor. Stored in " + p.path)
+	note.Importance = widget.LowImportance
+	note.Wrapping = fyne.TextWrapWord
+
+	header := container.NewVBox(
+		container.NewBorder(nil, nil, title, container.NewHBox(refreshBtn, testBtn, newBtn)),
+		note,
+		widget.NewSeparator(),
+	)
+
+	p.list = widget.NewList(
+		func() int {
+			if p.cfg == nil {
+				return 0
+			}
+			return len(p.cfg.Rules)
+		},
+		func() fyne.CanvasObject {
+			name := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+			info := widget.NewLabel("")
+			info.Importance = widget.LowImportance
+			return container.NewVBox(name, info)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			r := p.cfg.Rules[id]
+			box := obj.(*fyne.Container)
+			name := r.Name
+			if r.Disabled {
+				name += " (disabled)"
+			}
+			box.Objects[0].(*widget.Label).SetText(name)
+			box.Objects[1].(*widget.Label).SetText(r.String())
+		},
+	)
+	p.list.OnSelected = func(id widget.ListItemID) {
+		p.selected = p.cfg.Rules[id].Name
+		p.showDetails(p.cfg.Rules[id])
+	}
+	p.details = container.NewStack(container.NewCenter(widget.NewLabel("Select a rule")))
+
+	rules := container.NewHSplit(p.list, p.details)
+	rules.Offset = 0.4
+	p.channels = container.NewStack()
+	p.history = container.NewStack()
+
+	tabs := container.NewAppTabs(
+		container.NewTabItemWithIcon("Rules", theme.WarningIcon(), rules),
+		container.NewTabItemWithIcon("Channels", theme.MailComposeIcon(), p.channels),
+		container.NewTabItemWithIcon("History", theme.HistoryIcon(), p.history),
+	)
+	tabs.OnSelected = func(tab *container.TabItem) {
+		if tab.Text == "History" {
+			p.refreshHistory()
+		}
+	}
+	p.content = container.NewBorder(header, nil, nil, nil, tabs)
+}
+
+// Refresh reloads the rules and channels from the configuration file
+func (p *AlertsPage) Refresh() {
+	cfg, err := alerts.Load(p.path)
+	if err != nil {
+		logger.Error("Failed to load alerts", "error", err)
+		cfg = &alerts.Config{Channels: alerts.Channels{Desktop: true}}
+	}
+	p.cfg = cfg
+	p.list.UnselectAll()
+	p.list.Refresh()
+	p.channels.Objects = []fyne.CanvasObject{p.createChannels()}
+	p.channels.Refresh()
+	p.refreshHistory()
+
+	// Keep the selected rule open across refreshes
+	if i := cfg.Rule(p.selected); i >= 0 {
+		p.list.Select(i)
+		return
+	}
+	p.selected = ""
+	p.details.Objects = []fyne.CanvasObject{container.NewCenter(widget.NewLabel("Select a rule"))}
+	p.details.Refresh()
+}
+
+// save writes the configuration, reporting errors in a dialog, and shows
+// the result
+func (p *AlertsPage) save() bool {
+	if err := alerts.Save(p.path, p.cfg); err != nil {
+		dialog.ShowError(err, p.window)
+		p.Refresh()
+		return false
+	}
+	p.Refresh()
+	return true
+}
+
+// showDetails shows a rule with its recent alerts
+func (p *AlertsPage) showDetails(r alerts.Rule) {
+	content := container.NewVBox(
+		widget.NewLabelWithStyle(r.Name, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabel("Condition: "+r.String()),
+	)
+
+	enabled := widget.NewCheck("Enabled", nil)
+	enabled.SetChecked(!r.Disabled)
+	enabled.OnChanged = func(on bool) {
+		if i := p.cfg.Rule(r.Name); i >= 0 {
+			p.cfg.Rules[i].Disabled = !on
+			p.save()
+		}
+	}
+	editBtn := widget.NewButtonWithIcon("Edit", theme.DocumentCreateIcon(), func() { p.showForm(&r) })
+	deleteBtn := widget.NewButtonWithIcon("Delete", theme.DeleteIcon(), func() { p.confirmDelete(r) })
+	content.Add(container.NewHBox(enabled, editBtn, deleteBtn))
+
+	content.Add(widget.NewSeparator())
+	content.Add(widget.NewLabelWithStyle("Recent Alerts", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+	content.Add(p.createHistory(r.Name))
+
+	p.details.Objects = []fyne.CanvasObject{container.NewVScroll(container.NewPadded(content))}
+	p.details.Refresh()
+}
+
+// refreshHistory reloads the history tab
+func (p *AlertsPage) refreshHistory() {
+	p.history.Objects = []fyne.CanvasObject{container.NewVScroll(container.NewPadded(p.createHistory("")))}
+	p.history.Refresh()
+}
+
+// createHistory lists the latest alert events, only those of one rule when
+// rule is set
+func (p *AlertsPage) createHistory(rule string) fyne.CanvasObject {
+	database, err := openResults(p.dbPath)
+	if err != nil {
+		return widget.NewLabel(fmt.Sprintf("Failed to open database: %v", err))
+	}
+	defer func() { _ = database.Close() }()
+
+	events, err := database.ListAlertEvents(alertHistoryLimit)
+	if err != nil {
+		return widget.NewLabel(err.Error())
+	}
+
+	grid := container.NewGridWithColumns(5,
+		widget.NewLabelWithStyle("Time", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("State", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("Rule", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("Reading", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("Delivery", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+	)
+	shown := 0
+	for _, e := range events {
+		if rule != "" && !strings.EqualFold(e.Rule, rule) {
+			continue
+		}
+		state := widget.NewLabel("FIRED")
+		state.Importance = widget.DangerImportance
+		if e.State == string(alerts.Resolved) {
+			state.SetText("RESOLVED")
+			state.Importance = widget.SuccessImportance
+		}
+		delivery := widget.NewLabel("Sent (" + e.Source + ")")
+		if e.Error != "" {
+			delivery.SetText(e.Error)
+			delivery.Importance = widget.WarningImportance
+			delivery.Truncation = fyne.TextTruncateEllipsis
+		}
+		grid.Add(widget.NewLabel(e.Time.Local().Format("2006-01-02 15:04:05")))
+		grid.Add(state)
+		grid.Add(widget.NewLabel(e.Rule))
+		grid.Add(widget.NewLabel(fmt.Sprintf("%s = %.1f", e.Metric, e.Value)))
+		grid.Add(delivery)
+		shown++
+	}
+	if shown == 0 {
+		return widget.NewLabel("No alerts recorded")
+	}
+	return grid
+}
+
+// confirmDelete deletes a rule after confirmation
+func (p *AlertsPage) confirmDelete(r alerts.Rule) {
+	dialog.ShowConfirm("Delete Rule", fmt.Sprintf("Delete alert rule '%s'? Its alert history is kept.", r.Name), func(ok bool) {
+		if !ok {
+			return
+		}
+		if i := p.cfg.Rule(r.Name); i >= 0 {
+			p.cfg.Rules = append(p.cfg.Rules[:i], p.cfg.Rules[i+1:]...)
+			p.save()
+		}
+	}, p.window)
+}
+
+// NewRule opens the form for a new rule
+func (p *AlertsPage) NewRule() {
+	p.showForm(nil)
+}
+
+// showForm shows the create/edit form. existing is nil for a new rule.
+func (p *AlertsPage) showForm(existing *alerts.Rule) {
+	nameEntry := widget.NewEntry()
+	metricEntry := widget.NewSelectEntry(alertMetricSuggestions)
+	metricEntry.SetPlaceHolder("e.g. cpu_temp or gpu*_temp")
+	comparators := make([]string, len(alerts.Comparators))
+	for i, c := range alerts.Comparators {
+		comparators[i] = string(c)
+	}
+	comparatorSelect := widget.NewSelect(comparators, nil)
+	comparatorSelect.SetSelected(string(alerts.Above))
+	thresholdEntry := widget.NewEntry()
+	thresholdEntry.SetPlaceHolder("e.g. 95")
+	forEntry := widget.NewEntry()
+	forEntry.SetPlaceHolder("e.g. 30s (empty alerts straight away)")
+	enabledCheck := widget.NewCheck("Enabled", nil)
+	enabledCheck.SetChecked(true)
+
+	title, confirm := "New Alert Rule", "Create"
+	if existing != nil {
+		title, confirm = "Edit Alert Rule", "Save"
+		nameEntry.SetText(existing.Name)
+		metricEntry.SetText(existing.Metric)
+		comparatorSelect.SetSelected(string(existing.Comparator))
+		thresholdEntry.SetText(strconv.FormatFloat(existing.Threshold, 'f', -1, 64))
+		forEntry.SetText(existing.For)
+		enabledCheck.SetChecked(!existing.Disabled)
+	}
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("Metric", metricEntry),
+		widget.NewFormItem("Comparator", comparatorSelect),
+		widget.NewFormItem("Threshold", thresholdEntry),
+		widget.NewFormItem("For", forEntry),
+		widget.NewFormItem("", enabledCheck),
+	}
+
+	form := dialog.NewForm(title, confirm, "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		threshold, err := strconv.ParseFloat(strings.TrimSpace(thresholdEntry.Text), 64)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("invalid threshold %q", thresholdEntry.Text), p.window)
+			return
+		}
+		rule := alerts.Rule{
+			Name:       strings.TrimSpace(nameEntry.Text),
+			Metric:     strings.TrimSpace(metricEntry.Text),
+			Comparator: alerts.Comparator(comparatorSelect.Selected),
+			Threshold:  threshold,
+			For:        strings.TrimSpace(forEntry.Text),
+			Disabled:   !enabledCheck.Checked,
+		}
+		if err := rule.Validate(); err != nil {
+			dialog.ShowError(err, p.window)
+			return
+		}
+
+		// A new name must not replace another rule
+		i := p.cfg.Rule(rule.Name)
+		if existing != nil {
+			if i >= 0 && !strings.EqualFold(existing.Name, rule.Name) {
+				dialog.ShowError(fmt.Errorf("a rule named %q already exists", rule.Name), p.window)
+				return
+			}
+			i = p.cfg.Rule(existing.Name)
+		} else if i >= 0 {
+			dialog.ShowError(fmt.Errorf("a rule named %q already exists", rule.Name), p.window)
+			return
+		}
+		if i >= 0 {
+			p.cfg.Rules[i] = rule
+		} else {
+			p.cfg.Rules = append(p.cfg.Rules, rule)
+		}
+		p.selected = rule.Name
+		p.save()
+	}, p.window)
+	form.Resize(fyne.NewSize(480, 420))
+	form.Show()
+}
+
+// createChannels builds the form for where alerts are sent
+func (p *AlertsPage) createChannels() fyne.CanvasObject {
+	desktopCheck := widget.NewCheck("Desktop notifications while F.I.R.E. is open", nil)
+	desktopCheck.SetChecked(p.cfg.Channels.Desktop)
+
+	hostEntry := widget.NewEntry()
+	hostEntry.SetPlaceHolder("smtp.example.com")
+	portEntry := widget.NewEntry()
+	portEntry.SetPlaceHolder("587")
+	userEntry := widget.NewEntry()
+	passwordEntry := widget.NewPasswordEntry()
+	fromEntry := widget.NewEntry()
+	fromEntry.SetPlaceHolder("fire@example.com")
+	toEntry := widget.NewEntry()
+	toEntry.SetPlaceHolder("ops@example.com, lab@example.com")
+	tlsLabels := make([]string, len(mailTLSModes))
+	for i, m := range mailTLSModes {
+		tlsLabels[i] = m.label
+	}
+	tlsSelect := widget.NewSelect(tlsLabels, nil)
+	tlsSelect.SetSelectedIndex(0)
+	if e := p.cfg.Channels.Email; e != nil {
+		for i, m := range mailTLSModes {
+			if m.mode == e.TLS {
+				tlsSelect.SetSelectedIndex(i)
+			}
+		}
+		hostEntry.SetText(e.Host)
+		if e.Port != 0 {
+			portEntry.SetText(strconv.Itoa(e.Port))
+		}
+		userEntry.SetText(e.Username)
+		passwordEntry.SetText(e.Password)
+		fromEntry.SetText(e.From)
+		toEntry.SetText(strings.Join(e.To, ", "))
+	}
+
+	webhooksEntry := widget.NewMultiLineEntry()
+	webhooksEntry.SetPlaceHolder("One URL per line, e.g. https://hooks.slack.com/services/...")
+	webhooksEntry.SetMinRowsVisible(3)
+	var urls []string
+	for _, w := range p.cfg.Channels.Webhooks {
+		urls = append(urls, w.URL)
+	}
+	webhooksEntry.SetText(strings.Join(urls, "\n"))
+
+	saveBtn := widget.NewButtonWithIcon("Save Channels", theme.DocumentSaveIcon(), func() {
+		channels := alerts.Channels{Desktop: desktopCheck.Checked}
+		if host := strings.TrimSpace(hostEntry.Text); host != "" {
+			email := &alerts.EmailConfig{Config: mail.Config{
+				Host:     host,
+				Username: strings.TrimSpace(userEntry.Text),
+				Password: passwordEntry.Text,
+				From:     strings.TrimSpace(fromEntry.Text),
+				TLS:      mailTLSModes[tlsSelect.SelectedIndex()].mode,
+			}}
+			if port := strings.TrimSpace(portEntry.Text); port != "" {
+				n, err := strconv.Atoi(port)
+				if err != nil || n <= 0 || n > 65535 {
+					dialog.ShowError(fmt.Errorf("invalid SMTP port %q", port), p.window)
+					return
+				}
+				email.Port = n
+			}
+			for _, to := range strings.Split(toEntry.Text, ",") {
+				if to = strings.TrimSpace(to); to != "" {
+					email.To = append(email.To, to)
+				}
+			}
+			channels.Email = email
+		}
+		for _, line := range strings.Split(webhooksEntry.Text, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				webhook := alerts.Webhook{URL: line}
+				// Keep headers set in the file for webhooks that stay
+				for _, w := range p.cfg.Channels.Webhooks {
+					if w.URL == line {
+						webhook.Headers = w.Headers
+					}
+				}
+				channels.Webhooks = append(channels.Webhooks, webhook)
+			}
+		}
+		previous := p.cfg.Channels
+		p.cfg.Channels = channels
+		if err := p.cfg.Validate(); err != nil {
+			p.cfg.Channels = previous
+			dialog.ShowError(err, p.window)
+			return
+		}
+		if p.save() {
+			dialog.ShowInformation("Alert Channels", "Saved where alerts are sent", p.window)
+		}
+	})
+	saveBtn.Importance = widget.HighImportance
+
+	form := widget.NewForm(
+		widget.NewFormItem("SMTP server", hostEntry),
+		widget.NewFormItem("Port", portEntry),
+		widget.NewFormItem("Security", tlsSelect),
+		widget.NewFormItem("Username", userEntry),
+		widget.NewFormItem("Password", passwordEntry),
+		widget.NewFormItem("From", fromEntry),
+		widget.NewFormItem("To", toEntry),
+	)
+
+	return container.NewVScroll(container.NewPadded(container.NewVBox(
+		desktopCheck,
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle("Email", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		form,
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle("Webhooks", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		webhooksEntry,
+		container.NewHBox(saveBtn),
+	)))
+}
+
+// sendTest sends a test alert to the saved channels and reports which ones
+// delivered it
+func (p *AlertsPage) sendTest() {
+	cfg := p.cfg
+	go func() {
+		event := alerts.TestEvent(time.Now())
+		var lines []string
+		if cfg.Channels.Desktop {
+			fyne.CurrentApp().SendNotification(&fyne.Notification{Title: "F.I.R.E. Alert", Content: event.Message()})
+			lines = append(lines, "Desktop notification sent")
+		}
+		for _, n := range cfg.Channels.Notifiers() {
+			ctx, cancel := context.WithTimeout(context.Background(), alerts.DefaultTimeout)
+			err := n.Notify(ctx, event)
+			cancel()
+			if err != nil {
+				lines = append(lines, "Failed: "+err.Error())
+			} else {
+				lines = append(lines, "Sent: "+describeNotifier(n))
+			}
+		}
+		if len(lines) == 0 {
+			lines = append(lines, "No channels are set up. Add email or webhooks under Channels.")
+		}
+		fyne.Do(func() {
+			dialog.ShowInformation("Test Alert", strings.Join(lines, "\n"), p.window)
+		})
+	}()
+}
+
+// describeNotifier names a channel in the test alert results
+func describeNotifier(n alerts.Notifier) string {
+	switch n := n.(type) {
+	case *alerts.EmailNotifier:
+		return "email to " + strings.Join(n.Config.To, ", ")
+	case *alerts.WebhookNotifier:
+		return "webhook " + n.Webhook.URL
+	}
+	return "channel"
+}
+
+// WatchAlerts checks the alert rules against the sensors every
+// alertCheckInterval until the dashboard stops, and sends the alerts they
+// raise to the desktop and the configured channels. Changes to the rules
+// are picked up as the file is saved.
+func (d *Dashboard) WatchAlerts(dbPath string) {
+	database, err := openResults(dbPath)
+	if err != nil {
+		logger.Warn("Alert history not recorded", "error", err)
+	} else {
+		defer func() { _ = database.Close() }()
+	}
+	// Alerts still being sent are recorded before the database closes
+	var pending sync.WaitGroup
+	defer pending.Wait()
+
+	path := alerts.DefaultPath()
+	var (
+		modified   time.Time
+		rules      int
+		engine     = alerts.NewEngine(nil)
+		dispatcher = &alerts.Dispatcher{DB: database, Source: "gui"}
+	)
+	reload := func() {
+		info, err := os.Stat(path)
+		if err != nil || info.ModTime().Equal(modified) {
+			return
+		}
+		modified = info.ModTime()
+		cfg, err := alerts.Load(path)
+		if err != nil {
+			logger.Warn("Alert rules not loaded", "error", err)
+			return
+		}
+		engine.SetRules(cfg.Rules)
+		rules = len(cfg.Rules)
+
+		desktop := cfg.Channels.Desktop
+		notifiers := []alerts.Notifier{alerts.NotifierFunc(func(_ context.Context, e alerts.Event) error {
+			title := "F.I.R.E. Alert"
+			if e.State == alerts.Resolved {
+				title = "F.I.R.E. Alert Resolved"
+			}
+			logger.Log(context.Background(), logging.LevelAlert, fmt.Sprintf("%s: %s", title, e.Message()))
+			if e.State == alerts.Firing {
+				d.mu.Lock()
+				d.lastAlert = e.Message()
+				d.mu.Unlock()
+			}
+			if desktop {
+				fyne.CurrentApp().SendNotification(&fyne.Notification{Title: title, Content: e.Message()})
+			}
+			return nil
+		})}
+		dispatcher = &alerts.Dispatcher{
+			Notifiers: append(notifiers, cfg.Channels.Notifiers()...),
+			DB:        database,
+			Source:    "gui",
+		}
+	}
+
+	sample := safety.MonitorSampler()
+	ticker := time.NewTicker(alertCheckInterval)
+	defer ticker.Stop()
+	for {
+		reload()
+		// Without rules the sensors are left alone
+		if rules > 0 {
+			checkAlerts(engine, sample, dispatcher, &pending)
+		}
+		d.mu.Lock()
+		d.alertsFiring = engine.Firing()
+		d.mu.Unlock()
+
+		select {
+		case <-ticker.C:
+		case <-d.stopChan:
+			return
+		}
+	}
+}
+
+// checkAlerts takes one set of readings and sends the alerts they raise in
+// the background
+func checkAlerts(engine *alerts.Engine, sample safety.SampleFunc, dispatcher *alerts.Dispatcher, pending *sync.WaitGroup) {
+	values, err := sample(context.Background())
+	if err != nil {
+		return
+	}
+	for _, event := range engine.Evaluate(time.Now(), values) {
+		pending.Add(1)
+		go func(event alerts.Event) {
+			defer pending.Done()
+			if err := dispatcher.Dispatch(context.Background(), event); err != nil {
+				logger.Warn("Alert not delivered", "rule", event.Rule.Name, "error", err)
+			}
+		}(event)
+	}
+}
@@ -0,0 +1,312 @@
+package gui
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg" // Register JPEG for image sizes
+	_ "image/png"  // Register PNG for image sizes
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/mscrnt/project_fire/pkg/artifact"
+)
+
+// maxLogPreview limits how much of a log is loaded into the viewer
+const maxLogPreview = 1 << 20
+
+// Image zoom limits and step
+const (
+	minImageZoom  = 0.1
+	maxImageZoom  = 8.0
+	imageZoomStep = 1.25
+)
+
+// ShowArtifactBrowser opens a window listing the runs that have artifacts,
+// with a preview of the selected file
+func ShowArtifactBrowser(app fyne.App, dbPath string) fyne.Window {
+	root := artifact.DefaultRoot()
+	window := app.NewWindow("F.I.R.E. - Run Artifacts")
+	window.Resize(fyne.NewSize(1000, 700))
+
+	ids, err := artifact.Runs(root)
+	if err != nil {
+		logger.Warn("Failed to list artifacts", "error", err)
+	}
+	if len(ids) == 0 {
+		window.SetContent(container.NewCenter(widget.NewLabel("No run artifacts in " + root)))
+		window.Show()
+		return window
+	}
+
+	var (
+		runID     int64
+		artifacts []artifact.Artifact
+	)
+	preview := container.NewStack()
+	showPlaceholder := func() {
+		preview.Objects = []fyne.CanvasObject{container.NewCenter(widget.NewLabel("Select an artifact"))}
+		preview.Refresh()
+	}
+
+	files := widget.NewList(
+		func() int { return len(artifacts) },
+		func() fyne.CanvasObject {
+			size := widget.NewLabel("")
+			size.Importance = widget.LowImportance
+			return container.NewBorder(nil, nil, nil, size, widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			row := obj.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(artifacts[id].Name)
+			row.Objects[1].(*widget.Label).SetText(formatBytes(uint64(artifacts[id].Size))) // #nosec G115 -- file sizes are non-negative
+		},
+	)
+	files.OnSelected = func(id widget.ListItemID) {
+		preview.Objects = []fyne.CanvasObject{newArtifactPreview(app, artifacts[id])}
+		preview.Refresh()
+	}
+
+	runSelect := widget.NewSelect(artifactRunLabels(dbPath, ids), nil)
+	runSelect.OnChanged = func(string) {
+		runID = ids[runSelect.SelectedIndex()]
+		artifacts, err = artifact.List(root, runID)
+		if err != nil {
+			logger.Warn(err.Error())
+		}
+		files.UnselectAll()
+		files.Refresh()
+		showPlaceholder()
+		if len(artifacts) > 0 {
+			files.Select(0)
+		}
+	}
+
+	openFolder := widget.NewButtonWithIcon("Open Folder", theme.FolderOpenIcon(), func() {
+		openPath(app, artifact.RunDir(root, runID))
+	})
+
+	split := container.NewHSplit(files, preview)
+	split.Offset = 0.25
+	toolbar := container.NewBorder(nil, nil, widget.NewLabel("Run:"), openFolder, runSelect)
+	window.SetContent(container.NewBorder(toolbar, nil, nil, nil, split))
+	runSelect.SetSelectedIndex(0)
+	window.Show()
+	return window
+}
+
+// ShowArtifactViewer opens a single artifact in its own window
+func ShowArtifactViewer(app fyne.App, a artifact.Artifact) fyne.Window {
+	window := app.NewWindow("F.I.R.E. - " + a.Name)
+	window.Resize(fyne.NewSize(900, 650))
+	window.SetContent(newArtifactPreview(app, a))
+	window.Show()
+	return window
+}
+
+// artifactRunLabels describes each run for the run selector, using the
+// database when it is available
+func artifactRunLabels(dbPath string, ids []int64) []string {
+	labels := make([]string, len(ids))
+	for i, id := range ids {
+		labels[i] = fmt.Sprintf("Run #%d", id)
+	}
+
+	database, err := openResults(dbPath)
+	if err != nil {
+		return labels
+	}
+	defer func() { _ = database.Close() }()
+
+	for i, id := range ids {
+		run, err := database.GetRun(id)
+		if err != nil {
+			continue
+		}
+		status := "PASS"
+		if !run.Success {
+			status = "FAIL"
+		}
+		labels[i] = fmt.Sprintf("Run #%d  %s  %s  %s", id, run.Plugin, run.StartTime.Format("2006-01-02 15:04:05"), status)
+	}
+	return labels
+}
+
+// newArtifactPreview shows an image or log, or the file details for other types
+func newArtifactPreview(app fyne.App, a artifact.Artifact) fyne.CanvasObject {
+	switch a.Kind {
+	case artifact.KindImage:
+		return newImageViewer(a.Path)
+	case artifact.KindLog:
+		return newLogViewer(a.Path)
+	default:
+		return container.NewCenter(container.NewVBox(
+			widget.NewLabelWithStyle(a.Name, fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(fmt.Sprintf("%s, modified %s", formatBytes(uint64(a.Size)), a.ModTime.Format("2006-01-02 15:04:05")), // #nosec G115 -- file sizes are non-negative
+				fyne.TextAlignCenter, fyne.TextStyle{}),
+			widget.NewLabelWithStyle("No preview for this file type", fyne.TextAlignCenter, fyne.TextStyle{Italic: true}),
+			widget.NewButtonWithIcon("Open With Default App", theme.FileIcon(), func() { openPath(app, a.Path) }),
+		))
+	}
+}
+
+// newImageViewer shows an image fitted to the view, with zoom buttons
+func newImageViewer(path string) fyne.CanvasObject {
+	img := canvas.NewImageFromFile(path)
+	img.FillMode = canvas.ImageFillContain
+
+	// Pixel size for zooming; unknown for SVG, which is only fitted
+	var size fyne.Size
+	if f, err := os.Open(path); err == nil { // #nosec G304 -- artifact chosen from the run directory
+		if cfg, _, err := image.DecodeConfig(f); err == nil {
+			size = fyne.NewSize(float32(cfg.Width), float32(cfg.Height))
+		}
+		_ = f.Close()
+	}
+
+	zoom := 0.0 // 0 fits the image to the view
+	zoomLabel := widget.NewLabel("Fit")
+	scroll := container.NewScroll(img)
+	apply := func() {
+		if zoom == 0 {
+			img.SetMinSize(fyne.NewSize(0, 0))
+			zoomLabel.SetText("Fit")
+		} else {
+			img.SetMinSize(fyne.NewSize(size.Width*float32(zoom), size.Height*float32(zoom)))
+			zoomLabel.SetText(fmt.Sprintf("%.0f%%", zoom*100))
+		}
+		img.Refresh()
+		scroll.Refresh()
+	}
+
+	zoomIn := widget.NewButtonWithIcon("", theme.ZoomInIcon(), func() {
+		zoom = nextZoom(zoom, true)
+		apply()
+	})
+	zoomOut := widget.NewButtonWithIcon("", theme.ZoomOutIcon(), func() {
+		zoom = nextZoom(zoom, false)
+		apply()
+	})
+	actual := widget.NewButton("100%", func() {
+		zoom = 1
+		apply()
+	})
+	fit := widget.NewButtonWithIcon("Fit", theme.ZoomFitIcon(), func() {
+		zoom = 0
+		apply()
+	})
+	if size.Width == 0 {
+		zoomIn.Disable()
+		zoomOut.Disable()
+		actual.Disable()
+	}
+
+	toolbar := container.NewHBox(zoomOut, zoomLabel, zoomIn, actual, fit)
+	return container.NewBorder(toolbar, nil, nil, nil, scroll)
+}
+
+// nextZoom steps the zoom factor in or out. Zooming from the fitted view
+// (zoom 0) starts at 100%.
+func nextZoom(zoom float64, in bool) float64 {
+	if zoom == 0 {
+		zoom = 1
+	}
+	if in {
+		zoom *= imageZoomStep
+	} else {
+		zoom /= imageZoomStep
+	}
+	if zoom < minImageZoom {
+		return minImageZoom
+	}
+	if zoom > maxImageZoom {
+		return maxImageZoom
+	}
+	return zoom
+}
+
+// newLogViewer shows a log with each line coloured by its severity
+func newLogViewer(path string) fyne.CanvasObject {
+	f, err := os.Open(path) // #nosec G304 -- artifact chosen from the run directory
+	if err != nil {
+		return widget.NewLabel(fmt.Sprintf("Failed to open %s: %v", path, err))
+	}
+	defer func() { _ = f.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxLogPreview+1))
+	if err != nil {
+		return widget.NewLabel(fmt.Sprintf("Failed to read %s: %v", path, err))
+	}
+	truncated := len(data) > maxLogPreview
+	if truncated {
+		data = data[:maxLogPreview]
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\r\n"), "\n")
+	segments := make([]widget.RichTextSegment, 0, len(lines))
+	errorCount, warningCount := 0, 0
+	for _, line := range lines {
+		level := artifact.LineLevel(line)
+		switch level {
+		case artifact.LevelError:
+			errorCount++
+		case artifact.LevelWarning:
+			warningCount++
+		}
+		style := widget.RichTextStyleCodeBlock
+		style.ColorName = logLevelColor(level)
+		segments = append(segments, &widget.TextSegment{Text: strings.TrimRight(line, "\r"), Style: style})
+	}
+
+	text := widget.NewRichText(segments...)
+	summary := fmt.Sprintf("%d lines, %d errors, %d warnings", len(lines), errorCount, warningCount)
+	if truncated {
+		summary += fmt.Sprintf(" - showing the first %s", formatBytes(maxLogPreview))
+	}
+	wrap := widget.NewCheck("Wrap lines", func(on bool) {
+		if on {
+			text.Wrapping = fyne.TextWrapWord
+		} else {
+			text.Wrapping = fyne.TextWrapOff
+		}
+		text.Refresh()
+	})
+
+	toolbar := container.NewBorder(nil, nil, nil, wrap, widget.NewLabel(summary))
+	return container.NewBorder(toolbar, nil, nil, nil, container.NewScroll(text))
+}
+
+// logLevelColor returns the theme colour used for a log level
+func logLevelColor(level artifact.Level) fyne.ThemeColorName {
+	switch level {
+	case artifact.LevelError:
+		return theme.ColorNameError
+	case artifact.LevelWarning:
+		return theme.ColorNameWarning
+	case artifact.LevelInfo:
+		return theme.ColorNameSuccess
+	case artifact.LevelDebug:
+		return theme.ColorNameDisabled
+	default:
+		return theme.ColorNameForeground
+	}
+}
+
+// openPath opens a file or folder with the system's default application
+func openPath(app fyne.App, path string) {
+	u, err := url.Parse(storage.NewFileURI(path).String())
+	if err != nil {
+		logger.Error("Invalid path", "path", path, "error", err)
+		return
+	}
+	if err := app.OpenURL(u); err != nil {
+		logger.Error("Failed to open artifact", "path", path, "error", err)
+	}
+}
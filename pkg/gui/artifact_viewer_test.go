@@ -0,0 +1,18 @@
+package gui
+
+import "testing"
+
+func TestNextZoom(t *testing.T) {
+	if got := nextZoom(0, true); got != imageZoomStep {
+		t.Errorf("Expected zooming in from fit to start at 100%%, got %v", got)
+	}
+	if got := nextZoom(0, false); got != 1/imageZoomStep {
+		t.Errorf("Expected zooming out from fit to start at 100%%, got %v", got)
+	}
+	if got := nextZoom(maxImageZoom, true); got != maxImageZoom {
+		t.Errorf("Expected zoom to stop at %v, got %v", maxImageZoom, got)
+	}
+	if got := nextZoom(minImageZoom, false); got != minImageZoom {
+		t.Errorf("Expected zoom to stop at %v, got %v", minImageZoom, got)
+	}
+}
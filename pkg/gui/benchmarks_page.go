@@ -0,0 +1,340 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/mscrnt/project_fire/pkg/benchmark"
+	"github.com/mscrnt/project_fire/pkg/changelog"
+	"github.com/mscrnt/project_fire/pkg/config"
+)
+
+// BenchmarksPage runs the benchmark suite and shows the scores of this
+// machine over time, ranked against the runs on the same hardware model
+type BenchmarksPage struct {
+	dbPath string
+	window fyne.Window
+
+	content    fyne.CanvasObject
+	workloads  *widget.CheckGroup
+	shareCheck *widget.Check
+	runBtn     *widget.Button
+	stopBtn    *widget.Button
+	status     *widget.Label
+	progress   *widget.ProgressBar
+	scores     *fyne.Container
+	scoreList  *widget.Select
+	chart      *EnhancedLineChart
+	historyLbl *widget.Label
+
+	history []*benchmark.Suite
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewBenchmarksPage creates the Benchmarks page for the database at dbPath
+func NewBenchmarksPage(dbPath string, window fyne.Window) *BenchmarksPage {
+	p := &BenchmarksPage{dbPath: dbPath, window: window}
+	p.build()
+	p.Refresh()
+	return p
+}
+
+// build creates the page
+func (p *BenchmarksPage) build() {
+	var titles []string
+	for _, w := range benchmark.Workloads {
+		titles = append(titles, w.Title)
+	}
+	p.workloads = widget.NewCheckGroup(titles, nil)
+	p.workloads.SetSelected(titles)
+	p.shareCheck = widget.NewCheck("Share scores anonymously for comparison", nil)
+
+	p.runBtn = widget.NewButtonWithIcon("Run Benchmark", theme.MediaPlayIcon(), p.run)
+	p.runBtn.Importance = widget.HighImportance
+	p.stopBtn = widget.NewButtonWithIcon("Stop", theme.MediaStopIcon(), p.abort)
+	p.stopBtn.Disable()
+	p.status = widget.NewLabel("")
+	p.status.Wrapping = fyne.TextWrapWord
+	p.progress = widget.NewProgressBar()
+	p.progress.Hide()
+
+	note := widget.NewLabel("Each workload runs with fixed parameters, so scores compare across runs and machines. A reference desktop (8-core CPU, dual-channel DDR4, SATA SSD) scores 1000 points.")
+	note.Wrapping = fyne.TextWrapWord
+	note.Importance = widget.LowImportance
+
+	setup := container.NewVBox(
+		widget.NewLabelWithStyle("Workloads", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		p.workloads,
+		p.shareCheck,
+		note,
+		widget.NewSeparator(),
+		container.NewGridWithColumns(2, p.runBtn, p.stopBtn),
+		p.progress,
+		p.status,
+	)
+	setupScroll := container.NewVScroll(container.NewPadded(setup))
+	setupScroll.SetMinSize(fyne.NewSize(320, 0))
+
+	p.scores = container.NewGridWithColumns(4)
+
+	titlesByName := benchmark.Titles()
+	var scoreTitles []string
+	for _, name := range benchmark.ScoreNames() {
+		scoreTitles = append(scoreTitles, titlesByName[name])
+	}
+	p.chart = NewEnhancedLineChart("", 1, 0)
+	p.chart.SetUnit(benchmark.ScoreUnit)
+	p.historyLbl = widget.NewLabel("")
+	p.scoreList = widget.NewSelect(scoreTitles, func(string) { p.showHistory() })
+
+	history := container.NewBorder(
+		container.NewHBox(
+			widget.NewLabelWithStyle("Score History", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			p.scoreList,
+			p.historyLbl,
+		),
+		nil, nil, nil,
+		p.chart,
+	)
+
+	results := container.NewBorder(
+		container.NewVBox(
+			widget.NewLabelWithStyle("Latest Scores", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			p.scores,
+			widget.NewSeparator(),
+		),
+		nil, nil, nil,
+		history,
+	)
+
+	p.content = container.NewBorder(nil, nil, setupScroll, nil, container.NewPadded(results))
+	p.scoreList.SetSelectedIndex(0)
+}
+
+// Content returns the page
+func (p *BenchmarksPage) Content() fyne.CanvasObject {
+	return p.content
+}
+
+// Refresh reloads this machine's suites and shows the latest
+func (p *BenchmarksPage) Refresh() {
+	p.shareCheck.Enable()
+	if settings, err := config.Load(); err == nil && settings.Telemetry.Enabled != nil && !*settings.Telemetry.Enabled {
+		p.shareCheck.SetChecked(false)
+		p.shareCheck.Disable()
+	}
+
+	database, err := openResults(p.dbPath)
+	if err != nil {
+		p.status.SetText(fmt.Sprintf("Database error: %v", err))
+		return
+	}
+	defer func() { _ = database.Close() }()
+
+	p.history, err = benchmark.History(database, changelog.Machine())
+	if err != nil {
+		p.status.SetText(fmt.Sprintf("Failed to load the benchmark history: %v", err))
+		return
+	}
+	var ranks map[string]benchmark.Rank
+	if len(p.history) > 0 {
+		ranks, err = benchmark.Compare(database, p.history[len(p.history)-1])
+		if err != nil {
+			logger.Warn("Failed to compare benchmark scores", "error", err)
+		}
+	}
+	p.showScores(ranks)
+	p.showHistory()
+}
+
+// showScores shows a card for each score of the latest suite
+func (p *BenchmarksPage) showScores(ranks map[string]benchmark.Rank) {
+	p.scores.Objects = nil
+	if len(p.history) == 0 {
+		p.scores.Objects = []fyne.CanvasObject{widget.NewLabel("No benchmark runs yet.")}
+		p.scores.Refresh()
+		return
+	}
+	latest := p.history[len(p.history)-1]
+	titles := benchmark.Titles()
+	for _, name := range benchmark.ScoreNames() {
+		v, ok := latest.Scores[name]
+		if !ok {
+			continue
+		}
+		value := canvas.NewText(fmt.Sprintf("%.0f", v), ColorSunset)
+		value.TextSize = 28
+		value.TextStyle = fyne.TextStyle{Bold: true}
+
+		compared := "No other runs on this model yet"
+		if latest.Run.Model == "" {
+			compared = "Hardware model unknown"
+		}
+		if r, ok := ranks[name]; ok {
+			compared = fmt.Sprintf("Better than %.0f%% of %d runs on this model (median %.0f)", r.Percentile, r.Count, r.Median)
+		}
+		rank := widget.NewLabel(compared)
+		rank.Wrapping = fyne.TextWrapWord
+		rank.Importance = widget.LowImportance
+
+		bg := canvas.NewRectangle(ColorCardBackground)
+		bg.CornerRadius = 4
+		p.scores.Add(container.NewPadded(container.NewStack(bg, container.NewPadded(container.NewVBox(
+			widget.NewLabelWithStyle(titles[name], fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			value,
+			rank,
+		)))))
+	}
+	p.scores.Refresh()
+}
+
+// showHistory charts the chosen score over this machine's suites
+func (p *BenchmarksPage) showHistory() {
+	if p.chart == nil || p.scoreList.SelectedIndex() < 0 {
+		return
+	}
+	name := benchmark.ScoreNames()[p.scoreList.SelectedIndex()]
+	var values []float64
+	for _, s := range p.history {
+		if v, ok := s.Scores[name]; ok {
+			values = append(values, v)
+		}
+	}
+	p.chart.SetValues(values)
+	switch len(values) {
+	case 0:
+		p.historyLbl.SetText("not measured yet")
+	case 1:
+		p.historyLbl.SetText("1 run")
+	default:
+		p.historyLbl.SetText(fmt.Sprintf("%d runs, %+.1f%% since the first", len(values), (values[len(values)-1]/values[0]-1)*100))
+	}
+}
+
+// run runs the chosen workloads
+func (p *BenchmarksPage) run() {
+	selected := make(map[string]bool, len(p.workloads.Selected))
+	for _, title := range p.workloads.Selected {
+		selected[title] = true
+	}
+	var workloads []benchmark.Workload
+	for _, w := range benchmark.Workloads {
+		if selected[w.Title] {
+			workloads = append(workloads, w)
+		}
+	}
+	if len(workloads) == 0 {
+		dialog.ShowInformation("Benchmark", "Choose at least one workload.", p.window)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	p.mu.Lock()
+	p.cancel, p.done = cancel, done
+	p.mu.Unlock()
+	p.runBtn.Disable()
+	p.stopBtn.Enable()
+	p.workloads.Disable()
+	p.progress.SetValue(0)
+	p.progress.Show()
+	share := p.shareCheck.Checked
+
+	go func() {
+		defer func() {
+			cancel()
+			p.mu.Lock()
+			p.cancel, p.done = nil, nil
+			p.mu.Unlock()
+			close(done)
+			fyne.Do(func() {
+				p.runBtn.Enable()
+				p.stopBtn.Disable()
+				p.workloads.Enable()
+				p.progress.Hide()
+				p.Refresh()
+			})
+		}()
+
+		database, err := openResults(p.dbPath)
+		if err != nil {
+			fyne.Do(func() { p.status.SetText(fmt.Sprintf("Database error: %v", err)) })
+			return
+		}
+		defer func() { _ = database.Close() }()
+
+		finished := 0
+		runner := benchmark.NewRunner(database, log.New(io.Discard, "", 0))
+		runner.SetShare(share)
+		runner.SetProgress(func(w benchmark.Workload, done bool, _ map[string]float64, err error) {
+			if done {
+				finished++
+			}
+			text := fmt.Sprintf("Running %s (%s)...", w.Title, w.Duration)
+			if err != nil {
+				text = fmt.Sprintf("%s: %v", w.Title, err)
+				logger.Warn("Benchmark workload failed", "workload", w.Title, "error", err)
+			}
+			value := float64(finished) / float64(len(workloads))
+			fyne.Do(func() {
+				p.status.SetText(text)
+				p.progress.SetValue(value)
+			})
+		})
+		suite, err := runner.Run(ctx, workloads)
+		text := "Benchmark complete"
+		switch {
+		case err != nil:
+			text = fmt.Sprintf("Benchmark failed: %v", err)
+		case suite != nil && len(suite.Skipped) > 0:
+			for _, w := range workloads {
+				if reason, ok := suite.Skipped[w.Name]; ok {
+					text += fmt.Sprintf("\n%s skipped: %s", w.Title, reason)
+				}
+			}
+		}
+		fyne.Do(func() { p.status.SetText(text) })
+	}()
+}
+
+// abort stops the running suite; it is recorded as failed
+func (p *BenchmarksPage) abort() {
+	p.mu.Lock()
+	cancel := p.cancel
+	p.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		p.status.SetText("Stopping...")
+		p.stopBtn.Disable()
+	}
+}
+
+// Stop stops the running suite and waits up to timeout for it to be
+// recorded, e.g. before the window closes
+func (p *BenchmarksPage) Stop(timeout time.Duration) {
+	p.mu.Lock()
+	cancel, done := p.cancel, p.done
+	p.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		logger.Warn("Benchmark still running; its run may be left unfinished", "after", timeout)
+	}
+}
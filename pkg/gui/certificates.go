@@ -111,7 +111,7 @@ func (c *Certificates) SetWindow(w fyne.Window) {
 
 // loadRuns loads successful runs
 func (c *Certificates) loadRuns() {
-	database, err := db.Open(c.dbPath)
+	database, err := openResults(c.dbPath)
 	if err != nil {
 		c.statusLabel.SetText("Error: Failed to open database")
 		return
@@ -179,7 +179,7 @@ func (c *Certificates) issueCertificate() {
 		}
 
 		// Load results
-		database, err := db.Open(c.dbPath)
+		database, err := openResults(c.dbPath)
 		if err != nil {
 			c.statusLabel.SetText("Error: Failed to open database")
 			return
@@ -0,0 +1,315 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/mscrnt/project_fire/pkg/changelog"
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+// changeLogWindow is how long before and after each change the trend is compared
+const changeLogWindow = 14 * 24 * time.Hour
+
+// recordVersions notes firmware and driver updates since the last session and
+// announces them
+func (g *FireGUI) recordVersions() {
+	database, err := openResults(g.dbPath)
+	if err != nil {
+		logger.Warn("Versions not recorded", "error", err)
+		return
+	}
+	defer func() { _ = database.Close() }()
+
+	changes, err := changelog.Record(context.Background(), database)
+	if err != nil {
+		logger.Warn("Versions not recorded", "error", err)
+		return
+	}
+	for _, c := range changes {
+		if !c.Baseline() {
+			notifyDeviceChange("Version Change Detected", changelog.Describe(c))
+		}
+	}
+}
+
+// ShowChangeLog opens a window with this machine's firmware, driver and OS
+// version changes on a timeline against the trend of a selected metric
+func ShowChangeLog(app fyne.App, dbPath string) fyne.Window {
+	window := app.NewWindow("F.I.R.E. - Version Change Log")
+	window.Resize(fyne.NewSize(1000, 700))
+
+	database, err := openResults(dbPath)
+	if err != nil {
+		window.SetContent(container.NewCenter(widget.NewLabel(fmt.Sprintf("Failed to open database: %v", err))))
+		window.Show()
+		return window
+	}
+
+	changes, err := database.ListVersionChanges(changelog.Machine())
+	if err != nil {
+		logger.Warn("Failed to list version changes", "error", err)
+	}
+	metrics, err := database.MetricNames()
+	if err != nil {
+		logger.Warn("Failed to list metrics", "error", err)
+	}
+
+	timeline := newVersionTimeline(changes)
+	impactLabel := widget.NewLabel("Select a metric to compare before and after each change")
+	impactLabel.Wrapping = fyne.TextWrapWord
+
+	metricSelect := widget.NewSelect(metrics, func(metric string) {
+		buckets, err := database.MetricSeries(db.SeriesFilter{Metric: metric, Width: 24 * time.Hour})
+		if err != nil {
+			logger.Warn(err.Error())
+		}
+		timeline.SetSeries(buckets)
+		impactLabel.SetText(describeImpacts(changelog.Impacts(changes, buckets, changeLogWindow)))
+	})
+	metricSelect.PlaceHolder = "Select a metric"
+
+	list := widget.NewList(
+		func() int { return len(changes) },
+		func() fyne.CanvasObject {
+			date := widget.NewLabel("")
+			date.Importance = widget.LowImportance
+			return container.NewBorder(nil, nil, date, nil, widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			// Newest first
+			c := changes[len(changes)-1-id]
+			row := obj.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(changelog.Describe(c))
+			row.Objects[1].(*widget.Label).SetText(c.DetectedAt.Local().Format("2006-01-02 15:04"))
+		},
+	)
+
+	window.SetOnClosed(func() { _ = database.Close() })
+
+	toolbar := container.NewBorder(nil, nil, widget.NewLabel("Metric:"), nil, metricSelect)
+	top := container.NewBorder(toolbar, impactLabel, nil, nil, timeline)
+	split := container.NewVSplit(top, list)
+	split.Offset = 0.6
+	window.SetContent(split)
+	window.Show()
+	return window
+}
+
+// describeImpacts summarizes the before and after averages of each change
+func describeImpacts(impacts []changelog.Impact) string {
+	if len(impacts) == 0 {
+		return "No version changes since the first recorded versions"
+	}
+	text := ""
+	for i, impact := range impacts {
+		if i > 0 {
+			text += "\n"
+		}
+		text += fmt.Sprintf("%s  %s: ", impact.Change.DetectedAt.Local().Format("2006-01-02"), changelog.Describe(impact.Change))
+		if pct, ok := impact.ChangePct(); ok {
+			text += fmt.Sprintf("%.2f -> %.2f (%+.1f%%)", impact.Before.Avg, impact.After.Avg, pct)
+		} else {
+			text += "not enough results to compare"
+		}
+	}
+	return text
+}
+
+// versionTimeline draws a metric's daily averages with a marker at each
+// version change
+type versionTimeline struct {
+	widget.BaseWidget
+	changes []*db.VersionChange
+	buckets []db.Bucket
+}
+
+func newVersionTimeline(changes []*db.VersionChange) *versionTimeline {
+	t := &versionTimeline{changes: changes}
+	t.ExtendBaseWidget(t)
+	return t
+}
+
+// SetSeries replaces the plotted metric
+func (t *versionTimeline) SetSeries(buckets []db.Bucket) {
+	t.buckets = buckets
+	t.Refresh()
+}
+
+// CreateRenderer creates the timeline renderer
+func (t *versionTimeline) CreateRenderer() fyne.WidgetRenderer {
+	r := &versionTimelineRenderer{timeline: t}
+	r.Refresh()
+	return r
+}
+
+// versionTimelineRenderer rebuilds its objects on refresh and positions them
+// for the current size in Layout
+type versionTimelineRenderer struct {
+	timeline *versionTimeline
+	size     fyne.Size
+
+	background *canvas.Rectangle
+	axis       axisRange
+	gridLines  []*canvas.Line
+	tickLabels []*canvas.Text
+	segments   []*canvas.Line
+	markers    []*canvas.Line
+	labels     []*canvas.Text
+	emptyText  *canvas.Text
+}
+
+func (r *versionTimelineRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(400, 200)
+}
+
+func (r *versionTimelineRenderer) Refresh() {
+	r.background = canvas.NewRectangle(CardBackgroundColor())
+
+	values := make([]float64, len(r.timeline.buckets))
+	for i, b := range r.timeline.buckets {
+		values[i] = b.Avg
+	}
+	r.axis = autoAxis(values, "", defaultAxisTicks)
+	r.gridLines, r.tickLabels = nil, nil
+	if len(values) > 0 {
+		for _, tick := range r.axis.Ticks() {
+			r.gridLines = append(r.gridLines, canvas.NewLine(ChartGridColor()))
+			label := canvas.NewText(r.axis.Format(tick, ""), theme.Color(theme.ColorNameDisabled))
+			label.TextSize = 8
+			r.tickLabels = append(r.tickLabels, label)
+		}
+	}
+
+	r.segments = nil
+	for i := 1; i < len(r.timeline.buckets); i++ {
+		line := canvas.NewLine(ChartLineColor())
+		line.StrokeWidth = 2
+		r.segments = append(r.segments, line)
+	}
+
+	r.markers, r.labels = nil, nil
+	for _, c := range r.timeline.changes {
+		if c.Baseline() {
+			continue
+		}
+		marker := canvas.NewLine(theme.Color(theme.ColorNameWarning))
+		marker.StrokeWidth = 1
+		r.markers = append(r.markers, marker)
+		label := canvas.NewText(changelog.ComponentLabel(c.Component), theme.Color(theme.ColorNameWarning))
+		label.TextSize = 10
+		r.labels = append(r.labels, label)
+	}
+
+	r.emptyText = nil
+	if len(r.timeline.buckets) == 0 && len(r.markers) == 0 {
+		r.emptyText = canvas.NewText("No version changes or results to show", theme.Color(theme.ColorNameDisabled))
+	}
+	r.Layout(r.size)
+	canvas.Refresh(r.timeline)
+}
+
+func (r *versionTimelineRenderer) Layout(size fyne.Size) {
+	r.size = size
+	r.background.Resize(size)
+	if r.emptyText != nil {
+		textSize := r.emptyText.MinSize()
+		r.emptyText.Move(fyne.NewPos((size.Width-textSize.Width)/2, (size.Height-textSize.Height)/2))
+		return
+	}
+
+	padding := float32(10)
+	labelWidth := float32(0)
+	for _, label := range r.tickLabels {
+		labelWidth = fyne.Max(labelWidth, label.MinSize().Width)
+	}
+	left := padding + labelWidth
+	top := padding + 14 // Room for marker labels
+	width := size.Width - left - padding
+	height := size.Height - top - padding
+	start, end := r.timeRange()
+	span := end.Sub(start).Seconds()
+	xAt := func(t time.Time) float32 {
+		if span <= 0 {
+			return left + width/2
+		}
+		return left + width*float32(t.Sub(start).Seconds()/span)
+	}
+	yAt := func(v float64) float32 {
+		return top + height*float32(1-r.axis.Position(v))
+	}
+
+	for i, tick := range r.axis.Ticks() {
+		if i >= len(r.gridLines) {
+			break
+		}
+		y := yAt(tick)
+		r.gridLines[i].Position1 = fyne.NewPos(left, y)
+		r.gridLines[i].Position2 = fyne.NewPos(left+width, y)
+		r.tickLabels[i].Move(fyne.NewPos(padding-2, y-6))
+	}
+
+	for i, line := range r.segments {
+		from, to := r.timeline.buckets[i], r.timeline.buckets[i+1]
+		line.Position1 = fyne.NewPos(xAt(from.Start), yAt(from.Avg))
+		line.Position2 = fyne.NewPos(xAt(to.Start), yAt(to.Avg))
+	}
+
+	i := 0
+	for _, c := range r.timeline.changes {
+		if c.Baseline() {
+			continue
+		}
+		x := xAt(c.DetectedAt)
+		r.markers[i].Position1 = fyne.NewPos(x, top)
+		r.markers[i].Position2 = fyne.NewPos(x, top+height)
+		r.labels[i].Move(fyne.NewPos(x+2, padding-2))
+		i++
+	}
+}
+
+// timeRange spans the plotted buckets and the version changes
+func (r *versionTimelineRenderer) timeRange() (start, end time.Time) {
+	extend := func(t time.Time) {
+		if start.IsZero() || t.Before(start) {
+			start = t
+		}
+		if t.After(end) {
+			end = t
+		}
+	}
+	for _, b := range r.timeline.buckets {
+		extend(b.Start)
+	}
+	for _, c := range r.timeline.changes {
+		if !c.Baseline() {
+			extend(c.DetectedAt)
+		}
+	}
+	return start, end
+}
+
+func (r *versionTimelineRenderer) Objects() []fyne.CanvasObject {
+	objects := []fyne.CanvasObject{r.background}
+	if r.emptyText != nil {
+		return append(objects, r.emptyText)
+	}
+	for i := range r.gridLines {
+		objects = append(objects, r.gridLines[i], r.tickLabels[i])
+	}
+	for _, line := range r.segments {
+		objects = append(objects, line)
+	}
+	for i := range r.markers {
+		objects = append(objects, r.markers[i], r.labels[i])
+	}
+	return objects
+}
+
+func (r *versionTimelineRenderer) Destroy() {}
@@ -0,0 +1,156 @@
+package gui
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// defaultAxisTicks is about how many ticks a chart's y-axis shows
+const defaultAxisTicks = 5
+
+// axisRange is the span of a chart's y-axis and the spacing of its ticks
+type axisRange struct {
+	Min, Max, Step float64
+}
+
+// unitScale names the larger unit a value switches to at factor, e.g. MHz
+// to GHz at 1000
+type unitScale struct {
+	factor float64
+	next   string
+}
+
+// unitScales lists the units that are shown in a larger unit when the axis
+// reaches it
+var unitScales = map[string]unitScale{
+	"MHz":  {1000, "GHz"},
+	"KB/s": {1000, "MB/s"},
+	"MB/s": {1000, "GB/s"},
+	"W":    {1000, "kW"},
+	"MB":   {1024, "GB"},
+	"GB":   {1024, "TB"},
+}
+
+// Ticks returns the tick values from the first multiple of Step at or above
+// Min up to Max
+func (a axisRange) Ticks() []float64 {
+	if a.Step <= 0 || a.Max <= a.Min {
+		return []float64{a.Min}
+	}
+	var ticks []float64
+	for v := math.Ceil(a.Min/a.Step-1e-9) * a.Step; v <= a.Max+a.Step*1e-9; v += a.Step {
+		// Avoid -0 and accumulated rounding in the labels
+		ticks = append(ticks, math.Round(v/a.Step)*a.Step+0)
+	}
+	return ticks
+}
+
+// Position returns where v falls on the axis, 0 at Min and 1 at Max,
+// clamped to that range
+func (a axisRange) Position(v float64) float64 {
+	if a.Max <= a.Min {
+		return 0.5
+	}
+	return math.Max(0, math.Min(1, (v-a.Min)/(a.Max-a.Min)))
+}
+
+// Format formats a value on the axis with its unit. Units are scaled up
+// together for the whole axis, so ticks read "3.5 GHz, 4 GHz" rather than
+// mixing MHz and GHz, and decimals follow the tick spacing.
+func (a axisRange) Format(v float64, unit string) string {
+	divisor := 1.0
+	for {
+		scale, ok := unitScales[unit]
+		if !ok || math.Max(math.Abs(a.Min), math.Abs(a.Max))/divisor < scale.factor {
+			break
+		}
+		divisor *= scale.factor
+		unit = scale.next
+	}
+
+	decimals := 0
+	if step := a.Step / divisor; step > 0 && step < 1 {
+		decimals = int(math.Ceil(-math.Log10(step) - 1e-9))
+	}
+	text := strconv.FormatFloat(v/divisor, 'f', decimals, 64)
+	if decimals > 0 {
+		text = strings.TrimRight(strings.TrimRight(text, "0"), ".")
+	}
+
+	switch unit {
+	case "":
+		return text
+	case "%", "°C", "°F":
+		return text + unit
+	default:
+		return text + " " + unit
+	}
+}
+
+// niceStep returns a tick spacing of 1, 2, 2.5 or 5 times a power of ten
+// that splits span into at most about ticks steps
+func niceStep(span float64, ticks int) float64 {
+	if span <= 0 || ticks < 1 {
+		return 1
+	}
+	raw := span / float64(ticks)
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	for _, m := range []float64{1, 2, 2.5, 5, 10} {
+		if m*magnitude >= raw {
+			return m * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+// fixedAxis keeps a pinned range exactly and only picks the tick spacing
+func fixedAxis(minVal, maxVal float64, ticks int) axisRange {
+	return axisRange{Min: minVal, Max: maxVal, Step: niceStep(maxVal-minVal, ticks)}
+}
+
+// autoAxis fits an axis to values, rounded out to whole ticks. Values that
+// never go negative keep the axis at or above zero, and percentages stay
+// within 0-100. NaN values, gaps in a series, are left out.
+func autoAxis(values []float64, unit string, ticks int) axisRange {
+	var finite []float64
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			finite = append(finite, v)
+		}
+	}
+	values = finite
+	if len(values) == 0 {
+		if unit == "%" {
+			return fixedAxis(0, 100, ticks)
+		}
+		return fixedAxis(0, 1, ticks)
+	}
+
+	lo, hi := values[0], values[0]
+	for _, v := range values[1:] {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+	nonNegative := lo >= 0
+
+	// A flat line is centered in a band around its value
+	if hi == lo {
+		pad := math.Max(math.Abs(lo)*0.1, 1)
+		lo, hi = lo-pad, hi+pad
+	} else {
+		pad := (hi - lo) * 0.05
+		lo, hi = lo-pad, hi+pad
+	}
+	if nonNegative && lo < 0 {
+		lo = 0
+	}
+
+	step := niceStep(hi-lo, ticks)
+	a := axisRange{Min: math.Floor(lo/step) * step, Max: math.Ceil(hi/step) * step, Step: step}
+	if unit == "%" {
+		a.Min = math.Max(a.Min, 0)
+		a.Max = math.Min(a.Max, 100)
+	}
+	return a
+}
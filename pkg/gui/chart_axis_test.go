@@ -0,0 +1,102 @@
+package gui
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestNiceStep(t *testing.T) {
+	tests := []struct {
+		span  float64
+		ticks int
+		want  float64
+	}{
+		{100, 5, 20},
+		{95, 4, 25},
+		{1.3, 5, 0.5},
+		{3200, 5, 1000},
+		{0, 5, 1},
+	}
+	for _, tt := range tests {
+		if got := niceStep(tt.span, tt.ticks); got != tt.want {
+			t.Errorf("niceStep(%v, %d): expected %v, got %v", tt.span, tt.ticks, tt.want, got)
+		}
+	}
+}
+
+func TestAutoAxis(t *testing.T) {
+	// Clocks between 3.6 and 5.1 GHz don't start at zero
+	a := autoAxis([]float64{3600, 4800, 5100}, "MHz", 5)
+	if a.Min != 3500 || a.Max != 5500 || a.Step != 500 {
+		t.Errorf("expected 3500-5500 in steps of 500, got %+v", a)
+	}
+	if got := a.Ticks(); !reflect.DeepEqual(got, []float64{3500, 4000, 4500, 5000, 5500}) {
+		t.Errorf("unexpected ticks %v", got)
+	}
+
+	// Gaps in a series don't count
+	a = autoAxis([]float64{math.NaN(), 3600, math.NaN(), 5100}, "MHz", 5)
+	if a.Min != 3500 || a.Max != 5500 {
+		t.Errorf("expected gaps to be left out, got %+v", a)
+	}
+
+	// Percentages stay within 0-100
+	a = autoAxis([]float64{2, 99}, "%", 5)
+	if a.Min != 0 || a.Max != 100 {
+		t.Errorf("expected 0-100, got %+v", a)
+	}
+
+	// A flat line gets a band around it
+	a = autoAxis([]float64{65, 65}, "°C", 5)
+	if a.Min >= 65 || a.Max <= 65 {
+		t.Errorf("expected a range around 65, got %+v", a)
+	}
+
+	// Non-negative values never put the axis below zero
+	a = autoAxis([]float64{0, 3}, "W", 5)
+	if a.Min != 0 {
+		t.Errorf("expected the axis to start at zero, got %+v", a)
+	}
+
+	// Negative values are kept
+	a = autoAxis([]float64{-12, 8}, "", 5)
+	if a.Min > -12 || a.Max < 8 {
+		t.Errorf("expected the range to cover -12 to 8, got %+v", a)
+	}
+
+	if a := autoAxis(nil, "%", 5); a.Min != 0 || a.Max != 100 {
+		t.Errorf("expected 0-100 for an empty percentage chart, got %+v", a)
+	}
+}
+
+func TestAxisFormat(t *testing.T) {
+	tests := []struct {
+		axis  axisRange
+		value float64
+		unit  string
+		want  string
+	}{
+		{axisRange{Min: 3500, Max: 5500, Step: 500}, 4500, "MHz", "4.5 GHz"},
+		{axisRange{Min: 3500, Max: 5500, Step: 500}, 4000, "MHz", "4 GHz"},
+		{axisRange{Min: 0, Max: 800, Step: 200}, 400, "MHz", "400 MHz"},
+		{axisRange{Min: 0, Max: 7000, Step: 1000}, 3000, "MB/s", "3 GB/s"},
+		{axisRange{Min: 0, Max: 300, Step: 50}, 250, "W", "250 W"},
+		{axisRange{Min: 40, Max: 90, Step: 10}, 70, "°C", "70°C"},
+		{axisRange{Min: 0, Max: 100, Step: 25}, 75, "%", "75%"},
+		{axisRange{Min: 1.1, Max: 1.4, Step: 0.05}, 1.25, "V", "1.25 V"},
+		{axisRange{Min: 0, Max: 2, Step: 0.5}, 1, "", "1"},
+	}
+	for _, tt := range tests {
+		if got := tt.axis.Format(tt.value, tt.unit); got != tt.want {
+			t.Errorf("Format(%v, %q): expected %q, got %q", tt.value, tt.unit, tt.want, got)
+		}
+	}
+}
+
+func TestAxisPosition(t *testing.T) {
+	a := axisRange{Min: 40, Max: 90, Step: 10}
+	if a.Position(65) != 0.5 || a.Position(20) != 0 || a.Position(120) != 1 {
+		t.Error("expected positions within 0-1")
+	}
+}
@@ -0,0 +1,213 @@
+package gui
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// PaletteCommand is an action that can be run from the command palette
+type PaletteCommand struct {
+	Title    string // e.g. "Go to Stability Test"
+	Category string // Shown next to the title, e.g. "Navigation"
+	Run      func()
+}
+
+// CommandPalette is a Ctrl+K popup that runs commands found by fuzzy search
+type CommandPalette struct {
+	canvas   fyne.Canvas
+	commands func() []PaletteCommand // Rebuilt on every open so the list follows the UI state
+
+	popup    *widget.PopUp
+	entry    *paletteEntry
+	list     *widget.List
+	all      []PaletteCommand
+	matches  []PaletteCommand
+	selected int
+	moving   bool // Selection is being moved by the keyboard, not a click
+}
+
+// NewCommandPalette creates a command palette for the window's canvas
+func NewCommandPalette(canvas fyne.Canvas, commands func() []PaletteCommand) *CommandPalette {
+	p := &CommandPalette{canvas: canvas, commands: commands}
+
+	p.entry = newPaletteEntry(p.handleKey)
+	p.entry.SetPlaceHolder("Type a command...")
+	p.entry.OnChanged = p.filter
+	p.entry.OnSubmitted = func(string) { p.run(p.selected) }
+
+	p.list = widget.NewList(
+		func() int { return len(p.matches) },
+		func() fyne.CanvasObject {
+			category := widget.NewLabel("")
+			category.Importance = widget.LowImportance
+			return container.NewBorder(nil, nil, nil, category, widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			row := obj.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(p.matches[id].Title)
+			row.Objects[1].(*widget.Label).SetText(p.matches[id].Category)
+		},
+	)
+	p.list.OnSelected = func(id widget.ListItemID) {
+		p.selected = id
+		if !p.moving {
+			p.run(id)
+		}
+	}
+
+	content := container.NewBorder(p.entry, nil, nil, nil, p.list)
+	p.popup = widget.NewModalPopUp(content, canvas)
+	return p
+}
+
+// Show opens the palette with an empty query
+func (p *CommandPalette) Show() {
+	p.all = p.commands()
+	p.entry.SetText("")
+	p.filter("")
+
+	size := p.canvas.Size()
+	p.popup.Resize(fyne.NewSize(fyne.Min(640, size.Width*0.8), fyne.Min(420, size.Height*0.7)))
+	p.popup.Show()
+	p.canvas.Focus(p.entry)
+}
+
+// Hide closes the palette
+func (p *CommandPalette) Hide() {
+	p.popup.Hide()
+}
+
+// filter updates the list for the query and selects the best match
+func (p *CommandPalette) filter(query string) {
+	p.matches = filterCommands(p.all, query)
+	p.list.UnselectAll()
+	p.list.Refresh()
+	p.selected = 0
+	if len(p.matches) > 0 {
+		p.moveTo(0)
+		p.list.ScrollToTop()
+	}
+}
+
+// moveTo highlights the match at index without running it
+func (p *CommandPalette) moveTo(index int) {
+	p.moving = true
+	p.list.Select(index)
+	p.moving = false
+}
+
+// run closes the palette and runs the match at index
+func (p *CommandPalette) run(index int) {
+	if index < 0 || index >= len(p.matches) {
+		return
+	}
+	command := p.matches[index]
+	p.Hide()
+	if command.Run != nil {
+		command.Run()
+	}
+}
+
+// handleKey moves the selection with the arrow keys and closes on Escape.
+// It returns true when the key was handled.
+func (p *CommandPalette) handleKey(key *fyne.KeyEvent) bool {
+	switch key.Name {
+	case fyne.KeyEscape:
+		p.Hide()
+	case fyne.KeyDown:
+		if p.selected < len(p.matches)-1 {
+			p.moveTo(p.selected + 1)
+		}
+	case fyne.KeyUp:
+		if p.selected > 0 {
+			p.moveTo(p.selected - 1)
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// paletteEntry is an entry that lets the palette handle navigation keys
+type paletteEntry struct {
+	widget.Entry
+	onKey func(*fyne.KeyEvent) bool
+}
+
+func newPaletteEntry(onKey func(*fyne.KeyEvent) bool) *paletteEntry {
+	e := &paletteEntry{onKey: onKey}
+	e.ExtendBaseWidget(e)
+	return e
+}
+
+// TypedKey passes navigation keys to the palette before the entry sees them
+func (e *paletteEntry) TypedKey(key *fyne.KeyEvent) {
+	if e.onKey(key) {
+		return
+	}
+	e.Entry.TypedKey(key)
+}
+
+// filterCommands returns the commands matching query, best match first.
+// An empty query returns all commands in their original order.
+func filterCommands(commands []PaletteCommand, query string) []PaletteCommand {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return commands
+	}
+
+	type scored struct {
+		command PaletteCommand
+		score   int
+	}
+	var matches []scored
+	for _, c := range commands {
+		if score, ok := fuzzyScore(query, c.Category+" "+c.Title); ok {
+			matches = append(matches, scored{c, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	result := make([]PaletteCommand, len(matches))
+	for i, m := range matches {
+		result[i] = m.command
+	}
+	return result
+}
+
+// fuzzyScore matches the query characters in order against text, ignoring
+// case and spaces in the query. Matches at word starts and runs of
+// consecutive characters score higher.
+func fuzzyScore(query, text string) (int, bool) {
+	q := []rune(strings.ToLower(strings.ReplaceAll(query, " ", "")))
+	t := []rune(text)
+
+	score, qi := 0, 0
+	prevMatch := -2
+	for ti := 0; ti < len(t) && qi < len(q); ti++ {
+		if unicode.ToLower(t[ti]) != q[qi] {
+			continue
+		}
+		score++
+		if ti == prevMatch+1 {
+			score += 3
+		}
+		if ti == 0 || !unicode.IsLetter(t[ti-1]) && !unicode.IsDigit(t[ti-1]) {
+			score += 5
+		}
+		prevMatch = ti
+		qi++
+	}
+	if qi < len(q) {
+		return 0, false
+	}
+	// Prefer shorter titles among otherwise equal matches
+	return score*100 - len(t), true
+}
@@ -0,0 +1,45 @@
+package gui
+
+import "testing"
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		query string
+		text  string
+		match bool
+	}{
+		{"sys", "Navigation Go to System Info", true},
+		{"gtst", "Navigation Go to Stability Test", true},
+		{"SAVE", "File Save Session...", true},
+		{"save session", "File Save Session...", true},
+		{"xyz", "File Save Session...", false},
+		{"tset", "Test", false},
+	}
+	for _, tt := range tests {
+		if _, ok := fuzzyScore(tt.query, tt.text); ok != tt.match {
+			t.Errorf("fuzzyScore(%q, %q) matched = %v, expected %v", tt.query, tt.text, ok, tt.match)
+		}
+	}
+}
+
+func TestFilterCommandsRanksWordStarts(t *testing.T) {
+	commands := []PaletteCommand{
+		{Title: "Go to Monitoring", Category: "Navigation"},
+		{Title: "Start Memory Test", Category: "Test"},
+		{Title: "Save Session...", Category: "File"},
+	}
+
+	if got := filterCommands(commands, ""); len(got) != len(commands) {
+		t.Fatalf("Expected all commands for an empty query, got %d", len(got))
+	}
+
+	got := filterCommands(commands, "mem")
+	if len(got) == 0 || got[0].Title != "Start Memory Test" {
+		t.Errorf("Expected the memory test first, got %+v", got)
+	}
+
+	got = filterCommands(commands, "save")
+	if len(got) != 1 || got[0].Title != "Save Session..." {
+		t.Errorf("Expected only the save command, got %+v", got)
+	}
+}
@@ -0,0 +1,149 @@
+package gui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"github.com/mscrnt/project_fire/pkg/snippet"
+)
+
+// setupCommandPalette creates the command palette and binds it to Ctrl+K
+// (Cmd+K on macOS)
+func (g *FireGUI) setupCommandPalette() {
+	g.palette = NewCommandPalette(g.window.Canvas(), g.paletteCommands)
+	g.window.Canvas().AddShortcut(&desktop.CustomShortcut{
+		KeyName:  fyne.KeyK,
+		Modifier: fyne.KeyModifierShortcutDefault,
+	}, func(fyne.Shortcut) {
+		g.palette.Show()
+	})
+}
+
+// paletteCommands lists the actions available in the command palette
+func (g *FireGUI) paletteCommands() []PaletteCommand {
+	var commands []PaletteCommand
+
+	// Navigation, in sidebar order
+	for i, page := range pageNames {
+		index := i
+		commands = append(commands, PaletteCommand{
+			Title:    "Go to " + page,
+			Category: "Navigation",
+			Run:      func() { g.navigation.ShowPage(index) },
+		})
+	}
+
+	// Test presets, the active profile's favorites first
+	current := g.profiles.Current()
+	var others []PaletteCommand
+	for _, test := range g.testsPage.Tests() {
+		command := PaletteCommand{Title: "Start " + test.Name, Category: "Test", Run: test.OnStart}
+		if current.IsFavorite(test.Name) {
+			command.Category = "Favorite"
+			commands = append(commands, command)
+		} else {
+			others = append(others, command)
+		}
+	}
+	commands = append(commands, others...)
+
+	commands = append(commands, PaletteCommand{
+		Title:    "New Schedule...",
+		Category: "Test",
+		Run: func() {
+			g.navigation.ShowPage(2)
+			g.schedules.NewSchedule()
+		},
+	})
+	commands = append(commands, PaletteCommand{
+		Title:    "New Alert Rule...",
+		Category: "Settings",
+		Run: func() {
+			g.showAlertSettings()
+			g.alerts.NewRule()
+		},
+	})
+
+	// Files
+	commands = append(commands,
+		PaletteCommand{Title: "Save Session...", Category: "File", Run: g.saveSession},
+		PaletteCommand{Title: "Open Report or Session...", Category: "File", Run: g.openSessionFile},
+		PaletteCommand{Title: "Browse Run Artifacts", Category: "File", Run: func() { ShowArtifactBrowser(g.app, g.dbPath) }},
+		PaletteCommand{Title: "Show Version Change Log", Category: "View", Run: func() { ShowChangeLog(g.app, g.dbPath) }},
+		PaletteCommand{Title: "Show Event Timeline", Category: "View", Run: func() { ShowEventTimeline(g.app, g.dbPath, g.dashboard.recorder) }},
+		PaletteCommand{Title: "Show Sensor History", Category: "View", Run: func() { ShowSensorHistory(g.app, g.dbPath) }},
+		PaletteCommand{Title: "Show Processes", Category: "View", Run: func() { ShowProcesses(g.app) }},
+		PaletteCommand{Title: "Show Logs", Category: "View", Run: func() { ShowLogViewer(g.app) }},
+		PaletteCommand{Title: "Show Network Interfaces", Category: "View", Run: func() { g.dashboard.ShowNetwork() }},
+		PaletteCommand{Title: "Copy Summary as Markdown", Category: "Edit", Run: func() { g.copySnippet(snippet.Markdown) }},
+		PaletteCommand{Title: "Copy Summary as BBCode", Category: "Edit", Run: func() { g.copySnippet(snippet.BBCode) }},
+	)
+
+	// Component details
+	for _, comp := range g.dashboard.Components() {
+		comp := comp
+		commands = append(commands, PaletteCommand{
+			Title:    fmt.Sprintf("Show %s Details: %s", comp.Type, comp.Name),
+			Category: "Component",
+			Run:      func() { g.dashboard.ShowComponentDetails(&comp) },
+		})
+	}
+
+	// Operator profiles
+	for _, name := range g.profiles.Names() {
+		if name == current.Name {
+			continue
+		}
+		name := name
+		commands = append(commands, PaletteCommand{
+			Title:    "Switch to Profile: " + name,
+			Category: "Profile",
+			Run:      func() { g.switchProfile(name) },
+		})
+	}
+	commands = append(commands, PaletteCommand{
+		Title:    "Edit Profiles...",
+		Category: "Profile",
+		Run:      g.showProfileEditor,
+	})
+
+	// Settings
+	commands = append(commands, PaletteCommand{
+		Title:    "Toggle Sidebar",
+		Category: "Settings",
+		Run:      g.navigation.ToggleCollapse,
+	})
+	recording := "Pause Session Recording"
+	if g.dashboard.recorder.Paused() {
+		recording = "Resume Session Recording"
+	}
+	commands = append(commands, PaletteCommand{
+		Title:    recording,
+		Category: "Settings",
+		Run: func() {
+			g.dashboard.recorder.SetPaused(!g.dashboard.recorder.Paused())
+			g.updateSleepInhibitor()
+		},
+	})
+	monitoring := "Pause Monitoring"
+	if g.dashboard.Paused() {
+		monitoring = "Resume Monitoring"
+	}
+	commands = append(commands, PaletteCommand{
+		Title:    monitoring,
+		Category: "Settings",
+		Run:      func() { g.dashboard.SetPaused(!g.dashboard.Paused()) },
+	})
+	keepAwake := "Keep Awake While Recording"
+	if g.keepAwake {
+		keepAwake = "Allow Sleep While Recording"
+	}
+	commands = append(commands, PaletteCommand{
+		Title:    keepAwake,
+		Category: "Settings",
+		Run:      func() { g.setKeepAwake(!g.keepAwake) },
+	})
+
+	return commands
+}
@@ -95,7 +95,7 @@ func (c *Compare) Refresh() {
 
 // loadRuns loads available runs
 func (c *Compare) loadRuns() {
-	database, err := db.Open(c.dbPath)
+	database, err := openResults(c.dbPath)
 	if err != nil {
 		return
 	}
@@ -142,7 +142,7 @@ func (c *Compare) compareRuns() {
 	run2 := c.runs[idx2]
 
 	// Load results
-	database, err := db.Open(c.dbPath)
+	database, err := openResults(c.dbPath)
 	if err != nil {
 		c.resultLabel.SetText("Error: Failed to open database")
 		return
@@ -0,0 +1,166 @@
+package gui
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/mscrnt/project_fire/pkg/cpucores"
+)
+
+// heatmapStops are the colors of the utilization heatmap from idle to fully
+// loaded
+var heatmapStops = []struct {
+	pct float64
+	c   color.RGBA
+}{
+	{0, color.RGBA{0x1b, 0x2a, 0x3a, 0xff}},  // Idle, near the card background
+	{40, color.RGBA{0x00, 0x7a, 0xcc, 0xff}}, // Blue
+	{75, color.RGBA{0xff, 0xcc, 0x00, 0xff}}, // Yellow
+	{100, color.RGBA{0xff, 0x33, 0x00, 0xff}},
+}
+
+// heatColor returns the heatmap color of a utilization in percent
+func heatColor(pct float64) color.RGBA {
+	pct = math.Max(0, math.Min(100, pct))
+	for i := 1; i < len(heatmapStops); i++ {
+		lo, hi := heatmapStops[i-1], heatmapStops[i]
+		if pct > hi.pct {
+			continue
+		}
+		f := (pct - lo.pct) / (hi.pct - lo.pct)
+		mix := func(a, b uint8) uint8 { return uint8(math.Round(float64(a) + (float64(b)-float64(a))*f)) }
+		return color.RGBA{mix(lo.c.R, hi.c.R), mix(lo.c.G, hi.c.G), mix(lo.c.B, hi.c.B), 0xff}
+	}
+	return heatmapStops[len(heatmapStops)-1].c
+}
+
+// coreCell is one logical CPU of the heatmap
+type coreCell struct {
+	bg    *canvas.Rectangle
+	label *canvas.Text
+	usage *canvas.Text
+	clock *canvas.Text
+	temp  *canvas.Text
+}
+
+// newCoreCell creates an empty cell
+func newCoreCell() *coreCell {
+	text := func(size float32, bold bool) *canvas.Text {
+		t := canvas.NewText("", color.White)
+		t.TextSize = size
+		t.TextStyle = fyne.TextStyle{Bold: bold}
+		t.Alignment = fyne.TextAlignCenter
+		return t
+	}
+	c := &coreCell{
+		bg:    canvas.NewRectangle(heatColor(0)),
+		label: text(11, false),
+		usage: text(18, true),
+		clock: text(11, false),
+		temp:  text(11, false),
+	}
+	c.bg.CornerRadius = 4
+	return c
+}
+
+// object returns the cell's canvas object
+func (c *coreCell) object() fyne.CanvasObject {
+	return container.NewStack(c.bg, container.NewPadded(container.NewVBox(c.label, c.usage, c.clock, c.temp)))
+}
+
+// set shows a CPU's readings
+func (c *coreCell) set(cpu cpucores.CPU) {
+	c.bg.FillColor = heatColor(cpu.Usage)
+	c.label.Text = cpu.Label()
+	c.usage.Text = fmt.Sprintf("%.0f%%", cpu.Usage)
+	c.clock.Text, c.temp.Text = " ", " "
+	if cpu.Clock > 0 {
+		c.clock.Text = formatMetricValue(cpu.Clock, "MHz")
+	}
+	if cpu.Temp > 0 {
+		c.temp.Text = formatMetricValue(cpu.Temp, "°C")
+	}
+	for _, o := range []fyne.CanvasObject{c.bg, c.label, c.usage, c.clock, c.temp} {
+		o.Refresh()
+	}
+}
+
+// ShowCPUCores shows the per-core panel: a heatmap of each logical CPU's
+// utilization with its clock and core temperature, and the CCD temperatures,
+// updating at the dashboard's interval until closed
+func (d *Dashboard) ShowCPUCores() {
+	topology := cpucores.ReadTopology()
+	cells := make([]*coreCell, len(topology))
+	grid := container.NewGridWithColumns(min(len(topology), 8))
+	for i := range cells {
+		cells[i] = newCoreCell()
+		grid.Add(cells[i].object())
+	}
+
+	ccds := widget.NewLabel("")
+	legend := widget.NewLabel("Color shows utilization, from idle (dark) to fully loaded (red). Temperatures are per physical core where the sensors report them.")
+	legend.Importance = widget.LowImportance
+	legend.Wrapping = fyne.TextWrapWord
+	for _, t := range topology {
+		if t.Kind != "" {
+			legend.SetText(legend.Text + " P marks performance cores and E efficiency cores.")
+			break
+		}
+	}
+
+	show := func(readings *cpucores.Readings) {
+		for i, cpu := range readings.CPUs {
+			cells[i].set(cpu)
+		}
+		var dies []string
+		for _, r := range readings.CCDs {
+			dies = append(dies, fmt.Sprintf("%s %s", r.Name, formatMetricValue(r.Value, "°C")))
+		}
+		if len(dies) > 0 {
+			ccds.SetText("Core complex dies: " + strings.Join(dies, ", "))
+		}
+	}
+
+	content := container.NewBorder(nil,
+		container.NewVBox(widget.NewSeparator(), ccds, legend),
+		nil, nil,
+		container.NewVScroll(grid),
+	)
+	dlg := dialog.NewCustom("CPU Cores", "Close", content, d.window)
+
+	// Sensors are read off the UI thread; WMI queries can take a while
+	d.mu.Lock()
+	interval := d.updateInterval
+	d.mu.Unlock()
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			cpuCache.mu.RLock()
+			usage := append([]float64(nil), cpuCache.perCore...)
+			cpuCache.mu.RUnlock()
+			readings := cpucores.Combine(topology, usage, readSensors())
+			fyne.Do(func() { show(readings) })
+
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	dlg.SetOnClosed(func() {
+		ticker.Stop()
+		close(done)
+	})
+	dlg.Resize(fyne.NewSize(900, 600))
+	dlg.Show()
+}
@@ -0,0 +1,18 @@
+package gui
+
+import "testing"
+
+func TestHeatColor(t *testing.T) {
+	if got := heatColor(-5); got != heatmapStops[0].c {
+		t.Errorf("expected idle below 0%%, got %v", got)
+	}
+	if got := heatColor(100); got != heatmapStops[len(heatmapStops)-1].c {
+		t.Errorf("expected the hottest color at 100%%, got %v", got)
+	}
+	if got := heatColor(40); got != heatmapStops[1].c {
+		t.Errorf("expected the stop's color at 40%%, got %v", got)
+	}
+	if got := heatColor(20); got.B <= heatmapStops[0].c.B || got.B >= heatmapStops[1].c.B {
+		t.Errorf("expected a blend between the first stops at 20%%, got %v", got)
+	}
+}
@@ -1,6 +1,7 @@
 package gui
 
 import (
+	"context"
 	"fmt"
 	"image/color"
 	"runtime"
@@ -16,6 +17,12 @@ import (
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+	"github.com/mscrnt/project_fire/pkg/config"
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/inventory"
+	"github.com/mscrnt/project_fire/pkg/monitor"
+	"github.com/mscrnt/project_fire/pkg/session"
+	"github.com/mscrnt/project_fire/pkg/virt"
 	"github.com/shirou/gopsutil/v3/disk"
 )
 
@@ -24,21 +31,30 @@ type Dashboard struct {
 	content      fyne.CanvasObject
 	summaryStrip fyne.CanvasObject // Separate summary strip
 	window       fyne.Window       // Reference to main window
+	openSettings func()            // Shows the Settings page
 
 	// System info
-	sysInfo *SystemInfo
+	sysInfo *inventory.SystemInfo
 
 	// Update control
 	running  bool
+	paused   bool // Readings are not taken while monitoring is paused
 	mu       sync.Mutex
 	stopChan chan bool
 
+	// Alerts firing and the last one raised, shown by the tray
+	alertsFiring int
+	lastAlert    string
+
 	// Summary cards
 	cpuSummary     *SummaryCard
 	memorySummary  *SummaryCard
 	gpuSummary     *SummaryCard
 	gpuSummaries   []*SummaryCard // For multiple GPUs
 	storageSummary *SummaryCard
+	networkSummary *SummaryCard
+	networkSelect  *widget.Select
+	networkDetails *widget.Button
 	currentGPU     int                // Currently displayed GPU
 	gpuTabs        *container.AppTabs // GPU tabs
 
@@ -49,16 +65,11 @@ type Dashboard struct {
 	welcomeContainer fyne.CanvasObject // Reference to welcome pane
 	components       []Component
 	selectedIndex    int
-	storageDevices   []StorageInfo // Keep storage devices for details dialog
+	storageDevices   []inventory.StorageInfo // Keep storage devices for details dialog
 
 	// Update tickers
-	updateTicker *time.Ticker
-
-	// Cached data
-	lastGPUInfo       []GPUInfo
-	lastGPUUpdate     time.Time
-	lastStorageInfo   []StorageInfo
-	lastStorageUpdate time.Time
+	updateTicker   *time.Ticker
+	updateInterval time.Duration // gui.update_interval of the settings
 
 	// Metric history tracking
 	cpuDieTempHistory *MetricHistory
@@ -66,13 +77,33 @@ type Dashboard struct {
 	cpuUsageHistory   *MetricHistory
 	cpuClockHistory   *MetricHistory
 
+	// Disk I/O, read with the other metrics
+	diskMu      sync.Mutex
+	diskMeter   monitor.DiskMeter
+	disks       []monitor.Disk
+	diskHistory map[string]*diskHistory
+
+	// Network interfaces, read with the other metrics
+	netMu       sync.Mutex
+	netMeter    monitor.NetworkMeter
+	interfaces  []monitor.Interface
+	netSelected string // Shown on the summary card
+	netHistory  map[string]*networkHistory
+
+	// Telemetry kept for saving a .firesession
+	recorder *session.Recorder
+
+	// Readings waiting to be added to the sensor history in the database
+	historyMu      sync.Mutex
+	pendingHistory []db.SensorSample
+
 	// Static component cache - populated once at startup
 	staticComponentCache struct {
-		motherboard    *MotherboardInfo
-		memoryModules  []MemoryModule
-		gpus           []GPUInfo
-		storageDevices []StorageInfo
-		fans           []FanInfo
+		motherboard    *inventory.MotherboardInfo
+		memoryModules  []inventory.MemoryModule
+		gpus           []inventory.GPUInfo
+		storageDevices []inventory.StorageInfo
+		fans           []inventory.FanInfo
 	}
 	cacheInitialized bool
 }
@@ -105,12 +136,17 @@ func CreateDashboard(cache *StaticCache) *Dashboard {
 		cpuPowerHistory:   NewMetricHistory(),
 		cpuUsageHistory:   NewMetricHistory(),
 		cpuClockHistory:   NewMetricHistory(),
-		storageDevices:    make([]StorageInfo, 0),
+		recorder:          session.NewRecorder(session.DefaultCapacity),
+		storageDevices:    make([]inventory.StorageInfo, 0),
+		updateInterval:    config.DefaultUpdateInterval,
+	}
+	if settings, err := config.Load(); err == nil {
+		d.updateInterval = settings.GUI.Interval()
 	}
 
 	// Copy the preloaded cache if provided
 	if cache != nil {
-		DebugLog("DEBUG", fmt.Sprintf("CreateDashboard - Using provided cache: %d GPUs, %d memory modules", len(cache.GPUs), len(cache.MemoryModules)))
+		logger.Debug("CreateDashboard - Using provided cache", "gpus", len(cache.GPUs), "memory_modules", len(cache.MemoryModules))
 		d.staticComponentCache.motherboard = cache.Motherboard
 		d.staticComponentCache.memoryModules = cache.MemoryModules
 		d.staticComponentCache.gpus = cache.GPUs
@@ -124,7 +160,7 @@ func CreateDashboard(cache *StaticCache) *Dashboard {
 			d.sysInfo = cache.SysInfo
 		}
 	} else {
-		DebugLog("DEBUG", "CreateDashboard - No cache provided, will load data on demand")
+		logger.Debug("CreateDashboard - No cache provided, will load data on demand")
 	}
 
 	// Initialize with some default values so tooltips show data immediately
@@ -142,32 +178,37 @@ func (d *Dashboard) SetWindow(w fyne.Window) {
 	d.window = w
 }
 
+// SetOpenSettings sets what the Open Settings quick action does
+func (d *Dashboard) SetOpenSettings(open func()) {
+	d.openSettings = open
+}
+
 // build creates the dashboard UI
 func (d *Dashboard) build() {
-	DebugLog("DEBUG", "Dashboard.build() - Checking system info...")
+	logger.Debug("Dashboard.build() - Checking system info...")
 	// Get initial system info if not already loaded from cache
 	if d.sysInfo == nil {
-		DebugLog("DEBUG", "Dashboard.build() - Getting system info...")
-		d.sysInfo, _ = GetSystemInfo()
+		logger.Debug("Dashboard.build() - Getting system info...")
+		d.sysInfo, _ = inventory.GetSystemInfo()
 	} else {
-		DebugLog("DEBUG", "Dashboard.build() - Using cached system info")
+		logger.Debug("Dashboard.build() - Using cached system info")
 	}
 
 	// Initialize static component cache if not already done
 	if !d.cacheInitialized {
-		DebugLog("DEBUG", "Dashboard.build() - Initializing static cache...")
+		logger.Debug("Dashboard.build() - Initializing static cache...")
 		d.initializeStaticCache()
 	}
 
-	DebugLog("DEBUG", "Dashboard.build() - Populating components...")
+	logger.Debug("Dashboard.build() - Populating components...")
 	d.populateComponents()
 
 	// Create summary strip
-	DebugLog("DEBUG", "Dashboard.build() - Creating summary strip...")
+	logger.Debug("Dashboard.build() - Creating summary strip...")
 	summaryStrip := d.createSummaryStrip()
 
 	// Create main content area
-	DebugLog("DEBUG", "Dashboard.build() - Creating main content...")
+	logger.Debug("Dashboard.build() - Creating main content...")
 	mainContent := d.createMainContent()
 
 	// Store summary strip separately
@@ -175,8 +216,15 @@ func (d *Dashboard) build() {
 
 	// Main content is just the components and details
 	d.content = mainContent
+	if env := virt.Detect(); env.Virtual() {
+		banner := widget.NewLabelWithStyle(
+			fmt.Sprintf("Running in %s: hardware sensors are hidden and results reflect virtual hardware", env.Label()),
+			fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+		banner.Importance = widget.WarningImportance
+		d.content = container.NewBorder(banner, nil, nil, nil, mainContent)
+	}
 
-	DebugLog("DEBUG", "Dashboard.build() - Complete")
+	logger.Debug("Dashboard.build() - Complete")
 }
 
 // createSummaryStrip creates the top summary cards
@@ -188,7 +236,15 @@ func (d *Dashboard) createSummaryStrip() *fyne.Container {
 	}
 
 	// CPU Summary with actual CPU name - metrics in specific order
-	d.cpuSummary = d.createCompactSummaryCard("CPU", cpuName, []string{"Temp", "Voltage", "Power", "Usage", "Speed"}, map[string]color.Color{
+	// Virtual machines report no real temperature, voltage or power sensors
+	cpuMetrics := []string{"Temp", "Voltage", "Power", "Usage", "Speed"}
+	memoryMetrics := []string{"Temp", "Used", "Total"}
+	if virt.Detect().Virtual() {
+		cpuMetrics = []string{"Usage", "Speed"}
+		memoryMetrics = []string{"Used", "Total"}
+	}
+
+	d.cpuSummary = d.createCompactSummaryCard("CPU", cpuName, cpuMetrics, map[string]color.Color{
 		"Temp":    ColorTemperature,
 		"Voltage": ColorVoltage,
 		"Power":   ColorPower,
@@ -197,7 +253,7 @@ func (d *Dashboard) createSummaryStrip() *fyne.Container {
 	})
 
 	// Memory Summary - metrics in specific order
-	d.memorySummary = d.createCompactSummaryCard("Memory", "Memory", []string{"Temp", "Used", "Total"}, map[string]color.Color{
+	d.memorySummary = d.createCompactSummaryCard("Memory", "Memory", memoryMetrics, map[string]color.Color{
 		"Temp":  ColorTemperature,
 		"Used":  ColorMemoryUsage,
 		"Total": ColorFrequency,
@@ -294,17 +350,20 @@ func (d *Dashboard) createSummaryStrip() *fyne.Container {
 		"Write":  ColorGPUUsage,
 	})
 
+	d.networkSummary = d.createNetworkSummary()
+
 	// Create a full-width header with dark background
 	headerBg := canvas.NewRectangle(color.RGBA{0x1a, 0x1a, 0x1a, 0xff})
 
-	// Create proportional layout: CPU 25%, Memory 20%, GPU 30%, Storage 25%
+	// Create proportional layout: CPU 22%, Memory 16%, GPU 26%, Storage 18%, Network 18%
 	proportionalLayout := container.New(&proportionalSplitLayout{
-		ratios: []float32{0.25, 0.20, 0.30, 0.25},
+		ratios: []float32{0.22, 0.16, 0.26, 0.18, 0.18},
 	},
 		d.cpuSummary.container,
 		d.memorySummary.container,
 		gpuContainer,
 		d.storageSummary.container,
+		d.networkSummary.container,
 	)
 
 	// Wrap in horizontal scroll container
@@ -338,6 +397,8 @@ func (d *Dashboard) createCompactSummaryCard(title, deviceName string, metricOrd
 		iconResource = GetGPUIcon()
 	case "Storage":
 		iconResource = GetStorageIcon()
+	case "Network":
+		iconResource = GetNetworkIcon()
 	}
 
 	// Use device name if provided, otherwise use title
@@ -368,6 +429,13 @@ func (d *Dashboard) createCompactSummaryCard(title, deviceName string, metricOrd
 				d.gpuTabs,                           // Right: tabs
 				nil,
 			)
+		} else if title == "Network" && d.networkSelect != nil {
+			titleContent = container.NewBorder(
+				nil, nil,
+				container.NewHBox(icon, titleLabel),
+				container.NewHBox(d.networkSelect, d.networkDetails),
+				nil,
+			)
 		} else {
 			titleContent = container.NewHBox(icon, titleLabel)
 		}
@@ -382,7 +450,7 @@ func (d *Dashboard) createCompactSummaryCard(title, deviceName string, metricOrd
 	for _, name := range metricOrder {
 		if barColor, ok := metrics[name]; ok {
 			// Create metric bar - show bar for all except Voltage
-			showBar := name != "Voltage"
+			showBar := name != "Voltage" && name != "Link"
 			bar := NewMetricBar(name, barColor, showBar)
 			card.metrics[name] = bar
 			metricContainers = append(metricContainers, bar)
@@ -555,7 +623,7 @@ func (d *Dashboard) createSystemStatusCard() fyne.CanvasObject {
 	// Admin status
 	adminStatus := "Standard User"
 	adminIcon := theme.WarningIcon()
-	if IsRunningAsAdmin() {
+	if inventory.IsRunningAsAdmin() {
 		adminStatus = "Administrator"
 		adminIcon = theme.ConfirmIcon()
 	}
@@ -599,6 +667,7 @@ func (d *Dashboard) createGettingStartedSteps() fyne.CanvasObject {
 		{theme.DocumentIcon(), "Run Benchmarks", "Test your system's capabilities"},
 		{theme.SettingsIcon(), "Configure Tests", "Customize stress test parameters"},
 		{theme.FolderOpenIcon(), "Export Reports", "Save results in multiple formats"},
+		{theme.WarningIcon(), "Set Up Alerts", "Customize alerts and thresholds in SETTINGS"},
 	}
 
 	stepsContainer := container.NewVBox()
@@ -727,8 +796,9 @@ func (d *Dashboard) createQuickActionsCard() fyne.CanvasObject {
 	})
 
 	settingsBtn := widget.NewButtonWithIcon("Open Settings", theme.SettingsIcon(), func() {
-		// TODO: Open settings dialog
-		dialog.ShowInformation("Settings", "Settings dialog coming soon", d.window)
+		if d.openSettings != nil {
+			d.openSettings()
+		}
 	})
 
 	// Use vertical layout for better responsiveness
@@ -923,383 +993,131 @@ func (d *Dashboard) initializeStaticCache() {
 	}
 
 	// Cache all static component information
-	// This runs once at startup to avoid repeated queries
+	// The probes run at once and keep their results, so this only waits for
+	// the slowest of them
+	inventory.PrefetchStatic()
+	ctx := context.Background()
 
-	// Get all static info upfront
-	DebugLog("DEBUG", "initializeStaticCache - Getting motherboard info...")
-	d.staticComponentCache.motherboard, _ = GetMotherboardInfo()
+	logger.Debug("initializeStaticCache - Getting motherboard info...")
+	d.staticComponentCache.motherboard, _ = inventory.CachedMotherboardInfo(ctx)
 
-	DebugLog("DEBUG", "initializeStaticCache - Getting memory modules...")
-	d.staticComponentCache.memoryModules, _ = GetMemoryModules()
+	logger.Debug("initializeStaticCache - Getting memory modules...")
+	d.staticComponentCache.memoryModules, _ = inventory.CachedMemoryModules(ctx)
 
-	DebugLog("DEBUG", "initializeStaticCache - Getting GPU info...")
-	d.staticComponentCache.gpus, _ = GetGPUInfo()
+	logger.Debug("initializeStaticCache - Getting GPU info...")
+	d.staticComponentCache.gpus, _ = inventory.CachedGPUInventory(ctx)
 
-	DebugLog("DEBUG", "initializeStaticCache - Getting storage info...")
+	logger.Debug("initializeStaticCache - Getting storage info...")
 	// Skip storage info during initial load as it's slow and blocks UI
 	// We'll load it asynchronously later
-	d.staticComponentCache.storageDevices = []StorageInfo{}
-	DebugLog("DEBUG", "initializeStaticCache - Skipping storage info (will load async)")
+	d.staticComponentCache.storageDevices = []inventory.StorageInfo{}
+	logger.Debug("initializeStaticCache - Skipping storage info (will load async)")
 
-	DebugLog("DEBUG", "initializeStaticCache - Getting fan info...")
-	d.staticComponentCache.fans, _ = GetFanInfo()
+	logger.Debug("initializeStaticCache - Getting fan info...")
+	d.staticComponentCache.fans, _ = inventory.CachedFanInfo(ctx)
 
 	// Also cache storage devices for later use
 	d.storageDevices = d.staticComponentCache.storageDevices
 
 	d.cacheInitialized = true
-	DebugLog("DEBUG", "initializeStaticCache - Complete")
+	logger.Debug("initializeStaticCache - Complete")
 }
 
-// populateComponents populates the component list from cached static data
-func (d *Dashboard) populateComponents() {
-	d.components = []Component{}
-
-	// CPU - from system info (always available)
-	if d.sysInfo != nil && d.sysInfo.CPU.Model != "" {
-		d.components = append(d.components, Component{
-			Type:  "CPU",
-			Icon:  "🔥",
-			Name:  d.sysInfo.CPU.Model,
-			Index: len(d.components),
-			Details: map[string]string{
-				"Model":          d.sysInfo.CPU.Model,
-				"Vendor":         d.sysInfo.CPU.Vendor,
-				"Physical Cores": fmt.Sprintf("%d", d.sysInfo.CPU.PhysicalCores),
-				"Logical Cores":  fmt.Sprintf("%d", d.sysInfo.CPU.LogicalCores),
-			},
-		})
-	}
-
-	// Motherboard - from cache
-	motherboard := d.staticComponentCache.motherboard
-	if motherboard != nil && motherboard.Model != "" {
-		mbDetails := map[string]string{
-			"Manufacturer": motherboard.Manufacturer,
-			"Model":        motherboard.Model,
-		}
-		if motherboard.Version != "" && motherboard.Version != "Not Available" {
-			mbDetails["Version"] = motherboard.Version
-		}
-		if motherboard.BIOS.Vendor != "" {
-			mbDetails["BIOS Vendor"] = motherboard.BIOS.Vendor
-		}
-		if motherboard.BIOS.Version != "" {
-			mbDetails["BIOS Version"] = motherboard.BIOS.Version
-		}
-		if motherboard.BIOS.ReleaseDate != "" {
-			mbDetails["BIOS Date"] = FormatBIOSDate(motherboard.BIOS.ReleaseDate)
-		}
-
-		// Add chipset info if available
-		if motherboard.ChipsetInfo.Model != "" {
-			chipset := motherboard.ChipsetInfo.Model
-			if motherboard.ChipsetInfo.Vendor != "" {
-				chipset = fmt.Sprintf("%s %s", motherboard.ChipsetInfo.Vendor, motherboard.ChipsetInfo.Model)
-			}
-			mbDetails["Chipset"] = chipset
-		}
-
-		// Add memory slot info
-		if motherboard.Features.MemorySlots > 0 {
-			mbDetails["Memory Slots"] = fmt.Sprintf("%d", motherboard.Features.MemorySlots)
-		}
-		if motherboard.Features.MaxMemory > 0 {
-			maxMemGB := float64(motherboard.Features.MaxMemory) / (1024 * 1024 * 1024)
-			mbDetails["Max Memory"] = fmt.Sprintf("%.0f GB", maxMemGB)
-		}
+// ReloadHardware reads the hardware again in the background, as after a
+// device was added or removed, and rebuilds the component list
+func (d *Dashboard) ReloadHardware() {
+	go func() {
+		ctx := context.Background()
+		inventory.RefreshStatic(ctx)
 
-		mbName := motherboard.Model
-		if motherboard.Manufacturer != "" && motherboard.Manufacturer != "Not Available" {
-			mbName = fmt.Sprintf("%s %s", motherboard.Manufacturer, motherboard.Model)
+		d.mu.Lock()
+		d.staticComponentCache.motherboard, _ = inventory.CachedMotherboardInfo(ctx)
+		d.staticComponentCache.memoryModules, _ = inventory.CachedMemoryModules(ctx)
+		d.staticComponentCache.gpus, _ = inventory.CachedGPUInventory(ctx)
+		d.staticComponentCache.fans, _ = inventory.CachedFanInfo(ctx)
+		if storageDevices, err := inventory.CachedStorageInfo(ctx); err == nil {
+			d.staticComponentCache.storageDevices = storageDevices
+			d.storageDevices = storageDevices
 		}
+		d.populateComponents()
+		d.mu.Unlock()
 
-		d.components = append(d.components, Component{
-			Type:    "Motherboard",
-			Icon:    "🔧",
-			Name:    mbName,
-			Index:   len(d.components),
-			Details: mbDetails,
+		fyne.Do(func() {
+			d.RefreshComponentList()
 		})
-	}
-
-	// Memory - show individual modules from cache
-	memoryModules := d.staticComponentCache.memoryModules
-	DebugLog("DEBUG", fmt.Sprintf("populateComponents - Found %d memory modules in cache", len(memoryModules)))
-	if len(memoryModules) > 0 {
-		// Add individual memory modules
-		for i := range memoryModules {
-			// Update module with proper row number if not set
-			if memoryModules[i].Row == 0 {
-				memoryModules[i].Row = i + 1
-				memoryModules[i].Number = fmt.Sprintf("%d", i+1)
-			}
-
-			// Ensure all calculated fields are populated
-			module := &memoryModules[i]
-			if module.SizeGB == 0 && module.Size > 0 {
-				module.SizeGB = float64(module.Size) / (1024 * 1024 * 1024)
-			}
-			if module.BaseFrequency == 0 && module.Speed > 0 {
-				module.BaseFrequency = float64(module.Speed) / 2.0
-			}
-			if module.DataRate == 0 && module.Speed > 0 {
-				module.DataRate = int(module.Speed)
-			}
-			if module.PCRating == 0 && module.DataRate > 0 {
-				module.PCRating = module.DataRate * 8
-			}
-			if module.ChipManufacturer == "" {
-				module.ChipManufacturer = getChipManufacturer(module.Manufacturer, module.PartNumber)
-			}
-			// Build comprehensive details with all CPU-Z style fields
-			memDetails := map[string]string{}
-
-			// Add Name field if available
-			if module.Name != "" {
-				memDetails["Name"] = module.Name
-			} else {
-				// Build name if not set
-				module.Name = fmt.Sprintf("Row %d [%s/%s] – %.0f GB %s %s %s",
-					i+1, module.BankLabel, module.Slot, module.SizeGB, module.Type,
-					module.Manufacturer, module.PartNumber)
-			}
-
-			memDetails["Number"] = fmt.Sprintf("%d", i+1)
-			memDetails["Type"] = module.Type
-
-			if module.Manufacturer != "" && module.Manufacturer != "Unknown" &&
-				module.Manufacturer != "Not Specified" && module.Manufacturer != "NO DIMM" {
-				memDetails["Manufacturer"] = module.Manufacturer
-			}
-
-			// Add chip manufacturer if available
-			if module.ChipManufacturer != "" && module.ChipManufacturer != "Unknown" {
-				memDetails["Chip manufacturer"] = module.ChipManufacturer
-			}
-
-			// Add base frequency with full format
-			if module.BaseFrequency > 0 && module.DataRate > 0 && module.PCRating > 0 {
-				memDetails["Base frequency"] = fmt.Sprintf("%.1f MHz (DDR5-%d / PC5-%d)",
-					module.BaseFrequency, module.DataRate, module.PCRating)
-			} else if module.Speed > 0 {
-				// Calculate if not already set
-				baseFreq := float64(module.Speed) / 2.0
-				dataRate := int(module.Speed)
-				pcRating := dataRate * 8
-				pcPrefix := "PC5"
-				switch module.Type {
-				case "DDR4":
-					pcPrefix = "PC4"
-				case "DDR3":
-					pcPrefix = "PC3"
-				}
-				memDetails["Base frequency"] = fmt.Sprintf("%.1f MHz (%s-%d / %s-%d)",
-					baseFreq, module.Type, dataRate, pcPrefix, pcRating)
-			}
-
-			// Size in GBytes format
-			if module.SizeGB > 0 {
-				memDetails["Size"] = fmt.Sprintf("%.0f GBytes", module.SizeGB)
-			} else {
-				memDetails["Size"] = FormatMemorySize(module.Size)
-			}
-
-			if module.PartNumber != "" && module.PartNumber != "Unknown" &&
-				module.PartNumber != "Not Specified" {
-				memDetails["Part number"] = module.PartNumber
-			}
-
-			if module.SerialNumber != "" && module.SerialNumber != "Unknown" {
-				memDetails["Serial number"] = module.SerialNumber
-			}
-
-			// Additional details that might be useful
-			memDetails["Slot"] = module.Slot
-			if module.FormFactor != "" && module.FormFactor != "Unknown" {
-				memDetails["Form Factor"] = module.FormFactor
-			}
-
-			// Build display name
-			memName := fmt.Sprintf("%s %s", FormatMemorySize(module.Size), module.Type)
-			if module.Speed > 0 {
-				memName = fmt.Sprintf("%s %s @ %d MHz", FormatMemorySize(module.Size), module.Type, module.Speed)
-			}
-			if module.Manufacturer != "" && module.Manufacturer != "Unknown" &&
-				module.Manufacturer != "Not Specified" && module.Manufacturer != "NO DIMM" {
-				memName = fmt.Sprintf("%s %s", module.Manufacturer, memName)
-			}
-
-			// Add slot info to name if available
-			if module.Slot != "" && module.Slot != "Unknown" {
-				memName = fmt.Sprintf("%s (Slot: %s)", memName, module.Slot)
-			}
-
-			d.components = append(d.components, Component{
-				Type:    "Memory",
-				Icon:    "💾",
-				Name:    memName,
-				Index:   len(d.components),
-				Details: memDetails,
-			})
-		}
-	} else if d.sysInfo != nil {
-		// Fallback to system memory if no modules detected
-		memDetails := map[string]string{
-			"Total":     fmt.Sprintf("%.1f GB", d.sysInfo.Memory.TotalGB),
-			"Available": fmt.Sprintf("%.1f GB", d.sysInfo.Memory.AvailableGB),
-			"Used":      fmt.Sprintf("%.1f GB", d.sysInfo.Memory.UsedGB),
-		}
-
-		// Show host memory if in WSL
-		memName := fmt.Sprintf("System Memory (%.1f GB)", d.sysInfo.Memory.TotalGB)
-		if d.sysInfo.Host.IsWSL && d.sysInfo.Memory.HostTotalGB > 0 {
-			memName = fmt.Sprintf("System Memory (%.1f GB WSL / %.1f GB Host)",
-				d.sysInfo.Memory.TotalGB, d.sysInfo.Memory.HostTotalGB)
-			memDetails["Host Total"] = fmt.Sprintf("%.1f GB", d.sysInfo.Memory.HostTotalGB)
-			memDetails["Environment"] = "WSL2"
-		}
+		logger.Debug("Hardware reloaded")
+	}()
+}
 
-		d.components = append(d.components, Component{
-			Type:    "Memory",
-			Icon:    "💾",
-			Name:    memName,
-			Index:   len(d.components),
-			Details: memDetails,
-		})
+// populateComponents populates the component list from cached static data
+func (d *Dashboard) populateComponents() {
+	logger.Debug("populateComponents - Found cached components", "memory_modules", len(d.staticComponentCache.memoryModules), "gpus", len(d.staticComponentCache.gpus))
+	hardware := &inventory.Hardware{
+		System:         d.sysInfo,
+		Motherboard:    d.staticComponentCache.motherboard,
+		MemoryModules:  d.staticComponentCache.memoryModules,
+		GPUs:           d.staticComponentCache.gpus,
+		StorageDevices: d.staticComponentCache.storageDevices,
+		Fans:           d.staticComponentCache.fans,
 	}
 
-	// GPU - from cache
-	gpus := d.staticComponentCache.gpus
-	DebugLog("DEBUG", fmt.Sprintf("populateComponents - Found %d GPUs in cache", len(gpus)))
-	for i, gpu := range gpus {
-		// Clean up GPU name - remove vendor from name if it's already included
-		gpuName := gpu.Name
-		if strings.HasPrefix(strings.ToUpper(gpu.Name), strings.ToUpper(gpu.Vendor)) {
-			gpuName = strings.TrimPrefix(gpu.Name, gpu.Vendor)
-			gpuName = strings.TrimPrefix(gpuName, " ")
+	d.components = []Component{}
+	storageIndex := 0
+	for _, c := range hardware.Components() {
+		comp := Component{
+			Type:    c.Type,
+			Icon:    componentIcon(c),
+			Name:    c.Name,
+			Index:   len(d.components),
+			Details: c.Details,
 		}
-
-		displayName := gpuName
-		if gpu.Vendor != "" && !strings.Contains(strings.ToUpper(gpuName), strings.ToUpper(gpu.Vendor)) {
-			displayName = fmt.Sprintf("%s %s", gpu.Vendor, gpuName)
+		switch {
+		case c.Type == "Storage":
+			comp.Metrics = map[string]float64{"storageIndex": float64(storageIndex)} // Keep index for details lookup
+			storageIndex++
+		case c.Type == "Memory" && len(hardware.MemoryModules) == 0 && d.sysInfo != nil:
+			comp.Details["Available"] = fmt.Sprintf("%.1f GB", d.sysInfo.Memory.AvailableGB)
+			comp.Details["Used"] = fmt.Sprintf("%.1f GB", d.sysInfo.Memory.UsedGB)
 		}
-
-		d.components = append(d.components, Component{
-			Type:  "GPU",
-			Icon:  "🎮",
-			Name:  displayName,
-			Index: len(d.components),
-			Details: map[string]string{
-				"Name":         gpu.Name,
-				"Vendor":       gpu.Vendor,
-				"Memory Total": fmt.Sprintf("%d MB", gpu.MemoryTotal/(1024*1024)),
-				"GPU Index":    fmt.Sprintf("%d", i),
-			},
-		})
+		d.components = append(d.components, comp)
 	}
 
-	// Storage devices - from cache
-	storageDevices := d.staticComponentCache.storageDevices
-	for i := range storageDevices {
-		storage := &storageDevices[i]
-		icon := "💾"
-		switch storage.Type {
+	// System information moved to Getting Started page
+	// Removing from hardware list for cleaner component focus
+}
+
+// componentIcon picks the icon shown next to a component in the list
+func componentIcon(c session.Component) string {
+	switch c.Type {
+	case "CPU":
+		return "🔥"
+	case "Motherboard":
+		return "🔧"
+	case "GPU":
+		return "🎮"
+	case "Storage":
+		switch c.Details["Technology"] {
 		case "NVME":
-			icon = "⚡"
+			return "⚡"
 		case "SSD":
-			icon = "💿"
+			return "💿"
 		case "USB":
-			icon = "🔌"
+			return "🔌"
+		case "SD", "eMMC":
+			return "💳"
 		case "Windows Drive":
-			icon = "🪟"
-		}
-
-		// Build display name based on available information
-		displayName := ""
-		if storage.Model != "" {
-			displayName = storage.Model
-			if storage.Vendor != "" && !strings.Contains(strings.ToLower(storage.Model), strings.ToLower(storage.Vendor)) {
-				displayName = fmt.Sprintf("%s %s", storage.Vendor, storage.Model)
-			}
-			// Add mount point/drive letter
-			displayName = fmt.Sprintf("%s (%s)", displayName, storage.Mountpoint)
-		} else {
-			// Fallback to mount point if no model info
-			displayName = fmt.Sprintf("%s Drive", storage.Mountpoint)
-		}
-
-		// Add size to display name
-		sizeGB := float64(storage.Size) / (1024 * 1024 * 1024)
-		if sizeGB >= 1000 {
-			displayName = fmt.Sprintf("%s - %.1f TB", displayName, sizeGB/1024)
-		} else {
-			displayName = fmt.Sprintf("%s - %.1f GB", displayName, sizeGB)
-		}
-
-		// Build details map with ONLY static info
-		details := map[string]string{
-			"Technology":  storage.Type, // NVMe, SSD, HDD
-			"Capacity":    fmt.Sprintf("%.1f GB", float64(storage.Size)/(1024*1024*1024)),
-			"Mount Point": storage.Mountpoint,
-			"File System": storage.Filesystem,
-		}
-
-		// Add model and identification info
-		if storage.Model != "" {
-			details["Model"] = storage.Model
-		}
-		if storage.Vendor != "" {
-			details["Vendor"] = storage.Vendor
+			return "🪟"
 		}
-		if storage.Controller != "" {
-			details["Controller"] = storage.Controller
-		}
-		if storage.Firmware != "" {
-			details["Firmware"] = storage.Firmware
-		}
-		if storage.Serial != "" {
-			details["Serial"] = storage.Serial
-		}
-		if storage.Interface != "" {
-			details["Interface"] = storage.Interface
-		}
-
-		d.components = append(d.components, Component{
-			Type:    "Storage",
-			Icon:    icon,
-			Name:    displayName,
-			Index:   len(d.components),
-			Details: details,
-			Metrics: map[string]float64{"storageIndex": float64(i)}, // Keep index for details lookup
-		})
-	}
-
-	// Fans - from cache
-	fans := d.staticComponentCache.fans
-	for _, fan := range fans {
-		icon := "🌀"
-		switch fan.Type {
+	case "Fan":
+		switch c.Details["Type"] {
 		case "CPU":
-			icon = "❄️"
+			return "❄️"
 		case "GPU":
-			icon = "🔥"
+			return "🔥"
 		}
-
-		d.components = append(d.components, Component{
-			Type:  "Fan",
-			Icon:  icon,
-			Name:  fan.Name,
-			Index: len(d.components),
-			Details: map[string]string{
-				"Name": fan.Name,
-				"Type": fan.Type,
-			},
-		})
+		return "🌀"
 	}
-
-	// System information moved to Getting Started page
-	// Removing from hardware list for cleaner component focus
+	return "💾"
 }
 
 // updateDetails updates the details panel with static info only
@@ -1416,7 +1234,7 @@ func (d *Dashboard) updateDetails() {
 	buttonText := "View Details"
 	switch comp.Type {
 	case "CPU":
-		buttonText = "View CPU Metrics & Temperatures"
+		buttonText = "View Per-Core Usage, Clocks & Temperatures"
 	case "Memory":
 		buttonText = "View Memory Usage & Performance"
 	case "GPU":
@@ -1480,9 +1298,8 @@ func (d *Dashboard) Start() {
 
 	// Load storage info asynchronously after UI is shown
 	go func() {
-		time.Sleep(500 * time.Millisecond) // Let UI initialize first
-		DebugLog("DEBUG", "Loading storage info asynchronously...")
-		storageDevices, err := GetStorageInfo()
+		logger.Debug("Loading storage info asynchronously...")
+		storageDevices, err := inventory.CachedStorageInfo(context.Background())
 		if err == nil {
 			// Update cache under lock
 			d.mu.Lock()
@@ -1496,19 +1313,22 @@ func (d *Dashboard) Start() {
 				d.RefreshComponentList()
 			})
 
-			DebugLog("DEBUG", "Storage info loaded successfully and UI refreshed")
+			logger.Debug("Storage info loaded successfully and UI refreshed")
 		} else {
-			DebugLog("ERROR", "Failed to load storage info: %v", err)
+			logger.Error("Failed to load storage info", "error", err)
 		}
 	}()
 
-	// Start update timer with responsive interval
-	// 1 second provides good responsiveness
-	d.updateTicker = time.NewTicker(1 * time.Second)
+	// Start update timer with the interval of the settings
+	d.mu.Lock()
+	d.updateTicker = time.NewTicker(d.updateInterval)
+	d.mu.Unlock()
 
 	// Start CPU metrics updater goroutine
 	go d.updateCPUMetricsLoop()
 
+	go d.watchIntrusionLoop()
+
 	go d.monitorLoop()
 }
 
@@ -1529,6 +1349,57 @@ func (d *Dashboard) Stop() {
 	close(d.stopChan)
 }
 
+// SetPaused pauses or resumes taking readings, e.g. from the tray while a
+// benchmark runs. Alert rules are still checked.
+func (d *Dashboard) SetPaused(paused bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.paused = paused
+}
+
+// Paused reports whether monitoring is paused
+func (d *Dashboard) Paused() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.paused
+}
+
+// AlertState returns how many alerts are firing and the message of the
+// last one raised
+func (d *Dashboard) AlertState() (int, string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.alertsFiring, d.lastAlert
+}
+
+// RefreshUnits redraws the summary cards, e.g. after the preferred units
+// changed
+func (d *Dashboard) RefreshUnits() {
+	cards := append([]*SummaryCard{d.cpuSummary, d.memorySummary, d.storageSummary, d.networkSummary}, d.gpuSummaries...)
+	if len(d.gpuSummaries) == 0 {
+		cards = append(cards, d.gpuSummary)
+	}
+	for _, card := range cards {
+		if card == nil {
+			continue
+		}
+		for _, m := range card.metrics {
+			m.Refresh()
+		}
+	}
+}
+
+// SetUpdateInterval changes the time between dashboard updates, taking
+// effect from the next update
+func (d *Dashboard) SetUpdateInterval(interval time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.updateInterval = interval
+	if d.updateTicker != nil {
+		d.updateTicker.Reset(interval)
+	}
+}
+
 // monitorLoop is the main update loop
 func (d *Dashboard) monitorLoop() {
 	for {
@@ -1540,7 +1411,11 @@ func (d *Dashboard) monitorLoop() {
 				d.mu.Unlock()
 				return
 			}
+			paused := d.paused
 			d.mu.Unlock()
+			if paused {
+				continue
+			}
 
 			// Update metrics directly - we're already in a background goroutine
 			d.updateMetrics()
@@ -1577,10 +1452,7 @@ func (d *Dashboard) RefreshComponentList() {
 
 		// Send notification if storage devices were loaded
 		if storageCount > 0 {
-			fyne.CurrentApp().SendNotification(&fyne.Notification{
-				Title:   "Storage Devices Loaded",
-				Content: fmt.Sprintf("Detected %d storage devices", storageCount),
-			})
+			notifyDeviceChange("Storage Devices Loaded", fmt.Sprintf("Detected %d storage devices", storageCount))
 		}
 
 		// Refresh the list
@@ -1588,68 +1460,19 @@ func (d *Dashboard) RefreshComponentList() {
 	}
 }
 
-// getCachedGPUInfo returns cached GPU info if recent, otherwise fetches new data
-func (d *Dashboard) getCachedGPUInfo() []GPUInfo {
-	d.mu.Lock()
-	defer d.mu.Unlock()
-
-	// If data is less than 1 second old, use cached version
-	if time.Since(d.lastGPUUpdate) < 1*time.Second && len(d.lastGPUInfo) > 0 {
-		return d.lastGPUInfo
-	}
-
-	// Fetch new data
-	d.lastGPUInfo, _ = GetGPUInfo()
-	d.lastGPUUpdate = time.Now()
-	return d.lastGPUInfo
+// getCachedGPUInfo returns the GPUs' live readings without blocking the
+// caller, nil until the first reading is in
+func (d *Dashboard) getCachedGPUInfo() []inventory.GPUInfo {
+	gpus, _ := inventory.PeekGPUInfo()
+	return gpus
 }
 
-// updateDynamicStorageMetrics updates only the dynamic metrics of storage devices
-// This avoids expensive PowerShell queries for static information
-func (d *Dashboard) updateDynamicStorageMetrics(devices []StorageInfo) {
-	// Update usage statistics for each device
-	for i := range devices {
-		// Get usage stats using only the mount point (fast operation)
-		usage, err := disk.Usage(devices[i].Mountpoint)
-		if err == nil {
-			devices[i].Used = usage.Used
-			devices[i].Free = usage.Free
-			devices[i].UsedPercent = usage.UsedPercent
-		}
-
-		// Note: SMART data updates could be added here if needed,
-		// but they're also relatively expensive so we skip them
-		// for regular updates
-	}
-}
-
-// getCachedStorageInfo returns cached storage info if recent, otherwise fetches new data
-func (d *Dashboard) getCachedStorageInfo() []StorageInfo {
-	d.mu.Lock()
-	defer d.mu.Unlock()
-
-	// Storage info changes rarely, cache for 30 seconds
-	if time.Since(d.lastStorageUpdate) < 30*time.Second && len(d.lastStorageInfo) > 0 {
-		return d.lastStorageInfo
-	}
-
-	// Use the static cache that was populated at startup
-	// We only need to update dynamic metrics (usage, temperature)
-	if len(d.staticComponentCache.storageDevices) > 0 {
-		// Create a copy of static devices and update only dynamic fields
-		d.lastStorageInfo = make([]StorageInfo, len(d.staticComponentCache.storageDevices))
-		copy(d.lastStorageInfo, d.staticComponentCache.storageDevices)
-
-		// Update only dynamic metrics (usage percentage) without expensive queries
-		d.updateDynamicStorageMetrics(d.lastStorageInfo)
-		d.lastStorageUpdate = time.Now()
-		return d.lastStorageInfo
-	}
-
-	// Fallback: only if no static cache (shouldn't happen)
-	d.lastStorageInfo, _ = GetStorageInfo()
-	d.lastStorageUpdate = time.Now()
-	return d.lastStorageInfo
+// getCachedStorageInfo returns the storage devices with their usage without
+// blocking the caller, nil until the devices are in. The slow identity and
+// SMART queries are not repeated; only the usage is read again.
+func (d *Dashboard) getCachedStorageInfo() []inventory.StorageInfo {
+	devices, _ := inventory.PeekStorageUsage()
+	return devices
 }
 
 // updateGPUCardTitle updates the GPU name in a GPU card
@@ -1691,6 +1514,13 @@ func (d *Dashboard) updateGPUCardTitle(card *SummaryCard, gpuName string) {
 	}
 }
 
+// Components returns a snapshot of the detected hardware components
+func (d *Dashboard) Components() []Component {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]Component(nil), d.components...)
+}
+
 // ShowComponentDetails shows a dialog with detailed dynamic metrics for a component
 func (d *Dashboard) ShowComponentDetails(comp *Component) {
 	// Create content based on component type
@@ -1698,6 +1528,9 @@ func (d *Dashboard) ShowComponentDetails(comp *Component) {
 	title := fmt.Sprintf("%s Details - %s", comp.Type, comp.Name)
 
 	switch comp.Type {
+	case "CPU":
+		d.ShowCPUCores()
+		return
 	case "Storage":
 		// Special handling for storage - use existing storage details dialog
 		if storageIndex, ok := comp.Metrics["storageIndex"]; ok {
@@ -1749,7 +1582,7 @@ func (d *Dashboard) ShowComponentDetails(comp *Component) {
 }
 
 // ShowMemoryDetails shows the memory details page for a specific module
-func (d *Dashboard) ShowMemoryDetails(_ *MemoryModule) {
+func (d *Dashboard) ShowMemoryDetails(_ *inventory.MemoryModule) {
 	// Create memory details page
 	memoryDetailsPage := NewMemoryDetailsPage(d.window)
 
@@ -1879,17 +1712,23 @@ func (d *Dashboard) createMetricsGrid(metrics map[string]float64) fyne.CanvasObj
 		case strings.Contains(key, "Usage") || strings.Contains(key, "Percent"):
 			valueStr = fmt.Sprintf("%.1f%%", metrics[key])
 		case strings.Contains(key, "Temperature") || strings.Contains(key, "Temp"):
-			valueStr = fmt.Sprintf("%.1f°C", metrics[key])
+			valueStr = formatTemperature(metrics[key], "%.1f")
 		case strings.Contains(key, "Power"):
 			valueStr = fmt.Sprintf("%.1f W", metrics[key])
 		case strings.Contains(key, "Voltage"):
 			valueStr = fmt.Sprintf("%.3f V", metrics[key])
 		case strings.Contains(key, "Frequency") || strings.Contains(key, "Clock"):
-			if metrics[key] > 100 { // MHz
-				valueStr = fmt.Sprintf("%.0f MHz", metrics[key])
-			} else { // GHz
-				valueStr = fmt.Sprintf("%.2f GHz", metrics[key])
+			unit := "GHz"
+			if metrics[key] > 100 {
+				unit = "MHz"
+			}
+			if value, shown := convertReading(metrics[key], unit); shown == "MHz" {
+				valueStr = fmt.Sprintf("%.0f MHz", value)
+			} else {
+				valueStr = fmt.Sprintf("%.2f GHz", value)
 			}
+		case strings.Contains(key, "MB/s"):
+			valueStr = formatReading(metrics[key], "MB/s", "%.1f ")
 		case strings.Contains(key, "GB"):
 			valueStr = fmt.Sprintf("%.2f GB", metrics[key])
 		case strings.Contains(key, "MB"):
@@ -5,6 +5,8 @@ import (
 	"runtime"
 	"time"
 
+	"github.com/mscrnt/project_fire/pkg/inventory"
+	"github.com/mscrnt/project_fire/pkg/security"
 	"github.com/shirou/gopsutil/v3/cpu"
 	"github.com/shirou/gopsutil/v3/disk"
 	"github.com/shirou/gopsutil/v3/host"
@@ -47,15 +49,33 @@ func (d *Dashboard) getCPUDynamicMetrics() (metrics map[string]float64, addition
 		}
 	}
 
-	// Temperature
-	temp := getCPUTemperature()
-	if temp > 0 {
-		metrics["Die Temperature"] = temp
+	// Sensors, each only when the platform can read it
+	snapshot := readSensors()
+	if snapshot.CPUTemp > 0 {
+		metrics["Die Temperature"] = snapshot.CPUTemp
+	}
+	if snapshot.PackagePower > 0 {
+		metrics["Package Power"] = snapshot.PackagePower
+	}
+	if snapshot.PowerSource != "" {
+		additionalInfo["Power Source"] = snapshot.PowerSource
+	} else if snapshot.PowerUnavailable != "" {
+		additionalInfo["Package Power"] = "Unavailable: " + snapshot.PowerUnavailable
+	}
+	if snapshot.CPUVoltage > 0 {
+		metrics["Core Voltage"] = snapshot.CPUVoltage
+	}
+	for i, mhz := range snapshot.CoreClocks {
+		metrics[fmt.Sprintf("Core %d Clock (MHz)", i)] = mhz
+	}
+	for i, vid := range snapshot.CoreVoltages {
+		metrics[fmt.Sprintf("Core %d VID (V)", i)] = vid
+	}
+	if snapshot.VoltageSource != "" {
+		additionalInfo["Voltage Source"] = snapshot.VoltageSource
+	} else if snapshot.VoltageUnavailable != "" {
+		additionalInfo["Core Voltage"] = "Unavailable: " + snapshot.VoltageUnavailable
 	}
-
-	// Power metrics
-	metrics["Package Power"] = getCPUPackagePower()
-	metrics["Core Voltage"] = getCPUVoltage()
 
 	// CPU times
 	times, err := cpu.Times(false)
@@ -109,8 +129,10 @@ func (d *Dashboard) getMemoryDynamicMetrics() (metrics map[string]float64, addit
 		additionalInfo["Swap Free"] = fmt.Sprintf("%.1f GB", float64(swapStat.Free)/(1024*1024*1024))
 	}
 
-	// Memory pressure/temperature (placeholder)
-	metrics["Memory Temperature"] = 45.0 // Placeholder - would need specific sensor reading
+	// DIMM temperature sensors are only on some modules
+	if t := readSensors().MemoryTemp; t > 0 {
+		metrics["Memory Temperature"] = t
+	}
 
 	return metrics, additionalInfo
 }
@@ -121,7 +143,7 @@ func (d *Dashboard) getGPUDynamicMetrics(comp *Component) (metrics map[string]fl
 	additionalInfo = make(map[string]string)
 
 	// Get fresh GPU info
-	gpus, _ := GetGPUInfo()
+	gpus, _ := inventory.GetGPUInfo()
 
 	// Find the matching GPU by index
 	gpuIndexStr, ok := comp.Details["GPU Index"]
@@ -147,10 +169,23 @@ func (d *Dashboard) getGPUDynamicMetrics(comp *Component) (metrics map[string]fl
 			metrics["Memory Usage Percent"] = float64(gpu.MemoryUsed) / float64(gpu.MemoryTotal) * 100
 		}
 
-		// Placeholder metrics for clock speeds (not in current GPUInfo struct)
-		metrics["Core Clock MHz"] = 1800.0
-		metrics["Memory Clock MHz"] = 7000.0
-		metrics["Voltage"] = 0.850
+		// Intel drivers report the clock; otherwise clocks, voltage and hot
+		// spot come from a hardware monitor such as LibreHardwareMonitor,
+		// where one runs
+		if gpu.CoreClock > 0 {
+			metrics["Core Clock MHz"] = gpu.CoreClock
+		}
+		if reading := gpuSensorReading(readSensors().GPUs, gpu, len(gpus)); reading != nil {
+			if reading.Hotspot > 0 {
+				metrics["Hot Spot"] = reading.Hotspot
+			}
+			if reading.CoreClock > 0 && gpu.CoreClock == 0 {
+				metrics["Core Clock MHz"] = reading.CoreClock
+			}
+			if reading.CoreVoltage > 0 {
+				metrics["Voltage"] = reading.CoreVoltage
+			}
+		}
 
 		// Additional info - these fields may not exist in current GPUInfo struct
 		// Would need to be added to GPUInfo or fetched separately
@@ -173,22 +208,17 @@ func (d *Dashboard) getMotherboardDynamicMetrics() (metrics map[string]float64,
 	metrics = make(map[string]float64)
 	additionalInfo = make(map[string]string)
 
-	// Placeholder sensor readings - in a real implementation these would come from hardware monitoring chips
-	metrics["Chipset Temperature"] = 42.0
-	metrics["VRM Temperature"] = 55.0
-	metrics["System Temperature"] = 38.0
-
-	// Voltages
-	metrics["CPU VCore"] = 1.25
-	metrics["+12V Rail"] = 12.1
-	metrics["+5V Rail"] = 5.05
-	metrics["+3.3V Rail"] = 3.31
-	metrics["DRAM Voltage"] = 1.35
-
-	// Fan headers
-	metrics["CPU Fan RPM"] = 1200.0
-	metrics["System Fan 1 RPM"] = 800.0
-	metrics["System Fan 2 RPM"] = 900.0
+	// Board sensors, each only when the platform can read it
+	snapshot := readSensors()
+	if snapshot.VRMTemp > 0 {
+		metrics["VRM Temperature"] = snapshot.VRMTemp
+	}
+	for _, v := range snapshot.Voltages {
+		metrics[v.Name+" (V)"] = v.Value
+	}
+	for _, f := range snapshot.Fans {
+		metrics[f.Name+" RPM"] = f.Value
+	}
 
 	// Additional system info
 	hostInfo, err := host.Info()
@@ -214,7 +244,7 @@ func (d *Dashboard) getFanDynamicMetrics(comp *Component) (metrics map[string]fl
 	additionalInfo = make(map[string]string)
 
 	// Get fresh fan info
-	fans, _ := GetFanInfo()
+	fans, _ := inventory.GetFanInfo()
 
 	// Find matching fan by name
 	for _, fan := range fans {
@@ -257,6 +287,20 @@ func (d *Dashboard) getSystemDynamicMetrics() (metrics map[string]float64, addit
 		}
 	}
 
+	// What the BMC of a server reports
+	snapshot := readSensors()
+	if snapshot.SystemPower > 0 {
+		metrics["System Power (W)"] = snapshot.SystemPower
+	}
+	for _, p := range snapshot.Power {
+		metrics[p.Name+" (W)"] = p.Value
+	}
+	for _, t := range snapshot.Temperatures {
+		if t.Source == "BMC" {
+			metrics[t.Name+" (°C)"] = t.Value
+		}
+	}
+
 	// Load average (Unix-like systems)
 	if runtime.GOOS == "linux" || runtime.GOOS == "darwin" {
 		// Load average is not available in gopsutil v3 on all platforms
@@ -308,5 +352,18 @@ func (d *Dashboard) getSystemDynamicMetrics() (metrics map[string]float64, addit
 		additionalInfo["Sleeping Processes"] = fmt.Sprintf("%d", sleeping)
 	}
 
+	// Platform security state, which refurbishers document before shipping
+	sec := security.Collect()
+	additionalInfo["Secure Boot"] = sec.SecureBoot
+	additionalInfo["Boot Mode"] = sec.BootMode
+	additionalInfo["TPM"] = sec.TPMSummary()
+	if sec.TPM.Vendor != "" {
+		additionalInfo["TPM Vendor"] = sec.TPM.Vendor
+	}
+	additionalInfo["Disk Encryption"] = sec.EncryptionSummary()
+	for _, v := range sec.Volumes {
+		additionalInfo["Encryption "+v.Volume] = fmt.Sprintf("%s %s", v.Method, v.State)
+	}
+
 	return metrics, additionalInfo
 }
@@ -1,17 +1,19 @@
 package gui
 
 import (
-	"crypto/rand"
+	"context"
+	"errors"
 	"fmt"
-	"math/big"
-	"os"
-	"os/exec"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"fyne.io/fyne/v2"
+	"github.com/mscrnt/project_fire/pkg/intrusion"
+	"github.com/mscrnt/project_fire/pkg/inventory"
+	"github.com/mscrnt/project_fire/pkg/monitor"
+	"github.com/mscrnt/project_fire/pkg/sensors"
+	"github.com/mscrnt/project_fire/pkg/virt"
 	"github.com/shirou/gopsutil/v3/cpu"
 	"github.com/shirou/gopsutil/v3/mem"
 )
@@ -50,6 +52,13 @@ func (m *MetricHistory) Add(value float64) {
 	}
 }
 
+// Values returns the recorded values, oldest first
+func (m *MetricHistory) Values() []float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]float64(nil), m.values...)
+}
+
 // GetStats returns the minimum, maximum, and average values from the history.
 func (m *MetricHistory) GetStats() (minVal, maxVal, avgVal float64) {
 	m.mu.Lock()
@@ -80,11 +89,14 @@ func (m *MetricHistory) GetStats() (minVal, maxVal, avgVal float64) {
 // MetricData holds the collected metric data
 type MetricData struct {
 	// CPU specific metrics
-	CPUDieTemp      float64 // CPU Die (average) temperature
-	CPUVoltage      float64 // Core 0 VID
-	CPUPackagePower float64 // CPU Package Power
-	CPUUsage        float64 // Total CPU Usage
-	CPUClock        float64 // Core 0 T0 Effective Clock
+	CPUDieTemp            float64   // CPU package or die temperature
+	CPUVoltage            float64   // Core voltage
+	CPUCoreVoltages       []float64 // VID of each core, where reported
+	CPUVoltageUnavailable string    // Why the core voltage cannot be read, if it cannot
+	CPUPackagePower       float64   // CPU Package Power
+	CPUPowerUnavailable   string    // Why package power cannot be read, if it cannot
+	CPUUsage              float64   // Total CPU Usage
+	CPUClock              float64   // Highest core clock in GHz
 
 	// Historical data for tooltips
 	CPUDieTempMin float64
@@ -109,6 +121,10 @@ type MetricData struct {
 	MemAvailGB float64
 	MemTemp    float64
 
+	// GPUSensors are the per-card readings of a hardware monitor such as
+	// LibreHardwareMonitor, where one runs
+	GPUSensors []sensors.GPUReading
+
 	// GPU metrics
 	GPUUsage    float64
 	GPUTemp     float64
@@ -116,6 +132,15 @@ type MetricData struct {
 	GPUMemUsage float64
 	GPUClock    float64
 	GPUVoltage  float64
+
+	// Throughput of the primary drive in MB/s
+	DiskRead, DiskWrite float64
+
+	// Network interfaces and the one shown on the summary card, with its
+	// rates in MB/s
+	Network         []monitor.Interface
+	NetworkSelected string
+	NetDown, NetUp  float64
 }
 
 // updateMetrics updates all metrics in the dashboard
@@ -124,9 +149,9 @@ func (d *Dashboard) updateMetrics() {
 	defer func() {
 		elapsed := time.Since(startTime)
 		if elapsed > 100*time.Millisecond {
-			DebugLog("PERF", fmt.Sprintf("updateMetrics took %v (WARNING: >100ms)", elapsed))
+			perfLog.Warn("updateMetrics is slow", "took", elapsed, "budget", 100*time.Millisecond)
 		} else {
-			DebugLog("PERF", fmt.Sprintf("updateMetrics took %v", elapsed))
+			perfLog.Debug("updateMetrics", "took", elapsed)
 		}
 	}()
 
@@ -141,7 +166,7 @@ func (d *Dashboard) updateMetrics() {
 	// Use error recovery to prevent crashes
 	defer func() {
 		if r := recover(); r != nil {
-			DebugLog("ERROR", fmt.Sprintf("Panic in updateMetrics: %v", r))
+			logger.Error("Panic in updateMetrics", "panic", r)
 		}
 	}()
 
@@ -163,39 +188,48 @@ func (d *Dashboard) updateMetrics() {
 		}
 	}()
 
-	// CPU frequency
+	// CPU sensors and clocks. Virtual machines have no temperature or power
+	// sensors, so those are not read rather than showing values that look
+	// physical. Readings the sensors do not provide stay at zero.
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		cpuInfo, err := cpu.Info()
-		if err == nil && len(cpuInfo) > 0 {
-			data.CPUClock = cpuInfo[0].Mhz / 1000 // Convert to GHz
+		snapshot := readSensors()
+
+		data.CPUClock = snapshot.MaxCoreClock() / 1000 // Convert to GHz
+		if data.CPUClock == 0 {
+			if cpuInfo, err := cpu.Info(); err == nil && len(cpuInfo) > 0 {
+				data.CPUClock = cpuInfo[0].Mhz / 1000
+			}
+		}
+		if data.CPUClock > 0 {
 			d.cpuClockHistory.Add(data.CPUClock)
 			data.CPUClockMin, data.CPUClockMax, data.CPUClockAvg = d.cpuClockHistory.GetStats()
 		}
-	}()
 
-	// CPU temperature and power
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		// Get CPU Die temperature (average)
-		data.CPUDieTemp = getCPUDieTemperature()
-		d.cpuDieTempHistory.Add(data.CPUDieTemp)
-		data.CPUDieTempMin, data.CPUDieTempMax, data.CPUDieTempAvg = d.cpuDieTempHistory.GetStats()
-		DebugLog("SENSOR", fmt.Sprintf("CPU Die Temp: %.1f°C (min:%.1f, max:%.1f, avg:%.1f)",
-			data.CPUDieTemp, data.CPUDieTempMin, data.CPUDieTempMax, data.CPUDieTempAvg))
-
-		// Get CPU voltage (Core 0 VID)
-		data.CPUVoltage = getCPUVoltage()
-		DebugLog("SENSOR", fmt.Sprintf("Core 0 VID: %.3fV", data.CPUVoltage))
-
-		// Get CPU Package Power
-		data.CPUPackagePower = getCPUPackagePower()
-		d.cpuPowerHistory.Add(data.CPUPackagePower)
-		data.CPUPowerMin, data.CPUPowerMax, data.CPUPowerAvg = d.cpuPowerHistory.GetStats()
-		DebugLog("SENSOR", fmt.Sprintf("CPU Package Power: %.1fW (min:%.1f, max:%.1f, avg:%.1f)",
-			data.CPUPackagePower, data.CPUPowerMin, data.CPUPowerMax, data.CPUPowerAvg))
+		if virt.Detect().Virtual() {
+			return
+		}
+		data.CPUDieTemp = snapshot.CPUTemp
+		if data.CPUDieTemp > 0 {
+			d.cpuDieTempHistory.Add(data.CPUDieTemp)
+			data.CPUDieTempMin, data.CPUDieTempMax, data.CPUDieTempAvg = d.cpuDieTempHistory.GetStats()
+			sensorLog.Debug("CPU die temperature", "value", data.CPUDieTemp, "min", data.CPUDieTempMin, "max", data.CPUDieTempMax, "avg", data.CPUDieTempAvg)
+		}
+
+		data.CPUVoltage = snapshot.CPUVoltage
+		data.CPUCoreVoltages = snapshot.CoreVoltages
+		data.CPUVoltageUnavailable = snapshot.VoltageUnavailable
+		data.MemTemp = snapshot.MemoryTemp
+		data.GPUSensors = snapshot.GPUs
+
+		data.CPUPackagePower = snapshot.PackagePower
+		data.CPUPowerUnavailable = snapshot.PowerUnavailable
+		if data.CPUPackagePower > 0 {
+			d.cpuPowerHistory.Add(data.CPUPackagePower)
+			data.CPUPowerMin, data.CPUPowerMax, data.CPUPowerAvg = d.cpuPowerHistory.GetStats()
+			sensorLog.Debug("CPU package power", "value", data.CPUPackagePower, "min", data.CPUPowerMin, "max", data.CPUPowerMax, "avg", data.CPUPowerAvg)
+		}
 	}()
 
 	// Memory metrics
@@ -210,9 +244,33 @@ func (d *Dashboard) updateMetrics() {
 		}
 	}()
 
+	// Disk I/O, with the primary drive's throughput for the session
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		disks := d.readDisks()
+		if storage := d.getCachedStorageInfo(); len(storage) > 0 {
+			if disk, ok := monitor.DiskOf(disks, storage[0].Device); ok {
+				data.DiskRead, data.DiskWrite = disk.ReadMBps, disk.WriteMBps
+			}
+		}
+	}()
+
+	// Network interfaces
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		data.Network, data.NetworkSelected = d.readNetwork()
+		if i, ok := findInterface(data.Network, data.NetworkSelected); ok {
+			data.NetDown, data.NetUp = i.DownMBps, i.UpMBps
+		}
+	}()
+
 	// Wait for all goroutines to complete
 	wg.Wait()
 
+	d.recordSession(&data)
+
 	// Apply all updates at once
 	d.applyMetricUpdates(&data)
 }
@@ -223,43 +281,47 @@ func (d *Dashboard) applyMetricUpdates(data *MetricData) {
 	defer func() {
 		elapsed := time.Since(startTime)
 		if elapsed > 50*time.Millisecond {
-			DebugLog("PERF", fmt.Sprintf("applyMetricUpdates took %v (WARNING: >50ms)", elapsed))
+			perfLog.Warn("applyMetricUpdates is slow", "took", elapsed, "budget", 50*time.Millisecond)
 		}
 	}()
 
 	// Wrap all UI updates in fyne.Do for thread safety
 	fyne.Do(func() {
 		// CPU updates - in order: Temp, Voltage, Power, Usage, Speed
-		DebugLog("UI", "Updating CPU metrics in order: Temp, Voltage, Power, Usage, Speed")
+		uiLog.Debug("Updating CPU metrics", "temp", data.CPUDieTemp, "voltage", data.CPUVoltage,
+			"power", data.CPUPackagePower, "usage", data.CPUUsage, "clock", data.CPUClock)
 		if display, ok := d.cpuSummary.metrics["Temp"]; ok {
-			display.SetValue(data.CPUDieTemp, "°C", data.CPUDieTemp*1.8+32, "°F")
+			display.SetValue(data.CPUDieTemp, "°C", 0, "")
 			display.SetHistory(data.CPUDieTempMin, data.CPUDieTempMax, data.CPUDieTempAvg)
-			DebugLog("UI", fmt.Sprintf("  Temp: %.1f°C", data.CPUDieTemp))
 		}
 		if display, ok := d.cpuSummary.metrics["Voltage"]; ok {
-			display.SetValue(data.CPUVoltage, "V", 0, "")
-			DebugLog("UI", fmt.Sprintf("  Voltage: %.3fV", data.CPUVoltage))
+			if data.CPUVoltageUnavailable != "" {
+				display.SetUnavailable(data.CPUVoltageUnavailable)
+			} else {
+				display.SetDetail(formatCoreVoltages(data.CPUCoreVoltages))
+				display.SetValue(data.CPUVoltage, "V", 0, "")
+			}
 		}
 		if display, ok := d.cpuSummary.metrics["Power"]; ok {
-			display.SetValue(data.CPUPackagePower, "W", 0, "")
-			display.SetHistory(data.CPUPowerMin, data.CPUPowerMax, data.CPUPowerAvg)
-			DebugLog("UI", fmt.Sprintf("  Power: %.1fW", data.CPUPackagePower))
+			if data.CPUPowerUnavailable != "" {
+				display.SetUnavailable(data.CPUPowerUnavailable)
+			} else {
+				display.SetValue(data.CPUPackagePower, "W", 0, "")
+				display.SetHistory(data.CPUPowerMin, data.CPUPowerMax, data.CPUPowerAvg)
+			}
 		}
 		if display, ok := d.cpuSummary.metrics["Usage"]; ok {
 			display.SetValue(data.CPUUsage, "%", 0, "")
 			display.SetHistory(data.CPUUsageMin, data.CPUUsageMax, data.CPUUsageAvg)
-			DebugLog("UI", fmt.Sprintf("  Usage: %.1f%%", data.CPUUsage))
 		}
 		if display, ok := d.cpuSummary.metrics["Speed"]; ok {
 			display.SetValue(data.CPUClock, "GHz", 0, "")
 			display.SetHistory(data.CPUClockMin, data.CPUClockMax, data.CPUClockAvg)
-			DebugLog("UI", fmt.Sprintf("  Speed: %.2fGHz", data.CPUClock))
 		}
 
 		// Memory updates
-		if display, ok := d.memorySummary.metrics["Temp"]; ok {
-			// Memory temperature (placeholder for now)
-			display.SetValue(45.0, "°C", 0, "")
+		if display, ok := d.memorySummary.metrics["Temp"]; ok && data.MemTemp > 0 {
+			display.SetValue(data.MemTemp, "°C", 0, "")
 		}
 		if display, ok := d.memorySummary.metrics["Used"]; ok {
 			display.SetValue(data.MemUsage, "%", 0, "")
@@ -278,12 +340,16 @@ func (d *Dashboard) applyMetricUpdates(data *MetricData) {
 				continue
 			}
 			gpu := gpus[i]
+			reading := gpuSensorReading(data.GPUSensors, gpu, len(gpus))
 			if display, ok := gpuCard.metrics["Temp"]; ok {
-				display.SetValue(gpu.Temperature, "°C", 0, "")
+				temp := gpu.Temperature
+				if temp == 0 && reading != nil {
+					temp = reading.Temp
+				}
+				display.SetValue(temp, "°C", 0, "")
 			}
-			if display, ok := gpuCard.metrics["Voltage"]; ok {
-				// GPU voltage (placeholder for now)
-				display.SetValue(0.850, "V", 0, "")
+			if display, ok := gpuCard.metrics["Voltage"]; ok && reading != nil && reading.CoreVoltage > 0 {
+				display.SetValue(reading.CoreVoltage, "V", 0, "")
 			}
 			if display, ok := gpuCard.metrics["Power"]; ok {
 				display.SetValue(float64(gpu.PowerDraw), "W", 0, "")
@@ -292,9 +358,17 @@ func (d *Dashboard) applyMetricUpdates(data *MetricData) {
 				display.SetValue(gpu.Utilization, "%", 0, "")
 			}
 			if display, ok := gpuCard.metrics["Speed"]; ok {
-				// GPU clock speed in MHz (placeholder for now)
-				display.SetValue(1800, "MHz", 0, "")
-				display.SetMax(3000) // Max GPU speed
+				clock, maxClock := gpu.CoreClock, gpu.MaxClock
+				if clock == 0 && reading != nil {
+					clock = reading.CoreClock
+				}
+				if maxClock == 0 {
+					maxClock = 3000 // Max GPU speed
+				}
+				if clock > 0 {
+					display.SetValue(clock, "MHz", 0, "")
+					display.SetMax(maxClock)
+				}
 			}
 			if display, ok := gpuCard.metrics["VRAM"]; ok && gpu.MemoryTotal > 0 {
 				memPercent := float64(gpu.MemoryUsed) / float64(gpu.MemoryTotal) * 100
@@ -327,21 +401,14 @@ func (d *Dashboard) applyMetricUpdates(data *MetricData) {
 				if display, ok := d.storageSummary.metrics["Used"]; ok {
 					display.SetValue(storage.UsedPercent, "%", 0, "")
 				}
-				if display, ok := d.storageSummary.metrics["Read"]; ok && storage.SMART != nil {
-					// Show read speed in MB/s (placeholder for now)
-					display.SetValue(150.0, "MB/s", 0, "")
-					display.SetMax(600) // Max read speed
-				}
-				if display, ok := d.storageSummary.metrics["Write"]; ok && storage.SMART != nil {
-					// Show write speed in MB/s (placeholder for now)
-					display.SetValue(120.0, "MB/s", 0, "")
-					display.SetMax(500) // Max write speed
-				}
+				d.applyDiskUpdates(storage)
 
 				d.storageSummary.container.Refresh()
 			}
 		}
 
+		d.applyNetworkUpdates(data.Network, data.NetworkSelected)
+
 		// Refresh CPU and memory cards
 		d.cpuSummary.container.Refresh()
 		d.memorySummary.container.Refresh()
@@ -364,16 +431,15 @@ func (d *Dashboard) updateCPUComponentMetrics(comp *Component) {
 	}
 	cpuCache.mu.RUnlock()
 
-	// Frequency
-	cpuInfo, _ := cpu.Info()
-	if len(cpuInfo) > 0 {
+	// Frequency and temperature
+	snapshot := readSensors()
+	if mhz := snapshot.MaxCoreClock(); mhz > 0 {
+		comp.Metrics["Current Frequency (GHz)"] = mhz / 1000
+	} else if cpuInfo, _ := cpu.Info(); len(cpuInfo) > 0 {
 		comp.Metrics["Current Frequency (GHz)"] = cpuInfo[0].Mhz / 1000
 	}
-
-	// Temperature
-	temp := getCPUTemperature()
-	if temp > 0 {
-		comp.Metrics["Temperature (°C)"] = temp
+	if snapshot.CPUTemp > 0 {
+		comp.Metrics["Temperature (°C)"] = snapshot.CPUTemp
 	}
 }
 
@@ -415,7 +481,9 @@ func (d *Dashboard) updateGPUComponentMetrics(comp *Component) {
 			comp.Metrics["Memory Total (MB)"] = float64(gpu.MemoryTotal) / (1024 * 1024)
 			comp.Metrics["Memory Usage (%)"] = float64(gpu.MemoryUsed) / float64(gpu.MemoryTotal) * 100
 		}
-		// Clock speeds not available in current GPUInfo struct
+		if gpu.CoreClock > 0 {
+			comp.Metrics["Core Clock (MHz)"] = gpu.CoreClock
+		}
 		break
 	}
 }
@@ -456,108 +524,42 @@ func (d *Dashboard) updateStorageComponentMetrics(comp *Component) {
 	}
 }
 
-var (
-	lastTempCheck time.Time
-	cachedTemp    float64
-	tempMutex     sync.Mutex
-)
-
-// getCPUDieTemperature gets the CPU Die (average) temperature
-func getCPUDieTemperature() float64 {
-	return getCPUTemperature()
-}
-
-// secureRandomFloat returns a cryptographically secure random float64 in range [0, max)
-func secureRandomFloat(maxValue float64) float64 {
-	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
-	if err != nil {
-		return 0
+// formatCoreVoltages lists each core's VID for the voltage tooltip
+func formatCoreVoltages(vids []float64) string {
+	var b strings.Builder
+	for i, vid := range vids {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "Core %-2d %.3f V", i, vid)
 	}
-	return (float64(n.Int64()) / 1000000.0) * maxValue
-}
-
-// getCPUVoltage gets the Core 0 VID voltage
-func getCPUVoltage() float64 {
-	// Try to read Core 0 VID from sysfs or sensors
-	// For now, return a realistic placeholder
-	return 1.25 + secureRandomFloat(0.1) // 1.25-1.35V
-}
-
-// getCPUPackagePower gets the CPU Package Power
-func getCPUPackagePower() float64 {
-	// Try to read from Intel RAPL or AMD power interfaces
-	// /sys/class/powercap/intel-rapl/intel-rapl:0/energy_uj
-	// For now, return a realistic placeholder based on usage
-	// Don't call cpu.Percent here - we already have it from updateMetrics
-	return 45.0 + secureRandomFloat(20) // 45-65W
+	return b.String()
 }
 
-// getCPUTemperature attempts to get CPU temperature with caching
-func getCPUTemperature() float64 {
-	tempMutex.Lock()
-	defer tempMutex.Unlock()
-
-	// Cache temperature for 1 second to reduce system calls
-	if time.Since(lastTempCheck) < 1*time.Second && cachedTemp > 0 {
-		return cachedTemp
-	}
-
-	// Try Linux thermal zones first
-	thermalZones := []string{
-		"/sys/class/thermal/thermal_zone0/temp",
-		"/sys/class/thermal/thermal_zone1/temp",
-		"/sys/class/thermal/thermal_zone2/temp",
-		"/sys/class/hwmon/hwmon0/temp1_input",
-		"/sys/class/hwmon/hwmon1/temp1_input",
-		"/sys/class/hwmon/hwmon2/temp1_input",
-	}
-
-	for _, zone := range thermalZones {
-		data, err := os.ReadFile(zone) // #nosec G304 - Reading from known system thermal zone files
-		if err == nil {
-			temp, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
-			if err == nil && temp > 0 {
-				// Convert from millidegrees to degrees
-				cachedTemp = temp / 1000.0
-				lastTempCheck = time.Now()
-				return cachedTemp
-			}
+// gpuSensorReading returns the hardware monitor's reading of a card: the one
+// with its name, or the one at its index when the monitor sees as many cards
+// as the drivers do. Nil when there is none.
+func gpuSensorReading(readings []sensors.GPUReading, gpu inventory.GPUInfo, cards int) *sensors.GPUReading {
+	for i := range readings {
+		if strings.EqualFold(readings[i].Name, gpu.Name) || strings.EqualFold(readings[i].Name, gpu.Vendor+" "+gpu.Name) {
+			return &readings[i]
 		}
 	}
-
-	// Try sensors command only if file reading failed
-	if time.Since(lastTempCheck) > 30*time.Second { // Only run sensors every 30 seconds
-		cmd := exec.Command("sensors", "-u")
-		output, err := cmd.Output()
-		if err == nil {
-			lines := strings.Split(string(output), "\n")
-			for _, line := range lines {
-				// Look for CPU temperature lines
-				if strings.Contains(line, "temp1_input:") ||
-					strings.Contains(line, "Package id 0:") ||
-					strings.Contains(line, "Core 0:") {
-					parts := strings.Split(line, ":")
-					if len(parts) >= 2 {
-						tempStr := strings.TrimSpace(parts[1])
-						temp, err := strconv.ParseFloat(tempStr, 64)
-						if err == nil && temp > 0 && temp < 150 { // Sanity check
-							cachedTemp = temp
-							lastTempCheck = time.Now()
-							return cachedTemp
-						}
-					}
-				}
-			}
-		}
+	if len(readings) == cards && gpu.Index >= 0 && gpu.Index < len(readings) {
+		return &readings[gpu.Index]
 	}
+	return nil
+}
 
-	// Return a realistic placeholder for demo purposes
-	// In production, return 0 or error
-	if cachedTemp == 0 {
-		cachedTemp = 45.0 + secureRandomFloat(10) // 45-55°C
-		lastTempCheck = time.Now()
+// readSensors reads the hardware sensors shared with the CLI and agent. A
+// failed read gives an empty snapshot, so every reading is missing.
+func readSensors() *sensors.Snapshot {
+	snapshot, err := sensors.Default().Read(context.Background())
+	if err != nil {
+		sensorLog.Debug("Failed to read sensors", "error", err)
+		return &sensors.Snapshot{}
 	}
-	return cachedTemp
+	return snapshot
 }
 
 // updateCPUMetricsLoop runs in the background to update CPU metrics without blocking
@@ -574,7 +576,11 @@ func (d *Dashboard) updateCPUMetricsLoop() {
 				d.mu.Unlock()
 				return
 			}
+			paused := d.paused
 			d.mu.Unlock()
+			if paused {
+				continue
+			}
 
 			// Update CPU usage with instant reading (0 interval)
 			cpuPercent, err := cpu.Percent(0, false)
@@ -598,3 +604,60 @@ func (d *Dashboard) updateCPUMetricsLoop() {
 		}
 	}
 }
+
+// intrusionCheckInterval is how often the chassis intrusion alarms are read.
+// BMC sensors go through ipmitool, which is too slow to run every tick.
+const intrusionCheckInterval = 30 * time.Second
+
+// watchIntrusionLoop reads the chassis intrusion alarms, raises an alert
+// each time one sets and keeps the motherboard details current
+func (d *Dashboard) watchIntrusionLoop() {
+	ticker := time.NewTicker(intrusionCheckInterval)
+	defer ticker.Stop()
+
+	alerted := make(map[string]bool)
+	for {
+		found, err := intrusion.Read(context.Background())
+		if err != nil && !errors.Is(err, intrusion.ErrNotFound) {
+			logger.Warn("Failed to read chassis intrusion", "error", err)
+		}
+		for _, s := range found {
+			if s.Triggered && !alerted[s.Name] {
+				notifyWarning("Chassis Intrusion", fmt.Sprintf("%s reports the case has been opened", s.Name))
+			}
+			alerted[s.Name] = s.Triggered
+		}
+
+		d.mu.Lock()
+		changed := false
+		if mb := d.staticComponentCache.motherboard; mb != nil && !sameIntrusion(mb.Intrusion, found) {
+			mb.Intrusion = found
+			d.populateComponents()
+			changed = true
+		}
+		d.mu.Unlock()
+		if changed {
+			fyne.Do(func() {
+				d.RefreshComponentList()
+			})
+		}
+
+		select {
+		case <-ticker.C:
+		case <-d.stopChan:
+			return
+		}
+	}
+}
+
+func sameIntrusion(a, b []intrusion.Sensor) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
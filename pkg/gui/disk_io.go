@@ -0,0 +1,180 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/mscrnt/project_fire/pkg/inventory"
+	"github.com/mscrnt/project_fire/pkg/monitor"
+)
+
+// diskHistory keeps a disk's recent throughput for tooltips and charts
+type diskHistory struct {
+	read  *MetricHistory
+	write *MetricHistory
+}
+
+// readDisks reads every physical disk's I/O since the previous reading and
+// adds it to the disk's history
+func (d *Dashboard) readDisks() []monitor.Disk {
+	d.diskMu.Lock()
+	defer d.diskMu.Unlock()
+
+	disks := d.diskMeter.Read(context.Background())
+	if d.diskHistory == nil {
+		d.diskHistory = make(map[string]*diskHistory)
+	}
+	for _, disk := range disks {
+		h, ok := d.diskHistory[disk.Name]
+		if !ok {
+			h = &diskHistory{read: NewMetricHistory(), write: NewMetricHistory()}
+			d.diskHistory[disk.Name] = h
+		}
+		h.read.Add(disk.ReadMBps)
+		h.write.Add(disk.WriteMBps)
+	}
+	d.disks = disks
+	return disks
+}
+
+// diskState returns the last I/O read of the disk holding device, with its
+// history
+func (d *Dashboard) diskState(device string) (monitor.Disk, *diskHistory, bool) {
+	d.diskMu.Lock()
+	defer d.diskMu.Unlock()
+	disk, ok := monitor.DiskOf(d.disks, device)
+	return disk, d.diskHistory[disk.Name], ok
+}
+
+// describeDiskIO lists a disk's IOPS, latencies and busy time
+func describeDiskIO(disk monitor.Disk) string {
+	side := func(name string, iops, latency float64) string {
+		if iops == 0 {
+			return fmt.Sprintf("%s: idle", name)
+		}
+		return fmt.Sprintf("%s: %.0f IOPS, %.2f ms", name, iops, latency)
+	}
+	return strings.Join([]string{
+		side("Reads", disk.ReadIOPS, disk.ReadLatencyMs),
+		side("Writes", disk.WriteIOPS, disk.WriteLatencyMs),
+		fmt.Sprintf("Busy: %.0f%%", disk.Busy),
+	}, "\n")
+}
+
+// diskBarMax is the throughput a drive of the inventory type reaches, for
+// scaling the summary bars
+func diskBarMax(deviceType string) float64 {
+	switch strings.ToUpper(deviceType) {
+	case "NVME":
+		return 3500
+	case "SSD":
+		return 550
+	default:
+		return 250
+	}
+}
+
+// applyDiskUpdates shows the primary drive's throughput on the summary card
+func (d *Dashboard) applyDiskUpdates(storage inventory.StorageInfo) {
+	disk, history, ok := d.diskState(storage.Device)
+	for name, value := range map[string]float64{"Read": disk.ReadMBps, "Write": disk.WriteMBps} {
+		display, found := d.storageSummary.metrics[name]
+		if !found {
+			continue
+		}
+		if !ok {
+			display.SetUnavailable("No I/O counters for " + storage.Device)
+			continue
+		}
+		display.SetDetail(describeDiskIO(disk))
+		display.SetValue(value, "MB/s", 0, "")
+		display.SetMax(diskBarMax(storage.Type))
+		if history != nil {
+			h := history.read
+			if name == "Write" {
+				h = history.write
+			}
+			display.SetHistory(h.GetStats())
+		}
+	}
+}
+
+// createStoragePerformanceTab shows a drive's live throughput, IOPS and
+// latency, updating at the dashboard's interval until stop is called
+func (d *Dashboard) createStoragePerformanceTab(storage *inventory.StorageInfo) (content fyne.CanvasObject, stop func()) {
+	labels := make(map[string]*widget.Label)
+	grid := container.NewGridWithColumns(2)
+	for _, name := range []string{"Device", "Read", "Write", "Read IOPS", "Write IOPS", "Read Latency", "Write Latency", "Busy"} {
+		labels[name] = widget.NewLabel("-")
+		grid.Add(widget.NewLabel(name + ":"))
+		grid.Add(labels[name])
+	}
+	read := NewEnhancedLineChart("Read", 1, 0)
+	write := NewEnhancedLineChart("Write", 1, 0)
+	for _, chart := range []*EnhancedLineChart{read, write} {
+		chart.SetUnit("MB/s")
+		chart.SetShowDataPoints(false)
+	}
+
+	show := func() {
+		disk, history, ok := d.diskState(storage.Device)
+		if !ok {
+			labels["Device"].SetText("No I/O counters for " + storage.Device)
+			return
+		}
+		latency := func(ms, iops float64) string {
+			if iops == 0 {
+				return "-"
+			}
+			return fmt.Sprintf("%.2f ms", ms)
+		}
+		labels["Device"].SetText(disk.Name)
+		labels["Read"].SetText(formatMetricValue(disk.ReadMBps, "MB/s"))
+		labels["Write"].SetText(formatMetricValue(disk.WriteMBps, "MB/s"))
+		labels["Read IOPS"].SetText(fmt.Sprintf("%.0f", disk.ReadIOPS))
+		labels["Write IOPS"].SetText(fmt.Sprintf("%.0f", disk.WriteIOPS))
+		labels["Read Latency"].SetText(latency(disk.ReadLatencyMs, disk.ReadIOPS))
+		labels["Write Latency"].SetText(latency(disk.WriteLatencyMs, disk.WriteIOPS))
+		labels["Busy"].SetText(fmt.Sprintf("%.0f%%", disk.Busy))
+		if history != nil {
+			read.SetValues(history.read.Values())
+			write.SetValues(history.write.Values())
+		}
+	}
+	show()
+
+	d.mu.Lock()
+	interval := d.updateInterval
+	d.mu.Unlock()
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				fyne.Do(show)
+			}
+		}
+	}()
+	stop = func() {
+		ticker.Stop()
+		close(done)
+	}
+
+	note := widget.NewLabel("Latency is the average time a request took over the last update. Rates cover the whole physical drive.")
+	note.Importance = widget.LowImportance
+	note.Wrapping = fyne.TextWrapWord
+	content = container.NewBorder(
+		container.NewVBox(widget.NewCard("Live I/O", "", grid), note),
+		nil, nil, nil,
+		container.NewGridWithRows(2, read, write),
+	)
+	return content, stop
+}
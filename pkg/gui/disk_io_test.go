@@ -0,0 +1,15 @@
+package gui
+
+import (
+	"testing"
+
+	"github.com/mscrnt/project_fire/pkg/monitor"
+)
+
+func TestDescribeDiskIO(t *testing.T) {
+	got := describeDiskIO(monitor.Disk{ReadIOPS: 2000, ReadLatencyMs: 0.15, Busy: 42})
+	want := "Reads: 2000 IOPS, 0.15 ms\nWrites: idle\nBusy: 42%"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
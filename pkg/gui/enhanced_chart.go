@@ -1,8 +1,8 @@
 package gui
 
 import (
-	"fmt"
 	"image/color"
+	"math"
 	"sync"
 
 	"fyne.io/fyne/v2"
@@ -11,18 +11,25 @@ import (
 	"fyne.io/fyne/v2/widget"
 )
 
-// EnhancedLineChart is an improved line chart with gridlines, data points, and min/max tracking
+// EnhancedLineChart is an improved line chart with gridlines, data points, and min/max tracking.
+// The y-axis fits the visible values unless a range is pinned with SetRange.
 type EnhancedLineChart struct {
 	widget.BaseWidget
 	title    string
 	values   []float64
-	maxValue float64
+	unit     string // Used for axis and value labels, e.g. "W" or "MHz"
 	capacity int
 	mu       sync.Mutex
 
+	// Pinned y-axis range
+	fixed    bool
+	fixedMin float64
+	fixedMax float64
+
 	// Track min/max
 	minSeen float64
 	maxSeen float64
+	seen    bool
 
 	// Style options
 	showGrid       bool
@@ -32,15 +39,15 @@ type EnhancedLineChart struct {
 	pointColor     color.Color
 }
 
-// NewEnhancedLineChart creates a new enhanced line chart
+// NewEnhancedLineChart creates a new enhanced line chart. A maxValue above
+// zero pins the y-axis to 0..maxValue; zero scales it to the values.
 func NewEnhancedLineChart(title string, capacity int, maxValue float64) *EnhancedLineChart {
 	c := &EnhancedLineChart{
 		title:          title,
 		values:         make([]float64, 0, capacity),
-		maxValue:       maxValue,
 		capacity:       capacity,
-		minSeen:        maxValue,
-		maxSeen:        0,
+		fixed:          maxValue > 0,
+		fixedMax:       maxValue,
 		showGrid:       true,
 		showDataPoints: true,
 		lineColor:      ChartLineColor(),
@@ -54,24 +61,47 @@ func NewEnhancedLineChart(title string, capacity int, maxValue float64) *Enhance
 // AddValue adds a value to the chart
 func (c *EnhancedLineChart) AddValue(value float64) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
-
 	c.values = append(c.values, value)
 	if len(c.values) > c.capacity {
 		c.values = c.values[1:]
 	}
 
 	// Update min/max
-	if value < c.minSeen {
+	if !c.seen || value < c.minSeen {
 		c.minSeen = value
 	}
-	if value > c.maxSeen {
+	if !c.seen || value > c.maxSeen {
 		c.maxSeen = value
 	}
+	c.seen = true
+	c.mu.Unlock()
 
 	c.Refresh()
 }
 
+// SetValues replaces the values with a whole series, which fills the chart's
+// width. NaN values are gaps: no line is drawn to or from them.
+func (c *EnhancedLineChart) SetValues(values []float64) {
+	c.mu.Lock()
+	c.values = append([]float64(nil), values...)
+	c.capacity = max(len(values), 1)
+	c.seen = false
+	for _, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		if !c.seen || v < c.minSeen {
+			c.minSeen = v
+		}
+		if !c.seen || v > c.maxSeen {
+			c.maxSeen = v
+		}
+		c.seen = true
+	}
+	c.mu.Unlock()
+	c.Refresh()
+}
+
 // GetMinMax returns the minimum and maximum values seen
 func (c *EnhancedLineChart) GetMinMax() (minVal, maxVal float64) {
 	c.mu.Lock()
@@ -79,6 +109,48 @@ func (c *EnhancedLineChart) GetMinMax() (minVal, maxVal float64) {
 	return c.minSeen, c.maxSeen
 }
 
+// SetUnit sets the unit shown on the axis and value labels. Units such as
+// MHz, MB/s and W switch to GHz, GB/s and kW when the axis reaches them.
+func (c *EnhancedLineChart) SetUnit(unit string) {
+	c.mu.Lock()
+	c.unit = unit
+	c.mu.Unlock()
+	c.Refresh()
+}
+
+// SetRange pins the y-axis to minVal..maxVal; values outside it are clamped
+func (c *EnhancedLineChart) SetRange(minVal, maxVal float64) {
+	c.mu.Lock()
+	c.fixed = maxVal > minVal
+	c.fixedMin, c.fixedMax = minVal, maxVal
+	c.mu.Unlock()
+	c.Refresh()
+}
+
+// SetAutoScale removes a pinned range so the y-axis fits the values again
+func (c *EnhancedLineChart) SetAutoScale() {
+	c.mu.Lock()
+	c.fixed = false
+	c.mu.Unlock()
+	c.Refresh()
+}
+
+// display returns the values and unit in the preferred units, e.g. °F for
+// a chart of °C readings, with the y-axis range for them; callers hold mu
+func (c *EnhancedLineChart) display() ([]float64, string, axisRange) {
+	values := make([]float64, len(c.values))
+	for i, v := range c.values {
+		values[i], _ = convertReading(v, c.unit)
+	}
+	_, unit := convertReading(0, c.unit)
+	if c.fixed {
+		minVal, _ := convertReading(c.fixedMin, c.unit)
+		maxVal, _ := convertReading(c.fixedMax, c.unit)
+		return values, unit, fixedAxis(minVal, maxVal, defaultAxisTicks)
+	}
+	return values, unit, autoAxis(values, unit, defaultAxisTicks)
+}
+
 // SetShowGrid enables/disables grid lines
 func (c *EnhancedLineChart) SetShowGrid(show bool) {
 	c.mu.Lock()
@@ -118,7 +190,8 @@ func (r *enhancedChartRenderer) MinSize() fyne.Size {
 }
 
 func (r *enhancedChartRenderer) Layout(_ fyne.Size) {
-	// Layout is handled in Objects()
+	// Objects are positioned for the chart's size when rendered
+	r.objects = r.render()
 }
 
 func (r *enhancedChartRenderer) Refresh() {
@@ -137,7 +210,12 @@ func (r *enhancedChartRenderer) render() []fyne.CanvasObject {
 	defer r.chart.mu.Unlock()
 
 	objects := []fyne.CanvasObject{}
-	size := r.chart.MinSize()
+	size := r.chart.Size()
+	if size.Width == 0 || size.Height == 0 {
+		size = r.chart.MinSize()
+	}
+	values, unit, axis := r.chart.display()
+	ticks := axis.Ticks()
 
 	// Background with subtle gradient effect
 	bg := canvas.NewRectangle(CardBackgroundColor())
@@ -151,10 +229,26 @@ func (r *enhancedChartRenderer) render() []fyne.CanvasObject {
 	border.Resize(size)
 	objects = append(objects, border)
 
+	// Tick labels, measured to leave room for the widest
+	var tickLabels []*canvas.Text
+	labelWidth := float32(0)
+	if r.chart.showGrid {
+		for _, tick := range ticks {
+			label := canvas.NewText(axis.Format(tick, unit), theme.Color(theme.ColorNameDisabled))
+			label.TextSize = 8
+			tickLabels = append(tickLabels, label)
+			labelWidth = fyne.Max(labelWidth, label.MinSize().Width)
+		}
+	}
+
 	// Chart area (with padding)
 	padding := float32(10)
-	chartWidth := size.Width - 2*padding
+	left := padding + labelWidth
+	chartWidth := size.Width - left - padding
 	chartHeight := size.Height - 2*padding - 20 // Extra space for title
+	yAt := func(value float64) float32 {
+		return padding + 20 + chartHeight*(1-float32(axis.Position(value)))
+	}
 
 	// Title
 	if r.chart.title != "" {
@@ -166,28 +260,25 @@ func (r *enhancedChartRenderer) render() []fyne.CanvasObject {
 
 	// Grid lines
 	if r.chart.showGrid {
-		// Horizontal grid lines (25%, 50%, 75%)
-		for i := 1; i <= 3; i++ {
-			y := padding + 20 + chartHeight*float32(i)/4
+		// Horizontal grid lines at each tick
+		for i, tick := range ticks {
+			y := yAt(tick)
 			line := canvas.NewLine(r.chart.gridColor)
 			line.StrokeWidth = 1
-			line.Position1 = fyne.NewPos(padding, y)
-			line.Position2 = fyne.NewPos(padding+chartWidth, y)
+			line.Position1 = fyne.NewPos(left, y)
+			line.Position2 = fyne.NewPos(left+chartWidth, y)
 			objects = append(objects, line)
 
-			// Grid labels
-			percent := 100 - (i * 25)
-			label := canvas.NewText(fmt.Sprintf("%d%%", percent), theme.Color(theme.ColorNameDisabled))
-			label.TextSize = 8
-			label.Move(fyne.NewPos(2, y-6))
+			label := tickLabels[i]
+			label.Move(fyne.NewPos(padding-2, y-6))
 			objects = append(objects, label)
 		}
 
-		// Vertical grid lines (every 10 values)
-		gridInterval := 10
+		// Vertical grid lines (every 10 values, or a twelfth of long series)
+		gridInterval := max(10, r.chart.capacity/12)
 		if r.chart.capacity > 0 {
 			for i := gridInterval; i < r.chart.capacity; i += gridInterval {
-				x := padding + chartWidth*float32(i)/float32(r.chart.capacity)
+				x := left + chartWidth*float32(i)/float32(r.chart.capacity)
 				line := canvas.NewLine(r.chart.gridColor)
 				line.StrokeWidth = 1
 				line.Position1 = fyne.NewPos(x, padding+20)
@@ -198,25 +289,19 @@ func (r *enhancedChartRenderer) render() []fyne.CanvasObject {
 	}
 
 	// Draw the line chart
-	if len(r.chart.values) > 1 {
-		points := make([]fyne.Position, 0, len(r.chart.values))
-
-		for i, value := range r.chart.values {
-			x := padding + chartWidth*float32(i)/float32(r.chart.capacity)
-			y := padding + 20 + chartHeight*(1-float32(value)/float32(r.chart.maxValue))
-
-			// Clamp y to chart bounds
-			if y < padding+20 {
-				y = padding + 20
-			} else if y > padding+20+chartHeight {
-				y = padding + 20 + chartHeight
-			}
+	if len(values) > 1 {
+		points := make([]fyne.Position, 0, len(values))
 
-			points = append(points, fyne.NewPos(x, y))
+		for i, value := range values {
+			x := left + chartWidth*float32(i)/float32(r.chart.capacity)
+			points = append(points, fyne.NewPos(x, yAt(value)))
 		}
 
-		// Draw lines between points
+		// Draw lines between points, leaving gaps at NaN values
 		for i := 1; i < len(points); i++ {
+			if math.IsNaN(values[i-1]) || math.IsNaN(values[i]) {
+				continue
+			}
 			line := canvas.NewLine(r.chart.lineColor)
 			line.StrokeWidth = 2
 			line.Position1 = points[i-1]
@@ -224,10 +309,15 @@ func (r *enhancedChartRenderer) render() []fyne.CanvasObject {
 			objects = append(objects, line)
 		}
 
+		// Highlight the last point that has a value
+		last := len(points) - 1
+		for last >= 0 && math.IsNaN(values[last]) {
+			last--
+		}
+
 		// Draw data points
-		if r.chart.showDataPoints && len(points) > 0 {
-			// Highlight the last point
-			lastPoint := points[len(points)-1]
+		if r.chart.showDataPoints && last >= 0 {
+			lastPoint := points[last]
 
 			// Outer glow effect
 			glow := canvas.NewCircle(color.NRGBA{
@@ -247,25 +337,23 @@ func (r *enhancedChartRenderer) render() []fyne.CanvasObject {
 			objects = append(objects, point)
 
 			// Current value label
-			if len(r.chart.values) > 0 {
-				currentValue := r.chart.values[len(r.chart.values)-1]
-				valueLabel := canvas.NewText(fmt.Sprintf("%.1f", currentValue), r.chart.pointColor)
-				valueLabel.TextSize = 10
-				valueLabel.TextStyle = fyne.TextStyle{Bold: true}
-
-				// Position label above or below point based on position
-				labelY := lastPoint.Y - 15
-				if labelY < padding+20 {
-					labelY = lastPoint.Y + 8
-				}
-				valueLabel.Move(fyne.NewPos(lastPoint.X-10, labelY))
-				objects = append(objects, valueLabel)
+			currentValue := values[last]
+			valueLabel := canvas.NewText(axis.Format(currentValue, unit), r.chart.pointColor)
+			valueLabel.TextSize = 10
+			valueLabel.TextStyle = fyne.TextStyle{Bold: true}
+
+			// Position label above or below point based on position
+			labelY := lastPoint.Y - 15
+			if labelY < padding+20 {
+				labelY = lastPoint.Y + 8
 			}
+			valueLabel.Move(fyne.NewPos(lastPoint.X-10, labelY))
+			objects = append(objects, valueLabel)
 		}
-	} else if len(r.chart.values) == 1 {
+	} else if len(values) == 1 && !math.IsNaN(values[0]) {
 		// Single point
-		x := padding
-		y := padding + 20 + chartHeight*(1-float32(r.chart.values[0])/float32(r.chart.maxValue))
+		x := left
+		y := yAt(values[0])
 
 		point := canvas.NewCircle(r.chart.pointColor)
 		point.Resize(fyne.NewSize(6, 6))
@@ -273,8 +361,8 @@ func (r *enhancedChartRenderer) render() []fyne.CanvasObject {
 		objects = append(objects, point)
 	}
 
-	// "No data" message if empty
-	if len(r.chart.values) == 0 {
+	// "No data" message if empty or all gaps
+	if !r.chart.seen {
 		noDataLabel := canvas.NewText("No data", theme.Color(theme.ColorNameDisabled))
 		noDataLabel.TextSize = 12
 		noDataLabel.Alignment = fyne.TextAlignCenter
@@ -0,0 +1,536 @@
+package gui
+
+import (
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/logging"
+	"github.com/mscrnt/project_fire/pkg/session"
+	"github.com/mscrnt/project_fire/pkg/timeline"
+)
+
+// eventTimelineSpans are the lengths of time the event timeline can show
+var eventTimelineSpans = []struct {
+	label string
+	span  time.Duration
+}{
+	{"15 minutes", 15 * time.Minute},
+	{"1 hour", time.Hour},
+	{"6 hours", 6 * time.Hour},
+	{"24 hours", 24 * time.Hour},
+}
+
+// resultMetricSuffix marks metrics read from test results rather than from
+// the dashboard's recorded session
+const resultMetricSuffix = " (results)"
+
+// maxTimelinePoints limits how many points of a series are plotted
+const maxTimelinePoints = 600
+
+// eventLogPaths returns the GUI's log, its rotated files and the archives of
+// earlier sessions that were written to since the given time. Archived
+// perf.log files only hold timings and are left out.
+func eventLogPaths(since time.Time) []string {
+	paths := append([]string{LogFile}, logging.Backups(LogFile, 0)...)
+	archives, _ := filepath.Glob(filepath.Join(GetLogPath("logs"), "*.log"))
+	sort.Strings(archives)
+	for _, path := range archives {
+		if strings.HasPrefix(filepath.Base(path), "perf_") {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil && !info.ModTime().Before(since) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// ShowEventTimeline opens a window that interleaves log lines, test run
+// events and alerts on one timeline with a sensor chart of the same span,
+// either the latest span or one around a time the operator enters
+func ShowEventTimeline(app fyne.App, dbPath string, recorder *session.Recorder) fyne.Window {
+	window := app.NewWindow("F.I.R.E. - Event Timeline")
+	window.Resize(fyne.NewSize(1100, 750))
+
+	database, err := openResults(dbPath)
+	if err != nil {
+		window.SetContent(container.NewCenter(widget.NewLabel(fmt.Sprintf("Failed to open database: %v", err))))
+		window.Show()
+		return window
+	}
+	window.SetOnClosed(func() { _ = database.Close() })
+
+	var (
+		span   = time.Hour
+		w      timeline.Window
+		all    []timeline.Event
+		shown  []timeline.Event
+		points []timeline.Point
+		maxGap time.Duration
+	)
+	kinds := map[timeline.Kind]bool{timeline.KindLog: true, timeline.KindRun: true, timeline.KindAlert: true}
+	problemsOnly := false
+
+	chart := newEventTimelineChart()
+	detail := widget.NewLabel("Select an event to see the sensor reading at that time")
+	detail.Wrapping = fyne.TextWrapWord
+
+	list := widget.NewList(
+		func() int { return len(shown) },
+		func() fyne.CanvasObject {
+			at := widget.NewLabel("00:00:00")
+			at.TextStyle = fyne.TextStyle{Monospace: true}
+			level := widget.NewLabel("WARNING")
+			message := widget.NewLabel("")
+			message.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil, container.NewHBox(at, level), nil, message)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			e := shown[id]
+			row := obj.(*fyne.Container)
+			message := row.Objects[0].(*widget.Label)
+			left := row.Objects[1].(*fyne.Container)
+			left.Objects[0].(*widget.Label).SetText(e.Time.Local().Format("15:04:05"))
+			level := left.Objects[1].(*widget.Label)
+			level.Importance = eventImportance(e)
+			level.SetText(eventLabel(e))
+			message.SetText(e.Message)
+		},
+	)
+
+	metricSelect := widget.NewSelect(nil, nil)
+	metricSelect.PlaceHolder = "Select a sensor"
+
+	describe := func(e timeline.Event) string {
+		text := fmt.Sprintf("%s  %s", e.Time.Local().Format("2006-01-02 15:04:05"), e.Message)
+		if e.Source != "" {
+			text += fmt.Sprintf(" (%s)", e.Source)
+		}
+		if metric := metricSelect.Selected; metric != "" {
+			if v, ok := timeline.ValueAt(points, e.Time, maxGap); ok {
+				text += fmt.Sprintf("\n%s: %.2f", metric, v)
+			} else {
+				text += fmt.Sprintf("\n%s: no reading near this time", metric)
+			}
+		}
+		return text
+	}
+	list.OnSelected = func(id widget.ListItemID) {
+		chart.SetCursor(shown[id].Time)
+		detail.SetText(describe(shown[id]))
+	}
+	chart.OnTapped = func(t time.Time) {
+		if i := timeline.Nearest(shown, t); i >= 0 {
+			list.Select(i)
+			list.ScrollTo(i)
+		}
+	}
+
+	filter := func() {
+		shown = nil
+		for _, e := range all {
+			if kinds[e.Kind] && (!problemsOnly || e.Kind != timeline.KindLog || isProblemLevel(e.Level)) {
+				shown = append(shown, e)
+			}
+		}
+		list.UnselectAll()
+		list.Refresh()
+		chart.SetEvents(shown)
+	}
+
+	loadSeries := func() {
+		metric := metricSelect.Selected
+		points = nil
+		maxGap = span / 100
+		if maxGap < 5*time.Second {
+			maxGap = 5 * time.Second
+		}
+		switch {
+		case metric == "":
+		case strings.HasSuffix(metric, resultMetricSuffix):
+			width := span / maxTimelinePoints
+			buckets, err := database.MetricSeries(db.SeriesFilter{
+				Metric: strings.TrimSuffix(metric, resultMetricSuffix),
+				Since:  &w.Since,
+				Until:  &w.Until,
+				Width:  width,
+			})
+			if err != nil {
+				logger.Warn(err.Error())
+			}
+			points = timeline.BucketSeries(buckets)
+		default:
+			points = timeline.SampleSeries(recorder.Samples(), metric, w)
+		}
+		chart.SetSeries(w, timeline.Downsample(points, maxTimelinePoints))
+	}
+	metricSelect.OnChanged = func(string) { loadSeries() }
+
+	atEntry := widget.NewEntry()
+	atEntry.SetPlaceHolder("Now, or a time such as 03:12")
+
+	reload := func() {
+		now := time.Now()
+		w = timeline.Window{Since: now.Add(-span), Until: now}
+		if text := strings.TrimSpace(atEntry.Text); text != "" {
+			at, err := timeline.ParseTime(text, now)
+			if err != nil {
+				detail.SetText(err.Error())
+				return
+			}
+			w = timeline.Around(at, span)
+		}
+
+		all, err = timeline.Collect(database, eventLogPaths(w.Since), w)
+		if err != nil {
+			logger.Warn("Failed to collect timeline events", "error", err)
+			detail.SetText(fmt.Sprintf("Failed to collect events: %v", err))
+		} else {
+			detail.SetText(fmt.Sprintf("%d events from %s to %s", len(all),
+				w.Since.Local().Format("2006-01-02 15:04"), w.Until.Local().Format("15:04")))
+		}
+		metricSelect.Options = eventTimelineMetrics(database, recorder)
+		metricSelect.Refresh()
+		loadSeries()
+		filter()
+	}
+	atEntry.OnSubmitted = func(string) { reload() }
+
+	spanLabels := make([]string, len(eventTimelineSpans))
+	for i, s := range eventTimelineSpans {
+		spanLabels[i] = s.label
+	}
+	spanSelect := widget.NewSelect(spanLabels, func(string) {})
+	spanSelect.SetSelected(spanLabels[1])
+	spanSelect.OnChanged = func(string) {
+		span = eventTimelineSpans[spanSelect.SelectedIndex()].span
+		reload()
+	}
+
+	kindCheck := func(label string, kind timeline.Kind) *widget.Check {
+		check := widget.NewCheck(label, func(on bool) {
+			kinds[kind] = on
+			filter()
+		})
+		check.SetChecked(true)
+		return check
+	}
+	problemsCheck := widget.NewCheck("Warnings and errors only", func(on bool) {
+		problemsOnly = on
+		filter()
+	})
+
+	toolbar := container.NewVBox(
+		container.NewBorder(nil, nil,
+			container.NewHBox(widget.NewLabel("Span:"), spanSelect, widget.NewLabel("Around:")),
+			widget.NewButtonWithIcon("Refresh", theme.ViewRefreshIcon(), reload),
+			atEntry),
+		container.NewBorder(nil, nil,
+			container.NewHBox(kindCheck("Logs", timeline.KindLog), kindCheck("Runs", timeline.KindRun),
+				kindCheck("Alerts", timeline.KindAlert), problemsCheck, widget.NewLabel("Sensor:")),
+			nil, metricSelect),
+	)
+	top := container.NewBorder(toolbar, nil, nil, nil, chart)
+	bottom := container.NewBorder(nil, detail, nil, nil, list)
+	split := container.NewVSplit(top, bottom)
+	split.Offset = 0.45
+	window.SetContent(split)
+
+	reload()
+	window.Show()
+	return window
+}
+
+// eventTimelineMetrics lists the recorded session metrics, then the test
+// result metrics in the database
+func eventTimelineMetrics(database *db.DB, recorder *session.Recorder) []string {
+	seen := make(map[string]bool)
+	for _, s := range recorder.Samples() {
+		for name := range s.Metrics {
+			seen[name] = true
+		}
+	}
+	var metrics []string
+	for name := range seen {
+		metrics = append(metrics, name)
+	}
+	sort.Strings(metrics)
+
+	results, err := database.MetricNames()
+	if err != nil {
+		logger.Warn("Failed to list metrics", "error", err)
+	}
+	for _, name := range results {
+		metrics = append(metrics, name+resultMetricSuffix)
+	}
+	return metrics
+}
+
+// isProblemLevel reports whether a log level is a warning or an error
+func isProblemLevel(level string) bool {
+	switch level {
+	case "ERROR", "FATAL", "PANIC", "WARNING", "WARN":
+		return true
+	}
+	return false
+}
+
+// eventLabel is the short tag shown before an event's message
+func eventLabel(e timeline.Event) string {
+	switch e.Kind {
+	case timeline.KindRun:
+		return "RUN " + e.Level
+	case timeline.KindAlert:
+		return "ALERT"
+	}
+	return e.Level
+}
+
+// eventImportance colours an event's tag by its severity
+func eventImportance(e timeline.Event) widget.Importance {
+	switch {
+	case e.Kind == timeline.KindAlert, e.Level == "FAIL", e.Level == "ERROR", e.Level == "FATAL", e.Level == "PANIC":
+		return widget.DangerImportance
+	case e.Level == "WARNING", e.Level == "WARN":
+		return widget.WarningImportance
+	case e.Kind == timeline.KindRun:
+		return widget.HighImportance
+	}
+	return widget.LowImportance
+}
+
+// eventMarkerColor returns the marker colour of an event, or nil for
+// routine log lines, which are not marked on the chart
+func eventMarkerColor(e timeline.Event) color.Color {
+	switch eventImportance(e) {
+	case widget.DangerImportance:
+		return theme.Color(theme.ColorNameError)
+	case widget.WarningImportance:
+		return theme.Color(theme.ColorNameWarning)
+	case widget.HighImportance:
+		return theme.Color(theme.ColorNamePrimary)
+	}
+	return nil
+}
+
+// eventTimelineChart plots a sensor series over a window with a marker at
+// each run event, alert, warning and error, and a cursor at the selected
+// event. Tapping the chart reports the time under the pointer.
+type eventTimelineChart struct {
+	widget.BaseWidget
+	window timeline.Window
+	points []timeline.Point
+	events []timeline.Event
+	cursor time.Time
+
+	OnTapped func(time.Time)
+
+	plotLeft, plotWidth float32 // Set by the renderer's layout
+}
+
+func newEventTimelineChart() *eventTimelineChart {
+	c := &eventTimelineChart{}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+// SetSeries replaces the plotted window and readings
+func (c *eventTimelineChart) SetSeries(w timeline.Window, points []timeline.Point) {
+	c.window = w
+	c.points = points
+	c.Refresh()
+}
+
+// SetEvents replaces the marked events
+func (c *eventTimelineChart) SetEvents(events []timeline.Event) {
+	c.events = events
+	c.Refresh()
+}
+
+// SetCursor moves the cursor to t
+func (c *eventTimelineChart) SetCursor(t time.Time) {
+	c.cursor = t
+	c.Refresh()
+}
+
+// Tapped reports the time under the pointer
+func (c *eventTimelineChart) Tapped(ev *fyne.PointEvent) {
+	span := c.window.Until.Sub(c.window.Since)
+	if c.OnTapped == nil || c.plotWidth <= 0 || span <= 0 {
+		return
+	}
+	fraction := float64((ev.Position.X - c.plotLeft) / c.plotWidth)
+	if fraction < 0 {
+		fraction = 0
+	} else if fraction > 1 {
+		fraction = 1
+	}
+	c.OnTapped(c.window.Since.Add(time.Duration(fraction * float64(span))))
+}
+
+// CreateRenderer creates the chart renderer
+func (c *eventTimelineChart) CreateRenderer() fyne.WidgetRenderer {
+	r := &eventTimelineRenderer{chart: c}
+	r.Refresh()
+	return r
+}
+
+// eventTimelineRenderer rebuilds its objects on refresh and positions them
+// for the current size in Layout
+type eventTimelineRenderer struct {
+	chart *eventTimelineChart
+	size  fyne.Size
+
+	background *canvas.Rectangle
+	axis       axisRange
+	gridLines  []*canvas.Line
+	tickLabels []*canvas.Text
+	segments   []*canvas.Line
+	markers    []*canvas.Line
+	marked     []timeline.Event
+	cursor     *canvas.Line
+	startLabel *canvas.Text
+	endLabel   *canvas.Text
+}
+
+func (r *eventTimelineRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(400, 180)
+}
+
+func (r *eventTimelineRenderer) Refresh() {
+	c := r.chart
+	r.background = canvas.NewRectangle(CardBackgroundColor())
+
+	values := make([]float64, len(c.points))
+	for i, p := range c.points {
+		values[i] = p.Value
+	}
+	r.axis = autoAxis(values, "", defaultAxisTicks)
+	r.gridLines, r.tickLabels = nil, nil
+	if len(values) > 0 {
+		for _, tick := range r.axis.Ticks() {
+			r.gridLines = append(r.gridLines, canvas.NewLine(ChartGridColor()))
+			label := canvas.NewText(r.axis.Format(tick, ""), theme.Color(theme.ColorNameDisabled))
+			label.TextSize = 8
+			r.tickLabels = append(r.tickLabels, label)
+		}
+	}
+
+	r.segments = nil
+	for i := 1; i < len(c.points); i++ {
+		line := canvas.NewLine(ChartLineColor())
+		line.StrokeWidth = 2
+		r.segments = append(r.segments, line)
+	}
+
+	r.markers, r.marked = nil, nil
+	for _, e := range c.events {
+		if markerColor := eventMarkerColor(e); markerColor != nil {
+			r.markers = append(r.markers, canvas.NewLine(markerColor))
+			r.marked = append(r.marked, e)
+		}
+	}
+
+	r.cursor = nil
+	if !c.cursor.IsZero() {
+		r.cursor = canvas.NewLine(theme.Color(theme.ColorNameForeground))
+		r.cursor.StrokeWidth = 2
+	}
+
+	r.startLabel = canvas.NewText(c.window.Since.Local().Format("15:04:05"), theme.Color(theme.ColorNameDisabled))
+	r.startLabel.TextSize = 9
+	r.endLabel = canvas.NewText(c.window.Until.Local().Format("15:04:05"), theme.Color(theme.ColorNameDisabled))
+	r.endLabel.TextSize = 9
+
+	r.Layout(r.size)
+	canvas.Refresh(c)
+}
+
+func (r *eventTimelineRenderer) Layout(size fyne.Size) {
+	c := r.chart
+	r.size = size
+	r.background.Resize(size)
+
+	padding := float32(10)
+	labelWidth := float32(0)
+	for _, label := range r.tickLabels {
+		labelWidth = fyne.Max(labelWidth, label.MinSize().Width)
+	}
+	left := padding + labelWidth
+	top := padding
+	width := size.Width - left - padding
+	height := size.Height - top - padding - 14 // Room for the time labels
+	c.plotLeft, c.plotWidth = left, width
+
+	span := c.window.Until.Sub(c.window.Since).Seconds()
+	xAt := func(t time.Time) float32 {
+		if span <= 0 {
+			return left
+		}
+		return left + width*float32(t.Sub(c.window.Since).Seconds()/span)
+	}
+	yAt := func(v float64) float32 {
+		return top + height*float32(1-r.axis.Position(v))
+	}
+
+	for i, tick := range r.axis.Ticks() {
+		if i >= len(r.gridLines) {
+			break
+		}
+		y := yAt(tick)
+		r.gridLines[i].Position1 = fyne.NewPos(left, y)
+		r.gridLines[i].Position2 = fyne.NewPos(left+width, y)
+		r.tickLabels[i].Move(fyne.NewPos(padding-2, y-6))
+	}
+
+	for i, line := range r.segments {
+		from, to := c.points[i], c.points[i+1]
+		line.Position1 = fyne.NewPos(xAt(from.Time), yAt(from.Value))
+		line.Position2 = fyne.NewPos(xAt(to.Time), yAt(to.Value))
+	}
+
+	for i, marker := range r.markers {
+		x := xAt(r.marked[i].Time)
+		marker.Position1 = fyne.NewPos(x, top)
+		marker.Position2 = fyne.NewPos(x, top+height)
+	}
+
+	if r.cursor != nil {
+		x := xAt(c.cursor)
+		r.cursor.Position1 = fyne.NewPos(x, top)
+		r.cursor.Position2 = fyne.NewPos(x, top+height)
+	}
+
+	r.startLabel.Move(fyne.NewPos(left, top+height+2))
+	r.endLabel.Move(fyne.NewPos(left+width-r.endLabel.MinSize().Width, top+height+2))
+}
+
+func (r *eventTimelineRenderer) Objects() []fyne.CanvasObject {
+	objects := []fyne.CanvasObject{r.background}
+	for i := range r.gridLines {
+		objects = append(objects, r.gridLines[i], r.tickLabels[i])
+	}
+	for _, marker := range r.markers {
+		objects = append(objects, marker)
+	}
+	for _, line := range r.segments {
+		objects = append(objects, line)
+	}
+	if r.cursor != nil {
+		objects = append(objects, r.cursor)
+	}
+	return append(objects, r.startLabel, r.endLabel)
+}
+
+func (r *eventTimelineRenderer) Destroy() {}
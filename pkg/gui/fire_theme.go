@@ -5,8 +5,35 @@ import (
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/theme"
+	"github.com/mscrnt/project_fire/pkg/config"
 )
 
+// SettingsTheme returns the theme chosen with gui.theme in the settings
+func SettingsTheme() fyne.Theme {
+	settings, err := config.Load()
+	if err != nil {
+		return FireDarkTheme{}
+	}
+	switch settings.GUI.Theme {
+	case config.ThemeLight:
+		return variantTheme{Theme: theme.DefaultTheme(), variant: theme.VariantLight}
+	case config.ThemeSystem:
+		return theme.DefaultTheme()
+	}
+	return FireDarkTheme{}
+}
+
+// variantTheme shows a theme in one variant, whichever the system uses
+type variantTheme struct {
+	fyne.Theme
+	variant fyne.ThemeVariant
+}
+
+// Color returns the color of the theme's variant
+func (t variantTheme) Color(name fyne.ThemeColorName, _ fyne.ThemeVariant) color.Color {
+	return t.Theme.Color(name, t.variant)
+}
+
 // FireDarkTheme implements a dark theme for F.I.R.E. System Monitor
 type FireDarkTheme struct{}
 
@@ -2,12 +2,19 @@ package gui
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
+	"github.com/mscrnt/project_fire/pkg/config"
+	"github.com/mscrnt/project_fire/pkg/inventory"
+	"github.com/mscrnt/project_fire/pkg/journal"
+	"github.com/mscrnt/project_fire/pkg/profile"
+	"github.com/mscrnt/project_fire/pkg/snippet"
+	"github.com/mscrnt/project_fire/pkg/testplan"
 )
 
 // FireGUI represents the main GUI application
@@ -21,17 +28,41 @@ type FireGUI struct {
 	// Main content containers
 	dashboard  *Dashboard
 	testsPage  *TestsPage
+	stability  *StabilityPage
+	benchmarks *BenchmarksPage
+	monitoring *MonitoringPage
+	schedules  *SchedulesPage
+	alerts     *AlertsPage
 	testWizard *TestWizard
 	history    *History
 	compare    *Compare
 	aiInsights *AIInsights
 	certs      *Certificates
 
+	// Settings page tabs; General is rebuilt when the settings change
+	settingsTabs    *container.AppTabs
+	settingsGeneral *fyne.Container
+
+	// Ctrl+K command palette
+	palette *CommandPalette
+
+	// Operator profiles; the active one sets layout, units and notifications
+	profiles     *profile.Store
+	profilesPath string
+
+	// Sleep is inhibited while the session is recorded
+	keepAwake    bool
+	releaseSleep func()
+
+	// The alert rules are checked once, whichever entry point starts it
+	watchAlerts sync.Once
+
 	// Current database path
 	dbPath string
 
 	// Admin status
 	isAdmin           bool
+	hasHelper         bool
 	adminWarningShown bool
 }
 
@@ -39,9 +70,9 @@ type FireGUI struct {
 // Pass cache as nil to have the GUI load its own data
 func CreateFireGUI(app fyne.App, cache *StaticCache) *FireGUI {
 	if cache != nil {
-		DebugLog("DEBUG", fmt.Sprintf("CreateFireGUI - Creating GUI instance with cache: %d GPUs, %d memory modules", len(cache.GPUs), len(cache.MemoryModules)))
+		logger.Debug("CreateFireGUI - Creating GUI instance with cache", "gpus", len(cache.GPUs), "memory_modules", len(cache.MemoryModules))
 	} else {
-		DebugLog("DEBUG", "CreateFireGUI - Creating GUI instance without cache...")
+		logger.Debug("CreateFireGUI - Creating GUI instance without cache...")
 	}
 
 	gui := &FireGUI{
@@ -49,16 +80,17 @@ func CreateFireGUI(app fyne.App, cache *StaticCache) *FireGUI {
 		window: app.NewWindow("F.I.R.E. System Monitor"),
 		dbPath: getDefaultDBPath(),
 	}
+	gui.loadProfiles()
 
 	if cache != nil {
-		DebugLog("DEBUG", "CreateFireGUI - Calling setupWithCache()...")
+		logger.Debug("CreateFireGUI - Calling setupWithCache()...")
 		gui.setupWithCache(cache)
 	} else {
-		DebugLog("DEBUG", "CreateFireGUI - Calling setup()...")
+		logger.Debug("CreateFireGUI - Calling setup()...")
 		gui.setup()
 	}
 
-	DebugLog("DEBUG", "CreateFireGUI - Setup complete")
+	logger.Debug("CreateFireGUI - Setup complete")
 	return gui
 }
 
@@ -79,59 +111,76 @@ func (g *FireGUI) Navigation() *NavigationSidebar {
 
 // setup initializes the GUI layout
 func (g *FireGUI) setup() {
-	DebugCheckpoint("setup-start")
-	DebugLog("DEBUG", "setup() - Applying theme...")
-	// Apply FIRE theme
-	g.app.Settings().SetTheme(FireDarkTheme{})
+	logger.Debug("Checkpoint", "name", "setup-start")
+	logger.Debug("setup() - Applying theme...")
+	// Apply the theme of the settings
+	g.app.Settings().SetTheme(SettingsTheme())
 
-	DebugLog("DEBUG", "setup() - Setting window size...")
+	logger.Debug("setup() - Setting window size...")
 	// Set window size to 1600x900 (16:9 aspect ratio, HD+)
 	g.window.Resize(fyne.NewSize(1600, 900))
 	g.window.CenterOnScreen()
 
 	// Check for administrator privileges - defer the warning until window is shown
-	g.isAdmin = IsRunningAsAdmin()
+	g.isAdmin = inventory.IsRunningAsAdmin()
 	if !g.isAdmin {
-		DebugLog("WARNING", "Not running as Administrator - some features will be limited")
-	} else {
-		DebugLog("INFO", "Running with Administrator privileges")
+		g.hasHelper = connectPrivilegedHelper()
+	}
+	if !g.isAdmin && !g.hasHelper {
+		logger.Warn("Not running as Administrator - some features will be limited")
+	} else if g.isAdmin {
+		logger.Info("Running with Administrator privileges")
 	}
 
 	// Remove traditional menu bar - we'll integrate actions into navigation
 
-	DebugLog("DEBUG", "setup() - Creating Navigation...")
+	logger.Debug("setup() - Creating Navigation...")
 	g.navigation = NewNavigationSidebar()
 
-	DebugLog("DEBUG", "setup() - Creating Dashboard...")
+	logger.Debug("setup() - Creating Dashboard...")
 	g.dashboard = CreateDashboard(nil) // FIRE System Monitor
 	g.dashboard.SetWindow(g.window)    // Set window reference for dialogs
 
-	DebugLog("DEBUG", "setup() - Creating Tests Page...")
-	g.testsPage = NewTestsPage()
+	logger.Debug("setup() - Creating Stability Test Page...")
+	g.stability = NewStabilityPage(g.dbPath, g.window)
+	g.benchmarks = NewBenchmarksPage(g.dbPath, g.window)
+	g.monitoring = NewMonitoringPage(g.window, g.profiles.Current().Layout.Monitoring, g.saveMonitoringLayout)
+	g.testsPage = NewTestsPage(g.startPreset)
+
+	logger.Debug("setup() - Creating Schedules Page...")
+	g.schedules = NewSchedulesPage(g.dbPath, g.window)
+
+	logger.Debug("setup() - Creating Alerts Page...")
+	g.alerts = NewAlertsPage(g.dbPath, g.window)
+
+	logger.Debug("setup() - Creating History Page...")
+	g.history = NewHistory(g.dbPath, g.window)
 
 	// Delay navigation setup to avoid UI thread deadlock
-	DebugLog("DEBUG", "setup() - Deferring navigation page setup...")
+	logger.Debug("setup() - Deferring navigation page setup...")
 
 	// Store references for later setup
 	g.navigation.systemInfo = g.dashboard.Content()
-	g.navigation.tests = g.testsPage.Content()
-	g.navigation.history = widget.NewLabel("History page coming soon...")
-	g.navigation.reports = widget.NewLabel("Reports page coming soon...")
-	g.navigation.settings = widget.NewLabel("Settings page coming soon...")
-
-	DebugLog("DEBUG", "setup() - Creating other components (commented out for debugging)...")
+	g.navigation.tests = g.stability.Content()
+	g.navigation.schedules = g.schedules.Content()
+	g.navigation.benchmarks = g.benchmarks.Content()
+	g.navigation.reports = g.monitoring.Content()
+	g.navigation.history = g.history.Content()
+	g.navigation.onPageChanged = g.pageChanged
+	g.navigation.settings = g.createSettingsPage()
+
+	logger.Debug("setup() - Creating other components (commented out for debugging)...")
 	// Temporarily comment out other components to isolate the issue
 	// g.testWizard = NewTestWizard(g.dbPath)
-	// g.history = NewHistory(g.dbPath)
 	// g.compare = NewCompare(g.dbPath)
 	// g.aiInsights = NewAIInsights()
 	// g.certs = NewCertificates(g.dbPath)
 
 	// Get the summary strip from dashboard
-	DebugLog("DEBUG", "setup() - Getting summary strip...")
+	logger.Debug("setup() - Getting summary strip...")
 	summaryStrip := g.dashboard.SummaryStrip()
 	if summaryStrip == nil {
-		DebugLog("ERROR", "Summary strip is nil!")
+		logger.Error("Summary strip is nil!")
 		summaryStrip = container.NewHBox() // Empty container as fallback
 	}
 
@@ -140,7 +189,7 @@ func (g *FireGUI) setup() {
 	// Using a custom layout to enforce the height
 	summaryContainer := container.New(&fixedHeightLayout{height: 90}, summaryStrip)
 
-	DebugLog("DEBUG", "setup() - Setting window content...")
+	logger.Debug("setup() - Setting window content...")
 	// Set content with summary strip at top (no red header)
 	content := container.NewBorder(
 		summaryContainer,
@@ -149,55 +198,80 @@ func (g *FireGUI) setup() {
 	)
 	g.window.SetContent(content)
 
-	DebugLog("DEBUG", "setup() - Setting close handler...")
+	g.setupCommandPalette()
+	g.setupSleepIndicator()
+
+	logger.Debug("setup() - Setting close handler...")
 	// Set close handler
 	g.window.SetCloseIntercept(func() {
+		g.rememberLayout()
+		g.stability.Stop(10 * time.Second)
+		g.benchmarks.Stop(10 * time.Second)
+		g.monitoring.SetVisible(false)
 		g.dashboard.Stop()
 		g.window.Close()
 	})
 
-	DebugLog("DEBUG", "setup() - Complete!")
+	logger.Debug("setup() - Complete!")
 }
 
 // setupWithCache initializes the GUI layout with preloaded cache
 func (g *FireGUI) setupWithCache(cache *StaticCache) {
-	DebugCheckpoint("setupWithCache-start")
-	DebugLog("DEBUG", "setupWithCache() - Applying theme...")
-	// Apply FIRE theme
-	g.app.Settings().SetTheme(FireDarkTheme{})
+	logger.Debug("Checkpoint", "name", "setupWithCache-start")
+	logger.Debug("setupWithCache() - Applying theme...")
+	// Apply the theme of the settings
+	g.app.Settings().SetTheme(SettingsTheme())
 
-	DebugLog("DEBUG", "setupWithCache() - Setting window size...")
+	logger.Debug("setupWithCache() - Setting window size...")
 	// Set window size to 1600x900 (16:9 aspect ratio, HD+)
 	g.window.Resize(fyne.NewSize(1600, 900))
 	g.window.CenterOnScreen()
 
 	// Check for administrator privileges - defer the warning until window is shown
-	g.isAdmin = IsRunningAsAdmin()
+	g.isAdmin = inventory.IsRunningAsAdmin()
 	if !g.isAdmin {
-		DebugLog("WARNING", "Not running as Administrator - some features will be limited")
-	} else {
-		DebugLog("INFO", "Running with Administrator privileges")
+		g.hasHelper = connectPrivilegedHelper()
+	}
+	if !g.isAdmin && !g.hasHelper {
+		logger.Warn("Not running as Administrator - some features will be limited")
+	} else if g.isAdmin {
+		logger.Info("Running with Administrator privileges")
 	}
 
-	DebugLog("DEBUG", "setupWithCache() - Creating Navigation...")
+	logger.Debug("setupWithCache() - Creating Navigation...")
 	g.navigation = NewNavigationSidebar()
 
-	DebugLog("DEBUG", "setupWithCache() - Creating Dashboard with cache...")
+	logger.Debug("setupWithCache() - Creating Dashboard with cache...")
 	g.dashboard = CreateDashboard(cache) // Use cached data
 	g.dashboard.SetWindow(g.window)      // Set window reference for dialogs
 
-	DebugLog("DEBUG", "setupWithCache() - Creating Tests Page...")
-	g.testsPage = NewTestsPage()
+	logger.Debug("setupWithCache() - Creating Stability Test Page...")
+	g.stability = NewStabilityPage(g.dbPath, g.window)
+	g.benchmarks = NewBenchmarksPage(g.dbPath, g.window)
+	g.monitoring = NewMonitoringPage(g.window, g.profiles.Current().Layout.Monitoring, g.saveMonitoringLayout)
+	g.testsPage = NewTestsPage(g.startPreset)
+
+	logger.Debug("setupWithCache() - Creating Schedules Page...")
+	g.schedules = NewSchedulesPage(g.dbPath, g.window)
+
+	logger.Debug("setupWithCache() - Creating Alerts Page...")
+	g.alerts = NewAlertsPage(g.dbPath, g.window)
+
+	logger.Debug("setupWithCache() - Creating History Page...")
+	g.history = NewHistory(g.dbPath, g.window)
 
 	// Store references for navigation
 	g.navigation.systemInfo = g.dashboard.Content()
-	g.navigation.tests = g.testsPage.Content()
-	g.navigation.history = widget.NewLabel("History page coming soon...")
-	g.navigation.reports = widget.NewLabel("Reports page coming soon...")
-	g.navigation.settings = widget.NewLabel("Settings page coming soon...")
+	g.navigation.tests = g.stability.Content()
+	g.navigation.schedules = g.schedules.Content()
+	g.navigation.benchmarks = g.benchmarks.Content()
+	g.navigation.reports = g.monitoring.Content()
+	g.navigation.history = g.history.Content()
+	g.navigation.onPageChanged = g.pageChanged
+	g.navigation.settings = g.createSettingsPage()
 
 	// Start dashboard updates
-	DebugLog("DEBUG", "setupWithCache() - Starting dashboard updates...")
+	logger.Debug("setupWithCache() - Starting dashboard updates...")
 	g.dashboard.Start()
 
 	// Get summary strip
@@ -206,7 +280,7 @@ func (g *FireGUI) setupWithCache(cache *StaticCache) {
 	// Create a container that limits the height of the summary strip
 	summaryContainer := container.New(&fixedHeightLayout{height: 90}, summaryStrip)
 
-	DebugLog("DEBUG", "setupWithCache() - Setting window content...")
+	logger.Debug("setupWithCache() - Setting window content...")
 	// Set content with summary strip at top
 	content := container.NewBorder(
 		summaryContainer,
@@ -215,21 +289,30 @@ func (g *FireGUI) setupWithCache(cache *StaticCache) {
 	)
 	g.window.SetContent(content)
 
-	DebugLog("DEBUG", "setupWithCache() - Setting close handler...")
+	g.setupCommandPalette()
+	g.setupSleepIndicator()
+
+	logger.Debug("setupWithCache() - Setting close handler...")
 	// Set close handler
 	g.window.SetCloseIntercept(func() {
+		g.rememberLayout()
+		g.stability.Stop(10 * time.Second)
+		g.benchmarks.Stop(10 * time.Second)
+		g.monitoring.SetVisible(false)
 		g.dashboard.Stop()
 		g.window.Close()
 	})
 
-	DebugLog("DEBUG", "setupWithCache() - Complete!")
+	logger.Debug("setupWithCache() - Complete!")
 }
 
 // createMenu creates the application menu
 func (g *FireGUI) createMenu() {
 	fileMenu := fyne.NewMenu("File",
 		fyne.NewMenuItem("Open Database...", g.openDatabase),
+		fyne.NewMenuItem("Open Report or Session...", g.openSessionFile),
 		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Save Session...", g.saveSession),
 		fyne.NewMenuItem("Export Report...", g.exportReport),
 		fyne.NewMenuItemSeparator(),
 		fyne.NewMenuItem("Quit", func() {
@@ -238,12 +321,21 @@ func (g *FireGUI) createMenu() {
 	)
 
 	editMenu := fyne.NewMenu("Edit",
+		fyne.NewMenuItem("Copy Summary as Markdown", func() { g.copySnippet(snippet.Markdown) }),
+		fyne.NewMenuItem("Copy Summary as BBCode", func() { g.copySnippet(snippet.BBCode) }),
+		fyne.NewMenuItemSeparator(),
 		fyne.NewMenuItem("Preferences", g.showPreferences),
 	)
 
 	viewMenu := fyne.NewMenu("View",
 		fyne.NewMenuItem("Toggle Theme", g.toggleTheme),
 		fyne.NewMenuItem("Refresh", g.refresh),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Version Change Log", func() { ShowChangeLog(g.app, g.dbPath) }),
+		fyne.NewMenuItem("Event Timeline", func() { ShowEventTimeline(g.app, g.dbPath, g.dashboard.recorder) }),
+		fyne.NewMenuItem("Sensor History", func() { ShowSensorHistory(g.app, g.dbPath) }),
+		fyne.NewMenuItem("Processes", func() { ShowProcesses(g.app) }),
+		fyne.NewMenuItem("Logs", func() { ShowLogViewer(g.app) }),
 	)
 
 	helpMenu := fyne.NewMenu("Help",
@@ -257,16 +349,28 @@ func (g *FireGUI) createMenu() {
 
 // ShowAndRun displays the window and runs the application
 func (g *FireGUI) ShowAndRun() {
-	DebugLog("DEBUG", "ShowAndRun() - Starting dashboard monitoring...")
+	logger.Debug("ShowAndRun() - Starting dashboard monitoring...")
 	// Start dashboard monitoring
 	g.dashboard.Start()
 
-	// Show the first page before displaying window
-	DebugLog("DEBUG", "Showing first navigation page...")
-	g.navigation.ShowPage(0)
+	// Restore the active profile's layout before displaying window
+	logger.Debug("Applying operator profile...")
+	g.applyProfile(g.profiles.Current())
+	g.ShowStartPage()
 
-	DebugCheckpoint("window-show")
-	DebugLog("DEBUG", "ShowAndRun() - Calling window.ShowAndRun()...")
+	logger.Debug("Checkpoint", "name", "window-show")
+	logger.Debug("ShowAndRun() - Calling window.ShowAndRun()...")
+
+	// Note firmware and driver updates since the last session
+	go g.recordVersions()
+
+	// Mark the runs a crash, power loss or restart cut short
+	go g.recoverRuns()
+
+	// Keep the dashboard's readings for the sensor history
+	go g.dashboard.RecordHistory(g.dbPath)
+
+	g.WatchAlerts()
 
 	// Schedule admin notification after window is shown
 	go func() {
@@ -274,11 +378,9 @@ func (g *FireGUI) ShowAndRun() {
 		time.Sleep(2 * time.Second)
 
 		// Show admin notification if needed
-		if !g.isAdmin && !g.adminWarningShown {
-			fyne.CurrentApp().SendNotification(&fyne.Notification{
-				Title:   "Limited Functionality",
-				Content: "Running without Administrator privileges. Some features like SPD memory reading will be unavailable.",
-			})
+		if !g.isAdmin && !g.hasHelper && !g.adminWarningShown {
+			notifyWarning("Limited Functionality",
+				"Running without Administrator privileges. Some features like SPD memory reading will be unavailable.")
 			g.adminWarningShown = true
 		}
 	}()
@@ -286,12 +388,48 @@ func (g *FireGUI) ShowAndRun() {
 	// Show window and run - this is the ONLY ShowAndRun in the entire application
 	g.window.ShowAndRun()
 
-	DebugLog("DEBUG", "ShowAndRun() - Window closed")
+	logger.Debug("ShowAndRun() - Window closed")
+}
+
+// WatchAlerts checks the alert rules set under Settings in the background
+// until the dashboard stops. Later calls do nothing.
+func (g *FireGUI) WatchAlerts() {
+	g.watchAlerts.Do(func() {
+		go g.dashboard.WatchAlerts(g.dbPath)
+	})
+}
+
+// recoverRuns marks the runs and plan runs whose process is gone as
+// interrupted, and says so
+func (g *FireGUI) recoverRuns() {
+	database, err := openResults(g.dbPath)
+	if err != nil {
+		logger.Warn("Interrupted runs not recovered", "error", err)
+		return
+	}
+	defer func() { _ = database.Close() }()
+
+	runs, err := journal.Recover(database)
+	if err != nil {
+		logger.Warn("Interrupted runs not recovered", "error", err)
+	}
+	for _, run := range runs {
+		notifyWarning("Run Interrupted", fmt.Sprintf("Run %d (%s) never finished; last heartbeat at %s",
+			run.ID, run.Plugin, run.EndTime.Format("2006-01-02 15:04:05")))
+	}
+	plans, err := testplan.Recover(database)
+	if err != nil {
+		logger.Warn("Interrupted plan runs not recovered", "error", err)
+	}
+	for _, run := range plans {
+		notifyWarning("Plan Run Interrupted", fmt.Sprintf("Plan run %d (%s) never finished; continue it with 'bench plan resume %d'",
+			run.ID, run.Name, run.ID))
+	}
 }
 
 // showAdminWarning displays a warning dialog about limited functionality without admin privileges
 func (g *FireGUI) showAdminWarning() {
-	features := GetAdminRequiredFeatures()
+	features := inventory.GetAdminRequiredFeatures()
 	content := "F.I.R.E. is running without Administrator privileges.\n\n" +
 		"The following features will not be available:\n\n"
 
@@ -310,25 +448,47 @@ func (g *FireGUI) openDatabase() {
 	dialog.ShowInformation("Open Database", "This feature will be implemented soon", g.window)
 }
 
+// exportReport shows the history, where a run's report is opened or saved
 func (g *FireGUI) exportReport() {
-	// TODO: Implement report export
-	dialog.ShowInformation("Export Report", "This feature will be implemented soon", g.window)
+	g.navigation.ShowPage(5)
 }
 
 func (g *FireGUI) showPreferences() {
-	// TODO: Implement preferences dialog
-	dialog.ShowInformation("Preferences", "This feature will be implemented soon", g.window)
+	g.showProfileEditor()
 }
 
+// toggleTheme switches between the dark and light themes and keeps the
+// choice in the config file
 func (g *FireGUI) toggleTheme() {
-	// Theme toggling is deprecated in Fyne v2
-	// Users should use system theme preferences instead
-	dialog.ShowInformation("Theme", "Please use your system theme preferences to change the theme", g.window)
+	next := config.ThemeLight
+	if settings, err := config.Load(); err == nil && settings.GUI.Theme == config.ThemeLight {
+		next = config.ThemeDark
+	}
+	if err := config.Set("gui.theme", next); err != nil {
+		dialog.ShowError(err, g.window)
+		return
+	}
+	g.app.Settings().SetTheme(SettingsTheme())
+	g.refreshSettings()
+}
+
+// pageChanged samples the monitoring tiles only while they are shown, and
+// reloads the schedules and history so they show the scheduler's status and
+// the runs finished since they were last shown
+func (g *FireGUI) pageChanged(index int) {
+	g.monitoring.SetVisible(index == 4)
+	switch index {
+	case 2:
+		g.schedules.Refresh()
+	case 5:
+		g.history.Refresh()
+	}
 }
 
 func (g *FireGUI) refresh() {
-	// Refresh dashboard
+	// Refresh dashboard, reading the hardware again
 	if g.dashboard != nil {
+		g.dashboard.ReloadHardware()
 		g.dashboard.updateMetrics()
 	}
 }
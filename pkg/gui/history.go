@@ -2,32 +2,59 @@ package gui
 
 import (
 	"fmt"
+	"os"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+	"github.com/mscrnt/project_fire/pkg/artifact"
 	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/report"
 )
 
-// History represents the test history view
+// historySpans are the start times the history can be filtered by
+var historySpans = []struct {
+	label string
+	span  time.Duration // Zero for any time
+}{
+	{"Any time", 0},
+	{"Last 24 hours", 24 * time.Hour},
+	{"Last 7 days", 7 * 24 * time.Hour},
+	{"Last 30 days", 30 * 24 * time.Hour},
+}
+
+// History lists the runs recorded in the results database, by the CLI as
+// well as the GUI, and opens a run's charts and report
 type History struct {
 	content fyne.CanvasObject
 	dbPath  string
+	window  fyne.Window
 
 	// UI elements
-	table *widget.Table
-	runs  []*db.Run
+	table  *widget.Table
+	status *widget.Label
+	runs   []*db.Run
 
 	// Filters
 	pluginFilter *widget.Select
+	statusFilter *widget.Select
+	sinceFilter  *widget.Select
 	limitFilter  *widget.Select
+	searchEntry  *widget.Entry
 }
 
 // NewHistory creates a new history view
-func NewHistory(dbPath string) *History {
+func NewHistory(dbPath string, window fyne.Window) *History {
 	h := &History{
 		dbPath: dbPath,
+		window: window,
 		runs:   make([]*db.Run, 0),
 	}
 	h.build()
@@ -36,33 +63,53 @@ func NewHistory(dbPath string) *History {
 
 // build creates the history UI
 func (h *History) build() {
-	// Create filters
-	h.pluginFilter = widget.NewSelect([]string{"All", "cpu", "memory", "disk", "gpu"}, func(_ string) {
-		if h.limitFilter != nil {
+	reload := func(_ string) {
+		if h.table != nil {
 			h.loadRuns()
 		}
-	})
+	}
 
-	h.limitFilter = widget.NewSelect([]string{"50", "100", "250", "500"}, func(_ string) {
-		h.loadRuns()
-	})
+	// Create filters; the plugins are those of the recorded runs
+	h.pluginFilter = widget.NewSelect([]string{"All"}, reload)
+	h.statusFilter = widget.NewSelect([]string{"All", "Passed", "Failed"}, reload)
+	spanLabels := make([]string, len(historySpans))
+	for i, s := range historySpans {
+		spanLabels[i] = s.label
+	}
+	h.sinceFilter = widget.NewSelect(spanLabels, reload)
+	h.limitFilter = widget.NewSelect([]string{"50", "100", "250", "500"}, reload)
 
-	// Set defaults after both are created
+	// Tags such as customer=acme, or text of the tags, notes or error
+	h.searchEntry = widget.NewEntry()
+	h.searchEntry.SetPlaceHolder("customer=acme or text")
+	h.searchEntry.OnSubmitted = reload
+
+	// Set defaults before the table exists, so they load nothing
 	h.pluginFilter.SetSelected("All")
+	h.statusFilter.SetSelected("All")
+	h.sinceFilter.SetSelected(spanLabels[0])
 	h.limitFilter.SetSelected("50")
 
-	filterBar := container.NewHBox(
-		widget.NewLabel("Plugin:"),
-		h.pluginFilter,
-		widget.NewLabel("Limit:"),
-		h.limitFilter,
-		widget.NewButton("Refresh", h.Refresh),
+	filterBar := container.NewBorder(nil, nil,
+		container.NewHBox(
+			widget.NewLabel("Plugin:"),
+			h.pluginFilter,
+			widget.NewLabel("Status:"),
+			h.statusFilter,
+			widget.NewLabel("Started:"),
+			h.sinceFilter,
+			widget.NewLabel("Limit:"),
+			h.limitFilter,
+			widget.NewLabel("Search:"),
+		),
+		widget.NewButtonWithIcon("Refresh", theme.ViewRefreshIcon(), h.Refresh),
+		h.searchEntry,
 	)
 
 	// Create table
 	h.table = widget.NewTable(
 		func() (int, int) {
-			return len(h.runs) + 1, 7 // +1 for header, 7 columns
+			return len(h.runs) + 1, 8 // +1 for header, 8 columns
 		},
 		func() fyne.CanvasObject {
 			return widget.NewLabel("")
@@ -72,19 +119,20 @@ func (h *History) build() {
 
 			if i.Row == 0 {
 				// Header row
-				headers := []string{"ID", "Plugin", "Start Time", "Duration", "Status", "Exit Code", "Actions"}
+				headers := []string{"ID", "Plugin", "Start Time", "Duration", "Status", "Machine", "Tags", "Actions"}
 				label.SetText(headers[i.Col])
 				label.TextStyle = fyne.TextStyle{Bold: true}
 			} else {
 				// Data row
 				run := h.runs[i.Row-1]
+				label.TextStyle = fyne.TextStyle{}
 				switch i.Col {
 				case 0:
 					label.SetText(strconv.FormatInt(run.ID, 10))
 				case 1:
 					label.SetText(run.Plugin)
 				case 2:
-					label.SetText(run.StartTime.Format("2006-01-02 15:04:05"))
+					label.SetText(run.StartTime.Local().Format("2006-01-02 15:04:05"))
 				case 3:
 					if run.EndTime != nil {
 						label.SetText(formatDuration(run.Duration()))
@@ -92,14 +140,12 @@ func (h *History) build() {
 						label.SetText("Running...")
 					}
 				case 4:
-					if run.Success {
-						label.SetText("✓ Passed")
-					} else {
-						label.SetText("✗ Failed")
-					}
+					label.SetText(runStatus(run))
 				case 5:
-					label.SetText(strconv.Itoa(run.ExitCode))
+					label.SetText(run.Machine)
 				case 6:
+					label.SetText(db.FormatTags(run.Tags))
+				case 7:
 					label.SetText("View")
 				}
 			}
@@ -111,20 +157,24 @@ func (h *History) build() {
 	h.table.SetColumnWidth(1, 100) // Plugin
 	h.table.SetColumnWidth(2, 150) // Start Time
 	h.table.SetColumnWidth(3, 100) // Duration
-	h.table.SetColumnWidth(4, 100) // Status
-	h.table.SetColumnWidth(5, 80)  // Exit Code
-	h.table.SetColumnWidth(6, 100) // Actions
+	h.table.SetColumnWidth(4, 110) // Status
+	h.table.SetColumnWidth(5, 140) // Machine
+	h.table.SetColumnWidth(6, 200) // Tags
+	h.table.SetColumnWidth(7, 80)  // Actions
 
-	// Handle row selection
+	// Selecting any cell of a run opens it
 	h.table.OnSelected = func(id widget.TableCellID) {
-		if id.Row > 0 && id.Col == 6 { // Actions column
-			h.viewRunDetails(h.runs[id.Row-1])
+		h.table.UnselectAll()
+		if id.Row > 0 && id.Row <= len(h.runs) {
+			h.showRun(h.runs[id.Row-1])
 		}
 	}
 
+	h.status = widget.NewLabel("")
+
 	// Layout
 	h.content = container.NewBorder(
-		filterBar, nil, nil, nil,
+		filterBar, h.status, nil, nil,
 		h.table,
 	)
 
@@ -142,66 +192,149 @@ func (h *History) Refresh() {
 	h.loadRuns()
 }
 
-// loadRuns loads runs from the database
+// loadRuns loads the runs matching the filters, and the plugins that have
+// runs for the plugin filter
 func (h *History) loadRuns() {
-	database, err := db.Open(h.dbPath)
+	database, err := openResults(h.dbPath)
 	if err != nil {
+		h.status.SetText(fmt.Sprintf("Failed to open database: %v", err))
 		return
 	}
 	defer func() { _ = database.Close() }()
 
-	// Build filter
-	filter := db.RunFilter{}
+	if plugins, err := database.RunPlugins(); err == nil {
+		h.pluginFilter.Options = append([]string{"All"}, plugins...)
+		h.pluginFilter.Refresh()
+	}
 
+	filter := db.RunFilter{}
 	if h.pluginFilter.Selected != "All" {
 		filter.Plugin = h.pluginFilter.Selected
 	}
-
+	switch h.statusFilter.Selected {
+	case "Passed":
+		passed := true
+		filter.Success = &passed
+	case "Failed":
+		passed := false
+		filter.Success = &passed
+	}
+	if span := historySpans[max(h.sinceFilter.SelectedIndex(), 0)].span; span > 0 {
+		since := time.Now().Add(-span)
+		filter.StartTime = &since
+	}
 	if limit, err := strconv.Atoi(h.limitFilter.Selected); err == nil {
 		filter.Limit = limit
 	}
+	filter.Tags, filter.Search = parseHistorySearch(h.searchEntry.Text)
 
-	// Load runs
 	runs, err := database.ListRuns(filter)
 	if err != nil {
+		h.status.SetText(err.Error())
 		return
 	}
 
 	h.runs = runs
-	if h.table != nil {
-		h.table.Refresh()
+	h.table.Refresh()
+	switch len(runs) {
+	case 0:
+		h.status.SetText("No runs match the filters")
+	case 1:
+		h.status.SetText("1 run")
+	default:
+		h.status.SetText(fmt.Sprintf("%d runs", len(runs)))
+	}
+}
+
+// parseHistorySearch splits the history's search text into the tags runs
+// must have, written name=value, and the other words, which search the tags,
+// notes and error
+func parseHistorySearch(text string) (map[string]string, string) {
+	var (
+		tags  map[string]string
+		words []string
+	)
+	for _, term := range strings.Fields(text) {
+		if strings.Contains(term, "=") {
+			if parsed, err := db.ParseTags([]string{term}); err == nil {
+				if tags == nil {
+					tags = make(map[string]string)
+				}
+				for name, value := range parsed {
+					tags[name] = value
+				}
+				continue
+			}
+		}
+		words = append(words, term)
+	}
+	return tags, strings.Join(words, " ")
+}
+
+// runStatus describes how a run ended
+func runStatus(run *db.Run) string {
+	switch {
+	case run.EndTime == nil && !run.Interrupted:
+		return "● Running"
+	case run.Success:
+		return "✓ Passed"
+	case run.Interrupted:
+		return "⚠ Interrupted"
+	default:
+		return "✗ Failed"
 	}
 }
 
-// viewRunDetails shows detailed view of a run
-func (h *History) viewRunDetails(run *db.Run) {
-	// Load results
-	database, err := db.Open(h.dbPath)
+// showRun opens a window with a run's details, a chart of each metric it
+// sampled, and buttons to open or save its report
+func (h *History) showRun(run *db.Run) {
+	database, err := openResults(h.dbPath)
 	if err != nil {
+		dialog.ShowError(err, h.window)
 		return
 	}
 	defer func() { _ = database.Close() }()
 
 	results, err := database.GetResults(run.ID)
 	if err != nil {
+		dialog.ShowError(err, h.window)
 		return
 	}
 
-	// Create detail view
+	window := fyne.CurrentApp().NewWindow(fmt.Sprintf("F.I.R.E. - Run #%d", run.ID))
+	window.Resize(fyne.NewSize(1000, 700))
+
 	content := container.NewVBox(
-		widget.NewLabelWithStyle(fmt.Sprintf("Run #%d Details", run.ID), fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle(fmt.Sprintf("Run #%d: %s", run.ID, run.Plugin), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
 		widget.NewSeparator(),
-		widget.NewLabel(fmt.Sprintf("Plugin: %s", run.Plugin)),
-		widget.NewLabel(fmt.Sprintf("Start Time: %s", run.StartTime.Format("2006-01-02 15:04:05"))),
+		widget.NewLabel(fmt.Sprintf("Status: %s", runStatus(run))),
+		widget.NewLabel(fmt.Sprintf("Start Time: %s", run.StartTime.Local().Format("2006-01-02 15:04:05"))),
 	)
 
 	if run.EndTime != nil {
-		content.Add(widget.NewLabel(fmt.Sprintf("End Time: %s", run.EndTime.Format("2006-01-02 15:04:05"))))
+		content.Add(widget.NewLabel(fmt.Sprintf("End Time: %s", run.EndTime.Local().Format("2006-01-02 15:04:05"))))
 		content.Add(widget.NewLabel(fmt.Sprintf("Duration: %s", formatDuration(run.Duration()))))
 	}
-
-	content.Add(widget.NewLabel(fmt.Sprintf("Success: %v", run.Success)))
+	if run.Interrupted {
+		content.Add(widget.NewLabel("Interrupted: the run was cut short by a crash, power loss or restart"))
+	}
 	content.Add(widget.NewLabel(fmt.Sprintf("Exit Code: %d", run.ExitCode)))
+	if run.Machine != "" {
+		content.Add(widget.NewLabel(fmt.Sprintf("Machine: %s", run.Machine)))
+	}
+	if len(run.Tags) > 0 {
+		content.Add(widget.NewLabel(fmt.Sprintf("Tags: %s", db.FormatTags(run.Tags))))
+	}
+
+	if notes, err := database.RunNotes(run.ID); err != nil {
+		logger.Warn(err.Error())
+	} else if len(notes) > 0 {
+		content.Add(widget.NewSeparator())
+		content.Add(widget.NewLabel("Notes:"))
+		for _, n := range notes {
+			content.Add(widget.NewLabel(fmt.Sprintf("%s  %s", n.CreatedAt.Local().Format("2006-01-02 15:04"), n.Text)))
+		}
+	}
 
 	if run.Error != "" {
 		content.Add(widget.NewSeparator())
@@ -212,26 +345,219 @@ func (h *History) viewRunDetails(run *db.Run) {
 		content.Add(errorEntry)
 	}
 
-	// Add metrics
-	if len(results) > 0 {
+	// Sampled metrics are charted, metrics recorded once are listed
+	charts, values := resultCharts(results)
+	if len(charts) > 0 {
+		content.Add(widget.NewSeparator())
+		content.Add(widget.NewLabel("Charts:"))
+		content.Add(container.NewGridWithColumns(2, charts...))
+	}
+	if values != "" {
 		content.Add(widget.NewSeparator())
 		content.Add(widget.NewLabel("Metrics:"))
-
-		metricsStr := ""
-		for _, result := range results {
-			metricsStr += fmt.Sprintf("%s: %.2f %s\n", result.Metric, result.Value, result.Unit)
-		}
-
 		metricsEntry := widget.NewMultiLineEntry()
-		metricsEntry.SetText(metricsStr)
+		metricsEntry.SetText(values)
 		metricsEntry.Disable()
 		content.Add(metricsEntry)
 	}
 
-	// Show in dialog
-	dialog := widget.NewCard("Run Details", "", container.NewScroll(content))
-	dialog.Resize(fyne.NewSize(600, 500))
+	// Attached charts, logs and dumps
+	artifacts, err := artifact.List(artifact.DefaultRoot(), run.ID)
+	if err != nil {
+		logger.Warn(err.Error())
+	}
+	if len(artifacts) > 0 {
+		content.Add(widget.NewSeparator())
+		content.Add(widget.NewLabel("Artifacts:"))
+		for _, a := range artifacts {
+			a := a
+			content.Add(widget.NewButton(fmt.Sprintf("%s (%s)", a.Name, formatBytes(uint64(a.Size))), func() { // #nosec G115 -- file sizes are non-negative
+				ShowArtifactViewer(fyne.CurrentApp(), a)
+			}))
+		}
+	}
+
+	toolbar := container.NewHBox(
+		widget.NewButtonWithIcon("Open Report", theme.DocumentIcon(), func() { h.openReport(run, window) }),
+		widget.NewButtonWithIcon("Save HTML...", theme.DocumentSaveIcon(), func() { h.saveReport(run, ".html", window) }),
+		widget.NewButtonWithIcon("Save PDF...", theme.DocumentSaveIcon(), func() { h.saveReport(run, ".pdf", window) }),
+		widget.NewButtonWithIcon("Export Results...", theme.DownloadIcon(), func() { h.exportResults(run, window) }),
+	)
+	window.SetContent(container.NewBorder(toolbar, nil, nil, nil, container.NewVScroll(content)))
+	window.Show()
+}
+
+// resultCharts returns a chart of each metric a run recorded more than once,
+// in the order recorded, and lists the metrics recorded once
+func resultCharts(results []*db.Result) ([]fyne.CanvasObject, string) {
+	series := make(map[string][]*db.Result)
+	var metrics []string
+	for _, r := range results {
+		if series[r.Metric] == nil {
+			metrics = append(metrics, r.Metric)
+		}
+		series[r.Metric] = append(series[r.Metric], r)
+	}
+
+	var (
+		charts []fyne.CanvasObject
+		values strings.Builder
+	)
+	for _, metric := range metrics {
+		samples := series[metric]
+		if len(samples) == 1 {
+			fmt.Fprintf(&values, "%s: %s\n", metric, formatReading(samples[0].Value, samples[0].Unit, "%.2f "))
+			continue
+		}
+		sort.SliceStable(samples, func(i, j int) bool { return samples[i].CreatedAt.Before(samples[j].CreatedAt) })
+		points := make([]float64, len(samples))
+		for i, s := range samples {
+			points[i] = s.Value
+		}
 
-	popup := widget.NewModalPopUp(dialog, fyne.CurrentApp().Driver().AllWindows()[0].Canvas())
-	popup.Show()
+		chart := NewEnhancedLineChart("", 1, 0)
+		chart.SetShowDataPoints(len(points) <= 60)
+		chart.SetUnit(samples[0].Unit)
+		chart.SetValues(points)
+		minVal, maxVal := chart.GetMinMax()
+		charts = append(charts, widget.NewCard(metric,
+			fmt.Sprintf("%d samples, %s to %s", len(points),
+				formatReading(minVal, samples[0].Unit, "%.1f "), formatReading(maxVal, samples[0].Unit, "%.1f ")),
+			chart))
+	}
+	return charts, values.String()
+}
+
+// generateReport renders a run's report in the preferred units
+func (h *History) generateReport(runID int64) (string, error) {
+	database, err := openResults(h.dbPath)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = database.Close() }()
+
+	generator := report.NewGenerator(database)
+	generator.SetUnits(preferredUnits())
+	return generator.GenerateHTML(runID)
+}
+
+// openReport writes a run's report to a temporary file and opens it in the
+// browser
+func (h *History) openReport(run *db.Run, window fyne.Window) {
+	html, err := h.generateReport(run.ID)
+	if err != nil {
+		dialog.ShowError(err, window)
+		return
+	}
+	f, err := os.CreateTemp("", fmt.Sprintf("fire-run-%d-*.html", run.ID))
+	if err != nil {
+		dialog.ShowError(err, window)
+		return
+	}
+	if _, err := f.WriteString(html); err != nil {
+		_ = f.Close()
+		dialog.ShowError(err, window)
+		return
+	}
+	if err := f.Close(); err != nil {
+		dialog.ShowError(err, window)
+		return
+	}
+	openPath(fyne.CurrentApp(), f.Name())
+}
+
+// saveReport saves a run's report as HTML, or as PDF through Chrome
+func (h *History) saveReport(run *db.Run, ext string, window fyne.Window) {
+	saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		if writer == nil {
+			return
+		}
+
+		if ext == ".html" {
+			defer func() { _ = writer.Close() }()
+			html, err := h.generateReport(run.ID)
+			if err == nil {
+				_, err = writer.Write([]byte(html))
+			}
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			dialog.ShowInformation("Report Saved", fmt.Sprintf("Saved the report of run #%d to %s", run.ID, writer.URI().Name()), window)
+			return
+		}
+
+		// Chrome prints the PDF to the path, which takes a few seconds
+		path := writer.URI().Path()
+		_ = writer.Close()
+		progress := dialog.NewCustomWithoutButtons("Saving PDF", widget.NewProgressBarInfinite(), window)
+		progress.Show()
+		go func() {
+			err := h.savePDF(run.ID, path)
+			fyne.Do(func() {
+				progress.Hide()
+				if err != nil {
+					dialog.ShowError(err, window)
+					return
+				}
+				dialog.ShowInformation("Report Saved", fmt.Sprintf("Saved the report of run #%d to %s", run.ID, path), window)
+			})
+		}()
+	}, window)
+	saveDialog.SetFileName(fmt.Sprintf("fire-run-%d%s", run.ID, ext))
+	saveDialog.SetFilter(storage.NewExtensionFileFilter([]string{ext}))
+	saveDialog.Show()
+}
+
+// savePDF prints a run's report to a PDF file
+func (h *History) savePDF(runID int64, path string) error {
+	database, err := openResults(h.dbPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = database.Close() }()
+
+	generator := report.NewGenerator(database)
+	generator.SetUnits(preferredUnits())
+	return generator.QuickPDF(runID, path)
+}
+
+// exportResults saves a run's results as CSV, or as JSON when the file name
+// ends in .json
+func (h *History) exportResults(run *db.Run, window fyne.Window) {
+	saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer func() { _ = writer.Close() }()
+
+		database, err := openResults(h.dbPath)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		defer func() { _ = database.Close() }()
+
+		if strings.EqualFold(writer.URI().Extension(), ".json") {
+			err = database.ExportJSON(writer, run.ID)
+		} else {
+			err = database.ExportCSV(writer, run.ID)
+		}
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		dialog.ShowInformation("Results Exported", fmt.Sprintf("Saved the results of run #%d to %s", run.ID, writer.URI().Name()), window)
+	}, window)
+	saveDialog.SetFileName(fmt.Sprintf("fire-run-%d.csv", run.ID))
+	saveDialog.SetFilter(storage.NewExtensionFileFilter([]string{".csv", ".json"}))
+	saveDialog.Show()
 }
@@ -0,0 +1,40 @@
+package gui
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+func TestParseHistorySearch(t *testing.T) {
+	tags, search := parseHistorySearch("customer=acme psu  rma= =x")
+	if want := map[string]string{"customer": "acme", "rma": ""}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("expected tags %v, got %v", want, tags)
+	}
+	if search != "psu =x" {
+		t.Errorf("expected the other words to be searched, got %q", search)
+	}
+	if tags, search := parseHistorySearch("  "); tags != nil || search != "" {
+		t.Errorf("expected no filter, got %v %q", tags, search)
+	}
+}
+
+func TestRunStatus(t *testing.T) {
+	end := time.Now()
+	for _, tc := range []struct {
+		run  db.Run
+		want string
+	}{
+		{db.Run{}, "● Running"},
+		{db.Run{EndTime: &end, Success: true}, "✓ Passed"},
+		{db.Run{EndTime: &end, Interrupted: true}, "⚠ Interrupted"},
+		{db.Run{EndTime: &end}, "✗ Failed"},
+	} {
+		run := tc.run
+		if got := runStatus(&run); got != tc.want {
+			t.Errorf("expected %q for %+v, got %q", tc.want, tc.run, got)
+		}
+	}
+}
@@ -62,7 +62,7 @@ func LoadIconFromPath(fullPath string) fyne.Resource {
 	// Read file
 	data, err := os.ReadFile(fullPath) // #nosec G304 - fullPath is validated to be within assets/icons directory
 	if err != nil {
-		DebugLog("ERROR", "Failed to load icon %s: %v", fullPath, err)
+		logger.Error("Failed to load icon", "path", fullPath, "error", err)
 		return nil
 	}
 
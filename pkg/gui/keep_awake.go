@@ -0,0 +1,43 @@
+package gui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"github.com/mscrnt/project_fire/pkg/power"
+)
+
+// setupSleepIndicator shows in the sidebar whenever sleep is inhibited
+func (g *FireGUI) setupSleepIndicator() {
+	power.OnChange(func(active bool) {
+		fyne.Do(func() {
+			g.navigation.SetSleepBlocked(active)
+		})
+	})
+	g.navigation.SetSleepBlocked(power.Active())
+}
+
+// setKeepAwake turns keeping the machine awake during session recording on or off
+func (g *FireGUI) setKeepAwake(keepAwake bool) {
+	g.keepAwake = keepAwake
+	g.updateSleepInhibitor()
+}
+
+// updateSleepInhibitor holds a sleep inhibitor while keep-awake is on and the
+// session is being recorded
+func (g *FireGUI) updateSleepInhibitor() {
+	wanted := g.keepAwake && !g.dashboard.recorder.Paused()
+	switch {
+	case wanted && g.releaseSleep == nil:
+		release, err := power.Inhibit("Recording F.I.R.E. session")
+		if err != nil {
+			logger.Warn("Could not prevent sleep", "error", err)
+			g.keepAwake = false
+			dialog.ShowError(err, g.window)
+			return
+		}
+		g.releaseSleep = release
+	case !wanted && g.releaseSleep != nil:
+		g.releaseSleep()
+		g.releaseSleep = nil
+	}
+}
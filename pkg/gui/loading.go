@@ -1,7 +1,7 @@
 package gui
 
 import (
-	"fmt"
+	"context"
 	"image/color"
 	"time"
 
@@ -11,6 +11,8 @@ import (
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+
+	"github.com/mscrnt/project_fire/pkg/inventory"
 )
 
 // Update represents a progress update message
@@ -28,12 +30,12 @@ type StartupTask struct {
 
 // StaticCache holds preloaded component data
 type StaticCache struct {
-	Motherboard    *MotherboardInfo
-	MemoryModules  []MemoryModule
-	GPUs           []GPUInfo
-	StorageDevices []StorageInfo
-	Fans           []FanInfo
-	SysInfo        *SystemInfo
+	Motherboard    *inventory.MotherboardInfo
+	MemoryModules  []inventory.MemoryModule
+	GPUs           []inventory.GPUInfo
+	StorageDevices []inventory.StorageInfo
+	Fans           []inventory.FanInfo
+	SysInfo        *inventory.SystemInfo
 }
 
 // FireProgressBar is a custom progress bar with gradient from blue to fire red
@@ -96,13 +98,13 @@ func (r *fireProgressRenderer) Objects() []fyne.CanvasObject {
 
 // CreateLoadingOverlay creates a loading screen overlay
 func CreateLoadingOverlay() (fyne.CanvasObject, *widget.RichText, *FireProgressBar) {
-	DebugLog("LOADING_UI", "CreateLoadingOverlay called")
+	uiLog.Debug("CreateLoadingOverlay called")
 
 	// Load larger logo
 	logoResource, err := fyne.LoadResourceFromPath("assets/logos/fire_1024.png")
 	var logo *canvas.Image
 	if err != nil {
-		DebugLog("LOADING_UI", "Failed to load 1024 logo, trying 512: "+err.Error())
+		uiLog.Debug("Failed to load 1024 logo, trying 512", "error", err)
 		// Try 512 as fallback
 		logoResource, err = fyne.LoadResourceFromPath("assets/logos/fire_512.png")
 	}
@@ -111,9 +113,9 @@ func CreateLoadingOverlay() (fyne.CanvasObject, *widget.RichText, *FireProgressB
 		logo = canvas.NewImageFromResource(logoResource)
 		logo.FillMode = canvas.ImageFillContain
 		logo.SetMinSize(fyne.NewSize(512, 512)) // Even larger logo
-		DebugLog("LOADING_UI", "Logo loaded successfully, size: 512x512")
+		uiLog.Debug("Logo loaded successfully, size: 512x512")
 	} else {
-		DebugLog("LOADING_UI", "Failed to load any logo: "+err.Error())
+		uiLog.Debug("Failed to load any logo", "error", err)
 	}
 
 	// Create larger title
@@ -147,14 +149,14 @@ func CreateLoadingOverlay() (fyne.CanvasObject, *widget.RichText, *FireProgressB
 	progressBar := NewFireProgressBar()
 	progressBar.SetValue(0)
 
-	DebugLog("LOADING_UI", "Creating fire progress bar with gradient")
+	uiLog.Debug("Creating fire progress bar with gradient")
 
 	// Create a container for the progress bar with fixed size
 	progressContainer := container.NewWithoutLayout(progressBar)
 	progressContainer.Resize(fyne.NewSize(800, 50))
 	progressBar.Resize(fyne.NewSize(800, 50))
 
-	DebugLog("LOADING_UI", "Building content layout")
+	uiLog.Debug("Building content layout")
 
 	// Build the main content
 	var mainContent *fyne.Container
@@ -172,7 +174,7 @@ func CreateLoadingOverlay() (fyne.CanvasObject, *widget.RichText, *FireProgressB
 			container.NewCenter(progressContainer),
 			layout.NewSpacer(),
 		)
-		DebugLog("LOADING_UI", "Content created with logo")
+		uiLog.Debug("Content created with logo")
 	} else {
 		mainContent = container.NewVBox(
 			layout.NewSpacer(),
@@ -186,13 +188,13 @@ func CreateLoadingOverlay() (fyne.CanvasObject, *widget.RichText, *FireProgressB
 			container.NewCenter(progressContainer),
 			layout.NewSpacer(),
 		)
-		DebugLog("LOADING_UI", "Content created without logo")
+		uiLog.Debug("Content created without logo")
 	}
 
 	// Center everything
 	centeredContent := container.NewCenter(mainContent)
 
-	DebugLog("LOADING_UI", "Loading overlay created successfully")
+	uiLog.Debug("Loading overlay created successfully")
 
 	return centeredContent, loadingLabel, progressBar
 }
@@ -200,57 +202,61 @@ func CreateLoadingOverlay() (fyne.CanvasObject, *widget.RichText, *FireProgressB
 // LoadComponentsAsync loads all components in background and sends progress updates
 func LoadComponentsAsync(updates chan<- Update) *StaticCache {
 	cache := &StaticCache{}
+	ctx := context.Background()
+
+	// Start every query at once; the tasks below wait for them in turn
+	inventory.PrefetchStatic()
 
 	tasks := []StartupTask{
 		{Name: "Loading CPU information...", Fn: func() error {
-			DebugLog("STARTUP", "Detecting CPU information...")
+			startupLog.Debug("Detecting CPU information...")
 			start := time.Now()
-			cache.SysInfo, _ = GetSystemInfo()
-			DebugLog("TIMING", fmt.Sprintf("GetSystemInfo took %v", time.Since(start)))
+			cache.SysInfo, _ = inventory.CachedSystemInfo(ctx)
+			perfLog.Debug("GetSystemInfo", "took", time.Since(start))
 			return nil
 		}},
 		{Name: "Loading motherboard details...", Fn: func() error {
-			DebugLog("STARTUP", "Loading motherboard details...")
+			startupLog.Debug("Loading motherboard details...")
 			start := time.Now()
-			cache.Motherboard, _ = GetMotherboardInfo()
-			DebugLog("TIMING", fmt.Sprintf("GetMotherboardInfo took %v", time.Since(start)))
+			cache.Motherboard, _ = inventory.CachedMotherboardInfo(ctx)
+			perfLog.Debug("GetMotherboardInfo", "took", time.Since(start))
 			return nil
 		}},
 		{Name: "Scanning memory modules...", Fn: func() error {
-			DebugLog("STARTUP", "Scanning memory modules...")
+			startupLog.Debug("Scanning memory modules...")
 			start := time.Now()
-			cache.MemoryModules, _ = GetMemoryModules()
-			DebugLog("TIMING", fmt.Sprintf("GetMemoryModules took %v", time.Since(start)))
-			DebugLog("STARTUP", fmt.Sprintf("Loaded %d memory modules", len(cache.MemoryModules)))
+			cache.MemoryModules, _ = inventory.CachedMemoryModules(ctx)
+			perfLog.Debug("GetMemoryModules", "took", time.Since(start))
+			startupLog.Debug("Loaded memory modules", "count", len(cache.MemoryModules))
 			return nil
 		}},
 		{Name: "Detecting graphics cards...", Fn: func() error {
-			DebugLog("STARTUP", "Detecting graphics cards...")
+			startupLog.Debug("Detecting graphics cards...")
 			start := time.Now()
-			cache.GPUs, _ = GetGPUInfo()
-			DebugLog("TIMING", fmt.Sprintf("GetGPUInfo took %v", time.Since(start)))
-			DebugLog("STARTUP", fmt.Sprintf("Loaded %d GPUs", len(cache.GPUs)))
+			cache.GPUs, _ = inventory.CachedGPUInventory(ctx)
+			perfLog.Debug("GetGPUInfo", "took", time.Since(start))
+			startupLog.Debug("Loaded GPUs", "count", len(cache.GPUs))
 			return nil
 		}},
 		{Name: "Scanning storage devices...", Fn: func() error {
-			DebugLog("STARTUP", "Scanning storage devices...")
+			startupLog.Debug("Scanning storage devices...")
 			start := time.Now()
-			devices, err := quickStorageScan()
+			devices, err := quickStorageScan(ctx)
 			if err == nil {
 				cache.StorageDevices = devices
 			}
-			DebugLog("TIMING", fmt.Sprintf("quickStorageScan took %v", time.Since(start)))
+			perfLog.Debug("quickStorageScan", "took", time.Since(start))
 			return nil
 		}},
 		{Name: "Detecting cooling systems...", Fn: func() error {
-			DebugLog("STARTUP", "Detecting cooling systems...")
+			startupLog.Debug("Detecting cooling systems...")
 			start := time.Now()
-			cache.Fans, _ = GetFanInfo()
-			DebugLog("TIMING", fmt.Sprintf("GetFanInfo took %v", time.Since(start)))
+			cache.Fans, _ = inventory.CachedFanInfo(ctx)
+			perfLog.Debug("GetFanInfo", "took", time.Since(start))
 			return nil
 		}},
 		{Name: "Initializing sensor monitoring...", Fn: func() error {
-			DebugLog("STARTUP", "Initializing sensor monitoring...")
+			startupLog.Debug("Initializing sensor monitoring...")
 			time.Sleep(50 * time.Millisecond)
 			return nil
 		}},
@@ -269,7 +275,7 @@ func LoadComponentsAsync(updates chan<- Update) *StaticCache {
 
 		// Execute the task
 		if err := task.Fn(); err != nil {
-			DebugLog("ERROR", fmt.Sprintf("Task '%s' failed: %v", task.Name, err))
+			logger.Error("Task failed", "task", task.Name, "error", err)
 		}
 
 		// Ensure minimum visibility time
@@ -278,22 +284,24 @@ func LoadComponentsAsync(updates chan<- Update) *StaticCache {
 		}
 	}
 
-	DebugLog("STARTUP", fmt.Sprintf("Component loading complete - %d GPUs, %d memory modules",
-		len(cache.GPUs), len(cache.MemoryModules)))
+	startupLog.Debug("Component loading complete", "gpus", len(cache.GPUs), "memory_modules", len(cache.MemoryModules))
 
 	return cache
 }
 
 // quickStorageScan performs a quick scan to get basic storage info
-func quickStorageScan() ([]StorageInfo, error) {
-	DebugLog("STARTUP", "Performing quick storage scan...")
+func quickStorageScan(ctx context.Context) ([]inventory.StorageInfo, error) {
+	startupLog.Debug("Performing quick storage scan...")
 
-	devices, err := GetStorageInfo()
+	cached, err := inventory.CachedStorageInfo(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	// For the quick scan, clear out slow fields like SMART data
+	// For the quick scan, clear out slow fields like SMART data, on a copy
+	// as the probe's result is shared
+	devices := make([]inventory.StorageInfo, len(cached))
+	copy(devices, cached)
 	for i := range devices {
 		devices[i].SMART = nil
 	}
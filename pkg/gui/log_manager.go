@@ -7,12 +7,19 @@ import (
 	"time"
 )
 
-// ClearLogs clears or archives old log files
+// DefaultLogFile is the name of the GUI's log next to the executable
+const DefaultLogFile = "fire-gui.log"
+
+// LogFile is the path of the log the GUI writes, set from -log-file
+var LogFile = GetLogPath(DefaultLogFile)
+
+// ClearLogs archives the logs of the previous session. gui_debug.log and
+// perf.log are only written by older versions.
 func ClearLogs() {
 	logFiles := []string{
 		"gui_debug.log",
 		"perf.log",
-		"fire-gui.log",
+		DefaultLogFile,
 	}
 
 	// Get the directory where the executable is located
@@ -60,14 +67,6 @@ func ClearLogs() {
 		}
 	}
 
-	// Create new empty log files
-	for _, logFile := range logFiles {
-		logPath := filepath.Join(exeDir, logFile)
-		if file, err := os.Create(logPath); err == nil { // nolint:gosec // G304 - controlled filename
-			fmt.Fprintf(file, "# %s - Created %s\n", logFile, time.Now().Format("2006-01-02 15:04:05"))
-			_ = file.Close()
-		}
-	}
 }
 
 // copyFile copies a file from src to dst
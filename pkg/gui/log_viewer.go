@@ -0,0 +1,235 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/mscrnt/project_fire/pkg/logging"
+)
+
+// logViewerRefresh is how often the log viewer checks for new records
+const logViewerRefresh = time.Second
+
+// allComponents is the component select's entry for every component
+const allComponents = "All"
+
+// logColumns are the log viewer's columns
+var logColumns = []struct {
+	title string
+	width float32
+}{
+	{"Time", 110},
+	{"Level", 70},
+	{"Component", 100},
+	{"Message", 420},
+	{"Fields", 500},
+}
+
+// logLevels are the levels the viewer filters by, least severe first
+var logLevels = []struct {
+	label string
+	level slog.Level
+}{
+	{"Debug", logging.LevelDebug},
+	{"Info", logging.LevelInfo},
+	{"Warn", logging.LevelWarn},
+	{"Alert", logging.LevelAlert},
+	{"Error", logging.LevelError},
+}
+
+// logCell returns the text of a log entry's column
+func logCell(e logging.Entry, column int) string {
+	switch column {
+	case 0:
+		return e.Time.Format("15:04:05.000")
+	case 1:
+		return logging.LevelName(e.Level)
+	case 2:
+		return e.Component
+	case 3:
+		return e.Message
+	case 4:
+		return e.Fields
+	}
+	return ""
+}
+
+// logComponents returns the components of the entries, sorted
+func logComponents(entries []logging.Entry) []string {
+	seen := make(map[string]bool)
+	var components []string
+	for _, e := range entries {
+		if e.Component != "" && !seen[e.Component] {
+			seen[e.Component] = true
+			components = append(components, e.Component)
+		}
+	}
+	sort.Strings(components)
+	return components
+}
+
+// ShowLogViewer opens a window listing the latest log records, filtered by
+// level, component and text, and following new records until it is paused
+// or closed
+func ShowLogViewer(app fyne.App) fyne.Window {
+	window := app.NewWindow("F.I.R.E. - Logs")
+	window.Resize(fyne.NewSize(1200, 650))
+
+	var (
+		mu      sync.Mutex
+		rows    []logging.Entry
+		filter  = logging.Filter{Level: logging.Level()}
+		paused  bool
+		lastSeq uint64
+	)
+
+	table := widget.NewTableWithHeaders(
+		func() (int, int) {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(rows), len(logColumns)
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+			mu.Lock()
+			text := ""
+			if id.Row < len(rows) {
+				text = logCell(rows[id.Row], id.Col)
+			}
+			mu.Unlock()
+			o.(*widget.Label).SetText(text)
+		},
+	)
+	table.ShowHeaderColumn = false
+	table.UpdateHeader = func(id widget.TableCellID, o fyne.CanvasObject) {
+		if id.Col >= 0 && id.Col < len(logColumns) {
+			o.(*widget.Label).SetText(logColumns[id.Col].title)
+		}
+	}
+	for i, c := range logColumns {
+		table.SetColumnWidth(i, c.width)
+	}
+
+	status := widget.NewLabel("")
+	componentSelect := widget.NewSelect([]string{allComponents}, nil)
+	componentSelect.SetSelected(allComponents)
+
+	// show lists the buffered records that pass the filter, following the
+	// latest unless paused
+	show := func() {
+		entries := logging.Buffer.Entries()
+		mu.Lock()
+		rows = rows[:0]
+		for _, e := range entries {
+			if filter.Match(e) {
+				rows = append(rows, e)
+			}
+		}
+		shown, follow := len(rows), !paused
+		mu.Unlock()
+
+		componentSelect.Options = append([]string{allComponents}, logComponents(entries)...)
+		componentSelect.Refresh()
+		text := fmt.Sprintf("%d of %d records.", shown, len(entries))
+		if kept := logging.Level(); kept > logging.LevelDebug {
+			text += fmt.Sprintf(" Records below the %s level are not kept; start with -log-level debug to see them.",
+				strings.ToLower(logging.LevelName(kept)))
+		}
+		status.SetText(text)
+		table.Refresh()
+		if follow {
+			table.ScrollToBottom()
+		}
+	}
+
+	// The least severe level kept is selected at first
+	levelLabels := make([]string, len(logLevels))
+	selected := logLevels[0].label
+	for i, l := range logLevels {
+		levelLabels[i] = l.label
+		if l.level <= filter.Level {
+			selected = l.label
+		}
+	}
+	levelSelect := widget.NewSelect(levelLabels, nil)
+	levelSelect.SetSelected(selected)
+	levelSelect.OnChanged = func(string) {
+		mu.Lock()
+		filter.Level = logLevels[levelSelect.SelectedIndex()].level
+		mu.Unlock()
+		show()
+	}
+	componentSelect.OnChanged = func(component string) {
+		mu.Lock()
+		filter.Component = component
+		if component == allComponents {
+			filter.Component = ""
+		}
+		mu.Unlock()
+		show()
+	}
+	search := widget.NewEntry()
+	search.SetPlaceHolder("Search messages and fields")
+	search.OnChanged = func(text string) {
+		mu.Lock()
+		filter.Text = strings.TrimSpace(text)
+		mu.Unlock()
+		show()
+	}
+	pause := widget.NewCheck("Pause", func(on bool) {
+		mu.Lock()
+		paused = on
+		mu.Unlock()
+		if !on {
+			show()
+		}
+	})
+	clearButton := widget.NewButton("Clear", func() {
+		logging.Buffer.Clear()
+		show()
+	})
+
+	toolbar := container.NewBorder(nil, nil,
+		container.NewHBox(widget.NewLabel("Level:"), levelSelect, widget.NewLabel("Component:"), componentSelect),
+		container.NewHBox(pause, clearButton),
+		search)
+	window.SetContent(container.NewBorder(toolbar, status, nil, nil, table))
+	show()
+
+	// New records are picked up once a second while not paused
+	ctx, cancel := context.WithCancel(context.Background())
+	window.SetOnClosed(cancel)
+	go func() {
+		ticker := time.NewTicker(logViewerRefresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			mu.Lock()
+			idle := paused
+			mu.Unlock()
+			if seq := logging.Buffer.Seq(); !idle && seq != lastSeq {
+				lastSeq = seq
+				fyne.Do(show)
+			}
+		}
+	}()
+
+	window.Show()
+	return window
+}
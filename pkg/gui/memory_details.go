@@ -11,14 +11,16 @@ import (
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+
+	"github.com/mscrnt/project_fire/pkg/inventory"
 )
 
 // MemoryDetailsPage shows detailed memory information including SPD data
 type MemoryDetailsPage struct {
 	window       fyne.Window
 	container    *fyne.Container
-	modules      []MemoryModule
-	spdModules   []SPDData
+	modules      []inventory.MemoryModule
+	spdModules   []inventory.SPDData
 	selectedSlot int
 }
 
@@ -36,7 +38,7 @@ func (p *MemoryDetailsPage) CreateContent() fyne.CanvasObject {
 	header := widget.NewLabelWithStyle("Memory Details", fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
 
 	// Get memory modules
-	modules, err := GetMemoryModules()
+	modules, err := inventory.GetMemoryModules()
 	if err != nil {
 		log.Printf("Error getting memory modules: %v", err)
 	}
@@ -54,7 +56,7 @@ func (p *MemoryDetailsPage) CreateContent() fyne.CanvasObject {
 
 	// SPD data button (Windows only with admin)
 	var spdButton *widget.Button
-	if runtime.GOOS == "windows" && IsRunningAsAdmin() {
+	if runtime.GOOS == "windows" && inventory.IsRunningAsAdmin() {
 		spdButton = widget.NewButtonWithIcon("Read SPD Data", theme.InfoIcon(), func() {
 			p.readSPDData()
 		})
@@ -196,7 +198,7 @@ func (p *MemoryDetailsPage) readSPDData() {
 		defer progressDialog.Hide()
 
 		// Create SPD reader
-		reader := NewSPDReader()
+		reader := inventory.NewSPDReader()
 		defer reader.Close()
 
 		if err := reader.Initialize(); err != nil {
@@ -25,6 +25,9 @@ type MetricBar struct {
 	barColor color.Color
 	showBar  bool
 
+	unavailable string // Why the metric cannot be read; shown as N/A
+	detail      string // Extra tooltip lines, e.g. per-core readings
+
 	// Tooltip data
 	minValue     float64
 	maxValue     float64
@@ -55,9 +58,10 @@ func NewMetricBar(label string, barColor color.Color, showBar bool) *MetricBar {
 // SetValue updates the metric value
 func (m *MetricBar) SetValue(value float64, unit string, altValue float64, altUnit string) {
 	// Only update and refresh if value has changed
-	if m.value == value && m.altValue == altValue && m.unit == unit && m.altUnit == altUnit {
+	if m.unavailable == "" && m.value == value && m.altValue == altValue && m.unit == unit && m.altUnit == altUnit {
 		return
 	}
+	m.unavailable = ""
 
 	m.prevValue = m.value
 	m.prevAltValue = m.altValue
@@ -158,6 +162,22 @@ func (m *MetricBar) updateBarColor() {
 	}
 }
 
+// SetUnavailable shows the metric as N/A, with the reason in the tooltip,
+// until the next SetValue
+func (m *MetricBar) SetUnavailable(reason string) {
+	if m.unavailable == reason {
+		return
+	}
+	m.unavailable = reason
+	m.value = 0
+	m.Refresh()
+}
+
+// SetDetail sets extra lines shown in the tooltip below the history
+func (m *MetricBar) SetDetail(detail string) {
+	m.detail = detail
+}
+
 // SetMax sets the maximum value for the bar
 func (m *MetricBar) SetMax(maxValue float64) {
 	m.max = maxValue
@@ -226,7 +246,7 @@ func (m *MetricBar) showTooltip(event *desktop.MouseEvent) {
 		case "Temp":
 			metricName = "CPU Die (average)"
 		case "Voltage":
-			metricName = "Core 0 VID"
+			metricName = "CPU Core Voltage"
 		case "Power":
 			metricName = "CPU Package Power"
 		case "Usage":
@@ -319,26 +339,18 @@ func (m *MetricBar) updateTooltipContent() {
 // buildTooltipContent creates the tooltip text
 func (m *MetricBar) buildTooltipContent() string {
 	var content strings.Builder
-
-	// Format value based on unit type for cleaner display
-	formatValue := func(val float64, unit string) string {
-		switch unit {
-		case "V":
-			return fmt.Sprintf("%.3f %s", val, unit)
-		case "MHz", "MB":
-			return fmt.Sprintf("%.0f %s", val, unit)
-		case "°C", "°F", "%", "W", "GHz":
-			return fmt.Sprintf("%.1f %s", val, unit)
-		default:
-			return fmt.Sprintf("%.1f %s", val, unit)
-		}
+	if m.unavailable != "" {
+		return "Unavailable: " + m.unavailable
 	}
 
+	formatValue := formatMetricValue
+
 	// Current value
-	content.WriteString(fmt.Sprintf("Current: %s\n", formatValue(m.value, m.unit)))
+	current := formatValue(m.value, m.unit)
+	content.WriteString(fmt.Sprintf("Current: %s\n", current))
 
-	// Add alternative unit if available (e.g., Fahrenheit)
-	if m.altValue != 0 && m.altUnit != "" {
+	// Add alternative unit if available and not already shown (e.g., Fahrenheit)
+	if m.altValue != 0 && m.altUnit != "" && !strings.HasSuffix(current, m.altUnit) {
 		content.WriteString(fmt.Sprintf("         %s\n", formatValue(m.altValue, m.altUnit)))
 	}
 
@@ -349,6 +361,10 @@ func (m *MetricBar) buildTooltipContent() string {
 		content.WriteString(fmt.Sprintf("Max: %s\n", formatValue(m.maxValue, m.unit)))
 	}
 
+	if m.detail != "" {
+		content.WriteString("\n" + m.detail + "\n")
+	}
+
 	// Add status based on current value
 	content.WriteString("\nStatus: ")
 	switch m.label {
@@ -402,6 +418,20 @@ func (m *MetricBar) buildTooltipContent() string {
 	return content.String()
 }
 
+// formatMetricValue formats a reading in the preferred unit, with the
+// precision that unit is shown with
+func formatMetricValue(value float64, unit string) string {
+	value, unit = convertReading(value, unit)
+	switch unit {
+	case "V":
+		return fmt.Sprintf("%.3f %s", value, unit)
+	case "MHz", "MB", "Mbit/s":
+		return fmt.Sprintf("%.0f %s", value, unit)
+	default:
+		return fmt.Sprintf("%.1f %s", value, unit)
+	}
+}
+
 // CreateRenderer creates the widget renderer
 func (m *MetricBar) CreateRenderer() fyne.WidgetRenderer {
 	// Value text only - no label
@@ -481,18 +511,12 @@ func (r *metricBarRenderer) MinSize() fyne.Size {
 func (r *metricBarRenderer) Refresh() {
 	// Update value text
 	var text string
-	if r.metric.value == 0 && r.metric.unit != "°C" && r.metric.unit != "V" {
+	if r.metric.unavailable != "" {
+		text = "N/A"
+	} else if r.metric.value == 0 && r.metric.unit != "°C" && r.metric.unit != "V" {
 		text = fmt.Sprintf("-- %s", r.metric.unit)
 	} else {
-		// Format based on unit type
-		switch r.metric.unit {
-		case "V":
-			text = fmt.Sprintf("%.3f %s", r.metric.value, r.metric.unit)
-		case "MHz", "MB":
-			text = fmt.Sprintf("%.0f %s", r.metric.value, r.metric.unit)
-		default:
-			text = fmt.Sprintf("%.1f %s", r.metric.value, r.metric.unit)
-		}
+		text = formatMetricValue(r.metric.value, r.metric.unit)
 	}
 	r.valueText.SetText(text)
 
@@ -0,0 +1,601 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/mscrnt/project_fire/pkg/config"
+	"github.com/mscrnt/project_fire/pkg/monitor"
+	"github.com/mscrnt/project_fire/pkg/profile"
+)
+
+const (
+	// monitoringColumns is the width of the monitoring grid in tiles
+	monitoringColumns = profile.MaxTileWidth
+
+	// monitoringRowHeight is the height of one grid row
+	monitoringRowHeight = 170
+
+	// monitoringChartPoints is how many samples a chart tile keeps
+	monitoringChartPoints = 120
+)
+
+// monitoringPresets are the layouts offered on the monitoring page. The
+// first is shown until the operator changes it.
+var monitoringPresets = []struct {
+	name  string
+	tiles []profile.Tile
+}{
+	{"Thermals", []profile.Tile{
+		{Kind: profile.TileChart, Channels: []string{"cpu_temp"}, Width: 2, Height: 2},
+		{Kind: profile.TileNumber, Channels: []string{"cpu_temp"}, Width: 1, Height: 1},
+		{Kind: profile.TileNumber, Channels: []string{"gpu0_temp"}, Width: 1, Height: 1},
+		{Kind: profile.TileGauge, Channels: []string{"vrm_temp"}, Width: 1, Height: 1},
+		{Kind: profile.TileGauge, Channels: []string{"memory_temp"}, Width: 1, Height: 1},
+		{Kind: profile.TileChart, Channels: []string{"gpu0_temp"}, Width: 2, Height: 2},
+		{Title: "Fans", Kind: profile.TileTable, Channels: []string{"fan_*"}, Width: 2, Height: 2},
+	}},
+	{"Power", []profile.Tile{
+		{Kind: profile.TileChart, Channels: []string{"cpu_power"}, Width: 2, Height: 2},
+		{Kind: profile.TileChart, Channels: []string{"gpu0_power"}, Width: 2, Height: 2},
+		{Kind: profile.TileNumber, Channels: []string{"system_power"}, Width: 1, Height: 1},
+		{Kind: profile.TileNumber, Channels: []string{"cpu_voltage"}, Width: 1, Height: 1},
+		{Kind: profile.TileGauge, Channels: []string{"cpu_usage"}, Width: 1, Height: 1},
+		{Kind: profile.TileGauge, Channels: []string{"gpu0_usage"}, Width: 1, Height: 1},
+		{Title: "Power draw", Kind: profile.TileTable, Channels: []string{"*_power"}, Width: 4, Height: 1},
+	}},
+	{"Storage I/O", []profile.Tile{
+		{Title: "Disk reads", Kind: profile.TileChart, Channels: []string{"disk_*_read"}, Width: 2, Height: 2},
+		{Title: "Disk writes", Kind: profile.TileChart, Channels: []string{"disk_*_write"}, Width: 2, Height: 2},
+		{Title: "Disk busy", Kind: profile.TileGauge, Channels: []string{"disk_*_busy"}, Width: 2, Height: 1},
+		{Kind: profile.TileGauge, Channels: []string{"memory_usage"}, Width: 2, Height: 1},
+		{Title: "Disks", Kind: profile.TileTable, Channels: []string{"disk_*"}, Width: 4, Height: 2},
+	}},
+	{"Network", []profile.Tile{
+		{Title: "Download", Kind: profile.TileChart, Channels: []string{"net_*_down"}, Width: 2, Height: 2},
+		{Title: "Upload", Kind: profile.TileChart, Channels: []string{"net_*_up"}, Width: 2, Height: 2},
+		{Title: "Interfaces", Kind: profile.TileTable, Channels: []string{"net_*"}, Width: 4, Height: 2},
+	}},
+}
+
+// tileKinds are the tile kinds offered when adding a tile, with their titles
+var tileKinds = []struct {
+	kind  string
+	title string
+}{
+	{profile.TileChart, "Line chart"},
+	{profile.TileGauge, "Gauge"},
+	{profile.TileNumber, "Number"},
+	{profile.TileTable, "Table"},
+}
+
+// MonitoringPage shows live sensor readings in a grid of tiles the operator
+// arranges. Sensors are only read while the page is shown.
+type MonitoringPage struct {
+	window   fyne.Window
+	onChange func([]profile.Tile)
+
+	content fyne.CanvasObject
+	grid    *fyne.Container
+	layout  *tileGrid
+	presets *widget.Select
+
+	tiles []profile.Tile
+	views []*tileView
+
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+	channels []string // Channels of the latest sample, sorted
+}
+
+// NewMonitoringPage creates the monitoring page showing tiles; onChange is
+// called with the tiles whenever the operator changes them
+func NewMonitoringPage(window fyne.Window, tiles []profile.Tile, onChange func([]profile.Tile)) *MonitoringPage {
+	p := &MonitoringPage{window: window, onChange: onChange}
+	p.layout = &tileGrid{}
+	p.grid = container.New(p.layout)
+
+	var names []string
+	for _, preset := range monitoringPresets {
+		names = append(names, preset.name)
+	}
+	p.presets = widget.NewSelect(names, p.loadPreset)
+	p.presets.PlaceHolder = "Load preset..."
+
+	addBtn := widget.NewButtonWithIcon("Add Tile", theme.ContentAddIcon(), p.showAddTile)
+	toolbar := container.NewHBox(
+		widget.NewLabelWithStyle("Monitoring", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		layout.NewSpacer(),
+		p.presets,
+		addBtn,
+	)
+
+	p.content = container.NewBorder(
+		container.NewVBox(toolbar, widget.NewSeparator()),
+		nil, nil, nil,
+		container.NewVScroll(container.NewPadded(p.grid)),
+	)
+	p.SetTiles(tiles)
+	return p
+}
+
+// Content returns the page
+func (p *MonitoringPage) Content() fyne.CanvasObject {
+	return p.content
+}
+
+// SetTiles shows tiles, or the first preset when there are none, e.g. when
+// another profile is selected
+func (p *MonitoringPage) SetTiles(tiles []profile.Tile) {
+	if len(tiles) == 0 {
+		tiles = monitoringPresets[0].tiles
+	}
+	p.tiles = append([]profile.Tile(nil), tiles...)
+	p.rebuild()
+}
+
+// Tiles returns the tiles shown
+func (p *MonitoringPage) Tiles() []profile.Tile {
+	return append([]profile.Tile(nil), p.tiles...)
+}
+
+// SetVisible starts reading the sensors when the page is shown and stops
+// when it is hidden
+func (p *MonitoringPage) SetVisible(visible bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !visible {
+		if p.cancel != nil {
+			p.cancel()
+			p.cancel = nil
+		}
+		return
+	}
+	if p.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	go p.sample(ctx)
+}
+
+// sample reads the sensors at the dashboard's update interval into the
+// tiles until ctx ends
+func (p *MonitoringPage) sample(ctx context.Context) {
+	interval := config.DefaultUpdateInterval
+	if settings, err := config.Load(); err == nil {
+		interval = settings.GUI.Interval()
+	}
+	poller := monitor.NewPoller()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if sample, err := poller.Sample(ctx); err == nil {
+			values, units := sample.Metrics()
+			channels := make([]string, 0, len(values))
+			for name := range values {
+				channels = append(channels, name)
+			}
+			sort.Strings(channels)
+			p.mu.Lock()
+			p.channels = channels
+			p.mu.Unlock()
+
+			fyne.Do(func() {
+				for _, v := range p.views {
+					v.update(channels, values, units)
+				}
+			})
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// changed shows and saves the tiles after the operator changed them
+func (p *MonitoringPage) changed() {
+	p.rebuild()
+	if p.onChange != nil {
+		p.onChange(p.Tiles())
+	}
+}
+
+// rebuild creates the tile views
+func (p *MonitoringPage) rebuild() {
+	p.views = nil
+	p.grid.Objects = nil
+	p.layout.tiles = p.tiles
+	for i := range p.tiles {
+		v := newTileView(p.tiles[i])
+		p.views = append(p.views, v)
+
+		index := i
+		var menuBtn *widget.Button
+		menuBtn = widget.NewButtonWithIcon("", theme.MoreVerticalIcon(), func() {
+			p.showTileMenu(index, menuBtn)
+		})
+		menuBtn.Importance = widget.LowImportance
+
+		header := container.NewBorder(nil, nil, nil, menuBtn,
+			widget.NewLabelWithStyle(tileTitle(p.tiles[i]), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		bg := canvas.NewRectangle(ColorCardBackground)
+		bg.CornerRadius = 4
+		p.grid.Add(container.NewPadded(container.NewStack(bg, container.NewPadded(
+			container.NewBorder(header, nil, nil, nil, v.content),
+		))))
+	}
+	p.grid.Refresh()
+}
+
+// showTileMenu offers to resize, move or remove the tile at index
+func (p *MonitoringPage) showTileMenu(index int, anchor fyne.CanvasObject) {
+	tile := &p.tiles[index]
+	resize := func(width, height int) func() {
+		return func() {
+			tile.Width = min(max(tile.Width+width, 1), profile.MaxTileWidth)
+			tile.Height = min(max(tile.Height+height, 1), profile.MaxTileHeight)
+			p.changed()
+		}
+	}
+	move := func(by int) func() {
+		return func() {
+			if to := index + by; to >= 0 && to < len(p.tiles) {
+				p.tiles[index], p.tiles[to] = p.tiles[to], p.tiles[index]
+				p.changed()
+			}
+		}
+	}
+	wider := fyne.NewMenuItem("Wider", resize(1, 0))
+	wider.Disabled = tile.Width >= profile.MaxTileWidth
+	narrower := fyne.NewMenuItem("Narrower", resize(-1, 0))
+	narrower.Disabled = tile.Width <= 1
+	taller := fyne.NewMenuItem("Taller", resize(0, 1))
+	taller.Disabled = tile.Height >= profile.MaxTileHeight
+	shorter := fyne.NewMenuItem("Shorter", resize(0, -1))
+	shorter.Disabled = tile.Height <= 1
+	earlier := fyne.NewMenuItem("Move Back", move(-1))
+	earlier.Disabled = index == 0
+	later := fyne.NewMenuItem("Move Forward", move(1))
+	later.Disabled = index == len(p.tiles)-1
+
+	menu := fyne.NewMenu("",
+		wider, narrower, taller, shorter,
+		fyne.NewMenuItemSeparator(),
+		earlier, later,
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Remove", func() {
+			p.tiles = append(p.tiles[:index], p.tiles[index+1:]...)
+			p.changed()
+		}),
+	)
+	c := fyne.CurrentApp().Driver().CanvasForObject(anchor)
+	pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(anchor).AddXY(0, anchor.Size().Height)
+	widget.ShowPopUpMenuAtPosition(menu, c, pos)
+}
+
+// loadPreset replaces the tiles with the named preset once confirmed
+func (p *MonitoringPage) loadPreset(name string) {
+	if name == "" {
+		return
+	}
+	defer p.presets.ClearSelected()
+	for _, preset := range monitoringPresets {
+		if preset.name != name {
+			continue
+		}
+		tiles := preset.tiles
+		dialog.ShowConfirm("Load Preset", fmt.Sprintf("Replace the tiles with the %s preset?", name), func(ok bool) {
+			if ok {
+				p.tiles = append([]profile.Tile(nil), tiles...)
+				p.changed()
+			}
+		}, p.window)
+	}
+}
+
+// showAddTile asks for a new tile's kind, channels and size
+func (p *MonitoringPage) showAddTile() {
+	p.mu.Lock()
+	channels := p.channels
+	p.mu.Unlock()
+
+	var kinds []string
+	for _, k := range tileKinds {
+		kinds = append(kinds, k.title)
+	}
+	kind := widget.NewSelect(kinds, nil)
+	kind.SetSelectedIndex(0)
+	channel := widget.NewSelectEntry(channels)
+	channel.SetPlaceHolder("e.g. cpu_temp or fan_*")
+	title := widget.NewEntry()
+	title.SetPlaceHolder("Named after the channel")
+	sizes := func(n int) []string {
+		var s []string
+		for i := 1; i <= n; i++ {
+			s = append(s, fmt.Sprint(i))
+		}
+		return s
+	}
+	width := widget.NewSelect(sizes(profile.MaxTileWidth), nil)
+	width.SetSelected("1")
+	height := widget.NewSelect(sizes(profile.MaxTileHeight), nil)
+	height.SetSelected("1")
+
+	hint := widget.NewLabel("Separate channels with commas; * matches any part of a name. Tables show every match, the other tiles the first.")
+	hint.Wrapping = fyne.TextWrapWord
+	hint.Importance = widget.LowImportance
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Tile", kind),
+		widget.NewFormItem("Sensor channel", channel),
+		widget.NewFormItem("", hint),
+		widget.NewFormItem("Title", title),
+		widget.NewFormItem("Width", width),
+		widget.NewFormItem("Height", height),
+	}
+	d := dialog.NewForm("Add Tile", "Add", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		tile := profile.Tile{
+			Title:  strings.TrimSpace(title.Text),
+			Kind:   tileKinds[kind.SelectedIndex()].kind,
+			Width:  width.SelectedIndex() + 1,
+			Height: height.SelectedIndex() + 1,
+		}
+		for _, c := range strings.Split(channel.Text, ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				tile.Channels = append(tile.Channels, c)
+			}
+		}
+		if err := tile.Validate(); err != nil {
+			dialog.ShowError(err, p.window)
+			return
+		}
+		p.tiles = append(p.tiles, tile)
+		p.changed()
+	}, p.window)
+	d.Resize(fyne.NewSize(480, 0))
+	d.Show()
+}
+
+// matchChannels returns the channels matching any of the patterns, in order
+func matchChannels(patterns, channels []string) []string {
+	var matched []string
+	for _, c := range channels {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, c); ok {
+				matched = append(matched, c)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// channelTitle names a sensor channel, e.g. "gpu0_temp" becomes "GPU0 temp"
+func channelTitle(channel string) string {
+	words := strings.Split(channel, "_")
+	for i, w := range words {
+		for _, acronym := range []string{"cpu", "gpu", "vrm"} {
+			if strings.HasPrefix(w, acronym) {
+				w = strings.ToUpper(acronym) + w[len(acronym):]
+			}
+		}
+		words[i] = w
+	}
+	title := strings.Join(words, " ")
+	return strings.ToUpper(title[:1]) + title[1:]
+}
+
+// tileTitle returns the title shown on a tile
+func tileTitle(t profile.Tile) string {
+	if t.Title != "" {
+		return t.Title
+	}
+	return channelTitle(t.Channels[0])
+}
+
+// tileView shows the readings of one tile
+type tileView struct {
+	tile    profile.Tile
+	content fyne.CanvasObject
+
+	chart  *EnhancedLineChart
+	unit   string
+	gauge  *MetricBar
+	number *canvas.Text
+	table  *fyne.Container
+	rows   []string
+	labels map[string]*widget.Label
+}
+
+// newTileView creates the widgets of a tile
+func newTileView(t profile.Tile) *tileView {
+	v := &tileView{tile: t}
+	switch t.Kind {
+	case profile.TileChart:
+		v.chart = NewEnhancedLineChart("", monitoringChartPoints, 0)
+		v.chart.SetShowDataPoints(false)
+		v.content = v.chart
+	case profile.TileGauge:
+		v.gauge = NewMetricBar(tileTitle(t), ColorCPUUsage, true)
+		v.gauge.SetUnavailable("No reading")
+		v.content = container.NewVBox(layout.NewSpacer(), v.gauge, layout.NewSpacer())
+	case profile.TileNumber:
+		v.number = canvas.NewText("-", ColorSunset)
+		v.number.TextSize = 32
+		v.number.TextStyle = fyne.TextStyle{Bold: true}
+		v.content = container.NewCenter(v.number)
+	default:
+		v.table = container.NewGridWithColumns(2)
+		v.labels = make(map[string]*widget.Label)
+		v.content = container.NewVScroll(v.table)
+	}
+	return v
+}
+
+// update shows the latest readings
+func (v *tileView) update(channels []string, values map[string]float64, units map[string]string) {
+	matched := matchChannels(v.tile.Channels, channels)
+	if v.table != nil {
+		v.updateTable(matched, values, units)
+		return
+	}
+	if len(matched) == 0 {
+		if v.gauge != nil {
+			v.gauge.SetUnavailable("No reading")
+		}
+		if v.number != nil && v.number.Text != "-" {
+			v.number.Text = "-"
+			v.number.Refresh()
+		}
+		return
+	}
+
+	channel := matched[0]
+	value, unit := values[channel], units[channel]
+	switch {
+	case v.chart != nil:
+		if unit != v.unit {
+			v.unit = unit
+			v.chart.SetUnit(unit)
+			if unit == "%" {
+				v.chart.SetRange(0, 100)
+			}
+		}
+		v.chart.AddValue(value)
+	case v.gauge != nil:
+		v.gauge.SetMax(gaugeMax(unit, value))
+		v.gauge.SetValue(value, unit, 0, "")
+	case v.number != nil:
+		v.number.Text = formatMetricValue(value, unit)
+		v.number.Refresh()
+	}
+}
+
+// updateTable shows a row per matched channel, rebuilding the rows when the
+// channels change
+func (v *tileView) updateTable(matched []string, values map[string]float64, units map[string]string) {
+	if strings.Join(matched, ",") != strings.Join(v.rows, ",") {
+		v.rows = matched
+		v.table.Objects = nil
+		v.labels = make(map[string]*widget.Label, len(matched))
+		for _, channel := range matched {
+			label := widget.NewLabel("")
+			v.labels[channel] = label
+			v.table.Add(widget.NewLabel(channelTitle(channel)))
+			v.table.Add(label)
+		}
+		if len(matched) == 0 {
+			v.table.Add(widget.NewLabel("No reading"))
+		}
+		v.table.Refresh()
+	}
+	for _, channel := range matched {
+		v.labels[channel].SetText(formatMetricValue(values[channel], units[channel]))
+	}
+}
+
+// gaugeMax returns the full scale of a gauge: 100 for percentages and
+// temperatures, otherwise the next round number above the reading
+func gaugeMax(unit string, value float64) float64 {
+	switch unit {
+	case "%", "°C":
+		return 100
+	}
+	scale := 10.0
+	for scale < value {
+		scale *= 10
+	}
+	if scale/2 >= value {
+		scale /= 2
+	}
+	return scale
+}
+
+// tileGrid lays tiles out in a grid of monitoringColumns columns, each tile
+// spanning its width and height in cells
+type tileGrid struct {
+	tiles []profile.Tile
+}
+
+// tileCell is where a tile is placed, in grid cells
+type tileCell struct {
+	col, row, width, height int
+}
+
+// packTiles places tiles in order in the first free cells that fit them,
+// scanning rows from the top, and returns the cells and the number of rows
+func packTiles(tiles []profile.Tile, columns int) ([]tileCell, int) {
+	var used [][]bool
+	free := func(row, col, width, height int) bool {
+		for r := row; r < row+height; r++ {
+			for c := col; c < col+width; c++ {
+				if r < len(used) && used[r][c] {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	cells := make([]tileCell, len(tiles))
+	for i, t := range tiles {
+		width, height := min(max(t.Width, 1), columns), max(t.Height, 1)
+		placed := false
+		for row := 0; !placed; row++ {
+			for col := 0; col+width <= columns && !placed; col++ {
+				if !free(row, col, width, height) {
+					continue
+				}
+				for len(used) < row+height {
+					used = append(used, make([]bool, columns))
+				}
+				for r := row; r < row+height; r++ {
+					for c := col; c < col+width; c++ {
+						used[r][c] = true
+					}
+				}
+				cells[i] = tileCell{col, row, width, height}
+				placed = true
+			}
+		}
+	}
+	return cells, len(used)
+}
+
+// Layout places the tiles
+func (g *tileGrid) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	cells, _ := packTiles(g.tiles, monitoringColumns)
+	cellWidth := size.Width / monitoringColumns
+	for i, o := range objects {
+		if i >= len(cells) {
+			break
+		}
+		c := cells[i]
+		o.Move(fyne.NewPos(float32(c.col)*cellWidth, float32(c.row*monitoringRowHeight)))
+		o.Resize(fyne.NewSize(float32(c.width)*cellWidth, float32(c.height*monitoringRowHeight)))
+	}
+}
+
+// MinSize returns the height of the rows
+func (g *tileGrid) MinSize([]fyne.CanvasObject) fyne.Size {
+	_, rows := packTiles(g.tiles, monitoringColumns)
+	return fyne.NewSize(monitoringColumns*100, float32(rows*monitoringRowHeight))
+}
@@ -0,0 +1,101 @@
+package gui
+
+import (
+	"reflect"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+	"github.com/mscrnt/project_fire/pkg/profile"
+)
+
+func TestPackTiles(t *testing.T) {
+	tiles := []profile.Tile{
+		{Width: 2, Height: 2},
+		{Width: 1, Height: 1},
+		{Width: 1, Height: 1},
+		{Width: 1, Height: 1},
+		{Width: 4, Height: 1},
+		{Width: 1, Height: 1},
+	}
+	cells, rows := packTiles(tiles, 4)
+	want := []tileCell{
+		{0, 0, 2, 2},
+		{2, 0, 1, 1},
+		{3, 0, 1, 1},
+		{2, 1, 1, 1},
+		{0, 2, 4, 1},
+		{3, 1, 1, 1},
+	}
+	if !reflect.DeepEqual(cells, want) || rows != 3 {
+		t.Errorf("got %v in %d rows, want %v in 3", cells, rows, want)
+	}
+}
+
+func TestMatchChannels(t *testing.T) {
+	channels := []string{"cpu_power", "cpu_temp", "disk_sda_read", "disk_sda_write", "fan_cpu_fan", "gpu0_power"}
+	if got := matchChannels([]string{"*_power"}, channels); !reflect.DeepEqual(got, []string{"cpu_power", "gpu0_power"}) {
+		t.Errorf("unexpected power channels %v", got)
+	}
+	if got := matchChannels([]string{"fan_*", "cpu_temp"}, channels); !reflect.DeepEqual(got, []string{"cpu_temp", "fan_cpu_fan"}) {
+		t.Errorf("unexpected channels %v", got)
+	}
+	if got := matchChannels([]string{"gpu1_temp"}, channels); got != nil {
+		t.Errorf("expected no match, got %v", got)
+	}
+}
+
+func TestChannelTitle(t *testing.T) {
+	for channel, want := range map[string]string{
+		"cpu_temp":     "CPU temp",
+		"gpu0_power":   "GPU0 power",
+		"fan_cpu_fan":  "Fan CPU fan",
+		"memory_usage": "Memory usage",
+	} {
+		if got := channelTitle(channel); got != want {
+			t.Errorf("channelTitle(%q) = %q, want %q", channel, got, want)
+		}
+	}
+}
+
+func TestGaugeMax(t *testing.T) {
+	for _, tt := range []struct {
+		unit  string
+		value float64
+		want  float64
+	}{
+		{"%", 250, 100},
+		{"W", 65, 100},
+		{"W", 320, 500},
+		{"MB/s", 3, 5},
+	} {
+		if got := gaugeMax(tt.unit, tt.value); got != tt.want {
+			t.Errorf("gaugeMax(%q, %v) = %v, want %v", tt.unit, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestMonitoringPageDefaultsToThermals(t *testing.T) {
+	test.NewTempApp(t)
+
+	var saved []profile.Tile
+	p := NewMonitoringPage(test.NewWindow(nil), nil, func(tiles []profile.Tile) { saved = tiles })
+	if len(p.Tiles()) != len(monitoringPresets[0].tiles) {
+		t.Fatalf("expected the thermals preset, got %d tiles", len(p.Tiles()))
+	}
+
+	channels := []string{"cpu_temp", "fan_cpu_fan", "fan_system_fan"}
+	values := map[string]float64{"cpu_temp": 61.5, "fan_cpu_fan": 1200, "fan_system_fan": 800}
+	units := map[string]string{"cpu_temp": "°C", "fan_cpu_fan": "RPM", "fan_system_fan": "RPM"}
+	for _, v := range p.views {
+		v.update(channels, values, units)
+	}
+	if fans := p.views[len(p.views)-1]; len(fans.rows) != 2 {
+		t.Errorf("expected a row per fan, got %v", fans.rows)
+	}
+
+	p.tiles = p.tiles[:1]
+	p.changed()
+	if len(saved) != 1 || len(p.views) != 1 {
+		t.Errorf("expected one tile saved and shown, got %d and %d", len(saved), len(p.views))
+	}
+}
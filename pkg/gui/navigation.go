@@ -11,6 +11,7 @@ import (
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+	"github.com/mscrnt/project_fire/pkg/i18n"
 )
 
 // NavigationButton represents a button in the vertical navigation
@@ -224,13 +225,20 @@ type NavigationSidebar struct {
 	collapsed            bool
 	collapseBtn          *widget.Button
 	collapseBtnContainer *fyne.Container
+	sleepIndicator       *fyne.Container
+	sleepLabel           *widget.Label
 
 	// Content pages
 	systemInfo fyne.CanvasObject
 	tests      fyne.CanvasObject
-	history    fyne.CanvasObject
+	schedules  fyne.CanvasObject
+	benchmarks fyne.CanvasObject
 	reports    fyne.CanvasObject
+	history    fyne.CanvasObject
 	settings   fyne.CanvasObject
+
+	// onPageChanged is called with the index of the page shown
+	onPageChanged func(index int)
 }
 
 // NewNavigationSidebar creates a new navigation sidebar
@@ -246,7 +254,7 @@ func NewNavigationSidebar() *NavigationSidebar {
 	if systemIcon == nil {
 		systemIcon = theme.InfoIcon()
 	}
-	systemInfoBtn := NewNavigationButton("SYSTEM INFO", systemIcon, func() {
+	systemInfoBtn := NewNavigationButton(i18n.T("gui.nav.system_info"), systemIcon, func() {
 		n.ShowPage(0)
 	})
 	n.buttons = append(n.buttons, systemInfoBtn)
@@ -255,35 +263,45 @@ func NewNavigationSidebar() *NavigationSidebar {
 	if testIcon == nil {
 		testIcon = theme.ConfirmIcon()
 	}
-	testsBtn := NewNavigationButton("STABILITY TEST", testIcon, func() {
+	testsBtn := NewNavigationButton(i18n.T("gui.nav.stability"), testIcon, func() {
 		n.ShowPage(1)
 	})
 	n.buttons = append(n.buttons, testsBtn)
 
+	schedulesBtn := NewNavigationButton(i18n.T("gui.nav.schedules"), theme.HistoryIcon(), func() {
+		n.ShowPage(2)
+	})
+	n.buttons = append(n.buttons, schedulesBtn)
+
 	gaugeIcon := GetGaugeIcon()
 	if gaugeIcon == nil {
 		gaugeIcon = theme.StorageIcon()
 	}
-	historyBtn := NewNavigationButton("BENCHMARKS", gaugeIcon, func() {
-		n.ShowPage(2)
+	benchmarksBtn := NewNavigationButton(i18n.T("gui.nav.benchmarks"), gaugeIcon, func() {
+		n.ShowPage(3)
 	})
-	n.buttons = append(n.buttons, historyBtn)
+	n.buttons = append(n.buttons, benchmarksBtn)
 
 	cpuIcon := GetCPUIcon()
 	if cpuIcon == nil {
 		cpuIcon = theme.ViewRefreshIcon()
 	}
-	reportsBtn := NewNavigationButton("MONITORING", cpuIcon, func() {
-		n.ShowPage(3)
+	reportsBtn := NewNavigationButton(i18n.T("gui.nav.monitoring"), cpuIcon, func() {
+		n.ShowPage(4)
 	})
 	n.buttons = append(n.buttons, reportsBtn)
 
+	historyBtn := NewNavigationButton(i18n.T("gui.nav.history"), theme.ListIcon(), func() {
+		n.ShowPage(5)
+	})
+	n.buttons = append(n.buttons, historyBtn)
+
 	settingsIcon := GetSettingsIcon()
 	if settingsIcon == nil {
 		settingsIcon = theme.SettingsIcon()
 	}
-	settingsBtn := NewNavigationButton("SETTINGS", settingsIcon, func() {
-		n.ShowPage(4)
+	settingsBtn := NewNavigationButton(i18n.T("gui.nav.settings"), settingsIcon, func() {
+		n.ShowPage(6)
 	})
 	n.buttons = append(n.buttons, settingsBtn)
 
@@ -291,23 +309,30 @@ func NewNavigationSidebar() *NavigationSidebar {
 	buttonContainer := container.NewVBox()
 
 	// Add navigation buttons without spacing for tighter layout
-	for _, btn := range n.buttons[:5] { // First 5 buttons (main navigation)
+	for _, btn := range n.buttons[:7] { // First 7 buttons (main navigation)
 		buttonContainer.Add(btn)
 	}
 
 	// Add spacer to push bottom buttons down
 	buttonContainer.Add(layout.NewSpacer())
 
+	// Shown while tests or session recording keep the machine awake
+	n.sleepLabel = widget.NewLabelWithStyle("SLEEP BLOCKED", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	n.sleepLabel.Importance = widget.WarningImportance
+	n.sleepIndicator = container.NewHBox(widget.NewIcon(theme.VisibilityIcon()), n.sleepLabel)
+	n.sleepIndicator.Hide()
+	buttonContainer.Add(n.sleepIndicator)
+
 	// Add Buy Me Coffee button
 	supportIcon := GetSupportIcon()
 	if supportIcon == nil {
 		supportIcon = theme.HelpIcon()
 	}
-	supportBtn := NewNavigationButton("BUY ME COFFEE", supportIcon, func() {
+	supportBtn := NewNavigationButton(i18n.T("gui.nav.support"), supportIcon, func() {
 		// Open Buy Me a Coffee link
 		url := "https://buymeacoffee.com/mscrnt"
 		if err := fyne.CurrentApp().OpenURL(parseURL(url)); err != nil {
-			DebugLog("ERROR", "Failed to open URL: %v", err)
+			logger.Error("Failed to open URL", "error", err)
 		}
 	})
 	n.buttons = append(n.buttons, supportBtn)
@@ -353,13 +378,13 @@ func NewNavigationSidebar() *NavigationSidebar {
 
 // SetSystemInfo sets the system info page
 func (n *NavigationSidebar) SetSystemInfo(content fyne.CanvasObject) {
-	DebugLog("DEBUG", "NavigationSidebar.SetSystemInfo called")
+	logger.Debug("NavigationSidebar.SetSystemInfo called")
 	n.systemInfo = content
 	if n.currentIndex == -1 {
-		DebugLog("DEBUG", "Showing page 0 (system info) by default")
+		logger.Debug("Showing page 0 (system info) by default")
 		n.ShowPage(0) // Show system info by default
 	}
-	DebugLog("DEBUG", "NavigationSidebar.SetSystemInfo completed")
+	logger.Debug("NavigationSidebar.SetSystemInfo completed")
 }
 
 // SetTests sets the tests page
@@ -367,9 +392,14 @@ func (n *NavigationSidebar) SetTests(content fyne.CanvasObject) {
 	n.tests = content
 }
 
-// SetHistory sets the history page
-func (n *NavigationSidebar) SetHistory(content fyne.CanvasObject) {
-	n.history = content
+// SetSchedules sets the schedules page
+func (n *NavigationSidebar) SetSchedules(content fyne.CanvasObject) {
+	n.schedules = content
+}
+
+// SetBenchmarks sets the benchmarks page
+func (n *NavigationSidebar) SetBenchmarks(content fyne.CanvasObject) {
+	n.benchmarks = content
 }
 
 // SetReports sets the reports page
@@ -377,6 +407,11 @@ func (n *NavigationSidebar) SetReports(content fyne.CanvasObject) {
 	n.reports = content
 }
 
+// SetHistory sets the run history page
+func (n *NavigationSidebar) SetHistory(content fyne.CanvasObject) {
+	n.history = content
+}
+
 // SetSettings sets the settings page
 func (n *NavigationSidebar) SetSettings(content fyne.CanvasObject) {
 	n.settings = content
@@ -384,64 +419,75 @@ func (n *NavigationSidebar) SetSettings(content fyne.CanvasObject) {
 
 // ShowPage shows the specified page
 func (n *NavigationSidebar) ShowPage(index int) {
-	DebugLog("DEBUG", "ShowPage called with index %d", index)
+	logger.Debug("ShowPage called", "index", index)
 	if index < 0 || index >= len(n.buttons) {
-		DebugLog("DEBUG", "Invalid index %d (buttons: %d)", index, len(n.buttons))
+		logger.Debug("Invalid page index", "index", index, "buttons", len(n.buttons))
 		return
 	}
 
 	// Update button selection
-	DebugLog("DEBUG", "Updating button selection...")
+	logger.Debug("Updating button selection...")
 	for i, btn := range n.buttons {
 		if btn == nil {
-			DebugLog("ERROR", "Button %d is nil!", i)
+			logger.Error("Navigation button is nil", "index", i)
 			continue
 		}
 		btn.SetSelected(i == index)
 	}
 
 	// Update content
-	DebugLog("DEBUG", "Clearing content objects...")
+	logger.Debug("Clearing content objects...")
 	if n.content == nil {
-		DebugLog("ERROR", "Content container is nil!")
+		logger.Error("Content container is nil!")
 		return
 	}
 	n.content.Objects = nil
 
-	DebugLog("DEBUG", "Setting content for index %d", index)
+	logger.Debug("Setting page content", "index", index)
 	switch index {
 	case 0:
 		if n.systemInfo != nil {
-			DebugLog("DEBUG", "Setting system info content")
+			logger.Debug("Setting system info content")
 			n.content.Objects = []fyne.CanvasObject{n.systemInfo}
 		} else {
-			DebugLog("DEBUG", "System info is nil")
+			logger.Debug("System info is nil")
 		}
 	case 1:
 		if n.tests != nil {
-			DebugLog("DEBUG", "Setting tests content")
+			logger.Debug("Setting tests content")
 			n.content.Objects = []fyne.CanvasObject{n.tests}
 		} else {
-			DebugLog("DEBUG", "Tests content is nil")
+			logger.Debug("Tests content is nil")
 		}
 	case 2:
-		if n.history != nil {
-			n.content.Objects = []fyne.CanvasObject{n.history}
+		if n.schedules != nil {
+			n.content.Objects = []fyne.CanvasObject{n.schedules}
 		}
 	case 3:
+		if n.benchmarks != nil {
+			n.content.Objects = []fyne.CanvasObject{n.benchmarks}
+		}
+	case 4:
 		if n.reports != nil {
 			n.content.Objects = []fyne.CanvasObject{n.reports}
 		}
-	case 4:
+	case 5:
+		if n.history != nil {
+			n.content.Objects = []fyne.CanvasObject{n.history}
+		}
+	case 6:
 		if n.settings != nil {
 			n.content.Objects = []fyne.CanvasObject{n.settings}
 		}
 	}
 
-	DebugLog("DEBUG", "Refreshing content...")
+	logger.Debug("Refreshing content...")
 	n.content.Refresh()
 	n.currentIndex = index
-	DebugLog("DEBUG", "ShowPage completed")
+	if n.onPageChanged != nil {
+		n.onPageChanged(index)
+	}
+	logger.Debug("ShowPage completed")
 }
 
 // ToggleCollapse toggles the collapsed state of the sidebar
@@ -453,6 +499,12 @@ func (n *NavigationSidebar) ToggleCollapse() {
 		btn.SetCollapsed(n.collapsed)
 	}
 
+	if n.collapsed {
+		n.sleepLabel.Hide()
+	} else {
+		n.sleepLabel.Show()
+	}
+
 	// Update collapse button icon and alignment
 	if n.collapsed {
 		n.collapseBtn.SetIcon(theme.NavigateNextIcon())
@@ -471,6 +523,35 @@ func (n *NavigationSidebar) ToggleCollapse() {
 	n.container.Refresh()
 }
 
+// SetCollapsed collapses or expands the sidebar
+func (n *NavigationSidebar) SetCollapsed(collapsed bool) {
+	if n.collapsed != collapsed {
+		n.ToggleCollapse()
+	}
+}
+
+// Collapsed reports whether the sidebar is collapsed
+func (n *NavigationSidebar) Collapsed() bool {
+	return n.collapsed
+}
+
+// CurrentPage returns the index of the page shown, or 0 before any is shown
+func (n *NavigationSidebar) CurrentPage() int {
+	if n.currentIndex < 0 {
+		return 0
+	}
+	return n.currentIndex
+}
+
+// SetSleepBlocked shows or hides the indicator that sleep is inhibited
+func (n *NavigationSidebar) SetSleepBlocked(blocked bool) {
+	if blocked {
+		n.sleepIndicator.Show()
+	} else {
+		n.sleepIndicator.Hide()
+	}
+}
+
 // CreateLayout creates the main layout with sidebar and content
 func (n *NavigationSidebar) CreateLayout() fyne.CanvasObject {
 	// Create border layout with fixed width sidebar
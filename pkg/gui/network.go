@@ -0,0 +1,224 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+	"image/color"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/mscrnt/project_fire/pkg/monitor"
+)
+
+// networkHistory keeps an interface's recent rates for the charts
+type networkHistory struct {
+	down *MetricHistory
+	up   *MetricHistory
+}
+
+// readNetwork reads every interface's rates, adds them to its history and
+// returns them with the interface shown on the summary card
+func (d *Dashboard) readNetwork() ([]monitor.Interface, string) {
+	d.netMu.Lock()
+	defer d.netMu.Unlock()
+
+	ifaces := d.netMeter.Read(context.Background())
+	if d.netHistory == nil {
+		d.netHistory = make(map[string]*networkHistory)
+	}
+	selected := false
+	for _, i := range ifaces {
+		h, ok := d.netHistory[i.Name]
+		if !ok {
+			h = &networkHistory{down: NewMetricHistory(), up: NewMetricHistory()}
+			d.netHistory[i.Name] = h
+		}
+		h.down.Add(i.DownMBps)
+		h.up.Add(i.UpMBps)
+		selected = selected || i.Name == d.netSelected
+	}
+	if !selected {
+		d.netSelected = monitor.PrimaryInterface(ifaces)
+	}
+	d.interfaces = ifaces
+	return ifaces, d.netSelected
+}
+
+// networkState returns the last interfaces read, the selected one and the
+// history of name
+func (d *Dashboard) networkState(name string) ([]monitor.Interface, string, *networkHistory) {
+	d.netMu.Lock()
+	defer d.netMu.Unlock()
+	return d.interfaces, d.netSelected, d.netHistory[name]
+}
+
+// selectInterface picks the interface shown on the summary card
+func (d *Dashboard) selectInterface(name string) {
+	d.netMu.Lock()
+	d.netSelected = name
+	d.netMu.Unlock()
+}
+
+// findInterface returns the interface named name
+func findInterface(ifaces []monitor.Interface, name string) (monitor.Interface, bool) {
+	for _, i := range ifaces {
+		if i.Name == name {
+			return i, true
+		}
+	}
+	return monitor.Interface{}, false
+}
+
+// interfaceNames returns the names of the interfaces
+func interfaceNames(ifaces []monitor.Interface) []string {
+	names := make([]string, len(ifaces))
+	for n, i := range ifaces {
+		names[n] = i.Name
+	}
+	return names
+}
+
+// describeInterface summarizes an interface's link and addresses
+func describeInterface(i monitor.Interface) string {
+	lines := []string{"Link: unknown"}
+	if i.LinkMbps > 0 {
+		lines[0] = "Link: " + formatLinkSpeed(i.LinkMbps)
+	}
+	if i.MAC != "" {
+		lines = append(lines, "MAC: "+i.MAC)
+	}
+	if len(i.Addresses) > 0 {
+		lines = append(lines, "Addresses: "+strings.Join(i.Addresses, ", "))
+	} else {
+		lines = append(lines, "Addresses: none")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatLinkSpeed formats a link speed in Mbit/s, e.g. "2.5 Gbit/s"
+func formatLinkSpeed(mbit float64) string {
+	if mbit >= 1000 {
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", mbit/1000), ".0") + " Gbit/s"
+	}
+	return fmt.Sprintf("%.0f Mbit/s", mbit)
+}
+
+// createNetworkSummary creates the network card of the summary strip, with
+// a choice of interface and a button opening its charts
+func (d *Dashboard) createNetworkSummary() *SummaryCard {
+	d.networkSelect = widget.NewSelect(nil, d.selectInterface)
+	d.networkSelect.PlaceHolder = "No network"
+	d.networkDetails = widget.NewButtonWithIcon("", theme.InfoIcon(), d.ShowNetwork)
+	d.networkDetails.Importance = widget.LowImportance
+	return d.createCompactSummaryCard("Network", "Network", []string{"Down", "Up", "Link"}, map[string]color.Color{
+		"Down": ColorCPUUsage,
+		"Up":   ColorGPUUsage,
+		"Link": ColorFrequency,
+	})
+}
+
+// applyNetworkUpdates shows the selected interface on the summary card
+func (d *Dashboard) applyNetworkUpdates(ifaces []monitor.Interface, selected string) {
+	if d.networkSummary == nil {
+		return
+	}
+	names := interfaceNames(ifaces)
+	if strings.Join(names, "\n") != strings.Join(d.networkSelect.Options, "\n") {
+		d.networkSelect.SetOptions(names)
+	}
+	if d.networkSelect.Selected != selected {
+		d.networkSelect.SetSelected(selected)
+	}
+
+	i, ok := findInterface(ifaces, selected)
+	if !ok {
+		return
+	}
+	linkMBps := i.LinkMbps / 8 * 1e6 / (1 << 20) // The rates are in MB/s
+	for name, value := range map[string]float64{"Down": i.DownMBps, "Up": i.UpMBps} {
+		if display, ok := d.networkSummary.metrics[name]; ok {
+			display.SetValue(value, "MB/s", 0, "")
+			if linkMBps > 0 {
+				display.SetMax(linkMBps)
+			}
+		}
+	}
+	if display, ok := d.networkSummary.metrics["Link"]; ok {
+		if i.LinkMbps > 0 {
+			display.SetValue(i.LinkMbps, "Mbit/s", 0, "")
+		} else {
+			display.SetUnavailable("The interface does not report its link speed (wireless and virtual adapters)")
+		}
+	}
+	d.networkSummary.container.Refresh()
+}
+
+// ShowNetwork shows an interface's link, addresses and recent download and
+// upload rates, updating at the dashboard's interval until closed
+func (d *Dashboard) ShowNetwork() {
+	ifaces, selected, _ := d.networkState("")
+	if len(ifaces) == 0 {
+		dialog.ShowInformation("Network", "No network interface is up", d.window)
+		return
+	}
+
+	info := widget.NewLabel("")
+	info.Wrapping = fyne.TextWrapWord
+	down := NewEnhancedLineChart("Download", 1, 0)
+	up := NewEnhancedLineChart("Upload", 1, 0)
+	for _, chart := range []*EnhancedLineChart{down, up} {
+		chart.SetUnit("MB/s")
+		chart.SetShowDataPoints(false)
+	}
+
+	choice := widget.NewSelect(interfaceNames(ifaces), nil)
+	show := func() {
+		ifaces, _, history := d.networkState(choice.Selected)
+		i, ok := findInterface(ifaces, choice.Selected)
+		if !ok {
+			info.SetText("The interface went down")
+			return
+		}
+		info.SetText(describeInterface(i))
+		if history != nil {
+			down.SetValues(history.down.Values())
+			up.SetValues(history.up.Values())
+		}
+	}
+	choice.OnChanged = func(string) { show() }
+	choice.SetSelected(selected)
+
+	content := container.NewBorder(
+		container.NewVBox(container.NewBorder(nil, nil, widget.NewLabel("Interface:"), nil, choice), info),
+		nil, nil, nil,
+		container.NewGridWithRows(2, down, up),
+	)
+	dlg := dialog.NewCustom("Network", "Close", content, d.window)
+
+	d.mu.Lock()
+	interval := d.updateInterval
+	d.mu.Unlock()
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				fyne.Do(show)
+			}
+		}
+	}()
+	dlg.SetOnClosed(func() {
+		ticker.Stop()
+		close(done)
+	})
+	dlg.Resize(fyne.NewSize(800, 600))
+	dlg.Show()
+}
@@ -0,0 +1,26 @@
+package gui
+
+import (
+	"testing"
+
+	"github.com/mscrnt/project_fire/pkg/monitor"
+)
+
+func TestFormatLinkSpeed(t *testing.T) {
+	for mbit, want := range map[float64]string{100: "100 Mbit/s", 1000: "1 Gbit/s", 2500: "2.5 Gbit/s", 10000: "10 Gbit/s"} {
+		if got := formatLinkSpeed(mbit); got != want {
+			t.Errorf("formatLinkSpeed(%v) = %q, want %q", mbit, got, want)
+		}
+	}
+}
+
+func TestDescribeInterface(t *testing.T) {
+	got := describeInterface(monitor.Interface{Name: "eth0", MAC: "aa:bb:cc:dd:ee:ff", Addresses: []string{"10.0.0.2", "fe80::1"}, LinkMbps: 1000})
+	want := "Link: 1 Gbit/s\nMAC: aa:bb:cc:dd:ee:ff\nAddresses: 10.0.0.2, fe80::1"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := describeInterface(monitor.Interface{Name: "wg0"}); got != "Link: unknown\nAddresses: none" {
+		t.Errorf("unexpected description %q", got)
+	}
+}
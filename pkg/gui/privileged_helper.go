@@ -0,0 +1,23 @@
+package gui
+
+import (
+	"github.com/mscrnt/project_fire/pkg/helper"
+	"github.com/mscrnt/project_fire/pkg/inventory"
+	"github.com/mscrnt/project_fire/pkg/sensors"
+)
+
+// connectPrivilegedHelper connects to a running helper service, which then
+// reads the DMI tables, SMART data and MSRs. It returns false when no
+// helper is reachable.
+func connectPrivilegedHelper() bool {
+	client := helper.NewClient("")
+	if !client.Available() {
+		logger.Info("No privileged helper", "socket", client.SocketPath())
+		return false
+	}
+
+	inventory.Helper = client
+	sensors.SetHelper(client)
+	logger.Info("Using privileged helper", "socket", client.SocketPath())
+	return true
+}
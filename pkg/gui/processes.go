@@ -0,0 +1,185 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/mscrnt/project_fire/pkg/procs"
+)
+
+// processRefresh is how often the process viewer samples
+const processRefresh = 2 * time.Second
+
+// processColumns are the process viewer's columns
+var processColumns = []struct {
+	title string
+	width float32
+}{
+	{"PID", 70},
+	{"Name", 220},
+	{"CPU %", 70},
+	{"Memory", 90},
+	{"Mem %", 70},
+	{"Read", 90},
+	{"Write", 90},
+	{"GPU %", 70},
+	{"User", 160},
+}
+
+// processSortLabels maps the sort select's labels to procs sort keys
+var processSortLabels = []struct {
+	label string
+	key   string
+}{
+	{"CPU", procs.ByCPU},
+	{"Memory", procs.ByMemory},
+	{"Disk I/O", procs.ByIO},
+	{"GPU", procs.ByGPU},
+}
+
+// processCell returns the text of a process's column
+func processCell(p procs.Process, column int, gpuAvailable bool) string {
+	switch column {
+	case 0:
+		return strconv.Itoa(int(p.PID))
+	case 1:
+		if p.Self {
+			return p.Name + " (F.I.R.E.)"
+		}
+		return p.Name
+	case 2:
+		return fmt.Sprintf("%.1f", p.CPU)
+	case 3:
+		return fmt.Sprintf("%.0f MB", p.MemoryMB)
+	case 4:
+		return fmt.Sprintf("%.1f", p.MemoryPct)
+	case 5:
+		return formatMetricValue(p.ReadMBps, "MB/s")
+	case 6:
+		return formatMetricValue(p.WriteMBps, "MB/s")
+	case 7:
+		if !gpuAvailable {
+			return "-"
+		}
+		return fmt.Sprintf("%.0f", p.GPU)
+	case 8:
+		return p.User
+	}
+	return ""
+}
+
+// ShowProcesses opens a window listing the processes using the most CPU,
+// memory, disk I/O or GPU, refreshed every two seconds until it is closed
+func ShowProcesses(app fyne.App) fyne.Window {
+	window := app.NewWindow("F.I.R.E. - Processes")
+	window.Resize(fyne.NewSize(1000, 600))
+
+	var (
+		mu     sync.Mutex
+		sample = &procs.Sample{}
+		rows   []procs.Process
+		key    = procs.ByCPU
+	)
+
+	table := widget.NewTableWithHeaders(
+		func() (int, int) {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(rows), len(processColumns)
+		},
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+			mu.Lock()
+			text := ""
+			if id.Row < len(rows) {
+				text = processCell(rows[id.Row], id.Col, sample.GPUAvailable)
+			}
+			mu.Unlock()
+			o.(*widget.Label).SetText(text)
+		},
+	)
+	table.ShowHeaderColumn = false
+	table.UpdateHeader = func(id widget.TableCellID, o fyne.CanvasObject) {
+		if id.Col >= 0 && id.Col < len(processColumns) {
+			o.(*widget.Label).SetText(processColumns[id.Col].title)
+		}
+	}
+	for i, c := range processColumns {
+		table.SetColumnWidth(i, c.width)
+	}
+
+	status := widget.NewLabel("Reading processes...")
+	status.Wrapping = fyne.TextWrapWord
+
+	show := func() {
+		mu.Lock()
+		rows = procs.Top(sample.Processes, key, 0)
+		text := fmt.Sprintf("%d processes. Other processes use %.1f%% of the CPU.", len(sample.Processes), procs.Others(sample.Processes))
+		if !sample.GPUAvailable {
+			text += " GPU usage needs an NVIDIA card."
+		}
+		if sample.Time.IsZero() {
+			text = "Reading processes..."
+		}
+		mu.Unlock()
+		status.SetText(text)
+		table.Refresh()
+	}
+
+	sortLabels := make([]string, len(processSortLabels))
+	for i, s := range processSortLabels {
+		sortLabels[i] = s.label
+	}
+	sortSelect := widget.NewSelect(sortLabels, nil)
+	sortSelect.SetSelected(sortLabels[0])
+	sortSelect.OnChanged = func(string) {
+		mu.Lock()
+		key = processSortLabels[sortSelect.SelectedIndex()].key
+		mu.Unlock()
+		show()
+	}
+
+	toolbar := container.NewHBox(widget.NewLabel("Sort by:"), sortSelect)
+	window.SetContent(container.NewBorder(toolbar, status, nil, nil, table))
+
+	// Processes are read off the UI thread; listing them takes a moment
+	ctx, cancel := context.WithCancel(context.Background())
+	window.SetOnClosed(cancel)
+	go func() {
+		sampler := procs.NewSampler()
+		ticker := time.NewTicker(processRefresh)
+		defer ticker.Stop()
+		first := true
+		for {
+			next, err := sampler.Sample(ctx)
+			switch {
+			case ctx.Err() != nil:
+				return
+			case err != nil:
+				fyne.Do(func() { status.SetText(err.Error()) })
+			case !first:
+				// The first sample has no rates yet
+				mu.Lock()
+				sample = next
+				mu.Unlock()
+				fyne.Do(show)
+			}
+			first = false
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	window.Show()
+	return window
+}
@@ -0,0 +1,26 @@
+package gui
+
+import (
+	"testing"
+
+	"github.com/mscrnt/project_fire/pkg/procs"
+)
+
+func TestProcessCell(t *testing.T) {
+	p := procs.Process{PID: 42, Name: "bench", CPU: 12.34, MemoryMB: 256.4, GPU: 80, Self: true}
+	if got := processCell(p, 0, true); got != "42" {
+		t.Errorf("PID = %q", got)
+	}
+	if got := processCell(p, 1, true); got != "bench (F.I.R.E.)" {
+		t.Errorf("name = %q", got)
+	}
+	if got := processCell(p, 2, true); got != "12.3" {
+		t.Errorf("CPU = %q", got)
+	}
+	if got := processCell(p, 3, true); got != "256 MB" {
+		t.Errorf("memory = %q", got)
+	}
+	if got := processCell(p, 7, false); got != "-" {
+		t.Errorf("GPU without nvidia-smi = %q", got)
+	}
+}
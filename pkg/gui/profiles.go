@@ -0,0 +1,376 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/mscrnt/project_fire/pkg/config"
+	"github.com/mscrnt/project_fire/pkg/logging"
+	"github.com/mscrnt/project_fire/pkg/profile"
+	"github.com/mscrnt/project_fire/pkg/units"
+)
+
+// pageNames are the sidebar pages, in order, as offered for a profile's start page
+var pageNames = []string{"System Info", "Stability Test", "Schedules", "Benchmarks", "Monitoring", "History", "Settings"}
+
+// Display preferences of the active profile, read by widgets that have no
+// reference to the GUI
+var (
+	prefsMu       sync.RWMutex
+	displayUnits  = units.Default()
+	notifyOptions = profile.New(profile.DefaultName).Notifications
+)
+
+// setDisplayPreferences applies the units of the settings, with the
+// temperature unit a profile chooses, and the profile's notification choices
+func setDisplayPreferences(p profile.Profile) {
+	prefs := units.Default()
+	if settings, err := config.Load(); err == nil {
+		prefs = settings.Units
+	}
+	if p.Units.Temperature != "" {
+		prefs.Temperature = string(p.Units.Temperature)
+	}
+	prefsMu.Lock()
+	defer prefsMu.Unlock()
+	displayUnits = prefs
+	notifyOptions = p.Notifications
+}
+
+// formatTemperature formats a Celsius reading in the preferred unit
+func formatTemperature(celsius float64, format string) string {
+	return formatReading(celsius, "°C", format)
+}
+
+// formatReading formats a reading in the preferred unit, e.g. a rate in
+// "MB/s" as MiB/s
+func formatReading(value float64, unit, format string) string {
+	prefsMu.RLock()
+	defer prefsMu.RUnlock()
+	return displayUnits.Format(value, unit, format)
+}
+
+// convertReading converts a reading to the preferred unit
+func convertReading(value float64, unit string) (float64, string) {
+	prefsMu.RLock()
+	defer prefsMu.RUnlock()
+	return displayUnits.Convert(value, unit)
+}
+
+// preferredUnits returns the units readings are shown in, such as for
+// generated reports
+func preferredUnits() units.Prefs {
+	prefsMu.RLock()
+	defer prefsMu.RUnlock()
+	return displayUnits
+}
+
+// notifyWarning sends a desktop notification unless the profile mutes
+// warnings. Every warning is logged as an alert for the event timeline.
+func notifyWarning(title, content string) {
+	logger.Log(context.Background(), logging.LevelAlert, fmt.Sprintf("%s: %s", title, content))
+	prefsMu.RLock()
+	enabled := notifyOptions.Warnings
+	prefsMu.RUnlock()
+	if enabled {
+		fyne.CurrentApp().SendNotification(&fyne.Notification{Title: title, Content: content})
+	}
+}
+
+// notifyDeviceChange sends a desktop notification unless the profile mutes
+// hardware change notifications
+func notifyDeviceChange(title, content string) {
+	prefsMu.RLock()
+	enabled := notifyOptions.DeviceChanges
+	prefsMu.RUnlock()
+	if enabled {
+		fyne.CurrentApp().SendNotification(&fyne.Notification{Title: title, Content: content})
+	}
+}
+
+// temperatureChoices are the temperature units a profile can choose; the
+// first follows the settings
+var temperatureChoices = []string{"As in Settings", "Celsius", "Fahrenheit"}
+
+// temperatureChoice returns the choice showing a profile's temperature unit
+func temperatureChoice(unit profile.TemperatureUnit) string {
+	switch unit {
+	case profile.Celsius:
+		return temperatureChoices[1]
+	case profile.Fahrenheit:
+		return temperatureChoices[2]
+	}
+	return temperatureChoices[0]
+}
+
+// temperatureUnit returns the profile temperature unit of a choice
+func temperatureUnit(choice string) profile.TemperatureUnit {
+	switch choice {
+	case temperatureChoices[1]:
+		return profile.Celsius
+	case temperatureChoices[2]:
+		return profile.Fahrenheit
+	}
+	return ""
+}
+
+// loadProfiles reads the operator profiles, falling back to the default
+// profile when the file cannot be read
+func (g *FireGUI) loadProfiles() {
+	g.profilesPath = profile.DefaultPath()
+	store, err := profile.Load(g.profilesPath)
+	if err != nil {
+		logger.Warn("Using the default profile", "error", err)
+		store = &profile.Store{Active: profile.DefaultName, Profiles: []profile.Profile{profile.New(profile.DefaultName)}}
+	}
+	g.profiles = store
+	setDisplayPreferences(store.Current())
+}
+
+// saveProfiles writes the operator profiles, reporting failures in a dialog
+func (g *FireGUI) saveProfiles() {
+	if err := profile.Save(g.profilesPath, g.profiles); err != nil {
+		dialog.ShowError(fmt.Errorf("failed to save profiles: %w", err), g.window)
+	}
+}
+
+// applyProfile shows the GUI as the profile describes it
+func (g *FireGUI) applyProfile(p profile.Profile) {
+	setDisplayPreferences(p)
+	g.dashboard.recorder.SetDisabled(p.Recording.Disabled)
+	g.window.SetTitle("F.I.R.E. System Monitor - " + p.Name)
+	g.navigation.SetCollapsed(p.Layout.SidebarCollapsed)
+	g.monitoring.SetTiles(p.Layout.Monitoring)
+	g.navigation.ShowPage(p.Layout.StartPage)
+	g.dashboard.RefreshUnits()
+	g.refreshSettings()
+}
+
+// rememberLayout stores the current page and sidebar state in the active
+// profile, so the next shift finds the window as it was left
+func (g *FireGUI) rememberLayout() {
+	p := g.profiles.Current()
+	p.Layout.StartPage = g.navigation.CurrentPage()
+	p.Layout.SidebarCollapsed = g.navigation.Collapsed()
+	if err := g.profiles.Put(p); err != nil {
+		logger.Warn("Failed to remember layout", "error", err)
+		return
+	}
+	if err := profile.Save(g.profilesPath, g.profiles); err != nil {
+		logger.Warn("Failed to save profiles", "error", err)
+	}
+}
+
+// saveMonitoringLayout stores the monitoring page's tiles in the active
+// profile
+func (g *FireGUI) saveMonitoringLayout(tiles []profile.Tile) {
+	p := g.profiles.Current()
+	p.Layout.Monitoring = tiles
+	if err := g.profiles.Put(p); err != nil {
+		dialog.ShowError(err, g.window)
+		return
+	}
+	g.saveProfiles()
+}
+
+// switchProfile makes another operator's profile active
+func (g *FireGUI) switchProfile(name string) {
+	g.rememberLayout()
+	p, err := g.profiles.Switch(name)
+	if err != nil {
+		dialog.ShowError(err, g.window)
+		return
+	}
+	g.saveProfiles()
+	g.applyProfile(p)
+}
+
+// showProfileEditor edits the active profile, and creates, renames, deletes
+// and switches profiles
+func (g *FireGUI) showProfileEditor() {
+	current := g.profiles.Current()
+
+	profileSelect := widget.NewSelect(g.profiles.Names(), nil)
+	profileSelect.SetSelected(current.Name)
+
+	nameEntry := widget.NewEntry()
+	startPage := widget.NewSelect(pageNames, nil)
+	collapsed := widget.NewCheck("Collapse the sidebar", nil)
+	temperature := widget.NewRadioGroup(temperatureChoices, nil)
+	temperature.Horizontal = true
+
+	var presets []string
+	for _, test := range g.testsPage.Tests() {
+		presets = append(presets, test.Name)
+	}
+	favorites := widget.NewCheckGroup(presets, nil)
+	warnings := widget.NewCheck("Warnings", nil)
+	devices := widget.NewCheck("Hardware changes", nil)
+
+	// Session recording choices, grouped by component, with the disk space
+	// a saved session takes
+	recordSize := widget.NewLabel("")
+	recordSize.Wrapping = fyne.TextWrapWord
+	var recordGroups []*widget.CheckGroup
+	recorded := func() (enabled, disabled []string) {
+		for _, group := range recordGroups {
+			selected := make(map[string]bool, len(group.Selected))
+			for _, name := range group.Selected {
+				selected[name] = true
+			}
+			for _, name := range group.Options {
+				if selected[name] {
+					enabled = append(enabled, name)
+				} else {
+					disabled = append(disabled, name)
+				}
+			}
+		}
+		return enabled, disabled
+	}
+	updateRecordSize := func([]string) {
+		enabled, _ := recorded()
+		recordSize.SetText(sessionSizeEstimate(enabled, g.dashboard.recorder.Capacity()))
+	}
+	recording := container.NewVBox()
+	var components []string
+	byComponent := make(map[string][]string)
+	for _, m := range sessionMetrics {
+		if _, ok := byComponent[m.component]; !ok {
+			components = append(components, m.component)
+		}
+		byComponent[m.component] = append(byComponent[m.component], m.name)
+	}
+	for _, component := range components {
+		group := widget.NewCheckGroup(byComponent[component], updateRecordSize)
+		group.Horizontal = true
+		recordGroups = append(recordGroups, group)
+		recording.Add(group)
+	}
+	recording.Add(recordSize)
+
+	show := func(p profile.Profile) {
+		nameEntry.SetText(p.Name)
+		if p.Layout.StartPage < len(pageNames) {
+			startPage.SetSelectedIndex(p.Layout.StartPage)
+		}
+		collapsed.SetChecked(p.Layout.SidebarCollapsed)
+		temperature.SetSelected(temperatureChoice(p.Units.Temperature))
+		favorites.SetSelected(p.Favorites)
+		warnings.SetChecked(p.Notifications.Warnings)
+		devices.SetChecked(p.Notifications.DeviceChanges)
+		for _, group := range recordGroups {
+			var selected []string
+			for _, name := range group.Options {
+				if p.Recording.Records(name) {
+					selected = append(selected, name)
+				}
+			}
+			group.SetSelected(selected)
+		}
+		updateRecordSize(nil)
+	}
+	show(current)
+	editing := current.Name
+	profileSelect.OnChanged = func(name string) {
+		if p, ok := g.profiles.Get(name); ok {
+			editing = p.Name
+			show(p)
+		}
+	}
+
+	form := widget.NewForm(
+		widget.NewFormItem("Profile", profileSelect),
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("Start page", startPage),
+		widget.NewFormItem("Layout", collapsed),
+		widget.NewFormItem("Temperature", temperature),
+		widget.NewFormItem("Favorite tests", favorites),
+		widget.NewFormItem("Notifications", container.NewHBox(warnings, devices)),
+		widget.NewFormItem("Session recording", recording),
+	)
+
+	// edited returns the profile as shown in the form
+	edited := func() profile.Profile {
+		p := profile.New(strings.TrimSpace(nameEntry.Text))
+		p.Layout = profile.Layout{StartPage: startPage.SelectedIndex(), SidebarCollapsed: collapsed.Checked}
+		if p.Layout.StartPage < 0 {
+			p.Layout.StartPage = 0
+		}
+		p.Units.Temperature = temperatureUnit(temperature.Selected)
+		p.Favorites = favorites.Selected
+		p.Notifications = profile.Notifications{Warnings: warnings.Checked, DeviceChanges: devices.Checked}
+		_, p.Recording.Disabled = recorded()
+		return p
+	}
+
+	var editor dialog.Dialog
+	newProfile := widget.NewButton("New", func() {
+		nameInput := widget.NewEntry()
+		dialog.ShowForm("New Profile", "Create", "Cancel",
+			[]*widget.FormItem{widget.NewFormItem("Name", nameInput)},
+			func(ok bool) {
+				if !ok {
+					return
+				}
+				name := strings.TrimSpace(nameInput.Text)
+				if _, exists := g.profiles.Get(name); exists {
+					dialog.ShowError(fmt.Errorf("profile %q already exists", name), g.window)
+					return
+				}
+				if err := g.profiles.Put(profile.New(name)); err != nil {
+					dialog.ShowError(err, g.window)
+					return
+				}
+				g.saveProfiles()
+				editor.Hide()
+				g.switchProfile(name)
+				g.showProfileEditor()
+			}, g.window)
+	})
+	deleteProfile := widget.NewButton("Delete", func() {
+		name := editing
+		dialog.ShowConfirm("Delete Profile", fmt.Sprintf("Delete the profile %q?", name), func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := g.profiles.Delete(name); err != nil {
+				dialog.ShowError(err, g.window)
+				return
+			}
+			g.saveProfiles()
+			editor.Hide()
+			g.applyProfile(g.profiles.Current())
+		}, g.window)
+	})
+
+	content := container.NewBorder(nil, container.NewHBox(newProfile, deleteProfile), nil, nil, container.NewVScroll(form))
+	editor = dialog.NewCustomConfirm("Operator Profiles", "Save and Switch", "Cancel", content, func(save bool) {
+		if !save {
+			return
+		}
+		p := edited()
+		if err := g.profiles.Rename(editing, p.Name); err != nil {
+			dialog.ShowError(err, g.window)
+			return
+		}
+		if err := g.profiles.Put(p); err != nil {
+			dialog.ShowError(err, g.window)
+			return
+		}
+		if _, err := g.profiles.Switch(p.Name); err != nil {
+			dialog.ShowError(err, g.window)
+			return
+		}
+		g.saveProfiles()
+		g.applyProfile(p)
+	}, g.window)
+	editor.Resize(fyne.NewSize(560, 620))
+	editor.Show()
+}
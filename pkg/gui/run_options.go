@@ -0,0 +1,90 @@
+package gui
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/benchmode"
+	"github.com/mscrnt/project_fire/pkg/config"
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/logging"
+	"github.com/mscrnt/project_fire/pkg/notify"
+	"github.com/mscrnt/project_fire/pkg/sink"
+	"github.com/mscrnt/project_fire/pkg/testrun"
+)
+
+// runOptions returns how a test started from the GUI is run: with the
+// safety limits, hooks, UPS, sinks and notifications of the settings file,
+// as bench test runs it. done closes the sinks once the run is recorded.
+func runOptions(database *db.DB, tags map[string]string, benchMode bool) (opts testrun.Options, done func()) {
+	runLog := slog.NewLogLogger(logger.Handler(), logging.LevelWarn)
+	opts = testrun.Options{Source: "gui", Tags: tags, Logger: runLog}
+	if benchMode {
+		opts.BenchMode = &benchmode.Options{StatePath: benchmode.DefaultStatePath()}
+	}
+
+	settings, err := config.Load()
+	if err != nil {
+		logger.Warn("Test runs without the safety limits, hooks, UPS, sinks and notifications of the settings", "error", err)
+		return opts, func() {}
+	}
+
+	opts.Limits = settings.Safety
+
+	if err := settings.Hooks.Validate(); err != nil {
+		logger.Warn("Hooks not run", "error", err)
+	} else {
+		opts.Hooks = settings.Hooks
+	}
+
+	if sources, err := testrun.PowerSources(settings.Power.UPS); err != nil {
+		logger.Warn("Power events not recorded", "error", err)
+	} else {
+		opts.Power = sources
+		opts.PauseOnBattery = settings.Power.PauseOnBattery
+	}
+
+	if targets := notify.Merge(settings.Notify, nil); targets != nil {
+		if err := targets.Validate(); err != nil {
+			logger.Warn("Run reports not sent", "error", err)
+		} else {
+			notifier := &testrun.Notifier{Database: database, Units: settings.Units, Logger: runLog}
+			if settings.SMTP.Host != "" {
+				notifier.SMTP = &settings.SMTP
+			}
+			opts.Finished = func(run *db.Run) {
+				if err := notifier.Send(context.Background(), run, targets, ""); err != nil {
+					logger.Warn("Could not send every notification", "run", run.ID, "error", err)
+				}
+			}
+		}
+	}
+
+	interval := sink.DefaultInterval
+	if settings.Sink.Interval != "" {
+		if interval, err = time.ParseDuration(settings.Sink.Interval); err != nil || interval <= 0 {
+			logger.Warn("Samples not streamed: invalid sink interval", "interval", settings.Sink.Interval)
+			return opts, func() {}
+		}
+	}
+	sinks, err := testrun.OpenSinks(context.Background(), testrun.SinkURLs(settings.Sink.URLs, nil), interval, runLog.Printf)
+	if err != nil {
+		logger.Warn("Samples not streamed", "error", err)
+		return opts, func() {}
+	}
+	opts.Sinks = sinks
+	return opts, func() { closeSinks(sinks, runLog) }
+}
+
+// closeSinks writes the queued points and logs the points that were lost
+func closeSinks(sinks *testrun.Sinks, runLog *log.Logger) {
+	dropped, err := sinks.Close()
+	if dropped > 0 {
+		runLog.Printf("%d samples were not streamed because the sinks could not keep up", dropped)
+	}
+	if err != nil {
+		runLog.Printf("%v", err)
+	}
+}
@@ -0,0 +1,598 @@
+package gui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/mscrnt/project_fire/pkg/plugin"
+	_ "github.com/mscrnt/project_fire/pkg/plugin/cpu"     // Register CPU plugin
+	_ "github.com/mscrnt/project_fire/pkg/plugin/disk"    // Register Disk plugin
+	_ "github.com/mscrnt/project_fire/pkg/plugin/memory"  // Register Memory plugin
+	_ "github.com/mscrnt/project_fire/pkg/plugin/network" // Register Network plugin
+	_ "github.com/mscrnt/project_fire/pkg/plugin/trim"    // Register TRIM plugin
+	"github.com/mscrnt/project_fire/pkg/schedule"
+)
+
+// scheduleHistoryLimit is how many past runs are shown for a schedule
+const scheduleHistoryLimit = 25
+
+// scheduleNextRuns is how many upcoming run times are shown for a schedule
+const scheduleNextRuns = 5
+
+// frequencyLabels are the recurrence picker choices
+var frequencyLabels = map[schedule.Frequency]string{
+	schedule.Hourly:   "Every hour",
+	schedule.Daily:    "Every day",
+	schedule.Weekdays: "Every weekday (Mon-Fri)",
+	schedule.Weekly:   "Every week",
+	schedule.Monthly:  "Every month",
+	schedule.Custom:   "Custom (cron expression)",
+}
+
+// SchedulesPage lists the test schedules stored in the results database and
+// lets the user create, edit and review them
+type SchedulesPage struct {
+	dbPath string
+	window fyne.Window
+
+	content   fyne.CanvasObject
+	status    *widget.Label
+	list      *widget.List
+	details   *fyne.Container
+	schedules []*schedule.Schedule
+	selected  int64
+}
+
+// NewSchedulesPage creates the schedules page for the database at dbPath
+func NewSchedulesPage(dbPath string, window fyne.Window) *SchedulesPage {
+	p := &SchedulesPage{dbPath: dbPath, window: window}
+	p.build()
+	p.Refresh()
+	return p
+}
+
+// Content returns the schedules page content
+func (p *SchedulesPage) Content() fyne.CanvasObject {
+	return p.content
+}
+
+// build creates the page layout
+func (p *SchedulesPage) build() {
+	title := widget.NewLabelWithStyle("Test Schedules", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	newBtn := widget.NewButtonWithIcon("New Schedule", theme.ContentAddIcon(), p.NewSchedule)
+	newBtn.Importance = widget.HighImportance
+	refreshBtn := widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), p.Refresh)
+
+	p.status = widget.NewLabel("")
+
+	header := container.NewVBox(
+		container.NewBorder(nil, nil, title, container.NewHBox(refreshBtn, newBtn)),
+		p.status,
+		widget.NewSeparator(),
+	)
+
+	p.list = widget.NewList(
+		func() int { return len(p.schedules) },
+		func() fyne.CanvasObject {
+			name := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+			info := widget.NewLabel("")
+			info.Importance = widget.LowImportance
+			return container.NewVBox(name, info)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			s := p.schedules[id]
+			box := obj.(*fyne.Container)
+			name := s.Name
+			if !s.Enabled {
+				name += " (disabled)"
+			}
+			box.Objects[0].(*widget.Label).SetText(name)
+			box.Objects[1].(*widget.Label).SetText(fmt.Sprintf("%s · %s · next %s",
+				s.Plugin, schedule.ParseRecurrence(s.CronExpr), formatScheduleTime(s.NextRunTime)))
+		},
+	)
+	p.list.OnSelected = func(id widget.ListItemID) {
+		p.selected = p.schedules[id].ID
+		p.showDetails(p.schedules[id])
+	}
+
+	p.details = container.NewStack(container.NewCenter(widget.NewLabel("Select a schedule to see its runs")))
+
+	split := container.NewHSplit(p.list, p.details)
+	split.Offset = 0.4
+	p.content = container.NewBorder(header, nil, nil, nil, split)
+}
+
+// Refresh reloads the schedules from the database
+func (p *SchedulesPage) Refresh() {
+	database, err := openResults(p.dbPath)
+	if err != nil {
+		logger.Error("Failed to open database for schedules", "error", err)
+		return
+	}
+	defer func() { _ = database.Close() }()
+
+	store := schedule.NewStore(database)
+	daemons, err := store.Daemons()
+	if err != nil {
+		logger.Error("Failed to get the scheduler status", "error", err)
+	}
+	text, running := daemonStatus(daemons, time.Now())
+	p.status.SetText(text)
+	if running {
+		p.status.Importance = widget.SuccessImportance
+	} else {
+		p.status.Importance = widget.WarningImportance
+	}
+	p.status.Refresh()
+
+	schedules, err := store.List(schedule.Filter{})
+	if err != nil {
+		logger.Error("Failed to list schedules", "error", err)
+		return
+	}
+	p.schedules = schedules
+	p.list.UnselectAll()
+	p.list.Refresh()
+
+	// Keep the selected schedule open across refreshes
+	for i, s := range schedules {
+		if s.ID == p.selected {
+			p.list.Select(i)
+			return
+		}
+	}
+	p.selected = 0
+	p.details.Objects = []fyne.CanvasObject{container.NewCenter(widget.NewLabel("Select a schedule to see its runs"))}
+	p.details.Refresh()
+}
+
+// daemonStatus describes the running schedulers, which run the schedules
+func daemonStatus(daemons []*schedule.Daemon, now time.Time) (string, bool) {
+	var running []string
+	for _, d := range daemons {
+		if d.Running(now) {
+			running = append(running, fmt.Sprintf("%s (pid %d) since %s",
+				d.Machine, d.PID, d.StartedAt.Local().Format("2006-01-02 15:04")))
+		}
+	}
+	if len(running) == 0 {
+		return "● Scheduler not running: schedules run once it is started (bench schedule start)", false
+	}
+	return "● Scheduler running on " + strings.Join(running, ", "), true
+}
+
+// showDetails shows the upcoming and past runs of a schedule
+func (p *SchedulesPage) showDetails(s *schedule.Schedule) {
+	content := container.NewVBox(
+		widget.NewLabelWithStyle(s.Name, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+	)
+	if s.Description != "" {
+		content.Add(widget.NewLabel(s.Description))
+	}
+	content.Add(widget.NewLabel(fmt.Sprintf("Test: %s", s.Plugin)))
+	content.Add(widget.NewLabel(fmt.Sprintf("When: %s", schedule.ParseRecurrence(s.CronExpr))))
+	if len(s.Params) > 0 {
+		content.Add(widget.NewLabel(fmt.Sprintf("Settings: %s", formatScheduleParams(s.Params))))
+	}
+
+	enabled := widget.NewCheck("Enabled", nil)
+	enabled.SetChecked(s.Enabled)
+	enabled.OnChanged = func(on bool) {
+		p.setEnabled(s, on)
+	}
+	editBtn := widget.NewButtonWithIcon("Edit", theme.DocumentCreateIcon(), func() {
+		p.showForm(s)
+	})
+	deleteBtn := widget.NewButtonWithIcon("Delete", theme.DeleteIcon(), func() {
+		p.confirmDelete(s)
+	})
+	content.Add(container.NewHBox(enabled, editBtn, deleteBtn))
+
+	// Upcoming runs
+	content.Add(widget.NewSeparator())
+	content.Add(widget.NewLabelWithStyle("Next Runs", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+	if !s.Enabled {
+		content.Add(widget.NewLabel("Schedule is disabled"))
+	} else if next, err := schedule.NextRuns(s.CronExpr, time.Now(), scheduleNextRuns); err == nil {
+		for _, t := range next {
+			content.Add(widget.NewLabel(t.Format("Mon 2006-01-02 15:04")))
+		}
+	}
+
+	// Past runs
+	content.Add(widget.NewSeparator())
+	content.Add(widget.NewLabelWithStyle("Past Runs", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+	content.Add(p.createHistory(s))
+
+	p.details.Objects = []fyne.CanvasObject{container.NewVScroll(container.NewPadded(content))}
+	p.details.Refresh()
+}
+
+// createHistory lists the outcomes of a schedule's past runs
+func (p *SchedulesPage) createHistory(s *schedule.Schedule) fyne.CanvasObject {
+	database, err := openResults(p.dbPath)
+	if err != nil {
+		return widget.NewLabel(fmt.Sprintf("Failed to open database: %v", err))
+	}
+	defer func() { _ = database.Close() }()
+
+	runs, err := schedule.NewStore(database).History(s.ID, scheduleHistoryLimit)
+	if err != nil {
+		return widget.NewLabel(err.Error())
+	}
+	if len(runs) == 0 {
+		return widget.NewLabel("This schedule has not run yet")
+	}
+
+	grid := container.NewGridWithColumns(4,
+		widget.NewLabelWithStyle("Run", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("Started", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("Duration", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("Result", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+	)
+	for _, run := range runs {
+		duration := "-"
+		if run.EndTime != nil {
+			duration = formatDuration(run.Duration())
+		}
+		status := widget.NewLabel(i18n.T("status.passed"))
+		status.Importance = widget.SuccessImportance
+		switch {
+		case run.EndTime == nil:
+			status.SetText(strings.ToUpper(i18n.T("status.running")))
+			status.Importance = widget.MediumImportance
+		case run.Interrupted:
+			status.SetText(i18n.T("status.interrupted"))
+			status.Importance = widget.WarningImportance
+		case !run.Success:
+			status.SetText(i18n.T("status.failed"))
+			status.Importance = widget.DangerImportance
+		}
+		grid.Add(widget.NewLabel(fmt.Sprintf("#%d", run.ID)))
+		grid.Add(widget.NewLabel(run.StartTime.Format("2006-01-02 15:04")))
+		grid.Add(widget.NewLabel(duration))
+		grid.Add(status)
+	}
+	return grid
+}
+
+// setEnabled enables or disables a schedule
+func (p *SchedulesPage) setEnabled(s *schedule.Schedule, enabled bool) {
+	database, err := openResults(p.dbPath)
+	if err != nil {
+		dialog.ShowError(err, p.window)
+		return
+	}
+	defer func() { _ = database.Close() }()
+
+	store := schedule.NewStore(database)
+	if enabled {
+		err = store.Enable(s.ID)
+	} else {
+		err = store.Disable(s.ID)
+	}
+	if err != nil {
+		dialog.ShowError(err, p.window)
+	}
+	p.Refresh()
+}
+
+// confirmDelete deletes a schedule after confirmation
+func (p *SchedulesPage) confirmDelete(s *schedule.Schedule) {
+	dialog.ShowConfirm("Delete Schedule", fmt.Sprintf("Delete schedule '%s'? Past test runs are kept.", s.Name), func(ok bool) {
+		if !ok {
+			return
+		}
+		database, err := openResults(p.dbPath)
+		if err != nil {
+			dialog.ShowError(err, p.window)
+			return
+		}
+		defer func() { _ = database.Close() }()
+
+		if err := schedule.NewStore(database).Delete(s.ID); err != nil {
+			dialog.ShowError(err, p.window)
+			return
+		}
+		p.Refresh()
+	}, p.window)
+}
+
+// NewSchedule opens the form for a new schedule
+func (p *SchedulesPage) NewSchedule() {
+	p.showForm(nil)
+}
+
+// showForm shows the create/edit form. existing is nil for a new schedule.
+func (p *SchedulesPage) showForm(existing *schedule.Schedule) {
+	nameEntry := widget.NewEntry()
+	descEntry := widget.NewEntry()
+	paramsEntry := widget.NewEntry()
+	paramsEntry.SetPlaceHolder("e.g. duration=10m, threads=8")
+	enabledCheck := widget.NewCheck("Enabled", nil)
+	enabledCheck.SetChecked(true)
+	resumeCheck := widget.NewCheck("Run again after an interruption, e.g. a power loss", nil)
+
+	plugins := plugin.List()
+	sort.Strings(plugins)
+	pluginSelect := widget.NewSelect(plugins, nil)
+
+	recurrence := schedule.Recurrence{Frequency: schedule.Daily, Hour: 2}
+	if existing != nil {
+		nameEntry.SetText(existing.Name)
+		descEntry.SetText(existing.Description)
+		paramsEntry.SetText(formatScheduleParams(existing.Params))
+		enabledCheck.SetChecked(existing.Enabled)
+		resumeCheck.SetChecked(existing.Resume)
+		pluginSelect.SetSelected(existing.Plugin)
+		recurrence = schedule.ParseRecurrence(existing.CronExpr)
+	} else if len(plugins) > 0 {
+		pluginSelect.SetSelected(plugins[0])
+	}
+
+	picker := newRecurrencePicker(recurrence)
+
+	title := "New Schedule"
+	confirm := "Create"
+	if existing != nil {
+		title = "Edit Schedule"
+		confirm = "Save"
+	}
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("Description", descEntry),
+		widget.NewFormItem("Test", pluginSelect),
+		widget.NewFormItem("When", picker.content),
+		widget.NewFormItem("Next run", picker.preview),
+		widget.NewFormItem("Settings", paramsEntry),
+		widget.NewFormItem("", enabledCheck),
+		widget.NewFormItem("", resumeCheck),
+	}
+
+	form := dialog.NewForm(title, confirm, "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+
+		s := &schedule.Schedule{}
+		if existing != nil {
+			copied := *existing
+			s = &copied
+		}
+		s.Name = strings.TrimSpace(nameEntry.Text)
+		s.Description = strings.TrimSpace(descEntry.Text)
+		s.Plugin = pluginSelect.Selected
+		s.Enabled = enabledCheck.Checked
+		s.Resume = resumeCheck.Checked
+
+		var err error
+		if s.CronExpr, err = picker.recurrence().CronExpr(); err != nil {
+			dialog.ShowError(err, p.window)
+			return
+		}
+		if s.Params, err = parseScheduleParams(paramsEntry.Text); err != nil {
+			dialog.ShowError(err, p.window)
+			return
+		}
+		if s.Name == "" || s.Plugin == "" {
+			dialog.ShowError(fmt.Errorf("name and test are required"), p.window)
+			return
+		}
+
+		if err := p.save(s, existing == nil); err != nil {
+			dialog.ShowError(err, p.window)
+			return
+		}
+		p.selected = s.ID
+		p.Refresh()
+	}, p.window)
+	form.Resize(fyne.NewSize(520, 480))
+	form.Show()
+}
+
+// save creates or updates a schedule
+func (p *SchedulesPage) save(s *schedule.Schedule, create bool) error {
+	database, err := openResults(p.dbPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = database.Close() }()
+
+	store := schedule.NewStore(database)
+	if create {
+		return store.Create(s)
+	}
+	return store.Update(s)
+}
+
+// recurrencePicker edits a schedule recurrence without writing cron syntax
+type recurrencePicker struct {
+	content *fyne.Container
+	preview *widget.Label
+
+	frequency *widget.Select
+	timeEntry *widget.Entry
+	minute    *widget.Entry
+	weekday   *widget.Select
+	day       *widget.Entry
+	cron      *widget.Entry
+	options   *fyne.Container
+}
+
+// newRecurrencePicker creates a picker showing r
+func newRecurrencePicker(r schedule.Recurrence) *recurrencePicker {
+	rp := &recurrencePicker{
+		preview:   widget.NewLabel(""),
+		timeEntry: widget.NewEntry(),
+		minute:    widget.NewEntry(),
+		day:       widget.NewEntry(),
+		cron:      widget.NewEntry(),
+		options:   container.NewHBox(),
+	}
+
+	weekdays := make([]string, 7)
+	for i := range weekdays {
+		weekdays[i] = time.Weekday(i).String()
+	}
+	rp.weekday = widget.NewSelect(weekdays, func(string) { rp.update() })
+
+	labels := make([]string, len(schedule.Frequencies))
+	for i, f := range schedule.Frequencies {
+		labels[i] = frequencyLabels[f]
+	}
+	rp.frequency = widget.NewSelect(labels, func(string) { rp.update() })
+
+	rp.timeEntry.SetPlaceHolder("HH:MM")
+	rp.timeEntry.SetText(fmt.Sprintf("%02d:%02d", r.Hour, r.Minute))
+	rp.minute.SetText(strconv.Itoa(r.Minute))
+	rp.weekday.SetSelected(r.Weekday.String())
+	if r.Day == 0 {
+		r.Day = 1
+	}
+	rp.day.SetText(strconv.Itoa(r.Day))
+	rp.cron.SetPlaceHolder("minute hour day month weekday")
+	rp.cron.SetText(r.Expr)
+
+	for _, entry := range []*widget.Entry{rp.timeEntry, rp.minute, rp.day, rp.cron} {
+		entry.OnChanged = func(string) { rp.update() }
+	}
+
+	rp.content = container.NewVBox(rp.frequency, rp.options)
+	rp.frequency.SetSelected(frequencyLabels[r.Frequency])
+	return rp
+}
+
+// selectedFrequency returns the frequency chosen in the picker
+func (rp *recurrencePicker) selectedFrequency() schedule.Frequency {
+	for f, label := range frequencyLabels {
+		if label == rp.frequency.Selected {
+			return f
+		}
+	}
+	return schedule.Daily
+}
+
+// recurrence reads the recurrence from the picker fields
+func (rp *recurrencePicker) recurrence() schedule.Recurrence {
+	r := schedule.Recurrence{Frequency: rp.selectedFrequency(), Expr: strings.TrimSpace(rp.cron.Text)}
+
+	if r.Frequency == schedule.Hourly {
+		r.Minute = parseIntOr(rp.minute.Text, -1)
+	} else if hour, minute, ok := strings.Cut(strings.TrimSpace(rp.timeEntry.Text), ":"); ok {
+		r.Hour = parseIntOr(hour, -1)
+		r.Minute = parseIntOr(minute, -1)
+	} else {
+		r.Hour, r.Minute = -1, -1
+	}
+
+	for i := 0; i < 7; i++ {
+		if time.Weekday(i).String() == rp.weekday.Selected {
+			r.Weekday = time.Weekday(i)
+		}
+	}
+	r.Day = parseIntOr(rp.day.Text, 0)
+	return r
+}
+
+// update shows the fields for the chosen frequency and refreshes the preview
+func (rp *recurrencePicker) update() {
+	var fields []fyne.CanvasObject
+	switch rp.selectedFrequency() {
+	case schedule.Hourly:
+		fields = []fyne.CanvasObject{widget.NewLabel("at minute"), rp.minute}
+	case schedule.Daily, schedule.Weekdays:
+		fields = []fyne.CanvasObject{widget.NewLabel("at"), rp.timeEntry}
+	case schedule.Weekly:
+		fields = []fyne.CanvasObject{widget.NewLabel("on"), rp.weekday, widget.NewLabel("at"), rp.timeEntry}
+	case schedule.Monthly:
+		fields = []fyne.CanvasObject{widget.NewLabel("on day"), rp.day, widget.NewLabel("at"), rp.timeEntry}
+	case schedule.Custom:
+		fields = []fyne.CanvasObject{rp.cron}
+	}
+	rp.options.Objects = fields
+	rp.options.Refresh()
+
+	r := rp.recurrence()
+	expr, err := r.CronExpr()
+	if err != nil {
+		rp.preview.SetText(err.Error())
+		return
+	}
+	next, err := schedule.NextRuns(expr, time.Now(), 1)
+	if err != nil {
+		rp.preview.SetText(err.Error())
+		return
+	}
+	rp.preview.SetText(fmt.Sprintf("%s (%s)", next[0].Format("Mon 2006-01-02 15:04"), schedule.ParseRecurrence(expr)))
+}
+
+// parseIntOr parses s as an integer, returning fallback if it is not one
+func parseIntOr(s string, fallback int) int {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return fallback
+	}
+	return n
+}
+
+// parseScheduleParams parses "key=value, key=value" test settings. Numbers
+// and booleans are stored typed, as "bench schedule add --config" does.
+func parseScheduleParams(text string) (db.JSONData, error) {
+	params := make(db.JSONData)
+	for _, pair := range strings.Split(text, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid setting %q, expected key=value", pair)
+		}
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			params[key] = int(n)
+		} else if f, err := strconv.ParseFloat(value, 64); err == nil {
+			params[key] = f
+		} else if value == "true" || value == "false" {
+			params[key] = value == "true"
+		} else {
+			params[key] = value
+		}
+	}
+	return params, nil
+}
+
+// formatScheduleParams formats test settings as "key=value, key=value"
+func formatScheduleParams(params db.JSONData) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = fmt.Sprintf("%s=%v", key, params[key])
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// formatScheduleTime formats an optional schedule time
+func formatScheduleTime(t *time.Time) string {
+	if t == nil {
+		return "not scheduled"
+	}
+	return t.Local().Format("Mon 15:04")
+}
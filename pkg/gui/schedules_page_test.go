@@ -0,0 +1,28 @@
+package gui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/schedule"
+)
+
+func TestDaemonStatus(t *testing.T) {
+	now := time.Now()
+	if text, running := daemonStatus(nil, now); running || !strings.Contains(text, "not running") {
+		t.Errorf("expected no scheduler running, got %q", text)
+	}
+
+	stopped := &schedule.Daemon{Machine: db.Hostname() + "-lab", PID: 7, StartedAt: now.Add(-time.Hour), Heartbeat: now.Add(-time.Hour)}
+	if _, running := daemonStatus([]*schedule.Daemon{stopped}, now); running {
+		t.Error("expected a scheduler without recent heartbeats to be stopped")
+	}
+
+	stopped.Heartbeat = now
+	text, running := daemonStatus([]*schedule.Daemon{stopped}, now)
+	if !running || !strings.Contains(text, db.Hostname()+"-lab (pid 7)") {
+		t.Errorf("expected the scheduler on the lab machine to run, got %q", text)
+	}
+}
@@ -0,0 +1,245 @@
+package gui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+// sensorHistoryInterval is how often the dashboard's readings are added to
+// the sensor history in the database
+const sensorHistoryInterval = 10 * time.Second
+
+// maxPendingHistory bounds the readings kept while the database cannot be
+// written, about ten minutes of dashboard updates
+var maxPendingHistory = 600 * len(sessionMetrics)
+
+// sensorHistorySpans are the lengths of time the history window can show
+var sensorHistorySpans = []struct {
+	label string
+	span  time.Duration
+}{
+	{"Last hour", time.Hour},
+	{"Last day", 24 * time.Hour},
+	{"Last week", 7 * 24 * time.Hour},
+}
+
+// addHistory queues a dashboard update for the sensor history. Zero values
+// mean the sensor was unavailable and are left out, as in sessions.
+func (d *Dashboard) addHistory(at time.Time, metrics map[string]float64) {
+	d.historyMu.Lock()
+	defer d.historyMu.Unlock()
+	for name, value := range metrics {
+		if value != 0 {
+			d.pendingHistory = append(d.pendingHistory, db.SensorSample{Time: at, Metric: name, Value: value})
+		}
+	}
+	if n := len(d.pendingHistory); n > maxPendingHistory {
+		d.pendingHistory = d.pendingHistory[n-maxPendingHistory:]
+	}
+}
+
+// RecordHistory adds the queued readings to the sensor history in the
+// database at dbPath every sensorHistoryInterval until the dashboard stops
+func (d *Dashboard) RecordHistory(dbPath string) {
+	database, err := openResults(dbPath)
+	if err != nil {
+		logger.Warn("Sensor history not recorded", "error", err)
+		return
+	}
+	defer func() { _ = database.Close() }()
+
+	flush := func() {
+		d.historyMu.Lock()
+		samples := d.pendingHistory
+		d.pendingHistory = nil
+		d.historyMu.Unlock()
+
+		if err := database.RecordSensorSamples(samples); err != nil {
+			logger.Warn("Sensor history not recorded", "error", err)
+			d.historyMu.Lock()
+			d.pendingHistory = append(samples, d.pendingHistory...)
+			d.historyMu.Unlock()
+		}
+	}
+
+	ticker := time.NewTicker(sensorHistoryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			flush()
+		case <-d.stopChan:
+			flush()
+			return
+		}
+	}
+}
+
+// ShowSensorHistory opens a window charting a recorded sensor over the last
+// hour, day or week, with the chart's buckets exportable as CSV
+func ShowSensorHistory(app fyne.App, dbPath string) fyne.Window {
+	window := app.NewWindow("F.I.R.E. - Sensor History")
+	window.Resize(fyne.NewSize(1000, 600))
+
+	database, err := openResults(dbPath)
+	if err != nil {
+		window.SetContent(container.NewCenter(widget.NewLabel(fmt.Sprintf("Failed to open database: %v", err))))
+		window.Show()
+		return window
+	}
+	window.SetOnClosed(func() { _ = database.Close() })
+
+	var (
+		span    = time.Hour
+		buckets []db.Bucket
+	)
+
+	chart := NewEnhancedLineChart("", 1, 0)
+	chart.SetShowDataPoints(false)
+	summary := widget.NewLabel("Select a sensor")
+	summary.Wrapping = fyne.TextWrapWord
+
+	metricSelect := widget.NewSelect(nil, nil)
+	metricSelect.PlaceHolder = "Select a sensor"
+
+	load := func() {
+		metric := metricSelect.Selected
+		if metric == "" {
+			return
+		}
+		until := time.Now()
+		since := until.Add(-span)
+		tier, recorded, err := database.SensorHistory(metric, since, until)
+		if err != nil {
+			summary.SetText(err.Error())
+			return
+		}
+		buckets = recorded
+		chart.SetUnit(metricUnit(metric))
+		chart.SetValues(historyValues(buckets, tier, since, until))
+		summary.SetText(describeHistory(buckets, tier, since, until))
+	}
+	metricSelect.OnChanged = func(string) { load() }
+
+	refresh := func() {
+		metrics, err := database.SensorHistoryMetrics()
+		if err != nil {
+			summary.SetText(err.Error())
+			return
+		}
+		metricSelect.Options = metrics
+		metricSelect.Refresh()
+		if len(metrics) == 0 {
+			summary.SetText("No sensor history yet. Readings are recorded while the dashboard is open.")
+		}
+		load()
+	}
+
+	spanLabels := make([]string, len(sensorHistorySpans))
+	for i, s := range sensorHistorySpans {
+		spanLabels[i] = s.label
+	}
+	spanSelect := widget.NewSelect(spanLabels, func(string) {})
+	spanSelect.SetSelected(spanLabels[0])
+	spanSelect.OnChanged = func(string) {
+		span = sensorHistorySpans[spanSelect.SelectedIndex()].span
+		load()
+	}
+
+	exportButton := widget.NewButtonWithIcon("Export CSV...", theme.DocumentSaveIcon(), func() {
+		metric := metricSelect.Selected
+		if metric == "" || len(buckets) == 0 {
+			dialog.ShowInformation("Export CSV", "Select a sensor with recorded history first", window)
+			return
+		}
+		exported := buckets
+		saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			defer func() { _ = writer.Close() }()
+
+			if err := db.WriteSensorHistoryCSV(writer, metric, exported); err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			dialog.ShowInformation("History Exported",
+				fmt.Sprintf("Saved %d readings of %s to %s", len(exported), metric, writer.URI().Name()), window)
+		}, window)
+		saveDialog.SetFileName("fire-history-" + time.Now().Format("20060102-150405") + ".csv")
+		saveDialog.SetFilter(storage.NewExtensionFileFilter([]string{".csv"}))
+		saveDialog.Show()
+	})
+
+	toolbar := container.NewBorder(nil, nil,
+		container.NewHBox(widget.NewLabel("Span:"), spanSelect, widget.NewLabel("Sensor:")),
+		container.NewHBox(widget.NewButtonWithIcon("Refresh", theme.ViewRefreshIcon(), refresh), exportButton),
+		metricSelect)
+	window.SetContent(container.NewBorder(toolbar, summary, nil, nil, chart))
+
+	refresh()
+	window.Show()
+	return window
+}
+
+// historyValues spreads buckets over the span, one value per bucket of the
+// tier, with NaN where nothing was recorded so the chart shows the gap
+func historyValues(buckets []db.Bucket, tier db.HistoryTier, since, until time.Time) []float64 {
+	n := int(until.Sub(since) / tier.Resolution)
+	if n < 1 {
+		return nil
+	}
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = math.NaN()
+	}
+	for _, b := range buckets {
+		if i := int(b.Start.Sub(since) / tier.Resolution); i >= 0 && i < n {
+			values[i] = b.Avg
+		}
+	}
+	return values
+}
+
+// describeHistory summarizes the buckets shown, e.g. "Average 64.2, min
+// 41.0, max 88.5 over 312 readings at 10.0s resolution, ..."
+func describeHistory(buckets []db.Bucket, tier db.HistoryTier, since, until time.Time) string {
+	if len(buckets) == 0 {
+		return "Nothing recorded from " + since.Local().Format("2006-01-02 15:04") + " to " + until.Local().Format("15:04")
+	}
+	low, high := buckets[0].Min, buckets[0].Max
+	var sum float64
+	var count int
+	for _, b := range buckets {
+		low, high = math.Min(low, b.Min), math.Max(high, b.Max)
+		sum += b.Avg * float64(b.Count)
+		count += b.Count
+	}
+	return fmt.Sprintf("Average %.1f, min %.1f, max %.1f over %d readings at %s resolution, from %s to %s",
+		sum/float64(count), low, high, count, formatDuration(tier.Resolution),
+		since.Local().Format("2006-01-02 15:04"), until.Local().Format("2006-01-02 15:04"))
+}
+
+// metricUnit returns the unit in a metric's name, e.g. "°C" for
+// "CPU Temp (°C)"
+func metricUnit(name string) string {
+	start, end := strings.LastIndex(name, "("), strings.LastIndex(name, ")")
+	if start < 0 || end < start {
+		return ""
+	}
+	return name[start+1 : end]
+}
@@ -0,0 +1,41 @@
+package gui
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+func TestHistoryValues(t *testing.T) {
+	tier := db.HistoryTier{Resolution: 10 * time.Second, Slots: 360}
+	since := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	buckets := []db.Bucket{
+		{Start: since.Add(-10 * time.Second), Avg: 40}, // Before the span
+		{Start: since, Avg: 50},
+		{Start: since.Add(30 * time.Second), Avg: 60},
+	}
+	values := historyValues(buckets, tier, since, since.Add(time.Minute))
+	if len(values) != 6 {
+		t.Fatalf("expected one value per bucket of the span, got %v", values)
+	}
+	if values[0] != 50 || values[3] != 60 {
+		t.Errorf("expected the buckets at their times, got %v", values)
+	}
+	if !math.IsNaN(values[1]) || !math.IsNaN(values[5]) {
+		t.Errorf("expected gaps where nothing was recorded, got %v", values)
+	}
+}
+
+func TestMetricUnit(t *testing.T) {
+	for name, want := range map[string]string{
+		"CPU Temp (°C)":   "°C",
+		"GPU Clock (MHz)": "MHz",
+		"Fan":             "",
+	} {
+		if got := metricUnit(name); got != want {
+			t.Errorf("%s: expected %q, got %q", name, want, got)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package gui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/session"
+	"github.com/mscrnt/project_fire/pkg/snippet"
+	"github.com/mscrnt/project_fire/pkg/virt"
+)
+
+// sessionRunLimit is how many recent test runs are included in a saved session
+const sessionRunLimit = 20
+
+// sessionMetric is a dashboard reading kept in recorded sessions
+type sessionMetric struct {
+	component string // Groups the recording choices, e.g. "CPU"
+	name      string // Includes the unit, e.g. "CPU Temp (°C)"
+	value     func(*MetricData) float64
+}
+
+// sessionMetrics lists the readings the session recorder can keep
+var sessionMetrics = []sessionMetric{
+	{"CPU", "CPU Temp (°C)", func(d *MetricData) float64 { return d.CPUDieTemp }},
+	{"CPU", "CPU Voltage (V)", func(d *MetricData) float64 { return d.CPUVoltage }},
+	{"CPU", "CPU Power (W)", func(d *MetricData) float64 { return d.CPUPackagePower }},
+	{"CPU", "CPU Usage (%)", func(d *MetricData) float64 { return d.CPUUsage }},
+	{"CPU", "CPU Clock (GHz)", func(d *MetricData) float64 { return d.CPUClock }},
+	{"Memory", "Memory Usage (%)", func(d *MetricData) float64 { return d.MemUsage }},
+	{"Memory", "Memory Used (GB)", func(d *MetricData) float64 { return d.MemUsedGB }},
+	{"GPU", "GPU Usage (%)", func(d *MetricData) float64 { return d.GPUUsage }},
+	{"GPU", "GPU Temp (°C)", func(d *MetricData) float64 { return d.GPUTemp }},
+	{"GPU", "GPU Power (W)", func(d *MetricData) float64 { return d.GPUPower }},
+	{"GPU", "GPU Clock (MHz)", func(d *MetricData) float64 { return d.GPUClock }},
+	{"Storage", "Disk Read (MB/s)", func(d *MetricData) float64 { return d.DiskRead }},
+	{"Storage", "Disk Write (MB/s)", func(d *MetricData) float64 { return d.DiskWrite }},
+	{"Network", "Network Download (MB/s)", func(d *MetricData) float64 { return d.NetDown }},
+	{"Network", "Network Upload (MB/s)", func(d *MetricData) float64 { return d.NetUp }},
+}
+
+// recordSession adds the latest dashboard readings to the session recorder
+func (d *Dashboard) recordSession(data *MetricData) {
+	metrics := make(map[string]float64, len(sessionMetrics))
+	for _, m := range sessionMetrics {
+		metrics[m.name] = m.value(data)
+	}
+	now := time.Now()
+	d.recorder.Add(now, metrics)
+	d.addHistory(now, metrics)
+}
+
+// sessionSizeEstimate describes how much disk a full saved session takes
+// when recording the named metrics
+func sessionSizeEstimate(names []string, capacity int) string {
+	size := uint64(session.SampleSize(names)) * uint64(capacity) // #nosec G115 -- sizes and capacities are non-negative
+	return fmt.Sprintf("About %s per saved session (the last %s at one sample per second)",
+		formatBytes(size), formatDuration(time.Duration(capacity)*time.Second))
+}
+
+// SessionSnapshot captures the hardware inventory, the recorded telemetry and
+// the most recent test runs from the database at dbPath
+func (d *Dashboard) SessionSnapshot(dbPath string) *session.File {
+	f := session.NewSession()
+	f.Samples = d.recorder.Samples()
+
+	if d.sysInfo != nil {
+		f.System = map[string]string{
+			"Hostname":     d.sysInfo.Host.Hostname,
+			"Platform":     strings.TrimSpace(d.sysInfo.Host.Platform + " " + d.sysInfo.Host.PlatformVersion),
+			"Kernel":       d.sysInfo.Host.KernelVersion,
+			"Architecture": d.sysInfo.Host.Architecture,
+		}
+		if env := virt.Detect(); env.Virtual() {
+			f.System["Environment"] = env.Label()
+		}
+	}
+
+	for _, comp := range d.Components() {
+		f.Components = append(f.Components, session.Component{
+			Type:    comp.Type,
+			Name:    comp.Name,
+			Details: comp.Details,
+		})
+	}
+
+	database, err := openResults(dbPath)
+	if err != nil {
+		logger.Warn("Session saved without test runs", "error", err)
+		return f
+	}
+	defer func() { _ = database.Close() }()
+
+	runs, err := database.ListRuns(db.RunFilter{Limit: sessionRunLimit})
+	if err != nil {
+		logger.Warn("Session saved without test runs", "error", err)
+		return f
+	}
+	for _, run := range runs {
+		results, err := database.GetResults(run.ID)
+		if err != nil {
+			continue
+		}
+		f.Runs = append(f.Runs, session.RunRecord{Run: run, Results: results})
+	}
+	return f
+}
+
+// saveSession asks for a destination and writes the current session to it
+func (g *FireGUI) saveSession() {
+	snapshot := g.dashboard.SessionSnapshot(g.dbPath)
+
+	saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, g.window)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer func() { _ = writer.Close() }()
+
+		if err := session.Write(writer, snapshot); err != nil {
+			dialog.ShowError(err, g.window)
+			return
+		}
+		dialog.ShowInformation("Session Saved",
+			fmt.Sprintf("Saved %d samples and %d test runs to %s", len(snapshot.Samples), len(snapshot.Runs), writer.URI().Name()),
+			g.window)
+	}, g.window)
+	saveDialog.SetFileName("fire-" + time.Now().Format("20060102-150405") + session.SessionExtension)
+	saveDialog.SetFilter(storage.NewExtensionFileFilter([]string{session.SessionExtension}))
+	saveDialog.Show()
+}
+
+// copySnippet copies the hardware inventory and the latest score of each test
+// to the clipboard, formatted for a forum post
+func (g *FireGUI) copySnippet(format snippet.Format) {
+	s := snippet.FromSession(g.dashboard.SessionSnapshot(g.dbPath))
+	g.app.Clipboard().SetContent(s.Render(format))
+
+	name := "Markdown"
+	if format == snippet.BBCode {
+		name = "BBCode"
+	}
+	dialog.ShowInformation("Copied to Clipboard",
+		fmt.Sprintf("Copied %d components and %d test results as %s", len(s.Specs), len(s.Runs), name),
+		g.window)
+}
+
+// openSessionFile asks for a report or session file and opens it in the viewer
+func (g *FireGUI) openSessionFile() {
+	openDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, g.window)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defer func() { _ = reader.Close() }()
+
+		f, err := session.Read(reader)
+		if err != nil {
+			dialog.ShowError(err, g.window)
+			return
+		}
+		ShowSessionViewer(g.app, f, reader.URI().Name())
+	}, g.window)
+	openDialog.SetFilter(storage.NewExtensionFileFilter([]string{session.ReportExtension, session.SessionExtension}))
+	openDialog.Show()
+}
+
+// ShowSessionViewer opens a window showing a report or a recorded session.
+// Sessions get a timeline slider that replays the telemetry as the customer's
+// dashboard showed it.
+func ShowSessionViewer(app fyne.App, f *session.File, name string) fyne.Window {
+	window := app.NewWindow("F.I.R.E. - " + name)
+	window.Resize(fyne.NewSize(900, 650))
+
+	tabs := container.NewAppTabs()
+	if len(f.Samples) > 0 {
+		tabs.Append(container.NewTabItem("Telemetry", createReplayView(f)))
+	}
+	if len(f.Components) > 0 || len(f.System) > 0 {
+		tabs.Append(container.NewTabItem("System", createSessionSystemView(f)))
+	}
+	tabs.Append(container.NewTabItem("Test Runs", createSessionRunsView(f.AllRuns())))
+
+	header := widget.NewLabelWithStyle(sessionSummary(f), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	window.SetContent(container.NewBorder(header, nil, nil, nil, tabs))
+	window.Show()
+	return window
+}
+
+// sessionSummary describes the file in one line
+func sessionSummary(f *session.File) string {
+	if !f.IsSession() {
+		return fmt.Sprintf("Test report created %s", f.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+	return fmt.Sprintf("Session recorded %s: %d samples over %s, %d test runs",
+		f.CreatedAt.Local().Format("2006-01-02 15:04:05"), len(f.Samples), formatDuration(f.Duration()), len(f.Runs))
+}
+
+// createReplayView shows the recorded metrics at the position of a timeline slider
+func createReplayView(f *session.File) fyne.CanvasObject {
+	names := f.MetricNames()
+
+	// Per-metric range over the whole recording, shown next to the replayed value
+	minValues := make(map[string]float64)
+	maxValues := make(map[string]float64)
+	for _, s := range f.Samples {
+		for name, value := range s.Metrics {
+			if current, ok := minValues[name]; !ok || value < current {
+				minValues[name] = value
+			}
+			if value > maxValues[name] {
+				maxValues[name] = value
+			}
+		}
+	}
+
+	valueLabels := make(map[string]*widget.Label, len(names))
+	grid := container.NewGridWithColumns(3,
+		widget.NewLabelWithStyle("Metric", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("Value", fyne.TextAlignTrailing, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("Min / Max", fyne.TextAlignTrailing, fyne.TextStyle{Bold: true}),
+	)
+	for _, name := range names {
+		valueLabels[name] = widget.NewLabelWithStyle("-", fyne.TextAlignTrailing, fyne.TextStyle{Monospace: true})
+		grid.Add(widget.NewLabel(name))
+		grid.Add(valueLabels[name])
+		grid.Add(widget.NewLabelWithStyle(fmt.Sprintf("%.2f / %.2f", minValues[name], maxValues[name]),
+			fyne.TextAlignTrailing, fyne.TextStyle{Monospace: true}))
+	}
+
+	positionLabel := widget.NewLabel("")
+	show := func(idx int) {
+		s := f.Samples[idx]
+		positionLabel.SetText(fmt.Sprintf("%s (+%s)", s.Time.Local().Format("15:04:05"),
+			formatDuration(s.Time.Sub(f.Samples[0].Time))))
+		for _, name := range names {
+			if value, ok := s.Metrics[name]; ok {
+				valueLabels[name].SetText(fmt.Sprintf("%.2f", value))
+			} else {
+				valueLabels[name].SetText("-")
+			}
+		}
+	}
+
+	slider := widget.NewSlider(0, float64(len(f.Samples)-1))
+	slider.Step = 1
+	slider.OnChanged = func(value float64) {
+		show(int(value))
+	}
+	show(0)
+
+	return container.NewBorder(
+		container.NewBorder(nil, nil, nil, positionLabel, slider),
+		nil, nil, nil,
+		container.NewVScroll(grid),
+	)
+}
+
+// createSessionSystemView lists the system information and hardware components
+func createSessionSystemView(f *session.File) fyne.CanvasObject {
+	content := container.NewVBox()
+
+	if len(f.System) > 0 {
+		content.Add(widget.NewLabelWithStyle("System", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		content.Add(sortedDetailsGrid(f.System))
+	}
+
+	for _, comp := range f.Components {
+		content.Add(widget.NewSeparator())
+		content.Add(widget.NewLabelWithStyle(fmt.Sprintf("%s: %s", comp.Type, comp.Name), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		if len(comp.Details) > 0 {
+			content.Add(sortedDetailsGrid(comp.Details))
+		}
+	}
+
+	return container.NewVScroll(content)
+}
+
+// sortedDetailsGrid shows key/value pairs in name order
+func sortedDetailsGrid(details map[string]string) fyne.CanvasObject {
+	keys := make([]string, 0, len(details))
+	for key := range details {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	grid := container.NewGridWithColumns(2)
+	for _, key := range keys {
+		grid.Add(widget.NewLabel(key))
+		grid.Add(widget.NewLabel(details[key]))
+	}
+	return grid
+}
+
+// createSessionRunsView lists test runs with their results
+func createSessionRunsView(runs []session.RunRecord) fyne.CanvasObject {
+	if len(runs) == 0 {
+		return widget.NewLabel("No test runs in this file")
+	}
+
+	items := make([]*widget.AccordionItem, 0, len(runs))
+	for _, record := range runs {
+		run := record.Run
+		status := "PASS"
+		if !run.Success {
+			status = "FAIL"
+		}
+
+		details := container.NewVBox(
+			widget.NewLabel(fmt.Sprintf("Start Time: %s", run.StartTime.Format("2006-01-02 15:04:05"))),
+		)
+		if run.EndTime != nil {
+			details.Add(widget.NewLabel(fmt.Sprintf("Duration: %s", formatDuration(run.Duration()))))
+		}
+		if run.Environment != "" {
+			details.Add(widget.NewLabel(fmt.Sprintf("Environment: %s", run.Environment)))
+		}
+		if run.Error != "" {
+			details.Add(widget.NewLabel(fmt.Sprintf("Error: %s", run.Error)))
+		}
+		if len(record.Results) > 0 {
+			grid := container.NewGridWithColumns(2)
+			for _, result := range record.Results {
+				grid.Add(widget.NewLabel(result.Metric))
+				grid.Add(widget.NewLabel(fmt.Sprintf("%.2f %s", result.Value, result.Unit)))
+			}
+			details.Add(grid)
+		}
+
+		title := fmt.Sprintf("Run #%d  %s  %s", run.ID, run.Plugin, status)
+		items = append(items, widget.NewAccordionItem(title, details))
+	}
+
+	accordion := widget.NewAccordion(items...)
+	if len(items) == 1 {
+		accordion.Open(0)
+	}
+	return container.NewVScroll(accordion)
+}
@@ -0,0 +1,254 @@
+package gui
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/mscrnt/project_fire/pkg/config"
+	"github.com/mscrnt/project_fire/pkg/telemetry"
+	"github.com/mscrnt/project_fire/pkg/units"
+)
+
+// updateIntervals are offered for the dashboard; gui.update_interval in
+// the config file takes any duration from config.MinUpdateInterval
+var updateIntervals = []string{"250ms", "500ms", "1s", "2s", "5s", "10s"}
+
+// themeNames are the labels of config.Themes
+var themeNames = map[string]string{
+	config.ThemeDark:   "F.I.R.E. Dark",
+	config.ThemeLight:  "Light",
+	config.ThemeSystem: "Follow the system",
+}
+
+// settingsAlertsTab is the index of the alert rules in the Settings tabs
+const settingsAlertsTab = 1
+
+// createSettingsPage builds the Settings page: the preferences kept in the
+// config file shared with bench and the alert rules with their thresholds
+func (g *FireGUI) createSettingsPage() fyne.CanvasObject {
+	g.settingsGeneral = container.NewStack()
+	g.refreshSettings()
+
+	g.settingsTabs = container.NewAppTabs(
+		container.NewTabItemWithIcon("General", theme.SettingsIcon(), g.settingsGeneral),
+		container.NewTabItemWithIcon("Alerts", theme.WarningIcon(), g.alerts.Content()),
+	)
+	g.settingsTabs.OnSelected = func(tab *container.TabItem) {
+		if tab.Text == "General" {
+			g.refreshSettings()
+		}
+	}
+	g.dashboard.SetOpenSettings(func() { g.navigation.ShowPage(6) })
+	return g.settingsTabs
+}
+
+// showAlertSettings opens the alert rules under Settings
+func (g *FireGUI) showAlertSettings() {
+	g.navigation.ShowPage(6)
+	if g.settingsTabs != nil {
+		g.settingsTabs.SelectIndex(settingsAlertsTab)
+	}
+}
+
+// refreshSettings rebuilds the General tab from the saved settings, e.g.
+// after another profile was made active
+func (g *FireGUI) refreshSettings() {
+	if g.settingsGeneral == nil {
+		return
+	}
+	g.settingsGeneral.Objects = []fyne.CanvasObject{g.createGeneralSettings()}
+	g.settingsGeneral.Refresh()
+}
+
+// createGeneralSettings builds the form of the General tab. Every change
+// is saved and applied straight away.
+func (g *FireGUI) createGeneralSettings() fyne.CanvasObject {
+	settings, err := config.Load()
+	if err != nil {
+		return widget.NewLabel(fmt.Sprintf("Failed to load settings: %v", err))
+	}
+
+	// Theme
+	themeLabels := make([]string, len(config.Themes))
+	for i, name := range config.Themes {
+		themeLabels[i] = themeNames[name]
+	}
+	themeSelect := widget.NewSelect(themeLabels, nil)
+	themeSelect.SetSelectedIndex(indexOf(config.Themes, settings.GUI.Theme))
+	themeSelect.OnChanged = func(string) {
+		if g.saveSetting("gui.theme", config.Themes[themeSelect.SelectedIndex()]) {
+			g.app.Settings().SetTheme(SettingsTheme())
+		}
+	}
+
+	// Update interval
+	intervals := updateIntervals
+	current := settings.GUI.Interval().String()
+	if indexOf(intervals, current) < 0 {
+		intervals = append(append([]string{}, intervals...), current)
+	}
+	intervalSelect := widget.NewSelect(intervals, nil)
+	intervalSelect.SetSelected(current)
+	intervalSelect.OnChanged = func(value string) {
+		if g.saveSetting("gui.update_interval", value) {
+			if d, err := time.ParseDuration(value); err == nil {
+				g.dashboard.SetUpdateInterval(d)
+			}
+		}
+	}
+
+	// Units
+	tempSelect := unitSelect("units.temperature", units.Temperatures, settings.Units.Temperature, g.applyUnits)
+	rateSelect := unitSelect("units.data_rate", units.DataRates, settings.Units.DataRate, g.applyUnits)
+	freqSelect := unitSelect("units.frequency", units.Frequencies, settings.Units.Frequency, g.applyUnits)
+	tempItem := g.settingControl("units.temperature", tempSelect)
+	if unit := g.profiles.Current().Units.Temperature; unit != "" {
+		note := widget.NewLabel(fmt.Sprintf("The profile %q shows %s", g.profiles.Current().Name, temperatureChoice(unit)))
+		note.Importance = widget.WarningImportance
+		tempItem = container.NewHBox(tempItem, note)
+	}
+
+	// Startup
+	startLabels := append([]string{"Last page used"}, pageNames...)
+	startSelect := widget.NewSelect(startLabels, nil)
+	startSelect.SetSelectedIndex(max(indexOf(config.StartPages, settings.GUI.StartPage), 0))
+	startSelect.OnChanged = func(string) {
+		g.saveSetting("gui.start_page", config.StartPages[startSelect.SelectedIndex()])
+	}
+	splashCheck := widget.NewCheck("Show the loading screen while hardware is detected", nil)
+	splashCheck.SetChecked(settings.GUI.Splash == nil || *settings.GUI.Splash)
+	splashCheck.OnChanged = func(on bool) {
+		g.saveSetting("gui.splash", strconv.FormatBool(on))
+	}
+	trayCheck := widget.NewCheck("Keep running in the system tray when the window is closed (from the next start)", nil)
+	trayCheck.SetChecked(settings.GUI.Tray)
+	trayCheck.OnChanged = func(on bool) {
+		g.saveSetting("gui.tray", strconv.FormatBool(on))
+	}
+
+	// Telemetry
+	telemetryCheck := widget.NewCheck("Send anonymous hardware compatibility and crash reports", nil)
+	telemetryCheck.SetChecked(settings.Telemetry.Enabled == nil || *settings.Telemetry.Enabled)
+	telemetryCheck.OnChanged = func(on bool) {
+		if g.saveSetting("telemetry.enabled", strconv.FormatBool(on)) {
+			telemetry.SetEnabled(on)
+		}
+	}
+
+	form := widget.NewForm(
+		widget.NewFormItem("Theme", g.settingControl("gui.theme", themeSelect)),
+		widget.NewFormItem("Update interval", g.settingControl("gui.update_interval", intervalSelect)),
+		widget.NewFormItem("Temperature", tempItem),
+		widget.NewFormItem("Data rates", g.settingControl("units.data_rate", rateSelect)),
+		widget.NewFormItem("Clocks", g.settingControl("units.frequency", freqSelect)),
+		widget.NewFormItem("Open on start", g.settingControl("gui.start_page", startSelect)),
+		widget.NewFormItem("Startup", g.settingControl("gui.splash", splashCheck)),
+		widget.NewFormItem("Tray", g.settingControl("gui.tray", trayCheck)),
+		widget.NewFormItem("Telemetry", g.settingControl("telemetry.enabled", telemetryCheck)),
+	)
+
+	note := widget.NewLabel(fmt.Sprintf("Saved in %s, shared with bench (see bench config).", config.Path()))
+	note.Importance = widget.LowImportance
+	note.Wrapping = fyne.TextWrapWord
+
+	profilesBtn := widget.NewButtonWithIcon("Operator Profiles...", theme.AccountIcon(), g.showProfileEditor)
+	alertsBtn := widget.NewButtonWithIcon("Alert Rules and Thresholds", theme.WarningIcon(), g.showAlertSettings)
+
+	return container.NewVScroll(container.NewPadded(container.NewVBox(
+		widget.NewLabelWithStyle("Preferences", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		note,
+		widget.NewSeparator(),
+		form,
+		widget.NewSeparator(),
+		container.NewHBox(profilesBtn, alertsBtn),
+	)))
+}
+
+// unitLabels describe the unit choices
+var unitLabels = map[string]string{
+	units.Celsius:    "Celsius (°C)",
+	units.Fahrenheit: "Fahrenheit (°F)",
+	units.MBps:       "MB/s (10^6 bytes)",
+	units.MiBps:      "MiB/s (2^20 bytes)",
+	units.Auto:       "As measured",
+	units.MHz:        "MHz",
+	units.GHz:        "GHz",
+}
+
+// unitSelect offers the choices of a unit setting and saves the one picked
+// under key
+func unitSelect(key string, choices []string, current string, saved func(key, value string)) *widget.Select {
+	labels := make([]string, len(choices))
+	for i, c := range choices {
+		labels[i] = unitLabels[c]
+	}
+	sel := widget.NewSelect(labels, nil)
+	sel.SetSelectedIndex(max(indexOf(choices, current), 0))
+	sel.OnChanged = func(string) {
+		saved(key, choices[sel.SelectedIndex()])
+	}
+	return sel
+}
+
+// applyUnits saves a unit setting and shows every reading in the new units
+func (g *FireGUI) applyUnits(key, value string) {
+	if !g.saveSetting(key, value) {
+		return
+	}
+	setDisplayPreferences(g.profiles.Current())
+	g.dashboard.RefreshUnits()
+	g.refresh()
+}
+
+// settingControl disables a control whose setting an environment variable
+// overrides, saying which one
+func (g *FireGUI) settingControl(key string, control fyne.Disableable) fyne.CanvasObject {
+	obj, _ := control.(fyne.CanvasObject)
+	v, ok, err := config.Get(key)
+	if err != nil || !ok || v.Source == config.SourceDefault || v.Source == config.Path() || v.Source == config.LegacyPath() {
+		return obj
+	}
+	control.Disable()
+	note := widget.NewLabel("Set by " + v.Source)
+	note.Importance = widget.WarningImportance
+	return container.NewHBox(obj, note)
+}
+
+// saveSetting writes a setting to the config file, reporting errors in a
+// dialog
+func (g *FireGUI) saveSetting(key, value string) bool {
+	if err := config.Set(key, value); err != nil {
+		dialog.ShowError(err, g.window)
+		return false
+	}
+	logger.Info("Setting changed", "key", key, "value", value)
+	return true
+}
+
+// ShowStartPage shows the page gui.start_page names, or the page the active
+// profile was left on
+func (g *FireGUI) ShowStartPage() {
+	page := g.profiles.Current().Layout.StartPage
+	if settings, err := config.Load(); err == nil {
+		if i := indexOf(config.StartPages, settings.GUI.StartPage); i > 0 {
+			page = i - 1
+		}
+	}
+	g.navigation.ShowPage(page)
+}
+
+// indexOf returns the position of s in list, or -1
+func indexOf(list []string, s string) int {
+	for i, item := range list {
+		if item == s {
+			return i
+		}
+	}
+	return -1
+}
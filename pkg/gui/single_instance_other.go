@@ -16,14 +16,14 @@ func CheckSingleInstance() bool {
 	// Use file-based locking for Unix-like systems
 	lockFile, err := CreateLockFile()
 	if err != nil {
-		DebugLog("INFO", fmt.Sprintf("Another instance might be running: %v", err))
+		logger.Info("Another instance might be running", "error", err)
 		return false
 	}
 
 	// We successfully created the lock file, so we're the first instance
 	// Note: The lock file will be automatically released when the process exits
 	_ = lockFile // Keep reference to prevent GC
-	DebugLog("INFO", "This is the first instance")
+	logger.Info("This is the first instance")
 	return true
 }
 
@@ -13,8 +13,8 @@ import (
 )
 
 var (
-	// Use existing kernel32 from admin_check_windows.go
-	user32 = windows.NewLazySystemDLL("user32.dll")
+	kernel32 = windows.NewLazySystemDLL("kernel32.dll")
+	user32   = windows.NewLazySystemDLL("user32.dll")
 
 	procCreateMutex         = kernel32.NewProc("CreateMutexW")
 	procGetLastError        = kernel32.NewProc("GetLastError")
@@ -37,7 +37,7 @@ func CheckSingleInstance() bool {
 	// Convert string to UTF16
 	mutexNamePtr, err := windows.UTF16PtrFromString(mutexName)
 	if err != nil {
-		DebugLog("ERROR", fmt.Sprintf("Failed to create mutex name: %v", err))
+		logger.Error("Failed to create mutex name", "error", err)
 		return true // Allow to continue on error
 	}
 
@@ -49,14 +49,14 @@ func CheckSingleInstance() bool {
 	)
 
 	if ret == 0 {
-		DebugLog("ERROR", fmt.Sprintf("Failed to create mutex: %v", err))
+		logger.Error("Failed to create mutex", "error", err)
 		return true // Allow to continue on error
 	}
 
 	// Check if mutex already exists
 	lastErr, _, _ := procGetLastError.Call()
 	if lastErr == ERROR_ALREADY_EXISTS {
-		DebugLog("INFO", "Another instance is already running")
+		logger.Info("Another instance is already running")
 
 		// Try to find and bring the existing window to front
 		className, _ := windows.UTF16PtrFromString("FyneWindow")
@@ -72,13 +72,13 @@ func CheckSingleInstance() bool {
 			procShowWindow.Call(hwnd, SW_RESTORE)
 			// Bring window to foreground
 			procSetForegroundWindow.Call(hwnd)
-			DebugLog("INFO", "Brought existing instance to foreground")
+			logger.Info("Brought existing instance to foreground")
 		}
 
 		return false
 	}
 
-	DebugLog("INFO", "This is the first instance")
+	logger.Info("This is the first instance")
 	return true
 }
 
@@ -0,0 +1,587 @@
+package gui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/mscrnt/project_fire/pkg/config"
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/ecc"
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/mscrnt/project_fire/pkg/monitor"
+	"github.com/mscrnt/project_fire/pkg/plugin"
+	"github.com/mscrnt/project_fire/pkg/testrun"
+	"github.com/mscrnt/project_fire/pkg/throttle"
+)
+
+// errAborted is the cause of a run stopped with the abort button
+var errAborted = errors.New("aborted by the operator")
+
+// stabilityDurations are offered by the duration picker; any duration can
+// be typed
+var stabilityDurations = []string{"1m", "5m", "15m", "30m", "1h", "2h", "4h", "8h", "12h", "24h"}
+
+// stabilityChartPoints is how many samples each live chart shows
+const stabilityChartPoints = 120
+
+// liveMetric is a reading charted while a test runs
+type liveMetric struct {
+	title string
+	unit  string
+	max   float64 // Pins the y-axis to 0..max; 0 fits it to the values
+	value func(s *monitor.Sample) float64
+}
+
+// liveMetrics are the charts of the Stability Test page
+var liveMetrics = []liveMetric{
+	{"CPU Usage", "%", 100, func(s *monitor.Sample) float64 { return s.CPU.Usage }},
+	{"CPU Temperature", "°C", 0, func(s *monitor.Sample) float64 { return s.CPU.Temp }},
+	{"CPU Power", "W", 0, func(s *monitor.Sample) float64 { return s.CPU.Power }},
+	{"CPU Clock", "MHz", 0, func(s *monitor.Sample) float64 { return s.CPU.Clock }},
+	{"Memory Usage", "%", 100, func(s *monitor.Sample) float64 { return s.Memory.Usage }},
+	{"Disk Throughput", "MB/s", 0, func(s *monitor.Sample) float64 {
+		var total float64
+		for _, d := range s.Disks {
+			total += d.ReadMBps + d.WriteMBps
+		}
+		return total
+	}},
+}
+
+// paramField is the form field of one plugin parameter
+type paramField struct {
+	info  plugin.ParamInfo
+	entry *widget.Entry
+	check *widget.Check
+}
+
+// newParamField creates the field for a parameter, showing value
+func newParamField(info plugin.ParamInfo, value interface{}) *paramField {
+	f := &paramField{info: info}
+	if info.Type == "boolean" {
+		f.check = widget.NewCheck("", nil)
+		on, _ := value.(bool)
+		f.check.SetChecked(on)
+		return f
+	}
+	f.entry = widget.NewEntry()
+	if value != nil {
+		f.entry.SetText(fmt.Sprint(value))
+	}
+	if info.Default != nil && fmt.Sprint(info.Default) != "" {
+		f.entry.SetPlaceHolder(fmt.Sprint(info.Default))
+	}
+	return f
+}
+
+// widget returns the field's control
+func (f *paramField) widget() fyne.CanvasObject {
+	if f.check != nil {
+		return f.check
+	}
+	return f.entry
+}
+
+// value parses the field as the parameter's type. ok is false when the
+// field is empty, leaving the plugin's default.
+func (f *paramField) value() (v interface{}, ok bool, err error) {
+	if f.check != nil {
+		return f.check.Checked, true, nil
+	}
+	text := strings.TrimSpace(f.entry.Text)
+	if text == "" {
+		return nil, false, nil
+	}
+	switch f.info.Type {
+	case "integer":
+		n, err := strconv.Atoi(text)
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %q is not a whole number", f.info.Name, text)
+		}
+		return n, true, nil
+	case "float":
+		n, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %q is not a number", f.info.Name, text)
+		}
+		return n, true, nil
+	case "duration":
+		if _, err := time.ParseDuration(text); err != nil {
+			return nil, false, fmt.Errorf("%s: %q is not a duration (e.g. 30s, 5m or 1h)", f.info.Name, text)
+		}
+	}
+	return text, true, nil
+}
+
+// StabilityPage runs a test plugin from the GUI: the plugin and its
+// parameters, live charts of the sensors while it runs and the result at
+// the end. Runs are recorded like bench test records them, so they show in
+// bench list.
+type StabilityPage struct {
+	dbPath string
+	window fyne.Window
+
+	content       fyne.CanvasObject
+	pluginSelect  *widget.Select
+	description   *widget.Label
+	paramForm     *widget.Form
+	fields        []*paramField
+	durationEntry *widget.SelectEntry
+	tagsEntry     *widget.Entry
+	benchMode     *widget.Check
+	startBtn      *widget.Button
+	abortBtn      *widget.Button
+	status        *widget.Label
+	progress      *widget.ProgressBar
+	charts        []*EnhancedLineChart
+	summary       *fyne.Container
+
+	mu     sync.Mutex
+	cancel context.CancelCauseFunc
+	done   chan struct{}
+}
+
+// NewStabilityPage creates the Stability Test page recording runs in the
+// database at dbPath
+func NewStabilityPage(dbPath string, window fyne.Window) *StabilityPage {
+	s := &StabilityPage{dbPath: dbPath, window: window}
+	s.build()
+	return s
+}
+
+// build creates the page
+func (s *StabilityPage) build() {
+	names := plugin.List()
+	sort.Strings(names)
+	s.description = widget.NewLabel("")
+	s.description.Wrapping = fyne.TextWrapWord
+	s.paramForm = widget.NewForm()
+	s.durationEntry = widget.NewSelectEntry(stabilityDurations)
+	s.pluginSelect = widget.NewSelect(names, s.selectPlugin)
+	s.tagsEntry = widget.NewEntry()
+	s.tagsEntry.SetPlaceHolder("customer=acme build=retail")
+	s.benchMode = widget.NewCheck("Lock GPU clocks and the power plan", nil)
+
+	s.startBtn = widget.NewButtonWithIcon("Start Test", theme.MediaPlayIcon(), s.start)
+	s.startBtn.Importance = widget.HighImportance
+	s.abortBtn = widget.NewButtonWithIcon("ABORT", theme.MediaStopIcon(), s.Abort)
+	s.abortBtn.Importance = widget.DangerImportance
+	s.abortBtn.Disable()
+	abort := container.NewGridWrap(fyne.NewSize(260, 64), s.abortBtn)
+
+	s.status = widget.NewLabel("Choose a test and press Start.")
+	s.progress = widget.NewProgressBar()
+	s.progress.Hide()
+
+	setup := container.NewVBox(
+		widget.NewLabelWithStyle("Test", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		s.pluginSelect,
+		s.description,
+		widget.NewForm(
+			widget.NewFormItem("Duration", s.durationEntry),
+			widget.NewFormItem("Tags", s.tagsEntry),
+			widget.NewFormItem("Benchmark mode", s.benchMode),
+		),
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle("Parameters", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		s.paramForm,
+		widget.NewSeparator(),
+		s.startBtn,
+		abort,
+	)
+	setupScroll := container.NewVScroll(container.NewPadded(setup))
+	setupScroll.SetMinSize(fyne.NewSize(360, 0))
+
+	grid := container.NewGridWithColumns(3)
+	for _, m := range liveMetrics {
+		chart := NewEnhancedLineChart(m.title, stabilityChartPoints, m.max)
+		chart.SetUnit(m.unit)
+		chart.SetShowDataPoints(false)
+		s.charts = append(s.charts, chart)
+
+		bg := canvas.NewRectangle(ColorCardBackground)
+		bg.CornerRadius = 4
+		grid.Add(container.NewPadded(container.NewStack(bg, container.NewPadded(chart))))
+	}
+
+	s.summary = container.NewVBox()
+	run := container.NewBorder(
+		container.NewVBox(s.status, s.progress),
+		s.summary,
+		nil, nil,
+		grid,
+	)
+
+	s.content = container.NewBorder(nil, nil, setupScroll, nil, container.NewPadded(run))
+
+	if len(names) > 0 {
+		s.pluginSelect.SetSelected(names[0])
+	}
+}
+
+// Content returns the page
+func (s *StabilityPage) Content() fyne.CanvasObject {
+	return s.content
+}
+
+// Select chooses the plugin to run, e.g. for a test preset
+func (s *StabilityPage) Select(name string) {
+	if s.Running() {
+		return
+	}
+	s.pluginSelect.SetSelected(name)
+}
+
+// Running reports whether a test is running
+func (s *StabilityPage) Running() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.done != nil
+}
+
+// selectPlugin builds the parameter form of a plugin from the parameters it
+// describes, filled in with its defaults and those of the settings
+func (s *StabilityPage) selectPlugin(name string) {
+	p, err := plugin.Get(name)
+	if err != nil {
+		return
+	}
+	params := pluginDefaults(p)
+	s.description.SetText(p.Description())
+	s.durationEntry.SetText(params.Duration.String())
+
+	s.fields = nil
+	s.paramForm.Items = nil
+	if infoPlugin, ok := p.(interface{ Info() plugin.Info }); ok {
+		for _, info := range infoPlugin.Info().Parameters {
+			var value interface{}
+			switch {
+			case info.Name == "duration":
+				continue // The duration picker
+			case info.Name == "threads":
+				value = info.Default
+				if params.Threads > 0 {
+					value = params.Threads
+				}
+			default:
+				var ok bool
+				if value, ok = params.Config[info.Name]; !ok {
+					value = info.Default
+				}
+			}
+			field := newParamField(info, value)
+			s.fields = append(s.fields, field)
+			item := widget.NewFormItem(info.Name, field.widget())
+			item.HintText = info.Description
+			s.paramForm.AppendItem(item)
+		}
+	}
+	if len(s.fields) == 0 {
+		s.paramForm.Append("", widget.NewLabel("This test takes no parameters."))
+	}
+	s.paramForm.Refresh()
+}
+
+// params reads the form into the parameters of the plugin
+func (s *StabilityPage) params(p plugin.TestPlugin) (plugin.Params, error) {
+	params := pluginDefaults(p)
+	d, err := time.ParseDuration(strings.TrimSpace(s.durationEntry.Text))
+	if err != nil || d <= 0 {
+		return params, fmt.Errorf("invalid duration %q (e.g. 30s, 5m or 1h)", s.durationEntry.Text)
+	}
+	params.Duration = d
+	if params.Config == nil {
+		params.Config = make(map[string]interface{})
+	}
+	for _, f := range s.fields {
+		v, ok, err := f.value()
+		if err != nil {
+			return params, err
+		}
+		if !ok {
+			continue
+		}
+		if f.info.Name == "threads" {
+			if n, isInt := v.(int); isInt {
+				params.Threads = n
+			}
+			continue
+		}
+		params.Config[f.info.Name] = v
+	}
+	return params, p.ValidateParams(params)
+}
+
+// start checks the form and runs the test
+func (s *StabilityPage) start() {
+	p, err := plugin.Get(s.pluginSelect.Selected)
+	if err != nil {
+		dialog.ShowError(errors.New("choose a test to run"), s.window)
+		return
+	}
+	params, err := s.params(p)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("invalid parameters: %w", err), s.window)
+		return
+	}
+	tags, err := db.ParseTags(strings.Fields(s.tagsEntry.Text))
+	if err != nil {
+		dialog.ShowError(err, s.window)
+		return
+	}
+
+	benchMode := s.benchMode.Checked
+
+	s.mu.Lock()
+	if s.done != nil {
+		s.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancelCause(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	done := s.done
+	s.mu.Unlock()
+
+	s.setRunning(true)
+	s.summary.Objects = nil
+	s.summary.Refresh()
+	for _, chart := range s.charts {
+		chart.SetValues(nil)
+	}
+
+	go func() {
+		defer func() {
+			s.mu.Lock()
+			s.cancel = nil
+			s.done = nil
+			s.mu.Unlock()
+			close(done)
+			fyne.Do(func() { s.setRunning(false) })
+		}()
+		s.run(ctx, p, params, tags, benchMode)
+	}()
+}
+
+// QuickStart runs a plugin for duration, with its other parameters as set
+// on the page, e.g. for the quick stress test of the tray
+func (s *StabilityPage) QuickStart(name string, duration time.Duration) error {
+	if s.Running() {
+		return errors.New("a test is already running")
+	}
+	if _, err := plugin.Get(name); err != nil {
+		return err
+	}
+	s.pluginSelect.SetSelected(name)
+	s.durationEntry.SetText(duration.String())
+	s.start()
+	if !s.Running() {
+		return errors.New("the test did not start; see the Stability Test page")
+	}
+	return nil
+}
+
+// Abort stops the running test; it is recorded as failed
+func (s *StabilityPage) Abort() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel(errAborted)
+		s.status.SetText("Aborting...")
+		s.abortBtn.Disable()
+	}
+}
+
+// Stop aborts the running test and waits up to timeout for it to be
+// recorded, e.g. before the window closes
+func (s *StabilityPage) Stop(timeout time.Duration) {
+	s.mu.Lock()
+	cancel, done := s.cancel, s.done
+	s.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel(errAborted)
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		logger.Warn("Test still running; its run may be left unfinished", "after", timeout)
+	}
+}
+
+// setRunning switches the controls between setup and a running test
+func (s *StabilityPage) setRunning(running bool) {
+	if running {
+		s.pluginSelect.Disable()
+		s.durationEntry.Disable()
+		s.tagsEntry.Disable()
+		s.benchMode.Disable()
+		s.startBtn.Disable()
+		s.abortBtn.Enable()
+		s.progress.SetValue(0)
+		s.progress.Show()
+		return
+	}
+	s.pluginSelect.Enable()
+	s.durationEntry.Enable()
+	s.tagsEntry.Enable()
+	s.benchMode.Enable()
+	s.startBtn.Enable()
+	s.abortBtn.Disable()
+	s.progress.Hide()
+}
+
+// run runs the test and records it the way bench test does, in the same
+// database and with the hooks, UPS, sinks and notifications of the
+// settings. The sensors are charted until the test ends.
+func (s *StabilityPage) run(runCtx context.Context, p plugin.TestPlugin, params plugin.Params, tags map[string]string, benchMode bool) {
+	setStatus := func(text string) {
+		fyne.Do(func() { s.status.SetText(text) })
+	}
+
+	database, err := openResults(s.dbPath)
+	if err != nil {
+		setStatus(fmt.Sprintf("Database error: %v", err))
+		return
+	}
+	defer func() { _ = database.Close() }()
+
+	opts, done := runOptions(database, tags, benchMode)
+	defer done()
+	output := &strings.Builder{}
+	opts.Output = output
+
+	// Chart the sensors from the start of the test until it ends
+	chartCtx, stopCharts := context.WithCancel(runCtx)
+	defer stopCharts()
+	var charting sync.WaitGroup
+	opts.Started = func(run *db.Run) {
+		setStatus(fmt.Sprintf("Running %s for %s (run %d)", p.Name(), params.Duration, run.ID))
+		charting.Add(1)
+		go func() {
+			defer charting.Done()
+			s.chart(chartCtx, time.Now(), params.Duration)
+		}()
+	}
+
+	outcome, err := testrun.Run(runCtx, database, p, params, opts)
+	stopCharts()
+	charting.Wait()
+	if output.Len() > 0 {
+		logger.Info("Test run output", "plugin", p.Name(), "output", output.String())
+	}
+	if outcome == nil {
+		setStatus(fmt.Sprintf("Failed to start the test: %v", err))
+		return
+	}
+
+	run := outcome.Run
+	if !run.Success && !errors.Is(context.Cause(runCtx), errAborted) {
+		notifyWarning("Test Failed", fmt.Sprintf("%s test (run %d) failed: %s", p.Name(), run.ID, run.Error))
+	}
+	fyne.Do(func() { s.showResult(run, outcome.Result, outcome.Units, outcome.Throttling, outcome.ECC) })
+}
+
+// chart samples the sensors at the dashboard's update interval into the
+// live charts, and moves the progress bar, until ctx ends
+func (s *StabilityPage) chart(ctx context.Context, start time.Time, duration time.Duration) {
+	interval := config.DefaultUpdateInterval
+	if settings, err := config.Load(); err == nil {
+		interval = settings.GUI.Interval()
+	}
+	poller := monitor.NewPoller()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if sample, err := poller.Sample(ctx); err == nil {
+			elapsed := time.Since(start)
+			fyne.Do(func() {
+				for i, m := range liveMetrics {
+					s.charts[i].AddValue(m.value(sample))
+				}
+				s.progress.SetValue(min(elapsed.Seconds()/duration.Seconds(), 1))
+			})
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// showResult shows the summary of a finished run
+func (s *StabilityPage) showResult(run *db.Run, result plugin.Result, units map[string]string, throttling *throttle.Report, eccErrors *ecc.Report) {
+	verdict := widget.NewLabelWithStyle(i18n.T("status.passed"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	verdict.Importance = widget.SuccessImportance
+	if !run.Success {
+		verdict.SetText(i18n.T("status.failed"))
+		verdict.Importance = widget.DangerImportance
+	}
+	s.status.SetText(i18n.T("gui.finished_in", run.Plugin, formatDuration(run.Duration())))
+
+	objects := []fyne.CanvasObject{
+		widget.NewSeparator(),
+		container.NewHBox(verdict, widget.NewLabel(fmt.Sprintf("Recorded as run %d (bench show %d)", run.ID, run.ID))),
+	}
+	if run.Error != "" {
+		errLabel := widget.NewLabel(run.Error)
+		errLabel.Wrapping = fyne.TextWrapWord
+		errLabel.Importance = widget.DangerImportance
+		objects = append(objects, errLabel)
+	}
+	if throttling != nil && throttling.Samples > 0 {
+		objects = append(objects, widget.NewLabel(throttling.Summary()))
+	}
+	if eccErrors != nil && (eccErrors.Available() || run.Plugin == "memory") {
+		objects = append(objects, widget.NewLabel(eccErrors.Summary()))
+	}
+
+	if len(result.Metrics) > 0 {
+		names := make([]string, 0, len(result.Metrics))
+		for name := range result.Metrics {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		metrics := container.NewGridWithColumns(4)
+		for _, name := range names {
+			value, unit := convertReading(result.Metrics[name], units[name])
+			metrics.Add(widget.NewLabelWithStyle(name, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+			metrics.Add(widget.NewLabel(strings.TrimSpace(fmt.Sprintf("%.2f %s", value, unit))))
+		}
+		scroll := container.NewVScroll(metrics)
+		scroll.SetMinSize(fyne.NewSize(0, 160))
+		objects = append(objects, scroll)
+	}
+
+	s.summary.Objects = objects
+	s.summary.Refresh()
+}
+
+// startPreset opens a test preset on the Stability Test page with its
+// plugin chosen
+func (g *FireGUI) startPreset(test TestOption) {
+	if test.Plugin == "" {
+		dialog.ShowInformation(test.Name, "No test plugin runs this test yet.", g.window)
+		return
+	}
+	g.navigation.ShowPage(1)
+	if g.stability.Running() {
+		dialog.ShowInformation(test.Name, "A test is already running; abort it or wait for it to finish.", g.window)
+		return
+	}
+	g.stability.Select(test.Plugin)
+}
@@ -0,0 +1,40 @@
+package gui
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+	"github.com/mscrnt/project_fire/pkg/plugin"
+)
+
+func TestParamFieldValue(t *testing.T) {
+	test.NewTempApp(t)
+
+	tests := []struct {
+		info  plugin.ParamInfo
+		text  string
+		want  interface{}
+		ok    bool
+		fails bool
+	}{
+		{plugin.ParamInfo{Name: "size_mb", Type: "integer"}, " 2048 ", 2048, true, false},
+		{plugin.ParamInfo{Name: "size_mb", Type: "integer"}, "2 GB", nil, false, true},
+		{plugin.ParamInfo{Name: "free_fraction", Type: "float"}, "0.5", 0.5, true, false},
+		{plugin.ParamInfo{Name: "pattern", Type: "string"}, "random", "random", true, false},
+		{plugin.ParamInfo{Name: "interval", Type: "duration"}, "soon", nil, false, true},
+		{plugin.ParamInfo{Name: "target", Type: "string", Default: ""}, "", nil, false, false},
+	}
+	for _, tt := range tests {
+		f := newParamField(tt.info, nil)
+		f.entry.SetText(tt.text)
+		got, ok, err := f.value()
+		if (err != nil) != tt.fails || ok != tt.ok || got != tt.want {
+			t.Errorf("%s=%q: got %v, %v, %v", tt.info.Name, tt.text, got, ok, err)
+		}
+	}
+
+	check := newParamField(plugin.ParamInfo{Name: "smart", Type: "boolean"}, true)
+	if got, ok, _ := check.value(); got != true || !ok {
+		t.Errorf("expected the boolean checked, got %v", got)
+	}
+}
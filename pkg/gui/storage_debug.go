@@ -2,6 +2,8 @@ package gui
 
 import (
 	"fmt"
+
+	"github.com/mscrnt/project_fire/pkg/inventory"
 )
 
 // DebugStorageInfo prints detailed debug information about storage detection
@@ -9,7 +11,7 @@ func DebugStorageInfo() {
 	fmt.Println("=== Storage Debug Info ===")
 
 	// Get drive models
-	models := getDriveModels()
+	models := inventory.DriveModels()
 	fmt.Printf("\nFound %d drive models:\n", len(models))
 	for key, model := range models {
 		fmt.Printf("  Key: %s\n", key)
@@ -21,15 +23,15 @@ func DebugStorageInfo() {
 		fmt.Println()
 	}
 
-	// Test getDriveLettersForDisk
-	fmt.Println("\nTesting getDriveLettersForDisk:")
+	// Test DriveLettersForDisk
+	fmt.Println("\nTesting DriveLettersForDisk:")
 	for i := 0; i < 5; i++ {
-		letters := getDriveLettersForDisk(i)
+		letters := inventory.DriveLettersForDisk(i)
 		fmt.Printf("  Disk %d -> %v\n", i, letters)
 	}
 
 	// Get full storage info
-	storageDevices, err := GetStorageInfo()
+	storageDevices, err := inventory.GetStorageInfo()
 	if err != nil {
 		fmt.Printf("\nError getting storage info: %v\n", err)
 		return
@@ -8,18 +8,25 @@ import (
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
+
+	"github.com/mscrnt/project_fire/pkg/inventory"
+	"github.com/mscrnt/project_fire/pkg/storage"
 )
 
 // ShowStorageDetails displays detailed storage information including full SMART data
-func (d *Dashboard) ShowStorageDetails(storage *StorageInfo) {
+func (d *Dashboard) ShowStorageDetails(storage *inventory.StorageInfo) {
 	// Create tabs for different sections
 	generalTab := d.createStorageGeneralTab(storage)
 	smartTab := d.createStorageSMARTTab(storage)
+	partitionsTab := d.createStoragePartitionsTab(storage)
 	capabilitiesTab := d.createStorageCapabilitiesTab(storage)
+	performanceTab, stopPerformance := d.createStoragePerformanceTab(storage)
 
 	tabs := container.NewAppTabs(
 		container.NewTabItem("General Information", generalTab),
+		container.NewTabItem("Performance", performanceTab),
 		container.NewTabItem("S.M.A.R.T. Details", smartTab),
+		container.NewTabItem("Partitions", partitionsTab),
 		container.NewTabItem("Capabilities", capabilitiesTab),
 	)
 
@@ -38,12 +45,13 @@ func (d *Dashboard) ShowStorageDetails(storage *StorageInfo) {
 	)
 
 	dlg := dialog.NewCustom(title, "Close", content, d.window)
+	dlg.SetOnClosed(stopPerformance)
 	dlg.Resize(fyne.NewSize(800, 600))
 	dlg.Show()
 }
 
 // createStorageGeneralTab creates the general information tab
-func (d *Dashboard) createStorageGeneralTab(storage *StorageInfo) fyne.CanvasObject {
+func (d *Dashboard) createStorageGeneralTab(storage *inventory.StorageInfo) fyne.CanvasObject {
 	// Device Information Card
 	deviceInfo := widget.NewCard("Device Information", "",
 		container.NewGridWithColumns(2,
@@ -98,15 +106,136 @@ func (d *Dashboard) createStorageGeneralTab(storage *StorageInfo) fyne.CanvasObj
 		),
 	)
 
-	return container.NewVBox(
-		deviceInfo,
-		capacityInfo,
-		usageCard,
+	content := container.NewVBox(deviceInfo)
+	if storage.Link != nil {
+		content.Add(createHostLinkCard(storage.Link))
+	}
+	if storage.WriteCache != nil {
+		content.Add(d.createWriteCacheCard(storage))
+	}
+	content.Add(capacityInfo)
+	content.Add(usageCard)
+	return content
+}
+
+// createHostLinkCard shows the negotiated link against what the drive
+// supports, and the queue configuration
+func createHostLinkCard(link *storage.Link) *widget.Card {
+	supported := link.MaxSpeed()
+	if supported == "" {
+		supported = "Unknown"
+	}
+	queues := link.Queues()
+	if queues == "" {
+		queues = "Unknown"
+	}
+	grid := container.NewGridWithColumns(2,
+		widget.NewLabel("Negotiated:"),
+		widget.NewLabelWithStyle(link.Speed(), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabel("Drive Supports:"),
+		widget.NewLabel(supported),
+		widget.NewLabel("Command Queues:"),
+		widget.NewLabel(queues),
 	)
+	if link.Requests > 0 {
+		grid.Add(widget.NewLabel("Block Queue Size:"))
+		grid.Add(widget.NewLabel(fmt.Sprintf("%d requests", link.Requests)))
+	}
+
+	content := container.NewVBox(grid)
+	for _, w := range link.Warnings {
+		warning := widget.NewLabel("⚠ " + w)
+		warning.Wrapping = fyne.TextWrapWord
+		warning.Importance = widget.WarningImportance
+		content.Add(warning)
+	}
+	return widget.NewCard("Host Link", link.Interface+" link and queue depth", content)
+}
+
+// createWriteCacheCard shows the volatile write cache and power-loss
+// protection, with a button to toggle the cache after a warning
+func (d *Dashboard) createWriteCacheCard(info *inventory.StorageInfo) *widget.Card {
+	content := container.NewVBox()
+	var render func()
+	render = func() {
+		wc := info.WriteCache
+		plp := wc.PLP
+		if wc.PLPSource == "model" {
+			plp += " (from model number)"
+		}
+		content.Objects = []fyne.CanvasObject{container.NewGridWithColumns(2,
+			widget.NewLabel("Write Cache:"),
+			widget.NewLabelWithStyle(wc.Cache, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabel("Power-Loss Protection:"),
+			widget.NewLabel(plp),
+		)}
+		for _, w := range wc.Warnings {
+			warning := widget.NewLabel("⚠ " + w)
+			warning.Wrapping = fyne.TextWrapWord
+			warning.Importance = widget.WarningImportance
+			content.Add(warning)
+		}
+		if wc.Cache == storage.CacheEnabled || wc.Cache == storage.CacheDisabled {
+			enable := wc.Cache == storage.CacheDisabled
+			label := "Disable Write Cache"
+			if enable {
+				label = "Enable Write Cache"
+			}
+			content.Add(widget.NewButton(label, func() {
+				d.confirmWriteCache(info, enable, render)
+			}))
+		}
+		content.Refresh()
+	}
+	render()
+	return widget.NewCard("Write Cache", "Affects write results and data safety", content)
+}
+
+// confirmWriteCache warns about the consequences of the change, then applies
+// it and calls done with info.WriteCache re-read
+func (d *Dashboard) confirmWriteCache(info *inventory.StorageInfo, enable bool, done func()) {
+	message := "Disabling the write cache makes every write wait for the media.\n" +
+		"Write benchmarks will run far below the drive's rating until it is enabled again."
+	if enable {
+		message = "Enabling the write cache speeds up writes, but without power-loss protection\n" +
+			"writes the drive has acknowledged are lost if power fails before they are flushed."
+	}
+	message += "\n\nMany drives return to their default at the next power cycle. Continue?"
+
+	drive := inventory.PhysicalDrive(info.Device)
+	dialog.ShowConfirm("Change Write Cache", message, func(ok bool) {
+		if !ok {
+			return
+		}
+		go func() {
+			err := storage.SetWriteCache(drive, enable)
+			if err == nil {
+				logger.Info("Write cache set", "drive", drive, "enabled", enable)
+				info.WriteCache, _ = storage.ReadWriteCache(drive, info.Model)
+			}
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(err, d.window)
+					return
+				}
+				done()
+			})
+		}()
+	}, d.window)
 }
 
 // createStorageSMARTTab creates the SMART details tab
-func (d *Dashboard) createStorageSMARTTab(storage *StorageInfo) fyne.CanvasObject {
+func (d *Dashboard) createStorageSMARTTab(storage *inventory.StorageInfo) fyne.CanvasObject {
+	if (storage.SMART == nil || !storage.SMART.Available) && len(storage.RAIDMembers) > 0 {
+		// The controller hides the volume's SMART data; show the member drives instead
+		return container.NewScroll(container.NewVBox(createRAIDMembersCard(storage.RAIDMembers)))
+	}
+
+	if (storage.SMART == nil || !storage.SMART.Available) && storage.MMC != nil {
+		// SD cards and pre-5.0 eMMC have no wear estimates, but the card identity is known
+		return container.NewScroll(container.NewVBox(createMMCLifeTimeCard(storage.MMC)))
+	}
+
 	if storage.SMART == nil || !storage.SMART.Available {
 		return container.NewCenter(
 			widget.NewLabelWithStyle(
@@ -188,6 +317,14 @@ func (d *Dashboard) createStorageSMARTTab(storage *StorageInfo) fyne.CanvasObjec
 		content.Add(wearCard)
 	}
 
+	if len(storage.RAIDMembers) > 0 {
+		content.Add(createRAIDMembersCard(storage.RAIDMembers))
+	}
+
+	if storage.MMC != nil {
+		content.Add(createMMCLifeTimeCard(storage.MMC))
+	}
+
 	// Add raw SMART attributes section
 	smartAttrsCard := widget.NewCard("S.M.A.R.T. Attributes", "Self-Monitoring, Analysis and Reporting Technology",
 		widget.NewLabelWithStyle(
@@ -201,8 +338,176 @@ func (d *Dashboard) createStorageSMARTTab(storage *StorageInfo) fyne.CanvasObjec
 	return container.NewScroll(content)
 }
 
+// createRAIDMembersCard lists the drives behind a RAID controller with their health
+func createRAIDMembersCard(members []inventory.RAIDMember) *widget.Card {
+	accordion := widget.NewAccordion()
+	for i := range members {
+		m := &members[i]
+		title := m.Model
+		if title == "" {
+			title = m.Device
+		}
+		health := "Unknown"
+		if m.SMART != nil && m.SMART.HealthStatus != "" {
+			health = m.SMART.HealthStatus
+		}
+		accordion.Append(widget.NewAccordionItem(fmt.Sprintf("%s - %s", title, health), createRAIDMemberDetails(m)))
+	}
+
+	return widget.NewCard("RAID Member Drives",
+		fmt.Sprintf("%d drives read through the RAID controller", len(members)),
+		accordion,
+	)
+}
+
+// createRAIDMemberDetails creates the expanded view for a single RAID member
+func createRAIDMemberDetails(m *inventory.RAIDMember) fyne.CanvasObject {
+	grid := container.NewGridWithColumns(2,
+		widget.NewLabel("Device:"),
+		widget.NewLabel(m.Device),
+		widget.NewLabel("Model:"),
+		widget.NewLabel(m.Model),
+		widget.NewLabel("Serial Number:"),
+		widget.NewLabel(m.Serial),
+		widget.NewLabel("Firmware:"),
+		widget.NewLabel(m.Firmware),
+	)
+
+	if m.SMART == nil || !m.SMART.Available {
+		return grid
+	}
+
+	grid.Add(widget.NewLabel("Temperature:"))
+	grid.Add(widget.NewLabel(fmt.Sprintf("%.0f°C", m.SMART.Temperature)))
+	grid.Add(widget.NewLabel("Power On Hours:"))
+	grid.Add(widget.NewLabel(fmt.Sprintf("%d hours", m.SMART.PowerOnHours)))
+	if m.SMART.WearLevel > 0 {
+		grid.Add(widget.NewLabel("Wear Level:"))
+		grid.Add(widget.NewLabel(fmt.Sprintf("%.1f%%", m.SMART.WearLevel)))
+	}
+	return grid
+}
+
+// createMMCLifeTimeCard shows the eMMC device life time estimates
+func createMMCLifeTimeCard(mmc *inventory.MMCInfo) *widget.Card {
+	preEOL := mmc.PreEOL
+	if preEOL == "" {
+		preEOL = "Not reported"
+	}
+
+	return widget.NewCard("Flash Life Time", "eMMC EXT_CSD device health",
+		container.NewGridWithColumns(2,
+			widget.NewLabel("Card Type:"),
+			widget.NewLabel(mmc.CardType),
+			widget.NewLabel("Life Time (SLC area):"),
+			widget.NewLabel(inventory.LifeTimeRange(mmc.LifeTimeA)),
+			widget.NewLabel("Life Time (main area):"),
+			widget.NewLabel(inventory.LifeTimeRange(mmc.LifeTimeB)),
+			widget.NewLabel("Reserved Blocks (Pre-EOL):"),
+			widget.NewLabel(preEOL),
+			widget.NewLabel("Manufacture Date:"),
+			widget.NewLabel(mmc.ManufactureDate),
+		),
+	)
+}
+
+// createStoragePartitionsTab creates the partition layout tab. The layout is
+// loaded in the background because it shells out to lsblk/PowerShell.
+func (d *Dashboard) createStoragePartitionsTab(storage *inventory.StorageInfo) fyne.CanvasObject {
+	content := container.NewVBox(
+		widget.NewLabelWithStyle("Loading partition layout...", fyne.TextAlignCenter, fyne.TextStyle{Italic: true}),
+	)
+
+	go func() {
+		partitions, err := inventory.GetPartitionLayout(storage)
+		if err != nil {
+			logger.Warn("Failed to get partition layout", "device", storage.Device, "error", err)
+		}
+
+		fyne.Do(func() {
+			content.Objects = nil
+			if len(partitions) == 0 {
+				content.Add(widget.NewLabelWithStyle(
+					"Partition layout not available for this device",
+					fyne.TextAlignCenter,
+					fyne.TextStyle{Italic: true},
+				))
+				content.Refresh()
+				return
+			}
+
+			accordion := widget.NewAccordion()
+			for i := range partitions {
+				p := &partitions[i]
+				accordion.Append(widget.NewAccordionItem(partitionTitle(p), createPartitionDetails(p)))
+			}
+			if len(accordion.Items) == 1 {
+				accordion.Open(0)
+			}
+			content.Add(accordion)
+			content.Refresh()
+		})
+	}()
+
+	return container.NewScroll(content)
+}
+
+// partitionTitle builds the accordion header for a partition
+func partitionTitle(p *inventory.PartitionInfo) string {
+	name := p.Device
+	if p.Mountpoint != "" {
+		name = fmt.Sprintf("%s (%s)", p.Mountpoint, p.Device)
+	}
+	fs := p.Filesystem
+	if fs == "" {
+		fs = "unformatted"
+	}
+	return fmt.Sprintf("%s - %s, %.1f GB - %s", name, fs, float64(p.Size)/(1024*1024*1024), p.Health)
+}
+
+// createPartitionDetails creates the expanded view for a single partition
+func createPartitionDetails(p *inventory.PartitionInfo) fyne.CanvasObject {
+	grid := container.NewGridWithColumns(2,
+		widget.NewLabel("Device:"),
+		widget.NewLabel(p.Device),
+		widget.NewLabel("Label:"),
+		widget.NewLabel(p.Label),
+		widget.NewLabel("Partition Type:"),
+		widget.NewLabel(p.Type),
+		widget.NewLabel("File System:"),
+		widget.NewLabel(p.Filesystem),
+		widget.NewLabel("Mount Point:"),
+		widget.NewLabel(p.Mountpoint),
+		widget.NewLabel("Size:"),
+		widget.NewLabel(fmt.Sprintf("%.1f GB", float64(p.Size)/(1024*1024*1024))),
+	)
+
+	if p.Mountpoint != "" {
+		grid.Add(widget.NewLabel("Free Space:"))
+		grid.Add(widget.NewLabel(fmt.Sprintf("%.1f GB (%.1f%% used)",
+			float64(p.Free)/(1024*1024*1024), p.UsedPercent)))
+	}
+
+	healthLabel := widget.NewLabelWithStyle(p.Health, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	switch p.Health {
+	case inventory.FSHealthDirty, inventory.FSHealthErrors:
+		healthLabel.Importance = widget.DangerImportance
+	case inventory.FSHealthClean:
+		healthLabel.Importance = widget.SuccessImportance
+	}
+	grid.Add(widget.NewLabel("File System Health:"))
+	grid.Add(healthLabel)
+
+	if p.HealthNote != "" {
+		grid.Add(widget.NewLabel(""))
+		grid.Add(widget.NewLabel(p.HealthNote))
+	}
+
+	return grid
+}
+
 // createStorageCapabilitiesTab creates the capabilities tab
-func (d *Dashboard) createStorageCapabilitiesTab(storage *StorageInfo) fyne.CanvasObject {
+func (d *Dashboard) createStorageCapabilitiesTab(storage *inventory.StorageInfo) fyne.CanvasObject {
 	// I/O Command Sets
 	commandSets := []string{}
 
@@ -216,6 +521,17 @@ func (d *Dashboard) createStorageCapabilitiesTab(storage *StorageInfo) fyne.Canv
 			"Security Send/Receive",
 			"Firmware Download/Commit",
 		)
+	} else if interfaceLower == "emmc" {
+		commandSets = append(commandSets,
+			"JEDEC eMMC (JESD84)",
+			"Extended CSD Register",
+			"Secure Erase/Trim",
+		)
+	} else if interfaceLower == "sd" {
+		commandSets = append(commandSets,
+			"SD Physical Layer",
+			"Erase Command",
+		)
 	} else if strings.Contains(interfaceLower, "sata") || strings.Contains(interfaceLower, "ide") {
 		commandSets = append(commandSets,
 			"ATA/ATAPI-8",
@@ -259,6 +575,12 @@ func (d *Dashboard) createStorageCapabilitiesTab(storage *StorageInfo) fyne.Canv
 			"Silent operation",
 			"Shock resistant",
 		)
+	case "eMMC", "SD":
+		features = append(features,
+			"No moving parts",
+			"Low power consumption",
+			"Limited write endurance",
+		)
 	case "HDD":
 		features = append(features,
 			"High capacity",
@@ -292,11 +614,60 @@ func (d *Dashboard) createStorageCapabilitiesTab(storage *StorageInfo) fyne.Canv
 	return container.NewVBox(
 		commandSetsCard,
 		featuresCard,
+		createDriveCharacteristicsCard(storage),
 		perfCard,
 	)
 }
 
+// createDriveCharacteristicsCard shows recording technology, zoning and media
+// type, which change how benchmark results should be read. Detection runs in
+// the background because it may query sysfs or PowerShell.
+func createDriveCharacteristicsCard(info *inventory.StorageInfo) *widget.Card {
+	content := container.NewVBox(
+		widget.NewLabelWithStyle("Detecting drive characteristics...", fyne.TextAlignLeading, fyne.TextStyle{Italic: true}),
+	)
+
+	go func() {
+		chars, err := storage.Detect(inventory.PhysicalDrive(info.Device), info.Model)
+		if err != nil {
+			logger.Warn("Drive characteristics incomplete", "device", info.Device, "error", err)
+		}
+
+		fyne.Do(func() {
+			content.Objects = nil
+			recording := chars.Recording
+			if recording == "" {
+				recording = "N/A (solid state)"
+			}
+			if chars.SMRSource == "model" {
+				recording += " (from model number)"
+			}
+			zoned := chars.Zoned
+			if chars.ZNS {
+				zoned += " (NVMe ZNS)"
+			}
+			content.Add(container.NewGridWithColumns(2,
+				widget.NewLabel("Media:"),
+				widget.NewLabel(chars.Media),
+				widget.NewLabel("Recording:"),
+				widget.NewLabel(recording),
+				widget.NewLabel("Zoned Model:"),
+				widget.NewLabel(zoned),
+			))
+			for _, w := range chars.Warnings {
+				warning := widget.NewLabel("⚠ " + w)
+				warning.Wrapping = fyne.TextWrapWord
+				warning.Importance = widget.WarningImportance
+				content.Add(warning)
+			}
+			content.Refresh()
+		})
+	}()
+
+	return widget.NewCard("Drive Characteristics", "Properties that affect benchmark results", content)
+}
+
 // Add click handler to storage items to show details
-func (d *Dashboard) handleStorageClick(storage *StorageInfo) {
+func (d *Dashboard) handleStorageClick(storage *inventory.StorageInfo) {
 	d.ShowStorageDetails(storage)
 }
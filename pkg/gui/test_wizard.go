@@ -10,8 +10,10 @@ import (
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/widget"
+	"github.com/mscrnt/project_fire/pkg/config"
 	"github.com/mscrnt/project_fire/pkg/db"
 	"github.com/mscrnt/project_fire/pkg/plugin"
+	"github.com/mscrnt/project_fire/pkg/testrun"
 )
 
 // TestWizard represents the test configuration wizard
@@ -213,7 +215,7 @@ func (w *TestWizard) updateParameterForm() {
 		return
 	}
 
-	defaultParams := p.DefaultParams()
+	defaultParams := pluginDefaults(p)
 
 	// Add duration field
 	durationEntry := widget.NewEntry()
@@ -229,7 +231,7 @@ func (w *TestWizard) updateParameterForm() {
 		}
 		w.paramForm.Append("Threads", threadsEntry)
 
-	case "memory":
+	case "memory", "disk":
 		sizeEntry := widget.NewEntry()
 		if size, ok := defaultParams.Config["size_mb"].(int); ok {
 			sizeEntry.SetText(strconv.Itoa(size))
@@ -279,7 +281,7 @@ func (w *TestWizard) updateSummary() {
 		if threads, ok := w.params["threads"].(int); ok {
 			summary += fmt.Sprintf("Threads: %d\n", threads)
 		}
-	case "memory":
+	case "memory", "disk":
 		if size, ok := w.params["size_mb"].(int); ok {
 			summary += fmt.Sprintf("Size: %d MB\n", size)
 		}
@@ -324,7 +326,7 @@ func (w *TestWizard) runTest() {
 		}
 
 		// Prepare parameters
-		params := p.DefaultParams()
+		params := pluginDefaults(p)
 		if duration, ok := w.params["duration"].(float64); ok {
 			params.Duration = time.Duration(duration) * time.Second
 		}
@@ -337,61 +339,40 @@ func (w *TestWizard) runTest() {
 		}
 
 		// Open database
-		database, err := db.Open(w.dbPath)
+		database, err := openResults(w.dbPath)
 		if err != nil {
 			w.appendLog(fmt.Sprintf("Database error: %v\n", err))
 			return
 		}
 		defer func() { _ = database.Close() }()
 
-		// Create run record
-		run, err := database.CreateRun(w.selectedPlugin, params.Config)
-		if err != nil {
-			w.appendLog(fmt.Sprintf("Failed to create run: %v\n", err))
-			return
+		opts, done := runOptions(database, nil, false)
+		defer done()
+		opts.Output = writerFunc(func(text string) { w.appendLog(text) })
+		opts.Started = func(run *db.Run) {
+			w.appendLog(fmt.Sprintf("Created run ID: %d\n", run.ID))
 		}
 
-		w.appendLog(fmt.Sprintf("Created run ID: %d\n", run.ID))
-
-		// Run the test
-		result, err := p.Run(ctx, params)
-		if err != nil {
-			w.appendLog(fmt.Sprintf("Test error: %v\n", err))
-			run.Success = false
-			run.Error = err.Error()
-		} else {
-			run.Success = result.Success
-			run.Stdout = result.Stdout
-			run.Stderr = result.Stderr
-
-			// Save metrics
-			if len(result.Metrics) > 0 {
-				units := make(map[string]string)
-				// Try to get units from plugin info
-				if infoPlugin, ok := p.(interface{ Info() plugin.Info }); ok {
-					info := infoPlugin.Info()
-					for _, metric := range info.Metrics {
-						units[metric.Name] = metric.Unit
-					}
-				}
-
-				if err := database.CreateResults(run.ID, result.Metrics, units); err != nil {
-					w.appendLog(fmt.Sprintf("Failed to save metrics: %v\n", err))
-				}
-			}
+		outcome, err := testrun.Run(ctx, database, p, params, opts)
+		if outcome == nil {
+			w.appendLog(fmt.Sprintf("Failed to create run: %v\n", err))
+			return
 		}
-
-		// Update run record
-		endTime := time.Now()
-		run.EndTime = &endTime
-		if err := database.UpdateRun(run); err != nil {
-			w.appendLog(fmt.Sprintf("Failed to update run: %v\n", err))
+		run, result := outcome.Run, outcome.Result
+		if outcome.Err != nil {
+			w.appendLog(fmt.Sprintf("Test error: %v\n", outcome.Err))
 		}
 
 		// Display results
 		w.appendLog("\nTest completed!\n")
 		w.appendLog(fmt.Sprintf("Success: %v\n", run.Success))
 		w.appendLog(fmt.Sprintf("Duration: %s\n", run.Duration()))
+		if outcome.Throttling != nil && outcome.Throttling.Samples > 0 {
+			w.appendLog(outcome.Throttling.Summary() + "\n")
+		}
+		if outcome.ECC != nil && (outcome.ECC.Available() || run.Plugin == "memory") {
+			w.appendLog(outcome.ECC.Summary() + "\n")
+		}
 
 		if result.Stdout != "" {
 			w.appendLog("\nOutput:\n" + result.Stdout)
@@ -406,9 +387,32 @@ func (w *TestWizard) runTest() {
 	}()
 }
 
+// writerFunc passes what is written to it to a function, e.g. to show the
+// progress of a run in a log widget
+type writerFunc func(text string)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	f(string(p))
+	return len(p), nil
+}
+
 // appendLog appends text to the log
 func (w *TestWizard) appendLog(text string) {
 	current := w.logEntry.Text
 	w.logEntry.SetText(current + text)
 	w.logEntry.CursorRow = len(w.logEntry.Text)
 }
+
+// pluginDefaults returns the default parameters of a plugin with the
+// plugin defaults of the settings applied
+func pluginDefaults(p plugin.TestPlugin) plugin.Params {
+	settings, err := config.Load()
+	if err != nil {
+		return p.DefaultParams()
+	}
+	params, err := settings.PluginParams(p)
+	if err != nil {
+		return p.DefaultParams()
+	}
+	return params
+}
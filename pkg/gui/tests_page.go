@@ -1,8 +1,6 @@
 package gui
 
 import (
-	"fmt"
-
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
@@ -13,6 +11,7 @@ import (
 // TestsPage represents the tests selection page
 type TestsPage struct {
 	content fyne.CanvasObject
+	tests   []TestOption
 }
 
 // TestOption represents a test option
@@ -21,18 +20,19 @@ type TestOption struct {
 	Description string
 	Icon        fyne.Resource
 	Category    string
+	Plugin      string // The plugin that runs it; empty when none does yet
 	OnStart     func()
 }
 
-// NewTestsPage creates a new tests page
-func NewTestsPage() *TestsPage {
+// NewTestsPage creates a new tests page whose presets are started with start
+func NewTestsPage(start func(TestOption)) *TestsPage {
 	t := &TestsPage{}
-	t.build()
+	t.build(start)
 	return t
 }
 
 // build creates the tests page UI
-func (t *TestsPage) build() {
+func (t *TestsPage) build(start func(TestOption)) {
 	// Title
 	title := widget.NewLabelWithStyle("Performance Tests", fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
 
@@ -44,14 +44,14 @@ func (t *TestsPage) build() {
 			Description: "Test CPU performance under maximum load",
 			Icon:        theme.ComputerIcon(),
 			Category:    "CPU",
-			OnStart:     func() { fmt.Println("Starting CPU stress test...") },
+			Plugin:      "cpu",
 		},
 		{
 			Name:        "CPU Benchmark",
 			Description: "Measure CPU computational performance",
 			Icon:        theme.ComputerIcon(),
 			Category:    "CPU",
-			OnStart:     func() { fmt.Println("Starting CPU benchmark...") },
+			Plugin:      "cpu",
 		},
 		// Memory Tests
 		{
@@ -59,14 +59,14 @@ func (t *TestsPage) build() {
 			Description: "Test RAM for errors and stability",
 			Icon:        theme.StorageIcon(),
 			Category:    "Memory",
-			OnStart:     func() { fmt.Println("Starting memory test...") },
+			Plugin:      "memory",
 		},
 		{
 			Name:        "Memory Bandwidth",
 			Description: "Measure memory throughput performance",
 			Icon:        theme.StorageIcon(),
 			Category:    "Memory",
-			OnStart:     func() { fmt.Println("Starting memory bandwidth test...") },
+			Plugin:      "memory",
 		},
 		// GPU Tests
 		{
@@ -74,21 +74,18 @@ func (t *TestsPage) build() {
 			Description: "Test GPU stability under load",
 			Icon:        theme.ColorPaletteIcon(),
 			Category:    "GPU",
-			OnStart:     func() { fmt.Println("Starting GPU stress test...") },
 		},
 		{
 			Name:        "3D Graphics Test",
 			Description: "Test 3D rendering performance",
 			Icon:        theme.ColorPaletteIcon(),
 			Category:    "GPU",
-			OnStart:     func() { fmt.Println("Starting 3D graphics test...") },
 		},
 		{
 			Name:        "GPU Compute Test",
 			Description: "Test GPU compute capabilities",
 			Icon:        theme.ColorPaletteIcon(),
 			Category:    "GPU",
-			OnStart:     func() { fmt.Println("Starting GPU compute test...") },
 		},
 		// Storage Tests
 		{
@@ -96,14 +93,13 @@ func (t *TestsPage) build() {
 			Description: "Measure storage read/write performance",
 			Icon:        theme.FolderIcon(),
 			Category:    "Storage",
-			OnStart:     func() { fmt.Println("Starting disk speed test...") },
+			Plugin:      "disk",
 		},
 		{
 			Name:        "SMART Test",
 			Description: "Check disk health and SMART data",
 			Icon:        theme.FolderIcon(),
 			Category:    "Storage",
-			OnStart:     func() { fmt.Println("Starting SMART test...") },
 		},
 		// Combined Tests
 		{
@@ -111,24 +107,28 @@ func (t *TestsPage) build() {
 			Description: "Comprehensive test of all components",
 			Icon:        theme.ViewFullScreenIcon(),
 			Category:    "System",
-			OnStart:     func() { fmt.Println("Starting full system test...") },
 		},
 		{
 			Name:        "Stability Test",
 			Description: "Long-duration stability testing",
 			Icon:        theme.ViewFullScreenIcon(),
 			Category:    "System",
-			OnStart:     func() { fmt.Println("Starting stability test...") },
+			Plugin:      "cpu",
 		},
 		{
 			Name:        "Power Test",
 			Description: "Test power consumption and efficiency",
 			Icon:        theme.ViewFullScreenIcon(),
 			Category:    "System",
-			OnStart:     func() { fmt.Println("Starting power test...") },
 		},
 	}
 
+	for i := range testOptions {
+		test := testOptions[i]
+		testOptions[i].OnStart = func() { start(test) }
+	}
+	t.tests = testOptions
+
 	// Group tests by category
 	categories := make(map[string][]TestOption)
 	for _, test := range testOptions {
@@ -205,6 +205,11 @@ func (t *TestsPage) Content() fyne.CanvasObject {
 	return t.content
 }
 
+// Tests returns the test presets shown on the page
+func (t *TestsPage) Tests() []TestOption {
+	return t.tests
+}
+
 // createTestsAccordion creates an accordion with test categories
 func createTestsAccordion() *widget.Accordion {
 	// CPU Tests
@@ -0,0 +1,206 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/systray"
+	"github.com/mscrnt/project_fire/pkg/config"
+	"github.com/mscrnt/project_fire/pkg/safety"
+)
+
+// trayInterval is how often the tray reads the temperatures for its tooltip
+const trayInterval = 5 * time.Second
+
+// The quick stress test started from the tray
+const (
+	quickStressPlugin   = "cpu"
+	quickStressDuration = 5 * time.Minute
+)
+
+// trayAlertLength is how much of the last alert the tray menu shows
+const trayAlertLength = 60
+
+// tray is the system tray icon of the GUI. Its tooltip shows the CPU and GPU
+// temperatures, its icon turns to a warning while an alert is firing, and
+// its menu opens the dashboard, starts a quick stress test or pauses
+// monitoring.
+type tray struct {
+	g      *FireGUI
+	app    desktop.App
+	window fyne.Window
+	menu   *fyne.Menu
+	status *fyne.MenuItem
+	stress *fyne.MenuItem
+	pause  *fyne.MenuItem
+	alert  bool // The warning icon is shown
+}
+
+// SetupTray adds the tray icon when the settings keep the GUI in the tray
+// (gui.tray) or always is set, e.g. by --tray, and the platform has a
+// system tray. window is the one Open Dashboard shows. It reports whether
+// the icon was added, in which case closing the window should hide it.
+func (g *FireGUI) SetupTray(window fyne.Window, always bool) bool {
+	app, ok := g.app.(desktop.App)
+	if !ok {
+		return false
+	}
+	if !always {
+		if settings, err := config.Load(); err != nil || !settings.GUI.Tray {
+			return false
+		}
+	}
+
+	t := &tray{g: g, app: app, window: window}
+	t.status = fyne.NewMenuItem("", nil)
+	t.status.Disabled = true
+	t.stress = fyne.NewMenuItem("", t.toggleStress)
+	t.pause = fyne.NewMenuItem("Pause Monitoring", t.togglePause)
+	t.menu = fyne.NewMenu("F.I.R.E.",
+		t.status,
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Open Dashboard", t.open),
+		t.stress,
+		t.pause,
+	)
+	t.updateMenu()
+	app.SetSystemTrayMenu(t.menu)
+	app.SetSystemTrayIcon(g.app.Icon())
+
+	// Notifications come from the alert engine, which runs while the
+	// window is hidden
+	g.WatchAlerts()
+	go t.watch()
+	return true
+}
+
+// open shows the window on the dashboard
+func (t *tray) open() {
+	t.window.Show()
+	t.window.RequestFocus()
+	t.g.navigation.ShowPage(0)
+}
+
+// toggleStress starts the quick stress test, or aborts the running test
+func (t *tray) toggleStress() {
+	if t.g.stability.Running() {
+		t.g.stability.Abort()
+	} else if err := t.g.stability.QuickStart(quickStressPlugin, quickStressDuration); err != nil {
+		notifyWarning("Quick Stress Test", err.Error())
+	} else {
+		t.g.app.SendNotification(&fyne.Notification{
+			Title:   "Quick Stress Test",
+			Content: fmt.Sprintf("Running the %s test for %s; see the Stability Test page", quickStressPlugin, formatDuration(quickStressDuration)),
+		})
+	}
+	t.updateMenu()
+}
+
+// togglePause pauses or resumes the dashboard readings
+func (t *tray) togglePause() {
+	t.g.dashboard.SetPaused(!t.g.dashboard.Paused())
+	t.updateMenu()
+}
+
+// updateMenu brings the menu in line with the GUI, rebuilding the tray
+// menu only when an item changed
+func (t *tray) updateMenu() {
+	firing, last := t.g.dashboard.AlertState()
+	paused := t.g.dashboard.Paused()
+	status := trayAlertStatus(firing, last)
+	stress := fmt.Sprintf("Quick Stress Test (%s)", formatDuration(quickStressDuration))
+	if t.g.stability.Running() {
+		stress = "Abort Stress Test"
+	}
+
+	changed := t.status.Label != status || t.stress.Label != stress || t.pause.Checked != paused
+	t.status.Label = status
+	t.stress.Label = stress
+	t.pause.Checked = paused
+	if changed {
+		t.menu.Refresh()
+	}
+
+	if alert := firing > 0; alert != t.alert {
+		t.alert = alert
+		if alert {
+			t.app.SetSystemTrayIcon(theme.NewErrorThemedResource(theme.WarningIcon()))
+		} else {
+			t.app.SetSystemTrayIcon(t.g.app.Icon())
+		}
+	}
+}
+
+// watch updates the tooltip and menu every trayInterval until the
+// dashboard stops
+func (t *tray) watch() {
+	sample := safety.MonitorSampler()
+	ticker := time.NewTicker(trayInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-t.g.dashboard.stopChan:
+			return
+		}
+
+		var values map[string]float64
+		paused := t.g.dashboard.Paused()
+		if !paused {
+			values, _ = sample(context.Background())
+		}
+		systray.SetTooltip(trayTooltip(values, paused))
+		fyne.Do(t.updateMenu)
+	}
+}
+
+// trayTooltip describes the temperatures for the tray tooltip, e.g.
+// "F.I.R.E. - CPU 64°C, GPU 51°C". The hottest GPU is shown.
+func trayTooltip(values map[string]float64, paused bool) string {
+	if paused {
+		return "F.I.R.E. - monitoring paused"
+	}
+	var temps []string
+	if temp, ok := values["cpu_temp"]; ok {
+		temps = append(temps, "CPU "+formatTemperature(temp, "%.0f"))
+	}
+	var gpus []float64
+	for name, value := range values {
+		if strings.HasPrefix(name, "gpu") && strings.HasSuffix(name, "_temp") {
+			gpus = append(gpus, value)
+		}
+	}
+	if len(gpus) > 0 {
+		sort.Float64s(gpus)
+		temps = append(temps, "GPU "+formatTemperature(gpus[len(gpus)-1], "%.0f"))
+	}
+	if len(temps) == 0 {
+		return "F.I.R.E. - no temperature sensors"
+	}
+	return "F.I.R.E. - " + strings.Join(temps, ", ")
+}
+
+// trayAlertStatus describes the firing alerts for the tray menu
+func trayAlertStatus(firing int, last string) string {
+	switch {
+	case firing == 0:
+		return "No alerts firing"
+	case last == "" && firing == 1:
+		return "1 alert firing"
+	case last == "":
+		return fmt.Sprintf("%d alerts firing", firing)
+	}
+	if runes := []rune(last); len(runes) > trayAlertLength {
+		last = string(runes[:trayAlertLength-1]) + "…"
+	}
+	if firing == 1 {
+		return "Alert: " + last
+	}
+	return fmt.Sprintf("%d alerts firing, last: %s", firing, last)
+}
@@ -0,0 +1,37 @@
+package gui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTrayTooltip(t *testing.T) {
+	values := map[string]float64{"cpu_temp": 64.4, "gpu0_temp": 51, "gpu1_temp": 58.2, "gpu1_hotspot": 70, "cpu_usage": 99}
+	if got, want := trayTooltip(values, false), "F.I.R.E. - CPU "+formatTemperature(64.4, "%.0f")+", GPU "+formatTemperature(58.2, "%.0f"); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if got := trayTooltip(map[string]float64{"cpu_usage": 5}, false); !strings.Contains(got, "no temperature") {
+		t.Errorf("expected no temperatures, got %q", got)
+	}
+	if got := trayTooltip(values, true); !strings.Contains(got, "paused") {
+		t.Errorf("expected monitoring to be paused, got %q", got)
+	}
+}
+
+func TestTrayAlertStatus(t *testing.T) {
+	for _, tc := range []struct {
+		firing int
+		last   string
+		want   string
+	}{
+		{0, "CPU hot: cpu_temp is 93.5", "No alerts firing"},
+		{1, "", "1 alert firing"},
+		{1, "CPU hot: cpu_temp is 93.5", "Alert: CPU hot: cpu_temp is 93.5"},
+		{3, "", "3 alerts firing"},
+		{2, strings.Repeat("x", 100), "2 alerts firing, last: " + strings.Repeat("x", trayAlertLength-1) + "…"},
+	} {
+		if got := trayAlertStatus(tc.firing, tc.last); got != tc.want {
+			t.Errorf("trayAlertStatus(%d, %q) = %q, expected %q", tc.firing, tc.last, got, tc.want)
+		}
+	}
+}
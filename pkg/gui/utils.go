@@ -2,18 +2,31 @@ package gui
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 	"time"
+
+	"github.com/mscrnt/project_fire/pkg/config"
+	"github.com/mscrnt/project_fire/pkg/db"
 )
 
-// getDefaultDBPath returns the default database path
+// getDefaultDBPath returns the database path of the settings
 func getDefaultDBPath() string {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return "fire.db"
+	if settings, err := config.Load(); err == nil && settings.Database.Path != "" {
+		return settings.Database.Path
+	}
+	return config.DefaultDBPath()
+}
+
+// openResults opens the results database the CLI records runs in: the
+// configured PostgreSQL server, or else the SQLite database at path
+func openResults(path string) (*db.DB, error) {
+	if settings, err := config.Load(); err == nil {
+		if driver, err := db.ParseDriver(string(settings.Database.Driver)); err == nil && driver == db.DriverPostgres {
+			cfg := settings.Database
+			cfg.Driver = driver
+			return db.OpenConfig(cfg)
+		}
 	}
-	return filepath.Join(homeDir, ".fire", "fire.db")
+	return db.Open(path)
 }
 
 // formatDuration formats a duration for display
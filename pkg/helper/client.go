@@ -0,0 +1,133 @@
+package helper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Client talks to the privileged helper over its local socket
+type Client struct {
+	socketPath string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the helper listening on socketPath.
+// An empty path selects DefaultSocketPath.
+func NewClient(socketPath string) *Client {
+	if socketPath == "" {
+		socketPath = DefaultSocketPath()
+	}
+
+	dialer := &net.Dialer{Timeout: 2 * time.Second}
+	return &Client{
+		socketPath: socketPath,
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return dialer.DialContext(ctx, "unix", socketPath)
+				},
+			},
+			Timeout: commandTimeout + 5*time.Second,
+		},
+	}
+}
+
+// SocketPath returns the socket the client connects to
+func (c *Client) SocketPath() string {
+	return c.socketPath
+}
+
+// Available reports whether a helper is answering on the socket
+func (c *Client) Available() bool {
+	_, err := c.get("/v1/health", nil)
+	return err == nil
+}
+
+// SMART returns smartctl output (info, attributes and health) for a device
+func (c *Client) SMART(device string) (string, error) {
+	var resp OutputResponse
+	if err := c.getJSON("/v1/smart", url.Values{"device": {device}}, &resp); err != nil {
+		return "", err
+	}
+	return resp.Output, nil
+}
+
+// SMARTPassthrough returns smartctl output for a drive behind a RAID
+// controller, addressed with a smartctl device type such as megaraid,0
+func (c *Client) SMARTPassthrough(device, devType string) (string, error) {
+	var resp OutputResponse
+	if err := c.getJSON("/v1/smart", url.Values{"device": {device}, "type": {devType}}, &resp); err != nil {
+		return "", err
+	}
+	return resp.Output, nil
+}
+
+// SMARTScan returns the JSON output of smartctl --scan-open
+func (c *Client) SMARTScan() (string, error) {
+	var resp OutputResponse
+	if err := c.getJSON("/v1/smart/scan", nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.Output, nil
+}
+
+// DMI returns the decoded DMI table of the given type (memory, baseboard, bios, processor)
+func (c *Client) DMI(table string) (string, error) {
+	var resp OutputResponse
+	if err := c.getJSON("/v1/dmi", url.Values{"type": {table}}, &resp); err != nil {
+		return "", err
+	}
+	return resp.Output, nil
+}
+
+// ReadMSR reads an allowlisted model-specific register on a CPU
+func (c *Client) ReadMSR(cpu int, register string) (uint64, error) {
+	var resp MSRResponse
+	query := url.Values{"cpu": {strconv.Itoa(cpu)}, "register": {register}}
+	if err := c.getJSON("/v1/msr", query, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Value, nil
+}
+
+// getJSON performs a GET request and decodes the JSON response into v
+func (c *Client) getJSON(path string, query url.Values, v interface{}) error {
+	body, err := c.get(path, query)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// get performs a GET request against the helper
+func (c *Client) get(path string, query url.Values) ([]byte, error) {
+	// The host is ignored; requests always go to the Unix socket
+	u := url.URL{Scheme: "http", Host: "fire-helper", Path: path, RawQuery: query.Encode()}
+
+	resp, err := c.httpClient.Get(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to helper: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("helper returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return body, nil
+}
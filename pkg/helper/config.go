@@ -0,0 +1,57 @@
+package helper
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// Config contains configuration for the privileged helper
+type Config struct {
+	SocketPath string      // Unix domain socket the helper listens on
+	SocketMode os.FileMode // Permissions applied to the socket
+	Group      string      // Optional group allowed to connect (Unix only)
+	LogFile    string      // Optional log file path
+}
+
+// DefaultSocketPath returns the platform default socket location
+func DefaultSocketPath() string {
+	if path := os.Getenv("FIRE_HELPER_SOCKET"); path != "" {
+		return path
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		programData := os.Getenv("ProgramData")
+		if programData == "" {
+			programData = `C:\ProgramData`
+		}
+		return filepath.Join(programData, "FIRE", "helper.sock")
+	case "darwin":
+		return "/var/run/fire-helper.sock"
+	default:
+		return "/run/fire-helper.sock"
+	}
+}
+
+// DefaultConfig returns default helper configuration
+func DefaultConfig() Config {
+	return Config{
+		SocketPath: DefaultSocketPath(),
+		SocketMode: 0o660,
+	}
+}
+
+// Validate checks if the configuration is valid
+func (c Config) Validate() error {
+	if c.SocketPath == "" {
+		return fmt.Errorf("socket path is required")
+	}
+
+	if c.SocketMode&0o002 != 0 {
+		return fmt.Errorf("socket mode %o is world-writable; use a group to share the helper", c.SocketMode)
+	}
+
+	return nil
+}
@@ -0,0 +1,183 @@
+package helper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// commandTimeout bounds how long a single helper query may run
+const commandTimeout = 30 * time.Second
+
+// OutputResponse carries the raw output of a privileged tool
+type OutputResponse struct {
+	Output string `json:"output"`
+}
+
+// MSRResponse carries a model-specific register value
+type MSRResponse struct {
+	CPU      int    `json:"cpu"`
+	Register string `json:"register"`
+	Value    uint64 `json:"value"`
+}
+
+// dmiTypes lists the DMI tables that may be queried
+var dmiTypes = map[string]bool{
+	"memory":    true,
+	"baseboard": true,
+	"bios":      true,
+	"processor": true,
+}
+
+// msrRegisters lists the model-specific registers that may be read. Only
+// thermal and voltage status registers are exposed; nothing is writable.
+// The RAPL energy counters are left out: read at a high rate they leak
+// what other processes compute (PLATYPUS), which is why Linux keeps them
+// root-only.
+var msrRegisters = map[string]int64{
+	"perf_status":          0x198, // IA32_PERF_STATUS
+	"therm_status":         0x19C, // IA32_THERM_STATUS
+	"temperature_target":   0x1A2, // MSR_TEMPERATURE_TARGET
+	"package_therm_status": 0x1B1, // IA32_PACKAGE_THERM_STATUS
+}
+
+// healthHandler returns server health status
+func healthHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	_, _ = fmt.Fprintf(w, "OK\n")
+}
+
+// smartHandler returns smartctl output for a single device
+func smartHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	device := r.URL.Query().Get("device")
+	if err := safeexec.ValidateDevicePath(device); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	args := []string{"-i", "-A", "-H"}
+	if devType := r.URL.Query().Get("type"); devType != "" {
+		if err := safeexec.ValidateSMARTDeviceType(devType); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		args = append(args, "-d", devType)
+	}
+	args = append(args, device)
+
+	ctx, cancel := context.WithTimeout(r.Context(), commandTimeout)
+	defer cancel()
+
+	// smartctl uses non-zero exit codes for drive warnings, so any output is returned
+	output, err := safeexec.CommandContext(ctx, "smartctl", args...).Output()
+	if err != nil && len(output) == 0 {
+		http.Error(w, fmt.Sprintf("smartctl failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, OutputResponse{Output: string(output)})
+}
+
+// smartScanHandler returns the devices smartctl can open, including drives
+// that are only reachable through a RAID controller
+func smartScanHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), commandTimeout)
+	defer cancel()
+
+	output, err := safeexec.CommandContext(ctx, "smartctl", "--scan-open", "-j").Output()
+	if err != nil && len(output) == 0 {
+		http.Error(w, fmt.Sprintf("smartctl failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, OutputResponse{Output: string(output)})
+}
+
+// dmiHandler returns a decoded DMI/SMBIOS table
+func dmiHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	table := r.URL.Query().Get("type")
+	if !dmiTypes[table] {
+		http.Error(w, fmt.Sprintf("unsupported DMI type %q", table), http.StatusBadRequest)
+		return
+	}
+
+	if runtime.GOOS == "windows" {
+		http.Error(w, "DMI tables are read through WMI on Windows", http.StatusNotImplemented)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), commandTimeout)
+	defer cancel()
+
+	output, err := safeexec.CommandContext(ctx, "dmidecode", "-t", table).Output()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("dmidecode failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, OutputResponse{Output: string(output)})
+}
+
+// msrHandler reads an allowlisted model-specific register on one CPU
+func msrHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := r.URL.Query().Get("register")
+	offset, ok := msrRegisters[name]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unsupported register %q", name), http.StatusBadRequest)
+		return
+	}
+
+	cpu, err := strconv.Atoi(r.URL.Query().Get("cpu"))
+	if err != nil || cpu < 0 || cpu >= runtime.NumCPU() {
+		http.Error(w, "invalid cpu", http.StatusBadRequest)
+		return
+	}
+
+	value, err := readMSR(cpu, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, MSRResponse{CPU: cpu, Register: name, Value: value})
+}
+
+// writeJSON encodes v as the response body
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,87 @@
+package helper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHandlersRejectUnsafeInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		url     string
+	}{
+		{"device injection", smartHandler, "/v1/smart?device=/dev/sda;reboot"},
+		{"device flag", smartHandler, "/v1/smart?device=--scan"},
+		{"device type injection", smartHandler, "/v1/smart?device=/dev/sda&type=megaraid,0%3Breboot"},
+		{"unknown dmi table", dmiHandler, "/v1/dmi?type=all"},
+		{"unknown register", msrHandler, "/v1/msr?cpu=0&register=0x10"},
+		{"energy counter", msrHandler, "/v1/msr?cpu=0&register=pkg_energy_status"},
+		{"negative cpu", msrHandler, "/v1/msr?cpu=-1&register=therm_status"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.handler(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+			}
+		})
+	}
+}
+
+func TestConfigRejectsWorldWritableSocket(t *testing.T) {
+	config := DefaultConfig()
+	config.SocketMode = 0o666
+	if err := config.Validate(); err == nil {
+		t.Error("expected a world-writable socket to be rejected")
+	}
+	config.SocketMode = 0o660
+	config.Group = "fire"
+	if err := config.Validate(); err != nil {
+		t.Errorf("expected a group socket to be accepted: %v", err)
+	}
+}
+
+func TestClientServerOverSocket(t *testing.T) {
+	// Keep the path short; Unix socket paths are limited to ~100 bytes
+	dir, err := os.MkdirTemp("", "fh")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	config := DefaultConfig()
+	config.SocketPath = filepath.Join(dir, "helper.sock")
+	config.SocketMode = 0o600
+
+	server, err := NewServer(config)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	go func() { _ = server.Start() }()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+	}()
+
+	client := NewClient(config.SocketPath)
+	deadline := time.Now().Add(2 * time.Second)
+	for !client.Available() {
+		if time.Now().After(deadline) {
+			t.Fatal("helper did not become available")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := client.SMART("not-a-device"); err == nil {
+		t.Error("expected invalid device to be rejected")
+	}
+}
@@ -0,0 +1,26 @@
+//go:build linux
+// +build linux
+
+package helper
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+)
+
+// readMSR reads a model-specific register through the msr kernel module
+func readMSR(cpu int, offset int64) (uint64, error) {
+	path := fmt.Sprintf("/dev/cpu/%d/msr", cpu)
+	f, err := os.Open(path) // #nosec G304 -- cpu is a validated integer
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %s (is the msr module loaded?): %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var buf [8]byte
+	if _, err := f.ReadAt(buf[:], offset); err != nil {
+		return 0, fmt.Errorf("failed to read MSR 0x%X: %w", offset, err)
+	}
+	return binary.LittleEndian.Uint64(buf[:]), nil
+}
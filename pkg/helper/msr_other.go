@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package helper
+
+import "fmt"
+
+// readMSR is only supported on Linux
+func readMSR(_ int, offset int64) (uint64, error) {
+	return 0, fmt.Errorf("reading MSR 0x%X is not supported on this platform", offset)
+}
@@ -0,0 +1,168 @@
+// Package helper implements the privileged helper service.
+//
+// Reading SMART data, SPD/DMI tables and CPU MSRs needs root or
+// Administrator rights. Instead of elevating the whole GUI, a small helper
+// runs as a service and answers a fixed set of read-only queries over a
+// local Unix domain socket. The GUI and CLI stay unprivileged and use Client.
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// Server is the privileged helper server
+type Server struct {
+	config     Config
+	httpServer *http.Server
+	listener   net.Listener
+	logger     *log.Logger
+}
+
+// NewServer creates a new helper server
+func NewServer(config Config) (*Server, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	// Setup logger
+	logger := log.New(os.Stdout, "[helper] ", log.LstdFlags)
+	if config.LogFile != "" {
+		logFile, err := os.OpenFile(config.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		logger = log.New(logFile, "[helper] ", log.LstdFlags)
+	}
+
+	server := &Server{
+		config: config,
+		logger: logger,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/health", server.loggingMiddleware(healthHandler))
+	mux.HandleFunc("/v1/smart", server.loggingMiddleware(smartHandler))
+	mux.HandleFunc("/v1/smart/scan", server.loggingMiddleware(smartScanHandler))
+	mux.HandleFunc("/v1/dmi", server.loggingMiddleware(dmiHandler))
+	mux.HandleFunc("/v1/msr", server.loggingMiddleware(msrHandler))
+
+	server.httpServer = &http.Server{
+		Handler:      mux,
+		ErrorLog:     logger,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 60 * time.Second,
+		IdleTimeout:  120 * time.Second,
+	}
+
+	return server, nil
+}
+
+// Start listens on the socket and serves requests until Shutdown is called
+func (s *Server) Start() error {
+	if err := os.MkdirAll(filepath.Dir(s.config.SocketPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create socket directory: %w", err)
+	}
+
+	// Remove a stale socket left behind by a previous instance
+	if err := os.Remove(s.config.SocketPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", s.config.SocketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.config.SocketPath, err)
+	}
+	s.listener = listener
+
+	if err := s.restrictSocket(); err != nil {
+		_ = listener.Close()
+		return err
+	}
+
+	s.logger.Printf("Privileged helper listening on %s", s.config.SocketPath)
+
+	err = s.httpServer.Serve(listener)
+	if err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("server error: %w", err)
+	}
+
+	return nil
+}
+
+// Shutdown gracefully shuts down the server and removes the socket
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.logger.Println("Shutting down privileged helper...")
+	err := s.httpServer.Shutdown(ctx)
+	_ = os.Remove(s.config.SocketPath)
+	return err
+}
+
+// restrictSocket applies the configured permissions and group to the socket
+func (s *Server) restrictSocket() error {
+	if runtime.GOOS == "windows" {
+		// Access is governed by the ACL of the socket directory
+		return nil
+	}
+
+	if err := os.Chmod(s.config.SocketPath, s.config.SocketMode); err != nil {
+		return fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+
+	if s.config.Group == "" {
+		return nil
+	}
+
+	group, err := user.LookupGroup(s.config.Group)
+	if err != nil {
+		return fmt.Errorf("failed to look up group %s: %w", s.config.Group, err)
+	}
+	gid, err := strconv.Atoi(group.Gid)
+	if err != nil {
+		return fmt.Errorf("invalid gid for group %s: %w", s.config.Group, err)
+	}
+	if err := os.Chown(s.config.SocketPath, -1, gid); err != nil {
+		return fmt.Errorf("failed to set socket group: %w", err)
+	}
+
+	return nil
+}
+
+// loggingMiddleware logs incoming requests
+func (s *Server) loggingMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next(wrapped, r)
+
+		s.logger.Printf("%s %s?%s %d duration=%s",
+			r.Method,
+			r.URL.Path,
+			r.URL.RawQuery,
+			wrapped.statusCode,
+			time.Since(start),
+		)
+	}
+}
+
+// responseWriter wraps http.ResponseWriter to capture status code
+type responseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (w *responseWriter) WriteHeader(statusCode int) {
+	w.statusCode = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
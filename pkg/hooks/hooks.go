@@ -0,0 +1,179 @@
+// Package hooks runs user-configured scripts before and after test runs.
+//
+// Hooks let a shop plug its own steps around every run, such as mounting a
+// network share for artifacts or switching on a light while a machine is
+// under test. Each hook is an executable run directly, without a shell, with
+// the run metadata in FIRE_* environment variables.
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// DefaultTimeout limits how long a single hook may run
+const DefaultTimeout = time.Minute
+
+// Phase identifies when a hook runs
+type Phase string
+
+// Hook phases
+const (
+	PreRun  Phase = "pre_run"
+	PostRun Phase = "post_run"
+)
+
+// Hook is a script or program to run
+type Hook struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+}
+
+// Config is the "hooks" section of the settings file
+type Config struct {
+	PreRun  []Hook `json:"pre_run"`
+	PostRun []Hook `json:"post_run"`
+	Timeout string `json:"timeout"` // per hook, e.g. "30s"; default 1m
+
+	// AbortOnFailure skips the test when a pre-run hook fails. Otherwise the
+	// failure is reported and the test runs anyway.
+	AbortOnFailure bool `json:"abort_on_failure"`
+}
+
+// RunInfo is the run metadata passed to hooks
+type RunInfo struct {
+	Run      *db.Run
+	Source   string // "cli", "schedule", "agent", "plan" or "gui"
+	Schedule string // Schedule name for scheduled runs
+
+	// ArtifactDir is where files attached to the run are stored
+	ArtifactDir string
+}
+
+// Enabled reports whether any hooks are configured
+func (c Config) Enabled() bool {
+	return len(c.PreRun) > 0 || len(c.PostRun) > 0
+}
+
+// Validate checks the hook commands and timeout
+func (c Config) Validate() error {
+	if _, err := c.timeout(); err != nil {
+		return err
+	}
+	for _, h := range append(append([]Hook(nil), c.PreRun...), c.PostRun...) {
+		if strings.TrimSpace(h.Command) == "" {
+			return fmt.Errorf("hook command is required")
+		}
+	}
+	return nil
+}
+
+// timeout returns the per-hook timeout
+func (c Config) timeout() (time.Duration, error) {
+	if c.Timeout == "" {
+		return DefaultTimeout, nil
+	}
+	d, err := time.ParseDuration(c.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid hook timeout: %w", err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("hook timeout must be positive")
+	}
+	return d, nil
+}
+
+// Run runs the hooks for phase in order, writing their output to out. All
+// hooks run even if one fails; the first error is returned.
+func (c Config) Run(ctx context.Context, phase Phase, info RunInfo, out io.Writer) error {
+	hooks := c.PreRun
+	if phase == PostRun {
+		hooks = c.PostRun
+	}
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	timeout, err := c.timeout()
+	if err != nil {
+		return err
+	}
+	env := append(os.Environ(), Env(phase, info)...)
+
+	var firstErr error
+	for _, h := range hooks {
+		if err := runHook(ctx, h, env, timeout, out); err != nil {
+			err = fmt.Errorf("%s hook %s failed: %w", phase, h.Command, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			_, _ = fmt.Fprintln(out, err)
+		}
+	}
+	return firstErr
+}
+
+// runHook runs a single hook with a timeout
+func runHook(ctx context.Context, h Hook, env []string, timeout time.Duration, out io.Writer) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := safeexec.CommandContext(ctx, h.Command, h.Args...)
+	cmd.Env = env
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	return err
+}
+
+// Env returns the FIRE_* environment variables describing the run. Post-run
+// hooks also get the outcome.
+func Env(phase Phase, info RunInfo) []string {
+	env := []string{
+		"FIRE_HOOK_PHASE=" + string(phase),
+		"FIRE_RUN_SOURCE=" + info.Source,
+	}
+	if info.Schedule != "" {
+		env = append(env, "FIRE_SCHEDULE="+info.Schedule)
+	}
+
+	run := info.Run
+	if run == nil {
+		return env
+	}
+	params, _ := json.Marshal(run.Params)
+	env = append(env,
+		"FIRE_RUN_ID="+strconv.FormatInt(run.ID, 10),
+		"FIRE_RUN_UUID="+run.UUID,
+		"FIRE_RUN_PLUGIN="+run.Plugin,
+		"FIRE_RUN_PARAMS="+string(params),
+		"FIRE_RUN_START="+run.StartTime.Format(time.RFC3339),
+		"FIRE_RUN_ENVIRONMENT="+run.Environment,
+	)
+	if info.ArtifactDir != "" {
+		env = append(env, "FIRE_RUN_ARTIFACTS="+info.ArtifactDir)
+	}
+
+	if phase == PostRun && run.EndTime != nil {
+		env = append(env,
+			"FIRE_RUN_END="+run.EndTime.Format(time.RFC3339),
+			"FIRE_RUN_DURATION="+strconv.FormatFloat(run.Duration().Seconds(), 'f', 1, 64),
+			"FIRE_RUN_SUCCESS="+strconv.FormatBool(run.Success),
+			"FIRE_RUN_EXIT_CODE="+strconv.Itoa(run.ExitCode),
+			"FIRE_RUN_ERROR="+run.Error,
+		)
+	}
+	return env
+}
@@ -0,0 +1,127 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+func testRun() *db.Run {
+	start := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	end := start.Add(90 * time.Second)
+	return &db.Run{
+		ID:        7,
+		UUID:      "0b9e5f9c-1111-4222-8333-444455556666",
+		Plugin:    "cpu",
+		Params:    db.JSONData{"threads": 4},
+		StartTime: start,
+		EndTime:   &end,
+		Success:   false,
+		ExitCode:  1,
+		Error:     "thermal throttling",
+	}
+}
+
+func TestEnv(t *testing.T) {
+	info := RunInfo{Run: testRun(), Source: "schedule", Schedule: "Nightly", ArtifactDir: "/data/artifacts/7"}
+
+	pre := strings.Join(Env(PreRun, info), "\n")
+	for _, want := range []string{"FIRE_HOOK_PHASE=pre_run", "FIRE_RUN_ID=7", "FIRE_RUN_PLUGIN=cpu",
+		`FIRE_RUN_PARAMS={"threads":4}`, "FIRE_RUN_START=2025-01-02T03:04:05Z", "FIRE_SCHEDULE=Nightly",
+		"FIRE_RUN_ARTIFACTS=/data/artifacts/7"} {
+		if !strings.Contains(pre, want) {
+			t.Errorf("expected %s in pre-run environment:\n%s", want, pre)
+		}
+	}
+	if strings.Contains(pre, "FIRE_RUN_SUCCESS") {
+		t.Error("expected no outcome in pre-run environment")
+	}
+
+	post := strings.Join(Env(PostRun, info), "\n")
+	for _, want := range []string{"FIRE_HOOK_PHASE=post_run", "FIRE_RUN_SUCCESS=false", "FIRE_RUN_EXIT_CODE=1",
+		"FIRE_RUN_DURATION=90.0", "FIRE_RUN_ERROR=thermal throttling"} {
+		if !strings.Contains(post, want) {
+			t.Errorf("expected %s in post-run environment:\n%s", want, post)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		config Config
+		valid  bool
+	}{
+		{Config{}, true},
+		{Config{PreRun: []Hook{{Command: "/opt/fire/mount.sh"}}, Timeout: "30s"}, true},
+		{Config{PostRun: []Hook{{Command: " "}}}, false},
+		{Config{Timeout: "soon"}, false},
+		{Config{Timeout: "-1s"}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.config.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Validate(%+v) = %v, expected valid = %v", tt.config, err, tt.valid)
+		}
+	}
+}
+
+func TestRunScripts(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts")
+	}
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, "env.txt")
+	script := filepath.Join(dir, "hook.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho \"$FIRE_HOOK_PHASE $FIRE_RUN_ID $1\" >> \""+envFile+"\"\n"), 0o700); err != nil { // #nosec G306 -- test script must be executable
+		t.Fatal(err)
+	}
+	failing := filepath.Join(dir, "fail.sh")
+	if err := os.WriteFile(failing, []byte("#!/bin/sh\necho mount failed\nexit 3\n"), 0o700); err != nil { // #nosec G306 -- test script must be executable
+		t.Fatal(err)
+	}
+
+	config := Config{
+		PreRun:  []Hook{{Command: failing}, {Command: script, Args: []string{"first"}}},
+		PostRun: []Hook{{Command: script, Args: []string{"second"}}},
+	}
+	info := RunInfo{Run: testRun(), Source: "cli"}
+
+	var out bytes.Buffer
+	if err := config.Run(context.Background(), PreRun, info, &out); err == nil {
+		t.Error("expected the failing pre-run hook to be reported")
+	}
+	if !strings.Contains(out.String(), "mount failed") {
+		t.Errorf("expected hook output to be captured, got %q", out.String())
+	}
+	if err := config.Run(context.Background(), PostRun, info, &out); err != nil {
+		t.Errorf("unexpected post-run error: %v", err)
+	}
+
+	// Hooks after a failing one still run
+	data, err := os.ReadFile(envFile) // #nosec G304 -- test file in a temp directory
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); got != "pre_run 7 first\npost_run 7 second\n" {
+		t.Errorf("unexpected hook invocations:\n%s", got)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sleep")
+	}
+	config := Config{PreRun: []Hook{{Command: "sleep", Args: []string{"5"}}}, Timeout: "100ms"}
+
+	var out bytes.Buffer
+	err := config.Run(context.Background(), PreRun, RunInfo{Source: "cli"}, &out)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a timeout error, got %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+// Package i18n translates user-facing messages.
+//
+// Catalogs are flat JSON files in translations/, one per language, mapping a
+// message ID to a fmt format string. English (en.json) is the reference
+// catalog: messages missing from another language fall back to it, and
+// unknown IDs are returned unchanged. The CLI and the GUI both translate
+// through T, so they share the catalogs and the language setting.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Fallback is the language used when no catalog matches
+const Fallback = "en"
+
+//go:embed translations/*.json
+var translations embed.FS
+
+var (
+	loadOnce sync.Once
+	catalogs map[string]map[string]string
+
+	mu      sync.RWMutex
+	current = Fallback
+)
+
+// load reads the embedded catalogs. A catalog that fails to parse is left
+// out, so its language falls back to English.
+func load() {
+	catalogs = make(map[string]map[string]string)
+	entries, _ := translations.ReadDir("translations")
+	for _, entry := range entries {
+		data, err := translations.ReadFile(path.Join("translations", entry.Name()))
+		if err != nil {
+			continue
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			continue
+		}
+		catalogs[strings.TrimSuffix(entry.Name(), ".json")] = messages
+	}
+}
+
+// Languages returns the available languages, sorted
+func Languages() []string {
+	loadOnce.Do(load)
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Normalize reduces a locale such as "de_DE.UTF-8" or "pt-BR" to its
+// language code. The POSIX locales "C" and "POSIX" yield "".
+func Normalize(locale string) string {
+	locale = strings.TrimSpace(locale)
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	if i := strings.IndexAny(locale, "_-"); i >= 0 {
+		locale = locale[:i]
+	}
+	locale = strings.ToLower(locale)
+	if locale == "c" || locale == "posix" {
+		return ""
+	}
+	return locale
+}
+
+// Detect returns the language requested by the environment, from
+// LANGUAGE, LC_ALL, LC_MESSAGES or LANG, or "" when none is set
+func Detect() string {
+	if list := os.Getenv("LANGUAGE"); list != "" {
+		// LANGUAGE is a priority list, e.g. "de:en"
+		for _, locale := range strings.Split(list, ":") {
+			if lang := Normalize(locale); lang != "" {
+				return lang
+			}
+		}
+	}
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return Normalize(value)
+		}
+	}
+	return ""
+}
+
+// SetLanguage selects the language for T. Unsupported languages select
+// English. It returns the language in use.
+func SetLanguage(locale string) string {
+	loadOnce.Do(load)
+	lang := Normalize(locale)
+	if _, ok := catalogs[lang]; !ok {
+		lang = Fallback
+	}
+	mu.Lock()
+	current = lang
+	mu.Unlock()
+	return lang
+}
+
+// Language returns the language in use
+func Language() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// lookup returns the format string for a message ID
+func lookup(id string) string {
+	loadOnce.Do(load)
+	if msg, ok := catalogs[Language()][id]; ok {
+		return msg
+	}
+	if msg, ok := catalogs[Fallback][id]; ok {
+		return msg
+	}
+	return id
+}
+
+// T returns the translated message, formatted with args
+func T(id string, args ...interface{}) string {
+	format := lookup(id)
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Errorf returns an error with the translated message. Like fmt.Errorf, a
+// %w verb wraps its argument.
+func Errorf(id string, args ...interface{}) error {
+	return fmt.Errorf(lookup(id), args...)
+}
@@ -0,0 +1,109 @@
+package i18n
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+// placeholderPattern matches fmt verbs and template actions, which
+// translations must keep in the same order
+var placeholderPattern = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]|\{\{[^}]*\}\}`)
+
+func TestCatalogsMatchEnglish(t *testing.T) {
+	loadOnce.Do(load)
+	english := catalogs[Fallback]
+	if len(english) == 0 {
+		t.Fatal("expected an English catalog")
+	}
+
+	for _, lang := range Languages() {
+		catalog := catalogs[lang]
+		for id, msg := range catalog {
+			reference, ok := english[id]
+			if !ok {
+				t.Errorf("%s: message %q is not in the English catalog", lang, id)
+				continue
+			}
+			got := placeholderPattern.FindAllString(msg, -1)
+			want := placeholderPattern.FindAllString(reference, -1)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: message %q has placeholders %v, expected %v", lang, id, got, want)
+			}
+		}
+		for id := range english {
+			if _, ok := catalog[id]; !ok {
+				t.Errorf("%s: missing translation for %q", lang, id)
+			}
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"de_DE.UTF-8":      "de",
+		"pt-BR":            "pt",
+		"fr_FR@euro":       "fr",
+		"ES":               "es",
+		"C":                "",
+		"POSIX":            "",
+		"":                 "",
+		"en_US.ISO-8859-1": "en",
+	}
+	for locale, expected := range tests {
+		if got := Normalize(locale); got != expected {
+			t.Errorf("expected %q for %q, got %q", expected, locale, got)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	t.Setenv("LANGUAGE", "")
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "es_ES.UTF-8")
+	if got := Detect(); got != "es" {
+		t.Errorf("expected es from LANG, got %q", got)
+	}
+
+	t.Setenv("LC_ALL", "fr_FR.UTF-8")
+	if got := Detect(); got != "fr" {
+		t.Errorf("expected LC_ALL to override LANG, got %q", got)
+	}
+
+	t.Setenv("LANGUAGE", "C:de")
+	if got := Detect(); got != "de" {
+		t.Errorf("expected first usable LANGUAGE entry, got %q", got)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	defer SetLanguage(Fallback)
+
+	if lang := SetLanguage("xx_XX"); lang != Fallback {
+		t.Errorf("expected unsupported language to select %s, got %s", Fallback, lang)
+	}
+	if got := T("list.total", 3); got != "Total: 3 runs" {
+		t.Errorf("unexpected English message %q", got)
+	}
+
+	if lang := SetLanguage("de_DE.UTF-8"); lang != "de" {
+		t.Fatalf("expected de, got %s", lang)
+	}
+	if got := T("list.total", 3); got != "Gesamt: 3 Testläufe" {
+		t.Errorf("unexpected German message %q", got)
+	}
+	if got := T("no.such.message"); got != "no.such.message" {
+		t.Errorf("expected unknown ID to be returned unchanged, got %q", got)
+	}
+
+	cause := errors.New("disk full")
+	err := Errorf("error.open_database", cause)
+	if !errors.Is(err, cause) {
+		t.Error("expected translated error to wrap its cause")
+	}
+	if err.Error() != "Datenbank konnte nicht geöffnet werden: disk full" {
+		t.Errorf("unexpected error %q", err)
+	}
+}
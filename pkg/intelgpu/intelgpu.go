@@ -0,0 +1,34 @@
+// Package intelgpu reads the live utilization, graphics frequency and power
+// of Intel graphics, integrated and Arc, which the vendor tools the other
+// GPU readers use do not cover.
+//
+// On Linux it reads the i915 and xe drivers' sysfs files, and the i915 PMU's
+// engine busy counters (the ones intel_gpu_top shows) where the process may
+// open them. On Windows it reads the GPU engine performance counters Task
+// Manager shows, which the graphics kernel (D3DKMT) keeps, and the Intel
+// Graphics Control Library (IGCL) for frequency and power.
+package intelgpu
+
+// GPU holds one Intel GPU's readings since the previous read. Values the
+// driver does not report are zero.
+type GPU struct {
+	Card     string  // DRM card on Linux, e.g. "card0"
+	Name     string  // Adapter name on Windows, e.g. "Intel(R) UHD Graphics 770"
+	Usage    float64 // Percent of the time the busiest engine was running
+	Clock    float64 // Actual graphics frequency in MHz
+	MaxClock float64 // Highest graphics frequency the GPU runs at, in MHz
+	Power    float64 // W
+	Temp     float64 // °C
+}
+
+// Meter turns the drivers' counters into rates between reads, so the first
+// read reports no usage or power. It is not safe for concurrent use.
+type Meter struct {
+	meterState
+}
+
+// clampPercent limits a utilization computed from counters to 0-100, which
+// rounding between the counters and the clock can overshoot
+func clampPercent(v float64) float64 {
+	return min(max(v, 0), 100)
+}
@@ -0,0 +1,259 @@
+package intelgpu
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// sysfsRoot is the root the driver files are read under, replaced in tests
+var sysfsRoot = "/"
+
+// Counter names, the values a card's readings are computed from
+const (
+	counterIdle   = "idle"   // Time in RC6 (i915) or idle (xe), in ms
+	counterEnergy = "energy" // Energy used, in µJ
+	counterBusy   = "busy:"  // Prefix of each engine's busy time, in ns
+)
+
+type meterState struct {
+	last     map[string]map[string]float64 // Counters by card
+	lastTime time.Time
+	pmus     map[string]*pmu // Nil for cards whose PMU cannot be opened
+}
+
+// Read returns every GPU driven by i915 or xe, in card order
+func (m *Meter) Read() []GPU {
+	cards, _ := filepath.Glob(filepath.Join(sysfsRoot, "sys/class/drm/card[0-9]*"))
+	now := time.Now()
+	last, elapsed := m.last, now.Sub(m.lastTime)
+	m.last, m.lastTime = make(map[string]map[string]float64), now
+
+	var gpus []GPU
+	for _, dir := range cards {
+		card := filepath.Base(dir)
+		if strings.Contains(card, "-") || readFile(dir, "device/vendor") != "0x8086" {
+			continue // A connector such as card0-HDMI-A-1, or not Intel
+		}
+		counters := make(map[string]float64)
+		g := GPU{Card: card}
+		switch driver(dir) {
+		case "i915":
+			readI915(dir, &g, counters)
+			if p := m.pmu(dir); p != nil {
+				p.read(counters)
+			}
+		case "xe":
+			readXe(dir, &g, counters)
+		default:
+			continue
+		}
+		readHwmon(dir, &g, counters)
+		m.last[card] = counters
+
+		if prev, ok := last[card]; ok && elapsed > 0 {
+			g.Usage, g.Power = rates(prev, counters, elapsed)
+		}
+		gpus = append(gpus, g)
+	}
+	return gpus
+}
+
+// rates computes the utilization and power between two readings of a card's
+// counters. Usage is the busiest engine's share of the time where the PMU
+// counters were read, else the share of the time the GPU was out of RC6.
+func rates(prev, cur map[string]float64, elapsed time.Duration) (usage, power float64) {
+	delta := func(name string) (float64, bool) {
+		a, okA := prev[name]
+		b, okB := cur[name]
+		if !okA || !okB || b < a {
+			return 0, false // Missing, or the counter wrapped or was reset
+		}
+		return b - a, true
+	}
+
+	engines := false
+	for name := range cur {
+		if !strings.HasPrefix(name, counterBusy) {
+			continue
+		}
+		if busy, ok := delta(name); ok {
+			engines = true
+			usage = max(usage, busy/float64(elapsed.Nanoseconds())*100)
+		}
+	}
+	if !engines {
+		if idle, ok := delta(counterIdle); ok {
+			usage = 100 - idle/float64(elapsed.Milliseconds())*100
+		}
+	}
+	if energy, ok := delta(counterEnergy); ok {
+		power = energy / 1e6 / elapsed.Seconds()
+	}
+	return clampPercent(usage), power
+}
+
+// readI915 reads an i915 card's frequency and RC6 residency. Kernels from
+// 5.13 keep them per GT under gt/gt0; older ones only at the card.
+func readI915(dir string, g *GPU, counters map[string]float64) {
+	g.Clock = firstNumber(dir, "gt/gt0/rps_act_freq_mhz", "gt_act_freq_mhz")
+	g.MaxClock = firstNumber(dir, "gt/gt0/rps_RP0_freq_mhz", "gt_RP0_freq_mhz")
+	for _, name := range []string{"gt/gt0/rc6_residency_ms", "power/rc6_residency_ms"} {
+		if v, ok := number(dir, name); ok {
+			counters[counterIdle] = v
+			break
+		}
+	}
+}
+
+// readXe reads an xe card's frequency and idle residency from its first GT
+func readXe(dir string, g *GPU, counters map[string]float64) {
+	gt := "device/tile0/gt0/"
+	g.Clock = firstNumber(dir, gt+"freq0/act_freq")
+	g.MaxClock = firstNumber(dir, gt+"freq0/rp0_freq")
+	if v, ok := number(dir, gt+"gtidle/idle_residency_ms"); ok {
+		counters[counterIdle] = v
+	}
+}
+
+// readHwmon reads the temperature and energy the driver's hwmon reports,
+// which Arc cards have and integrated GPUs do not. For those the energy
+// comes from the RAPL uncore domain, which is readable by root only.
+func readHwmon(dir string, g *GPU, counters map[string]float64) {
+	hwmons, _ := filepath.Glob(filepath.Join(dir, "device/hwmon/hwmon*"))
+	for _, hwmon := range hwmons {
+		if v, ok := number(hwmon, "temp1_input"); ok {
+			g.Temp = v / 1000 // Millidegrees
+		}
+		if v, ok := number(hwmon, "energy1_input"); ok {
+			counters[counterEnergy] = v
+		}
+	}
+	if _, ok := counters[counterEnergy]; ok || len(hwmons) > 0 {
+		return
+	}
+	domains, _ := filepath.Glob(filepath.Join(sysfsRoot, "sys/class/powercap/intel-rapl:0:*"))
+	for _, domain := range domains {
+		if readFile(domain, "name") != "uncore" {
+			continue
+		}
+		if v, ok := number(domain, "energy_uj"); ok {
+			counters[counterEnergy] = v
+		}
+	}
+}
+
+// driver returns the name of the kernel driver bound to a card
+func driver(dir string) string {
+	target, err := os.Readlink(filepath.Join(dir, "device/driver"))
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(target)
+}
+
+func readFile(dir, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name)) // #nosec G304 -- sysfs path built from a glob
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func number(dir, name string) (float64, bool) {
+	v, err := strconv.ParseFloat(readFile(dir, name), 64)
+	return v, err == nil
+}
+
+// firstNumber returns the first of the files that holds a number, or zero
+func firstNumber(dir string, names ...string) float64 {
+	for _, name := range names {
+		if v, ok := number(dir, name); ok {
+			return v
+		}
+	}
+	return 0
+}
+
+// pmu reads the i915 PMU's engine busy counters. Opening them needs
+// CAP_PERFMON or kernel.perf_event_paranoid set to 0 or less.
+type pmu struct {
+	engines map[string]int // Counter file descriptor by engine, e.g. "rcs0"
+}
+
+// pmu returns the card's PMU, opening it on first use
+func (m *Meter) pmu(dir string) *pmu {
+	if m.pmus == nil {
+		m.pmus = make(map[string]*pmu)
+	}
+	card := filepath.Base(dir)
+	if p, ok := m.pmus[card]; ok {
+		return p
+	}
+	p := openPMU(dir)
+	m.pmus[card] = p
+	return p
+}
+
+// openPMU opens the busy counter of each of a card's engines. The PMU of
+// the integrated GPU is named "i915", those of discrete cards carry their
+// PCI address, e.g. "i915_0000_03_00.0".
+func openPMU(dir string) *pmu {
+	device, err := filepath.EvalSymlinks(filepath.Join(dir, "device"))
+	if err != nil {
+		return nil
+	}
+	address := filepath.Base(device)
+	sources := filepath.Join(sysfsRoot, "sys/bus/event_source/devices")
+	pmuDir := filepath.Join(sources, "i915_"+strings.ReplaceAll(address, ":", "_"))
+	if _, err := os.Stat(pmuDir); err != nil {
+		if address != "0000:00:02.0" {
+			return nil
+		}
+		pmuDir = filepath.Join(sources, "i915")
+	}
+	typ, okType := number(pmuDir, "type")
+	// The counters are read on the first CPU of the PMU's mask, e.g. "0-3"
+	first, _, _ := strings.Cut(readFile(pmuDir, "cpumask"), ",")
+	first, _, _ = strings.Cut(first, "-")
+	cpu, err := strconv.Atoi(first)
+	if !okType || err != nil {
+		return nil
+	}
+
+	events, _ := filepath.Glob(filepath.Join(pmuDir, "events/*-busy"))
+	p := &pmu{engines: make(map[string]int)}
+	for _, event := range events {
+		config, err := strconv.ParseUint(strings.TrimPrefix(readFile(pmuDir, "events/"+filepath.Base(event)), "config="), 0, 64)
+		if err != nil {
+			continue
+		}
+		attr := unix.PerfEventAttr{Type: uint32(typ), Config: config}
+		attr.Size = uint32(unsafe.Sizeof(attr))
+		fd, err := unix.PerfEventOpen(&attr, -1, cpu, -1, unix.PERF_FLAG_FD_CLOEXEC)
+		if err != nil {
+			break // Not permitted, so no engine will open
+		}
+		p.engines[strings.TrimSuffix(filepath.Base(event), "-busy")] = fd
+	}
+	if len(p.engines) == 0 {
+		return nil
+	}
+	return p
+}
+
+// read adds each engine's busy time to the counters
+func (p *pmu) read(counters map[string]float64) {
+	buf := make([]byte, 8)
+	for engine, fd := range p.engines {
+		if n, err := unix.Read(fd, buf); err == nil && n == len(buf) {
+			counters[counterBusy+engine] = float64(binary.NativeEndian.Uint64(buf))
+		}
+	}
+}
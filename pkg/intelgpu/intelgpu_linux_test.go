@@ -0,0 +1,114 @@
+package intelgpu
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCard creates a DRM card bound to driver under root, with the files
+// given relative to the card
+func writeCard(t *testing.T, root, card, vendor, driver string, files map[string]string) {
+	t.Helper()
+	dir := filepath.Join(root, "sys/class/drm", card)
+	files["device/vendor"] = vendor
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	target := filepath.Join(root, "sys/bus/pci/drivers", driver)
+	if err := os.MkdirAll(target, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, filepath.Join(dir, "device/driver")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRead(t *testing.T) {
+	root := t.TempDir()
+	old := sysfsRoot
+	sysfsRoot = root
+	t.Cleanup(func() { sysfsRoot = old })
+
+	writeCard(t, root, "card0", "0x8086", "i915", map[string]string{
+		"gt/gt0/rps_act_freq_mhz": "1450",
+		"gt/gt0/rps_RP0_freq_mhz": "1650",
+		"gt/gt0/rc6_residency_ms": "1000",
+	})
+	writeCard(t, root, "card1", "0x8086", "xe", map[string]string{
+		"device/tile0/gt0/freq0/act_freq":           "2050",
+		"device/tile0/gt0/freq0/rp0_freq":           "2400",
+		"device/tile0/gt0/gtidle/idle_residency_ms": "500",
+		"device/hwmon/hwmon3/temp1_input":           "61000",
+		"device/hwmon/hwmon3/energy1_input":         "123456789",
+	})
+	writeCard(t, root, "card2", "0x1002", "amdgpu", map[string]string{})
+
+	var m Meter
+	gpus := m.Read()
+	if len(gpus) != 2 {
+		t.Fatalf("expected the 2 Intel cards, got %+v", gpus)
+	}
+	if g := gpus[0]; g.Card != "card0" || g.Clock != 1450 || g.MaxClock != 1650 || g.Usage != 0 {
+		t.Errorf("unexpected i915 card %+v", g)
+	}
+	if g := gpus[1]; g.Card != "card1" || g.Clock != 2050 || g.MaxClock != 2400 || g.Temp != 61 {
+		t.Errorf("unexpected xe card %+v", g)
+	}
+
+	// A card that never left RC6 is idle
+	time.Sleep(20 * time.Millisecond)
+	rc6 := filepath.Join(root, "sys/class/drm/card0/gt/gt0/rc6_residency_ms")
+	if err := os.WriteFile(rc6, []byte("100000\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if g := m.Read()[0]; g.Usage != 0 {
+		t.Errorf("expected an idle card to read 0%%, got %.1f", g.Usage)
+	}
+}
+
+func TestRates(t *testing.T) {
+	second := time.Second
+	tests := []struct {
+		name      string
+		prev, cur map[string]float64
+		usage     float64
+		power     float64
+	}{
+		{
+			name:  "RC6",
+			prev:  map[string]float64{counterIdle: 1000, counterEnergy: 5e6},
+			cur:   map[string]float64{counterIdle: 1250, counterEnergy: 17e6},
+			usage: 75,
+			power: 12,
+		},
+		{
+			name: "busiest engine",
+			prev: map[string]float64{counterIdle: 1000, counterBusy + "rcs0": 0, counterBusy + "vcs0": 0},
+			cur:  map[string]float64{counterIdle: 1900, counterBusy + "rcs0": 4e8, counterBusy + "vcs0": 6e8},
+			// The PMU counters win over RC6
+			usage: 60,
+		},
+		{
+			name:  "counter reset",
+			prev:  map[string]float64{counterIdle: 5000, counterEnergy: 9e6},
+			cur:   map[string]float64{counterIdle: 10, counterEnergy: 1e6},
+			usage: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usage, power := rates(tt.prev, tt.cur, second)
+			if usage != tt.usage || power != tt.power {
+				t.Errorf("expected %.0f%% and %.0f W, got %.1f%% and %.1f W", tt.usage, tt.power, usage, power)
+			}
+		})
+	}
+}
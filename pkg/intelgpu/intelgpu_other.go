@@ -0,0 +1,10 @@
+//go:build !linux && !windows
+
+package intelgpu
+
+type meterState struct{}
+
+// Read returns nothing: there is no Intel GPU reader for this platform
+func (m *Meter) Read() []GPU {
+	return nil
+}
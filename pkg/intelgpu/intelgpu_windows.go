@@ -0,0 +1,345 @@
+package intelgpu
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	dxgi                            = windows.NewLazySystemDLL("dxgi.dll")
+	procCreateDXGIFactory1          = dxgi.NewProc("CreateDXGIFactory1")
+	pdh                             = windows.NewLazySystemDLL("pdh.dll")
+	procPdhOpenQueryW               = pdh.NewProc("PdhOpenQueryW")
+	procPdhAddEnglishCounterW       = pdh.NewProc("PdhAddEnglishCounterW")
+	procPdhCollectQueryData         = pdh.NewProc("PdhCollectQueryData")
+	procPdhGetFormattedCounterArray = pdh.NewProc("PdhGetFormattedCounterArrayW")
+	igcl                            = windows.NewLazySystemDLL("ControlLib.dll")
+)
+
+// DXGI, PDH and IGCL constants from dxgi.h, pdh.h and igcl_api.h
+const (
+	intelVendorID       = 0x8086
+	dxgiAdapterSoftware = 2
+	pdhFmtDouble        = 0x00000200
+	pdhFmtNoCap100      = 0x00008000
+	pdhMoreData         = 0x800007D2
+	pdhCStatusNewData   = 1
+	ctlImplVersion      = 1<<16 | 1 // CTL_MAKE_VERSION(1, 1)
+)
+
+// iidIDXGIFactory1 is IID_IDXGIFactory1
+var iidIDXGIFactory1 = windows.GUID{Data1: 0x770aae78, Data2: 0xf26f, Data3: 0x4dba, Data4: [8]byte{0xa8, 0x29, 0x25, 0x3c, 0x83, 0xd1, 0xb3, 0x87}}
+
+// engineCounters lists every process's use of every GPU engine. Task Manager
+// sums an engine's instances and shows the busiest engine.
+const engineCounters = `\GPU Engine(*)\Utilization Percentage`
+
+type meterState struct {
+	opened   bool
+	adapters []adapter
+	query    uintptr // PDH query holding engineCounters, zero if it failed
+	counter  uintptr
+	control  *controlLib // Nil without the Intel driver's IGCL
+	energy   map[int]ctlEnergyCounter
+}
+
+// adapter is one Intel graphics adapter DXGI lists
+type adapter struct {
+	name string
+	luid string // As in the engine counters' instance names, e.g. "0x00000000_0x0000D1F4"
+}
+
+// Read returns every Intel graphics adapter, in the order DXGI lists them
+func (m *Meter) Read() []GPU {
+	if !m.opened {
+		m.open()
+	}
+	usage := m.engineUsage()
+	gpus := make([]GPU, len(m.adapters))
+	for i, a := range m.adapters {
+		gpus[i] = GPU{Name: a.name, Usage: clampPercent(usage[strings.ToLower(a.luid)])}
+		if m.control != nil {
+			m.readControl(i, &gpus[i])
+		}
+	}
+	return gpus
+}
+
+// open finds the adapters and opens the counters, once. The engine counters
+// are rates, so the first read after this reports no usage.
+func (m *Meter) open() {
+	m.opened = true
+	m.adapters = intelAdapters()
+	if len(m.adapters) == 0 {
+		return
+	}
+	if procPdhOpenQueryW.Find() == nil {
+		var query uintptr
+		if r, _, _ := procPdhOpenQueryW.Call(0, 0, uintptr(unsafe.Pointer(&query))); r == 0 { // #nosec G103 -- output for the PDH API
+			path, _ := windows.UTF16PtrFromString(engineCounters)
+			if r, _, _ := procPdhAddEnglishCounterW.Call(query, uintptr(unsafe.Pointer(path)), 0, uintptr(unsafe.Pointer(&m.counter))); r == 0 { // #nosec G103 -- input and output for the PDH API
+				m.query = query
+				_, _, _ = procPdhCollectQueryData.Call(query)
+			}
+		}
+	}
+	m.control = openControlLib()
+}
+
+// engineUsage returns the busiest engine's utilization of each adapter, by
+// LUID in lower case
+func (m *Meter) engineUsage() map[string]float64 {
+	usage := make(map[string]float64)
+	if m.query == 0 {
+		return usage
+	}
+	if r, _, _ := procPdhCollectQueryData.Call(m.query); r != 0 {
+		return usage
+	}
+	var size, count uint32
+	r, _, _ := procPdhGetFormattedCounterArray.Call(m.counter, pdhFmtDouble|pdhFmtNoCap100, uintptr(unsafe.Pointer(&size)), uintptr(unsafe.Pointer(&count)), 0) // #nosec G103 -- output for the PDH API
+	if r != pdhMoreData || size == 0 {
+		return usage
+	}
+	buf := make([]byte, size)
+	r, _, _ = procPdhGetFormattedCounterArray.Call(m.counter, pdhFmtDouble|pdhFmtNoCap100, uintptr(unsafe.Pointer(&size)), uintptr(unsafe.Pointer(&count)), uintptr(unsafe.Pointer(&buf[0]))) // #nosec G103 -- output for the PDH API
+	if r != 0 {
+		return usage
+	}
+
+	type item struct { // PDH_FMT_COUNTERVALUE_ITEM_W
+		name   *uint16
+		status uint32
+		_      uint32
+		value  float64
+	}
+	items := unsafe.Slice((*item)(unsafe.Pointer(&buf[0])), count) // #nosec G103 -- the array PDH wrote into buf
+	engines := make(map[string]float64)
+	for _, it := range items {
+		if it.status > pdhCStatusNewData {
+			continue
+		}
+		luid, engine, ok := parseEngineInstance(windows.UTF16PtrToString(it.name))
+		if ok {
+			engines[luid+"/"+engine] += it.value
+		}
+	}
+	for key, value := range engines {
+		luid, _, _ := strings.Cut(key, "/")
+		usage[luid] = max(usage[luid], value)
+	}
+	return usage
+}
+
+// parseEngineInstance returns the adapter LUID (in lower case) and engine of
+// a GPU Engine counter instance, e.g.
+// "pid_1234_luid_0x00000000_0x0000D1F4_phys_0_eng_3_engtype_VideoDecode"
+func parseEngineInstance(instance string) (luid, engine string, ok bool) {
+	_, rest, found := strings.Cut(instance, "luid_")
+	if !found {
+		return "", "", false
+	}
+	luid, rest, found = strings.Cut(rest, "_phys_")
+	if !found {
+		return "", "", false
+	}
+	_, rest, found = strings.Cut(rest, "_eng_")
+	if !found {
+		return "", "", false
+	}
+	engine, _, _ = strings.Cut(rest, "_")
+	return strings.ToLower(luid), engine, true
+}
+
+// dxgiAdapterDesc1 is DXGI_ADAPTER_DESC1
+type dxgiAdapterDesc1 struct {
+	description           [128]uint16
+	vendorID              uint32
+	deviceID              uint32
+	subSysID              uint32
+	revision              uint32
+	dedicatedVideoMemory  uintptr
+	dedicatedSystemMemory uintptr
+	sharedSystemMemory    uintptr
+	luid                  windows.LUID
+	flags                 uint32
+}
+
+// comObject is the start of a COM object, the pointer to its vtable. The
+// array bound only has to cover the methods called.
+type comObject struct {
+	vtable *[16]uintptr
+}
+
+// call calls method index of the object's vtable
+func (o *comObject) call(index int, args ...uintptr) uintptr {
+	r, _, _ := syscall.SyscallN(o.vtable[index], append([]uintptr{uintptr(unsafe.Pointer(o))}, args...)...) // #nosec G103 -- COM calls pass the object itself
+	return r
+}
+
+// IDXGIFactory1 and IDXGIAdapter1 vtable indices
+const (
+	comRelease        = 2
+	factoryEnumAdapt1 = 12
+	adapterGetDesc1   = 10
+)
+
+// intelAdapters lists the Intel hardware adapters through DXGI
+func intelAdapters() []adapter {
+	if procCreateDXGIFactory1.Find() != nil {
+		return nil
+	}
+	var factory *comObject
+	if r, _, _ := procCreateDXGIFactory1.Call(uintptr(unsafe.Pointer(&iidIDXGIFactory1)), uintptr(unsafe.Pointer(&factory))); r != 0 { // #nosec G103 -- input and output for the DXGI API
+		return nil
+	}
+	defer factory.call(comRelease)
+
+	var adapters []adapter
+	for i := uintptr(0); ; i++ {
+		var a *comObject
+		if r := factory.call(factoryEnumAdapt1, i, uintptr(unsafe.Pointer(&a))); r != 0 { // #nosec G103 -- output for the DXGI API
+			break // DXGI_ERROR_NOT_FOUND past the last adapter
+		}
+		var desc dxgiAdapterDesc1
+		r := a.call(adapterGetDesc1, uintptr(unsafe.Pointer(&desc))) // #nosec G103 -- output for the DXGI API
+		a.call(comRelease)
+		if r != 0 || desc.vendorID != intelVendorID || desc.flags&dxgiAdapterSoftware != 0 {
+			continue
+		}
+		adapters = append(adapters, adapter{
+			name: windows.UTF16ToString(desc.description[:]),
+			luid: fmt.Sprintf("0x%08X_0x%08X", uint32(desc.luid.HighPart), desc.luid.LowPart),
+		})
+	}
+	return adapters
+}
+
+// ctlInitArgs is ctl_init_args_t
+type ctlInitArgs struct {
+	size             uint32
+	version          uint8
+	appVersion       uint32
+	flags            uint32
+	supportedVersion uint32
+	applicationUID   [16]byte
+}
+
+// ctlFreqState is ctl_freq_state_t. Frequencies are in MHz, negative when
+// unknown.
+type ctlFreqState struct {
+	size            uint32
+	version         uint8
+	currentVoltage  float64
+	request         float64
+	tdp             float64
+	efficient       float64
+	actual          float64
+	throttleReasons uint32
+}
+
+// ctlFreqProperties is ctl_freq_properties_t
+type ctlFreqProperties struct {
+	size       uint32
+	version    uint8
+	typ        uint32
+	canControl bool
+	min        float64
+	max        float64
+}
+
+// ctlEnergyCounter is ctl_power_energy_counter_t
+type ctlEnergyCounter struct {
+	size      uint32
+	version   uint8
+	energy    uint64 // µJ
+	timestamp uint64 // µs
+}
+
+// controlLib is the Intel Graphics Control Library the driver installs
+type controlLib struct {
+	devices []uintptr // Device handles, in the order DXGI lists the adapters
+}
+
+// openControlLib initializes IGCL and lists its devices
+func openControlLib() *controlLib {
+	if igcl.Load() != nil {
+		return nil
+	}
+	args := ctlInitArgs{appVersion: ctlImplVersion}
+	args.size = uint32(unsafe.Sizeof(args))
+	var api uintptr
+	if ctlCall("ctlInit", uintptr(unsafe.Pointer(&args)), uintptr(unsafe.Pointer(&api))) != nil { // #nosec G103 -- input and output for IGCL
+		return nil
+	}
+	devices := ctlHandles("ctlEnumerateDevices", api)
+	if len(devices) == 0 {
+		return nil
+	}
+	return &controlLib{devices: devices}
+}
+
+// ctlCall calls an IGCL function and turns its ctl_result_t into an error
+func ctlCall(name string, args ...uintptr) error {
+	proc := igcl.NewProc(name)
+	if err := proc.Find(); err != nil {
+		return err
+	}
+	if r, _, _ := proc.Call(args...); r != 0 {
+		return fmt.Errorf("%s failed with %#x", name, r)
+	}
+	return nil
+}
+
+// ctlHandles calls one of IGCL's enumerations, which take the parent, a
+// count and an array of handles
+func ctlHandles(name string, parent uintptr) []uintptr {
+	var count uint32
+	if ctlCall(name, parent, uintptr(unsafe.Pointer(&count)), 0) != nil || count == 0 { // #nosec G103 -- output for IGCL
+		return nil
+	}
+	handles := make([]uintptr, count)
+	if ctlCall(name, parent, uintptr(unsafe.Pointer(&count)), uintptr(unsafe.Pointer(&handles[0]))) != nil { // #nosec G103 -- output for IGCL
+		return nil
+	}
+	return handles[:count]
+}
+
+// readControl reads the graphics frequency and the power of the adapter's
+// IGCL device. Power is the energy used since the previous read.
+func (m *Meter) readControl(index int, g *GPU) {
+	if index >= len(m.control.devices) {
+		return
+	}
+	device := m.control.devices[index]
+	if domains := ctlHandles("ctlEnumFrequencyDomains", device); len(domains) > 0 {
+		state := ctlFreqState{}
+		state.size = uint32(unsafe.Sizeof(state))
+		if ctlCall("ctlFrequencyGetState", domains[0], uintptr(unsafe.Pointer(&state))) == nil && state.actual > 0 { // #nosec G103 -- output for IGCL
+			g.Clock = state.actual
+		}
+		props := ctlFreqProperties{}
+		props.size = uint32(unsafe.Sizeof(props))
+		if ctlCall("ctlFrequencyGetProperties", domains[0], uintptr(unsafe.Pointer(&props))) == nil && props.max > 0 { // #nosec G103 -- output for IGCL
+			g.MaxClock = props.max
+		}
+	}
+	if domains := ctlHandles("ctlEnumPowerDomains", device); len(domains) > 0 {
+		counter := ctlEnergyCounter{}
+		counter.size = uint32(unsafe.Sizeof(counter))
+		if ctlCall("ctlPowerGetEnergyCounter", domains[0], uintptr(unsafe.Pointer(&counter))) != nil { // #nosec G103 -- output for IGCL
+			return
+		}
+		if m.energy == nil {
+			m.energy = make(map[int]ctlEnergyCounter)
+		}
+		if prev, ok := m.energy[index]; ok && counter.timestamp > prev.timestamp && counter.energy >= prev.energy {
+			g.Power = float64(counter.energy-prev.energy) / float64(counter.timestamp-prev.timestamp) // µJ per µs
+		}
+		m.energy[index] = counter
+	}
+}
@@ -0,0 +1,13 @@
+package intelgpu
+
+import "testing"
+
+func TestParseEngineInstance(t *testing.T) {
+	luid, engine, ok := parseEngineInstance("pid_1234_luid_0x00000000_0x0000D1F4_phys_0_eng_3_engtype_VideoDecode")
+	if !ok || luid != "0x00000000_0x0000d1f4" || engine != "3" {
+		t.Errorf("unexpected %q %q %v", luid, engine, ok)
+	}
+	if _, _, ok := parseEngineInstance("_Total"); ok {
+		t.Error("expected an instance without a LUID to be skipped")
+	}
+}
@@ -0,0 +1,130 @@
+// Package intrusion reads the chassis intrusion switch, which latches an
+// alarm when the side panel is removed.
+//
+// The alarm is read from the Super I/O through hwmon on Linux
+// (intrusionN_alarm, exposed by drivers such as nct6775 and it87) and from
+// the BMC's physical security sensors with ipmitool on server boards.
+// Integrators open the case, check the alarm is set, clear it and check it
+// stays clear once the panel is back on, which confirms the switch is wired.
+package intrusion
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// ErrNotFound is returned when neither the Super I/O nor a BMC reports an
+// intrusion switch
+var ErrNotFound = errors.New("no chassis intrusion sensor found")
+
+// ErrNotClearable is returned when clearing an alarm this package cannot
+// reset, such as one latched by a BMC
+var ErrNotClearable = errors.New("this intrusion alarm can only be cleared from the BMC or firmware setup")
+
+// Sensor sources
+const (
+	SourceHwmon = "hwmon"
+	SourceBMC   = "bmc"
+)
+
+// Sensor is one intrusion switch
+type Sensor struct {
+	Name      string `json:"name"`   // e.g. "nct6798 intrusion0" or "Chassis Intru"
+	Source    string `json:"source"` // SourceHwmon or SourceBMC
+	Triggered bool   `json:"triggered"`
+
+	alarm string // hwmon alarm file, which Clear resets
+}
+
+// Status describes the state of the switch
+func (s Sensor) Status() string {
+	if s.Triggered {
+		return "Case opened"
+	}
+	return "Cleared"
+}
+
+// Clearable reports whether Clear can reset the alarm
+func (s Sensor) Clearable() bool {
+	return s.alarm != ""
+}
+
+// Read returns every intrusion sensor found. BMC sensors are only read when
+// ipmitool is installed and can reach the BMC.
+func Read(ctx context.Context) ([]Sensor, error) {
+	sensors := hwmonSensors()
+	sensors = append(sensors, bmcSensors(ctx)...)
+	if len(sensors) == 0 {
+		return nil, ErrNotFound
+	}
+	return sensors, nil
+}
+
+// Triggered returns the sensors whose alarm is set
+func Triggered(sensors []Sensor) []Sensor {
+	var open []Sensor
+	for _, s := range sensors {
+		if s.Triggered {
+			open = append(open, s)
+		}
+	}
+	return open
+}
+
+// Clear resets a latched alarm and reads it back. An alarm that sets again
+// straight away means the case is still open, or the switch is stuck.
+func Clear(s Sensor) error {
+	if !s.Clearable() {
+		return ErrNotClearable
+	}
+	return clearAlarm(s.alarm)
+}
+
+// bmcSensors reads the physical security sensors of the BMC
+func bmcSensors(ctx context.Context) []Sensor {
+	if _, err := exec.LookPath("ipmitool"); err != nil {
+		return nil
+	}
+	out, err := safeexec.CommandContext(ctx, "ipmitool", "sdr", "type", "Physical Security").Output()
+	if err != nil {
+		return nil // No BMC, or no access to /dev/ipmi0
+	}
+	return parseSDR(string(out))
+}
+
+// parseSDR parses `ipmitool sdr type "Physical Security"`, e.g.
+//
+//	Chassis Intru    | 73h | ok  | 23.1 | General Chassis intrusion
+//
+// The last column lists the asserted states and is empty while the case is
+// closed. Sensors the BMC cannot read ("ns") are left out.
+func parseSDR(out string) []Sensor {
+	var sensors []Sensor
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "|")
+		if len(fields) < 5 {
+			continue
+		}
+		name := strings.TrimSpace(fields[0])
+		status := strings.TrimSpace(fields[2])
+		states := strings.ToLower(strings.TrimSpace(fields[4]))
+		if name == "" || status == "ns" {
+			continue
+		}
+		if !strings.Contains(strings.ToLower(name), "intru") && !strings.Contains(states, "intrusion") {
+			continue // Other physical security sensors, such as LAN leash
+		}
+		sensors = append(sensors, Sensor{
+			Name:      name,
+			Source:    SourceBMC,
+			Triggered: strings.Contains(states, "intrusion"),
+		})
+	}
+	return sensors
+}
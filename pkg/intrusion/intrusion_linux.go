@@ -0,0 +1,73 @@
+//go:build linux
+// +build linux
+
+package intrusion
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// sysfsRoot is where sysfs is looked up, replaced in tests
+var sysfsRoot = "/"
+
+// hwmonSensors reads the intrusion alarms of the hwmon chips
+func hwmonSensors() []Sensor {
+	alarms, _ := filepath.Glob(filepath.Join(sysfsRoot, "sys/class/hwmon/hwmon*/intrusion*_alarm"))
+	sort.Strings(alarms)
+
+	var sensors []Sensor
+	for _, alarm := range alarms {
+		value, err := readAlarm(alarm)
+		if err != nil {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(alarm), "_alarm")
+		if chip := readString(filepath.Join(filepath.Dir(alarm), "name")); chip != "" {
+			name = chip + " " + name
+		}
+		sensors = append(sensors, Sensor{Name: name, Source: SourceHwmon, Triggered: value, alarm: alarm})
+	}
+	return sensors
+}
+
+// clearAlarm writes 0 to the alarm, which is how hwmon drivers reset it
+func clearAlarm(alarm string) error {
+	if err := os.WriteFile(alarm, []byte("0"), 0o600); err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return fmt.Errorf("clearing the intrusion alarm needs root: %w", err)
+		}
+		return err
+	}
+	set, err := readAlarm(alarm)
+	if err != nil {
+		return err
+	}
+	if set {
+		return errors.New("the alarm set again after clearing: close the case, or check the intrusion switch and its header")
+	}
+	return nil
+}
+
+func readAlarm(path string) (bool, error) {
+	switch v := readString(path); v {
+	case "0":
+		return false, nil
+	case "1":
+		return true, nil
+	default:
+		return false, fmt.Errorf("unexpected intrusion alarm value %q", v)
+	}
+}
+
+func readString(path string) string {
+	data, err := os.ReadFile(path) // #nosec G304 -- sysfs path
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
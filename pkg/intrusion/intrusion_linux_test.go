@@ -0,0 +1,47 @@
+package intrusion
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHwmonSensors(t *testing.T) {
+	root := t.TempDir()
+	old := sysfsRoot
+	sysfsRoot = root
+	t.Cleanup(func() { sysfsRoot = old })
+
+	chip := filepath.Join(root, "sys/class/hwmon/hwmon2")
+	if err := os.MkdirAll(chip, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"name":             "nct6798\n",
+		"intrusion0_alarm": "1\n",
+		"intrusion1_alarm": "0\n",
+	} {
+		if err := os.WriteFile(filepath.Join(chip, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sensors := hwmonSensors()
+	if len(sensors) != 2 {
+		t.Fatalf("expected 2 sensors, got %+v", sensors)
+	}
+	if sensors[0].Name != "nct6798 intrusion0" || !sensors[0].Triggered || !sensors[0].Clearable() {
+		t.Errorf("unexpected sensor %+v", sensors[0])
+	}
+	if sensors[1].Triggered || sensors[1].Status() != "Cleared" {
+		t.Errorf("expected intrusion1 to be clear, got %+v", sensors[1])
+	}
+
+	// A plain file keeps the 0 written to it, like a closed case
+	if err := Clear(sensors[0]); err != nil {
+		t.Fatal(err)
+	}
+	if sensors := hwmonSensors(); sensors[0].Triggered {
+		t.Error("expected the alarm to be cleared")
+	}
+}
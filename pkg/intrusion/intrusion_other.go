@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package intrusion
+
+// hwmonSensors finds no Super I/O alarms: only Linux exposes them
+func hwmonSensors() []Sensor {
+	return nil
+}
+
+func clearAlarm(string) error {
+	return ErrNotClearable
+}
@@ -0,0 +1,30 @@
+package intrusion
+
+import "testing"
+
+func TestParseSDR(t *testing.T) {
+	out := `Chassis Intru    | 73h | ok  | 23.1 | General Chassis intrusion
+Drive Intru      | 74h | ok  | 23.2 |
+LAN Leash Lost   | 75h | ok  | 23.3 |
+Front Panel      | 76h | ns  | 23.4 | No Reading
+`
+	sensors := parseSDR(out)
+	if len(sensors) != 2 {
+		t.Fatalf("expected 2 intrusion sensors, got %+v", sensors)
+	}
+	if sensors[0].Name != "Chassis Intru" || !sensors[0].Triggered || sensors[0].Source != SourceBMC {
+		t.Errorf("expected the chassis sensor to be triggered, got %+v", sensors[0])
+	}
+	if sensors[1].Triggered {
+		t.Errorf("expected the drive bay sensor to be clear, got %+v", sensors[1])
+	}
+	if sensors[0].Clearable() {
+		t.Error("expected BMC sensors not to be clearable")
+	}
+	if err := Clear(sensors[0]); err != ErrNotClearable {
+		t.Errorf("expected ErrNotClearable, got %v", err)
+	}
+	if open := Triggered(sensors); len(open) != 1 || open[0].Name != "Chassis Intru" {
+		t.Errorf("unexpected triggered sensors %+v", open)
+	}
+}
@@ -1,7 +1,7 @@
 //go:build !windows
 // +build !windows
 
-package gui
+package inventory
 
 // IsRunningAsAdmin checks if the current process is running with administrator privileges
 // On non-Windows systems, this returns true as admin checks are Windows-specific
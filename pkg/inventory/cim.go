@@ -0,0 +1,79 @@
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// queryCIM reads every instance of a root\cimv2 class into dst, a pointer to
+// a slice of structs whose fields are named after the class's properties.
+//
+// Windows queries WMI in-process. Where that fails, and under WSL, the
+// instances come from PowerShell's Get-CimInstance, which replaces the wmic
+// tool newer Windows builds no longer ship. Fields tagged `cim:"ref"` hold a
+// reference to another instance and are read as its text, e.g.
+// Win32_DiskPartition (DeviceID = "Disk #0, Partition #1").
+func queryCIM(class string, dst interface{}) error {
+	fields, err := cimFields(dst)
+	if err != nil {
+		return err
+	}
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.Name
+	}
+	if err := queryWMI(fmt.Sprintf("SELECT %s FROM %s", strings.Join(names, ", "), class), dst); err == nil {
+		return nil
+	}
+
+	output, err := safeexec.PowerShell(cimScript(fields), class).Output()
+	if err != nil {
+		return fmt.Errorf("failed to query %s: %w", class, err)
+	}
+	return parseCIMJSON(output, dst)
+}
+
+// cimFields returns the fields of the struct type dst holds a slice of
+func cimFields(dst interface{}) ([]reflect.StructField, error) {
+	t := reflect.TypeOf(dst)
+	if t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Slice || t.Elem().Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("CIM destination must be a pointer to a slice of structs, not %T", dst)
+	}
+	elem := t.Elem().Elem()
+	fields := make([]reflect.StructField, 0, elem.NumField())
+	for i := 0; i < elem.NumField(); i++ {
+		fields = append(fields, elem.Field(i))
+	}
+	return fields, nil
+}
+
+// cimScript returns the PowerShell script listing a class's instances as a
+// JSON array, with the class name left as the script's one value. References
+// are turned into text so they survive ConvertTo-Json.
+func cimScript(fields []reflect.StructField) string {
+	properties := make([]string, len(fields))
+	for i, f := range fields {
+		properties[i] = f.Name
+		if f.Tag.Get("cim") == "ref" {
+			properties[i] = fmt.Sprintf("@{n='%s';e={[string]$_.%s}}", f.Name, f.Name)
+		}
+	}
+	return `ConvertTo-Json -Compress -InputObject @(Get-CimInstance -ClassName %s | Select-Object ` + strings.Join(properties, ", ") + `)`
+}
+
+// parseCIMJSON unmarshals the output of cimScript into dst. Properties the
+// instance leaves null keep their zero value.
+func parseCIMJSON(output []byte, dst interface{}) error {
+	trimmed := strings.TrimSpace(string(output))
+	if trimmed == "" {
+		return nil // No instances
+	}
+	if err := json.Unmarshal([]byte(trimmed), dst); err != nil {
+		return fmt.Errorf("failed to parse CIM instances: %w", err)
+	}
+	return nil
+}
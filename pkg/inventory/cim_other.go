@@ -0,0 +1,12 @@
+//go:build !windows
+// +build !windows
+
+package inventory
+
+import "errors"
+
+// queryWMI fails outside Windows; WSL reaches the host's WMI through
+// PowerShell instead
+func queryWMI(string, interface{}) error {
+	return errors.New("WMI is only available on Windows")
+}
@@ -0,0 +1,119 @@
+package inventory
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Get-CimInstance output as cimScript formats it, with an empty slot and a
+// property left null
+const cimPhysicalMemoryJSON = `[{"Capacity":17179869184,"Speed":6000,"ConfiguredClockSpeed":6000,"SMBIOSMemoryType":34,"FormFactor":8,"Manufacturer":"G Skill Intl","PartNumber":"F5-6000J3038F16G    ","SerialNumber":"00000000","DeviceLocator":"DIMM 1","BankLabel":"P0 CHANNEL A","Tag":"Physical Memory 1"},` +
+	`{"Capacity":null,"Speed":null,"ConfiguredClockSpeed":null,"SMBIOSMemoryType":2,"FormFactor":0,"Manufacturer":null,"PartNumber":null,"SerialNumber":null,"DeviceLocator":"DIMM 0","BankLabel":"P0 CHANNEL B","Tag":"Physical Memory 2"},` +
+	`{"Capacity":17179869184,"Speed":6400,"ConfiguredClockSpeed":null,"SMBIOSMemoryType":34,"FormFactor":8,"Manufacturer":"G Skill Intl","PartNumber":"F5-6000J3038F16G","SerialNumber":"00000001","DeviceLocator":"DIMM 1","BankLabel":"P0 CHANNEL B","Tag":"Physical Memory 3"}]`
+
+func TestMemoryModulesFromCIM(t *testing.T) {
+	var rows []win32PhysicalMemory
+	if err := parseCIMJSON([]byte(cimPhysicalMemoryJSON), &rows); err != nil {
+		t.Fatal(err)
+	}
+	modules := memoryModulesFromCIM(rows)
+	if len(modules) != 2 {
+		t.Fatalf("expected the empty slot to be skipped, got %d modules", len(modules))
+	}
+
+	m := modules[0]
+	if m.Size != 16<<30 || m.Speed != 6000 || m.DataRate != 6000 || m.PCRating != 48000 {
+		t.Errorf("unexpected size or speed %+v", m)
+	}
+	if m.Slot != "DIMM 1" || m.BankLabel != "P0 CHANNEL A" || m.PartNumber != "F5-6000J3038F16G" {
+		t.Errorf("unexpected slot or part number %+v", m)
+	}
+	if !strings.Contains(m.Type, "DDR5") || !strings.Contains(m.Name, "PC5-48000") {
+		t.Errorf("expected a DDR5 module, got %q (%s)", m.Type, m.Name)
+	}
+
+	// The slot comes from the tag, the speed from Speed when the configured
+	// clock is missing
+	m = modules[1]
+	if m.Row != 2 || m.Slot != "DIMM 3" || m.Speed != 6400 {
+		t.Errorf("unexpected second module %+v", m)
+	}
+}
+
+func TestGPUsFromVideoControllers(t *testing.T) {
+	var controllers []win32VideoController
+	err := parseCIMJSON([]byte(`[{"Name":"NVIDIA GeForce RTX 4090","AdapterRAM":4293918720,"Status":"OK"},`+
+		`{"Name":"Intel(R) UHD Graphics 770","AdapterRAM":1073741824,"Status":"OK"},`+
+		`{"Name":"AMD Radeon(TM) Graphics","AdapterRAM":536870912,"Status":"Error"},`+
+		`{"Name":"Microsoft Basic Display Adapter","AdapterRAM":0,"Status":"OK"},`+
+		`{"Name":"Parsec Virtual Display Adapter","AdapterRAM":null,"Status":null}]`), &controllers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gpus := gpusFromVideoControllers(controllers)
+	if len(gpus) != 2 {
+		t.Fatalf("expected the failed and virtual adapters to be skipped, got %+v", gpus)
+	}
+	if g := gpus[0]; g.Vendor != "NVIDIA" || g.Name != "NVIDIA GeForce RTX 4090" || g.MemoryTotal != 4293918720 {
+		t.Errorf("unexpected NVIDIA card %+v", g)
+	}
+	if g := gpus[1]; g.Vendor != "Intel" || g.Name != "Intel(R) UHD Graphics 770 (Integrated)" {
+		t.Errorf("unexpected Intel iGPU %+v", g)
+	}
+}
+
+func TestDriveLettersFromPartitions(t *testing.T) {
+	links := []win32LogicalDiskToPartition{
+		// As the wmi library returns references
+		{
+			Antecedent: `\\DESKTOP\root\cimv2:Win32_DiskPartition.DeviceID="Disk #0, Partition #2"`,
+			Dependent:  `\\DESKTOP\root\cimv2:Win32_LogicalDisk.DeviceID="C:"`,
+		},
+		// As cimScript turns them into text
+		{
+			Antecedent: `Win32_DiskPartition (DeviceID = "Disk #1, Partition #1")`,
+			Dependent:  `Win32_LogicalDisk (DeviceID = "D:")`,
+		},
+		{
+			Antecedent: `Win32_DiskPartition (DeviceID = "Disk #10, Partition #0")`,
+			Dependent:  `Win32_LogicalDisk (DeviceID = "F:")`,
+		},
+		{
+			Antecedent: `Win32_DiskPartition (DeviceID = "Disk #1, Partition #3")`,
+			Dependent:  `Win32_LogicalDisk (DeviceID = "e:")`,
+		},
+	}
+
+	for disk, want := range map[int][]string{0: {"C:"}, 1: {"D:", "E:"}, 10: {"F:"}, 2: nil} {
+		if got := driveLettersFromPartitions(links, disk); !reflect.DeepEqual(got, want) {
+			t.Errorf("disk %d: expected %v, got %v", disk, want, got)
+		}
+	}
+}
+
+func TestCIMScript(t *testing.T) {
+	fields, err := cimFields(&[]win32LogicalDiskToPartition{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `Select-Object @{n='Antecedent';e={[string]$_.Antecedent}}, @{n='Dependent';e={[string]$_.Dependent}})`
+	if script := cimScript(fields); !strings.HasSuffix(script, want) {
+		t.Errorf("expected references to be read as text, got %s", script)
+	}
+
+	fields, _ = cimFields(&[]win32DiskDrive{})
+	if script := cimScript(fields); !strings.Contains(script, "Select-Object Index, Model, Caption,") {
+		t.Errorf("unexpected property list in %s", script)
+	}
+
+	if _, err := cimFields([]win32DiskDrive{}); err == nil {
+		t.Error("expected an error for a destination that is not a pointer")
+	}
+
+	var rows []win32DiskDrive
+	if err := parseCIMJSON([]byte("\r\n"), &rows); err != nil || rows != nil {
+		t.Errorf("expected no instances from empty output, got %v (%v)", rows, err)
+	}
+}
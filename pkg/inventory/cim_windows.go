@@ -0,0 +1,15 @@
+//go:build windows
+// +build windows
+
+package inventory
+
+import "github.com/StackExchange/wmi"
+
+// wmiClient reads properties an instance leaves null as zero values, matching
+// the PowerShell path, instead of failing the query
+var wmiClient = &wmi.Client{NonePtrZero: true}
+
+// queryWMI runs a WQL query through WMI's COM interface
+func queryWMI(query string, dst interface{}) error {
+	return wmiClient.Query(query, dst)
+}
@@ -0,0 +1,426 @@
+package inventory
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/session"
+	"github.com/mscrnt/project_fire/pkg/virt"
+)
+
+// Hardware is everything the collectors found on a machine
+type Hardware struct {
+	System         *SystemInfo
+	Motherboard    *MotherboardInfo
+	MemoryModules  []MemoryModule
+	GPUs           []GPUInfo
+	StorageDevices []StorageInfo
+	Fans           []FanInfo
+}
+
+// Collect runs every collector. Collectors that fail leave their part
+// empty. SMART data is left out of the storage devices, as it is read
+// separately when needed.
+func Collect() *Hardware {
+	h := &Hardware{}
+	h.System, _ = GetSystemInfo()
+	h.Motherboard, _ = GetMotherboardInfo()
+	h.MemoryModules, _ = GetMemoryModules()
+	h.GPUs, _ = GetGPUInventory()
+	if devices, err := GetStorageInfo(); err == nil {
+		for i := range devices {
+			devices[i].SMART = nil
+		}
+		h.StorageDevices = devices
+	}
+	h.Fans, _ = GetFanInfo()
+	return h
+}
+
+// Components lists the hardware as shown in the dashboard: the CPU,
+// motherboard, memory modules, GPUs, storage devices and fans, in that order.
+// Storage components are in the order of StorageDevices. Memory modules are
+// updated with the fields derived from their size and speed.
+func (h *Hardware) Components() []session.Component {
+	var components []session.Component
+
+	// CPU - from system info (always available)
+	if h.System != nil && h.System.CPU.Model != "" {
+		components = append(components, session.Component{
+			Type: "CPU",
+			Name: h.System.CPU.Model,
+			Details: map[string]string{
+				"Model":          h.System.CPU.Model,
+				"Vendor":         h.System.CPU.Vendor,
+				"Physical Cores": fmt.Sprintf("%d", h.System.CPU.PhysicalCores),
+				"Logical Cores":  fmt.Sprintf("%d", h.System.CPU.LogicalCores),
+			},
+		})
+	}
+
+	if motherboard := h.Motherboard; motherboard != nil && motherboard.Model != "" {
+		components = append(components, motherboardComponent(motherboard))
+	}
+
+	if len(h.MemoryModules) > 0 {
+		for i := range h.MemoryModules {
+			components = append(components, memoryComponent(&h.MemoryModules[i], i))
+		}
+	} else if h.System != nil {
+		// Fallback to system memory if no modules detected
+		memDetails := map[string]string{
+			"Total": fmt.Sprintf("%.1f GB", h.System.Memory.TotalGB),
+		}
+
+		// Show host memory if in WSL
+		memName := fmt.Sprintf("System Memory (%.1f GB)", h.System.Memory.TotalGB)
+		if h.System.Host.IsWSL && h.System.Memory.HostTotalGB > 0 {
+			memName = fmt.Sprintf("System Memory (%.1f GB WSL / %.1f GB Host)",
+				h.System.Memory.TotalGB, h.System.Memory.HostTotalGB)
+			memDetails["Host Total"] = fmt.Sprintf("%.1f GB", h.System.Memory.HostTotalGB)
+			memDetails["Environment"] = "WSL2"
+		}
+
+		components = append(components, session.Component{
+			Type:    "Memory",
+			Name:    memName,
+			Details: memDetails,
+		})
+	}
+
+	for i, gpu := range h.GPUs {
+		components = append(components, gpuComponent(gpu, i))
+	}
+
+	for i := range h.StorageDevices {
+		components = append(components, storageComponent(&h.StorageDevices[i]))
+	}
+
+	// Virtual machines have no fans of their own
+	if !virt.Detect().Virtual() {
+		for _, fan := range h.Fans {
+			components = append(components, session.Component{
+				Type: "Fan",
+				Name: fan.Name,
+				Details: map[string]string{
+					"Name": fan.Name,
+					"Type": fan.Type,
+				},
+			})
+		}
+	}
+
+	return components
+}
+
+// motherboardComponent describes the board, its BIOS and its slots
+func motherboardComponent(motherboard *MotherboardInfo) session.Component {
+	mbDetails := map[string]string{
+		"Manufacturer": motherboard.Manufacturer,
+		"Model":        motherboard.Model,
+	}
+	if motherboard.Version != "" && motherboard.Version != "Not Available" {
+		mbDetails["Version"] = motherboard.Version
+	}
+	if motherboard.SerialNumber != "" && motherboard.SerialNumber != "Not Available" {
+		mbDetails["Serial"] = motherboard.SerialNumber
+	}
+	if motherboard.BIOS.Vendor != "" {
+		mbDetails["BIOS Vendor"] = motherboard.BIOS.Vendor
+	}
+	if motherboard.BIOS.Version != "" {
+		mbDetails["BIOS Version"] = motherboard.BIOS.Version
+	}
+	if motherboard.BIOS.ReleaseDate != "" {
+		mbDetails["BIOS Date"] = FormatBIOSDate(motherboard.BIOS.ReleaseDate)
+	}
+
+	// Add chipset info if available
+	if motherboard.ChipsetInfo.Model != "" {
+		chipset := motherboard.ChipsetInfo.Model
+		if motherboard.ChipsetInfo.Vendor != "" {
+			chipset = fmt.Sprintf("%s %s", motherboard.ChipsetInfo.Vendor, motherboard.ChipsetInfo.Model)
+		}
+		mbDetails["Chipset"] = chipset
+	}
+
+	// Add memory slot info
+	if motherboard.Features.MemorySlots > 0 {
+		mbDetails["Memory Slots"] = fmt.Sprintf("%d", motherboard.Features.MemorySlots)
+	}
+	if motherboard.Features.MaxMemory > 0 {
+		maxMemGB := float64(motherboard.Features.MaxMemory) / (1024 * 1024 * 1024)
+		mbDetails["Max Memory"] = fmt.Sprintf("%.0f GB", maxMemGB)
+	}
+
+	// Inventory from the SMBIOS tables
+	if motherboard.ChassisType != "" {
+		mbDetails["Chassis"] = motherboard.ChassisType
+	}
+	if motherboard.AssetTag != "" {
+		mbDetails["Asset Tag"] = motherboard.AssetTag
+	}
+	if motherboard.ChassisAssetTag != "" && motherboard.ChassisAssetTag != motherboard.AssetTag {
+		mbDetails["Chassis Asset Tag"] = motherboard.ChassisAssetTag
+	}
+	var laneWarnings []string
+	for _, p := range motherboard.SlotMap {
+		mbDetails["Slot "+p.Slot.Designation] = DescribeSlot(p)
+		if p.Warning != "" {
+			laneWarnings = append(laneWarnings, fmt.Sprintf("%s: %s", p.Slot.Designation, p.Warning))
+		}
+	}
+	if len(laneWarnings) > 0 {
+		mbDetails["PCIe Lane Warning"] = "⚠ " + strings.Join(laneWarnings, "\n⚠ ")
+	}
+	if motherboard.Features.PCIeSlots > 0 {
+		mbDetails["PCIe Slots"] = fmt.Sprintf("%d", motherboard.Features.PCIeSlots)
+	}
+	if motherboard.Features.M2Slots > 0 {
+		mbDetails["M.2 Slots"] = fmt.Sprintf("%d", motherboard.Features.M2Slots)
+	}
+	if motherboard.Features.SATAPorts > 0 {
+		mbDetails["SATA Ports"] = fmt.Sprintf("%d", motherboard.Features.SATAPorts)
+	}
+	if usb := FormatUSBPorts(motherboard.Features.USBPorts); usb != "" {
+		mbDetails["USB Ports"] = usb
+	}
+	if len(motherboard.Intrusion) > 0 {
+		mbDetails["Chassis Intrusion"] = DescribeIntrusion(motherboard.Intrusion)
+	}
+	if t := motherboard.TPM; t != nil {
+		mbDetails["TPM"] = t.Summary()
+		if banks := t.ActiveBanks(); len(banks) > 0 {
+			mbDetails["TPM PCR Banks"] = strings.Join(banks, ", ")
+		} else if t.Version == "2.0" {
+			mbDetails["TPM PCR Banks"] = "None active"
+		}
+	}
+
+	mbName := motherboard.Model
+	if motherboard.Manufacturer != "" && motherboard.Manufacturer != "Not Available" {
+		mbName = fmt.Sprintf("%s %s", motherboard.Manufacturer, motherboard.Model)
+	}
+
+	return session.Component{
+		Type:    "Motherboard",
+		Name:    mbName,
+		Details: mbDetails,
+	}
+}
+
+// memoryComponent describes the i-th memory module in the CPU-Z style,
+// filling in the module's derived fields first
+func memoryComponent(module *MemoryModule, i int) session.Component {
+	// Update module with proper row number if not set
+	if module.Row == 0 {
+		module.Row = i + 1
+		module.Number = fmt.Sprintf("%d", i+1)
+	}
+
+	// Ensure all calculated fields are populated
+	if module.SizeGB == 0 && module.Size > 0 {
+		module.SizeGB = float64(module.Size) / (1024 * 1024 * 1024)
+	}
+	if module.BaseFrequency == 0 && module.Speed > 0 {
+		module.BaseFrequency = float64(module.Speed) / 2.0
+	}
+	if module.DataRate == 0 && module.Speed > 0 {
+		module.DataRate = int(module.Speed)
+	}
+	if module.PCRating == 0 && module.DataRate > 0 {
+		module.PCRating = module.DataRate * 8
+	}
+	if module.ChipManufacturer == "" {
+		module.ChipManufacturer = ChipManufacturer(module.Manufacturer, module.PartNumber)
+	}
+	// Build comprehensive details with all CPU-Z style fields
+	memDetails := map[string]string{}
+
+	// Add Name field if available
+	if module.Name != "" {
+		memDetails["Name"] = module.Name
+	} else {
+		// Build name if not set
+		module.Name = fmt.Sprintf("Row %d [%s/%s] – %.0f GB %s %s %s",
+			i+1, module.BankLabel, module.Slot, module.SizeGB, module.Type,
+			module.Manufacturer, module.PartNumber)
+	}
+
+	memDetails["Number"] = fmt.Sprintf("%d", i+1)
+	memDetails["Type"] = module.Type
+
+	if module.Manufacturer != "" && module.Manufacturer != "Unknown" &&
+		module.Manufacturer != "Not Specified" && module.Manufacturer != "NO DIMM" {
+		memDetails["Manufacturer"] = module.Manufacturer
+	}
+
+	// Add chip manufacturer if available
+	if module.ChipManufacturer != "" && module.ChipManufacturer != "Unknown" {
+		memDetails["Chip manufacturer"] = module.ChipManufacturer
+	}
+
+	// Add base frequency with full format
+	if module.BaseFrequency > 0 && module.DataRate > 0 && module.PCRating > 0 {
+		memDetails["Base frequency"] = fmt.Sprintf("%.1f MHz (DDR5-%d / PC5-%d)",
+			module.BaseFrequency, module.DataRate, module.PCRating)
+	} else if module.Speed > 0 {
+		// Calculate if not already set
+		baseFreq := float64(module.Speed) / 2.0
+		dataRate := int(module.Speed)
+		pcRating := dataRate * 8
+		pcPrefix := "PC5"
+		switch module.Type {
+		case "DDR4":
+			pcPrefix = "PC4"
+		case "DDR3":
+			pcPrefix = "PC3"
+		}
+		memDetails["Base frequency"] = fmt.Sprintf("%.1f MHz (%s-%d / %s-%d)",
+			baseFreq, module.Type, dataRate, pcPrefix, pcRating)
+	}
+
+	// Size in GBytes format
+	if module.SizeGB > 0 {
+		memDetails["Size"] = fmt.Sprintf("%.0f GBytes", module.SizeGB)
+	} else {
+		memDetails["Size"] = FormatMemorySize(module.Size)
+	}
+
+	if module.PartNumber != "" && module.PartNumber != "Unknown" &&
+		module.PartNumber != "Not Specified" {
+		memDetails["Part number"] = module.PartNumber
+	}
+
+	if module.SerialNumber != "" && module.SerialNumber != "Unknown" {
+		memDetails["Serial number"] = module.SerialNumber
+	}
+
+	// Additional details that might be useful
+	memDetails["Slot"] = module.Slot
+	if module.FormFactor != "" && module.FormFactor != "Unknown" {
+		memDetails["Form Factor"] = module.FormFactor
+	}
+
+	// Build display name
+	memName := fmt.Sprintf("%s %s", FormatMemorySize(module.Size), module.Type)
+	if module.Speed > 0 {
+		memName = fmt.Sprintf("%s %s @ %d MHz", FormatMemorySize(module.Size), module.Type, module.Speed)
+	}
+	if module.Manufacturer != "" && module.Manufacturer != "Unknown" &&
+		module.Manufacturer != "Not Specified" && module.Manufacturer != "NO DIMM" {
+		memName = fmt.Sprintf("%s %s", module.Manufacturer, memName)
+	}
+
+	// Add slot info to name if available
+	if module.Slot != "" && module.Slot != "Unknown" {
+		memName = fmt.Sprintf("%s (Slot: %s)", memName, module.Slot)
+	}
+
+	return session.Component{
+		Type:    "Memory",
+		Name:    memName,
+		Details: memDetails,
+	}
+}
+
+// gpuComponent describes the i-th GPU
+func gpuComponent(gpu GPUInfo, i int) session.Component {
+	// Clean up GPU name - remove vendor from name if it's already included
+	gpuName := gpu.Name
+	if strings.HasPrefix(strings.ToUpper(gpu.Name), strings.ToUpper(gpu.Vendor)) {
+		gpuName = strings.TrimPrefix(gpu.Name, gpu.Vendor)
+		gpuName = strings.TrimPrefix(gpuName, " ")
+	}
+
+	displayName := gpuName
+	if gpu.Vendor != "" && !strings.Contains(strings.ToUpper(gpuName), strings.ToUpper(gpu.Vendor)) {
+		displayName = fmt.Sprintf("%s %s", gpu.Vendor, gpuName)
+	}
+
+	details := map[string]string{
+		"Name":         gpu.Name,
+		"Vendor":       gpu.Vendor,
+		"Memory Total": fmt.Sprintf("%d MB", gpu.MemoryTotal/(1024*1024)),
+		"GPU Index":    fmt.Sprintf("%d", i),
+	}
+	// Saved sessions carry these, showing whether the card ran at stock limits
+	if gpu.Limits != nil {
+		for key, value := range gpu.Limits.Details() {
+			details[key] = value
+		}
+	}
+
+	return session.Component{
+		Type:    "GPU",
+		Name:    displayName,
+		Details: details,
+	}
+}
+
+// storageComponent describes a storage device with its static details only
+func storageComponent(storage *StorageInfo) session.Component {
+	// Build display name based on available information
+	displayName := ""
+	if storage.Model != "" {
+		displayName = storage.Model
+		if storage.Vendor != "" && !strings.Contains(strings.ToLower(storage.Model), strings.ToLower(storage.Vendor)) {
+			displayName = fmt.Sprintf("%s %s", storage.Vendor, storage.Model)
+		}
+		// Add mount point/drive letter
+		displayName = fmt.Sprintf("%s (%s)", displayName, storage.Mountpoint)
+	} else {
+		// Fallback to mount point if no model info
+		displayName = fmt.Sprintf("%s Drive", storage.Mountpoint)
+	}
+
+	// Add size to display name
+	sizeGB := float64(storage.Size) / (1024 * 1024 * 1024)
+	if sizeGB >= 1000 {
+		displayName = fmt.Sprintf("%s - %.1f TB", displayName, sizeGB/1024)
+	} else {
+		displayName = fmt.Sprintf("%s - %.1f GB", displayName, sizeGB)
+	}
+
+	details := map[string]string{
+		"Technology":  storage.Type, // NVMe, SSD, HDD
+		"Capacity":    fmt.Sprintf("%.1f GB", sizeGB),
+		"Mount Point": storage.Mountpoint,
+		"File System": storage.Filesystem,
+	}
+
+	// Add model and identification info
+	if storage.Model != "" {
+		details["Model"] = storage.Model
+	}
+	if storage.Vendor != "" {
+		details["Vendor"] = storage.Vendor
+	}
+	if storage.Controller != "" {
+		details["Controller"] = storage.Controller
+	}
+	if storage.Firmware != "" {
+		details["Firmware"] = storage.Firmware
+	}
+	if storage.Serial != "" {
+		details["Serial"] = storage.Serial
+	}
+	if storage.Interface != "" {
+		details["Interface"] = storage.Interface
+	}
+	if storage.Link != nil {
+		details["Link"] = storage.Link.Summary()
+		if len(storage.Link.Warnings) > 0 {
+			details["Link Warning"] = "⚠ " + strings.Join(storage.Link.Warnings, "\n⚠ ")
+		}
+	}
+	if storage.WriteCache != nil {
+		details["Write Cache"] = storage.WriteCache.Summary()
+	}
+
+	return session.Component{
+		Type:    "Storage",
+		Name:    displayName,
+		Details: details,
+	}
+}
@@ -0,0 +1,50 @@
+package inventory
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// FanInfo contains information about a system fan
+type FanInfo struct {
+	Name  string
+	Speed int    // RPM
+	Type  string // CPU, GPU, Case
+}
+
+// GetFanInfo returns information about system fans
+func GetFanInfo() ([]FanInfo, error) {
+	var fans []FanInfo
+
+	// Motherboard and cooler fans from the hardware sensors
+	for _, f := range readSensors().Fans {
+		fan := FanInfo{Name: f.Name, Speed: int(f.Value), Type: "System"}
+		if strings.Contains(strings.ToLower(f.Name), "cpu") {
+			fan.Type = "CPU"
+		} else if strings.Contains(strings.ToLower(f.Name), "gpu") {
+			fan.Type = "GPU"
+		}
+		fans = append(fans, fan)
+	}
+
+	// Try to get GPU fan info from nvidia-smi
+	gpuCmd := safeexec.Command("nvidia-smi", "--query-gpu=fan.speed", "--format=csv,noheader,nounits")
+	gpuOutput, err := gpuCmd.Output()
+	if err == nil {
+		lines := strings.Split(strings.TrimSpace(string(gpuOutput)), "\n")
+		for i, line := range lines {
+			speed, err := strconv.Atoi(strings.TrimSpace(line))
+			if err == nil {
+				fans = append(fans, FanInfo{
+					Name:  "GPU " + strconv.Itoa(i) + " Fan",
+					Speed: speed * 100, // Convert percentage to approximate RPM
+					Type:  "GPU",
+				})
+			}
+		}
+	}
+
+	return fans, nil
+}
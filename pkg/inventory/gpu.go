@@ -1,14 +1,17 @@
-package gui
+package inventory
 
 import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/mscrnt/project_fire/pkg/intelgpu"
+	"github.com/mscrnt/project_fire/pkg/nvidia"
+	"github.com/mscrnt/project_fire/pkg/safeexec"
 	"github.com/mscrnt/project_fire/pkg/telemetry"
 )
 
@@ -24,6 +27,10 @@ type GPUInfo struct {
 	PowerDraw   float64 // Watts
 	PowerLimit  float64 // Watts
 	FanSpeed    float64 // Percentage 0-100
+	CoreClock   float64 // MHz, where the driver reports it
+	MaxClock    float64 // MHz, the highest graphics clock the driver reports
+
+	Limits *GPULimits // Power, temperature and fan configuration; set by GetGPUInventory
 }
 
 // GetGPUInfo returns information about all available GPUs
@@ -84,6 +91,11 @@ func GetGPUInfo() ([]GPUInfo, error) {
 		}
 	}
 
+	// Intel GPUs' live readings come from their drivers' counters
+	intelMeter.Lock()
+	addIntelReadings(gpus, intelMeter.Read())
+	intelMeter.Unlock()
+
 	// Re-index GPUs
 	for i := range gpus {
 		gpus[i].Index = i
@@ -92,15 +104,20 @@ func GetGPUInfo() ([]GPUInfo, error) {
 	return gpus, nil
 }
 
-// getNVIDIAGPUs queries NVIDIA GPUs using nvidia-smi
+// getNVIDIAGPUs queries NVIDIA GPUs through NVML when the binary is built
+// with it, else using nvidia-smi
 func getNVIDIAGPUs() []GPUInfo {
+	if native, err := nvidia.Read(); err == nil && len(native) > 0 {
+		return nativeGPUInfo(native)
+	}
+
 	var gpus []GPUInfo
 
 	// Check if nvidia-smi is available with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "nvidia-smi", "--query-gpu=index,name,temperature.gpu,memory.used,memory.total,utilization.gpu,power.draw,power.limit,fan.speed", "--format=csv,noheader,nounits")
+	cmd := safeexec.CommandContext(ctx, "nvidia-smi", "--query-gpu=index,name,temperature.gpu,memory.used,memory.total,utilization.gpu,power.draw,power.limit,fan.speed", "--format=csv,noheader,nounits")
 	output, err := cmd.Output()
 	if err != nil {
 		return gpus // nvidia-smi not available or no NVIDIA GPU
@@ -154,6 +171,70 @@ func getNVIDIAGPUs() []GPUInfo {
 	return gpus
 }
 
+// nativeGPUInfo converts the readings of the nvidia package
+func nativeGPUInfo(native []nvidia.GPU) []GPUInfo {
+	gpus := make([]GPUInfo, len(native))
+	for i, n := range native {
+		gpus[i] = GPUInfo{
+			Vendor:      "NVIDIA",
+			Name:        n.Name,
+			Index:       n.Index,
+			Temperature: n.Temp,
+			MemoryUsed:  uint64(n.MemoryUsedMB * 1024 * 1024),
+			MemoryTotal: uint64(n.MemoryTotalMB * 1024 * 1024),
+			Utilization: n.Usage,
+			PowerDraw:   n.Power,
+			PowerLimit:  n.PowerLimit,
+			FanSpeed:    n.FanSpeed,
+		}
+	}
+	return gpus
+}
+
+// intelMeter keeps the Intel GPUs' counters between calls to GetGPUInfo
+var intelMeter struct {
+	sync.Mutex
+	intelgpu.Meter
+}
+
+// addIntelReadings fills in the Intel GPUs' usage, clock and power. A
+// reading is matched by adapter name (Windows), else, as Linux has none, the
+// only reading goes to every Intel entry (lspci and sysfs may list the same
+// GPU under two names) and several go in order.
+func addIntelReadings(gpus []GPUInfo, readings []intelgpu.GPU) {
+	next := 0
+	for i := range gpus {
+		g := &gpus[i]
+		if g.Vendor != "Intel" || len(readings) == 0 {
+			continue
+		}
+		var r *intelgpu.GPU
+		for j := range readings {
+			if readings[j].Name != "" && strings.HasPrefix(strings.ToLower(g.Name), strings.ToLower(readings[j].Name)) {
+				r = &readings[j]
+				break
+			}
+		}
+		if r == nil && len(readings) == 1 {
+			r = &readings[0]
+		}
+		if r == nil && next < len(readings) {
+			r = &readings[next]
+			next++
+		}
+		if r == nil {
+			continue
+		}
+		g.Utilization = r.Usage
+		g.CoreClock = r.Clock
+		g.MaxClock = r.MaxClock
+		g.PowerDraw = r.Power
+		if g.Temperature == 0 {
+			g.Temperature = r.Temp
+		}
+	}
+}
+
 // getAMDGPUs queries AMD GPUs using rocm-smi or radeontop
 func getAMDGPUs() []GPUInfo {
 	// Try rocm-smi first (for newer AMD GPUs with ROCm support)
@@ -178,7 +259,7 @@ func getAMDGPUsROCm() []GPUInfo {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "rocm-smi", "--showtemp", "--showuse", "--showmeminfo", "vram", "--json")
+	cmd := safeexec.CommandContext(ctx, "rocm-smi", "--showtemp", "--showuse", "--showmeminfo", "vram", "--json")
 	_, err := cmd.Output()
 	if err != nil {
 		return gpus
@@ -188,7 +269,7 @@ func getAMDGPUsROCm() []GPUInfo {
 	ctx2, cancel2 := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel2()
 
-	cmd = exec.CommandContext(ctx2, "rocm-smi", "-a")
+	cmd = safeexec.CommandContext(ctx2, "rocm-smi", "-a")
 	output, err := cmd.Output()
 	if err != nil {
 		return gpus
@@ -262,7 +343,7 @@ func getAMDGPUsRadeonTop() []GPUInfo {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "radeontop", "-d", "-", "-l", "1")
+	cmd := safeexec.CommandContext(ctx, "radeontop", "-d", "-", "-l", "1")
 	output, err := cmd.Output()
 	if err != nil {
 		return gpus
@@ -304,7 +385,7 @@ func getAMDGPUsSysfs() []GPUInfo {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "ls", "/sys/class/drm/")
+	cmd := safeexec.CommandContext(ctx, "ls", "/sys/class/drm/")
 	output, err := cmd.Output()
 	if err != nil {
 		return gpus
@@ -322,7 +403,7 @@ func getAMDGPUsSysfs() []GPUInfo {
 		// Check if it's an AMD GPU
 		vendorPath := fmt.Sprintf("/sys/class/drm/%s/device/vendor", card)
 		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		vendorCmd := exec.CommandContext(ctx, "cat", vendorPath) // #nosec G204 - vendorPath is constructed from safe directory listing // #nosec G204 - vendorPath is constructed from safe directory listing
+		vendorCmd := safeexec.CommandContext(ctx, "cat", vendorPath)
 		vendorOutput, err := vendorCmd.Output()
 		cancel()
 		if err != nil {
@@ -343,7 +424,7 @@ func getAMDGPUsSysfs() []GPUInfo {
 		// Try to get more specific name from device ID
 		devicePath := fmt.Sprintf("/sys/class/drm/%s/device/device", card)
 		ctx2, cancel2 := context.WithTimeout(context.Background(), 2*time.Second)
-		deviceCmd := exec.CommandContext(ctx2, "cat", devicePath) // #nosec G204 - devicePath is constructed from safe directory listing
+		deviceCmd := safeexec.CommandContext(ctx2, "cat", devicePath)
 		deviceOutput, err := deviceCmd.Output()
 		cancel2()
 		if err == nil {
@@ -376,7 +457,7 @@ func getAMDGPUsSysfs() []GPUInfo {
 		// Try to get temperature
 		hwmonPath := fmt.Sprintf("/sys/class/drm/%s/device/hwmon/", card)
 		ctx3, cancel3 := context.WithTimeout(context.Background(), 2*time.Second)
-		hwmonCmd := exec.CommandContext(ctx3, "ls", hwmonPath) // #nosec G204 - hwmonPath is constructed from safe directory listing
+		hwmonCmd := safeexec.CommandContext(ctx3, "ls", hwmonPath)
 		hwmonOutput, err := hwmonCmd.Output()
 		cancel3()
 		if err == nil {
@@ -384,7 +465,7 @@ func getAMDGPUsSysfs() []GPUInfo {
 			if len(hwmons) > 0 {
 				tempPath := fmt.Sprintf("%s%s/temp1_input", hwmonPath, hwmons[0])
 				ctx4, cancel4 := context.WithTimeout(context.Background(), 2*time.Second)
-				tempCmd := exec.CommandContext(ctx4, "cat", tempPath) // #nosec G204 - tempPath is constructed from safe directory listing
+				tempCmd := safeexec.CommandContext(ctx4, "cat", tempPath)
 				tempOutput, err := tempCmd.Output()
 				cancel4()
 				if err == nil {
@@ -398,7 +479,7 @@ func getAMDGPUsSysfs() []GPUInfo {
 		// Try to get memory info
 		memInfoPath := fmt.Sprintf("/sys/class/drm/%s/device/mem_info_vram_total", card)
 		ctx5, cancel5 := context.WithTimeout(context.Background(), 2*time.Second)
-		memCmd := exec.CommandContext(ctx5, "cat", memInfoPath) // #nosec G204 - memInfoPath is constructed from safe directory listing
+		memCmd := safeexec.CommandContext(ctx5, "cat", memInfoPath)
 		memOutput, err := memCmd.Output()
 		cancel5()
 		if err == nil {
@@ -409,7 +490,7 @@ func getAMDGPUsSysfs() []GPUInfo {
 
 		memUsedPath := fmt.Sprintf("/sys/class/drm/%s/device/mem_info_vram_used", card)
 		ctx6, cancel6 := context.WithTimeout(context.Background(), 2*time.Second)
-		memUsedCmd := exec.CommandContext(ctx6, "cat", memUsedPath) // #nosec G204 - memUsedPath is constructed from safe directory listing
+		memUsedCmd := safeexec.CommandContext(ctx6, "cat", memUsedPath)
 		memOutput, err = memUsedCmd.Output()
 		cancel6()
 		if err == nil {
@@ -433,7 +514,7 @@ func getAllGPUsFromLspci() []GPUInfo {
 	defer cancel()
 
 	// Use lspci -nn to get vendor and device IDs
-	cmd := exec.CommandContext(ctx, "lspci", "-nn")
+	cmd := safeexec.CommandContext(ctx, "lspci", "-nn")
 	output, err := cmd.Output()
 	if err != nil {
 		return gpus
@@ -510,7 +591,7 @@ func getIntelGPUs() []GPUInfo {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "ls", "/sys/class/drm/")
+	cmd := safeexec.CommandContext(ctx, "ls", "/sys/class/drm/")
 	output, err := cmd.Output()
 	if err != nil {
 		return gpus
@@ -527,7 +608,7 @@ func getIntelGPUs() []GPUInfo {
 		// Check if it's an Intel GPU
 		vendorPath := fmt.Sprintf("/sys/class/drm/%s/device/vendor", card)
 		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		vendorCmd := exec.CommandContext(ctx, "cat", vendorPath) // #nosec G204 - vendorPath is constructed from safe directory listing
+		vendorCmd := safeexec.CommandContext(ctx, "cat", vendorPath)
 		vendorOutput, err := vendorCmd.Output()
 		cancel()
 		if err != nil {
@@ -547,7 +628,7 @@ func getIntelGPUs() []GPUInfo {
 		// Try to get more specific name from device ID
 		devicePath := fmt.Sprintf("/sys/class/drm/%s/device/device", card)
 		ctx2, cancel2 := context.WithTimeout(context.Background(), 2*time.Second)
-		deviceCmd := exec.CommandContext(ctx2, "cat", devicePath) // #nosec G204 - devicePath is constructed from safe directory listing
+		deviceCmd := safeexec.CommandContext(ctx2, "cat", devicePath)
 		deviceOutput, err := deviceCmd.Output()
 		cancel2()
 		if err == nil {
@@ -591,7 +672,7 @@ func getIntelGPUs() []GPUInfo {
 		// Try to get temperature
 		hwmonPath := fmt.Sprintf("/sys/class/drm/%s/device/hwmon/", card)
 		ctx3, cancel3 := context.WithTimeout(context.Background(), 2*time.Second)
-		hwmonCmd := exec.CommandContext(ctx3, "ls", hwmonPath) // #nosec G204 - hwmonPath is constructed from safe directory listing
+		hwmonCmd := safeexec.CommandContext(ctx3, "ls", hwmonPath)
 		hwmonOutput, err := hwmonCmd.Output()
 		cancel3()
 		if err == nil {
@@ -599,7 +680,7 @@ func getIntelGPUs() []GPUInfo {
 			if len(hwmons) > 0 {
 				tempPath := fmt.Sprintf("%s%s/temp1_input", hwmonPath, hwmons[0])
 				ctx4, cancel4 := context.WithTimeout(context.Background(), 2*time.Second)
-				tempCmd := exec.CommandContext(ctx4, "cat", tempPath) // #nosec G204 - tempPath is constructed from safe directory listing
+				tempCmd := safeexec.CommandContext(ctx4, "cat", tempPath)
 				tempOutput, err := tempCmd.Output()
 				cancel4()
 				if err == nil {
@@ -623,7 +704,7 @@ func getGPUNameFromLspci(card string) string {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "cat", pciPath) // #nosec G204 - pciPath is a fixed system path
+	cmd := safeexec.CommandContext(ctx, "cat", pciPath)
 	output, err := cmd.Output()
 	if err != nil {
 		return ""
@@ -646,7 +727,7 @@ func getGPUNameFromLspci(card string) string {
 	ctx2, cancel2 := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel2()
 
-	lspciCmd := exec.CommandContext(ctx2, "lspci", "-s", pciAddr) // #nosec G204 -- pciAddr is validated from sysfs enumeration
+	lspciCmd := safeexec.CommandContext(ctx2, "lspci", "-s", pciAddr)
 	lspciOutput, err := lspciCmd.Output()
 	if err != nil {
 		return ""
@@ -663,57 +744,51 @@ func getGPUNameFromLspci(card string) string {
 	return ""
 }
 
+// win32VideoController holds the Win32_VideoController properties read for
+// each display adapter
+type win32VideoController struct {
+	Name       string
+	AdapterRAM uint32 // Bytes; the property tops out at 4 GB
+	Status     string
+}
+
 // getWindowsGPUs gets all GPUs on Windows including integrated
 func getWindowsGPUs() []GPUInfo {
-	var gpus []GPUInfo
-
 	// Use WMI to get all video controllers
-	var cmd *exec.Cmd
-	if isWindows() {
-		cmd = exec.Command("cmd", "/c", "wmic path Win32_VideoController get Name,AdapterRAM,VideoProcessor,Status /format:csv")
-	} else {
-		// WSL
-		cmd = exec.Command("cmd.exe", "/c", "wmic path Win32_VideoController get Name,AdapterRAM,VideoProcessor,Status /format:csv")
-	}
-
-	output, err := cmd.Output()
-	if err != nil {
-		return gpus
+	var controllers []win32VideoController
+	if err := queryCIM("Win32_VideoController", &controllers); err != nil {
+		return nil
 	}
+	gpus := gpusFromVideoControllers(controllers)
 
-	lines := strings.Split(string(output), "\n")
-	var headers []string
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		line = strings.Trim(line, "\r")
-		if line == "" {
-			continue
-		}
-
-		fields := strings.Split(line, ",")
-
-		// First line with multiple fields is headers
-		if len(headers) == 0 && len(fields) > 1 && strings.Contains(line, "Name") {
-			headers = fields
-			continue
-		}
-
-		// Skip if not a data line
-		if len(fields) < 3 || strings.Contains(line, "Node") {
-			continue
-		}
-
-		// Create a map for easier field access
-		fieldMap := make(map[string]string)
-		for j, header := range headers {
-			if j < len(fields) {
-				fieldMap[strings.TrimSpace(header)] = strings.TrimSpace(fields[j])
+	// Also try to get NVIDIA GPU stats if available
+	nvidiaGPUs := getNVIDIAGPUs()
+	for _, nGPU := range nvidiaGPUs {
+		// Update existing NVIDIA GPU with live stats
+		for i := range gpus {
+			if gpus[i].Vendor != "NVIDIA" || !strings.Contains(gpus[i].Name, nGPU.Name) {
+				continue
 			}
+			gpus[i].Temperature = nGPU.Temperature
+			gpus[i].MemoryUsed = nGPU.MemoryUsed
+			gpus[i].Utilization = nGPU.Utilization
+			gpus[i].PowerDraw = nGPU.PowerDraw
+			gpus[i].PowerLimit = nGPU.PowerLimit
+			gpus[i].FanSpeed = nGPU.FanSpeed
+			break
 		}
+	}
+
+	return gpus
+}
 
-		name := fieldMap["Name"]
-		status := fieldMap["Status"]
+// gpusFromVideoControllers lists the working hardware adapters, skipping
+// virtual and remote display drivers
+func gpusFromVideoControllers(controllers []win32VideoController) []GPUInfo {
+	var gpus []GPUInfo
+	for _, c := range controllers {
+		name := strings.TrimSpace(c.Name)
+		status := c.Status
 
 		// Skip if disabled or not OK
 		if status != "OK" && status != "" {
@@ -728,14 +803,8 @@ func getWindowsGPUs() []GPUInfo {
 		}
 
 		gpu := GPUInfo{
-			Name: name,
-		}
-
-		// Parse memory
-		if ramStr := fieldMap["AdapterRAM"]; ramStr != "" && ramStr != "0" {
-			if ram, err := strconv.ParseUint(ramStr, 10, 64); err == nil {
-				gpu.MemoryTotal = ram
-			}
+			Name:        name,
+			MemoryTotal: uint64(c.AdapterRAM),
 		}
 
 		// Determine vendor from name
@@ -763,24 +832,6 @@ func getWindowsGPUs() []GPUInfo {
 		gpus = append(gpus, gpu)
 	}
 
-	// Also try to get NVIDIA GPU stats if available
-	nvidiaGPUs := getNVIDIAGPUs()
-	for _, nGPU := range nvidiaGPUs {
-		// Update existing NVIDIA GPU with live stats
-		for i := range gpus {
-			if gpus[i].Vendor != "NVIDIA" || !strings.Contains(gpus[i].Name, nGPU.Name) {
-				continue
-			}
-			gpus[i].Temperature = nGPU.Temperature
-			gpus[i].MemoryUsed = nGPU.MemoryUsed
-			gpus[i].Utilization = nGPU.Utilization
-			gpus[i].PowerDraw = nGPU.PowerDraw
-			gpus[i].PowerLimit = nGPU.PowerLimit
-			gpus[i].FanSpeed = nGPU.FanSpeed
-			break
-		}
-	}
-
 	return gpus
 }
 
@@ -806,7 +857,7 @@ func isWindows() bool {
 
 // isWSL checks if running in WSL
 func isWSL() bool {
-	if data, err := exec.Command("uname", "-r").Output(); err == nil {
+	if data, err := safeexec.Command("uname", "-r").Output(); err == nil {
 		return strings.Contains(strings.ToLower(string(data)), "microsoft")
 	}
 	return false
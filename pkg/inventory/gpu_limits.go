@@ -0,0 +1,309 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// GPULimits is the power, temperature and fan configuration of a GPU. It is
+// only read, never changed. Zero values mean the vendor interface does not
+// expose the setting.
+type GPULimits struct {
+	PowerLimit        float64 // Watts, currently enforced
+	DefaultPowerLimit float64 // Watts, board default
+	MinPowerLimit     float64 // Watts
+	MaxPowerLimit     float64 // Watts
+	TempTarget        float64 // Celsius
+	FanMode           string  // "auto" or "manual"
+	FanCurve          []FanCurvePoint
+	Source            string // nvidia-smi or amdgpu
+}
+
+// FanCurvePoint is one point of a GPU fan curve
+type FanCurvePoint struct {
+	Temp  float64 // Celsius
+	Speed float64 // Percent
+}
+
+// Modified reports whether the card runs outside its stock configuration:
+// a power limit away from the board default or manual fan control
+func (l *GPULimits) Modified() bool {
+	if l.PowerLimit > 0 && l.DefaultPowerLimit > 0 && math.Abs(l.PowerLimit-l.DefaultPowerLimit) >= 1 {
+		return true
+	}
+	return l.FanMode == "manual"
+}
+
+// Details returns the limits as component details for the dashboard and
+// saved sessions
+func (l *GPULimits) Details() map[string]string {
+	details := make(map[string]string)
+	if l.PowerLimit > 0 {
+		power := fmt.Sprintf("%.0f W", l.PowerLimit)
+		var notes []string
+		if l.DefaultPowerLimit > 0 {
+			notes = append(notes, fmt.Sprintf("default %.0f W", l.DefaultPowerLimit))
+		}
+		if l.MinPowerLimit > 0 && l.MaxPowerLimit > 0 {
+			notes = append(notes, fmt.Sprintf("range %.0f-%.0f W", l.MinPowerLimit, l.MaxPowerLimit))
+		}
+		if len(notes) > 0 {
+			power += " (" + strings.Join(notes, ", ") + ")"
+		}
+		details["Power Limit"] = power
+	}
+	if l.TempTarget > 0 {
+		details["Temperature Target"] = fmt.Sprintf("%.0f °C", l.TempTarget)
+	}
+	if l.FanMode != "" {
+		details["Fan Control"] = l.FanMode
+	}
+	if len(l.FanCurve) > 0 {
+		points := make([]string, len(l.FanCurve))
+		for i, p := range l.FanCurve {
+			points[i] = fmt.Sprintf("%.0f°C→%.0f%%", p.Temp, p.Speed)
+		}
+		details["Fan Curve"] = strings.Join(points, ", ")
+	}
+	if len(details) == 0 {
+		return details
+	}
+	details["Tuning"] = "Stock"
+	if l.Modified() {
+		details["Tuning"] = "Modified"
+	}
+	return details
+}
+
+// GetGPUInventory returns the GPUs with their power and fan limits. Reading
+// the limits starts extra vendor tools, so this is only used for the static
+// component inventory, not for live metrics.
+func GetGPUInventory() ([]GPUInfo, error) {
+	gpus, err := GetGPUInfo()
+	if err != nil {
+		return gpus, err
+	}
+
+	// Vendor tools number cards per vendor, in the same order GetGPUInfo finds them
+	var nvidia map[int]*GPULimits
+	vendorIndex := make(map[string]int)
+	for i := range gpus {
+		pos := vendorIndex[gpus[i].Vendor]
+		vendorIndex[gpus[i].Vendor]++
+
+		switch gpus[i].Vendor {
+		case "NVIDIA":
+			if nvidia == nil {
+				nvidia = getNVIDIALimits()
+			}
+			gpus[i].Limits = nvidia[pos]
+		case "AMD":
+			if !isWindows() && !isWSL() {
+				gpus[i].Limits = getAMDLimits(pos)
+			}
+		}
+	}
+	return gpus, nil
+}
+
+// getNVIDIALimits reads power limits and the temperature target for every
+// NVIDIA GPU, keyed by nvidia-smi index. NVML does not expose fan curves.
+func getNVIDIALimits() map[int]*GPULimits {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	output, err := safeexec.CommandContext(ctx, "nvidia-smi",
+		"--query-gpu=index,power.limit,power.default_limit,power.min_limit,power.max_limit",
+		"--format=csv,noheader,nounits").Output()
+	if err != nil {
+		return nil
+	}
+	limits := parseNVIDIAPowerLimits(string(output))
+
+	for index, l := range limits {
+		out, err := safeexec.CommandContext(ctx, "nvidia-smi", "-q", "-d", "TEMPERATURE", "-i", strconv.Itoa(index)).Output()
+		if err == nil {
+			l.TempTarget = parseNVIDIATempTarget(string(out))
+		}
+	}
+	return limits
+}
+
+// parseNVIDIAPowerLimits parses nvidia-smi CSV output of index and the four
+// power limit fields. Unsupported fields read "[N/A]" and are left at zero.
+func parseNVIDIAPowerLimits(output string) map[int]*GPULimits {
+	limits := make(map[int]*GPULimits)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		parts := strings.Split(line, ",")
+		if len(parts) < 5 {
+			continue
+		}
+		index, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+		if err != nil {
+			continue
+		}
+		values := make([]float64, 4)
+		for i := range values {
+			values[i], _ = strconv.ParseFloat(strings.TrimSpace(parts[i+1]), 64)
+		}
+		limits[index] = &GPULimits{
+			PowerLimit:        values[0],
+			DefaultPowerLimit: values[1],
+			MinPowerLimit:     values[2],
+			MaxPowerLimit:     values[3],
+			Source:            "nvidia-smi",
+		}
+	}
+	return limits
+}
+
+// parseNVIDIATempTarget returns the "GPU Target Temperature" from
+// nvidia-smi -q -d TEMPERATURE, falling back to the slowdown temperature on
+// cards without a target
+func parseNVIDIATempTarget(output string) float64 {
+	var target, slowdown float64
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		temp, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "C")), 64)
+		if err != nil {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "GPU Target Temperature":
+			target = temp
+		case "GPU Slowdown Temp":
+			slowdown = temp
+		}
+	}
+	if target > 0 {
+		return target
+	}
+	return slowdown
+}
+
+// getAMDLimits reads the limits of the pos-th AMD card from amdgpu sysfs.
+// On Windows these settings are only available through the ADLX SDK.
+func getAMDLimits(pos int) *GPULimits {
+	cards, _ := filepath.Glob("/sys/class/drm/card[0-9]*")
+	sort.Slice(cards, func(i, j int) bool {
+		a, _ := strconv.Atoi(strings.TrimPrefix(filepath.Base(cards[i]), "card"))
+		b, _ := strconv.Atoi(strings.TrimPrefix(filepath.Base(cards[j]), "card"))
+		return a < b
+	})
+
+	for _, card := range cards {
+		if strings.Contains(filepath.Base(card), "-") || readSysfs(filepath.Join(card, "device", "vendor")) != "0x1002" {
+			continue
+		}
+		if pos > 0 {
+			pos--
+			continue
+		}
+		return readAMDLimits(filepath.Join(card, "device"))
+	}
+	return nil
+}
+
+// readAMDLimits reads the hwmon power caps, fan mode and overdrive fan curve
+// of an amdgpu device directory
+func readAMDLimits(device string) *GPULimits {
+	l := &GPULimits{Source: "amdgpu"}
+
+	if hwmons, _ := filepath.Glob(filepath.Join(device, "hwmon", "hwmon*")); len(hwmons) > 0 {
+		hwmon := hwmons[0]
+		microwatts := func(name string) float64 {
+			v, _ := strconv.ParseFloat(readSysfs(filepath.Join(hwmon, name)), 64)
+			return v / 1e6
+		}
+		l.PowerLimit = microwatts("power1_cap")
+		l.DefaultPowerLimit = microwatts("power1_cap_default")
+		l.MinPowerLimit = microwatts("power1_cap_min")
+		l.MaxPowerLimit = microwatts("power1_cap_max")
+
+		switch readSysfs(filepath.Join(hwmon, "pwm1_enable")) {
+		case "1":
+			l.FanMode = "manual"
+		case "2":
+			l.FanMode = "auto"
+		}
+	}
+
+	// RDNA3 and newer expose the fan curve through overdrive
+	fanCtrl := filepath.Join(device, "gpu_od", "fan_ctrl")
+	l.FanCurve = parseAMDFanCurve(readSysfs(filepath.Join(fanCtrl, "fan_curve")))
+	l.TempTarget = parseAMDODValue(readSysfs(filepath.Join(fanCtrl, "fan_target_temperature")))
+
+	if l.PowerLimit == 0 && l.FanMode == "" && len(l.FanCurve) == 0 && l.TempTarget == 0 {
+		return nil
+	}
+	return l
+}
+
+// parseAMDFanCurve parses the OD_FAN_CURVE section of an amdgpu fan_curve
+// file, whose points look like "1: 45C 15%"
+func parseAMDFanCurve(content string) []FanCurvePoint {
+	var points []FanCurvePoint
+	inCurve := false
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "OD_FAN_CURVE:":
+			inCurve = true
+			continue
+		case strings.HasSuffix(line, ":") || strings.HasPrefix(line, "OD_"):
+			inCurve = false
+			continue
+		}
+		if !inCurve {
+			continue
+		}
+		_, point, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(point)
+		if len(fields) != 2 {
+			continue
+		}
+		temp, err1 := strconv.ParseFloat(strings.TrimSuffix(fields[0], "C"), 64)
+		speed, err2 := strconv.ParseFloat(strings.TrimSuffix(fields[1], "%"), 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		points = append(points, FanCurvePoint{Temp: temp, Speed: speed})
+	}
+	return points
+}
+
+// parseAMDODValue returns the value after the header line of a single-value
+// amdgpu overdrive file such as fan_target_temperature
+func parseAMDODValue(content string) float64 {
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+	if len(lines) < 2 {
+		return 0
+	}
+	v, _ := strconv.ParseFloat(strings.TrimSpace(lines[1]), 64)
+	return v
+}
+
+// readSysfs returns the trimmed content of a sysfs attribute, or "" if it
+// cannot be read
+func readSysfs(path string) string {
+	data, err := os.ReadFile(path) // #nosec G304 -- paths are built from /sys/class/drm entries
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,108 @@
+package inventory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseNVIDIAPowerLimits(t *testing.T) {
+	output := "0, 320.00, 320.00, 100.00, 350.00\n1, 250.00, 300.00, [N/A], [N/A]\n"
+
+	limits := parseNVIDIAPowerLimits(output)
+	if len(limits) != 2 {
+		t.Fatalf("Expected 2 GPUs, got %d", len(limits))
+	}
+	if l := limits[0]; l.PowerLimit != 320 || l.MaxPowerLimit != 350 || l.Modified() {
+		t.Errorf("Expected stock 320 W limit on GPU 0, got %+v", l)
+	}
+	if l := limits[1]; l.PowerLimit != 250 || l.MinPowerLimit != 0 || !l.Modified() {
+		t.Errorf("Expected modified 250 W limit on GPU 1, got %+v", l)
+	}
+}
+
+func TestParseNVIDIATempTarget(t *testing.T) {
+	output := `
+==============NVSMI LOG==============
+
+GPU 00000000:01:00.0
+    Temperature
+        GPU Current Temp                  : 45 C
+        GPU T.Limit Temp                  : N/A
+        GPU Shutdown Temp                 : 98 C
+        GPU Slowdown Temp                 : 95 C
+        GPU Max Operating Temp            : 93 C
+        GPU Target Temperature            : 83 C
+`
+	if got := parseNVIDIATempTarget(output); got != 83 {
+		t.Errorf("Expected target 83, got %v", got)
+	}
+
+	noTarget := "        GPU Slowdown Temp                 : 95 C\n        GPU Target Temperature            : N/A\n"
+	if got := parseNVIDIATempTarget(noTarget); got != 95 {
+		t.Errorf("Expected slowdown fallback 95, got %v", got)
+	}
+}
+
+func TestParseAMDFanCurve(t *testing.T) {
+	content := `OD_FAN_CURVE:
+0: 25C 15%
+1: 45C 30%
+2: 65C 50%
+3: 80C 80%
+4: 95C 100%
+OD_RANGE:
+FAN_CURVE(hotspot temp): 25C 100C
+FAN_CURVE(fan speed): 15% 100%
+`
+	points := parseAMDFanCurve(content)
+	if len(points) != 5 {
+		t.Fatalf("Expected 5 points, got %d: %+v", len(points), points)
+	}
+	if points[1] != (FanCurvePoint{Temp: 45, Speed: 30}) || points[4] != (FanCurvePoint{Temp: 95, Speed: 100}) {
+		t.Errorf("Unexpected points %+v", points)
+	}
+
+	if got := parseAMDODValue("FAN_TARGET_TEMPERATURE:\n95\nOD_RANGE:\nTARGET_TEMPERATURE: 25 110\n"); got != 95 {
+		t.Errorf("Expected target 95, got %v", got)
+	}
+}
+
+func TestReadAMDLimits(t *testing.T) {
+	device := t.TempDir()
+	hwmon := filepath.Join(device, "hwmon", "hwmon3")
+	files := map[string]string{
+		filepath.Join(hwmon, "power1_cap"):         "230000000\n",
+		filepath.Join(hwmon, "power1_cap_default"): "263000000\n",
+		filepath.Join(hwmon, "power1_cap_min"):     "0\n",
+		filepath.Join(hwmon, "power1_cap_max"):     "289000000\n",
+		filepath.Join(hwmon, "pwm1_enable"):        "2\n",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	l := readAMDLimits(device)
+	if l == nil {
+		t.Fatal("Expected limits")
+	}
+	if l.PowerLimit != 230 || l.DefaultPowerLimit != 263 || l.FanMode != "auto" {
+		t.Errorf("Unexpected limits %+v", l)
+	}
+	details := l.Details()
+	if details["Tuning"] != "Modified" {
+		t.Errorf("Expected reduced power limit to read as modified, got %q", details["Tuning"])
+	}
+	if details["Power Limit"] != "230 W (default 263 W)" {
+		t.Errorf("Unexpected power limit detail %q", details["Power Limit"])
+	}
+
+	if readAMDLimits(t.TempDir()) != nil {
+		t.Error("Expected nil limits for a device without sysfs attributes")
+	}
+}
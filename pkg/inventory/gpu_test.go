@@ -0,0 +1,44 @@
+package inventory
+
+import (
+	"testing"
+
+	"github.com/mscrnt/project_fire/pkg/intelgpu"
+)
+
+func TestAddIntelReadings(t *testing.T) {
+	// Windows: matched by adapter name, whatever the order
+	gpus := []GPUInfo{
+		{Vendor: "NVIDIA", Name: "NVIDIA GeForce RTX 4070", Utilization: 12},
+		{Vendor: "Intel", Name: "Intel(R) UHD Graphics 770 (Integrated)"},
+		{Vendor: "Intel", Name: "Intel(R) Arc(TM) A770 Graphics (Integrated)"},
+	}
+	addIntelReadings(gpus, []intelgpu.GPU{
+		{Name: "Intel(R) Arc(TM) A770 Graphics", Usage: 80, Clock: 2400, Power: 190, Temp: 70},
+		{Name: "Intel(R) UHD Graphics 770", Usage: 5, Clock: 300, MaxClock: 1450},
+	})
+	if g := gpus[0]; g.Utilization != 12 {
+		t.Errorf("expected the NVIDIA card to be left alone, got %+v", g)
+	}
+	if g := gpus[1]; g.Utilization != 5 || g.CoreClock != 300 || g.MaxClock != 1450 {
+		t.Errorf("unexpected iGPU %+v", g)
+	}
+	if g := gpus[2]; g.Utilization != 80 || g.PowerDraw != 190 || g.Temperature != 70 {
+		t.Errorf("unexpected Arc card %+v", g)
+	}
+
+	// Linux: one unnamed reading for the GPU lspci and sysfs both list
+	gpus = []GPUInfo{
+		{Vendor: "Intel", Name: "Alder Lake-S GT1 (Integrated)"},
+		{Vendor: "Intel", Name: "Intel UHD Graphics 770 (Integrated)", Temperature: 48},
+	}
+	addIntelReadings(gpus, []intelgpu.GPU{{Card: "card0", Usage: 35, Clock: 1200, Temp: 50}})
+	for _, g := range gpus {
+		if g.Utilization != 35 || g.CoreClock != 1200 {
+			t.Errorf("expected the reading on every Intel entry, got %+v", g)
+		}
+	}
+	if gpus[1].Temperature != 48 {
+		t.Errorf("expected the sysfs temperature to be kept, got %v", gpus[1].Temperature)
+	}
+}
@@ -0,0 +1,161 @@
+// Package inventory collects the hardware of the machine F.I.R.E. runs on:
+// the CPU, motherboard, memory modules, GPUs, storage devices and fans.
+//
+// The GUI shows it in the dashboard, bench inventory prints it, and a
+// Snapshot of it is stored with every test run so reports show exactly what
+// hardware was tested. A snapshot is stored once per distinct inventory and
+// shared by the runs tested on that hardware.
+package inventory
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/changelog"
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/session"
+	"github.com/mscrnt/project_fire/pkg/virt"
+)
+
+// Snapshot format
+const (
+	Format        = "fire-inventory"
+	SchemaVersion = 1
+)
+
+// cacheFor is how long Current reuses a snapshot. Collecting takes seconds,
+// and a scheduler or plan starts many runs on the same hardware.
+const cacheFor = 10 * time.Minute
+
+// Snapshot is the hardware of a machine at one time
+type Snapshot struct {
+	Format        string              `json:"format" yaml:"format"`
+	SchemaVersion int                 `json:"schema_version" yaml:"schema_version"`
+	TakenAt       time.Time           `json:"taken_at" yaml:"taken_at"`
+	Machine       string              `json:"machine" yaml:"machine"`
+	Model         string              `json:"model,omitempty" yaml:"model,omitempty"`
+	System        map[string]string   `json:"system,omitempty" yaml:"system,omitempty"`
+	Components    []session.Component `json:"components" yaml:"components"`
+}
+
+var (
+	cacheMu sync.Mutex
+	cached  *Snapshot
+)
+
+// Take collects the hardware of this machine
+func Take() *Snapshot {
+	return NewSnapshot(Collect())
+}
+
+// Current returns a snapshot of this machine, reusing one taken in the last
+// few minutes
+func Current() *Snapshot {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if cached == nil || time.Since(cached.TakenAt) > cacheFor {
+		cached = Take()
+	}
+	return cached
+}
+
+// NewSnapshot creates a snapshot of collected hardware, taken now
+func NewSnapshot(h *Hardware) *Snapshot {
+	s := &Snapshot{
+		Format:        Format,
+		SchemaVersion: SchemaVersion,
+		TakenAt:       time.Now().UTC(),
+		Machine:       changelog.Machine(),
+		Model:         changelog.Model(),
+		Components:    h.Components(),
+	}
+	if h.System != nil {
+		host := h.System.Host
+		s.System = map[string]string{}
+		for key, value := range map[string]string{
+			"OS":           join(host.Platform, host.PlatformVersion),
+			"Kernel":       host.KernelVersion,
+			"Architecture": host.Architecture,
+		} {
+			if value != "" {
+				s.System[key] = value
+			}
+		}
+	}
+	if env := virt.Detect(); env.Virtual() {
+		if s.System == nil {
+			s.System = map[string]string{}
+		}
+		s.System["Environment"] = env.Label()
+	}
+	return s
+}
+
+// Parse reads a snapshot written as JSON
+func Parse(data []byte) (*Snapshot, error) {
+	s := &Snapshot{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse inventory: %w", err)
+	}
+	if s.Format != Format {
+		return nil, fmt.Errorf("not a hardware inventory: format %q", s.Format)
+	}
+	return s, nil
+}
+
+// Hash identifies the hardware in a snapshot. Snapshots of the same machine
+// and hardware have the same hash whenever they were taken.
+func (s *Snapshot) Hash() string {
+	data, _ := json.Marshal(struct {
+		Machine    string              `json:"machine"`
+		Model      string              `json:"model"`
+		System     map[string]string   `json:"system"`
+		Components []session.Component `json:"components"`
+	}{s.Machine, s.Model, s.System, s.Components})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Save stores the snapshot, or finds the stored snapshot of the same
+// hardware, and returns it as stored
+func (s *Snapshot) Save(database *db.DB) (*db.InventorySnapshot, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode inventory: %w", err)
+	}
+	stored := &db.InventorySnapshot{Machine: s.Machine, Hash: s.Hash(), TakenAt: s.TakenAt, Data: string(data)}
+	if err := database.SaveInventorySnapshot(stored); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// Record stores the current snapshot of this machine as the hardware a run
+// is tested on
+func Record(database *db.DB, runID int64) error {
+	stored, err := Current().Save(database)
+	if err != nil {
+		return err
+	}
+	return database.SetRunInventory(runID, stored.ID)
+}
+
+// ForRun returns the snapshot recorded for a run, or nil when the run has
+// none
+func ForRun(database *db.DB, runID int64) (*Snapshot, error) {
+	stored, err := database.RunInventory(runID)
+	if err != nil || stored == nil {
+		return nil, err
+	}
+	return Parse([]byte(stored.Data))
+}
+
+// join joins the non-empty parts with spaces
+func join(parts ...string) string {
+	return strings.Join(strings.Fields(strings.Join(parts, " ")), " ")
+}
@@ -0,0 +1,104 @@
+package inventory
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+func testHardware() *Hardware {
+	return &Hardware{
+		System: &SystemInfo{
+			Host:   HostInfo{Platform: "ubuntu", PlatformVersion: "24.04", Architecture: "x86_64"},
+			CPU:    CPUInfo{Model: "AMD Ryzen 9 7950X", Vendor: "AuthenticAMD", PhysicalCores: 16, LogicalCores: 32},
+			Memory: MemoryInfo{TotalGB: 64},
+		},
+		Motherboard:   &MotherboardInfo{Manufacturer: "ASUSTeK", Model: "ROG STRIX X670E-E", SerialNumber: "MB123"},
+		MemoryModules: []MemoryModule{{Size: 32 << 30, Type: "DDR5", Speed: 6000, Manufacturer: "Kingston", Slot: "A2", SerialNumber: "1234"}},
+		GPUs:          []GPUInfo{{Vendor: "NVIDIA", Name: "NVIDIA GeForce RTX 4090", MemoryTotal: 24 << 30}},
+		StorageDevices: []StorageInfo{
+			{Model: "Samsung SSD 990 PRO 2TB", Type: "NVME", Mountpoint: "/", Size: 2000 << 30, Serial: "S6Z"},
+		},
+	}
+}
+
+func TestComponents(t *testing.T) {
+	h := testHardware()
+	components := h.Components()
+
+	types := make([]string, 0, len(components))
+	for _, c := range components {
+		types = append(types, c.Type)
+	}
+	if len(types) != 5 || types[0] != "CPU" || types[1] != "Motherboard" || types[2] != "Memory" || types[3] != "GPU" || types[4] != "Storage" {
+		t.Fatalf("unexpected components %v", types)
+	}
+	if got := components[1].Details["Serial"]; got != "MB123" {
+		t.Errorf("expected the board serial, got %q", got)
+	}
+	if got := components[2].Name; got != "Kingston 32 GB DDR5 @ 6000 MHz (Slot: A2)" {
+		t.Errorf("unexpected memory name %q", got)
+	}
+	if h.MemoryModules[0].DataRate != 6000 || h.MemoryModules[0].Row != 1 {
+		t.Errorf("expected the module's derived fields filled in, got %+v", h.MemoryModules[0])
+	}
+	if got := components[3].Name; got != "NVIDIA GeForce RTX 4090" {
+		t.Errorf("unexpected GPU name %q", got)
+	}
+	if got := components[4].Name; got != "Samsung SSD 990 PRO 2TB (/) - 2.0 TB" {
+		t.Errorf("unexpected storage name %q", got)
+	}
+
+	// Without modules the system memory is listed
+	h.MemoryModules = nil
+	if c := h.Components()[2]; c.Type != "Memory" || c.Name != "System Memory (64.0 GB)" {
+		t.Errorf("expected the system memory, got %+v", c)
+	}
+}
+
+func TestSnapshotSave(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = database.Close() }()
+
+	first := NewSnapshot(testHardware())
+	if first.System["OS"] != "ubuntu 24.04" || first.Format != Format {
+		t.Fatalf("unexpected snapshot %+v", first)
+	}
+	second := NewSnapshot(testHardware())
+	second.TakenAt = first.TakenAt.Add(time.Hour)
+	if first.Hash() != second.Hash() {
+		t.Error("expected the same hardware to hash the same whenever it was taken")
+	}
+
+	run, err := database.CreateRun("cpu", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := first.Save(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := second.Save(database); err != nil || again.ID != stored.ID {
+		t.Errorf("expected the same hardware stored once, got %+v, %v", again, err)
+	}
+	if err := database.SetRunInventory(run.ID, stored.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ForRun(database, run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.Hash() != first.Hash() || len(got.Components) != 5 {
+		t.Errorf("expected the snapshot back, got %+v", got)
+	}
+
+	if _, err := Parse([]byte(`{"format": "fire-report"}`)); err == nil {
+		t.Error("expected another format to be rejected")
+	}
+}
@@ -0,0 +1,50 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mscrnt/project_fire/pkg/helper"
+	"github.com/mscrnt/project_fire/pkg/logging"
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+	"github.com/mscrnt/project_fire/pkg/sensors"
+)
+
+// Helper is the connected privileged helper service, or nil when the
+// collectors have to read privileged data themselves
+var Helper *helper.Client
+
+// readDMI returns the dmidecode output of a DMI table, e.g. "memory",
+// through the privileged helper when one is connected, since dmidecode
+// needs root
+func readDMI(table string) (string, error) {
+	if Helper != nil {
+		return Helper.DMI(table)
+	}
+	output, err := safeexec.Command("dmidecode", "-t", table).Output()
+	if err != nil {
+		return "", fmt.Errorf("dmidecode failed: %w", err)
+	}
+	return string(output), nil
+}
+
+// Loggers of the collectors
+var (
+	logger     = logging.For("inventory")
+	memoryLog  = logging.For("memory")
+	spdLog     = logging.For("spd")
+	storageLog = logging.For("storage")
+	perfLog    = logging.For("perf") // How long the slow queries take
+	sensorLog  = logging.For("sensor")
+)
+
+// readSensors takes a sensor snapshot, returning an empty one when sensors
+// cannot be read
+func readSensors() *sensors.Snapshot {
+	snapshot, err := sensors.Default().Read(context.Background())
+	if err != nil {
+		sensorLog.Debug("Failed to read sensors", "error", err)
+		return &sensors.Snapshot{}
+	}
+	return snapshot
+}
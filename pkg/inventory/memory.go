@@ -1,8 +1,7 @@
-package gui
+package inventory
 
 import (
 	"fmt"
-	"os/exec"
 	"runtime"
 	"strconv"
 	"strings"
@@ -47,15 +46,15 @@ func GetMemoryModules() ([]MemoryModule, error) {
 	case "windows":
 		// Check if running as admin
 		if IsRunningAsAdmin() {
-			DebugLog("MEMORY", "Running as Administrator - enhanced memory detection available")
+			memoryLog.Debug("Running as Administrator - enhanced memory detection available")
 			// For now, skip SPD reader as WinRing0 doesn't support SMBUS
 			// We'll use enhanced WMI detection instead
-			DebugLog("MEMORY", "Using enhanced WMI detection (SPD reading requires specialized hardware access)")
+			memoryLog.Debug("Using enhanced WMI detection (SPD reading requires specialized hardware access)")
 		} else {
-			DebugLog("MEMORY", "Not running as Administrator - using basic WMI detection")
+			memoryLog.Debug("Not running as Administrator - using basic WMI detection")
 		}
 		// Fall back to WMI
-		DebugLog("MEMORY", "Using WMI for memory detection")
+		memoryLog.Debug("Using WMI for memory detection")
 		return getMemoryModulesWindows()
 	case "linux":
 		return getMemoryModulesLinux()
@@ -66,70 +65,56 @@ func GetMemoryModules() ([]MemoryModule, error) {
 	}
 }
 
+// win32PhysicalMemory holds the Win32_PhysicalMemory properties read for
+// each module
+type win32PhysicalMemory struct {
+	Capacity             uint64
+	Speed                uint32
+	ConfiguredClockSpeed uint32
+	SMBIOSMemoryType     uint32
+	FormFactor           uint32
+	Manufacturer         string
+	PartNumber           string
+	SerialNumber         string
+	DeviceLocator        string
+	BankLabel            string
+	Tag                  string // e.g. "Physical Memory 3"
+}
+
 // getMemoryModulesWindows uses WMI to get memory module information
 func getMemoryModulesWindows() ([]MemoryModule, error) {
-	var modules []MemoryModule
-
-	// Use wmic to get memory information including SMBIOSMemoryType and Tag for physical slot number
-	cmd := exec.Command("cmd", "/c", "wmic memorychip get Capacity,Speed,SMBIOSMemoryType,Manufacturer,PartNumber,SerialNumber,DeviceLocator,FormFactor,ConfiguredClockSpeed,BankLabel,Tag /format:csv")
-
-	output, err := cmd.Output()
-	if err != nil {
-		return modules, err
+	var rows []win32PhysicalMemory
+	if err := queryCIM("Win32_PhysicalMemory", &rows); err != nil {
+		return nil, err
 	}
+	return memoryModulesFromCIM(rows), nil
+}
 
-	lines := strings.Split(string(output), "\r\n")
-	var headers []string
+// memoryModulesFromCIM describes the populated slots CPU-Z style
+func memoryModulesFromCIM(rows []win32PhysicalMemory) []MemoryModule {
+	var modules []MemoryModule
 	moduleIndex := 0
 
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		fields := strings.Split(line, ",")
-
-		// First line with multiple fields is headers
-		if len(headers) == 0 && len(fields) > 1 {
-			headers = fields
-			continue
-		}
-
-		// Skip if not enough fields
-		if len(fields) < 5 {
-			continue
-		}
-
-		// Create a map for easier field access
-		fieldMap := make(map[string]string)
-		for j, header := range headers {
-			if j < len(fields) {
-				fieldMap[strings.TrimSpace(header)] = strings.TrimSpace(fields[j])
-			}
-		}
-
-		// Parse capacity
-		capacity, _ := strconv.ParseUint(fieldMap["Capacity"], 10, 64)
+	for _, row := range rows {
+		capacity := row.Capacity
 		if capacity == 0 {
 			continue // Skip empty slots
 		}
 
 		// Parse speed - prefer ConfiguredClockSpeed over Speed
-		speedStr := fieldMap["ConfiguredClockSpeed"]
-		if speedStr == "" || speedStr == "0" {
-			speedStr = fieldMap["Speed"]
+		speed := row.ConfiguredClockSpeed
+		if speed == 0 {
+			speed = row.Speed
 		}
-		speed, _ := strconv.ParseUint(speedStr, 10, 32)
 
 		// Get memory type using SMBIOSMemoryType
-		smbiosType := fieldMap["SMBIOSMemoryType"]
-		smbiosTypeInt, _ := strconv.Atoi(smbiosType)
+		smbiosType := strconv.FormatUint(uint64(row.SMBIOSMemoryType), 10)
+		smbiosTypeInt := int(row.SMBIOSMemoryType)
 		memType := getSMBIOSMemoryTypeName(smbiosType)
-		DebugLog("MEMORY", fmt.Sprintf("SMBIOSMemoryType: %s -> %s for %s", smbiosType, memType, fieldMap["DeviceLocator"]))
+		memoryLog.Debug("SMBIOS memory type", "code", smbiosType, "type", memType, "slot", row.DeviceLocator)
 
 		// Get form factor
-		formFactor := getFormFactorName(fieldMap["FormFactor"])
+		formFactor := getFormFactorName(strconv.FormatUint(uint64(row.FormFactor), 10))
 
 		// Calculate derived values
 		sizeGB := float64(capacity) / (1024 * 1024 * 1024)
@@ -152,12 +137,12 @@ func getMemoryModulesWindows() ([]MemoryModule, error) {
 		}
 
 		// Clean up manufacturer and part number
-		manufacturer := cleanManufacturerName(fieldMap["Manufacturer"])
-		partNumber := strings.TrimSpace(fieldMap["PartNumber"])
-		serialNumber := strings.TrimSpace(fieldMap["SerialNumber"])
-		slot := strings.TrimSpace(fieldMap["DeviceLocator"])
-		bankLabel := strings.TrimSpace(fieldMap["BankLabel"])
-		tag := strings.TrimSpace(fieldMap["Tag"])
+		manufacturer := cleanManufacturerName(row.Manufacturer)
+		partNumber := strings.TrimSpace(row.PartNumber)
+		serialNumber := strings.TrimSpace(row.SerialNumber)
+		slot := strings.TrimSpace(row.DeviceLocator)
+		bankLabel := strings.TrimSpace(row.BankLabel)
+		tag := strings.TrimSpace(row.Tag)
 
 		moduleIndex++
 
@@ -175,8 +160,7 @@ func getMemoryModulesWindows() ([]MemoryModule, error) {
 		}
 
 		// Debug logging
-		DebugLog("MEMORY", fmt.Sprintf("Module %d: Tag=%q, DeviceLocator=%q, BankLabel=%q, physicalSlot=%d",
-			moduleIndex, tag, slot, bankLabel, physicalSlot))
+		memoryLog.Debug("Module slot", "module", moduleIndex, "tag", tag, "device_locator", slot, "bank_label", bankLabel, "physical_slot", physicalSlot)
 
 		// Create a better slot display value
 		displaySlot := slot
@@ -188,7 +172,7 @@ func getMemoryModulesWindows() ([]MemoryModule, error) {
 			displaySlot = bankLabel
 		}
 
-		DebugLog("MEMORY", fmt.Sprintf("Module %d: Final displaySlot=%q", moduleIndex, displaySlot))
+		memoryLog.Debug("Module display slot", "module", moduleIndex, "slot", displaySlot)
 
 		module := MemoryModule{
 			Row:              moduleIndex,
@@ -197,14 +181,14 @@ func getMemoryModulesWindows() ([]MemoryModule, error) {
 			Number:           fmt.Sprintf("%d", moduleIndex),
 			Size:             capacity,
 			SizeGB:           sizeGB,
-			Speed:            uint32(speed),
+			Speed:            speed,
 			Type:             memType,
 			FormFactor:       formFactor,
 			BaseFrequency:    baseFreq,
 			DataRate:         dataRate,
 			PCRating:         pcRating,
 			Manufacturer:     manufacturer,
-			ChipManufacturer: getChipManufacturer(manufacturer, partNumber),
+			ChipManufacturer: ChipManufacturer(manufacturer, partNumber),
 			PartNumber:       partNumber,
 			SerialNumber:     serialNumber,
 			SMBIOSType:       smbiosTypeInt,
@@ -222,184 +206,117 @@ func getMemoryModulesWindows() ([]MemoryModule, error) {
 		modules = append(modules, module)
 	}
 
-	return modules, nil
+	return modules
 }
 
-// getMemoryModulesLinux uses dmidecode or /sys to get memory information
+// getMemoryModulesLinux uses dmidecode to get memory information
 func getMemoryModulesLinux() ([]MemoryModule, error) {
-	// For WSL, try to get Windows memory info
+	// For WSL, read the Windows host's modules
 	if isWSL() {
-		return getMemoryModulesWSL()
+		return getMemoryModulesWindows()
 	}
 
-	// Regular Linux - would need sudo for dmidecode
-	return []MemoryModule{}, nil
-}
-
-// getMemoryModulesWSL gets memory info from Windows host
-func getMemoryModulesWSL() ([]MemoryModule, error) {
-	// Try to run Windows wmic command from WSL
-	cmd := exec.Command("cmd.exe", "/c", "wmic memorychip get Capacity,Speed,SMBIOSMemoryType,Manufacturer,PartNumber,SerialNumber,DeviceLocator,FormFactor,ConfiguredClockSpeed,BankLabel /format:csv")
-
-	output, err := cmd.Output()
+	// dmidecode needs root or the privileged helper
+	output, err := readDMI("memory")
 	if err != nil {
-		return []MemoryModule{}, err
+		memoryLog.Debug("Memory modules not read", "error", err)
+		return []MemoryModule{}, nil
 	}
-
-	// Parse the same way as Windows
-	return parseWMICMemoryOutput(string(output))
+	return memoryModulesFromCIM(parseDMIMemory(output)), nil
 }
 
-// parseWMICMemoryOutput parses WMIC CSV output
-func parseWMICMemoryOutput(output string) ([]MemoryModule, error) {
-	var modules []MemoryModule
-
-	lines := strings.Split(output, "\n")
-	var headers []string
-	moduleIndex := 0
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		line = strings.Trim(line, "\r")
-		if line == "" {
+// parseDMIMemory reads the Memory Device entries of dmidecode -t memory
+// into the properties Win32_PhysicalMemory has for them
+func parseDMIMemory(output string) []win32PhysicalMemory {
+	var rows []win32PhysicalMemory
+	var row *win32PhysicalMemory
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "Memory Device" {
+			rows = append(rows, win32PhysicalMemory{})
+			row = &rows[len(rows)-1]
 			continue
 		}
-
-		fields := strings.Split(line, ",")
-
-		// First line with multiple fields is headers
-		if len(headers) == 0 && len(fields) > 1 && strings.Contains(line, "Capacity") {
-			headers = fields
+		if row == nil || !strings.HasPrefix(line, "\t") {
+			if strings.TrimSpace(line) != "" && !strings.HasPrefix(line, "\t") {
+				row = nil // Another DMI structure
+			}
 			continue
 		}
-
-		// Skip if not a data line
-		if len(fields) < 5 || strings.Contains(line, "Node") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
 			continue
 		}
-
-		// Create a map for easier field access
-		fieldMap := make(map[string]string)
-		for j, header := range headers {
-			if j < len(fields) {
-				fieldMap[strings.TrimSpace(header)] = strings.TrimSpace(fields[j])
-			}
-		}
-
-		// Parse capacity
-		capacity, _ := strconv.ParseUint(fieldMap["Capacity"], 10, 64)
-		if capacity == 0 {
-			continue // Skip empty slots
-		}
-
-		// Parse speed
-		speedStr := fieldMap["ConfiguredClockSpeed"]
-		if speedStr == "" || speedStr == "0" {
-			speedStr = fieldMap["Speed"]
-		}
-		speed, _ := strconv.ParseUint(speedStr, 10, 32)
-
-		// Get memory type using SMBIOSMemoryType
-		smbiosType := fieldMap["SMBIOSMemoryType"]
-		smbiosTypeInt, _ := strconv.Atoi(smbiosType)
-		memType := getSMBIOSMemoryTypeName(smbiosType)
-		DebugLog("MEMORY", fmt.Sprintf("SMBIOSMemoryType: %s -> %s for %s", smbiosType, memType, fieldMap["DeviceLocator"]))
-
-		// Get form factor
-		formFactor := getFormFactorName(fieldMap["FormFactor"])
-
-		// Calculate derived values
-		sizeGB := float64(capacity) / (1024 * 1024 * 1024)
-		baseFreq := float64(speed) / 2.0 // DDR = Double Data Rate
-		dataRate := int(speed)
-
-		// Calculate PC rating based on memory type
-		var pcRating int
-		var pcPrefix string
-		switch {
-		case strings.Contains(memType, "DDR5"):
-			pcPrefix = "PC5"
-			pcRating = dataRate * 8 // DDR5: MT/s * 8
-		case strings.Contains(memType, "DDR4"):
-			pcPrefix = "PC4"
-			pcRating = dataRate * 8 // DDR4: MT/s * 8
-		case strings.Contains(memType, "DDR3"):
-			pcPrefix = "PC3"
-			pcRating = dataRate * 8 // DDR3: MT/s * 8
-		}
-
-		// Clean up manufacturer and part number
-		manufacturer := cleanManufacturerName(fieldMap["Manufacturer"])
-		partNumber := strings.TrimSpace(fieldMap["PartNumber"])
-		serialNumber := strings.TrimSpace(fieldMap["SerialNumber"])
-		slot := strings.TrimSpace(fieldMap["DeviceLocator"])
-		bankLabel := strings.TrimSpace(fieldMap["BankLabel"])
-		tag := strings.TrimSpace(fieldMap["Tag"])
-
-		moduleIndex++
-
-		// Extract physical slot number from Tag field (e.g., "Physical Memory 3" -> 3)
-		var physicalSlot int
-		if tag != "" {
-			// Try to extract the number from the tag
-			parts := strings.Fields(tag)
-			if len(parts) > 0 {
-				// Get the last part which should be the number
-				if num, err := strconv.Atoi(parts[len(parts)-1]); err == nil {
-					physicalSlot = num
-				}
-			}
-		}
-
-		// Debug logging
-		DebugLog("MEMORY", fmt.Sprintf("Module %d: Tag=%q, DeviceLocator=%q, BankLabel=%q, physicalSlot=%d",
-			moduleIndex, tag, slot, bankLabel, physicalSlot))
-
-		// Create a better slot display value
-		displaySlot := slot
-		if physicalSlot > 0 {
-			// Use the physical slot number from Tag
-			displaySlot = fmt.Sprintf("DIMM %d", physicalSlot)
-		} else if bankLabel != "" && (slot == "DIMM 1" || slot == "DIMM 0" || slot == "") {
-			// Fallback: use bank label when slot is generic or empty
-			displaySlot = bankLabel
+		value = strings.TrimSpace(value)
+		switch key {
+		case "Size":
+			row.Capacity = parseDMISize(value)
+		case "Speed":
+			row.Speed = parseDMISpeed(value)
+		case "Configured Memory Speed", "Configured Clock Speed":
+			row.ConfiguredClockSpeed = parseDMISpeed(value)
+		case "Type":
+			row.SMBIOSMemoryType = dmiMemoryTypes[value]
+		case "Form Factor":
+			row.FormFactor = dmiFormFactors[value]
+		case "Manufacturer":
+			row.Manufacturer = value
+		case "Part Number":
+			row.PartNumber = value
+		case "Serial Number":
+			row.SerialNumber = value
+		case "Locator":
+			row.DeviceLocator = value
+		case "Bank Locator":
+			row.BankLabel = value
 		}
+	}
+	return rows
+}
 
-		DebugLog("MEMORY", fmt.Sprintf("Module %d: Final displaySlot=%q", moduleIndex, displaySlot))
-
-		module := MemoryModule{
-			Row:              moduleIndex,
-			Slot:             displaySlot,
-			BankLabel:        bankLabel,
-			Number:           fmt.Sprintf("%d", moduleIndex),
-			Size:             capacity,
-			SizeGB:           sizeGB,
-			Speed:            uint32(speed),
-			Type:             memType,
-			FormFactor:       formFactor,
-			BaseFrequency:    baseFreq,
-			DataRate:         dataRate,
-			PCRating:         pcRating,
-			Manufacturer:     manufacturer,
-			ChipManufacturer: getChipManufacturer(manufacturer, partNumber),
-			PartNumber:       partNumber,
-			SerialNumber:     serialNumber,
-			SMBIOSType:       smbiosTypeInt,
-		}
+// dmiFormFactors maps dmidecode's form factors to their SMBIOS codes
+var dmiFormFactors = map[string]uint32{"DIMM": 8, "SODIMM": 12, "SRIMM": 13}
 
-		// Build the full name string CPU-Z style
-		if pcRating > 0 {
-			module.Name = fmt.Sprintf("Row %d [%s] – %.0f GB %s-%d %s %s %s",
-				module.Row, displaySlot, sizeGB, pcPrefix, pcRating, memType, manufacturer, partNumber)
-		} else {
-			module.Name = fmt.Sprintf("Row %d [%s] – %.0f GB %s %s %s",
-				module.Row, displaySlot, sizeGB, memType, manufacturer, partNumber)
-		}
+// parseDMISize returns the bytes of a dmidecode size such as "16 GB", or 0
+// for "No Module Installed"
+func parseDMISize(value string) uint64 {
+	fields := strings.Fields(value)
+	if len(fields) != 2 {
+		return 0
+	}
+	n, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return 0
+	}
+	switch fields[1] {
+	case "kB":
+		return n << 10
+	case "MB":
+		return n << 20
+	case "GB":
+		return n << 30
+	case "TB":
+		return n << 40
+	}
+	return 0
+}
 
-		modules = append(modules, module)
+// parseDMISpeed returns the MT/s of a dmidecode speed such as "4800 MT/s"
+// or, from older versions, "4800 MHz"
+func parseDMISpeed(value string) uint32 {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return 0
 	}
+	n, _ := strconv.ParseUint(fields[0], 10, 32)
+	return uint32(n)
+}
 
-	return modules, nil
+// dmiMemoryTypes maps dmidecode's memory types to the SMBIOS codes
+// getSMBIOSMemoryTypeName gives the same names for
+var dmiMemoryTypes = map[string]uint32{
+	"DDR": 20, "DDR2": 21, "DDR3": 24, "DDR4": 26, "DDR5": 34,
+	"LPDDR": 28, "LPDDR2": 29, "LPDDR3": 30, "LPDDR4": 31, "LPDDR5": 36,
+	"HBM3": 35,
 }
 
 // getMemoryModulesDarwin gets memory info on macOS
@@ -540,8 +457,8 @@ func getFormFactorName(formFactorCode string) string {
 	}
 }
 
-// getChipManufacturer attempts to determine the chip manufacturer from module info
-func getChipManufacturer(moduleManufacturer, partNumber string) string {
+// ChipManufacturer attempts to determine the chip manufacturer from module info
+func ChipManufacturer(moduleManufacturer, partNumber string) string {
 	// Common chip manufacturers based on part numbers and module vendors
 	partLower := strings.ToLower(partNumber)
 
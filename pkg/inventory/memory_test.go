@@ -0,0 +1,65 @@
+package inventory
+
+import (
+	"strings"
+	"testing"
+)
+
+// dmidecodeMemory is dmidecode -t memory output with a populated slot and
+// an empty one
+const dmidecodeMemory = `# dmidecode 3.5
+Getting SMBIOS data from sysfs.
+SMBIOS 3.5.0 present.
+
+Handle 0x0010, DMI type 16, 23 bytes
+Physical Memory Array
+	Location: System Board Or Motherboard
+	Maximum Capacity: 128 GB
+	Number Of Devices: 2
+
+Handle 0x0012, DMI type 17, 100 bytes
+Memory Device
+	Array Handle: 0x0010
+	Size: 32 GB
+	Form Factor: DIMM
+	Locator: DIMM_A1
+	Bank Locator: BANK 0
+	Type: DDR5
+	Speed: 6400 MT/s
+	Manufacturer: Kingston
+	Serial Number: 1A2B3C4D
+	Part Number: KF564C32-32
+	Configured Memory Speed: 6000 MT/s
+
+Handle 0x0013, DMI type 17, 100 bytes
+Memory Device
+	Array Handle: 0x0010
+	Size: No Module Installed
+	Form Factor: DIMM
+	Locator: DIMM_A2
+	Bank Locator: BANK 1
+	Type: Unknown
+	Speed: Unknown
+`
+
+func TestParseDMIMemory(t *testing.T) {
+	rows := parseDMIMemory(dmidecodeMemory)
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 memory devices, got %d", len(rows))
+	}
+
+	modules := memoryModulesFromCIM(rows)
+	if len(modules) != 1 {
+		t.Fatalf("expected the empty slot to be skipped, got %d modules", len(modules))
+	}
+	m := modules[0]
+	if m.Size != 32<<30 || m.Speed != 6000 || m.PCRating != 48000 || m.FormFactor != "DIMM" {
+		t.Errorf("unexpected size or speed %+v", m)
+	}
+	if m.Slot != "DIMM_A1" || m.BankLabel != "BANK 0" || m.PartNumber != "KF564C32-32" || m.SerialNumber != "1A2B3C4D" {
+		t.Errorf("unexpected slot or part number %+v", m)
+	}
+	if !strings.Contains(m.Type, "DDR5") {
+		t.Errorf("expected a DDR5 module, got %q", m.Type)
+	}
+}
@@ -1,11 +1,18 @@
-package gui
+package inventory
 
 import (
+	"errors"
 	"fmt"
-	"os/exec"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/intrusion"
+	"github.com/mscrnt/project_fire/pkg/pcie"
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+	"github.com/mscrnt/project_fire/pkg/smbios"
+	"github.com/mscrnt/project_fire/pkg/tpm"
 )
 
 // MotherboardInfo contains motherboard information
@@ -17,6 +24,16 @@ type MotherboardInfo struct {
 	BIOS         BIOSInfo
 	Features     MotherboardFeatures
 	ChipsetInfo  ChipsetInfo
+
+	// Filled from the SMBIOS tables when they can be read
+	ChassisType     string
+	AssetTag        string // Baseboard asset tag
+	ChassisAssetTag string
+	Slots           []smbios.Slot
+	SlotMap         []pcie.Population // PCIe and M.2 slots with the devices fitted in them
+
+	TPM       *tpm.Info          // nil when the machine has no TPM
+	Intrusion []intrusion.Sensor // Chassis intrusion alarms, kept current by the dashboard
 }
 
 // MotherboardFeatures contains motherboard feature information
@@ -43,10 +60,42 @@ type BIOSInfo struct {
 	ReleaseDate string
 }
 
-// GetMotherboardInfo retrieves motherboard information
+// GetMotherboardInfo retrieves motherboard information, from the SMBIOS
+// tables when they can be read and from the platform tools otherwise, along
+// with the TPM
 func GetMotherboardInfo() (*MotherboardInfo, error) {
+	info, err := getMotherboardInfo()
+	if info != nil {
+		info.TPM = readTPM()
+	}
+	return info, err
+}
+
+// readTPM reads the TPM details, or nil when the machine has none
+func readTPM() *tpm.Info {
+	t, err := tpm.Read()
+	if err != nil {
+		if !errors.Is(err, tpm.ErrNotFound) {
+			logger.Warn("Failed to read TPM", "error", err)
+		}
+		return nil
+	}
+	return t
+}
+
+func getMotherboardInfo() (*MotherboardInfo, error) {
 	info := &MotherboardInfo{}
 
+	table, err := smbios.Read()
+	if err != nil && !errors.Is(err, smbios.ErrUnavailable) {
+		logger.Warn("Failed to read SMBIOS tables", "error", err)
+	}
+	if table != nil {
+		if info := motherboardFromSMBIOS(table.Decode()); info.Model != "" {
+			return info, nil
+		}
+	}
+
 	switch runtime.GOOS {
 	case "windows":
 		return getMotherboardInfoWindows()
@@ -59,12 +108,121 @@ func GetMotherboardInfo() (*MotherboardInfo, error) {
 	}
 }
 
+// motherboardFromSMBIOS builds the motherboard details from the decoded
+// SMBIOS inventory. Boards that leave the baseboard strings empty, as some
+// laptops do, are described by the system structure instead.
+func motherboardFromSMBIOS(inv *smbios.Info) *MotherboardInfo {
+	info := &MotherboardInfo{
+		Manufacturer:    inv.Baseboard.Manufacturer,
+		Model:           inv.Baseboard.Product,
+		Version:         inv.Baseboard.Version,
+		SerialNumber:    inv.Baseboard.SerialNumber,
+		ChassisType:     inv.Chassis.Type,
+		AssetTag:        inv.Baseboard.AssetTag,
+		ChassisAssetTag: inv.Chassis.AssetTag,
+		Slots:           inv.Slots,
+		BIOS: BIOSInfo{
+			Vendor:      inv.BIOS.Vendor,
+			Version:     inv.BIOS.Version,
+			ReleaseDate: inv.BIOS.ReleaseDate,
+		},
+		Features: MotherboardFeatures{
+			MemorySlots: inv.MemorySlots(),
+			MaxMemory:   inv.MaxMemory(),
+			SATAPorts:   inv.CountPorts("SATA"),
+			USBPorts:    inv.USBPortCounts(),
+		},
+		ChipsetInfo: GetChipsetInfo(),
+	}
+	if info.Model == "" {
+		info.Manufacturer = inv.System.Manufacturer
+		info.Model = inv.System.Product
+		info.Version = inv.System.Version
+		info.SerialNumber = inv.System.SerialNumber
+	}
+	for _, slot := range inv.Slots {
+		switch {
+		case slot.PCIe:
+			info.Features.PCIeSlots++
+		case slot.M2:
+			info.Features.M2Slots++
+		}
+	}
+
+	if info.Features.PCIeSlots+info.Features.M2Slots > 0 {
+		devices, err := pcie.Devices()
+		if err != nil {
+			logger.Debug("Slots listed without their devices", "error", err)
+		}
+		info.SlotMap = pcie.Map(inv.Slots, devices)
+		for _, p := range info.SlotMap {
+			if p.Warning != "" {
+				logger.Warn("PCIe slot warning", "slot", p.Slot.Designation, "warning", p.Warning)
+			}
+		}
+	}
+	return info
+}
+
+// DescribeSlot summarises a slot for the motherboard details, e.g.
+// "PCI Express Gen 4 x16 (wired x4): NVIDIA ... (x4)". Slots holding a card
+// that runs on fewer lanes than it supports are marked with a warning sign.
+func DescribeSlot(p pcie.Population) string {
+	desc := p.Slot.Type
+	if p.Wired > 0 && p.Connector > p.Wired {
+		desc += fmt.Sprintf(" (wired x%d)", p.Wired)
+	}
+	if occupant := p.Occupant(); occupant != "" {
+		desc += ": " + occupant
+	} else if p.Slot.Usage != "" {
+		desc += ", " + p.Slot.Usage
+	}
+	if p.Warning != "" {
+		desc = "⚠ " + desc
+	}
+	return desc
+}
+
+// DescribeIntrusion summarises the chassis intrusion alarms, e.g. "Cleared"
+// for a single switch, or one line per switch when there are several
+func DescribeIntrusion(found []intrusion.Sensor) string {
+	if len(found) == 1 {
+		return intrusionStatus(found[0])
+	}
+	lines := make([]string, 0, len(found))
+	for _, s := range found {
+		lines = append(lines, fmt.Sprintf("%s: %s", s.Name, intrusionStatus(s)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func intrusionStatus(s intrusion.Sensor) string {
+	if s.Triggered {
+		return "⚠ " + s.Status()
+	}
+	return s.Status()
+}
+
+// FormatUSBPorts lists the USB port counts by connector, e.g. "6 Type-A, 2 Type-C"
+func FormatUSBPorts(ports map[string]int) string {
+	types := make([]string, 0, len(ports))
+	for typ := range ports {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	parts := make([]string, 0, len(types))
+	for _, typ := range types {
+		parts = append(parts, fmt.Sprintf("%d %s", ports[typ], typ))
+	}
+	return strings.Join(parts, ", ")
+}
+
 // getMotherboardInfoWindows gets motherboard info on Windows
 func getMotherboardInfoWindows() (*MotherboardInfo, error) {
 	info := &MotherboardInfo{}
 
 	// Get motherboard info
-	cmd := exec.Command("cmd", "/c", "wmic baseboard get manufacturer,product,version,serialnumber /value")
+	cmd := safeexec.Shell("wmic baseboard get manufacturer,product,version,serialnumber /value")
 	output, err := cmd.Output()
 	if err == nil {
 		lines := strings.Split(string(output), "\n")
@@ -88,7 +246,7 @@ func getMotherboardInfoWindows() (*MotherboardInfo, error) {
 	info.ChipsetInfo = GetChipsetInfo()
 
 	// Get BIOS info
-	cmd = exec.Command("cmd", "/c", "wmic bios get manufacturer,version,releasedate /value")
+	cmd = safeexec.Shell("wmic bios get manufacturer,version,releasedate /value")
 	output, err = cmd.Output()
 	if err == nil {
 		lines := strings.Split(string(output), "\n")
@@ -139,9 +297,8 @@ func getMotherboardInfoLinux() (*MotherboardInfo, error) {
 
 	// If DMI is not available, try dmidecode
 	if info.Model == "" {
-		cmd := exec.Command("dmidecode", "-t", "baseboard")
-		if output, err := cmd.Output(); err == nil {
-			lines := strings.Split(string(output), "\n")
+		if output, err := readDMI("baseboard"); err == nil {
+			lines := strings.Split(output, "\n")
 			for _, line := range lines {
 				line = strings.TrimSpace(line)
 				switch {
@@ -164,7 +321,7 @@ func getMotherboardInfoDarwin() (*MotherboardInfo, error) {
 	info := &MotherboardInfo{}
 
 	// Use system_profiler for hardware info
-	cmd := exec.Command("system_profiler", "SPHardwareDataType")
+	cmd := safeexec.Command("system_profiler", "SPHardwareDataType")
 	if output, err := cmd.Output(); err == nil {
 		lines := strings.Split(string(output), "\n")
 		for _, line := range lines {
@@ -186,7 +343,7 @@ func getMotherboardInfoDarwin() (*MotherboardInfo, error) {
 
 // readFile is a helper to read file contents
 func readFile(path string) ([]byte, error) {
-	return exec.Command("cat", path).Output()
+	return safeexec.Command("cat", path).Output()
 }
 
 // FormatBIOSDate formats a BIOS date string to a more readable format
@@ -241,12 +398,7 @@ func GetMotherboardFeatures() MotherboardFeatures {
 
 	if runtime.GOOS == "windows" || isWSL() {
 		// Get memory slot information
-		var cmd *exec.Cmd
-		if runtime.GOOS == "windows" {
-			cmd = exec.Command("cmd", "/c", "wmic memorychip get DeviceLocator /value | find /c \"DIMM\"")
-		} else {
-			cmd = exec.Command("cmd.exe", "/c", "wmic memorychip get DeviceLocator /value | find /c \"DIMM\"")
-		}
+		cmd := safeexec.Shell("wmic memorychip get DeviceLocator /value | find /c \"DIMM\"")
 
 		if output, err := cmd.Output(); err == nil {
 			if count, err := strconv.Atoi(strings.TrimSpace(string(output))); err == nil {
@@ -255,11 +407,7 @@ func GetMotherboardFeatures() MotherboardFeatures {
 		}
 
 		// Get system info for max memory
-		if runtime.GOOS == "windows" {
-			cmd = exec.Command("cmd", "/c", "wmic computersystem get TotalPhysicalMemory,MaxCapacity /value")
-		} else {
-			cmd = exec.Command("cmd.exe", "/c", "wmic computersystem get TotalPhysicalMemory,MaxCapacity /value")
-		}
+		cmd = safeexec.Shell("wmic computersystem get TotalPhysicalMemory,MaxCapacity /value")
 
 		if output, err := cmd.Output(); err == nil {
 			lines := strings.Split(string(output), "\n")
@@ -284,12 +432,7 @@ func GetChipsetInfo() ChipsetInfo {
 
 	if runtime.GOOS == "windows" || isWSL() {
 		// Try to get chipset info from system devices
-		var cmd *exec.Cmd
-		if runtime.GOOS == "windows" {
-			cmd = exec.Command("cmd", "/c", "wmic path Win32_IDEController get Name /value")
-		} else {
-			cmd = exec.Command("cmd.exe", "/c", "wmic path Win32_IDEController get Name /value")
-		}
+		cmd := safeexec.Shell("wmic path Win32_IDEController get Name /value")
 
 		if output, err := cmd.Output(); err == nil {
 			outputStr := string(output)
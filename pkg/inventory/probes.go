@@ -0,0 +1,190 @@
+package inventory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/probe"
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// Names of the hardware queries run through the probe pool
+const (
+	ProbeSystem       = "inventory.system"
+	ProbeMotherboard  = "inventory.motherboard"
+	ProbeMemory       = "inventory.memory"
+	ProbeGPUInventory = "inventory.gpu_inventory" // Adapters with their limits
+	ProbeGPUs         = "inventory.gpus"          // Live GPU readings
+	ProbeFans         = "inventory.fans"
+	ProbeStorage      = "inventory.storage"
+	ProbeDriveModels  = "inventory.drive_models"
+	ProbeStorageUsage = "inventory.storage_usage" // Storage devices with their used space
+)
+
+// StaticProbes are the probes of hardware that only changes when a device
+// is added or removed, read once and again on refresh
+var StaticProbes = []string{
+	ProbeDriveModels, ProbeSystem, ProbeMotherboard, ProbeMemory, ProbeGPUInventory, ProbeFans, ProbeStorage,
+}
+
+func init() {
+	static := func(name string, timeout time.Duration, fn func() (interface{}, error)) {
+		probe.Register(probe.Probe{
+			Name:    name,
+			Timeout: timeout,
+			Run:     func(context.Context) (interface{}, error) { return fn() },
+		})
+	}
+	static(ProbeSystem, 0, func() (interface{}, error) { return GetSystemInfo() })
+	static(ProbeMotherboard, 0, func() (interface{}, error) { return GetMotherboardInfo() })
+	static(ProbeMemory, 0, func() (interface{}, error) { return GetMemoryModules() })
+	static(ProbeGPUInventory, 0, func() (interface{}, error) { return GetGPUInventory() })
+	static(ProbeFans, 0, func() (interface{}, error) { return GetFanInfo() })
+	// Storage goes through PowerShell per drive on Windows
+	static(ProbeStorage, 2*time.Minute, func() (interface{}, error) { return GetStorageInfo() })
+	static(ProbeDriveModels, time.Minute, func() (interface{}, error) { return readDriveModels(), nil })
+
+	probe.Register(probe.Probe{
+		Name:    ProbeStorageUsage,
+		TTL:     30 * time.Second,
+		Timeout: 10 * time.Second,
+		Run: func(ctx context.Context) (interface{}, error) {
+			devices, err := CachedStorageInfo(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return withUsage(devices), nil
+		},
+	})
+	probe.Register(probe.Probe{
+		Name:    ProbeGPUs,
+		TTL:     time.Second,
+		Timeout: 5 * time.Second,
+		Run:     func(context.Context) (interface{}, error) { return GetGPUInfo() },
+	})
+}
+
+// The Cached functions return a probe's shared result, reading it on first
+// use. Callers must copy a result before modifying it.
+
+// CachedSystemInfo returns the system information
+func CachedSystemInfo(ctx context.Context) (*SystemInfo, error) {
+	v, err := probe.Get(ctx, ProbeSystem)
+	if err != nil {
+		return nil, err
+	}
+	return v.(*SystemInfo), nil
+}
+
+// CachedMotherboardInfo returns the motherboard information
+func CachedMotherboardInfo(ctx context.Context) (*MotherboardInfo, error) {
+	v, err := probe.Get(ctx, ProbeMotherboard)
+	if err != nil {
+		return nil, err
+	}
+	return v.(*MotherboardInfo), nil
+}
+
+// CachedMemoryModules returns the memory modules
+func CachedMemoryModules(ctx context.Context) ([]MemoryModule, error) {
+	v, err := probe.Get(ctx, ProbeMemory)
+	if err != nil {
+		return nil, err
+	}
+	return v.([]MemoryModule), nil
+}
+
+// CachedGPUInventory returns the GPUs with their limits
+func CachedGPUInventory(ctx context.Context) ([]GPUInfo, error) {
+	v, err := probe.Get(ctx, ProbeGPUInventory)
+	if err != nil {
+		return nil, err
+	}
+	return v.([]GPUInfo), nil
+}
+
+// CachedGPUInfo returns the GPUs' live readings, at most a second old once
+// the first reading is in
+func CachedGPUInfo(ctx context.Context) ([]GPUInfo, error) {
+	v, err := probe.Get(ctx, ProbeGPUs)
+	if err != nil {
+		return nil, err
+	}
+	return v.([]GPUInfo), nil
+}
+
+// CachedFanInfo returns the fans
+func CachedFanInfo(ctx context.Context) ([]FanInfo, error) {
+	v, err := probe.Get(ctx, ProbeFans)
+	if err != nil {
+		return nil, err
+	}
+	return v.([]FanInfo), nil
+}
+
+// CachedStorageInfo returns the storage devices
+func CachedStorageInfo(ctx context.Context) ([]StorageInfo, error) {
+	v, err := probe.Get(ctx, ProbeStorage)
+	if err != nil {
+		return nil, err
+	}
+	return v.([]StorageInfo), nil
+}
+
+// PeekGPUInfo returns the GPUs' live readings without waiting, false until
+// the first reading is in
+func PeekGPUInfo() ([]GPUInfo, bool) {
+	v, ok := probe.Peek(ProbeGPUs)
+	if !ok {
+		return nil, false
+	}
+	return v.([]GPUInfo), true
+}
+
+// PeekStorageUsage returns the storage devices with their used and free
+// space, at most 30 s old, without waiting; false until the devices are in
+func PeekStorageUsage() ([]StorageInfo, bool) {
+	v, ok := probe.Peek(ProbeStorageUsage)
+	if !ok {
+		return nil, false
+	}
+	return v.([]StorageInfo), true
+}
+
+// withUsage returns a copy of the devices with their used and free space
+// read again, which only needs the mount point and is fast
+func withUsage(devices []StorageInfo) []StorageInfo {
+	updated := make([]StorageInfo, len(devices))
+	copy(updated, devices)
+	for i := range updated {
+		if usage, err := disk.Usage(updated[i].Mountpoint); err == nil {
+			updated[i].Used = usage.Used
+			updated[i].Free = usage.Free
+			updated[i].UsedPercent = usage.UsedPercent
+		}
+	}
+	return updated
+}
+
+// PrefetchStatic starts reading every static probe in the background
+func PrefetchStatic() {
+	probe.Prefetch(StaticProbes...)
+}
+
+// RefreshStatic reads every static probe again, as after a device was added
+// or removed, and waits for them. The drive models are read first, as the
+// storage probe uses them.
+func RefreshStatic(ctx context.Context) {
+	_, _ = probe.Refresh(ctx, ProbeDriveModels)
+	var wg sync.WaitGroup
+	for _, name := range StaticProbes[1:] {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			_, _ = probe.Refresh(ctx, name)
+		}(name)
+	}
+	wg.Wait()
+	probe.Invalidate(ProbeStorageUsage)
+}
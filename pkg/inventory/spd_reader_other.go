@@ -1,7 +1,7 @@
 //go:build !windows
 // +build !windows
 
-package gui
+package inventory
 
 import "fmt"
 
@@ -1,7 +1,7 @@
 //go:build windows
 // +build windows
 
-package gui
+package inventory
 
 import (
 	"encoding/binary"
@@ -106,7 +106,7 @@ func NewSPDReader() *SPDReader {
 		if err := dll.Load(); err != nil {
 			dll = syscall.NewLazyDLL("OlsApi64.dll")
 			if err := dll.Load(); err != nil {
-				DebugLog("SPD", fmt.Sprintf("WinRing0 DLL not found: %v", err))
+				spdLog.Debug("WinRing0 DLL not found", "error", err)
 			}
 		}
 	}
@@ -145,7 +145,7 @@ func (r *SPDReader) Initialize() error {
 
 	// Check other procedures
 	if err := r.procGetAdapterCount.Find(); err != nil {
-		DebugLog("SPD", fmt.Sprintf("Warning: GetSmbusAdapterCount not found: %v", err))
+		spdLog.Debug("Warning: GetSmbusAdapterCount not found", "error", err)
 	}
 
 	ret, _, err := r.procInitialize.Call()
@@ -167,10 +167,10 @@ func (r *SPDReader) Close() {
 
 // ReadAllSPD reads SPD data from all memory modules
 func (r *SPDReader) ReadAllSPD() ([]SPDData, error) {
-	DebugLog("SPD", "Entering ReadAllSPD")
+	spdLog.Debug("Entering ReadAllSPD")
 
 	if !r.initialized {
-		DebugLog("SPD", "Not initialized, initializing now")
+		spdLog.Debug("Not initialized, initializing now")
 		if err := r.Initialize(); err != nil {
 			return nil, err
 		}
@@ -178,18 +178,18 @@ func (r *SPDReader) ReadAllSPD() ([]SPDData, error) {
 
 	var results []SPDData
 
-	DebugLog("SPD", "Getting adapter count...")
+	spdLog.Debug("Getting adapter count...")
 
 	// Get adapter count
 	var count uint32
 	ret, _, err := r.procGetAdapterCount.Call(uintptr(unsafe.Pointer(&count)))
-	DebugLog("SPD", fmt.Sprintf("GetAdapterCount returned: ret=%d, err=%v", ret, err))
+	spdLog.Debug("GetAdapterCount returned", "ret", ret, "error", err)
 
 	if ret == 0 {
 		return nil, fmt.Errorf("failed to get adapter count: %v", err)
 	}
 
-	DebugLog("SPD", fmt.Sprintf("Found %d SMBUS adapters", count))
+	spdLog.Debug("Found SMBUS adapters", "count", count)
 
 	// For each adapter
 	for i := uint32(0); i < count; i++ {
@@ -199,12 +199,11 @@ func (r *SPDReader) ReadAllSPD() ([]SPDData, error) {
 			uintptr(unsafe.Pointer(&info)),
 		)
 		if ret == 0 {
-			DebugLog("SPD", fmt.Sprintf("Failed to get info for adapter %d", i))
+			spdLog.Debug("Failed to get adapter info", "adapter", i)
 			continue
 		}
 
-		DebugLog("SPD", fmt.Sprintf("Adapter %d: BasePort=0x%X, VendorID=0x%X, DeviceID=0x%X",
-			i, info.BasePort, info.VendorID, info.DeviceID))
+		spdLog.Debug("SMBUS adapter", "adapter", i, "base_port", fmt.Sprintf("0x%X", info.BasePort), "vendor_id", fmt.Sprintf("0x%X", info.VendorID), "device_id", fmt.Sprintf("0x%X", info.DeviceID))
 
 		// Try SPD addresses 0x50-0x57 (8 possible DIMM slots)
 		for addr := byte(0x50); addr <= 0x57; addr++ {
@@ -212,21 +211,20 @@ func (r *SPDReader) ReadAllSPD() ([]SPDData, error) {
 			length := r.readSPDBlock(byte(i), addr, spd)
 
 			if length >= 256 { // Valid SPD data
-				DebugLog("SPD", fmt.Sprintf("Found SPD data at address 0x%X (length=%d)", addr, length))
+				spdLog.Debug("Found SPD data", "address", fmt.Sprintf("0x%X", addr), "length", length)
 				if data, err := r.parseSPD(spd[:length]); err == nil {
 					// Set slot number based on address
 					data.Slot = int(addr - 0x50)
-					DebugLog("SPD", fmt.Sprintf("Parsed SPD: Type=%s, Size=%d MB, Speed=%d MHz, PartNumber=%s",
-						data.MemoryType, data.ModuleSize/(1024*1024), data.Speed, data.PartNumber))
+					spdLog.Debug("Parsed SPD", "type", data.MemoryType, "size_mb", data.ModuleSize/(1024*1024), "speed_mhz", data.Speed, "part_number", data.PartNumber)
 					results = append(results, data)
 				} else {
-					DebugLog("SPD", fmt.Sprintf("Failed to parse SPD at 0x%X: %v", addr, err))
+					spdLog.Debug("Failed to parse SPD", "address", fmt.Sprintf("0x%X", addr), "error", err)
 				}
 			}
 		}
 	}
 
-	DebugLog("SPD", fmt.Sprintf("Total SPD entries found: %d", len(results)))
+	spdLog.Debug("Total SPD entries found", "count", len(results))
 
 	return results, nil
 }
@@ -522,16 +520,16 @@ func GetManufacturerName(id uint16) string {
 
 // ReadMemoryModulesWithSPD enhances memory module information with SPD data
 func ReadMemoryModulesWithSPD() ([]MemoryModule, error) {
-	DebugLog("SPD", "Starting ReadMemoryModulesWithSPD")
+	spdLog.Debug("Starting ReadMemoryModulesWithSPD")
 
 	// First get basic info from WMI
 	modules, err := getMemoryModulesWindows()
 	if err != nil {
-		DebugLog("SPD", fmt.Sprintf("Failed to get WMI modules: %v", err))
+		spdLog.Debug("Failed to get WMI modules", "error", err)
 		return nil, err
 	}
 
-	DebugLog("SPD", fmt.Sprintf("Got %d modules from WMI", len(modules)))
+	spdLog.Debug("Got modules from WMI", "count", len(modules))
 
 	// Try to read SPD data
 	reader := NewSPDReader()
@@ -539,11 +537,11 @@ func ReadMemoryModulesWithSPD() ([]MemoryModule, error) {
 
 	if err := reader.Initialize(); err != nil {
 		// If we can't initialize WinRing0, just return WMI data
-		DebugLog("SPD", fmt.Sprintf("Failed to initialize SPD reader: %v", err))
+		spdLog.Debug("Failed to initialize SPD reader", "error", err)
 		return modules, nil
 	}
 
-	DebugLog("SPD", "SPD reader initialized successfully")
+	spdLog.Debug("SPD reader initialized successfully")
 
 	// Add timeout protection for SPD reading
 	done := make(chan bool)
@@ -558,15 +556,15 @@ func ReadMemoryModulesWithSPD() ([]MemoryModule, error) {
 	select {
 	case <-done:
 		if spdErr != nil {
-			DebugLog("SPD", fmt.Sprintf("Failed to read SPD data: %v", spdErr))
+			spdLog.Debug("Failed to read SPD data", "error", spdErr)
 			return modules, nil
 		}
 	case <-time.After(2 * time.Second):
-		DebugLog("SPD", "SPD reading timed out after 2 seconds, using WMI data")
+		spdLog.Debug("SPD reading timed out after 2 seconds, using WMI data")
 		return modules, nil
 	}
 
-	DebugLog("SPD", fmt.Sprintf("Read %d SPD entries", len(spdData)))
+	spdLog.Debug("Read SPD entries", "count", len(spdData))
 
 	// Match SPD data to modules
 	matchCount := 0
@@ -597,14 +595,14 @@ func ReadMemoryModulesWithSPD() ([]MemoryModule, error) {
 				// modules[i].HasXMP = spd.HasXMP
 				// modules[i].HasEXPO = spd.HasEXPO
 
-				DebugLog("SPD", fmt.Sprintf("Enhanced module %d with SPD data", i))
+				spdLog.Debug("Enhanced module with SPD data", "module", i)
 				matchCount++
 				break
 			}
 		}
 	}
 
-	DebugLog("SPD", fmt.Sprintf("Enhanced %d modules with SPD data", matchCount))
+	spdLog.Debug("Enhanced modules with SPD data", "count", matchCount)
 
 	return modules, nil
 }
@@ -1,7 +1,7 @@
 //go:build windows
 // +build windows
 
-package gui
+package inventory
 
 import (
 	"fmt"
@@ -224,7 +224,7 @@ func GetDriveBusType(driveLetter string) (string, error) {
 func IsNVMeDrive(driveLetter string) bool {
 	busType, err := GetDriveBusType(driveLetter)
 	if err != nil {
-		DebugLog("STORAGE", fmt.Sprintf("Failed to get bus type for drive %s: %v", driveLetter, err))
+		storageLog.Debug("Failed to get bus type", "drive", driveLetter, "error", err)
 		return false
 	}
 
@@ -1,7 +1,7 @@
 //go:build !windows
 // +build !windows
 
-package gui
+package inventory
 
 // GetDriveBusTypeEnhanced uses platform-specific methods to detect bus type (stub for non-Windows)
 func GetDriveBusTypeEnhanced(_ string) (string, error) {
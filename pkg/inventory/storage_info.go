@@ -1,15 +1,19 @@
-package gui
+package inventory
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/mscrnt/project_fire/pkg/probe"
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+	"github.com/mscrnt/project_fire/pkg/smart"
+	"github.com/mscrnt/project_fire/pkg/storage"
 	"github.com/shirou/gopsutil/v3/disk"
 )
 
@@ -33,19 +37,19 @@ type StorageInfo struct {
 	Interface  string // SATA, NVMe, USB, etc.
 
 	// SMART data
-	SMART *SMARTData
-}
+	SMART *smart.Data
+
+	// eMMC/SD card identity and wear estimates, nil for other devices
+	MMC *MMCInfo
+
+	// Member drives of a RAID volume, read through the controller
+	RAIDMembers []RAIDMember
+
+	// Negotiated SATA or PCIe link and queue depths, nil when unknown
+	Link *storage.Link
 
-// SMARTData contains SMART attributes for a storage device
-type SMARTData struct {
-	Temperature    float64 // Celsius
-	HealthStatus   string  // Good, Warning, Critical
-	PowerOnHours   uint64
-	PowerCycles    uint64
-	TotalWrittenGB float64
-	TotalReadGB    float64
-	WearLevel      float64 // Percentage for SSDs
-	Available      bool    // Whether SMART data is available
+	// Volatile write cache and power-loss protection, nil when unknown
+	WriteCache *storage.WriteCache
 }
 
 // GetStorageInfo returns information about all storage devices
@@ -59,7 +63,10 @@ func GetStorageInfo() ([]StorageInfo, error) {
 	}
 
 	// Build a map of physical drives first
-	driveModels := getDriveModels()
+	driveModels := DriveModels()
+	raidMembers := make(map[string][]RAIDMember)
+	links := make(map[string]*storage.Link)
+	writeCaches := make(map[string]*storage.WriteCache)
 
 	for _, partition := range partitions {
 		// Skip certain filesystems
@@ -77,7 +84,7 @@ func GetStorageInfo() ([]StorageInfo, error) {
 
 		// Determine device type and get physical drive info
 		deviceType := "HDD"
-		physicalDrive := getPhysicalDrive(partition.Device)
+		physicalDrive := PhysicalDrive(partition.Device)
 
 		switch {
 		case strings.Contains(strings.ToLower(partition.Device), "nvme"):
@@ -192,8 +199,48 @@ func GetStorageInfo() ([]StorageInfo, error) {
 			storageInfo.Type = deviceType
 		}
 
-		// Get SMART data for the physical drive
-		storageInfo.SMART = getSMARTData(physicalDrive)
+		// Get SMART data for the physical drive. eMMC and SD have no SMART;
+		// their health comes from the card registers instead.
+		if mmc := getMMCInfo(physicalDrive); mmc != nil {
+			storageInfo.MMC = mmc
+			storageInfo.Type = mmc.CardType
+			storageInfo.Interface = mmc.CardType
+			if storageInfo.Model == "" {
+				storageInfo.Model = mmc.Name
+				storageInfo.Serial = mmc.Serial
+				storageInfo.Firmware = mmc.Firmware
+			}
+			storageInfo.SMART = mmc.SMARTData()
+		} else {
+			storageInfo.SMART = getSMARTData(physicalDrive)
+		}
+
+		// RAID volumes report the controller; look through it for the member drives
+		if isRAIDVolume(&storageInfo, physicalDrive) {
+			if _, ok := raidMembers[physicalDrive]; !ok {
+				raidMembers[physicalDrive] = getRAIDMembers(physicalDrive)
+			}
+			storageInfo.RAIDMembers = raidMembers[physicalDrive]
+			if !storageInfo.SMART.Available && len(storageInfo.RAIDMembers) > 0 {
+				storageInfo.SMART.HealthStatus = worstMemberHealth(storageInfo.RAIDMembers)
+			}
+		}
+
+		// SATA and NVMe drives report the link they trained at
+		if !isWindowsDrive {
+			if _, ok := links[physicalDrive]; !ok {
+				links[physicalDrive], _ = storage.ReadLink(physicalDrive)
+			}
+			storageInfo.Link = links[physicalDrive]
+		}
+		if _, ok := writeCaches[physicalDrive]; !ok {
+			if wc, err := storage.ReadWriteCache(physicalDrive, storageInfo.Model); err == nil {
+				writeCaches[physicalDrive] = wc
+			} else {
+				writeCaches[physicalDrive] = nil
+			}
+		}
+		storageInfo.WriteCache = writeCaches[physicalDrive]
 
 		storageDevices = append(storageDevices, storageInfo)
 	}
@@ -227,11 +274,18 @@ type DriveModel struct {
 	MediaType string // SSD, HDD
 }
 
-// getPhysicalDrive extracts the physical drive from a partition device path
-func getPhysicalDrive(device string) string {
+// PhysicalDrive extracts the physical drive from a partition device path
+func PhysicalDrive(device string) string {
 	// Remove partition numbers from device path
-	// e.g., /dev/sda1 -> /dev/sda, /dev/nvme0n1p1 -> /dev/nvme0n1
-	if strings.Contains(device, "nvme") {
+	// e.g., /dev/sda1 -> /dev/sda, /dev/nvme0n1p1 -> /dev/nvme0n1, /dev/mmcblk0p1 -> /dev/mmcblk0
+	if strings.Contains(device, "mmcblk") {
+		// eMMC/SD devices: /dev/mmcblk0p1 -> /dev/mmcblk0
+		re := regexp.MustCompile(`^(/dev/mmcblk\d+)(p\d+)?$`)
+		matches := re.FindStringSubmatch(device)
+		if len(matches) > 1 {
+			return matches[1]
+		}
+	} else if strings.Contains(device, "nvme") {
 		// NVMe devices: /dev/nvme0n1p1 -> /dev/nvme0n1
 		re := regexp.MustCompile(`^(/dev/nvme\d+n\d+)p?\d*$`)
 		matches := re.FindStringSubmatch(device)
@@ -249,8 +303,20 @@ func getPhysicalDrive(device string) string {
 	return device
 }
 
-// getDriveModels returns a map of physical drives to their model information
-func getDriveModels() map[string]DriveModel {
+// DriveModels returns a map of physical drives to their model information.
+// The map is read through the drive models probe and shared, so callers
+// must not modify it.
+func DriveModels() map[string]DriveModel {
+	models, err := probe.Get(context.Background(), ProbeDriveModels)
+	if err != nil {
+		return map[string]DriveModel{}
+	}
+	return models.(map[string]DriveModel)
+}
+
+// readDriveModels queries the drives' models, which on Windows goes through
+// PowerShell and WMI and can take seconds
+func readDriveModels() map[string]DriveModel {
 	models := make(map[string]DriveModel)
 
 	// Check if running on Windows or WSL
@@ -266,7 +332,7 @@ func getDriveModels() map[string]DriveModel {
 						if err == nil {
 							model.Interface = busType
 							driveInfo[driveLetter] = model
-							DebugLog("STORAGE", fmt.Sprintf("Enhanced detection: Drive %s is %s", driveLetter, busType))
+							storageLog.Debug("Enhanced detection", "drive", driveLetter, "bus_type", busType)
 						}
 					}
 				}
@@ -304,7 +370,7 @@ func getDriveModelsFromLsblk() map[string]DriveModel {
 	models := make(map[string]DriveModel)
 
 	// Try lsblk with specific columns
-	cmd := exec.Command("lsblk", "-d", "-n", "-o", "NAME,MODEL,VENDOR,SERIAL")
+	cmd := safeexec.Command("lsblk", "-d", "-n", "-o", "NAME,MODEL,VENDOR,SERIAL")
 	output, err := cmd.Output()
 	if err != nil {
 		return models
@@ -400,7 +466,7 @@ func getDriveModelsFromSmartctl() map[string]DriveModel {
 	models := make(map[string]DriveModel)
 
 	// List all drives using smartctl --scan
-	cmd := exec.Command("smartctl", "--scan")
+	cmd := safeexec.Command("smartctl", "--scan")
 	output, err := cmd.Output()
 	if err != nil {
 		return models
@@ -418,15 +484,18 @@ func getDriveModelsFromSmartctl() map[string]DriveModel {
 			device := fields[0]
 
 			// Get device info
-			infoCmd := exec.Command("smartctl", "-i", device) // #nosec G204 -- device comes from trusted smartctl --scan output
+			if err := safeexec.ValidateDevicePath(device); err != nil {
+				continue
+			}
+			infoCmd := safeexec.Command("smartctl", "-i", device)
 			infoOutput, err := infoCmd.Output()
 			if err == nil {
-				model := extractSmartctlField(string(infoOutput), "Device Model:")
+				model := smart.Field(string(infoOutput), "Device Model:")
 				if model == "" {
-					model = extractSmartctlField(string(infoOutput), "Model Number:")
+					model = smart.Field(string(infoOutput), "Model Number:")
 				}
-				vendor := extractSmartctlField(string(infoOutput), "Vendor:")
-				serial := extractSmartctlField(string(infoOutput), "Serial Number:")
+				vendor := smart.Field(string(infoOutput), "Vendor:")
+				serial := smart.Field(string(infoOutput), "Serial Number:")
 
 				if model != "" {
 					models[device] = DriveModel{
@@ -459,95 +528,11 @@ func isNonRotational(device string) bool {
 }
 
 // getSMARTData retrieves SMART data for a physical drive
-func getSMARTData(device string) *SMARTData {
-	smart := &SMARTData{
-		Available: false,
-	}
-
-	// Try smartctl first
-	cmd := exec.Command("smartctl", "-A", "-H", device)
-	output, err := cmd.Output()
-	if err != nil {
-		// smartctl returns non-zero exit code even on success sometimes
-		// Check if we got any output
-		if len(output) == 0 {
-			return smart
-		}
-	}
-
-	outputStr := string(output)
-
-	// Check health status
-	switch {
-	case strings.Contains(outputStr, "SMART overall-health self-assessment test result: PASSED"):
-		smart.HealthStatus = "Good"
-	case strings.Contains(outputStr, "SMART overall-health self-assessment test result: FAILED"):
-		smart.HealthStatus = "Critical"
-	default:
-		smart.HealthStatus = "Unknown"
-	}
-
-	// Extract temperature
-	if temp := extractSmartAttribute(outputStr, "194", "Temperature_Celsius"); temp != "" {
-		if val, err := strconv.ParseFloat(temp, 64); err == nil {
-			smart.Temperature = val
-			smart.Available = true
-		}
-	} else if temp := extractSmartAttribute(outputStr, "190", "Airflow_Temperature_Cel"); temp != "" {
-		if val, err := strconv.ParseFloat(temp, 64); err == nil {
-			smart.Temperature = val
-			smart.Available = true
-		}
-	}
-
-	// Extract power-on hours
-	if hours := extractSmartAttribute(outputStr, "9", "Power_On_Hours"); hours != "" {
-		if val, err := strconv.ParseUint(hours, 10, 64); err == nil {
-			smart.PowerOnHours = val
-			smart.Available = true
-		}
-	}
-
-	// Extract power cycles
-	if cycles := extractSmartAttribute(outputStr, "12", "Power_Cycle_Count"); cycles != "" {
-		if val, err := strconv.ParseUint(cycles, 10, 64); err == nil {
-			smart.PowerCycles = val
-			smart.Available = true
-		}
-	}
-
-	// Extract wear level for SSDs
-	if wear := extractSmartAttribute(outputStr, "177", "Wear_Leveling_Count"); wear != "" {
-		if val, err := strconv.ParseFloat(wear, 64); err == nil {
-			smart.WearLevel = 100 - val // Convert to percentage used
-			smart.Available = true
-		}
-	} else if wear := extractSmartAttribute(outputStr, "231", "SSD_Life_Left"); wear != "" {
-		if val, err := strconv.ParseFloat(wear, 64); err == nil {
-			smart.WearLevel = 100 - val
-			smart.Available = true
-		}
+func getSMARTData(device string) *smart.Data {
+	if data, err := smart.Read(device); err == nil {
+		return data
 	}
-
-	// Extract total written (LBAs)
-	if written := extractSmartAttribute(outputStr, "241", "Total_LBAs_Written"); written != "" {
-		if val, err := strconv.ParseFloat(written, 64); err == nil {
-			// Convert LBAs to GB (assuming 512 bytes per LBA)
-			smart.TotalWrittenGB = val * 512 / (1024 * 1024 * 1024)
-			smart.Available = true
-		}
-	}
-
-	// Extract total read (LBAs)
-	if read := extractSmartAttribute(outputStr, "242", "Total_LBAs_Read"); read != "" {
-		if val, err := strconv.ParseFloat(read, 64); err == nil {
-			// Convert LBAs to GB (assuming 512 bytes per LBA)
-			smart.TotalReadGB = val * 512 / (1024 * 1024 * 1024)
-			smart.Available = true
-		}
-	}
-
-	return smart
+	return &smart.Data{HealthStatus: smart.HealthUnknown}
 }
 
 // Helper functions
@@ -560,47 +545,18 @@ func readSysFile(path string) string {
 	return strings.TrimSpace(string(data))
 }
 
-func extractSmartctlField(output, field string) string {
-	lines := strings.Split(output, "\n")
-	for _, line := range lines {
-		if strings.Contains(line, field) {
-			parts := strings.SplitN(line, ":", 2)
-			if len(parts) == 2 {
-				return strings.TrimSpace(parts[1])
-			}
-		}
-	}
-	return ""
-}
-
-func extractSmartAttribute(output, id, name string) string {
-	lines := strings.Split(output, "\n")
-	for _, line := range lines {
-		// SMART attributes are formatted with fixed columns
-		fields := strings.Fields(line)
-		if len(fields) >= 10 {
-			// Check if this line has the attribute ID we're looking for
-			if fields[0] == id || strings.Contains(fields[1], name) {
-				// RAW_VALUE is typically in the last column
-				return fields[len(fields)-1]
-			}
-		}
-	}
-	return ""
-}
-
 // getDriveModelsWindows gets drive models on Windows using multiple methods
 func getDriveModelsWindows() map[string]DriveModel {
 	startTime := time.Now()
 	defer func() {
-		DebugLog("PERF", fmt.Sprintf("getDriveModelsWindows took %v", time.Since(startTime)))
+		perfLog.Debug("getDriveModelsWindows", "took", time.Since(startTime))
 	}()
 
 	models := make(map[string]DriveModel)
 
 	// Method 0: Try the improved PowerShell implementation first (most accurate)
 	if v2Models := getDriveModelsFromPowerShellV2(); len(v2Models) > 0 {
-		DebugLog("STORAGE", fmt.Sprintf("Using V2 models, found %d drives", len(v2Models)))
+		storageLog.Debug("Using V2 models", "drives", len(v2Models))
 		return v2Models
 	}
 
@@ -628,67 +584,26 @@ func getDriveModelsWindows() map[string]DriveModel {
 	}
 
 	// Method 2: Traditional WMI diskdrive query
-	// Build the wmic command - get more detailed drive info
-	var cmd *exec.Cmd
-	if isWindows() {
-		cmd = exec.Command("cmd", "/c", "wmic diskdrive get Model,Size,InterfaceType,MediaType,SerialNumber,FirmwareRevision,Index,Caption /format:csv")
-	} else {
-		// WSL
-		cmd = exec.Command("cmd.exe", "/c", "wmic diskdrive get Model,Size,InterfaceType,MediaType,SerialNumber,FirmwareRevision,Index,Caption /format:csv")
-	}
-
-	output, err := cmd.Output()
-	if err != nil {
+	var drives []win32DiskDrive
+	if err := queryCIM("Win32_DiskDrive", &drives); err != nil {
 		return models
 	}
 
-	lines := strings.Split(string(output), "\n")
-	var headers []string
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		line = strings.Trim(line, "\r")
-		if line == "" {
-			continue
-		}
-
-		fields := strings.Split(line, ",")
-
-		// First line with multiple fields is headers
-		if len(headers) == 0 && len(fields) > 1 && strings.Contains(line, "Model") {
-			headers = fields
-			continue
-		}
-
-		// Skip if not a data line
-		if len(fields) < 3 || strings.Contains(line, "Node") {
-			continue
-		}
-
-		// Create a map for easier field access
-		fieldMap := make(map[string]string)
-		for j, header := range headers {
-			if j < len(fields) {
-				fieldMap[strings.TrimSpace(header)] = strings.TrimSpace(fields[j])
-			}
-		}
-
+	for _, d := range drives {
 		// Get the index to map to drive letters later
-		indexStr := fieldMap["Index"]
-		model := fieldMap["Model"]
-		caption := fieldMap["Caption"]
-		serial := fieldMap["SerialNumber"]
-		firmware := fieldMap["FirmwareRevision"]
-		interfaceType := fieldMap["InterfaceType"]
-
-		driveIndex, _ := strconv.Atoi(indexStr)
+		driveIndex := int(d.Index)
+		model := strings.TrimSpace(d.Model)
+		caption := strings.TrimSpace(d.Caption)
+		serial := strings.TrimSpace(d.SerialNumber)
+		firmware := strings.TrimSpace(d.FirmwareRevision)
+		interfaceType := d.InterfaceType
 
 		// Skip RAID controller entries if we already have better info from PowerShell
 		if model != "" && (strings.Contains(strings.ToLower(model), "raid") ||
 			strings.Contains(strings.ToLower(model), "scsi") ||
 			strings.Contains(strings.ToLower(model), "controller")) {
 			// Check if we already have this drive from PowerShell
-			driveLetters := getDriveLettersForDisk(driveIndex)
+			driveLetters := DriveLettersForDisk(driveIndex)
 			if len(driveLetters) > 0 {
 				if _, exists := models[driveLetters[0]]; exists {
 					continue // Skip this RAID entry
@@ -711,7 +626,7 @@ func getDriveModelsWindows() map[string]DriveModel {
 		if model != "" {
 
 			// Get drive letters for this physical disk
-			driveLetters := getDriveLettersForDisk(driveIndex)
+			driveLetters := DriveLettersForDisk(driveIndex)
 
 			// Determine vendor from model
 			vendor := ""
@@ -735,7 +650,7 @@ func getDriveModelsWindows() map[string]DriveModel {
 
 			for _, driveLetter := range driveLetters {
 				// Debug log
-				DebugLog("STORAGE", fmt.Sprintf("WMI: Mapping disk %d (%s) to drive %s", driveIndex, model, driveLetter))
+				storageLog.Debug("WMI: Mapping disk to drive", "disk", driveIndex, "model", model, "drive", driveLetter)
 
 				driveModel := DriveModel{
 					Model:  model,
@@ -761,91 +676,58 @@ func getDriveModelsWindows() map[string]DriveModel {
 	return models
 }
 
-// getDriveLettersForDisk gets all drive letters associated with a physical disk
-func getDriveLettersForDisk(diskIndex int) []string {
-	var driveLetters []string
+// win32DiskDrive holds the Win32_DiskDrive properties read for each
+// physical disk
+type win32DiskDrive struct {
+	Index            uint32
+	Model            string
+	Caption          string
+	SerialNumber     string
+	FirmwareRevision string
+	InterfaceType    string
+	SCSIPort         uint32 // Controller the disk is on; CSMI numbers controllers by it
+}
 
-	// Method 1: Try to get logical disks directly from disk index using associations
-	var assocCmd *exec.Cmd
-	if isWindows() {
-		// Query for logical disks associated with this physical disk
-		assocCmd = exec.Command("cmd", "/c", fmt.Sprintf("wmic path Win32_DiskDriveToDiskPartition where Antecedent='Win32_DiskDrive.DeviceID=\"\\\\\\\\.\\\\PHYSICALDRIVE%d\"' get Dependent /value", diskIndex)) // #nosec G204 - diskIndex is a validated integer from WMI query
-	} else {
-		assocCmd = exec.Command("cmd.exe", "/c", fmt.Sprintf("wmic path Win32_DiskDriveToDiskPartition where Antecedent='Win32_DiskDrive.DeviceID=\"\\\\\\\\.\\\\PHYSICALDRIVE%d\"' get Dependent /value", diskIndex)) // #nosec G204 - diskIndex is a validated integer from WMI query
-	}
+// win32LogicalDiskToPartition links a partition to the volume on it
+type win32LogicalDiskToPartition struct {
+	Antecedent string `cim:"ref"` // The Win32_DiskPartition
+	Dependent  string `cim:"ref"` // The Win32_LogicalDisk
+}
 
-	output, err := assocCmd.Output()
-	if err == nil && len(output) > 0 {
-		// Parse partition associations
-		lines := strings.Split(string(output), "\n")
-		for _, line := range lines {
-			if strings.Contains(line, "Disk #") && strings.Contains(line, "Partition #") {
-				// Extract partition info
-				// Format: Dependent=\\COMPUTER\root\cimv2:Win32_DiskPartition.DeviceID="Disk #0, Partition #1"
-				start := strings.Index(line, "Disk #")
-				end := strings.Index(line[start:], "\"") + start
-				if start > -1 && end > start {
-					partitionID := line[start:end]
-
-					// Now get logical disk for this partition
-					var logicalCmd *exec.Cmd
-					if isWindows() {
-						logicalCmd = exec.Command("cmd", "/c", fmt.Sprintf("wmic path Win32_LogicalDiskToPartition where Antecedent='Win32_DiskPartition.DeviceID=%q' get Dependent /value", partitionID)) // #nosec G204 - partitionID is validated from WMI output
-					} else {
-						logicalCmd = exec.Command("cmd.exe", "/c", fmt.Sprintf("wmic path Win32_LogicalDiskToPartition where Antecedent='Win32_DiskPartition.DeviceID=%q' get Dependent /value", partitionID)) // #nosec G204 - partitionID is validated from WMI output
-					}
+// win32LogicalDisk holds the Win32_LogicalDisk properties read for each volume
+type win32LogicalDisk struct {
+	DeviceID  string
+	DriveType uint32 // 3 for a local disk
+}
 
-					logicalOutput, err := logicalCmd.Output()
-					if err == nil {
-						logicalLines := strings.Split(string(logicalOutput), "\n")
-						for _, logicalLine := range logicalLines {
-							// Extract drive letter from Dependent=\\COMPUTER\root\cimv2:Win32_LogicalDisk.DeviceID="C:"
-							if strings.Contains(logicalLine, "DeviceID=") {
-								start := strings.Index(logicalLine, "DeviceID=\"")
-								if start > -1 {
-									start += len("DeviceID=\"")
-									end := strings.Index(logicalLine[start:], "\"")
-									if end > -1 {
-										driveLetter := logicalLine[start : start+end]
-										if len(driveLetter) == 2 && driveLetter[1] == ':' {
-											driveLetters = append(driveLetters, driveLetter)
-										}
-									}
-								}
-							}
-						}
-					}
-				}
-			}
-		}
+// Partition and volume references, as WMI paths
+// (Win32_DiskPartition.DeviceID="Disk #0, Partition #1") or as CIM instance
+// text (Win32_DiskPartition (DeviceID = "Disk #0, Partition #1"))
+var (
+	partitionDiskPattern = regexp.MustCompile(`Disk #(\d+),`)
+	volumeLetterPattern  = regexp.MustCompile(`DeviceID\s*=\s*"([A-Za-z]:)"`)
+)
+
+// DriveLettersForDisk gets all drive letters associated with a physical disk
+func DriveLettersForDisk(diskIndex int) []string {
+	var driveLetters []string
+
+	// Method 1: Follow the partitions of the disk to their logical disks
+	var links []win32LogicalDiskToPartition
+	if err := queryCIM("Win32_LogicalDiskToPartition", &links); err == nil {
+		driveLetters = driveLettersFromPartitions(links, diskIndex)
 	}
 
 	// Method 2: If the above didn't work, try a simpler approach
 	if len(driveLetters) == 0 {
-		// Get all logical disks and their associated disk indices
-		var cmd *exec.Cmd
-		if isWindows() {
-			cmd = exec.Command("cmd", "/c", "wmic logicaldisk where DriveType=3 get DeviceID,Size /format:csv")
-		} else {
-			cmd = exec.Command("cmd.exe", "/c", "wmic logicaldisk where DriveType=3 get DeviceID,Size /format:csv")
-		}
-
-		output, err := cmd.Output()
-		if err == nil {
-			// Parse and find which logical disks exist
+		// Get all local logical disks
+		var volumes []win32LogicalDisk
+		if err := queryCIM("Win32_LogicalDisk", &volumes); err == nil {
+			// Find which logical disks exist
 			existingDrives := make(map[string]bool)
-			lines := strings.Split(string(output), "\n")
-			for _, line := range lines {
-				if strings.Contains(line, ":") && !strings.Contains(line, "DeviceID") {
-					fields := strings.Split(line, ",")
-					if len(fields) >= 2 {
-						driveLetter := fields[len(fields)-2] // DeviceID is second to last
-						driveLetter = strings.TrimSpace(driveLetter)
-						driveLetter = strings.Trim(driveLetter, "\r")
-						if len(driveLetter) == 2 && driveLetter[1] == ':' {
-							existingDrives[driveLetter] = true
-						}
-					}
+			for _, v := range volumes {
+				if v.DriveType == 3 && len(v.DeviceID) == 2 && v.DeviceID[1] == ':' {
+					existingDrives[v.DeviceID] = true
 				}
 			}
 
@@ -885,11 +767,26 @@ func getDriveLettersForDisk(diskIndex int) []string {
 	return driveLetters
 }
 
+// driveLettersFromPartitions returns the letters of the volumes on a disk's
+// partitions, in the order WMI lists them
+func driveLettersFromPartitions(links []win32LogicalDiskToPartition, diskIndex int) []string {
+	var driveLetters []string
+	for _, link := range links {
+		disk := partitionDiskPattern.FindStringSubmatch(link.Antecedent)
+		letter := volumeLetterPattern.FindStringSubmatch(link.Dependent)
+		if disk == nil || letter == nil || disk[1] != strconv.Itoa(diskIndex) {
+			continue
+		}
+		driveLetters = append(driveLetters, strings.ToUpper(letter[1]))
+	}
+	return driveLetters
+}
+
 // getDriveModelsFromPowerShell uses PowerShell Get-PhysicalDisk for better NVMe detection
 func getDriveModelsFromPowerShell() map[string]DriveModel {
 	startTime := time.Now()
 	defer func() {
-		DebugLog("PERF", fmt.Sprintf("getDriveModelsFromPowerShell took %v", time.Since(startTime)))
+		perfLog.Debug("getDriveModelsFromPowerShell", "took", time.Since(startTime))
 	}()
 
 	models := make(map[string]DriveModel)
@@ -906,13 +803,7 @@ func getDriveModelsFromPowerShell() map[string]DriveModel {
 		`@{Name='FirmwareVersion';Expression={$_.FirmwareVersion}} | ` +
 		`ConvertTo-Json -Compress`
 
-	var cmd *exec.Cmd
-	if isWindows() {
-		cmd = exec.Command("powershell", "-NoProfile", "-Command", psCmd)
-	} else {
-		// WSL
-		cmd = exec.Command("powershell.exe", "-NoProfile", "-Command", psCmd)
-	}
+	cmd := safeexec.PowerShell(psCmd)
 
 	output, err := cmd.Output()
 	if err != nil {
@@ -963,7 +854,7 @@ func getDriveModelsFromPowerShell() map[string]DriveModel {
 		if diskNumberStr != "" {
 			if num, err := strconv.Atoi(diskNumberStr); err == nil {
 				diskNum = num
-				DebugLog("STORAGE", fmt.Sprintf("PowerShell: Using DiskNumber %d for %s", diskNum, model))
+				storageLog.Debug("PowerShell: Using DiskNumber", "disk", diskNum, "model", model)
 			}
 		}
 
@@ -972,7 +863,7 @@ func getDriveModelsFromPowerShell() map[string]DriveModel {
 			diskNum = extractDiskNumber(deviceID)
 		}
 		if diskNum >= 0 {
-			driveLetters := getDriveLettersForDisk(diskNum)
+			driveLetters := DriveLettersForDisk(diskNum)
 
 			// Determine vendor first (we'll need modelLower for interface detection)
 			vendor := ""
@@ -1032,7 +923,7 @@ func getDriveModelsFromPowerShell() map[string]DriveModel {
 
 			for _, driveLetter := range driveLetters {
 				// Debug log
-				DebugLog("STORAGE", fmt.Sprintf("PowerShell: Mapping disk %d (%s, Serial: %s) to drive %s", diskNum, model, serialNumber, driveLetter))
+				storageLog.Debug("PowerShell: Mapping disk to drive", "disk", diskNum, "model", model, "serial", serialNumber, "drive", driveLetter)
 
 				models[driveLetter] = DriveModel{
 					Model:     model,
@@ -1096,17 +987,11 @@ foreach ($disk in $disks) {
 $mappings | ConvertTo-Json -Compress
 `
 
-	var cmd *exec.Cmd
-	if isWindows() {
-		cmd = exec.Command("powershell", "-NoProfile", "-Command", psScript)
-	} else {
-		// WSL
-		cmd = exec.Command("powershell.exe", "-NoProfile", "-Command", psScript)
-	}
+	cmd := safeexec.PowerShell(psScript)
 
 	output, err := cmd.Output()
 	if err != nil {
-		DebugLog("STORAGE", fmt.Sprintf("PowerShell V2 error: %v", err))
+		storageLog.Debug("PowerShell V2 error", "error", err)
 		return models
 	}
 
@@ -1208,8 +1093,7 @@ $mappings | ConvertTo-Json -Compress
 			interfaceType = busType
 		}
 
-		DebugLog("STORAGE", fmt.Sprintf("PowerShell V2: Mapping %s (Serial: %s, BusType: %s) to drive %s",
-			model, serialNumber, busType, driveLetter))
+		storageLog.Debug("PowerShell V2: Mapping disk to drive", "model", model, "serial", serialNumber, "bus_type", busType, "drive", driveLetter)
 
 		models[driveLetter] = DriveModel{
 			Model:     model,
@@ -1238,7 +1122,7 @@ func extractJSONField(json, field string) string {
 // extractDiskNumber extracts disk number from DeviceId like "\\?\scsi#disk&ven..."
 func extractDiskNumber(deviceID string) int {
 	// Debug log the deviceID
-	DebugLog("STORAGE", fmt.Sprintf("extractDiskNumber: deviceID=%s", deviceID))
+	storageLog.Debug("extractDiskNumber", "device_id", deviceID)
 
 	// Try to extract from the deviceID
 	// Look for patterns like "physicaldrive0", "physicaldrive1", etc
@@ -1246,7 +1130,7 @@ func extractDiskNumber(deviceID string) int {
 	matches := re.FindStringSubmatch(strings.ToLower(deviceID))
 	if len(matches) > 1 {
 		if num, err := strconv.Atoi(matches[1]); err == nil {
-			DebugLog("STORAGE", fmt.Sprintf("extractDiskNumber: found physicaldrive%d", num))
+			storageLog.Debug("extractDiskNumber: found physicaldrive", "disk", num)
 			return num
 		}
 	}
@@ -1257,7 +1141,7 @@ func extractDiskNumber(deviceID string) int {
 	matches2 := re2.FindStringSubmatch(deviceID)
 	if len(matches2) > 1 {
 		if num, err := strconv.Atoi(matches2[1]); err == nil {
-			DebugLog("STORAGE", fmt.Sprintf("extractDiskNumber: found disk number %d at end", num))
+			storageLog.Debug("extractDiskNumber: found disk number at end", "disk", num)
 			return num
 		}
 	}
@@ -1272,14 +1156,14 @@ func extractDiskNumber(deviceID string) int {
 			matches3 := re3.FindStringSubmatch(part)
 			if len(matches3) > 1 {
 				if num, err := strconv.Atoi(matches3[1]); err == nil {
-					DebugLog("STORAGE", fmt.Sprintf("extractDiskNumber: found disk number %d in last part", num))
+					storageLog.Debug("extractDiskNumber: found disk number in last part", "disk", num)
 					return num
 				}
 			}
 		}
 	}
 
-	DebugLog("STORAGE", "extractDiskNumber: no disk number found, returning -1")
+	storageLog.Debug("extractDiskNumber: no disk number found, returning -1")
 	return -1
 }
 
@@ -1313,13 +1197,7 @@ func getDriveModelsFromMSFTDisk() map[string]DriveModel {
 		$results | ConvertTo-Json -Compress
 	`
 
-	var cmd *exec.Cmd
-	if isWindows() {
-		cmd = exec.Command("powershell", "-NoProfile", "-Command", psCmd)
-	} else {
-		// WSL
-		cmd = exec.Command("powershell.exe", "-NoProfile", "-Command", psCmd)
-	}
+	cmd := safeexec.PowerShell(psCmd)
 
 	output, err := cmd.Output()
 	if err != nil {
@@ -1382,6 +1260,10 @@ func getDriveModelsFromMSFTDisk() map[string]DriveModel {
 			interfaceType = "USB"
 		case "8": // RAID
 			interfaceType = "RAID"
+		case "12": // SD
+			interfaceType = "SD"
+		case "13": // MMC
+			interfaceType = "eMMC"
 		default:
 			interfaceType = busType
 		}
@@ -1403,7 +1285,7 @@ func getDriveModelsFromMSFTDisk() map[string]DriveModel {
 		}
 
 		// Debug log
-		DebugLog("STORAGE", fmt.Sprintf("MSFT_Disk: Mapping %s (Serial: %s) to drive %s", displayModel, serialNumber, driveLetter))
+		storageLog.Debug("MSFT_Disk: Mapping disk to drive", "model", displayModel, "serial", serialNumber, "drive", driveLetter)
 
 		models[driveLetter] = DriveModel{
 			Model:     displayModel,
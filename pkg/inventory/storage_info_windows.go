@@ -1,13 +1,14 @@
 //go:build windows
 // +build windows
 
-package gui
+package inventory
 
 import (
 	"encoding/json"
 	"fmt"
-	"os/exec"
 	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
 )
 
 // WindowsDriveMapping represents the mapping between physical disks and logical drives
@@ -78,7 +79,7 @@ foreach ($diskDrive in $wmiServices) {
     try {
         $msftDisk = Get-WmiObject -Namespace root\Microsoft\Windows\Storage -Query "SELECT * FROM MSFT_Disk WHERE Number=$diskNumber" -ErrorAction Stop
         if ($msftDisk) {
-            # BusType values: 17=NVMe, 11=SATA, 8=RAID, 7=USB, 9=iSCSI, 1=SCSI
+            # BusType values: 17=NVMe, 11=SATA, 8=RAID, 7=USB, 9=iSCSI, 1=SCSI, 12=SD, 13=MMC
             switch ($msftDisk.BusType) {
                 17 { $detectedBusType = "NVMe" }
                 11 { $detectedBusType = "SATA" }
@@ -91,6 +92,8 @@ foreach ($diskDrive in $wmiServices) {
                 }
                 7 { $detectedBusType = "USB" }
                 9 { $detectedBusType = "iSCSI" }
+                12 { $detectedBusType = "SD" }
+                13 { $detectedBusType = "MMC" }
                 1 { 
                     # SCSI - check if actually NVMe
                     if ($diskDrive.PNPDeviceID -match "VEN_NVME") {
@@ -148,23 +151,17 @@ if ($mappings.Count -eq 0) {
 }
 `
 
-	var cmd *exec.Cmd
-	if isWindows() {
-		cmd = exec.Command("powershell", "-NoProfile", "-Command", psScript)
-	} else {
-		// WSL
-		cmd = exec.Command("powershell.exe", "-NoProfile", "-Command", psScript)
-	}
+	cmd := safeexec.PowerShell(psScript)
 
 	output, err := cmd.CombinedOutput() // Get both stdout and stderr
 	if err != nil {
-		DebugLog("STORAGE", fmt.Sprintf("PowerShell execution error: %v, output: %s", err, string(output)))
+		storageLog.Debug("PowerShell execution error", "error", err, "output", string(output))
 		return nil, fmt.Errorf("failed to execute PowerShell: %w", err)
 	}
 
 	// Parse JSON output
 	outputStr := strings.TrimSpace(string(output))
-	DebugLog("STORAGE", fmt.Sprintf("PowerShell raw output: %s", outputStr))
+	storageLog.Debug("PowerShell raw output", "output", outputStr)
 
 	if outputStr == "" || outputStr == "null" {
 		return nil, fmt.Errorf("no drive mappings found")
@@ -178,7 +175,7 @@ if ($mappings.Count -eq 0) {
 	var mappings []WindowsDriveMapping
 	err = json.Unmarshal([]byte(outputStr), &mappings)
 	if err != nil {
-		DebugLog("STORAGE", fmt.Sprintf("JSON parse error: %v", err))
+		storageLog.Debug("JSON parse error", "error", err)
 		return nil, fmt.Errorf("failed to parse JSON: %w", err)
 	}
 
@@ -195,11 +192,11 @@ func GetWindowsDriveModelsV2() map[string]DriveModel {
 
 	mappings, err := GetWindowsDriveMappings()
 	if err != nil {
-		DebugLog("STORAGE", fmt.Sprintf("GetWindowsDriveMappings error: %v", err))
+		storageLog.Debug("GetWindowsDriveMappings error", "error", err)
 		return models
 	}
 
-	DebugLog("STORAGE", fmt.Sprintf("Found %d drive mappings from V2 method", len(mappings)))
+	storageLog.Debug("Found drive mappings from V2 method", "count", len(mappings))
 
 	for _, mapping := range mappings {
 		// Determine vendor from model
@@ -270,12 +267,17 @@ func GetWindowsDriveModelsV2() map[string]DriveModel {
 			}
 		case "USB":
 			interfaceType = "USB"
+		case "SD":
+			interfaceType = "SD"
+			mapping.MediaType = "SD"
+		case "MMC":
+			interfaceType = "eMMC"
+			mapping.MediaType = "eMMC"
 		default:
 			interfaceType = mapping.BusType
 		}
 
-		DebugLog("STORAGE", fmt.Sprintf("Mapping disk %d (%s, Serial: %s) to drive %s",
-			mapping.DiskNumber, mapping.Model, mapping.SerialNumber, mapping.DriveLetter))
+		storageLog.Debug("Mapping disk to drive", "disk", mapping.DiskNumber, "model", mapping.Model, "serial", mapping.SerialNumber, "drive", mapping.DriveLetter)
 
 		models[mapping.DriveLetter] = DriveModel{
 			Model:     mapping.Model,
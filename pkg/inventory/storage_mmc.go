@@ -0,0 +1,147 @@
+package inventory
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/smart"
+)
+
+// MMCInfo holds identity and wear data for eMMC and SD storage
+type MMCInfo struct {
+	CardType        string // eMMC, SD, SDIO
+	Name            string
+	Serial          string
+	Firmware        string
+	ManufacturerID  string
+	ManufactureDate string
+
+	// Device life time estimates from EXT_CSD (eMMC 5.0+ only), in 10% steps
+	// of rated erase cycles used: 1 = 0-10% ... 10 = 90-100%, 11 = exceeded.
+	// Type A covers SLC-mode areas, type B the MLC/TLC main area.
+	LifeTimeA int
+	LifeTimeB int
+	PreEOL    string // Normal, Warning, Urgent or empty when not reported
+}
+
+// isMMCDevice reports whether a physical drive is an eMMC/SD block device
+func isMMCDevice(physicalDrive string) bool {
+	return strings.HasPrefix(filepath.Base(physicalDrive), "mmcblk")
+}
+
+// getMMCInfo reads card identity and EXT_CSD health from sysfs. It returns
+// nil when the device is not an MMC block device.
+func getMMCInfo(physicalDrive string) *MMCInfo {
+	if !isMMCDevice(physicalDrive) {
+		return nil
+	}
+
+	devicePath := filepath.Join("/sys/block", filepath.Base(physicalDrive), "device")
+	cardType := readSysFile(filepath.Join(devicePath, "type"))
+	if cardType == "" {
+		return nil
+	}
+
+	info := &MMCInfo{
+		CardType:        mmcCardType(cardType),
+		Name:            readSysFile(filepath.Join(devicePath, "name")),
+		Serial:          readSysFile(filepath.Join(devicePath, "serial")),
+		Firmware:        readSysFile(filepath.Join(devicePath, "fwrev")),
+		ManufacturerID:  readSysFile(filepath.Join(devicePath, "manfid")),
+		ManufactureDate: readSysFile(filepath.Join(devicePath, "date")),
+	}
+
+	// life_time and pre_eol_info are only exported for eMMC 5.0 and later
+	info.LifeTimeA, info.LifeTimeB = parseMMCLifeTime(readSysFile(filepath.Join(devicePath, "life_time")))
+	info.PreEOL = parseMMCPreEOL(readSysFile(filepath.Join(devicePath, "pre_eol_info")))
+
+	return info
+}
+
+// mmcCardType maps the kernel card type to the name shown to users
+func mmcCardType(kernelType string) string {
+	if kernelType == "MMC" {
+		return "eMMC"
+	}
+	return kernelType
+}
+
+// parseMMCLifeTime parses the sysfs life_time attribute ("0x01 0x02")
+func parseMMCLifeTime(value string) (typeA, typeB int) {
+	fields := strings.Fields(value)
+	if len(fields) != 2 {
+		return 0, 0
+	}
+	a, errA := strconv.ParseUint(fields[0], 0, 8)
+	b, errB := strconv.ParseUint(fields[1], 0, 8)
+	if errA != nil || errB != nil {
+		return 0, 0
+	}
+	return int(a), int(b)
+}
+
+// parseMMCPreEOL parses the sysfs pre_eol_info attribute
+func parseMMCPreEOL(value string) string {
+	v, err := strconv.ParseUint(strings.TrimSpace(value), 0, 8)
+	if err != nil {
+		return ""
+	}
+	switch v {
+	case 1:
+		return "Normal"
+	case 2:
+		return "Warning" // 80% of reserved blocks consumed
+	case 3:
+		return "Urgent" // 90% of reserved blocks consumed
+	default:
+		return ""
+	}
+}
+
+// LifeTimeRange formats a life time estimate as a used-percentage range
+func LifeTimeRange(estimate int) string {
+	switch {
+	case estimate <= 0:
+		return "Not reported"
+	case estimate >= 11:
+		return "Exceeded rated life"
+	default:
+		return fmt.Sprintf("%d-%d%% used", (estimate-1)*10, estimate*10)
+	}
+}
+
+// HasLifeTime reports whether the device exported EXT_CSD wear estimates
+func (m *MMCInfo) HasLifeTime() bool {
+	return m.LifeTimeA > 0 || m.LifeTimeB > 0 || m.PreEOL != ""
+}
+
+// SMARTData converts the EXT_CSD estimates into the common SMART summary so
+// eMMC devices show health and wear like SATA and NVMe drives
+func (m *MMCInfo) SMARTData() *smart.Data {
+	data := &smart.Data{HealthStatus: smart.HealthUnknown}
+	if !m.HasLifeTime() {
+		return data
+	}
+
+	worst := m.LifeTimeA
+	if m.LifeTimeB > worst {
+		worst = m.LifeTimeB
+	}
+	if worst > 10 {
+		worst = 10
+	}
+
+	data.Available = true
+	data.WearLevel = float64(worst * 10)
+	switch {
+	case m.PreEOL == "Urgent" || m.LifeTimeA > 10 || m.LifeTimeB > 10:
+		data.HealthStatus = smart.HealthCritical
+	case m.PreEOL == "Warning" || worst >= 9:
+		data.HealthStatus = smart.HealthWarning
+	default:
+		data.HealthStatus = smart.HealthGood
+	}
+	return data
+}
@@ -0,0 +1,36 @@
+package inventory
+
+import "testing"
+
+func TestMMCLifeTime(t *testing.T) {
+	a, b := parseMMCLifeTime("0x01 0x09")
+	if a != 1 || b != 9 {
+		t.Fatalf("Expected life time 1/9, got %d/%d", a, b)
+	}
+	if a, b := parseMMCLifeTime(""); a != 0 || b != 0 {
+		t.Errorf("Expected no life time for empty attribute, got %d/%d", a, b)
+	}
+
+	tests := []struct {
+		info      MMCInfo
+		health    string
+		wear      float64
+		available bool
+	}{
+		{MMCInfo{LifeTimeA: 1, LifeTimeB: 2, PreEOL: "Normal"}, "Good", 20, true},
+		{MMCInfo{LifeTimeA: 1, LifeTimeB: 9, PreEOL: "Normal"}, "Warning", 90, true},
+		{MMCInfo{LifeTimeA: 1, LifeTimeB: 3, PreEOL: "Urgent"}, "Critical", 30, true},
+		{MMCInfo{LifeTimeA: 11, LifeTimeB: 4}, "Critical", 100, true},
+		{MMCInfo{CardType: "SD"}, "Unknown", 0, false},
+	}
+	for _, tt := range tests {
+		smart := tt.info.SMARTData()
+		if smart.HealthStatus != tt.health || smart.WearLevel != tt.wear || smart.Available != tt.available {
+			t.Errorf("SMARTData(%+v) = %+v", tt.info, smart)
+		}
+	}
+
+	if got := PhysicalDrive("/dev/mmcblk0p2"); got != "/dev/mmcblk0" {
+		t.Errorf("Expected /dev/mmcblk0, got %s", got)
+	}
+}
@@ -0,0 +1,247 @@
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// Filesystem health states reported for each partition
+const (
+	FSHealthClean   = "Clean"
+	FSHealthDirty   = "Dirty"
+	FSHealthErrors  = "Errors"
+	FSHealthUnknown = "Unknown"
+)
+
+// PartitionInfo describes one partition on a physical drive
+type PartitionInfo struct {
+	Device      string
+	Label       string
+	Type        string // partition type name, e.g. "EFI System", "Basic data"
+	Filesystem  string
+	Mountpoint  string
+	Size        uint64
+	Used        uint64
+	Free        uint64
+	UsedPercent float64
+	Health      string // Clean, Dirty, Errors, Unknown
+	HealthNote  string
+}
+
+// GetPartitionLayout lists every partition on the physical drive backing the
+// given storage entry, including partitions that are not mounted
+func GetPartitionLayout(storage *StorageInfo) ([]PartitionInfo, error) {
+	if runtime.GOOS == "windows" {
+		return getPartitionLayoutWindows(storage.Device)
+	}
+	return getPartitionLayoutLinux(PhysicalDrive(storage.Device))
+}
+
+// lsblkPartition is one entry of lsblk --json output
+type lsblkPartition struct {
+	Name       string           `json:"name"`
+	Path       string           `json:"path"`
+	Type       string           `json:"type"`
+	Size       json.Number      `json:"size"`
+	FSType     string           `json:"fstype"`
+	Label      string           `json:"label"`
+	PartType   string           `json:"parttypename"`
+	MountPoint string           `json:"mountpoint"`
+	Children   []lsblkPartition `json:"children"`
+}
+
+// getPartitionLayoutLinux uses lsblk for the layout and sysfs for ext4 error counters
+func getPartitionLayoutLinux(drive string) ([]PartitionInfo, error) {
+	if err := safeexec.ValidateDevicePath(drive); err != nil {
+		return nil, err
+	}
+
+	output, err := safeexec.Command("lsblk", "--json", "-b", "-o", "NAME,PATH,TYPE,SIZE,FSTYPE,LABEL,PARTTYPENAME,MOUNTPOINT", drive).Output()
+	if err != nil {
+		return nil, fmt.Errorf("lsblk failed: %w", err)
+	}
+
+	partitions, err := parseLsblkPartitions(output)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range partitions {
+		p := &partitions[i]
+		if p.Mountpoint != "" {
+			if usage, err := disk.Usage(p.Mountpoint); err == nil {
+				p.Used = usage.Used
+				p.Free = usage.Free
+				p.UsedPercent = usage.UsedPercent
+			}
+		}
+		p.Health, p.HealthNote = linuxFilesystemHealth(p)
+	}
+
+	return partitions, nil
+}
+
+// parseLsblkPartitions flattens lsblk output into partitions. Filesystems
+// inside containers (LUKS, LVM) are reported on their parent partition.
+func parseLsblkPartitions(data []byte) ([]PartitionInfo, error) {
+	var tree struct {
+		BlockDevices []lsblkPartition `json:"blockdevices"`
+	}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("failed to parse lsblk output: %w", err)
+	}
+
+	var partitions []PartitionInfo
+	for _, dev := range tree.BlockDevices {
+		for _, part := range dev.Children {
+			if part.Type != "part" {
+				continue
+			}
+			size, _ := strconv.ParseUint(part.Size.String(), 10, 64)
+			info := PartitionInfo{
+				Device:     part.Path,
+				Label:      part.Label,
+				Type:       part.PartType,
+				Filesystem: part.FSType,
+				Mountpoint: part.MountPoint,
+				Size:       size,
+			}
+
+			// Use the innermost mounted filesystem of a container
+			for inner := part.Children; len(inner) > 0; inner = inner[0].Children {
+				if inner[0].FSType != "" {
+					info.Filesystem = fmt.Sprintf("%s (%s)", inner[0].FSType, part.FSType)
+				}
+				if inner[0].MountPoint != "" {
+					info.Mountpoint = inner[0].MountPoint
+				}
+			}
+
+			partitions = append(partitions, info)
+		}
+	}
+	return partitions, nil
+}
+
+// linuxFilesystemHealth reads the kernel's ext4 error counters. Other
+// filesystems only report health when checked offline, so they are unknown.
+func linuxFilesystemHealth(p *PartitionInfo) (state, note string) {
+	if !strings.HasPrefix(p.Filesystem, "ext4") || p.Mountpoint == "" {
+		return FSHealthUnknown, ""
+	}
+
+	errorsPath := filepath.Join("/sys/fs/ext4", filepath.Base(p.Device), "errors_count")
+	data, err := os.ReadFile(errorsPath) // #nosec G304 -- path is built from the lsblk device name
+	if err != nil {
+		return FSHealthUnknown, ""
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return FSHealthUnknown, ""
+	}
+	if count > 0 {
+		return FSHealthErrors, fmt.Sprintf("%d errors recorded since last fsck", count)
+	}
+	return FSHealthClean, ""
+}
+
+// windowsPartition is one entry of the Get-Partition/Get-Volume script output
+type windowsPartition struct {
+	Number       int    `json:"Number"`
+	DriveLetter  string `json:"DriveLetter"`
+	Type         string `json:"Type"`
+	Size         uint64 `json:"Size"`
+	Label        string `json:"Label"`
+	FileSystem   string `json:"FileSystem"`
+	Remaining    uint64 `json:"Remaining"`
+	HealthStatus string `json:"HealthStatus"`
+}
+
+// getPartitionLayoutWindows lists the partitions on the disk holding the
+// given drive letter and checks the dirty bit of lettered volumes
+func getPartitionLayoutWindows(device string) ([]PartitionInfo, error) {
+	letter := strings.TrimSuffix(strings.TrimSpace(device), ":")
+	if len(letter) != 1 {
+		return nil, fmt.Errorf("invalid drive %q", device)
+	}
+
+	output, err := safeexec.PowerShell(`$n = (Get-Partition -DriveLetter %s).DiskNumber; `+
+		`ConvertTo-Json -Compress -InputObject @(Get-Partition -DiskNumber $n | ForEach-Object { $v = $_ | Get-Volume -ErrorAction SilentlyContinue; `+
+		`[pscustomobject]@{ Number = $_.PartitionNumber; DriveLetter = [string]$_.DriveLetter; Type = [string]$_.Type; Size = $_.Size; `+
+		`Label = $v.FileSystemLabel; FileSystem = $v.FileSystem; Remaining = $v.SizeRemaining; HealthStatus = [string]$v.HealthStatus } })`,
+		letter).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query partitions: %w", err)
+	}
+
+	partitions, err := parseWindowsPartitions(output)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range partitions {
+		p := &partitions[i]
+		if p.Mountpoint == "" || p.Health == FSHealthErrors {
+			continue
+		}
+		// fsutil needs Administrator; leave the volume health as-is if it fails
+		if out, err := safeexec.Command("fsutil", "dirty", "query", p.Mountpoint).Output(); err == nil {
+			if strings.Contains(string(out), "is Dirty") {
+				p.Health = FSHealthDirty
+				p.HealthNote = "chkdsk will run at next boot"
+			} else if strings.Contains(string(out), "is NOT Dirty") {
+				p.Health = FSHealthClean
+			}
+		}
+	}
+
+	return partitions, nil
+}
+
+// parseWindowsPartitions converts the PowerShell JSON into partitions
+func parseWindowsPartitions(data []byte) ([]PartitionInfo, error) {
+	var raw []windowsPartition
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse partition output: %w", err)
+	}
+
+	partitions := make([]PartitionInfo, 0, len(raw))
+	for _, r := range raw {
+		info := PartitionInfo{
+			Device:     fmt.Sprintf("Partition %d", r.Number),
+			Label:      r.Label,
+			Type:       r.Type,
+			Filesystem: r.FileSystem,
+			Size:       r.Size,
+			Health:     FSHealthUnknown,
+		}
+		if r.DriveLetter != "" && r.DriveLetter != "\u0000" {
+			info.Mountpoint = r.DriveLetter + ":"
+		}
+		if r.FileSystem != "" && r.Remaining <= r.Size {
+			info.Free = r.Remaining
+			info.Used = r.Size - r.Remaining
+			if r.Size > 0 {
+				info.UsedPercent = float64(info.Used) / float64(r.Size) * 100
+			}
+		}
+		switch r.HealthStatus {
+		case "Healthy":
+			info.Health = FSHealthClean
+		case "Warning", "Unhealthy":
+			info.Health = FSHealthErrors
+			info.HealthNote = "Volume reports " + r.HealthStatus
+		}
+		partitions = append(partitions, info)
+	}
+	return partitions, nil
+}
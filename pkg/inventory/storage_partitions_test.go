@@ -0,0 +1,46 @@
+package inventory
+
+import "testing"
+
+func TestParseLsblkPartitions(t *testing.T) {
+	data := []byte(`{"blockdevices":[{"name":"nvme0n1","path":"/dev/nvme0n1","type":"disk","size":512110190592,"children":[
+		{"name":"nvme0n1p1","path":"/dev/nvme0n1p1","type":"part","size":536870912,"fstype":"vfat","parttypename":"EFI System","mountpoint":"/boot/efi"},
+		{"name":"nvme0n1p2","path":"/dev/nvme0n1p2","type":"part","size":511571214336,"fstype":"crypto_LUKS","parttypename":"Linux filesystem","children":[
+			{"name":"root","path":"/dev/mapper/root","type":"crypt","size":511554437120,"fstype":"ext4","mountpoint":"/"}]}]}]}`)
+
+	partitions, err := parseLsblkPartitions(data)
+	if err != nil {
+		t.Fatalf("parseLsblkPartitions returned error: %v", err)
+	}
+	if len(partitions) != 2 {
+		t.Fatalf("Expected 2 partitions, got %d", len(partitions))
+	}
+
+	if partitions[0].Type != "EFI System" || partitions[0].Mountpoint != "/boot/efi" || partitions[0].Size != 536870912 {
+		t.Errorf("Unexpected EFI partition: %+v", partitions[0])
+	}
+	if partitions[1].Filesystem != "ext4 (crypto_LUKS)" || partitions[1].Mountpoint != "/" {
+		t.Errorf("Expected LUKS container to report inner ext4 at /, got %+v", partitions[1])
+	}
+}
+
+func TestParseWindowsPartitions(t *testing.T) {
+	data := []byte(`[{"Number":1,"DriveLetter":"","Type":"System","Size":104857600,"Label":null,"FileSystem":"FAT32","Remaining":73400320,"HealthStatus":"Healthy"},
+		{"Number":2,"DriveLetter":"C","Type":"Basic","Size":1000000000,"Label":"Windows","FileSystem":"NTFS","Remaining":250000000,"HealthStatus":"Warning"}]`)
+
+	partitions, err := parseWindowsPartitions(data)
+	if err != nil {
+		t.Fatalf("parseWindowsPartitions returned error: %v", err)
+	}
+	if len(partitions) != 2 {
+		t.Fatalf("Expected 2 partitions, got %d", len(partitions))
+	}
+
+	if partitions[0].Mountpoint != "" || partitions[0].Health != FSHealthClean {
+		t.Errorf("Unexpected system partition: %+v", partitions[0])
+	}
+	c := partitions[1]
+	if c.Mountpoint != "C:" || c.Health != FSHealthErrors || c.UsedPercent != 75 {
+		t.Errorf("Unexpected C: partition: %+v", c)
+	}
+}
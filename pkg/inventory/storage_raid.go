@@ -0,0 +1,263 @@
+package inventory
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+	"github.com/mscrnt/project_fire/pkg/smart"
+)
+
+// RAIDMember describes a physical drive behind a RAID controller
+type RAIDMember struct {
+	Device   string // smartctl device name, e.g. /dev/csmi0,1 or /dev/nvme0n1
+	Type     string // smartctl -d value used to reach the drive, empty for direct access
+	Model    string
+	Serial   string
+	Firmware string
+	SMART    *smart.Data
+}
+
+// sysfsDir is where the Linux block and SCSI devices are looked up,
+// replaced in tests
+var sysfsDir = "/sys"
+
+// Controller numbers in device names: the CSMI controller of /dev/csmiN,P
+// (Windows' SCSI port N), the SCSI host of /dev/bus/N (megaraid on Linux)
+// and the disk number of \\.\PHYSICALDRIVEN
+var (
+	csmiPattern          = regexp.MustCompile(`^/dev/csmi(\d+),\d+$`)
+	scsiBusPattern       = regexp.MustCompile(`^/dev/bus/(\d+)$`)
+	physicalDrivePattern = regexp.MustCompile(`(?i)^\\\\\.\\PHYSICALDRIVE(\d+)$`)
+)
+
+// passthroughTypes are smartctl device types that address a drive through a
+// RAID controller rather than the volume the OS sees
+var passthroughTypes = []string{
+	"megaraid", "areca", "aacraid", "cciss", "3ware", "hpt", "intelliprop", "jmb39x", "sssraid", "csmi",
+}
+
+// isRAIDVolume reports whether a storage entry is a RAID volume whose
+// identity and SMART come from the controller instead of the drives
+func isRAIDVolume(storage *StorageInfo, physicalDrive string) bool {
+	if strings.Contains(strings.ToUpper(storage.Interface), "RAID") ||
+		strings.Contains(strings.ToLower(storage.Model), "raid") {
+		return true
+	}
+	// Linux software and Intel RST/VMD (imsm) arrays are md devices
+	return strings.HasPrefix(filepath.Base(physicalDrive), "md")
+}
+
+// getRAIDMembers returns the member drives of the RAID volume backing
+// physicalDrive. Linux md arrays, including Intel RST/VMD (imsm) arrays,
+// list their members in sysfs; hardware and firmware RAID members are found
+// with smartctl's controller passthrough (CSMI for Intel RST, megaraid,
+// areca, ...) on the volume's controller. The drives of every array on that
+// controller are returned, since the controllers do not say which array a
+// drive belongs to. AMD RAIDXpert and NVMe drives behind VMD on Windows
+// expose no passthrough interface, so their members cannot be reached.
+func getRAIDMembers(physicalDrive string) []RAIDMember {
+	if runtime.GOOS != "windows" {
+		if members := getMDMembers(physicalDrive); len(members) > 0 {
+			return members
+		}
+	}
+
+	devices, err := smart.Scan()
+	if err != nil {
+		logger.Warn("RAID passthrough scan failed", "error", err)
+		return nil
+	}
+
+	var members []RAIDMember
+	for _, dev := range raidMemberDevices(devices, physicalDrive) {
+		members = append(members, readRAIDMember(dev.Name, dev.Type))
+	}
+	storageLog.Debug("Found RAID member drives", "count", len(members), "volume", physicalDrive)
+	return members
+}
+
+// getMDMembers resolves the member drives of a Linux md array from sysfs
+func getMDMembers(physicalDrive string) []RAIDMember {
+	name := filepath.Base(physicalDrive)
+	if !strings.HasPrefix(name, "md") {
+		return nil
+	}
+
+	entries, err := os.ReadDir(filepath.Join(sysfsDir, "block", name, "slaves"))
+	if err != nil {
+		return nil
+	}
+
+	var members []RAIDMember
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		drive := PhysicalDrive("/dev/" + entry.Name())
+		if seen[drive] {
+			continue
+		}
+		seen[drive] = true
+		members = append(members, readRAIDMember(drive, ""))
+	}
+	return members
+}
+
+// raidMemberDevices returns the scanned passthrough devices on the
+// controller of physicalDrive. When that controller cannot be told, every
+// passthrough device is returned.
+func raidMemberDevices(devices []smart.ScanDevice, physicalDrive string) []smart.ScanDevice {
+	controller, known := raidController(physicalDrive)
+	if !known {
+		storageLog.Debug("RAID controller not known; listing every passthrough drive", "volume", physicalDrive)
+	}
+	var members []smart.ScanDevice
+	for _, dev := range devices {
+		if !isPassthroughDevice(dev) {
+			continue
+		}
+		if known {
+			if c, ok := raidController(dev.Name); !ok || c != controller {
+				continue
+			}
+		}
+		members = append(members, dev)
+	}
+	return members
+}
+
+// raidController returns the controller a volume or passthrough device is
+// on: the SCSI port on Windows, which CSMI numbers its controllers by, and
+// the SCSI host on Linux
+func raidController(device string) (int, bool) {
+	if m := csmiPattern.FindStringSubmatch(device); m != nil {
+		n, err := strconv.Atoi(m[1])
+		return n, err == nil
+	}
+	if m := scsiBusPattern.FindStringSubmatch(device); m != nil {
+		n, err := strconv.Atoi(m[1])
+		return n, err == nil
+	}
+	if m := physicalDrivePattern.FindStringSubmatch(device); m != nil {
+		index, err := strconv.Atoi(m[1])
+		if err != nil {
+			return 0, false
+		}
+		return windowsSCSIPort(index)
+	}
+	return linuxSCSIHost(device)
+}
+
+// linuxSCSIHost returns the SCSI host of a block (/dev/sda) or SCSI generic
+// (/dev/sg2) device, from the H:C:T:L name of its SCSI device in sysfs
+func linuxSCSIHost(device string) (int, bool) {
+	name := filepath.Base(device)
+	for _, link := range []string{
+		filepath.Join(sysfsDir, "block", name, "device"),
+		filepath.Join(sysfsDir, "class", "scsi_generic", name, "device"),
+	} {
+		target, err := filepath.EvalSymlinks(link)
+		if err != nil {
+			continue
+		}
+		host, _, ok := strings.Cut(filepath.Base(target), ":")
+		if !ok {
+			return 0, false // Not a SCSI device, e.g. NVMe
+		}
+		n, err := strconv.Atoi(host)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// windowsSCSIPort returns the SCSI port of a physical disk
+func windowsSCSIPort(index int) (int, bool) {
+	if runtime.GOOS != "windows" {
+		return 0, false
+	}
+	var drives []win32DiskDrive
+	if err := queryCIM("Win32_DiskDrive", &drives); err != nil {
+		return 0, false
+	}
+	for _, d := range drives {
+		if int(d.Index) == index {
+			return int(d.SCSIPort), true
+		}
+	}
+	return 0, false
+}
+
+// isPassthroughDevice reports whether a scanned device is a drive reached
+// through a RAID controller
+func isPassthroughDevice(dev smart.ScanDevice) bool {
+	if strings.HasPrefix(dev.Name, "/dev/csmi") {
+		return true
+	}
+	for _, t := range passthroughTypes {
+		if strings.Contains(dev.Type, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// readRAIDMember reads identity and SMART data for one member drive
+func readRAIDMember(device, devType string) RAIDMember {
+	member := RAIDMember{
+		Device: device,
+		Type:   devType,
+		SMART:  &smart.Data{HealthStatus: smart.HealthUnknown},
+	}
+
+	if err := safeexec.ValidateDevicePath(device); err != nil {
+		return member
+	}
+	args := []string{"-i", "-A", "-H"}
+	if devType != "" {
+		if err := safeexec.ValidateSMARTDeviceType(devType); err != nil {
+			return member
+		}
+		args = append(args, "-d", devType)
+	}
+	args = append(args, device)
+
+	var output []byte
+	if Helper != nil {
+		if text, err := Helper.SMARTPassthrough(device, devType); err == nil {
+			output = []byte(text)
+		}
+	}
+	if output == nil {
+		// smartctl returns non-zero exit codes for drive warnings; use any output
+		output, _ = safeexec.Command("smartctl", args...).Output()
+	}
+	if len(output) == 0 {
+		return member
+	}
+
+	return parseRAIDMember(member, string(output))
+}
+
+// parseRAIDMember fills a member's identity and SMART data from smartctl -i -A -H output
+func parseRAIDMember(member RAIDMember, output string) RAIDMember {
+	member.SMART = smart.Parse(output)
+	member.Model = member.SMART.Model
+	member.Serial = member.SMART.Serial
+	member.Firmware = member.SMART.Firmware
+	return member
+}
+
+// worstMemberHealth returns the most severe health status among RAID members
+func worstMemberHealth(members []RAIDMember) string {
+	rank := map[string]int{smart.HealthUnknown: 0, smart.HealthGood: 1, smart.HealthWarning: 2, smart.HealthCritical: 3}
+	worst := smart.HealthUnknown
+	for _, m := range members {
+		if m.SMART != nil && rank[m.SMART.HealthStatus] > rank[worst] {
+			worst = m.SMART.HealthStatus
+		}
+	}
+	return worst
+}
@@ -0,0 +1,101 @@
+package inventory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mscrnt/project_fire/pkg/smart"
+)
+
+func TestParseSMARTScanPassthrough(t *testing.T) {
+	data := []byte(`{"devices":[
+		{"name":"/dev/sda","info_name":"/dev/sda [SAT]","type":"sat","protocol":"ATA"},
+		{"name":"/dev/bus/0","info_name":"/dev/bus/0 [megaraid_disk_00] [SAT]","type":"sat+megaraid,0","protocol":"ATA"},
+		{"name":"/dev/csmi0,1","info_name":"/dev/csmi0,1","type":"ata","protocol":"ATA"}]}`)
+
+	devices, err := smart.ParseScan(data)
+	if err != nil {
+		t.Fatalf("ParseScan returned error: %v", err)
+	}
+
+	var passthrough []string
+	for _, dev := range devices {
+		if isPassthroughDevice(dev) {
+			passthrough = append(passthrough, dev.Name)
+		}
+	}
+	if len(passthrough) != 2 || passthrough[0] != "/dev/bus/0" || passthrough[1] != "/dev/csmi0,1" {
+		t.Errorf("Expected megaraid and CSMI members, got %v", passthrough)
+	}
+}
+
+func TestParseRAIDMember(t *testing.T) {
+	output := `=== START OF INFORMATION SECTION ===
+Device Model:     Samsung SSD 870 EVO 1TB
+Serial Number:    S6PUNX0R123456
+Firmware Version: SVT02B6Q
+
+SMART overall-health self-assessment test result: FAILED!
+
+ID# ATTRIBUTE_NAME          FLAG     VALUE WORST THRESH TYPE      UPDATED  WHEN_FAILED RAW_VALUE
+  9 Power_On_Hours          0x0032   099   099   000    Old_age   Always       -       1234
+194 Temperature_Celsius     0x0022   062   052   000    Old_age   Always       -       38
+`
+	member := parseRAIDMember(RAIDMember{Device: "/dev/csmi0,1"}, output)
+
+	if member.Model != "Samsung SSD 870 EVO 1TB" || member.Serial != "S6PUNX0R123456" || member.Firmware != "SVT02B6Q" {
+		t.Errorf("Unexpected identity: %+v", member)
+	}
+	if member.SMART.HealthStatus != "Critical" || member.SMART.PowerOnHours != 1234 || member.SMART.Temperature != 38 {
+		t.Errorf("Unexpected SMART data: %+v", member.SMART)
+	}
+
+	members := []RAIDMember{{SMART: &smart.Data{HealthStatus: "Good"}}, member}
+	if got := worstMemberHealth(members); got != "Critical" {
+		t.Errorf("Expected worst health Critical, got %s", got)
+	}
+}
+
+func TestRAIDMemberDevices(t *testing.T) {
+	oldDir := sysfsDir
+	defer func() { sysfsDir = oldDir }()
+	sysfsDir = t.TempDir()
+
+	// sda is a megaraid volume on SCSI host 2, sdb a plain disk on host 0
+	for name, hctl := range map[string]string{"sda": "2:2:0:0", "sdb": "0:0:0:0"} {
+		target := filepath.Join(sysfsDir, "devices", "host", hctl)
+		if err := os.MkdirAll(target, 0o750); err != nil {
+			t.Fatal(err)
+		}
+		link := filepath.Join(sysfsDir, "block", name, "device")
+		if err := os.MkdirAll(filepath.Dir(link), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, link); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	devices := []smart.ScanDevice{
+		{Name: "/dev/sda", Type: "scsi"},
+		{Name: "/dev/bus/0", Type: "sat+megaraid,0"},
+		{Name: "/dev/bus/2", Type: "sat+megaraid,4"},
+		{Name: "/dev/bus/2", Type: "sat+megaraid,5"},
+		{Name: "/dev/csmi0,1", Type: "ata"},
+	}
+	members := raidMemberDevices(devices, "/dev/sda")
+	if len(members) != 2 || members[0].Type != "sat+megaraid,4" || members[1].Type != "sat+megaraid,5" {
+		t.Errorf("expected the drives of host 2, got %+v", members)
+	}
+
+	// The CSMI controller is the SCSI port
+	if c, ok := raidController("/dev/csmi1,3"); !ok || c != 1 {
+		t.Errorf("expected controller 1, got %d (%v)", c, ok)
+	}
+
+	// A volume whose controller cannot be told keeps every passthrough drive
+	if members := raidMemberDevices(devices, "/dev/nvme0n1"); len(members) != 4 {
+		t.Errorf("expected every passthrough drive, got %+v", members)
+	}
+}
@@ -1,7 +1,7 @@
 //go:build windows
 // +build windows
 
-package gui
+package inventory
 
 import (
 	"fmt"
@@ -95,8 +95,7 @@ func GetDriveInfoWMI() (map[string]DriveModel, error) {
 		// This would require additional WMI queries to map disk number to drive letters
 		// For now, we'll use a simplified approach
 
-		DebugLog("STORAGE", fmt.Sprintf("WMI COM: Disk %d - Model: %s, BusType: %d (%s)",
-			diskNumber, modelStr, busTypeInt, interfaceType))
+		storageLog.Debug("WMI COM disk", "disk", diskNumber, "model", modelStr, "bus_type", busTypeInt, "interface", interfaceType)
 
 		item.Release()
 	}
@@ -1,11 +1,11 @@
-package gui
+package inventory
 
 import (
 	"fmt"
-	"os/exec"
 	"runtime"
 	"strings"
 
+	"github.com/mscrnt/project_fire/pkg/safeexec"
 	"github.com/shirou/gopsutil/v3/cpu"
 	"github.com/shirou/gopsutil/v3/host"
 	"github.com/shirou/gopsutil/v3/mem"
@@ -121,7 +121,7 @@ func GetSystemInfo() (*SystemInfo, error) {
 func getWindowsHostMemory() float64 {
 	// Try to read from /proc/meminfo which might show host memory in some WSL configs
 	// In WSL2, we can try to query Windows through PowerShell
-	cmd := exec.Command("powershell.exe", "-Command", "(Get-CimInstance Win32_ComputerSystem).TotalPhysicalMemory")
+	cmd := safeexec.PowerShell("(Get-CimInstance Win32_ComputerSystem).TotalPhysicalMemory")
 	output, err := cmd.Output()
 	if err == nil {
 		var bytes uint64
@@ -0,0 +1,168 @@
+// Package ipmi reads the baseboard management controller (BMC) of server
+// boards through ipmitool: its sensors, such as chassis and board
+// temperatures, power supply input power and fan speeds, and its system
+// event log (SEL), where it records hardware errors like ECC errors, power
+// supply faults and processor machine checks.
+//
+// ipmitool reaches the local BMC through /dev/ipmi0 on Linux (the ipmi_si
+// and ipmi_devintf modules, which needs root) and the Microsoft IPMI driver
+// on Windows. Machines without ipmitool or a BMC report ErrUnavailable.
+//
+// The sensors package adds the BMC's sensors to its readings. A SEL watch
+// reads the event log before a run and after it; the entries the BMC added
+// in between are saved with the run as an "sel" annotation each and counted
+// as the sel_entries result, so the report lists them.
+package ipmi
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// ErrUnavailable is returned when ipmitool is not installed or cannot reach
+// a BMC
+var ErrUnavailable = errors.New("no BMC found through ipmitool")
+
+// Sensor kinds
+const (
+	KindTemperature = "temperature"
+	KindFan         = "fan"
+	KindVoltage     = "voltage"
+	KindCurrent     = "current"
+	KindPower       = "power"
+)
+
+// Sensor is one reading of a BMC sensor
+type Sensor struct {
+	Name  string  `json:"name"` // e.g. "System Temp" or "PS1 Input Power"
+	Kind  string  `json:"kind"`
+	Value float64 `json:"value"` // °C, RPM, V, A or W
+}
+
+// runIPMI runs ipmitool, replaced in tests
+var runIPMI = func(ctx context.Context, args ...string) (string, error) {
+	if _, err := exec.LookPath("ipmitool"); err != nil {
+		return "", ErrUnavailable
+	}
+	out, err := safeexec.CommandContext(ctx, "ipmitool", args...).Output()
+	if err != nil {
+		// No BMC, or no access to /dev/ipmi0
+		return "", fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return string(out), nil
+}
+
+// ReadSensors returns the readings of the BMC's temperature, fan, voltage,
+// current and power sensors. Sensors without a reading are left out.
+func ReadSensors(ctx context.Context) ([]Sensor, error) {
+	out, err := runIPMI(ctx, "sdr", "elist", "full")
+	if err != nil {
+		return nil, err
+	}
+	return parseSensors(out), nil
+}
+
+// parseSensors parses `ipmitool sdr elist full`, e.g.
+//
+//	CPU Temp         | 01h | ok  |  3.1 | 45 degrees C
+//	FAN1             | 41h | ok  | 29.1 | 5400 RPM
+//	PS1 Input Power  | 64h | ok  | 10.1 | 120 Watts
+//	FAN5             | 45h | ns  | 29.5 | No Reading
+func parseSensors(out string) []Sensor {
+	units := map[string]string{
+		"degrees C": KindTemperature,
+		"RPM":       KindFan,
+		"Volts":     KindVoltage,
+		"Amps":      KindCurrent,
+		"Watts":     KindPower,
+	}
+
+	var sensors []Sensor
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "|")
+		if len(fields) < 5 || strings.TrimSpace(fields[2]) == "ns" {
+			continue
+		}
+		value, unit, ok := strings.Cut(strings.TrimSpace(fields[4]), " ")
+		if !ok {
+			continue
+		}
+		kind, ok := units[unit]
+		if !ok {
+			continue // Discrete sensors, e.g. "0x01" or "Presence detected"
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+		sensors = append(sensors, Sensor{Name: strings.TrimSpace(fields[0]), Kind: kind, Value: v})
+	}
+	return sensors
+}
+
+// Entry is a record of the system event log
+type Entry struct {
+	ID        string `json:"id"`        // Record ID in hex, as ipmitool shows it
+	Timestamp string `json:"timestamp"` // BMC clock, e.g. "04/12/2026 10:15:32", or "Pre-Init"
+	Sensor    string `json:"sensor"`    // e.g. "Memory #0x87"
+	Event     string `json:"event"`     // e.g. "Correctable ECC | Asserted"
+}
+
+// String describes the entry, e.g. "Memory #0x87: Correctable ECC |
+// Asserted (BMC time 04/12/2026 10:15:32)"
+func (e Entry) String() string {
+	return fmt.Sprintf("%s: %s (BMC time %s)", e.Sensor, e.Event, e.Timestamp)
+}
+
+// key identifies the entry. Record IDs are reused once the log is cleared,
+// so the rest of the entry is part of it.
+func (e Entry) key() string {
+	return strings.Join([]string{e.ID, e.Timestamp, e.Sensor, e.Event}, "|")
+}
+
+// ReadSEL returns the entries of the system event log, oldest first
+func ReadSEL(ctx context.Context) ([]Entry, error) {
+	out, err := runIPMI(ctx, "sel", "elist")
+	if err != nil {
+		return nil, err
+	}
+	return parseSEL(out), nil
+}
+
+// parseSEL parses `ipmitool sel elist`, e.g.
+//
+//	 1 | 04/12/2026 | 10:15:32 | Memory #0x87 | Correctable ECC | Asserted
+//	1a | Pre-Init  |0000000005| Power Supply #0x51 | Presence detected | Asserted
+//	1b | 04/12/2026 | 10:20:01 | Temperature CPU1 Temp | Upper Critical going high | Asserted | Reading 96 > Threshold 95 degrees C
+func parseSEL(out string) []Entry {
+	var entries []Entry
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "|")
+		if len(fields) < 5 {
+			continue // e.g. "SEL has no entries"
+		}
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		timestamp := fields[1]
+		if fields[1] != "Pre-Init" {
+			timestamp += " " + fields[2]
+		}
+		entries = append(entries, Entry{
+			ID:        fields[0],
+			Timestamp: timestamp,
+			Sensor:    fields[3],
+			Event:     strings.Join(fields[4:], " | "),
+		})
+	}
+	return entries
+}
@@ -0,0 +1,87 @@
+package ipmi
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseSensors(t *testing.T) {
+	out := `CPU Temp         | 01h | ok  |  3.1 | 45 degrees C
+System Temp      | 11h | ok  |  7.1 | 30 degrees C
+FAN1             | 41h | ok  | 29.1 | 5400 RPM
+FAN5             | 45h | ns  | 29.5 | No Reading
+12V              | 30h | ok  |  7.17 | 12.19 Volts
+PS1 Input Power  | 64h | ok  | 10.1 | 120 Watts
+PS1 Status       | c8h | ok  | 10.1 | Presence detected
+`
+	want := []Sensor{
+		{Name: "CPU Temp", Kind: KindTemperature, Value: 45},
+		{Name: "System Temp", Kind: KindTemperature, Value: 30},
+		{Name: "FAN1", Kind: KindFan, Value: 5400},
+		{Name: "12V", Kind: KindVoltage, Value: 12.19},
+		{Name: "PS1 Input Power", Kind: KindPower, Value: 120},
+	}
+	if got := parseSensors(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestParseSEL(t *testing.T) {
+	out := `   1 | 04/12/2026 | 10:15:32 | Memory #0x87 | Correctable ECC | Asserted
+  1a | Pre-Init  |0000000005| Power Supply #0x51 | Presence detected | Asserted
+  1b | 04/12/2026 | 10:20:01 | Temperature CPU1 Temp | Upper Critical going high | Asserted | Reading 96 > Threshold 95 degrees C
+`
+	entries := parseSEL(out)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %+v", entries)
+	}
+	if got := entries[0].String(); got != "Memory #0x87: Correctable ECC | Asserted (BMC time 04/12/2026 10:15:32)" {
+		t.Errorf("unexpected entry %q", got)
+	}
+	if entries[1].Timestamp != "Pre-Init" {
+		t.Errorf("expected a Pre-Init timestamp, got %q", entries[1].Timestamp)
+	}
+	if got := entries[2].Event; got != "Upper Critical going high | Asserted | Reading 96 > Threshold 95 degrees C" {
+		t.Errorf("unexpected event %q", got)
+	}
+	if got := parseSEL("SEL has no entries\n"); len(got) != 0 {
+		t.Errorf("expected no entries, got %+v", got)
+	}
+}
+
+func TestWatchSEL(t *testing.T) {
+	log := []Entry{{ID: "1", Timestamp: "04/12/2026 10:15:32", Sensor: "Memory #0x87", Event: "Correctable ECC | Asserted"}}
+	read := func(context.Context) ([]Entry, error) { return log, nil }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := WatchSEL(ctx, read)
+	// The log is cleared and the BMC reuses record ID 1 during the run
+	log = []Entry{
+		{ID: "1", Timestamp: "04/12/2026 11:00:00", Sensor: "PS2 Status", Event: "Power Supply AC lost | Asserted"},
+	}
+	cancel()
+	r := w.Stop()
+	if !r.Available || len(r.Entries) != 1 || r.Entries[0].Sensor != "PS2 Status" {
+		t.Errorf("expected the new entry, got %+v", r)
+	}
+	if got := r.Summary(); got != "1 new BMC event log entry" {
+		t.Errorf("unexpected summary %q", got)
+	}
+
+	unavailable := func(context.Context) ([]Entry, error) { return nil, ErrUnavailable }
+	if r := WatchSEL(context.Background(), unavailable).Stop(); r.Available {
+		t.Errorf("expected no report without a BMC, got %+v", r)
+	}
+}
+
+func TestReadSensorsUnavailable(t *testing.T) {
+	old := runIPMI
+	runIPMI = func(context.Context, ...string) (string, error) { return "", ErrUnavailable }
+	t.Cleanup(func() { runIPMI = old })
+
+	if _, err := ReadSensors(context.Background()); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected ErrUnavailable, got %v", err)
+	}
+}
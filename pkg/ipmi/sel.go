@@ -0,0 +1,124 @@
+package ipmi
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+// AnnotationKind is the kind of the annotations new SEL entries are saved as
+const AnnotationKind = "sel"
+
+// MetricSELEntries is the result counting the entries the BMC logged during
+// the run
+const MetricSELEntries = "sel_entries"
+
+// readTimeout bounds the final read of the log, which is slow on large logs
+const readTimeout = 30 * time.Second
+
+// SELReport holds the entries the BMC logged during a run
+type SELReport struct {
+	Available bool // Whether the log was read before and after the run
+	Time      time.Time
+	Entries   []Entry
+}
+
+// Summary describes the report in one line
+func (r *SELReport) Summary() string {
+	if !r.Available {
+		return "BMC event log not read: " + ErrUnavailable.Error()
+	}
+	return Describe(len(r.Entries))
+}
+
+// Describe names the number of new entries, e.g. "2 new BMC event log entries"
+func Describe(count int) string {
+	switch count {
+	case 0:
+		return "No new BMC event log entries"
+	case 1:
+		return "1 new BMC event log entry"
+	}
+	return fmt.Sprintf("%d new BMC event log entries", count)
+}
+
+// Save stores the report with the run: the number of new entries as a
+// result and each entry as an annotation
+func (r *SELReport) Save(database *db.DB, runID int64) error {
+	if !r.Available {
+		return nil
+	}
+	values := map[string]float64{MetricSELEntries: float64(len(r.Entries))}
+	if err := database.CreateResults(runID, values, map[string]string{}); err != nil {
+		return err
+	}
+	for _, e := range r.Entries {
+		// The BMC clock is often off, so the entry is placed at the time it
+		// was read and keeps its own timestamp in the message
+		a := &db.Annotation{RunID: &runID, Time: r.Time, Source: "bmc", Kind: AnnotationKind, Message: e.String()}
+		if err := database.CreateAnnotation(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FromResults reads the number of new entries back from a run's results. ok
+// is false when the run was not watched or the machine has no BMC.
+func FromResults(results []*db.Result) (entries int, ok bool) {
+	for _, r := range results {
+		if r.Metric == MetricSELEntries {
+			return int(r.Value), true
+		}
+	}
+	return 0, false
+}
+
+// ReadSELFunc reads the system event log
+type ReadSELFunc func(ctx context.Context) ([]Entry, error)
+
+// SELWatch compares the system event log before and after a run
+type SELWatch struct {
+	ctx    context.Context
+	read   ReadSELFunc
+	before map[string]bool // nil when the log could not be read
+}
+
+// WatchSEL reads the log before returning, so entries that predate the run
+// are not blamed on it
+func WatchSEL(ctx context.Context, read ReadSELFunc) *SELWatch {
+	w := &SELWatch{ctx: ctx, read: read}
+	entries, err := read(ctx)
+	if err != nil {
+		return w
+	}
+	w.before = make(map[string]bool, len(entries))
+	for _, e := range entries {
+		w.before[e.key()] = true
+	}
+	return w
+}
+
+// Stop reads the log again, even when the run's context has ended, and
+// returns the entries added since the watch started
+func (w *SELWatch) Stop() *SELReport {
+	r := &SELReport{Time: time.Now()}
+	if w.before == nil {
+		return r
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(w.ctx), readTimeout)
+	defer cancel()
+	entries, err := w.read(ctx)
+	if err != nil {
+		return r
+	}
+	r.Available = true
+	for _, e := range entries {
+		if !w.before[e.key()] {
+			r.Entries = append(r.Entries, e)
+		}
+	}
+	return r
+}
@@ -0,0 +1,152 @@
+// Package journal finds the runs that a crash, power loss or restart cut
+// short, which is what burn-in machines do now and then.
+//
+// A run is journaled as it is created: the database records the machine and
+// process running it. While the run is in progress Start records a
+// heartbeat every DefaultInterval. When F.I.R.E. starts again, Recover looks
+// for runs of this machine that never ended and whose process is gone, and
+// marks them interrupted as of their last heartbeat, so they no longer show
+// as running forever.
+package journal
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/logging"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// DefaultInterval is how often a run in progress records a heartbeat
+const DefaultInterval = 15 * time.Second
+
+// startSlack allows for process start times read with a resolution of a
+// second or so
+const startSlack = 5 * time.Second
+
+// AnnotationKind marks the annotation recorded when a run was interrupted
+const AnnotationKind = "interrupted"
+
+var logger = logging.For("journal")
+
+// processStarted is replaced in tests
+var processStarted = defaultProcessStarted
+
+// defaultProcessStarted returns when the process with a PID started, and
+// false if no such process runs. A zero time means it runs but its start
+// time cannot be read.
+func defaultProcessStarted(pid int) (time.Time, bool) {
+	if pid <= 0 {
+		return time.Time{}, false
+	}
+	p, err := process.NewProcess(int32(pid)) // #nosec G115 -- PIDs fit in 32 bits
+	if err != nil {
+		return time.Time{}, false
+	}
+	ms, err := p.CreateTime()
+	if err != nil {
+		return time.Time{}, true
+	}
+	return time.UnixMilli(ms), true
+}
+
+// Alive reports whether the process that journaled something at started is
+// still running. A process with the same PID that started later, e.g. after
+// a restart, is another process.
+func Alive(pid int, started time.Time) bool {
+	created, ok := processStarted(pid)
+	if !ok {
+		return false
+	}
+	return created.IsZero() || !created.After(started.Add(startSlack))
+}
+
+// Keep calls beat every interval until the returned stop is called. Failed
+// beats are logged; the next one tries again.
+func Keep(interval time.Duration, beat func(time.Time) error) (stop func()) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case t := <-ticker.C:
+				if err := beat(t); err != nil {
+					logger.Warn("Failed to record heartbeat", "error", err)
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}
+}
+
+// Start keeps the heartbeat of a run until the returned stop is called. The
+// database is flushed first, so the run's journal survives a power loss.
+func Start(database *db.DB, run *db.Run) (stop func()) {
+	if err := database.Flush(); err != nil {
+		logger.Warn("Could not flush the journal of a run", "run", run.ID, "error", err)
+	}
+	return Keep(DefaultInterval, func(t time.Time) error {
+		return database.Heartbeat(run.ID, t)
+	})
+}
+
+// Message is the error recorded for a run interrupted after lastSeen
+func Message(lastSeen time.Time) string {
+	return fmt.Sprintf("interrupted: the run never finished, e.g. the machine lost power, restarted or F.I.R.E. crashed; last heartbeat at %s",
+		lastSeen.Format("2006-01-02 15:04:05"))
+}
+
+// Recover marks the runs of this machine whose process is gone as
+// interrupted at their last heartbeat, with an annotation at that time for
+// the event timeline, and returns them
+func Recover(database *db.DB) ([]*db.Run, error) {
+	open, err := database.OpenRuns(db.Hostname())
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []*db.Run
+	for _, r := range open {
+		if Alive(r.PID, r.StartTime) {
+			continue
+		}
+		at := r.LastSeen()
+		if err := database.MarkInterrupted(r.ID, at, Message(at)); err != nil {
+			return runs, err
+		}
+		id := r.ID
+		annotation := &db.Annotation{
+			RunID: &id, Time: at, Source: "journal", Kind: AnnotationKind,
+			Message: fmt.Sprintf("Run %d (%s) was interrupted", r.ID, r.Name),
+		}
+		if err := database.CreateAnnotation(annotation); err != nil {
+			logger.Warn("Could not annotate an interrupted run", "run", r.ID, "error", err)
+		}
+		logger.Warn("Found an interrupted run", "run", r.ID, "plugin", r.Name, "last_seen", at)
+
+		run, err := database.GetRun(r.ID)
+		if err != nil {
+			return runs, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
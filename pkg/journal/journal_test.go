@@ -0,0 +1,113 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+func TestAlive(t *testing.T) {
+	started := time.Now()
+	if !Alive(os.Getpid(), started) {
+		t.Error("expected this process to be alive")
+	}
+	// A process that started after the run is another process reusing the PID
+	if Alive(os.Getpid(), started.Add(-24*time.Hour)) {
+		t.Error("expected a process started after the run not to be its owner")
+	}
+	if Alive(0, started) {
+		t.Error("expected no process for PID 0")
+	}
+}
+
+func TestRecover(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = database.Close() }()
+
+	running, err := database.CreateRun("cpu", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crashed, err := database.CreateRun("memory", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lastBeat := time.Now().Add(-time.Minute).Truncate(time.Second)
+	if _, err := database.Exec(`UPDATE runs SET start_time = ? WHERE id = ?`, lastBeat.Add(-time.Hour), crashed.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.Heartbeat(crashed.ID, lastBeat); err != nil {
+		t.Fatal(err)
+	}
+	// Recorded on another machine sharing the database
+	other, err := database.CreateRun("gpu", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.Exec(`UPDATE runs SET machine = ? WHERE id = ?`, "bench-02", other.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	processStarted = func(pid int) (time.Time, bool) {
+		return time.Time{}, pid == os.Getpid()
+	}
+	defer func() { processStarted = defaultProcessStarted }()
+	if _, err := database.Exec(`UPDATE runs SET pid = ? WHERE id IN (?, ?)`, os.Getpid()+1, crashed.ID, other.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	runs, err := Recover(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].ID != crashed.ID {
+		t.Fatalf("expected only the crashed run, got %+v", runs)
+	}
+	run := runs[0]
+	if !run.Interrupted || run.Success || run.EndTime == nil || !run.EndTime.Equal(lastBeat) ||
+		run.GetStatus() != db.RunStatusInterrupted || !strings.Contains(run.Error, lastBeat.Format("15:04:05")) {
+		t.Errorf("expected the run interrupted at its last heartbeat, got %+v", run)
+	}
+
+	annotations, err := database.ListAnnotations(lastBeat.Add(-time.Second), lastBeat.Add(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(annotations) != 1 || annotations[0].Kind != AnnotationKind || *annotations[0].RunID != crashed.ID {
+		t.Errorf("expected an annotation of the interruption, got %+v", annotations)
+	}
+
+	if r, _ := database.GetRun(running.ID); r.EndTime != nil {
+		t.Errorf("expected the run of a live process to stay open, got %+v", r)
+	}
+	if again, err := Recover(database); err != nil || len(again) != 0 {
+		t.Errorf("expected nothing left to recover, got %+v (%v)", again, err)
+	}
+}
+
+func TestKeep(t *testing.T) {
+	var beats atomic.Int32
+	stop := Keep(5*time.Millisecond, func(time.Time) error {
+		beats.Add(1)
+		return nil
+	})
+	time.Sleep(50 * time.Millisecond)
+	stop()
+	stop()
+	n := beats.Load()
+	if n == 0 {
+		t.Fatal("expected heartbeats")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if beats.Load() != n {
+		t.Error("expected no heartbeats after stop")
+	}
+}
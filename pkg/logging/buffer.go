@@ -0,0 +1,171 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBufferSize is how many records the in-memory buffer keeps
+const DefaultBufferSize = 5000
+
+// Entry is a record kept in the buffer
+type Entry struct {
+	Seq       uint64 // Increases by one with each record
+	Time      time.Time
+	Level     slog.Level
+	Component string
+	Message   string
+	Fields    string // Other fields as key=value pairs
+}
+
+// Ring keeps the latest records, oldest first, for the GUI's log viewer
+type Ring struct {
+	mu      sync.Mutex
+	entries []Entry // Circular once full
+	start   int     // Index of the oldest entry
+	size    int
+	seq     uint64
+}
+
+// Buffer is the ring every logger writes to once Setup has run
+var Buffer = NewRing(DefaultBufferSize)
+
+// NewRing creates a ring keeping up to size records
+func NewRing(size int) *Ring {
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	return &Ring{size: size}
+}
+
+// Add appends a record, dropping the oldest once the ring is full
+func (r *Ring) Add(e Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	e.Seq = r.seq
+	if len(r.entries) < r.size {
+		r.entries = append(r.entries, e)
+		return
+	}
+	r.entries[r.start] = e
+	r.start = (r.start + 1) % r.size
+}
+
+// Entries returns a copy of the kept records
+func (r *Ring) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := make([]Entry, 0, len(r.entries))
+	entries = append(entries, r.entries[r.start:]...)
+	return append(entries, r.entries[:r.start]...)
+}
+
+// Seq returns the sequence number of the latest record, to tell whether
+// records were added since the entries were last read
+func (r *Ring) Seq() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.seq
+}
+
+// Clear drops the kept records
+func (r *Ring) Clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries, r.start = nil, 0
+}
+
+// Filter selects entries
+type Filter struct {
+	Level     slog.Level // Lowest level shown
+	Component string     // "" for every component
+	Text      string     // Found in the message or fields, ignoring case
+}
+
+// Match reports whether an entry passes the filter
+func (f Filter) Match(e Entry) bool {
+	if e.Level < f.Level || (f.Component != "" && e.Component != f.Component) {
+		return false
+	}
+	if f.Text == "" {
+		return true
+	}
+	text := strings.ToLower(f.Text)
+	return strings.Contains(strings.ToLower(e.Message), text) || strings.Contains(strings.ToLower(e.Fields), text)
+}
+
+// handler returns a handler adding records at or above the level
+func (r *Ring) handler(level slog.Level) slog.Handler {
+	return &ringHandler{ring: r, level: level}
+}
+
+type ringHandler struct {
+	ring      *Ring
+	level     slog.Level
+	component string
+	prefix    string // Of the current group, e.g. "disk."
+	fields    []string
+}
+
+func (h *ringHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *ringHandler) Handle(_ context.Context, r slog.Record) error {
+	e := Entry{Time: r.Time, Level: r.Level, Component: h.component, Message: r.Message}
+	fields := append([]string(nil), h.fields...)
+	r.Attrs(func(a slog.Attr) bool {
+		if h.prefix == "" && a.Key == ComponentKey {
+			e.Component = a.Value.String()
+		} else {
+			fields = appendAttr(fields, h.prefix, a)
+		}
+		return true
+	})
+	e.Fields = strings.Join(fields, " ")
+	h.ring.Add(e)
+	return nil
+}
+
+func (h *ringHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.fields = append([]string(nil), h.fields...)
+	for _, a := range attrs {
+		if h.prefix == "" && a.Key == ComponentKey {
+			c.component = a.Value.String()
+			continue
+		}
+		c.fields = appendAttr(c.fields, h.prefix, a)
+	}
+	return &c
+}
+
+func (h *ringHandler) WithGroup(name string) slog.Handler {
+	c := *h
+	c.prefix = h.prefix + name + "."
+	return &c
+}
+
+// appendAttr appends an attribute as key=value, flattening groups
+func appendAttr(fields []string, prefix string, a slog.Attr) []string {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		for _, g := range a.Value.Group() {
+			fields = appendAttr(fields, prefix+a.Key+".", g)
+		}
+		return fields
+	}
+	if a.Key == "" {
+		return fields
+	}
+	value := a.Value.String()
+	if strings.ContainsAny(value, " =\"") {
+		value = fmt.Sprintf("%q", value)
+	}
+	return append(fields, prefix+a.Key+"="+value)
+}
@@ -0,0 +1,222 @@
+// Package logging is the structured logger shared by bench and the GUI.
+//
+// Each part of the program logs through its own logger from For, which tags
+// every record with a component field, e.g. component=storage. Records at
+// or above the configured level go to the console as text, to a log file as
+// JSON lines, rotated by size, and to an in-memory buffer the GUI's log
+// viewer reads. Loggers can be created before Setup runs, e.g. as package
+// variables; until then they discard their records.
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+)
+
+// Levels, from the most to the least verbose. Alerts are the warnings the
+// GUI raises to the operator, which the event timeline shows apart.
+const (
+	LevelDebug = slog.LevelDebug
+	LevelInfo  = slog.LevelInfo
+	LevelWarn  = slog.LevelWarn
+	LevelAlert = slog.Level(6)
+	LevelError = slog.LevelError
+)
+
+// Defaults of the --log-level and --log-file flags
+const (
+	DefaultLevel = "info"
+	LevelUsage   = "Log level: debug, info, warn, alert or error"
+	FileUsage    = "Write the log to this file as JSON lines, rotated by size"
+)
+
+// ComponentKey is the field naming the part of the program a record is from
+const ComponentKey = "component"
+
+// Options configure where records go
+type Options struct {
+	Level      slog.Level
+	Console    io.Writer // Text records, nil for none
+	File       string    // JSON records, "" for none
+	MaxSize    int64     // Bytes a file grows to before rotating; 0 = DefaultMaxSize
+	MaxBackups int       // Rotated files kept; 0 = DefaultMaxBackups
+}
+
+// ParseLevel parses a level name, as given to --log-level
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return LevelDebug, nil
+	case "", "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "alert":
+		return LevelAlert, nil
+	case "error":
+		return LevelError, nil
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q (want debug, info, warn, alert or error)", name)
+}
+
+// LevelName returns the name a level is written with, e.g. "WARN" or "ALERT"
+func LevelName(level slog.Level) string {
+	if level == LevelAlert {
+		return "ALERT"
+	}
+	return level.String()
+}
+
+// target is the handler every logger writes to, replaced by Setup
+var target atomic.Pointer[slog.Handler]
+
+// level is the level of the latest Setup
+var level slog.LevelVar
+
+func init() {
+	var discard slog.Handler = discardHandler{}
+	target.Store(&discard)
+}
+
+// For returns the logger of a component
+func For(component string) *slog.Logger {
+	return slog.New(dispatchHandler{}).With(ComponentKey, component)
+}
+
+// Level returns the lowest level records are kept at
+func Level() slog.Level {
+	return level.Level()
+}
+
+// Setup sends the records of every logger at or above the level to the
+// console, file and buffer of the options. The returned closer closes the
+// file.
+func Setup(opts Options) (io.Closer, error) {
+	replace := func(_ []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.LevelKey {
+			if level, ok := a.Value.Any().(slog.Level); ok {
+				a.Value = slog.StringValue(LevelName(level))
+			}
+		}
+		return a
+	}
+	handlerOpts := &slog.HandlerOptions{Level: opts.Level, ReplaceAttr: replace}
+
+	handlers := []slog.Handler{Buffer.handler(opts.Level)}
+	if opts.Console != nil {
+		handlers = append(handlers, slog.NewTextHandler(opts.Console, handlerOpts))
+	}
+	var closer io.Closer = nopCloser{}
+	if opts.File != "" {
+		file, err := OpenRotating(opts.File, opts.MaxSize, opts.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		handlers = append(handlers, slog.NewJSONHandler(file, handlerOpts))
+		closer = file
+	}
+
+	var h slog.Handler = fanoutHandler(handlers)
+	target.Store(&h)
+	level.Set(opts.Level)
+	return closer, nil
+}
+
+// Configure sets up logging from the --log-level and --log-file flags
+func Configure(level, file string, console io.Writer) (io.Closer, error) {
+	l, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	return Setup(Options{Level: l, Console: console, File: file})
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+// dispatchHandler hands records to the current target, keeping the fields
+// and groups a logger was given to apply them to it
+type dispatchHandler struct {
+	ops []func(slog.Handler) slog.Handler
+}
+
+func (h dispatchHandler) current() slog.Handler {
+	handler := *target.Load()
+	for _, op := range h.ops {
+		handler = op(handler)
+	}
+	return handler
+}
+
+func (h dispatchHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return (*target.Load()).Enabled(ctx, level)
+}
+
+func (h dispatchHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.current().Handle(ctx, r)
+}
+
+func (h dispatchHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler { return handler.WithAttrs(attrs) })
+}
+
+func (h dispatchHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler { return handler.WithGroup(name) })
+}
+
+func (h dispatchHandler) with(op func(slog.Handler) slog.Handler) slog.Handler {
+	ops := make([]func(slog.Handler) slog.Handler, len(h.ops), len(h.ops)+1)
+	copy(ops, h.ops)
+	return dispatchHandler{ops: append(ops, op)}
+}
+
+// fanoutHandler hands each record to every handler that takes its level
+type fanoutHandler []slog.Handler
+
+func (f fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range f {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (f fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (f fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
+
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (d discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return d }
+func (d discardHandler) WithGroup(string) slog.Handler           { return d }
@@ -0,0 +1,145 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	for name, want := range map[string]slog.Level{
+		"debug": LevelDebug, "INFO": LevelInfo, "": LevelInfo, "warning": LevelWarn, "alert": LevelAlert, "error": LevelError,
+	} {
+		if got, err := ParseLevel(name); err != nil || got != want {
+			t.Errorf("%q: expected %v, got %v (%v)", name, want, got, err)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+	if LevelName(LevelAlert) != "ALERT" || LevelName(LevelWarn) != "WARN" {
+		t.Errorf("unexpected level names %s and %s", LevelName(LevelAlert), LevelName(LevelWarn))
+	}
+}
+
+func TestSetup(t *testing.T) {
+	// Created before Setup, as package variables are
+	storage := For("storage")
+	storage.Error("discarded before setup")
+
+	Buffer.Clear()
+	var console bytes.Buffer
+	path := filepath.Join(t.TempDir(), "logs", "fire.log")
+	closer, err := Setup(Options{Level: LevelInfo, Console: &console, File: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _, _ = Setup(Options{Level: LevelError + 1}) }()
+
+	storage.Debug("below the level")
+	storage.With("drive", "C:").Info("drive found", "model", "Samsung SSD 990 PRO")
+	For("gui").Log(context.Background(), LevelAlert, "limited functionality")
+	if err := closer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	text := console.String()
+	if strings.Contains(text, "below the level") || strings.Contains(text, "before setup") {
+		t.Errorf("expected only records at the level, got %s", text)
+	}
+	if !strings.Contains(text, `component=storage drive=C: msg="drive found"`) &&
+		!strings.Contains(text, `msg="drive found" component=storage drive=C:`) {
+		t.Errorf("expected the component and fields on the console, got %s", text)
+	}
+	if !strings.Contains(text, "level=ALERT") {
+		t.Errorf("expected the alert level by name, got %s", text)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 JSON lines, got %q", data)
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["component"] != "storage" || record["drive"] != "C:" || record["level"] != "INFO" {
+		t.Errorf("unexpected record %v", record)
+	}
+
+	entries := Buffer.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 buffered entries, got %+v", entries)
+	}
+	e := entries[0]
+	if e.Component != "storage" || e.Message != "drive found" || e.Fields != `drive=C: model="Samsung SSD 990 PRO"` {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if entries[1].Level != LevelAlert || entries[1].Seq != e.Seq+1 {
+		t.Errorf("unexpected alert entry %+v", entries[1])
+	}
+}
+
+func TestRing(t *testing.T) {
+	r := NewRing(3)
+	for _, msg := range []string{"a", "b", "c", "d"} {
+		r.Add(Entry{Message: msg})
+	}
+	entries := r.Entries()
+	if len(entries) != 3 || entries[0].Message != "b" || entries[2].Message != "d" || r.Seq() != 4 {
+		t.Errorf("expected the latest 3 entries oldest first, got %+v", entries)
+	}
+
+	e := Entry{Level: LevelWarn, Component: "spd", Message: "SPD read failed", Fields: "slot=2"}
+	for _, c := range []struct {
+		f    Filter
+		want bool
+	}{
+		{Filter{}, true},
+		{Filter{Level: LevelError}, false},
+		{Filter{Component: "spd"}, true},
+		{Filter{Component: "storage"}, false},
+		{Filter{Text: "read FAILED"}, true},
+		{Filter{Text: "slot=2"}, true},
+		{Filter{Text: "timeout"}, false},
+	} {
+		if got := c.f.Match(e); got != c.want {
+			t.Errorf("%+v: expected %v", c.f, c.want)
+		}
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fire.log")
+	f, err := OpenRotating(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = f.Close()
+
+	for file, want := range map[string]string{path: "fourth\n", path + ".1": "third\n", path + ".2": "second\n"} {
+		if data, _ := os.ReadFile(file); string(data) != want {
+			t.Errorf("%s: expected %q, got %q", filepath.Base(file), want, data)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); err == nil {
+		t.Error("expected the oldest file beyond the backups to be dropped")
+	}
+	if _, err := f.Write([]byte("late\n")); err == nil {
+		t.Error("expected writing a closed file to fail")
+	}
+}
@@ -0,0 +1,119 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Defaults of file rotation
+const (
+	DefaultMaxSize    = 10 << 20 // 10 MB
+	DefaultMaxBackups = 5
+)
+
+// RotatingFile is a log file that is moved aside once it reaches its
+// maximum size. fire.log becomes fire.log.1, fire.log.1 becomes fire.log.2
+// and so on, dropping the oldest beyond the backups kept.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// OpenRotating opens a log file for appending, creating its directory
+func OpenRotating(path string, maxSize int64, maxBackups int) (*RotatingFile, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	if maxBackups <= 0 {
+		maxBackups = DefaultMaxBackups
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	f := &RotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Backups returns the paths of a log's rotated files, newest first, whether
+// or not they exist
+func Backups(path string, maxBackups int) []string {
+	if maxBackups <= 0 {
+		maxBackups = DefaultMaxBackups
+	}
+	paths := make([]string, maxBackups)
+	for i := range paths {
+		paths[i] = fmt.Sprintf("%s.%d", path, i+1)
+	}
+	return paths
+}
+
+// Write appends to the file, rotating it first if the write would take it
+// past its maximum size
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close closes the file
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) // #nosec G304 -- log file chosen by the operator
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+// rotate shifts the backups up by one and starts a new file
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	f.file = nil
+	backups := Backups(f.path, f.maxBackups)
+	_ = os.Remove(backups[len(backups)-1])
+	for i := len(backups) - 1; i > 0; i-- {
+		_ = os.Rename(backups[i-1], backups[i])
+	}
+	if err := os.Rename(f.path, backups[0]); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	return f.open()
+}
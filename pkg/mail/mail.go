@@ -0,0 +1,227 @@
+// Package mail sends email through an SMTP server, such as run reports
+// after unattended tests and alerts.
+//
+// The server is reached with TLS from the start (port 465), with STARTTLS,
+// or in the clear for relays on a trusted network.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TLSMode is how the connection to the SMTP server is secured
+type TLSMode string
+
+// TLS modes. The zero value uses TLS on port 465 and otherwise STARTTLS when
+// the server offers it.
+const (
+	ImplicitTLS TLSMode = "tls"      // TLS from the start, usually port 465
+	StartTLS    TLSMode = "starttls" // Upgrade with STARTTLS, which the server must offer
+	NoTLS       TLSMode = "none"     // Plain text, for relays on a trusted network
+)
+
+// Config is the SMTP server mail is sent through
+type Config struct {
+	Host     string  `json:"host"`
+	Port     int     `json:"port,omitempty"` // Defaults to 465 with TLS, otherwise 587
+	Username string  `json:"username,omitempty"`
+	Password string  `json:"password,omitempty"`
+	From     string  `json:"from"`
+	TLS      TLSMode `json:"tls,omitempty"`
+}
+
+// Validate checks that mail can be sent with the configuration
+func (c Config) Validate() error {
+	if c.Host == "" {
+		return errors.New("SMTP host is required")
+	}
+	if _, err := netmail.ParseAddress(c.From); err != nil {
+		return fmt.Errorf("invalid sender %q: %w", c.From, err)
+	}
+	switch c.TLS {
+	case "", ImplicitTLS, StartTLS, NoTLS:
+	default:
+		return fmt.Errorf("unknown TLS mode %q, expected tls, starttls or none", c.TLS)
+	}
+	if c.Port < 0 || c.Port > 65535 {
+		return fmt.Errorf("invalid SMTP port %d", c.Port)
+	}
+	return nil
+}
+
+// implicitTLS reports whether the connection is TLS from the start
+func (c Config) implicitTLS() bool {
+	return c.TLS == ImplicitTLS || (c.TLS == "" && c.Port == 465)
+}
+
+// port returns the configured port or the default for the TLS mode
+func (c Config) port() int {
+	switch {
+	case c.Port != 0:
+		return c.Port
+	case c.implicitTLS():
+		return 465
+	}
+	return 587
+}
+
+// Attachment is a file sent with a message
+type Attachment struct {
+	Name        string
+	ContentType string // Guessed from Name when empty
+	Data        []byte
+}
+
+// Message is a plain text email with optional attachments
+type Message struct {
+	To          []string
+	Subject     string
+	Body        string
+	Attachments []Attachment
+}
+
+// Send delivers the message through the server in cfg
+func Send(ctx context.Context, cfg Config, msg Message) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if len(msg.To) == 0 {
+		return errors.New("no recipients")
+	}
+	if err := send(ctx, cfg, msg); err != nil {
+		return fmt.Errorf("failed to send mail via %s: %w", cfg.Host, err)
+	}
+	return nil
+}
+
+func send(ctx context.Context, cfg Config, msg Message) error {
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.port())))
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	tlsConfig := &tls.Config{ServerName: cfg.Host, MinVersion: tls.VersionTLS12}
+	if cfg.implicitTLS() {
+		conn = tls.Client(conn, tlsConfig)
+	}
+	client, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer func() { _ = client.Close() }()
+
+	if !cfg.implicitTLS() && cfg.TLS != NoTLS {
+		ok, _ := client.Extension("STARTTLS")
+		switch {
+		case ok:
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		case cfg.TLS == StartTLS:
+			return errors.New("server does not offer STARTTLS")
+		}
+	}
+	if cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(address(cfg.From)); err != nil {
+		return err
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(address(to)); err != nil {
+			return fmt.Errorf("recipient %s: %w", to, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg.Bytes(cfg.From, time.Now())); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// address returns the bare address of "Name <addr>"
+func address(s string) string {
+	if a, err := netmail.ParseAddress(s); err == nil {
+		return a.Address
+	}
+	return s
+}
+
+// Bytes formats the message with its headers, as a multipart message when
+// it has attachments
+func (m Message) Bytes(from string, date time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(m.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	body := strings.ReplaceAll(strings.ReplaceAll(m.Body, "\r\n", "\n"), "\n", "\r\n")
+
+	if len(m.Attachments) == 0 {
+		b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+		b.WriteString(body)
+		b.WriteString("\r\n")
+		return b.Bytes()
+	}
+
+	boundary := newBoundary()
+	fmt.Fprintf(&b, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", boundary)
+	fmt.Fprintf(&b, "--%s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n", boundary, body)
+	for _, a := range m.Attachments {
+		contentType := a.ContentType
+		if contentType == "" {
+			contentType = mime.TypeByExtension(filepath.Ext(a.Name))
+		}
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		name := mime.QEncoding.Encode("utf-8", a.Name)
+		fmt.Fprintf(&b, "--%s\r\n", boundary)
+		fmt.Fprintf(&b, "Content-Type: %s; name=%q\r\n", contentType, name)
+		fmt.Fprintf(&b, "Content-Disposition: attachment; filename=%q\r\n", name)
+		b.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+		encoded := base64.StdEncoding.EncodeToString(a.Data)
+		for len(encoded) > 76 {
+			b.WriteString(encoded[:76] + "\r\n")
+			encoded = encoded[76:]
+		}
+		b.WriteString(encoded + "\r\n")
+	}
+	fmt.Fprintf(&b, "--%s--\r\n", boundary)
+	return b.Bytes()
+}
+
+// newBoundary returns a random multipart boundary
+func newBoundary() string {
+	buf := make([]byte, 12)
+	_, _ = rand.Read(buf)
+	return "fire-" + hex.EncodeToString(buf)
+}
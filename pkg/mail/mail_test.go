@@ -0,0 +1,157 @@
+package mail
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	netmail "net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMessageBytes(t *testing.T) {
+	date := time.Date(2026, 3, 1, 22, 0, 0, 0, time.UTC)
+	msg := Message{
+		To:      []string{"ops@example.com", "lab@example.com"},
+		Subject: "[F.I.R.E. bench-01] cpu run #12 PASSED",
+		Body:    "Run finished.\nSee the attached report.",
+		Attachments: []Attachment{
+			{Name: "run-12.html", Data: []byte(strings.Repeat("<p>report</p>", 20))},
+		},
+	}
+
+	parsed, err := netmail.ReadMessage(bytes.NewReader(msg.Bytes("F.I.R.E. <fire@example.com>", date)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := parsed.Header.Get("To"); got != "ops@example.com, lab@example.com" {
+		t.Errorf("unexpected recipients %q", got)
+	}
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("expected a multipart message, got %q: %v", mediaType, err)
+	}
+
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	text, err := reader.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(text)
+	if string(body) != "Run finished.\r\nSee the attached report." {
+		t.Errorf("unexpected body %q", body)
+	}
+	attachment, err := reader.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attachment.FileName() != "run-12.html" || !strings.HasPrefix(attachment.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("unexpected attachment headers %v", attachment.Header)
+	}
+	data, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, attachment))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(msg.Attachments[0].Data) {
+		t.Errorf("expected the attachment to survive encoding, got %q", data)
+	}
+	if _, err := reader.NextPart(); err != io.EOF {
+		t.Errorf("expected two parts, got %v", err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	for name, cfg := range map[string]Config{
+		"no host":    {From: "fire@example.com"},
+		"bad sender": {Host: "smtp.example.com", From: "fire"},
+		"bad tls":    {Host: "smtp.example.com", From: "fire@example.com", TLS: "ssl"},
+		"bad port":   {Host: "smtp.example.com", From: "fire@example.com", Port: 70000},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	if port := (Config{TLS: ImplicitTLS}).port(); port != 465 {
+		t.Errorf("expected port 465 with TLS, got %d", port)
+	}
+	if port := (Config{}).port(); port != 587 {
+		t.Errorf("expected port 587 by default, got %d", port)
+	}
+	if !(Config{Port: 465}).implicitTLS() || (Config{Port: 465, TLS: StartTLS}).implicitTLS() {
+		t.Error("expected port 465 to imply TLS unless another mode is set")
+	}
+}
+
+func TestSend(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ln.Close() }()
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		received <- serveSMTP(conn)
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	cfg := Config{Host: "127.0.0.1", Port: addr.Port, From: "fire@example.com", TLS: NoTLS}
+	msg := Message{To: []string{"Ops <ops@example.com>"}, Subject: "Test", Body: "hello"}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := Send(ctx, cfg, msg); err != nil {
+		t.Fatal(err)
+	}
+
+	commands := strings.Join(<-received, "\n")
+	for _, want := range []string{"MAIL FROM:<fire@example.com>", "RCPT TO:<ops@example.com>", "Subject: Test", "hello"} {
+		if !strings.Contains(commands, want) {
+			t.Errorf("expected %q in the session:\n%s", want, commands)
+		}
+	}
+}
+
+// serveSMTP answers one minimal SMTP session and returns the lines received
+func serveSMTP(conn net.Conn) []string {
+	var lines []string
+	r := bufio.NewReader(conn)
+	reply := func(s string) { _, _ = io.WriteString(conn, s+"\r\n") }
+	reply("220 localhost ready")
+	inData := false
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return lines
+		}
+		line = strings.TrimRight(line, "\r\n")
+		lines = append(lines, line)
+		switch {
+		case inData && line == ".":
+			inData = false
+			reply("250 queued")
+		case inData:
+		case strings.HasPrefix(line, "EHLO"):
+			reply("250 localhost")
+		case strings.HasPrefix(line, "DATA"):
+			inData = true
+			reply("354 go ahead")
+		case strings.HasPrefix(line, "QUIT"):
+			reply("221 bye")
+			return lines
+		default:
+			reply("250 ok")
+		}
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package monitor
+
+import (
+	"context"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// diskCounters returns the cumulative I/O counters of every block device
+func diskCounters(ctx context.Context) (map[string]disk.IOCountersStat, error) {
+	return disk.IOCountersWithContext(ctx)
+}
@@ -0,0 +1,219 @@
+package monitor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/intelgpu"
+	"github.com/mscrnt/project_fire/pkg/nvidia"
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+	"github.com/mscrnt/project_fire/pkg/sensors"
+)
+
+// GPU holds the readings of one graphics card. Values a driver does not
+// report are zero.
+type GPU struct {
+	Index        int     `json:"index"`
+	Vendor       string  `json:"vendor"`
+	Name         string  `json:"name,omitempty"`
+	Usage        float64 `json:"usage_pct"`
+	Temp         float64 `json:"temp_c,omitempty"`
+	Hotspot      float64 `json:"hotspot_c,omitempty"`
+	Power        float64 `json:"power_w,omitempty"`
+	Clock        float64 `json:"clock_mhz,omitempty"`
+	MemoryUsedMB float64 `json:"memory_used_mb,omitempty"`
+}
+
+// addHardwareMonitor fills in the readings a hardware monitor such as
+// LibreHardwareMonitor reports for the cards, matched by name: the hot spot,
+// and whatever the driver left out. Cards only the monitor sees are added.
+func addHardwareMonitor(gpus []GPU, readings []sensors.GPUReading) []GPU {
+	next := 0
+	for _, g := range gpus {
+		next = max(next, g.Index+1)
+	}
+	for _, r := range readings {
+		i := slices.IndexFunc(gpus, func(g GPU) bool { return strings.EqualFold(g.Name, r.Name) })
+		if i < 0 {
+			gpus = append(gpus, GPU{Index: next, Vendor: r.Vendor, Name: r.Name})
+			i = len(gpus) - 1
+			next++
+		}
+		g := &gpus[i]
+		g.Hotspot = r.Hotspot
+		if g.Temp == 0 {
+			g.Temp = r.Temp
+		}
+		if g.Power == 0 {
+			g.Power = r.Power
+		}
+		if g.Clock == 0 {
+			g.Clock = r.CoreClock
+		}
+	}
+	return gpus
+}
+
+// sysfsRoot is the root the amdgpu files are read under, replaced in tests
+var sysfsRoot = "/"
+
+// nvidiaQuery lists the nvidia-smi fields parseNVIDIA expects, in order
+const nvidiaQuery = "index,name,utilization.gpu,temperature.gpu,power.draw,clocks.gr,memory.used"
+
+// readGPUs reads NVIDIA cards through NVML or nvidia-smi, AMD cards through
+// the amdgpu sysfs files and Intel GPUs through their drivers' counters, the
+// same sources as the dashboard
+func readGPUs(ctx context.Context, intel *intelgpu.Meter) []GPU {
+	gpus := readNVIDIA(ctx)
+	for _, g := range append(readAMDGPU(), fromIntel(intel.Read())...) {
+		g.Index = len(gpus)
+		gpus = append(gpus, g)
+	}
+	return gpus
+}
+
+// fromIntel converts the readings of the intelgpu package. Linux has no
+// adapter name, so the hardware monitor's readings cannot be matched to it.
+func fromIntel(intel []intelgpu.GPU) []GPU {
+	gpus := make([]GPU, len(intel))
+	for i, g := range intel {
+		name := g.Name
+		if name == "" {
+			name = "Intel Graphics"
+		}
+		gpus[i] = GPU{Vendor: "Intel", Name: name, Usage: g.Usage, Temp: g.Temp, Power: g.Power, Clock: g.Clock}
+	}
+	return gpus
+}
+
+// readNVIDIA reads the NVIDIA cards through the native library when the
+// binary is built with it, else through nvidia-smi
+func readNVIDIA(ctx context.Context) []GPU {
+	if native, err := nvidia.Read(); err == nil && len(native) > 0 {
+		return fromNative(native)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	output, err := safeexec.CommandContext(ctx, "nvidia-smi", "--query-gpu="+nvidiaQuery, "--format=csv,noheader,nounits").Output()
+	if err != nil {
+		return nil // No NVIDIA driver or card
+	}
+	return parseNVIDIA(string(output))
+}
+
+// fromNative converts the readings of the nvidia package
+func fromNative(native []nvidia.GPU) []GPU {
+	gpus := make([]GPU, len(native))
+	for i, n := range native {
+		gpus[i] = GPU{
+			Index:        n.Index,
+			Vendor:       "NVIDIA",
+			Name:         n.Name,
+			Usage:        n.Usage,
+			Temp:         n.Temp,
+			Power:        n.Power,
+			Clock:        n.Clock,
+			MemoryUsedMB: n.MemoryUsedMB,
+		}
+	}
+	return gpus
+}
+
+// parseNVIDIA parses the CSV nvidia-smi prints for nvidiaQuery. Fields the
+// card does not support read "[N/A]" and are left at zero.
+func parseNVIDIA(output string) []GPU {
+	var gpus []GPU
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Split(line, ",")
+		if len(fields) < 7 {
+			continue
+		}
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		index, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		number := func(s string) float64 {
+			v, _ := strconv.ParseFloat(s, 64)
+			return v
+		}
+		gpus = append(gpus, GPU{
+			Index:        index,
+			Vendor:       "NVIDIA",
+			Name:         fields[1],
+			Usage:        number(fields[2]),
+			Temp:         number(fields[3]),
+			Power:        number(fields[4]),
+			Clock:        number(fields[5]),
+			MemoryUsedMB: number(fields[6]),
+		})
+	}
+	return gpus
+}
+
+// readAMDGPU reads the cards bound to the amdgpu driver
+func readAMDGPU() []GPU {
+	cards, _ := filepath.Glob(filepath.Join(sysfsRoot, "sys/class/drm/card[0-9]*/device"))
+	var gpus []GPU
+	for _, device := range cards {
+		if readSysfs(filepath.Join(device, "vendor")) != "0x1002" {
+			continue
+		}
+		g := GPU{Vendor: "AMD", Name: "AMD GPU"}
+		if v, err := strconv.ParseFloat(readSysfs(filepath.Join(device, "gpu_busy_percent")), 64); err == nil {
+			g.Usage = v
+		}
+		if v, err := strconv.ParseFloat(readSysfs(filepath.Join(device, "mem_info_vram_used")), 64); err == nil {
+			g.MemoryUsedMB = v / (1 << 20)
+		}
+		g.Clock = activeSclk(readSysfs(filepath.Join(device, "pp_dpm_sclk")))
+
+		hwmons, _ := filepath.Glob(filepath.Join(device, "hwmon/hwmon*"))
+		if len(hwmons) > 0 {
+			if v, err := strconv.ParseFloat(readSysfs(filepath.Join(hwmons[0], "temp1_input")), 64); err == nil {
+				g.Temp = v / 1000 // Millidegrees
+			}
+			// Newer kernels report power1_input, older ones power1_average
+			for _, name := range []string{"power1_input", "power1_average"} {
+				if v, err := strconv.ParseFloat(readSysfs(filepath.Join(hwmons[0], name)), 64); err == nil {
+					g.Power = v / 1e6 // Microwatts
+					break
+				}
+			}
+		}
+		gpus = append(gpus, g)
+	}
+	return gpus
+}
+
+// activeSclk returns the clock of the active level in pp_dpm_sclk, the line
+// marked with "*", e.g. "1: 2105Mhz *"
+func activeSclk(levels string) float64 {
+	for _, line := range strings.Split(levels, "\n") {
+		if !strings.HasSuffix(strings.TrimSpace(line), "*") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return 0
+		}
+		mhz, _ := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(fields[1]), "mhz"), 64)
+		return mhz
+	}
+	return 0
+}
+
+func readSysfs(path string) string {
+	data, err := os.ReadFile(path) // #nosec G304 -- sysfs path built from a glob
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,316 @@
+// Package monitor polls the readings the GUI dashboard shows (CPU, memory,
+// GPU, storage, network and fans) without a display, for `bench monitor` on
+// headless machines.
+//
+// A Poller keeps the previous disk, network and Intel GPU counters so each Sample
+// carries rates rather than totals. Samples marshal to one JSON object per line, and
+// Metrics flattens them into the named values stored as results.
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/intelgpu"
+	"github.com/mscrnt/project_fire/pkg/sensors"
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/mem"
+)
+
+// CPU holds the processor readings. Sensor readings are zero when the
+// platform cannot take them.
+type CPU struct {
+	Usage   float64 `json:"usage_pct"`
+	Clock   float64 `json:"clock_mhz,omitempty"` // Fastest core
+	Temp    float64 `json:"temp_c,omitempty"`
+	Power   float64 `json:"power_w,omitempty"`
+	Voltage float64 `json:"voltage_v,omitempty"`
+}
+
+// Memory holds the system memory readings
+type Memory struct {
+	Usage  float64 `json:"usage_pct"`
+	UsedGB float64 `json:"used_gb"`
+	Total  float64 `json:"total_gb"`
+	Temp   float64 `json:"temp_c,omitempty"` // Hottest DIMM
+}
+
+// Disk holds the throughput of one physical disk since the previous sample.
+// Latencies are the average time a read or write took, and zero when there
+// were none.
+type Disk struct {
+	Name           string  `json:"name"`
+	ReadMBps       float64 `json:"read_mbps"`
+	WriteMBps      float64 `json:"write_mbps"`
+	ReadIOPS       float64 `json:"read_iops"`
+	WriteIOPS      float64 `json:"write_iops"`
+	ReadLatencyMs  float64 `json:"read_latency_ms,omitempty"`
+	WriteLatencyMs float64 `json:"write_latency_ms,omitempty"`
+	Busy           float64 `json:"busy_pct"`
+}
+
+// Sample is one poll of every reading
+type Sample struct {
+	Time    time.Time         `json:"time"`
+	CPU     CPU               `json:"cpu"`
+	Memory  Memory            `json:"memory"`
+	GPUs    []GPU             `json:"gpus,omitempty"`
+	Disks   []Disk            `json:"disks,omitempty"`
+	Network []Interface       `json:"network,omitempty"`
+	Fans    []sensors.Reading `json:"fans,omitempty"`
+
+	// SystemPower is the power supplies' input power, on servers whose BMC
+	// reports it
+	SystemPower float64 `json:"system_power_w,omitempty"`
+
+	// VRMTemp is the hottest voltage regulator temperature, where the board's
+	// sensors report one
+	VRMTemp float64 `json:"vrm_temp_c,omitempty"`
+}
+
+// Poller takes samples. It is not safe for concurrent use.
+type Poller struct {
+	sensors sensors.Provider
+	gpus    func(context.Context) []GPU
+
+	lastTime time.Time
+	disks    DiskMeter
+	network  NetworkMeter
+	intel    intelgpu.Meter
+}
+
+// NewPoller returns a poller reading the shared sensor provider
+func NewPoller() *Poller {
+	p := &Poller{sensors: sensors.Default()}
+	p.gpus = func(ctx context.Context) []GPU { return readGPUs(ctx, &p.intel) }
+	return p
+}
+
+// Sample polls every reading. A reading that fails is left out of the
+// sample; only a cancelled context is returned as an error.
+func (p *Poller) Sample(ctx context.Context) (*Sample, error) {
+	s := &Sample{Time: time.Now()}
+
+	// Usage since the previous call; the first sample measures over 200 ms
+	interval := time.Duration(0)
+	if p.lastTime.IsZero() {
+		interval = 200 * time.Millisecond
+	}
+	if usage, err := cpu.PercentWithContext(ctx, interval, false); err == nil && len(usage) > 0 {
+		s.CPU.Usage = usage[0]
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if vm, err := mem.VirtualMemoryWithContext(ctx); err == nil {
+		s.Memory.Usage = vm.UsedPercent
+		s.Memory.UsedGB = float64(vm.Used) / (1 << 30)
+		s.Memory.Total = float64(vm.Total) / (1 << 30)
+	}
+
+	snap, err := p.sensors.Read(ctx)
+	if err == nil {
+		s.CPU.Clock = snap.MaxCoreClock()
+		s.CPU.Temp = snap.CPUTemp
+		s.CPU.Power = snap.PackagePower
+		s.CPU.Voltage = snap.CPUVoltage
+		s.Memory.Temp = snap.MemoryTemp
+		s.Fans = snap.Fans
+		s.SystemPower = snap.SystemPower
+		s.VRMTemp = snap.VRMTemp
+	}
+	if s.CPU.Clock == 0 {
+		if info, err := cpu.InfoWithContext(ctx); err == nil && len(info) > 0 {
+			s.CPU.Clock = info[0].Mhz
+		}
+	}
+
+	s.GPUs = p.gpus(ctx)
+	if snap != nil {
+		s.GPUs = addHardwareMonitor(s.GPUs, snap.GPUs)
+	}
+
+	s.Disks = p.disks.Read(ctx)
+	s.Network = p.network.Read(ctx)
+	p.lastTime = s.Time
+	return s, ctx.Err()
+}
+
+// DiskMeter turns the disks' I/O counters into rates. It is shared by the
+// Poller and the GUI dashboard and is not safe for concurrent use.
+type DiskMeter struct {
+	last     map[string]disk.IOCountersStat
+	lastTime time.Time
+}
+
+// Read returns the throughput, IOPS, latency and busy time of each physical
+// disk since the previous call, sorted by name. The first call returns none.
+func (m *DiskMeter) Read(ctx context.Context) []Disk {
+	counters, err := diskCounters(ctx)
+	if err != nil {
+		return nil
+	}
+	return m.rates(counters, time.Now())
+}
+
+// rates turns the cumulative disk counters into rates since the previous
+// call. The first call only stores the counters.
+func (m *DiskMeter) rates(counters map[string]disk.IOCountersStat, now time.Time) []Disk {
+	last, elapsed := m.last, now.Sub(m.lastTime).Seconds()
+	m.last, m.lastTime = counters, now
+	if last == nil || elapsed <= 0 {
+		return nil
+	}
+
+	var disks []Disk
+	for _, name := range sortedKeys(counters) {
+		cur := counters[name]
+		prev, ok := last[name]
+		if !ok || isPartition(name, counters) || cur.ReadBytes < prev.ReadBytes || cur.WriteBytes < prev.WriteBytes {
+			continue
+		}
+		d := Disk{
+			Name:      name,
+			ReadMBps:  float64(cur.ReadBytes-prev.ReadBytes) / (1 << 20) / elapsed,
+			WriteMBps: float64(cur.WriteBytes-prev.WriteBytes) / (1 << 20) / elapsed,
+		}
+		if cur.IoTime >= prev.IoTime {
+			d.Busy = min(float64(cur.IoTime-prev.IoTime)/(elapsed*1000)*100, 100) // IoTime is in ms
+		}
+		if reads := delta(cur.ReadCount, prev.ReadCount); reads > 0 {
+			d.ReadIOPS = float64(reads) / elapsed
+			d.ReadLatencyMs = float64(delta(cur.ReadTime, prev.ReadTime)) / float64(reads)
+		}
+		if writes := delta(cur.WriteCount, prev.WriteCount); writes > 0 {
+			d.WriteIOPS = float64(writes) / elapsed
+			d.WriteLatencyMs = float64(delta(cur.WriteTime, prev.WriteTime)) / float64(writes)
+		}
+		disks = append(disks, d)
+	}
+	return disks
+}
+
+// delta returns how far a counter moved, or 0 if it was reset
+func delta(now, before uint64) uint64 {
+	if now < before {
+		return 0
+	}
+	return now - before
+}
+
+// DiskOf returns the physical disk holding a partition or volume, e.g.
+// nvme0n1 for /dev/nvme0n1p2 on Linux or C: on Windows
+func DiskOf(disks []Disk, device string) (Disk, bool) {
+	name := strings.TrimPrefix(device, "/dev/")
+	for _, d := range disks {
+		if strings.EqualFold(d.Name, name) || partitionOf(name, d.Name) {
+			return d, true
+		}
+	}
+	return Disk{}, false
+}
+
+func sortedKeys(counters map[string]disk.IOCountersStat) []string {
+	names := make([]string, 0, len(counters))
+	for name := range counters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isPartition reports whether a Linux block device is a partition of another
+// device in the set, e.g. sda1 of sda or nvme0n1p2 of nvme0n1. Counting both
+// would report the same I/O twice.
+func isPartition(name string, counters map[string]disk.IOCountersStat) bool {
+	for other := range counters {
+		if partitionOf(name, other) {
+			return true
+		}
+	}
+	return false
+}
+
+// partitionOf reports whether name is a partition of the Linux block device
+// parent
+func partitionOf(name, parent string) bool {
+	if name == parent || !strings.HasPrefix(name, parent) {
+		return false
+	}
+	suffix := strings.TrimPrefix(strings.TrimPrefix(name, parent), "p")
+	return suffix != "" && strings.Trim(suffix, "0123456789") == ""
+}
+
+// Metrics flattens the sample into named values and their units, for storing
+// as results. Readings that were not taken are left out.
+func (s *Sample) Metrics() (values map[string]float64, units map[string]string) {
+	values = make(map[string]float64)
+	units = make(map[string]string)
+	add := func(name string, value float64, unit string) {
+		if value != 0 {
+			values[name] = value
+			units[name] = unit
+		}
+	}
+
+	add("cpu_usage", s.CPU.Usage, "%")
+	add("cpu_clock", s.CPU.Clock, "MHz")
+	add("cpu_temp", s.CPU.Temp, "°C")
+	add("cpu_power", s.CPU.Power, "W")
+	add("cpu_voltage", s.CPU.Voltage, "V")
+	add("memory_usage", s.Memory.Usage, "%")
+	add("memory_used", s.Memory.UsedGB, "GB")
+	add("memory_temp", s.Memory.Temp, "°C")
+	add("system_power", s.SystemPower, "W")
+	add("vrm_temp", s.VRMTemp, "°C")
+	for _, g := range s.GPUs {
+		prefix := fmt.Sprintf("gpu%d_", g.Index)
+		add(prefix+"usage", g.Usage, "%")
+		add(prefix+"temp", g.Temp, "°C")
+		add(prefix+"hotspot", g.Hotspot, "°C")
+		add(prefix+"power", g.Power, "W")
+		add(prefix+"clock", g.Clock, "MHz")
+		add(prefix+"memory_used", g.MemoryUsedMB, "MB")
+	}
+	for _, d := range s.Disks {
+		prefix := "disk_" + metricName(d.Name) + "_"
+		add(prefix+"read", d.ReadMBps, "MB/s")
+		add(prefix+"write", d.WriteMBps, "MB/s")
+		add(prefix+"read_iops", d.ReadIOPS, "IOPS")
+		add(prefix+"write_iops", d.WriteIOPS, "IOPS")
+		add(prefix+"read_latency", d.ReadLatencyMs, "ms")
+		add(prefix+"write_latency", d.WriteLatencyMs, "ms")
+		add(prefix+"busy", d.Busy, "%")
+	}
+	for _, i := range s.Network {
+		prefix := "net_" + metricName(i.Name) + "_"
+		add(prefix+"down", i.DownMBps, "MB/s")
+		add(prefix+"up", i.UpMBps, "MB/s")
+	}
+	for _, f := range s.Fans {
+		add("fan_"+metricName(f.Name), f.Value, "RPM")
+	}
+	return values, units
+}
+
+// metricName lowercases a device or sensor name and replaces anything but
+// letters and digits with underscores, e.g. "CPU Fan" becomes "cpu_fan"
+func metricName(name string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			underscore = false
+		} else if !underscore && b.Len() > 0 {
+			b.WriteByte('_')
+			underscore = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "_")
+}
@@ -0,0 +1,220 @@
+package monitor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/intelgpu"
+	"github.com/mscrnt/project_fire/pkg/nvidia"
+	"github.com/mscrnt/project_fire/pkg/sensors"
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// fakeSensors returns a fixed snapshot
+type fakeSensors struct{ snap *sensors.Snapshot }
+
+func (f fakeSensors) Name() string { return "fake" }
+
+func (f fakeSensors) Read(context.Context) (*sensors.Snapshot, error) { return f.snap, nil }
+
+func TestSample(t *testing.T) {
+	p := &Poller{
+		sensors: fakeSensors{&sensors.Snapshot{
+			CPUTemp:    71.5,
+			CoreClocks: []float64{4200, 5100},
+			Fans:       []sensors.Reading{{Name: "CPU Fan", Value: 1450}},
+			GPUs: []sensors.GPUReading{
+				{Name: "NVIDIA GeForce RTX 4090", Temp: 60, Hotspot: 74},
+				{Name: "Intel(R) UHD Graphics 770", Vendor: "Intel", Temp: 45},
+			},
+		}},
+		gpus: func(context.Context) []GPU {
+			return []GPU{{Index: 0, Vendor: "NVIDIA", Name: "NVIDIA GeForce RTX 4090", Usage: 97, Temp: 62}}
+		},
+	}
+
+	s, err := p.Sample(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.CPU.Temp != 71.5 || s.CPU.Clock != 5100 || len(s.Fans) != 1 || len(s.GPUs) != 2 {
+		t.Errorf("expected the sensor and GPU readings in the sample, got %+v", s)
+	}
+	if g := s.GPUs[0]; g.Hotspot != 74 || g.Temp != 62 {
+		t.Errorf("expected the monitor's hot spot next to the driver's temperature, got %+v", g)
+	}
+	if g := s.GPUs[1]; g.Index != 1 || g.Vendor != "Intel" || g.Temp != 45 {
+		t.Errorf("expected the card only the monitor sees to be added, got %+v", g)
+	}
+	if s.Memory.Total <= 0 {
+		t.Errorf("expected the memory size, got %+v", s.Memory)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.Sample(ctx); err == nil {
+		t.Error("expected an error for a cancelled context")
+	}
+}
+
+func TestDiskRates(t *testing.T) {
+	m := &DiskMeter{}
+	start := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	first := map[string]disk.IOCountersStat{
+		"nvme0n1":   {ReadBytes: 0, WriteBytes: 0, IoTime: 0},
+		"nvme0n1p1": {ReadBytes: 0, WriteBytes: 0},
+	}
+	if rates := m.rates(first, start); rates != nil {
+		t.Errorf("expected no rates from the first counters, got %+v", rates)
+	}
+
+	second := map[string]disk.IOCountersStat{
+		"nvme0n1":   {ReadBytes: 200 << 20, WriteBytes: 50 << 20, IoTime: 1500, ReadCount: 4000, ReadTime: 600, WriteCount: 100, WriteTime: 150},
+		"nvme0n1p1": {ReadBytes: 200 << 20, WriteBytes: 50 << 20},
+	}
+	rates := m.rates(second, start.Add(2*time.Second))
+	if len(rates) != 1 {
+		t.Fatalf("expected only the whole disk, got %+v", rates)
+	}
+	if r := rates[0]; r.Name != "nvme0n1" || r.ReadMBps != 100 || r.WriteMBps != 25 || r.Busy != 75 {
+		t.Errorf("unexpected rates %+v", r)
+	}
+	if r := rates[0]; r.ReadIOPS != 2000 || r.ReadLatencyMs != 0.15 || r.WriteIOPS != 50 || r.WriteLatencyMs != 1.5 {
+		t.Errorf("unexpected IOPS or latency %+v", r)
+	}
+}
+
+func TestDiskOf(t *testing.T) {
+	disks := []Disk{{Name: "C:"}, {Name: "nvme0n1"}, {Name: "sda"}}
+	for device, want := range map[string]string{"/dev/nvme0n1p2": "nvme0n1", "/dev/sda": "sda", "c:": "C:", "/dev/sdb1": ""} {
+		d, ok := DiskOf(disks, device)
+		if ok != (want != "") || d.Name != want {
+			t.Errorf("DiskOf(%q) = %q, %v; want %q", device, d.Name, ok, want)
+		}
+	}
+}
+
+func TestIsPartition(t *testing.T) {
+	counters := map[string]disk.IOCountersStat{"sda": {}, "sda1": {}, "sdab": {}, "nvme0n1": {}, "nvme0n1p2": {}}
+	for name, want := range map[string]bool{"sda": false, "sda1": true, "sdab": false, "nvme0n1": false, "nvme0n1p2": true} {
+		if got := isPartition(name, counters); got != want {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	s := &Sample{
+		CPU:     CPU{Usage: 55, Temp: 80},
+		GPUs:    []GPU{{Index: 1, Usage: 99, Power: 320, Hotspot: 81}},
+		Disks:   []Disk{{Name: "C:", WriteMBps: 12}},
+		Network: []Interface{{Name: "Ethernet 2", DownMBps: 110, UpMBps: 3}},
+		Fans:    []sensors.Reading{{Name: "CPU Fan #1", Value: 1200}},
+
+		SystemPower: 450,
+		VRMTemp:     58,
+	}
+	values, units := s.Metrics()
+	want := map[string]float64{
+		"cpu_usage":           55,
+		"cpu_temp":            80,
+		"gpu1_usage":          99,
+		"gpu1_power":          320,
+		"gpu1_hotspot":        81,
+		"disk_c_write":        12,
+		"net_ethernet_2_down": 110,
+		"net_ethernet_2_up":   3,
+		"fan_cpu_fan_1":       1200,
+		"system_power":        450,
+		"vrm_temp":            58,
+	}
+	if len(values) != len(want) {
+		t.Errorf("expected %d metrics, got %v", len(want), values)
+	}
+	for name, value := range want {
+		if values[name] != value {
+			t.Errorf("%s: expected %v, got %v", name, value, values[name])
+		}
+	}
+	if units["cpu_temp"] != "°C" || units["disk_c_write"] != "MB/s" {
+		t.Errorf("unexpected units %v", units)
+	}
+}
+
+func TestParseNVIDIA(t *testing.T) {
+	output := "0, NVIDIA GeForce RTX 4090, 98, 71, 402.55, 2745, 18211\n1, Tesla T4, [N/A], 45, 27.10, 585, 0\n"
+	gpus := parseNVIDIA(output)
+	if len(gpus) != 2 {
+		t.Fatalf("expected 2 GPUs, got %d", len(gpus))
+	}
+	if g := gpus[0]; g.Name != "NVIDIA GeForce RTX 4090" || g.Usage != 98 || g.Power != 402.55 || g.Clock != 2745 || g.MemoryUsedMB != 18211 {
+		t.Errorf("unexpected first GPU %+v", g)
+	}
+	if g := gpus[1]; g.Index != 1 || g.Usage != 0 || g.Temp != 45 {
+		t.Errorf("expected an unsupported field to read 0, got %+v", g)
+	}
+}
+
+func TestFromNative(t *testing.T) {
+	gpus := fromNative([]nvidia.GPU{{Index: 1, Name: "NVIDIA GeForce RTX 4080", Usage: 55, Temp: 62, Power: 210.5, PowerLimit: 320, Clock: 2610, MemoryUsedMB: 4096, MemoryTotalMB: 16376}})
+	if len(gpus) != 1 {
+		t.Fatalf("expected 1 GPU, got %d", len(gpus))
+	}
+	want := GPU{Index: 1, Vendor: "NVIDIA", Name: "NVIDIA GeForce RTX 4080", Usage: 55, Temp: 62, Power: 210.5, Clock: 2610, MemoryUsedMB: 4096}
+	if gpus[0] != want {
+		t.Errorf("expected %+v, got %+v", want, gpus[0])
+	}
+}
+
+func TestFromIntel(t *testing.T) {
+	gpus := fromIntel([]intelgpu.GPU{
+		{Card: "card0", Usage: 42, Clock: 1550, MaxClock: 2250, Power: 9.5},
+		{Name: "Intel(R) Arc(TM) A380 Graphics", Usage: 7, Temp: 44},
+	})
+	if len(gpus) != 2 {
+		t.Fatalf("expected 2 GPUs, got %d", len(gpus))
+	}
+	if g := gpus[0]; g.Vendor != "Intel" || g.Name != "Intel Graphics" || g.Usage != 42 || g.Clock != 1550 || g.Power != 9.5 {
+		t.Errorf("unexpected unnamed GPU %+v", g)
+	}
+	if g := gpus[1]; g.Name != "Intel(R) Arc(TM) A380 Graphics" || g.Temp != 44 {
+		t.Errorf("unexpected named GPU %+v", g)
+	}
+}
+
+func TestReadAMDGPU(t *testing.T) {
+	root := t.TempDir()
+	old := sysfsRoot
+	sysfsRoot = root
+	t.Cleanup(func() { sysfsRoot = old })
+
+	device := filepath.Join(root, "sys/class/drm/card1/device")
+	files := map[string]string{
+		"vendor":                      "0x1002",
+		"gpu_busy_percent":            "64",
+		"mem_info_vram_used":          "2147483648",
+		"pp_dpm_sclk":                 "0: 500Mhz\n1: 2105Mhz *\n2: 2600Mhz\n",
+		"hwmon/hwmon4/temp1_input":    "58000",
+		"hwmon/hwmon4/power1_average": "212000000",
+	}
+	for name, content := range files {
+		path := filepath.Join(device, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gpus := readAMDGPU()
+	if len(gpus) != 1 {
+		t.Fatalf("expected 1 GPU, got %d", len(gpus))
+	}
+	if g := gpus[0]; g.Usage != 64 || g.MemoryUsedMB != 2048 || g.Clock != 2105 || g.Temp != 58 || g.Power != 212 {
+		t.Errorf("unexpected GPU %+v", g)
+	}
+}
@@ -0,0 +1,98 @@
+package monitor
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	psnet "github.com/shirou/gopsutil/v3/net"
+)
+
+// Interface holds a network interface's link and its throughput since the
+// previous reading
+type Interface struct {
+	Name      string   `json:"name"`
+	MAC       string   `json:"mac,omitempty"`
+	Addresses []string `json:"addresses,omitempty"` // IPv4 and IPv6, without the prefix length
+	LinkMbps  float64  `json:"link_mbit,omitempty"` // Negotiated speed, where the platform reports it
+	DownMBps  float64  `json:"down_mbps"`
+	UpMBps    float64  `json:"up_mbps"`
+}
+
+// NetworkMeter turns the interfaces' byte counters into rates. It is shared
+// by the Poller and the GUI dashboard and is not safe for concurrent use.
+type NetworkMeter struct {
+	last     map[string]psnet.IOCountersStat
+	lastTime time.Time
+}
+
+// Read returns every interface that is up, other than loopback, sorted by
+// name. Rates are zero on the first call and for interfaces that just came
+// up.
+func (m *NetworkMeter) Read(ctx context.Context) []Interface {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	counters := make(map[string]psnet.IOCountersStat)
+	if stats, err := psnet.IOCountersWithContext(ctx, true); err == nil {
+		for _, s := range stats {
+			counters[s.Name] = s
+		}
+	}
+	speeds := linkSpeeds(ifaces)
+	now := time.Now()
+	last, elapsed := m.last, now.Sub(m.lastTime).Seconds()
+	m.last, m.lastTime = counters, now
+
+	var list []Interface
+	for _, ifi := range ifaces {
+		if ifi.Flags&net.FlagUp == 0 || ifi.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		i := Interface{Name: ifi.Name, MAC: ifi.HardwareAddr.String(), LinkMbps: speeds[ifi.Index]}
+		if addrs, err := ifi.Addrs(); err == nil {
+			for _, a := range addrs {
+				i.Addresses = append(i.Addresses, strings.SplitN(a.String(), "/", 2)[0])
+			}
+		}
+		cur, ok := counters[ifi.Name]
+		prev, seen := last[ifi.Name]
+		if ok && seen && elapsed > 0 && cur.BytesRecv >= prev.BytesRecv && cur.BytesSent >= prev.BytesSent {
+			i.DownMBps = float64(cur.BytesRecv-prev.BytesRecv) / (1 << 20) / elapsed
+			i.UpMBps = float64(cur.BytesSent-prev.BytesSent) / (1 << 20) / elapsed
+		}
+		list = append(list, i)
+	}
+	sort.Slice(list, func(a, b int) bool { return list[a].Name < list[b].Name })
+	return list
+}
+
+// PrimaryInterface returns the name of the interface the dashboard shows
+// first: one with an IPv4 address, preferring the fastest link, or "" when
+// there is none
+func PrimaryInterface(ifaces []Interface) string {
+	best, bestSpeed := "", -1.0
+	for _, i := range ifaces {
+		if !hasIPv4(i.Addresses) || i.LinkMbps <= bestSpeed {
+			continue
+		}
+		best, bestSpeed = i.Name, i.LinkMbps
+	}
+	if best == "" && len(ifaces) > 0 {
+		best = ifaces[0].Name
+	}
+	return best
+}
+
+// hasIPv4 reports whether any of the addresses is IPv4
+func hasIPv4(addresses []string) bool {
+	for _, a := range addresses {
+		if ip := net.ParseIP(a); ip != nil && ip.To4() != nil {
+			return true
+		}
+	}
+	return false
+}
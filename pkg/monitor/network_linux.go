@@ -0,0 +1,28 @@
+package monitor
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sysfsNet is where Linux lists the network interfaces
+var sysfsNet = "/sys/class/net"
+
+// linkSpeeds returns the negotiated link speed in Mbit/s of each interface by
+// index. Wireless and virtual interfaces report none.
+func linkSpeeds(ifaces []net.Interface) map[int]float64 {
+	speeds := make(map[int]float64)
+	for _, ifi := range ifaces {
+		data, err := os.ReadFile(filepath.Join(sysfsNet, ifi.Name, "speed"))
+		if err != nil {
+			continue // Reading fails while the link is down
+		}
+		if mbit, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64); err == nil && mbit > 0 {
+			speeds[ifi.Index] = mbit
+		}
+	}
+	return speeds
+}
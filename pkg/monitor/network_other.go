@@ -0,0 +1,10 @@
+//go:build !linux && !windows
+
+package monitor
+
+import "net"
+
+// linkSpeeds is not implemented on this platform
+func linkSpeeds(_ []net.Interface) map[int]float64 {
+	return nil
+}
@@ -0,0 +1,41 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPrimaryInterface(t *testing.T) {
+	ifaces := []Interface{
+		{Name: "docker0", Addresses: []string{"172.17.0.1"}},
+		{Name: "enp5s0", Addresses: []string{"192.168.1.20", "fe80::1"}, LinkMbps: 2500},
+		{Name: "wg0", Addresses: []string{"fd00::2"}},
+		{Name: "wlp4s0", Addresses: []string{"192.168.1.21"}},
+	}
+	if got := PrimaryInterface(ifaces); got != "enp5s0" {
+		t.Errorf("expected the fastest link with an IPv4 address, got %q", got)
+	}
+	if got := PrimaryInterface(ifaces[2:3]); got != "wg0" {
+		t.Errorf("expected the only interface, got %q", got)
+	}
+	if got := PrimaryInterface(nil); got != "" {
+		t.Errorf("expected no interface, got %q", got)
+	}
+}
+
+func TestNetworkMeter(t *testing.T) {
+	var m NetworkMeter
+	for _, i := range m.Read(context.Background()) {
+		if i.DownMBps != 0 || i.UpMBps != 0 {
+			t.Errorf("expected no rates from the first reading, got %+v", i)
+		}
+		if i.Name == "lo" {
+			t.Error("expected loopback to be left out")
+		}
+	}
+	for _, i := range m.Read(context.Background()) {
+		if i.DownMBps < 0 || i.UpMBps < 0 {
+			t.Errorf("unexpected rates %+v", i)
+		}
+	}
+}
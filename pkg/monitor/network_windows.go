@@ -0,0 +1,34 @@
+package monitor
+
+import (
+	"net"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// linkSpeeds returns the negotiated link speed in Mbit/s of each interface by
+// index, the slower of the transmit and receive speeds the adapter reports
+func linkSpeeds(_ []net.Interface) map[int]float64 {
+	speeds := make(map[int]float64)
+	size := uint32(15000) // The documented starting size
+	var buf []byte
+	for {
+		buf = make([]byte, size)
+		err := windows.GetAdaptersAddresses(windows.AF_UNSPEC, windows.GAA_FLAG_SKIP_ANYCAST|windows.GAA_FLAG_SKIP_MULTICAST|windows.GAA_FLAG_SKIP_DNS_SERVER,
+			0, (*windows.IpAdapterAddresses)(unsafe.Pointer(&buf[0])), &size)
+		if err == nil {
+			break
+		}
+		if err != windows.ERROR_BUFFER_OVERFLOW {
+			return speeds
+		}
+	}
+	for a := (*windows.IpAdapterAddresses)(unsafe.Pointer(&buf[0])); a != nil; a = a.Next {
+		bits := min(a.TransmitLinkSpeed, a.ReceiveLinkSpeed)
+		if bits > 0 && bits != ^uint64(0) { // All ones means unknown
+			speeds[int(a.IfIndex)] = float64(bits) / 1e6
+		}
+	}
+	return speeds
+}
@@ -0,0 +1,279 @@
+// Package mqtt publishes messages to an MQTT 3.1.1 broker, such as the
+// Mosquitto broker of a Home Assistant installation.
+//
+// Only what publishing readings needs is implemented: connecting with a
+// client ID, credentials and a last will, over TCP or TLS, and publishing
+// at QoS 0 or 1. A client never subscribes, so the only packets a broker
+// sends it are acknowledgements.
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Packet types
+const (
+	packetConnect    = 1
+	packetConnack    = 2
+	packetPublish    = 3
+	packetPuback     = 4
+	packetPingresp   = 13
+	packetDisconnect = 14
+)
+
+// DefaultKeepAlive is how long the broker keeps an idle connection
+const DefaultKeepAlive = 60 * time.Second
+
+// ackTimeout bounds waiting for the broker to acknowledge a packet
+const ackTimeout = 10 * time.Second
+
+// connackErrors explains the return codes of a refused connection
+var connackErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client ID rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// Message is published to a topic
+type Message struct {
+	Topic   string
+	Payload []byte
+	QoS     byte // 0 (at most once) or 1 (at least once)
+	Retain  bool // The broker keeps the message for later subscribers
+}
+
+// Options configures a connection
+type Options struct {
+	Address   string      // host:port
+	TLS       *tls.Config // Connect over TLS when set
+	ClientID  string
+	Username  string
+	Password  string
+	KeepAlive time.Duration // DefaultKeepAlive when zero
+	Will      *Message      // Published by the broker when the connection is lost
+}
+
+// Client is a connection to a broker. It is safe for concurrent use.
+type Client struct {
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+	nextID uint16
+}
+
+// Dial connects to a broker and waits for it to accept the connection
+func Dial(ctx context.Context, opts Options) (*Client, error) {
+	if opts.Will != nil && opts.Will.QoS > 1 || opts.ClientID == "" {
+		return nil, errors.New("invalid MQTT options: a client ID is required and QoS must be 0 or 1")
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", opts.Address)
+	if err != nil {
+		return nil, err
+	}
+	if opts.TLS != nil {
+		tlsConn := tls.Client(conn, opts.TLS)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	c := &Client{conn: conn, reader: bufio.NewReader(conn)}
+	if err := c.connect(ctx, opts); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// connect sends CONNECT and reads CONNACK
+func (c *Client) connect(ctx context.Context, opts Options) error {
+	keepAlive := opts.KeepAlive
+	if keepAlive <= 0 {
+		keepAlive = DefaultKeepAlive
+	}
+
+	var body []byte
+	body = appendString(body, "MQTT")
+	body = append(body, 4) // Protocol level of 3.1.1
+	flags := byte(0x02)    // Clean session
+	if opts.Will != nil {
+		flags |= 0x04 | opts.Will.QoS<<3
+		if opts.Will.Retain {
+			flags |= 0x20
+		}
+	}
+	if opts.Password != "" {
+		flags |= 0x40
+	}
+	if opts.Username != "" {
+		flags |= 0x80
+	}
+	body = append(body, flags)
+	body = append(body, byte(keepAlive/time.Second>>8), byte(keepAlive/time.Second))
+	body = appendString(body, opts.ClientID)
+	if opts.Will != nil {
+		body = appendString(body, opts.Will.Topic)
+		body = appendString(body, string(opts.Will.Payload))
+	}
+	if opts.Username != "" {
+		body = appendString(body, opts.Username)
+	}
+	if opts.Password != "" {
+		body = appendString(body, opts.Password)
+	}
+
+	if err := c.write(ctx, packetConnect<<4, body); err != nil {
+		return err
+	}
+	kind, resp, err := c.read(ctx)
+	if err != nil {
+		return fmt.Errorf("no answer to connect: %w", err)
+	}
+	if kind != packetConnack || len(resp) != 2 {
+		return fmt.Errorf("unexpected packet %d instead of CONNACK", kind)
+	}
+	if code := resp[1]; code != 0 {
+		if reason, ok := connackErrors[code]; ok {
+			return fmt.Errorf("broker refused the connection: %s", reason)
+		}
+		return fmt.Errorf("broker refused the connection with code %d", code)
+	}
+	return nil
+}
+
+// Publish sends a message. At QoS 1 it waits until the broker acknowledges
+// it.
+func (c *Client) Publish(ctx context.Context, m Message) error {
+	if m.QoS > 1 {
+		return fmt.Errorf("QoS %d is not supported", m.QoS)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	header := byte(packetPublish<<4) | m.QoS<<1
+	if m.Retain {
+		header |= 0x01
+	}
+	body := appendString(nil, m.Topic)
+	var id uint16
+	if m.QoS > 0 {
+		c.nextID++
+		if c.nextID == 0 {
+			c.nextID = 1
+		}
+		id = c.nextID
+		body = append(body, byte(id>>8), byte(id))
+	}
+	body = append(body, m.Payload...)
+	if err := c.write(ctx, header, body); err != nil {
+		return err
+	}
+	if m.QoS == 0 {
+		return nil
+	}
+
+	for {
+		kind, resp, err := c.read(ctx)
+		if err != nil {
+			return fmt.Errorf("no acknowledgement for %s: %w", m.Topic, err)
+		}
+		switch {
+		case kind == packetPuback && len(resp) == 2 && uint16(resp[0])<<8|uint16(resp[1]) == id:
+			return nil
+		case kind == packetPuback, kind == packetPingresp:
+			// A late acknowledgement of an earlier message
+		default:
+			return fmt.Errorf("unexpected packet %d from the broker", kind)
+		}
+	}
+}
+
+// Close disconnects cleanly, so the broker does not publish the will
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_ = c.write(ctx, packetDisconnect<<4, nil)
+	return c.conn.Close()
+}
+
+// write sends a packet
+func (c *Client) write(ctx context.Context, header byte, body []byte) error {
+	packet := append([]byte{header}, appendLength(nil, len(body))...)
+	packet = append(packet, body...)
+	_ = c.conn.SetWriteDeadline(deadline(ctx))
+	_, err := c.conn.Write(packet)
+	return err
+}
+
+// read receives a packet and returns its type and body
+func (c *Client) read(ctx context.Context) (kind byte, body []byte, err error) {
+	_ = c.conn.SetReadDeadline(deadline(ctx))
+	header, err := c.reader.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		b, err := c.reader.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(b&0x7f) * multiplier
+		if b&0x80 == 0 {
+			break
+		}
+		if i == 3 {
+			return 0, nil, errors.New("malformed packet length")
+		}
+		multiplier *= 128
+	}
+	body = make([]byte, length)
+	if _, err := io.ReadFull(c.reader, body); err != nil {
+		return 0, nil, err
+	}
+	return header >> 4, body, nil
+}
+
+// deadline returns the context deadline, or ackTimeout from now
+func deadline(ctx context.Context) time.Time {
+	if d, ok := ctx.Deadline(); ok {
+		return d
+	}
+	return time.Now().Add(ackTimeout)
+}
+
+// appendString appends a length-prefixed UTF-8 string
+func appendString(b []byte, s string) []byte {
+	b = append(b, byte(len(s)>>8), byte(len(s)))
+	return append(b, s...)
+}
+
+// appendLength appends the variable-length remaining length of a packet
+func appendLength(b []byte, n int) []byte {
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		b = append(b, digit)
+		if n == 0 {
+			return b
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+// packet is a packet received by the test broker
+type packet struct {
+	kind, flags byte
+	body        []byte
+}
+
+// broker accepts one connection, answers CONNACK with code and acknowledges
+// QoS 1 publishes. It sends every packet it receives on the channel.
+func broker(t *testing.T, code byte) (string, <-chan packet) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	packets := make(chan packet, 100)
+	go func() {
+		defer close(packets)
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			header, err := r.ReadByte()
+			if err != nil {
+				return
+			}
+			length, multiplier := 0, 1
+			for {
+				b, err := r.ReadByte()
+				if err != nil {
+					return
+				}
+				length += int(b&0x7f) * multiplier
+				multiplier *= 128
+				if b&0x80 == 0 {
+					break
+				}
+			}
+			body := make([]byte, length)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return
+			}
+			p := packet{kind: header >> 4, flags: header & 0x0f, body: body}
+			packets <- p
+			switch {
+			case p.kind == packetConnect:
+				_, _ = conn.Write([]byte{packetConnack << 4, 2, 0, code})
+			case p.kind == packetPublish && p.flags&0x06 != 0:
+				n := int(body[0])<<8 | int(body[1])
+				id := body[2+n : 4+n]
+				_, _ = conn.Write([]byte{packetPuback << 4, 2, id[0], id[1]})
+			}
+		}
+	}()
+	return ln.Addr().String(), packets
+}
+
+func TestPublish(t *testing.T) {
+	addr, packets := broker(t, 0)
+	ctx := context.Background()
+	c, err := Dial(ctx, Options{
+		Address:  addr,
+		ClientID: "fire-test",
+		Username: "bench",
+		Password: "secret",
+		Will:     &Message{Topic: "fire/rig/status", Payload: []byte("offline"), Retain: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Publish(ctx, Message{Topic: "fire/rig/cpu_temp", Payload: []byte("71.5"), QoS: 1, Retain: true}); err != nil {
+		t.Fatal(err)
+	}
+	// A payload over 127 bytes needs a two byte length
+	if err := c.Publish(ctx, Message{Topic: "fire/rig/result", Payload: []byte(strings.Repeat("x", 300))}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []packet
+	for p := range packets {
+		got = append(got, p)
+	}
+	if len(got) != 4 {
+		t.Fatalf("expected CONNECT, 2 PUBLISH and DISCONNECT, got %d packets", len(got))
+	}
+	connect := got[0].body
+	if string(connect[2:6]) != "MQTT" || connect[6] != 4 {
+		t.Errorf("expected MQTT 3.1.1, got %q", connect[:7])
+	}
+	if flags := connect[7]; flags != 0x02|0x04|0x20|0x40|0x80 {
+		t.Errorf("unexpected connect flags %08b", flags)
+	}
+	for _, want := range []string{"fire-test", "fire/rig/status", "offline", "bench", "secret"} {
+		if !strings.Contains(string(connect), want) {
+			t.Errorf("expected %q in CONNECT", want)
+		}
+	}
+	if p := got[1]; p.kind != packetPublish || p.flags != 0x03 || !strings.HasSuffix(string(p.body), "fire/rig/cpu_temp\x00\x0171.5") {
+		t.Errorf("unexpected QoS 1 publish %x %q", p.flags, p.body)
+	}
+	if p := got[2]; p.flags != 0 || len(p.body) != 2+len("fire/rig/result")+300 {
+		t.Errorf("unexpected QoS 0 publish %x of %d bytes", p.flags, len(p.body))
+	}
+	if got[3].kind != packetDisconnect {
+		t.Errorf("expected DISCONNECT, got %d", got[3].kind)
+	}
+}
+
+func TestRefused(t *testing.T) {
+	addr, _ := broker(t, 4)
+	_, err := Dial(context.Background(), Options{Address: addr, ClientID: "fire-test"})
+	if err == nil || !strings.Contains(err.Error(), "bad user name or password") {
+		t.Errorf("expected the refusal explained, got %v", err)
+	}
+	if _, err := Dial(context.Background(), Options{Address: addr}); err == nil {
+		t.Error("expected a client ID to be required")
+	}
+}
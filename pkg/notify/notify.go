@@ -0,0 +1,187 @@
+// Package notify tells people when a run finishes or fails. The report of
+// the run is mailed, and a message with the outcome, the duration and a
+// link to the report is posted to webhooks: plain JSON, or the message
+// formats of Slack and Discord incoming webhooks.
+//
+// A Notify names the targets, as given to --notify, stored with a schedule
+// or set for every run in the "notify" section of the settings file.
+package notify
+
+import (
+	"fmt"
+	netmail "net/mail"
+	"net/url"
+	"strings"
+)
+
+// Report formats a run report can be sent in
+const (
+	FormatHTML = "html"
+	FormatPDF  = "pdf"
+)
+
+// Kind is the message format a webhook expects
+type Kind string
+
+// Webhook kinds
+const (
+	KindJSON    Kind = "webhook" // The fields of the run as JSON
+	KindSlack   Kind = "slack"   // Slack incoming webhook
+	KindDiscord Kind = "discord" // Discord webhook, which also receives the report file
+)
+
+// Webhook is a URL the outcome of a run is posted to
+type Webhook struct {
+	URL     string            `json:"url"`
+	Kind    Kind              `json:"kind,omitempty"` // Defaults to KindJSON
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// kind returns the webhook's message format
+func (w Webhook) kind() Kind {
+	if w.Kind == "" {
+		return KindJSON
+	}
+	return w.Kind
+}
+
+// Validate checks the URL and the kind
+func (w Webhook) Validate() error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webhook URL %q", w.URL)
+	}
+	switch w.kind() {
+	case KindJSON, KindSlack, KindDiscord:
+	default:
+		return fmt.Errorf("unknown webhook kind %q, expected webhook, slack or discord", w.Kind)
+	}
+	return nil
+}
+
+// Notify names who hears about a run when it finishes or fails
+type Notify struct {
+	Email        []string  `json:"email,omitempty"`
+	Format       string    `json:"format,omitempty"` // FormatHTML (default) or FormatPDF
+	Webhooks     []Webhook `json:"webhooks,omitempty"`
+	OnlyFailures bool      `json:"only_failures,omitempty"` // Stay quiet about runs that passed
+	// ReportURL links messages to the published report; {host} and {run}
+	// are replaced with the machine and the run ID
+	ReportURL string `json:"report_url,omitempty"`
+}
+
+// ParseNotify parses --notify values such as "email=ops@example.com",
+// "email=ops@example.com,lab@example.com", "slack=https://hooks.slack.com/..."
+// or "discord=https://discord.com/api/webhooks/...". It returns nil when no
+// values are given.
+func ParseNotify(specs []string, format string, onlyFailures bool) (*Notify, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	n := &Notify{Format: format, OnlyFailures: onlyFailures}
+	for _, spec := range specs {
+		kind, value, ok := strings.Cut(spec, "=")
+		kind = strings.TrimSpace(kind)
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("invalid notify target %q, expected email=, webhook=, slack= or discord=", spec)
+		case kind == "email":
+			for _, addr := range strings.Split(value, ",") {
+				if addr = strings.TrimSpace(addr); addr != "" {
+					n.Email = append(n.Email, addr)
+				}
+			}
+		case Kind(kind) == KindJSON || Kind(kind) == KindSlack || Kind(kind) == KindDiscord:
+			n.Webhooks = append(n.Webhooks, Webhook{URL: strings.TrimSpace(value), Kind: Kind(kind)})
+		default:
+			return nil, fmt.Errorf("unknown notify target %q, expected email, webhook, slack or discord", kind)
+		}
+	}
+	if err := n.Validate(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// Validate checks the recipients, the webhooks and the format
+func (n *Notify) Validate() error {
+	if len(n.Email) == 0 && len(n.Webhooks) == 0 {
+		return fmt.Errorf("no email recipients or webhooks")
+	}
+	for _, addr := range n.Email {
+		if _, err := netmail.ParseAddress(addr); err != nil {
+			return fmt.Errorf("invalid email address %q: %w", addr, err)
+		}
+	}
+	for _, w := range n.Webhooks {
+		if err := w.Validate(); err != nil {
+			return err
+		}
+	}
+	switch n.Format {
+	case "", FormatHTML, FormatPDF:
+	default:
+		return fmt.Errorf("unknown report format %q, expected html or pdf", n.Format)
+	}
+	return nil
+}
+
+// ReportFormat returns the format reports are sent in
+func (n *Notify) ReportFormat() string {
+	if n.Format == "" {
+		return FormatHTML
+	}
+	return n.Format
+}
+
+// Wants reports whether the targets hear about a run with this outcome
+func (n *Notify) Wants(passed bool) bool {
+	return !passed || !n.OnlyFailures
+}
+
+// Merge returns the targets of n and other together, for the targets of the
+// settings file with those of a schedule or --notify. Either may be nil.
+// Settings of other, such as the format, win when both set them.
+func Merge(n, other *Notify) *Notify {
+	switch {
+	case n == nil:
+		return other
+	case other == nil:
+		return n
+	}
+	merged := *n
+	merged.Email = append(append([]string(nil), n.Email...), other.Email...)
+	merged.Webhooks = append(append([]Webhook(nil), n.Webhooks...), other.Webhooks...)
+	merged.OnlyFailures = n.OnlyFailures && other.OnlyFailures
+	if other.Format != "" {
+		merged.Format = other.Format
+	}
+	if other.ReportURL != "" {
+		merged.ReportURL = other.ReportURL
+	}
+	return &merged
+}
+
+// String formats the targets as given to --notify. Webhook URLs carry their
+// secret in the path, so only their host is shown.
+func (n *Notify) String() string {
+	var parts []string
+	if len(n.Email) > 0 {
+		parts = append(parts, "email="+strings.Join(n.Email, ","))
+	}
+	for _, w := range n.Webhooks {
+		host := w.URL
+		if u, err := url.Parse(w.URL); err == nil {
+			host = u.Host
+		}
+		parts = append(parts, string(w.kind())+"="+host)
+	}
+	s := strings.Join(parts, " ")
+	if n.Format == FormatPDF {
+		s += " (PDF)"
+	}
+	if n.OnlyFailures {
+		s += " on failure"
+	}
+	return s
+}
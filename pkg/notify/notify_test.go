@@ -0,0 +1,188 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/mail"
+)
+
+func TestParseNotify(t *testing.T) {
+	n, err := ParseNotify([]string{
+		"email=ops@example.com, lab@example.com",
+		"email=Night Shift <night@example.com>",
+		"slack=https://hooks.slack.com/services/T0/B0/secret",
+		"discord=https://discord.com/api/webhooks/1/secret",
+	}, FormatPDF, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(n.Email) != 3 || n.Email[2] != "Night Shift <night@example.com>" || n.ReportFormat() != FormatPDF {
+		t.Errorf("unexpected notify %+v", n)
+	}
+	if len(n.Webhooks) != 2 || n.Webhooks[0].Kind != KindSlack || n.Webhooks[1].Kind != KindDiscord {
+		t.Errorf("unexpected webhooks %+v", n.Webhooks)
+	}
+	if got := n.String(); got != "email=ops@example.com,lab@example.com,Night Shift <night@example.com> slack=hooks.slack.com discord=discord.com (PDF) on failure" {
+		t.Errorf("unexpected description %q", got)
+	}
+
+	if n, err := ParseNotify(nil, "", false); n != nil || err != nil {
+		t.Errorf("expected nothing for no values, got %+v, %v", n, err)
+	}
+	for _, specs := range [][]string{{"teams=https://example.com"}, {"email="}, {"email=ops"}, {"slack=#ops"}, {"webhook"}} {
+		if _, err := ParseNotify(specs, "", false); err == nil {
+			t.Errorf("%v: expected an error", specs)
+		}
+	}
+	if _, err := ParseNotify([]string{"email=ops@example.com"}, "docx", false); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	global := &Notify{Webhooks: []Webhook{{URL: "https://example.com/hook"}}, ReportURL: "https://reports.lab/{host}/{run}"}
+	run := &Notify{Email: []string{"ops@example.com"}, Format: FormatPDF, OnlyFailures: true}
+
+	if Merge(nil, nil) != nil || Merge(global, nil) != global || Merge(nil, run) != run {
+		t.Error("expected a missing side to leave the other as it is")
+	}
+	m := Merge(global, run)
+	if len(m.Email) != 1 || len(m.Webhooks) != 1 || m.Format != FormatPDF || m.ReportURL != global.ReportURL {
+		t.Errorf("unexpected merge %+v", m)
+	}
+	// The global webhook still wants to hear about passing runs
+	if m.OnlyFailures || !m.Wants(true) || !run.Wants(false) || run.Wants(true) {
+		t.Errorf("unexpected failure filter %+v", m)
+	}
+}
+
+func TestSend(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received = make(map[string]*http.Request)
+		bodies   = make(map[string][]byte)
+		files    = make(map[string]string)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		received[r.URL.Path] = r
+		mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType == "multipart/form-data" {
+			reader := multipart.NewReader(r.Body, params["boundary"])
+			for {
+				part, err := reader.NextPart()
+				if err != nil {
+					break
+				}
+				data, _ := io.ReadAll(part)
+				if part.FormName() == "payload_json" {
+					bodies[r.URL.Path] = data
+				} else {
+					files[r.URL.Path] = part.FileName() + ":" + string(data)
+				}
+			}
+		} else {
+			bodies[r.URL.Path], _ = io.ReadAll(r.Body)
+		}
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	n := &Notify{
+		Webhooks: []Webhook{
+			{URL: server.URL + "/json", Headers: map[string]string{"Authorization": "Bearer token"}},
+			{URL: server.URL + "/slack", Kind: KindSlack},
+			{URL: server.URL + "/discord", Kind: KindDiscord},
+		},
+		ReportURL: "https://reports.lab/{host}/{run}.html",
+	}
+	start := time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)
+	end := start.Add(8*time.Hour + 1500*time.Millisecond)
+	run := NewRun(&db.Run{ID: 42, Plugin: "cpu", StartTime: start, EndTime: &end, Error: "worker 3 stopped"}, "rig-01", "Weekly Burn-in", n.ReportURL)
+
+	reports := 0
+	sender := &Sender{Report: func(format string) (mail.Attachment, error) {
+		reports++
+		return mail.Attachment{Name: "fire-run-42." + format, ContentType: "text/html", Data: []byte("<html>")}, nil
+	}}
+	if err := sender.Send(context.Background(), n, run); err != nil {
+		t.Fatal(err)
+	}
+	if reports != 1 {
+		t.Errorf("expected the report to be generated once for Discord, got %d", reports)
+	}
+
+	var plain JSONPayload
+	if err := json.Unmarshal(bodies["/json"], &plain); err != nil {
+		t.Fatal(err)
+	}
+	if plain.Event != "run_finished" || plain.RunID != 42 || plain.Success || plain.Status != "FAILED" ||
+		plain.Duration != 8*3600+2 || plain.ReportURL != "https://reports.lab/rig-01/42.html" || plain.Schedule != "Weekly Burn-in" {
+		t.Errorf("unexpected JSON payload %+v", plain)
+	}
+	if got := received["/json"].Header.Get("Authorization"); got != "Bearer token" {
+		t.Errorf("expected the configured header, got %q", got)
+	}
+
+	var slack struct {
+		Text        string
+		Attachments []struct {
+			Color     string
+			TitleLink string `json:"title_link"`
+			Fields    []struct{ Title, Value string }
+		}
+	}
+	if err := json.Unmarshal(bodies["/slack"], &slack); err != nil {
+		t.Fatal(err)
+	}
+	if slack.Text != "[F.I.R.E. rig-01] cpu run #42 FAILED" || len(slack.Attachments) != 1 ||
+		slack.Attachments[0].Color != "danger" || slack.Attachments[0].TitleLink != plain.ReportURL {
+		t.Errorf("unexpected Slack payload %s", bodies["/slack"])
+	}
+
+	var discord struct {
+		Content string
+		Embeds  []struct {
+			Color  int
+			URL    string
+			Fields []struct{ Name, Value string }
+		}
+	}
+	if err := json.Unmarshal(bodies["/discord"], &discord); err != nil {
+		t.Fatal(err)
+	}
+	if len(discord.Embeds) != 1 || discord.Embeds[0].Color != colorFail || discord.Embeds[0].Fields[2].Value != "8h0m2s" {
+		t.Errorf("unexpected Discord payload %s", bodies["/discord"])
+	}
+	if files["/discord"] != "fire-run-42.html:<html>" {
+		t.Errorf("expected the report attached to the Discord message, got %q", files["/discord"])
+	}
+
+	// Passing runs are skipped when only failures are wanted
+	delete(received, "/json")
+	n.OnlyFailures = true
+	run.Passed = true
+	if err := sender.Send(context.Background(), n, run); err != nil || received["/json"] != nil {
+		t.Errorf("expected nothing sent for a passing run, got %v", err)
+	}
+
+	broken := &Notify{Webhooks: []Webhook{{URL: server.URL + "/broken"}}, Email: []string{"ops@example.com"}}
+	err := (&Sender{}).Send(context.Background(), broken, run)
+	if err == nil || !strings.Contains(err.Error(), "500") || !strings.Contains(err.Error(), "no SMTP server") {
+		t.Errorf("expected the webhook and mail errors, got %v", err)
+	}
+}
@@ -0,0 +1,374 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/mail"
+)
+
+// DefaultTimeout limits how long one webhook may take to accept a message
+const DefaultTimeout = 30 * time.Second
+
+// Colors of the Discord embed, matching the PASS and FAIL of the reports
+const (
+	colorPass = 0x1a7f37
+	colorFail = 0xcf222e
+)
+
+// Run is what notifications say about a finished run
+type Run struct {
+	ID          int64
+	Plugin      string
+	Host        string
+	Schedule    string // Schedule that started the run, if any
+	Passed      bool
+	Error       string
+	Environment string
+	Started     time.Time
+	Duration    time.Duration // 0 when the run has not ended
+	ReportURL   string        // Link to the published report, if any
+}
+
+// NewRun describes a finished run recorded on host. reportURL is the
+// ReportURL of the targets, with {host} and {run} still to be replaced.
+func NewRun(run *db.Run, host, schedule, reportURL string) Run {
+	r := Run{
+		ID:          run.ID,
+		Plugin:      run.Plugin,
+		Host:        host,
+		Schedule:    schedule,
+		Passed:      run.Success,
+		Error:       run.Error,
+		Environment: run.Environment,
+		Started:     run.StartTime,
+	}
+	if run.EndTime != nil {
+		r.Duration = run.EndTime.Sub(run.StartTime).Round(time.Second)
+	}
+	if reportURL != "" {
+		r.ReportURL = strings.NewReplacer("{host}", host, "{run}", strconv.FormatInt(run.ID, 10)).Replace(reportURL)
+	}
+	return r
+}
+
+// Status returns PASSED or FAILED
+func (r Run) Status() string {
+	if r.Passed {
+		return "PASSED"
+	}
+	return "FAILED"
+}
+
+// Subject summarizes the run in one line, e.g. "[F.I.R.E. rig-01] cpu run
+// #12 PASSED"
+func (r Run) Subject() string {
+	return fmt.Sprintf("[F.I.R.E. %s] %s run #%d %s", r.Host, r.Plugin, r.ID, r.Status())
+}
+
+// Body describes the run as plain text
+func (r Run) Body() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "The %s test on %s %s.\n\n", r.Plugin, r.Host, strings.ToLower(r.Status()))
+	fmt.Fprintf(&b, "Run:       #%d\n", r.ID)
+	if r.Schedule != "" {
+		fmt.Fprintf(&b, "Schedule:  %s\n", r.Schedule)
+	}
+	fmt.Fprintf(&b, "Started:   %s\n", r.Started.Format(time.RFC1123))
+	if r.Duration > 0 {
+		fmt.Fprintf(&b, "Duration:  %s\n", r.Duration)
+	}
+	if r.Environment != "" {
+		fmt.Fprintf(&b, "Machine:   %s\n", r.Environment)
+	}
+	if r.Error != "" {
+		fmt.Fprintf(&b, "Error:     %s\n", r.Error)
+	}
+	if r.ReportURL != "" {
+		fmt.Fprintf(&b, "Report:    %s\n", r.ReportURL)
+	}
+	return b.String()
+}
+
+// ReportFunc generates the report of the run in a format, to be attached
+type ReportFunc func(format string) (mail.Attachment, error)
+
+// Sender delivers the notifications of a run
+type Sender struct {
+	SMTP    *mail.Config // Needed when the targets include email
+	Client  *http.Client // Defaults to http.DefaultClient
+	Report  ReportFunc   // Generates the attached report; nil attaches none
+	Timeout time.Duration
+}
+
+// Send mails the run's report and posts it to every webhook of n, unless n
+// only wants failures and the run passed. The report is generated once and
+// attached to the mail and to Discord messages; when it cannot be made the
+// messages are sent without it. It returns the delivery errors joined.
+func (s *Sender) Send(ctx context.Context, n *Notify, r Run) error {
+	if n == nil || !n.Wants(r.Passed) {
+		return nil
+	}
+
+	var (
+		attachment *mail.Attachment
+		reportErr  error
+	)
+	needsReport := len(n.Email) > 0
+	for _, w := range n.Webhooks {
+		needsReport = needsReport || w.kind() == KindDiscord
+	}
+	if s.Report != nil && needsReport {
+		a, err := s.Report(n.ReportFormat())
+		if err != nil {
+			reportErr = err
+		} else {
+			attachment = &a
+		}
+	}
+
+	var errs []error
+	if len(n.Email) > 0 {
+		errs = append(errs, s.mail(ctx, n.Email, r, attachment, reportErr))
+	}
+	for _, w := range n.Webhooks {
+		errs = append(errs, s.post(ctx, w, r, attachment))
+	}
+	return errors.Join(errs...)
+}
+
+// mail sends the run's description with the report attached
+func (s *Sender) mail(ctx context.Context, to []string, r Run, attachment *mail.Attachment, reportErr error) error {
+	if s.SMTP == nil {
+		return errors.New("no SMTP server configured")
+	}
+	msg := mail.Message{To: to, Subject: r.Subject(), Body: r.Body()}
+	if attachment != nil {
+		msg.Attachments = []mail.Attachment{*attachment}
+	} else if reportErr != nil {
+		msg.Body += fmt.Sprintf("\nThe report could not be attached: %v\n", reportErr)
+	}
+	if err := mail.Send(ctx, *s.SMTP, msg); err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	return nil
+}
+
+// post sends the run to one webhook in its format and fails unless the
+// server answers 2xx
+func (s *Sender) post(ctx context.Context, w Webhook, r Run, attachment *mail.Attachment) error {
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	payload, err := json.Marshal(Payload(w.kind(), r))
+	if err != nil {
+		return err
+	}
+	body, contentType := io.Reader(bytes.NewReader(payload)), "application/json"
+	if w.kind() == KindDiscord && attachment != nil {
+		body, contentType, err = discordMultipart(payload, *attachment)
+		if err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for name, value := range w.Headers {
+		req.Header.Set(name, value)
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s webhook %s: %w", w.kind(), req.URL.Host, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s webhook %s returned %s", w.kind(), req.URL.Host, resp.Status)
+	}
+	return nil
+}
+
+// JSONPayload is the body posted to plain webhooks. Text repeats the
+// summary under the key Mattermost and similar incoming webhooks display.
+type JSONPayload struct {
+	Event       string    `json:"event"` // Always "run_finished"
+	RunID       int64     `json:"run_id"`
+	Plugin      string    `json:"plugin"`
+	Host        string    `json:"host"`
+	Schedule    string    `json:"schedule,omitempty"`
+	Success     bool      `json:"success"`
+	Status      string    `json:"status"`
+	Error       string    `json:"error,omitempty"`
+	Environment string    `json:"environment,omitempty"`
+	Started     time.Time `json:"started"`
+	Duration    float64   `json:"duration_seconds"`
+	ReportURL   string    `json:"report_url,omitempty"`
+	Text        string    `json:"text"`
+}
+
+// Payload returns the message posted to a webhook of the given kind
+func Payload(kind Kind, r Run) interface{} {
+	switch kind {
+	case KindSlack:
+		return slackPayload(r)
+	case KindDiscord:
+		return discordPayload(r)
+	}
+	return JSONPayload{
+		Event:       "run_finished",
+		RunID:       r.ID,
+		Plugin:      r.Plugin,
+		Host:        r.Host,
+		Schedule:    r.Schedule,
+		Success:     r.Passed,
+		Status:      r.Status(),
+		Error:       r.Error,
+		Environment: r.Environment,
+		Started:     r.Started.UTC(),
+		Duration:    r.Duration.Seconds(),
+		ReportURL:   r.ReportURL,
+		Text:        r.Subject(),
+	}
+}
+
+// field is one name and value shown in a Slack attachment or Discord embed
+type field struct {
+	name, value string
+}
+
+// fields lists the details of the run shown in chat messages
+func (r Run) fields() []field {
+	fields := []field{{"Host", r.Host}, {"Test", r.Plugin}}
+	if r.Duration > 0 {
+		fields = append(fields, field{"Duration", r.Duration.String()})
+	}
+	if r.Schedule != "" {
+		fields = append(fields, field{"Schedule", r.Schedule})
+	}
+	if r.Environment != "" {
+		fields = append(fields, field{"Environment", r.Environment})
+	}
+	return fields
+}
+
+// slackPayload formats the run as a Slack message with a colored
+// attachment linking to the report
+func slackPayload(r Run) map[string]interface{} {
+	color := "good"
+	if !r.Passed {
+		color = "danger"
+	}
+	fields := make([]map[string]interface{}, 0, 6)
+	for _, f := range r.fields() {
+		fields = append(fields, map[string]interface{}{"title": f.name, "value": f.value, "short": true})
+	}
+	if r.Error != "" {
+		fields = append(fields, map[string]interface{}{"title": "Error", "value": r.Error, "short": false})
+	}
+	attachment := map[string]interface{}{
+		"color":    color,
+		"title":    fmt.Sprintf("%s run #%d %s", r.Plugin, r.ID, r.Status()),
+		"fields":   fields,
+		"ts":       r.Started.Unix(),
+		"fallback": r.Subject(),
+	}
+	if r.ReportURL != "" {
+		attachment["title_link"] = r.ReportURL
+	}
+	return map[string]interface{}{
+		"text":        r.Subject(),
+		"attachments": []interface{}{attachment},
+	}
+}
+
+// discordPayload formats the run as a Discord message with an embed
+// linking to the report
+func discordPayload(r Run) map[string]interface{} {
+	color := colorPass
+	if !r.Passed {
+		color = colorFail
+	}
+	fields := make([]map[string]interface{}, 0, 6)
+	for _, f := range r.fields() {
+		fields = append(fields, map[string]interface{}{"name": f.name, "value": f.value, "inline": true})
+	}
+	if r.Error != "" {
+		fields = append(fields, map[string]interface{}{"name": "Error", "value": truncate(r.Error, 1024), "inline": false})
+	}
+	embed := map[string]interface{}{
+		"title":     fmt.Sprintf("%s run #%d %s", r.Plugin, r.ID, r.Status()),
+		"color":     color,
+		"fields":    fields,
+		"timestamp": r.Started.UTC().Format(time.RFC3339),
+	}
+	if r.ReportURL != "" {
+		embed["url"] = r.ReportURL
+	}
+	return map[string]interface{}{
+		"content": r.Subject(),
+		"embeds":  []interface{}{embed},
+	}
+}
+
+// discordMultipart attaches the report to a Discord message, which takes
+// the message as payload_json and the file as files[0]
+func discordMultipart(payload []byte, a mail.Attachment) (io.Reader, string, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormField("payload_json")
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := part.Write(payload); err != nil {
+		return nil, "", err
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="files[0]"; filename=%q`, a.Name))
+	if a.ContentType != "" {
+		header.Set("Content-Type", a.ContentType)
+	}
+	part, err = w.CreatePart(header)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := part.Write(a.Data); err != nil {
+		return nil, "", err
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return &body, w.FormDataContentType(), nil
+}
+
+// truncate shortens s to at most n characters, as chat services cap the
+// size of fields
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-3]) + "..."
+}
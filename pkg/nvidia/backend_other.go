@@ -0,0 +1,6 @@
+//go:build !nvml || (linux && !cgo) || (!linux && !windows)
+
+package nvidia
+
+// backends is empty without the nvml build tag
+var backends []func() (backend, error)
@@ -0,0 +1,78 @@
+// Package nvidia reads NVIDIA GPU telemetry straight from the driver's
+// libraries instead of running nvidia-smi for every poll: NVML
+// (libnvidia-ml.so.1 on Linux, nvml.dll on Windows) and, on Windows drivers
+// without NVML, NVAPI (nvapi64.dll), which reports usage and temperature
+// only.
+//
+// The native backends are built with the nvml build tag; Linux also needs
+// cgo to load the library. Without them, or when no NVIDIA driver is
+// installed, Read returns ErrUnavailable and callers fall back to
+// nvidia-smi.
+package nvidia
+
+import (
+	"errors"
+	"sync"
+)
+
+// GPU holds one card's readings. Values the backend cannot read are zero.
+type GPU struct {
+	Index         int
+	Name          string
+	Usage         float64 // Percent
+	Temp          float64 // °C
+	Power         float64 // W
+	PowerLimit    float64 // W
+	Clock         float64 // Graphics clock in MHz
+	MemoryUsedMB  float64
+	MemoryTotalMB float64
+	FanSpeed      float64 // Percent
+}
+
+// ErrUnavailable means no native backend could be loaded
+var ErrUnavailable = errors.New("no native NVIDIA library")
+
+// backend reads the cards through one library
+type backend interface {
+	name() string
+	read() ([]GPU, error)
+}
+
+var (
+	loadOnce sync.Once
+	loaded   backend
+	readMu   sync.Mutex // The libraries are not documented as thread-safe
+)
+
+// load opens the first backend that works, once per process
+func load() backend {
+	loadOnce.Do(func() {
+		for _, open := range backends {
+			if b, err := open(); err == nil {
+				loaded = b
+				return
+			}
+		}
+	})
+	return loaded
+}
+
+// Read returns the readings of every NVIDIA card, in the driver's order
+func Read() ([]GPU, error) {
+	b := load()
+	if b == nil {
+		return nil, ErrUnavailable
+	}
+	readMu.Lock()
+	defer readMu.Unlock()
+	return b.read()
+}
+
+// Backend names the library Read uses, "NVML" or "NVAPI", or returns ""
+// when there is none
+func Backend() string {
+	if b := load(); b != nil {
+		return b.name()
+	}
+	return ""
+}
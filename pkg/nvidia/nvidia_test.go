@@ -0,0 +1,17 @@
+//go:build !nvml
+
+package nvidia
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReadWithoutBackend(t *testing.T) {
+	if _, err := Read(); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected ErrUnavailable without the nvml tag, got %v", err)
+	}
+	if name := Backend(); name != "" {
+		t.Errorf("expected no backend, got %q", name)
+	}
+}
@@ -0,0 +1,159 @@
+//go:build nvml && cgo
+
+package nvidia
+
+/*
+#cgo LDFLAGS: -ldl
+#include <dlfcn.h>
+#include <stdlib.h>
+
+// The subset of nvml.h the package calls. The library is opened with dlopen
+// so the binary still starts on machines without the NVIDIA driver.
+
+typedef void *nvmlDevice_t;
+typedef struct { unsigned int gpu; unsigned int memory; } nvmlUtilization_t;
+typedef struct { unsigned long long total; unsigned long long free; unsigned long long used; } nvmlMemory_t;
+
+static void *nvml;
+
+static int nvmlOpen(void) {
+	nvml = dlopen("libnvidia-ml.so.1", RTLD_LAZY);
+	if (!nvml) return -1;
+	int (*init)(void) = dlsym(nvml, "nvmlInit_v2");
+	return init ? init() : -1;
+}
+
+static void *sym(const char *name) { return dlsym(nvml, name); }
+
+static int deviceCount(unsigned int *count) {
+	int (*f)(unsigned int *) = sym("nvmlDeviceGetCount_v2");
+	return f ? f(count) : -1;
+}
+
+static int deviceHandle(unsigned int index, nvmlDevice_t *dev) {
+	int (*f)(unsigned int, nvmlDevice_t *) = sym("nvmlDeviceGetHandleByIndex_v2");
+	return f ? f(index, dev) : -1;
+}
+
+static int deviceName(nvmlDevice_t dev, char *name, unsigned int length) {
+	int (*f)(nvmlDevice_t, char *, unsigned int) = sym("nvmlDeviceGetName");
+	return f ? f(dev, name, length) : -1;
+}
+
+static int deviceUtilization(nvmlDevice_t dev, nvmlUtilization_t *util) {
+	int (*f)(nvmlDevice_t, nvmlUtilization_t *) = sym("nvmlDeviceGetUtilizationRates");
+	return f ? f(dev, util) : -1;
+}
+
+static int deviceMemory(nvmlDevice_t dev, nvmlMemory_t *mem) {
+	int (*f)(nvmlDevice_t, nvmlMemory_t *) = sym("nvmlDeviceGetMemoryInfo");
+	return f ? f(dev, mem) : -1;
+}
+
+// deviceValue calls one of the getters that take the device and return an
+// unsigned int
+static int deviceValue(const char *name, nvmlDevice_t dev, unsigned int *value) {
+	int (*f)(nvmlDevice_t, unsigned int *) = sym(name);
+	return f ? f(dev, value) : -1;
+}
+
+// deviceTypedValue calls one of the getters that also take a sensor or
+// clock type
+static int deviceTypedValue(const char *name, nvmlDevice_t dev, int type, unsigned int *value) {
+	int (*f)(nvmlDevice_t, int, unsigned int *) = sym(name);
+	return f ? f(dev, type, value) : -1;
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// NVML constants from nvml.h
+const (
+	nvmlNameBufferSize = 96
+	nvmlTemperatureGPU = 0
+	nvmlClockGraphics  = 0
+)
+
+var backends = []func() (backend, error){openNVML}
+
+// nvml reads the cards through libnvidia-ml.so.1
+type nvml struct{}
+
+// openNVML loads libnvidia-ml.so.1 and initializes it
+func openNVML() (backend, error) {
+	if C.nvmlOpen() != 0 {
+		return nil, ErrUnavailable
+	}
+	return nvml{}, nil
+}
+
+func (nvml) name() string { return "NVML" }
+
+// value reads one of NVML's unsigned int getters
+func value(name string, dev C.nvmlDevice_t) (float64, bool) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	var v C.uint
+	ok := C.deviceValue(cname, dev, &v) == 0
+	return float64(v), ok
+}
+
+// typedValue reads one of NVML's unsigned int getters that take a type
+func typedValue(name string, dev C.nvmlDevice_t, typ int) (float64, bool) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	var v C.uint
+	ok := C.deviceTypedValue(cname, dev, C.int(typ), &v) == 0
+	return float64(v), ok
+}
+
+func (nvml) read() ([]GPU, error) {
+	var count C.uint
+	if r := C.deviceCount(&count); r != 0 {
+		return nil, fmt.Errorf("nvmlDeviceGetCount_v2 failed with %d", r)
+	}
+	gpus := make([]GPU, 0, count)
+	for i := C.uint(0); i < count; i++ {
+		var dev C.nvmlDevice_t
+		if r := C.deviceHandle(i, &dev); r != 0 {
+			return nil, fmt.Errorf("nvmlDeviceGetHandleByIndex_v2 failed with %d", r)
+		}
+		gpu := GPU{Index: int(i)}
+
+		// Each reading is optional: consumer cards do not report every one
+		var name [nvmlNameBufferSize]C.char
+		if C.deviceName(dev, &name[0], nvmlNameBufferSize) == 0 {
+			gpu.Name = C.GoString(&name[0])
+		}
+		var util C.nvmlUtilization_t
+		if C.deviceUtilization(dev, &util) == 0 {
+			gpu.Usage = float64(util.gpu)
+		}
+		if v, ok := typedValue("nvmlDeviceGetTemperature", dev, nvmlTemperatureGPU); ok {
+			gpu.Temp = v
+		}
+		if v, ok := value("nvmlDeviceGetPowerUsage", dev); ok {
+			gpu.Power = v / 1000
+		}
+		if v, ok := value("nvmlDeviceGetEnforcedPowerLimit", dev); ok {
+			gpu.PowerLimit = v / 1000
+		}
+		if v, ok := typedValue("nvmlDeviceGetClockInfo", dev, nvmlClockGraphics); ok {
+			gpu.Clock = v
+		}
+		if v, ok := value("nvmlDeviceGetFanSpeed", dev); ok {
+			gpu.FanSpeed = v
+		}
+		var mem C.nvmlMemory_t
+		if C.deviceMemory(dev, &mem) == 0 {
+			gpu.MemoryUsedMB = float64(mem.used) / (1 << 20)
+			gpu.MemoryTotalMB = float64(mem.total) / (1 << 20)
+		}
+		gpus = append(gpus, gpu)
+	}
+	return gpus, nil
+}
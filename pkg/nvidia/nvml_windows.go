@@ -0,0 +1,224 @@
+//go:build nvml && windows
+
+package nvidia
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// NVML and NVAPI constants from nvml.h and nvapi.h
+const (
+	nvmlSuccess          = 0
+	nvmlNameBufferSize   = 96
+	nvmlTemperatureGPU   = 0
+	nvmlClockGraphics    = 0
+	nvapiMaxPhysicalGPUs = 64
+	nvapiShortString     = 64
+	nvapiThermalAll      = 15 // NVAPI_THERMAL_TARGET_ALL
+
+	nvapiInitialize              = 0x0150E828
+	nvapiEnumPhysicalGPUs        = 0xE5AC921F
+	nvapiGetFullName             = 0xCEEE8E9F
+	nvapiGetThermalSettings      = 0xE3640A56
+	nvapiGetDynamicPstatesInfoEx = 0x60DED2ED
+)
+
+var backends = []func() (backend, error){openNVML, openNVAPI}
+
+// nvmlUtilization is nvmlUtilization_t
+type nvmlUtilization struct {
+	gpu    uint32
+	memory uint32
+}
+
+// nvmlMemory is nvmlMemory_t
+type nvmlMemory struct {
+	total uint64
+	free  uint64
+	used  uint64
+}
+
+// nvml reads the cards through nvml.dll
+type nvml struct {
+	dll *windows.LazyDLL
+}
+
+// openNVML loads nvml.dll from System32, where current drivers install it,
+// or from the NVSMI folder of older drivers, and initializes it
+func openNVML() (backend, error) {
+	for _, dll := range []*windows.LazyDLL{
+		windows.NewLazySystemDLL("nvml.dll"),
+		windows.NewLazyDLL(`C:\Program Files\NVIDIA Corporation\NVSMI\nvml.dll`),
+	} {
+		if dll.Load() != nil {
+			continue
+		}
+		n := &nvml{dll: dll}
+		if err := n.call("nvmlInit_v2"); err != nil {
+			return nil, err
+		}
+		return n, nil
+	}
+	return nil, ErrUnavailable
+}
+
+func (n *nvml) name() string { return "NVML" }
+
+// call calls an NVML function and turns its nvmlReturn_t into an error
+func (n *nvml) call(name string, args ...uintptr) error {
+	proc := n.dll.NewProc(name)
+	if err := proc.Find(); err != nil {
+		return err
+	}
+	if r, _, _ := proc.Call(args...); r != nvmlSuccess {
+		return fmt.Errorf("%s failed with %d", name, r)
+	}
+	return nil
+}
+
+func (n *nvml) read() ([]GPU, error) {
+	var count uint32
+	if err := n.call("nvmlDeviceGetCount_v2", uintptr(unsafe.Pointer(&count))); err != nil { // #nosec G103 -- output for the NVML API
+		return nil, err
+	}
+	gpus := make([]GPU, 0, count)
+	for i := uint32(0); i < count; i++ {
+		var h uintptr
+		if err := n.call("nvmlDeviceGetHandleByIndex_v2", uintptr(i), uintptr(unsafe.Pointer(&h))); err != nil { // #nosec G103 -- output for the NVML API
+			return nil, err
+		}
+		gpu := GPU{Index: int(i)}
+
+		// Each reading is optional: consumer cards do not report every one
+		var name [nvmlNameBufferSize]byte
+		if n.call("nvmlDeviceGetName", h, uintptr(unsafe.Pointer(&name[0])), nvmlNameBufferSize) == nil { // #nosec G103 -- output for the NVML API
+			gpu.Name = windows.ByteSliceToString(name[:])
+		}
+		var util nvmlUtilization
+		if n.call("nvmlDeviceGetUtilizationRates", h, uintptr(unsafe.Pointer(&util))) == nil { // #nosec G103 -- output for the NVML API
+			gpu.Usage = float64(util.gpu)
+		}
+		var value uint32
+		if n.call("nvmlDeviceGetTemperature", h, nvmlTemperatureGPU, uintptr(unsafe.Pointer(&value))) == nil { // #nosec G103 -- output for the NVML API
+			gpu.Temp = float64(value)
+		}
+		if n.call("nvmlDeviceGetPowerUsage", h, uintptr(unsafe.Pointer(&value))) == nil { // #nosec G103 -- output for the NVML API
+			gpu.Power = float64(value) / 1000
+		}
+		if n.call("nvmlDeviceGetEnforcedPowerLimit", h, uintptr(unsafe.Pointer(&value))) == nil { // #nosec G103 -- output for the NVML API
+			gpu.PowerLimit = float64(value) / 1000
+		}
+		if n.call("nvmlDeviceGetClockInfo", h, nvmlClockGraphics, uintptr(unsafe.Pointer(&value))) == nil { // #nosec G103 -- output for the NVML API
+			gpu.Clock = float64(value)
+		}
+		if n.call("nvmlDeviceGetFanSpeed", h, uintptr(unsafe.Pointer(&value))) == nil { // #nosec G103 -- output for the NVML API
+			gpu.FanSpeed = float64(value)
+		}
+		var mem nvmlMemory
+		if n.call("nvmlDeviceGetMemoryInfo", h, uintptr(unsafe.Pointer(&mem))) == nil { // #nosec G103 -- output for the NVML API
+			gpu.MemoryUsedMB = float64(mem.used) / (1 << 20)
+			gpu.MemoryTotalMB = float64(mem.total) / (1 << 20)
+		}
+		gpus = append(gpus, gpu)
+	}
+	return gpus, nil
+}
+
+// nvapiThermalSensor is one sensor of NV_GPU_THERMAL_SETTINGS_V2
+type nvapiThermalSensor struct {
+	controller     int32
+	defaultMinTemp int32
+	defaultMaxTemp int32
+	currentTemp    int32
+	target         int32
+}
+
+// nvapiThermalSettings is NV_GPU_THERMAL_SETTINGS_V2
+type nvapiThermalSettings struct {
+	version uint32
+	count   uint32
+	sensor  [3]nvapiThermalSensor
+}
+
+// nvapiPstatesInfo is NV_GPU_DYNAMIC_PSTATES_INFO_EX. Utilization 0 is the
+// graphics engine.
+type nvapiPstatesInfo struct {
+	version     uint32
+	flags       uint32
+	utilization [8]struct {
+		present    uint32
+		percentage uint32
+	}
+}
+
+// nvapiVersion builds an NVAPI struct version, the struct size with the
+// version number in the high word
+func nvapiVersion(size uintptr, version uint32) uint32 {
+	return uint32(size) | version<<16
+}
+
+// nvapi reads usage and temperature through nvapi64.dll, for drivers that
+// ship without NVML
+type nvapi struct {
+	query *windows.LazyProc
+}
+
+// openNVAPI loads nvapi64.dll and initializes it
+func openNVAPI() (backend, error) {
+	dll := windows.NewLazySystemDLL("nvapi64.dll")
+	query := dll.NewProc("nvapi_QueryInterface")
+	if query.Find() != nil {
+		return nil, ErrUnavailable
+	}
+	n := &nvapi{query: query}
+	if err := n.call(nvapiInitialize); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func (n *nvapi) name() string { return "NVAPI" }
+
+// call looks up an NVAPI function by its interface ID and calls it
+func (n *nvapi) call(id uintptr, args ...uintptr) error {
+	fn, _, _ := n.query.Call(id)
+	if fn == 0 {
+		return errors.New("NVAPI function not available")
+	}
+	if r, _, _ := syscall.SyscallN(fn, args...); r != 0 {
+		return fmt.Errorf("NVAPI call %#x failed with %d", id, int32(r))
+	}
+	return nil
+}
+
+func (n *nvapi) read() ([]GPU, error) {
+	var handles [nvapiMaxPhysicalGPUs]uintptr
+	var count uint32
+	if err := n.call(nvapiEnumPhysicalGPUs, uintptr(unsafe.Pointer(&handles[0])), uintptr(unsafe.Pointer(&count))); err != nil { // #nosec G103 -- output for the NVAPI
+		return nil, err
+	}
+	count = min(count, nvapiMaxPhysicalGPUs)
+	gpus := make([]GPU, 0, count)
+	for i, h := range handles[:count] {
+		gpu := GPU{Index: i}
+		var name [nvapiShortString]byte
+		if n.call(nvapiGetFullName, h, uintptr(unsafe.Pointer(&name[0]))) == nil { // #nosec G103 -- output for the NVAPI
+			gpu.Name = windows.ByteSliceToString(name[:])
+		}
+		thermal := nvapiThermalSettings{version: nvapiVersion(unsafe.Sizeof(nvapiThermalSettings{}), 2)}
+		if n.call(nvapiGetThermalSettings, h, nvapiThermalAll, uintptr(unsafe.Pointer(&thermal))) == nil && thermal.count > 0 { // #nosec G103 -- output for the NVAPI
+			gpu.Temp = float64(thermal.sensor[0].currentTemp)
+		}
+		pstates := nvapiPstatesInfo{version: nvapiVersion(unsafe.Sizeof(nvapiPstatesInfo{}), 1)}
+		if n.call(nvapiGetDynamicPstatesInfoEx, h, uintptr(unsafe.Pointer(&pstates))) == nil && pstates.utilization[0].present != 0 { // #nosec G103 -- output for the NVAPI
+			gpu.Usage = float64(pstates.utilization[0].percentage)
+		}
+		gpus = append(gpus, gpu)
+	}
+	return gpus, nil
+}
@@ -0,0 +1,113 @@
+//go:build linux
+// +build linux
+
+package pcie
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// sysfsRoot is the root the PCI devices are read under, replaced in tests
+var sysfsRoot = "/"
+
+// pciAddress matches a domain:bus:device.function address
+var pciAddress = regexp.MustCompile(`^[0-9a-f]{4}:[0-9a-f]{2}:[0-9a-f]{2}\.[0-7]$`)
+
+// Devices lists the PCI functions in /sys/bus/pci/devices, the source lspci
+// reads, named by lspci when it is installed
+func Devices() ([]Device, error) {
+	dir := filepath.Join(sysfsRoot, "sys/bus/pci/devices")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []Device
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		d := Device{
+			Address:      e.Name(),
+			LinkWidth:    readInt(filepath.Join(path, "current_link_width")),
+			MaxLinkWidth: readInt(filepath.Join(path, "max_link_width")),
+			LinkSpeed:    readString(filepath.Join(path, "current_link_speed")),
+			MaxLinkSpeed: readString(filepath.Join(path, "max_link_speed")),
+		}
+		if class, err := strconv.ParseUint(strings.TrimPrefix(readString(filepath.Join(path, "class")), "0x"), 16, 32); err == nil {
+			d.Class = uint32(class)
+		}
+		// The link resolves to the device's place in the hierarchy, e.g.
+		// /sys/devices/pci0000:00/0000:00:01.1/0000:01:00.0
+		if target, err := filepath.EvalSymlinks(path); err == nil {
+			if parent := filepath.Base(filepath.Dir(target)); pciAddress.MatchString(parent) {
+				d.Parent = parent
+			}
+		}
+		// Width 255 means the link is down or the field is not implemented
+		if d.LinkWidth == 255 {
+			d.LinkWidth = 0
+		}
+		devices = append(devices, d)
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Address < devices[j].Address })
+
+	names := lspciNames()
+	for i := range devices {
+		devices[i].Name = names[devices[i].Address]
+	}
+	return devices, nil
+}
+
+// lspciNames returns the vendor and device name of each function from
+// "lspci -mm -D", or nothing when lspci is missing
+func lspciNames() map[string]string {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	output, err := safeexec.CommandContext(ctx, "lspci", "-mm", "-D").Output()
+	if err != nil {
+		return nil
+	}
+	return parseLspci(string(output))
+}
+
+// parseLspci reads the machine-readable lspci format, where each line is an
+// address followed by quoted class, vendor and device names:
+//
+//	0000:01:00.0 "VGA compatible controller" "NVIDIA Corporation" "AD102 [GeForce RTX 4090]" -ra1 ...
+func parseLspci(output string) map[string]string {
+	names := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		address, rest, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if !ok {
+			continue
+		}
+		quoted := strings.Split(rest, "\"")
+		// Quoted fields sit at the odd indexes: class, vendor, device
+		if len(quoted) < 7 {
+			continue
+		}
+		names[address] = strings.TrimSpace(quoted[3] + " " + quoted[5])
+	}
+	return names
+}
+
+func readString(path string) string {
+	data, err := os.ReadFile(path) // #nosec G304 -- sysfs path
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func readInt(path string) int {
+	n, _ := strconv.Atoi(readString(path))
+	return n
+}
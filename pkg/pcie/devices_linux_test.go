@@ -0,0 +1,71 @@
+package pcie
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDevicesLinux(t *testing.T) {
+	root := t.TempDir()
+	old := sysfsRoot
+	sysfsRoot = root
+	t.Cleanup(func() { sysfsRoot = old })
+
+	// A root port with a graphics card below it, linked through
+	// /sys/bus/pci/devices as the kernel does
+	port := filepath.Join(root, "sys/devices/pci0000:00/0000:00:01.1")
+	card := filepath.Join(port, "0000:01:00.0")
+	files := map[string]string{
+		filepath.Join(port, "class"):              "0x060400",
+		filepath.Join(port, "current_link_width"): "16",
+		filepath.Join(card, "class"):              "0x030000",
+		filepath.Join(card, "current_link_width"): "8",
+		filepath.Join(card, "max_link_width"):     "16",
+		filepath.Join(card, "current_link_speed"): "16.0 GT/s PCIe",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := filepath.Join(root, "sys/bus/pci/devices")
+	if err := os.MkdirAll(links, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	for _, target := range []string{port, card} {
+		if err := os.Symlink(target, filepath.Join(links, filepath.Base(target))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	devices, err := Devices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 2 {
+		t.Fatalf("expected 2 devices, got %d", len(devices))
+	}
+	if !devices[0].Bridge() || devices[0].Parent != "" {
+		t.Errorf("expected a root port without a parent, got %+v", devices[0])
+	}
+	if d := devices[1]; d.Parent != "0000:00:01.1" || d.LinkWidth != 8 || d.MaxLinkWidth != 16 || d.LinkSpeed != "16.0 GT/s PCIe" || d.Class != 0x030000 {
+		t.Errorf("unexpected card %+v", d)
+	}
+}
+
+func TestParseLspci(t *testing.T) {
+	output := `0000:00:01.1 "PCI bridge" "Advanced Micro Devices, Inc. [AMD]" "Raphael/Granite Ridge GPP Bridge" "" ""
+0000:01:00.0 "VGA compatible controller" "NVIDIA Corporation" "AD102 [GeForce RTX 4090]" -ra1 "ASUSTeK Computer Inc." "Device 889d"
+`
+	names := parseLspci(output)
+	if names["0000:01:00.0"] != "NVIDIA Corporation AD102 [GeForce RTX 4090]" {
+		t.Errorf("unexpected name %q", names["0000:01:00.0"])
+	}
+	if names["0000:00:01.1"] != "Advanced Micro Devices, Inc. [AMD] Raphael/Granite Ridge GPP Bridge" {
+		t.Errorf("unexpected name %q", names["0000:00:01.1"])
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package pcie
+
+import "errors"
+
+// Devices is only implemented on Linux. Elsewhere the slot map lists the
+// slots with the usage SMBIOS reports but without the fitted devices.
+func Devices() ([]Device, error) {
+	return nil, errors.New("listing PCI devices is not supported on this platform")
+}
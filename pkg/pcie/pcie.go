@@ -0,0 +1,171 @@
+// Package pcie matches the PCI Express devices in a machine to the physical
+// slots the SMBIOS tables describe, so the inventory can show what sits in
+// each slot and flag cards that run on fewer lanes than they support.
+//
+// A common assembly mistake is fitting a graphics card or NVMe adapter in a
+// full-length slot that is only wired for four lanes, or one that shares its
+// lanes with an M.2 socket. Map compares the card's link with the slot's
+// wiring and explains the difference.
+package pcie
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/smbios"
+)
+
+// Device is one PCI function
+type Device struct {
+	Address      string `json:"address"`          // Domain:bus:device.function, e.g. "0000:01:00.0"
+	Parent       string `json:"parent,omitempty"` // Address of the upstream bridge, if any
+	Class        uint32 `json:"class"`            // Class code, e.g. 0x030000 for a VGA controller
+	Name         string `json:"name,omitempty"`   // Vendor and device, e.g. "NVIDIA Corporation AD102 [GeForce RTX 4090]"
+	LinkWidth    int    `json:"link_width,omitempty"`
+	MaxLinkWidth int    `json:"max_link_width,omitempty"`
+	LinkSpeed    string `json:"link_speed,omitempty"` // e.g. "16.0 GT/s PCIe"
+	MaxLinkSpeed string `json:"max_link_speed,omitempty"`
+}
+
+// classBridge is the class code of a PCI-to-PCI bridge, such as a root port
+const classBridge = 0x0604
+
+// Bridge reports whether the device is a PCI-to-PCI bridge
+func (d Device) Bridge() bool {
+	return d.Class>>8 == classBridge
+}
+
+// Population is one slot and the devices fitted in it
+type Population struct {
+	Slot      smbios.Slot `json:"slot"`
+	Devices   []Device    `json:"devices,omitempty"`
+	Connector int         `json:"connector_lanes,omitempty"` // Physical size of the slot
+	Wired     int         `json:"wired_lanes,omitempty"`     // Lanes connected to it
+	Warning   string      `json:"warning,omitempty"`         // Why the card runs on fewer lanes than it supports
+}
+
+// Occupant describes the devices in the slot, e.g.
+// "NVIDIA Corporation AD102 [GeForce RTX 4090] (x16)"
+func (p Population) Occupant() string {
+	if len(p.Devices) == 0 {
+		if p.Slot.InUse() {
+			return "Unidentified device"
+		}
+		return ""
+	}
+	// Multi-function cards list each function; the first names the card
+	d := p.Devices[0]
+	name := d.Name
+	if name == "" {
+		name = d.Address
+	}
+	if d.LinkWidth > 0 {
+		name += fmt.Sprintf(" (x%d)", d.LinkWidth)
+	}
+	return name
+}
+
+// Map matches devices to the slots by the address SMBIOS records for each
+// slot. Firmware gives either the address of the card or that of the root
+// port above it; in the second case the devices below the port are used.
+// Slots that are not PCI Express or M.2 are left out.
+func Map(slots []smbios.Slot, devices []Device) []Population {
+	byAddress := make(map[string]Device, len(devices))
+	children := make(map[string][]Device)
+	for _, d := range devices {
+		byAddress[d.Address] = d
+		if d.Parent != "" {
+			children[d.Parent] = append(children[d.Parent], d)
+		}
+	}
+
+	var pop []Population
+	for _, slot := range slots {
+		if !slot.PCIe && !slot.M2 {
+			continue
+		}
+		p := Population{
+			Slot:  slot,
+			Wired: lanes(slot.Width),
+		}
+		p.Connector = lanes(slot.Physical)
+		if p.Connector == 0 {
+			// The slot type names the connector, e.g. "PCI Express Gen 4 x16"
+			p.Connector = lanes(lastField(slot.Type))
+		}
+
+		if d, ok := byAddress[slot.Address]; ok && slot.Address != "" {
+			if d.Bridge() {
+				// A card with its own switch, such as a multi-drive NVMe
+				// adapter, shows its upstream port: the link the slot carries
+				p.Devices = children[d.Address]
+			} else {
+				p.Devices = functions(d, devices)
+			}
+		}
+		p.Warning = laneWarning(p)
+		pop = append(pop, p)
+	}
+	return pop
+}
+
+// functions returns every function of the card holding d, e.g. the audio
+// function of a graphics card alongside its display function
+func functions(d Device, devices []Device) []Device {
+	dot := strings.LastIndex(d.Address, ".")
+	if dot < 0 {
+		return []Device{d}
+	}
+	var found []Device
+	for _, other := range devices {
+		if strings.HasPrefix(other.Address, d.Address[:dot+1]) {
+			found = append(found, other)
+		}
+	}
+	return found
+}
+
+// laneWarning explains a card running on fewer lanes than it supports. The
+// wiring of the slot is blamed when it is narrower than the card; otherwise
+// the link trained below what both ends allow, which usually means a badly
+// seated card or lanes shared with another slot or M.2 socket.
+func laneWarning(p Population) string {
+	if len(p.Devices) == 0 {
+		return ""
+	}
+	d := p.Devices[0]
+	if p.Wired > 0 && d.MaxLinkWidth > p.Wired && p.Connector > p.Wired {
+		return fmt.Sprintf("x%d card in a x%d slot wired for x%d: it is limited to x%d lanes",
+			d.MaxLinkWidth, p.Connector, p.Wired, p.Wired)
+	}
+	expected := d.MaxLinkWidth
+	if p.Wired > 0 && p.Wired < expected {
+		expected = p.Wired
+	}
+	if d.LinkWidth > 0 && d.LinkWidth < expected {
+		return fmt.Sprintf("Linked at x%d of x%d lanes: reseat the card, or check whether the slot shares lanes with an M.2 socket",
+			d.LinkWidth, expected)
+	}
+	return ""
+}
+
+// lanes parses a width such as "x16" into a lane count, or 0
+func lanes(width string) int {
+	if !strings.HasPrefix(width, "x") {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(width, "x"))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+func lastField(s string) string {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[len(fields)-1]
+}
@@ -0,0 +1,55 @@
+package pcie
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mscrnt/project_fire/pkg/smbios"
+)
+
+func TestMap(t *testing.T) {
+	slots := []smbios.Slot{
+		// A full-length slot wired for four lanes, holding a x16 card
+		{Designation: "PCIEX16_2", Type: "PCI Express Gen 4 x16", Width: "x4", Usage: "In Use", PCIe: true, Address: "0000:05:00.0"},
+		// Firmware that records the root port rather than the card
+		{Designation: "PCIEX16_1", Type: "PCI Express Gen 5", Width: "x16", Physical: "x16", Usage: "In Use", PCIe: true, Address: "0000:00:01.1"},
+		// A NVMe drive that trained below its width
+		{Designation: "M.2_1", Type: "M.2 Socket 3 (Key M)", Width: "x4", Usage: "In Use", M2: true, Address: "0000:02:00.0"},
+		{Designation: "PCIEX1_1", Type: "PCI Express Gen 3 x1", Width: "x1", Usage: "Available", PCIe: true},
+		{Designation: "PCI1", Type: "PCI", Usage: "Available"},
+	}
+	devices := []Device{
+		{Address: "0000:00:01.1", Class: 0x060400, LinkWidth: 16, MaxLinkWidth: 16},
+		{Address: "0000:01:00.0", Parent: "0000:00:01.1", Class: 0x030000, Name: "NVIDIA Corporation AD102 [GeForce RTX 4090]", LinkWidth: 16, MaxLinkWidth: 16},
+		{Address: "0000:01:00.1", Parent: "0000:00:01.1", Class: 0x040300, Name: "NVIDIA Corporation AD102 High Definition Audio Controller"},
+		{Address: "0000:02:00.0", Class: 0x010802, Name: "Samsung Electronics Co Ltd NVMe SSD Controller", LinkWidth: 2, MaxLinkWidth: 4},
+		{Address: "0000:05:00.0", Class: 0x030000, Name: "Advanced Micro Devices, Inc. [AMD/ATI] Navi 31", LinkWidth: 4, MaxLinkWidth: 16},
+		{Address: "0000:05:00.1", Class: 0x040300, Name: "Advanced Micro Devices, Inc. [AMD/ATI] Navi 31 HDMI/DP Audio"},
+	}
+
+	pop := Map(slots, devices)
+	if len(pop) != 4 {
+		t.Fatalf("expected the 4 PCIe and M.2 slots, got %d", len(pop))
+	}
+
+	wired := pop[0]
+	if wired.Connector != 16 || wired.Wired != 4 || len(wired.Devices) != 2 {
+		t.Errorf("expected both functions of the card in a x16 slot wired x4, got %+v", wired)
+	}
+	if !strings.Contains(wired.Warning, "x16 card in a x16 slot wired for x4") {
+		t.Errorf("expected a wiring warning, got %q", wired.Warning)
+	}
+
+	port := pop[1]
+	if len(port.Devices) != 2 || port.Occupant() != "NVIDIA Corporation AD102 [GeForce RTX 4090] (x16)" || port.Warning != "" {
+		t.Errorf("expected the card below the root port without a warning, got %+v", port)
+	}
+
+	if m2 := pop[2]; !strings.HasPrefix(m2.Warning, "Linked at x2 of x4") {
+		t.Errorf("expected a degraded link warning, got %q", m2.Warning)
+	}
+
+	if empty := pop[3]; empty.Occupant() != "" || empty.Warning != "" || empty.Connector != 1 {
+		t.Errorf("expected an empty x1 slot, got %+v", empty)
+	}
+}
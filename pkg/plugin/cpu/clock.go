@@ -0,0 +1,110 @@
+package cpu
+
+import (
+	"bufio"
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/plugin"
+)
+
+// clockPollInterval is how often the core clock is sampled during a run
+const clockPollInterval = time.Second
+
+// clockMonitor samples the clock of the fastest core in the background
+// during a run
+type clockMonitor struct {
+	read    func() (float64, error)
+	mu      sync.Mutex
+	samples []float64
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// startClockMonitor starts sampling the core clock. It returns nil when the
+// clock cannot be read on this system.
+func startClockMonitor(ctx context.Context) *clockMonitor {
+	if readCoreClock == nil {
+		return nil
+	}
+	if _, err := readCoreClock(); err != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	m := &clockMonitor{
+		read:   readCoreClock,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go m.poll(ctx)
+	return m
+}
+
+// poll samples the clock until the context is cancelled. The first sample
+// is taken after one interval, once the load has ramped up.
+func (m *clockMonitor) poll(ctx context.Context) {
+	defer close(m.done)
+	ticker := time.NewTicker(clockPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if mhz, err := m.read(); err == nil && mhz > 0 {
+				m.mu.Lock()
+				m.samples = append(m.samples, mhz)
+				m.mu.Unlock()
+			}
+		}
+	}
+}
+
+// annotate stops the monitor and records the peak and average clock
+func (m *clockMonitor) annotate(result *plugin.Result) {
+	m.cancel()
+	<-m.done
+
+	peak, avg := clockStats(m.samples)
+	if peak == 0 {
+		return
+	}
+	result.Metrics["max_clock_mhz"] = peak
+	result.Metrics["avg_clock_mhz"] = avg
+}
+
+// clockStats returns the highest and the average of the samples
+func clockStats(samples []float64) (peak, avg float64) {
+	if len(samples) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, mhz := range samples {
+		sum += mhz
+		if mhz > peak {
+			peak = mhz
+		}
+	}
+	return peak, sum / float64(len(samples))
+}
+
+// parseCPUInfoMHz returns the highest "cpu MHz" value in /proc/cpuinfo
+func parseCPUInfoMHz(cpuinfo string) float64 {
+	var peak float64
+	scanner := bufio.NewScanner(strings.NewReader(cpuinfo))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok || strings.TrimSpace(key) != "cpu MHz" {
+			continue
+		}
+		if mhz, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && mhz > peak {
+			peak = mhz
+		}
+	}
+	return peak
+}
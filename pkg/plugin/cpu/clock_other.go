@@ -0,0 +1,8 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package cpu
+
+// readCoreClock is not available; the nominal frequency reported on other
+// platforms is not a measurement
+var readCoreClock func() (float64, error)
@@ -0,0 +1,34 @@
+package cpu
+
+import "testing"
+
+func TestClockStats(t *testing.T) {
+	peak, avg := clockStats([]float64{4800, 5200, 5000})
+	if peak != 5200 {
+		t.Errorf("expected peak 5200, got %v", peak)
+	}
+	if avg != 5000 {
+		t.Errorf("expected average 5000, got %v", avg)
+	}
+
+	if peak, avg := clockStats(nil); peak != 0 || avg != 0 {
+		t.Errorf("expected zero stats without samples, got %v, %v", peak, avg)
+	}
+}
+
+func TestParseCPUInfoMHz(t *testing.T) {
+	cpuinfo := `processor	: 0
+model name	: AMD Ryzen 9 7950X 16-Core Processor
+cpu MHz		: 3000.000
+
+processor	: 1
+model name	: AMD Ryzen 9 7950X 16-Core Processor
+cpu MHz		: 5687.512
+`
+	if got := parseCPUInfoMHz(cpuinfo); got != 5687.512 {
+		t.Errorf("expected 5687.512, got %v", got)
+	}
+	if got := parseCPUInfoMHz("processor : 0\n"); got != 0 {
+		t.Errorf("expected 0 without cpu MHz lines, got %v", got)
+	}
+}
@@ -0,0 +1,30 @@
+//go:build windows
+// +build windows
+
+package cpu
+
+import (
+	"fmt"
+
+	"github.com/StackExchange/wmi"
+)
+
+// win32ProcessorInformation is the formatted processor performance counter
+type win32ProcessorInformation struct {
+	PercentProcessorPerformance uint64
+	ProcessorFrequency          uint64
+}
+
+// readCoreClock returns the effective clock in MHz the way Task Manager
+// computes it: the base frequency scaled by the processor performance counter
+var readCoreClock = func() (float64, error) {
+	var info []win32ProcessorInformation
+	query := "SELECT PercentProcessorPerformance, ProcessorFrequency FROM Win32_PerfFormattedData_Counters_ProcessorInformation WHERE Name = '_Total'"
+	if err := wmi.Query(query, &info); err != nil {
+		return 0, err
+	}
+	if len(info) == 0 || info[0].ProcessorFrequency == 0 {
+		return 0, fmt.Errorf("processor performance counter not available")
+	}
+	return float64(info[0].ProcessorFrequency) * float64(info[0].PercentProcessorPerformance) / 100, nil
+}
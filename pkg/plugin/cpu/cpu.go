@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/mscrnt/project_fire/pkg/plugin"
+	"github.com/mscrnt/project_fire/pkg/safeexec"
 )
 
 func init() {
@@ -45,6 +46,21 @@ func (p *Plugin) ValidateParams(params plugin.Params) error {
 		return fmt.Errorf("duration must be positive")
 	}
 
+	if m, ok := params.Config["method"].(string); ok {
+		switch m {
+		case "auto", "stress-ng", "native":
+		default:
+			return fmt.Errorf("invalid method %q (must be auto, stress-ng or native)", m)
+		}
+	}
+
+	// cpu-method is passed through to stress-ng
+	if m, ok := params.Config["cpu-method"].(string); ok {
+		if err := safeexec.ValidateIdentifier(m); err != nil {
+			return fmt.Errorf("invalid cpu-method: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -76,6 +92,17 @@ func (p *Plugin) Run(ctx context.Context, params plugin.Params) (plugin.Result,
 		return result, err
 	}
 
+	// Sample the core clock while the load runs
+	clocks := startClockMonitor(ctx)
+	loadResult, err := p.runLoad(ctx, params, &result)
+	if clocks != nil {
+		clocks.annotate(&loadResult)
+	}
+	return loadResult, err
+}
+
+// runLoad runs the stress method selected in the config
+func (p *Plugin) runLoad(ctx context.Context, params plugin.Params, result *plugin.Result) (plugin.Result, error) {
 	// Get method from config
 	method := "auto"
 	if m, ok := params.Config["method"].(string); ok {
@@ -84,21 +111,21 @@ func (p *Plugin) Run(ctx context.Context, params plugin.Params) (plugin.Result,
 
 	// Try stress-ng first if available
 	if method == "auto" || method == "stress-ng" {
-		if err := p.runStressNG(ctx, params, &result); err == nil {
-			return result, nil
+		if err := p.runStressNG(ctx, params, result); err == nil {
+			return *result, nil
 		} else if method == "stress-ng" {
 			// If specifically requested stress-ng and it failed, return error
 			result.EndTime = time.Now()
 			result.Success = false
 			result.Error = fmt.Sprintf("stress-ng failed: %v", err)
-			return result, err
+			return *result, err
 		}
 		// Fall back to native implementation
 		result.Details["fallback"] = "stress-ng not available, using native implementation"
 	}
 
 	// Use native Go implementation
-	return p.runNative(ctx, params, &result)
+	return p.runNative(ctx, params, result)
 }
 
 // runStressNG runs the stress-ng tool
@@ -117,11 +144,14 @@ func (p *Plugin) runStressNG(ctx context.Context, params plugin.Params, result *
 
 	// Add CPU method if specified
 	if method, ok := params.Config["cpu-method"].(string); ok {
+		if err := safeexec.ValidateIdentifier(method); err != nil {
+			return fmt.Errorf("invalid cpu-method: %w", err)
+		}
 		args = append(args, "--cpu-method", method)
 	}
 
 	// Create command
-	cmd := exec.CommandContext(ctx, "stress-ng", args...) // #nosec G204 - args are constructed from validated parameters
+	cmd := safeexec.CommandContext(ctx, "stress-ng", args...)
 
 	// Run command and capture output
 	output, err := cmd.CombinedOutput()
@@ -259,6 +289,18 @@ func (p *Plugin) Info() plugin.Info {
 				Unit:        "ops/s",
 				Description: "Operations per second (native)",
 			},
+			{
+				Name:        "max_clock_mhz",
+				Type:        plugin.MetricTypeGauge,
+				Unit:        "MHz",
+				Description: "Highest core clock observed under load (cpufreq on Linux, performance counters on Windows)",
+			},
+			{
+				Name:        "avg_clock_mhz",
+				Type:        plugin.MetricTypeGauge,
+				Unit:        "MHz",
+				Description: "Average clock of the fastest core under load",
+			},
 		},
 		Parameters: []plugin.ParamInfo{
 			{
@@ -0,0 +1,44 @@
+package disk
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCacheCompare(t *testing.T) {
+	// Leave the page cache of the machine running the tests alone
+	old := dropCachesPath
+	dropCachesPath = filepath.Join(t.TempDir(), "drop_caches")
+	t.Cleanup(func() { dropCachesPath = old })
+
+	p := &Plugin{}
+	params := p.DefaultParams()
+	params.Duration = 400 * time.Millisecond
+	params.Config["path"] = t.TempDir()
+	params.Config["size_mb"] = 4
+	params.Config["pattern"] = "sequential"
+	params.Config["cache"] = "compare"
+	params.Config["smart"] = false
+
+	result, err := p.Run(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for _, m := range []string{"seq_read_mb_per_sec", "warm_seq_read_mb_per_sec", "warm_seq_read_latency_p99_us"} {
+		if result.Metrics[m] <= 0 {
+			t.Errorf("expected %s > 0, got %v", m, result.Metrics[m])
+		}
+	}
+	if _, ok := result.Metrics["cache_gain_seq_read_pct"]; !ok {
+		t.Errorf("expected the warm/cold gain, got %v", result.Metrics)
+	}
+	if result.Details["cache"] != "compare" {
+		t.Errorf("expected the cache mode in the details, got %v", result.Details["cache"])
+	}
+	if data, err := os.ReadFile(dropCachesPath); err != nil || string(data) != "1" {
+		t.Errorf("expected the cold pass to drop the caches, got %q %v", data, err)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !linux && !windows && !darwin
+// +build !linux,!windows,!darwin
+
+package disk
+
+import (
+	"errors"
+	"os"
+)
+
+// canDropCache is set where cold passes can evict the target from the cache
+const canDropCache = false
+
+// openDirect fails: unbuffered I/O is only implemented for Linux, Windows
+// and macOS
+func openDirect(string) (*os.File, error) {
+	return nil, errors.New("unbuffered I/O is not supported on this platform")
+}
+
+func dropCache(*os.File) error {
+	return nil
+}
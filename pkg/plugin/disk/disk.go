@@ -0,0 +1,864 @@
+// Package disk provides a storage benchmark plugin for FIRE.
+package disk
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	mathrand "math/rand"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/plugin"
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+	"github.com/mscrnt/project_fire/pkg/smart"
+	"github.com/mscrnt/project_fire/pkg/storage"
+)
+
+func init() {
+	// Register the disk benchmark plugin
+	if err := plugin.Register(&Plugin{}); err != nil {
+		// Since init() can't return an error, we panic on registration failure
+		// This is acceptable because plugin registration is a critical startup operation
+		panic(fmt.Sprintf("failed to register disk plugin: %v", err))
+	}
+}
+
+// maxInFlightBytes limits the buffers held by the queue_depth workers
+const maxInFlightBytes = 1 << 30
+
+// Plugin implements a file-based storage benchmark
+type Plugin struct{}
+
+// phase is one access pattern run against the test file
+type phase struct {
+	name   string
+	metric string // metric name prefix
+	write  bool
+	random bool
+}
+
+// workload is the I/O shape shared by every phase
+type workload struct {
+	data       []byte // random test data, as long as the larger block size
+	seqBlock   int64
+	randBlock  int64
+	queueDepth int  // operations kept in flight at once
+	drop       bool // evict the target from the OS cache before every phase
+}
+
+// latencyPercentiles are the latency percentiles recorded for every phase
+var latencyPercentiles = []struct {
+	suffix string
+	value  float64
+}{
+	{"p50", 50},
+	{"p95", 95},
+	{"p99", 99},
+}
+
+// Name returns the plugin name
+func (p *Plugin) Name() string {
+	return "disk"
+}
+
+// Description returns the plugin description
+func (p *Plugin) Description() string {
+	return "Storage benchmark measuring sequential throughput and random IOPS on a test file or raw device"
+}
+
+// ValidateParams validates the parameters
+func (p *Plugin) ValidateParams(params plugin.Params) error {
+	if params.Duration <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+
+	if path, ok := params.Config["path"].(string); ok && path != "" {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("invalid path: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("path %q is not a directory", path)
+		}
+	}
+
+	if paths := configPaths(params.Config); len(paths) > 0 {
+		seen := make(map[string]bool)
+		for _, path := range paths {
+			info, err := os.Stat(path)
+			if err != nil {
+				return fmt.Errorf("invalid path in paths: %w", err)
+			}
+			if !info.IsDir() {
+				return fmt.Errorf("path %q in paths is not a directory", path)
+			}
+			if seen[path] {
+				return fmt.Errorf("path %q is listed twice in paths", path)
+			}
+			seen[path] = true
+		}
+		if mode, ok := params.Config["mode"].(string); ok && mode != "file" {
+			return fmt.Errorf("paths needs file mode; raw devices are tested one at a time")
+		}
+	}
+
+	if size := configInt(params.Config, "size_mb", 256); size <= 0 {
+		return fmt.Errorf("size_mb must be positive")
+	}
+	block := configInt(params.Config, "block_kb", 1024)
+	if block <= 0 || block > 65536 {
+		return fmt.Errorf("block_kb must be between 1 and 65536")
+	}
+	randBlock := configInt(params.Config, "random_block_kb", 4)
+	if randBlock <= 0 || randBlock > 65536 {
+		return fmt.Errorf("random_block_kb must be between 1 and 65536")
+	}
+	depth := configInt(params.Config, "queue_depth", 1)
+	if depth <= 0 || depth > 256 {
+		return fmt.Errorf("queue_depth must be between 1 and 256")
+	}
+	if int64(depth)*int64(max(block, randBlock))*1024 > maxInFlightBytes {
+		return fmt.Errorf("queue_depth %d with %d KB blocks needs more than 1 GB of buffers", depth, max(block, randBlock))
+	}
+
+	if pattern, ok := params.Config["pattern"].(string); ok {
+		switch pattern {
+		case "auto", "sequential", "random", "mixed":
+		default:
+			return fmt.Errorf("invalid pattern %q (must be auto, sequential, random or mixed)", pattern)
+		}
+	}
+
+	cache := cacheOS
+	if c, ok := params.Config["cache"].(string); ok && c != "" {
+		cache = c
+	}
+	switch cache {
+	case cacheOS:
+	case cacheCold, cacheDirect, cacheCompare:
+		// Unbuffered I/O transfers whole 4 KB sectors
+		if block%4 != 0 || randBlock%4 != 0 {
+			return fmt.Errorf("cache=%s needs block_kb and random_block_kb in multiples of 4", cache)
+		}
+	default:
+		return fmt.Errorf("invalid cache %q (must be os, cold, direct or compare)", cache)
+	}
+
+	mode := "file"
+	if m, ok := params.Config["mode"].(string); ok {
+		mode = m
+	}
+	if cache == cacheCompare && (mode == "both" || len(configPaths(params.Config)) > 0) {
+		return fmt.Errorf("cache=compare runs a single target; it cannot be combined with mode=both or paths")
+	}
+	switch mode {
+	case "file":
+	case "raw", "both":
+		device, _ := params.Config["raw_device"].(string)
+		if err := safeexec.ValidateDevicePath(device); err != nil {
+			return fmt.Errorf("raw mode needs raw_device: %w", err)
+		}
+		// Raw mode overwrites the device, so it must be requested explicitly
+		if confirm, _ := params.Config["confirm_raw"].(bool); !confirm {
+			return fmt.Errorf("raw mode destroys all data on %s; set confirm_raw=true to proceed", device)
+		}
+	default:
+		return fmt.Errorf("invalid mode %q (must be file, raw or both)", mode)
+	}
+
+	return nil
+}
+
+// DefaultParams returns default parameters
+func (p *Plugin) DefaultParams() plugin.Params {
+	return plugin.Params{
+		Duration: 60 * time.Second,
+		Threads:  1,
+		Config: map[string]interface{}{
+			"path":            os.TempDir(), // directory for the test file
+			"size_mb":         256,          // test file size in MB
+			"block_kb":        1024,         // sequential transfer size in KB
+			"random_block_kb": 4,            // random transfer size in KB
+			"queue_depth":     1,            // I/Os in flight per phase
+			"pattern":         "auto",       // auto, sequential, random, mixed
+			"mode":            "file",       // file, raw, both
+			"smart":           true,         // compare SMART data before and after
+			"paths":           "",           // several directories to stress at once, separated like PATH
+			"cache":           "os",         // os, cold, direct, compare
+		},
+	}
+}
+
+// Run executes the disk benchmark
+func (p *Plugin) Run(ctx context.Context, params plugin.Params) (plugin.Result, error) {
+	result := plugin.Result{
+		StartTime: time.Now(),
+		Metrics:   make(map[string]float64),
+		Details:   make(map[string]interface{}),
+	}
+
+	fail := func(err error) (plugin.Result, error) {
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(result.StartTime)
+		result.Success = false
+		result.Error = err.Error()
+		return result, err
+	}
+
+	if err := p.ValidateParams(params); err != nil {
+		return fail(err)
+	}
+
+	dir := os.TempDir()
+	if path, ok := params.Config["path"].(string); ok && path != "" {
+		dir = path
+	}
+	sizeMB := configInt(params.Config, "size_mb", 256)
+	work := workload{
+		seqBlock:   int64(configInt(params.Config, "block_kb", 1024)) * 1024,
+		randBlock:  int64(configInt(params.Config, "random_block_kb", 4)) * 1024,
+		queueDepth: configInt(params.Config, "queue_depth", 1),
+	}
+	pattern := "auto"
+	if v, ok := params.Config["pattern"].(string); ok {
+		pattern = v
+	}
+	mode := "file"
+	if v, ok := params.Config["mode"].(string); ok {
+		mode = v
+	}
+	rawDevice, _ := params.Config["raw_device"].(string)
+	cache := cacheOS
+	if v, ok := params.Config["cache"].(string); ok && v != "" {
+		cache = v
+	}
+	passes := cachePasses(cache)
+
+	done := func() (plugin.Result, error) {
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(result.StartTime)
+		result.Success = true
+		result.Details["method"] = "native"
+		result.Details["mode"] = mode
+		result.Details["size_mb"] = sizeMB
+		result.Details["block_kb"] = work.seqBlock / 1024
+		result.Details["random_block_kb"] = work.randBlock / 1024
+		result.Details["queue_depth"] = work.queueDepth
+		result.Details["pattern"] = pattern
+		result.Details["cache"] = cache
+		return result, nil
+	}
+
+	size := int64(sizeMB) * 1024 * 1024
+	work.data = alignedBuffer(max(work.seqBlock, work.randBlock))
+	if _, err := rand.Read(work.data); err != nil {
+		return fail(fmt.Errorf("failed to generate test data: %w", err))
+	}
+
+	// Several drives are stressed together, each with its own workers
+	if paths := configPaths(params.Config); len(paths) > 0 {
+		work.drop = passes[0].drop
+		err := runDrives(ctx, paths, size, work, passes[0].direct, pattern, smartEnabled(params.Config), params.Duration, result.Metrics, result.Details)
+		if err != nil {
+			return fail(err)
+		}
+		result.Details["paths"] = paths
+		return done()
+	}
+
+	if mode != "file" {
+		if err := storage.InUse(rawDevice); err != nil {
+			return fail(fmt.Errorf("refusing raw benchmark: %w", err))
+		}
+	}
+
+	// Detect drive characteristics so results can be interpreted correctly
+	device, _ := params.Config["device"].(string)
+	switch {
+	case device != "":
+	case mode != "file":
+		device = rawDevice
+	default:
+		device, _ = storage.DeviceForPath(dir)
+	}
+	var chars *storage.Characteristics
+	if device != "" {
+		chars, _ = storage.Detect(device, "")
+		result.Details["device"] = device
+		result.Details["characteristics"] = chars
+	}
+
+	phases, notes := planPhases(pattern, chars)
+	if len(notes) > 0 {
+		result.Details["adjustments"] = notes
+	}
+	var warnings []string
+	if chars != nil {
+		warnings = append(warnings, chars.Warnings...)
+	}
+	// A drive linked below its capability caps throughput before it does
+	if device != "" {
+		if link, err := storage.ReadLink(storage.PhysicalDrive(device)); err == nil {
+			result.Details["link"] = link
+			warnings = append(warnings, link.Warnings...)
+		}
+		// So does a disabled write cache; an enabled one without power-loss
+		// protection makes write results look better than the data is safe
+		if wc, err := storage.ReadWriteCache(storage.PhysicalDrive(device), ""); err == nil {
+			result.Details["write_cache"] = wc
+			warnings = append(warnings, wc.Warnings...)
+		}
+	}
+	if len(warnings) > 0 {
+		result.Details["warnings"] = warnings
+	}
+
+	// SMART is read before the workload so the report can show what it cost
+	// the drive in wear and temperature
+	var smartBefore *smart.Data
+	smartDevice := ""
+	if smartEnabled(params.Config) {
+		smartDevice = storage.PhysicalDrive(device)
+		var err error
+		if smartBefore, err = readSMART(smartDevice); err != nil {
+			result.Details["smart"] = fmt.Sprintf("unavailable: %v", err)
+			smartBefore = nil
+		}
+	}
+
+	targets := 1
+	if mode == "both" {
+		targets = 2
+	}
+	passDuration := params.Duration / time.Duration(targets*len(passes))
+
+	// measure runs every cache pass against a target
+	measure := func(target string, size int64, prefix string) error {
+		for _, pass := range passes {
+			f, err := openTarget(target, pass.direct)
+			if err != nil {
+				return fmt.Errorf("failed to open %s: %w", target, err)
+			}
+			w := work
+			w.drop = pass.drop
+			err = benchmark(ctx, f, size, w, phases, passDuration, pass.prefix+prefix, result.Metrics)
+			_ = f.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if mode == "file" || mode == "both" {
+		f, err := os.CreateTemp(dir, "fire-disk-*.dat")
+		if err != nil {
+			return fail(fmt.Errorf("failed to create test file: %w", err))
+		}
+		_ = f.Close()
+		err = measure(f.Name(), size, "")
+		_ = os.Remove(f.Name())
+		if err != nil {
+			return fail(err)
+		}
+	}
+
+	if mode == "raw" || mode == "both" {
+		f, err := os.OpenFile(rawDevice, os.O_RDONLY, 0) // #nosec G304 -- validated device path, confirmed by the user
+		if err != nil {
+			return fail(fmt.Errorf("failed to open %s: %w", rawDevice, err))
+		}
+		// Never run past the end of a small device
+		if end, err := f.Seek(0, io.SeekEnd); err == nil && end > 0 && end < size {
+			size = end - end%work.seqBlock
+		}
+		_ = f.Close()
+		if err := measure(rawDevice, size, "raw_"); err != nil {
+			return fail(err)
+		}
+		result.Details["raw_device"] = rawDevice
+	}
+
+	if mode == "both" {
+		addOverheadMetrics(result.Metrics)
+	}
+	if cache == cacheCompare {
+		addCacheMetrics(result.Metrics)
+	}
+
+	if smartBefore != nil {
+		if smartAfter, err := readSMART(smartDevice); err == nil {
+			addSMARTMetrics(result.Metrics, smartBefore, smartAfter)
+			result.Details["smart_before"] = smartBefore
+			result.Details["smart_after"] = smartAfter
+		} else {
+			result.Details["smart"] = fmt.Sprintf("unavailable after the run: %v", err)
+		}
+	}
+
+	result.Details["path"] = dir
+	return done()
+}
+
+// benchmark prepares a target (test file or raw device) and runs every
+// phase against it. Metric names are prefixed with prefix.
+func benchmark(ctx context.Context, f *os.File, size int64, work workload, phases []phase, limit time.Duration, prefix string, metrics map[string]float64) error {
+	// Reads and random writes need the target written at full size
+	if err := fill(ctx, f, size, work.data[:work.seqBlock]); err != nil {
+		return fmt.Errorf("failed to prepare %s: %w", f.Name(), err)
+	}
+
+	phaseDuration := limit / time.Duration(len(phases))
+	for _, ph := range phases {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := runPhase(ctx, f, size, work, ph, phaseDuration, prefix, metrics); err != nil {
+			return fmt.Errorf("%s failed: %w", ph.name, err)
+		}
+	}
+	return nil
+}
+
+// addOverheadMetrics compares file and raw results. A positive value is the
+// share of device performance lost to the filesystem.
+func addOverheadMetrics(metrics map[string]float64) {
+	for _, name := range []string{"seq_write_mb_per_sec", "seq_read_mb_per_sec", "random_read_iops", "random_write_iops"} {
+		fs, okFS := metrics[name]
+		raw, okRaw := metrics["raw_"+name]
+		if !okFS || !okRaw || raw <= 0 {
+			continue
+		}
+		metrics["fs_overhead_"+strings.TrimSuffix(strings.TrimSuffix(name, "_mb_per_sec"), "_iops")+"_pct"] = (raw - fs) / raw * 100
+	}
+}
+
+// planPhases selects the access patterns to run. In auto mode the plan is
+// adapted to the drive: zoned devices reject or penalise random writes and
+// SMR drives collapse under them, so those runs use sequential writes only.
+func planPhases(pattern string, chars *storage.Characteristics) ([]phase, []string) {
+	seqWrite := phase{name: "sequential write", metric: "seq_write", write: true}
+	seqRead := phase{name: "sequential read", metric: "seq_read"}
+	randRead := phase{name: "random read", metric: "random_read", random: true}
+	randWrite := phase{name: "random write", metric: "random_write", write: true, random: true}
+
+	switch pattern {
+	case "sequential":
+		return []phase{seqWrite, seqRead}, nil
+	case "random":
+		if chars != nil && chars.SequentialWritesOnly() {
+			return []phase{randRead}, []string{"random writes skipped: zoned device requires sequential writes"}
+		}
+		return []phase{randRead, randWrite}, nil
+	}
+
+	phases := []phase{seqWrite, seqRead, randRead}
+	var notes []string
+	switch {
+	case chars != nil && chars.SequentialWritesOnly():
+		notes = append(notes, "random writes skipped: zoned device requires sequential writes")
+	case pattern == "auto" && chars != nil && chars.Recording == storage.RecordingSMR:
+		notes = append(notes, "random writes skipped: SMR drive; use pattern=mixed to force them")
+	default:
+		phases = append(phases, randWrite)
+	}
+	return phases, notes
+}
+
+// fill writes the target to its full size
+func fill(ctx context.Context, f *os.File, size int64, buf []byte) error {
+	for off := int64(0); off < size; off += int64(len(buf)) {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		n := int64(len(buf))
+		if size-off < n {
+			n = size - off
+		}
+		if _, err := f.WriteAt(buf[:n], off); err != nil {
+			return err
+		}
+	}
+	return f.Sync()
+}
+
+// runPhase runs one access pattern until the target has been covered or the
+// phase duration is used up, and records its metrics. queue_depth workers
+// keep that many operations in flight.
+func runPhase(ctx context.Context, f *os.File, size int64, work workload, ph phase, limit time.Duration, prefix string, metrics map[string]float64) error {
+	block := work.seqBlock
+	if ph.random {
+		block = work.randBlock
+	}
+	blocks := size / block
+	if blocks == 0 {
+		return fmt.Errorf("target smaller than one block")
+	}
+	if work.drop {
+		if err := dropCache(f); err != nil {
+			return fmt.Errorf("failed to drop the cache: %w", err)
+		}
+	}
+
+	deadline := time.Now().Add(limit)
+	start := time.Now()
+	var next atomic.Int64 // next block of a sequential pass
+	histograms := make([]*latencyHistogram, work.queueDepth)
+	errs := make([]error, work.queueDepth)
+
+	var wg sync.WaitGroup
+	for w := range histograms {
+		hist := &latencyHistogram{}
+		histograms[w] = hist
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			// Writes share the test data; reads each need their own buffer
+			buf := work.data[:block]
+			if !ph.write {
+				buf = alignedBuffer(block)
+			}
+			rng := mathrand.New(mathrand.NewSource(time.Now().UnixNano() + int64(w))) // #nosec G404 -- offsets only, not security sensitive
+
+			for ctx.Err() == nil && time.Now().Before(deadline) {
+				var idx int64
+				if ph.random {
+					idx = rng.Int63n(blocks)
+				} else if idx = next.Add(1) - 1; idx >= blocks {
+					return // sequential phases stop after one full pass
+				}
+
+				opStart := time.Now()
+				var err error
+				if ph.write {
+					_, err = f.WriteAt(buf, idx*block)
+				} else {
+					_, err = f.ReadAt(buf, idx*block)
+				}
+				if err != nil {
+					errs[w] = err
+					return
+				}
+				hist.record(time.Since(opStart))
+			}
+		}(w)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	if ph.write {
+		if err := f.Sync(); err != nil {
+			return err
+		}
+	}
+	elapsed := time.Since(start).Seconds()
+
+	latency := &latencyHistogram{}
+	for _, hist := range histograms {
+		latency.merge(hist)
+	}
+	if latency.n == 0 || elapsed == 0 {
+		return nil
+	}
+	ops := float64(latency.n)
+
+	name := prefix + ph.metric
+	if ph.random {
+		metrics[name+"_iops"] = ops / elapsed
+	} else {
+		metrics[name+"_mb_per_sec"] = ops * float64(block) / elapsed / (1024 * 1024)
+	}
+	metrics[name+"_latency_us"] = float64(latency.mean()) / float64(time.Microsecond)
+	for _, p := range latencyPercentiles {
+		metrics[name+"_latency_"+p.suffix+"_us"] = float64(latency.percentile(p.value)) / float64(time.Microsecond)
+	}
+	return nil
+}
+
+// configInt reads an integer config value that may have been decoded as float64
+func configInt(config map[string]interface{}, key string, def int) int {
+	switch v := config[key].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	}
+	return def
+}
+
+// Info returns detailed plugin information
+func (p *Plugin) Info() plugin.Info {
+	info := plugin.Info{
+		Name:        p.Name(),
+		Description: p.Description(),
+		Category:    "benchmark",
+		Metrics: []plugin.MetricInfo{
+			{
+				Name:        "seq_write_mb_per_sec",
+				Type:        plugin.MetricTypeThroughput,
+				Unit:        "MB/s",
+				Description: "Sequential write throughput including the final flush",
+			},
+			{
+				Name:        "seq_read_mb_per_sec",
+				Type:        plugin.MetricTypeThroughput,
+				Unit:        "MB/s",
+				Description: "Sequential read throughput",
+			},
+			{
+				Name:        "random_read_iops",
+				Type:        plugin.MetricTypeThroughput,
+				Unit:        "IOPS",
+				Description: "Random read operations per second (random_block_kb, 4K by default)",
+			},
+			{
+				Name:        "random_read_latency_us",
+				Type:        plugin.MetricTypeLatency,
+				Unit:        "us",
+				Description: "Average random read latency",
+			},
+			{
+				Name:        "random_write_iops",
+				Type:        plugin.MetricTypeThroughput,
+				Unit:        "IOPS",
+				Description: "Random write operations per second (random_block_kb, 4K by default)",
+			},
+			{
+				Name:        "random_write_latency_us",
+				Type:        plugin.MetricTypeLatency,
+				Unit:        "us",
+				Description: "Average random write latency",
+			},
+			{
+				Name:        "raw_seq_write_mb_per_sec",
+				Type:        plugin.MetricTypeThroughput,
+				Unit:        "MB/s",
+				Description: "Sequential write throughput on the raw device (raw and both modes)",
+			},
+			{
+				Name:        "raw_seq_read_mb_per_sec",
+				Type:        plugin.MetricTypeThroughput,
+				Unit:        "MB/s",
+				Description: "Sequential read throughput on the raw device (raw and both modes)",
+			},
+			{
+				Name:        "raw_random_read_iops",
+				Type:        plugin.MetricTypeThroughput,
+				Unit:        "IOPS",
+				Description: "Random reads per second on the raw device (raw and both modes)",
+			},
+			{
+				Name:        "raw_random_write_iops",
+				Type:        plugin.MetricTypeThroughput,
+				Unit:        "IOPS",
+				Description: "Random writes per second on the raw device (raw and both modes)",
+			},
+			{
+				Name:        "fs_overhead_seq_write_pct",
+				Type:        plugin.MetricTypeGauge,
+				Unit:        "%",
+				Description: "Sequential write performance lost to the filesystem (both mode)",
+			},
+			{
+				Name:        "fs_overhead_seq_read_pct",
+				Type:        plugin.MetricTypeGauge,
+				Unit:        "%",
+				Description: "Sequential read performance lost to the filesystem (both mode)",
+			},
+			{
+				Name:        "fs_overhead_random_read_pct",
+				Type:        plugin.MetricTypeGauge,
+				Unit:        "%",
+				Description: "Random read performance lost to the filesystem (both mode)",
+			},
+			{
+				Name:        "fs_overhead_random_write_pct",
+				Type:        plugin.MetricTypeGauge,
+				Unit:        "%",
+				Description: "Random write performance lost to the filesystem (both mode)",
+			},
+		},
+		Parameters: []plugin.ParamInfo{
+			{
+				Name:        "duration",
+				Type:        "duration",
+				Default:     "60s",
+				Description: "Total test duration, split across the access patterns",
+				Required:    true,
+			},
+			{
+				Name:        "path",
+				Type:        "string",
+				Default:     os.TempDir(),
+				Description: "Directory on the drive under test where the test file is created",
+				Required:    false,
+			},
+			{
+				Name:        "paths",
+				Type:        "string",
+				Default:     "",
+				Description: "Directories on several drives to stress at once, separated like PATH (: or ; on Windows); replaces path and reports every drive as driveN_ metrics",
+				Required:    false,
+			},
+			{
+				Name:        "size_mb",
+				Type:        "integer",
+				Default:     256,
+				Description: "Test file size in MB",
+				Required:    false,
+			},
+			{
+				Name:        "block_kb",
+				Type:        "integer",
+				Default:     1024,
+				Description: "Transfer size for sequential access in KB",
+				Required:    false,
+			},
+			{
+				Name:        "random_block_kb",
+				Type:        "integer",
+				Default:     4,
+				Description: "Transfer size for random access in KB",
+				Required:    false,
+			},
+			{
+				Name:        "queue_depth",
+				Type:        "integer",
+				Default:     1,
+				Description: "Operations kept in flight at once in every phase (1-256)",
+				Required:    false,
+			},
+			{
+				Name:        "pattern",
+				Type:        "string",
+				Default:     "auto",
+				Description: "Access patterns: auto (adapted to the drive), sequential, random, or mixed",
+				Required:    false,
+			},
+			{
+				Name:        "device",
+				Type:        "string",
+				Default:     "",
+				Description: "Physical drive used for characteristics detection (default: drive holding path)",
+				Required:    false,
+			},
+			{
+				Name:        "mode",
+				Type:        "string",
+				Default:     "file",
+				Description: "Target: file (test file under path), raw (raw_device), or both to measure filesystem overhead",
+				Required:    false,
+			},
+			{
+				Name:        "raw_device",
+				Type:        "string",
+				Default:     "",
+				Description: "Unmounted device for raw and both modes; its contents are destroyed",
+				Required:    false,
+			},
+			{
+				Name:        "confirm_raw",
+				Type:        "boolean",
+				Default:     false,
+				Description: "Must be true to allow raw mode to overwrite raw_device",
+				Required:    false,
+			},
+			{
+				Name:        "smart",
+				Type:        "boolean",
+				Default:     true,
+				Description: "Read SMART data before and after the run (smartctl or the privileged helper) to report wear and temperature deltas",
+				Required:    false,
+			},
+			{
+				Name:        "cache",
+				Type:        "string",
+				Default:     "os",
+				Description: "OS cache: os (buffered), cold (drop the cache before every phase on Linux, unbuffered I/O elsewhere), direct (O_DIRECT, FILE_FLAG_NO_BUFFERING or F_NOCACHE), or compare (a warm pass stored as warm_ results, then a cold one)",
+				Required:    false,
+			},
+		},
+	}
+	info.Metrics = append(info.Metrics, latencyMetricInfo()...)
+	info.Metrics = append(info.Metrics, smartMetricInfo...)
+	info.Metrics = append(info.Metrics, driveMetricInfo...)
+	info.Metrics = append(info.Metrics, cacheMetricInfo...)
+	return info
+}
+
+// latencyMetricInfo describes the latency percentiles recorded for every phase
+func latencyMetricInfo() []plugin.MetricInfo {
+	var metrics []plugin.MetricInfo
+	for _, ph := range []struct{ metric, name string }{
+		{"seq_write", "sequential write"},
+		{"seq_read", "sequential read"},
+		{"random_read", "random read"},
+		{"random_write", "random write"},
+	} {
+		if !strings.HasPrefix(ph.metric, "random") {
+			metrics = append(metrics, plugin.MetricInfo{
+				Name:        ph.metric + "_latency_us",
+				Type:        plugin.MetricTypeLatency,
+				Unit:        "us",
+				Description: "Average " + ph.name + " latency",
+			})
+		}
+		for _, p := range latencyPercentiles {
+			metrics = append(metrics, plugin.MetricInfo{
+				Name:        ph.metric + "_latency_" + p.suffix + "_us",
+				Type:        plugin.MetricTypeLatency,
+				Unit:        "us",
+				Description: fmt.Sprintf("%gth percentile %s latency", p.value, ph.name),
+			})
+		}
+	}
+	return metrics
+}
+
+// smartMetricInfo describes the SMART deltas recorded when smart is enabled
+var smartMetricInfo = []plugin.MetricInfo{
+	{
+		Name:        "smart_temp_before_c",
+		Type:        plugin.MetricTypeGauge,
+		Unit:        "°C",
+		Description: "Drive temperature before the run",
+	},
+	{
+		Name:        "smart_temp_after_c",
+		Type:        plugin.MetricTypeGauge,
+		Unit:        "°C",
+		Description: "Drive temperature after the run",
+	},
+	{
+		Name:        "smart_temp_delta_c",
+		Type:        plugin.MetricTypeGauge,
+		Unit:        "°C",
+		Description: "Drive temperature rise during the run",
+	},
+	{
+		Name:        "smart_wear_delta_pct",
+		Type:        plugin.MetricTypeGauge,
+		Unit:        "%",
+		Description: "SSD wear added by the run",
+	},
+	{
+		Name:        "smart_written_delta_gb",
+		Type:        plugin.MetricTypeCounter,
+		Unit:        "GB",
+		Description: "Data the drive counted as written during the run",
+	},
+	{
+		Name:        "smart_read_delta_gb",
+		Type:        plugin.MetricTypeCounter,
+		Unit:        "GB",
+		Description: "Data the drive counted as read during the run",
+	},
+}
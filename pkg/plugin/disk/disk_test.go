@@ -0,0 +1,239 @@
+package disk
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/plugin"
+	"github.com/mscrnt/project_fire/pkg/smart"
+	"github.com/mscrnt/project_fire/pkg/storage"
+)
+
+func TestPlanPhasesAdaptsToDrive(t *testing.T) {
+	names := func(phases []phase) []string {
+		var n []string
+		for _, ph := range phases {
+			n = append(n, ph.metric)
+		}
+		return n
+	}
+
+	phases, _ := planPhases("auto", &storage.Characteristics{Zoned: storage.ZonedNone})
+	if len(phases) != 4 {
+		t.Errorf("expected all phases on a conventional drive, got %v", names(phases))
+	}
+
+	phases, notes := planPhases("auto", &storage.Characteristics{Zoned: storage.ZonedNone, Recording: storage.RecordingSMR})
+	if len(phases) != 3 || len(notes) != 1 {
+		t.Errorf("expected random writes skipped on SMR, got %v %v", names(phases), notes)
+	}
+
+	phases, _ = planPhases("mixed", &storage.Characteristics{Zoned: storage.ZonedNone, Recording: storage.RecordingSMR})
+	if len(phases) != 4 {
+		t.Errorf("expected mixed to force random writes on SMR, got %v", names(phases))
+	}
+
+	phases, _ = planPhases("random", &storage.Characteristics{Zoned: storage.ZonedHostManaged})
+	if len(phases) != 1 || phases[0].write {
+		t.Errorf("expected only random reads on a host-managed drive, got %v", names(phases))
+	}
+}
+
+func TestRunNative(t *testing.T) {
+	p := &Plugin{}
+	params := p.DefaultParams()
+	params.Duration = 400 * time.Millisecond
+	params.Config["path"] = t.TempDir()
+	params.Config["size_mb"] = 4
+	params.Config["pattern"] = "mixed"
+	params.Config["queue_depth"] = 4
+	params.Config["smart"] = false
+
+	result, err := p.Run(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for _, m := range []string{"seq_write_mb_per_sec", "seq_read_mb_per_sec", "random_read_iops", "random_write_iops",
+		"seq_read_latency_p50_us", "random_read_latency_p99_us"} {
+		if result.Metrics[m] <= 0 {
+			t.Errorf("expected %s > 0, got %v", m, result.Metrics[m])
+		}
+	}
+	if result.Metrics["random_read_latency_p99_us"] < result.Metrics["random_read_latency_p50_us"] {
+		t.Errorf("expected p99 latency >= p50, got %v", result.Metrics)
+	}
+
+	params.Config["pattern"] = "bogus"
+	if err := p.ValidateParams(params); err == nil {
+		t.Error("expected invalid pattern to be rejected")
+	}
+	if _, err := plugin.Get("disk"); err != nil {
+		t.Errorf("disk plugin not registered: %v", err)
+	}
+}
+
+func TestRawModeRequiresConfirmation(t *testing.T) {
+	p := &Plugin{}
+	params := p.DefaultParams()
+	params.Config["mode"] = "raw"
+
+	if err := p.ValidateParams(params); err == nil {
+		t.Error("expected raw mode without raw_device to be rejected")
+	}
+
+	params.Config["raw_device"] = "/dev/sdz"
+	if err := p.ValidateParams(params); err == nil {
+		t.Error("expected raw mode without confirm_raw to be rejected")
+	}
+
+	params.Config["confirm_raw"] = true
+	if err := p.ValidateParams(params); err != nil {
+		t.Errorf("expected confirmed raw mode to validate, got %v", err)
+	}
+
+	params.Config["raw_device"] = "/dev/sdz; rm -rf /"
+	if err := p.ValidateParams(params); err == nil {
+		t.Error("expected unsafe device path to be rejected")
+	}
+}
+
+func TestQueueDepthBuffersLimited(t *testing.T) {
+	p := &Plugin{}
+	params := p.DefaultParams()
+	params.Config["block_kb"] = 65536
+	params.Config["queue_depth"] = 32
+	if err := p.ValidateParams(params); err == nil {
+		t.Error("expected 2 GB of in-flight buffers to be rejected")
+	}
+}
+
+func TestAddSMARTMetrics(t *testing.T) {
+	metrics := make(map[string]float64)
+	addSMARTMetrics(metrics,
+		&smart.Data{Temperature: 35, WearLevel: 3, TotalWrittenGB: 1000, Available: true},
+		&smart.Data{Temperature: 52, WearLevel: 3, TotalWrittenGB: 1004.5, Available: true})
+
+	if metrics["smart_temp_delta_c"] != 17 || metrics["smart_written_delta_gb"] != 4.5 || metrics["smart_wear_delta_pct"] != 0 {
+		t.Errorf("unexpected SMART deltas %v", metrics)
+	}
+	if _, ok := metrics["smart_read_delta_gb"]; ok {
+		t.Error("expected no read delta when the drive does not count reads")
+	}
+}
+
+func TestAddOverheadMetrics(t *testing.T) {
+	metrics := map[string]float64{
+		"seq_write_mb_per_sec":     400,
+		"raw_seq_write_mb_per_sec": 500,
+		"random_read_iops":         9000,
+	}
+	addOverheadMetrics(metrics)
+
+	if got := metrics["fs_overhead_seq_write_pct"]; got != 20 {
+		t.Errorf("expected 20%% sequential write overhead, got %v", got)
+	}
+	if _, ok := metrics["fs_overhead_random_read_pct"]; ok {
+		t.Error("overhead should only be reported when both results exist")
+	}
+}
+
+func TestRunDrivesTogether(t *testing.T) {
+	p := &Plugin{}
+	params := p.DefaultParams()
+	params.Duration = 400 * time.Millisecond
+	params.Config["paths"] = t.TempDir() + string(filepath.ListSeparator) + t.TempDir()
+	params.Config["size_mb"] = 2
+	params.Config["pattern"] = "mixed"
+	params.Config["smart"] = false
+
+	result, err := p.Run(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Metrics["drives"] != 2 {
+		t.Errorf("expected 2 drives, got %v", result.Metrics["drives"])
+	}
+	for _, m := range []string{"drive1_seq_write_mb_per_sec", "drive2_seq_write_mb_per_sec", "drive2_random_read_latency_p99_us"} {
+		if result.Metrics[m] <= 0 {
+			t.Errorf("expected %s > 0, got %v", m, result.Metrics[m])
+		}
+	}
+	if total := result.Metrics["drive1_random_read_iops"] + result.Metrics["drive2_random_read_iops"]; result.Metrics["random_read_iops"] != total {
+		t.Errorf("expected total random_read_iops %v, got %v", total, result.Metrics["random_read_iops"])
+	}
+
+	params.Config["mode"] = "raw"
+	if err := p.ValidateParams(params); err == nil {
+		t.Error("expected paths with raw mode to be rejected")
+	}
+}
+
+func TestAddDriveTotals(t *testing.T) {
+	metrics := make(map[string]float64)
+	addDriveTotals(metrics, []map[string]float64{
+		{"seq_read_mb_per_sec": 500, "seq_read_latency_p99_us": 900, "smart_temp_after_c": 41},
+		{"seq_read_mb_per_sec": 250, "seq_read_latency_p99_us": 2000, "smart_temp_after_c": 55},
+	})
+
+	if metrics["seq_read_mb_per_sec"] != 750 || metrics["seq_read_spread_pct"] != 50 {
+		t.Errorf("expected 750 MB/s total with a 50%% spread, got %v", metrics)
+	}
+	if metrics["seq_read_latency_p99_us"] != 2000 || metrics["smart_temp_after_c"] != 55 {
+		t.Errorf("expected the worst drive's latency and temperature, got %v", metrics)
+	}
+	if _, ok := metrics["random_read_iops"]; ok {
+		t.Error("expected no total for a phase no drive ran")
+	}
+}
+
+func TestCacheDirect(t *testing.T) {
+	p := &Plugin{}
+	params := p.DefaultParams()
+	params.Duration = 200 * time.Millisecond
+	params.Config["path"] = t.TempDir()
+	params.Config["size_mb"] = 2
+	params.Config["pattern"] = "random"
+	params.Config["cache"] = "direct"
+	params.Config["smart"] = false
+
+	result, err := p.Run(context.Background(), params)
+	if err != nil {
+		t.Skipf("unbuffered I/O not supported here: %v", err)
+	}
+	if result.Metrics["random_read_iops"] <= 0 {
+		t.Errorf("expected random reads with unbuffered I/O, got %v", result.Metrics)
+	}
+
+	params.Config["random_block_kb"] = 2
+	if err := p.ValidateParams(params); err == nil {
+		t.Error("expected a 2 KB transfer to be rejected for unbuffered I/O")
+	}
+	params.Config["random_block_kb"] = 4
+	params.Config["cache"] = "compare"
+	params.Config["mode"] = "both"
+	if err := p.ValidateParams(params); err == nil {
+		t.Error("expected cache=compare with mode=both to be rejected")
+	}
+}
+
+func TestAddCacheMetrics(t *testing.T) {
+	metrics := map[string]float64{
+		"seq_read_mb_per_sec":       500,
+		"warm_seq_read_mb_per_sec":  2000,
+		"warm_seq_read_latency_us":  10,
+		"seq_read_latency_us":       80,
+		"warm_raw_random_read_iops": 90000,
+		"raw_random_read_iops":      30000,
+		"warm_random_write_iops":    100,
+	}
+	addCacheMetrics(metrics)
+
+	if metrics["cache_gain_seq_read_pct"] != 300 || metrics["cache_gain_raw_random_read_pct"] != 200 {
+		t.Errorf("unexpected cache gains %v", metrics)
+	}
+	if _, ok := metrics["cache_gain_random_write_pct"]; ok {
+		t.Error("expected no gain without a cold result")
+	}
+}
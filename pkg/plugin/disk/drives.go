@@ -0,0 +1,299 @@
+package disk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/plugin"
+	"github.com/mscrnt/project_fire/pkg/smart"
+	"github.com/mscrnt/project_fire/pkg/storage"
+)
+
+// Stressing drives one at a time misses what only shows when they all work
+// at once: a shared controller or expander that cannot carry their combined
+// bandwidth, or a PSU rail that sags when every drive seeks. The paths
+// config runs the benchmark on several drives simultaneously, one set of
+// queue_depth workers per drive, and moves them through the phases in
+// lockstep so every phase loads all drives together.
+
+// throughputMetrics are the per-phase results summed across drives
+var throughputMetrics = []string{"seq_write_mb_per_sec", "seq_read_mb_per_sec", "random_read_iops", "random_write_iops"}
+
+// drive is one target of a multi-drive run
+type drive struct {
+	Label           string                   `json:"label"` // Metric prefix, e.g. drive1
+	Path            string                   `json:"path"`
+	Device          string                   `json:"device,omitempty"`
+	Characteristics *storage.Characteristics `json:"characteristics,omitempty"`
+	Warnings        []string                 `json:"warnings,omitempty"`
+
+	file        *os.File
+	metrics     map[string]float64
+	smartDevice string
+	smartBefore *smart.Data
+}
+
+func (d *drive) String() string {
+	if d.Device == "" {
+		return d.Label + " " + d.Path
+	}
+	return fmt.Sprintf("%s %s (%s)", d.Label, d.Path, d.Device)
+}
+
+// configPaths reads the paths config: directories on the drives under test,
+// separated like PATH (":" on Linux and macOS, ";" on Windows)
+func configPaths(config map[string]interface{}) []string {
+	v, ok := config["paths"].(string)
+	if !ok {
+		return nil
+	}
+	var paths []string
+	for _, p := range filepath.SplitList(v) {
+		if p = strings.TrimSpace(p); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// runDrives benchmarks every path at once. Each drive's metrics are prefixed
+// with its label; the unprefixed metrics are the system totals.
+func runDrives(ctx context.Context, paths []string, size int64, work workload, direct bool, pattern string, withSMART bool, duration time.Duration, metrics map[string]float64, details map[string]interface{}) error {
+	drives := make([]*drive, len(paths))
+	owner := make(map[string]string) // physical drive -> first path on it
+	var notes []string
+	var phases []phase
+	for i, path := range paths {
+		d := &drive{Label: fmt.Sprintf("drive%d", i+1), Path: path, metrics: make(map[string]float64)}
+		drives[i] = d
+		d.Device, _ = storage.DeviceForPath(path)
+		if d.Device != "" {
+			d.Characteristics, _ = storage.Detect(d.Device, "")
+			if d.Characteristics != nil {
+				d.Warnings = append(d.Warnings, d.Characteristics.Warnings...)
+			}
+			physical := storage.PhysicalDrive(d.Device)
+			if first, ok := owner[physical]; ok {
+				d.Warnings = append(d.Warnings, fmt.Sprintf("%s is on the same drive as %s (%s); they share its bandwidth", path, first, physical))
+			} else {
+				owner[physical] = path
+			}
+		}
+
+		// Every drive runs the same phases, so a drive that must not take
+		// random writes keeps them off all of them
+		planned, n := planPhases(pattern, d.Characteristics)
+		for _, note := range n {
+			notes = append(notes, d.Label+": "+note)
+		}
+		if i == 0 {
+			phases = planned
+		} else {
+			phases = commonPhases(phases, planned)
+		}
+	}
+	details["drives"] = drives
+	if len(notes) > 0 {
+		details["adjustments"] = notes
+	}
+	var warnings []string
+	for _, d := range drives {
+		for _, w := range d.Warnings {
+			warnings = append(warnings, d.Label+": "+w)
+		}
+	}
+	if len(warnings) > 0 {
+		details["warnings"] = warnings
+	}
+
+	if withSMART {
+		for _, d := range drives {
+			if d.Device == "" {
+				continue
+			}
+			d.smartDevice = storage.PhysicalDrive(d.Device)
+			d.smartBefore, _ = readSMART(d.smartDevice)
+		}
+	}
+
+	defer func() {
+		for _, d := range drives {
+			if d.file != nil {
+				_ = d.file.Close()
+				_ = os.Remove(d.file.Name())
+			}
+		}
+	}()
+	for _, d := range drives {
+		f, err := os.CreateTemp(d.Path, "fire-disk-*.dat")
+		if err != nil {
+			return fmt.Errorf("failed to create test file on %s: %w", d.Path, err)
+		}
+		d.file = f
+		if direct {
+			_ = f.Close()
+			if d.file, err = openDirect(f.Name()); err != nil {
+				d.file = nil
+				_ = os.Remove(f.Name())
+				return fmt.Errorf("failed to open %s: %w", f.Name(), err)
+			}
+		}
+	}
+
+	// Fill the test files together, then run each phase on all drives at once
+	err := eachDrive(drives, func(d *drive) error {
+		if err := fill(ctx, d.file, size, work.data[:work.seqBlock]); err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", d.file.Name(), err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	phaseDuration := duration / time.Duration(len(phases))
+	for _, ph := range phases {
+		if ctx.Err() != nil {
+			break
+		}
+		err := eachDrive(drives, func(d *drive) error {
+			if err := runPhase(ctx, d.file, size, work, ph, phaseDuration, "", d.metrics); err != nil {
+				return fmt.Errorf("%s failed on %s: %w", ph.name, d.Path, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, d := range drives {
+		if d.smartBefore == nil {
+			continue
+		}
+		if after, err := readSMART(d.smartDevice); err == nil {
+			addSMARTMetrics(d.metrics, d.smartBefore, after)
+		}
+	}
+
+	perDrive := make([]map[string]float64, len(drives))
+	for i, d := range drives {
+		perDrive[i] = d.metrics
+		for name, v := range d.metrics {
+			metrics[d.Label+"_"+name] = v
+		}
+	}
+	addDriveTotals(metrics, perDrive)
+	metrics["drives"] = float64(len(drives))
+	return nil
+}
+
+// eachDrive runs fn for every drive concurrently and joins their errors
+func eachDrive(drives []*drive, fn func(d *drive) error) error {
+	errs := make([]error, len(drives))
+	var wg sync.WaitGroup
+	for i, d := range drives {
+		wg.Add(1)
+		go func(i int, d *drive) {
+			defer wg.Done()
+			errs[i] = fn(d)
+		}(i, d)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// commonPhases keeps the phases of a that b also runs
+func commonPhases(a, b []phase) []phase {
+	var out []phase
+	for _, pa := range a {
+		for _, pb := range b {
+			if pa.metric == pb.metric {
+				out = append(out, pa)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// addDriveTotals records how the drives behaved together: throughput and
+// IOPS summed across drives, the worst latency and hottest drive, and the
+// spread between the fastest and slowest drive. A wide spread between
+// identical drives points at a shared link starving some of them.
+func addDriveTotals(metrics map[string]float64, drives []map[string]float64) {
+	for _, name := range throughputMetrics {
+		total, lowest, highest, n := 0.0, math.Inf(1), 0.0, 0
+		for _, m := range drives {
+			if v, ok := m[name]; ok {
+				total += v
+				lowest = math.Min(lowest, v)
+				highest = math.Max(highest, v)
+				n++
+			}
+		}
+		if n == 0 {
+			continue
+		}
+		metrics[name] = total
+		if n > 1 && highest > 0 {
+			metrics[strings.TrimSuffix(strings.TrimSuffix(name, "_mb_per_sec"), "_iops")+"_spread_pct"] = (highest - lowest) / highest * 100
+		}
+	}
+
+	// Latency and temperature are judged by the worst drive
+	worst := make(map[string]float64)
+	for _, m := range drives {
+		for name, v := range m {
+			if strings.Contains(name, "_latency_") || name == "smart_temp_after_c" || name == "smart_temp_delta_c" {
+				if cur, ok := worst[name]; !ok || v > cur {
+					worst[name] = v
+				}
+			}
+		}
+	}
+	for name, v := range worst {
+		metrics[name] = v
+	}
+}
+
+// driveMetricInfo describes the totals recorded when paths is set. Each
+// drive's own results are recorded under its label, e.g.
+// drive2_seq_read_mb_per_sec.
+var driveMetricInfo = []plugin.MetricInfo{
+	{
+		Name:        "drives",
+		Type:        plugin.MetricTypeGauge,
+		Description: "Drives stressed at once (paths); throughput and IOPS are then their sum, latencies and temperatures the worst drive's",
+	},
+	{
+		Name:        "seq_write_spread_pct",
+		Type:        plugin.MetricTypeGauge,
+		Unit:        "%",
+		Description: "How far the slowest drive's sequential writes fell behind the fastest's (paths)",
+	},
+	{
+		Name:        "seq_read_spread_pct",
+		Type:        plugin.MetricTypeGauge,
+		Unit:        "%",
+		Description: "How far the slowest drive's sequential reads fell behind the fastest's (paths)",
+	},
+	{
+		Name:        "random_read_spread_pct",
+		Type:        plugin.MetricTypeGauge,
+		Unit:        "%",
+		Description: "How far the slowest drive's random reads fell behind the fastest's (paths)",
+	},
+	{
+		Name:        "random_write_spread_pct",
+		Type:        plugin.MetricTypeGauge,
+		Unit:        "%",
+		Description: "How far the slowest drive's random writes fell behind the fastest's (paths)",
+	},
+}
@@ -0,0 +1,73 @@
+package disk
+
+import (
+	"math"
+	"time"
+)
+
+// Latency histogram buckets grow by 2%, so a percentile is accurate to
+// about 1% while using a fixed amount of memory however long the run
+const (
+	latencyGrowth  = 1.02
+	latencyBuckets = 1400 // up to about 17 minutes per operation
+)
+
+var logLatencyGrowth = math.Log(latencyGrowth)
+
+// latencyHistogram records operation latencies for percentile reporting
+type latencyHistogram struct {
+	counts [latencyBuckets]uint64
+	total  time.Duration
+	n      uint64
+}
+
+// record adds one operation's latency
+func (h *latencyHistogram) record(d time.Duration) {
+	idx := 0
+	if d > 1 {
+		idx = int(math.Log(float64(d)) / logLatencyGrowth)
+	}
+	if idx >= latencyBuckets {
+		idx = latencyBuckets - 1
+	}
+	h.counts[idx]++
+	h.total += d
+	h.n++
+}
+
+// merge adds the operations recorded by another histogram
+func (h *latencyHistogram) merge(other *latencyHistogram) {
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+	h.total += other.total
+	h.n += other.n
+}
+
+// mean returns the average latency
+func (h *latencyHistogram) mean() time.Duration {
+	if h.n == 0 {
+		return 0
+	}
+	return h.total / time.Duration(h.n) // #nosec G115 -- operation counts fit in int64
+}
+
+// percentile returns the latency below which p percent of operations
+// completed, estimated from the middle of its bucket
+func (h *latencyHistogram) percentile(p float64) time.Duration {
+	if h.n == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(p / 100 * float64(h.n)))
+	if rank == 0 {
+		rank = 1
+	}
+	var seen uint64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= rank {
+			return time.Duration(math.Pow(latencyGrowth, float64(i)+0.5))
+		}
+	}
+	return time.Duration(math.Pow(latencyGrowth, latencyBuckets))
+}
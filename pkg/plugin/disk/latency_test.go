@@ -0,0 +1,41 @@
+package disk
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLatencyHistogramPercentiles(t *testing.T) {
+	var a, b latencyHistogram
+	for i := 1; i <= 1000; i++ {
+		h := &a
+		if i%2 == 0 {
+			h = &b
+		}
+		h.record(time.Duration(i) * time.Microsecond)
+	}
+	a.merge(&b)
+
+	if a.n != 1000 || a.mean() != 500500*time.Nanosecond {
+		t.Errorf("expected 1000 operations averaging 500.5us, got %d and %v", a.n, a.mean())
+	}
+	for _, tt := range []struct {
+		p    float64
+		want time.Duration
+	}{
+		{50, 500 * time.Microsecond},
+		{95, 950 * time.Microsecond},
+		{99, 990 * time.Microsecond},
+	} {
+		got := a.percentile(tt.p)
+		if math.Abs(float64(got-tt.want))/float64(tt.want) > 0.02 {
+			t.Errorf("p%v: expected about %v, got %v", tt.p, tt.want, got)
+		}
+	}
+
+	var empty latencyHistogram
+	if empty.percentile(99) != 0 || empty.mean() != 0 {
+		t.Error("expected zero latencies without operations")
+	}
+}
@@ -0,0 +1,41 @@
+package disk
+
+import (
+	"fmt"
+
+	"github.com/mscrnt/project_fire/pkg/smart"
+)
+
+// smartEnabled reports whether SMART snapshots were requested. They are on
+// by default and skipped quietly when smartctl is missing.
+func smartEnabled(config map[string]interface{}) bool {
+	enabled, ok := config["smart"].(bool)
+	return !ok || enabled
+}
+
+// readSMART reads the SMART data of the drive under test
+func readSMART(device string) (*smart.Data, error) {
+	if device == "" {
+		return nil, fmt.Errorf("no drive found for SMART")
+	}
+	return smart.Read(device)
+}
+
+// addSMARTMetrics records what the run cost the drive: temperature rise,
+// wear and the data the drive counted as written and read
+func addSMARTMetrics(metrics map[string]float64, before, after *smart.Data) {
+	if before.Temperature > 0 && after.Temperature > 0 {
+		metrics["smart_temp_before_c"] = before.Temperature
+		metrics["smart_temp_after_c"] = after.Temperature
+		metrics["smart_temp_delta_c"] = after.Temperature - before.Temperature
+	}
+	if before.WearLevel > 0 || after.WearLevel > 0 {
+		metrics["smart_wear_delta_pct"] = after.WearLevel - before.WearLevel
+	}
+	if before.TotalWrittenGB > 0 {
+		metrics["smart_written_delta_gb"] = after.TotalWrittenGB - before.TotalWrittenGB
+	}
+	if before.TotalReadGB > 0 {
+		metrics["smart_read_delta_gb"] = after.TotalReadGB - before.TotalReadGB
+	}
+}
@@ -0,0 +1,209 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/plugin"
+)
+
+// clockPollInterval is how often the effective memory clock is sampled
+const clockPollInterval = 2 * time.Second
+
+// clockChangeThreshold is the relative change in data rate treated as a
+// frequency change rather than measurement noise
+const clockChangeThreshold = 0.01
+
+// umcConfigRegister is the SMN address of the UMC0 configuration register on
+// AMD Zen processors. Bits 6:0 hold the MEMCLK ratio and bit 11 gear-down mode.
+const umcConfigRegister = 0x50200
+
+// ClockSample is one reading of the memory controller state
+type ClockSample struct {
+	Time     time.Time `json:"time"`
+	MTs      float64   `json:"mts"`       // effective data rate in MT/s
+	GearDown bool      `json:"gear_down"` // gear-down mode active
+}
+
+// ClockEvent records a change of memory frequency or gear during a run
+type ClockEvent struct {
+	Time         time.Time `json:"time"`
+	Offset       string    `json:"offset"` // time since the run started
+	FromMTs      float64   `json:"from_mts"`
+	ToMTs        float64   `json:"to_mts"`
+	GearDownFrom bool      `json:"gear_down_from"`
+	GearDownTo   bool      `json:"gear_down_to"`
+	Description  string    `json:"description"`
+}
+
+// clockReader reads the current memory controller state
+type clockReader func() (ClockSample, error)
+
+// clockMonitor polls the memory clock in the background during a run
+type clockMonitor struct {
+	source  string
+	read    clockReader
+	mu      sync.Mutex
+	samples []ClockSample
+	err     error
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// startClockMonitor starts polling the memory clock. It returns nil when no
+// clock source is available on this system, with the reason.
+func startClockMonitor(ctx context.Context) (*clockMonitor, string) {
+	source, read, reason := newClockReader()
+	if read == nil {
+		return nil, reason
+	}
+	first, err := read()
+	if err != nil {
+		return nil, err.Error()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	m := &clockMonitor{
+		source:  source,
+		read:    read,
+		samples: []ClockSample{first},
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	go m.poll(ctx)
+	return m, ""
+}
+
+// poll samples the clock until the context is cancelled
+func (m *clockMonitor) poll(ctx context.Context) {
+	defer close(m.done)
+	ticker := time.NewTicker(clockPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sample, err := m.read()
+			m.mu.Lock()
+			if err != nil {
+				m.err = err
+			} else {
+				m.samples = append(m.samples, sample)
+			}
+			m.mu.Unlock()
+		}
+	}
+}
+
+// stop takes a final sample and waits for polling to finish
+func (m *clockMonitor) stop() []ClockSample {
+	m.cancel()
+	<-m.done
+	if sample, err := m.read(); err == nil {
+		m.samples = append(m.samples, sample)
+	}
+	return m.samples
+}
+
+// annotate stops the monitor and records the clock history in the result
+func (m *clockMonitor) annotate(result *plugin.Result) {
+	samples := m.stop()
+	events := analyzeClock(samples)
+
+	result.Details["memory_clock_source"] = m.source
+	if m.err != nil {
+		result.Details["memory_clock_error"] = m.err.Error()
+	}
+
+	minMTs := samples[0].MTs
+	for _, s := range samples {
+		if s.MTs < minMTs {
+			minMTs = s.MTs
+		}
+	}
+	result.Metrics["memory_clock_start_mts"] = samples[0].MTs
+	result.Metrics["memory_clock_min_mts"] = minMTs
+	result.Metrics["memory_clock_changes"] = float64(len(events))
+
+	if len(events) == 0 {
+		return
+	}
+	result.Details["memory_clock_events"] = events
+	warnings, _ := result.Details["warnings"].([]string)
+	for _, e := range events {
+		warnings = append(warnings, fmt.Sprintf("%s after %s", e.Description, e.Offset))
+	}
+	result.Details["warnings"] = warnings
+}
+
+// analyzeClock returns the frequency and gear changes between consecutive
+// samples
+func analyzeClock(samples []ClockSample) []ClockEvent {
+	var events []ClockEvent
+	if len(samples) == 0 {
+		return events
+	}
+	start := samples[0].Time
+	prev := samples[0]
+	for _, s := range samples[1:] {
+		freqChanged := prev.MTs > 0 && math.Abs(s.MTs-prev.MTs)/prev.MTs > clockChangeThreshold
+		gearChanged := s.GearDown != prev.GearDown
+		if !freqChanged && !gearChanged {
+			continue
+		}
+
+		e := ClockEvent{
+			Time:         s.Time,
+			Offset:       s.Time.Sub(start).Round(time.Second).String(),
+			FromMTs:      prev.MTs,
+			ToMTs:        s.MTs,
+			GearDownFrom: prev.GearDown,
+			GearDownTo:   s.GearDown,
+		}
+		switch {
+		case freqChanged && s.MTs < prev.MTs:
+			e.Description = fmt.Sprintf("Memory downclocked from %.0f to %.0f MT/s", prev.MTs, s.MTs)
+		case freqChanged:
+			e.Description = fmt.Sprintf("Memory clock rose from %.0f to %.0f MT/s", prev.MTs, s.MTs)
+		case s.GearDown:
+			e.Description = "Memory controller switched to gear-down mode"
+		default:
+			e.Description = "Memory controller left gear-down mode"
+		}
+		events = append(events, e)
+		prev = s
+	}
+	return events
+}
+
+// decodeUMCConfig converts an AMD UMC configuration register value into a
+// clock sample. The MEMCLK ratio is in units of 33.33 MHz, i.e. 66.67 MT/s.
+func decodeUMCConfig(value uint32) ClockSample {
+	ratio := value & 0x7F
+	return ClockSample{
+		Time:     time.Now(),
+		MTs:      float64(ratio) * 200 / 3,
+		GearDown: value&(1<<11) != 0,
+	}
+}
+
+// zenUMCSupported reports whether the CPU is an AMD Zen 1-3 part whose UMC
+// configuration register uses the DDR4 layout decoded by decodeUMCConfig.
+// Zen 4 DDR5 controllers use a different layout and are not decoded.
+func zenUMCSupported(vendor string, family, model int) bool {
+	if vendor != "AuthenticAMD" {
+		return false
+	}
+	switch family {
+	case 0x17:
+		return true
+	case 0x19:
+		return model < 0x10 || (model >= 0x20 && model < 0x60)
+	}
+	return false
+}
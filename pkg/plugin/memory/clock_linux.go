@@ -0,0 +1,73 @@
+//go:build linux
+// +build linux
+
+package memory
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+)
+
+// smnDebugfs is the kernel's AMD SMN interface. The kernel reads the
+// register under the lock k10temp, amd_pmc and amd_energy take, so the
+// index/data pair in the root complex is never shared with them unlocked.
+// A variable so tests can point it at a directory.
+var smnDebugfs = "/sys/kernel/debug/x86/amd_smn"
+
+// smnMu serializes this process's use of the interface, whose address is
+// shared by every reader
+var smnMu sync.Mutex
+
+// newClockReader returns the memory clock source for this system
+func newClockReader() (string, clockReader, string) {
+	infos, err := cpu.Info()
+	if err != nil || len(infos) == 0 {
+		return "", nil, "CPU identification unavailable"
+	}
+	family, _ := strconv.Atoi(infos[0].Family)
+	model, _ := strconv.Atoi(infos[0].Model)
+	if !zenUMCSupported(infos[0].VendorID, family, model) {
+		return "", nil, "effective memory clock is only readable on AMD Zen 1-3 processors"
+	}
+	if os.Geteuid() != 0 {
+		return "", nil, "reading the memory controller requires root"
+	}
+	if _, err := os.Stat(smnDebugfs); err != nil {
+		return "", nil, "reading the memory controller needs the kernel's amd_smn debugfs interface"
+	}
+
+	return "amd-umc", func() (ClockSample, error) {
+		value, err := readSMN(umcConfigRegister)
+		if err != nil {
+			return ClockSample{}, err
+		}
+		return decodeUMCConfig(value), nil
+	}, ""
+}
+
+// readSMN reads a register on the AMD system management network through
+// the kernel
+func readSMN(address uint32) (uint32, error) {
+	smnMu.Lock()
+	defer smnMu.Unlock()
+
+	addressFile := filepath.Join(smnDebugfs, "address")
+	if err := os.WriteFile(addressFile, []byte(fmt.Sprintf("0x%x", address)), 0o600); err != nil {
+		return 0, fmt.Errorf("failed to select SMN register 0x%X: %w", address, err)
+	}
+	data, err := os.ReadFile(filepath.Join(smnDebugfs, "value")) // #nosec G304 -- fixed debugfs path
+	if err != nil {
+		return 0, fmt.Errorf("failed to read SMN register 0x%X: %w", address, err)
+	}
+	value, err := strconv.ParseUint(strings.TrimSpace(string(data)), 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse SMN register 0x%X: %w", address, err)
+	}
+	return uint32(value), nil
+}
@@ -0,0 +1,33 @@
+//go:build linux
+// +build linux
+
+package memory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadSMN(t *testing.T) {
+	smnDebugfs = t.TempDir()
+	defer func() { smnDebugfs = "/sys/kernel/debug/x86/amd_smn" }()
+
+	if err := os.WriteFile(filepath.Join(smnDebugfs, "value"), []byte("0x00000836\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	value, err := readSMN(umcConfigRegister)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != 0x836 {
+		t.Errorf("expected 0x836, got 0x%x", value)
+	}
+	address, err := os.ReadFile(filepath.Join(smnDebugfs, "address"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(address) != "0x50200" {
+		t.Errorf("expected the UMC register to be selected, got %q", address)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package memory
+
+// newClockReader returns the memory clock source for this system
+func newClockReader() (string, clockReader, string) {
+	return "", nil, "effective memory clock monitoring is not supported on this platform"
+}
@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDecodeUMCConfig(t *testing.T) {
+	// DDR4-3600: MEMCLK ratio 54 (1800 MHz), gear-down enabled
+	sample := decodeUMCConfig(54 | 1<<11)
+	if sample.MTs != 3600 {
+		t.Errorf("expected 3600 MT/s, got %v", sample.MTs)
+	}
+	if !sample.GearDown {
+		t.Error("expected gear-down mode to be decoded")
+	}
+}
+
+func TestAnalyzeClock(t *testing.T) {
+	start := time.Now()
+	samples := []ClockSample{
+		{Time: start, MTs: 3600},
+		{Time: start.Add(2 * time.Second), MTs: 3600},
+		{Time: start.Add(4 * time.Second), MTs: 3602}, // rounding noise
+		{Time: start.Add(6 * time.Second), MTs: 2133},
+		{Time: start.Add(8 * time.Second), MTs: 2133, GearDown: true},
+	}
+
+	events := analyzeClock(samples)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d: %+v", len(events), events)
+	}
+	if events[0].FromMTs != 3600 || events[0].ToMTs != 2133 || events[0].Offset != "6s" {
+		t.Errorf("unexpected downclock event: %+v", events[0])
+	}
+	if !events[1].GearDownTo || events[1].GearDownFrom {
+		t.Errorf("expected gear-down event, got %+v", events[1])
+	}
+}
+
+func TestZenUMCSupported(t *testing.T) {
+	tests := []struct {
+		vendor        string
+		family, model int
+		want          bool
+	}{
+		{"AuthenticAMD", 0x17, 0x71, true},  // Zen 2 (Matisse)
+		{"AuthenticAMD", 0x19, 0x21, true},  // Zen 3 (Vermeer)
+		{"AuthenticAMD", 0x19, 0x61, false}, // Zen 4 (Raphael), DDR5
+		{"GenuineIntel", 6, 0x97, false},
+	}
+	for _, tt := range tests {
+		if got := zenUMCSupported(tt.vendor, tt.family, tt.model); got != tt.want {
+			t.Errorf("zenUMCSupported(%s, %#x, %#x) = %v, want %v", tt.vendor, tt.family, tt.model, got, tt.want)
+		}
+	}
+}
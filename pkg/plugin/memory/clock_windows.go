@@ -0,0 +1,101 @@
+//go:build windows
+// +build windows
+
+package memory
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// winRing0 is loaded on first use; it is the same driver used for SPD reads
+var (
+	winRing0Once sync.Once
+	winRing0     *syscall.LazyDLL
+	winRing0Err  error
+	smnMu        sync.Mutex
+)
+
+// newClockReader returns the memory clock source for this system
+func newClockReader() (string, clockReader, string) {
+	vendor, family, model := processorIdentifier()
+	if !zenUMCSupported(vendor, family, model) {
+		return "", nil, "effective memory clock is only readable on AMD Zen 1-3 processors"
+	}
+	if err := loadWinRing0(); err != nil {
+		return "", nil, err.Error()
+	}
+
+	return "amd-umc", func() (ClockSample, error) {
+		value, err := readSMN(umcConfigRegister)
+		if err != nil {
+			return ClockSample{}, err
+		}
+		return decodeUMCConfig(value), nil
+	}, ""
+}
+
+// processorIdentifier parses PROCESSOR_IDENTIFIER, e.g.
+// "AMD64 Family 23 Model 113 Stepping 0, AuthenticAMD"
+func processorIdentifier() (string, int, int) {
+	id := os.Getenv("PROCESSOR_IDENTIFIER")
+	var vendor string
+	if i := strings.LastIndex(id, ","); i >= 0 {
+		vendor = strings.TrimSpace(id[i+1:])
+	}
+	var family, model int
+	fields := strings.Fields(id)
+	for i := 0; i+1 < len(fields); i++ {
+		switch fields[i] {
+		case "Family":
+			family, _ = strconv.Atoi(strings.TrimSuffix(fields[i+1], ","))
+		case "Model":
+			model, _ = strconv.Atoi(strings.TrimSuffix(fields[i+1], ","))
+		}
+	}
+	return vendor, family, model
+}
+
+// loadWinRing0 loads and initializes the WinRing0 driver
+func loadWinRing0() error {
+	winRing0Once.Do(func() {
+		for _, name := range []string{"OlsApi.dll", "WinRing0x64.dll", "OlsApi64.dll"} {
+			dll := syscall.NewLazyDLL(name)
+			if dll.Load() == nil {
+				winRing0 = dll
+				break
+			}
+		}
+		if winRing0 == nil {
+			winRing0Err = fmt.Errorf("WinRing0 DLL not found")
+			return
+		}
+		if ret, _, err := winRing0.NewProc("InitializeOls").Call(); ret == 0 {
+			winRing0Err = fmt.Errorf("failed to initialize WinRing0 driver (needs Administrator): %v", err)
+		}
+	})
+	return winRing0Err
+}
+
+// readSMN reads a register on the AMD system management network through the
+// SMN index/data pair of the root complex (bus 0, device 0, function 0)
+func readSMN(address uint32) (uint32, error) {
+	smnMu.Lock()
+	defer smnMu.Unlock()
+
+	write := winRing0.NewProc("WritePciConfigDwordEx")
+	read := winRing0.NewProc("ReadPciConfigDwordEx")
+	if ret, _, err := write.Call(0, 0x60, uintptr(address)); ret == 0 {
+		return 0, fmt.Errorf("failed to select SMN register 0x%X: %v", address, err)
+	}
+	var value uint32
+	if ret, _, err := read.Call(0, 0x64, uintptr(unsafe.Pointer(&value))); ret == 0 {
+		return 0, fmt.Errorf("failed to read SMN register 0x%X: %v", address, err)
+	}
+	return value, nil
+}
@@ -0,0 +1,11 @@
+package memory
+
+// canHammer reports whether the hammer test can flush its addresses from
+// the cache, which it needs to reach DRAM on every read
+const canHammer = true
+
+// hammerPair reads a and b reads times each, flushing both cache lines
+// after every read so each read opens the DRAM row again
+//
+//go:noescape
+func hammerPair(a, b *uint64, reads int)
@@ -0,0 +1,21 @@
+#include "textflag.h"
+
+// func hammerPair(a, b *uint64, reads int)
+TEXT ·hammerPair(SB), NOSPLIT, $0-24
+	MOVQ a+0(FP), AX
+	MOVQ b+8(FP), BX
+	MOVQ reads+16(FP), CX
+	TESTQ CX, CX
+	JLE done
+
+loop:
+	MOVQ (AX), DX
+	MOVQ (BX), DX
+	CLFLUSH (AX)
+	CLFLUSH (BX)
+	MFENCE
+	DECQ CX
+	JNZ loop
+
+done:
+	RET
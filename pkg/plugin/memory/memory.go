@@ -4,7 +4,9 @@ package memory
 import (
 	"context"
 	"fmt"
+	"math/bits"
 	"os/exec"
+	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
@@ -12,6 +14,8 @@ import (
 	"time"
 
 	"github.com/mscrnt/project_fire/pkg/plugin"
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+	"github.com/shirou/gopsutil/v3/mem"
 )
 
 func init() {
@@ -33,7 +37,7 @@ func (p *Plugin) Name() string {
 
 // Description returns the plugin description
 func (p *Plugin) Description() string {
-	return "Memory stress test using memtester or native Go implementation"
+	return "Memory pattern test (walking ones/zeros, random inversions, hammer) using memtester or native Go implementation"
 }
 
 // ValidateParams validates the parameters
@@ -48,6 +52,36 @@ func (p *Plugin) ValidateParams(params plugin.Params) error {
 		params.Config["size_mb"] = 1024
 	}
 
+	if m, ok := params.Config["method"].(string); ok {
+		switch m {
+		case "auto", "memtester", "native":
+		default:
+			return fmt.Errorf("invalid method %q (must be auto, memtester or native)", m)
+		}
+	}
+
+	if p, ok := params.Config["pattern"].(string); ok {
+		switch p {
+		case "zero", "random", "sequential":
+		default:
+			return fmt.Errorf("invalid pattern %q (must be zero, random or sequential)", p)
+		}
+	}
+
+	if _, err := parseTests(params.Config); err != nil {
+		return err
+	}
+
+	switch v := params.Config["free_fraction"].(type) {
+	case nil:
+	case float64:
+		if v <= 0 || v > 0.95 {
+			return fmt.Errorf("free_fraction must be above 0 and at most 0.95, got %v", v)
+		}
+	default:
+		return fmt.Errorf("free_fraction must be a number between 0 and 0.95, got %v", v)
+	}
+
 	return nil
 }
 
@@ -55,11 +89,12 @@ func (p *Plugin) ValidateParams(params plugin.Params) error {
 func (p *Plugin) DefaultParams() plugin.Params {
 	return plugin.Params{
 		Duration: 60 * time.Second,
-		Threads:  1,
+		Threads:  runtime.NumCPU(),
 		Config: map[string]interface{}{
 			"method":  "auto",   // auto, memtester, native
-			"size_mb": 1024,     // memory size in MB
-			"pattern": "random", // fill pattern: zero, random, sequential
+			"size_mb": 1024,     // memory size in MB, unless free_fraction is set
+			"pattern": "random", // background for the hammer test: zero, random, sequential
+			"tests":   "all",    // native tests: walking_ones, walking_zeros, random_inversions, hammer
 		},
 	}
 }
@@ -80,6 +115,22 @@ func (p *Plugin) Run(ctx context.Context, params plugin.Params) (plugin.Result,
 		return result, err
 	}
 
+	// Watch for silent downclocking or gear changes while the test runs; the
+	// static SPD/WMI speed does not show them
+	clock, reason := startClockMonitor(ctx)
+	if clock == nil {
+		result.Details["memory_clock_unavailable"] = reason
+	}
+
+	err := p.run(ctx, params, &result)
+	if clock != nil {
+		clock.annotate(&result)
+	}
+	return result, err
+}
+
+// run executes the test with the configured method
+func (p *Plugin) run(ctx context.Context, params plugin.Params, result *plugin.Result) error {
 	// Get method from config
 	method := "auto"
 	if m, ok := params.Config["method"].(string); ok {
@@ -88,21 +139,22 @@ func (p *Plugin) Run(ctx context.Context, params plugin.Params) (plugin.Result,
 
 	// Try memtester first if available
 	if method == "auto" || method == "memtester" {
-		if err := p.runMemtester(ctx, params, &result); err == nil {
-			return result, nil
+		if err := p.runMemtester(ctx, params, result); err == nil {
+			return nil
 		} else if method == "memtester" {
 			// If specifically requested memtester and it failed, return error
 			result.EndTime = time.Now()
 			result.Success = false
 			result.Error = fmt.Sprintf("memtester failed: %v", err)
-			return result, err
+			return err
 		}
 		// Fall back to native implementation
 		result.Details["fallback"] = "memtester not available, using native implementation"
 	}
 
 	// Use native Go implementation
-	return p.runNative(ctx, params, &result)
+	_, err := p.runNative(ctx, params, result)
+	return err
 }
 
 // runMemtester runs the memtester tool
@@ -112,13 +164,9 @@ func (p *Plugin) runMemtester(ctx context.Context, params plugin.Params, result
 		return fmt.Errorf("memtester not found in PATH")
 	}
 
-	// Get memory size
-	sizeMB := 1024
-	switch v := params.Config["size_mb"].(type) {
-	case int:
-		sizeMB = v
-	case float64:
-		sizeMB = int(v)
+	sizeMB, err := testSizeMB(ctx, params.Config)
+	if err != nil {
+		return err
 	}
 
 	// Calculate iterations based on duration
@@ -138,7 +186,7 @@ func (p *Plugin) runMemtester(ctx context.Context, params plugin.Params, result
 	ctx, cancel := context.WithTimeout(ctx, params.Duration+30*time.Second)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "memtester", args...) // #nosec G204 - args are constructed from validated parameters
+	cmd := safeexec.CommandContext(ctx, "memtester", args...)
 
 	// Run command and capture output
 	output, err := cmd.CombinedOutput()
@@ -147,30 +195,52 @@ func (p *Plugin) runMemtester(ctx context.Context, params plugin.Params, result
 	result.EndTime = time.Now()
 	result.Duration = result.EndTime.Sub(result.StartTime)
 
-	if err != nil && ctx.Err() != context.DeadlineExceeded {
+	// memtester exits non-zero when it finds errors; those are results, not
+	// a reason to fall back to the native test
+	errs := p.parseMemtesterMetrics(string(output), result)
+	if err != nil && ctx.Err() != context.DeadlineExceeded && errs.count == 0 {
 		result.Success = false
 		result.Error = err.Error()
 		return err
 	}
 
-	// Parse metrics from output
-	p.parseMemtesterMetrics(string(output), result)
-
 	result.Success = true
 	result.Details["method"] = "memtester"
 	result.Details["command"] = strings.Join(append([]string{"memtester"}, args...), " ")
 	result.Details["size_mb"] = sizeMB
+	if errs.count > 0 {
+		result.Success = false
+		result.Details["bit_errors"] = errs.sorted()
+		result.Error = fmt.Sprintf("%d bit errors in %d words; first: %s", errs.bits, errs.count, errs.sorted()[0])
+	}
 
 	return nil
 }
 
-// parseMemtesterMetrics parses metrics from memtester output
-func (p *Plugin) parseMemtesterMetrics(output string, result *plugin.Result) {
+// memtesterFailure matches a miscompare memtester reports, e.g.
+// "  Compare XOR         : FAILURE: 0x7e4b2a91 != 0x7e4b2a93 at offset 0x0001f3a8."
+var memtesterFailure = regexp.MustCompile(`^\s*([^:]*?)\s*:.*FAILURE: 0x([0-9a-fA-F]+) != 0x([0-9a-fA-F]+) at offset 0x([0-9a-fA-F]+)`)
+
+// parseMemtesterMetrics parses metrics and bit errors from memtester output
+func (p *Plugin) parseMemtesterMetrics(output string, result *plugin.Result) *errorLog {
 	lines := strings.Split(output, "\n")
 	testsRun := 0
 	testsPassed := 0
+	errs := &errorLog{}
 
 	for _, line := range lines {
+		if m := memtesterFailure.FindStringSubmatch(line); m != nil {
+			actual, _ := strconv.ParseUint(m[2], 16, 64)
+			expected, _ := strconv.ParseUint(m[3], 16, 64)
+			offset, _ := strconv.ParseInt(m[4], 16, 64)
+			errs.add(BitError{
+				Test:     m[1],
+				Offset:   offset,
+				Expected: expected,
+				Actual:   actual,
+				Bits:     bits.OnesCount64(actual ^ expected),
+			})
+		}
 		line = strings.TrimSpace(line)
 
 		// Look for test results
@@ -187,17 +257,28 @@ func (p *Plugin) parseMemtesterMetrics(output string, result *plugin.Result) {
 	if testsRun > 0 {
 		result.Metrics["pass_rate"] = float64(testsPassed) / float64(testsRun) * 100
 	}
+	result.Metrics["bit_errors"] = float64(errs.bits)
+	result.Metrics["error_words"] = float64(errs.count)
+	return errs
 }
 
-// runNative runs a native Go memory stress test
+// runNative runs the pattern tests in Go. The memory is split between the
+// workers, and each worker writes, reads back and verifies its share with
+// every test in turn, repeating the tests until the duration is up.
 func (p *Plugin) runNative(ctx context.Context, params plugin.Params, result *plugin.Result) (plugin.Result, error) {
-	// Get memory size
-	sizeMB := 1024
-	switch v := params.Config["size_mb"].(type) {
-	case int:
-		sizeMB = v
-	case float64:
-		sizeMB = int(v)
+	sizeMB, err := testSizeMB(ctx, params.Config)
+	if err != nil {
+		result.EndTime = time.Now()
+		result.Success = false
+		result.Error = err.Error()
+		return *result, err
+	}
+	tests, err := parseTests(params.Config)
+	if err != nil {
+		result.EndTime = time.Now()
+		result.Success = false
+		result.Error = err.Error()
+		return *result, err
 	}
 
 	// Get pattern
@@ -205,133 +286,130 @@ func (p *Plugin) runNative(ctx context.Context, params plugin.Params, result *pl
 	if p, ok := params.Config["pattern"].(string); ok {
 		pattern = p
 	}
+	background := backgroundPattern(pattern)
 
-	// Allocate memory blocks
-	blockSize := 1024 * 1024 // 1MB blocks
-	numBlocks := sizeMB
-	blocks := make([][]byte, numBlocks)
-
-	// Allocate and fill memory
-	var wg sync.WaitGroup
-	errors := make(chan error, numBlocks)
-	allocStart := time.Now()
-
-	for i := 0; i < numBlocks; i++ {
-		wg.Add(1)
-		go func(idx int) {
-			defer wg.Done()
-
-			// Allocate block
-			blocks[idx] = make([]byte, blockSize)
-
-			// Fill block based on pattern
-			switch pattern {
-			case "zero":
-				// Already zero-filled
-			case "sequential":
-				for j := 0; j < blockSize; j++ {
-					blocks[idx][j] = byte(j % 256)
-				}
-			case "random":
-				// Fill with pseudo-random data
-				for j := 0; j < blockSize; j++ {
-					blocks[idx][j] = byte((idx*blockSize + j) * 2654435761 % 256)
-				}
-			}
-		}(i)
+	numWorkers := runtime.NumCPU()
+	if params.Threads > 0 {
+		numWorkers = params.Threads
+	}
+	if numWorkers > sizeMB {
+		numWorkers = sizeMB
 	}
 
-	wg.Wait()
-	close(errors)
-
-	// Check for allocation errors
-	for err := range errors {
-		if err != nil {
-			result.EndTime = time.Now()
-			result.Success = false
-			result.Error = fmt.Sprintf("memory allocation failed: %v", err)
-			return *result, err
+	// Allocate an equal share for each worker
+	allocStart := time.Now()
+	errs := &errorLog{}
+	regions := make([]*region, numWorkers)
+	wordsPerMB := (1 << 20) / 8
+	var offset int64
+	for w := range regions {
+		mb := sizeMB / numWorkers
+		if w < sizeMB%numWorkers {
+			mb++
 		}
+		regions[w] = &region{words: make([]uint64, mb*wordsPerMB), base: offset, log: errs}
+		offset += int64(mb) << 20
 	}
-
 	allocDuration := time.Since(allocStart)
 	result.Metrics["allocation_time_ms"] = float64(allocDuration.Milliseconds())
 	result.Metrics["allocated_mb"] = float64(sizeMB)
 
-	// Perform memory access patterns for remaining duration
-	accessStart := time.Now()
-	accessCount := int64(0)
-	done := make(chan struct{})
-
-	// Start workers to access memory
-	numWorkers := runtime.NumCPU()
-	if params.Threads > 0 {
-		numWorkers = params.Threads
-	}
-
-	for w := 0; w < numWorkers; w++ {
-		go func(workerID int) {
-			for {
-				select {
-				case <-done:
-					return
-				case <-ctx.Done():
-					return
-				default:
-					// Access random blocks
-					blockIdx := int(time.Now().UnixNano()) % numBlocks
-					if blockIdx < len(blocks) && blocks[blockIdx] != nil {
-						// Read and write to trigger memory access
-						sum := 0
-						for i := 0; i < 1024; i++ {
-							idx := (i * 1024) % blockSize
-							sum += int(blocks[blockIdx][idx])
-							blocks[blockIdx][idx] = byte((sum + workerID) % 256)
-						}
-						accessCount++
-					}
+	// Repeat the tests until the duration is up
+	testCtx, cancel := context.WithTimeout(ctx, params.Duration-allocDuration)
+	defer cancel()
+	testStart := time.Now()
+	passes := make([]int, numWorkers)
+	var wg sync.WaitGroup
+	for w, r := range regions {
+		wg.Add(1)
+		go func(w int, r *region) {
+			defer wg.Done()
+			for testCtx.Err() == nil {
+				seed := uint64(passes[w])<<32 | uint64(w)
+				if r.run(testCtx, tests, seed, background) {
+					passes[w]++
 				}
 			}
-		}(w)
+		}(w, r)
 	}
-
-	// Wait for duration or context cancellation
-	remaining := params.Duration - allocDuration
-	if remaining > 0 {
-		select {
-		case <-time.After(remaining):
-		case <-ctx.Done():
-		}
-	}
-
-	close(done)
-	time.Sleep(100 * time.Millisecond) // Allow workers to finish
-
-	accessDuration := time.Since(accessStart)
+	wg.Wait()
+	testDuration := time.Since(testStart)
 
 	result.EndTime = time.Now()
 	result.Duration = result.EndTime.Sub(result.StartTime)
 
-	// Calculate metrics
-	result.Metrics["access_operations"] = float64(accessCount)
-	result.Metrics["access_rate_ops_per_sec"] = float64(accessCount) / accessDuration.Seconds()
-	result.Metrics["bandwidth_mb_per_sec"] = (float64(accessCount) * 1024 * 1024) / (accessDuration.Seconds() * 1024 * 1024)
-
-	// Memory stats
-	var m runtime.MemStats
-	runtime.ReadMemStats(&m)
-	result.Metrics["heap_alloc_mb"] = float64(m.HeapAlloc) / 1024 / 1024
-	result.Metrics["sys_memory_mb"] = float64(m.Sys) / 1024 / 1024
+	// A pass counts once every worker completed it
+	completed := passes[0]
+	var verified int64
+	for w, r := range regions {
+		if passes[w] < completed {
+			completed = passes[w]
+		}
+		verified += r.bytes
+	}
+	result.Metrics["passes"] = float64(completed)
+	result.Metrics["verified_gb"] = float64(verified) / (1 << 30)
+	result.Metrics["bandwidth_mb_per_sec"] = float64(verified) / (1 << 20) / testDuration.Seconds()
+	result.Metrics["bit_errors"] = float64(errs.bits)
+	result.Metrics["error_words"] = float64(errs.count)
 
-	result.Success = true
 	result.Details["method"] = "native"
+	result.Details["tests"] = strings.Join(tests, ",")
 	result.Details["pattern"] = pattern
 	result.Details["workers"] = numWorkers
-	result.Details["blocks"] = numBlocks
+	if recorded := errs.sorted(); len(recorded) > 0 {
+		result.Details["bit_errors"] = recorded
+	}
 
+	if ctx.Err() != nil {
+		result.Success = false
+		result.Error = ctx.Err().Error()
+		return *result, ctx.Err()
+	}
+	if errs.count > 0 {
+		result.Success = false
+		result.Error = fmt.Sprintf("%d bit errors in %d words; first: %s", errs.bits, errs.count, errs.sorted()[0])
+		return *result, nil
+	}
+	result.Success = true
 	return *result, nil
 }
 
+// testSizeMB returns the memory to test: free_fraction of the available
+// memory when given, otherwise size_mb
+func testSizeMB(ctx context.Context, config map[string]interface{}) (int, error) {
+	fraction := 0.0
+	switch v := config["free_fraction"].(type) {
+	case float64:
+		fraction = v
+	case int:
+		fraction = float64(v)
+	}
+	if fraction > 0 {
+		vm, err := mem.VirtualMemoryWithContext(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read free memory: %w", err)
+		}
+		mb := int(float64(vm.Available) * fraction / (1 << 20))
+		if mb < 1 {
+			return 0, fmt.Errorf("%.0f%% of the %d MB available is less than 1 MB", fraction*100, vm.Available>>20)
+		}
+		return mb, nil
+	}
+
+	sizeMB := 1024
+	switch v := config["size_mb"].(type) {
+	case int:
+		sizeMB = v
+	case float64:
+		sizeMB = int(v)
+	}
+	if sizeMB < 1 {
+		return 0, fmt.Errorf("size_mb must be at least 1, got %d", sizeMB)
+	}
+	return sizeMB, nil
+}
+
 // Info returns detailed plugin information
 func (p *Plugin) Info() plugin.Info {
 	return plugin.Info{
@@ -370,22 +448,52 @@ func (p *Plugin) Info() plugin.Info {
 				Description: "Time taken to allocate memory",
 			},
 			{
-				Name:        "access_operations",
+				Name:        "passes",
 				Type:        plugin.MetricTypeCounter,
-				Unit:        "operations",
-				Description: "Number of memory access operations",
+				Unit:        "passes",
+				Description: "Complete passes of every pattern test over the tested memory (native)",
 			},
 			{
-				Name:        "access_rate_ops_per_sec",
-				Type:        plugin.MetricTypeThroughput,
-				Unit:        "ops/s",
-				Description: "Memory access operations per second",
+				Name:        "verified_gb",
+				Type:        plugin.MetricTypeCounter,
+				Unit:        "GB",
+				Description: "Memory written and read back by the pattern tests (native)",
 			},
 			{
 				Name:        "bandwidth_mb_per_sec",
 				Type:        plugin.MetricTypeThroughput,
 				Unit:        "MB/s",
-				Description: "Estimated memory bandwidth",
+				Description: "Rate at which the pattern tests wrote and verified memory (native)",
+			},
+			{
+				Name:        "bit_errors",
+				Type:        plugin.MetricTypeCounter,
+				Unit:        "bits",
+				Description: "Bits that read back differently than written; their addresses are in the details",
+			},
+			{
+				Name:        "error_words",
+				Type:        plugin.MetricTypeCounter,
+				Unit:        "words",
+				Description: "64-bit words with at least one bit error",
+			},
+			{
+				Name:        "memory_clock_start_mts",
+				Type:        plugin.MetricTypeGauge,
+				Unit:        "MT/s",
+				Description: "Effective memory data rate when the run started (AMD Zen 1-3, root/Administrator)",
+			},
+			{
+				Name:        "memory_clock_min_mts",
+				Type:        plugin.MetricTypeGauge,
+				Unit:        "MT/s",
+				Description: "Lowest effective memory data rate seen during the run",
+			},
+			{
+				Name:        "memory_clock_changes",
+				Type:        plugin.MetricTypeCounter,
+				Unit:        "changes",
+				Description: "Memory frequency or gear-down changes during the run",
 			},
 		},
 		Parameters: []plugin.ParamInfo{
@@ -403,11 +511,25 @@ func (p *Plugin) Info() plugin.Info {
 				Description: "Amount of memory to test in MB",
 				Required:    false,
 			},
+			{
+				Name:        "free_fraction",
+				Type:        "float",
+				Default:     0,
+				Description: "Fraction of the available memory to test, e.g. 0.8; overrides size_mb when set",
+				Required:    false,
+			},
+			{
+				Name:        "tests",
+				Type:        "string",
+				Default:     "all",
+				Description: "Native pattern tests, comma-separated: walking_ones, walking_zeros, random_inversions, hammer (amd64 only), or all",
+				Required:    false,
+			},
 			{
 				Name:        "pattern",
 				Type:        "string",
 				Default:     "random",
-				Description: "Memory fill pattern: zero, random, or sequential",
+				Description: "Background the hammer test checks for disturbance: zero, random, or sequential",
 				Required:    false,
 			},
 			{
@@ -0,0 +1,225 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"sort"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// Pattern tests the native method runs, in order
+const (
+	TestWalkingOnes      = "walking_ones"
+	TestWalkingZeros     = "walking_zeros"
+	TestRandomInversions = "random_inversions"
+	TestHammer           = "hammer"
+)
+
+// allTests lists every pattern test in the order they run. The hammer test
+// needs to flush the cache, which it can only do on amd64.
+var allTests = func() []string {
+	tests := []string{TestWalkingOnes, TestWalkingZeros, TestRandomInversions}
+	if canHammer {
+		tests = append(tests, TestHammer)
+	}
+	return tests
+}()
+
+const (
+	// maxRecordedErrors is how many bit errors are kept with their
+	// addresses; every error is counted
+	maxRecordedErrors = 100
+	// hammerStride is the distance in words between the two addresses the
+	// hammer test alternates between: 8 KiB, a common DRAM row size
+	hammerStride = 8 << 10 / 8
+	// hammerReads is how often each pair of addresses is read: about as
+	// many row activations as fit in one 64 ms refresh window
+	hammerReads = 1 << 17
+	// hammerPairs is how many pairs each pass hammers; the pairs move with
+	// the seed, so repeated passes cover the region
+	hammerPairs = 8
+)
+
+// BitError is a word that read back differently than written
+type BitError struct {
+	Test     string  `json:"test"`
+	Address  uintptr `json:"address"` // Virtual address of the word
+	Offset   int64   `json:"offset"`  // Byte offset in the tested memory
+	Expected uint64  `json:"expected"`
+	Actual   uint64  `json:"actual"`
+	Bits     int     `json:"bits"` // Bits that differ
+}
+
+func (e BitError) String() string {
+	return fmt.Sprintf("%s: 0x%016x != 0x%016x at offset 0x%x (address 0x%x, %d bits)",
+		e.Test, e.Actual, e.Expected, e.Offset, e.Address, e.Bits)
+}
+
+// errorLog collects the bit errors of every worker
+type errorLog struct {
+	mu       sync.Mutex
+	count    int64 // Words that read back wrong
+	bits     int64 // Bits that read back wrong
+	recorded []BitError
+}
+
+func (l *errorLog) add(e BitError) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.count++
+	l.bits += int64(e.Bits)
+	if len(l.recorded) < maxRecordedErrors {
+		l.recorded = append(l.recorded, e)
+	}
+}
+
+// sorted returns the recorded errors in address order
+func (l *errorLog) sorted() []BitError {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := append([]BitError(nil), l.recorded...)
+	sort.Slice(out, func(i, j int) bool { return out[i].Offset < out[j].Offset })
+	return out
+}
+
+// parseTests reads the "tests" config: a comma-separated list, or "all"
+func parseTests(config map[string]interface{}) ([]string, error) {
+	v, ok := config["tests"].(string)
+	if !ok || v == "" || v == "all" {
+		return allTests, nil
+	}
+	var tests []string
+	for _, name := range strings.Split(v, ",") {
+		name = strings.TrimSpace(name)
+		known := false
+		for _, t := range allTests {
+			known = known || t == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown test %q (must be %s or all)", name, strings.Join(allTests, ", "))
+		}
+		tests = append(tests, name)
+	}
+	return tests, nil
+}
+
+// corrupt lets tests flip bits after a pattern is written; nil otherwise
+var corrupt func(test string, words []uint64)
+
+// region is the memory one worker tests
+type region struct {
+	words []uint64
+	base  int64 // Byte offset of the region in the tested memory
+	log   *errorLog
+
+	bytes int64 // Bytes written and read back
+}
+
+// verify compares every word with want(i) and logs the mismatches
+func (r *region) verify(test string, want func(i int) uint64) {
+	if corrupt != nil {
+		corrupt(test, r.words)
+	}
+	for i, actual := range r.words {
+		expected := want(i)
+		if actual != expected {
+			r.log.add(BitError{
+				Test:     test,
+				Address:  uintptr(unsafe.Pointer(&r.words[i])), // #nosec G103 -- the address is only reported
+				Offset:   r.base + int64(i)*8,
+				Expected: expected,
+				Actual:   actual,
+				Bits:     bits.OnesCount64(actual ^ expected),
+			})
+		}
+	}
+	r.bytes += int64(len(r.words)) * 16 // Written, then read
+}
+
+// fill writes pattern(i) to every word and reads it back
+func (r *region) fill(test string, pattern func(i int) uint64) {
+	for i := range r.words {
+		r.words[i] = pattern(i)
+	}
+	r.verify(test, pattern)
+}
+
+// run runs the tests once, stopping between passes when ctx ends. It
+// reports whether every test completed.
+func (r *region) run(ctx context.Context, tests []string, seed uint64, background func(i int) uint64) bool {
+	for _, test := range tests {
+		switch test {
+		case TestWalkingOnes, TestWalkingZeros:
+			// One pass per bit: a single bit set (or cleared) in every word
+			for b := 0; b < 64; b++ {
+				if ctx.Err() != nil {
+					return false
+				}
+				v := uint64(1) << b
+				if test == TestWalkingZeros {
+					v = ^v
+				}
+				r.fill(test, func(int) uint64 { return v })
+			}
+
+		case TestRandomInversions:
+			// Random values, then the same values inverted
+			random := func(i int) uint64 { return mix(seed + uint64(i)) }
+			if ctx.Err() != nil {
+				return false
+			}
+			r.fill(test, random)
+			for i := range r.words {
+				r.words[i] = ^r.words[i]
+			}
+			r.verify(test, func(i int) uint64 { return ^random(i) })
+
+		case TestHammer:
+			// Alternate between addresses a row apart many times, flushing
+			// them from the cache so every read reaches DRAM, then check the
+			// words around them kept their background pattern
+			if ctx.Err() != nil {
+				return false
+			}
+			for i := range r.words {
+				r.words[i] = background(i)
+			}
+			if span := len(r.words) - hammerStride; span > 0 {
+				for p := 0; p < hammerPairs; p++ {
+					if ctx.Err() != nil {
+						return false
+					}
+					a := int(mix(seed+uint64(p)) % uint64(span)) // #nosec G115 -- span is positive
+					hammerPair(&r.words[a], &r.words[a+hammerStride], hammerReads)
+				}
+			}
+			r.verify(test, background)
+		}
+	}
+	return true
+}
+
+// mix is the SplitMix64 finalizer: a fast, repeatable pseudo-random value
+// for each word
+func mix(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// backgroundPattern returns the fill for the configured pattern, which the
+// hammer test checks its victims against
+func backgroundPattern(pattern string) func(i int) uint64 {
+	switch pattern {
+	case "zero":
+		return func(int) uint64 { return 0 }
+	case "sequential":
+		return func(i int) uint64 { return uint64(i) }
+	default:
+		return func(i int) uint64 { return mix(uint64(i) ^ 0x5555555555555555) }
+	}
+}
@@ -0,0 +1,139 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/plugin"
+)
+
+func nativeParams(config map[string]interface{}) plugin.Params {
+	config["method"] = "native"
+	if _, ok := config["size_mb"]; !ok {
+		config["size_mb"] = 4
+	}
+	return plugin.Params{Duration: 200 * time.Millisecond, Threads: 2, Config: config}
+}
+
+func TestNativePatterns(t *testing.T) {
+	p := &Plugin{}
+	params := nativeParams(map[string]interface{}{"size_mb": 1})
+	params.Duration = time.Second
+	result, err := p.Run(context.Background(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Success {
+		t.Fatalf("expected healthy memory to pass, got %s", result.Error)
+	}
+	if result.Metrics["passes"] < 1 || result.Metrics["bit_errors"] != 0 || result.Metrics["verified_gb"] <= 0 {
+		t.Errorf("unexpected metrics %v", result.Metrics)
+	}
+	if result.Details["tests"] != strings.Join(allTests, ",") {
+		t.Errorf("expected every test to run, got %v", result.Details["tests"])
+	}
+}
+
+func TestNativePatternsFindBitErrors(t *testing.T) {
+	// Bit 3 of the 100th word of every region sticks at 0
+	corrupt = func(test string, words []uint64) {
+		words[100] &^= 1 << 3
+	}
+	defer func() { corrupt = nil }()
+
+	p := &Plugin{}
+	result, err := p.Run(context.Background(), nativeParams(map[string]interface{}{"tests": "walking_ones,random_inversions"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success {
+		t.Fatal("expected bit errors to fail the run")
+	}
+	if result.Metrics["bit_errors"] == 0 || result.Metrics["error_words"] != result.Metrics["bit_errors"] {
+		t.Errorf("expected single-bit errors to be counted, got %v", result.Metrics)
+	}
+
+	errs, ok := result.Details["bit_errors"].([]BitError)
+	if !ok || len(errs) == 0 {
+		t.Fatalf("expected the bit errors in the details, got %#v", result.Details["bit_errors"])
+	}
+	e := errs[0]
+	if e.Offset != 800 || e.Bits != 1 || e.Expected^e.Actual != 1<<3 || e.Address == 0 {
+		t.Errorf("unexpected first error %+v", e)
+	}
+	if e.Test != TestWalkingOnes || !strings.Contains(result.Error, "at offset 0x320") {
+		t.Errorf("expected the error to name the test and offset, got %q", result.Error)
+	}
+	if len(errs) > maxRecordedErrors {
+		t.Errorf("expected at most %d recorded errors, got %d", maxRecordedErrors, len(errs))
+	}
+}
+
+func TestNativeHammer(t *testing.T) {
+	if !canHammer {
+		t.Skip("the hammer test needs CLFLUSH")
+	}
+	// A corrupted victim word is found after the hammer reads
+	corrupt = func(test string, words []uint64) {
+		words[hammerStride/2] ^= 1
+	}
+	defer func() { corrupt = nil }()
+
+	p := &Plugin{}
+	params := nativeParams(map[string]interface{}{"tests": "hammer", "size_mb": 1})
+	params.Duration = time.Second
+	result, err := p.Run(context.Background(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success || result.Metrics["passes"] < 1 {
+		t.Fatalf("expected the hammer test to check its victims, got %v", result.Metrics)
+	}
+	if errs, ok := result.Details["bit_errors"].([]BitError); !ok || errs[0].Test != TestHammer {
+		t.Errorf("expected a hammer error, got %#v", result.Details["bit_errors"])
+	}
+}
+
+func TestParseMemtesterFailures(t *testing.T) {
+	output := `memtester version 4.5.1 (64-bit)
+  Stuck Address       : ok
+  Random Value        : ok
+  Compare XOR         : FAILURE: 0x7e4b2a91 != 0x7e4b2a93 at offset 0x0001f3a8.
+  Compare SUB         : ok
+`
+	result := plugin.Result{Metrics: make(map[string]float64)}
+	errs := (&Plugin{}).parseMemtesterMetrics(output, &result)
+	if errs.count != 1 || result.Metrics["bit_errors"] != 1 {
+		t.Fatalf("expected one bit error, got %v", result.Metrics)
+	}
+	e := errs.sorted()[0]
+	if e.Test != "Compare XOR" || e.Offset != 0x1f3a8 || e.Actual != 0x7e4b2a91 || e.Expected != 0x7e4b2a93 {
+		t.Errorf("unexpected error %+v", e)
+	}
+}
+
+func TestValidatePatternParams(t *testing.T) {
+	p := &Plugin{}
+	for _, config := range []map[string]interface{}{
+		{"tests": "walking_ones,checkerboard"},
+		{"free_fraction": 1.5},
+		{"free_fraction": "half"},
+	} {
+		params := p.DefaultParams()
+		for k, v := range config {
+			params.Config[k] = v
+		}
+		if err := p.ValidateParams(params); err == nil {
+			t.Errorf("expected %v to be refused", config)
+		}
+	}
+
+	params := p.DefaultParams()
+	params.Config["free_fraction"] = 0.5
+	params.Config["tests"] = "walking_ones,random_inversions"
+	if err := p.ValidateParams(params); err != nil {
+		t.Errorf("expected valid params, got %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// iperfOptions configures an iperf3 client run
+type iperfOptions struct {
+	target    string
+	port      int
+	duration  time.Duration
+	streams   int
+	udp       bool
+	bandwidth string
+}
+
+// iperfReport is the part of iperf3's JSON output that the plugin reads
+type iperfReport struct {
+	Error string `json:"error"`
+	End   struct {
+		Streams []struct {
+			Sender struct {
+				MeanRTT float64 `json:"mean_rtt"` // microseconds
+				MaxRTT  float64 `json:"max_rtt"`
+			} `json:"sender"`
+		} `json:"streams"`
+		SumSent struct {
+			Bytes         float64 `json:"bytes"`
+			BitsPerSecond float64 `json:"bits_per_second"`
+			Retransmits   *int    `json:"retransmits"`
+		} `json:"sum_sent"`
+		SumReceived struct {
+			Bytes         float64 `json:"bytes"`
+			BitsPerSecond float64 `json:"bits_per_second"`
+		} `json:"sum_received"`
+		Sum struct {
+			Bytes         float64 `json:"bytes"`
+			BitsPerSecond float64 `json:"bits_per_second"`
+			JitterMs      float64 `json:"jitter_ms"`
+			LostPercent   float64 `json:"lost_percent"`
+		} `json:"sum"`
+	} `json:"end"`
+}
+
+// runIperf runs the iperf3 client against a peer running iperf3 -s
+func runIperf(ctx context.Context, opts iperfOptions) (Stats, error) {
+	seconds := int(opts.duration.Round(time.Second) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	args := []string{
+		"-c", opts.target,
+		"-p", strconv.Itoa(opts.port),
+		"-t", strconv.Itoa(seconds),
+		"-P", strconv.Itoa(opts.streams),
+		"-J",
+	}
+	if opts.udp {
+		args = append(args, "-u")
+	}
+	if opts.bandwidth != "" {
+		args = append(args, "-b", opts.bandwidth)
+	}
+
+	// iperf3 exits non-zero on failure but still explains why in its JSON output
+	output, runErr := safeexec.CommandContext(ctx, "iperf3", args...).Output()
+	if ctx.Err() != nil {
+		return Stats{}, ctx.Err()
+	}
+	if len(output) == 0 && runErr != nil {
+		return Stats{}, fmt.Errorf("iperf3 failed (is it installed?): %w", runErr)
+	}
+	stats, err := parseIperf(output, opts.udp)
+	if err != nil {
+		return Stats{}, err
+	}
+	return stats, nil
+}
+
+// parseIperf reads the measurements from iperf3 -J output
+func parseIperf(output []byte, udp bool) (Stats, error) {
+	var report iperfReport
+	if err := json.Unmarshal(output, &report); err != nil {
+		return Stats{}, fmt.Errorf("failed to parse iperf3 output: %w", err)
+	}
+	if report.Error != "" {
+		return Stats{}, errors.New("iperf3: " + report.Error)
+	}
+
+	end := report.End
+	if udp {
+		return Stats{
+			BandwidthMbps: end.Sum.BitsPerSecond / 1e6,
+			TransferredMB: end.Sum.Bytes / (1024 * 1024),
+			JitterMs:      end.Sum.JitterMs,
+			PacketLossPct: end.Sum.LostPercent,
+			HasLoss:       true,
+		}, nil
+	}
+
+	stats := Stats{
+		BandwidthMbps: end.SumReceived.BitsPerSecond / 1e6,
+		TransferredMB: end.SumReceived.Bytes / (1024 * 1024),
+	}
+	if end.SumSent.Retransmits != nil {
+		stats.Retransmits = float64(*end.SumSent.Retransmits)
+		stats.HasRetransmits = true
+	}
+	// RTTs are reported per stream by the sender on Linux only
+	var sum float64
+	var count int
+	for _, stream := range end.Streams {
+		if stream.Sender.MeanRTT <= 0 {
+			continue
+		}
+		sum += stream.Sender.MeanRTT
+		count++
+		if maxMs := stream.Sender.MaxRTT / 1000; maxMs > stats.LatencyMaxMs {
+			stats.LatencyMaxMs = maxMs
+		}
+	}
+	if count > 0 {
+		stats.LatencyMs = sum / float64(count) / 1000
+		stats.HasRTT = true
+	}
+	return stats, nil
+}
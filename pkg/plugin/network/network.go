@@ -0,0 +1,321 @@
+// Package network provides a network stress test plugin for FIRE.
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/plugin"
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+func init() {
+	// Register the network stress plugin
+	if err := plugin.Register(&Plugin{}); err != nil {
+		// Since init() can't return an error, we panic on registration failure
+		// This is acceptable because plugin registration is a critical startup operation
+		panic(fmt.Sprintf("failed to register network plugin: %v", err))
+	}
+}
+
+// Default ports: iperf3's own, and the one used between two F.I.R.E. machines
+const (
+	defaultIperfPort     = 5201
+	defaultSyntheticPort = 5202
+)
+
+// bandwidthPattern matches iperf3 -b values such as 500M or 1.5G
+var bandwidthPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?[KMGkmg]?$`)
+
+// Plugin implements a network throughput, latency and packet loss test
+type Plugin struct{}
+
+// Name returns the plugin name
+func (p *Plugin) Name() string {
+	return "network"
+}
+
+// Description returns the plugin description
+func (p *Plugin) Description() string {
+	return "Network stress test measuring bandwidth, latency and packet loss with synthetic traffic or iperf3"
+}
+
+// ValidateParams validates the parameters
+func (p *Plugin) ValidateParams(params plugin.Params) error {
+	if params.Duration <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+	if params.Threads < 0 || params.Threads > 128 {
+		return fmt.Errorf("threads must be between 1 and 128")
+	}
+
+	mode := configString(params.Config, "mode", "synthetic")
+	target := configString(params.Config, "target", "")
+	switch mode {
+	case "synthetic", "server":
+	case "iperf3":
+		if target == "" {
+			return fmt.Errorf("iperf3 mode needs a target running iperf3 -s")
+		}
+	default:
+		return fmt.Errorf("invalid mode %q (must be synthetic, iperf3 or server)", mode)
+	}
+	if target != "" {
+		if err := safeexec.ValidateIdentifier(target); err != nil {
+			return fmt.Errorf("invalid target: %w", err)
+		}
+	}
+
+	if port := configInt(params.Config, "port", 0); port < 0 || port > 65535 {
+		return fmt.Errorf("port must be between 1 and 65535")
+	}
+	if interval := configInt(params.Config, "probe_interval_ms", 10); interval <= 0 {
+		return fmt.Errorf("probe_interval_ms must be positive")
+	}
+
+	switch configString(params.Config, "protocol", "tcp") {
+	case "tcp", "udp":
+	default:
+		return fmt.Errorf("protocol must be tcp or udp")
+	}
+	if bw := configString(params.Config, "bandwidth", ""); bw != "" && !bandwidthPattern.MatchString(bw) {
+		return fmt.Errorf("invalid bandwidth %q, expected e.g. 500M or 1G", bw)
+	}
+
+	return nil
+}
+
+// DefaultParams returns default parameters
+func (p *Plugin) DefaultParams() plugin.Params {
+	return plugin.Params{
+		Duration: 30 * time.Second,
+		Threads:  4, // parallel TCP streams
+		Config: map[string]interface{}{
+			"mode":              "synthetic", // synthetic, iperf3, server
+			"target":            "",          // peer host; empty tests the local stack over loopback
+			"probe_interval_ms": 10,          // UDP latency probe interval
+			"protocol":          "tcp",       // iperf3 only: tcp, udp
+		},
+	}
+}
+
+// Run executes the network test
+func (p *Plugin) Run(ctx context.Context, params plugin.Params) (plugin.Result, error) {
+	result := plugin.Result{
+		StartTime: time.Now(),
+		Metrics:   make(map[string]float64),
+		Details:   make(map[string]interface{}),
+	}
+
+	fail := func(err error) (plugin.Result, error) {
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(result.StartTime)
+		result.Success = false
+		result.Error = err.Error()
+		return result, err
+	}
+
+	if err := p.ValidateParams(params); err != nil {
+		return fail(err)
+	}
+
+	mode := configString(params.Config, "mode", "synthetic")
+	target := configString(params.Config, "target", "")
+	streams := params.Threads
+	if streams <= 0 {
+		streams = 4
+	}
+	result.Details["mode"] = mode
+	result.Details["streams"] = streams
+
+	var (
+		stats Stats
+		err   error
+	)
+	switch mode {
+	case "server":
+		port := configInt(params.Config, "port", defaultSyntheticPort)
+		var srv *server
+		srv, err = listen(net.JoinHostPort("", strconv.Itoa(port)))
+		if err != nil {
+			return fail(err)
+		}
+		result.Details["listen"] = srv.tcpAddr()
+		stats = srv.serve(ctx, params.Duration)
+
+	case "iperf3":
+		opts := iperfOptions{
+			target:    target,
+			port:      configInt(params.Config, "port", defaultIperfPort),
+			duration:  params.Duration,
+			streams:   streams,
+			udp:       configString(params.Config, "protocol", "tcp") == "udp",
+			bandwidth: configString(params.Config, "bandwidth", ""),
+		}
+		stats, err = runIperf(ctx, opts)
+		result.Details["target"] = target
+		result.Details["protocol"] = configString(params.Config, "protocol", "tcp")
+
+	default:
+		tcpAddr, udpAddr := "", ""
+		if target == "" {
+			// Loopback self-test: exercises the network stack, not the NIC
+			srv, lerr := listen("127.0.0.1:0")
+			if lerr != nil {
+				return fail(lerr)
+			}
+			serveCtx, stop := context.WithCancel(ctx)
+			defer stop()
+			go srv.serve(serveCtx, params.Duration+probeGrace+time.Second)
+			tcpAddr, udpAddr = srv.tcpAddr(), srv.udpAddr()
+			result.Details["target"] = "loopback"
+		} else {
+			addr := net.JoinHostPort(target, strconv.Itoa(configInt(params.Config, "port", defaultSyntheticPort)))
+			tcpAddr, udpAddr = addr, addr
+			result.Details["target"] = target
+		}
+		interval := time.Duration(configInt(params.Config, "probe_interval_ms", 10)) * time.Millisecond
+		stats, err = runSynthetic(ctx, tcpAddr, udpAddr, streams, params.Duration, interval)
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	stats.addMetrics(result.Metrics)
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(result.StartTime)
+	result.Success = true
+	if mode != "server" && stats.BandwidthMbps <= 0 {
+		result.Success = false
+		result.Error = "no data was transferred"
+	}
+	return result, nil
+}
+
+// configString reads a string config value. Numbers are accepted too, since
+// the CLI decodes --config bandwidth=1000 as one.
+func configString(config map[string]interface{}, key, def string) string {
+	switch v := config[key].(type) {
+	case string:
+		if v != "" {
+			return v
+		}
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return def
+}
+
+// configInt reads an integer config value that may have been decoded as float64
+func configInt(config map[string]interface{}, key string, def int) int {
+	switch v := config[key].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	}
+	return def
+}
+
+// Info returns detailed plugin information
+func (p *Plugin) Info() plugin.Info {
+	return plugin.Info{
+		Name:        p.Name(),
+		Description: p.Description(),
+		Category:    "stress",
+		Metrics: []plugin.MetricInfo{
+			{
+				Name:        "bandwidth_mbps",
+				Type:        plugin.MetricTypeThroughput,
+				Unit:        "Mbit/s",
+				Description: "Throughput received by the peer",
+			},
+			{
+				Name:        "transferred_mb",
+				Type:        plugin.MetricTypeCounter,
+				Unit:        "MB",
+				Description: "Data transferred during the test",
+			},
+			{
+				Name:        "latency_ms",
+				Type:        plugin.MetricTypeLatency,
+				Unit:        "ms",
+				Description: "Average round-trip time under load",
+			},
+			{
+				Name:        "latency_max_ms",
+				Type:        plugin.MetricTypeLatency,
+				Unit:        "ms",
+				Description: "Highest round-trip time under load",
+			},
+			{
+				Name:        "jitter_ms",
+				Type:        plugin.MetricTypeLatency,
+				Unit:        "ms",
+				Description: "Average variation between consecutive round-trip times (iperf3 udp: one-way jitter)",
+			},
+			{
+				Name:        "packet_loss_pct",
+				Type:        plugin.MetricTypeGauge,
+				Unit:        "%",
+				Description: "Probe or datagram packets lost",
+			},
+			{
+				Name:        "retransmits",
+				Type:        plugin.MetricTypeCounter,
+				Unit:        "segments",
+				Description: "TCP retransmissions (iperf3 tcp on Linux)",
+			},
+		},
+		Parameters: []plugin.ParamInfo{
+			{
+				Name:        "mode",
+				Type:        "string",
+				Default:     "synthetic",
+				Description: "synthetic (built-in traffic to a peer in server mode), iperf3 (needs iperf3 -s on the peer) or server",
+				Required:    false,
+			},
+			{
+				Name:        "target",
+				Type:        "string",
+				Default:     "",
+				Description: "Peer host name or address; empty runs a loopback self-test",
+				Required:    false,
+			},
+			{
+				Name:        "port",
+				Type:        "integer",
+				Default:     defaultSyntheticPort,
+				Description: "Peer port (5202 for synthetic and server, 5201 for iperf3)",
+				Required:    false,
+			},
+			{
+				Name:        "probe_interval_ms",
+				Type:        "integer",
+				Default:     10,
+				Description: "Interval between UDP latency probes in synthetic mode",
+				Required:    false,
+			},
+			{
+				Name:        "protocol",
+				Type:        "string",
+				Default:     "tcp",
+				Description: "iperf3 protocol: tcp, or udp for jitter and packet loss",
+				Required:    false,
+			},
+			{
+				Name:        "bandwidth",
+				Type:        "string",
+				Default:     "",
+				Description: "iperf3 target bandwidth, e.g. 1G (iperf3 defaults to 1M for udp)",
+				Required:    false,
+			},
+		},
+	}
+}
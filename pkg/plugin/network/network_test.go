@@ -0,0 +1,95 @@
+package network
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/plugin"
+)
+
+func TestLoopbackRun(t *testing.T) {
+	p := &Plugin{}
+	params := p.DefaultParams()
+	params.Duration = 500 * time.Millisecond
+	params.Threads = 2
+
+	result, err := p.Run(context.Background(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Success {
+		t.Fatalf("expected a successful run, got %q", result.Error)
+	}
+	for _, metric := range []string{"bandwidth_mbps", "transferred_mb", "latency_ms", "jitter_ms", "packet_loss_pct"} {
+		if _, ok := result.Metrics[metric]; !ok {
+			t.Errorf("expected metric %s, got %v", metric, result.Metrics)
+		}
+	}
+	if result.Metrics["bandwidth_mbps"] <= 0 {
+		t.Errorf("expected traffic over loopback, got %v", result.Metrics)
+	}
+}
+
+func TestValidateParams(t *testing.T) {
+	p := &Plugin{}
+	tests := []struct {
+		config map[string]interface{}
+		valid  bool
+	}{
+		{map[string]interface{}{}, true},
+		{map[string]interface{}{"mode": "iperf3", "target": "10.0.0.2", "protocol": "udp", "bandwidth": "1G"}, true},
+		{map[string]interface{}{"mode": "iperf3"}, false},
+		{map[string]interface{}{"mode": "flood"}, false},
+		{map[string]interface{}{"target": "host; rm -rf /"}, false},
+		{map[string]interface{}{"port": 70000}, false},
+		{map[string]interface{}{"bandwidth": "fast"}, false},
+	}
+	for _, tt := range tests {
+		params := plugin.Params{Duration: time.Second, Threads: 1, Config: tt.config}
+		if err := p.ValidateParams(params); (err == nil) != tt.valid {
+			t.Errorf("config %v: expected valid=%v, got %v", tt.config, tt.valid, err)
+		}
+	}
+}
+
+func TestParseIperf(t *testing.T) {
+	tcp := []byte(`{"end": {
+		"streams": [{"sender": {"mean_rtt": 400, "max_rtt": 900}}, {"sender": {"mean_rtt": 600, "max_rtt": 1500}}],
+		"sum_sent": {"bytes": 1258291200, "bits_per_second": 1010000000, "retransmits": 12},
+		"sum_received": {"bytes": 1248854016, "bits_per_second": 998000000}
+	}}`)
+	stats, err := parseIperf(tcp, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.BandwidthMbps != 998 || stats.Retransmits != 12 || stats.LatencyMs != 0.5 || stats.LatencyMaxMs != 1.5 {
+		t.Errorf("unexpected tcp stats %+v", stats)
+	}
+
+	udp := []byte(`{"end": {"sum": {"bytes": 1310720, "bits_per_second": 1048576, "jitter_ms": 0.25, "lost_percent": 2.5}}}`)
+	stats, err = parseIperf(udp, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.TransferredMB != 1.25 || stats.JitterMs != 0.25 || stats.PacketLossPct != 2.5 || stats.HasRTT {
+		t.Errorf("unexpected udp stats %+v", stats)
+	}
+
+	if _, err := parseIperf([]byte(`{"error": "unable to connect to server"}`), false); err == nil {
+		t.Error("expected the iperf3 error to be returned")
+	}
+}
+
+func TestLatencyStats(t *testing.T) {
+	avg, maxRTT, jitter := latencyStats([]time.Duration{time.Millisecond, 3 * time.Millisecond, 2 * time.Millisecond})
+	if avg != 2 || maxRTT != 3 || jitter != 1.5 {
+		t.Errorf("expected avg 2, max 3, jitter 1.5, got %v %v %v", avg, maxRTT, jitter)
+	}
+}
+
+func TestRegistered(t *testing.T) {
+	if _, err := plugin.Get("network"); err != nil {
+		t.Errorf("expected the network plugin to be registered: %v", err)
+	}
+}
@@ -0,0 +1,312 @@
+package network
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// writeBufferSize is the size of each write on a TCP stream
+	writeBufferSize = 128 * 1024
+
+	// probeSize is the size of a UDP latency probe: sequence number and send offset
+	probeSize = 16
+
+	// probeGrace is how long late probe replies are still accepted after the
+	// traffic stops
+	probeGrace = time.Second
+)
+
+// Stats holds the measurements of a network test
+type Stats struct {
+	BandwidthMbps  float64
+	TransferredMB  float64
+	LatencyMs      float64
+	LatencyMaxMs   float64
+	JitterMs       float64
+	PacketLossPct  float64
+	Retransmits    float64
+	HasRTT         bool // Round-trip times were measured
+	HasLoss        bool // Jitter and packet loss were measured
+	HasRetransmits bool
+}
+
+// addMetrics copies the measured values into a result's metrics
+func (s Stats) addMetrics(metrics map[string]float64) {
+	metrics["bandwidth_mbps"] = s.BandwidthMbps
+	metrics["transferred_mb"] = s.TransferredMB
+	if s.HasRTT {
+		metrics["latency_ms"] = s.LatencyMs
+		metrics["latency_max_ms"] = s.LatencyMaxMs
+	}
+	if s.HasLoss {
+		metrics["jitter_ms"] = s.JitterMs
+		metrics["packet_loss_pct"] = s.PacketLossPct
+	}
+	if s.HasRetransmits {
+		metrics["retransmits"] = s.Retransmits
+	}
+}
+
+// server accepts synthetic traffic: TCP streams are drained and UDP probes
+// are echoed back to the sender
+type server struct {
+	tcp      net.Listener
+	udp      net.PacketConn
+	received atomic.Int64
+}
+
+// listen opens the TCP and UDP sockets on addr. A zero port picks a free one,
+// shared by both sockets when possible.
+func listen(addr string) (*server, error) {
+	lc := net.ListenConfig{}
+	tcp, err := lc.Listen(context.Background(), "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	udp, err := lc.ListenPacket(context.Background(), "udp", tcp.Addr().String())
+	if err != nil {
+		_ = tcp.Close()
+		return nil, fmt.Errorf("failed to listen on udp %s: %w", tcp.Addr(), err)
+	}
+	return &server{tcp: tcp, udp: udp}, nil
+}
+
+func (s *server) tcpAddr() string { return s.tcp.Addr().String() }
+func (s *server) udpAddr() string { return s.udp.LocalAddr().String() }
+
+// serve handles clients until the context is cancelled or the duration
+// elapses, then closes the sockets and reports what was received
+func (s *server) serve(ctx context.Context, duration time.Duration) Stats {
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	var connsMu sync.Mutex
+	var conns []net.Conn
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for {
+			conn, err := s.tcp.Accept()
+			if err != nil {
+				return
+			}
+			connsMu.Lock()
+			conns = append(conns, conn)
+			connsMu.Unlock()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				n, _ := io.Copy(io.Discard, conn)
+				s.received.Add(n)
+				_ = conn.Close()
+			}()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		buf := make([]byte, 2048)
+		for {
+			n, addr, err := s.udp.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = s.udp.WriteTo(buf[:n], addr)
+		}
+	}()
+
+	<-ctx.Done()
+	_ = s.tcp.Close()
+	_ = s.udp.Close()
+	connsMu.Lock()
+	for _, conn := range conns {
+		_ = conn.Close()
+	}
+	connsMu.Unlock()
+	wg.Wait()
+
+	return throughput(s.received.Load(), time.Since(start))
+}
+
+// runSynthetic saturates the link with streams parallel TCP writers while
+// UDP probes measure the round-trip time under load
+func runSynthetic(ctx context.Context, tcpAddr, udpAddr string, streams int, duration, probeInterval time.Duration) (Stats, error) {
+	dialer := net.Dialer{Timeout: 5 * time.Second}
+	conns := make([]net.Conn, 0, streams)
+	defer func() {
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+	}()
+	for i := 0; i < streams; i++ {
+		conn, err := dialer.DialContext(ctx, "tcp", tcpAddr)
+		if err != nil {
+			return Stats{}, fmt.Errorf("failed to connect to %s: %w", tcpAddr, err)
+		}
+		conns = append(conns, conn)
+	}
+	probeConn, err := dialer.DialContext(ctx, "udp", udpAddr)
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to connect to udp %s: %w", udpAddr, err)
+	}
+	defer func() { _ = probeConn.Close() }()
+
+	buf := make([]byte, writeBufferSize)
+	if _, err := rand.Read(buf); err != nil {
+		return Stats{}, err
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+	start := time.Now()
+
+	// Unblock writers stalled on a slow peer as soon as the test ends
+	stopWriters := context.AfterFunc(runCtx, func() {
+		for _, conn := range conns {
+			_ = conn.SetWriteDeadline(time.Now())
+		}
+	})
+	defer stopWriters()
+
+	var sent atomic.Int64
+	var wg sync.WaitGroup
+	for _, conn := range conns {
+		wg.Add(1)
+		go func(conn net.Conn) {
+			defer wg.Done()
+			for runCtx.Err() == nil {
+				n, err := conn.Write(buf)
+				sent.Add(int64(n))
+				if err != nil {
+					return
+				}
+			}
+		}(conn)
+	}
+
+	rtts, probes := probe(runCtx, probeConn, start, probeInterval)
+	wg.Wait()
+	elapsed := time.Since(start)
+	if ctx.Err() != nil {
+		return Stats{}, ctx.Err()
+	}
+
+	stats := throughput(sent.Load(), elapsed)
+	stats.HasRTT = len(rtts) > 0
+	stats.HasLoss = probes > 0
+	stats.LatencyMs, stats.LatencyMaxMs, stats.JitterMs = latencyStats(rtts)
+	if probes > 0 {
+		stats.PacketLossPct = float64(probes-len(rtts)) / float64(probes) * 100
+	}
+	return stats, nil
+}
+
+// probe sends a UDP probe every interval until ctx is done and returns the
+// round-trip times of the replies in send order, and the number sent
+func probe(ctx context.Context, conn net.Conn, start time.Time, interval time.Duration) ([]time.Duration, int) {
+	var (
+		mu      sync.Mutex
+		replies = make(map[uint64]time.Duration)
+	)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, 64)
+		for {
+			n, err := conn.Read(buf)
+			if errors.Is(err, net.ErrClosed) || errors.Is(err, os.ErrDeadlineExceeded) {
+				// Closed, or the grace period after the last probe expired
+				return
+			}
+			if err != nil {
+				continue // e.g. an ICMP unreachable for an earlier probe
+			}
+			if n < probeSize {
+				continue
+			}
+			seq := binary.BigEndian.Uint64(buf[0:8])
+			// Offsets from start use the monotonic clock, so wall clock steps don't skew the RTT
+			sentAt := time.Duration(binary.BigEndian.Uint64(buf[8:16])) // #nosec G115 -- offsets are positive
+			rtt := time.Since(start) - sentAt
+			mu.Lock()
+			if _, dup := replies[seq]; !dup {
+				replies[seq] = rtt
+			}
+			mu.Unlock()
+		}
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	packet := make([]byte, probeSize)
+	var seq uint64
+	for ctx.Err() == nil {
+		binary.BigEndian.PutUint64(packet[0:8], seq)
+		binary.BigEndian.PutUint64(packet[8:16], uint64(time.Since(start))) // #nosec G115 -- offsets are positive
+		if _, err := conn.Write(packet); err == nil {
+			seq++
+		}
+		select {
+		case <-ctx.Done():
+		case <-ticker.C:
+		}
+	}
+
+	// Give replies still in flight a chance before counting them as lost
+	_ = conn.SetReadDeadline(time.Now().Add(probeGrace))
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	rtts := make([]time.Duration, 0, len(replies))
+	for i := uint64(0); i < seq; i++ {
+		if rtt, ok := replies[i]; ok {
+			rtts = append(rtts, rtt)
+		}
+	}
+	return rtts, int(seq) // #nosec G115 -- bounded by the test duration
+}
+
+// throughput returns the data transferred in MB and the rate in Mbit/s
+func throughput(bytes int64, elapsed time.Duration) Stats {
+	stats := Stats{TransferredMB: float64(bytes) / (1024 * 1024)}
+	if elapsed > 0 {
+		stats.BandwidthMbps = float64(bytes) * 8 / 1e6 / elapsed.Seconds()
+	}
+	return stats
+}
+
+// latencyStats returns the average and maximum round-trip time and the jitter,
+// the mean difference between consecutive round-trip times, in milliseconds
+func latencyStats(rtts []time.Duration) (avg, maxRTT, jitter float64) {
+	if len(rtts) == 0 {
+		return 0, 0, 0
+	}
+	var sum, diffs float64
+	for i, rtt := range rtts {
+		ms := float64(rtt) / float64(time.Millisecond)
+		sum += ms
+		maxRTT = math.Max(maxRTT, ms)
+		if i > 0 {
+			diffs += math.Abs(ms - float64(rtts[i-1])/float64(time.Millisecond))
+		}
+	}
+	avg = sum / float64(len(rtts))
+	if len(rtts) > 1 {
+		jitter = diffs / float64(len(rtts)-1)
+	}
+	return avg, maxRTT, jitter
+}
@@ -4,6 +4,7 @@ package plugin
 import (
 	"context"
 	"encoding/json"
+	"regexp"
 	"time"
 )
 
@@ -31,6 +32,9 @@ type Result struct {
 	// Raw output
 	Stdout string `json:"stdout,omitempty"`
 	Stderr string `json:"stderr,omitempty"`
+
+	// Files to attach to the run (charts, logs, dumps), keyed by file name
+	Artifacts map[string][]byte `json:"-"`
 }
 
 // TestPlugin is the interface that all test plugins must implement
@@ -88,6 +92,36 @@ type ParamInfo struct {
 	Required    bool        `json:"required"`
 }
 
+// instancePrefix matches the prefix of a metric recorded once per device or
+// pass, e.g. "drive2_" in drive2_seq_read_mb_per_sec or "warm_" in
+// warm_seq_read_mb_per_sec
+var instancePrefix = regexp.MustCompile(`^[a-z]+\d*_`)
+
+// MetricUnits returns the unit of every metric the plugin describes in its
+// Info, keyed by metric name. A metric recorded per device or pass, such as
+// drive2_seq_read_mb_per_sec, takes the unit of the metric it repeats.
+func MetricUnits(p TestPlugin, metrics map[string]float64) map[string]string {
+	units := make(map[string]string)
+	infoPlugin, ok := p.(interface{ Info() Info })
+	if !ok {
+		return units
+	}
+	known := make(map[string]string)
+	for _, metric := range infoPlugin.Info().Metrics {
+		known[metric.Name] = metric.Unit
+	}
+	for name := range metrics {
+		unit, ok := known[name]
+		if !ok {
+			unit, ok = known[instancePrefix.ReplaceAllString(name, "")]
+		}
+		if ok {
+			units[name] = unit
+		}
+	}
+	return units
+}
+
 // MarshalParams converts Params to JSON
 func MarshalParams(p Params) ([]byte, error) {
 	return json.Marshal(p)
@@ -171,3 +171,35 @@ func TestPluginInfo(t *testing.T) {
 		t.Errorf("Expected description 'Basic plugin', got %s", info.Description)
 	}
 }
+
+// infoMockPlugin is a mock plugin that describes its metrics
+type infoMockPlugin struct {
+	mockPlugin
+}
+
+func (m *infoMockPlugin) Info() Info {
+	return Info{Name: m.name, Metrics: []MetricInfo{
+		{Name: "seq_read_mb_per_sec", Unit: "MB/s"},
+		{Name: "drives"},
+	}}
+}
+
+func TestMetricUnits(t *testing.T) {
+	p := &infoMockPlugin{mockPlugin{name: "disk"}}
+	units := MetricUnits(p, map[string]float64{
+		"seq_read_mb_per_sec":        900,
+		"drive2_seq_read_mb_per_sec": 450,
+		"warm_seq_read_mb_per_sec":   3000,
+		"unknown":                    1,
+	})
+
+	if units["seq_read_mb_per_sec"] != "MB/s" || units["drive2_seq_read_mb_per_sec"] != "MB/s" || units["warm_seq_read_mb_per_sec"] != "MB/s" {
+		t.Errorf("expected MB/s for the total, per-drive and warm metric, got %v", units)
+	}
+	if _, ok := units["unknown"]; ok {
+		t.Error("expected no unit for an undescribed metric")
+	}
+	if len(MetricUnits(&mockPlugin{name: "plain"}, map[string]float64{"x": 1})) != 0 {
+		t.Error("expected no units from a plugin without Info")
+	}
+}
@@ -0,0 +1,216 @@
+// Package trim provides a TRIM/discard verification plugin for FIRE.
+package trim
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/plugin"
+	"github.com/mscrnt/project_fire/pkg/storage"
+	"github.com/mscrnt/project_fire/pkg/virt"
+)
+
+func init() {
+	// Register the TRIM verification plugin
+	if err := plugin.Register(&Plugin{}); err != nil {
+		// Since init() can't return an error, we panic on registration failure
+		// This is acceptable because plugin registration is a critical startup operation
+		panic(fmt.Sprintf("failed to register trim plugin: %v", err))
+	}
+}
+
+// Plugin verifies that TRIM is supported, enabled and working
+type Plugin struct{}
+
+// Name returns the plugin name
+func (p *Plugin) Name() string {
+	return "trim"
+}
+
+// Description returns the plugin description
+func (p *Plugin) Description() string {
+	return "Verify TRIM/discard is supported, enabled and reaching the SSD"
+}
+
+// defaultPath is the directory checked when none is given. The home
+// directory is used because the temporary directory is often a tmpfs.
+func defaultPath() string {
+	if home, err := os.UserHomeDir(); err == nil {
+		return home
+	}
+	return os.TempDir()
+}
+
+// ValidateParams validates the parameters
+func (p *Plugin) ValidateParams(params plugin.Params) error {
+	if path, ok := params.Config["path"].(string); ok && path != "" {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("invalid path: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("path %q is not a directory", path)
+		}
+		if _, err := storage.TrimMount(path); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range []string{"issue", "verify"} {
+		if v, ok := params.Config[key]; ok {
+			if _, isBool := v.(bool); !isBool {
+				return fmt.Errorf("%s must be true or false", key)
+			}
+		}
+	}
+
+	return nil
+}
+
+// DefaultParams returns default parameters
+func (p *Plugin) DefaultParams() plugin.Params {
+	return plugin.Params{
+		Duration: 60 * time.Second,
+		Threads:  1,
+		Config: map[string]interface{}{
+			"path":   defaultPath(), // directory on the filesystem to check
+			"issue":  true,          // run fstrim / Optimize-Volume -ReTrim
+			"verify": true,          // read trimmed blocks back from the device (Linux, root)
+		},
+	}
+}
+
+// Run executes the TRIM verification
+func (p *Plugin) Run(ctx context.Context, params plugin.Params) (plugin.Result, error) {
+	result := plugin.Result{
+		StartTime: time.Now(),
+		Metrics:   make(map[string]float64),
+		Details:   make(map[string]interface{}),
+	}
+
+	if err := p.ValidateParams(params); err != nil {
+		result.EndTime = time.Now()
+		result.Success = false
+		result.Error = err.Error()
+		return result, err
+	}
+
+	path := defaultPath()
+	if v, ok := params.Config["path"].(string); ok && v != "" {
+		path = v
+	}
+	opts := storage.TrimOptions{Issue: true, Verify: true}
+	if v, ok := params.Config["issue"].(bool); ok {
+		opts.Issue = v
+	}
+	if v, ok := params.Config["verify"].(bool); ok {
+		opts.Verify = v
+	}
+
+	report, err := storage.CheckTrim(ctx, path, opts)
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(result.StartTime)
+	if err != nil {
+		result.Success = false
+		result.Error = err.Error()
+		return result, err
+	}
+
+	result.Metrics["trim_supported"] = boolMetric(report.Supported)
+	result.Metrics["trim_enabled"] = boolMetric(report.Enabled)
+	result.Metrics["trim_issued"] = boolMetric(report.Issued)
+	result.Metrics["trimmed_mb"] = float64(report.TrimmedBytes) / (1024 * 1024)
+	result.Metrics["issues"] = float64(len(report.Issues))
+	result.Details["report"] = report
+
+	// Virtual disks often do not pass discards through to the backing
+	// storage, so there the issues are reported without failing the test
+	if env := virt.Detect(); env.Virtual() && len(report.Issues) > 0 {
+		result.Details["environment"] = env.Label()
+		result.Details["warnings"] = report.Issues
+		result.Success = true
+		return result, nil
+	}
+
+	// Misconfigurations fail the test so they are not missed in batch runs
+	result.Success = len(report.Issues) == 0
+	if !result.Success {
+		result.Error = strings.Join(report.Issues, "; ")
+	}
+	return result, nil
+}
+
+// boolMetric converts a flag to a 0/1 metric value
+func boolMetric(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Info returns detailed plugin information
+func (p *Plugin) Info() plugin.Info {
+	return plugin.Info{
+		Name:        p.Name(),
+		Description: p.Description(),
+		Category:    "validation",
+		Metrics: []plugin.MetricInfo{
+			{
+				Name:        "trim_supported",
+				Type:        plugin.MetricTypeGauge,
+				Unit:        "bool",
+				Description: "Device accepts TRIM/discard requests",
+			},
+			{
+				Name:        "trim_enabled",
+				Type:        plugin.MetricTypeGauge,
+				Unit:        "bool",
+				Description: "OS issues TRIM continuously or on a schedule",
+			},
+			{
+				Name:        "trim_issued",
+				Type:        plugin.MetricTypeGauge,
+				Unit:        "bool",
+				Description: "A manual trim was issued successfully during the test",
+			},
+			{
+				Name:        "trimmed_mb",
+				Type:        plugin.MetricTypeGauge,
+				Unit:        "MB",
+				Description: "Space reported as trimmed by fstrim",
+			},
+			{
+				Name:        "issues",
+				Type:        plugin.MetricTypeCounter,
+				Unit:        "issues",
+				Description: "Number of TRIM misconfigurations found",
+			},
+		},
+		Parameters: []plugin.ParamInfo{
+			{
+				Name:        "path",
+				Type:        "string",
+				Default:     defaultPath(),
+				Description: "Directory on the filesystem to check; not a tmpfs, which has no device to trim",
+				Required:    false,
+			},
+			{
+				Name:        "issue",
+				Type:        "boolean",
+				Default:     true,
+				Description: "Issue a trim with fstrim or Optimize-Volume -ReTrim (needs root/Administrator)",
+				Required:    false,
+			},
+			{
+				Name:        "verify",
+				Type:        "boolean",
+				Default:     true,
+				Description: "Read trimmed blocks back from the raw device (Linux, root)",
+				Required:    false,
+			},
+		},
+	}
+}
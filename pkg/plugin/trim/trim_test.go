@@ -0,0 +1,63 @@
+package trim
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mscrnt/project_fire/pkg/plugin"
+	"github.com/mscrnt/project_fire/pkg/storage"
+)
+
+func TestValidateParams(t *testing.T) {
+	p := &Plugin{}
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		err    string
+	}{
+		{"missing path", map[string]interface{}{"path": filepath.Join(t.TempDir(), "missing")}, "invalid path"},
+		{"file as path", map[string]interface{}{"path": file}, "not a directory"},
+		{"issue not a flag", map[string]interface{}{"issue": "yes"}, "issue must be true or false"},
+		{"verify not a flag", map[string]interface{}{"verify": 1}, "verify must be true or false"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.ValidateParams(plugin.Params{Config: tt.config})
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected an error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+
+	if err := p.ValidateParams(plugin.Params{Config: map[string]interface{}{"issue": false, "verify": true}}); err != nil {
+		t.Errorf("expected flags to be accepted, got %v", err)
+	}
+}
+
+func TestDefaultPath(t *testing.T) {
+	path, _ := (&Plugin{}).DefaultParams().Config["path"].(string)
+	if path == "" {
+		t.Fatal("expected a default path")
+	}
+	if home, err := os.UserHomeDir(); err == nil && path != home {
+		t.Errorf("expected the home directory %s, got %s", home, path)
+	}
+}
+
+func TestValidateParamsTmpfs(t *testing.T) {
+	m, err := storage.MountForPath("/dev/shm")
+	if err != nil || m.Fstype != "tmpfs" {
+		t.Skip("/dev/shm is not a tmpfs here")
+	}
+	err = (&Plugin{}).ValidateParams(plugin.Params{Config: map[string]interface{}{"path": "/dev/shm"}})
+	if err == nil || !strings.Contains(err.Error(), "no block device") {
+		t.Errorf("expected a path on a tmpfs to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+// Package power keeps the machine awake while tests run.
+//
+// Each platform has its own inhibitor: SetThreadExecutionState on Windows,
+// systemd-inhibit on Linux and caffeinate on macOS. Inhibitors are tied to
+// the lifetime of the F.I.R.E. process, so the operating system releases
+// them even if the process crashes before calling the release function.
+package power
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrUnsupported is returned when the platform has no sleep inhibitor
+var ErrUnsupported = errors.New("preventing sleep is not supported on this system")
+
+// releaser releases an operating system inhibitor
+type releaser func() error
+
+// acquireInhibitor takes the platform inhibitor; replaced in tests
+var acquireInhibitor = acquire
+
+var (
+	mu        sync.Mutex
+	holders   = make(map[int]string)
+	nextID    int
+	release   releaser
+	listeners []func(active bool)
+)
+
+// Inhibit prevents the system from sleeping, idling into the lock screen or
+// turning off the display until the returned function is called. Holders
+// share a single operating system inhibitor, which is released when the
+// last holder releases. The returned function is safe to call more than once.
+func Inhibit(reason string) (func(), error) {
+	mu.Lock()
+	if len(holders) == 0 {
+		r, err := acquireInhibitor(reason)
+		if err != nil {
+			mu.Unlock()
+			return func() {}, err
+		}
+		release = r
+	}
+	nextID++
+	id := nextID
+	holders[id] = reason
+	changed := len(holders) == 1
+	mu.Unlock()
+
+	if changed {
+		notify(true)
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { drop(id) })
+	}, nil
+}
+
+// drop removes a holder and releases the inhibitor after the last one
+func drop(id int) {
+	mu.Lock()
+	delete(holders, id)
+	if len(holders) > 0 || release == nil {
+		mu.Unlock()
+		return
+	}
+	r := release
+	release = nil
+	mu.Unlock()
+
+	_ = r()
+	notify(false)
+}
+
+// Active reports whether sleep is currently inhibited
+func Active() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return len(holders) > 0
+}
+
+// Reasons returns why sleep is inhibited, one entry per holder
+func Reasons() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	reasons := make([]string, 0, len(holders))
+	for id := 1; id <= nextID; id++ {
+		if reason, ok := holders[id]; ok {
+			reasons = append(reasons, reason)
+		}
+	}
+	return reasons
+}
+
+// OnChange registers fn to be called when sleep becomes inhibited or allowed
+func OnChange(fn func(active bool)) {
+	mu.Lock()
+	defer mu.Unlock()
+	listeners = append(listeners, fn)
+}
+
+func notify(active bool) {
+	mu.Lock()
+	fns := append([]func(bool){}, listeners...)
+	mu.Unlock()
+	for _, fn := range fns {
+		fn(active)
+	}
+}
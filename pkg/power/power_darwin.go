@@ -0,0 +1,27 @@
+//go:build darwin
+// +build darwin
+
+package power
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// acquire runs caffeinate for this process. caffeinate exits by itself when
+// the process it waits for is gone, so a crash releases the assertion.
+func acquire(_ string) (releaser, error) {
+	cmd := safeexec.Command("caffeinate", "-dis", "-w", strconv.Itoa(os.Getpid()))
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start caffeinate: %w", err)
+	}
+
+	return func() error {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil
+	}, nil
+}
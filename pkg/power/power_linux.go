@@ -0,0 +1,40 @@
+//go:build linux
+// +build linux
+
+package power
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// acquire takes a logind block inhibitor through systemd-inhibit. The child
+// holds the lock until its stdin closes, which also happens when this
+// process dies, so a crash cannot leave the machine unable to sleep.
+func acquire(reason string) (releaser, error) {
+	path, err := exec.LookPath("systemd-inhibit")
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+
+	cmd := safeexec.Command(path,
+		"--what=sleep:idle:handle-lid-switch",
+		"--who=F.I.R.E.",
+		"--why="+reason,
+		"--mode=block",
+		"cat")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start systemd-inhibit: %w", err)
+	}
+
+	return func() error {
+		_ = stdin.Close()
+		return cmd.Wait()
+	}, nil
+}
@@ -0,0 +1,22 @@
+//go:build !linux && !darwin && !windows
+// +build !linux,!darwin,!windows
+
+package power
+
+import "time"
+
+func acquire(_ string) (releaser, error) {
+	return nil, ErrUnsupported
+}
+
+func scheduleWake(_ time.Time) error {
+	return ErrUnsupported
+}
+
+func cancelWake() error {
+	return ErrUnsupported
+}
+
+func readSystemSupply() (Supply, error) {
+	return Supply{}, errNoSupply
+}
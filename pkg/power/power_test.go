@@ -0,0 +1,79 @@
+package power
+
+import (
+	"errors"
+	"testing"
+)
+
+func fakeInhibitor(t *testing.T) (acquired, released *int) {
+	t.Helper()
+	acquired, released = new(int), new(int)
+	orig := acquireInhibitor
+	acquireInhibitor = func(string) (releaser, error) {
+		*acquired++
+		return func() error {
+			*released++
+			return nil
+		}, nil
+	}
+	t.Cleanup(func() { acquireInhibitor = orig })
+	return acquired, released
+}
+
+func TestInhibitSharesInhibitor(t *testing.T) {
+	acquired, released := fakeInhibitor(t)
+
+	var changes []bool
+	OnChange(func(active bool) { changes = append(changes, active) })
+	defer func() { listeners = nil }()
+
+	releaseTest, err := Inhibit("test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	releaseRecording, err := Inhibit("recording")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *acquired != 1 {
+		t.Errorf("expected one inhibitor, got %d", *acquired)
+	}
+	if !Active() {
+		t.Error("expected sleep to be inhibited")
+	}
+	if got := Reasons(); len(got) != 2 || got[0] != "test" || got[1] != "recording" {
+		t.Errorf("unexpected reasons %v", got)
+	}
+
+	releaseTest()
+	releaseTest()
+	if *released != 0 || !Active() {
+		t.Error("expected inhibitor to be held until the last release")
+	}
+
+	releaseRecording()
+	if *released != 1 {
+		t.Errorf("expected inhibitor to be released once, got %d", *released)
+	}
+	if Active() {
+		t.Error("expected sleep to be allowed")
+	}
+	if len(changes) != 2 || !changes[0] || changes[1] {
+		t.Errorf("expected changes [true false], got %v", changes)
+	}
+}
+
+func TestInhibitError(t *testing.T) {
+	orig := acquireInhibitor
+	acquireInhibitor = func(string) (releaser, error) { return nil, ErrUnsupported }
+	defer func() { acquireInhibitor = orig }()
+
+	release, err := Inhibit("test")
+	if !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected ErrUnsupported, got %v", err)
+	}
+	release()
+	if Active() {
+		t.Error("expected sleep to be allowed after a failed inhibit")
+	}
+}
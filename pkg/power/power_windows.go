@@ -0,0 +1,55 @@
+//go:build windows
+// +build windows
+
+package power
+
+import (
+	"fmt"
+	"runtime"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	kernel32                    = windows.NewLazySystemDLL("kernel32.dll")
+	procSetThreadExecutionState = kernel32.NewProc("SetThreadExecutionState")
+)
+
+const (
+	esSystemRequired  = 0x00000001
+	esDisplayRequired = 0x00000002
+	esContinuous      = 0x80000000
+)
+
+// acquire sets the execution state from a dedicated OS thread. The state
+// belongs to that thread and Windows clears it when the process exits.
+func acquire(_ string) (releaser, error) {
+	result := make(chan error)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		defer close(stopped)
+
+		r, _, err := procSetThreadExecutionState.Call(uintptr(esContinuous | esSystemRequired | esDisplayRequired))
+		if r == 0 {
+			result <- fmt.Errorf("SetThreadExecutionState failed: %w", err)
+			return
+		}
+		result <- nil
+
+		<-done
+		_, _, _ = procSetThreadExecutionState.Call(uintptr(esContinuous))
+	}()
+
+	if err := <-result; err != nil {
+		return nil, err
+	}
+	return func() error {
+		close(done)
+		<-stopped
+		return nil
+	}, nil
+}
@@ -0,0 +1,240 @@
+package power
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultPollInterval is how often the monitor reads its power sources
+const DefaultPollInterval = 5 * time.Second
+
+// Charge thresholds for sources that do not report low and critical themselves
+const (
+	lowCharge      = 20.0
+	criticalCharge = 5.0
+)
+
+// errNoSupply is returned by sources with nothing to report, such as a
+// desktop without a battery
+var errNoSupply = errors.New("no battery or AC adapter found")
+
+// ErrUPSOnBattery is the cause given to tests stopped because a UPS switched
+// to battery power
+var ErrUPSOnBattery = errors.New("stopped: UPS switched to battery power")
+
+// ErrUPSBatteryLow is the cause given to tests interrupted because a UPS
+// battery ran low, ahead of the machine losing power
+var ErrUPSBatteryLow = errors.New("interrupted: UPS battery low")
+
+// Supply is the state of a power source at one moment
+type Supply struct {
+	OnBattery bool
+	Low       bool
+	Critical  bool
+	Charge    float64       // Percent; negative if unknown
+	Runtime   time.Duration // Estimated time left on battery; 0 if unknown
+	Load      float64       // Percent of the UPS's rated output; negative if unknown
+}
+
+// String summarizes the supply, e.g. "on battery, charge 45%, 12m0s left, load 30%"
+func (s Supply) String() string {
+	parts := []string{"on line power"}
+	if s.OnBattery {
+		parts[0] = "on battery"
+	}
+	if s.Critical {
+		parts = append(parts, "battery critical")
+	} else if s.Low {
+		parts = append(parts, "battery low")
+	}
+	if s.Charge >= 0 {
+		parts = append(parts, fmt.Sprintf("charge %.0f%%", s.Charge))
+	}
+	if s.Runtime > 0 {
+		parts = append(parts, fmt.Sprintf("%s left", s.Runtime.Round(time.Second)))
+	}
+	if s.Load >= 0 {
+		parts = append(parts, fmt.Sprintf("load %.0f%%", s.Load))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// lowFromCharge fills in Low and Critical from the charge when the source
+// has no flags of its own
+func (s *Supply) lowFromCharge() {
+	if s.Charge < 0 {
+		return
+	}
+	s.Low = s.Low || s.Charge <= lowCharge
+	s.Critical = s.Critical || s.Charge <= criticalCharge
+}
+
+// Source is a power supply to watch: the machine's battery and AC adapter,
+// or a UPS
+type Source struct {
+	Name string // e.g. "system", "nut:ups@localhost", "apcupsd"
+	UPS  bool
+	read func() (Supply, error)
+}
+
+// Read returns the current state of the source
+func (s Source) Read() (Supply, error) {
+	return s.read()
+}
+
+// SystemSource watches the machine's own AC adapter and battery, including
+// UPSs the operating system reports as batteries. ok is false on systems
+// without either, such as most desktops.
+func SystemSource() (Source, bool) {
+	if _, err := readSystemSupply(); err != nil {
+		return Source{}, false
+	}
+	return Source{Name: "system", read: readSystemSupply}, true
+}
+
+// EventKind identifies a power event
+type EventKind string
+
+// EventKind constants
+const (
+	EventOnBattery       EventKind = "on_battery"
+	EventPowerRestored   EventKind = "power_restored"
+	EventBatteryLow      EventKind = "battery_low"
+	EventBatteryCritical EventKind = "battery_critical"
+)
+
+// Event is a change in a power source's state
+type Event struct {
+	Time    time.Time
+	Source  string
+	UPS     bool
+	Kind    EventKind
+	Message string
+}
+
+// changes returns the events between two readings of a source. Low and
+// critical charge only count while running on battery.
+func changes(source Source, prev, cur Supply, now time.Time) []Event {
+	device, onBattery, restored := "AC power", "%s lost, running on battery", "%s restored"
+	if source.UPS {
+		device, onBattery, restored = "UPS "+source.Name, "%s lost mains power, running on battery", "%s back on mains power"
+	}
+	var details []string
+	if cur.Charge >= 0 {
+		details = append(details, fmt.Sprintf("charge %.0f%%", cur.Charge))
+	}
+	if cur.Runtime > 0 {
+		details = append(details, fmt.Sprintf("%s left", cur.Runtime.Round(time.Second)))
+	}
+	charge := ""
+	if len(details) > 0 {
+		charge = " (" + strings.Join(details, ", ") + ")"
+	}
+
+	var events []Event
+	add := func(kind EventKind, format string) {
+		events = append(events, Event{
+			Time:    now,
+			Source:  source.Name,
+			UPS:     source.UPS,
+			Kind:    kind,
+			Message: fmt.Sprintf(format, device) + charge,
+		})
+	}
+
+	switch {
+	case cur.OnBattery && !prev.OnBattery:
+		add(EventOnBattery, onBattery)
+	case !cur.OnBattery && prev.OnBattery:
+		add(EventPowerRestored, restored)
+	}
+	if cur.OnBattery && cur.Critical && !(prev.OnBattery && prev.Critical) {
+		add(EventBatteryCritical, "%s: battery critical")
+	} else if cur.OnBattery && cur.Low && !(prev.OnBattery && prev.Low) {
+		add(EventBatteryLow, "%s: battery low")
+	}
+	return events
+}
+
+// UPSLow reports whether the event is a UPS battery running low, the signal
+// to stop tests and save results before the UPS shuts off
+func (e Event) UPSLow() bool {
+	return e.UPS && (e.Kind == EventBatteryLow || e.Kind == EventBatteryCritical)
+}
+
+// Monitor polls power sources and reports changes as events
+type Monitor struct {
+	sources  []Source
+	interval time.Duration
+	logger   *log.Logger
+
+	mu    sync.Mutex
+	state map[string]Supply
+}
+
+// NewMonitor creates a monitor for the sources, polled every interval
+// (DefaultPollInterval if zero)
+func NewMonitor(sources []Source, interval time.Duration, logger *log.Logger) *Monitor {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Monitor{sources: sources, interval: interval, logger: logger, state: make(map[string]Supply)}
+}
+
+// Run polls the sources until ctx is canceled and calls handle for every
+// event. A source already on battery when monitoring starts is reported
+// right away. Read errors are logged when a source first becomes unreadable.
+func (m *Monitor) Run(ctx context.Context, handle func(Event)) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	failing := make(map[string]bool)
+	for {
+		for _, source := range m.sources {
+			cur, err := source.read()
+			if err != nil {
+				if !failing[source.Name] {
+					m.logger.Printf("Cannot read power source %s: %v", source.Name, err)
+				}
+				failing[source.Name] = true
+				continue
+			}
+			failing[source.Name] = false
+
+			m.mu.Lock()
+			prev := m.state[source.Name]
+			m.state[source.Name] = cur
+			m.mu.Unlock()
+
+			for _, event := range changes(source, prev, cur, time.Now()) {
+				handle(event)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// UPSOnBattery reports whether any UPS was on battery at the last reading
+func (m *Monitor) UPSOnBattery() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, source := range m.sources {
+		if source.UPS && m.state[source.Name].OnBattery {
+			return true
+		}
+	}
+	return false
+}
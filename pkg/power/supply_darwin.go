@@ -0,0 +1,39 @@
+//go:build darwin
+// +build darwin
+
+package power
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+var pmsetCharge = regexp.MustCompile(`(\d+)%`)
+
+// readSystemSupply parses "pmset -g batt", whose first line names the
+// source the machine draws from: 'AC Power', 'Battery Power' or 'UPS Power'
+func readSystemSupply() (Supply, error) {
+	out, err := safeexec.Command("pmset", "-g", "batt").Output()
+	if err != nil {
+		return Supply{}, err
+	}
+	return parsePmset(string(out))
+}
+
+// parsePmset reads the output of "pmset -g batt"
+func parsePmset(out string) (Supply, error) {
+	supply := Supply{Charge: -1, Load: -1}
+	if m := pmsetCharge.FindStringSubmatch(out); m != nil {
+		supply.Charge, _ = strconv.ParseFloat(m[1], 64)
+	}
+	onBattery := strings.Contains(out, "'Battery Power'") || strings.Contains(out, "'UPS Power'")
+	if supply.Charge < 0 && !onBattery {
+		return Supply{}, errNoSupply
+	}
+	supply.OnBattery = onBattery
+	supply.lowFromCharge()
+	return supply, nil
+}
@@ -0,0 +1,76 @@
+//go:build linux
+// +build linux
+
+package power
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// powerSupplyRoot is the sysfs power supply class; replaced in tests
+var powerSupplyRoot = "/sys/class/power_supply"
+
+// readSystemSupply reads the AC adapters and system batteries from sysfs.
+// Batteries of peripherals (scope "Device") are ignored.
+func readSystemSupply() (Supply, error) {
+	entries, err := os.ReadDir(powerSupplyRoot)
+	if err != nil {
+		return Supply{}, errNoSupply
+	}
+
+	supply := Supply{Charge: -1, Load: -1}
+	mains, mainsOnline, batteries, discharging := 0, false, 0, false
+	for _, entry := range entries {
+		dir := filepath.Join(powerSupplyRoot, entry.Name())
+		if sysfsValue(dir, "scope") == "Device" {
+			continue
+		}
+		switch sysfsValue(dir, "type") {
+		case "Mains":
+			mains++
+			if sysfsValue(dir, "online") == "1" {
+				mainsOnline = true
+			}
+		case "Battery", "UPS":
+			batteries++
+			if sysfsValue(dir, "status") == "Discharging" {
+				discharging = true
+			}
+			switch sysfsValue(dir, "capacity_level") {
+			case "Critical":
+				supply.Critical = true
+				supply.Low = true
+			case "Low":
+				supply.Low = true
+			}
+			if capacity, err := strconv.ParseFloat(sysfsValue(dir, "capacity"), 64); err == nil {
+				if supply.Charge < 0 || capacity < supply.Charge {
+					supply.Charge = capacity
+				}
+			}
+		}
+	}
+
+	if mains == 0 && batteries == 0 {
+		return Supply{}, errNoSupply
+	}
+	if mains > 0 {
+		supply.OnBattery = !mainsOnline
+	} else {
+		supply.OnBattery = discharging
+	}
+	supply.lowFromCharge()
+	return supply, nil
+}
+
+// sysfsValue reads a sysfs attribute, returning "" if it is missing
+func sysfsValue(dir, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name)) // #nosec G304 -- fixed sysfs path
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
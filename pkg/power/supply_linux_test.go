@@ -0,0 +1,55 @@
+//go:build linux
+// +build linux
+
+package power
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeSupply(t *testing.T, root, name string, attrs map[string]string) {
+	t.Helper()
+	dir := filepath.Join(root, name)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	for attr, value := range attrs {
+		if err := os.WriteFile(filepath.Join(dir, attr), []byte(value+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadSystemSupply(t *testing.T) {
+	root := t.TempDir()
+	orig := powerSupplyRoot
+	powerSupplyRoot = root
+	defer func() { powerSupplyRoot = orig }()
+
+	if _, err := readSystemSupply(); err != errNoSupply {
+		t.Errorf("expected errNoSupply without supplies, got %v", err)
+	}
+
+	writeSupply(t, root, "AC", map[string]string{"type": "Mains", "online": "0"})
+	writeSupply(t, root, "BAT0", map[string]string{"type": "Battery", "status": "Discharging", "capacity": "42", "capacity_level": "Normal"})
+	writeSupply(t, root, "hidpp_battery_0", map[string]string{"type": "Battery", "scope": "Device", "capacity": "3"})
+
+	supply, err := readSystemSupply()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !supply.OnBattery || supply.Charge != 42 || supply.Low {
+		t.Errorf("unexpected supply %+v", supply)
+	}
+
+	writeSupply(t, root, "AC", map[string]string{"online": "1"})
+	supply, err = readSystemSupply()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if supply.OnBattery {
+		t.Errorf("expected AC power, got %+v", supply)
+	}
+}
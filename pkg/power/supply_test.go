@@ -0,0 +1,150 @@
+package power
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestParseNUT(t *testing.T) {
+	out := "battery.charge: 38\nbattery.runtime: 640\nups.load: 27\nups.status: OB DISCHRG\nups.model: Back-UPS\n"
+	supply, err := parseNUT(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !supply.OnBattery || supply.Charge != 38 || supply.Low || supply.Critical {
+		t.Errorf("unexpected supply %+v", supply)
+	}
+	if supply.Runtime != 640*time.Second || supply.Load != 27 {
+		t.Errorf("expected 640s runtime at 27%% load, got %+v", supply)
+	}
+
+	supply, err = parseNUT("battery.charge: 9\nups.status: OB LB\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !supply.Critical || !supply.Low {
+		t.Errorf("expected LB to be critical, got %+v", supply)
+	}
+
+	if _, err := parseNUT("battery.charge: 100\n"); err == nil {
+		t.Error("expected error without ups.status")
+	}
+}
+
+func TestParseAPC(t *testing.T) {
+	out := "APC      : 001,036,0863\nSTATUS   : ONLINE \nBCHARGE  : 100.0 Percent\nTIMELEFT : 12.5 Minutes\nLOADPCT  : 30.0 Percent\n"
+	supply, err := parseAPC(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if supply.OnBattery || supply.Charge != 100 || supply.Runtime != 750*time.Second || supply.Load != 30 {
+		t.Errorf("unexpected supply %+v", supply)
+	}
+	if got := supply.String(); got != "on line power, charge 100%, 12m30s left, load 30%" {
+		t.Errorf("unexpected summary %q", got)
+	}
+
+	supply, err = parseAPC("STATUS   : ONBATT \nBCHARGE  : 15.0 Percent\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !supply.OnBattery || !supply.Low || supply.Critical {
+		t.Errorf("expected low charge on battery, got %+v", supply)
+	}
+
+	if _, err := parseAPC("STATUS   : COMMLOST \n"); err == nil {
+		t.Error("expected error when apcupsd lost the UPS")
+	}
+}
+
+func TestParseUPS(t *testing.T) {
+	for _, spec := range []string{"nut:ups@localhost", "apcupsd", "apcupsd:10.0.0.5:3551"} {
+		source, err := ParseUPS(spec)
+		if err != nil {
+			t.Errorf("ParseUPS(%q): %v", spec, err)
+		}
+		if !source.UPS || source.Name != spec {
+			t.Errorf("ParseUPS(%q) = %+v", spec, source)
+		}
+	}
+	for _, spec := range []string{"", "nut", "nut:", "eaton"} {
+		if _, err := ParseUPS(spec); err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+}
+
+func TestChanges(t *testing.T) {
+	ups := Source{Name: "nut:ups", UPS: true}
+	now := time.Now()
+	online := Supply{Charge: 100}
+	onBattery := Supply{OnBattery: true, Charge: 90}
+	low := Supply{OnBattery: true, Low: true, Charge: 18}
+	critical := Supply{OnBattery: true, Low: true, Critical: true, Charge: 4}
+
+	tests := []struct {
+		name      string
+		prev, cur Supply
+		want      []EventKind
+	}{
+		{"steady", online, online, nil},
+		{"unplugged", online, onBattery, []EventKind{EventOnBattery}},
+		{"draining", onBattery, low, []EventKind{EventBatteryLow}},
+		{"still low", low, low, nil},
+		{"nearly empty", low, critical, []EventKind{EventBatteryCritical}},
+		{"restored", critical, online, []EventKind{EventPowerRestored}},
+		{"low while charging", online, Supply{Low: true, Charge: 10}, nil},
+	}
+	for _, tt := range tests {
+		events := changes(ups, tt.prev, tt.cur, now)
+		if len(events) != len(tt.want) {
+			t.Errorf("%s: expected %v, got %+v", tt.name, tt.want, events)
+			continue
+		}
+		for i, e := range events {
+			if e.Kind != tt.want[i] || !e.UPS || e.Source != "nut:ups" {
+				t.Errorf("%s: expected %s, got %+v", tt.name, tt.want[i], e)
+			}
+			wantLow := e.Kind == EventBatteryLow || e.Kind == EventBatteryCritical
+			if e.UPSLow() != wantLow {
+				t.Errorf("%s: expected UPSLow %v for %s", tt.name, wantLow, e.Kind)
+			}
+		}
+	}
+}
+
+func TestMonitorReportsChanges(t *testing.T) {
+	readings := []Supply{{OnBattery: true, Charge: 80}, {OnBattery: true, Charge: 80}, {Charge: 81}}
+	reads := 0
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	source := Source{Name: "apcupsd", UPS: true, read: func() (Supply, error) {
+		supply := readings[reads]
+		if reads < len(readings)-1 {
+			reads++
+		} else {
+			cancel()
+		}
+		return supply, nil
+	}}
+	monitor := NewMonitor([]Source{source}, time.Millisecond, log.New(io.Discard, "", 0))
+
+	var kinds []EventKind
+	monitor.Run(ctx, func(e Event) {
+		kinds = append(kinds, e.Kind)
+		if e.Kind == EventOnBattery && !monitor.UPSOnBattery() {
+			t.Error("expected UPS to be reported on battery")
+		}
+	})
+
+	if len(kinds) != 2 || kinds[0] != EventOnBattery || kinds[1] != EventPowerRestored {
+		t.Errorf("expected on battery then restored, got %v", kinds)
+	}
+	if monitor.UPSOnBattery() {
+		t.Error("expected UPS back on line power")
+	}
+}
@@ -0,0 +1,61 @@
+//go:build windows
+// +build windows
+
+package power
+
+import (
+	"time"
+	"unsafe"
+)
+
+var procGetSystemPowerStatus = kernel32.NewProc("GetSystemPowerStatus")
+
+// systemPowerStatus mirrors SYSTEM_POWER_STATUS
+type systemPowerStatus struct {
+	ACLineStatus        byte
+	BatteryFlag         byte
+	BatteryLifePercent  byte
+	SystemStatusFlag    byte
+	BatteryLifeTime     uint32
+	BatteryFullLifeTime uint32
+}
+
+// BatteryFlag bits
+const (
+	batteryFlagLow       = 2
+	batteryFlagCritical  = 4
+	batteryFlagNoBattery = 128
+	batteryFlagUnknown   = 255
+)
+
+// batteryLifeUnknown is BatteryLifeTime when no estimate is available
+const batteryLifeUnknown = 0xFFFFFFFF
+
+// readSystemSupply reads the AC line and battery state. USB UPSs using the
+// HID power device class appear here as the system battery.
+func readSystemSupply() (Supply, error) {
+	var status systemPowerStatus
+	if r, _, err := procGetSystemPowerStatus.Call(uintptr(unsafe.Pointer(&status))); r == 0 {
+		return Supply{}, err
+	}
+	if status.BatteryFlag == batteryFlagNoBattery || status.BatteryFlag == batteryFlagUnknown {
+		return Supply{}, errNoSupply
+	}
+
+	supply := Supply{
+		OnBattery: status.ACLineStatus == 0,
+		Low:       status.BatteryFlag&batteryFlagLow != 0,
+		Critical:  status.BatteryFlag&batteryFlagCritical != 0,
+		Charge:    -1,
+		Load:      -1,
+	}
+	if status.BatteryLifePercent <= 100 {
+		supply.Charge = float64(status.BatteryLifePercent)
+	}
+	if status.BatteryLifeTime != batteryLifeUnknown {
+		supply.Runtime = time.Duration(status.BatteryLifeTime) * time.Second
+	}
+	supply.Low = supply.Low || supply.Critical
+	supply.lowFromCharge()
+	return supply, nil
+}
@@ -0,0 +1,151 @@
+package power
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// upsTimeout bounds each query of a UPS daemon
+const upsTimeout = 10 * time.Second
+
+// ParseUPS returns the source for a UPS spec:
+//
+//	nut:<ups>[@<host>[:<port>]]  a UPS served by Network UPS Tools (upsc)
+//	apcupsd[:<host>:<port>]      a UPS served by apcupsd (apcaccess)
+func ParseUPS(spec string) (Source, error) {
+	kind, target, _ := strings.Cut(strings.TrimSpace(spec), ":")
+	switch kind {
+	case "nut":
+		if target == "" {
+			return Source{}, fmt.Errorf("nut UPS needs a name, e.g. nut:ups@localhost")
+		}
+		return Source{Name: spec, UPS: true, read: func() (Supply, error) {
+			out, err := upsQuery("upsc", target)
+			if err != nil {
+				return Supply{}, err
+			}
+			return parseNUT(out)
+		}}, nil
+	case "apcupsd":
+		args := []string{"status"}
+		if target != "" {
+			args = append([]string{"-h", target}, args...)
+		}
+		return Source{Name: spec, UPS: true, read: func() (Supply, error) {
+			out, err := upsQuery("apcaccess", args...)
+			if err != nil {
+				return Supply{}, err
+			}
+			return parseAPC(out)
+		}}, nil
+	default:
+		return Source{}, fmt.Errorf("unknown UPS %q, expected nut:<ups>[@host] or apcupsd[:host:port]", spec)
+	}
+}
+
+// upsQuery runs a UPS client and returns its output
+func upsQuery(name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), upsTimeout)
+	defer cancel()
+	out, err := safeexec.CommandContext(ctx, name, args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %w", name, err)
+	}
+	return string(out), nil
+}
+
+// keyValues parses "key: value" lines; apcaccess pads keys with spaces
+func keyValues(out string) map[string]string {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if ok {
+			values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return values
+}
+
+// parseNUT reads upsc output. ups.status holds flags such as OL (online),
+// OB (on battery) and LB (low battery, shutdown imminent); battery.runtime
+// is in seconds.
+func parseNUT(out string) (Supply, error) {
+	values := keyValues(out)
+	status, ok := values["ups.status"]
+	if !ok {
+		return Supply{}, fmt.Errorf("upsc output has no ups.status")
+	}
+	supply := Supply{Charge: -1, Load: -1}
+	for _, flag := range strings.Fields(status) {
+		switch flag {
+		case "OB":
+			supply.OnBattery = true
+		case "LB":
+			supply.Low = true
+			supply.Critical = true
+		}
+	}
+	if charge, err := strconv.ParseFloat(values["battery.charge"], 64); err == nil {
+		supply.Charge = charge
+	}
+	if runtime, err := strconv.ParseFloat(values["battery.runtime"], 64); err == nil {
+		supply.Runtime = time.Duration(runtime * float64(time.Second))
+	}
+	if load, err := strconv.ParseFloat(values["ups.load"], 64); err == nil {
+		supply.Load = load
+	}
+	supply.lowFromCharge()
+	return supply, nil
+}
+
+// parseAPC reads apcaccess output, e.g. "STATUS   : ONBATT LOWBATT",
+// "BCHARGE  : 45.0 Percent", "TIMELEFT : 12.5 Minutes" and
+// "LOADPCT  : 30.0 Percent"
+func parseAPC(out string) (Supply, error) {
+	values := keyValues(out)
+	status, ok := values["STATUS"]
+	if !ok {
+		return Supply{}, fmt.Errorf("apcaccess output has no STATUS")
+	}
+	if strings.Contains(status, "COMMLOST") {
+		return Supply{}, fmt.Errorf("apcupsd lost contact with the UPS")
+	}
+	supply := Supply{Charge: -1, Load: -1}
+	for _, flag := range strings.Fields(status) {
+		switch flag {
+		case "ONBATT":
+			supply.OnBattery = true
+		case "LOWBATT":
+			supply.Low = true
+			supply.Critical = true
+		}
+	}
+	if charge, ok := apcNumber(values["BCHARGE"]); ok {
+		supply.Charge = charge
+	}
+	if minutes, ok := apcNumber(values["TIMELEFT"]); ok {
+		supply.Runtime = time.Duration(minutes * float64(time.Minute))
+	}
+	if load, ok := apcNumber(values["LOADPCT"]); ok {
+		supply.Load = load
+	}
+	supply.lowFromCharge()
+	return supply, nil
+}
+
+// apcNumber reads the number from an apcaccess value such as "45.0 Percent"
+func apcNumber(value string) (float64, bool) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(fields[0], 64)
+	return n, err == nil
+}
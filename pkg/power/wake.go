@@ -0,0 +1,21 @@
+package power
+
+import (
+	"fmt"
+	"time"
+)
+
+// ScheduleWake programs the hardware to wake the machine from sleep at the
+// given time, replacing any wake programmed earlier. It needs administrator
+// rights on every platform.
+func ScheduleWake(at time.Time) error {
+	if !at.After(time.Now()) {
+		return fmt.Errorf("wake time %s is in the past", at.Format(time.RFC3339))
+	}
+	return scheduleWake(at)
+}
+
+// CancelWake removes the wake programmed by ScheduleWake
+func CancelWake() error {
+	return cancelWake()
+}
@@ -0,0 +1,51 @@
+//go:build darwin
+// +build darwin
+
+package power
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// pmset needs the exact time to cancel a scheduled wake
+var (
+	wakeMu   sync.Mutex
+	lastWake string
+)
+
+// scheduleWake adds a pmset wake event, replacing the previous one
+func scheduleWake(at time.Time) error {
+	if err := cancelWake(); err != nil {
+		return err
+	}
+
+	when := at.Local().Format("01/02/06 15:04:05")
+	output, err := safeexec.Command("pmset", "schedule", "wake", when).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("pmset failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	wakeMu.Lock()
+	lastWake = when
+	wakeMu.Unlock()
+	return nil
+}
+
+func cancelWake() error {
+	wakeMu.Lock()
+	defer wakeMu.Unlock()
+	if lastWake == "" {
+		return nil
+	}
+	output, err := safeexec.Command("pmset", "schedule", "cancel", "wake", lastWake).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("pmset failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	lastWake = ""
+	return nil
+}
@@ -0,0 +1,30 @@
+//go:build linux
+// +build linux
+
+package power
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// scheduleWake sets the RTC alarm with rtcwake without suspending
+func scheduleWake(at time.Time) error {
+	output, err := safeexec.Command("rtcwake", "-m", "no", "-t", strconv.FormatInt(at.Unix(), 10)).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("rtcwake failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func cancelWake() error {
+	output, err := safeexec.Command("rtcwake", "-m", "disable").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("rtcwake failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+//go:build windows
+// +build windows
+
+package power
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// scheduleWake registers a one-shot task that is allowed to wake the
+// computer. Windows only honours it when "Allow wake timers" is enabled in
+// the active power plan.
+func scheduleWake(at time.Time) error {
+	output, err := safeexec.PowerShell(
+		"$action = New-ScheduledTaskAction -Execute 'cmd.exe' -Argument '/c exit 0'; "+
+			"$trigger = New-ScheduledTaskTrigger -Once -At ([DateTime]::Parse(%s)); "+
+			"$settings = New-ScheduledTaskSettingsSet -WakeToRun -AllowStartIfOnBatteries -DontStopIfGoingOnBatteries; "+
+			"Register-ScheduledTask -TaskName 'FIRE Wake' -Action $action -Trigger $trigger -Settings $settings -User 'SYSTEM' -Force | Out-Null",
+		at.Local().Format("2006-01-02T15:04:05"),
+	).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to register wake task: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func cancelWake() error {
+	output, err := safeexec.PowerShell(
+		"Unregister-ScheduledTask -TaskName 'FIRE Wake' -Confirm:$false -ErrorAction SilentlyContinue",
+	).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to remove wake task: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
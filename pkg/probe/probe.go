@@ -0,0 +1,277 @@
+// Package probe runs hardware queries on a bounded pool of workers and keeps
+// their results.
+//
+// A probe is a named query, such as listing the storage devices, which may
+// take seconds where it goes through PowerShell or WMI. Its first Get runs the
+// query and waits for it; later ones return the kept result at once. Once the
+// probe's TTL has passed the kept result is still returned, and the query runs
+// again in the background, so only the very first read of a probe blocks.
+// Peek returns the kept result without ever waiting, and Refresh runs a
+// query again and waits for the new result.
+//
+// Each run is bounded by the probe's timeout. A query that overruns it is
+// abandoned: waiters get ErrTimeout and the previous result is kept, while
+// the query holds its worker until it returns, so a hung query cannot make
+// the pool start more work than it has workers for.
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Defaults of a pool and its probes
+const (
+	DefaultWorkers = 4
+	DefaultTimeout = 30 * time.Second
+)
+
+// ErrUnknown is returned for a probe that was never registered
+var ErrUnknown = errors.New("unknown probe")
+
+// ErrTimeout is returned when a query overran its probe's timeout
+var ErrTimeout = errors.New("probe timed out")
+
+// Func is a hardware query. Its result is shared by every caller, which must
+// not modify it.
+type Func func(ctx context.Context) (interface{}, error)
+
+// Probe is a named hardware query
+type Probe struct {
+	Name    string
+	Run     Func
+	TTL     time.Duration // How long a result is fresh; 0 keeps it until refreshed
+	Timeout time.Duration // 0 = DefaultTimeout
+}
+
+// Pool runs probes and keeps their results. The zero value is not usable;
+// create pools with NewPool.
+type Pool struct {
+	workers chan struct{} // A slot per worker
+	now     func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+// entry is a probe and its last result
+type entry struct {
+	probe   Probe
+	value   interface{}
+	err     error         // Of the last run
+	read    time.Time     // When value was read, zero when there is none
+	stale   bool          // Invalidated before its TTL passed
+	running chan struct{} // Closed when the run in progress ends, nil when idle
+}
+
+// NewPool creates a pool running at most workers queries at once
+func NewPool(workers int) *Pool {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	return &Pool{
+		workers: make(chan struct{}, workers),
+		now:     time.Now,
+		entries: make(map[string]*entry),
+	}
+}
+
+// Default is the pool the package-level functions use
+var Default = NewPool(DefaultWorkers)
+
+// Register adds a probe, replacing one of the same name and its result
+func (p *Pool) Register(probe Probe) {
+	if probe.Timeout <= 0 {
+		probe.Timeout = DefaultTimeout
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.entries[probe.Name] = &entry{probe: probe}
+}
+
+// Get returns a probe's result. Without one it runs the query and waits for
+// it, or until ctx is done; with a stale one it returns that and reads the
+// probe again in the background.
+func (p *Pool) Get(ctx context.Context, name string) (interface{}, error) {
+	p.mu.Lock()
+	e, ok := p.entries[name]
+	if !ok {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrUnknown, name)
+	}
+	if !e.read.IsZero() {
+		if p.expired(e) {
+			p.start(e)
+		}
+		value := e.value
+		p.mu.Unlock()
+		return value, nil
+	}
+	done := p.start(e)
+	p.mu.Unlock()
+	return p.wait(ctx, e, done)
+}
+
+// Peek returns a probe's result without waiting, for callers such as the
+// GUI thread which must not block. A probe without a result, or with a stale
+// one, is read in the background.
+func (p *Pool) Peek(name string) (interface{}, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e, ok := p.entries[name]
+	if !ok {
+		return nil, false
+	}
+	if e.read.IsZero() || p.expired(e) {
+		p.start(e)
+	}
+	return e.value, !e.read.IsZero()
+}
+
+// Refresh reads a probe again and waits for the new result, or until ctx is
+// done. A run already in progress is waited for rather than repeated.
+func (p *Pool) Refresh(ctx context.Context, name string) (interface{}, error) {
+	p.mu.Lock()
+	e, ok := p.entries[name]
+	if !ok {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrUnknown, name)
+	}
+	e.stale = true
+	done := p.start(e)
+	p.mu.Unlock()
+	return p.wait(ctx, e, done)
+}
+
+// Prefetch starts reading the probes that have no fresh result, without
+// waiting for them
+func (p *Pool) Prefetch(names ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, name := range names {
+		if e, ok := p.entries[name]; ok && (e.read.IsZero() || p.expired(e)) {
+			p.start(e)
+		}
+	}
+}
+
+// Invalidate marks a probe's result stale, so the next Get reads it again
+func (p *Pool) Invalidate(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if e, ok := p.entries[name]; ok {
+		e.stale = true
+	}
+}
+
+// InvalidateAll marks every probe's result stale
+func (p *Pool) InvalidateAll() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, e := range p.entries {
+		e.stale = true
+	}
+}
+
+// expired reports whether an entry's result is due to be read again. The
+// pool's lock must be held.
+func (p *Pool) expired(e *entry) bool {
+	return e.stale || (e.probe.TTL > 0 && p.now().Sub(e.read) >= e.probe.TTL)
+}
+
+// start runs an entry's query unless a run is in progress, returning the
+// channel closed when the run ends. The pool's lock must be held.
+func (p *Pool) start(e *entry) chan struct{} {
+	if e.running != nil {
+		return e.running
+	}
+	done := make(chan struct{})
+	e.running = done
+	probe := e.probe
+
+	go func() {
+		value, err := p.run(probe)
+
+		p.mu.Lock()
+		if err == nil {
+			e.value, e.read, e.stale = value, p.now(), false
+		}
+		e.err = err
+		e.running = nil
+		p.mu.Unlock()
+		close(done)
+	}()
+	return done
+}
+
+// run runs a query on a worker, giving up on it after the probe's timeout
+func (p *Pool) run(probe Probe) (interface{}, error) {
+	type result struct {
+		value interface{}
+		err   error
+	}
+
+	p.workers <- struct{}{}
+	ctx, cancel := context.WithTimeout(context.Background(), probe.Timeout)
+	defer cancel()
+
+	results := make(chan result, 1)
+	go func() {
+		defer func() { <-p.workers }()
+		defer func() {
+			if r := recover(); r != nil {
+				results <- result{err: fmt.Errorf("probe %s panicked: %v", probe.Name, r)}
+			}
+		}()
+		value, err := probe.Run(ctx)
+		results <- result{value, err}
+	}()
+
+	select {
+	case r := <-results:
+		return r.value, r.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%w: %s after %v", ErrTimeout, probe.Name, probe.Timeout)
+	}
+}
+
+// wait waits for a run to end and returns the entry's result
+func (p *Pool) wait(ctx context.Context, e *entry, done chan struct{}) (interface{}, error) {
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.value, nil
+}
+
+// Register adds a probe to the default pool
+func Register(probe Probe) { Default.Register(probe) }
+
+// Get returns a probe's result from the default pool
+func Get(ctx context.Context, name string) (interface{}, error) { return Default.Get(ctx, name) }
+
+// Peek returns a probe's result from the default pool without waiting
+func Peek(name string) (interface{}, bool) { return Default.Peek(name) }
+
+// Refresh reads a probe of the default pool again and waits for the result
+func Refresh(ctx context.Context, name string) (interface{}, error) {
+	return Default.Refresh(ctx, name)
+}
+
+// Prefetch starts reading probes of the default pool
+func Prefetch(names ...string) { Default.Prefetch(names...) }
+
+// Invalidate marks a probe's result in the default pool stale
+func Invalidate(name string) { Default.Invalidate(name) }
+
+// InvalidateAll marks every result in the default pool stale
+func InvalidateAll() { Default.InvalidateAll() }
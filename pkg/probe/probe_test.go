@@ -0,0 +1,208 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// counter is a query returning how many times it ran
+func counter(runs *int32) Func {
+	return func(context.Context) (interface{}, error) {
+		return int(atomic.AddInt32(runs, 1)), nil
+	}
+}
+
+// settle waits for the background runs of a pool to end
+func settle(t *testing.T, p *Pool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		p.mu.Lock()
+		busy := false
+		for _, e := range p.entries {
+			busy = busy || e.running != nil
+		}
+		p.mu.Unlock()
+		if !busy {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("probes still running")
+}
+
+func TestGetMemoizes(t *testing.T) {
+	p := NewPool(2)
+	var runs int32
+	p.Register(Probe{Name: "disks", Run: counter(&runs)})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := p.Get(context.Background(), "disks"); err != nil || v != 1 {
+				t.Errorf("expected the first result, got %v (%v)", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if runs != 1 {
+		t.Errorf("expected concurrent Gets to share one run, got %d", runs)
+	}
+
+	if _, err := p.Get(context.Background(), "fans"); !errors.Is(err, ErrUnknown) {
+		t.Errorf("expected ErrUnknown, got %v", err)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	p := NewPool(1)
+	now := time.Unix(1000, 0)
+	p.now = func() time.Time { return now }
+	var runs int32
+	p.Register(Probe{Name: "gpus", Run: counter(&runs), TTL: time.Second})
+	ctx := context.Background()
+
+	if v, _ := p.Get(ctx, "gpus"); v != 1 {
+		t.Fatalf("expected 1, got %v", v)
+	}
+	now = now.Add(500 * time.Millisecond)
+	if v, _ := p.Get(ctx, "gpus"); v != 1 || runs != 1 {
+		t.Errorf("expected the fresh result to be kept, got %v after %d runs", v, runs)
+	}
+
+	// Past the TTL the old result is returned while the probe is read again
+	now = now.Add(time.Second)
+	if v, _ := p.Get(ctx, "gpus"); v != 1 {
+		t.Errorf("expected the stale result, got %v", v)
+	}
+	settle(t, p)
+	if v, _ := p.Get(ctx, "gpus"); v != 2 {
+		t.Errorf("expected the new result, got %v", v)
+	}
+}
+
+func TestRefreshAndInvalidate(t *testing.T) {
+	p := NewPool(1)
+	var runs int32
+	p.Register(Probe{Name: "memory", Run: counter(&runs)})
+	ctx := context.Background()
+
+	if v, _ := p.Get(ctx, "memory"); v != 1 {
+		t.Fatalf("expected 1, got %v", v)
+	}
+	if v, err := p.Refresh(ctx, "memory"); err != nil || v != 2 {
+		t.Errorf("expected Refresh to read again, got %v (%v)", v, err)
+	}
+	if v, _ := p.Get(ctx, "memory"); v != 2 {
+		t.Errorf("expected the refreshed result to be kept, got %v", v)
+	}
+
+	p.InvalidateAll()
+	p.Get(ctx, "memory")
+	settle(t, p)
+	if v, _ := p.Get(ctx, "memory"); v != 3 || runs != 3 {
+		t.Errorf("expected an invalidated result to be read again once, got %v after %d runs", v, runs)
+	}
+}
+
+func TestErrorsKeepResult(t *testing.T) {
+	p := NewPool(1)
+	fail := errors.New("PowerShell not found")
+	var failing atomic.Bool
+	p.Register(Probe{Name: "storage", Run: func(context.Context) (interface{}, error) {
+		if failing.Load() {
+			return nil, fail
+		}
+		return "nvme0", nil
+	}})
+	ctx := context.Background()
+
+	p.Get(ctx, "storage")
+	failing.Store(true)
+	if _, err := p.Refresh(ctx, "storage"); !errors.Is(err, fail) {
+		t.Errorf("expected the query's error, got %v", err)
+	}
+	if v, err := p.Get(ctx, "storage"); err != nil || v != "nvme0" {
+		t.Errorf("expected the last good result, got %v (%v)", v, err)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	p := NewPool(1)
+	release := make(chan struct{})
+	p.Register(Probe{Name: "hung", Timeout: 10 * time.Millisecond, Run: func(context.Context) (interface{}, error) {
+		<-release // Ignores its context, as a stuck WMI call would
+		return nil, nil
+	}})
+	var runs int32
+	p.Register(Probe{Name: "next", Run: counter(&runs)})
+
+	if _, err := p.Get(context.Background(), "hung"); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected ErrTimeout, got %v", err)
+	}
+
+	// The hung query keeps the only worker until it returns
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := p.Get(ctx, "next"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the caller to give up waiting, got %v", err)
+	}
+	close(release)
+	if v, err := p.Get(context.Background(), "next"); err != nil || v != 1 {
+		t.Errorf("expected the probe to run once the worker is free, got %v (%v)", v, err)
+	}
+}
+
+func TestPeek(t *testing.T) {
+	p := NewPool(1)
+	release := make(chan struct{})
+	p.Register(Probe{Name: "storage", Run: func(context.Context) (interface{}, error) {
+		<-release
+		return "nvme0", nil
+	}})
+
+	if _, ok := p.Peek("storage"); ok {
+		t.Error("expected no result before the first read")
+	}
+	close(release)
+	settle(t, p)
+	if v, ok := p.Peek("storage"); !ok || v != "nvme0" {
+		t.Errorf("expected the result of the read Peek started, got %v", v)
+	}
+	if _, ok := p.Peek("fans"); ok {
+		t.Error("expected no result for an unknown probe")
+	}
+}
+
+func TestPrefetch(t *testing.T) {
+	p := NewPool(2)
+	var a, b int32
+	p.Register(Probe{Name: "a", Run: counter(&a)})
+	p.Register(Probe{Name: "b", Run: counter(&b)})
+	p.Prefetch("a", "b", "missing")
+	settle(t, p)
+	if a != 1 || b != 1 {
+		t.Errorf("expected both probes read, got %d and %d", a, b)
+	}
+	p.Prefetch("a")
+	settle(t, p)
+	if a != 1 {
+		t.Errorf("expected a fresh result not to be read again, got %d runs", a)
+	}
+}
+
+func TestPanic(t *testing.T) {
+	p := NewPool(1)
+	p.Register(Probe{Name: "bad", Run: func(context.Context) (interface{}, error) {
+		panic("index out of range")
+	}})
+	if _, err := p.Get(context.Background(), "bad"); err == nil {
+		t.Error("expected the panic to be returned as an error")
+	}
+}
@@ -0,0 +1,243 @@
+// Package procs lists the processes using the most CPU, memory, disk I/O and
+// GPU, for `bench top` and the GUI's process viewer. During a stress test it
+// shows whether the load comes from the test or from something else.
+//
+// A Sampler keeps each process's previous CPU time and I/O counters, so
+// every sample carries rates over the time since the last one. CPU usage is
+// a share of all logical CPUs, so a process keeping every core busy shows
+// 100%. GPU usage is read through nvidia-smi and is only available on NVIDIA
+// cards.
+package procs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// Sort keys
+const (
+	ByCPU    = "cpu"
+	ByMemory = "memory"
+	ByIO     = "io"
+	ByGPU    = "gpu"
+)
+
+// SortKeys lists the keys processes can be sorted by
+var SortKeys = []string{ByCPU, ByMemory, ByIO, ByGPU}
+
+// Process holds one process's usage since the previous sample. Rates are
+// zero in the first sample that sees the process.
+type Process struct {
+	PID       int32   `json:"pid"`
+	Name      string  `json:"name"`
+	User      string  `json:"user,omitempty"`
+	CPU       float64 `json:"cpu_pct"`        // Share of all logical CPUs
+	MemoryMB  float64 `json:"memory_mb"`      // Resident set
+	MemoryPct float64 `json:"memory_pct"`     // Of physical memory
+	ReadMBps  float64 `json:"read_mbps"`      // Disk reads
+	WriteMBps float64 `json:"write_mbps"`     // Disk writes
+	GPU       float64 `json:"gpu_pct"`        // Summed over the GPUs; see Sample.GPUAvailable
+	Self      bool    `json:"self,omitempty"` // This process, which runs the tests
+}
+
+// IO returns the process's combined disk throughput in MB/s
+func (p Process) IO() float64 {
+	return p.ReadMBps + p.WriteMBps
+}
+
+// Sample is one poll of every process
+type Sample struct {
+	Time         time.Time
+	Processes    []Process
+	GPUAvailable bool // Whether per-process GPU usage could be read
+}
+
+// previous holds what the last sample read of a process
+type previous struct {
+	created int64 // Start time, to tell a reused PID from the same process
+	name    string
+	user    string
+	cpu     float64
+	read    uint64
+	written uint64
+}
+
+// Sampler takes samples. It is not safe for concurrent use.
+type Sampler struct {
+	gpu func(context.Context) (map[int32]float64, bool)
+
+	last     map[int32]previous
+	lastTime time.Time
+}
+
+// NewSampler returns a sampler reading GPU usage through nvidia-smi
+func NewSampler() *Sampler {
+	return &Sampler{gpu: readNVIDIA}
+}
+
+// Sample reads every process the user can see. Processes that exit while
+// they are read are left out.
+func (s *Sampler) Sample(ctx context.Context) (*Sample, error) {
+	list, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+	now := time.Now()
+	elapsed := now.Sub(s.lastTime).Seconds()
+	if s.lastTime.IsZero() {
+		elapsed = 0
+	}
+	gpu, gpuAvailable := s.gpu(ctx)
+
+	var totalMB float64
+	if vm, err := mem.VirtualMemoryWithContext(ctx); err == nil {
+		totalMB = float64(vm.Total) / (1 << 20)
+	}
+	self := int32(os.Getpid())
+
+	sample := &Sample{Time: now, GPUAvailable: gpuAvailable}
+	current := make(map[int32]previous, len(list))
+	for _, p := range list {
+		var state previous
+		state.created, _ = p.CreateTimeWithContext(ctx)
+		last, seen := s.last[p.Pid]
+		seen = seen && last.created == state.created
+		if seen {
+			state.name, state.user = last.name, last.user
+		} else {
+			name, err := p.NameWithContext(ctx)
+			if err != nil {
+				continue // Exited
+			}
+			state.name = name
+			state.user, _ = p.UsernameWithContext(ctx)
+		}
+		proc := Process{PID: p.Pid, Name: state.name, User: state.user, GPU: gpu[p.Pid], Self: p.Pid == self}
+
+		if times, err := p.TimesWithContext(ctx); err == nil {
+			state.cpu = times.User + times.System
+		}
+		if io, err := p.IOCountersWithContext(ctx); err == nil {
+			state.read, state.written = io.ReadBytes, io.WriteBytes
+		}
+		if info, err := p.MemoryInfoWithContext(ctx); err == nil {
+			proc.MemoryMB = float64(info.RSS) / (1 << 20)
+			if totalMB > 0 {
+				proc.MemoryPct = proc.MemoryMB / totalMB * 100
+			}
+		}
+
+		if seen && elapsed > 0 {
+			proc.CPU = max(state.cpu-last.cpu, 0) / elapsed / float64(runtime.NumCPU()) * 100
+			proc.ReadMBps = float64(delta(state.read, last.read)) / (1 << 20) / elapsed
+			proc.WriteMBps = float64(delta(state.written, last.written)) / (1 << 20) / elapsed
+		}
+		current[p.Pid] = state
+		sample.Processes = append(sample.Processes, proc)
+	}
+	s.last, s.lastTime = current, now
+	return sample, nil
+}
+
+// delta returns how far a counter moved, or 0 if it was reset
+func delta(now, before uint64) uint64 {
+	if now < before {
+		return 0
+	}
+	return now - before
+}
+
+// Top returns the n processes using the most of key, or all when n is zero
+func Top(processes []Process, key string, n int) []Process {
+	sorted := append([]Process(nil), processes...)
+	value := func(p Process) float64 {
+		switch key {
+		case ByMemory:
+			return p.MemoryMB
+		case ByIO:
+			return p.IO()
+		case ByGPU:
+			return p.GPU
+		}
+		return p.CPU
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if a, b := value(sorted[i]), value(sorted[j]); a != b {
+			return a > b
+		}
+		return sorted[i].PID < sorted[j].PID
+	})
+	if n > 0 && len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// ValidSortKey reports whether processes can be sorted by key
+func ValidSortKey(key string) bool {
+	for _, k := range SortKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Others returns the CPU share of every process but this one, the load that
+// does not come from the tests
+func Others(processes []Process) float64 {
+	var total float64
+	for _, p := range processes {
+		if !p.Self {
+			total += p.CPU
+		}
+	}
+	return total
+}
+
+// readNVIDIA reads each process's share of the NVIDIA GPUs' streaming
+// multiprocessors, summed over the cards
+func readNVIDIA(ctx context.Context) (map[int32]float64, bool) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	output, err := safeexec.CommandContext(ctx, "nvidia-smi", "pmon", "-c", "1", "-s", "u").Output()
+	if err != nil {
+		return nil, false // No NVIDIA driver or card
+	}
+	return parsePmon(string(output)), true
+}
+
+// parsePmon parses the output of nvidia-smi pmon -s u:
+//
+//	# gpu         pid   type     sm    mem    enc    dec   command
+//	# Idx           #    C/G      %      %      %      %   name
+//	    0       4242     C     97     41      -      -   python
+//
+// Idle cards list a "-" PID, and processes without samples a "-" share.
+func parsePmon(output string) map[int32]float64 {
+	usage := make(map[int32]float64)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		pid, err := strconv.ParseInt(fields[1], 10, 32)
+		if err != nil {
+			continue
+		}
+		if sm, err := strconv.ParseFloat(fields[3], 64); err == nil {
+			usage[int32(pid)] += sm
+		}
+	}
+	return usage
+}
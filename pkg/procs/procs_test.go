@@ -0,0 +1,66 @@
+package procs
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+func TestParsePmon(t *testing.T) {
+	output := `# gpu         pid   type     sm    mem    enc    dec   command
+# Idx           #    C/G      %      %      %      %   name
+    0       4242     C     60     41      -      -   python
+    1       4242     C     30     12      -      -   python
+    0       1337     G      -      -      -      -   Xorg
+    1          -     -      -      -      -      -   -
+`
+	usage := parsePmon(output)
+	if len(usage) != 1 || usage[4242] != 90 {
+		t.Errorf("expected python at 90%% over both cards, got %v", usage)
+	}
+}
+
+func TestTop(t *testing.T) {
+	processes := []Process{
+		{PID: 1, Name: "idle", CPU: 0.1, MemoryMB: 10},
+		{PID: 2, Name: "bench", CPU: 95, MemoryMB: 300, Self: true},
+		{PID: 3, Name: "indexer", CPU: 3, MemoryMB: 900, ReadMBps: 40, WriteMBps: 5},
+	}
+	if top := Top(processes, ByCPU, 2); len(top) != 2 || top[0].PID != 2 || top[1].PID != 3 {
+		t.Errorf("unexpected CPU order %+v", top)
+	}
+	if top := Top(processes, ByMemory, 0); len(top) != 3 || top[0].PID != 3 {
+		t.Errorf("unexpected memory order %+v", top)
+	}
+	if top := Top(processes, ByIO, 1); top[0].Name != "indexer" {
+		t.Errorf("unexpected I/O order %+v", top)
+	}
+	if others := Others(processes); others < 3.09 || others > 3.11 {
+		t.Errorf("expected 3.1%% outside the tests, got %v", others)
+	}
+}
+
+func TestSample(t *testing.T) {
+	self := int32(os.Getpid())
+	s := &Sampler{gpu: func(context.Context) (map[int32]float64, bool) {
+		return map[int32]float64{self: 50}, true
+	}}
+	for i := 0; i < 2; i++ {
+		sample, err := s.Sample(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, p := range sample.Processes {
+			if p.PID == self {
+				found = true
+				if !p.Self || p.GPU != 50 || p.MemoryMB <= 0 || p.Name == "" {
+					t.Errorf("unexpected readings for this process: %+v", p)
+				}
+			}
+		}
+		if !found || !sample.GPUAvailable {
+			t.Fatalf("expected this process in the sample")
+		}
+	}
+}
@@ -0,0 +1,328 @@
+// Package profile stores named operator profiles for shared bench machines.
+//
+// Each technician keeps their own GUI layout, units, favorite test presets,
+// notification preferences and session recording choices in a profile, and
+// switches to it at the start of a shift. All profiles live in one JSON file
+// (~/.fire/profiles.json, or $FIRE_PROFILES) together with the name of the
+// active one.
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultName is the profile used until an operator creates their own
+const DefaultName = "Default"
+
+// TemperatureUnit selects how temperatures are shown
+type TemperatureUnit string
+
+// TemperatureUnit constants
+const (
+	Celsius    TemperatureUnit = "C"
+	Fahrenheit TemperatureUnit = "F"
+)
+
+// Layout is the window arrangement restored when a profile is selected
+type Layout struct {
+	StartPage        int  `json:"start_page"` // Sidebar page index, 0 = System Info
+	SidebarCollapsed bool `json:"sidebar_collapsed"`
+
+	// Monitoring holds the tiles of the monitoring page in order; empty
+	// shows the thermals preset
+	Monitoring []Tile `json:"monitoring,omitempty"`
+}
+
+// Tile kinds on the monitoring page
+const (
+	TileChart  = "chart"  // Line chart of the last few minutes
+	TileGauge  = "gauge"  // Bar filling towards the channel's maximum
+	TileNumber = "number" // Large current reading
+	TileTable  = "table"  // Current reading of several channels
+)
+
+// Largest tile, in cells of the monitoring page's grid
+const (
+	MaxTileWidth  = 4
+	MaxTileHeight = 3
+)
+
+// Tile is one tile of the monitoring page, bound to sensor channels such as
+// "cpu_temp" (see bench monitor). A channel may be a pattern such as "fan_*";
+// tables show every match and the other kinds the first.
+type Tile struct {
+	Title    string   `json:"title,omitempty"` // Empty names the tile after its first channel
+	Kind     string   `json:"kind"`
+	Channels []string `json:"channels"`
+	Width    int      `json:"width"`  // Grid columns, 1 to MaxTileWidth
+	Height   int      `json:"height"` // Grid rows, 1 to MaxTileHeight
+}
+
+// Validate checks the tile's kind, channels and size
+func (t Tile) Validate() error {
+	switch t.Kind {
+	case TileChart, TileGauge, TileNumber, TileTable:
+	default:
+		return fmt.Errorf("unknown tile kind %q, expected chart, gauge, number or table", t.Kind)
+	}
+	if len(t.Channels) == 0 {
+		return fmt.Errorf("%s tile has no sensor channel", t.Kind)
+	}
+	for _, channel := range t.Channels {
+		if _, err := path.Match(channel, ""); err != nil || channel == "" {
+			return fmt.Errorf("invalid sensor channel %q", channel)
+		}
+	}
+	if t.Width < 1 || t.Width > MaxTileWidth || t.Height < 1 || t.Height > MaxTileHeight {
+		return fmt.Errorf("tile size %dx%d is outside 1x1 to %dx%d", t.Width, t.Height, MaxTileWidth, MaxTileHeight)
+	}
+	return nil
+}
+
+// Units are the display units of a profile
+type Units struct {
+	Temperature TemperatureUnit `json:"temperature,omitempty"` // Empty follows units.temperature in the settings
+}
+
+// Notifications selects which desktop notifications are shown
+type Notifications struct {
+	Warnings      bool `json:"warnings"`       // e.g. running without administrator rights
+	DeviceChanges bool `json:"device_changes"` // e.g. storage devices detected
+}
+
+// Recording selects the dashboard metrics kept in recorded sessions. Metrics
+// are listed when left out, so readings added in later versions are
+// recorded by default.
+type Recording struct {
+	Disabled []string `json:"disabled,omitempty"` // e.g. "CPU Voltage (V)"
+}
+
+// Profile is one operator's GUI preferences
+type Profile struct {
+	Name          string        `json:"name"`
+	Layout        Layout        `json:"layout"`
+	Units         Units         `json:"units"`
+	Favorites     []string      `json:"favorites,omitempty"` // Test preset names, in the order shown
+	Notifications Notifications `json:"notifications"`
+	Recording     Recording     `json:"recording"`
+}
+
+// New returns a profile with the default preferences
+func New(name string) Profile {
+	return Profile{
+		Name:          name,
+		Notifications: Notifications{Warnings: true, DeviceChanges: true},
+	}
+}
+
+// Validate checks the profile name and units
+func (p Profile) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("profile name is required")
+	}
+	switch p.Units.Temperature {
+	case "", Celsius, Fahrenheit:
+	default:
+		return fmt.Errorf("unknown temperature unit %q, expected C or F", p.Units.Temperature)
+	}
+	if p.Layout.StartPage < 0 {
+		return fmt.Errorf("invalid start page %d", p.Layout.StartPage)
+	}
+	for _, tile := range p.Layout.Monitoring {
+		if err := tile.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsFavorite reports whether a test preset is one of the profile's favorites
+func (p Profile) IsFavorite(preset string) bool {
+	for _, name := range p.Favorites {
+		if name == preset {
+			return true
+		}
+	}
+	return false
+}
+
+// Records reports whether the named dashboard metric is kept in recorded
+// sessions
+func (r Recording) Records(metric string) bool {
+	for _, name := range r.Disabled {
+		if name == metric {
+			return false
+		}
+	}
+	return true
+}
+
+// FromCelsius converts a Celsius reading to the profile's unit and returns
+// it with the unit symbol
+func (u Units) FromCelsius(celsius float64) (float64, string) {
+	if u.Temperature == Fahrenheit {
+		return celsius*1.8 + 32, "°F"
+	}
+	return celsius, "°C"
+}
+
+// Store holds every profile on the machine and which one is active
+type Store struct {
+	Active   string    `json:"active"`
+	Profiles []Profile `json:"profiles"`
+}
+
+// DefaultPath returns where profiles are stored
+func DefaultPath() string {
+	if path := os.Getenv("FIRE_PROFILES"); path != "" {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "profiles.json"
+	}
+	return filepath.Join(homeDir, ".fire", "profiles.json")
+}
+
+// Load reads the profile store. A missing file yields a store holding only
+// the default profile.
+func Load(path string) (*Store, error) {
+	store := &Store{}
+	data, err := os.ReadFile(path) // #nosec G304 -- profile store in the user's config directory
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(data, store); err != nil {
+			return nil, fmt.Errorf("failed to parse profiles %s: %w", path, err)
+		}
+	}
+
+	if len(store.Profiles) == 0 {
+		store.Profiles = []Profile{New(DefaultName)}
+	}
+	if store.index(store.Active) < 0 {
+		store.Active = store.Profiles[0].Name
+	}
+	return store, nil
+}
+
+// Save writes the store as indented JSON
+func Save(path string, store *Store) error {
+	data, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// index returns the position of the named profile, or -1. Names are matched
+// ignoring case so "alice" and "Alice" cannot both exist.
+func (s *Store) index(name string) int {
+	for i, p := range s.Profiles {
+		if strings.EqualFold(p.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Current returns the active profile
+func (s *Store) Current() Profile {
+	if i := s.index(s.Active); i >= 0 {
+		return s.Profiles[i]
+	}
+	return New(DefaultName)
+}
+
+// Get returns the named profile
+func (s *Store) Get(name string) (Profile, bool) {
+	if i := s.index(name); i >= 0 {
+		return s.Profiles[i], true
+	}
+	return Profile{}, false
+}
+
+// Names returns the profile names in alphabetical order
+func (s *Store) Names() []string {
+	names := make([]string, len(s.Profiles))
+	for i, p := range s.Profiles {
+		names[i] = p.Name
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+	return names
+}
+
+// Put adds a profile, or replaces the one with the same name
+func (s *Store) Put(p Profile) error {
+	p.Name = strings.TrimSpace(p.Name)
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	if i := s.index(p.Name); i >= 0 {
+		s.Profiles[i] = p
+		return nil
+	}
+	s.Profiles = append(s.Profiles, p)
+	return nil
+}
+
+// Rename changes a profile's name, keeping it active if it was
+func (s *Store) Rename(oldName, newName string) error {
+	i := s.index(oldName)
+	if i < 0 {
+		return fmt.Errorf("profile %q not found", oldName)
+	}
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return errors.New("profile name is required")
+	}
+	if j := s.index(newName); j >= 0 && j != i {
+		return fmt.Errorf("profile %q already exists", newName)
+	}
+	if strings.EqualFold(s.Active, oldName) {
+		s.Active = newName
+	}
+	s.Profiles[i].Name = newName
+	return nil
+}
+
+// Delete removes a profile. The last profile cannot be deleted; deleting the
+// active one switches to the first remaining profile.
+func (s *Store) Delete(name string) error {
+	i := s.index(name)
+	if i < 0 {
+		return fmt.Errorf("profile %q not found", name)
+	}
+	if len(s.Profiles) == 1 {
+		return errors.New("cannot delete the only profile")
+	}
+	s.Profiles = append(s.Profiles[:i], s.Profiles[i+1:]...)
+	if strings.EqualFold(s.Active, name) {
+		s.Active = s.Profiles[0].Name
+	}
+	return nil
+}
+
+// Switch makes the named profile active and returns it
+func (s *Store) Switch(name string) (Profile, error) {
+	i := s.index(name)
+	if i < 0 {
+		return Profile{}, fmt.Errorf("profile %q not found", name)
+	}
+	s.Active = s.Profiles[i].Name
+	return s.Profiles[i], nil
+}
@@ -0,0 +1,121 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadMissingFile(t *testing.T) {
+	store, err := Load(filepath.Join(t.TempDir(), "profiles.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(store.Profiles) != 1 || store.Active != DefaultName {
+		t.Errorf("expected only the default profile, got %+v", store)
+	}
+	if current := store.Current(); current.Units.Temperature != "" || !current.Notifications.Warnings {
+		t.Errorf("expected default preferences, got %+v", current)
+	}
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	store, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	night := New("Night Shift")
+	night.Units.Temperature = Fahrenheit
+	night.Layout = Layout{StartPage: 2, SidebarCollapsed: true, Monitoring: []Tile{
+		{Kind: TileTable, Channels: []string{"fan_*"}, Width: 2, Height: 2},
+	}}
+	night.Favorites = []string{"Memory Stress"}
+	night.Recording.Disabled = []string{"CPU Voltage (V)"}
+	if err := store.Put(night); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Switch("night shift"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(path, store); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current := loaded.Current()
+	if current.Name != "Night Shift" || current.Layout.StartPage != 2 || len(current.Layout.Monitoring) != 1 || !current.IsFavorite("Memory Stress") ||
+		current.Recording.Records("CPU Voltage (V)") || !current.Recording.Records("CPU Temp (°C)") {
+		t.Errorf("expected the night shift profile, got %+v", current)
+	}
+	if names := loaded.Names(); len(names) != 2 || names[0] != "Default" {
+		t.Errorf("expected two sorted names, got %v", names)
+	}
+}
+
+func TestPutRejectsInvalidProfiles(t *testing.T) {
+	store := &Store{}
+	if err := store.Put(Profile{Name: " ", Units: Units{Temperature: Celsius}}); err == nil {
+		t.Error("expected error for an empty name")
+	}
+	if err := store.Put(Profile{Name: "Kelvin", Units: Units{Temperature: "K"}}); err == nil {
+		t.Error("expected error for an unknown unit")
+	}
+	wide := New("Wide")
+	wide.Layout.Monitoring = []Tile{{Kind: TileChart, Channels: []string{"cpu_temp"}, Width: 5, Height: 1}}
+	if err := store.Put(wide); err == nil {
+		t.Error("expected error for a tile wider than the grid")
+	}
+	pattern := New("Pattern")
+	pattern.Layout.Monitoring = []Tile{{Kind: TileTable, Channels: []string{"fan_["}, Width: 1, Height: 1}}
+	if err := store.Put(pattern); err == nil {
+		t.Error("expected error for a malformed channel pattern")
+	}
+}
+
+func TestRenameAndDelete(t *testing.T) {
+	store := &Store{Active: "Alice", Profiles: []Profile{New("Alice"), New("Bob")}}
+
+	if err := store.Rename("alice", "Bob"); err == nil {
+		t.Error("expected error renaming to an existing name")
+	}
+	if err := store.Rename("alice", "Alice (days)"); err != nil {
+		t.Fatal(err)
+	}
+	if store.Active != "Alice (days)" {
+		t.Errorf("expected the renamed profile to stay active, got %q", store.Active)
+	}
+
+	if err := store.Delete("Alice (days)"); err != nil {
+		t.Fatal(err)
+	}
+	if store.Active != "Bob" {
+		t.Errorf("expected Bob to become active, got %q", store.Active)
+	}
+	if err := store.Delete("Bob"); err == nil {
+		t.Error("expected error deleting the only profile")
+	}
+}
+
+func TestTemperatureUnits(t *testing.T) {
+	if value, unit := (Units{Temperature: Fahrenheit}).FromCelsius(100); value != 212 || unit != "°F" {
+		t.Errorf("expected 212 °F, got %v %s", value, unit)
+	}
+	if value, unit := (Units{Temperature: Celsius}).FromCelsius(40); value != 40 || unit != "°C" {
+		t.Errorf("expected 40 °C, got %v %s", value, unit)
+	}
+}
+
+func TestLoadRejectsCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("expected error for a corrupt file")
+	}
+}
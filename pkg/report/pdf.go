@@ -50,7 +50,12 @@ func (g *Generator) GeneratePDF(runID int64, outputPath string, options *PDFOpti
 	if err != nil {
 		return fmt.Errorf("failed to generate HTML: %w", err)
 	}
+	return HTMLToPDF(html, outputPath, options)
+}
 
+// HTMLToPDF prints an HTML page to a PDF file, for pages other than run
+// reports such as burn-in certificates
+func HTMLToPDF(html, outputPath string, options *PDFOptions) error {
 	// Create temporary HTML file
 	tmpFile, err := os.CreateTemp("", "fire-report-*.html")
 	if err != nil {
@@ -4,9 +4,17 @@ import (
 	"bytes"
 	"fmt"
 	"html/template"
+	"io"
 	"time"
 
 	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/ecc"
+	"github.com/mscrnt/project_fire/pkg/inventory"
+	"github.com/mscrnt/project_fire/pkg/ipmi"
+	"github.com/mscrnt/project_fire/pkg/session"
+	"github.com/mscrnt/project_fire/pkg/specsheet"
+	"github.com/mscrnt/project_fire/pkg/throttle"
+	"github.com/mscrnt/project_fire/pkg/units"
 )
 
 // Data contains all data needed for report generation
@@ -17,6 +25,38 @@ type Data struct {
 	GeneratedAt  time.Time
 	SystemInfo   SystemInfo
 	MetricGroups []MetricGroup
+
+	// SpecComparison compares the advertised specs with measured results;
+	// empty when no spec sheet was given
+	SpecComparison []specsheet.Row
+	SpecSheetName  string
+
+	// Annotations are the events recorded while the run was in progress, such
+	// as power outages or a drive linked below its capability
+	Annotations []*db.Annotation
+
+	// Notes are the remarks added to the run, such as the repair made
+	// before it
+	Notes []*db.Note
+
+	// Hardware is the inventory recorded when the run started; empty for
+	// runs recorded before inventories were stored
+	Hardware []session.Component
+
+	// Throttling summarizes the throttle events detected during the run;
+	// empty when the run was not watched
+	Throttling string
+	Throttled  bool
+
+	// ECCErrors summarizes the memory errors counted during the run; empty
+	// when the machine has no ECC counters
+	ECCErrors   string
+	Uncorrected bool
+
+	// SELEntries counts the entries the BMC added to its event log during
+	// the run; empty when the machine has no BMC
+	SELEntries string
+	SELLogged  bool
 }
 
 // SystemInfo contains system information
@@ -46,15 +86,29 @@ type MetricDisplay struct {
 // Generator creates reports from test data
 type Generator struct {
 	database *db.DB
+	specs    *specsheet.Sheet
+	units    units.Prefs
 }
 
 // NewGenerator creates a new report generator
 func NewGenerator(database *db.DB) *Generator {
 	return &Generator{
 		database: database,
+		units:    units.Default(),
 	}
 }
 
+// SetSpecSheet adds a comparison of the advertised specs with the measured
+// results to generated reports
+func (g *Generator) SetSpecSheet(sheet *specsheet.Sheet) {
+	g.specs = sheet
+}
+
+// SetUnits sets the units results are shown in
+func (g *Generator) SetUnits(prefs units.Prefs) {
+	g.units = prefs
+}
+
 // GenerateHTML generates an HTML report for a run
 func (g *Generator) GenerateHTML(runID int64) (string, error) {
 	// Load data
@@ -63,6 +117,39 @@ func (g *Generator) GenerateHTML(runID int64) (string, error) {
 		return "", err
 	}
 
+	return g.renderHTML(data)
+}
+
+// GenerateHTMLFromExport generates an HTML report from a run exported with
+// "bench export json" or a saved session, such as a .firereport or
+// .firesession file
+func GenerateHTMLFromExport(r io.Reader) (string, error) {
+	export, err := session.Read(r)
+	if err != nil {
+		return "", err
+	}
+	// Sessions carry any number of runs; the report shows the newest
+	runs := export.AllRuns()
+	if len(runs) == 0 {
+		return "", fmt.Errorf("file does not contain a test run")
+	}
+	run, results := runs[0].Run, runs[0].Results
+
+	g := &Generator{}
+	data := &Data{
+		Run:          run,
+		Results:      results,
+		Plugin:       run.Plugin,
+		GeneratedAt:  time.Now(),
+		SystemInfo:   g.getSystemInfo(),
+		MetricGroups: g.groupMetrics(results),
+		Hardware:     export.Components,
+	}
+	return g.renderHTML(data)
+}
+
+// renderHTML executes the HTML template for the report data
+func (g *Generator) renderHTML(data *Data) (string, error) {
 	// Load template
 	tmpl, err := g.loadHTMLTemplate()
 	if err != nil {
@@ -104,6 +191,56 @@ func (g *Generator) loadReportData(runID int64) (*Data, error) {
 	// Group metrics
 	data.MetricGroups = g.groupMetrics(results)
 
+	// Power events explain failures that are not the hardware's fault
+	annotations, err := g.database.RunAnnotations(run)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get annotations: %w", err)
+	}
+	data.Annotations = annotations
+
+	notes, err := g.database.RunNotes(runID)
+	if err != nil {
+		return nil, err
+	}
+	data.Notes = notes
+
+	// The hardware the run was tested on
+	snapshot, err := inventory.ForRun(g.database, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hardware inventory: %w", err)
+	}
+	if snapshot != nil {
+		data.Hardware = snapshot.Components
+	}
+
+	// Each throttle event is also among the annotations
+	if throttled, seconds, ok := throttle.FromResults(results); ok {
+		data.Throttled = throttled
+		data.Throttling = "None"
+		if throttled {
+			data.Throttling = fmt.Sprintf("Throttled for %s", (time.Duration(seconds) * time.Second).String())
+		}
+	}
+
+	// So is each reading that found new ECC errors
+	if corrected, uncorrected, ok := ecc.FromResults(results); ok {
+		data.Uncorrected = uncorrected > 0
+		data.ECCErrors = (&ecc.Counts{Corrected: corrected, Uncorrected: uncorrected}).Describe()
+	}
+
+	// And each entry the BMC logged
+	if entries, ok := ipmi.FromResults(results); ok {
+		data.SELLogged = entries > 0
+		data.SELEntries = ipmi.Describe(entries)
+	}
+
+	// Compare with the advertised specs, measuring each from this run or the
+	// latest run of its plugin
+	if g.specs != nil {
+		data.SpecSheetName = g.specs.Name
+		data.SpecComparison = specsheet.Compare(g.specs, specsheet.FromDatabase(g.database, run, results))
+	}
+
 	return data, nil
 }
 
@@ -130,6 +267,16 @@ func (g *Generator) groupMetrics(results []*db.Result) []MetricGroup {
 
 		// Determine group based on metric name
 		switch {
+		case contains(result.Metric, []string{"warm_", "cache_gain_"}):
+			group = "Cache-Warm Results"
+		case contains(result.Metric, []string{"smart_"}):
+			group = "Drive Health"
+		case contains(result.Metric, []string{"throttle"}):
+			group = "Throttling"
+		case contains(result.Metric, []string{"ecc_"}):
+			group = "ECC Memory"
+		case contains(result.Metric, []string{"sel_"}):
+			group = "BMC Event Log"
 		case contains(result.Metric, []string{"cpu", "operations", "bogo"}):
 			group = "CPU Performance"
 		case contains(result.Metric, []string{"memory", "alloc", "heap"}):
@@ -138,10 +285,11 @@ func (g *Generator) groupMetrics(results []*db.Result) []MetricGroup {
 			group = "Disk Performance"
 		}
 
+		value, unit := g.units.Convert(result.Value, result.Unit)
 		display := MetricDisplay{
 			Name:  formatMetricName(result.Metric),
-			Value: formatValue(result.Value, result.Unit),
-			Unit:  result.Unit,
+			Value: formatValue(value, unit),
+			Unit:  unit,
 			Raw:   result.Value,
 		}
 
@@ -167,6 +315,7 @@ func (g *Generator) loadHTMLTemplate() (*template.Template, error) {
 		"formatTime": func(t time.Time) string {
 			return t.Format("2006-01-02 15:04:05")
 		},
+		"formatTags": db.FormatTags,
 		"formatDuration": func(d time.Duration) string {
 			return fmt.Sprintf("%.2f seconds", d.Seconds())
 		},
@@ -182,6 +331,16 @@ func (g *Generator) loadHTMLTemplate() (*template.Template, error) {
 			}
 			return "FAILED"
 		},
+		"specStatusClass": func(row specsheet.Row) string {
+			switch {
+			case !row.Found:
+				return "not-measured"
+			case row.Pass:
+				return "success"
+			default:
+				return "failure"
+			}
+		},
 	}
 
 	// Parse template
@@ -227,7 +386,7 @@ func formatValue(value float64, unit string) string {
 	switch {
 	case unit == "%":
 		return fmt.Sprintf("%.1f", value)
-	case unit == "MB/s" || unit == "ops/s":
+	case unit == "MB/s" || unit == "MiB/s" || unit == "ops/s":
 		return fmt.Sprintf("%.2f", value)
 	case value >= 1000000:
 		return fmt.Sprintf("%.2fM", value/1000000)
@@ -357,6 +516,17 @@ const htmlTemplate = `
             color: #C00;
             margin-top: 0;
         }
+        .spec-table td.success {
+            color: #28a745;
+            font-weight: 600;
+        }
+        .spec-table td.failure {
+            color: #dc3545;
+            font-weight: 600;
+        }
+        .spec-table td.not-measured {
+            color: #999;
+        }
         pre {
             background-color: #f4f4f4;
             padding: 10px;
@@ -391,6 +561,36 @@ const htmlTemplate = `
                 <h3>Exit Code</h3>
                 <p>{{.Run.ExitCode}}</p>
             </div>
+            {{if .Run.Environment}}
+            <div class="info-card">
+                <h3>Environment</h3>
+                <p>{{.Run.Environment}}</p>
+            </div>
+            {{end}}
+            {{if .Run.Tags}}
+            <div class="info-card">
+                <h3>Tags</h3>
+                <p>{{formatTags .Run.Tags}}</p>
+            </div>
+            {{end}}
+            {{if .Throttling}}
+            <div class="info-card">
+                <h3>Throttling</h3>
+                <p class="status {{statusClass (not .Throttled)}}">{{.Throttling}}</p>
+            </div>
+            {{end}}
+            {{if .ECCErrors}}
+            <div class="info-card">
+                <h3>ECC Errors</h3>
+                <p class="status {{statusClass (not .Uncorrected)}}">{{.ECCErrors}}</p>
+            </div>
+            {{end}}
+            {{if .SELEntries}}
+            <div class="info-card">
+                <h3>BMC Event Log</h3>
+                <p class="status {{statusClass (not .SELLogged)}}">{{.SELEntries}}</p>
+            </div>
+            {{end}}
         </div>
 
         {{if .Run.Error}}
@@ -449,6 +649,100 @@ const htmlTemplate = `
             {{end}}
         </div>
 
+        {{if .SpecComparison}}
+        <div class="metrics-section">
+            <h2>Specification Compliance{{if .SpecSheetName}} - {{.SpecSheetName}}{{end}}</h2>
+            <table class="metrics-table spec-table">
+                <thead>
+                    <tr>
+                        <th>Specification</th>
+                        <th>Advertised</th>
+                        <th>Measured</th>
+                        <th>Margin</th>
+                        <th>Tolerance</th>
+                        <th>Result</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{range .SpecComparison}}
+                    <tr>
+                        <td>{{.Spec.Name}}</td>
+                        <td>{{printf "%.0f" .Spec.Expected}} {{.Spec.Unit}}</td>
+                        <td>{{if .Found}}{{printf "%.0f" .Measured.Value}} {{.Spec.Unit}} (run #{{.Measured.RunID}}){{else}}-{{end}}</td>
+                        <td>{{if .Found}}{{printf "%+.1f%%" .Margin}}{{else}}-{{end}}</td>
+                        <td>-{{printf "%.1f%%" .Tolerance}}</td>
+                        <td class="{{specStatusClass .}}">{{.Status}}</td>
+                    </tr>
+                    {{end}}
+                </tbody>
+            </table>
+        </div>
+        {{end}}
+
+        {{if .Hardware}}
+        <div class="metrics-section">
+            <h2>Hardware Tested</h2>
+            <table class="metrics-table">
+                <thead>
+                    <tr>
+                        <th>Component</th>
+                        <th>Name</th>
+                        <th>Details</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{range .Hardware}}
+                    <tr>
+                        <td>{{.Type}}</td>
+                        <td>{{.Name}}</td>
+                        <td>{{range $key, $value := .Details}}{{$key}}: {{$value}}<br>{{end}}</td>
+                    </tr>
+                    {{end}}
+                </tbody>
+            </table>
+        </div>
+        {{end}}
+
+        {{if .Notes}}
+        <div class="metrics-section">
+            <h2>Notes</h2>
+            <table class="metrics-table">
+                <tbody>
+                    {{range .Notes}}
+                    <tr>
+                        <td>{{formatTime .CreatedAt}}</td>
+                        <td>{{.Text}}</td>
+                    </tr>
+                    {{end}}
+                </tbody>
+            </table>
+        </div>
+        {{end}}
+
+        {{if .Annotations}}
+        <div class="metrics-section">
+            <h2>Events</h2>
+            <table class="metrics-table">
+                <thead>
+                    <tr>
+                        <th>Time</th>
+                        <th>Source</th>
+                        <th>Event</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{range .Annotations}}
+                    <tr>
+                        <td>{{formatTime .Time}}</td>
+                        <td>{{.Source}}</td>
+                        <td>{{.Message}}</td>
+                    </tr>
+                    {{end}}
+                </tbody>
+            </table>
+        </div>
+        {{end}}
+
         <div class="footer">
             <p>Generated by F.I.R.E. on {{formatTime .GeneratedAt}}</p>
             <p>Full Intensity Rigorous Evaluation</p>
@@ -0,0 +1,183 @@
+// Package safeexec runs external tools without exposing them to shell
+// injection.
+//
+// Arguments are always passed as argv. Shell interpreters can only be reached
+// through Shell and PowerShell, which take a fixed script and validate every
+// value interpolated into it. Validation failures are reported through the
+// returned command's Err field, so callers use the result exactly like an
+// *exec.Cmd and see the error from Run, Output or Start.
+package safeexec
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+var (
+	// identifierPattern matches plugin names, device names, metric keys and similar tokens
+	identifierPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.:-]*$`)
+
+	// devicePattern matches Unix block device paths, Windows physical drive
+	// paths and smartctl controller ports such as /dev/csmi0,1
+	devicePattern = regexp.MustCompile(`^(/dev/[A-Za-z0-9][A-Za-z0-9/_.,-]*|\\\\\.\\PHYSICALDRIVE[0-9]+)$`)
+
+	// smartDeviceTypePattern matches smartctl -d values, optionally tunnelled
+	// (sat+megaraid) and followed by numeric ports (megaraid,0 or aacraid,0,0,1)
+	smartDeviceTypePattern = regexp.MustCompile(`^(sat\+|scsi\+)?(ata|scsi|sat|nvme|csmi|intelliprop|megaraid|areca|aacraid|cciss|3ware|hpt|jmb39x|sssraid)(,[0-9]+)*$`)
+
+	// shellValuePattern matches values that are safe to interpolate into cmd.exe
+	// and PowerShell scripts: no quotes, separators, redirections or variables.
+	shellValuePattern = regexp.MustCompile(`^[A-Za-z0-9 #,._:\\/-]*$`)
+)
+
+// shells lists interpreters that must not receive a command string via Command
+var shells = map[string]string{
+	"sh": "-c", "bash": "-c", "zsh": "-c", "dash": "-c",
+	"cmd": "/c", "powershell": "-command", "pwsh": "-command",
+}
+
+// Command returns a command that runs name with args as argv
+func Command(name string, args ...string) *exec.Cmd {
+	return CommandContext(context.Background(), name, args...)
+}
+
+// CommandContext is like Command but the process is killed when ctx is done
+func CommandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...) // #nosec G204 -- arguments are validated below and never reach a shell
+	if err := validateCommand(name, args); err != nil {
+		cmd.Err = err
+	}
+	return cmd
+}
+
+// Shell runs a cmd.exe command line built from format and values. Use it only
+// when cmd's own syntax (pipes, wmic where clauses) is required. Every value
+// must be an integer or a string matching a conservative character set.
+// Under WSL the Windows interpreter is reached as cmd.exe.
+func Shell(format string, values ...interface{}) *exec.Cmd {
+	line, err := interpolate(format, values)
+	cmd := exec.Command(windowsTool("cmd"), "/c", line) // #nosec G204 -- format is a constant and values are validated
+	if err != nil {
+		cmd.Err = err
+	}
+	return cmd
+}
+
+// PowerShell runs a PowerShell script non-interactively. Values are
+// interpolated like Shell; strings are validated and single-quoted.
+func PowerShell(script string, values ...interface{}) *exec.Cmd {
+	line, err := powerShellScript(script, values)
+	cmd := exec.Command(windowsTool("powershell"), "-NoProfile", "-NonInteractive", "-Command", line) // #nosec G204 -- script is a constant and values are validated
+	if err != nil {
+		cmd.Err = err
+	}
+	return cmd
+}
+
+// psValue marks a string for quoting as a PowerShell literal
+type psValue string
+
+// powerShellScript interpolates values into script, quoting strings as literals
+func powerShellScript(script string, values []interface{}) (string, error) {
+	quoted := make([]interface{}, len(values))
+	for i, v := range values {
+		if s, ok := v.(string); ok {
+			quoted[i] = psValue(s)
+			continue
+		}
+		quoted[i] = v
+	}
+	return interpolate(script, quoted)
+}
+
+// ValidateIdentifier checks that s is a plain token such as a plugin or device name
+func ValidateIdentifier(s string) error {
+	if !identifierPattern.MatchString(s) {
+		return fmt.Errorf("invalid identifier %q", s)
+	}
+	return nil
+}
+
+// ValidateDevicePath checks that s is a block device path such as /dev/sda
+// or \\.\PHYSICALDRIVE0
+func ValidateDevicePath(s string) error {
+	if !devicePattern.MatchString(s) || strings.Contains(s, "..") {
+		return fmt.Errorf("invalid device path %q", s)
+	}
+	return nil
+}
+
+// ValidateSMARTDeviceType checks that s is a smartctl device type such as
+// nvme or megaraid,0
+func ValidateSMARTDeviceType(s string) error {
+	if !smartDeviceTypePattern.MatchString(s) {
+		return fmt.Errorf("invalid smartctl device type %q", s)
+	}
+	return nil
+}
+
+// QuotePowerShell returns s as a single-quoted PowerShell string literal
+func QuotePowerShell(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// windowsTool returns the name used to start a Windows executable. Under WSL
+// the .exe suffix is needed to reach the Windows side.
+func windowsTool(name string) string {
+	if runtime.GOOS == "windows" {
+		return name
+	}
+	return name + ".exe"
+}
+
+// validateCommand rejects program names and arguments that could be
+// reinterpreted by a shell
+func validateCommand(name string, args []string) error {
+	if name == "" || strings.ContainsAny(name, "\x00\n\r;&|<>`$") {
+		return fmt.Errorf("invalid program name %q", name)
+	}
+	for _, arg := range args {
+		if strings.ContainsRune(arg, 0) {
+			return fmt.Errorf("argument for %s contains a NUL byte", name)
+		}
+	}
+
+	base := strings.ToLower(strings.TrimSuffix(filepath.Base(name), ".exe"))
+	if flag, ok := shells[base]; ok {
+		for _, arg := range args {
+			if strings.EqualFold(arg, flag) || strings.EqualFold(arg, "-c") {
+				return fmt.Errorf("refusing to pass a command string to %s; use safeexec.Shell or safeexec.PowerShell", name)
+			}
+		}
+	}
+	return nil
+}
+
+// interpolate formats values into format after validating each of them
+func interpolate(format string, values []interface{}) (string, error) {
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		switch val := v.(type) {
+		case int, int32, int64, uint, uint32, uint64:
+			args[i] = val
+		case psValue:
+			if !shellValuePattern.MatchString(string(val)) {
+				return "", fmt.Errorf("unsafe value %q", string(val))
+			}
+			args[i] = QuotePowerShell(string(val))
+		case string:
+			if !shellValuePattern.MatchString(val) {
+				return "", fmt.Errorf("unsafe value %q", val)
+			}
+			args[i] = val
+		default:
+			return "", fmt.Errorf("unsupported value type %T", v)
+		}
+	}
+	return fmt.Sprintf(format, args...), nil
+}
@@ -0,0 +1,93 @@
+package safeexec
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCommandRejectsShellStrings(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{"smartctl", []string{"-i", "/dev/sda"}, false},
+		{"stress-ng", []string{"--cpu", "4", "--timeout", "60s"}, false},
+		{"sh", []string{"-c", "rm -rf /"}, true},
+		{"cmd.exe", []string{"/C", "dir"}, true},
+		{"powershell", []string{"-NoProfile", "-Command", "Get-Date"}, true},
+		{"smartctl; reboot", nil, true},
+		{"smartctl", []string{"-i\x00"}, true},
+	}
+
+	for _, tt := range tests {
+		err := validateCommand(tt.name, tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateCommand(%q, %q) err = %v, wantErr %v", tt.name, tt.args, err, tt.wantErr)
+		}
+	}
+}
+
+func TestShellValidatesValues(t *testing.T) {
+	line, err := interpolate("wmic diskdrive where Index=%d get Model", []interface{}{3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if line != "wmic diskdrive where Index=3 get Model" {
+		t.Errorf("unexpected command line %q", line)
+	}
+
+	if _, err := interpolate("wmic path X where DeviceID=%q get Y", []interface{}{"Disk #0, Partition #1"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	for _, bad := range []string{`x" & del C:\`, "a|b", "%PATH%", "$(whoami)"} {
+		if _, err := interpolate("echo %s", []interface{}{bad}); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestPowerShellQuotesStrings(t *testing.T) {
+	script, err := powerShellScript("Get-PhysicalDisk -FriendlyName %s", []interface{}{"Samsung SSD 980"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(script, "'Samsung SSD 980'") {
+		t.Errorf("string was not quoted: %q", script)
+	}
+
+	if _, err := powerShellScript("Get-Item %s", []interface{}{"a'; Remove-Item C:\\"}); err == nil {
+		t.Error("expected quote injection to be rejected")
+	}
+}
+
+func TestValidators(t *testing.T) {
+	for _, ok := range []string{"/dev/sda", "/dev/nvme0n1", `\\.\PHYSICALDRIVE2`, "/dev/csmi0,1"} {
+		if err := ValidateDevicePath(ok); err != nil {
+			t.Errorf("ValidateDevicePath(%q) = %v", ok, err)
+		}
+	}
+	for _, bad := range []string{"/dev/../etc/passwd", "/dev/sda; reboot", "sda", "-d /dev/sda"} {
+		if err := ValidateDevicePath(bad); err == nil {
+			t.Errorf("ValidateDevicePath(%q) should fail", bad)
+		}
+	}
+
+	for _, ok := range []string{"nvme", "megaraid,0", "aacraid,0,0,1", "sat+megaraid,2"} {
+		if err := ValidateSMARTDeviceType(ok); err != nil {
+			t.Errorf("ValidateSMARTDeviceType(%q) = %v", ok, err)
+		}
+	}
+	for _, bad := range []string{"", "test", "megaraid,a", "nvme -s on"} {
+		if err := ValidateSMARTDeviceType(bad); err == nil {
+			t.Errorf("ValidateSMARTDeviceType(%q) should fail", bad)
+		}
+	}
+	if err := ValidateIdentifier("cpu"); err != nil {
+		t.Errorf("ValidateIdentifier(cpu) = %v", err)
+	}
+	if err := ValidateIdentifier("--help"); err == nil {
+		t.Error("identifiers must not look like flags")
+	}
+}
@@ -0,0 +1,166 @@
+// Package safety stops a test before it cooks the machine.
+//
+// Limits set the highest CPU temperature, GPU temperature and power draw a
+// test may reach. Watch reads the sensors the way bench monitor does while
+// the test runs and cancels it with a *LimitError as soon as a reading
+// crosses a limit, so the reason ends up in the run record.
+package safety
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/monitor"
+)
+
+// DefaultInterval is the time between readings
+const DefaultInterval = 2 * time.Second
+
+// ErrLimit is wrapped by every *LimitError
+var ErrLimit = errors.New("safety limit reached")
+
+var (
+	gpuTemp  = regexp.MustCompile(`^gpu\d+_temp$`)
+	gpuPower = regexp.MustCompile(`^gpu\d+_power$`)
+)
+
+// Limits are the readings at which a test is stopped. A zero limit is not
+// checked. They are the "safety" section of the settings file.
+type Limits struct {
+	CPUTempC float64 `json:"cpu_temp"` // CPU package temperature
+	GPUTempC float64 `json:"gpu_temp"` // Any GPU's temperature
+	PowerW   float64 `json:"power"`    // CPU package power plus the power of every GPU
+}
+
+// Enabled reports whether any limit is set
+func (l Limits) Enabled() bool {
+	return l.CPUTempC > 0 || l.GPUTempC > 0 || l.PowerW > 0
+}
+
+// String lists the limits that are set, e.g. "CPU 95 °C, power 600 W"
+func (l Limits) String() string {
+	s := ""
+	add := func(name string, value float64, unit string) {
+		if value <= 0 {
+			return
+		}
+		if s != "" {
+			s += ", "
+		}
+		s += name + " " + strconv.FormatFloat(value, 'f', -1, 64) + " " + unit
+	}
+	add("CPU", l.CPUTempC, "°C")
+	add("GPU", l.GPUTempC, "°C")
+	add("power", l.PowerW, "W")
+	return s
+}
+
+// LimitError says which limit a reading crossed
+type LimitError struct {
+	Reading string // The metric, e.g. cpu_temp or gpu1_temp, or "power"
+	Value   float64
+	Limit   float64
+	Unit    string
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s: %s reached %.1f %s (limit %s %s)", ErrLimit, e.Reading, e.Value, e.Unit,
+		strconv.FormatFloat(e.Limit, 'f', -1, 64), e.Unit)
+}
+
+// Unwrap lets errors.Is match ErrLimit
+func (e *LimitError) Unwrap() error {
+	return ErrLimit
+}
+
+// Check returns a *LimitError for the first limit the readings cross, or
+// nil. Readings are named as monitor.Sample.Metrics names them; one that
+// was not taken never crosses its limit.
+func (l Limits) Check(values map[string]float64) error {
+	if l.CPUTempC > 0 {
+		if v, ok := values["cpu_temp"]; ok && v >= l.CPUTempC {
+			return &LimitError{Reading: "cpu_temp", Value: v, Limit: l.CPUTempC, Unit: "°C"}
+		}
+	}
+
+	power, powerRead := values["cpu_power"]
+	for name, v := range values {
+		if l.GPUTempC > 0 && gpuTemp.MatchString(name) && v >= l.GPUTempC {
+			return &LimitError{Reading: name, Value: v, Limit: l.GPUTempC, Unit: "°C"}
+		}
+		if gpuPower.MatchString(name) {
+			power += v
+			powerRead = true
+		}
+	}
+
+	if l.PowerW > 0 && powerRead && power >= l.PowerW {
+		return &LimitError{Reading: "power", Value: power, Limit: l.PowerW, Unit: "W"}
+	}
+	return nil
+}
+
+// Unread names the limits that are set but have no reading in values, e.g.
+// "GPU temperature", so they can be reported as not enforced
+func (l Limits) Unread(values map[string]float64) []string {
+	var gpuTempRead, powerRead bool
+	for name := range values {
+		gpuTempRead = gpuTempRead || gpuTemp.MatchString(name)
+		powerRead = powerRead || name == "cpu_power" || gpuPower.MatchString(name)
+	}
+	var unread []string
+	if _, ok := values["cpu_temp"]; l.CPUTempC > 0 && !ok {
+		unread = append(unread, "CPU temperature")
+	}
+	if l.GPUTempC > 0 && !gpuTempRead {
+		unread = append(unread, "GPU temperature")
+	}
+	if l.PowerW > 0 && !powerRead {
+		unread = append(unread, "power")
+	}
+	return unread
+}
+
+// SampleFunc takes one set of readings
+type SampleFunc func(ctx context.Context) (map[string]float64, error)
+
+// MonitorSampler reads the sensors through a monitor poller
+func MonitorSampler() SampleFunc {
+	poller := monitor.NewPoller()
+	return func(ctx context.Context) (map[string]float64, error) {
+		s, err := poller.Sample(ctx)
+		if err != nil {
+			return nil, err
+		}
+		values, _ := s.Metrics()
+		return values, nil
+	}
+}
+
+// Watch checks the limits every interval until ctx ends, and calls stop
+// with the *LimitError of the first limit crossed. It returns once ctx ends
+// or a limit is crossed.
+func Watch(ctx context.Context, l Limits, sample SampleFunc, interval time.Duration, stop context.CancelCauseFunc) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if values, err := sample(ctx); err == nil {
+			if err := l.Check(values); err != nil {
+				stop(err)
+				return
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package safety
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCheck(t *testing.T) {
+	l := Limits{CPUTempC: 95, GPUTempC: 90, PowerW: 600}
+	if l.String() != "CPU 95 °C, GPU 90 °C, power 600 W" {
+		t.Errorf("unexpected description %q", l.String())
+	}
+
+	if err := l.Check(map[string]float64{"cpu_temp": 94.9, "gpu0_temp": 80, "cpu_power": 200, "gpu0_power": 350}); err != nil {
+		t.Errorf("expected readings under the limits to pass, got %v", err)
+	}
+
+	tests := []struct {
+		values  map[string]float64
+		reading string
+		value   float64
+	}{
+		{map[string]float64{"cpu_temp": 95}, "cpu_temp", 95},
+		{map[string]float64{"cpu_temp": 70, "gpu0_temp": 60, "gpu1_temp": 91}, "gpu1_temp", 91},
+		// The CPU and both GPUs together draw more than the limit
+		{map[string]float64{"cpu_power": 250, "gpu0_power": 200, "gpu1_power": 180}, "power", 630},
+	}
+	for _, tt := range tests {
+		err := l.Check(tt.values)
+		var limit *LimitError
+		if !errors.As(err, &limit) {
+			t.Errorf("expected %v to cross a limit, got %v", tt.values, err)
+			continue
+		}
+		if limit.Reading != tt.reading || limit.Value != tt.value {
+			t.Errorf("expected %s at %.0f, got %+v", tt.reading, tt.value, limit)
+		}
+		if !errors.Is(err, ErrLimit) {
+			t.Errorf("expected %v to wrap ErrLimit", err)
+		}
+	}
+
+	if got := (&LimitError{Reading: "cpu_temp", Value: 96.2, Limit: 95, Unit: "°C"}).Error(); got != "safety limit reached: cpu_temp reached 96.2 °C (limit 95 °C)" {
+		t.Errorf("unexpected message %q", got)
+	}
+
+	if err := (Limits{CPUTempC: 95}).Check(map[string]float64{"gpu0_temp": 120, "cpu_power": 900}); err != nil {
+		t.Errorf("expected limits that are not set to be ignored, got %v", err)
+	}
+	if got := l.Unread(map[string]float64{"cpu_temp": 50, "gpu0_power": 100}); len(got) != 1 || got[0] != "GPU temperature" {
+		t.Errorf("expected only the GPU temperature to be unread, got %v", got)
+	}
+	if (Limits{}).Enabled() {
+		t.Error("expected no limits to be disabled")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	readings := []float64{80, 88, 96}
+	n := 0
+	sample := func(context.Context) (map[string]float64, error) {
+		v := readings[n]
+		if n < len(readings)-1 {
+			n++
+		}
+		return map[string]float64{"cpu_temp": v}, nil
+	}
+
+	ctx, stop := context.WithCancelCause(context.Background())
+	defer stop(nil)
+	done := make(chan struct{})
+	go func() {
+		Watch(ctx, Limits{CPUTempC: 95}, sample, time.Millisecond, stop)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the watch to stop at the limit")
+	}
+	var limit *LimitError
+	if !errors.As(context.Cause(ctx), &limit) || limit.Value != 96 {
+		t.Errorf("expected the run to be cancelled by the limit, got %v", context.Cause(ctx))
+	}
+}
@@ -0,0 +1,92 @@
+package schedule
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/journal"
+)
+
+// DaemonInterval is how often a running scheduler records a heartbeat
+const DaemonInterval = journal.DefaultInterval
+
+// daemonTimeout is how long after its last heartbeat a scheduler on another
+// machine is taken to have stopped
+const daemonTimeout = 3 * DaemonInterval
+
+// Daemon is a scheduler recorded as running on a machine
+type Daemon struct {
+	Machine   string
+	PID       int
+	StartedAt time.Time
+	Heartbeat time.Time
+}
+
+// Running reports whether the scheduler still runs. One on this machine
+// runs while its process does; one on another machine sharing the database
+// must have recorded a heartbeat recently.
+func (d *Daemon) Running(now time.Time) bool {
+	if d.Machine == db.Hostname() {
+		return journal.Alive(d.PID, d.StartedAt)
+	}
+	return now.Sub(d.Heartbeat) < daemonTimeout
+}
+
+// RegisterDaemon records the scheduler running in the process pid on this
+// machine, replacing the record of an earlier one
+func (s *Store) RegisterDaemon(pid int, started time.Time) error {
+	machine := db.Hostname()
+	if _, err := s.db.Exec(`DELETE FROM schedulers WHERE machine = ?`, machine); err != nil {
+		return fmt.Errorf("failed to record scheduler: %w", err)
+	}
+	_, err := s.db.Exec(
+		`INSERT INTO schedulers (machine, pid, started_at, heartbeat_at) VALUES (?, ?, ?, ?)`,
+		machine, pid, started, started,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record scheduler: %w", err)
+	}
+	return nil
+}
+
+// DaemonHeartbeat records that the scheduler in the process pid still runs
+func (s *Store) DaemonHeartbeat(pid int, at time.Time) error {
+	_, err := s.db.Exec(
+		`UPDATE schedulers SET heartbeat_at = ? WHERE machine = ? AND pid = ?`,
+		at, db.Hostname(), pid,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record scheduler heartbeat: %w", err)
+	}
+	return nil
+}
+
+// UnregisterDaemon removes the record of the scheduler in the process pid
+func (s *Store) UnregisterDaemon(pid int) error {
+	_, err := s.db.Exec(`DELETE FROM schedulers WHERE machine = ? AND pid = ?`, db.Hostname(), pid)
+	if err != nil {
+		return fmt.Errorf("failed to remove scheduler: %w", err)
+	}
+	return nil
+}
+
+// Daemons returns the recorded schedulers by machine, including any that
+// stopped without removing their record; see Daemon.Running
+func (s *Store) Daemons() ([]*Daemon, error) {
+	rows, err := s.db.Query(`SELECT machine, pid, started_at, heartbeat_at FROM schedulers ORDER BY machine`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedulers: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var daemons []*Daemon
+	for rows.Next() {
+		d := &Daemon{}
+		if err := rows.Scan(&d.Machine, &d.PID, &d.StartedAt, &d.Heartbeat); err != nil {
+			return nil, fmt.Errorf("failed to scan scheduler: %w", err)
+		}
+		daemons = append(daemons, d)
+	}
+	return daemons, rows.Err()
+}
@@ -0,0 +1,127 @@
+package schedule
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/robfig/cron/v3"
+)
+
+func TestStoreDaemons(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	store := NewStore(database)
+	started := time.Now()
+	if err := store.RegisterDaemon(os.Getpid(), started); err != nil {
+		t.Fatalf("failed to register scheduler: %v", err)
+	}
+	// A restarted scheduler replaces the record of the earlier one
+	if err := store.RegisterDaemon(os.Getpid(), started); err != nil {
+		t.Fatalf("failed to register scheduler again: %v", err)
+	}
+	beat := started.Add(30 * time.Second)
+	if err := store.DaemonHeartbeat(os.Getpid(), beat); err != nil {
+		t.Fatalf("failed to record heartbeat: %v", err)
+	}
+
+	daemons, err := store.Daemons()
+	if err != nil {
+		t.Fatalf("failed to list schedulers: %v", err)
+	}
+	if len(daemons) != 1 || daemons[0].Machine != db.Hostname() || daemons[0].PID != os.Getpid() || !daemons[0].Heartbeat.Equal(beat) {
+		t.Fatalf("expected this process's scheduler, got %+v", daemons)
+	}
+	if !daemons[0].Running(time.Now()) {
+		t.Error("expected the scheduler of this process to be running")
+	}
+
+	if err := store.UnregisterDaemon(os.Getpid()); err != nil {
+		t.Fatalf("failed to remove scheduler: %v", err)
+	}
+	if daemons, _ := store.Daemons(); len(daemons) != 0 {
+		t.Errorf("expected no schedulers, got %+v", daemons)
+	}
+}
+
+func TestDaemonRunningElsewhere(t *testing.T) {
+	now := time.Now()
+	d := &Daemon{Machine: db.Hostname() + "-other", PID: 1, StartedAt: now.Add(-time.Hour), Heartbeat: now.Add(-DaemonInterval)}
+	if !d.Running(now) {
+		t.Error("expected a scheduler with a recent heartbeat to be running")
+	}
+	d.Heartbeat = now.Add(-daemonTimeout)
+	if d.Running(now) {
+		t.Error("expected a scheduler without heartbeats to have stopped")
+	}
+}
+
+func TestRunnerReload(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	store := NewStore(database)
+	runner := NewRunner(database, nil)
+	registered := func() map[int64]cron.EntryID {
+		runner.mu.RLock()
+		defer runner.mu.RUnlock()
+		jobs := make(map[int64]cron.EntryID, len(runner.jobs))
+		for id, entry := range runner.jobs {
+			jobs[id] = entry
+		}
+		return jobs
+	}
+
+	sched := &Schedule{Name: "Nightly", CronExpr: "0 2 * * *", Plugin: "cpu", Enabled: true}
+	if err := store.Create(sched); err != nil {
+		t.Fatalf("failed to create schedule: %v", err)
+	}
+	if err := runner.Reload(); err != nil {
+		t.Fatalf("failed to reload: %v", err)
+	}
+	before, ok := registered()[sched.ID]
+	if !ok {
+		t.Fatal("expected the new schedule to be registered")
+	}
+
+	// Recording a run touches updated_at but leaves the job as it is
+	if err := store.UpdateLastRun(sched.ID, 1); err != nil {
+		t.Fatalf("failed to record the last run: %v", err)
+	}
+	if err := runner.Reload(); err != nil {
+		t.Fatalf("failed to reload: %v", err)
+	}
+	if after := registered()[sched.ID]; after != before {
+		t.Errorf("expected the schedule to stay registered after a run, got entry %d (was %d)", after, before)
+	}
+
+	sched.CronExpr = "0 3 * * *"
+	if err := store.Update(sched); err != nil {
+		t.Fatalf("failed to update schedule: %v", err)
+	}
+	if err := runner.Reload(); err != nil {
+		t.Fatalf("failed to reload: %v", err)
+	}
+	if after, ok := registered()[sched.ID]; !ok || after == before {
+		t.Errorf("expected the edited schedule to be registered again, got entry %d (was %d)", after, before)
+	}
+
+	if err := store.Disable(sched.ID); err != nil {
+		t.Fatalf("failed to disable schedule: %v", err)
+	}
+	if err := runner.Reload(); err != nil {
+		t.Fatalf("failed to reload: %v", err)
+	}
+	if loaded := registered(); len(loaded) != 0 {
+		t.Errorf("expected the disabled schedule to be unregistered, got %v", loaded)
+	}
+}
@@ -5,22 +5,25 @@ import (
 	"time"
 
 	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/notify"
 )
 
 // Schedule represents a scheduled test configuration
 type Schedule struct {
-	ID          int64       `json:"id"`
-	Name        string      `json:"name"`
-	Description string      `json:"description"`
-	CronExpr    string      `json:"cron_expr"`
-	Plugin      string      `json:"plugin"`
-	Params      db.JSONData `json:"params"`
-	Enabled     bool        `json:"enabled"`
-	LastRunID   *int64      `json:"last_run_id"`
-	LastRunTime *time.Time  `json:"last_run_time"`
-	NextRunTime *time.Time  `json:"next_run_time"`
-	CreatedAt   time.Time   `json:"created_at"`
-	UpdatedAt   time.Time   `json:"updated_at"`
+	ID          int64          `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	CronExpr    string         `json:"cron_expr"`
+	Plugin      string         `json:"plugin"`
+	Params      db.JSONData    `json:"params"`
+	Notify      *notify.Notify `json:"notify,omitempty"` // Who hears about each run
+	Enabled     bool           `json:"enabled"`
+	Resume      bool           `json:"resume,omitempty"` // Start again at once when a run was interrupted
+	LastRunID   *int64         `json:"last_run_id"`
+	LastRunTime *time.Time     `json:"last_run_time"`
+	NextRunTime *time.Time     `json:"next_run_time"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
 }
 
 // Filter represents filters for querying schedules
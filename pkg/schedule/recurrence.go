@@ -0,0 +1,170 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// cronParser parses the five-field cron expressions used by schedules
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+
+// Frequency is how often a recurrence repeats
+type Frequency string
+
+// Frequencies offered by the GUI recurrence picker
+const (
+	Hourly   Frequency = "hourly"
+	Daily    Frequency = "daily"
+	Weekdays Frequency = "weekdays"
+	Weekly   Frequency = "weekly"
+	Monthly  Frequency = "monthly"
+	Custom   Frequency = "custom" // Any other cron expression
+)
+
+// Frequencies lists the frequencies in the order the picker shows them
+var Frequencies = []Frequency{Hourly, Daily, Weekdays, Weekly, Monthly, Custom}
+
+// Recurrence is a human-friendly description of a cron expression
+type Recurrence struct {
+	Frequency Frequency
+	Minute    int          // 0-59
+	Hour      int          // 0-23, unused for Hourly
+	Weekday   time.Weekday // Weekly only
+	Day       int          // 1-31, Monthly only
+	Expr      string       // Custom only
+}
+
+// CronExpr converts the recurrence into a cron expression
+func (r Recurrence) CronExpr() (string, error) {
+	if r.Frequency == Custom {
+		if _, err := cronParser.Parse(r.Expr); err != nil {
+			return "", fmt.Errorf("invalid cron expression: %w", err)
+		}
+		return r.Expr, nil
+	}
+
+	if r.Minute < 0 || r.Minute > 59 {
+		return "", fmt.Errorf("minute must be between 0 and 59")
+	}
+	if r.Frequency != Hourly && (r.Hour < 0 || r.Hour > 23) {
+		return "", fmt.Errorf("hour must be between 0 and 23")
+	}
+
+	switch r.Frequency {
+	case Hourly:
+		return fmt.Sprintf("%d * * * *", r.Minute), nil
+	case Daily:
+		return fmt.Sprintf("%d %d * * *", r.Minute, r.Hour), nil
+	case Weekdays:
+		return fmt.Sprintf("%d %d * * 1-5", r.Minute, r.Hour), nil
+	case Weekly:
+		if r.Weekday < time.Sunday || r.Weekday > time.Saturday {
+			return "", fmt.Errorf("invalid weekday %d", r.Weekday)
+		}
+		return fmt.Sprintf("%d %d * * %d", r.Minute, r.Hour, r.Weekday), nil
+	case Monthly:
+		if r.Day < 1 || r.Day > 31 {
+			return "", fmt.Errorf("day of month must be between 1 and 31")
+		}
+		return fmt.Sprintf("%d %d %d * *", r.Minute, r.Hour, r.Day), nil
+	default:
+		return "", fmt.Errorf("unknown frequency %q", r.Frequency)
+	}
+}
+
+// ParseRecurrence recognises the cron expressions produced by CronExpr.
+// Anything else is returned as a Custom recurrence.
+func ParseRecurrence(expr string) Recurrence {
+	custom := Recurrence{Frequency: Custom, Expr: expr}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 || fields[3] != "*" {
+		return custom
+	}
+	minute, ok := parseField(fields[0], 0, 59)
+	if !ok {
+		return custom
+	}
+	if fields[1] == "*" {
+		if fields[2] == "*" && fields[4] == "*" {
+			return Recurrence{Frequency: Hourly, Minute: minute}
+		}
+		return custom
+	}
+	hour, ok := parseField(fields[1], 0, 23)
+	if !ok {
+		return custom
+	}
+
+	r := Recurrence{Minute: minute, Hour: hour}
+	switch {
+	case fields[2] == "*" && fields[4] == "*":
+		r.Frequency = Daily
+	case fields[2] == "*" && fields[4] == "1-5":
+		r.Frequency = Weekdays
+	case fields[2] == "*":
+		day, ok := parseField(fields[4], 0, 6)
+		if !ok {
+			return custom
+		}
+		r.Frequency = Weekly
+		r.Weekday = time.Weekday(day)
+	case fields[4] == "*":
+		day, ok := parseField(fields[2], 1, 31)
+		if !ok {
+			return custom
+		}
+		r.Frequency = Monthly
+		r.Day = day
+	default:
+		return custom
+	}
+	return r
+}
+
+// parseField parses a single numeric cron field within [lo, hi]
+func parseField(field string, lo, hi int) (int, bool) {
+	n, err := strconv.Atoi(field)
+	if err != nil || n < lo || n > hi {
+		return 0, false
+	}
+	return n, true
+}
+
+// String describes the recurrence, e.g. "Every weekday at 02:00"
+func (r Recurrence) String() string {
+	at := fmt.Sprintf("%02d:%02d", r.Hour, r.Minute)
+	switch r.Frequency {
+	case Hourly:
+		return fmt.Sprintf("Every hour at :%02d", r.Minute)
+	case Daily:
+		return "Every day at " + at
+	case Weekdays:
+		return "Every weekday at " + at
+	case Weekly:
+		return fmt.Sprintf("Every %s at %s", r.Weekday, at)
+	case Monthly:
+		return fmt.Sprintf("Monthly on day %d at %s", r.Day, at)
+	default:
+		return "Cron: " + r.Expr
+	}
+}
+
+// NextRuns returns the next n times a cron expression fires after from
+func NextRuns(expr string, from time.Time, n int) ([]time.Time, error) {
+	sched, err := cronParser.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression: %w", err)
+	}
+	runs := make([]time.Time, 0, n)
+	t := from
+	for i := 0; i < n; i++ {
+		t = sched.Next(t)
+		runs = append(runs, t)
+	}
+	return runs, nil
+}
@@ -0,0 +1,74 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecurrenceRoundTrip(t *testing.T) {
+	tests := []struct {
+		recurrence Recurrence
+		expr       string
+		text       string
+	}{
+		{Recurrence{Frequency: Hourly, Minute: 15}, "15 * * * *", "Every hour at :15"},
+		{Recurrence{Frequency: Daily, Hour: 2}, "0 2 * * *", "Every day at 02:00"},
+		{Recurrence{Frequency: Weekdays, Hour: 23, Minute: 30}, "30 23 * * 1-5", "Every weekday at 23:30"},
+		{Recurrence{Frequency: Weekly, Hour: 3, Minute: 30, Weekday: time.Monday}, "30 3 * * 1", "Every Monday at 03:30"},
+		{Recurrence{Frequency: Monthly, Hour: 4, Day: 1}, "0 4 1 * *", "Monthly on day 1 at 04:00"},
+	}
+	for _, tt := range tests {
+		expr, err := tt.recurrence.CronExpr()
+		if err != nil {
+			t.Fatalf("%+v: unexpected error %v", tt.recurrence, err)
+		}
+		if expr != tt.expr {
+			t.Errorf("expected %q, got %q", tt.expr, expr)
+		}
+		if got := ParseRecurrence(expr); got != tt.recurrence {
+			t.Errorf("ParseRecurrence(%q) = %+v, expected %+v", expr, got, tt.recurrence)
+		}
+		if got := tt.recurrence.String(); got != tt.text {
+			t.Errorf("expected %q, got %q", tt.text, got)
+		}
+	}
+}
+
+func TestParseRecurrenceCustom(t *testing.T) {
+	for _, expr := range []string{"*/5 * * * *", "0 2 * 6 *", "0 9-17 * * *", "0 2 1 * 1"} {
+		if r := ParseRecurrence(expr); r.Frequency != Custom || r.Expr != expr {
+			t.Errorf("expected %q to be custom, got %+v", expr, r)
+		}
+	}
+}
+
+func TestRecurrenceValidation(t *testing.T) {
+	invalid := []Recurrence{
+		{Frequency: Daily, Hour: 24},
+		{Frequency: Hourly, Minute: 60},
+		{Frequency: Monthly, Day: 0},
+		{Frequency: Custom, Expr: "not cron"},
+	}
+	for _, r := range invalid {
+		if _, err := r.CronExpr(); err == nil {
+			t.Errorf("expected an error for %+v", r)
+		}
+	}
+}
+
+func TestNextRuns(t *testing.T) {
+	from := time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC) // a Friday
+	runs, err := NextRuns("0 2 * * 1-5", from, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []time.Time{
+		time.Date(2025, 3, 10, 2, 0, 0, 0, time.UTC),
+		time.Date(2025, 3, 11, 2, 0, 0, 0, time.UTC),
+	}
+	for i := range expected {
+		if !runs[i].Equal(expected[i]) {
+			t.Errorf("run %d: expected %v, got %v", i, expected[i], runs[i])
+		}
+	}
+}
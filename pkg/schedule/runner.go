@@ -2,13 +2,22 @@ package schedule
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"os"
 	"sync"
 	"time"
 
 	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/hooks"
+	"github.com/mscrnt/project_fire/pkg/journal"
+	"github.com/mscrnt/project_fire/pkg/notify"
 	"github.com/mscrnt/project_fire/pkg/plugin"
+	"github.com/mscrnt/project_fire/pkg/power"
+	"github.com/mscrnt/project_fire/pkg/safety"
+	"github.com/mscrnt/project_fire/pkg/testrun"
 	"github.com/robfig/cron/v3"
 )
 
@@ -18,10 +27,31 @@ type Runner struct {
 	store    *Store
 	database *db.DB
 	jobs     map[int64]cron.EntryID
+	loaded   map[int64]string // Definitions of the registered schedules
 	mu       sync.RWMutex
 	logger   *log.Logger
+	hooks    hooks.Config
+	limits   safety.Limits
 	ctx      context.Context
 	cancel   context.CancelFunc
+
+	// Wake the machine from sleep wakeLead before the next scheduled run
+	wake     bool
+	wakeLead time.Duration
+	wakeMu   sync.Mutex
+	wakeAt   time.Time
+
+	// Hold new runs while a UPS is on battery; see SetPowerMonitor
+	power   *power.Monitor
+	runsMu  sync.Mutex
+	running map[int64]context.CancelCauseFunc
+	runs    sync.WaitGroup
+
+	// Called once each run is recorded; see SetOnFinish
+	onFinish func(ctx context.Context, schedule *Schedule, run *db.Run)
+
+	// Stops the heartbeat recording that the scheduler runs
+	stopBeat func()
 }
 
 // NewRunner creates a new schedule runner
@@ -37,12 +67,63 @@ func NewRunner(database *db.DB, logger *log.Logger) *Runner {
 		store:    NewStore(database),
 		database: database,
 		jobs:     make(map[int64]cron.EntryID),
+		loaded:   make(map[int64]string),
+		running:  make(map[int64]context.CancelCauseFunc),
 		logger:   logger,
 		ctx:      ctx,
 		cancel:   cancel,
 	}
 }
 
+// SetHooks sets the scripts run before and after every scheduled test
+func (r *Runner) SetHooks(cfg hooks.Config) {
+	r.hooks = cfg
+}
+
+// SetLimits sets the readings at which every scheduled test is stopped
+func (r *Runner) SetLimits(limits safety.Limits) {
+	r.limits = limits
+}
+
+// SetWake programs a hardware wake lead before each scheduled run, so the
+// machine can sleep between test windows
+func (r *Runner) SetWake(lead time.Duration) {
+	r.wake = true
+	r.wakeLead = lead
+}
+
+// SetPowerMonitor makes the runner skip scheduled tests while any UPS
+// watched by m is on battery. Runs already in progress are stopped with
+// StopRuns.
+func (r *Runner) SetPowerMonitor(m *power.Monitor) {
+	r.power = m
+}
+
+// SetOnFinish sets a function called after every scheduled run is recorded,
+// whether it passed, failed or was aborted by a pre-run hook, such as to mail
+// its report. Stop waits for it to return.
+func (r *Runner) SetOnFinish(fn func(ctx context.Context, schedule *Schedule, run *db.Run)) {
+	r.onFinish = fn
+}
+
+// finished calls the SetOnFinish function. It is not cancelled by Stop, so a
+// report being sent as the scheduler shuts down still goes out.
+func (r *Runner) finished(schedule *Schedule, run *db.Run) {
+	if r.onFinish != nil {
+		r.onFinish(context.WithoutCancel(r.ctx), schedule, run)
+	}
+}
+
+// StopRuns cancels the runs in progress, recording cause as their error
+func (r *Runner) StopRuns(cause error) {
+	r.runsMu.Lock()
+	defer r.runsMu.Unlock()
+	for id, cancel := range r.running {
+		r.logger.Printf("Stopping run %d: %v", id, cause)
+		cancel(cause)
+	}
+}
+
 // Start starts the scheduler
 func (r *Runner) Start() error {
 	r.logger.Println("Starting scheduler...")
@@ -64,10 +145,56 @@ func (r *Runner) Start() error {
 	// Start cron scheduler
 	r.cron.Start()
 
+	// Let the GUI and bench schedule status tell that the scheduler runs
+	pid := os.Getpid()
+	if err := r.store.RegisterDaemon(pid, time.Now()); err != nil {
+		r.logger.Printf("%v", err)
+	}
+	r.stopBeat = journal.Keep(DaemonInterval, func(t time.Time) error {
+		return r.store.DaemonHeartbeat(pid, t)
+	})
+
 	r.logger.Printf("Scheduler started with %d active schedules", len(r.jobs))
+	r.updateWake()
+	r.recoverInterrupted(schedules)
 	return nil
 }
 
+// recoverInterrupted marks the runs a restart cut short as interrupted. A
+// schedule whose last run was interrupted starts again at once if it is set
+// to resume, and otherwise waits for its next time.
+func (r *Runner) recoverInterrupted(schedules []*Schedule) {
+	if _, err := journal.Recover(r.database); err != nil {
+		r.logger.Printf("Failed to recover interrupted runs: %v", err)
+		return
+	}
+
+	for _, schedule := range schedules {
+		history, err := r.store.History(schedule.ID, 1)
+		if err != nil || len(history) == 0 {
+			continue
+		}
+		run := history[0]
+		if !run.Interrupted || (schedule.LastRunID != nil && *schedule.LastRunID == run.ID) {
+			continue
+		}
+		// Recorded as the last run, so it is not run again as overdue
+		if err := r.store.UpdateLastRun(schedule.ID, run.ID); err != nil {
+			r.logger.Printf("Failed to update schedule last run: %v", err)
+		}
+		if !schedule.Resume {
+			r.logger.Printf("Run %d of schedule %s was interrupted; the schedule runs again at its next time", run.ID, schedule.Name)
+			continue
+		}
+		r.logger.Printf("Run %d of schedule %s was interrupted; running it again", run.ID, schedule.Name)
+		go func(s *Schedule) {
+			if err := r.executeSchedule(s); err != nil {
+				r.logger.Printf("Failed to resume schedule %s: %v", s.Name, err)
+			}
+		}(schedule)
+	}
+}
+
 // Stop stops the scheduler
 func (r *Runner) Stop() {
 	r.logger.Println("Stopping scheduler...")
@@ -75,12 +202,31 @@ func (r *Runner) Stop() {
 	// Cancel context
 	r.cancel()
 
+	if r.stopBeat != nil {
+		r.stopBeat()
+		if err := r.store.UnregisterDaemon(os.Getpid()); err != nil {
+			r.logger.Printf("%v", err)
+		}
+	}
+
+	if r.wake {
+		if err := power.CancelWake(); err != nil {
+			r.logger.Printf("Failed to cancel wake: %v", err)
+		}
+	}
+
 	// Stop cron scheduler
 	ctx := r.cron.Stop()
 
-	// Wait for running jobs to complete
+	// Wait for running jobs to complete and record their runs
+	done := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		r.runs.Wait()
+		close(done)
+	}()
 	select {
-	case <-ctx.Done():
+	case <-done:
 		r.logger.Println("All jobs completed")
 	case <-time.After(5 * time.Minute):
 		r.logger.Println("Timeout waiting for jobs to complete")
@@ -102,14 +248,15 @@ func (r *Runner) RegisterSchedule(scheduleID int64) error {
 // UnregisterSchedule removes a schedule from the runner
 func (r *Runner) UnregisterSchedule(scheduleID int64) error {
 	r.mu.Lock()
-	defer r.mu.Unlock()
-
 	if entryID, exists := r.jobs[scheduleID]; exists {
 		r.cron.Remove(entryID)
 		delete(r.jobs, scheduleID)
+		delete(r.loaded, scheduleID)
 		r.logger.Printf("Unregistered schedule ID %d", scheduleID)
 	}
+	r.mu.Unlock()
 
+	r.updateWake()
 	return nil
 }
 
@@ -133,6 +280,64 @@ func (r *Runner) RefreshSchedule(scheduleID int64) error {
 	return nil
 }
 
+// Reload brings the registered schedules in line with the database, so
+// schedules added, edited, enabled, disabled or deleted while the scheduler
+// runs, such as from the GUI, take effect without a restart
+func (r *Runner) Reload() error {
+	enabled := true
+	schedules, err := r.store.List(Filter{Enabled: &enabled})
+	if err != nil {
+		return fmt.Errorf("failed to load schedules: %w", err)
+	}
+	current := make(map[int64]*Schedule, len(schedules))
+	for _, schedule := range schedules {
+		current[schedule.ID] = schedule
+	}
+
+	// Drop the schedules disabled, deleted or edited since they were loaded
+	var stale []int64
+	r.mu.RLock()
+	for id, loaded := range r.loaded {
+		if schedule, ok := current[id]; !ok || definition(schedule) != loaded {
+			stale = append(stale, id)
+		}
+	}
+	r.mu.RUnlock()
+	for _, id := range stale {
+		if err := r.UnregisterSchedule(id); err != nil {
+			return err
+		}
+	}
+
+	for _, schedule := range schedules {
+		r.mu.RLock()
+		_, registered := r.jobs[schedule.ID]
+		r.mu.RUnlock()
+		if registered {
+			continue
+		}
+		if err := r.registerSchedule(schedule); err != nil {
+			r.logger.Printf("Failed to register schedule %s: %v", schedule.Name, err)
+		}
+	}
+	return nil
+}
+
+// definition returns what the job of a schedule runs, so a schedule is
+// registered again when any of it is edited. UpdatedAt cannot tell, since
+// recording each run updates the schedule too.
+func definition(schedule *Schedule) string {
+	data, _ := json.Marshal(struct {
+		Name     string
+		CronExpr string
+		Plugin   string
+		Params   db.JSONData
+		Notify   *notify.Notify
+		Resume   bool
+	}{schedule.Name, schedule.CronExpr, schedule.Plugin, schedule.Params, schedule.Notify, schedule.Resume})
+	return string(data)
+}
+
 // registerSchedule registers a schedule with the cron scheduler
 func (r *Runner) registerSchedule(schedule *Schedule) error {
 	if !schedule.Enabled {
@@ -151,14 +356,60 @@ func (r *Runner) registerSchedule(schedule *Schedule) error {
 	// Track job
 	r.mu.Lock()
 	r.jobs[schedule.ID] = entryID
+	r.loaded[schedule.ID] = definition(schedule)
 	r.mu.Unlock()
 
 	r.logger.Printf("Registered schedule '%s' (ID: %d) with cron expression: %s",
 		schedule.Name, schedule.ID, schedule.CronExpr)
 
+	r.updateWake()
 	return nil
 }
 
+// updateWake programs the wake for the next scheduled run
+func (r *Runner) updateWake() {
+	if !r.wake {
+		return
+	}
+
+	var next []time.Time
+	for _, entry := range r.cron.Entries() {
+		next = append(next, entry.Next)
+	}
+	at, ok := nextWake(next, r.wakeLead, time.Now())
+
+	r.wakeMu.Lock()
+	defer r.wakeMu.Unlock()
+	if !ok || at.Equal(r.wakeAt) {
+		return
+	}
+	if err := power.ScheduleWake(at); err != nil {
+		r.logger.Printf("Failed to schedule wake at %s: %v", at.Format(time.RFC3339), err)
+		return
+	}
+	r.wakeAt = at
+	r.logger.Printf("Wake scheduled for %s", at.Format(time.RFC3339))
+}
+
+// nextWake returns the time to wake for the earliest of the upcoming runs.
+// Runs too close to now to be slept through are skipped.
+func nextWake(runs []time.Time, lead time.Duration, now time.Time) (time.Time, bool) {
+	var earliest time.Time
+	for _, run := range runs {
+		if run.IsZero() {
+			continue
+		}
+		at := run.Add(-lead)
+		if !at.After(now) {
+			continue
+		}
+		if earliest.IsZero() || at.Before(earliest) {
+			earliest = at
+		}
+	}
+	return earliest, !earliest.IsZero()
+}
+
 // createJob creates a job function for a schedule
 func (r *Runner) createJob(schedule *Schedule) func() {
 	return func() {
@@ -170,6 +421,7 @@ func (r *Runner) createJob(schedule *Schedule) func() {
 		}
 
 		r.logger.Printf("Executing scheduled job: %s", schedule.Name)
+		r.updateWake()
 
 		// Run in goroutine to not block scheduler
 		go func() {
@@ -189,6 +441,13 @@ func (r *Runner) executeSchedule(schedule *Schedule) error {
 		}
 	}()
 
+	if r.power != nil && r.power.UPSOnBattery() {
+		r.logger.Printf("Skipping schedule %s: UPS is on battery", schedule.Name)
+		return nil
+	}
+	r.runs.Add(1)
+	defer r.runs.Done()
+
 	// Get plugin
 	p, err := plugin.Get(schedule.Plugin)
 	if err != nil {
@@ -209,64 +468,49 @@ func (r *Runner) executeSchedule(schedule *Schedule) error {
 		}
 	}
 
-	// Create run record
-	run, err := r.database.CreateRun(schedule.Plugin, schedule.Params)
-	if err != nil {
-		return fmt.Errorf("failed to create run record: %w", err)
-	}
-
-	r.logger.Printf("Started run %d for schedule %s", run.ID, schedule.Name)
-
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(r.ctx, params.Duration+30*time.Second)
-	defer cancel()
-
-	// Run the test
-	startTime := time.Now()
-	result, err := p.Run(ctx, params)
-	endTime := time.Now()
-
-	// Update run record
-	run.EndTime = &endTime
-	run.Success = result.Success
-	run.Error = result.Error
-	run.Stdout = result.Stdout
-	run.Stderr = result.Stderr
-	if err != nil {
-		run.ExitCode = 1
-		if run.Error == "" {
-			run.Error = err.Error()
-		}
-	}
-
-	if err := r.database.UpdateRun(run); err != nil {
-		r.logger.Printf("Failed to update run record: %v", err)
-	}
+	// StopRuns cancels the run with its cause
+	runCtx, stopRun := context.WithCancelCause(r.ctx)
+	defer stopRun(nil)
+	var runID int64
+	defer func() {
+		r.runsMu.Lock()
+		delete(r.running, runID)
+		r.runsMu.Unlock()
+	}()
 
-	// Save metrics
-	if len(result.Metrics) > 0 {
-		units := make(map[string]string)
-		// Try to get units from plugin info
-		if infoPlugin, ok := p.(interface{ Info() plugin.Info }); ok {
-			info := infoPlugin.Info()
-			for _, metric := range info.Metrics {
-				units[metric.Name] = metric.Unit
+	outcome, err := testrun.Run(runCtx, r.database, p, params, testrun.Options{
+		Source:   "schedule",
+		Schedule: schedule.Name,
+		Hooks:    r.hooks,
+		Limits:   r.limits,
+		Output:   r.logger.Writer(),
+		Logger:   r.logger,
+		Started: func(run *db.Run) {
+			r.logger.Printf("Started run %d for schedule %s", run.ID, schedule.Name)
+			if err := r.store.AddRun(schedule.ID, run.ID); err != nil {
+				r.logger.Printf("Failed to record schedule run: %v", err)
 			}
-		}
-
-		if err := r.database.CreateResults(run.ID, result.Metrics, units); err != nil {
-			r.logger.Printf("Failed to save metrics: %v", err)
-		}
+			r.runsMu.Lock()
+			runID = run.ID
+			r.running[run.ID] = stopRun
+			r.runsMu.Unlock()
+		},
+		Finished: func(run *db.Run) {
+			if err := r.store.UpdateLastRun(schedule.ID, run.ID); err != nil {
+				r.logger.Printf("Failed to update schedule last run: %v", err)
+			}
+			r.finished(schedule, run)
+		},
+	})
+	if errors.Is(err, testrun.ErrAborted) {
+		return fmt.Errorf("run %d aborted: %w", outcome.Run.ID, outcome.Err)
 	}
-
-	// Update schedule's last run info
-	if err := r.store.UpdateLastRun(schedule.ID, run.ID); err != nil {
-		r.logger.Printf("Failed to update schedule last run: %v", err)
+	if err != nil {
+		return err
 	}
 
 	r.logger.Printf("Completed run %d for schedule %s (success: %v, duration: %s)",
-		run.ID, schedule.Name, result.Success, endTime.Sub(startTime))
-
+		outcome.Run.ID, schedule.Name, outcome.Run.Success, outcome.Duration)
 	return nil
 }
 
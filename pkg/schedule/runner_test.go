@@ -0,0 +1,32 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextWake(t *testing.T) {
+	now := time.Date(2025, 1, 6, 18, 0, 0, 0, time.UTC)
+	lead := 2 * time.Minute
+
+	runs := []time.Time{
+		{},                      // Entry not scheduled yet
+		now.Add(time.Minute),    // Too close to sleep through
+		now.Add(10 * time.Hour), // Next morning
+		now.Add(8 * time.Hour),  // Overnight window
+	}
+	at, ok := nextWake(runs, lead, now)
+	if !ok {
+		t.Fatal("expected a wake time")
+	}
+	if expected := now.Add(8*time.Hour - lead); !at.Equal(expected) {
+		t.Errorf("expected wake at %s, got %s", expected, at)
+	}
+
+	if _, ok := nextWake([]time.Time{now.Add(time.Minute)}, lead, now); ok {
+		t.Error("expected no wake time when every run is imminent")
+	}
+	if _, ok := nextWake(nil, lead, now); ok {
+		t.Error("expected no wake time without runs")
+	}
+}
@@ -2,13 +2,56 @@ package schedule
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/notify"
 	"github.com/robfig/cron/v3"
 )
 
+// scheduleColumns are the columns scanSchedule reads, in order
+const scheduleColumns = `id, name, description, cron_expr, plugin, params, notify, enabled,
+	COALESCE(resume, FALSE), last_run_id, last_run_time, next_run_time, created_at, updated_at`
+
+// scanSchedule reads a schedule selected with scheduleColumns
+func scanSchedule(row interface{ Scan(...interface{}) error }) (*Schedule, error) {
+	schedule := &Schedule{}
+	var notifyJSON sql.NullString
+	err := row.Scan(
+		&schedule.ID, &schedule.Name, &schedule.Description,
+		&schedule.CronExpr, &schedule.Plugin, &schedule.Params, &notifyJSON,
+		&schedule.Enabled, &schedule.Resume, &schedule.LastRunID, &schedule.LastRunTime,
+		&schedule.NextRunTime, &schedule.CreatedAt, &schedule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if notifyJSON.Valid && notifyJSON.String != "" {
+		schedule.Notify = &notify.Notify{}
+		if err := json.Unmarshal([]byte(notifyJSON.String), schedule.Notify); err != nil {
+			return nil, fmt.Errorf("invalid notify settings: %w", err)
+		}
+	}
+	return schedule, nil
+}
+
+// notifyValue returns the notify column for n, NULL when nobody is told
+func notifyValue(n *notify.Notify) (interface{}, error) {
+	if n == nil {
+		return nil, nil
+	}
+	if err := n.Validate(); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
 // Store handles schedule persistence
 type Store struct {
 	db *db.DB
@@ -35,40 +78,32 @@ func (s *Store) Create(schedule *Schedule) error {
 	schedule.CreatedAt = now
 	schedule.UpdatedAt = now
 
-	result, err := s.db.Conn().Exec(
-		`INSERT INTO schedules (name, description, cron_expr, plugin, params, enabled, next_run_time, created_at, updated_at)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+	notifyCol, err := notifyValue(schedule.Notify)
+	if err != nil {
+		return err
+	}
+
+	id, err := s.db.Insert(
+		`INSERT INTO schedules (name, description, cron_expr, plugin, params, notify, enabled, resume, next_run_time, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		schedule.Name, schedule.Description, schedule.CronExpr, schedule.Plugin,
-		schedule.Params, schedule.Enabled, schedule.NextRunTime,
+		schedule.Params, notifyCol, schedule.Enabled, schedule.Resume, schedule.NextRunTime,
 		schedule.CreatedAt, schedule.UpdatedAt,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create schedule: %w", err)
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return fmt.Errorf("failed to get last insert id: %w", err)
-	}
-
 	schedule.ID = id
 	return nil
 }
 
 // Get retrieves a schedule by ID
 func (s *Store) Get(id int64) (*Schedule, error) {
-	schedule := &Schedule{}
-	err := s.db.Conn().QueryRow(
-		`SELECT id, name, description, cron_expr, plugin, params, enabled,
-		 last_run_id, last_run_time, next_run_time, created_at, updated_at
-		 FROM schedules WHERE id = ?`,
+	schedule, err := scanSchedule(s.db.QueryRow(
+		`SELECT `+scheduleColumns+` FROM schedules WHERE id = ?`,
 		id,
-	).Scan(
-		&schedule.ID, &schedule.Name, &schedule.Description,
-		&schedule.CronExpr, &schedule.Plugin, &schedule.Params,
-		&schedule.Enabled, &schedule.LastRunID, &schedule.LastRunTime,
-		&schedule.NextRunTime, &schedule.CreatedAt, &schedule.UpdatedAt,
-	)
+	))
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("schedule not found")
 	}
@@ -80,18 +115,10 @@ func (s *Store) Get(id int64) (*Schedule, error) {
 
 // GetByName retrieves a schedule by name
 func (s *Store) GetByName(name string) (*Schedule, error) {
-	schedule := &Schedule{}
-	err := s.db.Conn().QueryRow(
-		`SELECT id, name, description, cron_expr, plugin, params, enabled,
-		 last_run_id, last_run_time, next_run_time, created_at, updated_at
-		 FROM schedules WHERE name = ?`,
+	schedule, err := scanSchedule(s.db.QueryRow(
+		`SELECT `+scheduleColumns+` FROM schedules WHERE name = ?`,
 		name,
-	).Scan(
-		&schedule.ID, &schedule.Name, &schedule.Description,
-		&schedule.CronExpr, &schedule.Plugin, &schedule.Params,
-		&schedule.Enabled, &schedule.LastRunID, &schedule.LastRunTime,
-		&schedule.NextRunTime, &schedule.CreatedAt, &schedule.UpdatedAt,
-	)
+	))
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("schedule not found")
 	}
@@ -103,9 +130,7 @@ func (s *Store) GetByName(name string) (*Schedule, error) {
 
 // List retrieves schedules based on filters
 func (s *Store) List(filter Filter) ([]*Schedule, error) {
-	query := `SELECT id, name, description, cron_expr, plugin, params, enabled,
-	          last_run_id, last_run_time, next_run_time, created_at, updated_at
-	          FROM schedules WHERE 1=1`
+	query := `SELECT ` + scheduleColumns + ` FROM schedules WHERE 1=1`
 	args := []interface{}{}
 
 	if filter.Plugin != "" {
@@ -130,7 +155,7 @@ func (s *Store) List(filter Filter) ([]*Schedule, error) {
 		}
 	}
 
-	rows, err := s.db.Conn().Query(query, args...)
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list schedules: %w", err)
 	}
@@ -138,13 +163,7 @@ func (s *Store) List(filter Filter) ([]*Schedule, error) {
 
 	var schedules []*Schedule
 	for rows.Next() {
-		schedule := &Schedule{}
-		err := rows.Scan(
-			&schedule.ID, &schedule.Name, &schedule.Description,
-			&schedule.CronExpr, &schedule.Plugin, &schedule.Params,
-			&schedule.Enabled, &schedule.LastRunID, &schedule.LastRunTime,
-			&schedule.NextRunTime, &schedule.CreatedAt, &schedule.UpdatedAt,
-		)
+		schedule, err := scanSchedule(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan schedule: %w", err)
 		}
@@ -169,12 +188,17 @@ func (s *Store) Update(schedule *Schedule) error {
 	schedule.NextRunTime = &nextRun
 	schedule.UpdatedAt = now
 
-	_, err = s.db.Conn().Exec(
+	notifyCol, err := notifyValue(schedule.Notify)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(
 		`UPDATE schedules SET name = ?, description = ?, cron_expr = ?, plugin = ?,
-		 params = ?, enabled = ?, next_run_time = ?, updated_at = ?
+		 params = ?, notify = ?, enabled = ?, resume = ?, next_run_time = ?, updated_at = ?
 		 WHERE id = ?`,
 		schedule.Name, schedule.Description, schedule.CronExpr, schedule.Plugin,
-		schedule.Params, schedule.Enabled, schedule.NextRunTime, schedule.UpdatedAt,
+		schedule.Params, notifyCol, schedule.Enabled, schedule.Resume, schedule.NextRunTime, schedule.UpdatedAt,
 		schedule.ID,
 	)
 	if err != nil {
@@ -202,7 +226,7 @@ func (s *Store) UpdateLastRun(scheduleID, runID int64) error {
 	now := time.Now()
 	nextRun := cronSchedule.Next(now)
 
-	_, err = s.db.Conn().Exec(
+	_, err = s.db.Exec(
 		`UPDATE schedules SET last_run_id = ?, last_run_time = ?, next_run_time = ?
 		 WHERE id = ?`,
 		runID, now, nextRun, scheduleID,
@@ -210,9 +234,70 @@ func (s *Store) UpdateLastRun(scheduleID, runID int64) error {
 	if err != nil {
 		return fmt.Errorf("failed to update last run: %w", err)
 	}
+
+	// Keep every run, not just the last, for the schedule's history
+	return s.AddRun(scheduleID, runID)
+}
+
+// AddRun records a run in a schedule's history, once. Runs are added as they
+// start, so one interrupted by a restart is still found in the history.
+func (s *Store) AddRun(scheduleID, runID int64) error {
+	var count int
+	if err := s.db.QueryRow(
+		`SELECT COUNT(*) FROM schedule_runs WHERE schedule_id = ? AND run_id = ?`,
+		scheduleID, runID,
+	).Scan(&count); err != nil {
+		return fmt.Errorf("failed to check schedule run: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+	_, err := s.db.Exec(
+		`INSERT INTO schedule_runs (schedule_id, run_id, created_at) VALUES (?, ?, ?)`,
+		scheduleID, runID, time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record schedule run: %w", err)
+	}
 	return nil
 }
 
+// History returns the runs started by a schedule, newest first
+func (s *Store) History(scheduleID int64, limit int) ([]*db.Run, error) {
+	query := `SELECT run_id FROM schedule_runs WHERE schedule_id = ? ORDER BY id DESC`
+	args := []interface{}{scheduleID}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule history: %w", err)
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("failed to scan schedule run: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	_ = rows.Close()
+
+	runs := make([]*db.Run, 0, len(ids))
+	for _, id := range ids {
+		run, err := s.db.GetRun(id)
+		if err != nil {
+			// The run was deleted from the results database
+			continue
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
 // Enable enables a schedule
 func (s *Store) Enable(id int64) error {
 	// Get schedule to recalculate next run time
@@ -232,9 +317,9 @@ func (s *Store) Enable(id int64) error {
 	now := time.Now()
 	nextRun := cronSchedule.Next(now)
 
-	_, err = s.db.Conn().Exec(
-		`UPDATE schedules SET enabled = 1, next_run_time = ? WHERE id = ?`,
-		nextRun, id,
+	_, err = s.db.Exec(
+		`UPDATE schedules SET enabled = ?, next_run_time = ? WHERE id = ?`,
+		true, nextRun, id,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to enable schedule: %w", err)
@@ -244,9 +329,9 @@ func (s *Store) Enable(id int64) error {
 
 // Disable disables a schedule
 func (s *Store) Disable(id int64) error {
-	_, err := s.db.Conn().Exec(
-		`UPDATE schedules SET enabled = 0 WHERE id = ?`,
-		id,
+	_, err := s.db.Exec(
+		`UPDATE schedules SET enabled = ? WHERE id = ?`,
+		false, id,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to disable schedule: %w", err)
@@ -256,7 +341,11 @@ func (s *Store) Disable(id int64) error {
 
 // Delete deletes a schedule
 func (s *Store) Delete(id int64) error {
-	_, err := s.db.Conn().Exec(
+	// SQLite only enforces ON DELETE CASCADE with foreign keys enabled
+	if _, err := s.db.Exec(`DELETE FROM schedule_runs WHERE schedule_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete schedule history: %w", err)
+	}
+	_, err := s.db.Exec(
 		`DELETE FROM schedules WHERE id = ?`,
 		id,
 	)
@@ -269,13 +358,11 @@ func (s *Store) Delete(id int64) error {
 // GetDue returns all schedules that are due to run
 func (s *Store) GetDue() ([]*Schedule, error) {
 	now := time.Now()
-	rows, err := s.db.Conn().Query(
-		`SELECT id, name, description, cron_expr, plugin, params, enabled,
-		 last_run_id, last_run_time, next_run_time, created_at, updated_at
-		 FROM schedules 
-		 WHERE enabled = 1 AND (next_run_time IS NULL OR next_run_time <= ?)
+	rows, err := s.db.Query(
+		`SELECT `+scheduleColumns+` FROM schedules
+		 WHERE enabled = ? AND (next_run_time IS NULL OR next_run_time <= ?)
 		 ORDER BY next_run_time`,
-		now,
+		true, now,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get due schedules: %w", err)
@@ -284,13 +371,7 @@ func (s *Store) GetDue() ([]*Schedule, error) {
 
 	var schedules []*Schedule
 	for rows.Next() {
-		schedule := &Schedule{}
-		err := rows.Scan(
-			&schedule.ID, &schedule.Name, &schedule.Description,
-			&schedule.CronExpr, &schedule.Plugin, &schedule.Params,
-			&schedule.Enabled, &schedule.LastRunID, &schedule.LastRunTime,
-			&schedule.NextRunTime, &schedule.CreatedAt, &schedule.UpdatedAt,
-		)
+		schedule, err := scanSchedule(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan schedule: %w", err)
 		}
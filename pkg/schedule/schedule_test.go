@@ -0,0 +1,119 @@
+package schedule
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/notify"
+)
+
+func TestStoreHistory(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	store := NewStore(database)
+	sched := &Schedule{Name: "Nightly", CronExpr: "0 2 * * *", Plugin: "cpu", Enabled: true}
+	if err := store.Create(sched); err != nil {
+		t.Fatalf("failed to create schedule: %v", err)
+	}
+
+	var runIDs []int64
+	for i := 0; i < 3; i++ {
+		run, err := database.CreateRun("cpu", nil)
+		if err != nil {
+			t.Fatalf("failed to create run: %v", err)
+		}
+		if err := store.UpdateLastRun(sched.ID, run.ID); err != nil {
+			t.Fatalf("failed to update last run: %v", err)
+		}
+		runIDs = append(runIDs, run.ID)
+	}
+
+	history, err := store.History(sched.ID, 2)
+	if err != nil {
+		t.Fatalf("failed to get history: %v", err)
+	}
+	if len(history) != 2 || history[0].ID != runIDs[2] || history[1].ID != runIDs[1] {
+		t.Errorf("expected the two newest runs, got %+v", history)
+	}
+
+	if err := store.Delete(sched.ID); err != nil {
+		t.Fatalf("failed to delete schedule: %v", err)
+	}
+	if history, _ := store.History(sched.ID, 0); len(history) != 0 {
+		t.Errorf("expected history to be removed with the schedule, got %d runs", len(history))
+	}
+}
+
+func TestStoreNotify(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	store := NewStore(database)
+	sched := &Schedule{
+		Name: "Nightly", CronExpr: "0 2 * * *", Plugin: "cpu", Enabled: true,
+		Notify: &notify.Notify{Email: []string{"ops@example.com"}, Format: notify.FormatPDF},
+	}
+	if err := store.Create(sched); err != nil {
+		t.Fatalf("failed to create schedule: %v", err)
+	}
+	got, err := store.GetByName("Nightly")
+	if err != nil {
+		t.Fatalf("failed to get schedule: %v", err)
+	}
+	if got.Notify == nil || got.Notify.String() != "email=ops@example.com (PDF)" {
+		t.Errorf("expected the recipients to be stored, got %+v", got.Notify)
+	}
+
+	got.Notify = nil
+	if err := store.Update(got); err != nil {
+		t.Fatalf("failed to update schedule: %v", err)
+	}
+	if got, _ := store.Get(sched.ID); got.Notify != nil {
+		t.Errorf("expected nobody to be mailed, got %+v", got.Notify)
+	}
+
+	sched.Notify = &notify.Notify{Email: []string{"ops"}}
+	if err := store.Update(sched); err == nil {
+		t.Error("expected an invalid address to be rejected")
+	}
+}
+
+func TestStoreAddRun(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	store := NewStore(database)
+	sched := &Schedule{Name: "Burn-in", CronExpr: "0 20 * * 5", Plugin: "cpu", Enabled: true, Resume: true}
+	if err := store.Create(sched); err != nil {
+		t.Fatalf("failed to create schedule: %v", err)
+	}
+	if got, _ := store.Get(sched.ID); !got.Resume {
+		t.Error("expected the schedule to resume")
+	}
+
+	run, err := database.CreateRun("cpu", nil)
+	if err != nil {
+		t.Fatalf("failed to create run: %v", err)
+	}
+	// Added as the run starts, then again as it is recorded
+	if err := store.AddRun(sched.ID, run.ID); err != nil {
+		t.Fatalf("failed to add run: %v", err)
+	}
+	if err := store.UpdateLastRun(sched.ID, run.ID); err != nil {
+		t.Fatalf("failed to update last run: %v", err)
+	}
+	if history, _ := store.History(sched.ID, 0); len(history) != 1 || history[0].ID != run.ID {
+		t.Errorf("expected the run once in the history, got %+v", history)
+	}
+}
@@ -0,0 +1,128 @@
+// Package schema publishes the JSON Schemas of the files F.I.R.E. exports,
+// so tooling built on them can check what it reads and tell when a release
+// changes a format.
+//
+// The schemas live in schemas/ and are embedded in the binary, which prints
+// them with bench schema show. Every exported document carries the format it
+// follows and a schema_version, raised only when a field is removed or
+// changes meaning; new fields are added without raising it. Validate checks
+// a document against a schema, and imports use it to reject files that do
+// not match rather than load them half-read.
+package schema
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:embed schemas/*.json
+var files embed.FS
+
+// Schema names
+const (
+	Run         = "run"         // A run with its results, embedded in reports and sessions
+	Report      = "report"      // One run, written by bench export json
+	Session     = "session"     // Hardware inventory, telemetry and runs of a GUI session
+	Certificate = "certificate" // A verified certificate, written by bench cert verify --json
+	BurnIn      = "burnin"      // A signed burn-in certificate, written by bench cert burnin
+	Inventory   = "inventory"   // The hardware of a machine, written by bench inventory
+	Record      = "record"      // One line of bench export --format ndjson
+)
+
+// Formats maps the format field of a document to the schema it follows
+var Formats = map[string]string{
+	"fire-report":      Report,
+	"fire-session":     Session,
+	"fire-certificate": Certificate,
+	"fire-burnin":      BurnIn,
+	"fire-inventory":   Inventory,
+	"fire-record":      Record,
+}
+
+// Names returns the names of the published schemas, sorted
+func Names() []string {
+	entries, _ := files.ReadDir("schemas")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".schema.json"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the JSON Schema with the given name
+func Get(name string) ([]byte, error) {
+	data, err := files.ReadFile(path.Join("schemas", name+".schema.json"))
+	if err != nil {
+		return nil, fmt.Errorf("unknown schema %q", name)
+	}
+	return data, nil
+}
+
+// Detect returns the schema a document follows, from its format field
+func Detect(data []byte) (string, error) {
+	var header struct {
+		Format string `json:"format"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return "", fmt.Errorf("invalid JSON: %w", err)
+	}
+	name, ok := Formats[header.Format]
+	if !ok {
+		if header.Format == "" {
+			return "", errors.New("document has no format field")
+		}
+		return "", fmt.Errorf("unknown format %q", header.Format)
+	}
+	return name, nil
+}
+
+// Validate checks a JSON document against the named schema. The error lists
+// every violation with the path where it was found.
+func Validate(name string, data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return ValidateValue(name, doc)
+}
+
+// ValidateValue checks a decoded document against the named schema. Numbers
+// must be decoded as json.Number or float64.
+func ValidateValue(name string, doc interface{}) error {
+	root, err := load(name)
+	if err != nil {
+		return err
+	}
+	v := &validator{}
+	v.check(root, name, doc, "")
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return &Error{Schema: name, Problems: v.errs}
+}
+
+// Error lists where a document does not match a schema
+type Error struct {
+	Schema   string
+	Problems []string // e.g. "run.start_time: expected string, got number"
+}
+
+func (e *Error) Error() string {
+	const shown = 5
+	problems := e.Problems
+	more := ""
+	if len(problems) > shown {
+		more = fmt.Sprintf(" (and %d more)", len(problems)-shown)
+		problems = problems[:shown]
+	}
+	return fmt.Sprintf("does not match the %s schema: %s%s", e.Schema, strings.Join(problems, "; "), more)
+}
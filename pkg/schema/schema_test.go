@@ -0,0 +1,114 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSchemasParse(t *testing.T) {
+	if got, want := Names(), []string{BurnIn, Certificate, Inventory, Record, Report, Run, Session}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected schemas %v, got %v", want, got)
+	}
+	for _, name := range Names() {
+		data, err := Get(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc map[string]interface{}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if id, _ := doc["$id"].(string); id != "urn:fire:schema:"+name+":1" {
+			t.Errorf("%s: unexpected $id %q", name, id)
+		}
+	}
+	if _, err := Get("telemetry"); err == nil {
+		t.Error("expected an unknown schema to fail")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	report := `{
+		"format": "fire-report", "schema_version": 1, "version": 1, "created_at": "2026-01-02T03:04:05.123+01:00",
+		"run": {"id": 42, "plugin": "cpu", "params": null, "start_time": "2026-01-02T03:00:00Z", "end_time": null, "exit_code": 0, "success": true},
+		"results": [{"id": 1, "run_id": 42, "metric": "ops", "value": 1.5, "unit": ""}]
+	}`
+	if err := Validate(Report, []byte(report)); err != nil {
+		t.Errorf("expected the report to match, got %v", err)
+	}
+
+	session := `{
+		"format": "fire-session", "schema_version": 1,
+		"components": [{"type": "CPU", "name": "Test CPU", "details": {"Cores": "8"}}],
+		"samples": [{"time": "2026-01-02T03:00:00Z", "metrics": {"CPU Temp (°C)": 40}}],
+		"runs": [{"run": {"id": 1, "plugin": "memory", "success": false}, "results": null}]
+	}`
+	if err := Validate(Session, []byte(session)); err != nil {
+		t.Errorf("expected the session to match, got %v", err)
+	}
+
+	certificate := `{
+		"format": "fire-certificate", "schema_version": 1, "valid": true,
+		"subject": "CN=Test Run #42", "issuer": "CN=F.I.R.E. CA", "serial": "123",
+		"not_before": "2026-01-02T03:00:00Z", "not_after": "2027-01-02T03:00:00Z",
+		"status": "PASSED", "duration_seconds": 60, "metrics": {"ops": "1.500000"}
+	}`
+	if err := Validate(Certificate, []byte(certificate)); err != nil {
+		t.Errorf("expected the certificate to match, got %v", err)
+	}
+}
+
+func TestValidateProblems(t *testing.T) {
+	doc := `{
+		"format": "fire-session", "schema_version": 0,
+		"components": [{"type": "CPU"}],
+		"samples": [{"time": "yesterday", "metrics": {"CPU Temp (°C)": "hot"}}],
+		"runs": [{"run": {"id": 1.5, "plugin": "cpu", "success": true, "start_time": 1700000000}}]
+	}`
+	err := Validate(Session, []byte(doc))
+	var invalid *Error
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected a schema error, got %v", err)
+	}
+	want := []string{
+		"components[0].name: is required",
+		`runs[0].run.id: expected integer, got number`,
+		`runs[0].run.start_time: expected string, got integer`,
+		`samples[0].metrics.CPU Temp (°C): expected number, got string`,
+		`samples[0].time: "yesterday" is not an RFC 3339 date-time`,
+		"schema_version: 0 is less than the minimum 1",
+	}
+	if !reflect.DeepEqual(invalid.Problems, want) {
+		t.Errorf("unexpected problems:\n%s", strings.Join(invalid.Problems, "\n"))
+	}
+	if !strings.Contains(err.Error(), "(and 1 more)") {
+		t.Errorf("expected the error to show the first five problems, got %v", err)
+	}
+
+	if err := Validate(Report, []byte(`{"format": "fire-session", "schema_version": 1}`)); err == nil {
+		t.Error("expected the wrong format to fail")
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := map[string]string{
+		`{"format": "fire-report"}`:      Report,
+		`{"format": "fire-certificate"}`: Certificate,
+		`{"format": "fire-burnin"}`:      BurnIn,
+		`{"format": "fire-inventory"}`:   Inventory,
+		`{"format": "fire-record"}`:      Record,
+		`{"format": "other"}`:            "",
+		`{"run": {}}`:                    "",
+		`[`:                              "",
+	}
+	for input, want := range tests {
+		got, err := Detect([]byte(input))
+		if got != want || (want == "") != (err != nil) {
+			t.Errorf("Detect(%s) = %q, %v; expected %q", input, got, err, want)
+		}
+	}
+}
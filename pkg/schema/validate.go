@@ -0,0 +1,237 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// validator checks documents against the keywords the published schemas
+// use: $ref, type, const, enum, required, properties, additionalProperties,
+// items, minimum and the date-time format. Other keywords are ignored.
+type validator struct {
+	errs []string
+}
+
+// load parses a schema
+func load(name string) (map[string]interface{}, error) {
+	data, err := Get(name)
+	if err != nil {
+		return nil, err
+	}
+	var node map[string]interface{}
+	if err := json.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("schema %s is invalid: %w", name, err)
+	}
+	return node, nil
+}
+
+// check validates value at path against a schema node of the named schema
+func (v *validator) check(node map[string]interface{}, name string, value interface{}, path string) {
+	if ref, ok := node["$ref"].(string); ok {
+		target, targetName, err := resolve(ref, name)
+		if err != nil {
+			v.fail(path, err.Error())
+			return
+		}
+		v.check(target, targetName, value, path)
+		return
+	}
+
+	if want, ok := node["const"]; ok && !equal(want, value) {
+		v.fail(path, fmt.Sprintf("expected %v", jsonText(want)))
+		return
+	}
+	if options, ok := node["enum"].([]interface{}); ok {
+		found := false
+		for _, option := range options {
+			found = found || equal(option, value)
+		}
+		if !found {
+			v.fail(path, fmt.Sprintf("%s is not one of %s", jsonText(value), jsonText(options)))
+			return
+		}
+	}
+	if types := typeList(node["type"]); len(types) > 0 && !hasType(types, value) {
+		v.fail(path, fmt.Sprintf("expected %s, got %s", strings.Join(types, " or "), typeOf(value)))
+		return
+	}
+
+	switch val := value.(type) {
+	case map[string]interface{}:
+		v.checkObject(node, name, val, path)
+	case []interface{}:
+		if items, ok := node["items"].(map[string]interface{}); ok {
+			for i, item := range val {
+				v.check(items, name, item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	case string:
+		if node["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, val); err != nil {
+				v.fail(path, fmt.Sprintf("%q is not an RFC 3339 date-time", val))
+			}
+		}
+	default:
+		if minimum, ok := node["minimum"].(float64); ok {
+			if n, ok := number(value); ok && n < minimum {
+				v.fail(path, fmt.Sprintf("%v is less than the minimum %v", n, minimum))
+			}
+		}
+	}
+}
+
+// checkObject validates the members of an object
+func (v *validator) checkObject(node map[string]interface{}, name string, obj map[string]interface{}, path string) {
+	if required, ok := node["required"].([]interface{}); ok {
+		for _, key := range required {
+			if k, ok := key.(string); ok {
+				if _, present := obj[k]; !present {
+					v.fail(join(path, k), "is required")
+				}
+			}
+		}
+	}
+
+	properties, _ := node["properties"].(map[string]interface{})
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	// Report problems in a stable order
+	sort.Strings(keys)
+	for _, key := range keys {
+		if prop, ok := properties[key].(map[string]interface{}); ok {
+			v.check(prop, name, obj[key], join(path, key))
+			continue
+		}
+		switch extra := node["additionalProperties"].(type) {
+		case map[string]interface{}:
+			v.check(extra, name, obj[key], join(path, key))
+		case bool:
+			if !extra {
+				v.fail(join(path, key), "is not allowed")
+			}
+		}
+	}
+}
+
+func (v *validator) fail(path, problem string) {
+	if path == "" {
+		path = "document"
+	}
+	v.errs = append(v.errs, path+": "+problem)
+}
+
+// resolve finds the node a $ref points to: "#/$defs/run" within the same
+// schema, or "run.schema.json#/$defs/run" in another published one
+func resolve(ref, name string) (map[string]interface{}, string, error) {
+	file, pointer, _ := strings.Cut(ref, "#")
+	if file != "" {
+		name = strings.TrimSuffix(file, ".schema.json")
+	}
+	node, err := load(name)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, part := range strings.Split(strings.Trim(pointer, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		next, ok := node[part].(map[string]interface{})
+		if !ok {
+			return nil, "", fmt.Errorf("unresolvable schema reference %q", ref)
+		}
+		node = next
+	}
+	return node, name, nil
+}
+
+// typeList reads the type keyword, a name or a list of names
+func typeList(t interface{}) []string {
+	switch t := t.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		types := make([]string, 0, len(t))
+		for _, name := range t {
+			if s, ok := name.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func hasType(types []string, value interface{}) bool {
+	actual := typeOf(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// typeOf names the JSON type of a decoded value, telling integers apart
+func typeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	if n, ok := number(value); ok {
+		if n == math.Trunc(n) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func number(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// equal compares JSON values, treating numbers by value
+func equal(a, b interface{}) bool {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func jsonText(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,58 @@
+package security
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// bitLockerVolume holds the Get-BitLockerVolume properties read for each
+// volume
+type bitLockerVolume struct {
+	MountPoint           string `json:"MountPoint"`
+	VolumeStatus         int    `json:"VolumeStatus"`
+	ProtectionStatus     int    `json:"ProtectionStatus"`
+	EncryptionMethod     int    `json:"EncryptionMethod"`
+	EncryptionPercentage int    `json:"EncryptionPercentage"`
+}
+
+// parseBitLocker reads the Get-BitLockerVolume output converted to JSON.
+// ConvertTo-Json writes a lone volume as an object rather than an array, so
+// both are accepted.
+func parseBitLocker(output []byte) ([]VolumeEncryption, error) {
+	trimmed := strings.TrimSpace(string(output))
+	if trimmed == "" {
+		return nil, nil // No volumes
+	}
+
+	var volumes []bitLockerVolume
+	if strings.HasPrefix(trimmed, "{") {
+		var v bitLockerVolume
+		if err := json.Unmarshal([]byte(trimmed), &v); err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, v)
+	} else if err := json.Unmarshal([]byte(trimmed), &volumes); err != nil {
+		return nil, err
+	}
+
+	result := make([]VolumeEncryption, 0, len(volumes))
+	for _, v := range volumes {
+		vol := VolumeEncryption{
+			Volume:     v.MountPoint,
+			Method:     "BitLocker",
+			State:      StateDisabled,
+			Percentage: v.EncryptionPercentage,
+		}
+		// VolumeStatus 0 is FullyDecrypted
+		if v.VolumeStatus != 0 {
+			vol.State = StateEnabled
+		}
+		if v.ProtectionStatus == 1 {
+			vol.Detail = "protection on"
+		} else {
+			vol.Detail = "protection off"
+		}
+		result = append(result, vol)
+	}
+	return result, nil
+}
@@ -0,0 +1,27 @@
+package security
+
+import "testing"
+
+func TestParseBitLocker(t *testing.T) {
+	// A machine with only C: gets a single object from ConvertTo-Json
+	volumes, err := parseBitLocker([]byte(`{"MountPoint":"C:","VolumeStatus":1,"ProtectionStatus":1,"EncryptionMethod":6,"EncryptionPercentage":100}` + "\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(volumes) != 1 || volumes[0].Volume != "C:" || volumes[0].State != StateEnabled || volumes[0].Detail != "protection on" {
+		t.Errorf("unexpected volume from a single object: %+v", volumes)
+	}
+
+	volumes, err = parseBitLocker([]byte(`[{"MountPoint":"C:","VolumeStatus":1,"ProtectionStatus":0,"EncryptionMethod":6,"EncryptionPercentage":40},` +
+		`{"MountPoint":"D:","VolumeStatus":0,"ProtectionStatus":0,"EncryptionMethod":0,"EncryptionPercentage":0}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(volumes) != 2 || volumes[0].Percentage != 40 || volumes[0].Detail != "protection off" || volumes[1].State != StateDisabled {
+		t.Errorf("unexpected volumes: %+v", volumes)
+	}
+
+	if volumes, err := parseBitLocker([]byte("  ")); err != nil || len(volumes) != 0 {
+		t.Errorf("expected no volumes from empty output, got %+v, %v", volumes, err)
+	}
+}
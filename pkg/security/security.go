@@ -0,0 +1,94 @@
+// Package security reports platform security state that refurbishers must
+// document before shipping a machine: disk encryption, Secure Boot and TPM.
+package security
+
+import (
+	"fmt"
+	"time"
+)
+
+// State values used for Secure Boot and encryption
+const (
+	StateEnabled     = "enabled"
+	StateDisabled    = "disabled"
+	StateUnsupported = "unsupported" // e.g. legacy BIOS boot
+	StateUnknown     = "unknown"     // could not be determined (often needs admin/root)
+)
+
+// Status is a snapshot of platform security state
+type Status struct {
+	Timestamp  time.Time          `json:"timestamp"`
+	SecureBoot string             `json:"secure_boot"`
+	BootMode   string             `json:"boot_mode"` // uefi or legacy
+	TPM        TPMInfo            `json:"tpm"`
+	Volumes    []VolumeEncryption `json:"volumes"`
+	Errors     []string           `json:"errors,omitempty"`
+}
+
+// TPMInfo describes the Trusted Platform Module
+type TPMInfo struct {
+	Present bool   `json:"present"`
+	Version string `json:"version,omitempty"` // "2.0", "1.2"
+	Enabled bool   `json:"enabled"`
+	Vendor  string `json:"vendor,omitempty"`
+}
+
+// VolumeEncryption describes the encryption state of one volume
+type VolumeEncryption struct {
+	Volume     string `json:"volume"`           // mount point, drive letter or device
+	Method     string `json:"method"`           // BitLocker, LUKS, FileVault
+	State      string `json:"state"`            // enabled, disabled, unknown
+	Detail     string `json:"detail,omitempty"` // e.g. XtsAes128, protection status
+	Percentage int    `json:"percentage,omitempty"`
+}
+
+// Collect gathers the security status of the local machine. Parts that
+// cannot be read are reported as unknown and listed in Errors.
+func Collect() *Status {
+	status := &Status{
+		Timestamp:  time.Now(),
+		SecureBoot: StateUnknown,
+		BootMode:   StateUnknown,
+	}
+	collect(status)
+	return status
+}
+
+// EncryptionSummary returns a one-line summary of volume encryption
+func (s *Status) EncryptionSummary() string {
+	if len(s.Volumes) == 0 {
+		return "None detected"
+	}
+
+	encrypted := 0
+	method := ""
+	for _, v := range s.Volumes {
+		if v.State == StateEnabled {
+			encrypted++
+			method = v.Method
+		}
+	}
+	if encrypted == 0 {
+		return fmt.Sprintf("Not encrypted (%d volumes)", len(s.Volumes))
+	}
+	return fmt.Sprintf("%s (%d of %d volumes)", method, encrypted, len(s.Volumes))
+}
+
+// TPMSummary returns a one-line summary of the TPM
+func (s *Status) TPMSummary() string {
+	if !s.TPM.Present {
+		return "Not present"
+	}
+	summary := "Present"
+	if s.TPM.Version != "" {
+		summary = "TPM " + s.TPM.Version
+	}
+	if !s.TPM.Enabled {
+		summary += " (disabled)"
+	}
+	return summary
+}
+
+func (s *Status) addError(format string, args ...interface{}) {
+	s.Errors = append(s.Errors, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,33 @@
+//go:build darwin
+// +build darwin
+
+package security
+
+import (
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// Macs have no TPM; Secure Boot on T2/Apple silicon is not exposed to
+// unprivileged tools, so only FileVault is reported.
+func collect(status *Status) {
+	status.BootMode = "uefi"
+
+	output, err := safeexec.Command("fdesetup", "status").Output()
+	if err != nil {
+		status.addError("filevault: %v", err)
+		return
+	}
+
+	state := StateDisabled
+	if strings.Contains(string(output), "FileVault is On") {
+		state = StateEnabled
+	}
+	status.Volumes = append(status.Volumes, VolumeEncryption{
+		Volume: "/",
+		Method: "FileVault",
+		State:  state,
+		Detail: strings.TrimSpace(string(output)),
+	})
+}
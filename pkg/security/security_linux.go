@@ -0,0 +1,155 @@
+//go:build linux
+// +build linux
+
+package security
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// secureBootVar is the EFI variable holding the Secure Boot state
+const secureBootVar = "/sys/firmware/efi/efivars/SecureBoot-8be4df61-93ca-11d2-aa0d-00e098032b8c"
+
+func collect(status *Status) {
+	collectSecureBoot(status)
+	collectTPM(status)
+	collectLUKS(status)
+}
+
+// collectSecureBoot reads the Secure Boot EFI variable
+func collectSecureBoot(status *Status) {
+	if _, err := os.Stat("/sys/firmware/efi"); err != nil {
+		status.BootMode = "legacy"
+		status.SecureBoot = StateUnsupported
+		return
+	}
+	status.BootMode = "uefi"
+
+	data, err := os.ReadFile(secureBootVar)
+	if err != nil {
+		status.addError("secure boot: %v", err)
+		return
+	}
+	status.SecureBoot = parseSecureBootVar(data)
+}
+
+// parseSecureBootVar decodes an efivarfs variable: 4 attribute bytes followed by the value
+func parseSecureBootVar(data []byte) string {
+	if len(data) < 5 {
+		return StateUnknown
+	}
+	if data[4] == 1 {
+		return StateEnabled
+	}
+	return StateDisabled
+}
+
+// collectTPM reads TPM information from sysfs
+func collectTPM(status *Status) {
+	tpmDir := "/sys/class/tpm/tpm0"
+	if _, err := os.Stat(tpmDir); err != nil {
+		return
+	}
+	status.TPM.Present = true
+	status.TPM.Enabled = true
+
+	if data, err := os.ReadFile(filepath.Join(tpmDir, "tpm_version_major")); err == nil {
+		switch strings.TrimSpace(string(data)) {
+		case "2":
+			status.TPM.Version = "2.0"
+		case "1":
+			status.TPM.Version = "1.2"
+		}
+	}
+
+	// TPM 1.2 exposes enabled state and vendor through the device caps
+	if data, err := os.ReadFile(filepath.Join(tpmDir, "device", "enabled")); err == nil {
+		status.TPM.Enabled = strings.TrimSpace(string(data)) == "1"
+	}
+	if data, err := os.ReadFile(filepath.Join(tpmDir, "device", "caps")); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.HasPrefix(line, "Manufacturer:") {
+				status.TPM.Vendor = strings.TrimSpace(strings.TrimPrefix(line, "Manufacturer:"))
+			}
+			if strings.HasPrefix(line, "TCG version:") && status.TPM.Version == "" {
+				status.TPM.Version = strings.TrimSpace(strings.TrimPrefix(line, "TCG version:"))
+			}
+		}
+	}
+	if status.TPM.Version == "" {
+		// Kernels before 5.6 lack tpm_version_major; TPM 2.0 devices use tpmrm
+		if _, err := os.Stat("/sys/class/tpmrm/tpmrm0"); err == nil {
+			status.TPM.Version = "2.0"
+		}
+	}
+}
+
+// lsblkDevice is one entry of lsblk --json output
+type lsblkDevice struct {
+	Name       string        `json:"name"`
+	Type       string        `json:"type"`
+	FSType     string        `json:"fstype"`
+	MountPoint string        `json:"mountpoint"`
+	Children   []lsblkDevice `json:"children"`
+}
+
+// collectLUKS reports whether mounted filesystems sit on LUKS containers
+func collectLUKS(status *Status) {
+	output, err := safeexec.Command("lsblk", "--json", "-o", "NAME,TYPE,FSTYPE,MOUNTPOINT").Output()
+	if err != nil {
+		status.addError("lsblk: %v", err)
+		return
+	}
+
+	volumes, err := parseLsblk(output)
+	if err != nil {
+		status.addError("lsblk: %v", err)
+		return
+	}
+	status.Volumes = volumes
+}
+
+// parseLsblk walks the block device tree and reports every mounted
+// filesystem along with whether a LUKS container sits above it
+func parseLsblk(data []byte) ([]VolumeEncryption, error) {
+	var tree struct {
+		BlockDevices []lsblkDevice `json:"blockdevices"`
+	}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("failed to parse output: %w", err)
+	}
+
+	var volumes []VolumeEncryption
+	var walk func(dev lsblkDevice, luks string)
+	walk = func(dev lsblkDevice, luks string) {
+		if dev.FSType == "crypto_LUKS" {
+			luks = "/dev/" + dev.Name
+		}
+		if dev.MountPoint != "" && dev.MountPoint != "[SWAP]" && !strings.HasPrefix(dev.MountPoint, "/snap/") {
+			v := VolumeEncryption{
+				Volume: dev.MountPoint,
+				Method: "LUKS",
+				State:  StateDisabled,
+			}
+			if luks != "" {
+				v.State = StateEnabled
+				v.Detail = luks
+			}
+			volumes = append(volumes, v)
+		}
+		for _, child := range dev.Children {
+			walk(child, luks)
+		}
+	}
+	for _, dev := range tree.BlockDevices {
+		walk(dev, "")
+	}
+
+	return volumes, nil
+}
@@ -0,0 +1,51 @@
+//go:build linux
+// +build linux
+
+package security
+
+import "testing"
+
+func TestParseSecureBootVar(t *testing.T) {
+	if got := parseSecureBootVar([]byte{6, 0, 0, 0, 1}); got != StateEnabled {
+		t.Errorf("expected enabled, got %s", got)
+	}
+	if got := parseSecureBootVar([]byte{6, 0, 0, 0, 0}); got != StateDisabled {
+		t.Errorf("expected disabled, got %s", got)
+	}
+	if got := parseSecureBootVar(nil); got != StateUnknown {
+		t.Errorf("expected unknown, got %s", got)
+	}
+}
+
+func TestParseLsblk(t *testing.T) {
+	output := []byte(`{
+	  "blockdevices": [
+	    {"name": "nvme0n1", "type": "disk", "fstype": null, "mountpoint": null, "children": [
+	      {"name": "nvme0n1p1", "type": "part", "fstype": "vfat", "mountpoint": "/boot/efi"},
+	      {"name": "nvme0n1p2", "type": "part", "fstype": "crypto_LUKS", "mountpoint": null, "children": [
+	        {"name": "luks-root", "type": "crypt", "fstype": "ext4", "mountpoint": "/"}
+	      ]}
+	    ]},
+	    {"name": "sda", "type": "disk", "fstype": "ext4", "mountpoint": "/data"}
+	  ]
+	}`)
+
+	volumes, err := parseLsblk(output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		"/boot/efi": StateDisabled,
+		"/":         StateEnabled,
+		"/data":     StateDisabled,
+	}
+	if len(volumes) != len(want) {
+		t.Fatalf("expected %d volumes, got %d: %+v", len(want), len(volumes), volumes)
+	}
+	for _, v := range volumes {
+		if v.State != want[v.Volume] {
+			t.Errorf("%s: expected %s, got %s", v.Volume, want[v.Volume], v.State)
+		}
+	}
+}
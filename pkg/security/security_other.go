@@ -0,0 +1,8 @@
+//go:build !linux && !windows && !darwin
+// +build !linux,!windows,!darwin
+
+package security
+
+func collect(status *Status) {
+	status.addError("security status is not supported on this platform")
+}
@@ -0,0 +1,91 @@
+//go:build windows
+// +build windows
+
+package security
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// Most of these cmdlets require an elevated session; without it the values
+// stay unknown and the error is recorded.
+func collect(status *Status) {
+	collectSecureBoot(status)
+	collectTPM(status)
+	collectBitLocker(status)
+}
+
+// collectSecureBoot uses Confirm-SecureBootUEFI, which fails on legacy BIOS systems
+func collectSecureBoot(status *Status) {
+	output, err := safeexec.PowerShell(`try { if (Confirm-SecureBootUEFI) { 'enabled' } else { 'disabled' } } ` +
+		`catch [System.PlatformNotSupportedException] { 'unsupported' } catch { 'unknown' }`).Output()
+	if err != nil {
+		status.addError("secure boot: %v", err)
+		return
+	}
+
+	switch state := strings.TrimSpace(string(output)); state {
+	case StateEnabled, StateDisabled:
+		status.SecureBoot = state
+		status.BootMode = "uefi"
+	case StateUnsupported:
+		status.SecureBoot = state
+		status.BootMode = "legacy"
+	}
+}
+
+// collectTPM uses Get-Tpm and Win32_Tpm for the specification version
+func collectTPM(status *Status) {
+	output, err := safeexec.PowerShell(`$t = Get-Tpm; $w = Get-CimInstance -Namespace root/cimv2/Security/MicrosoftTpm -ClassName Win32_Tpm -ErrorAction SilentlyContinue; ` +
+		`[pscustomobject]@{ Present = $t.TpmPresent; Enabled = $t.TpmEnabled; Vendor = $t.ManufacturerIdTxt; Spec = $w.SpecVersion } | ConvertTo-Json -Compress`).Output()
+	if err != nil {
+		status.addError("tpm: %v", err)
+		return
+	}
+
+	var tpm struct {
+		Present bool   `json:"Present"`
+		Enabled bool   `json:"Enabled"`
+		Vendor  string `json:"Vendor"`
+		Spec    string `json:"Spec"`
+	}
+	if err := json.Unmarshal(output, &tpm); err != nil {
+		status.addError("tpm: %v", err)
+		return
+	}
+
+	status.TPM = TPMInfo{
+		Present: tpm.Present,
+		Enabled: tpm.Enabled,
+		Vendor:  strings.TrimSpace(tpm.Vendor),
+		Version: parseTPMSpecVersion(tpm.Spec),
+	}
+}
+
+// parseTPMSpecVersion extracts the major spec version from Win32_Tpm.SpecVersion ("2.0, 0, 1.59")
+func parseTPMSpecVersion(spec string) string {
+	if spec == "" {
+		return ""
+	}
+	return strings.TrimSpace(strings.Split(spec, ",")[0])
+}
+
+// collectBitLocker reports the BitLocker state of every volume
+func collectBitLocker(status *Status) {
+	output, err := safeexec.PowerShell(`ConvertTo-Json -Compress -InputObject @(Get-BitLockerVolume | ` +
+		`Select-Object MountPoint, VolumeStatus, ProtectionStatus, EncryptionMethod, EncryptionPercentage)`).Output()
+	if err != nil {
+		status.addError("bitlocker: %v", err)
+		return
+	}
+
+	volumes, err := parseBitLocker(output)
+	if err != nil {
+		status.addError("bitlocker: %v", err)
+		return
+	}
+	status.Volumes = append(status.Volumes, volumes...)
+}
@@ -0,0 +1,342 @@
+// Package sensorcheck checks that the sensors respond to a known load before
+// a long unattended burn-in relies on them.
+//
+// A check reads the sensors while the machine idles, then while a short
+// all-core load runs, and compares the two. CPU usage must rise to nearly
+// full, package power and temperature must rise, and fans must keep
+// spinning. A sensor that reports the same value throughout is frozen; one
+// that stops reporting under load is dead. Either makes safety limits and
+// throttle detection blind, so the check fails.
+package sensorcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/monitor"
+	"github.com/mscrnt/project_fire/pkg/plugin"
+)
+
+// Defaults for a check
+const (
+	DefaultLoad     = "cpu"
+	DefaultDuration = 30 * time.Second
+	DefaultIdle     = 5 * time.Second
+	DefaultInterval = time.Second
+)
+
+// Plausible responses to an all-core load
+const (
+	// MinLoadUsage is the CPU usage in percent the load must reach
+	MinLoadUsage = 80.0
+	// MinPowerRise is the rise in package power, as a fraction of idle, and
+	// MinPowerRiseW the rise in watts; both must be met
+	MinPowerRise  = 0.1
+	MinPowerRiseW = 2.0
+	// MinTempRise is the rise in °C from the idle mean to the peak under load
+	MinTempRise = 2.0
+	// MinSamples is how many readings a sensor needs to be judged frozen
+	MinSamples = 4
+)
+
+// Status is the verdict on one sensor
+type Status string
+
+// Statuses, from best to worst
+const (
+	StatusOK      Status = "ok"
+	StatusMissing Status = "missing" // Not read on this machine
+	StatusWarn    Status = "warn"
+	StatusFail    Status = "fail"
+)
+
+// Options configure a check. Zero values get the defaults.
+type Options struct {
+	Load     string
+	Duration time.Duration // How long the load runs
+	Idle     time.Duration // How long the idle baseline is read
+	Interval time.Duration
+	Threads  int // 0 = the plugin's default, every core for cpu
+}
+
+// Result is the verdict on one sensor
+type Result struct {
+	Sensor  string // The reading, e.g. cpu_temp or fan_cpu_fan
+	Status  Status
+	Idle    float64 // Mean while idle
+	Loaded  float64 // Mean under load
+	Peak    float64 // Highest under load
+	Unit    string
+	Message string
+}
+
+// Report is the outcome of a check
+type Report struct {
+	Load    string
+	Results []Result
+}
+
+// Passed reports whether no sensor failed. Missing sensors and warnings do
+// not fail the check.
+func (r *Report) Passed() bool {
+	for _, res := range r.Results {
+		if res.Status == StatusFail {
+			return false
+		}
+	}
+	return true
+}
+
+// Failures returns the messages of the sensors that failed
+func (r *Report) Failures() []string {
+	var out []string
+	for _, res := range r.Results {
+		if res.Status == StatusFail {
+			out = append(out, res.Message)
+		}
+	}
+	return out
+}
+
+// SampleFunc takes one set of readings with their units
+type SampleFunc func(ctx context.Context) (values map[string]float64, units map[string]string, err error)
+
+// MonitorSampler reads the sensors through a monitor poller
+func MonitorSampler() SampleFunc {
+	poller := monitor.NewPoller()
+	return func(ctx context.Context) (map[string]float64, map[string]string, error) {
+		s, err := poller.Sample(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		values, units := s.Metrics()
+		return values, units, nil
+	}
+}
+
+// Run reads the idle baseline, runs the load while reading the sensors and
+// judges them. The error is set when the check could not be carried out,
+// not when a sensor failed it.
+func Run(ctx context.Context, opts Options, sample SampleFunc) (*Report, error) {
+	if opts.Load == "" {
+		opts.Load = DefaultLoad
+	}
+	if opts.Duration <= 0 {
+		opts.Duration = DefaultDuration
+	}
+	if opts.Idle <= 0 {
+		opts.Idle = DefaultIdle
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+
+	p, err := plugin.Get(opts.Load)
+	if err != nil {
+		return nil, err
+	}
+	params := p.DefaultParams()
+	params.Duration = opts.Duration
+	if opts.Threads > 0 {
+		params.Threads = opts.Threads
+	}
+	if err := p.ValidateParams(params); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	units := make(map[string]string)
+	idleCtx, cancelIdle := context.WithTimeout(ctx, opts.Idle)
+	idle := record(idleCtx, sample, opts.Interval, units)
+	cancelIdle()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	loadCtx, stopLoad := context.WithTimeout(ctx, params.Duration+30*time.Second)
+	defer stopLoad()
+	readCtx, stopReading := context.WithCancel(loadCtx)
+	recorded := make(chan []map[string]float64, 1)
+	go func() {
+		recorded <- record(readCtx, sample, opts.Interval, units)
+	}()
+	result, loadErr := p.Run(loadCtx, params)
+	stopReading()
+	loaded := <-recorded
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if loadErr != nil || !result.Success {
+		msg := result.Error
+		if msg == "" && loadErr != nil {
+			msg = loadErr.Error()
+		}
+		return nil, fmt.Errorf("%s load failed: %s", opts.Load, msg)
+	}
+	if len(idle) == 0 || len(loaded) == 0 {
+		return nil, errors.New("no sensor readings were taken")
+	}
+
+	report := Evaluate(idle, loaded, units)
+	report.Load = opts.Load
+	return report, nil
+}
+
+// record takes a reading every interval until ctx ends. units collects the
+// unit of every reading seen.
+func record(ctx context.Context, sample SampleFunc, interval time.Duration, units map[string]string) []map[string]float64 {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var samples []map[string]float64
+	for {
+		if values, u, err := sample(ctx); err == nil && len(values) > 0 {
+			samples = append(samples, values)
+			for k, v := range u {
+				units[k] = v
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return samples
+		case <-ticker.C:
+		}
+	}
+}
+
+// series is one reading across the samples of a phase
+type series struct {
+	values []float64
+	seen   int // Samples it appeared in
+}
+
+func collect(samples []map[string]float64, metric string) series {
+	var s series
+	for _, sample := range samples {
+		if v, ok := sample[metric]; ok {
+			s.values = append(s.values, v)
+			s.seen++
+		}
+	}
+	return s
+}
+
+func (s series) mean() float64 {
+	if len(s.values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range s.values {
+		sum += v
+	}
+	return sum / float64(len(s.values))
+}
+
+func (s series) peak() float64 {
+	peak := math.Inf(-1)
+	for _, v := range s.values {
+		peak = math.Max(peak, v)
+	}
+	if len(s.values) == 0 {
+		return 0
+	}
+	return peak
+}
+
+// frozen reports whether the readings of both phases are all the same value
+func frozen(idle, loaded series) bool {
+	all := append(append([]float64(nil), idle.values...), loaded.values...)
+	if len(all) < MinSamples {
+		return false
+	}
+	for _, v := range all[1:] {
+		if v != all[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// Evaluate judges the sensors from the readings taken while idle and under
+// load. Readings are named as monitor.Sample.Metrics names them.
+func Evaluate(idle, loaded []map[string]float64, units map[string]string) *Report {
+	r := &Report{}
+	judge := func(metric string, check func(res *Result, idle, loaded series)) {
+		i, l := collect(idle, metric), collect(loaded, metric)
+		res := Result{Sensor: metric, Unit: units[metric], Idle: i.mean(), Loaded: l.mean(), Peak: l.peak()}
+		switch {
+		case i.seen == 0 && l.seen == 0:
+			res.Status = StatusMissing
+			res.Message = fmt.Sprintf("%s is not read on this machine", metric)
+		case i.seen > 0 && l.seen == 0:
+			res.Status = StatusFail
+			res.Message = fmt.Sprintf("%s stopped reporting under load", metric)
+		case frozen(i, l):
+			res.Status = StatusFail
+			res.Message = fmt.Sprintf("%s is frozen at %g %s", metric, l.values[0], res.Unit)
+		default:
+			res.Status = StatusOK
+			check(&res, i, l)
+		}
+		r.Results = append(r.Results, res)
+	}
+
+	judge("cpu_usage", func(res *Result, _, _ series) {
+		if res.Loaded < MinLoadUsage {
+			res.Status = StatusFail
+			res.Message = fmt.Sprintf("cpu_usage averaged %.0f%% under an all-core load (expected at least %.0f%%)", res.Loaded, MinLoadUsage)
+		}
+	})
+	judge("cpu_power", func(res *Result, i, _ series) {
+		rise := res.Loaded - res.Idle
+		if i.seen > 0 && (rise < MinPowerRiseW || rise < res.Idle*MinPowerRise) {
+			res.Status = StatusFail
+			res.Message = fmt.Sprintf("cpu_power did not rise under load (%.1f W idle, %.1f W loaded)", res.Idle, res.Loaded)
+		}
+	})
+	judge("cpu_temp", func(res *Result, i, _ series) {
+		if i.seen > 0 && res.Peak-res.Idle < MinTempRise {
+			res.Status = StatusFail
+			res.Message = fmt.Sprintf("cpu_temp did not rise under load (%.1f °C idle, %.1f °C peak)", res.Idle, res.Peak)
+		}
+	})
+
+	// Fans only need to keep spinning; one that does not speed up may be on
+	// a fixed curve
+	fans := make(map[string]bool)
+	for _, phase := range [][]map[string]float64{idle, loaded} {
+		for _, sample := range phase {
+			for metric := range sample {
+				if strings.HasPrefix(metric, "fan_") {
+					fans[metric] = true
+				}
+			}
+		}
+	}
+	names := make([]string, 0, len(fans))
+	for name := range fans {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		judge(name, func(res *Result, _, _ series) {
+			if res.Loaded < res.Idle {
+				res.Status = StatusWarn
+				res.Message = fmt.Sprintf("%s slowed down under load (%.0f to %.0f %s)", name, res.Idle, res.Loaded, res.Unit)
+			}
+		})
+	}
+	if len(names) == 0 {
+		r.Results = append(r.Results, Result{Sensor: "fan_*", Status: StatusMissing, Message: "no fan speeds are read on this machine"})
+	}
+
+	for i := range r.Results {
+		if r.Results[i].Status == StatusOK && r.Results[i].Message == "" {
+			r.Results[i].Message = fmt.Sprintf("%s responds to load", r.Results[i].Sensor)
+		}
+	}
+	return r
+}
@@ -0,0 +1,172 @@
+package sensorcheck
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/plugin"
+)
+
+// phase builds samples from per-metric series of equal length
+func phase(series map[string][]float64) []map[string]float64 {
+	var n int
+	for _, values := range series {
+		n = len(values)
+	}
+	samples := make([]map[string]float64, n)
+	for i := range samples {
+		samples[i] = make(map[string]float64)
+		for metric, values := range series {
+			if values[i] != 0 {
+				samples[i][metric] = values[i]
+			}
+		}
+	}
+	return samples
+}
+
+func resultFor(t *testing.T, r *Report, sensor string) Result {
+	t.Helper()
+	for _, res := range r.Results {
+		if res.Sensor == sensor {
+			return res
+		}
+	}
+	t.Fatalf("no result for %s in %+v", sensor, r.Results)
+	return Result{}
+}
+
+func TestEvaluate(t *testing.T) {
+	idle := phase(map[string][]float64{
+		"cpu_usage":   {3, 5, 4, 2},
+		"cpu_power":   {12, 13, 12, 11},
+		"cpu_temp":    {38, 39, 38, 38},
+		"fan_cpu_fan": {900, 910, 905, 900},
+	})
+	loaded := phase(map[string][]float64{
+		"cpu_usage":   {99, 100, 100, 100},
+		"cpu_power":   {85, 110, 112, 111},
+		"cpu_temp":    {55, 68, 74, 77},
+		"fan_cpu_fan": {1100, 1500, 1700, 1800},
+	})
+	r := Evaluate(idle, loaded, map[string]string{"cpu_temp": "°C"})
+	if !r.Passed() {
+		t.Fatalf("expected responsive sensors to pass, got %v", r.Failures())
+	}
+	if res := resultFor(t, r, "cpu_temp"); res.Status != StatusOK || res.Peak != 77 || res.Idle != 38.25 {
+		t.Errorf("unexpected cpu_temp result %+v", res)
+	}
+}
+
+func TestEvaluateFaults(t *testing.T) {
+	idle := phase(map[string][]float64{
+		"cpu_usage":    {3, 5, 4},
+		"cpu_power":    {12, 13, 12},
+		"cpu_temp":     {45, 45, 45},
+		"fan_cpu_fan":  {900, 910, 905},
+		"fan_case_fan": {700, 700, 710},
+	})
+	loaded := phase(map[string][]float64{
+		"cpu_usage":    {40, 45, 42},
+		"cpu_power":    {12, 13, 13},
+		"cpu_temp":     {45, 45, 45},
+		"fan_cpu_fan":  {0, 0, 0},
+		"fan_case_fan": {650, 640, 600},
+	})
+	r := Evaluate(idle, loaded, map[string]string{"cpu_temp": "°C"})
+	if r.Passed() {
+		t.Fatal("expected the check to fail")
+	}
+
+	want := map[string]struct {
+		status  Status
+		message string
+	}{
+		"cpu_usage":    {StatusFail, "averaged 42%"},
+		"cpu_power":    {StatusFail, "did not rise"},
+		"cpu_temp":     {StatusFail, "frozen at 45 °C"},
+		"fan_cpu_fan":  {StatusFail, "stopped reporting"},
+		"fan_case_fan": {StatusWarn, "slowed down"},
+	}
+	for sensor, w := range want {
+		res := resultFor(t, r, sensor)
+		if res.Status != w.status || !strings.Contains(res.Message, w.message) {
+			t.Errorf("expected %s to be %s with %q, got %+v", sensor, w.status, w.message, res)
+		}
+	}
+	if len(r.Failures()) != 4 {
+		t.Errorf("expected four failures, got %v", r.Failures())
+	}
+}
+
+func TestEvaluateMissing(t *testing.T) {
+	idle := phase(map[string][]float64{"cpu_usage": {2, 3}})
+	loaded := phase(map[string][]float64{"cpu_usage": {100, 100}})
+	r := Evaluate(idle, loaded, nil)
+	if !r.Passed() {
+		t.Errorf("expected sensors this machine lacks not to fail the check, got %v", r.Failures())
+	}
+	for _, sensor := range []string{"cpu_power", "cpu_temp", "fan_*"} {
+		if res := resultFor(t, r, sensor); res.Status != StatusMissing {
+			t.Errorf("expected %s to be missing, got %+v", sensor, res)
+		}
+	}
+}
+
+// checkTestPlugin runs for its duration
+type checkTestPlugin struct{}
+
+func (checkTestPlugin) Name() string        { return "sensorcheck-test" }
+func (checkTestPlugin) Description() string { return "Test load for sensor checks" }
+func (checkTestPlugin) DefaultParams() plugin.Params {
+	return plugin.Params{Duration: time.Second, Threads: 1}
+}
+func (checkTestPlugin) ValidateParams(plugin.Params) error { return nil }
+func (checkTestPlugin) Run(ctx context.Context, params plugin.Params) (plugin.Result, error) {
+	select {
+	case <-time.After(params.Duration):
+	case <-ctx.Done():
+		return plugin.Result{Error: "stopped"}, ctx.Err()
+	}
+	return plugin.Result{Success: true}, nil
+}
+
+func init() {
+	_ = plugin.Register(checkTestPlugin{})
+}
+
+func TestRun(t *testing.T) {
+	var loading atomic.Bool
+	var temp atomic.Int64
+	temp.Store(40)
+	sample := func(context.Context) (map[string]float64, map[string]string, error) {
+		if !loading.Load() {
+			return map[string]float64{"cpu_usage": 2, "cpu_temp": 40}, map[string]string{"cpu_usage": "%", "cpu_temp": "°C"}, nil
+		}
+		return map[string]float64{"cpu_usage": 100, "cpu_temp": float64(temp.Add(1))}, nil, nil
+	}
+	opts := Options{Load: "sensorcheck-test", Duration: 50 * time.Millisecond, Idle: 20 * time.Millisecond, Interval: 5 * time.Millisecond}
+
+	// The load starts once the idle phase is over
+	go func() {
+		time.Sleep(opts.Idle + 5*time.Millisecond)
+		loading.Store(true)
+	}()
+	r, err := Run(context.Background(), opts, sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Load != "sensorcheck-test" {
+		t.Errorf("expected the load to be named, got %q", r.Load)
+	}
+	if res := resultFor(t, r, "cpu_temp"); res.Status != StatusOK || res.Unit != "°C" {
+		t.Errorf("expected a rising temperature to pass, got %+v", res)
+	}
+
+	if _, err := Run(context.Background(), Options{Load: "no-such-plugin"}, sample); err == nil {
+		t.Error("expected an unknown load to be refused")
+	}
+}
@@ -0,0 +1,131 @@
+package sensors
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/ipmi"
+)
+
+// bmcInterval is how long the BMC's readings are reused. ipmitool takes a
+// second or more to walk the sensor records, so they are refreshed in the
+// background while the previous ones are returned.
+const bmcInterval = 10 * time.Second
+
+// bmcTimeout bounds one walk of the sensor records
+const bmcTimeout = 30 * time.Second
+
+// bmcProvider adds the sensors of a server's BMC to the readings of the
+// platform provider: chassis and board temperatures, power supply input
+// power and the fans the BMC drives
+type bmcProvider struct {
+	host Provider
+	read func(ctx context.Context) ([]ipmi.Sensor, error)
+
+	mu          sync.Mutex
+	readings    []ipmi.Sensor
+	at          time.Time
+	refreshing  bool
+	unavailable bool // No BMC answered the first read
+}
+
+func withBMC(host Provider) Provider {
+	return &bmcProvider{host: host, read: ipmi.ReadSensors}
+}
+
+func (b *bmcProvider) Name() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.unavailable || b.at.IsZero() {
+		return b.host.Name()
+	}
+	return b.host.Name() + "+ipmi"
+}
+
+func (b *bmcProvider) Read(ctx context.Context) (*Snapshot, error) {
+	s, err := b.host.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	addBMC(s, b.sensors(ctx))
+	return s, nil
+}
+
+// sensors returns the latest BMC readings. The first call waits for them;
+// later calls start a refresh once they are older than bmcInterval.
+func (b *bmcProvider) sensors(ctx context.Context) []ipmi.Sensor {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.unavailable {
+		return nil
+	}
+	if b.at.IsZero() {
+		ctx, cancel := context.WithTimeout(ctx, bmcTimeout)
+		defer cancel()
+		readings, err := b.read(ctx)
+		if err != nil {
+			b.unavailable = true
+			return nil
+		}
+		b.readings, b.at = readings, time.Now()
+	} else if time.Since(b.at) >= bmcInterval && !b.refreshing {
+		b.refreshing = true
+		go b.refresh()
+	}
+	return b.readings
+}
+
+func (b *bmcProvider) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), bmcTimeout)
+	defer cancel()
+	readings, err := b.read(ctx)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refreshing = false
+	b.at = time.Now()
+	if err != nil {
+		readings = nil // Stale readings are worse than none
+	}
+	b.readings = readings
+}
+
+// addBMC adds the BMC's readings to a snapshot. The CPU temperature is only
+// taken from the BMC when the platform provider has none.
+func addBMC(s *Snapshot, readings []ipmi.Sensor) {
+	var total, inputs, cpuTemp float64
+	for _, r := range readings {
+		reading := Reading{Name: r.Name, Source: "BMC", Value: r.Value}
+		name := strings.ToLower(r.Name)
+		switch r.Kind {
+		case ipmi.KindTemperature:
+			s.Temperatures = append(s.Temperatures, reading)
+			if strings.Contains(name, "cpu") {
+				cpuTemp = max(cpuTemp, r.Value)
+			}
+		case ipmi.KindFan:
+			s.Fans = append(s.Fans, reading)
+		case ipmi.KindVoltage:
+			s.Voltages = append(s.Voltages, reading)
+		case ipmi.KindPower:
+			s.Power = append(s.Power, reading)
+			// A total such as "Pwr Consumption" or "Total Power" beats the
+			// sum of the supplies' input power
+			switch {
+			case strings.Contains(name, "consumption") || strings.Contains(name, "total") || strings.Contains(name, "system"):
+				total = max(total, r.Value)
+			case strings.Contains(name, "input") || strings.Contains(name, " pin"):
+				inputs += r.Value
+			}
+		}
+	}
+	if total == 0 {
+		total = inputs
+	}
+	s.SystemPower = total
+	if s.CPUTemp == 0 {
+		s.CPUTemp = cpuTemp
+	}
+}
@@ -0,0 +1,234 @@
+package sensors
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// monitorSensor is a LibreHardwareMonitor or OpenHardwareMonitor sensor
+type monitorSensor struct {
+	Name       string  // e.g. "CPU Package"
+	Identifier string  // e.g. "/intelcpu/0/temperature/0"
+	SensorType string  // e.g. "Temperature"
+	Parent     string  // Hardware identifier, e.g. "/intelcpu/0"
+	Value      float32 // °C, W, MHz, RPM or V
+}
+
+// monitorHardware is a device the hardware monitor reads sensors from
+type monitorHardware struct {
+	Name       string // e.g. "NVIDIA GeForce RTX 4090"
+	Identifier string // e.g. "/gpu-nvidia/0"
+}
+
+// fromHardwareMonitor fills the snapshot from the sensors of the named
+// hardware monitor
+func fromHardwareMonitor(s *Snapshot, sensors []monitorSensor, hardware []monitorHardware, name string) {
+	sort.Slice(sensors, func(i, j int) bool { return sensors[i].Identifier < sensors[j].Identifier })
+	names := make(map[string]string, len(hardware))
+	for _, h := range hardware {
+		names[h.Identifier] = h.Name
+	}
+
+	var cpuTemp, voltage bestScore
+	var clocks, vids []monitorSensor
+	gpus := make(map[string]*GPUReading)
+	for _, sensor := range sensors {
+		value := float64(sensor.Value)
+		cpu := strings.Contains(sensor.Parent, "cpu")
+		r := Reading{Name: sensor.Name, Source: sensor.Parent, Value: value}
+
+		if strings.HasPrefix(sensor.Parent, "/gpu") {
+			g := gpus[sensor.Parent]
+			if g == nil {
+				g = &GPUReading{Name: names[sensor.Parent], Vendor: gpuVendor(sensor.Parent)}
+				gpus[sensor.Parent] = g
+			}
+			g.add(sensor)
+		}
+
+		switch sensor.SensorType {
+		case "Temperature":
+			s.Temperatures = append(s.Temperatures, r)
+			if cpu {
+				cpuTemp.offer(cpuSensorScore(sensor.Name), value, name)
+			}
+			if (strings.HasPrefix(sensor.Parent, "/ram") || strings.HasPrefix(sensor.Parent, "/memory")) && value > s.MemoryTemp {
+				s.MemoryTemp = value
+			}
+			if vrmSensor(sensor) && value > s.VRMTemp {
+				s.VRMTemp = value
+			}
+		case "Fan":
+			s.Fans = append(s.Fans, r)
+		case "Voltage":
+			s.Voltages = append(s.Voltages, r)
+			if cpu || cpuVoltageLabel(sensor.Name) {
+				voltage.offer(cpuSensorScore(sensor.Name), value, name)
+			}
+			if cpu && strings.Contains(sensor.Name, "Core #") {
+				vids = append(vids, sensor)
+			}
+		case "Power":
+			if cpu && (sensor.Name == "CPU Package" || sensor.Name == "Package") {
+				s.PackagePower += value
+				s.PowerSource = name
+			}
+		case "Clock":
+			if cpu && strings.Contains(sensor.Name, "Core #") {
+				clocks = append(clocks, sensor)
+			}
+		}
+	}
+	s.CPUTemp = cpuTemp.value
+	s.CPUVoltage, s.VoltageSource = voltage.value, voltage.source
+
+	// Identifiers sort "/clock/10" before "/clock/2", so order by core number
+	sort.SliceStable(clocks, func(i, j int) bool { return coreNumber(clocks[i].Name) < coreNumber(clocks[j].Name) })
+	for _, c := range clocks {
+		s.CoreClocks = append(s.CoreClocks, float64(c.Value))
+	}
+	sort.SliceStable(vids, func(i, j int) bool { return coreNumber(vids[i].Name) < coreNumber(vids[j].Name) })
+	for _, v := range vids {
+		s.CoreVoltages = append(s.CoreVoltages, float64(v.Value))
+	}
+
+	ids := make([]string, 0, len(gpus))
+	for id := range gpus {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		s.GPUs = append(s.GPUs, *gpus[id])
+	}
+}
+
+// add takes a GPU sensor into the reading
+func (g *GPUReading) add(sensor monitorSensor) {
+	value := float64(sensor.Value)
+	switch {
+	case sensor.SensorType == "Temperature" && sensor.Name == "GPU Core":
+		g.Temp = value
+	case sensor.SensorType == "Temperature" && strings.HasPrefix(sensor.Name, "GPU Hot Spot"):
+		g.Hotspot = value
+	case sensor.SensorType == "Clock" && sensor.Name == "GPU Core":
+		g.CoreClock = value
+	case sensor.SensorType == "Voltage" && sensor.Name == "GPU Core":
+		g.CoreVoltage = value
+	case sensor.SensorType == "Power" && (sensor.Name == "GPU Package" || sensor.Name == "GPU Power") && value > g.Power:
+		g.Power = value
+	}
+}
+
+// gpuVendor names the vendor of a GPU from its identifier, e.g.
+// "/gpu-nvidia/0"
+func gpuVendor(identifier string) string {
+	switch {
+	case strings.HasPrefix(identifier, "/gpu-nvidia"):
+		return "NVIDIA"
+	case strings.HasPrefix(identifier, "/gpu-amd"):
+		return "AMD"
+	case strings.HasPrefix(identifier, "/gpu-intel"):
+		return "Intel"
+	}
+	return ""
+}
+
+// vrmSensor reports whether a temperature sensor is on the voltage
+// regulators, e.g. "VRM MOS" or "VR VCC Temperature"
+func vrmSensor(sensor monitorSensor) bool {
+	if strings.HasPrefix(sensor.Parent, "/gpu") {
+		return false
+	}
+	name := strings.ToLower(sensor.Name)
+	return strings.Contains(name, "vrm") || strings.Contains(name, "mos") || strings.HasPrefix(name, "vr ")
+}
+
+// cpuSensorScore ranks CPU sensors so the package reading wins over a
+// single core's
+func cpuSensorScore(name string) int {
+	switch {
+	case name == "CPU Package", name == "Core (Tctl/Tdie)", name == "CPU Core":
+		return 10
+	case name == "Core (SVI2 TFN)", strings.HasPrefix(name, "Core Max"):
+		return 8
+	case strings.HasPrefix(name, "CPU Core #"), strings.HasPrefix(name, "Core #"):
+		return 5
+	}
+	return 1
+}
+
+// coreNumber returns N for a sensor named "CPU Core #N"
+func coreNumber(name string) int {
+	i := strings.LastIndex(name, "#")
+	n, _ := strconv.Atoi(strings.Fields(name[i+1:] + " ")[0])
+	return n
+}
+
+// webNode is a node of the tree LibreHardwareMonitor's web server serves as
+// data.json: the computer, its hardware, sensor groups and sensors
+type webNode struct {
+	Text     string    `json:"Text"`
+	Value    string    `json:"Value"` // e.g. "45.0 °C" or "1,224 V"
+	SensorID string    `json:"SensorId"`
+	Type     string    `json:"Type"`
+	Children []webNode `json:"Children"`
+}
+
+// parseWebData reads the sensors and hardware out of LibreHardwareMonitor's
+// data.json. A sensor's hardware is the node above its group, e.g.
+// "Voltages".
+func parseWebData(data []byte) ([]monitorSensor, []monitorHardware, error) {
+	var root webNode
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse LibreHardwareMonitor data: %w", err)
+	}
+
+	var sensors []monitorSensor
+	var hardware []monitorHardware
+	seen := make(map[string]bool)
+	var walk func(n webNode, path []string)
+	walk = func(n webNode, path []string) {
+		if n.SensorID == "" {
+			path = append(path[:len(path):len(path)], n.Text)
+			for _, c := range n.Children {
+				walk(c, path)
+			}
+			return
+		}
+
+		// "/lpc/nct6798d/0/voltage/0" belongs to "/lpc/nct6798d/0"
+		parts := strings.Split(n.SensorID, "/")
+		if len(parts) < 3 || len(path) < 2 {
+			return
+		}
+		parent := strings.Join(parts[:len(parts)-2], "/")
+		sensorType := n.Type
+		if kind := parts[len(parts)-2]; sensorType == "" && kind != "" {
+			sensorType = strings.ToUpper(kind[:1]) + kind[1:]
+		}
+		fields := strings.Fields(strings.ReplaceAll(n.Value, ",", "."))
+		if len(fields) == 0 {
+			return
+		}
+		value, err := strconv.ParseFloat(fields[0], 32)
+		if err != nil {
+			return
+		}
+		sensors = append(sensors, monitorSensor{
+			Name:       n.Text,
+			Identifier: n.SensorID,
+			SensorType: sensorType,
+			Parent:     parent,
+			Value:      float32(value),
+		})
+		if !seen[parent] {
+			seen[parent] = true
+			hardware = append(hardware, monitorHardware{Name: path[len(path)-2], Identifier: parent})
+		}
+	}
+	walk(root, nil)
+	return sensors, hardware, nil
+}
@@ -0,0 +1,96 @@
+package sensors
+
+import "testing"
+
+// webData is a trimmed data.json from LibreHardwareMonitor's web server
+const webData = `{"id": 0, "Text": "Sensor", "Value": "Value", "Children": [
+  {"id": 1, "Text": "BENCH-01", "Value": "", "Children": [
+    {"id": 2, "Text": "ASUS ProArt X670E-CREATOR", "Value": "", "Children": [
+      {"id": 3, "Text": "Nuvoton NCT6799D", "Value": "", "Children": [
+        {"id": 4, "Text": "Voltages", "Value": "", "Children": [
+          {"id": 5, "Text": "Vcore", "Value": "1,224 V", "SensorId": "/lpc/nct6799d/0/voltage/0", "Type": "Voltage", "Children": []},
+          {"id": 6, "Text": "+12V", "Value": "12,096 V", "SensorId": "/lpc/nct6799d/0/voltage/1", "Type": "Voltage", "Children": []}
+        ]},
+        {"id": 7, "Text": "Temperatures", "Value": "", "Children": [
+          {"id": 8, "Text": "VRM MOS", "Value": "61,0 °C", "SensorId": "/lpc/nct6799d/0/temperature/2", "Children": []}
+        ]}
+      ]}
+    ]},
+    {"id": 9, "Text": "AMD Ryzen 9 7950X", "Value": "", "Children": [
+      {"id": 10, "Text": "Clocks", "Value": "", "Children": [
+        {"id": 11, "Text": "Core #2", "Value": "5475,3 MHz", "SensorId": "/amdcpu/0/clock/3", "Type": "Clock", "Children": []},
+        {"id": 12, "Text": "Core #1", "Value": "5500,1 MHz", "SensorId": "/amdcpu/0/clock/2", "Type": "Clock", "Children": []}
+      ]},
+      {"id": 13, "Text": "Temperatures", "Value": "", "Children": [
+        {"id": 14, "Text": "Core (Tctl/Tdie)", "Value": "88,4 °C", "SensorId": "/amdcpu/0/temperature/2", "Type": "Temperature", "Children": []}
+      ]},
+      {"id": 15, "Text": "Powers", "Value": "", "Children": [
+        {"id": 16, "Text": "Package", "Value": "201,7 W", "SensorId": "/amdcpu/0/power/0", "Type": "Power", "Children": []}
+      ]}
+    ]},
+    {"id": 17, "Text": "NVIDIA GeForce RTX 4090", "Value": "", "Children": [
+      {"id": 18, "Text": "Temperatures", "Value": "", "Children": [
+        {"id": 19, "Text": "GPU Core", "Value": "64,0 °C", "SensorId": "/gpu-nvidia/0/temperature/0", "Type": "Temperature", "Children": []},
+        {"id": 20, "Text": "GPU Hot Spot", "Value": "75,5 °C", "SensorId": "/gpu-nvidia/0/temperature/2", "Type": "Temperature", "Children": []}
+      ]},
+      {"id": 21, "Text": "Clocks", "Value": "", "Children": [
+        {"id": 22, "Text": "GPU Core", "Value": "2745,0 MHz", "SensorId": "/gpu-nvidia/0/clock/0", "Type": "Clock", "Children": []}
+      ]},
+      {"id": 23, "Text": "Powers", "Value": "", "Children": [
+        {"id": 24, "Text": "GPU Package", "Value": "431,2 W", "SensorId": "/gpu-nvidia/0/power/0", "Type": "Power", "Children": []}
+      ]},
+      {"id": 25, "Text": "Fans", "Value": "", "Children": [
+        {"id": 26, "Text": "GPU Fan 1", "Value": "-", "SensorId": "/gpu-nvidia/0/fan/1", "Type": "Fan", "Children": []}
+      ]}
+    ]}
+  ]}
+]}`
+
+func TestParseWebData(t *testing.T) {
+	sensors, hardware, err := parseWebData([]byte(webData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sensors) != 11 {
+		t.Fatalf("expected 11 sensors with readings, got %+v", sensors)
+	}
+	if s := sensors[0]; s.Name != "Vcore" || s.Parent != "/lpc/nct6799d/0" || s.Value < 1.2239 || s.Value > 1.2241 {
+		t.Errorf("unexpected sensor %+v", s)
+	}
+	if s := sensors[2]; s.SensorType != "Temperature" {
+		t.Errorf("expected the type from the identifier, got %+v", s)
+	}
+	if len(hardware) != 3 || hardware[2].Name != "NVIDIA GeForce RTX 4090" || hardware[2].Identifier != "/gpu-nvidia/0" {
+		t.Errorf("unexpected hardware %+v", hardware)
+	}
+
+	if _, _, err := parseWebData([]byte("<html>")); err == nil {
+		t.Error("expected an error for a page that is not data.json")
+	}
+}
+
+func TestFromHardwareMonitor(t *testing.T) {
+	sensors, hardware, err := parseWebData([]byte(webData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Snapshot{}
+	fromHardwareMonitor(s, sensors, hardware, "LibreHardwareMonitor")
+
+	if s.CPUTemp < 88.3 || s.CPUTemp > 88.5 || s.PackagePower < 201 || s.PowerSource != "LibreHardwareMonitor" {
+		t.Errorf("unexpected CPU readings: %v °C, %v W from %q", s.CPUTemp, s.PackagePower, s.PowerSource)
+	}
+	if len(s.CoreClocks) != 2 || s.CoreClocks[0] < 5500 {
+		t.Errorf("expected core clocks in core order, got %v", s.CoreClocks)
+	}
+	if s.CPUVoltage < 1.2 || s.VRMTemp != 61 || len(s.Voltages) != 2 {
+		t.Errorf("expected the board's Vcore and VRM temperature, got %v V and %v °C", s.CPUVoltage, s.VRMTemp)
+	}
+	if len(s.GPUs) != 1 {
+		t.Fatalf("expected one GPU, got %+v", s.GPUs)
+	}
+	g := s.GPUs[0]
+	if g.Name != "NVIDIA GeForce RTX 4090" || g.Vendor != "NVIDIA" || g.Temp != 64 || g.Hotspot != 75.5 || g.CoreClock != 2745 || g.Power < 431 {
+		t.Errorf("unexpected GPU %+v", g)
+	}
+}
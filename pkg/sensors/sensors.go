@@ -0,0 +1,203 @@
+// Package sensors reads hardware sensors: CPU temperature, package power,
+// per-core clocks and voltages, fan speeds and board voltages.
+//
+// Each platform has its own backend: hwmon, RAPL and cpufreq in sysfs on
+// Linux, and LibreHardwareMonitor (or OpenHardwareMonitor) through WMI on
+// Windows, falling back to the ACPI thermal zones. On servers the BMC's
+// sensors are added through ipmitool, with the power supplies' input power
+// as the system power. The GUI dashboard and the remote agent read the same
+// provider, so both show the same values. Readings a backend cannot take are
+// left out rather than estimated.
+package sensors
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/helper"
+)
+
+// Reading is one sensor value
+type Reading struct {
+	Name     string  `json:"name"`               // e.g. "Package id 0" or "CPU Fan"
+	Source   string  `json:"source,omitempty"`   // Chip or hardware, e.g. "coretemp"
+	Value    float64 `json:"value"`              // °C, RPM, V or W
+	Critical float64 `json:"critical,omitempty"` // Temperatures only, when reported
+}
+
+// GPUReading holds the sensors of one GPU. Values the backend cannot read
+// are zero.
+type GPUReading struct {
+	Name        string  `json:"name"`
+	Vendor      string  `json:"vendor,omitempty"`
+	Temp        float64 `json:"temp_c,omitempty"`
+	Hotspot     float64 `json:"hotspot_c,omitempty"` // Hottest point of the die
+	CoreClock   float64 `json:"core_clock_mhz,omitempty"`
+	CoreVoltage float64 `json:"core_voltage_v,omitempty"`
+	Power       float64 `json:"power_w,omitempty"`
+}
+
+// Snapshot holds the readings taken at one time. The headline values are
+// zero when the backend cannot read them.
+type Snapshot struct {
+	Time         time.Time `json:"time"`
+	CPUTemp      float64   `json:"cpu_temp_c,omitempty"`
+	PackagePower float64   `json:"package_power_w,omitempty"`
+	CPUVoltage   float64   `json:"cpu_voltage_v,omitempty"`   // Core voltage, or the highest core VID
+	CoreVoltages []float64 `json:"core_voltages_v,omitempty"` // VID of each core, where reported
+	MemoryTemp   float64   `json:"memory_temp_c,omitempty"`   // Hottest DIMM
+	VRMTemp      float64   `json:"vrm_temp_c,omitempty"`      // Hottest voltage regulator sensor on the board
+	CoreClocks   []float64 `json:"core_clocks_mhz,omitempty"`
+	Temperatures []Reading `json:"temperatures,omitempty"`
+	Fans         []Reading `json:"fans,omitempty"`
+	Voltages     []Reading `json:"voltages,omitempty"`
+	Power        []Reading `json:"power,omitempty"` // Power sensors of the BMC, e.g. "PS1 Input Power"
+
+	// GPUs holds the GPU sensors the backend reads, on Windows through the
+	// hardware monitor. Elsewhere the GPU readings come from the drivers.
+	GPUs []GPUReading `json:"gpus,omitempty"`
+
+	// SystemPower is the input power of the power supplies, from the BMC
+	SystemPower float64 `json:"system_power_w,omitempty"`
+
+	// PowerSource names the counter package power comes from, e.g.
+	// "intel-rapl". Energy counters give no power until the second read.
+	PowerSource string `json:"power_source,omitempty"`
+	// PowerUnavailable says why package power cannot be read, when it cannot
+	PowerUnavailable string `json:"power_unavailable,omitempty"`
+
+	// VoltageSource names where the CPU voltage comes from, e.g. "zenpower"
+	// for SVI2 telemetry or "msr" for the VID in IA32_PERF_STATUS
+	VoltageSource string `json:"voltage_source,omitempty"`
+	// VoltageUnavailable says why the CPU voltage cannot be read, when it cannot
+	VoltageUnavailable string `json:"voltage_unavailable,omitempty"`
+}
+
+// MaxCoreClock returns the highest core clock in MHz, or 0 if unknown
+func (s *Snapshot) MaxCoreClock() float64 {
+	highest := 0.0
+	for _, mhz := range s.CoreClocks {
+		if mhz > highest {
+			highest = mhz
+		}
+	}
+	return highest
+}
+
+// AvgCoreClock returns the average core clock in MHz, or 0 if unknown
+func (s *Snapshot) AvgCoreClock() float64 {
+	if len(s.CoreClocks) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, mhz := range s.CoreClocks {
+		sum += mhz
+	}
+	return sum / float64(len(s.CoreClocks))
+}
+
+// Provider reads the sensors of a platform
+type Provider interface {
+	// Name identifies the backend, e.g. "hwmon" or "LibreHardwareMonitor"
+	Name() string
+	// Read takes a snapshot of every sensor the backend can read
+	Read(ctx context.Context) (*Snapshot, error)
+}
+
+// minReadInterval is how long a snapshot is reused. Package power is
+// derived from the energy used between reads, which is noisy over very short
+// intervals, and WMI queries are slow.
+const minReadInterval = 500 * time.Millisecond
+
+var (
+	defaultOnce     sync.Once
+	defaultProvider Provider
+	options         Options
+)
+
+// Options selects the backends of the default provider
+type Options struct {
+	NoBMC  bool   // Do not read the BMC through ipmitool
+	WebURL string // LibreHardwareMonitor's web server on Windows, if not the default
+}
+
+// privilegedHelper reads the registers that need root, when connected
+var privilegedHelper atomic.Pointer[helper.Client]
+
+// SetHelper makes the providers read model-specific registers through the
+// privileged helper instead of the MSR devices, which need root. nil goes
+// back to reading the devices.
+func SetHelper(client *helper.Client) {
+	privilegedHelper.Store(client)
+}
+
+// Configure sets the options of the default provider. It has to be called
+// before the first call of Default.
+func Configure(o Options) {
+	options = o
+}
+
+// Default returns the provider for this platform. It is shared by every
+// caller in the process, so package power is measured over the intervals
+// between any callers' reads.
+func Default() Provider {
+	defaultOnce.Do(func() {
+		provider := newProvider()
+		if !options.NoBMC {
+			provider = withBMC(provider)
+		}
+		defaultProvider = &cachedProvider{provider: provider}
+	})
+	return defaultProvider
+}
+
+// cachedProvider reuses a recent snapshot instead of reading again
+type cachedProvider struct {
+	provider Provider
+
+	mu   sync.Mutex
+	last *Snapshot
+}
+
+func (c *cachedProvider) Name() string {
+	return c.provider.Name()
+}
+
+func (c *cachedProvider) Read(ctx context.Context) (*Snapshot, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.last != nil && time.Since(c.last.Time) < minReadInterval {
+		return c.last, nil
+	}
+	s, err := c.provider.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.last = s
+	return s, nil
+}
+
+// cpuVoltageLabel reports whether a voltage sensor label names the CPU core
+// voltage, e.g. "Vcore", "CPU Core" or "Core #1 VID"
+func cpuVoltageLabel(label string) bool {
+	label = strings.ToLower(label)
+	return strings.Contains(label, "vcore") || strings.Contains(label, "cpu core") ||
+		strings.Contains(label, "core voltage") || strings.HasSuffix(label, " vid") || label == "vid"
+}
+
+// bestScore keeps the value with the highest score above zero, and where
+// it came from
+type bestScore struct {
+	score  int
+	value  float64
+	source string
+}
+
+func (b *bestScore) offer(score int, value float64, source string) {
+	if score > b.score && value > 0 {
+		b.score, b.value, b.source = score, value, source
+	}
+}
@@ -0,0 +1,456 @@
+//go:build linux
+// +build linux
+
+package sensors
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/helper"
+)
+
+// sysfsRoot is the root the sysfs, procfs and device paths are read under,
+// replaced in tests
+var sysfsRoot = "/"
+
+// msrPerfStatus is IA32_PERF_STATUS, whose bits 47:32 hold the core's
+// voltage in units of 1/8192 V on Intel CPUs since Sandy Bridge
+const msrPerfStatus = 0x198
+
+// linuxProvider reads hwmon chips, RAPL energy counters and cpufreq
+type linuxProvider struct {
+	now func() time.Time
+
+	mu     sync.Mutex
+	energy map[string]energySample // Energy counter file -> previous reading
+}
+
+type energySample struct {
+	microjoules uint64
+	at          time.Time
+}
+
+func newProvider() Provider {
+	return &linuxProvider{now: time.Now, energy: make(map[string]energySample)}
+}
+
+func (p *linuxProvider) Name() string {
+	return "hwmon"
+}
+
+func (p *linuxProvider) Read(_ context.Context) (*Snapshot, error) {
+	s := &Snapshot{Time: p.now()}
+	readHwmon(s)
+	if s.CPUTemp == 0 {
+		s.CPUTemp = thermalZoneTemp()
+	}
+	p.readPower(s)
+	s.CoreClocks = coreClocks()
+	readVoltage(s)
+	return s, nil
+}
+
+// readVoltage adds the per-core VIDs and falls back to the highest of them
+// when no hwmon chip reports the CPU voltage
+func readVoltage(s *Snapshot) {
+	vids, reason := coreVIDs()
+	s.CoreVoltages = vids
+	if s.CPUVoltage == 0 {
+		for _, vid := range vids {
+			if vid > s.CPUVoltage {
+				s.CPUVoltage, s.VoltageSource = vid, "msr"
+			}
+		}
+	}
+	if s.CPUVoltage > 0 {
+		return
+	}
+	if reason == "" {
+		reason = "no SVI2/SVI3 telemetry, Vcore sensor or MSR VID found"
+	}
+	s.VoltageUnavailable = reason
+}
+
+// coreVIDs reads each CPU's VID from IA32_PERF_STATUS on Intel CPUs,
+// through the privileged helper when one is connected. The MSR devices need
+// the msr module and root, which the reason explains.
+func coreVIDs() ([]float64, string) {
+	if !strings.Contains(readString(filepath.Join(sysfsRoot, "proc/cpuinfo")), "GenuineIntel") {
+		return nil, ""
+	}
+	devices, _ := filepath.Glob(filepath.Join(sysfsRoot, "dev/cpu/[0-9]*/msr"))
+	if len(devices) == 0 {
+		return nil, "the msr module is not loaded, so the core VID cannot be read"
+	}
+	sort.Slice(devices, func(i, j int) bool { return msrIndex(devices[i]) < msrIndex(devices[j]) })
+
+	client := privilegedHelper.Load()
+	var vids []float64
+	for _, device := range devices {
+		var vid float64
+		var err error
+		if client != nil {
+			vid, err = helperVID(client, msrIndex(device))
+		} else {
+			vid, err = readVID(device)
+		}
+		if errors.Is(err, fs.ErrPermission) {
+			return nil, "MSR reads need root or the privileged helper, so the core VID cannot be read"
+		}
+		if err == nil && vid > 0 {
+			vids = append(vids, vid)
+		}
+	}
+	return vids, ""
+}
+
+// readVID decodes the voltage from a CPU's IA32_PERF_STATUS
+func readVID(device string) (float64, error) {
+	f, err := os.Open(device) // #nosec G304 -- MSR device path
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = f.Close() }()
+
+	buf := make([]byte, 8)
+	if _, err := f.ReadAt(buf, msrPerfStatus); err != nil {
+		return 0, err
+	}
+	return perfStatusVID(binary.LittleEndian.Uint64(buf)), nil
+}
+
+// helperVID reads a CPU's IA32_PERF_STATUS through the privileged helper
+func helperVID(client *helper.Client, cpu int) (float64, error) {
+	status, err := client.ReadMSR(cpu, "perf_status")
+	if err != nil {
+		return 0, err
+	}
+	return perfStatusVID(status), nil
+}
+
+// perfStatusVID decodes the voltage in IA32_PERF_STATUS
+func perfStatusVID(status uint64) float64 {
+	return float64((status>>32)&0xffff) / 8192
+}
+
+// msrIndex returns N for /dev/cpu/N/msr
+func msrIndex(path string) int {
+	n, _ := strconv.Atoi(filepath.Base(filepath.Dir(path)))
+	return n
+}
+
+// readHwmon reads the temperature, fan and voltage inputs of every hwmon chip
+func readHwmon(s *Snapshot) {
+	chips, _ := filepath.Glob(filepath.Join(sysfsRoot, "sys/class/hwmon/hwmon*"))
+	sort.Strings(chips)
+
+	var cpu, voltage bestScore
+	for _, chip := range chips {
+		name := readString(filepath.Join(chip, "name"))
+
+		temps, _ := filepath.Glob(filepath.Join(chip, "temp*_input"))
+		sort.Strings(temps)
+		for _, input := range temps {
+			prefix := strings.TrimSuffix(input, "_input")
+			milli, ok := readInt(input)
+			if !ok {
+				continue
+			}
+			r := Reading{Name: sensorLabel(prefix), Source: name, Value: float64(milli) / 1000}
+			if crit, ok := readInt(prefix + "_crit"); ok {
+				r.Critical = float64(crit) / 1000
+			}
+			s.Temperatures = append(s.Temperatures, r)
+			cpu.offer(cpuTempScore(name, r.Name), r.Value, name)
+			if (name == "jc42" || name == "spd5118") && r.Value > s.MemoryTemp {
+				s.MemoryTemp = r.Value
+			}
+		}
+
+		fans, _ := filepath.Glob(filepath.Join(chip, "fan*_input"))
+		sort.Strings(fans)
+		for _, input := range fans {
+			if rpm, ok := readInt(input); ok {
+				s.Fans = append(s.Fans, Reading{Name: sensorLabel(strings.TrimSuffix(input, "_input")), Source: name, Value: float64(rpm)})
+			}
+		}
+
+		volts, _ := filepath.Glob(filepath.Join(chip, "in*_input"))
+		sort.Strings(volts)
+		for _, input := range volts {
+			milli, ok := readInt(input)
+			if !ok {
+				continue
+			}
+			r := Reading{Name: sensorLabel(strings.TrimSuffix(input, "_input")), Source: name, Value: float64(milli) / 1000}
+			s.Voltages = append(s.Voltages, r)
+			voltage.offer(cpuVoltageScore(r.Name), r.Value, name)
+		}
+	}
+	s.CPUTemp = cpu.value
+	s.CPUVoltage, s.VoltageSource = voltage.value, voltage.source
+}
+
+// cpuVoltageScore ranks how well a hwmon voltage represents the CPU core
+// voltage. The SVI2 and SVI3 telemetry AMD CPUs report through zenpower is
+// measured by the voltage regulator; a Super I/O Vcore input depends on the
+// board's wiring and only has a label when the driver knows it.
+func cpuVoltageScore(label string) int {
+	lower := strings.ToLower(label)
+	switch {
+	case strings.HasPrefix(lower, "svi2_core"), strings.HasPrefix(lower, "svi3_core"):
+		return 10
+	case cpuVoltageLabel(label):
+		return 6
+	}
+	return 0
+}
+
+// cpuTempScore ranks how well a hwmon sensor represents the CPU temperature.
+// The package or die sensor wins over single cores; ACPI zones are a last
+// resort. Zero means the sensor is not a CPU sensor.
+func cpuTempScore(chip, label string) int {
+	switch chip {
+	case "coretemp":
+		if strings.HasPrefix(label, "Package id") {
+			return 10
+		}
+		return 5
+	case "k10temp", "zenpower":
+		switch label {
+		case "Tdie":
+			return 10
+		case "Tctl":
+			return 9
+		}
+		return 5
+	case "cpu_thermal", "soc_thermal":
+		return 8
+	case "acpitz":
+		return 1
+	}
+	return 0
+}
+
+// thermalZoneTemp returns the hottest CPU thermal zone, for systems without
+// a CPU hwmon chip
+func thermalZoneTemp() float64 {
+	zones, _ := filepath.Glob(filepath.Join(sysfsRoot, "sys/class/thermal/thermal_zone*"))
+	hottest := 0.0
+	for _, zone := range zones {
+		kind := strings.ToLower(readString(filepath.Join(zone, "type")))
+		if !strings.Contains(kind, "cpu") && !strings.Contains(kind, "x86_pkg") && !strings.Contains(kind, "soc") {
+			continue
+		}
+		if milli, ok := readInt(filepath.Join(zone, "temp")); ok && float64(milli)/1000 > hottest {
+			hottest = float64(milli) / 1000
+		}
+	}
+	return hottest
+}
+
+// energyCounter is a cumulative energy counter in microjoules
+type energyCounter struct {
+	path        string
+	microjoules uint64
+	maxRange    string // File holding the value the counter wraps at; empty if it does not wrap
+}
+
+// readPower fills in package power from the first source available: the
+// RAPL powercap zones (Intel, and AMD since Linux 5.8), the amd_energy
+// driver, or the power inputs of a CPU hwmon chip
+func (p *linuxProvider) readPower(s *Snapshot) {
+	counters, reason := raplCounters()
+	source := "intel-rapl"
+	if len(counters) == 0 {
+		counters, source = amdEnergyCounters(), "amd_energy"
+	}
+	if len(counters) > 0 {
+		s.PowerSource = source
+		s.PackagePower = p.energyPower(counters)
+		return
+	}
+
+	if watts, chip := hwmonCPUPower(); chip != "" {
+		s.PowerSource = chip
+		s.PackagePower = watts
+		return
+	}
+	if reason == "" {
+		reason = "no RAPL, amd_energy or CPU power sensors found"
+	}
+	s.PowerUnavailable = reason
+}
+
+// raplCounters reads the package zones' energy counters. Subzones such as
+// intel-rapl:0:0 (cores) are already counted in their package. Since Linux
+// 5.10 only root can read the counters, which the reason explains.
+func raplCounters() ([]energyCounter, string) {
+	zones, _ := filepath.Glob(filepath.Join(sysfsRoot, "sys/class/powercap/intel-rapl:[0-9]*"))
+	sort.Strings(zones)
+
+	var counters []energyCounter
+	reason := ""
+	for _, zone := range zones {
+		if strings.Count(filepath.Base(zone), ":") != 1 {
+			continue
+		}
+		path := filepath.Join(zone, "energy_uj")
+		data, err := os.ReadFile(path) // #nosec G304 -- sysfs path
+		if errors.Is(err, fs.ErrPermission) {
+			reason = "RAPL energy counters are readable by root only"
+			continue
+		}
+		energy, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil {
+			continue
+		}
+		counters = append(counters, energyCounter{path: path, microjoules: energy, maxRange: filepath.Join(zone, "max_energy_range_uj")})
+	}
+	return counters, reason
+}
+
+// amdEnergyCounters reads the per-socket counters of the amd_energy driver.
+// Its counters are accumulated to 64 bits and do not wrap.
+func amdEnergyCounters() []energyCounter {
+	var counters []energyCounter
+	for _, chip := range hwmonChips("amd_energy") {
+		inputs, _ := filepath.Glob(filepath.Join(chip, "energy*_input"))
+		sort.Strings(inputs)
+		for _, input := range inputs {
+			if !strings.HasPrefix(readString(strings.TrimSuffix(input, "_input")+"_label"), "Esocket") {
+				continue
+			}
+			if energy, ok := readUint(input); ok {
+				counters = append(counters, energyCounter{path: input, microjoules: energy})
+			}
+		}
+	}
+	return counters
+}
+
+// hwmonCPUPower sums the power inputs of a CPU chip that reports power
+// directly, such as zenpower's core and SoC rails, and returns the chip
+func hwmonCPUPower() (float64, string) {
+	for _, name := range []string{"zenpower", "k10temp", "fam15h_power"} {
+		for _, chip := range hwmonChips(name) {
+			inputs, _ := filepath.Glob(filepath.Join(chip, "power*_input"))
+			if len(inputs) == 0 {
+				inputs, _ = filepath.Glob(filepath.Join(chip, "power*_average"))
+			}
+			microwatts, found := int64(0), false
+			for _, input := range inputs {
+				if n, ok := readInt(input); ok {
+					microwatts += n
+					found = true
+				}
+			}
+			if found {
+				return float64(microwatts) / 1e6, name
+			}
+		}
+	}
+	return 0, ""
+}
+
+// hwmonChips returns the hwmon directories of the chips with the given name
+func hwmonChips(name string) []string {
+	all, _ := filepath.Glob(filepath.Join(sysfsRoot, "sys/class/hwmon/hwmon*"))
+	sort.Strings(all)
+	var chips []string
+	for _, chip := range all {
+		if readString(filepath.Join(chip, "name")) == name {
+			chips = append(chips, chip)
+		}
+	}
+	return chips
+}
+
+// energyPower sums the power of the counters over the time since the
+// previous read. A counter's first read only records it.
+func (p *linuxProvider) energyPower(counters []energyCounter) float64 {
+	now := p.now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	watts := 0.0
+	for _, c := range counters {
+		prev, seen := p.energy[c.path]
+		p.energy[c.path] = energySample{microjoules: c.microjoules, at: now}
+		elapsed := now.Sub(prev.at).Seconds()
+		if !seen || elapsed <= 0 {
+			continue
+		}
+
+		used := c.microjoules - prev.microjoules
+		if c.microjoules < prev.microjoules {
+			// The counter wrapped
+			limit, ok := readUint(c.maxRange)
+			if !ok {
+				continue
+			}
+			used = limit - prev.microjoules + c.microjoules
+		}
+		watts += float64(used) / 1e6 / elapsed
+	}
+	return watts
+}
+
+// coreClocks returns the current clock of each CPU in MHz
+func coreClocks() []float64 {
+	paths, _ := filepath.Glob(filepath.Join(sysfsRoot, "sys/devices/system/cpu/cpu[0-9]*/cpufreq/scaling_cur_freq"))
+	sort.Slice(paths, func(i, j int) bool { return cpuIndex(paths[i]) < cpuIndex(paths[j]) })
+
+	var clocks []float64
+	for _, path := range paths {
+		if khz, ok := readInt(path); ok {
+			clocks = append(clocks, float64(khz)/1000)
+		}
+	}
+	return clocks
+}
+
+// cpuIndex returns N for a path under .../cpuN/
+func cpuIndex(path string) int {
+	dir := filepath.Base(filepath.Dir(filepath.Dir(path)))
+	n, _ := strconv.Atoi(strings.TrimPrefix(dir, "cpu"))
+	return n
+}
+
+// sensorLabel returns the chip's label for an input, or names it after the
+// input, e.g. "fan1"
+func sensorLabel(prefix string) string {
+	if label := readString(prefix + "_label"); label != "" {
+		return label
+	}
+	return filepath.Base(prefix)
+}
+
+func readString(path string) string {
+	data, err := os.ReadFile(path) // #nosec G304 -- sysfs path
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func readInt(path string) (int64, bool) {
+	n, err := strconv.ParseInt(readString(path), 10, 64)
+	return n, err == nil
+}
+
+func readUint(path string) (uint64, bool) {
+	n, err := strconv.ParseUint(readString(path), 10, 64)
+	return n, err == nil
+}
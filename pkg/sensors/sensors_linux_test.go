@@ -0,0 +1,217 @@
+//go:build linux
+// +build linux
+
+package sensors
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeSysfs creates files under a fake sysfs root
+func writeSysfs(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		full := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLinuxProvider(t *testing.T) {
+	root := t.TempDir()
+	writeSysfs(t, root, map[string]string{
+		"sys/class/hwmon/hwmon0/name":        "acpitz",
+		"sys/class/hwmon/hwmon0/temp1_input": "30000",
+		"sys/class/hwmon/hwmon1/name":        "coretemp",
+		"sys/class/hwmon/hwmon1/temp1_input": "61000",
+		"sys/class/hwmon/hwmon1/temp1_label": "Package id 0",
+		"sys/class/hwmon/hwmon1/temp1_crit":  "100000",
+		"sys/class/hwmon/hwmon1/temp2_input": "64000",
+		"sys/class/hwmon/hwmon1/temp2_label": "Core 0",
+		"sys/class/hwmon/hwmon2/name":        "nct6798",
+		"sys/class/hwmon/hwmon2/fan2_input":  "1150",
+		"sys/class/hwmon/hwmon2/in0_input":   "1248",
+		"sys/class/hwmon/hwmon2/in0_label":   "Vcore",
+		"sys/class/hwmon/hwmon2/in1_input":   "12096",
+		"sys/class/hwmon/hwmon3/name":        "jc42",
+		"sys/class/hwmon/hwmon3/temp1_input": "41500",
+
+		"sys/class/powercap/intel-rapl:0/energy_uj":           "1000000",
+		"sys/class/powercap/intel-rapl:0/max_energy_range_uj": "262143328850",
+		"sys/class/powercap/intel-rapl:0:0/energy_uj":         "500000",
+
+		"sys/devices/system/cpu/cpu0/cpufreq/scaling_cur_freq":  "4800000",
+		"sys/devices/system/cpu/cpu1/cpufreq/scaling_cur_freq":  "4200000",
+		"sys/devices/system/cpu/cpu10/cpufreq/scaling_cur_freq": "3600000",
+	})
+	oldRoot := sysfsRoot
+	sysfsRoot = root
+	defer func() { sysfsRoot = oldRoot }()
+
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	p := newProvider().(*linuxProvider)
+	p.now = func() time.Time { return now }
+
+	s, err := p.Read(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.CPUTemp != 61 {
+		t.Errorf("expected the package temperature 61, got %v", s.CPUTemp)
+	}
+	if s.MemoryTemp != 41.5 {
+		t.Errorf("expected memory temperature 41.5, got %v", s.MemoryTemp)
+	}
+	if s.CPUVoltage != 1.248 || s.VoltageSource != "nct6798" {
+		t.Errorf("expected Vcore 1.248 from nct6798, got %v from %q", s.CPUVoltage, s.VoltageSource)
+	}
+	if len(s.Fans) != 1 || s.Fans[0].Name != "fan2" || s.Fans[0].Value != 1150 {
+		t.Errorf("expected fan2 at 1150 RPM, got %+v", s.Fans)
+	}
+	if len(s.Temperatures) != 4 || s.Temperatures[1].Critical != 100 {
+		t.Errorf("expected 4 temperatures with a critical limit, got %+v", s.Temperatures)
+	}
+	if len(s.CoreClocks) != 3 || s.CoreClocks[0] != 4800 || s.CoreClocks[2] != 3600 {
+		t.Errorf("expected clocks in CPU order, got %v", s.CoreClocks)
+	}
+	if s.PackagePower != 0 || s.PowerSource != "intel-rapl" {
+		t.Errorf("expected RAPL with no power on the first read, got %v from %q", s.PackagePower, s.PowerSource)
+	}
+
+	// 30 J over two seconds in the package zone
+	writeSysfs(t, root, map[string]string{"sys/class/powercap/intel-rapl:0/energy_uj": "31000000"})
+	now = now.Add(2 * time.Second)
+	if s, _ = p.Read(context.Background()); s.PackagePower != 15 {
+		t.Errorf("expected 15 W, got %v", s.PackagePower)
+	}
+
+	// The counter wraps, so the energy is the rest of the range plus 1 J
+	writeSysfs(t, root, map[string]string{"sys/class/powercap/intel-rapl:0/energy_uj": "1000000"})
+	now = now.Add(time.Second)
+	if s, _ = p.Read(context.Background()); s.PackagePower < 262112 || s.PackagePower > 262114 {
+		t.Errorf("expected the wrapped counter to be handled, got %v W", s.PackagePower)
+	}
+}
+
+func TestThermalZoneFallback(t *testing.T) {
+	root := t.TempDir()
+	writeSysfs(t, root, map[string]string{
+		"sys/class/thermal/thermal_zone0/type": "x86_pkg_temp",
+		"sys/class/thermal/thermal_zone0/temp": "55000",
+		"sys/class/thermal/thermal_zone1/type": "iwlwifi_1",
+		"sys/class/thermal/thermal_zone1/temp": "70000",
+	})
+	oldRoot := sysfsRoot
+	sysfsRoot = root
+	defer func() { sysfsRoot = oldRoot }()
+
+	s, _ := newProvider().Read(context.Background())
+	if s.CPUTemp != 55 {
+		t.Errorf("expected the x86_pkg_temp zone at 55, got %v", s.CPUTemp)
+	}
+}
+
+func TestPowerSources(t *testing.T) {
+	oldRoot := sysfsRoot
+	defer func() { sysfsRoot = oldRoot }()
+
+	// amd_energy counts per socket and per core; only sockets are summed
+	sysfsRoot = t.TempDir()
+	writeSysfs(t, sysfsRoot, map[string]string{
+		"sys/class/hwmon/hwmon0/name":           "amd_energy",
+		"sys/class/hwmon/hwmon0/energy1_input":  "5000000",
+		"sys/class/hwmon/hwmon0/energy1_label":  "Ecore000",
+		"sys/class/hwmon/hwmon0/energy17_input": "10000000",
+		"sys/class/hwmon/hwmon0/energy17_label": "Esocket0",
+	})
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	p := newProvider().(*linuxProvider)
+	p.now = func() time.Time { return now }
+	_, _ = p.Read(context.Background())
+	writeSysfs(t, sysfsRoot, map[string]string{
+		"sys/class/hwmon/hwmon0/energy1_input":  "9000000",
+		"sys/class/hwmon/hwmon0/energy17_input": "52000000",
+	})
+	now = now.Add(time.Second)
+	if s, _ := p.Read(context.Background()); s.PowerSource != "amd_energy" || s.PackagePower != 42 {
+		t.Errorf("expected 42 W from amd_energy, got %v from %q", s.PackagePower, s.PowerSource)
+	}
+
+	// zenpower reports the core and SoC rails directly in microwatts
+	sysfsRoot = t.TempDir()
+	writeSysfs(t, sysfsRoot, map[string]string{
+		"sys/class/hwmon/hwmon0/name":         "zenpower",
+		"sys/class/hwmon/hwmon0/power1_input": "61500000",
+		"sys/class/hwmon/hwmon0/power2_input": "18500000",
+	})
+	if s, _ := newProvider().Read(context.Background()); s.PowerSource != "zenpower" || s.PackagePower != 80 {
+		t.Errorf("expected 80 W from zenpower, got %v from %q", s.PackagePower, s.PowerSource)
+	}
+
+	// Without a source the power is marked unavailable, not estimated
+	sysfsRoot = t.TempDir()
+	s, _ := newProvider().Read(context.Background())
+	if s.PackagePower != 0 || s.PowerSource != "" || s.PowerUnavailable == "" {
+		t.Errorf("expected power to be unavailable, got %+v", s)
+	}
+}
+
+func TestVoltageSources(t *testing.T) {
+	oldRoot := sysfsRoot
+	defer func() { sysfsRoot = oldRoot }()
+
+	// The VID of each Intel core from IA32_PERF_STATUS
+	sysfsRoot = t.TempDir()
+	writeSysfs(t, sysfsRoot, map[string]string{"proc/cpuinfo": "vendor_id\t: GenuineIntel"})
+	for cpu, vid := range map[string]uint64{"0": 10240, "1": 10650, "2": 0} { // 1.25 V, 1.3 V, unsupported
+		msr := make([]byte, msrPerfStatus+8)
+		binary.LittleEndian.PutUint64(msr[msrPerfStatus:], vid<<32|0x2a00)
+		path := filepath.Join(sysfsRoot, "dev/cpu", cpu, "msr")
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, msr, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s, _ := newProvider().Read(context.Background())
+	if len(s.CoreVoltages) != 2 || s.CoreVoltages[0] != 1.25 {
+		t.Errorf("expected the VIDs of two cores, got %v", s.CoreVoltages)
+	}
+	if s.CPUVoltage != 10650.0/8192 || s.VoltageSource != "msr" {
+		t.Errorf("expected the highest VID from the MSR, got %v from %q", s.CPUVoltage, s.VoltageSource)
+	}
+
+	// SVI2 telemetry wins over an unlabelled Super I/O input
+	sysfsRoot = t.TempDir()
+	writeSysfs(t, sysfsRoot, map[string]string{
+		"proc/cpuinfo":                     "vendor_id\t: AuthenticAMD",
+		"sys/class/hwmon/hwmon0/name":      "nct6775",
+		"sys/class/hwmon/hwmon0/in0_input": "1400",
+		"sys/class/hwmon/hwmon1/name":      "zenpower",
+		"sys/class/hwmon/hwmon1/in1_input": "1356",
+		"sys/class/hwmon/hwmon1/in1_label": "SVI2_Core",
+		"sys/class/hwmon/hwmon1/in2_input": "1100",
+		"sys/class/hwmon/hwmon1/in2_label": "SVI2_SoC",
+	})
+	if s, _ := newProvider().Read(context.Background()); s.CPUVoltage != 1.356 || s.VoltageSource != "zenpower" {
+		t.Errorf("expected SVI2_Core 1.356 from zenpower, got %v from %q", s.CPUVoltage, s.VoltageSource)
+	}
+
+	// Without a source the voltage is marked unavailable
+	sysfsRoot = t.TempDir()
+	writeSysfs(t, sysfsRoot, map[string]string{"proc/cpuinfo": "vendor_id\t: GenuineIntel"})
+	s, _ = newProvider().Read(context.Background())
+	if s.CPUVoltage != 0 || s.VoltageUnavailable == "" {
+		t.Errorf("expected the voltage to be unavailable, got %+v", s)
+	}
+}
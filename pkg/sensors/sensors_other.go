@@ -0,0 +1,44 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package sensors
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/host"
+)
+
+// hostProvider reads the temperatures gopsutil can find, such as the SMC
+// sensors on macOS
+type hostProvider struct{}
+
+func newProvider() Provider {
+	return hostProvider{}
+}
+
+func (hostProvider) Name() string {
+	return "gopsutil"
+}
+
+func (hostProvider) Read(ctx context.Context) (*Snapshot, error) {
+	s := &Snapshot{
+		Time:               time.Now(),
+		PowerUnavailable:   "CPU package power is not supported on this platform",
+		VoltageUnavailable: "CPU core voltage is not supported on this platform",
+	}
+	temps, err := host.SensorsTemperaturesWithContext(ctx)
+	if err != nil && len(temps) == 0 {
+		return s, nil
+	}
+	for _, t := range temps {
+		s.Temperatures = append(s.Temperatures, Reading{Name: t.SensorKey, Value: t.Temperature, Critical: t.Critical})
+		key := strings.ToLower(t.SensorKey)
+		if (strings.Contains(key, "cpu") || strings.Contains(key, "die") || strings.HasPrefix(key, "tc")) && t.Temperature > s.CPUTemp {
+			s.CPUTemp = t.Temperature
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,120 @@
+package sensors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/ipmi"
+)
+
+func TestCoreClocks(t *testing.T) {
+	s := &Snapshot{CoreClocks: []float64{3600, 4800, 4200}}
+	if got := s.MaxCoreClock(); got != 4800 {
+		t.Errorf("expected max clock 4800, got %v", got)
+	}
+	if got := s.AvgCoreClock(); got != 4200 {
+		t.Errorf("expected average clock 4200, got %v", got)
+	}
+	if got := (&Snapshot{}).AvgCoreClock(); got != 0 {
+		t.Errorf("expected 0 without clocks, got %v", got)
+	}
+}
+
+func TestCPUVoltageLabel(t *testing.T) {
+	for label, want := range map[string]bool{
+		"Vcore":       true,
+		"CPU Core":    true,
+		"Core #1 VID": true,
+		"+12V":        false,
+		"3VSB":        false,
+		"in0":         false,
+	} {
+		if got := cpuVoltageLabel(label); got != want {
+			t.Errorf("cpuVoltageLabel(%q) = %v, expected %v", label, got, want)
+		}
+	}
+}
+
+type countingProvider struct{ reads int }
+
+func (p *countingProvider) Name() string { return "counting" }
+
+func (p *countingProvider) Read(context.Context) (*Snapshot, error) {
+	p.reads++
+	return &Snapshot{Time: time.Now(), CPUTemp: float64(p.reads)}, nil
+}
+
+func TestCachedProvider(t *testing.T) {
+	inner := &countingProvider{}
+	c := &cachedProvider{provider: inner}
+	for i := 0; i < 3; i++ {
+		if _, err := c.Read(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if inner.reads != 1 {
+		t.Errorf("expected one read within the interval, got %d", inner.reads)
+	}
+
+	c.last.Time = time.Now().Add(-minReadInterval)
+	s, _ := c.Read(context.Background())
+	if inner.reads != 2 || s.CPUTemp != 2 {
+		t.Errorf("expected a fresh read after the interval, got %d reads", inner.reads)
+	}
+}
+
+func TestAddBMC(t *testing.T) {
+	s := &Snapshot{}
+	addBMC(s, []ipmi.Sensor{
+		{Name: "CPU1 Temp", Kind: ipmi.KindTemperature, Value: 52},
+		{Name: "System Temp", Kind: ipmi.KindTemperature, Value: 31},
+		{Name: "FAN1", Kind: ipmi.KindFan, Value: 5400},
+		{Name: "PS1 Input Power", Kind: ipmi.KindPower, Value: 180},
+		{Name: "PS2 Input Power", Kind: ipmi.KindPower, Value: 170},
+	})
+	if s.CPUTemp != 52 || len(s.Temperatures) != 2 || s.Temperatures[1].Source != "BMC" {
+		t.Errorf("expected the BMC temperatures, got %+v", s)
+	}
+	if len(s.Fans) != 1 || len(s.Power) != 2 || s.SystemPower != 350 {
+		t.Errorf("expected both supplies' input power as the system power, got %+v", s)
+	}
+
+	// A total reading beats the sum, and the host's CPU temperature is kept
+	s = &Snapshot{CPUTemp: 60}
+	addBMC(s, []ipmi.Sensor{
+		{Name: "CPU Temp", Kind: ipmi.KindTemperature, Value: 55},
+		{Name: "PS1 Input Power", Kind: ipmi.KindPower, Value: 180},
+		{Name: "Pwr Consumption", Kind: ipmi.KindPower, Value: 340},
+	})
+	if s.CPUTemp != 60 || s.SystemPower != 340 {
+		t.Errorf("expected CPU temperature 60 and system power 340, got %v and %v", s.CPUTemp, s.SystemPower)
+	}
+}
+
+func TestBMCProvider(t *testing.T) {
+	reads := 0
+	b := &bmcProvider{host: &countingProvider{}, read: func(context.Context) ([]ipmi.Sensor, error) {
+		reads++
+		return nil, ipmi.ErrUnavailable
+	}}
+	for i := 0; i < 2; i++ {
+		if _, err := b.Read(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if reads != 1 || b.Name() != "counting" {
+		t.Errorf("expected one attempt to reach the BMC, got %d as %q", reads, b.Name())
+	}
+
+	b = &bmcProvider{host: &countingProvider{}, read: func(context.Context) ([]ipmi.Sensor, error) {
+		return []ipmi.Sensor{{Name: "FAN1", Kind: ipmi.KindFan, Value: 5400}}, nil
+	}}
+	s, err := b.Read(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Fans) != 1 || b.Name() != "counting+ipmi" {
+		t.Errorf("expected the BMC fan, got %+v as %q", s.Fans, b.Name())
+	}
+}
@@ -0,0 +1,133 @@
+//go:build windows
+// +build windows
+
+package sensors
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/StackExchange/wmi"
+)
+
+// hardwareMonitorNamespaces are the WMI namespaces published by
+// LibreHardwareMonitor and its predecessor while they are running
+var hardwareMonitorNamespaces = []struct{ namespace, name string }{
+	{`root\LibreHardwareMonitor`, "LibreHardwareMonitor"},
+	{`root\OpenHardwareMonitor`, "OpenHardwareMonitor"},
+}
+
+// defaultWebURL is where LibreHardwareMonitor's web server (Options → Remote
+// Web Server) serves its sensors. FIRE_LHM_URL points elsewhere, e.g. at
+// another port.
+const defaultWebURL = "http://localhost:8085/data.json"
+
+// webTimeout bounds one request to the web server
+const webTimeout = 2 * time.Second
+
+// acpiThermalZone is a firmware thermal zone, readable without a monitor
+type acpiThermalZone struct {
+	InstanceName       string
+	CurrentTemperature uint32 // Tenths of a kelvin
+}
+
+type windowsProvider struct{}
+
+func newProvider() Provider {
+	return windowsProvider{}
+}
+
+func (windowsProvider) Name() string {
+	if _, _, name := hardwareMonitorSensors(context.Background()); name != "" {
+		return name
+	}
+	return "ACPI"
+}
+
+func (windowsProvider) Read(ctx context.Context) (*Snapshot, error) {
+	s := &Snapshot{Time: time.Now()}
+	if sensors, hardware, name := hardwareMonitorSensors(ctx); name != "" {
+		fromHardwareMonitor(s, sensors, hardware, name)
+		if s.PowerSource == "" {
+			s.PowerUnavailable = name + " reports no CPU package power"
+		}
+		if s.VoltageSource == "" {
+			s.VoltageUnavailable = name + " reports no CPU core voltage"
+		}
+		return s, nil
+	}
+
+	// Without a hardware monitor only the ACPI zones are readable, which
+	// need administrator rights on most systems. Package power is in the
+	// RAPL MSRs, which user mode cannot read; the monitor reads them through
+	// its kernel driver.
+	s.PowerUnavailable = "CPU package power needs LibreHardwareMonitor running"
+	s.VoltageUnavailable = "CPU core voltage needs LibreHardwareMonitor running"
+	var zones []acpiThermalZone
+	if err := wmi.QueryNamespace("SELECT InstanceName, CurrentTemperature FROM MSAcpi_ThermalZoneTemperature", &zones, `root\WMI`); err != nil {
+		return s, nil
+	}
+	for _, z := range zones {
+		celsius := float64(z.CurrentTemperature)/10 - 273.15
+		s.Temperatures = append(s.Temperatures, Reading{Name: z.InstanceName, Source: "acpi", Value: celsius})
+		if celsius > s.CPUTemp {
+			s.CPUTemp = celsius
+		}
+	}
+	return s, nil
+}
+
+// hardwareMonitorSensors reads the first hardware monitor that is running,
+// through WMI or else LibreHardwareMonitor's web server, and returns its
+// sensors, hardware and name
+func hardwareMonitorSensors(ctx context.Context) ([]monitorSensor, []monitorHardware, string) {
+	for _, m := range hardwareMonitorNamespaces {
+		var sensors []monitorSensor
+		err := wmi.QueryNamespace("SELECT Name, Identifier, SensorType, Parent, Value FROM Sensor", &sensors, m.namespace)
+		if err == nil && len(sensors) > 0 {
+			var hardware []monitorHardware
+			_ = wmi.QueryNamespace("SELECT Name, Identifier FROM Hardware", &hardware, m.namespace)
+			return sensors, hardware, m.name
+		}
+	}
+
+	sensors, hardware, err := webSensors(ctx)
+	if err == nil && len(sensors) > 0 {
+		return sensors, hardware, "LibreHardwareMonitor"
+	}
+	return nil, nil, ""
+}
+
+// webSensors reads the sensors from LibreHardwareMonitor's web server, which
+// newer releases offer when WMI is not published
+func webSensors(ctx context.Context) ([]monitorSensor, []monitorHardware, error) {
+	url := defaultWebURL
+	if options.WebURL != "" {
+		url = options.WebURL
+	} else if u := os.Getenv("FIRE_LHM_URL"); u != "" {
+		url = u
+	}
+	ctx, cancel := context.WithTimeout(ctx, webTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("LibreHardwareMonitor web server returned %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return nil, nil, err
+	}
+	return parseWebData(data)
+}
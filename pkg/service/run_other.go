@@ -0,0 +1,25 @@
+//go:build !windows
+// +build !windows
+
+package service
+
+import "io"
+
+// IsService reports whether the process was started by the Windows service
+// control manager. systemd runs services like any other process and stops
+// them with SIGTERM.
+func IsService() bool {
+	return false
+}
+
+// Run calls run, which stops on SIGTERM; the stop channel is only used on
+// Windows
+func Run(_ string, run func(stop <-chan struct{}) error) error {
+	return run(nil)
+}
+
+// EventLog is only available on Windows; systemd keeps the output of a
+// service in the journal
+func EventLog(_ string) (io.WriteCloser, error) {
+	return nil, ErrUnsupported
+}
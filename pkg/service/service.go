@@ -0,0 +1,63 @@
+// Package service installs F.I.R.E. commands as services of the operating
+// system, so they start at boot and keep running without a logged-in user.
+//
+// Linux gets a systemd unit under /etc/systemd/system. Windows gets a service
+// registered with the service control manager, which the command answers
+// through Run, and an event log source its output is written to through
+// EventLog. Installing needs root or Administrator rights.
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnsupported is returned when the platform has no supported service manager
+var ErrUnsupported = errors.New("installing services is not supported on this system")
+
+// Config describes a service
+type Config struct {
+	Name        string   // e.g. fire-scheduler
+	DisplayName string   // Shown by the Windows Services console
+	Description string   // One line
+	Executable  string   // Absolute path
+	Args        []string // Command line after the executable
+	Env         []string // KEY=value, e.g. to pin the config file and database
+	User        string   // Account the systemd unit runs as; root when empty. Windows services run as LocalSystem.
+}
+
+// systemdUnit returns the systemd unit running the service
+func systemdUnit(c Config) string {
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	fmt.Fprintf(&b, "Description=%s\n", c.Description)
+	b.WriteString("After=network-online.target\nWants=network-online.target\n\n")
+
+	b.WriteString("[Service]\n")
+	exec := []string{systemdQuote(c.Executable)}
+	for _, arg := range c.Args {
+		exec = append(exec, systemdQuote(arg))
+	}
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(exec, " "))
+	for _, env := range c.Env {
+		fmt.Fprintf(&b, "Environment=%s\n", systemdQuote(env))
+	}
+	if c.User != "" && c.User != "root" {
+		fmt.Fprintf(&b, "User=%s\n", c.User)
+	}
+	b.WriteString("Restart=on-failure\nRestartSec=30\n\n")
+
+	b.WriteString("[Install]\nWantedBy=multi-user.target\n")
+	return b.String()
+}
+
+// systemdQuote quotes a word of a unit file, escaping the specifiers (%) and
+// variables ($) systemd would otherwise expand
+func systemdQuote(s string) string {
+	s = strings.NewReplacer("%", "%%", "$", "$$").Replace(s)
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,66 @@
+//go:build linux
+// +build linux
+
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// unitDir is where installed units are written
+var unitDir = "/etc/systemd/system"
+
+// unitPath returns the path of a service's unit
+func unitPath(name string) string {
+	return filepath.Join(unitDir, name+".service")
+}
+
+// Install writes the systemd unit of a service, enables it at boot and
+// starts it
+func Install(c Config) error {
+	path := unitPath(c.Name)
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("service %s is already installed (%s)", c.Name, path)
+	}
+	if err := os.WriteFile(path, []byte(systemdUnit(c)), 0o644); err != nil { // #nosec G306 -- unit files are world-readable
+		return fmt.Errorf("failed to write %s (run as root): %w", path, err)
+	}
+	if err := systemctl("daemon-reload"); err != nil {
+		_ = os.Remove(path)
+		return err
+	}
+	if err := systemctl("enable", "--now", c.Name+".service"); err != nil {
+		_ = os.Remove(path)
+		_ = systemctl("daemon-reload")
+		return err
+	}
+	return nil
+}
+
+// Remove stops a service, disables it and removes its unit
+func Remove(name string) error {
+	path := unitPath(name)
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("service %s is not installed (%s)", name, path)
+	}
+	if err := systemctl("disable", "--now", name+".service"); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove %s (run as root): %w", path, err)
+	}
+	return systemctl("daemon-reload")
+}
+
+func systemctl(args ...string) error {
+	output, err := safeexec.Command("systemctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl %s failed: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
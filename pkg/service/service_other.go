@@ -0,0 +1,14 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package service
+
+// Install is not supported on this platform
+func Install(_ Config) error {
+	return ErrUnsupported
+}
+
+// Remove is not supported on this platform
+func Remove(_ string) error {
+	return ErrUnsupported
+}
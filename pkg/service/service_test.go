@@ -0,0 +1,34 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSystemdUnit(t *testing.T) {
+	unit := systemdUnit(Config{
+		Name:        "fire-scheduler",
+		Description: "Runs the scheduled F.I.R.E. tests",
+		Executable:  "/opt/fire tools/bench",
+		Args:        []string{"schedule", "start", "--check-interval", "1m0s"},
+		Env:         []string{"FIRE_CONFIG=/home/lab/.config/fire/config.yaml", "FIRE_DB_PATH=/home/lab/100% $burn/fire.db"},
+		User:        "lab",
+	})
+	for _, line := range []string{
+		"Description=Runs the scheduled F.I.R.E. tests",
+		`ExecStart="/opt/fire tools/bench" schedule start --check-interval 1m0s`,
+		"Environment=FIRE_CONFIG=/home/lab/.config/fire/config.yaml",
+		`Environment="FIRE_DB_PATH=/home/lab/100%% $$burn/fire.db"`,
+		"User=lab",
+		"Restart=on-failure",
+		"WantedBy=multi-user.target",
+	} {
+		if !strings.Contains(unit, line+"\n") {
+			t.Errorf("expected the unit to contain %q, got:\n%s", line, unit)
+		}
+	}
+
+	if unit := systemdUnit(Config{Executable: "/usr/bin/bench", User: "root"}); strings.Contains(unit, "User=") {
+		t.Errorf("expected a unit running as root to have no User, got:\n%s", unit)
+	}
+}
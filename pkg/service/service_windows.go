@@ -0,0 +1,221 @@
+//go:build windows
+// +build windows
+
+package service
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/eventlog"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// stopTimeout is how long Remove waits for a service to stop
+const stopTimeout = 30 * time.Second
+
+// Install registers a service that starts at boot, restarts after a
+// failure and writes to an event log source of the same name, then starts it
+func Install(c Config) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the service control manager (run as Administrator): %w", err)
+	}
+	defer func() { _ = m.Disconnect() }()
+
+	if s, err := m.OpenService(c.Name); err == nil {
+		_ = s.Close()
+		return fmt.Errorf("service %s is already installed", c.Name)
+	}
+	s, err := m.CreateService(c.Name, c.Executable, mgr.Config{
+		DisplayName:      c.DisplayName,
+		Description:      c.Description,
+		StartType:        mgr.StartAutomatic,
+		DelayedAutoStart: true,
+	}, c.Args...)
+	if err != nil {
+		return fmt.Errorf("failed to create service %s: %w", c.Name, err)
+	}
+	defer func() { _ = s.Close() }()
+
+	if err := setEnvironment(c.Name, c.Env); err != nil {
+		_ = s.Delete()
+		return err
+	}
+	if err := s.SetRecoveryActions([]mgr.RecoveryAction{
+		{Type: mgr.ServiceRestart, Delay: 30 * time.Second},
+		{Type: mgr.ServiceRestart, Delay: time.Minute},
+		{Type: mgr.ServiceRestart, Delay: 5 * time.Minute},
+	}, uint32((24 * time.Hour).Seconds())); err != nil {
+		_ = s.Delete()
+		return fmt.Errorf("failed to set the recovery actions of %s: %w", c.Name, err)
+	}
+	if err := eventlog.InstallAsEventCreate(c.Name, eventlog.Error|eventlog.Warning|eventlog.Info); err != nil {
+		// Left over from an earlier install
+		if !strings.Contains(err.Error(), "exists") {
+			_ = s.Delete()
+			return fmt.Errorf("failed to register the event log source %s: %w", c.Name, err)
+		}
+	}
+	if err := s.Start(); err != nil {
+		return fmt.Errorf("service %s was installed but failed to start: %w", c.Name, err)
+	}
+	return nil
+}
+
+// setEnvironment sets the environment the service control manager starts a
+// service with
+func setEnvironment(name string, env []string) error {
+	if len(env) == 0 {
+		return nil
+	}
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Services\`+name, registry.SET_VALUE)
+	if err != nil {
+		return fmt.Errorf("failed to open the registry key of %s: %w", name, err)
+	}
+	defer func() { _ = k.Close() }()
+	if err := k.SetStringsValue("Environment", env); err != nil {
+		return fmt.Errorf("failed to set the environment of %s: %w", name, err)
+	}
+	return nil
+}
+
+// Remove stops a service, deletes it and removes its event log source
+func Remove(name string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the service control manager (run as Administrator): %w", err)
+	}
+	defer func() { _ = m.Disconnect() }()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("service %s is not installed", name)
+	}
+	defer func() { _ = s.Close() }()
+
+	if status, err := s.Query(); err == nil && status.State != svc.Stopped {
+		if _, err := s.Control(svc.Stop); err != nil {
+			return fmt.Errorf("failed to stop service %s: %w", name, err)
+		}
+		deadline := time.Now().Add(stopTimeout)
+		for status.State != svc.Stopped {
+			if time.Now().After(deadline) {
+				return fmt.Errorf("service %s did not stop within %s", name, stopTimeout)
+			}
+			time.Sleep(500 * time.Millisecond)
+			if status, err = s.Query(); err != nil {
+				return fmt.Errorf("failed to query service %s: %w", name, err)
+			}
+		}
+	}
+	if err := s.Delete(); err != nil {
+		return fmt.Errorf("failed to delete service %s: %w", name, err)
+	}
+	_ = eventlog.Remove(name)
+	return nil
+}
+
+// IsService reports whether the process was started by the service control
+// manager
+func IsService() bool {
+	ok, err := svc.IsWindowsService()
+	return err == nil && ok
+}
+
+// Run runs a service under the service control manager: stop is closed when
+// the service is asked to stop or the machine shuts down, and Run returns
+// once run does
+func Run(name string, run func(stop <-chan struct{}) error) error {
+	h := &handler{run: run}
+	if err := svc.Run(name, h); err != nil {
+		return err
+	}
+	return h.err
+}
+
+// handler adapts a run function to the service control manager
+type handler struct {
+	run func(stop <-chan struct{}) error
+	err error
+}
+
+// Execute implements svc.Handler
+func (h *handler) Execute(_ []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+
+	stop := make(chan struct{})
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- h.run(stop)
+	}()
+
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case h.err = <-errChan:
+			if h.err != nil {
+				return true, 1
+			}
+			return false, 0
+
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				close(stop)
+				if h.err = <-errChan; h.err != nil {
+					return true, 1
+				}
+				return false, 0
+			}
+		}
+	}
+}
+
+// EventLog returns a writer adding each line written to it to the event log
+// as an entry of the source name, for use with log.New. Lines mentioning an
+// error or failure are logged as errors.
+func EventLog(name string) (io.WriteCloser, error) {
+	l, err := eventlog.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the event log: %w", err)
+	}
+	return &eventWriter{log: l}, nil
+}
+
+// eventWriter writes lines to the event log
+type eventWriter struct {
+	log *eventlog.Log
+}
+
+// Event IDs of the entries; EventCreate sources accept 1 to 1000
+const (
+	eventInfo  = 1
+	eventError = 2
+)
+
+func (w *eventWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimRight(string(p), "\r\n")
+	lower := strings.ToLower(msg)
+	var err error
+	if strings.Contains(lower, "error") || strings.Contains(lower, "fail") {
+		err = w.log.Error(eventError, msg)
+	} else {
+		err = w.log.Info(eventInfo, msg)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *eventWriter) Close() error {
+	return w.log.Close()
+}
@@ -0,0 +1,107 @@
+package session
+
+import (
+	"bytes"
+	"sync"
+	"time"
+)
+
+// DefaultCapacity keeps one hour of dashboard updates at one per second
+const DefaultCapacity = 3600
+
+// Recorder keeps the most recent telemetry samples for saving as a session
+type Recorder struct {
+	mu       sync.Mutex
+	samples  []Sample
+	capacity int
+	paused   bool
+	disabled map[string]bool
+}
+
+// NewRecorder creates a recorder that keeps up to capacity samples
+func NewRecorder(capacity int) *Recorder {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	return &Recorder{capacity: capacity}
+}
+
+// Add records a sample. Zero values are dropped since they mean the sensor
+// was unavailable for that update, and so are disabled metrics.
+func (r *Recorder) Add(t time.Time, metrics map[string]float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.paused {
+		return
+	}
+
+	sample := Sample{Time: t, Metrics: make(map[string]float64, len(metrics))}
+	for name, value := range metrics {
+		if value != 0 && !r.disabled[name] {
+			sample.Metrics[name] = value
+		}
+	}
+	r.samples = append(r.samples, sample)
+	if len(r.samples) > r.capacity {
+		r.samples = r.samples[len(r.samples)-r.capacity:]
+	}
+}
+
+// Samples returns a copy of the recorded samples, oldest first
+func (r *Recorder) Samples() []Sample {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Sample(nil), r.samples...)
+}
+
+// SetPaused stops or resumes recording. Samples already recorded are kept.
+func (r *Recorder) SetPaused(paused bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.paused = paused
+}
+
+// Paused reports whether recording is paused
+func (r *Recorder) Paused() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.paused
+}
+
+// SetDisabled leaves the named metrics out of future samples, so long
+// recordings stay small. Samples already recorded are kept.
+func (r *Recorder) SetDisabled(names []string) {
+	disabled := make(map[string]bool, len(names))
+	for _, name := range names {
+		disabled[name] = true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.disabled = disabled
+}
+
+// Capacity returns how many samples the recorder keeps
+func (r *Recorder) Capacity() int {
+	return r.capacity
+}
+
+// SampleSize estimates how many bytes one sample recording the named metrics
+// adds to a saved session, assuming typical sensor readings
+func SampleSize(names []string) int {
+	sample := Sample{
+		Time:    time.Date(2026, 1, 1, 12, 0, 0, 123456789, time.FixedZone("", 3600)),
+		Metrics: make(map[string]float64, len(names)),
+	}
+	for _, name := range names {
+		sample.Metrics[name] = 1234.56789
+	}
+
+	// The difference between one and two samples includes the indentation
+	// and separator the file format adds around each
+	var one, two bytes.Buffer
+	if Write(&one, &File{Samples: []Sample{sample}}) != nil || Write(&two, &File{Samples: []Sample{sample, sample}}) != nil {
+		return 0
+	}
+	return two.Len() - one.Len()
+}
@@ -0,0 +1,255 @@
+// Package session defines the .firereport and .firesession file formats.
+//
+// A report holds one test run and its results, as written by
+// "bench export json". A session additionally holds the hardware inventory
+// and the dashboard telemetry recorded while F.I.R.E. was running, so that a
+// support engineer can replay exactly what the customer's dashboard showed.
+// Both are JSON documents following the report and session schemas
+// published in pkg/schema, and are checked against them when read. Files
+// written before the format field existed are read as reports.
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/schema"
+)
+
+// File extensions registered by the installers
+const (
+	ReportExtension  = ".firereport"
+	SessionExtension = ".firesession"
+)
+
+// Format identifiers stored in the file
+const (
+	FormatReport  = "fire-report"
+	FormatSession = "fire-session"
+)
+
+// Version is the newest schema version this package reads and writes
+const Version = 1
+
+// File is the content of a .firereport or .firesession file
+type File struct {
+	Format        string    `json:"format"`
+	SchemaVersion int       `json:"schema_version"`
+	Version       int       `json:"version"` // Deprecated: the same as SchemaVersion, kept for older readers
+	CreatedAt     time.Time `json:"created_at"`
+
+	// Report content: a single run. Sessions may also carry one.
+	Run     *db.Run      `json:"run,omitempty"`
+	Results []*db.Result `json:"results,omitempty"`
+
+	// Session content
+	System     map[string]string `json:"system,omitempty"`
+	Components []Component       `json:"components,omitempty"`
+	Samples    []Sample          `json:"samples,omitempty"`
+	Runs       []RunRecord       `json:"runs,omitempty"`
+}
+
+// Component is a hardware component as shown in the dashboard
+type Component struct {
+	Type    string            `json:"type"`
+	Name    string            `json:"name"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// Sample is one dashboard telemetry update. Metric keys are display names
+// including the unit, e.g. "CPU Temp (°C)".
+type Sample struct {
+	Time    time.Time          `json:"time"`
+	Metrics map[string]float64 `json:"metrics"`
+}
+
+// RunRecord is a test run with its results
+type RunRecord struct {
+	Run     *db.Run      `json:"run"`
+	Results []*db.Result `json:"results"`
+}
+
+// NewReport creates a report for a single run
+func NewReport(run *db.Run, results []*db.Result) *File {
+	return &File{
+		Format:        FormatReport,
+		SchemaVersion: Version,
+		Version:       Version,
+		CreatedAt:     time.Now(),
+		Run:           run,
+		Results:       results,
+	}
+}
+
+// NewSession creates an empty session
+func NewSession() *File {
+	return &File{
+		Format:        FormatSession,
+		SchemaVersion: Version,
+		Version:       Version,
+		CreatedAt:     time.Now(),
+	}
+}
+
+// IsSession reports whether the file carries a recorded session
+func (f *File) IsSession() bool {
+	return f.Format == FormatSession
+}
+
+// AllRuns returns every run in the file, including the report run
+func (f *File) AllRuns() []RunRecord {
+	var runs []RunRecord
+	if f.Run != nil {
+		runs = append(runs, RunRecord{Run: f.Run, Results: f.Results})
+	}
+	return append(runs, f.Runs...)
+}
+
+// MetricNames returns the telemetry metrics recorded in the session, sorted
+// by name
+func (f *File) MetricNames() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, s := range f.Samples {
+		for name := range s.Metrics {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	// Map iteration order is random; keep the list stable for the viewer
+	sort.Strings(names)
+	return names
+}
+
+// Duration returns the time covered by the recorded samples
+func (f *File) Duration() time.Duration {
+	if len(f.Samples) < 2 {
+		return 0
+	}
+	return f.Samples[len(f.Samples)-1].Time.Sub(f.Samples[0].Time)
+}
+
+// SampleAt returns the index of the last sample taken at or before offset
+// from the start of the recording
+func (f *File) SampleAt(offset time.Duration) int {
+	if len(f.Samples) == 0 {
+		return -1
+	}
+	t := f.Samples[0].Time.Add(offset)
+	idx := 0
+	for i, s := range f.Samples {
+		if s.Time.After(t) {
+			break
+		}
+		idx = i
+	}
+	return idx
+}
+
+// Read decodes a report or session and checks it against its schema
+func Read(r io.Reader) (*File, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc map[string]interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode file: %w", err)
+	}
+
+	var name string
+	switch doc["format"] {
+	case nil, "":
+		// Written by "bench export json" before the format was versioned
+		if doc["run"] == nil {
+			return nil, fmt.Errorf("file does not contain a F.I.R.E. report or session")
+		}
+		doc["format"] = FormatReport
+		doc["schema_version"] = json.Number("1")
+		name = schema.Report
+	case FormatReport:
+		if doc["run"] == nil {
+			return nil, fmt.Errorf("report does not contain a run")
+		}
+		name = schema.Report
+	case FormatSession:
+		name = schema.Session
+	default:
+		return nil, fmt.Errorf("unsupported file format %v", doc["format"])
+	}
+	if _, ok := doc["schema_version"]; !ok {
+		// Files written before schema_version carry the same number as version
+		doc["schema_version"] = doc["version"]
+	}
+
+	if n, ok := doc["schema_version"].(json.Number); ok {
+		if v, err := n.Int64(); err == nil && v > Version {
+			return nil, fmt.Errorf("schema version %d is newer than supported version %d", v, Version)
+		}
+	}
+	if err := schema.ValidateValue(name, doc); err != nil {
+		return nil, fmt.Errorf("invalid %s file: %w", doc["format"], err)
+	}
+
+	data, err = json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode file: %w", err)
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to decode file: %w", err)
+	}
+	f.Version = f.SchemaVersion
+	return &f, nil
+}
+
+// Write encodes the file as indented JSON
+func Write(w io.Writer, f *File) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(f); err != nil {
+		return fmt.Errorf("failed to encode file: %w", err)
+	}
+	return nil
+}
+
+// Load reads a report or session from disk
+func Load(path string) (*File, error) {
+	in, err := os.Open(path) // #nosec G304 -- user-selected report file
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = in.Close() }()
+	return Read(in)
+}
+
+// Save writes the file to disk
+func Save(path string, f *File) error {
+	out, err := os.Create(path) // #nosec G304 -- user-selected output file
+	if err != nil {
+		return err
+	}
+	if err := Write(out, f); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// IsSessionPath reports whether path has a report or session extension
+func IsSessionPath(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ReportExtension || ext == SessionExtension
+}
@@ -0,0 +1,159 @@
+package session
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+func TestReadLegacyExport(t *testing.T) {
+	// Output of "bench export json" before the format was versioned
+	legacy := `{"run": {"id": 42, "plugin": "cpu", "success": true}, "results": [{"metric": "ops", "value": 1.5}]}`
+
+	f, err := Read(strings.NewReader(legacy))
+	if err != nil {
+		t.Fatalf("expected legacy export to be read, got %v", err)
+	}
+	if f.Format != FormatReport || f.IsSession() {
+		t.Errorf("expected legacy export to be a report, got %q", f.Format)
+	}
+	if runs := f.AllRuns(); len(runs) != 1 || runs[0].Run.ID != 42 {
+		t.Errorf("unexpected runs: %+v", runs)
+	}
+
+	// Written before schema_version was added
+	f, err = Read(strings.NewReader(`{"format": "fire-report", "version": 1, "run": {"id": 1, "plugin": "cpu", "success": true}}`))
+	if err != nil {
+		t.Fatalf("expected a versioned report to be read, got %v", err)
+	}
+	if f.SchemaVersion != 1 {
+		t.Errorf("expected schema version 1, got %d", f.SchemaVersion)
+	}
+}
+
+func TestReadRejectsUnknownFiles(t *testing.T) {
+	tests := map[string]string{
+		"not json":      `hello`,
+		"no run":        `{"results": []}`,
+		"other format":  `{"format": "something-else", "version": 1}`,
+		"newer version": `{"format": "fire-session", "version": 99}`,
+		"newer schema":  `{"format": "fire-session", "schema_version": 2, "version": 1}`,
+		"schema":        `{"format": "fire-report", "schema_version": 1, "run": {"id": 1, "plugin": "cpu", "success": true, "start_time": 1700000000}}`,
+	}
+	for name, input := range tests {
+		if _, err := Read(strings.NewReader(input)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestSessionRoundTrip(t *testing.T) {
+	start := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	f := NewSession()
+	f.Components = []Component{{Type: "CPU", Name: "Test CPU", Details: map[string]string{"Cores": "8"}}}
+	f.Samples = []Sample{
+		{Time: start, Metrics: map[string]float64{"CPU Temp (°C)": 40}},
+		{Time: start.Add(time.Second), Metrics: map[string]float64{"CPU Temp (°C)": 45, "CPU Power (W)": 60}},
+	}
+	f.Runs = []RunRecord{{Run: &db.Run{ID: 1, Plugin: "memory"}}}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, f); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !got.IsSession() || len(got.Samples) != 2 || len(got.AllRuns()) != 1 {
+		t.Errorf("session did not round-trip: %+v", got)
+	}
+	if names := got.MetricNames(); len(names) != 2 || names[0] != "CPU Power (W)" {
+		t.Errorf("unexpected metric names: %v", names)
+	}
+	if got.Duration() != time.Second {
+		t.Errorf("expected 1s duration, got %v", got.Duration())
+	}
+	if idx := got.SampleAt(1500 * time.Millisecond); idx != 1 {
+		t.Errorf("expected sample 1 at 1.5s, got %d", idx)
+	}
+}
+
+func TestRecorderCapacity(t *testing.T) {
+	r := NewRecorder(2)
+	now := time.Now()
+	for i := 1; i <= 3; i++ {
+		r.Add(now.Add(time.Duration(i)*time.Second), map[string]float64{"Usage (%)": float64(i), "Temp (°C)": 0})
+	}
+
+	samples := r.Samples()
+	if len(samples) != 2 || samples[0].Metrics["Usage (%)"] != 2 {
+		t.Errorf("expected the two newest samples, got %+v", samples)
+	}
+	if _, ok := samples[0].Metrics["Temp (°C)"]; ok {
+		t.Error("expected unavailable (zero) readings to be dropped")
+	}
+
+	r.SetPaused(true)
+	r.Add(now.Add(time.Minute), map[string]float64{"Usage (%)": 9})
+	if got := r.Samples(); len(got) != 2 || got[1].Metrics["Usage (%)"] != 3 {
+		t.Errorf("expected no samples while paused, got %+v", got)
+	}
+}
+
+func TestRecorderDisabledMetrics(t *testing.T) {
+	r := NewRecorder(10)
+	r.SetDisabled([]string{"CPU Voltage (V)"})
+	r.Add(time.Now(), map[string]float64{"CPU Temp (°C)": 60, "CPU Voltage (V)": 1.2})
+
+	samples := r.Samples()
+	if len(samples) != 1 || len(samples[0].Metrics) != 1 || samples[0].Metrics["CPU Temp (°C)"] != 60 {
+		t.Errorf("expected only the enabled metric, got %+v", samples)
+	}
+
+	r.SetDisabled(nil)
+	r.Add(time.Now(), map[string]float64{"CPU Voltage (V)": 1.2})
+	if got := r.Samples(); len(got) != 2 || got[1].Metrics["CPU Voltage (V)"] != 1.2 {
+		t.Errorf("expected the metric to be recorded again, got %+v", got)
+	}
+}
+
+func TestSampleSize(t *testing.T) {
+	empty := SampleSize(nil)
+	one := SampleSize([]string{"CPU Temp (°C)"})
+	two := SampleSize([]string{"CPU Temp (°C)", "GPU Temp (°C)"})
+	if empty <= 0 || one <= empty || two <= one {
+		t.Errorf("expected the size to grow with each metric, got %d, %d, %d", empty, one, two)
+	}
+
+	// The estimate matches what a recorded sample adds to a file
+	r := NewRecorder(10)
+	r.Add(time.Date(2026, 1, 1, 12, 0, 0, 123456789, time.FixedZone("", 3600)),
+		map[string]float64{"CPU Temp (°C)": 1234.56789, "GPU Temp (°C)": 1234.56789})
+	var without, with bytes.Buffer
+	if err := Write(&without, &File{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Write(&with, &File{Samples: r.Samples()}); err != nil {
+		t.Fatal(err)
+	}
+	if with.Len()-without.Len() < two {
+		t.Errorf("expected at least %d bytes for the sample, got %d", two, with.Len()-without.Len())
+	}
+}
+
+func TestIsSessionPath(t *testing.T) {
+	for path, want := range map[string]bool{
+		"run42.firereport":    true,
+		"support.FIRESESSION": true,
+		"results.json":        false,
+	} {
+		if got := IsSessionPath(path); got != want {
+			t.Errorf("IsSessionPath(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// influx writes the InfluxDB line protocol over HTTP
+type influx struct {
+	endpoint string // Write URL with the database or bucket
+	token    string // InfluxDB 2.x API token
+	user     string // InfluxDB 1.x credentials
+	password string
+	client   *http.Client
+	name     string
+}
+
+// newInflux creates a sink for an influxdb:// URL. The path names the 1.x
+// database, or the 2.x bucket when an org is given.
+func newInflux(u *url.URL) (*influx, error) {
+	target := strings.Trim(u.Path, "/")
+	if u.Host == "" || target == "" {
+		return nil, fmt.Errorf("InfluxDB sink needs a host and a database, e.g. influxdb://localhost:8086/fire")
+	}
+	scheme := "http"
+	if u.Scheme == "influxdbs" {
+		scheme = "https"
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host += ":8086"
+	}
+
+	q := u.Query()
+	s := &influx{token: q.Get("token"), client: &http.Client{Timeout: writeTimeout}}
+	if u.User != nil {
+		s.user = u.User.Username()
+		s.password, _ = u.User.Password()
+	}
+	params := url.Values{"precision": {"ms"}}
+	path := "/write"
+	if org := q.Get("org"); org != "" {
+		path = "/api/v2/write"
+		params.Set("org", org)
+		params.Set("bucket", target)
+		if s.token == "" {
+			s.token = s.password
+		}
+	} else {
+		params.Set("db", target)
+		if rp := q.Get("rp"); rp != "" {
+			params.Set("rp", rp)
+		}
+	}
+	s.endpoint = (&url.URL{Scheme: scheme, Host: host, Path: path, RawQuery: params.Encode()}).String()
+	s.name = fmt.Sprintf("InfluxDB %s/%s", host, target)
+	return s, nil
+}
+
+func (s *influx) Write(ctx context.Context, points []Point) error {
+	var body bytes.Buffer
+	for _, p := range points {
+		if line := LineProtocol(p); line != "" {
+			body.WriteString(line)
+			body.WriteByte('\n')
+		}
+	}
+	if body.Len() == 0 {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	switch {
+	case s.token != "":
+		req.Header.Set("Authorization", "Token "+s.token)
+	case s.user != "":
+		req.SetBasicAuth(s.user, s.password)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("InfluxDB returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+func (s *influx) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+func (s *influx) String() string {
+	return s.name
+}
+
+// LineProtocol formats a point as one line of the InfluxDB line protocol
+// with a millisecond timestamp. Fields that are not finite are left out,
+// and a point without fields gives an empty line.
+func LineProtocol(p Point) string {
+	var b strings.Builder
+	b.WriteString(escape(p.Measurement, ", "))
+
+	tags := make([]string, 0, len(p.Tags))
+	for k, v := range p.Tags {
+		if k != "" && v != "" {
+			tags = append(tags, k)
+		}
+	}
+	sort.Strings(tags)
+	for _, k := range tags {
+		b.WriteString("," + escape(k, ",= ") + "=" + escape(p.Tags[k], ",= "))
+	}
+
+	sep := byte(' ')
+	written := false
+	for _, name := range sortedFields(p.Fields) {
+		value := p.Fields[name]
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		b.WriteByte(sep)
+		b.WriteString(escape(name, ",= "))
+		b.WriteByte('=')
+		b.WriteString(strconv.FormatFloat(value, 'f', -1, 64))
+		sep = ','
+		written = true
+	}
+	if !written {
+		return ""
+	}
+	t := p.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	b.WriteString(" " + strconv.FormatInt(t.UnixMilli(), 10))
+	return b.String()
+}
+
+// escape backslash-escapes the given characters, and replaces line breaks,
+// which the line protocol cannot carry
+func escape(s, chars string) string {
+	s = strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+	if !strings.ContainsAny(s, chars) {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(chars, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,277 @@
+package sink
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/changelog"
+	"github.com/mscrnt/project_fire/pkg/mqtt"
+)
+
+// MQTT defaults
+const (
+	defaultTopicPrefix     = "fire"
+	defaultDiscoveryPrefix = "homeassistant"
+)
+
+// deviceClasses are the Home Assistant device classes of reading units
+var deviceClasses = map[string]string{
+	"°C":   "temperature",
+	"W":    "power",
+	"V":    "voltage",
+	"MHz":  "frequency",
+	"MB/s": "data_rate",
+	"GB":   "data_size",
+	"MB":   "data_size",
+}
+
+// mqttSink publishes every reading to its own topic,
+// <prefix>/<machine>/<reading>, and the results of a run as one JSON
+// message to <prefix>/<machine>/result. With discovery on, Home Assistant
+// is told about each reading the first time it is published, so the
+// machine shows up as a device with a sensor per reading.
+type mqttSink struct {
+	opts      mqtt.Options
+	client    *mqtt.Client
+	prefix    string
+	qos       byte
+	retain    bool
+	discovery string // Discovery prefix; empty when off
+	announced map[string]bool
+	machine   string
+	name      string
+}
+
+// newMQTT creates a sink for an mqtt:// or mqtts:// URL and connects to the
+// broker. The path is the topic prefix.
+func newMQTT(ctx context.Context, u *url.URL) (*mqttSink, error) {
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("MQTT sink needs a broker, e.g. mqtt://homeassistant.local/fire")
+	}
+	q := u.Query()
+	machine := changelog.Machine()
+	s := &mqttSink{
+		prefix:    strings.Trim(u.Path, "/"),
+		discovery: defaultDiscoveryPrefix,
+		announced: make(map[string]bool),
+		machine:   machine,
+	}
+	if s.prefix == "" {
+		s.prefix = defaultTopicPrefix
+	}
+	if strings.ContainsAny(s.prefix, "+#") {
+		return nil, fmt.Errorf("invalid MQTT topic prefix %q", s.prefix)
+	}
+
+	if v := q.Get("qos"); v != "" {
+		qos, err := strconv.Atoi(v)
+		if err != nil || qos < 0 || qos > 1 {
+			return nil, fmt.Errorf("invalid MQTT QoS %q (use 0 or 1)", v)
+		}
+		s.qos = byte(qos)
+	}
+	if v := q.Get("retain"); v != "" {
+		retain, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MQTT retain %q", v)
+		}
+		s.retain = retain
+	}
+	if q.Has("discovery") {
+		switch v := q.Get("discovery"); v {
+		case "false", "off", "0", "":
+			s.discovery = ""
+		case "true", "on", "1":
+		default:
+			s.discovery = strings.Trim(v, "/")
+		}
+	}
+
+	port := u.Port()
+	s.opts = mqtt.Options{
+		ClientID: q.Get("client_id"),
+		Will:     &mqtt.Message{Topic: s.topic("status"), Payload: []byte("offline"), QoS: s.qos, Retain: true},
+	}
+	if s.opts.ClientID == "" {
+		s.opts.ClientID = "fire-" + topicSegment(machine)
+	}
+	if u.User != nil {
+		s.opts.Username = u.User.Username()
+		s.opts.Password, _ = u.User.Password()
+	}
+	if u.Scheme == "mqtts" {
+		if port == "" {
+			port = "8883"
+		}
+		cfg := &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}
+		if ca := q.Get("ca"); ca != "" {
+			pem, err := os.ReadFile(ca) // #nosec G304 -- CA file named in the sink URL
+			if err != nil {
+				return nil, fmt.Errorf("failed to read MQTT CA: %w", err)
+			}
+			cfg.RootCAs = x509.NewCertPool()
+			if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates in %s", ca)
+			}
+		}
+		if insecure, _ := strconv.ParseBool(q.Get("insecure")); insecure {
+			cfg.InsecureSkipVerify = true // #nosec G402 -- asked for in the sink URL, for brokers with self-signed certificates
+		}
+		s.opts.TLS = cfg
+	} else if port == "" {
+		port = "1883"
+	}
+	s.opts.Address = net.JoinHostPort(u.Hostname(), port)
+	s.name = fmt.Sprintf("MQTT %s/%s", s.opts.Address, s.prefix)
+
+	if err := s.connect(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", s.name, err)
+	}
+	return s, nil
+}
+
+// connect connects and marks the machine online
+func (s *mqttSink) connect(ctx context.Context) error {
+	client, err := mqtt.Dial(ctx, s.opts)
+	if err != nil {
+		return err
+	}
+	s.client = client
+	// A new session has not announced anything yet
+	s.announced = make(map[string]bool)
+	return s.publish(ctx, s.topic("status"), []byte("online"), true)
+}
+
+func (s *mqttSink) Write(ctx context.Context, points []Point) error {
+	err := s.write(ctx, points)
+	if err == nil {
+		return nil
+	}
+	// The broker may have dropped the connection; try once more on a new one
+	if s.client != nil {
+		_ = s.client.Close()
+		s.client = nil
+	}
+	if cerr := s.connect(ctx); cerr != nil {
+		return fmt.Errorf("%w (reconnecting failed: %v)", err, cerr)
+	}
+	return s.write(ctx, points)
+}
+
+func (s *mqttSink) write(ctx context.Context, points []Point) error {
+	if s.client == nil {
+		return fmt.Errorf("not connected")
+	}
+	for _, p := range points {
+		machine := p.Tags["machine"]
+		if machine == "" {
+			machine = s.machine
+		}
+		switch p.Measurement {
+		case MeasurementResult:
+			msg := map[string]interface{}{}
+			for k, v := range p.Tags {
+				msg[k] = v
+			}
+			for k, v := range p.Fields {
+				msg[k] = v
+			}
+			msg["time"] = p.Time.UTC()
+			payload, err := json.Marshal(msg)
+			if err != nil {
+				return err
+			}
+			if err := s.publish(ctx, s.machineTopic(machine, "result"), payload, s.retain); err != nil {
+				return err
+			}
+		default:
+			for _, field := range sortedFields(p.Fields) {
+				if err := s.announce(ctx, machine, field, p.Units[field]); err != nil {
+					return err
+				}
+				value := strconv.FormatFloat(p.Fields[field], 'f', -1, 64)
+				if err := s.publish(ctx, s.machineTopic(machine, field), []byte(value), s.retain); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// announce publishes the Home Assistant discovery config of a reading the
+// first time it is seen
+func (s *mqttSink) announce(ctx context.Context, machine, field, unit string) error {
+	id := "fire_" + topicSegment(machine) + "_" + topicSegment(field)
+	if s.discovery == "" || s.announced[id] {
+		return nil
+	}
+	config := map[string]interface{}{
+		"name":               field,
+		"unique_id":          id,
+		"object_id":          id,
+		"state_topic":        s.machineTopic(machine, field),
+		"availability_topic": s.topic("status"),
+		"state_class":        "measurement",
+		"device": map[string]interface{}{
+			"identifiers":  []string{"fire_" + topicSegment(machine)},
+			"name":         machine,
+			"manufacturer": "F.I.R.E.",
+		},
+	}
+	if unit != "" {
+		config["unit_of_measurement"] = unit
+	}
+	if class, ok := deviceClasses[unit]; ok {
+		config["device_class"] = class
+	}
+	payload, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if err := s.publish(ctx, s.discovery+"/sensor/"+id+"/config", payload, true); err != nil {
+		return err
+	}
+	s.announced[id] = true
+	return nil
+}
+
+func (s *mqttSink) publish(ctx context.Context, topic string, payload []byte, retain bool) error {
+	return s.client.Publish(ctx, mqtt.Message{Topic: topic, Payload: payload, QoS: s.qos, Retain: retain})
+}
+
+// topic returns a topic of this machine
+func (s *mqttSink) topic(name string) string {
+	return s.machineTopic(s.machine, name)
+}
+
+// machineTopic returns <prefix>/<machine>/<name>
+func (s *mqttSink) machineTopic(machine, name string) string {
+	return s.prefix + "/" + topicSegment(machine) + "/" + topicSegment(name)
+}
+
+func (s *mqttSink) Close() error {
+	if s.client == nil {
+		return nil
+	}
+	// Going offline on purpose is not a lost connection
+	_ = s.publish(context.Background(), s.topic("status"), []byte("offline"), true)
+	return s.client.Close()
+}
+
+func (s *mqttSink) String() string {
+	return s.name
+}
+
+// topicSegment replaces the characters that separate or match topic levels
+func topicSegment(s string) string {
+	return strings.NewReplacer("/", "_", "+", "_", "#", "_", " ", "_").Replace(s)
+}
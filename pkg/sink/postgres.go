@@ -0,0 +1,115 @@
+package sink
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+
+	_ "github.com/lib/pq" // PostgreSQL driver
+)
+
+// defaultTable holds the points written to PostgreSQL
+const defaultTable = "fire_metrics"
+
+// tableName limits table names to plain identifiers, as they cannot be
+// passed as query parameters
+var tableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// postgres inserts one row per field into a table of time, measurement,
+// field, value and tags, which a TimescaleDB hypertable partitions by time
+type postgres struct {
+	conn  *sql.DB
+	table string
+	name  string
+}
+
+// openPostgres connects and creates the table. timescale:// URLs make it a
+// hypertable and fail when the TimescaleDB extension is missing.
+func openPostgres(ctx context.Context, u *url.URL) (*postgres, error) {
+	timescale := u.Scheme == "timescale" || u.Scheme == "timescaledb"
+	q := u.Query()
+	table := q.Get("table")
+	if table == "" {
+		table = defaultTable
+	}
+	if !tableName.MatchString(table) {
+		return nil, fmt.Errorf("invalid sink table name %q", table)
+	}
+	q.Del("table")
+
+	dsn := *u
+	dsn.Scheme = "postgres"
+	dsn.RawQuery = q.Encode()
+	conn, err := sql.Open("postgres", dsn.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", u.Redacted(), err)
+	}
+	s := &postgres{conn: conn, table: table, name: fmt.Sprintf("PostgreSQL %s%s table %s", u.Host, u.Path, table)}
+	if timescale {
+		s.name = fmt.Sprintf("TimescaleDB %s%s table %s", u.Host, u.Path, table)
+	}
+
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS ` + table + ` (
+			time TIMESTAMPTZ NOT NULL,
+			measurement TEXT NOT NULL,
+			field TEXT NOT NULL,
+			value DOUBLE PRECISION NOT NULL,
+			tags JSONB
+		)`,
+		`CREATE INDEX IF NOT EXISTS ` + table + `_field_time ON ` + table + ` (field, time DESC)`,
+	}
+	if timescale {
+		stmts = append(stmts, `SELECT create_hypertable('`+table+`', 'time', if_not_exists => TRUE)`)
+	}
+	for _, stmt := range stmts {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("failed to prepare %s: %w", s.name, err)
+		}
+	}
+	return s, nil
+}
+
+func (s *postgres) Write(ctx context.Context, points []Point) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO `+s.table+` (time, measurement, field, value, tags) VALUES ($1, $2, $3, $4, $5)`)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for _, p := range points {
+		tags, err := json.Marshal(p.Tags)
+		if err != nil {
+			return err
+		}
+		for _, field := range sortedFields(p.Fields) {
+			value := p.Fields[field]
+			if math.IsNaN(value) || math.IsInf(value, 0) {
+				continue
+			}
+			if _, err := stmt.ExecContext(ctx, p.Time.UTC(), p.Measurement, field, value, string(tags)); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *postgres) Close() error {
+	return s.conn.Close()
+}
+
+func (s *postgres) String() string {
+	return s.name
+}
@@ -0,0 +1,230 @@
+package smart
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+// counter is a SMART value whose change between snapshots is reported
+type counter struct {
+	name     string
+	unit     string
+	decimals int
+	// worse is +1 when a rise means the drive is degrading, -1 when a drop
+	// does, and 0 when the change is only wear from use
+	worse int
+	value func(d *Data) float64
+}
+
+// counters are reported in this order
+var counters = []counter{
+	{name: "reallocated_sectors", worse: 1, value: func(d *Data) float64 { return float64(d.ReallocatedSectors) }},
+	{name: "pending_sectors", worse: 1, value: func(d *Data) float64 { return float64(d.PendingSectors) }},
+	{name: "uncorrectable", worse: 1, value: func(d *Data) float64 { return float64(d.Uncorrectable) }},
+	{name: "crc_errors", worse: 1, value: func(d *Data) float64 { return float64(d.CRCErrors) }},
+	{name: "media_errors", worse: 1, value: func(d *Data) float64 { return float64(d.MediaErrors) }},
+	{name: "error_log_entries", worse: 1, value: func(d *Data) float64 { return float64(d.ErrorLogEntries) }},
+	{name: "available_spare", unit: "%", worse: -1, value: func(d *Data) float64 { return d.AvailableSpare }},
+	{name: "wear", unit: "%", value: func(d *Data) float64 { return d.WearLevel }},
+	{name: "unsafe_shutdowns", value: func(d *Data) float64 { return float64(d.UnsafeShutdowns) }},
+	{name: "written", unit: "TB", decimals: 2, value: func(d *Data) float64 { return d.TotalWrittenGB / 1024 }},
+	{name: "read", unit: "TB", decimals: 2, value: func(d *Data) float64 { return d.TotalReadGB / 1024 }},
+}
+
+// Change is a counter that differs between two snapshots of a drive
+type Change struct {
+	Counter string  `json:"counter"` // e.g. media_errors or written
+	Before  float64 `json:"before"`
+	After   float64 `json:"after"`
+	Unit    string  `json:"unit,omitempty"`
+	Worse   bool    `json:"worse"` // The change means the drive is degrading
+
+	decimals int
+}
+
+// Delta returns how much the counter changed
+func (c Change) Delta() float64 {
+	return c.After - c.Before
+}
+
+// String describes the change, e.g. "media_errors: 0 -> 2 (+2)" or
+// "written: 12.34 -> 12.51 TB (+0.17 TB)"
+func (c Change) String() string {
+	unit := ""
+	if c.Unit != "" {
+		unit = " " + c.Unit
+	}
+	sign := ""
+	if c.Delta() > 0 {
+		sign = "+"
+	}
+	return fmt.Sprintf("%s: %s -> %s%s (%s%s%s)", c.Counter, c.format(c.Before), c.format(c.After), unit, sign, c.format(c.Delta()), unit)
+}
+
+// format shows a value of the counter
+func (c Change) format(v float64) string {
+	return strconv.FormatFloat(v, 'f', c.decimals, 64)
+}
+
+// Changes lists the counters that differ between two snapshots of a drive.
+// Counters are compared as they are shown, so a rounding difference in the
+// data written is not reported.
+func Changes(before, after *Data) []Change {
+	var changes []Change
+	for _, c := range counters {
+		ch := Change{Counter: c.name, Before: c.value(before), After: c.value(after), Unit: c.unit, decimals: c.decimals}
+		if ch.format(ch.Before) == ch.format(ch.After) {
+			continue
+		}
+		ch.Worse = (c.worse > 0 && ch.Delta() > 0) || (c.worse < 0 && ch.Delta() < 0)
+		changes = append(changes, ch)
+	}
+	return changes
+}
+
+// Save stores d as a snapshot taken at at
+func Save(database *db.DB, d *Data, at time.Time) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("failed to encode SMART data: %w", err)
+	}
+	return database.CreateSMARTSnapshot(&db.SMARTSnapshot{
+		Device:  d.Device,
+		Serial:  d.Serial,
+		Model:   d.Model,
+		TakenAt: at,
+		Data:    string(data),
+	})
+}
+
+// FromSnapshot decodes the SMART data of a saved snapshot
+func FromSnapshot(s *db.SMARTSnapshot) (*Data, error) {
+	d := &Data{}
+	if err := json.Unmarshal([]byte(s.Data), d); err != nil {
+		return nil, fmt.Errorf("failed to decode SMART snapshot %d: %w", s.ID, err)
+	}
+	return d, nil
+}
+
+// Record reads every drive Scan finds and saves a snapshot of each one that
+// reports SMART data. Drives that could not be read are skipped and their
+// errors returned with the snapshots that were saved.
+func Record(database *db.DB, at time.Time) ([]*Data, error) {
+	devices, err := Scan()
+	if err != nil {
+		return nil, err
+	}
+	var saved []*Data
+	var errs []error
+	seen := make(map[string]bool)
+	for _, dev := range devices {
+		// Drives behind a RAID controller share the controller's name and
+		// need a device type smartctl is not given here
+		if seen[dev.Name] {
+			continue
+		}
+		seen[dev.Name] = true
+		d, err := Read(dev.Name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := Save(database, d, at); err != nil {
+			return saved, err
+		}
+		saved = append(saved, d)
+	}
+	return saved, errors.Join(errs...)
+}
+
+// DriveChanges is what changed on one drive between two snapshots
+type DriveChanges struct {
+	Drive   *Data // As of the later snapshot
+	From    time.Time
+	To      time.Time
+	Changes []Change
+}
+
+// Worse reports whether any change means the drive is degrading
+func (c *DriveChanges) Worse() bool {
+	for _, ch := range c.Changes {
+		if ch.Worse {
+			return true
+		}
+	}
+	return false
+}
+
+// RunChanges compares every drive's snapshots from around the previous run
+// with its last snapshot by the end of run: the last one taken when the
+// previous run started, or the drive's first if there is none, against the
+// last one taken when run ended. Drives without two snapshots in that span
+// are left out.
+func RunChanges(database *db.DB, run *db.Run) ([]*DriveChanges, error) {
+	var from time.Time
+	runs, err := database.ListRuns(db.RunFilter{EndTime: &run.StartTime, Limit: 2})
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range runs {
+		if r.ID != run.ID && !r.StartTime.After(run.StartTime) {
+			from = r.StartTime
+			break
+		}
+	}
+	to := time.Now()
+	if run.EndTime != nil {
+		to = *run.EndTime
+	}
+
+	key := func(s *db.SMARTSnapshot) string {
+		return s.Device + "\x00" + s.Serial
+	}
+	before := make(map[string]*db.SMARTSnapshot)
+	if !from.IsZero() {
+		latest, err := database.LatestSMARTSnapshots(from)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range latest {
+			before[key(s)] = s
+		}
+	}
+	// Drives first seen after the previous run started count from then
+	span, err := database.ListSMARTSnapshots(from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range span {
+		if _, ok := before[key(s)]; !ok {
+			before[key(s)] = s
+		}
+	}
+
+	after, err := database.LatestSMARTSnapshots(to)
+	if err != nil {
+		return nil, err
+	}
+	var out []*DriveChanges
+	for _, a := range after {
+		b, ok := before[key(a)]
+		if !ok || b.ID == a.ID {
+			continue
+		}
+		older, err := FromSnapshot(b)
+		if err != nil {
+			return nil, err
+		}
+		newer, err := FromSnapshot(a)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, &DriveChanges{Drive: newer, From: b.TakenAt, To: a.TakenAt, Changes: Changes(older, newer)})
+	}
+	return out, nil
+}
@@ -0,0 +1,96 @@
+package smart
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+func TestChanges(t *testing.T) {
+	before := &Data{MediaErrors: 0, AvailableSpare: 100, TotalWrittenGB: 12636, UnsafeShutdowns: 4, PowerOnHours: 100}
+	after := &Data{MediaErrors: 2, AvailableSpare: 99, TotalWrittenGB: 12810, UnsafeShutdowns: 4, PowerOnHours: 130}
+
+	changes := Changes(before, after)
+	if len(changes) != 3 {
+		t.Fatalf("expected media errors, spare and writes to change, got %v", changes)
+	}
+	if c := changes[0]; c.Counter != "media_errors" || !c.Worse || c.String() != "media_errors: 0 -> 2 (+2)" {
+		t.Errorf("unexpected media error change %v", c)
+	}
+	if c := changes[1]; c.Counter != "available_spare" || !c.Worse {
+		t.Errorf("expected the spare dropping to be worse, got %v", c)
+	}
+	if c := changes[2]; c.Counter != "written" || c.Worse || c.String() != "written: 12.34 -> 12.51 TB (+0.17 TB)" {
+		t.Errorf("unexpected write change %v", c)
+	}
+
+	// Less than the shown precision is no change
+	if changes := Changes(&Data{TotalWrittenGB: 1000}, &Data{TotalWrittenGB: 1000.5}); len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+}
+
+func TestRunChanges(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = database.Close() }()
+
+	day := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	save := func(d *Data, at time.Time) {
+		t.Helper()
+		if err := Save(database, d, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	newRun := func(start time.Time) *db.Run {
+		t.Helper()
+		run, err := database.CreateRun("disk", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		end := start.Add(30 * time.Minute)
+		run.StartTime, run.EndTime = start, &end
+		if err := database.UpdateRun(run); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := database.Exec(`UPDATE runs SET start_time = ? WHERE id = ?`, start, run.ID); err != nil {
+			t.Fatal(err)
+		}
+		return run
+	}
+
+	nvme := func(media uint64, written float64) *Data {
+		return &Data{Device: "/dev/nvme0n1", Serial: "S5GX", Model: "Samsung SSD 980", MediaErrors: media, TotalWrittenGB: written, Available: true}
+	}
+	save(nvme(0, 10240), day)
+	save(nvme(0, 10752), day.Add(24*time.Hour))
+	save(nvme(3, 11264), day.Add(48*time.Hour))
+	// A drive first seen later counts from its first snapshot
+	save(&Data{Device: "/dev/sda", Serial: "WD-1", ReallocatedSectors: 0}, day.Add(30*time.Hour))
+	save(&Data{Device: "/dev/sda", Serial: "WD-1", ReallocatedSectors: 8}, day.Add(47*time.Hour))
+
+	newRun(day.Add(time.Hour))
+	run := newRun(day.Add(47*time.Hour + 45*time.Minute))
+
+	drives, err := RunChanges(database, run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drives) != 2 {
+		t.Fatalf("expected changes on 2 drives, got %d", len(drives))
+	}
+	nv := drives[0]
+	if nv.Drive.Device != "/dev/nvme0n1" || !nv.From.Equal(day) || !nv.To.Equal(day.Add(48*time.Hour)) || !nv.Worse() {
+		t.Errorf("expected the NVMe drive compared from the first to the last snapshot, got %+v", nv)
+	}
+	if len(nv.Changes) != 2 || nv.Changes[0].Counter != "media_errors" || nv.Changes[1].Counter != "written" || nv.Changes[1].Delta() != 1 {
+		t.Errorf("expected media errors and 1 TB written, got %v", nv.Changes)
+	}
+	if sda := drives[1]; len(sda.Changes) != 1 || sda.Changes[0].String() != "reallocated_sectors: 0 -> 8 (+8)" {
+		t.Errorf("unexpected changes on /dev/sda %v", sda.Changes)
+	}
+}
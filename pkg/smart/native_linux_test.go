@@ -0,0 +1,27 @@
+//go:build linux
+// +build linux
+
+package smart
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScanNative(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"nvme0n1", "nvme0n1p1", "nvme0c0n1", "nvme1n1", "sda"} {
+		if err := os.MkdirAll(filepath.Join(root, "sys/block", name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := sysfsRoot
+	sysfsRoot = root
+	defer func() { sysfsRoot = old }()
+
+	devices := scanNative()
+	if len(devices) != 2 || devices[0].Name != "/dev/nvme0n1" || devices[1].Name != "/dev/nvme1n1" {
+		t.Errorf("expected the two NVMe namespaces, got %+v", devices)
+	}
+}
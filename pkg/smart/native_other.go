@@ -0,0 +1,17 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package smart
+
+import "errors"
+
+// readNative needs smartctl elsewhere: only Linux and Windows read NVMe
+// drives directly
+func readNative(_ string) (*Data, error) {
+	return nil, errors.New("native SMART reads are only supported on Linux and Windows")
+}
+
+// scanNative finds nothing without smartctl
+func scanNative() []ScanDevice {
+	return nil
+}
@@ -0,0 +1,172 @@
+//go:build windows
+// +build windows
+
+package smart
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	ioctlStorageQueryProperty = 0x002D1400
+
+	// STORAGE_PROPERTY_ID and STORAGE_QUERY_TYPE values
+	storageDeviceProperty                 = 0
+	storageDeviceProtocolSpecificProperty = 50
+	propertyStandardQuery                 = 0
+
+	// STORAGE_PROTOCOL_TYPE and STORAGE_PROTOCOL_NVME_DATA_TYPE values
+	protocolTypeNvme    = 3
+	nvmeDataTypeLogPage = 2
+
+	// busTypeNvme is STORAGE_BUS_TYPE BusTypeNvme
+	busTypeNvme = 0x11
+
+	// storagePropertyHeader is the size of the STORAGE_PROPERTY_QUERY fields
+	// before AdditionalParameters, and of the Version and Size fields of
+	// STORAGE_PROTOCOL_DATA_DESCRIPTOR
+	storagePropertyHeader = 8
+	// storageProtocolDataSize is the size of STORAGE_PROTOCOL_SPECIFIC_DATA
+	storageProtocolDataSize = 40
+
+	// maxPhysicalDrives bounds the drives scanNative tries to open
+	maxPhysicalDrives = 32
+)
+
+// storageDevice is the part of STORAGE_DEVICE_DESCRIPTOR read here
+type storageDevice struct {
+	model    string
+	serial   string
+	firmware string
+	busType  uint32
+}
+
+// readNative asks an NVMe drive such as \\.\PHYSICALDRIVE0 for its health
+// log page through the Windows storage stack, which needs no vendor driver.
+// Reading the log needs an elevated process.
+func readNative(device string) (*Data, error) {
+	handle, err := openDrive(device, windows.GENERIC_READ|windows.GENERIC_WRITE)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = windows.CloseHandle(handle) }()
+
+	desc, err := queryDevice(handle)
+	if err != nil {
+		return nil, err
+	}
+	if desc.busType != busTypeNvme {
+		return nil, errors.New("native SMART reads are only supported for NVMe drives")
+	}
+
+	// STORAGE_PROPERTY_QUERY with STORAGE_PROTOCOL_SPECIFIC_DATA as its
+	// additional parameters, followed by room for the log page. The drive
+	// answers in the same buffer with a STORAGE_PROTOCOL_DATA_DESCRIPTOR.
+	buf := make([]byte, storagePropertyHeader+storageProtocolDataSize+nvmeHealthLogSize)
+	le := binary.LittleEndian
+	le.PutUint32(buf[0:], storageDeviceProtocolSpecificProperty)
+	le.PutUint32(buf[4:], propertyStandardQuery)
+	protocol := buf[storagePropertyHeader:]
+	le.PutUint32(protocol[0:], protocolTypeNvme)
+	le.PutUint32(protocol[4:], nvmeDataTypeLogPage)
+	le.PutUint32(protocol[8:], nvmeHealthLogID)
+	le.PutUint32(protocol[16:], storageProtocolDataSize) // The page follows the parameters
+	le.PutUint32(protocol[20:], nvmeHealthLogSize)
+
+	if err := storageQuery(handle, buf, buf); err != nil {
+		return nil, fmt.Errorf("failed to read the NVMe health log of %s: %w", device, err)
+	}
+	offset := storagePropertyHeader + int(le.Uint32(protocol[16:]))
+	length := int(le.Uint32(protocol[20:]))
+	if length < nvmeHealthLogSize || offset+nvmeHealthLogSize > len(buf) {
+		return nil, fmt.Errorf("NVMe health log of %s is %d bytes at offset %d", device, length, offset)
+	}
+
+	d, err := ParseNVMeHealthLog(buf[offset : offset+nvmeHealthLogSize])
+	if err != nil {
+		return nil, err
+	}
+	d.Device = device
+	d.Model = desc.model
+	d.Serial = desc.serial
+	d.Firmware = desc.firmware
+	return d, nil
+}
+
+// scanNative lists the NVMe drives among the physical drives. The device
+// descriptor can be read without elevation.
+func scanNative() []ScanDevice {
+	var devices []ScanDevice
+	for i := 0; i < maxPhysicalDrives; i++ {
+		name := fmt.Sprintf(`\\.\PHYSICALDRIVE%d`, i)
+		handle, err := openDrive(name, 0)
+		if err != nil {
+			continue // Drive numbers can have gaps
+		}
+		desc, err := queryDevice(handle)
+		_ = windows.CloseHandle(handle)
+		if err != nil || desc.busType != busTypeNvme {
+			continue
+		}
+		devices = append(devices, ScanDevice{Name: name, InfoName: name, Type: "nvme", Protocol: ProtocolNVMe})
+	}
+	return devices
+}
+
+// openDrive opens a physical drive with the given access
+func openDrive(device string, access uint32) (windows.Handle, error) {
+	path, err := windows.UTF16PtrFromString(device)
+	if err != nil {
+		return windows.InvalidHandle, err
+	}
+	return windows.CreateFile(path, access, windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE, nil, windows.OPEN_EXISTING, 0, 0)
+}
+
+// queryDevice reads the STORAGE_DEVICE_DESCRIPTOR of a drive
+func queryDevice(handle windows.Handle) (*storageDevice, error) {
+	query := make([]byte, storagePropertyHeader+4)
+	binary.LittleEndian.PutUint32(query[0:], storageDeviceProperty)
+	binary.LittleEndian.PutUint32(query[4:], propertyStandardQuery)
+	buf := make([]byte, 1024)
+	if err := storageQuery(handle, query, buf); err != nil {
+		return nil, fmt.Errorf("failed to query the storage device: %w", err)
+	}
+
+	// A string field is the offset of a NUL-terminated string, 0 if absent
+	str := func(field int) string {
+		offset := int(binary.LittleEndian.Uint32(buf[field:]))
+		if offset == 0 || offset >= len(buf) {
+			return ""
+		}
+		end := offset
+		for end < len(buf) && buf[end] != 0 {
+			end++
+		}
+		// Windows pads the strings with spaces
+		return strings.TrimSpace(string(buf[offset:end]))
+	}
+	model := str(16)
+	if vendor := str(12); vendor != "" {
+		model = vendor + " " + model
+	}
+	return &storageDevice{
+		model:    model,
+		serial:   str(24),
+		firmware: str(20),
+		busType:  binary.LittleEndian.Uint32(buf[28:]),
+	}, nil
+}
+
+// storageQuery sends IOCTL_STORAGE_QUERY_PROPERTY
+func storageQuery(handle windows.Handle, in, out []byte) error {
+	var returned uint32
+	return windows.DeviceIoControl(handle, ioctlStorageQueryProperty,
+		&in[0], uint32(len(in)), // #nosec G115 -- buffer sizes are small
+		&out[0], uint32(len(out)), // #nosec G115 -- buffer sizes are small
+		&returned, nil)
+}
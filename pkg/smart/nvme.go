@@ -0,0 +1,48 @@
+package smart
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// nvmeHealthLogSize is the size of the NVMe SMART / Health Information log
+// page (log identifier 02h)
+const nvmeHealthLogSize = 512
+
+// nvmeHealthLogID is the log identifier of the health log page
+const nvmeHealthLogID = 0x02
+
+// ParseNVMeHealthLog decodes the NVMe SMART / Health Information log page as
+// the drive returns it. Its 128-bit counters are read as their low 64 bits.
+func ParseNVMeHealthLog(page []byte) (*Data, error) {
+	if len(page) < nvmeHealthLogSize {
+		return nil, fmt.Errorf("NVMe health log is %d bytes, expected %d", len(page), nvmeHealthLogSize)
+	}
+	counter := func(offset int) uint64 {
+		return binary.LittleEndian.Uint64(page[offset : offset+8])
+	}
+
+	d := &Data{
+		Protocol:        ProtocolNVMe,
+		HealthStatus:    HealthGood,
+		Available:       true,
+		CriticalWarning: uint64(page[0]),
+		AvailableSpare:  float64(page[3]),
+		WearLevel:       float64(page[5]),
+		TotalReadGB:     float64(counter(32)) * nvmeDataUnit / bytesPerGB,
+		TotalWrittenGB:  float64(counter(48)) * nvmeDataUnit / bytesPerGB,
+		PowerCycles:     counter(112),
+		PowerOnHours:    counter(128),
+		UnsafeShutdowns: counter(144),
+		MediaErrors:     counter(160),
+		ErrorLogEntries: counter(176),
+	}
+	// The composite temperature is in kelvin
+	if kelvin := binary.LittleEndian.Uint16(page[1:3]); kelvin > 0 {
+		d.Temperature = float64(kelvin) - 273
+	}
+	if d.CriticalWarning != 0 {
+		d.HealthStatus = HealthWarning
+	}
+	return d, nil
+}
@@ -0,0 +1,340 @@
+package smart
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// nvmeDataUnit is the size of an NVMe "Data Units Read/Written" unit
+const nvmeDataUnit = 512 * 1000
+
+// bytesPerGB converts byte counts to the GB (GiB) the data is reported in
+const bytesPerGB = 1024 * 1024 * 1024
+
+// Parse extracts identity, health and attributes from smartctl -i -A -H
+// text output, for both ATA attribute tables and the NVMe health log
+func Parse(output string) *Data {
+	d := &Data{HealthStatus: HealthUnknown}
+
+	switch {
+	case strings.Contains(output, "SMART overall-health self-assessment test result: PASSED"),
+		strings.Contains(output, "SMART Health Status: OK"):
+		d.HealthStatus = HealthGood
+	case strings.Contains(output, "SMART overall-health self-assessment test result: FAILED"):
+		d.HealthStatus = HealthCritical
+	}
+
+	for _, field := range []string{"Device Model:", "Model Number:", "Product:"} {
+		if d.Model = Field(output, field); d.Model != "" {
+			break
+		}
+	}
+	for _, field := range []string{"Serial Number:", "Serial number:"} {
+		if d.Serial = Field(output, field); d.Serial != "" {
+			break
+		}
+	}
+	for _, field := range []string{"Firmware Version:", "Revision:"} {
+		if d.Firmware = Field(output, field); d.Firmware != "" {
+			break
+		}
+	}
+
+	d.Attributes = parseAttributeTable(output)
+	if len(d.Attributes) > 0 {
+		d.Protocol = ProtocolATA
+		applyAttributes(d)
+	}
+	if strings.Contains(output, "NVMe Log") {
+		d.Protocol = ProtocolNVMe
+		parseNVMeHealth(output, d)
+	}
+	return d
+}
+
+// parseAttributeTable reads the rows of smartctl -A's attribute table:
+// ID# ATTRIBUTE_NAME FLAG VALUE WORST THRESH TYPE UPDATED WHEN_FAILED RAW_VALUE
+func parseAttributeTable(output string) []Attribute {
+	var attrs []Attribute
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 10 || !strings.HasPrefix(fields[2], "0x") {
+			continue
+		}
+		id, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		a := Attribute{ID: id, Name: fields[1], RawString: strings.Join(fields[9:], " ")}
+		a.Value, _ = strconv.Atoi(fields[3])
+		a.Worst, _ = strconv.Atoi(fields[4])
+		a.Threshold, _ = strconv.Atoi(fields[5])
+		if fields[8] != "-" {
+			a.WhenFailed = fields[8]
+		}
+		a.Raw = leadingNumber(fields[9])
+		attrs = append(attrs, a)
+	}
+	return attrs
+}
+
+// leadingNumber parses the number a raw value starts with, e.g. 36 in
+// "36 (Min/Max 20/45)"
+func leadingNumber(s string) uint64 {
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	n, _ := strconv.ParseUint(s[:end], 10, 64)
+	return n
+}
+
+// applyAttributes fills in the values the ATA attributes report
+func applyAttributes(d *Data) {
+	raw := func(ids ...int) (uint64, bool) {
+		for _, id := range ids {
+			if a, ok := d.Attribute(id); ok {
+				return a.Raw, true
+			}
+		}
+		return 0, false
+	}
+
+	if v, ok := raw(194, 190); ok { // Temperature_Celsius, Airflow_Temperature_Cel
+		d.Temperature = float64(v)
+		d.Available = true
+	}
+	if v, ok := raw(9); ok {
+		d.PowerOnHours = v
+		d.Available = true
+	}
+	if v, ok := raw(12); ok {
+		d.PowerCycles = v
+		d.Available = true
+	}
+	if v, ok := raw(177, 231); ok { // Wear_Leveling_Count, SSD_Life_Left
+		d.WearLevel = 100 - float64(v) // Convert to percentage used
+		d.Available = true
+	}
+	if v, ok := raw(241); ok { // Total_LBAs_Written, assuming 512-byte LBAs
+		d.TotalWrittenGB = float64(v) * 512 / bytesPerGB
+		d.Available = true
+	}
+	if v, ok := raw(242); ok { // Total_LBAs_Read
+		d.TotalReadGB = float64(v) * 512 / bytesPerGB
+		d.Available = true
+	}
+
+	d.ReallocatedSectors, _ = raw(5)
+	d.PendingSectors, _ = raw(197)
+	d.Uncorrectable, _ = raw(198)
+	d.CRCErrors, _ = raw(199)
+	d.UnsafeShutdowns, _ = raw(174)
+	for _, a := range d.Attributes {
+		if a.Failing() && d.HealthStatus == HealthGood {
+			d.HealthStatus = HealthWarning
+		}
+	}
+}
+
+// parseNVMeHealth fills in the values of the NVMe SMART/Health Information log
+func parseNVMeHealth(output string, d *Data) {
+	number := func(field string) (float64, bool) {
+		value := Field(output, field)
+		if value == "" {
+			return 0, false
+		}
+		// e.g. "35 Celsius", "3%", "9,876,543 [5.05 TB]", "0x00"
+		value = strings.Fields(value)[0]
+		value = strings.TrimSuffix(strings.ReplaceAll(value, ",", ""), "%")
+		if val, err := strconv.ParseFloat(value, 64); err == nil {
+			return val, true
+		}
+		val, err := strconv.ParseUint(value, 0, 64)
+		return float64(val), err == nil
+	}
+	set := func(field string, to func(v float64)) {
+		if v, ok := number(field); ok {
+			to(v)
+			d.Available = true
+		}
+	}
+
+	set("Temperature:", func(v float64) { d.Temperature = v })
+	set("Power On Hours:", func(v float64) { d.PowerOnHours = uint64(v) })
+	set("Power Cycles:", func(v float64) { d.PowerCycles = uint64(v) })
+	set("Percentage Used:", func(v float64) { d.WearLevel = v })
+	set("Data Units Written:", func(v float64) { d.TotalWrittenGB = v * nvmeDataUnit / bytesPerGB })
+	set("Data Units Read:", func(v float64) { d.TotalReadGB = v * nvmeDataUnit / bytesPerGB })
+	set("Available Spare:", func(v float64) { d.AvailableSpare = v })
+	set("Media and Data Integrity Errors:", func(v float64) { d.MediaErrors = uint64(v) })
+	set("Error Information Log Entries:", func(v float64) { d.ErrorLogEntries = uint64(v) })
+	set("Unsafe Shutdowns:", func(v float64) { d.UnsafeShutdowns = uint64(v) })
+	set("Critical Warning:", func(v float64) { d.CriticalWarning = uint64(v) })
+	if d.CriticalWarning != 0 && d.HealthStatus == HealthGood {
+		d.HealthStatus = HealthWarning
+	}
+}
+
+// smartctlJSON is the part of smartctl -j -a output that is read
+type smartctlJSON struct {
+	Device struct {
+		Name     string `json:"name"`
+		Protocol string `json:"protocol"`
+	} `json:"device"`
+	ModelName       string `json:"model_name"`
+	ScsiProductName string `json:"scsi_model_name"`
+	SerialNumber    string `json:"serial_number"`
+	FirmwareVersion string `json:"firmware_version"`
+	ScsiRevision    string `json:"scsi_revision"`
+	SmartStatus     *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	Temperature *struct {
+		Current float64 `json:"current"`
+	} `json:"temperature"`
+	PowerOnTime *struct {
+		Hours uint64 `json:"hours"`
+	} `json:"power_on_time"`
+	PowerCycleCount    *uint64 `json:"power_cycle_count"`
+	AtaSmartAttributes *struct {
+		Table []struct {
+			ID         int    `json:"id"`
+			Name       string `json:"name"`
+			Value      int    `json:"value"`
+			Worst      int    `json:"worst"`
+			Thresh     int    `json:"thresh"`
+			WhenFailed string `json:"when_failed"`
+			Raw        struct {
+				String string `json:"string"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+	NVMeLog *struct {
+		CriticalWarning  uint64  `json:"critical_warning"`
+		Temperature      float64 `json:"temperature"`
+		AvailableSpare   float64 `json:"available_spare"`
+		PercentageUsed   float64 `json:"percentage_used"`
+		DataUnitsRead    uint64  `json:"data_units_read"`
+		DataUnitsWritten uint64  `json:"data_units_written"`
+		PowerCycles      uint64  `json:"power_cycles"`
+		PowerOnHours     uint64  `json:"power_on_hours"`
+		UnsafeShutdowns  uint64  `json:"unsafe_shutdowns"`
+		MediaErrors      uint64  `json:"media_errors"`
+		NumErrLogEntries uint64  `json:"num_err_log_entries"`
+	} `json:"nvme_smart_health_information_log"`
+	ScsiGrownDefectList *uint64 `json:"scsi_grown_defect_list"`
+	ScsiErrorCounterLog *struct {
+		Read struct {
+			TotalUncorrectedErrors uint64 `json:"total_uncorrected_errors"`
+		} `json:"read"`
+		Write struct {
+			TotalUncorrectedErrors uint64 `json:"total_uncorrected_errors"`
+		} `json:"write"`
+	} `json:"scsi_error_counter_log"`
+}
+
+// ParseJSON extracts identity, health and attributes from smartctl -j -a
+// output. It fails only when the output is not smartctl JSON; a drive that
+// reports nothing gives Data that is not Available.
+func ParseJSON(output []byte) (*Data, error) {
+	var j smartctlJSON
+	if err := json.Unmarshal(output, &j); err != nil {
+		return &Data{HealthStatus: HealthUnknown}, fmt.Errorf("failed to parse smartctl JSON: %w", err)
+	}
+
+	d := &Data{
+		Device:       j.Device.Name,
+		Protocol:     j.Device.Protocol,
+		Model:        j.ModelName,
+		Serial:       j.SerialNumber,
+		Firmware:     j.FirmwareVersion,
+		HealthStatus: HealthUnknown,
+	}
+	if d.Model == "" {
+		d.Model = j.ScsiProductName
+	}
+	if d.Firmware == "" {
+		d.Firmware = j.ScsiRevision
+	}
+	if j.SmartStatus != nil {
+		d.HealthStatus = HealthCritical
+		if j.SmartStatus.Passed {
+			d.HealthStatus = HealthGood
+		}
+	}
+
+	if j.AtaSmartAttributes != nil {
+		for _, row := range j.AtaSmartAttributes.Table {
+			d.Attributes = append(d.Attributes, Attribute{
+				ID:         row.ID,
+				Name:       row.Name,
+				Value:      row.Value,
+				Worst:      row.Worst,
+				Threshold:  row.Thresh,
+				WhenFailed: row.WhenFailed,
+				// The JSON raw value packs every field of attributes such
+				// as temperature; the string's leading number is the reading
+				Raw:       leadingNumber(row.Raw.String),
+				RawString: row.Raw.String,
+			})
+		}
+		applyAttributes(d)
+	}
+	if log := j.NVMeLog; log != nil {
+		d.Temperature = log.Temperature
+		d.PowerOnHours = log.PowerOnHours
+		d.PowerCycles = log.PowerCycles
+		d.WearLevel = log.PercentageUsed
+		d.TotalWrittenGB = float64(log.DataUnitsWritten) * nvmeDataUnit / bytesPerGB
+		d.TotalReadGB = float64(log.DataUnitsRead) * nvmeDataUnit / bytesPerGB
+		d.AvailableSpare = log.AvailableSpare
+		d.MediaErrors = log.MediaErrors
+		d.ErrorLogEntries = log.NumErrLogEntries
+		d.UnsafeShutdowns = log.UnsafeShutdowns
+		d.CriticalWarning = log.CriticalWarning
+		if d.CriticalWarning != 0 && d.HealthStatus == HealthGood {
+			d.HealthStatus = HealthWarning
+		}
+		d.Available = true
+	}
+	if j.ScsiGrownDefectList != nil {
+		d.ReallocatedSectors = *j.ScsiGrownDefectList
+		d.Available = true
+	}
+	if log := j.ScsiErrorCounterLog; log != nil {
+		d.Uncorrectable = log.Read.TotalUncorrectedErrors + log.Write.TotalUncorrectedErrors
+		d.Available = true
+	}
+
+	// The summary fields are what SCSI drives report instead of attributes
+	if j.Temperature != nil && d.Temperature == 0 {
+		d.Temperature = j.Temperature.Current
+		d.Available = true
+	}
+	if j.PowerOnTime != nil && d.PowerOnHours == 0 {
+		d.PowerOnHours = j.PowerOnTime.Hours
+		d.Available = true
+	}
+	if j.PowerCycleCount != nil && d.PowerCycles == 0 {
+		d.PowerCycles = *j.PowerCycleCount
+		d.Available = true
+	}
+	return d, nil
+}
+
+// Field returns the value of a "Field: value" line of smartctl output
+func Field(output, field string) string {
+	lines := strings.Split(output, "\n")
+	for _, line := range lines {
+		if strings.Contains(line, field) {
+			parts := strings.SplitN(line, ":", 2)
+			if len(parts) == 2 {
+				return strings.TrimSpace(parts[1])
+			}
+		}
+	}
+	return ""
+}
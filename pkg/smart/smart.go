@@ -0,0 +1,212 @@
+// Package smart reads the SMART data of drives and tracks how it changes.
+//
+// Read prefers the privileged helper, so callers do not need root, then
+// smartctl's JSON output (smartctl -j -a), which carries the whole ATA
+// attribute table and NVMe health log. Besides temperature, wear and the
+// data written and read, Data carries the counters that show a drive
+// failing: reallocated, pending and uncorrectable sectors on ATA drives,
+// media errors and error log entries on NVMe.
+//
+// Without smartctl, NVMe drives are asked for their health log directly:
+// through the NVMe admin ioctl on Linux and IOCTL_STORAGE_QUERY_PROPERTY on
+// Windows, where smartctl is rarely installed.
+//
+// The agent saves a snapshot of every drive periodically (Record). Changes
+// compares two snapshots of a drive and RunChanges finds the snapshots
+// around a run, so bench show can list what changed since the previous run.
+package smart
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/helper"
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// Health statuses, from best to worst
+const (
+	HealthUnknown  = "Unknown"
+	HealthGood     = "Good"
+	HealthWarning  = "Warning"
+	HealthCritical = "Critical"
+)
+
+// Protocols a drive is read with
+const (
+	ProtocolATA  = "ATA"
+	ProtocolNVMe = "NVMe"
+	ProtocolSCSI = "SCSI"
+)
+
+// readTimeout bounds one smartctl run; a drive that stopped answering must
+// not hang the caller
+const readTimeout = 30 * time.Second
+
+// ErrUnavailable is returned when a drive reports no SMART data
+var ErrUnavailable = errors.New("no SMART data reported")
+
+// Data is the SMART data of one drive
+type Data struct {
+	Device   string `json:"device"`
+	Model    string `json:"model,omitempty"`
+	Serial   string `json:"serial,omitempty"`
+	Firmware string `json:"firmware,omitempty"`
+	Protocol string `json:"protocol,omitempty"` // ATA, NVMe or SCSI
+
+	Temperature    float64 `json:"temperature_c,omitempty"` // Celsius
+	HealthStatus   string  `json:"health"`                  // Good, Warning, Critical or Unknown
+	PowerOnHours   uint64  `json:"power_on_hours,omitempty"`
+	PowerCycles    uint64  `json:"power_cycles,omitempty"`
+	TotalWrittenGB float64 `json:"written_gb,omitempty"`
+	TotalReadGB    float64 `json:"read_gb,omitempty"`
+	WearLevel      float64 `json:"wear_pct,omitempty"` // Percentage of rated life used, for SSDs
+	Available      bool    `json:"available"`          // Whether SMART data is available
+
+	// Counters of a failing drive
+	ReallocatedSectors uint64  `json:"reallocated_sectors,omitempty"` // ATA 5, SCSI grown defects
+	PendingSectors     uint64  `json:"pending_sectors,omitempty"`     // ATA 197
+	Uncorrectable      uint64  `json:"uncorrectable,omitempty"`       // ATA 198, SCSI uncorrected errors
+	CRCErrors          uint64  `json:"crc_errors,omitempty"`          // ATA 199, usually the cable
+	MediaErrors        uint64  `json:"media_errors,omitempty"`        // NVMe media and data integrity errors
+	ErrorLogEntries    uint64  `json:"error_log_entries,omitempty"`   // NVMe
+	UnsafeShutdowns    uint64  `json:"unsafe_shutdowns,omitempty"`    // NVMe, ATA 174
+	AvailableSpare     float64 `json:"available_spare_pct,omitempty"` // NVMe
+	CriticalWarning    uint64  `json:"critical_warning,omitempty"`    // NVMe bit field
+
+	Attributes []Attribute `json:"attributes,omitempty"` // ATA attribute table
+}
+
+// Attribute is one row of an ATA SMART attribute table
+type Attribute struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Value      int    `json:"value"` // Normalized, usually 100 or 200 when new
+	Worst      int    `json:"worst"`
+	Threshold  int    `json:"thresh"`
+	WhenFailed string `json:"when_failed,omitempty"`
+	Raw        uint64 `json:"raw"`
+	RawString  string `json:"raw_string,omitempty"` // e.g. "36 (Min/Max 20/45)"
+}
+
+// Failing reports whether the normalized value reached its threshold
+func (a Attribute) Failing() bool {
+	return a.Threshold > 0 && a.Value <= a.Threshold
+}
+
+// Attribute returns the ATA attribute with the given ID
+func (d *Data) Attribute(id int) (Attribute, bool) {
+	for _, a := range d.Attributes {
+		if a.ID == id {
+			return a, true
+		}
+	}
+	return Attribute{}, false
+}
+
+// String names the drive, e.g. "/dev/sda (Samsung SSD 870 EVO, S/N S6PU...)"
+func (d *Data) String() string {
+	var parts []string
+	if d.Model != "" {
+		parts = append(parts, d.Model)
+	}
+	if d.Serial != "" {
+		parts = append(parts, "S/N "+d.Serial)
+	}
+	if len(parts) == 0 {
+		return d.Device
+	}
+	return fmt.Sprintf("%s (%s)", d.Device, strings.Join(parts, ", "))
+}
+
+// Read returns the SMART data of a whole-disk device such as /dev/sda or
+// /dev/nvme0n1. Without the helper, smartctl usually needs root.
+func Read(device string) (*Data, error) {
+	if err := safeexec.ValidateDevicePath(device); err != nil {
+		return nil, err
+	}
+
+	// The helper runs smartctl as root and returns its text output
+	if client := helper.NewClient(""); client.Available() {
+		if output, err := client.SMART(device); err == nil {
+			if d := Parse(output); d.Available {
+				d.Device = device
+				return d, nil
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), readTimeout)
+	defer cancel()
+	// smartctl uses non-zero exit codes for drive warnings, so any output is parsed
+	output, err := safeexec.CommandContext(ctx, "smartctl", "-j", "-a", device).Output()
+	if len(output) > 0 {
+		d, jsonErr := ParseJSON(output)
+		if jsonErr != nil {
+			// smartctl before 7.0 has no JSON output
+			output, _ = safeexec.CommandContext(ctx, "smartctl", "-i", "-A", "-H", device).Output()
+			d = Parse(string(output))
+		}
+		if d.Available {
+			d.Device = device
+			return d, nil
+		}
+		return d, fmt.Errorf("%w for %s", ErrUnavailable, device)
+	}
+
+	// smartctl is missing or could not run; NVMe drives can still be asked
+	// for their health log directly
+	if d, nativeErr := readNative(device); nativeErr == nil {
+		return d, nil
+	}
+
+	if err == nil {
+		err = errors.New("no output")
+	}
+	return nil, fmt.Errorf("smartctl failed for %s: %w", device, err)
+}
+
+// ScanDevice is one entry of smartctl --scan-open -j output
+type ScanDevice struct {
+	Name     string `json:"name"`
+	InfoName string `json:"info_name"`
+	Type     string `json:"type"`
+	Protocol string `json:"protocol"`
+}
+
+// Scan lists the devices smartctl can open, via the helper when one is
+// running. Without smartctl it lists the NVMe drives that can be read
+// directly.
+func Scan() ([]ScanDevice, error) {
+	var output []byte
+	if client := helper.NewClient(""); client.Available() {
+		if text, err := client.SMARTScan(); err == nil {
+			output = []byte(text)
+		}
+	}
+	if output == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), readTimeout)
+		defer cancel()
+		var err error
+		output, err = safeexec.CommandContext(ctx, "smartctl", "--scan-open", "-j").Output()
+		if err != nil && len(output) == 0 {
+			return nil, fmt.Errorf("smartctl --scan-open failed: %w", err)
+		}
+	}
+	return ParseScan(output)
+}
+
+// ParseScan parses the JSON output of smartctl --scan-open -j
+func ParseScan(data []byte) ([]ScanDevice, error) {
+	var scan struct {
+		Devices []ScanDevice `json:"devices"`
+	}
+	if err := json.Unmarshal(data, &scan); err != nil {
+		return nil, fmt.Errorf("failed to parse smartctl scan: %w", err)
+	}
+	return scan.Devices, nil
+}
@@ -0,0 +1,165 @@
+package smart
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+func TestParseATA(t *testing.T) {
+	output := `=== START OF INFORMATION SECTION ===
+Device Model:     Samsung SSD 870 EVO 1TB
+Serial Number:    S6PUNX0R123456
+Firmware Version: SVT02B6Q
+
+SMART overall-health self-assessment test result: PASSED
+
+ID# ATTRIBUTE_NAME          FLAG     VALUE WORST THRESH TYPE      UPDATED  WHEN_FAILED RAW_VALUE
+  5 Reallocated_Sector_Ct   0x0033   100   100   010    Pre-fail  Always       -       8
+  9 Power_On_Hours          0x0032   097   097   000    Old_age   Always       -       12000
+ 12 Power_Cycle_Count       0x0032   099   099   000    Old_age   Always       -       420
+177 Wear_Leveling_Count     0x0013   098   098   000    Pre-fail  Always       -       2
+194 Temperature_Celsius     0x0022   064   052   000    Old_age   Always       -       36 (Min/Max 20/45)
+197 Current_Pending_Sector  0x0012   100   100   000    Old_age   Always       -       1
+241 Total_LBAs_Written      0x0032   099   099   000    Old_age   Always       -       2097152
+`
+	d := Parse(output)
+	if !d.Available || d.HealthStatus != HealthGood || d.Temperature != 36 || d.Protocol != ProtocolATA ||
+		d.PowerOnHours != 12000 || d.PowerCycles != 420 || d.WearLevel != 98 || d.TotalWrittenGB != 1 {
+		t.Errorf("unexpected ATA SMART data %+v", d)
+	}
+	if d.Model != "Samsung SSD 870 EVO 1TB" || d.Serial != "S6PUNX0R123456" || d.Firmware != "SVT02B6Q" {
+		t.Errorf("unexpected identity %+v", d)
+	}
+	if d.ReallocatedSectors != 8 || d.PendingSectors != 1 || len(d.Attributes) != 7 {
+		t.Errorf("expected 8 reallocated and 1 pending sector in 7 attributes, got %+v", d)
+	}
+	if a, ok := d.Attribute(194); !ok || a.RawString != "36 (Min/Max 20/45)" || a.Worst != 52 {
+		t.Errorf("unexpected temperature attribute %+v", a)
+	}
+
+	// An attribute at its threshold lowers the health
+	failing := strings.Replace(output, "0x0033   100   100   010", "0x0033   010   010   010", 1)
+	if d := Parse(failing); d.HealthStatus != HealthWarning {
+		t.Errorf("expected a failing attribute to lower the health to Warning, got %s", d.HealthStatus)
+	}
+}
+
+func TestParseNVMe(t *testing.T) {
+	output := `SMART overall-health self-assessment test result: PASSED
+
+=== START OF SMART DATA SECTION ===
+SMART/Health Information (NVMe Log 0x02)
+Critical Warning:                   0x00
+Temperature:                        41 Celsius
+Available Spare:                    100%
+Percentage Used:                    3%
+Data Units Read:                    2,097,152 [1.07 TB]
+Data Units Written:                 4,194,304 [2.14 TB]
+Power Cycles:                       1,234
+Power On Hours:                     5,678
+Unsafe Shutdowns:                   12
+Media and Data Integrity Errors:    2
+Error Information Log Entries:      7
+Warning  Comp. Temperature Time:    0
+`
+	d := Parse(output)
+	if !d.Available || d.HealthStatus != HealthGood || d.Temperature != 41 || d.WearLevel != 3 || d.Protocol != ProtocolNVMe ||
+		d.PowerCycles != 1234 || d.PowerOnHours != 5678 || d.TotalWrittenGB != 2000 || d.TotalReadGB != 1000 {
+		t.Errorf("unexpected NVMe SMART data %+v", d)
+	}
+	if d.MediaErrors != 2 || d.ErrorLogEntries != 7 || d.UnsafeShutdowns != 12 || d.AvailableSpare != 100 {
+		t.Errorf("unexpected NVMe error counters %+v", d)
+	}
+
+	d = Parse(strings.Replace(output, "0x00", "0x04", 1))
+	if d.HealthStatus != HealthWarning {
+		t.Errorf("expected a critical warning to lower the health to Warning, got %s", d.HealthStatus)
+	}
+}
+
+func TestParseUnavailable(t *testing.T) {
+	if d := Parse("Smartctl open device: /dev/sda failed: Permission denied"); d.Available {
+		t.Errorf("expected no SMART data, got %+v", d)
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	ata := `{
+  "device": {"name": "/dev/sda", "protocol": "ATA"},
+  "model_name": "WDC WD40EFRX", "serial_number": "WD-1234", "firmware_version": "82.00A82",
+  "smart_status": {"passed": true},
+  "ata_smart_attributes": {"table": [
+    {"id": 5, "name": "Reallocated_Sector_Ct", "value": 200, "worst": 200, "thresh": 140, "when_failed": "", "raw": {"value": 3, "string": "3"}},
+    {"id": 194, "name": "Temperature_Celsius", "value": 114, "worst": 100, "thresh": 0, "when_failed": "", "raw": {"value": 188978561057, "string": "33 (Min/Max 21/44)"}},
+    {"id": 198, "name": "Offline_Uncorrectable", "value": 200, "worst": 200, "thresh": 0, "when_failed": "", "raw": {"value": 1, "string": "1"}}
+  ]},
+  "power_on_time": {"hours": 30000},
+  "power_cycle_count": 88,
+  "temperature": {"current": 33}
+}`
+	d, err := ParseJSON([]byte(ata))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Available || d.Device != "/dev/sda" || d.Model != "WDC WD40EFRX" || d.HealthStatus != HealthGood ||
+		d.Temperature != 33 || d.ReallocatedSectors != 3 || d.Uncorrectable != 1 || d.PowerOnHours != 30000 || d.PowerCycles != 88 {
+		t.Errorf("unexpected ATA SMART data %+v", d)
+	}
+
+	nvme := `{
+  "device": {"name": "/dev/nvme0", "protocol": "NVMe"},
+  "model_name": "Samsung SSD 980 PRO 1TB", "serial_number": "S5GX", "firmware_version": "5B2QGXA7",
+  "smart_status": {"passed": false},
+  "nvme_smart_health_information_log": {
+    "critical_warning": 0, "temperature": 44, "available_spare": 90, "percentage_used": 12,
+    "data_units_read": 2097152, "data_units_written": 4194304, "power_cycles": 50, "power_on_hours": 900,
+    "unsafe_shutdowns": 4, "media_errors": 6, "num_err_log_entries": 9
+  }
+}`
+	d, err = ParseJSON([]byte(nvme))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Available || d.HealthStatus != HealthCritical || d.Temperature != 44 || d.WearLevel != 12 || d.AvailableSpare != 90 ||
+		d.MediaErrors != 6 || d.ErrorLogEntries != 9 || d.UnsafeShutdowns != 4 || d.TotalWrittenGB != 2000 {
+		t.Errorf("unexpected NVMe SMART data %+v", d)
+	}
+
+	if _, err := ParseJSON([]byte("smartctl: unrecognized option '-j'")); err == nil {
+		t.Error("expected text output to be rejected")
+	}
+}
+
+func TestParseNVMeHealthLog(t *testing.T) {
+	page := make([]byte, nvmeHealthLogSize)
+	page[0] = 0x04                                  // Reliability degraded
+	binary.LittleEndian.PutUint16(page[1:], 273+38) // Kelvin
+	page[3] = 95
+	page[5] = 7
+	binary.LittleEndian.PutUint64(page[48:], 2097152)
+	binary.LittleEndian.PutUint64(page[128:], 4321)
+	binary.LittleEndian.PutUint64(page[160:], 3)
+
+	d, err := ParseNVMeHealthLog(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Temperature != 38 || d.AvailableSpare != 95 || d.WearLevel != 7 || d.TotalWrittenGB != 1000 ||
+		d.PowerOnHours != 4321 || d.MediaErrors != 3 || d.HealthStatus != HealthWarning {
+		t.Errorf("unexpected NVMe health log %+v", d)
+	}
+	if _, err := ParseNVMeHealthLog(page[:64]); err == nil {
+		t.Error("expected a short log page to be rejected")
+	}
+}
+
+func TestParseScan(t *testing.T) {
+	devices, err := ParseScan([]byte(`{"devices":[{"name":"/dev/sda","info_name":"/dev/sda [SAT]","type":"sat","protocol":"ATA"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 1 || devices[0].Name != "/dev/sda" || devices[0].Type != "sat" {
+		t.Errorf("unexpected scan %+v", devices)
+	}
+}
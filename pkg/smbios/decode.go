@@ -0,0 +1,408 @@
+package smbios
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BIOS is the type 0 structure
+type BIOS struct {
+	Vendor      string `json:"vendor,omitempty"`
+	Version     string `json:"version,omitempty"`
+	ReleaseDate string `json:"release_date,omitempty"` // mm/dd/yyyy, as the firmware reports it
+	Release     string `json:"release,omitempty"`      // System BIOS major.minor release, SMBIOS 2.4+
+}
+
+// System is the type 1 structure
+type System struct {
+	Manufacturer string `json:"manufacturer,omitempty"`
+	Product      string `json:"product,omitempty"`
+	Version      string `json:"version,omitempty"`
+	SerialNumber string `json:"serial_number,omitempty"`
+	UUID         string `json:"uuid,omitempty"`
+	SKU          string `json:"sku,omitempty"`
+	Family       string `json:"family,omitempty"`
+}
+
+// Baseboard is the type 2 structure
+type Baseboard struct {
+	Manufacturer string `json:"manufacturer,omitempty"`
+	Product      string `json:"product,omitempty"`
+	Version      string `json:"version,omitempty"`
+	SerialNumber string `json:"serial_number,omitempty"`
+	AssetTag     string `json:"asset_tag,omitempty"`
+	Location     string `json:"location,omitempty"` // Location in the chassis
+}
+
+// Chassis is the type 3 structure
+type Chassis struct {
+	Manufacturer string `json:"manufacturer,omitempty"`
+	Type         string `json:"type,omitempty"` // e.g. "Desktop" or "Rack Mount Chassis"
+	Version      string `json:"version,omitempty"`
+	SerialNumber string `json:"serial_number,omitempty"`
+	AssetTag     string `json:"asset_tag,omitempty"`
+}
+
+// Port is a type 8 port connector
+type Port struct {
+	Internal      string `json:"internal,omitempty"` // Internal reference designator, e.g. "USB3_4"
+	External      string `json:"external,omitempty"` // External reference designator, e.g. "Rear USB"
+	Type          string `json:"type"`               // e.g. "USB" or "SATA"
+	ConnectorType uint8  `json:"connector_type"`     // External connector, or the internal one when there is none
+}
+
+// Name returns the most descriptive designator of the port
+func (p Port) Name() string {
+	if p.External != "" {
+		return p.External
+	}
+	return p.Internal
+}
+
+// Slot is a type 9 system slot
+type Slot struct {
+	Designation string `json:"designation"`              // e.g. "PCIEX16_1" or "M2_1"
+	Type        string `json:"type"`                     // e.g. "PCI Express Gen 4 x16"
+	Width       string `json:"width,omitempty"`          // Lanes wired to the slot
+	Physical    string `json:"physical_width,omitempty"` // Connector size, SMBIOS 3.4+
+	Usage       string `json:"usage,omitempty"`          // "Available", "In Use", ...
+	PCIe        bool   `json:"pcie"`
+	M2          bool   `json:"m2"`
+	Address     string `json:"address,omitempty"` // Segment:bus:device.function, SMBIOS 2.6+
+}
+
+// InUse reports whether a card occupies the slot
+func (s Slot) InUse() bool {
+	return s.Usage == "In Use"
+}
+
+// MemoryArray is a type 16 physical memory array
+type MemoryArray struct {
+	Location    string `json:"location,omitempty"`
+	Use         string `json:"use,omitempty"`
+	MaxCapacity uint64 `json:"max_capacity"` // Bytes
+	Devices     int    `json:"devices"`      // Memory slots in the array
+}
+
+// Info is the inventory decoded from a table
+type Info struct {
+	Version      string        `json:"version"`
+	BIOS         BIOS          `json:"bios"`
+	System       System        `json:"system"`
+	Baseboard    Baseboard     `json:"baseboard"`
+	Chassis      Chassis       `json:"chassis"`
+	Ports        []Port        `json:"ports,omitempty"`
+	Slots        []Slot        `json:"slots,omitempty"`
+	MemoryArrays []MemoryArray `json:"memory_arrays,omitempty"`
+}
+
+// Decode returns the inventory the table describes. Only the first BIOS,
+// system, baseboard and chassis structure is used.
+func (t *Table) Decode() *Info {
+	info := &Info{Version: t.Version()}
+	for _, s := range t.Structures {
+		switch s.Type {
+		case TypeBIOS:
+			if info.BIOS == (BIOS{}) {
+				info.BIOS = decodeBIOS(s)
+			}
+		case TypeSystem:
+			if info.System == (System{}) {
+				info.System = decodeSystem(s)
+			}
+		case TypeBaseboard:
+			if info.Baseboard == (Baseboard{}) {
+				info.Baseboard = decodeBaseboard(s)
+			}
+		case TypeChassis:
+			if info.Chassis == (Chassis{}) {
+				info.Chassis = decodeChassis(s)
+			}
+		case TypePortConnector:
+			info.Ports = append(info.Ports, decodePort(s))
+		case TypeSystemSlots:
+			info.Slots = append(info.Slots, t.decodeSlot(s))
+		case TypePhysicalMemoryArray:
+			info.MemoryArrays = append(info.MemoryArrays, decodeMemoryArray(s))
+		}
+	}
+	return info
+}
+
+// MemorySlots returns the number of slots in the system memory arrays
+func (i *Info) MemorySlots() int {
+	slots := 0
+	for _, a := range i.MemoryArrays {
+		if a.Use == "System Memory" {
+			slots += a.Devices
+		}
+	}
+	return slots
+}
+
+// MaxMemory returns the combined capacity of the system memory arrays in bytes
+func (i *Info) MaxMemory() uint64 {
+	var total uint64
+	for _, a := range i.MemoryArrays {
+		if a.Use == "System Memory" {
+			total += a.MaxCapacity
+		}
+	}
+	return total
+}
+
+func decodeBIOS(s Structure) BIOS {
+	b := BIOS{
+		Vendor:      s.String(0x04),
+		Version:     s.String(0x05),
+		ReleaseDate: s.String(0x08),
+	}
+	// 0xFF in both release bytes means the field is not supported
+	if len(s.Formatted) > 0x15 && s.Byte(0x14) != 0xff {
+		b.Release = fmt.Sprintf("%d.%d", s.Byte(0x14), s.Byte(0x15))
+	}
+	return b
+}
+
+func decodeSystem(s Structure) System {
+	return System{
+		Manufacturer: s.String(0x04),
+		Product:      s.String(0x05),
+		Version:      s.String(0x06),
+		SerialNumber: s.String(0x07),
+		UUID:         decodeUUID(s),
+		SKU:          s.String(0x19),
+		Family:       s.String(0x1a),
+	}
+}
+
+// decodeUUID formats the system UUID. The first three fields are stored
+// little-endian; all zeros and all ones mean the UUID is not set.
+func decodeUUID(s Structure) string {
+	if len(s.Formatted) < 0x18 {
+		return ""
+	}
+	u := s.Formatted[0x08:0x18]
+	zeros, ones := true, true
+	for _, b := range u {
+		zeros = zeros && b == 0x00
+		ones = ones && b == 0xff
+	}
+	if zeros || ones {
+		return ""
+	}
+	return fmt.Sprintf("%02X%02X%02X%02X-%02X%02X-%02X%02X-%02X%02X-%02X%02X%02X%02X%02X%02X",
+		u[3], u[2], u[1], u[0], u[5], u[4], u[7], u[6], u[8], u[9], u[10], u[11], u[12], u[13], u[14], u[15])
+}
+
+func decodeBaseboard(s Structure) Baseboard {
+	return Baseboard{
+		Manufacturer: s.String(0x04),
+		Product:      s.String(0x05),
+		Version:      s.String(0x06),
+		SerialNumber: s.String(0x07),
+		AssetTag:     s.String(0x08),
+		Location:     s.String(0x0a),
+	}
+}
+
+func decodeChassis(s Structure) Chassis {
+	return Chassis{
+		Manufacturer: s.String(0x04),
+		Type:         lookup(chassisTypes, s.Byte(0x05)&0x7f), // Bit 7 flags a chassis lock
+		Version:      s.String(0x06),
+		SerialNumber: s.String(0x07),
+		AssetTag:     s.String(0x08),
+	}
+}
+
+func decodePort(s Structure) Port {
+	p := Port{
+		Internal:      s.String(0x04),
+		External:      s.String(0x06),
+		Type:          lookup(portTypes, s.Byte(0x08)),
+		ConnectorType: s.Byte(0x07),
+	}
+	if p.ConnectorType == 0 {
+		p.ConnectorType = s.Byte(0x05)
+	}
+	return p
+}
+
+func (t *Table) decodeSlot(s Structure) Slot {
+	typ := s.Byte(0x05)
+	slot := Slot{
+		Designation: s.String(0x04),
+		Type:        slotTypeName(typ),
+		Width:       lookup(slotWidths, s.Byte(0x06)),
+		Usage:       lookup(slotUsages, s.Byte(0x07)),
+		PCIe:        isPCIeSlot(typ),
+		M2:          typ >= 0x14 && typ <= 0x17,
+	}
+	// The connector size follows the peer groups, five bytes each
+	if t.atLeast(3, 4) {
+		slot.Physical = lookup(slotWidths, s.Byte(0x14+5*int(s.Byte(0x12))))
+	}
+	// Segment, bus and device/function arrived in 2.6; 0xFF means unknown
+	if t.atLeast(2, 6) && len(s.Formatted) >= 0x11 && s.Byte(0x0f) != 0xff {
+		devfn := s.Byte(0x10)
+		slot.Address = fmt.Sprintf("%04x:%02x:%02x.%d", s.Word(0x0d), s.Byte(0x0f), devfn>>3, devfn&0x07)
+	}
+	return slot
+}
+
+func decodeMemoryArray(s Structure) MemoryArray {
+	a := MemoryArray{
+		Location: lookup(arrayLocations, s.Byte(0x04)),
+		Use:      lookup(arrayUses, s.Byte(0x05)),
+		Devices:  int(s.Word(0x0d)),
+	}
+	// The capacity is in KiB; 0x80000000 defers to the 2.7 extended field
+	// in bytes
+	if kib := s.DWord(0x07); kib == 0x80000000 {
+		a.MaxCapacity = s.QWord(0x0f)
+	} else {
+		a.MaxCapacity = uint64(kib) * 1024
+	}
+	return a
+}
+
+// isPCIeSlot reports whether a slot type is a PCI Express expansion slot.
+// U.2, mini card and M.2 sockets carry PCIe lanes too but are not counted.
+func isPCIeSlot(typ uint8) bool {
+	return typ >= 0xa5 && typ <= 0xc4
+}
+
+// slotTypeName names a slot type, spelling out the PCI Express generation
+// and width the code encodes
+func slotTypeName(typ uint8) string {
+	if name, ok := slotTypes[typ]; ok {
+		return name
+	}
+	// PCI Express: a generation base code followed by x1 to x16 variants
+	generations := []struct {
+		base uint8
+		name string
+	}{
+		{0xa5, "PCI Express"},
+		{0xab, "PCI Express Gen 2"},
+		{0xb1, "PCI Express Gen 3"},
+		{0xb8, "PCI Express Gen 4"},
+		{0xbe, "PCI Express Gen 5"},
+	}
+	widths := []string{"x1", "x2", "x4", "x8", "x16"}
+	for _, g := range generations {
+		if typ == g.base {
+			return g.name
+		}
+		if typ > g.base && int(typ-g.base) <= len(widths) {
+			return g.name + " " + widths[typ-g.base-1]
+		}
+	}
+	if typ == 0xc4 {
+		return "PCI Express Gen 6+"
+	}
+	return fmt.Sprintf("Unknown (%#02x)", typ)
+}
+
+// lookup names an enumerated value, falling back to its number
+func lookup(names map[uint8]string, value uint8) string {
+	if name, ok := names[value]; ok {
+		return name
+	}
+	if value == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Unknown (%#02x)", value)
+}
+
+// USBPortCounts groups the USB port connectors by connector, e.g.
+// {"Type-A": 6, "Type-C": 2}
+func (i *Info) USBPortCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, p := range i.Ports {
+		if p.Type != "USB" {
+			continue
+		}
+		if p.ConnectorType == connectorUSBTypeC {
+			counts["Type-C"]++
+		} else {
+			counts["Type-A"]++
+		}
+	}
+	return counts
+}
+
+// CountPorts returns the number of port connectors of a type, e.g. "SATA"
+func (i *Info) CountPorts(typ string) int {
+	n := 0
+	for _, p := range i.Ports {
+		if strings.EqualFold(p.Type, typ) {
+			n++
+		}
+	}
+	return n
+}
+
+// connectorUSBTypeC is the type 8 connector code of a USB Type-C receptacle
+const connectorUSBTypeC = 0x23
+
+var chassisTypes = map[uint8]string{
+	0x01: "Other", 0x02: "Unknown", 0x03: "Desktop", 0x04: "Low Profile Desktop",
+	0x05: "Pizza Box", 0x06: "Mini Tower", 0x07: "Tower", 0x08: "Portable",
+	0x09: "Laptop", 0x0a: "Notebook", 0x0b: "Hand Held", 0x0c: "Docking Station",
+	0x0d: "All in One", 0x0e: "Sub Notebook", 0x0f: "Space-saving", 0x10: "Lunch Box",
+	0x11: "Main Server Chassis", 0x12: "Expansion Chassis", 0x13: "SubChassis",
+	0x14: "Bus Expansion Chassis", 0x15: "Peripheral Chassis", 0x16: "RAID Chassis",
+	0x17: "Rack Mount Chassis", 0x18: "Sealed-case PC", 0x19: "Multi-system Chassis",
+	0x1a: "Compact PCI", 0x1b: "Advanced TCA", 0x1c: "Blade", 0x1d: "Blade Enclosure",
+	0x1e: "Tablet", 0x1f: "Convertible", 0x20: "Detachable", 0x21: "IoT Gateway",
+	0x22: "Embedded PC", 0x23: "Mini PC", 0x24: "Stick PC",
+}
+
+var portTypes = map[uint8]string{
+	0x01: "Parallel Port XT/AT", 0x02: "Parallel Port PS/2", 0x03: "Parallel Port ECP",
+	0x04: "Parallel Port EPP", 0x05: "Parallel Port ECP/EPP", 0x06: "Serial Port XT/AT",
+	0x07: "Serial Port 16450", 0x08: "Serial Port 16550", 0x09: "Serial Port 16550A",
+	0x0a: "SCSI", 0x0b: "MIDI", 0x0c: "Joystick", 0x0d: "Keyboard", 0x0e: "Mouse",
+	0x0f: "SSA SCSI", 0x10: "USB", 0x11: "FireWire", 0x12: "PCMCIA Type I",
+	0x13: "PCMCIA Type II", 0x14: "PCMCIA Type III", 0x15: "Cardbus", 0x16: "Access Bus",
+	0x17: "SCSI II", 0x18: "SCSI Wide", 0x1c: "Video", 0x1d: "Audio", 0x1e: "Modem",
+	0x1f: "Network", 0x20: "SATA", 0x21: "SAS", 0x22: "MFDP", 0x23: "Thunderbolt",
+	0xff: "Other",
+}
+
+var slotTypes = map[uint8]string{
+	0x01: "Other", 0x02: "Unknown", 0x03: "ISA", 0x04: "MCA", 0x05: "EISA", 0x06: "PCI",
+	0x07: "PC Card", 0x08: "VL-VESA", 0x09: "Proprietary", 0x0a: "Processor Card",
+	0x0b: "Proprietary Memory Card", 0x0c: "I/O Riser Card", 0x0d: "NuBus",
+	0x0e: "PCI 66MHz", 0x0f: "AGP", 0x10: "AGP 2X", 0x11: "AGP 4X", 0x12: "PCI-X",
+	0x13: "AGP 8X", 0x14: "M.2 Socket 1-DP (Key A)", 0x15: "M.2 Socket 1-SD (Key E)",
+	0x16: "M.2 Socket 2 (Key B)", 0x17: "M.2 Socket 3 (Key M)",
+	0x1e: "U.2 PCI Express Gen 2", 0x1f: "U.2 PCI Express Gen 3",
+	0x20: "PCI Express Mini 52-pin", 0x21: "PCI Express Mini 52-pin", 0x22: "PCI Express Mini 76-pin",
+	0x23: "U.2 PCI Express Gen 4", 0x24: "U.2 PCI Express Gen 5",
+	0x25: "OCP NIC 3.0 SFF", 0x26: "OCP NIC 3.0 LFF", 0x27: "OCP NIC", 0x30: "CXL Flexbus 1.0",
+	0xc5: "EDSFF E1", 0xc6: "EDSFF E3",
+}
+
+var slotWidths = map[uint8]string{
+	0x01: "Other", 0x02: "Unknown", 0x03: "8 bit", 0x04: "16 bit", 0x05: "32 bit",
+	0x06: "64 bit", 0x07: "128 bit", 0x08: "x1", 0x09: "x2", 0x0a: "x4", 0x0b: "x8",
+	0x0c: "x12", 0x0d: "x16", 0x0e: "x32",
+}
+
+var slotUsages = map[uint8]string{
+	0x01: "Other", 0x02: "Unknown", 0x03: "Available", 0x04: "In Use", 0x05: "Unavailable",
+}
+
+var arrayLocations = map[uint8]string{
+	0x01: "Other", 0x02: "Unknown", 0x03: "System Board", 0x04: "ISA Add-on Card",
+	0x05: "EISA Add-on Card", 0x06: "PCI Add-on Card", 0x07: "MCA Add-on Card",
+	0x08: "PCMCIA Add-on Card", 0x09: "Proprietary Add-on Card", 0x0a: "NuBus",
+}
+
+var arrayUses = map[uint8]string{
+	0x01: "Other", 0x02: "Unknown", 0x03: "System Memory", 0x04: "Video Memory",
+	0x05: "Flash Memory", 0x06: "Non-volatile RAM", 0x07: "Cache Memory",
+}
@@ -0,0 +1,43 @@
+//go:build linux
+// +build linux
+
+package smbios
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// sysfsRoot is the root the DMI tables are read under, replaced in tests
+var sysfsRoot = "/"
+
+// Read parses the tables the kernel exports in /sys/firmware/dmi/tables.
+// Both files are readable by root only, so other users get ErrUnavailable.
+func Read() (*Table, error) {
+	dir := filepath.Join(sysfsRoot, "sys/firmware/dmi/tables")
+	ep, err := os.ReadFile(filepath.Join(dir, "smbios_entry_point")) // #nosec G304 -- fixed sysfs path
+	if err != nil {
+		return nil, unavailable(err)
+	}
+	major, minor, err := parseEntryPoint(ep)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "DMI")) // #nosec G304 -- fixed sysfs path
+	if err != nil {
+		return nil, unavailable(err)
+	}
+	return Parse(major, minor, data)
+}
+
+// unavailable wraps a read error in ErrUnavailable when the tables are
+// missing or need root
+func unavailable(err error) error {
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return err
+}
@@ -0,0 +1,39 @@
+package smbios
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadLinux(t *testing.T) {
+	root := t.TempDir()
+	old := sysfsRoot
+	sysfsRoot = root
+	t.Cleanup(func() { sysfsRoot = old })
+
+	if _, err := Read(); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected ErrUnavailable without the tables, got %v", err)
+	}
+
+	dir := filepath.Join(root, "sys/firmware/dmi/tables")
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	ep := append([]byte("_SM3_"), 0x00, 0x18, 3, 6, 0)
+	if err := os.WriteFile(filepath.Join(dir, "smbios_entry_point"), ep, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "DMI"), testTable(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	table, err := Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Version() != "3.6" || len(table.Structures) != 13 {
+		t.Errorf("expected a 3.6 table with 13 structures, got %s with %d", table.Version(), len(table.Structures))
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package smbios
+
+// Read is not supported on this platform; macOS keeps SMBIOS data inside
+// IOKit rather than exposing the raw table
+func Read() (*Table, error) {
+	return nil, ErrUnavailable
+}
@@ -0,0 +1,52 @@
+//go:build windows
+// +build windows
+
+package smbios
+
+import (
+	"encoding/binary"
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	kernel32                   = windows.NewLazySystemDLL("kernel32.dll")
+	procGetSystemFirmwareTable = kernel32.NewProc("GetSystemFirmwareTable")
+)
+
+// firmwareRSMB is the 'RSMB' provider signature of the raw SMBIOS table
+const firmwareRSMB = 'R'<<24 | 'S'<<16 | 'M'<<8 | 'B'
+
+// rawHeaderLen is the size of the RawSMBIOSData header before the table:
+// calling method, major, minor and DMI revision bytes, then the length
+const rawHeaderLen = 8
+
+// Read parses the table returned by GetSystemFirmwareTable
+func Read() (*Table, error) {
+	if err := procGetSystemFirmwareTable.Find(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	// The first call, with no buffer, returns the size needed
+	size, _, err := procGetSystemFirmwareTable.Call(firmwareRSMB, 0, 0, 0)
+	if size == 0 {
+		return nil, fmt.Errorf("%w: GetSystemFirmwareTable failed: %v", ErrUnavailable, err)
+	}
+	buf := make([]byte, size)
+	n, _, err := procGetSystemFirmwareTable.Call(firmwareRSMB, 0, uintptr(unsafe.Pointer(&buf[0])), size) // #nosec G103 -- buffer passed to the Win32 API
+	if n == 0 || n > size {
+		return nil, fmt.Errorf("GetSystemFirmwareTable failed: %v", err)
+	}
+	buf = buf[:n]
+
+	if len(buf) < rawHeaderLen {
+		return nil, fmt.Errorf("raw SMBIOS data is too short (%d bytes)", len(buf))
+	}
+	length := int(binary.LittleEndian.Uint32(buf[4:8]))
+	if length > len(buf)-rawHeaderLen {
+		length = len(buf) - rawHeaderLen
+	}
+	return Parse(int(buf[1]), int(buf[2]), buf[rawHeaderLen:rawHeaderLen+length])
+}
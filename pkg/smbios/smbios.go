@@ -0,0 +1,192 @@
+// Package smbios parses the raw SMBIOS (DMI) tables the firmware publishes,
+// so inventory comes from one source on every platform instead of piecemeal
+// WMI queries and sysfs files.
+//
+// Parse splits a table into its structures, of any type; the decoders in
+// decode.go turn the ones the inventory uses (BIOS, system, baseboard,
+// chassis, port connectors, system slots and memory arrays) into Go values.
+// Read fetches the table from GetSystemFirmwareTable on Windows and
+// /sys/firmware/dmi/tables on Linux.
+package smbios
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Structure types decoded by this package
+const (
+	TypeBIOS                uint8 = 0
+	TypeSystem              uint8 = 1
+	TypeBaseboard           uint8 = 2
+	TypeChassis             uint8 = 3
+	TypePortConnector       uint8 = 8
+	TypeSystemSlots         uint8 = 9
+	TypePhysicalMemoryArray uint8 = 16
+	TypeMemoryDevice        uint8 = 17
+	TypeEndOfTable          uint8 = 127
+)
+
+// headerLen is the size of the type, length and handle fields every
+// structure starts with
+const headerLen = 4
+
+// ErrUnavailable is returned by Read when the platform does not expose the
+// tables, or the process lacks the rights to read them
+var ErrUnavailable = errors.New("SMBIOS tables are not available")
+
+// Structure is one entry of the table: the formatted area, header included,
+// and the strings that follow it
+type Structure struct {
+	Type      uint8
+	Handle    uint16
+	Formatted []byte
+	Strings   []string
+}
+
+// Table is a parsed SMBIOS table
+type Table struct {
+	Major, Minor int
+	Structures   []Structure
+}
+
+// Parse splits raw table data into structures, stopping at the end-of-table
+// marker. A structure cut short by the end of the data is an error; the ones
+// before it are still returned.
+func Parse(major, minor int, data []byte) (*Table, error) {
+	t := &Table{Major: major, Minor: minor}
+	for len(data) > 0 {
+		if len(data) < headerLen {
+			return t, fmt.Errorf("truncated structure header (%d bytes left)", len(data))
+		}
+		length := int(data[1])
+		if length < headerLen || length > len(data) {
+			return t, fmt.Errorf("structure type %d has an invalid length %d", data[0], length)
+		}
+		s := Structure{
+			Type:      data[0],
+			Handle:    binary.LittleEndian.Uint16(data[2:4]),
+			Formatted: data[:length],
+		}
+
+		// The string set ends with a double NUL, which is all there is
+		// when the structure has no strings
+		rest := data[length:]
+		end := bytes.Index(rest, []byte{0, 0})
+		if end < 0 {
+			return t, fmt.Errorf("structure type %d at handle %#04x has no string terminator", s.Type, s.Handle)
+		}
+		if end > 0 {
+			s.Strings = strings.Split(string(rest[:end]), "\x00")
+		}
+		t.Structures = append(t.Structures, s)
+		data = rest[end+2:]
+
+		if s.Type == TypeEndOfTable {
+			break
+		}
+	}
+	return t, nil
+}
+
+// Version returns the SMBIOS version, e.g. "3.3"
+func (t *Table) Version() string {
+	return fmt.Sprintf("%d.%d", t.Major, t.Minor)
+}
+
+// atLeast reports whether the table is at least the given version
+func (t *Table) atLeast(major, minor int) bool {
+	return t.Major > major || (t.Major == major && t.Minor >= minor)
+}
+
+// ByType returns the structures of one type, in table order
+func (t *Table) ByType(typ uint8) []Structure {
+	var found []Structure
+	for _, s := range t.Structures {
+		if s.Type == typ {
+			found = append(found, s)
+		}
+	}
+	return found
+}
+
+// Byte returns the formatted byte at offset, or 0 when the structure is too
+// short to have it
+func (s Structure) Byte(offset int) uint8 {
+	if offset >= len(s.Formatted) {
+		return 0
+	}
+	return s.Formatted[offset]
+}
+
+// Word returns the little-endian 16-bit field at offset, or 0
+func (s Structure) Word(offset int) uint16 {
+	if offset+2 > len(s.Formatted) {
+		return 0
+	}
+	return binary.LittleEndian.Uint16(s.Formatted[offset:])
+}
+
+// DWord returns the little-endian 32-bit field at offset, or 0
+func (s Structure) DWord(offset int) uint32 {
+	if offset+4 > len(s.Formatted) {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(s.Formatted[offset:])
+}
+
+// QWord returns the little-endian 64-bit field at offset, or 0
+func (s Structure) QWord(offset int) uint64 {
+	if offset+8 > len(s.Formatted) {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(s.Formatted[offset:])
+}
+
+// String returns the string the byte at offset refers to. Index 0, a
+// missing string and the placeholders firmware leaves in unfilled fields
+// all give "".
+func (s Structure) String(offset int) string {
+	idx := int(s.Byte(offset))
+	if idx == 0 || idx > len(s.Strings) {
+		return ""
+	}
+	value := strings.TrimSpace(s.Strings[idx-1])
+	if placeholders[strings.ToLower(value)] {
+		return ""
+	}
+	return value
+}
+
+// placeholders are the values firmware vendors leave in fields the board
+// maker never filled in
+var placeholders = map[string]bool{
+	"default string":         true,
+	"to be filled by o.e.m.": true,
+	"not specified":          true,
+	"not applicable":         true,
+	"not available":          true,
+	"system product name":    true,
+	"system manufacturer":    true,
+	"system version":         true,
+	"system serial number":   true,
+	"chassis serial number":  true,
+	"asset-1234567890":       true,
+	"0123456789":             true,
+}
+
+// parseEntryPoint reads the version from a 32-bit ("_SM_") or 64-bit
+// ("_SM3_") entry point structure
+func parseEntryPoint(ep []byte) (major, minor int, err error) {
+	switch {
+	case bytes.HasPrefix(ep, []byte("_SM3_")) && len(ep) >= 9:
+		return int(ep[7]), int(ep[8]), nil
+	case bytes.HasPrefix(ep, []byte("_SM_")) && len(ep) >= 8:
+		return int(ep[6]), int(ep[7]), nil
+	default:
+		return 0, 0, errors.New("unrecognised SMBIOS entry point")
+	}
+}
@@ -0,0 +1,161 @@
+package smbios
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// structure builds a raw structure from its formatted fields (after the
+// header) and strings
+func structure(typ uint8, handle uint16, fields []byte, strs ...string) []byte {
+	b := []byte{typ, byte(headerLen + len(fields)), 0, 0}
+	binary.LittleEndian.PutUint16(b[2:], handle)
+	b = append(b, fields...)
+	if len(strs) == 0 {
+		return append(b, 0, 0)
+	}
+	for _, s := range strs {
+		b = append(append(b, s...), 0)
+	}
+	return append(b, 0)
+}
+
+// testTable is a small desktop board: BIOS, system, baseboard and chassis,
+// two USB ports and two SATA ports, a used x16 slot wired for four lanes, a
+// free x1 slot, an M.2 socket and one memory array
+func testTable() []byte {
+	var data []byte
+	data = append(data, structure(TypeBIOS, 0x00, []byte{
+		1, 2, 0x00, 0xf0, 3, 0xff, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 5, 27, 0xff, 0xff,
+	}, "American Megatrends International, LLC.", "1820", "03/14/2024")...)
+
+	sys := make([]byte, 0x1b-headerLen)
+	sys[0], sys[1], sys[2], sys[3] = 1, 2, 3, 4
+	copy(sys[0x08-headerLen:], []byte{0x33, 0x22, 0x11, 0x00, 0x55, 0x44, 0x77, 0x66, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff})
+	sys[0x19-headerLen], sys[0x1a-headerLen] = 5, 6
+	data = append(data, structure(TypeSystem, 0x01, sys,
+		"ASUS", "System Product Name", "Rev 1.xx", "System Serial Number", "SKU", "To be filled by O.E.M.")...)
+
+	data = append(data, structure(TypeBaseboard, 0x02, []byte{1, 2, 3, 4, 5, 0x09, 6, 0x03, 0x00, 0x0a},
+		"ASUSTeK COMPUTER INC.", "ROG STRIX X670E-E GAMING WIFI", "Rev 1.xx", "230512345678", "IT-0042", "Default string")...)
+	data = append(data, structure(TypeChassis, 0x03, []byte{1, 0x87, 2, 3, 4},
+		"Fractal Design", "1.0", "Default string", "IT-0042-C")...)
+
+	data = append(data, structure(TypePortConnector, 0x10, []byte{1, 0x00, 2, 0x12, 0x10}, "USB1", "Rear USB 1")...)
+	data = append(data, structure(TypePortConnector, 0x11, []byte{1, 0x00, 2, 0x23, 0x10}, "USB_C1", "Rear USB-C")...)
+	data = append(data, structure(TypePortConnector, 0x12, []byte{1, 0x22, 0, 0x00, 0x20}, "SATA6G_1")...)
+	data = append(data, structure(TypePortConnector, 0x13, []byte{1, 0x22, 0, 0x00, 0x20}, "SATA6G_2")...)
+
+	data = append(data, structure(TypeSystemSlots, 0x20, []byte{1, 0xc3, 0x0a, 0x04, 0x04, 0, 0, 0x0c, 0x01, 0, 0, 0x01, 0x00, 0x0a, 0, 0, 0x0d}, "PCIEX16_1")...)
+	data = append(data, structure(TypeSystemSlots, 0x21, []byte{1, 0xb9, 0x08, 0x03, 0x03, 1, 0, 0x0c, 0x01, 0, 0, 0xff, 0xff}, "PCIEX1_1")...)
+	data = append(data, structure(TypeSystemSlots, 0x22, []byte{1, 0x17, 0x0a, 0x04, 0x03, 2, 0, 0x0c, 0x01, 0, 0, 0x02, 0x08}, "M.2_1")...)
+
+	array := []byte{0x03, 0x03, 0x03, 0x00, 0x00, 0x00, 0x80, 0xfe, 0xff, 0x04, 0x00, 0, 0, 0, 0, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint64(array[0x0f-headerLen:], 192<<30)
+	data = append(data, structure(TypePhysicalMemoryArray, 0x30, array)...)
+
+	data = append(data, structure(TypeEndOfTable, 0xfeff, nil)...)
+	return data
+}
+
+func TestParse(t *testing.T) {
+	table, err := Parse(3, 5, testTable())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table.Structures) != 13 {
+		t.Fatalf("expected 13 structures, got %d", len(table.Structures))
+	}
+	if slots := table.ByType(TypeSystemSlots); len(slots) != 3 || slots[0].Handle != 0x20 {
+		t.Errorf("expected three slots starting at handle 0x20, got %+v", slots)
+	}
+	if end := table.Structures[12]; end.Type != TypeEndOfTable || end.Strings != nil {
+		t.Errorf("expected an empty end-of-table structure, got %+v", end)
+	}
+
+	// Data after the end marker is ignored
+	if table, err := Parse(3, 5, append(testTable(), 0xde, 0xad)); err != nil || len(table.Structures) != 13 {
+		t.Errorf("expected trailing data to be ignored, got %v", err)
+	}
+
+	// Cutting into the memory array keeps the eleven structures before it
+	data := testTable()
+	table, err = Parse(3, 5, data[:len(data)-10])
+	if err == nil {
+		t.Error("expected an error for a truncated table")
+	}
+	if table == nil || len(table.Structures) != 11 {
+		t.Errorf("expected the intact structures to be kept")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	table, err := Parse(3, 5, testTable())
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := table.Decode()
+
+	if info.Version != "3.5" || info.BIOS.Version != "1820" || info.BIOS.ReleaseDate != "03/14/2024" || info.BIOS.Release != "5.27" {
+		t.Errorf("unexpected BIOS %+v (version %s)", info.BIOS, info.Version)
+	}
+	if info.System.Product != "" || info.System.SerialNumber != "" || info.System.Family != "" {
+		t.Errorf("expected placeholder strings to be dropped, got %+v", info.System)
+	}
+	if info.System.UUID != "00112233-4455-6677-8899-AABBCCDDEEFF" {
+		t.Errorf("unexpected UUID %s", info.System.UUID)
+	}
+	if info.Baseboard.Product != "ROG STRIX X670E-E GAMING WIFI" || info.Baseboard.AssetTag != "IT-0042" || info.Baseboard.Location != "" {
+		t.Errorf("unexpected baseboard %+v", info.Baseboard)
+	}
+	if info.Chassis.Type != "Tower" || info.Chassis.AssetTag != "IT-0042-C" || info.Chassis.SerialNumber != "" {
+		t.Errorf("unexpected chassis %+v", info.Chassis)
+	}
+
+	if len(info.Slots) != 3 {
+		t.Fatalf("expected 3 slots, got %d", len(info.Slots))
+	}
+	x16 := info.Slots[0]
+	if x16.Type != "PCI Express Gen 5 x16" || x16.Width != "x4" || x16.Physical != "x16" || !x16.InUse() || !x16.PCIe || x16.Address != "0000:01:00.0" {
+		t.Errorf("unexpected x16 slot %+v", x16)
+	}
+	if x1 := info.Slots[1]; x1.Type != "PCI Express Gen 4 x1" || x1.InUse() || x1.Address != "" {
+		t.Errorf("unexpected x1 slot %+v", x1)
+	}
+	if m2 := info.Slots[2]; !m2.M2 || m2.PCIe || m2.Address != "0000:02:01.0" {
+		t.Errorf("unexpected M.2 slot %+v", m2)
+	}
+
+	usb := info.USBPortCounts()
+	if usb["Type-A"] != 1 || usb["Type-C"] != 1 {
+		t.Errorf("expected one Type-A and one Type-C port, got %v", usb)
+	}
+	if n := info.CountPorts("SATA"); n != 2 {
+		t.Errorf("expected 2 SATA ports, got %d", n)
+	}
+	if info.Ports[0].Name() != "Rear USB 1" || info.Ports[2].Name() != "SATA6G_1" {
+		t.Errorf("unexpected port names %q and %q", info.Ports[0].Name(), info.Ports[2].Name())
+	}
+
+	if info.MemorySlots() != 4 || info.MaxMemory() != 192<<30 {
+		t.Errorf("expected 4 slots and 192 GiB, got %d and %d", info.MemorySlots(), info.MaxMemory())
+	}
+}
+
+func TestSlotTypeName(t *testing.T) {
+	tests := map[uint8]string{
+		0x06: "PCI",
+		0xa5: "PCI Express",
+		0xaa: "PCI Express x16",
+		0xab: "PCI Express Gen 2",
+		0xb4: "PCI Express Gen 3 x4",
+		0xb7: "Unknown (0xb7)",
+		0xbe: "PCI Express Gen 5",
+		0xc4: "PCI Express Gen 6+",
+	}
+	for typ, want := range tests {
+		if got := slotTypeName(typ); got != want {
+			t.Errorf("slot type %#02x: expected %q, got %q", typ, want, got)
+		}
+	}
+}
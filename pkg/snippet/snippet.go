@@ -0,0 +1,191 @@
+// Package snippet formats a system summary and benchmark results as Markdown
+// or BBCode, ready to paste into Reddit or an overclocking forum.
+package snippet
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/session"
+)
+
+// Format is a forum markup language
+type Format string
+
+// Supported formats
+const (
+	Markdown Format = "markdown"
+	BBCode   Format = "bbcode"
+)
+
+// ParseFormat returns the format named by s
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "markdown", "md":
+		return Markdown, nil
+	case "bbcode", "bb":
+		return BBCode, nil
+	}
+	return "", fmt.Errorf("unknown snippet format %q (use markdown or bbcode)", s)
+}
+
+// Row is one line of the spec table
+type Row struct {
+	Label string
+	Value string
+}
+
+// Snippet is the content of a forum post: a spec table followed by a score
+// table with one section per test
+type Snippet struct {
+	Title string
+	Specs []Row
+	Runs  []session.RunRecord
+}
+
+// FromSession builds a snippet from the hardware inventory of a session and
+// the latest successful run of each plugin in it. The hostname is left out,
+// since the snippet is meant for public posts.
+func FromSession(f *session.File) *Snippet {
+	s := &Snippet{Title: "F.I.R.E. System Summary"}
+	if platform := f.System["Platform"]; platform != "" {
+		s.Specs = append(s.Specs, Row{"OS", platform})
+	}
+	if env := f.System["Environment"]; env != "" {
+		s.Specs = append(s.Specs, Row{"Environment", env})
+	}
+	for _, comp := range f.Components {
+		s.Specs = append(s.Specs, Row{comp.Type, comp.Name})
+	}
+	s.Runs = LatestRuns(f.AllRuns())
+	return s
+}
+
+// LatestRuns keeps the newest successful run of each plugin, in the order
+// the plugins first appear
+func LatestRuns(runs []session.RunRecord) []session.RunRecord {
+	latest := make(map[string]int)
+	var kept []session.RunRecord
+	for _, record := range runs {
+		if record.Run == nil || !record.Run.Success || len(record.Results) == 0 {
+			continue
+		}
+		i, seen := latest[record.Run.Plugin]
+		switch {
+		case !seen:
+			latest[record.Run.Plugin] = len(kept)
+			kept = append(kept, record)
+		case record.Run.StartTime.After(kept[i].Run.StartTime):
+			kept[i] = record
+		}
+	}
+	return kept
+}
+
+// Render formats the snippet in the given format
+func (s *Snippet) Render(format Format) string {
+	if format == BBCode {
+		return s.BBCode()
+	}
+	return s.Markdown()
+}
+
+// Markdown formats the snippet as GitHub/Reddit flavoured Markdown tables
+func (s *Snippet) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s**\n\n", s.Title)
+
+	if len(s.Specs) > 0 {
+		b.WriteString("| Component | Spec |\n|:--|:--|\n")
+		for _, row := range s.Specs {
+			fmt.Fprintf(&b, "| %s | %s |\n", markdownCell(row.Label), markdownCell(row.Value))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(s.Runs) > 0 {
+		b.WriteString("| Test | Metric | Score |\n|:--|:--|--:|\n")
+		for _, record := range s.Runs {
+			for i, result := range record.Results {
+				test := ""
+				if i == 0 {
+					test = fmt.Sprintf("**%s** (%s)", markdownCell(record.Run.Plugin), record.Run.StartTime.Format("2006-01-02"))
+				}
+				fmt.Fprintf(&b, "| %s | %s | %s |\n", test, markdownCell(result.Metric), formatScore(result.Value, result.Unit))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("*Generated by F.I.R.E.*\n")
+	return b.String()
+}
+
+// BBCode formats the snippet as BBCode tables. Header cells are bold [td]
+// cells, since not every forum supports [th].
+func (s *Snippet) BBCode() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[b]%s[/b]\n\n", s.Title)
+
+	if len(s.Specs) > 0 {
+		b.WriteString("[table]\n[tr][td][b]Component[/b][/td][td][b]Spec[/b][/td][/tr]\n")
+		for _, row := range s.Specs {
+			fmt.Fprintf(&b, "[tr][td]%s[/td][td]%s[/td][/tr]\n", row.Label, row.Value)
+		}
+		b.WriteString("[/table]\n\n")
+	}
+
+	if len(s.Runs) > 0 {
+		b.WriteString("[table]\n[tr][td][b]Test[/b][/td][td][b]Metric[/b][/td][td][b]Score[/b][/td][/tr]\n")
+		for _, record := range s.Runs {
+			for i, result := range record.Results {
+				test := ""
+				if i == 0 {
+					test = fmt.Sprintf("[b]%s[/b] (%s)", record.Run.Plugin, record.Run.StartTime.Format("2006-01-02"))
+				}
+				fmt.Fprintf(&b, "[tr][td]%s[/td][td]%s[/td][td]%s[/td][/tr]\n", test, result.Metric, formatScore(result.Value, result.Unit))
+			}
+		}
+		b.WriteString("[/table]\n\n")
+	}
+
+	b.WriteString("[size=1]Generated by F.I.R.E.[/size]\n")
+	return b.String()
+}
+
+// markdownCell escapes characters that would break a table cell
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+// formatScore prints large values with thousands separators and small ones
+// with two decimals
+func formatScore(value float64, unit string) string {
+	var s string
+	if math.Abs(value) >= 1000 {
+		s = groupThousands(fmt.Sprintf("%.0f", value))
+	} else {
+		s = fmt.Sprintf("%.2f", value)
+	}
+	if unit != "" {
+		s += " " + unit
+	}
+	return s
+}
+
+// groupThousands inserts commas into an integer string
+func groupThousands(digits string) string {
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+	return sign + b.String()
+}
@@ -0,0 +1,72 @@
+package snippet
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/session"
+)
+
+func testSession() *session.File {
+	day := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	record := func(id int64, plugin string, start time.Time, success bool, value float64) session.RunRecord {
+		return session.RunRecord{
+			Run:     &db.Run{ID: id, Plugin: plugin, StartTime: start, Success: success},
+			Results: []*db.Result{{Metric: "score", Value: value, Unit: "ops/s"}},
+		}
+	}
+	f := session.NewSession()
+	f.System = map[string]string{"Hostname": "alice-pc", "Platform": "Windows 11"}
+	f.Components = []session.Component{{Type: "CPU", Name: "Ryzen 9 7950X"}, {Type: "GPU", Name: "RTX 4090 | OC"}}
+	f.Runs = []session.RunRecord{
+		record(3, "cpu", day.Add(2*time.Hour), false, 1),
+		record(2, "cpu", day.Add(time.Hour), true, 1234567),
+		record(1, "cpu", day, true, 1000),
+		record(4, "memory", day, true, 12.345),
+	}
+	return f
+}
+
+func TestLatestRuns(t *testing.T) {
+	runs := LatestRuns(testSession().Runs)
+	if len(runs) != 2 || runs[0].Run.ID != 2 || runs[1].Run.ID != 4 {
+		t.Errorf("expected the newest successful cpu run and the memory run, got %+v", runs)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	out := FromSession(testSession()).Markdown()
+	for _, want := range []string{
+		"| CPU | Ryzen 9 7950X |",
+		`| GPU | RTX 4090 \| OC |`,
+		"| **cpu** (2026-05-01) | score | 1,234,567 ops/s |",
+		"| **memory** (2026-05-01) | score | 12.35 ops/s |",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "alice-pc") {
+		t.Error("expected the hostname to be left out")
+	}
+}
+
+func TestBBCode(t *testing.T) {
+	out := FromSession(testSession()).Render(BBCode)
+	if !strings.Contains(out, "[tr][td]CPU[/td][td]Ryzen 9 7950X[/td][/tr]") ||
+		!strings.Contains(out, "[td]1,234,567 ops/s[/td]") ||
+		strings.Count(out, "[table]") != 2 || strings.Count(out, "[/table]") != 2 {
+		t.Errorf("unexpected BBCode:\n%s", out)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat("MD"); err != nil || f != Markdown {
+		t.Errorf("expected markdown, got %q %v", f, err)
+	}
+	if _, err := ParseFormat("html"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,36 @@
+package snippet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/virt"
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/host"
+	"github.com/shirou/gopsutil/v3/mem"
+)
+
+// SystemSpecs collects a spec table for this machine, for callers without
+// the dashboard's hardware inventory
+func SystemSpecs() []Row {
+	var rows []Row
+	if info, err := host.Info(); err == nil {
+		rows = append(rows, Row{"OS", strings.TrimSpace(info.Platform + " " + info.PlatformVersion)})
+	}
+	if env := virt.Detect(); env.Virtual() {
+		rows = append(rows, Row{"Environment", env.Label()})
+	}
+	if info, err := cpu.Info(); err == nil && len(info) > 0 {
+		model := strings.TrimSpace(info[0].ModelName)
+		physical, _ := cpu.Counts(false)
+		logical, _ := cpu.Counts(true)
+		if physical > 0 && logical > 0 {
+			model += fmt.Sprintf(" (%dC/%dT)", physical, logical)
+		}
+		rows = append(rows, Row{"CPU", model})
+	}
+	if vm, err := mem.VirtualMemory(); err == nil {
+		rows = append(rows, Row{"Memory", fmt.Sprintf("%.0f GB", float64(vm.Total)/(1<<30))})
+	}
+	return rows
+}
@@ -0,0 +1,110 @@
+package specsheet
+
+import (
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+// Measurement is a measured value and the run it came from
+type Measurement struct {
+	Value float64
+	RunID int64
+}
+
+// MeasureFunc finds the measured value of a plugin metric
+type MeasureFunc func(plugin, metric string) (Measurement, bool)
+
+// Row is the comparison of one spec with its measurement
+type Row struct {
+	Spec      Spec // Resolved from its preset
+	Measured  Measurement
+	Found     bool
+	Margin    float64 // Percent better (+) or worse (-) than advertised
+	Tolerance float64
+	Pass      bool
+}
+
+// Status describes the row for reports
+func (r Row) Status() string {
+	switch {
+	case !r.Found:
+		return "NOT MEASURED"
+	case r.Pass:
+		return "PASS"
+	default:
+		return "FAIL"
+	}
+}
+
+// Compare checks every spec in the sheet against the measurements. Specs
+// that fail to resolve are skipped; Validate reports them.
+func Compare(sheet *Sheet, measure MeasureFunc) []Row {
+	if sheet == nil {
+		return nil
+	}
+	rows := make([]Row, 0, len(sheet.Specs))
+	for _, spec := range sheet.Specs {
+		resolved, err := spec.resolve()
+		if err != nil {
+			continue
+		}
+		row := Row{Spec: resolved, Tolerance: sheet.tolerance(resolved)}
+		row.Measured, row.Found = measure(resolved.Plugin, resolved.Metric)
+		if row.Found {
+			row.Margin = margin(resolved, row.Measured.Value)
+			row.Pass = row.Margin >= -row.Tolerance
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// margin returns how far the measured value is above the advertised one, in
+// percent, with the sign flipped for lower-is-better specs
+func margin(spec Spec, measured float64) float64 {
+	m := (measured - spec.Expected) / spec.Expected * 100
+	if spec.LowerIsBetter {
+		return -m
+	}
+	return m
+}
+
+// runSearchLimit is how many recent runs of a plugin are searched for a metric
+const runSearchLimit = 20
+
+// FromDatabase measures specs from run and its results first, then from the
+// most recent successful run of the spec's plugin that recorded the metric
+func FromDatabase(database *db.DB, run *db.Run, results []*db.Result) MeasureFunc {
+	return func(plugin, metric string) (Measurement, bool) {
+		if run != nil && run.Plugin == plugin {
+			for _, r := range results {
+				if r.Metric == metric {
+					return Measurement{Value: r.Value, RunID: run.ID}, true
+				}
+			}
+		}
+		if database == nil {
+			return Measurement{}, false
+		}
+
+		success := true
+		runs, err := database.ListRuns(db.RunFilter{Plugin: plugin, Success: &success, Limit: runSearchLimit})
+		if err != nil {
+			return Measurement{}, false
+		}
+		for _, candidate := range runs {
+			if run != nil && candidate.StartTime.After(run.StartTime) {
+				continue // Newer than the reported run
+			}
+			results, err := database.GetResults(candidate.ID)
+			if err != nil {
+				continue
+			}
+			for _, r := range results {
+				if r.Metric == metric {
+					return Measurement{Value: r.Value, RunID: candidate.ID}, true
+				}
+			}
+		}
+		return Measurement{}, false
+	}
+}
@@ -0,0 +1,171 @@
+// Package specsheet compares measured results with the specifications a
+// build was sold with, such as the advertised boost clock, the rated memory
+// speed or the claimed SSD throughput.
+//
+// A sheet lists the advertised values. Common specs are named by preset key
+// (e.g. "memory_speed_mts"); anything else names the plugin and metric that
+// measures it. Each spec passes when the measured value is within its
+// tolerance of the advertised one.
+package specsheet
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DefaultTolerance is the shortfall, in percent, allowed when neither the
+// spec nor the sheet sets one
+const DefaultTolerance = 5.0
+
+// Spec is one advertised value
+type Spec struct {
+	Key      string  `json:"key,omitempty"` // Preset key; fills in the fields below
+	Name     string  `json:"name,omitempty"`
+	Plugin   string  `json:"plugin,omitempty"`
+	Metric   string  `json:"metric,omitempty"`
+	Expected float64 `json:"expected"`
+	Unit     string  `json:"unit,omitempty"`
+
+	// Tolerance is the allowed shortfall in percent; nil uses the sheet's
+	Tolerance *float64 `json:"tolerance_pct,omitempty"`
+	// LowerIsBetter marks specs such as latency, where exceeding the
+	// advertised value is the shortfall
+	LowerIsBetter bool `json:"lower_is_better,omitempty"`
+}
+
+// Sheet is the advertised specification of a build
+type Sheet struct {
+	Name      string   `json:"name,omitempty"` // e.g. "Order 1042 - Workstation"
+	Tolerance *float64 `json:"tolerance_pct,omitempty"`
+	Specs     []Spec   `json:"specs"`
+}
+
+// Presets are the specs customers most often ask to be proven, keyed by the
+// name used in sheets and on the command line
+var Presets = map[string]Spec{
+	"cpu_boost_clock_mhz":   {Name: "CPU boost clock", Plugin: "cpu", Metric: "max_clock_mhz", Unit: "MHz"},
+	"memory_speed_mts":      {Name: "Memory speed", Plugin: "memory", Metric: "memory_clock_start_mts", Unit: "MT/s"},
+	"memory_bandwidth_mbps": {Name: "Memory bandwidth", Plugin: "memory", Metric: "bandwidth_mb_per_sec", Unit: "MB/s"},
+	"ssd_seq_read_mbps":     {Name: "SSD sequential read", Plugin: "disk", Metric: "seq_read_mb_per_sec", Unit: "MB/s"},
+	"ssd_seq_write_mbps":    {Name: "SSD sequential write", Plugin: "disk", Metric: "seq_write_mb_per_sec", Unit: "MB/s"},
+	"ssd_random_read_iops":  {Name: "SSD random read", Plugin: "disk", Metric: "random_read_iops", Unit: "IOPS"},
+	"ssd_random_write_iops": {Name: "SSD random write", Plugin: "disk", Metric: "random_write_iops", Unit: "IOPS"},
+}
+
+// PresetKeys returns the preset keys, sorted
+func PresetKeys() []string {
+	keys := make([]string, 0, len(Presets))
+	for key := range Presets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// resolve fills in a spec from its preset. Fields set in the spec win.
+func (s Spec) resolve() (Spec, error) {
+	if s.Key != "" {
+		preset, ok := Presets[s.Key]
+		if !ok {
+			return s, fmt.Errorf("unknown spec %q", s.Key)
+		}
+		if s.Name == "" {
+			s.Name = preset.Name
+		}
+		if s.Plugin == "" {
+			s.Plugin = preset.Plugin
+		}
+		if s.Metric == "" {
+			s.Metric = preset.Metric
+		}
+		if s.Unit == "" {
+			s.Unit = preset.Unit
+		}
+		s.LowerIsBetter = s.LowerIsBetter || preset.LowerIsBetter
+	}
+	if s.Plugin == "" || s.Metric == "" {
+		return s, fmt.Errorf("spec %q needs a preset key or a plugin and metric", s.Name)
+	}
+	if s.Name == "" {
+		s.Name = s.Plugin + " " + s.Metric
+	}
+	if s.Expected <= 0 {
+		return s, fmt.Errorf("spec %q needs a positive expected value", s.Name)
+	}
+	if s.Tolerance != nil && (*s.Tolerance < 0 || *s.Tolerance >= 100) {
+		return s, fmt.Errorf("spec %q has an invalid tolerance %v%%", s.Name, *s.Tolerance)
+	}
+	return s, nil
+}
+
+// Validate checks every spec in the sheet
+func (sh *Sheet) Validate() error {
+	if sh.Tolerance != nil && (*sh.Tolerance < 0 || *sh.Tolerance >= 100) {
+		return fmt.Errorf("invalid tolerance %v%%", *sh.Tolerance)
+	}
+	for _, spec := range sh.Specs {
+		if _, err := spec.resolve(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Set adds a spec, replacing one for the same plugin and metric
+func (sh *Sheet) Set(spec Spec) error {
+	resolved, err := spec.resolve()
+	if err != nil {
+		return err
+	}
+	for i, existing := range sh.Specs {
+		if r, err := existing.resolve(); err == nil && r.Plugin == resolved.Plugin && r.Metric == resolved.Metric {
+			sh.Specs[i] = spec
+			return nil
+		}
+	}
+	sh.Specs = append(sh.Specs, spec)
+	return nil
+}
+
+// tolerance returns the allowed shortfall for a resolved spec
+func (sh *Sheet) tolerance(spec Spec) float64 {
+	switch {
+	case spec.Tolerance != nil:
+		return *spec.Tolerance
+	case sh.Tolerance != nil:
+		return *sh.Tolerance
+	default:
+		return DefaultTolerance
+	}
+}
+
+// Load reads a sheet from a JSON file
+func Load(path string) (*Sheet, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- user-selected spec sheet
+	if err != nil {
+		return nil, err
+	}
+	var sheet Sheet
+	if err := json.Unmarshal(data, &sheet); err != nil {
+		return nil, fmt.Errorf("failed to parse spec sheet %s: %w", path, err)
+	}
+	if err := sheet.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid spec sheet %s: %w", path, err)
+	}
+	return &sheet, nil
+}
+
+// Save writes a sheet as indented JSON
+func Save(path string, sheet *Sheet) error {
+	data, err := json.MarshalIndent(sheet, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
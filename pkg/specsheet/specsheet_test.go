@@ -0,0 +1,95 @@
+package specsheet
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	tight := 1.0
+	sheet := &Sheet{
+		Specs: []Spec{
+			{Key: "cpu_boost_clock_mhz", Expected: 5000},
+			{Key: "ssd_seq_read_mbps", Expected: 7000, Tolerance: &tight},
+			{Key: "memory_speed_mts", Expected: 6000},
+			{Name: "Disk latency", Plugin: "disk", Metric: "random_read_latency_us", Expected: 100, LowerIsBetter: true},
+		},
+	}
+	measured := map[string]float64{
+		"cpu/max_clock_mhz":           4800, // -4%, within the default 5%
+		"disk/seq_read_mb_per_sec":    6860, // -2%, outside 1%
+		"disk/random_read_latency_us": 90,   // 10% better
+	}
+	rows := Compare(sheet, func(plugin, metric string) (Measurement, bool) {
+		v, ok := measured[plugin+"/"+metric]
+		return Measurement{Value: v, RunID: 1}, ok
+	})
+
+	if len(rows) != 4 {
+		t.Fatalf("expected 4 rows, got %d", len(rows))
+	}
+	expected := []struct {
+		status string
+		margin float64
+	}{
+		{"PASS", -4},
+		{"FAIL", -2},
+		{"NOT MEASURED", 0},
+		{"PASS", 10},
+	}
+	for i, want := range expected {
+		if rows[i].Status() != want.status {
+			t.Errorf("row %d (%s): expected %s, got %s", i, rows[i].Spec.Name, want.status, rows[i].Status())
+		}
+		if math.Abs(rows[i].Margin-want.margin) > 1e-9 {
+			t.Errorf("row %d (%s): expected margin %v, got %v", i, rows[i].Spec.Name, want.margin, rows[i].Margin)
+		}
+	}
+	if rows[0].Spec.Unit != "MHz" || rows[0].Spec.Metric != "max_clock_mhz" {
+		t.Errorf("expected preset to be resolved, got %+v", rows[0].Spec)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	bad := []Spec{
+		{Key: "unknown", Expected: 1},
+		{Key: "memory_speed_mts"},
+		{Name: "No metric", Expected: 1},
+	}
+	for _, spec := range bad {
+		sheet := &Sheet{Specs: []Spec{spec}}
+		if err := sheet.Validate(); err == nil {
+			t.Errorf("expected error for %+v", spec)
+		}
+	}
+}
+
+func TestSetReplacesSameMetric(t *testing.T) {
+	var sheet Sheet
+	if err := sheet.Set(Spec{Key: "memory_speed_mts", Expected: 5600}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sheet.Set(Spec{Plugin: "memory", Metric: "memory_clock_start_mts", Expected: 6000}); err != nil {
+		t.Fatal(err)
+	}
+	if len(sheet.Specs) != 1 || sheet.Specs[0].Expected != 6000 {
+		t.Errorf("expected one spec at 6000, got %+v", sheet.Specs)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "specs.json")
+	tolerance := 3.0
+	sheet := &Sheet{Name: "Order 1042", Tolerance: &tolerance, Specs: []Spec{{Key: "ssd_seq_write_mbps", Expected: 5000}}}
+	if err := Save(path, sheet); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Name != sheet.Name || *loaded.Tolerance != 3 || len(loaded.Specs) != 1 {
+		t.Errorf("expected %+v, got %+v", sheet, loaded)
+	}
+}
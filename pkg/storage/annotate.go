@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+// AnnotationKind is the kind of the annotations of drive warnings
+const AnnotationKind = "storage_drive"
+
+// SaveWarnings records the warnings about the host link and write cache of
+// the drive a disk benchmark ran on, taken from the details of its result,
+// with the run so its report flags them
+func SaveWarnings(database *db.DB, run *db.Run, details map[string]interface{}) error {
+	var device string
+	var warnings []string
+	if link, ok := details["link"].(*Link); ok {
+		device = link.Device
+		warnings = append(warnings, link.Warnings...)
+	}
+	if wc, ok := details["write_cache"].(*WriteCache); ok {
+		device = wc.Device
+		warnings = append(warnings, wc.Warnings...)
+	}
+	for _, w := range warnings {
+		a := &db.Annotation{RunID: &run.ID, Time: run.StartTime, Source: device, Kind: AnnotationKind, Message: w}
+		if err := database.CreateAnnotation(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+func TestSaveWarnings(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = database.Close() }()
+
+	run, err := database.CreateRun("disk", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	details := map[string]interface{}{
+		"link":        &Link{Device: "/dev/sda", Warnings: []string{"linked at 3 Gbps"}},
+		"write_cache": &WriteCache{Device: "/dev/sda", Warnings: []string{"write cache off"}},
+	}
+	if err := SaveWarnings(database, run, details); err != nil {
+		t.Fatal(err)
+	}
+
+	annotations, err := database.RunAnnotations(run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(annotations) != 2 || annotations[0].Kind != AnnotationKind || annotations[0].Source != "/dev/sda" {
+		t.Errorf("expected both warnings recorded for /dev/sda, got %+v", annotations)
+	}
+}
@@ -0,0 +1,155 @@
+// Package storage detects drive characteristics that change how storage
+// benchmark results must be read: shingled recording, zoned namespaces,
+// persistent memory, host links slower than the drive supports and volatile
+// write caches.
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Recording technologies for rotational drives
+const (
+	RecordingSMR     = "SMR"
+	RecordingUnknown = "unknown"
+)
+
+// Zoned block device models, as reported by the Linux block layer
+const (
+	ZonedNone        = "none"
+	ZonedHostAware   = "host-aware"
+	ZonedHostManaged = "host-managed"
+)
+
+// Media types
+const (
+	MediaMagnetic = "magnetic"
+	MediaNAND     = "NAND flash"
+	MediaXPoint   = "3D XPoint"
+	MediaPMem     = "persistent memory"
+)
+
+// Characteristics describes properties of a drive that affect benchmarking
+type Characteristics struct {
+	Device     string   `json:"device"`
+	Model      string   `json:"model,omitempty"`
+	Media      string   `json:"media"`
+	Rotational bool     `json:"rotational"`
+	Recording  string   `json:"recording,omitempty"`  // SMR or unknown; rotational drives only
+	SMRSource  string   `json:"smr_source,omitempty"` // how SMR was detected: zoned or model
+	Zoned      string   `json:"zoned"`                // none, host-aware, host-managed
+	ZNS        bool     `json:"zns"`                  // NVMe zoned namespace
+	Warnings   []string `json:"warnings,omitempty"`
+}
+
+// smrModels lists model prefixes of drive-managed SMR disks, without vendor
+// prefixes. These drives do not advertise zoning, so the model number is the
+// only indication.
+var smrModels = []string{
+	// Western Digital Red (EFAX), Blue (EZAZ) and Blue mobile (SPZX)
+	"WD20EFAX", "WD30EFAX", "WD40EFAX", "WD60EFAX",
+	"WD20EZAZ", "WD60EZAZ", "WD10SPZX", "WD20SPZX",
+	// Seagate Barracuda and Archive
+	"ST2000DM008", "ST4000DM004", "ST6000DM003", "ST8000DM004",
+	"ST1000LM048", "ST2000LM015", "ST3000LM024", "ST4000LM024", "ST5000LM000",
+	"ST8000AS0002", "ST8000AS0003",
+	// Toshiba P300 and desktop drives
+	"DT02ABA400", "DT02ABA600", "HDWD240", "HDWD260",
+}
+
+// xpointModels lists model substrings of Intel Optane (3D XPoint) SSDs
+var xpointModels = []string{"OPTANE", "SSDPE21D", "SSDPED1K", "SSDPE21K", "SSDPEL1K", "SSDPEK1A", "SSDPF21Q", "MEMPEK1"}
+
+// Detect reports the characteristics of a physical drive. Model is used for
+// drive-managed SMR and Optane detection when the OS does not expose them.
+func Detect(device, model string) (*Characteristics, error) {
+	c := &Characteristics{
+		Device: device,
+		Model:  strings.TrimSpace(model),
+		Media:  MediaNAND,
+		Zoned:  ZonedNone,
+	}
+
+	// Model heuristics still apply when the platform query fails
+	err := detect(c)
+	c.applyModelHeuristics()
+	c.Warnings = c.warnings()
+	return c, err
+}
+
+// applyModelHeuristics fills in characteristics that can only be inferred
+// from the model number
+func (c *Characteristics) applyModelHeuristics() {
+	upper := strings.ToUpper(c.Model)
+
+	if c.Media != MediaPMem {
+		for _, m := range xpointModels {
+			if strings.Contains(upper, m) {
+				c.Media = MediaXPoint
+				break
+			}
+		}
+	}
+
+	if !c.Rotational {
+		return
+	}
+	c.Media = MediaMagnetic
+	if c.Recording == RecordingSMR {
+		return
+	}
+	c.Recording = RecordingUnknown
+	for _, vendor := range []string{"WDC ", "TOSHIBA ", "SEAGATE "} {
+		upper = strings.TrimPrefix(upper, vendor)
+	}
+	for _, m := range smrModels {
+		if strings.HasPrefix(upper, m) {
+			c.Recording = RecordingSMR
+			c.SMRSource = "model"
+			return
+		}
+	}
+}
+
+// warnings explains how each characteristic affects benchmark results
+func (c *Characteristics) warnings() []string {
+	var w []string
+	if c.Recording == RecordingSMR {
+		w = append(w, "Shingled (SMR) drive: sustained and random write throughput drops sharply once the CMR cache is full")
+	}
+	switch {
+	case c.ZNS:
+		w = append(w, "Zoned namespace (ZNS) device: writes must be sequential within a zone")
+	case c.Zoned == ZonedHostManaged:
+		w = append(w, "Host-managed zoned device: random writes are rejected by the drive")
+	case c.Zoned == ZonedHostAware:
+		w = append(w, "Host-aware zoned device: random writes are accepted but slow")
+	}
+	switch c.Media {
+	case MediaXPoint:
+		w = append(w, "3D XPoint (Optane) media: latency is far lower than NAND and results are not comparable with NAND SSDs")
+	case MediaPMem:
+		w = append(w, "Persistent memory device: results reflect memory bus bandwidth, not block storage")
+	}
+	return w
+}
+
+// SequentialWritesOnly reports whether random writes should be avoided
+func (c *Characteristics) SequentialWritesOnly() bool {
+	return c.ZNS || c.Zoned == ZonedHostManaged
+}
+
+// Summary returns a one-line description of the drive characteristics
+func (c *Characteristics) Summary() string {
+	parts := []string{c.Media}
+	if c.Recording != "" && c.Recording != RecordingUnknown {
+		parts = append(parts, c.Recording)
+	}
+	if c.ZNS {
+		parts = append(parts, "ZNS")
+	} else if c.Zoned != ZonedNone && c.Zoned != "" {
+		parts = append(parts, fmt.Sprintf("zoned (%s)", c.Zoned))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,57 @@
+//go:build linux
+// +build linux
+
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sysBlock is the sysfs block device directory, replaceable in tests
+var sysBlock = "/sys/block"
+
+// detect reads rotational, zoned and model attributes from sysfs
+func detect(c *Characteristics) error {
+	name := filepath.Base(c.Device)
+	if strings.HasPrefix(name, "pmem") {
+		c.Media = MediaPMem
+		return nil
+	}
+
+	queue := filepath.Join(sysBlock, name, "queue")
+	rotational, err := os.ReadFile(filepath.Join(queue, "rotational")) // #nosec G304 -- sysfs path built from the device name
+	if err != nil {
+		return fmt.Errorf("failed to read queue attributes for %s: %w", name, err)
+	}
+	c.Rotational = strings.TrimSpace(string(rotational)) == "1"
+
+	if zoned := readAttr(filepath.Join(queue, "zoned")); zoned != "" {
+		c.Zoned = zoned
+	}
+	if c.Zoned != ZonedNone {
+		if strings.HasPrefix(name, "nvme") {
+			c.ZNS = true
+		} else {
+			// Zoned SATA/SAS disks are host-aware or host-managed SMR
+			c.Recording = RecordingSMR
+			c.SMRSource = "zoned"
+		}
+	}
+
+	if c.Model == "" {
+		c.Model = readAttr(filepath.Join(sysBlock, name, "device", "model"))
+	}
+	return nil
+}
+
+// readAttr returns a trimmed sysfs attribute, or "" if it cannot be read
+func readAttr(path string) string {
+	data, err := os.ReadFile(path) // #nosec G304 -- sysfs attribute path
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
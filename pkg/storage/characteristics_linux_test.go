@@ -0,0 +1,62 @@
+//go:build linux
+// +build linux
+
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeQueue(t *testing.T, dir, name, rotational, zoned string) {
+	t.Helper()
+	queue := filepath.Join(dir, name, "queue")
+	if err := os.MkdirAll(queue, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(queue, "rotational"), []byte(rotational+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(queue, "zoned"), []byte(zoned+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	dir := t.TempDir()
+	sysBlock = dir
+	defer func() { sysBlock = "/sys/block" }()
+
+	writeQueue(t, dir, "sda", "1", "none")
+	writeQueue(t, dir, "sdb", "1", "host-managed")
+	writeQueue(t, dir, "nvme0n2", "0", "host-managed")
+	writeQueue(t, dir, "nvme1n1", "0", "none")
+
+	tests := []struct {
+		device    string
+		model     string
+		media     string
+		recording string
+		zns       bool
+		seqOnly   bool
+	}{
+		{"/dev/sda", "WDC WD40EFAX-68JH4N1", MediaMagnetic, RecordingSMR, false, false},
+		{"/dev/sda", "ST4000VN008-2DR166", MediaMagnetic, RecordingUnknown, false, false},
+		{"/dev/sdb", "ST14000NM0428", MediaMagnetic, RecordingSMR, false, true},
+		{"/dev/nvme0n2", "WZS4C8T4TDSP303", MediaNAND, "", true, true},
+		{"/dev/nvme1n1", "INTEL SSDPE21D480GA", MediaXPoint, "", false, false},
+		{"/dev/pmem0", "", MediaPMem, "", false, false},
+	}
+
+	for _, tt := range tests {
+		c, err := Detect(tt.device, tt.model)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.device, err)
+			continue
+		}
+		if c.Media != tt.media || c.Recording != tt.recording || c.ZNS != tt.zns || c.SequentialWritesOnly() != tt.seqOnly {
+			t.Errorf("%s (%s): got %+v", tt.device, tt.model, c)
+		}
+	}
+}
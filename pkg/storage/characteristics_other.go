@@ -0,0 +1,10 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package storage
+
+import "fmt"
+
+func detect(_ *Characteristics) error {
+	return fmt.Errorf("drive characteristics are not supported on this platform")
+}
@@ -0,0 +1,73 @@
+//go:build windows
+// +build windows
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// physicalDisk is the subset of Get-PhysicalDisk output used here
+type physicalDisk struct {
+	Model        string `json:"Model"`
+	MediaType    string `json:"MediaType"`
+	SpindleSpeed uint64 `json:"SpindleSpeed"`
+}
+
+// detect queries Storage Management for the media type. Windows does not
+// expose zoned devices, so SMR is only inferred from the model.
+func detect(c *Characteristics) error {
+	number, err := diskNumber(c.Device)
+	if err != nil {
+		return err
+	}
+
+	output, err := safeexec.PowerShell(`Get-PhysicalDisk | Where-Object { $_.DeviceId -eq '%d' } | `+
+		`Select-Object Model, @{n='MediaType';e={[string]$_.MediaType}}, SpindleSpeed | ConvertTo-Json -Compress`, number).Output()
+	if err != nil {
+		return fmt.Errorf("failed to query physical disk %d: %w", number, err)
+	}
+
+	var disk physicalDisk
+	if err := json.Unmarshal(output, &disk); err != nil {
+		return fmt.Errorf("failed to parse physical disk %d: %w", number, err)
+	}
+
+	if c.Model == "" {
+		c.Model = strings.TrimSpace(disk.Model)
+	}
+	switch disk.MediaType {
+	case "HDD":
+		c.Rotational = true
+	case "SCM":
+		c.Media = MediaPMem
+	}
+	// 0xFFFFFFFF means the spindle speed is unknown
+	if disk.SpindleSpeed > 0 && disk.SpindleSpeed != 0xFFFFFFFF {
+		c.Rotational = true
+	}
+	return nil
+}
+
+// diskNumber resolves \\.\PHYSICALDRIVEn or a drive letter to a disk number
+func diskNumber(device string) (int, error) {
+	upper := strings.ToUpper(device)
+	if strings.HasPrefix(upper, `\\.\PHYSICALDRIVE`) {
+		return strconv.Atoi(strings.TrimPrefix(upper, `\\.\PHYSICALDRIVE`))
+	}
+
+	letter := strings.TrimSuffix(strings.TrimSpace(upper), ":")
+	if len(letter) != 1 {
+		return 0, fmt.Errorf("invalid drive %q", device)
+	}
+	output, err := safeexec.PowerShell(`(Get-Partition -DriveLetter %s).DiskNumber`, letter).Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve drive %s: %w", letter, err)
+	}
+	return strconv.Atoi(strings.TrimSpace(string(output)))
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// partitionSuffix matches the partition part of Linux block device names:
+// sda1, nvme0n1p1, mmcblk0p1
+var partitionSuffix = regexp.MustCompile(`^(/dev/(?:nvme\d+n\d+|mmcblk\d+|pmem\d+|md\d+))p\d+$|^(/dev/[a-z]+)\d+$`)
+
+// PhysicalDrive returns the whole-disk device for a partition device path
+func PhysicalDrive(device string) string {
+	m := partitionSuffix.FindStringSubmatch(device)
+	switch {
+	case m == nil:
+		return device
+	case m[1] != "":
+		return m[1]
+	default:
+		return m[2]
+	}
+}
+
+// Mount describes the filesystem a path lives on
+type Mount struct {
+	Device     string
+	Mountpoint string
+	Fstype     string
+	Opts       []string
+}
+
+// MountForPath returns the mounted filesystem containing path. Filesystems
+// without a block device, such as a tmpfs /tmp, are included, so a path on
+// one is not taken to be on the filesystem mounted below it.
+func MountForPath(path string) (*Mount, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	partitions, err := disk.Partitions(true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions: %w", err)
+	}
+	mount := mountFor(abs, partitions, runtime.GOOS == "windows")
+	if mount == nil {
+		return nil, fmt.Errorf("no mounted filesystem found for %s", path)
+	}
+	return mount, nil
+}
+
+// mountFor returns the filesystem of partitions holding the absolute path
+// abs, or nil. On Windows a filesystem is matched by its volume.
+func mountFor(abs string, partitions []disk.PartitionStat, windows bool) *Mount {
+	// The longest mount point containing path is the filesystem it lives on
+	var best *disk.PartitionStat
+	for i := range partitions {
+		p := &partitions[i]
+		if windows {
+			if !strings.EqualFold(filepath.VolumeName(abs), strings.TrimSuffix(p.Mountpoint, `\`)) {
+				continue
+			}
+		} else if abs != p.Mountpoint && !strings.HasPrefix(abs, strings.TrimSuffix(p.Mountpoint, "/")+"/") {
+			continue
+		}
+		if best == nil || len(p.Mountpoint) > len(best.Mountpoint) {
+			best = p
+		}
+	}
+	if best == nil {
+		return nil
+	}
+
+	return &Mount{
+		Device:     best.Device,
+		Mountpoint: best.Mountpoint,
+		Fstype:     best.Fstype,
+		Opts:       best.Opts,
+	}
+}
+
+// BlockDevice reports whether the filesystem lives on a block device, which
+// tmpfs, overlay and network filesystems do not. Windows volumes always do.
+func (m *Mount) BlockDevice() bool {
+	return runtime.GOOS == "windows" || strings.HasPrefix(m.Device, "/dev/")
+}
+
+// DeviceForPath returns the physical drive holding path. On Windows the
+// drive letter is returned (e.g. "C:").
+func DeviceForPath(path string) (string, error) {
+	if runtime.GOOS == "windows" {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return "", err
+		}
+		return strings.ToUpper(filepath.VolumeName(abs)), nil
+	}
+
+	mount, err := MountForPath(path)
+	if err != nil {
+		return "", err
+	}
+	if !mount.BlockDevice() {
+		return "", fmt.Errorf("no block device found for %s", path)
+	}
+	return PhysicalDrive(mount.Device), nil
+}
@@ -0,0 +1,53 @@
+//go:build linux
+// +build linux
+
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+func TestMountFor(t *testing.T) {
+	partitions := []disk.PartitionStat{
+		{Device: "/dev/nvme0n1p2", Mountpoint: "/", Fstype: "ext4"},
+		{Device: "/dev/nvme0n1p1", Mountpoint: "/boot/efi", Fstype: "vfat"},
+		{Device: "tmpfs", Mountpoint: "/tmp", Fstype: "tmpfs"},
+		{Device: "/dev/sdb1", Mountpoint: "/data", Fstype: "xfs"},
+	}
+	tests := []struct {
+		path       string
+		mountpoint string
+		block      bool
+	}{
+		{"/", "/", true},
+		{"/home/user", "/", true},
+		{"/boot/efi/EFI", "/boot/efi", true},
+		{"/tmp", "/tmp", false},
+		{"/tmp/fire", "/tmp", false},
+		{"/tmpdir", "/", true},
+		{"/data/scratch", "/data", true},
+	}
+	for _, tt := range tests {
+		m := mountFor(tt.path, partitions, false)
+		if m == nil || m.Mountpoint != tt.mountpoint || m.BlockDevice() != tt.block {
+			t.Errorf("%s: expected %s (block device %v), got %+v", tt.path, tt.mountpoint, tt.block, m)
+		}
+	}
+
+	if m := mountFor("/srv", partitions[1:], false); m != nil {
+		t.Errorf("expected no filesystem without a root mount, got %+v", m)
+	}
+}
+
+func TestTrimMount(t *testing.T) {
+	m, err := MountForPath("/dev/shm")
+	if err != nil || m.Fstype != "tmpfs" {
+		t.Skip("/dev/shm is not a tmpfs here")
+	}
+	if _, err := TrimMount("/dev/shm/fire"); err == nil || !strings.Contains(err.Error(), "no block device") {
+		t.Errorf("expected the tmpfs to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+//go:build linux
+// +build linux
+
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// InUse returns an error when device, or any partition on it, is mounted or
+// claimed by another block device (LVM, dm-crypt, md RAID). Raw benchmarks
+// overwrite the device, so they must only run against free devices.
+func InUse(device string) error {
+	if resolved, err := filepath.EvalSymlinks(device); err == nil {
+		device = resolved
+	}
+	name := filepath.Base(device)
+
+	partitions, err := disk.Partitions(true)
+	if err != nil {
+		return fmt.Errorf("failed to list mounts: %w", err)
+	}
+	for _, p := range partitions {
+		if p.Device == device || PhysicalDrive(p.Device) == device {
+			return fmt.Errorf("%s is mounted at %s", p.Device, p.Mountpoint)
+		}
+	}
+
+	// Partitions of a whole disk appear as subdirectories named after it
+	dirs := []string{filepath.Join(sysBlock, name)}
+	if entries, err := os.ReadDir(filepath.Join(sysBlock, name)); err == nil {
+		for _, e := range entries {
+			if strings.HasPrefix(e.Name(), name) {
+				dirs = append(dirs, filepath.Join(sysBlock, name, e.Name()))
+			}
+		}
+	} else if PhysicalDrive(device) != device {
+		// A partition lives under its parent disk
+		parent := filepath.Base(PhysicalDrive(device))
+		dirs = []string{filepath.Join(sysBlock, parent, name)}
+	}
+
+	for _, dir := range dirs {
+		holders, err := os.ReadDir(filepath.Join(dir, "holders"))
+		if err == nil && len(holders) > 0 {
+			return fmt.Errorf("%s is in use by %s", filepath.Base(dir), holders[0].Name())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package storage
+
+import "fmt"
+
+// InUse is not implemented on this platform, so raw access is always refused
+func InUse(device string) error {
+	return fmt.Errorf("cannot verify that %s is unused on this platform", device)
+}
@@ -0,0 +1,31 @@
+//go:build windows
+// +build windows
+
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// InUse returns an error when any partition on the physical drive has a
+// volume mounted. Raw benchmarks overwrite the device, so they must only run
+// against disks without mounted volumes.
+func InUse(device string) error {
+	number, err := diskNumber(device)
+	if err != nil {
+		return err
+	}
+
+	output, err := safeexec.PowerShell(`@(Get-Partition -DiskNumber %d -ErrorAction SilentlyContinue | `+
+		`Where-Object { $_.DriveLetter -or $_.AccessPaths.Count -gt 1 }).Count`, number).Output()
+	if err != nil {
+		return fmt.Errorf("failed to query partitions on disk %d: %w", number, err)
+	}
+	if count := strings.TrimSpace(string(output)); count != "0" {
+		return fmt.Errorf("disk %d has %s mounted volume(s)", number, count)
+	}
+	return nil
+}
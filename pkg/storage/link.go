@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/smart"
+)
+
+// Host interfaces a drive links over
+const (
+	InterfaceSATA = "SATA"
+	InterfaceNVMe = "NVMe"
+)
+
+// Link describes the host link of a drive and how deep its command queues
+// are. A drive linked below what it supports, such as a SATA SSD on a
+// 3 Gbps port or a PCIe 4.0 NVMe drive in a PCIe 3.0 socket, benchmarks
+// slower than its specification without being faulty.
+type Link struct {
+	Device    string `json:"device"`
+	Interface string `json:"interface"` // SATA or NVMe
+
+	// SATA link rate in Gbps: negotiated, and the highest the drive supports
+	Gbps    float64 `json:"gbps,omitempty"`
+	MaxGbps float64 `json:"max_gbps,omitempty"`
+
+	// NVMe PCIe generation and lanes: negotiated, the highest the drive
+	// supports, and the highest the port above it supports
+	Gen          int `json:"pcie_gen,omitempty"`
+	Width        int `json:"pcie_width,omitempty"`
+	MaxGen       int `json:"pcie_max_gen,omitempty"`
+	MaxWidth     int `json:"pcie_max_width,omitempty"`
+	PortMaxGen   int `json:"port_max_gen,omitempty"`
+	PortMaxWidth int `json:"port_max_width,omitempty"`
+
+	QueueDepth     int `json:"queue_depth,omitempty"`     // Commands the drive takes at once: NCQ depth for SATA, tags per queue for NVMe
+	HardwareQueues int `json:"hardware_queues,omitempty"` // NVMe I/O queues
+	Requests       int `json:"nr_requests,omitempty"`     // Block layer queue size
+
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// ReadLink reports the host link of a whole-disk device such as /dev/sda or
+// /dev/nvme0n1
+func ReadLink(device string) (*Link, error) {
+	l := &Link{Device: device}
+	if err := readLink(l); err != nil {
+		return nil, err
+	}
+	l.Warnings = l.warnings()
+	return l, nil
+}
+
+// Speed describes the negotiated link, e.g. "6.0 Gbps" or "PCIe 4.0 x4"
+func (l *Link) Speed() string {
+	if l.Interface == InterfaceNVMe {
+		return pcieLink(l.Gen, l.Width)
+	}
+	return sataRate(l.Gbps)
+}
+
+// MaxSpeed describes the fastest link the drive supports
+func (l *Link) MaxSpeed() string {
+	if l.Interface == InterfaceNVMe {
+		return pcieLink(l.MaxGen, l.MaxWidth)
+	}
+	return sataRate(l.MaxGbps)
+}
+
+// Degraded reports whether the drive is linked below what it supports
+func (l *Link) Degraded() bool {
+	if l.Interface == InterfaceNVMe {
+		return (l.Gen > 0 && l.Gen < l.MaxGen) || (l.Width > 0 && l.Width < l.MaxWidth)
+	}
+	return l.Gbps > 0 && l.Gbps < l.MaxGbps
+}
+
+// Queues describes the queue configuration, e.g. "NCQ depth 32" or
+// "16 queues x 1023"
+func (l *Link) Queues() string {
+	switch {
+	case l.HardwareQueues > 0 && l.QueueDepth > 0:
+		return fmt.Sprintf("%d queues x %d", l.HardwareQueues, l.QueueDepth)
+	case l.HardwareQueues > 0:
+		return fmt.Sprintf("%d queues", l.HardwareQueues)
+	case l.Interface == InterfaceSATA && l.QueueDepth == 1:
+		return "NCQ disabled"
+	case l.Interface == InterfaceSATA && l.QueueDepth > 1:
+		return fmt.Sprintf("NCQ depth %d", l.QueueDepth)
+	case l.QueueDepth > 0:
+		return fmt.Sprintf("depth %d", l.QueueDepth)
+	}
+	return ""
+}
+
+// Summary returns a one-line description of the link, e.g.
+// "SATA 3.0 Gbps (supports 6.0 Gbps), NCQ depth 32"
+func (l *Link) Summary() string {
+	s := l.Interface
+	if speed := l.Speed(); speed != "" {
+		s += " " + strings.TrimPrefix(speed, "PCIe ")
+	}
+	if l.Degraded() {
+		s += fmt.Sprintf(" (supports %s)", strings.TrimPrefix(l.MaxSpeed(), "PCIe "))
+	}
+	if q := l.Queues(); q != "" {
+		s += ", " + q
+	}
+	return s
+}
+
+// warnings explains a link below capability and a disabled command queue
+func (l *Link) warnings() []string {
+	var w []string
+	switch {
+	case !l.Degraded():
+	case l.Interface == InterfaceSATA:
+		w = append(w, fmt.Sprintf("Linked at %s of the %s the drive supports: use a %s port and check the cable",
+			sataRate(l.Gbps), sataRate(l.MaxGbps), sataRate(l.MaxGbps)))
+	case l.PortMaxGen > 0 && l.PortMaxGen < l.MaxGen || l.PortMaxWidth > 0 && l.PortMaxWidth < l.MaxWidth:
+		w = append(w, fmt.Sprintf("Linked at %s of the %s the drive supports: the socket is limited to %s",
+			l.Speed(), l.MaxSpeed(), pcieLink(l.PortMaxGen, l.PortMaxWidth)))
+	default:
+		w = append(w, fmt.Sprintf("Linked at %s of the %s the drive supports: reseat the drive, or check whether the socket shares lanes",
+			l.Speed(), l.MaxSpeed()))
+	}
+	if l.Interface == InterfaceSATA && l.QueueDepth == 1 {
+		w = append(w, "NCQ is disabled (queue depth 1): random I/O results will be far below the drive's rating")
+	}
+	return w
+}
+
+// sataRate formats a SATA link rate, e.g. "6.0 Gbps"
+func sataRate(gbps float64) string {
+	if gbps <= 0 {
+		return ""
+	}
+	return strconv.FormatFloat(gbps, 'f', 1, 64) + " Gbps"
+}
+
+// pcieLink formats a PCIe link, e.g. "PCIe 4.0 x4"
+func pcieLink(gen, width int) string {
+	var parts []string
+	if gen > 0 {
+		parts = append(parts, fmt.Sprintf("PCIe %d.0", gen))
+	}
+	if width > 0 {
+		parts = append(parts, fmt.Sprintf("x%d", width))
+	}
+	return strings.Join(parts, " ")
+}
+
+// pcieGenerations maps per-lane transfer rates in GT/s to PCIe generations
+var pcieGenerations = map[float64]int{2.5: 1, 5: 2, 8: 3, 16: 4, 32: 5, 64: 6}
+
+// PCIeGen returns the generation of a link speed as sysfs reports it, e.g.
+// 4 for "16.0 GT/s PCIe", or 0 if the speed is unknown
+func PCIeGen(speed string) int {
+	fields := strings.Fields(speed)
+	if len(fields) == 0 {
+		return 0
+	}
+	rate, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0
+	}
+	return pcieGenerations[rate]
+}
+
+// sataVersionLine matches the rates of smartctl's "SATA Version is:" line,
+// e.g. "SATA 3.2, 6.0 Gb/s (current: 3.0 Gb/s)"
+var sataVersionLine = regexp.MustCompile(`(\d+\.\d+) Gb/s(?: \(current: (\d+\.\d+) Gb/s\))?`)
+
+// ParseSATAVersion returns the highest link rate the drive supports and the
+// negotiated rate from smartctl -i output. Current is 0 when smartctl does
+// not know it.
+func ParseSATAVersion(output string) (maxGbps, current float64) {
+	m := sataVersionLine.FindStringSubmatch(smart.Field(output, "SATA Version is"))
+	if m == nil {
+		return 0, 0
+	}
+	maxGbps, _ = strconv.ParseFloat(m[1], 64)
+	if m[2] != "" {
+		current, _ = strconv.ParseFloat(m[2], 64)
+	}
+	return maxGbps, current
+}
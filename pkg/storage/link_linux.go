@@ -0,0 +1,117 @@
+//go:build linux
+// +build linux
+
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// sysfsRoot is the root the link attributes are read under, replaced in
+// tests
+var sysfsRoot = "/"
+
+// ataPort matches the ATA port directory in a SATA disk's sysfs path, e.g.
+// /sys/devices/pci0000:00/0000:00:17.0/ata3/host2/...
+var ataPort = regexp.MustCompile(`^ata(\d+)$`)
+
+// sataVersion runs smartctl -i for the rates the drive supports, replaced
+// in tests
+var sataVersion = func(device string) (string, error) {
+	if err := safeexec.ValidateDevicePath(device); err != nil {
+		return "", err
+	}
+	output, err := safeexec.Command("smartctl", "-i", device).Output()
+	if len(output) == 0 {
+		return "", err
+	}
+	return string(output), nil
+}
+
+// readLink reads the link from sysfs: the ATA link of a SATA disk, or the
+// PCI function of an NVMe controller
+func readLink(l *Link) error {
+	name := filepath.Base(l.Device)
+	block := filepath.Join(sysfsRoot, "sys/block", name)
+	if _, err := os.Stat(block); err != nil {
+		return fmt.Errorf("no block device %s: %w", name, err)
+	}
+	l.Requests = readIntAttr(filepath.Join(block, "queue", "nr_requests"))
+
+	if strings.HasPrefix(name, "nvme") {
+		readNVMeLink(l, block)
+		return nil
+	}
+
+	target, err := filepath.EvalSymlinks(block)
+	if err != nil {
+		return err
+	}
+	port := ""
+	for _, dir := range strings.Split(target, string(filepath.Separator)) {
+		if m := ataPort.FindStringSubmatch(dir); m != nil {
+			port = m[1]
+		}
+	}
+	if port == "" {
+		return fmt.Errorf("%s is not linked over SATA or NVMe", name)
+	}
+	l.Interface = InterfaceSATA
+	l.QueueDepth = readIntAttr(filepath.Join(block, "device", "queue_depth"))
+	// "6.0 Gbps", or "<unknown>" while the link is down
+	if rate, err := strconv.ParseFloat(strings.TrimSuffix(readAttr(filepath.Join(sysfsRoot, "sys/class/ata_link", "link"+port, "sata_spd")), " Gbps"), 64); err == nil {
+		l.Gbps = rate
+	}
+
+	// Only the drive knows what it supports; smartctl reads it from IDENTIFY
+	if output, err := sataVersion(l.Device); err == nil {
+		maxGbps, current := ParseSATAVersion(output)
+		l.MaxGbps = maxGbps
+		if l.Gbps == 0 {
+			l.Gbps = current
+		}
+	}
+	return nil
+}
+
+// readNVMeLink reads the PCIe link of the controller holding the namespace
+// and the blk-mq queues the namespace was given
+func readNVMeLink(l *Link, block string) {
+	l.Interface = InterfaceNVMe
+	// device is the controller, e.g. nvme0; its device is the PCI function
+	if function, err := filepath.EvalSymlinks(filepath.Join(block, "device", "device")); err == nil {
+		l.Gen = PCIeGen(readAttr(filepath.Join(function, "current_link_speed")))
+		l.MaxGen = PCIeGen(readAttr(filepath.Join(function, "max_link_speed")))
+		l.Width = readIntAttr(filepath.Join(function, "current_link_width"))
+		l.MaxWidth = readIntAttr(filepath.Join(function, "max_link_width"))
+		// The root port or switch above limits the socket
+		port := filepath.Dir(function)
+		l.PortMaxGen = PCIeGen(readAttr(filepath.Join(port, "max_link_speed")))
+		l.PortMaxWidth = readIntAttr(filepath.Join(port, "max_link_width"))
+	}
+
+	queues, err := os.ReadDir(filepath.Join(block, "mq"))
+	if err != nil {
+		return
+	}
+	l.HardwareQueues = len(queues)
+	if len(queues) > 0 {
+		l.QueueDepth = readIntAttr(filepath.Join(block, "mq", queues[0].Name(), "nr_tags"))
+	}
+}
+
+// readIntAttr returns an integer sysfs attribute, or 0
+func readIntAttr(path string) int {
+	n, err := strconv.Atoi(readAttr(path))
+	if err != nil {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,98 @@
+//go:build linux
+// +build linux
+
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeAttr writes a sysfs attribute under dir, creating its directory
+func writeAttr(t *testing.T, dir, path, value string) {
+	t.Helper()
+	path = filepath.Join(dir, path)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(value+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// symlink links dir/name to dir/target
+func symlink(t *testing.T, dir, target, name string) {
+	t.Helper()
+	name = filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(dir, target), name); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadLink(t *testing.T) {
+	dir := t.TempDir()
+	sysfsRoot = dir
+	defer func() { sysfsRoot = "/" }()
+	readVersion := sataVersion
+	sataVersion = func(string) (string, error) {
+		return "SATA Version is:  SATA 3.3, 6.0 Gb/s (current: 3.0 Gb/s)\n", nil
+	}
+	defer func() { sataVersion = readVersion }()
+
+	// SATA SSD on a port that trained at 3 Gbps
+	sda := "sys/devices/pci0000:00/0000:00:17.0/ata3/host2/target2:0:0/2:0:0:0"
+	writeAttr(t, dir, sda+"/queue_depth", "32")
+	writeAttr(t, dir, sda+"/block/sda/queue/nr_requests", "64")
+	symlink(t, dir, sda, sda+"/block/sda/device")
+	symlink(t, dir, sda+"/block/sda", "sys/block/sda")
+	writeAttr(t, dir, "sys/class/ata_link/link3/sata_spd", "3.0 Gbps")
+
+	// PCIe 4.0 NVMe drive in a PCIe 3.0 socket
+	port := "sys/devices/pci0000:00/0000:00:1d.0"
+	function := port + "/0000:03:00.0"
+	writeAttr(t, dir, port+"/max_link_speed", "8.0 GT/s PCIe")
+	writeAttr(t, dir, port+"/max_link_width", "4")
+	writeAttr(t, dir, function+"/current_link_speed", "8.0 GT/s PCIe")
+	writeAttr(t, dir, function+"/current_link_width", "4")
+	writeAttr(t, dir, function+"/max_link_speed", "16.0 GT/s PCIe")
+	writeAttr(t, dir, function+"/max_link_width", "4")
+	nvme := function + "/nvme/nvme0"
+	symlink(t, dir, function, nvme+"/device")
+	ns := nvme + "/nvme0n1"
+	writeAttr(t, dir, ns+"/queue/nr_requests", "1023")
+	writeAttr(t, dir, ns+"/mq/0/nr_tags", "1023")
+	writeAttr(t, dir, ns+"/mq/1/nr_tags", "1023")
+	symlink(t, dir, nvme, ns+"/device")
+	symlink(t, dir, ns, "sys/block/nvme0n1")
+
+	l, err := ReadLink("/dev/sda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Interface != InterfaceSATA || l.Gbps != 3 || l.MaxGbps != 6 || l.QueueDepth != 32 || l.Requests != 64 {
+		t.Errorf("unexpected SATA link: %+v", l)
+	}
+	if !l.Degraded() || len(l.Warnings) != 1 {
+		t.Errorf("expected a link speed warning, got %v", l.Warnings)
+	}
+
+	l, err = ReadLink("/dev/nvme0n1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Interface != InterfaceNVMe || l.Gen != 3 || l.MaxGen != 4 || l.Width != 4 || l.PortMaxGen != 3 ||
+		l.HardwareQueues != 2 || l.QueueDepth != 1023 {
+		t.Errorf("unexpected NVMe link: %+v", l)
+	}
+	if got := l.Summary(); got != "NVMe 3.0 x4 (supports 4.0 x4), 2 queues x 1023" {
+		t.Errorf("unexpected summary %q", got)
+	}
+
+	if _, err := ReadLink("/dev/sdz"); err == nil {
+		t.Error("expected an error for a missing device")
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package storage
+
+import "fmt"
+
+func readLink(_ *Link) error {
+	return fmt.Errorf("drive link information is not supported on this platform")
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSATAVersion(t *testing.T) {
+	tests := []struct {
+		output  string
+		max     float64
+		current float64
+	}{
+		{"Device Model:     Samsung SSD 870 EVO 1TB\nSATA Version is:  SATA 3.3, 6.0 Gb/s (current: 3.0 Gb/s)\n", 6, 3},
+		{"SATA Version is:  SATA 2.6, 3.0 Gb/s\n", 3, 0},
+		{"Transport protocol:   SAS (SPL-4)\n", 0, 0},
+	}
+	for _, tt := range tests {
+		maxGbps, current := ParseSATAVersion(tt.output)
+		if maxGbps != tt.max || current != tt.current {
+			t.Errorf("%q: expected %v/%v, got %v/%v", tt.output, tt.max, tt.current, maxGbps, current)
+		}
+	}
+}
+
+func TestPCIeGen(t *testing.T) {
+	tests := map[string]int{
+		"2.5 GT/s PCIe":  1,
+		"8.0 GT/s PCIe":  3,
+		"16.0 GT/s PCIe": 4,
+		"32 GT/s":        5,
+		"Unknown":        0,
+		"":               0,
+	}
+	for speed, want := range tests {
+		if got := PCIeGen(speed); got != want {
+			t.Errorf("%q: expected gen %d, got %d", speed, want, got)
+		}
+	}
+}
+
+func TestLinkWarnings(t *testing.T) {
+	tests := []struct {
+		link    Link
+		summary string
+		warning string
+	}{
+		{
+			Link{Interface: InterfaceSATA, Gbps: 3, MaxGbps: 6, QueueDepth: 32},
+			"SATA 3.0 Gbps (supports 6.0 Gbps), NCQ depth 32",
+			"use a 6.0 Gbps port",
+		},
+		{
+			Link{Interface: InterfaceSATA, Gbps: 6, MaxGbps: 6, QueueDepth: 1},
+			"SATA 6.0 Gbps, NCQ disabled",
+			"NCQ is disabled",
+		},
+		{
+			Link{Interface: InterfaceNVMe, Gen: 3, Width: 4, MaxGen: 4, MaxWidth: 4, PortMaxGen: 3, PortMaxWidth: 4, HardwareQueues: 16, QueueDepth: 1023},
+			"NVMe 3.0 x4 (supports 4.0 x4), 16 queues x 1023",
+			"the socket is limited to PCIe 3.0 x4",
+		},
+		{
+			Link{Interface: InterfaceNVMe, Gen: 4, Width: 2, MaxGen: 4, MaxWidth: 4, PortMaxGen: 4, PortMaxWidth: 4},
+			"NVMe 4.0 x2 (supports 4.0 x4)",
+			"reseat the drive",
+		},
+		{
+			Link{Interface: InterfaceNVMe, Gen: 4, Width: 4, MaxGen: 4, MaxWidth: 4},
+			"NVMe 4.0 x4",
+			"",
+		},
+	}
+	for _, tt := range tests {
+		l := tt.link
+		w := l.warnings()
+		if got := l.Summary(); got != tt.summary {
+			t.Errorf("expected summary %q, got %q", tt.summary, got)
+		}
+		switch {
+		case tt.warning == "" && len(w) > 0:
+			t.Errorf("%s: expected no warning, got %v", tt.summary, w)
+		case tt.warning != "" && (len(w) == 0 || !strings.Contains(w[0], tt.warning)):
+			t.Errorf("%s: expected warning containing %q, got %v", tt.summary, tt.warning, w)
+		}
+	}
+}
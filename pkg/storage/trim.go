@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// Verification outcomes for TrimReport.Verified
+const (
+	TrimVerifyZeroed    = "zeroed"
+	TrimVerifyNotZeroed = "not zeroed"
+	TrimVerifySkipped   = "skipped"
+)
+
+// TrimReport describes whether TRIM/discard works end to end for the
+// filesystem holding a path
+type TrimReport struct {
+	Device       string   `json:"device"`
+	Mountpoint   string   `json:"mountpoint"`
+	Rotational   bool     `json:"rotational"`
+	Supported    bool     `json:"supported"`        // the device accepts discard requests
+	Enabled      bool     `json:"enabled"`          // the OS sends them, continuously or periodically
+	Method       string   `json:"method,omitempty"` // how trim is issued, e.g. "discard mount option"
+	Issued       bool     `json:"issued"`           // a trim was issued during the check
+	TrimmedBytes uint64   `json:"trimmed_bytes,omitempty"`
+	Verified     string   `json:"verified"` // zeroed, not zeroed or skipped
+	Issues       []string `json:"issues,omitempty"`
+	Notes        []string `json:"notes,omitempty"`
+}
+
+// TrimOptions controls the intrusive parts of CheckTrim
+type TrimOptions struct {
+	// Issue runs a filesystem trim (fstrim / Optimize-Volume -ReTrim)
+	Issue bool
+	// Verify writes a scratch file, deletes and trims it, then reads the
+	// freed blocks back from the raw device. Requires Issue and root.
+	Verify bool
+}
+
+// CheckTrim verifies TRIM support and configuration for the filesystem
+// holding path. Misconfigurations that silently degrade SSD performance are
+// listed in Issues; steps that could not run are explained in Notes.
+func CheckTrim(ctx context.Context, path string, opts TrimOptions) (*TrimReport, error) {
+	mount, err := TrimMount(path)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &TrimReport{
+		Device:     mount.Device,
+		Mountpoint: mount.Mountpoint,
+		Verified:   TrimVerifySkipped,
+	}
+	if err := checkTrim(ctx, mount, path, opts, report); err != nil {
+		return report, err
+	}
+
+	if !report.Rotational && !report.Supported {
+		report.addIssue("device does not accept TRIM/discard; check the controller, RAID or USB bridge")
+	}
+	if report.Supported && !report.Enabled {
+		report.addIssue("TRIM is supported but the OS never issues it; free space will not be reclaimed")
+	}
+	return report, nil
+}
+
+// TrimMount returns the filesystem holding path, refusing one without a
+// block device to trim, such as the tmpfs /tmp often is
+func TrimMount(path string) (*Mount, error) {
+	mount, err := MountForPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if !mount.BlockDevice() {
+		return nil, fmt.Errorf("%s is on a %s filesystem (%s) with no block device to trim; use a directory on the SSD", path, mount.Fstype, mount.Mountpoint)
+	}
+	return mount, nil
+}
+
+func (r *TrimReport) addIssue(format string, args ...interface{}) {
+	r.Issues = append(r.Issues, fmt.Sprintf(format, args...))
+}
+
+func (r *TrimReport) addNote(format string, args ...interface{}) {
+	r.Notes = append(r.Notes, fmt.Sprintf(format, args...))
+}
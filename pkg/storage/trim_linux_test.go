@@ -0,0 +1,30 @@
+//go:build linux
+// +build linux
+
+package storage
+
+import "testing"
+
+func TestParseFilefrag(t *testing.T) {
+	output := `Filesystem type is: ef53
+File size of /data/fire-trim-1.dat is 4194304 (1024 blocks of 4096 bytes)
+ ext:     logical_offset:        physical_offset: length:   expected: flags:
+   0:        0..     511:   34816000..  34816511:    512:
+   1:      512..    1023:   35000000..  35000511:    512:   34816512: last,eof
+/data/fire-trim-1.dat: 2 extents found
+`
+	extents, blockSize, err := parseFilefrag(output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if blockSize != 4096 {
+		t.Errorf("expected block size 4096, got %d", blockSize)
+	}
+	if len(extents) != 2 || extents[0] != (extent{34816000, 512}) || extents[1] != (extent{35000000, 512}) {
+		t.Errorf("unexpected extents: %+v", extents)
+	}
+
+	if _, _, err := parseFilefrag("Filesystem type is: 9123683e\n"); err == nil {
+		t.Error("expected an error when no extents are reported")
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+func checkTrim(_ context.Context, _ *Mount, _ string, _ TrimOptions, _ *TrimReport) error {
+	return fmt.Errorf("TRIM verification is not supported on this platform")
+}
@@ -0,0 +1,66 @@
+//go:build windows
+// +build windows
+
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+func checkTrim(ctx context.Context, mount *Mount, _ string, opts TrimOptions, report *TrimReport) error {
+	letter := strings.TrimSuffix(strings.ToUpper(filepath.VolumeName(mount.Mountpoint)), ":")
+
+	// Windows only sends TRIM to drives it identifies as solid state
+	chars, err := Detect(letter+":", "")
+	if err != nil {
+		report.addNote("media type unknown: %v", err)
+	}
+	report.Rotational = chars.Rotational
+	report.Supported = !chars.Rotational
+	if report.Rotational {
+		report.addNote("rotational drive: TRIM does not apply")
+		return nil
+	}
+	report.addNote("device support inferred from media type; Windows does not expose discard limits")
+
+	// "NTFS DisableDeleteNotify = 0  (Disabled)" means TRIM is sent
+	output, err := safeexec.CommandContext(ctx, "fsutil", "behavior", "query", "DisableDeleteNotify").Output()
+	if err != nil {
+		report.addNote("fsutil failed: %v", err)
+	} else if notifyEnabled(string(output), mount.Fstype) {
+		report.Enabled = true
+		report.Method = "delete notifications"
+	}
+
+	if !opts.Issue {
+		return nil
+	}
+	if _, err := safeexec.PowerShell(`Optimize-Volume -DriveLetter %s -ReTrim -ErrorAction Stop`, letter).CombinedOutput(); err != nil {
+		report.addNote("Optimize-Volume -ReTrim failed (Administrator is required): %v", err)
+		return nil
+	}
+	report.Issued = true
+	if opts.Verify {
+		report.addNote("read-back verification is not available on Windows")
+	}
+	return nil
+}
+
+// notifyEnabled parses fsutil DisableDeleteNotify output for a filesystem
+func notifyEnabled(output, fstype string) bool {
+	prefix := "NTFS"
+	if strings.EqualFold(fstype, "ReFS") {
+		prefix = "ReFS"
+	}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, prefix+" DisableDeleteNotify") {
+			return strings.Contains(line, "= 0")
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package storage
+
+import "strings"
+
+// Write cache states
+const (
+	CacheEnabled  = "enabled"
+	CacheDisabled = "disabled"
+	CacheNone     = "none" // The drive has no volatile write cache
+)
+
+// Power-loss protection states
+const (
+	PLPYes     = "yes"
+	PLPNo      = "no"
+	PLPUnknown = "unknown"
+)
+
+// WriteCache describes the volatile write cache of a drive and whether the
+// drive can flush it on power loss. An enabled cache makes write benchmarks
+// faster; without power-loss protection it also means acknowledged writes
+// can be lost when the power fails.
+type WriteCache struct {
+	Device    string   `json:"device"`
+	Model     string   `json:"model,omitempty"`
+	Cache     string   `json:"cache"`                 // enabled, disabled or none
+	PLP       string   `json:"power_loss_protection"` // yes, no or unknown
+	PLPSource string   `json:"plp_source,omitempty"`  // how PLP was detected: drive or model
+	Warnings  []string `json:"warnings,omitempty"`
+}
+
+// plpModels lists model substrings of SSDs with power-loss protection
+// capacitors, without vendor prefixes. SATA drives do not advertise it, so
+// the model number is the only indication.
+var plpModels = []string{
+	// Intel/Solidigm data center SATA and NVMe
+	"SSDSC2BA", "SSDSC2BB", "SSDSC2KB", "SSDSC2KG", "SSDPE2KX", "SSDPE2KE", "SSDPF2KX",
+	// Samsung data center
+	"MZ7LH", "MZ7L3", "MZ7KH", "MZ7KM", "MZQL2", "MZQLB", "MZPLJ", "PM883", "PM893", "PM897", "SM883", "PM9A3", "PM1733",
+	// Micron 5100-5300 SATA and 9300 NVMe
+	"MTFDDAK", "MTFDHAL",
+	// Kingston data center
+	"SEDC500", "SEDC600", "SEDC1500", "SEDC3000",
+}
+
+// ReadWriteCache reports the write cache state and power-loss protection of a
+// whole-disk device. Model is used for power-loss protection when the drive
+// does not report it.
+func ReadWriteCache(device, model string) (*WriteCache, error) {
+	wc := &WriteCache{
+		Device: device,
+		Model:  strings.TrimSpace(model),
+		PLP:    PLPUnknown,
+	}
+	err := readWriteCache(wc)
+	if wc.PLP == PLPUnknown && wc.Model != "" {
+		upper := strings.ToUpper(wc.Model)
+		for _, m := range plpModels {
+			if strings.Contains(upper, m) {
+				wc.PLP = PLPYes
+				wc.PLPSource = "model"
+				break
+			}
+		}
+	}
+	wc.Warnings = wc.warnings()
+	return wc, err
+}
+
+// SetWriteCache enables or disables the volatile write cache of a
+// whole-disk device. It usually needs root, and many drives return to their
+// default setting when power cycled.
+func SetWriteCache(device string, enable bool) error {
+	return setWriteCache(device, enable)
+}
+
+// Safe reports whether data acknowledged by the drive survives a power loss
+func (wc *WriteCache) Safe() bool {
+	return wc.Cache != CacheEnabled || wc.PLP == PLPYes
+}
+
+// Summary returns a one-line description, e.g. "Write cache enabled, no
+// power-loss protection"
+func (wc *WriteCache) Summary() string {
+	var s string
+	switch wc.Cache {
+	case CacheNone:
+		s = "No volatile write cache"
+	case "":
+		s = "Write cache unknown"
+	default:
+		s = "Write cache " + wc.Cache
+	}
+	switch wc.PLP {
+	case PLPYes:
+		s += ", power-loss protected"
+	case PLPNo:
+		s += ", no power-loss protection"
+	}
+	return s
+}
+
+// warnings explains how the settings affect results and data safety
+func (wc *WriteCache) warnings() []string {
+	var w []string
+	switch {
+	case wc.Cache == CacheDisabled:
+		w = append(w, "Write cache is disabled: write benchmarks will be far slower than the drive's rating")
+	case !wc.Safe():
+		w = append(w, "Write cache is enabled without known power-loss protection: writes the drive has acknowledged are lost if power fails before they are flushed")
+	}
+	return w
+}
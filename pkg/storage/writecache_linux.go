@@ -0,0 +1,134 @@
+//go:build linux
+// +build linux
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// Cache modes of the Linux block and SCSI disk layers
+const (
+	writeBack    = "write back"
+	writeThrough = "write through"
+)
+
+// vwceLine matches nvme get-feature -H output for the volatile write cache
+// feature, e.g. "Volatile Write Cache Enable (VWCE): Enabled"
+var vwceLine = regexp.MustCompile(`\(VWCE\):\s*(Enabled|Disabled)`)
+
+// nvmeFeature runs nvme-cli for the volatile write cache feature, replaced in
+// tests. Value is empty to read the feature, "0" or "1" to set it.
+var nvmeFeature = func(device, value string) (string, error) {
+	if err := safeexec.ValidateDevicePath(device); err != nil {
+		return "", err
+	}
+	args := []string{"get-feature", device, "--feature-id=6", "--human-readable"}
+	if value != "" {
+		args = []string{"set-feature", device, "--feature-id=6", "--value=" + value}
+	}
+	output, err := safeexec.Command("nvme", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("nvme %s failed: %w: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
+
+// readWriteCache reads the cache mode the SCSI disk driver reports for SATA
+// and SAS drives, or asks an NVMe drive through nvme-cli. The kernel marks
+// NVMe queues write back only when the drive has a volatile cache.
+func readWriteCache(wc *WriteCache) error {
+	name := filepath.Base(wc.Device)
+	block := filepath.Join(sysfsRoot, "sys/block", name)
+	mode := readAttr(filepath.Join(block, "queue", "write_cache"))
+	if mode == "" {
+		return fmt.Errorf("failed to read the write cache mode of %s", name)
+	}
+	if wc.Model == "" {
+		wc.Model = readAttr(filepath.Join(block, "device", "model"))
+	}
+
+	if strings.HasPrefix(name, "nvme") {
+		if mode != writeBack {
+			wc.Cache = CacheNone
+			return nil
+		}
+		wc.Cache = CacheEnabled
+		if output, err := nvmeFeature(wc.Device, ""); err == nil {
+			if m := vwceLine.FindStringSubmatch(output); m != nil && m[1] == "Disabled" {
+				wc.Cache = CacheDisabled
+			}
+		}
+		return nil
+	}
+
+	// The SCSI disk driver reads the drive's caching mode page; the queue
+	// attribute can be overridden by writes from userspace
+	if path := scsiCacheType(block); path != "" {
+		mode = readAttr(path)
+	}
+	if strings.HasPrefix(mode, writeBack) {
+		wc.Cache = CacheEnabled
+	} else {
+		wc.Cache = CacheDisabled
+	}
+	return nil
+}
+
+// setWriteCache changes the caching mode page of SATA and SAS drives through
+// the SCSI disk driver, and the volatile write cache feature of NVMe drives
+// through nvme-cli
+func setWriteCache(device string, enable bool) error {
+	name := filepath.Base(device)
+	block := filepath.Join(sysfsRoot, "sys/block", name)
+
+	if strings.HasPrefix(name, "nvme") {
+		// The kernel only sends flushes to queues it marked write back; a
+		// cache enabled behind its back would lose data on power failure
+		if readAttr(filepath.Join(block, "queue", "write_cache")) != writeBack {
+			return fmt.Errorf("%s has no volatile write cache", name)
+		}
+		value := "0"
+		if enable {
+			value = "1"
+		}
+		_, err := nvmeFeature(device, value)
+		return err
+	}
+
+	path := scsiCacheType(block)
+	if path == "" {
+		return fmt.Errorf("%s is not a SCSI or SATA disk", name)
+	}
+	mode := writeThrough
+	if enable {
+		mode = writeBack
+	}
+	if err := os.WriteFile(path, []byte(mode), 0o600); err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return fmt.Errorf("changing the write cache of %s needs root: %w", name, err)
+		}
+		return fmt.Errorf("failed to set the write cache of %s: %w", name, err)
+	}
+	if got := readAttr(path); strings.HasPrefix(got, writeBack) != enable {
+		return fmt.Errorf("%s did not accept the change: cache is %s", name, got)
+	}
+	return nil
+}
+
+// scsiCacheType returns the cache_type attribute of the SCSI disk behind a
+// block device, e.g. /sys/block/sda/device/scsi_disk/0:0:0:0/cache_type
+func scsiCacheType(block string) string {
+	matches, err := filepath.Glob(filepath.Join(block, "device", "scsi_disk", "*", "cache_type"))
+	if err != nil || len(matches) == 0 {
+		return ""
+	}
+	return matches[0]
+}
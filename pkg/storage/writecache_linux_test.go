@@ -0,0 +1,76 @@
+//go:build linux
+// +build linux
+
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadWriteCache(t *testing.T) {
+	dir := t.TempDir()
+	sysfsRoot = dir
+	defer func() { sysfsRoot = "/" }()
+	readFeature := nvmeFeature
+	var setValue string
+	nvmeFeature = func(device, value string) (string, error) {
+		if value != "" {
+			setValue = value
+			return "", nil
+		}
+		return "get-feature:0x06 (Volatile Write Cache), Current value:00000000\n" +
+			"\tVolatile Write Cache Enable (VWCE): Disabled\n", nil
+	}
+	defer func() { nvmeFeature = readFeature }()
+
+	writeAttr(t, dir, "sys/block/sda/queue/write_cache", "write back")
+	writeAttr(t, dir, "sys/block/sda/device/scsi_disk/0:0:0:0/cache_type", "write back")
+	writeAttr(t, dir, "sys/block/sdb/queue/write_cache", "write through")
+	writeAttr(t, dir, "sys/block/sdb/device/scsi_disk/1:0:0:0/cache_type", "write through")
+	writeAttr(t, dir, "sys/block/nvme0n1/queue/write_cache", "write back")
+	writeAttr(t, dir, "sys/block/nvme1n1/queue/write_cache", "write through")
+
+	tests := []struct {
+		device  string
+		model   string
+		cache   string
+		plp     string
+		warning string
+	}{
+		{"/dev/sda", "Samsung SSD 870 EVO 1TB", CacheEnabled, PLPUnknown, "without known power-loss protection"},
+		{"/dev/sda", "INTEL SSDSC2KB960G8", CacheEnabled, PLPYes, ""},
+		{"/dev/sdb", "ST4000VN008-2DR166", CacheDisabled, PLPUnknown, "far slower"},
+		{"/dev/nvme0n1", "WD_BLACK SN850X 2000GB", CacheDisabled, PLPUnknown, "far slower"},
+		{"/dev/nvme1n1", "SAMSUNG MZQL23T8HCLS-00A07", CacheNone, PLPYes, ""},
+	}
+	for _, tt := range tests {
+		wc, err := ReadWriteCache(tt.device, tt.model)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.device, err)
+			continue
+		}
+		if wc.Cache != tt.cache || wc.PLP != tt.plp {
+			t.Errorf("%s (%s): expected %s/%s, got %+v", tt.device, tt.model, tt.cache, tt.plp, wc)
+		}
+		switch {
+		case tt.warning == "" && len(wc.Warnings) > 0:
+			t.Errorf("%s: expected no warning, got %v", tt.device, wc.Warnings)
+		case tt.warning != "" && (len(wc.Warnings) == 0 || !strings.Contains(wc.Warnings[0], tt.warning)):
+			t.Errorf("%s: expected warning containing %q, got %v", tt.device, tt.warning, wc.Warnings)
+		}
+	}
+
+	if err := SetWriteCache("/dev/sdb", true); err != nil {
+		t.Fatal(err)
+	}
+	if wc, _ := ReadWriteCache("/dev/sdb", ""); wc.Cache != CacheEnabled {
+		t.Errorf("expected the cache of sdb enabled, got %s", wc.Cache)
+	}
+	if err := SetWriteCache("/dev/nvme0n1", true); err != nil || setValue != "1" {
+		t.Errorf("expected set-feature with value 1, got %q (%v)", setValue, err)
+	}
+	if err := SetWriteCache("/dev/nvme1n1", true); err == nil {
+		t.Error("expected an error enabling a cache the drive does not have")
+	}
+}
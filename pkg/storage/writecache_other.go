@@ -0,0 +1,14 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package storage
+
+import "fmt"
+
+func readWriteCache(_ *WriteCache) error {
+	return fmt.Errorf("write cache status is not supported on this platform")
+}
+
+func setWriteCache(_ string, _ bool) error {
+	return fmt.Errorf("changing the write cache is not supported on this platform")
+}
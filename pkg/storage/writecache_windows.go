@@ -0,0 +1,112 @@
+//go:build windows
+// +build windows
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"unsafe"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+	"golang.org/x/sys/windows"
+)
+
+// Disk cache control codes from ntdddisk.h
+const (
+	ioctlDiskGetCacheInformation = 0x740d4
+	ioctlDiskSetCacheInformation = 0x7c0d8
+)
+
+// diskCacheInformation is DISK_CACHE_INFORMATION; only WriteCacheEnabled is
+// changed, the rest is passed back as the drive reported it
+type diskCacheInformation struct {
+	ParametersSavable             byte
+	ReadCacheEnabled              byte
+	WriteCacheEnabled             byte
+	ReadRetentionPriority         uint32
+	WriteRetentionPriority        uint32
+	DisablePrefetchTransferLength uint16
+	PrefetchScalar                byte
+	Prefetch                      [3]uint16
+}
+
+// advancedProperty is the output of Get-StorageAdvancedProperty; fields are
+// null when the drive does not report them
+type advancedProperty struct {
+	IsDeviceCacheEnabled *bool `json:"IsDeviceCacheEnabled"`
+	IsPowerProtected     *bool `json:"IsPowerProtected"`
+}
+
+// readWriteCache asks Storage Management, which reports power-loss
+// protection as the drive advertises it
+func readWriteCache(wc *WriteCache) error {
+	number, err := diskNumber(wc.Device)
+	if err != nil {
+		return err
+	}
+
+	output, err := safeexec.PowerShell(`Get-PhysicalDisk | Where-Object { $_.DeviceId -eq '%d' } | `+
+		`Get-StorageAdvancedProperty | Select-Object IsDeviceCacheEnabled, IsPowerProtected | ConvertTo-Json -Compress`, number).Output()
+	if err != nil {
+		return fmt.Errorf("failed to query advanced properties of disk %d: %w", number, err)
+	}
+
+	var prop advancedProperty
+	if err := json.Unmarshal(output, &prop); err != nil {
+		return fmt.Errorf("failed to parse advanced properties of disk %d: %w", number, err)
+	}
+	if prop.IsDeviceCacheEnabled != nil {
+		wc.Cache = CacheDisabled
+		if *prop.IsDeviceCacheEnabled {
+			wc.Cache = CacheEnabled
+		}
+	}
+	if prop.IsPowerProtected != nil {
+		wc.PLP = PLPNo
+		wc.PLPSource = "drive"
+		if *prop.IsPowerProtected {
+			wc.PLP = PLPYes
+		}
+	}
+	return nil
+}
+
+// setWriteCache reads the cache settings of the physical drive and writes
+// them back with the write cache changed. The disk class driver turns this
+// into a caching mode page for SATA and SAS drives and the volatile write
+// cache feature for NVMe drives.
+func setWriteCache(device string, enable bool) error {
+	number, err := diskNumber(device)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf(`\\.\PHYSICALDRIVE%d`, number)
+	path, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+	h, err := windows.CreateFile(path, windows.GENERIC_READ|windows.GENERIC_WRITE,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE, nil, windows.OPEN_EXISTING, 0, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer func() { _ = windows.CloseHandle(h) }()
+
+	var info diskCacheInformation
+	size := uint32(unsafe.Sizeof(info))
+	var returned uint32
+	if err := windows.DeviceIoControl(h, ioctlDiskGetCacheInformation, nil, 0,
+		(*byte)(unsafe.Pointer(&info)), size, &returned, nil); err != nil {
+		return fmt.Errorf("failed to read the cache settings of disk %d: %w", number, err)
+	}
+	info.WriteCacheEnabled = 0
+	if enable {
+		info.WriteCacheEnabled = 1
+	}
+	if err := windows.DeviceIoControl(h, ioctlDiskSetCacheInformation,
+		(*byte)(unsafe.Pointer(&info)), size, nil, 0, &returned, nil); err != nil {
+		return fmt.Errorf("failed to change the write cache of disk %d: %w", number, err)
+	}
+	return nil
+}
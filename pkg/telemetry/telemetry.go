@@ -13,6 +13,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/mscrnt/project_fire/pkg/logging"
 )
 
 // Event represents a single telemetry event
@@ -57,24 +59,11 @@ var (
 	shutdownChan  chan struct{}
 	telemetryAuth string
 
-	// Debug logging
-	logFile *os.File
-)
+	// Whether the background flusher is running; guarded by telemetryMu
+	flusherRunning bool
 
-// logToFile writes telemetry debug info to fire-gui.log
-func logToFile(msg string) {
-	if logFile == nil {
-		// Try to open log file
-		var err error
-		logFile, err = os.OpenFile("fire-gui.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
-		if err != nil {
-			return
-		}
-	}
-	timestamp := time.Now().Format("2006-01-02 15:04:05.000")
-	fmt.Fprintf(logFile, "[%s] TELEMETRY: %s\n", timestamp, msg)
-	_ = logFile.Sync()
-}
+	logger = logging.For("telemetry")
+)
 
 // init initializes the telemetry service configuration
 func init() {
@@ -125,8 +114,7 @@ func Initialize(endpoint, apiKey string, enabled bool) {
 	// Check environment variable override
 	if os.Getenv("FIRE_TELEMETRY_DISABLED") == "true" {
 		enabled = false
-		fmt.Printf("[TELEMETRY] Disabled by environment variable\n")
-		logToFile("Disabled by environment variable")
+		logger.Info("Disabled by environment variable")
 	}
 
 	if endpoint == "" {
@@ -142,12 +130,9 @@ func Initialize(endpoint, apiKey string, enabled bool) {
 	}
 
 	if enabled {
-		msg := fmt.Sprintf("Initializing - endpoint: %s, version: %s", endpoint, appVersion)
-		fmt.Printf("[TELEMETRY] %s\n", msg)
-		logToFile(msg)
+		logger.Info("Initializing", "endpoint", endpoint, "version", appVersion)
 	} else {
-		fmt.Printf("[TELEMETRY] Disabled\n")
-		logToFile("Disabled")
+		logger.Info("Disabled")
 	}
 
 	client = &Client{
@@ -164,36 +149,63 @@ func Initialize(endpoint, apiKey string, enabled bool) {
 	if enabled {
 		// Test connection
 		go func() {
-			msg := fmt.Sprintf("Testing connection to %s...", endpoint)
-			fmt.Printf("[TELEMETRY] %s\n", msg)
-			logToFile(msg)
-
+			logger.Debug("Testing connection", "endpoint", endpoint)
 			if err := client.TestConnection(); err != nil {
-				msg = fmt.Sprintf("Connection test failed: %v", err)
-				fmt.Printf("[TELEMETRY] %s\n", msg)
-				logToFile(msg)
+				logger.Warn("Connection test failed", "error", err)
 			} else {
-				msg = "Connection test successful!"
-				fmt.Printf("[TELEMETRY] %s\n", msg)
-				logToFile(msg)
+				logger.Debug("Connection test successful")
 			}
 		}()
 
 		// Start background flusher
-		go backgroundFlusher()
+		startFlusher()
 	}
 }
 
+// SetEnabled turns telemetry on or off after Initialize, e.g. when the user
+// opts out in the GUI. Events buffered while it was on are dropped when it
+// is turned off.
+func SetEnabled(enabled bool) {
+	if client == nil {
+		return
+	}
+	telemetryMu.Lock()
+	client.enabled = enabled
+	telemetryEnabled = enabled
+	if !enabled {
+		telemetryBuf = nil
+	}
+	telemetryMu.Unlock()
+
+	if enabled {
+		logger.Info("Enabled")
+		startFlusher()
+	} else {
+		logger.Info("Disabled")
+	}
+}
+
+// startFlusher starts the background flusher unless it is running
+func startFlusher() {
+	telemetryMu.Lock()
+	defer telemetryMu.Unlock()
+	if flusherRunning {
+		return
+	}
+	flusherRunning = true
+	go backgroundFlusher()
+}
+
 // RecordEvent adds an event to the telemetry buffer
 func RecordEvent(eventType string, details map[string]interface{}) {
 	if !telemetryEnabled || client == nil {
 		if !telemetryEnabled {
-			fmt.Printf("[TELEMETRY] Skipping event (disabled) - type: %s\n", eventType)
+			logger.Debug("Skipping event, telemetry is disabled", "type", eventType)
 		}
 		return
 	}
 
-	fmt.Printf("[TELEMETRY] Recording event - type: %s, details: %v\n", eventType, details)
+	logger.Debug("Recording event", "type", eventType, "details", details)
 
 	event := Event{
 		Timestamp:  time.Now().Unix(),
@@ -214,7 +226,7 @@ func RecordEvent(eventType string, details map[string]interface{}) {
 	}
 
 	telemetryBuf = append(telemetryBuf, event)
-	fmt.Printf("[TELEMETRY] Buffer size: %d events\n", len(telemetryBuf))
+	logger.Debug("Buffered events", "count", len(telemetryBuf))
 }
 
 // RecordHardwareMiss records a hardware detection failure
@@ -251,17 +263,17 @@ func FlushTelemetry() {
 		return
 	}
 
-	fmt.Printf("[TELEMETRY] Flushing %d events to %s\n", len(events), client.endpoint)
+	logger.Debug("Flushing events", "count", len(events), "endpoint", client.endpoint)
 
 	// Send events
 	if err := client.Send(events); err != nil {
-		fmt.Printf("[TELEMETRY] Failed to send events: %v\n", err)
+		logger.Warn("Failed to send events", "error", err)
 		// Re-buffer failed events
 		telemetryMu.Lock()
 		telemetryBuf = append(events, telemetryBuf...)
 		telemetryMu.Unlock()
 	} else {
-		fmt.Printf("[TELEMETRY] Successfully sent %d events\n", len(events))
+		logger.Debug("Sent events", "count", len(events))
 	}
 }
 
@@ -299,9 +311,7 @@ func (c *Client) TestConnection() error {
 	// Don't use Basic Auth for S3 bucket - it expects AWS signatures or anonymous access
 	// The bucket should be configured for public write access for telemetry
 
-	msg := fmt.Sprintf("Sending test request to %s", bucketURL)
-	fmt.Printf("[TELEMETRY] %s\n", msg)
-	logToFile(msg)
+	logger.Debug("Sending test request", "url", bucketURL)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -310,9 +320,7 @@ func (c *Client) TestConnection() error {
 	defer resp.Body.Close()
 
 	body, _ := io.ReadAll(resp.Body)
-	msg = fmt.Sprintf("Response: %d - %s", resp.StatusCode, string(body))
-	fmt.Printf("[TELEMETRY] %s\n", msg)
-	logToFile(msg)
+	logger.Debug("Test response", "status", resp.StatusCode, "body", string(body))
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
@@ -333,7 +341,7 @@ func (c *Client) Send(events []Event) error {
 		return fmt.Errorf("failed to marshal telemetry: %w", err)
 	}
 
-	fmt.Printf("[TELEMETRY] Sending %d bytes to %s\n", len(data), c.endpoint)
+	logger.Debug("Sending events", "bytes", len(data), "endpoint", c.endpoint)
 
 	// Retry logic with exponential backoff
 	delays := []time.Duration{1 * time.Second, 2 * time.Second, 4 * time.Second}
@@ -389,21 +397,26 @@ func (c *Client) Send(events []Event) error {
 
 // backgroundFlusher periodically sends buffered events
 func backgroundFlusher() {
-	fmt.Printf("[TELEMETRY] Background flusher started - will flush every %v\n", flushInterval)
+	logger.Debug("Background flusher started", "interval", flushInterval)
 	ticker := time.NewTicker(flushInterval)
 	defer ticker.Stop()
+	defer func() {
+		telemetryMu.Lock()
+		flusherRunning = false
+		telemetryMu.Unlock()
+	}()
 
 	for {
 		select {
 		case <-ticker.C:
 			if !telemetryEnabled {
-				fmt.Printf("[TELEMETRY] Background flusher stopping (telemetry disabled)\n")
+				logger.Debug("Background flusher stopping, telemetry is disabled")
 				return
 			}
-			fmt.Printf("[TELEMETRY] Background flush triggered\n")
+			logger.Debug("Background flush triggered")
 			FlushTelemetry()
 		case <-shutdownChan:
-			fmt.Printf("[TELEMETRY] Background flusher stopping (shutdown signal received)\n")
+			fmt.Fprintf(os.Stderr, "[TELEMETRY] Background flusher stopping (shutdown signal received)\n")
 			return
 		}
 	}
@@ -411,7 +424,7 @@ func backgroundFlusher() {
 
 // Shutdown flushes any remaining events and stops the telemetry system
 func Shutdown() {
-	fmt.Printf("[TELEMETRY] Shutdown called\n")
+	fmt.Fprintf(os.Stderr, "[TELEMETRY] Shutdown called\n")
 	telemetryEnabled = false
 
 	// Signal shutdown to background flusher
@@ -422,5 +435,5 @@ func Shutdown() {
 	}
 
 	FlushTelemetry()
-	fmt.Printf("[TELEMETRY] Shutdown complete\n")
+	fmt.Fprintf(os.Stderr, "[TELEMETRY] Shutdown complete\n")
 }
@@ -0,0 +1,398 @@
+// Package testplan runs declarative multi-stage test plans, such as a
+// burn-in of 30 minutes of CPU load, 30 minutes of memory, two hours of both
+// at once and a cooldown, from a YAML or JSON file.
+//
+// Each stage runs one or more plugins for a duration, idles for a pause, or
+// waits for the operator. Abort thresholds on the readings bench monitor
+// takes (cpu_temp, gpu0_temp, ...) stop a stage as soon as one is crossed.
+// A stage can pin every controllable fan at a duty cycle while it runs; the
+// fans go back to their curve when it ends. Every plan run, its stages and
+// the runs they started are stored in the results database.
+package testplan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/fancontrol"
+	"github.com/mscrnt/project_fire/pkg/plugin"
+	"github.com/mscrnt/project_fire/pkg/safety"
+	"gopkg.in/yaml.v3"
+)
+
+// Plan is a test plan file
+type Plan struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+
+	// Abort thresholds checked during every stage, e.g. "cpu_temp > 95"
+	Abort []Threshold `yaml:"abort,omitempty" json:"abort,omitempty"`
+
+	// Interval between the readings thresholds are checked against
+	Interval Duration `yaml:"interval,omitempty" json:"interval,omitempty"`
+
+	// ContinueOnFailure runs the remaining stages after one fails or aborts
+	ContinueOnFailure bool `yaml:"continue_on_failure,omitempty" json:"continue_on_failure,omitempty"`
+
+	// Resume lets bench plan resume pick the plan up again, without naming
+	// the run, when a crash, power loss or restart cut it short
+	Resume bool `yaml:"resume,omitempty" json:"resume,omitempty"`
+
+	Stages []Stage `yaml:"stages" json:"stages"`
+}
+
+// Stage is one step of a plan. A stage with plugins runs them together for
+// its duration; a stage with a pause idles, ending early once Until holds; a
+// stage with a prompt waits for the operator.
+type Stage struct {
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
+	Plugin   string                 `yaml:"plugin,omitempty" json:"plugin,omitempty"`
+	Plugins  []string               `yaml:"plugins,omitempty" json:"plugins,omitempty"` // Run at the same time
+	Duration Duration               `yaml:"duration,omitempty" json:"duration,omitempty"`
+	Threads  int                    `yaml:"threads,omitempty" json:"threads,omitempty"`
+	Config   map[string]interface{} `yaml:"config,omitempty" json:"config,omitempty"`
+
+	Pause Duration   `yaml:"pause,omitempty" json:"pause,omitempty"`
+	Until *Threshold `yaml:"until,omitempty" json:"until,omitempty"`
+
+	Prompt string `yaml:"prompt,omitempty" json:"prompt,omitempty"`
+
+	// Abort thresholds checked during this stage, on top of the plan's
+	Abort []Threshold `yaml:"abort,omitempty" json:"abort,omitempty"`
+
+	// Fans is the duty cycle in percent every controllable fan is pinned at
+	// during the stage, 0 to leave them on their curve
+	Fans int `yaml:"fans,omitempty" json:"fans,omitempty"`
+}
+
+// Stage kinds
+const (
+	KindTest   = "test"
+	KindPause  = "pause"
+	KindPrompt = "prompt"
+)
+
+// DefaultInterval is how often readings are checked when the plan sets none
+const DefaultInterval = 5 * time.Second
+
+// Kind reports whether the stage runs plugins, pauses or prompts
+func (s Stage) Kind() string {
+	switch {
+	case s.Prompt != "":
+		return KindPrompt
+	case s.Pause > 0:
+		return KindPause
+	default:
+		return KindTest
+	}
+}
+
+// PluginNames returns the plugins the stage runs
+func (s Stage) PluginNames() []string {
+	if s.Plugin != "" {
+		return append([]string{s.Plugin}, s.Plugins...)
+	}
+	return s.Plugins
+}
+
+// Title names the stage for progress output, e.g. "Mixed (cpu + memory)"
+func (s Stage) Title() string {
+	var what string
+	switch s.Kind() {
+	case KindTest:
+		what = strings.Join(s.PluginNames(), " + ")
+	case KindPause:
+		what = "pause " + s.Pause.String()
+	default:
+		what = "prompt"
+	}
+	if s.Name == "" {
+		return what
+	}
+	return fmt.Sprintf("%s (%s)", s.Name, what)
+}
+
+// params returns the parameters the stage runs a plugin with: its defaults
+// overridden by the stage's duration, threads and config
+func (s Stage) params(p plugin.TestPlugin) plugin.Params {
+	params := p.DefaultParams()
+	if s.Duration > 0 {
+		params.Duration = time.Duration(s.Duration)
+	}
+	if s.Threads > 0 {
+		params.Threads = s.Threads
+	}
+	if params.Config == nil {
+		params.Config = make(map[string]interface{})
+	}
+	for k, v := range s.Config {
+		params.Config[k] = v
+	}
+	return params
+}
+
+// Length returns how long the stage runs at most, or 0 for a prompt or a
+// stage using its plugins' default durations
+func (s Stage) Length() time.Duration {
+	if s.Kind() == KindPause {
+		return time.Duration(s.Pause)
+	}
+	return time.Duration(s.Duration)
+}
+
+// Load reads a plan from a .yaml, .yml or .json file and validates it
+func Load(path string) (*Plan, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- plan file named by the user
+	if err != nil {
+		return nil, err
+	}
+	p, err := Parse(data, strings.EqualFold(filepath.Ext(path), ".json"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Parse decodes a plan from YAML, or JSON when isJSON is set, and validates
+// it. Unknown fields are rejected so a misspelt key is not silently ignored.
+func Parse(data []byte, isJSON bool) (*Plan, error) {
+	p := &Plan{}
+	if isJSON {
+		dec := json.NewDecoder(strings.NewReader(string(data)))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(p); err != nil {
+			return nil, fmt.Errorf("invalid plan: %w", err)
+		}
+	} else {
+		dec := yaml.NewDecoder(strings.NewReader(string(data)))
+		dec.KnownFields(true)
+		if err := dec.Decode(p); err != nil {
+			return nil, fmt.Errorf("invalid plan: %w", err)
+		}
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Validate checks that every stage does one thing and names registered
+// plugins
+func (p *Plan) Validate() error {
+	if len(p.Stages) == 0 {
+		return errors.New("plan has no stages")
+	}
+	if p.Interval < 0 {
+		return errors.New("interval must be positive")
+	}
+	for i, s := range p.Stages {
+		n := fmt.Sprintf("stage %d", i+1)
+		if s.Name != "" {
+			n += fmt.Sprintf(" (%s)", s.Name)
+		}
+		names := s.PluginNames()
+		kinds := 0
+		for _, set := range []bool{len(names) > 0, s.Pause != 0, s.Prompt != ""} {
+			if set {
+				kinds++
+			}
+		}
+		if kinds != 1 {
+			return fmt.Errorf("%s: set exactly one of plugin(s), pause or prompt", n)
+		}
+		if s.Duration < 0 || s.Pause < 0 {
+			return fmt.Errorf("%s: durations must be positive", n)
+		}
+		if s.Until != nil && s.Kind() != KindPause {
+			return fmt.Errorf("%s: until only applies to a pause", n)
+		}
+		if s.Fans != 0 && (s.Fans < fancontrol.MinDuty || s.Fans > fancontrol.FullDuty) {
+			return fmt.Errorf("%s: fans must be between %d and %d%%", n, fancontrol.MinDuty, fancontrol.FullDuty)
+		}
+		seen := make(map[string]bool)
+		for _, name := range names {
+			plug, err := plugin.Get(name)
+			if err != nil {
+				return fmt.Errorf("%s: %w", n, err)
+			}
+			if seen[name] {
+				return fmt.Errorf("%s: plugin %s is listed twice", n, name)
+			}
+			seen[name] = true
+			if err := plug.ValidateParams(s.params(plug)); err != nil {
+				return fmt.Errorf("%s: %s: invalid parameters: %w", n, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// PinsFans reports whether any stage pins the fans
+func (p *Plan) PinsFans() bool {
+	for _, s := range p.Stages {
+		if s.Fans > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Guarded reports whether the load of a stage is stopped at a temperature,
+// by limits or by an abort threshold on a temperature reading. Pinning the
+// fans below full speed needs that.
+func (p *Plan) Guarded(stage Stage, limits safety.Limits) bool {
+	if limits.CPUTempC > 0 || limits.GPUTempC > 0 {
+		return true
+	}
+	for _, t := range append(append([]Threshold(nil), p.Abort...), stage.Abort...) {
+		if strings.HasSuffix(t.Metric, "_temp") && (t.Op == ">" || t.Op == ">=") {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckFans refuses a plan with a stage that pins the fans below full speed
+// without being guarded by limits or a temperature abort threshold
+func (p *Plan) CheckFans(limits safety.Limits) error {
+	for i, s := range p.Stages {
+		if s.Fans > 0 && s.Fans < fancontrol.FullDuty && !p.Guarded(s, limits) {
+			return fmt.Errorf("stage %d: %w; set a safety limit or an abort threshold such as cpu_temp > 95", i+1, fancontrol.ErrUnguarded)
+		}
+	}
+	return nil
+}
+
+// Length returns the planned duration of the stages that have one
+func (p *Plan) Length() time.Duration {
+	var total time.Duration
+	for _, s := range p.Stages {
+		total += s.Length()
+	}
+	return total
+}
+
+// Duration is a time.Duration written as "30m" or "2h" in plan files
+type Duration time.Duration
+
+// String formats the duration like time.Duration
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// UnmarshalYAML parses a duration string
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	return d.parse(value.Value)
+}
+
+// UnmarshalJSON parses a duration string
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30m\": %w", err)
+	}
+	return d.parse(s)
+}
+
+// MarshalJSON writes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) parse(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Threshold compares a reading with a limit, written as "cpu_temp > 95".
+// Readings are named as bench monitor stores them.
+type Threshold struct {
+	Metric string
+	Op     string // >, >=, < or <=
+	Value  float64
+}
+
+// thresholdOps lists the comparison operators, longest first so ">=" is not
+// read as ">"
+var thresholdOps = []string{">=", "<=", ">", "<"}
+
+// ParseThreshold parses "metric op value"
+func ParseThreshold(s string) (Threshold, error) {
+	for _, op := range thresholdOps {
+		metric, limit, found := strings.Cut(s, op)
+		if !found {
+			continue
+		}
+		metric = strings.TrimSpace(metric)
+		value, err := strconv.ParseFloat(strings.TrimSpace(limit), 64)
+		if metric == "" || err != nil {
+			break
+		}
+		return Threshold{Metric: metric, Op: op, Value: value}, nil
+	}
+	return Threshold{}, fmt.Errorf("invalid threshold %q, expected e.g. \"cpu_temp > 95\"", s)
+}
+
+// String formats the threshold as it is written
+func (t Threshold) String() string {
+	return fmt.Sprintf("%s %s %s", t.Metric, t.Op, strconv.FormatFloat(t.Value, 'f', -1, 64))
+}
+
+// Crossed reports whether the reading of the threshold's metric in values
+// meets it. A reading that was not taken never does.
+func (t Threshold) Crossed(values map[string]float64) (float64, bool) {
+	v, ok := values[t.Metric]
+	if !ok {
+		return 0, false
+	}
+	switch t.Op {
+	case ">":
+		return v, v > t.Value
+	case ">=":
+		return v, v >= t.Value
+	case "<":
+		return v, v < t.Value
+	default:
+		return v, v <= t.Value
+	}
+}
+
+// UnmarshalYAML parses a threshold string
+func (t *Threshold) UnmarshalYAML(value *yaml.Node) error {
+	parsed, err := ParseThreshold(value.Value)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// UnmarshalJSON parses a threshold string
+func (t *Threshold) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := ParseThreshold(s)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// MarshalJSON writes the threshold as a string
+func (t Threshold) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
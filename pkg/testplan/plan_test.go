@@ -0,0 +1,141 @@
+package testplan
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseYAML(t *testing.T) {
+	p, err := Parse([]byte(`
+name: burn-in
+interval: 2s
+abort:
+  - cpu_temp > 95
+stages:
+  - name: CPU
+    plugin: plan-stage-test
+    duration: 30m
+    threads: 8
+  - name: Mixed
+    plugins: [plan-stage-test, plan-stage-other]
+    duration: 2h
+    config:
+      pattern: random
+      size_mb: 512
+    abort:
+      - gpu0_temp >= 88.5
+  - name: Cooldown
+    pause: 10m
+    until: cpu_temp < 45
+  - prompt: Swap the DIMMs and press Enter
+`), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "burn-in" || time.Duration(p.Interval) != 2*time.Second {
+		t.Errorf("unexpected plan header: %+v", p)
+	}
+	if len(p.Abort) != 1 || p.Abort[0] != (Threshold{Metric: "cpu_temp", Op: ">", Value: 95}) {
+		t.Errorf("unexpected plan abort thresholds: %+v", p.Abort)
+	}
+	if len(p.Stages) != 4 {
+		t.Fatalf("expected 4 stages, got %d", len(p.Stages))
+	}
+
+	kinds := []string{KindTest, KindTest, KindPause, KindPrompt}
+	for i, s := range p.Stages {
+		if s.Kind() != kinds[i] {
+			t.Errorf("expected stage %d to be a %s, got %s", i+1, kinds[i], s.Kind())
+		}
+	}
+
+	mixed := p.Stages[1]
+	if got := mixed.Title(); got != "Mixed (plan-stage-test + plan-stage-other)" {
+		t.Errorf("unexpected title %q", got)
+	}
+	if mixed.Config["size_mb"] != 512 || mixed.Config["pattern"] != "random" {
+		t.Errorf("expected typed config values, got %#v", mixed.Config)
+	}
+	if len(mixed.Abort) != 1 || mixed.Abort[0].Op != ">=" || mixed.Abort[0].Value != 88.5 {
+		t.Errorf("unexpected stage abort thresholds: %+v", mixed.Abort)
+	}
+	if until := p.Stages[2].Until; until == nil || until.String() != "cpu_temp < 45" {
+		t.Errorf("unexpected until condition: %+v", until)
+	}
+	if expected := 30*time.Minute + 2*time.Hour + 10*time.Minute; p.Length() != expected {
+		t.Errorf("expected length %s, got %s", expected, p.Length())
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	p, err := Parse([]byte(`{"name":"quick","stages":[
+		{"plugin":"plan-stage-test","duration":"5m","abort":["memory_temp > 80"]},
+		{"pause":"1m"}]}`), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Duration(p.Stages[0].Duration) != 5*time.Minute || p.Stages[1].Kind() != KindPause {
+		t.Errorf("unexpected stages: %+v", p.Stages)
+	}
+	if p.Stages[0].Abort[0].Metric != "memory_temp" {
+		t.Errorf("unexpected abort thresholds: %+v", p.Stages[0].Abort)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		plan string
+		err  string
+	}{
+		{"no stages", "name: empty\n", "no stages"},
+		{"unknown plugin", "stages:\n  - plugin: missing\n", "not found"},
+		{"two kinds", "stages:\n  - plugin: plan-stage-test\n    pause: 1m\n", "exactly one"},
+		{"nothing to do", "stages:\n  - name: idle\n", "exactly one"},
+		{"until on a test", "stages:\n  - plugin: plan-stage-test\n    until: cpu_temp < 40\n", "until"},
+		{"bad duration", "stages:\n  - plugin: plan-stage-test\n    duration: soon\n", "duration"},
+		{"bad threshold", "abort: [cpu_temp is hot]\nstages:\n  - pause: 1m\n", "threshold"},
+		{"unknown field", "stages:\n  - plugin: plan-stage-test\n    duraton: 1m\n", "duraton"},
+		{"repeated plugin", "stages:\n  - plugins: [plan-stage-test, plan-stage-test]\n", "twice"},
+		{"invalid parameters", "stages:\n  - plugin: plan-stage-test\n    config: {invalid: true}\n", "invalid parameters"},
+		{"fans too slow", "stages:\n  - plugin: plan-stage-test\n    fans: 5\n", "fans"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.plan), false)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected an error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestThreshold(t *testing.T) {
+	values := map[string]float64{"cpu_temp": 95, "fan_cpu": 600}
+	tests := []struct {
+		threshold string
+		crossed   bool
+	}{
+		{"cpu_temp > 95", false},
+		{"cpu_temp >= 95", true},
+		{"cpu_temp<96", true},
+		{"fan_cpu <= 500", false},
+		{"gpu0_temp > 0", false}, // Not read on this machine
+	}
+	for _, tt := range tests {
+		th, err := ParseThreshold(tt.threshold)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.threshold, err)
+		}
+		if _, crossed := th.Crossed(values); crossed != tt.crossed {
+			t.Errorf("%s: expected crossed %v", tt.threshold, tt.crossed)
+		}
+	}
+
+	for _, bad := range []string{"", "cpu_temp", "> 95", "cpu_temp > hot"} {
+		if _, err := ParseThreshold(bad); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
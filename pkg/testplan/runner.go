@@ -0,0 +1,503 @@
+package testplan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/fancontrol"
+	"github.com/mscrnt/project_fire/pkg/hooks"
+	"github.com/mscrnt/project_fire/pkg/journal"
+	"github.com/mscrnt/project_fire/pkg/monitor"
+	"github.com/mscrnt/project_fire/pkg/plugin"
+	"github.com/mscrnt/project_fire/pkg/power"
+	"github.com/mscrnt/project_fire/pkg/safety"
+	"github.com/mscrnt/project_fire/pkg/testrun"
+)
+
+// SampleFunc takes one set of readings, named as bench monitor names them
+type SampleFunc func(ctx context.Context) (map[string]float64, error)
+
+// PromptFunc shows a prompt stage's message and returns once the operator
+// has answered. An error fails the stage.
+type PromptFunc func(ctx context.Context, message string) error
+
+// FanFunc pins every controllable fan at duty percent and returns how to
+// hand them back to their curve. guarded is set when the stage is stopped at
+// a temperature limit, which a duty below full speed needs.
+type FanFunc func(ctx context.Context, duty int, guarded bool) (restore func() error, err error)
+
+// ProgressFunc is called when a stage starts, with its status running, and
+// again when it ends
+type ProgressFunc func(stage Stage, result *StageResult)
+
+// errUntil ends a pause once its until condition holds
+var errUntil = errors.New("until condition met")
+
+// thresholdError stops a stage whose abort threshold was crossed
+type thresholdError struct {
+	threshold Threshold
+	value     float64
+}
+
+func (e *thresholdError) Error() string {
+	return fmt.Sprintf("%s reached %.1f (abort at %s)", e.threshold.Metric, e.value, e.threshold)
+}
+
+// Runner executes plans and records them in the results database
+type Runner struct {
+	database *db.DB
+	store    *Store
+	logger   *log.Logger
+
+	sample   SampleFunc
+	prompt   PromptFunc
+	fans     FanFunc
+	progress ProgressFunc
+	hooks    hooks.Config
+	limits   safety.Limits
+
+	mu sync.Mutex // Guards the run IDs of the stage being run
+}
+
+// NewRunner creates a plan runner that reads thresholds from the same
+// sensors as bench monitor
+func NewRunner(database *db.DB, logger *log.Logger) *Runner {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Runner{
+		database: database,
+		store:    NewStore(database),
+		logger:   logger,
+		sample:   monitorSampler(),
+	}
+}
+
+// SetSampler replaces the readings thresholds are checked against
+func (r *Runner) SetSampler(sample SampleFunc) {
+	r.sample = sample
+}
+
+// SetPrompt sets how prompt stages reach the operator. Without one, prompt
+// stages fail.
+func (r *Runner) SetPrompt(prompt PromptFunc) {
+	r.prompt = prompt
+}
+
+// SetFans sets how stages pin the fans. Without one, stages that pin fans
+// fail, so changing the fans is always the caller's explicit choice.
+func (r *Runner) SetFans(fans FanFunc) {
+	r.fans = fans
+}
+
+// SetHooks sets the scripts run before and after the test of every stage
+func (r *Runner) SetHooks(cfg hooks.Config) {
+	r.hooks = cfg
+}
+
+// SetLimits sets the readings at which the test of every stage is stopped,
+// on top of the abort thresholds of the plan
+func (r *Runner) SetLimits(limits safety.Limits) {
+	r.limits = limits
+}
+
+// SetProgress sets a function told about each stage as it starts and ends
+func (r *Runner) SetProgress(progress ProgressFunc) {
+	r.progress = progress
+}
+
+// monitorSampler reads the sensors through a monitor poller
+func monitorSampler() SampleFunc {
+	poller := monitor.NewPoller()
+	return func(ctx context.Context) (map[string]float64, error) {
+		s, err := poller.Sample(ctx)
+		if err != nil {
+			return nil, err
+		}
+		values, _ := s.Metrics()
+		return values, nil
+	}
+}
+
+// Run executes the stages of a plan in order. A stage that fails or is
+// aborted skips the rest unless the plan continues on failure; cancelling
+// ctx stops the current stage and records the rest as cancelled. The error
+// is only set when the plan run could not be recorded.
+func (r *Runner) Run(ctx context.Context, p *Plan, file string) (*PlanRun, error) {
+	run := &PlanRun{Name: p.Name, File: file, StartTime: time.Now(), Resume: p.Resume}
+	if run.Name == "" {
+		run.Name = file
+	}
+	if err := r.store.CreateRun(run); err != nil {
+		return nil, err
+	}
+	r.logger.Printf("Started plan run %d: %s", run.ID, run.Name)
+	return r.execute(ctx, p, run, nil)
+}
+
+// Resume continues a plan run that was interrupted, e.g. by a power loss.
+// Stages that ended before the interruption keep their outcome; the stage
+// it was in runs again from its start, followed by the rest.
+func (r *Runner) Resume(ctx context.Context, p *Plan, run *PlanRun) (*PlanRun, error) {
+	if !run.Interrupted {
+		return run, fmt.Errorf("plan run %d was not interrupted", run.ID)
+	}
+	previous, err := r.store.Stages(run.ID)
+	if err != nil {
+		return run, err
+	}
+	if len(previous) > len(p.Stages) {
+		return run, fmt.Errorf("the plan has %d stages, plan run %d had %d", len(p.Stages), run.ID, len(previous))
+	}
+	if err := r.store.Reopen(run); err != nil {
+		return run, err
+	}
+	r.logger.Printf("Resuming plan run %d at stage %d: %s", run.ID, resumeStage(previous), run.Name)
+	return r.execute(ctx, p, run, previous)
+}
+
+// Recover marks the plan runs of this machine whose process is gone as
+// interrupted at their last heartbeat, along with the stage each was in, and
+// returns them. The runs their stages started are recovered by
+// journal.Recover.
+func Recover(database *db.DB) ([]*PlanRun, error) {
+	store := NewStore(database)
+	open, err := store.OpenRuns(db.Hostname())
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []*PlanRun
+	for _, r := range open {
+		if journal.Alive(r.PID, r.StartTime) {
+			continue
+		}
+		at := r.LastSeen()
+		if err := store.MarkInterrupted(r.ID, at, journal.Message(at)); err != nil {
+			return runs, err
+		}
+		run, err := store.GetRun(r.ID)
+		if err != nil {
+			return runs, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// resumeStage returns the position of the first stage that did not end
+// before an interruption
+func resumeStage(previous []*StageResult) int {
+	for _, stage := range previous {
+		if stage.Status == StatusInterrupted {
+			return stage.Position
+		}
+	}
+	return len(previous) + 1
+}
+
+// execute runs the stages of a plan run that did not end before it was
+// interrupted, which is every stage of a new run
+func (r *Runner) execute(ctx context.Context, p *Plan, run *PlanRun, previous []*StageResult) (*PlanRun, error) {
+	// Journal the plan run, so a restart finds it interrupted
+	if err := r.database.Flush(); err != nil {
+		r.logger.Printf("Could not flush the journal of plan run %d: %v", run.ID, err)
+	}
+	stopJournal := journal.Keep(journal.DefaultInterval, func(t time.Time) error {
+		return r.store.Heartbeat(run.ID, t)
+	})
+	defer stopJournal()
+
+	// Keep the machine awake through pauses and prompts too
+	releaseSleep, err := power.Inhibit(fmt.Sprintf("Running test plan %s", run.Name))
+	if err != nil {
+		r.logger.Printf("Could not prevent sleep: %v", err)
+	}
+	defer releaseSleep()
+
+	run.Success = true
+	stopped := false
+	for i, stage := range p.Stages {
+		var result *StageResult
+		if i < len(previous) && previous[i].Status != StatusInterrupted {
+			// Ended before the interruption
+			result = previous[i]
+		} else {
+			result = &StageResult{
+				PlanRunID: run.ID,
+				Position:  i + 1,
+				Name:      stage.Name,
+				Kind:      stage.Kind(),
+			}
+			if i < len(previous) {
+				result.ID = previous[i].ID
+			}
+			if result.Name == "" {
+				result.Name = stage.Title()
+			}
+			switch {
+			case ctx.Err() != nil:
+				result.Status = StatusCancelled
+			case stopped:
+				result.Status = StatusSkipped
+			default:
+				r.runStage(ctx, p, stage, result)
+			}
+			if result.StartTime == nil {
+				if err := r.saveStage(result); err != nil {
+					return run, err
+				}
+				r.report(stage, result)
+			}
+		}
+
+		if result.Status == StatusPassed {
+			continue
+		}
+		if run.Success {
+			run.Success = false
+			run.Error = fmt.Sprintf("stage %d (%s) %s", result.Position, stage.Title(), result.Status)
+			if result.Message != "" {
+				run.Error += ": " + result.Message
+			}
+		}
+		if !p.ContinueOnFailure {
+			stopped = true
+		}
+	}
+
+	endTime := time.Now()
+	run.EndTime = &endTime
+	if err := r.store.UpdateRun(run); err != nil {
+		return run, err
+	}
+	r.logger.Printf("Completed plan run %d (success: %v, duration: %s)", run.ID, run.Success, endTime.Sub(run.StartTime))
+	return run, nil
+}
+
+// saveStage records a stage, updating the record an interrupted run left
+func (r *Runner) saveStage(result *StageResult) error {
+	if result.ID != 0 {
+		return r.store.UpdateStage(result)
+	}
+	return r.store.CreateStage(result)
+}
+
+// runStage runs one stage while watching its thresholds, and records it
+func (r *Runner) runStage(ctx context.Context, p *Plan, stage Stage, result *StageResult) {
+	start := time.Now()
+	result.StartTime = &start
+	result.Status = StatusRunning
+	if err := r.saveStage(result); err != nil {
+		r.logger.Printf("Failed to record stage %d: %v", result.Position, err)
+	}
+	r.report(stage, result)
+
+	stageCtx, stop := context.WithCancelCause(ctx)
+	defer stop(nil)
+
+	// Pin the fans before the load starts, so it never runs without the
+	// cooling the stage asked for
+	restoreFans, err := r.pinFans(stageCtx, p, stage)
+
+	// Watch the sensors for the whole stage, except while waiting for the
+	// operator
+	peaks := make(map[string]float64)
+	watchDone := make(chan struct{})
+	var watching sync.WaitGroup
+	if err == nil && stage.Kind() != KindPrompt && r.sample != nil {
+		interval := time.Duration(p.Interval)
+		if interval <= 0 {
+			interval = DefaultInterval
+		}
+		thresholds := append(append([]Threshold(nil), p.Abort...), stage.Abort...)
+		watching.Add(1)
+		go func() {
+			defer watching.Done()
+			r.watch(stageCtx, watchDone, interval, thresholds, stage.Until, peaks, stop)
+		}()
+	}
+
+	if err == nil {
+		switch stage.Kind() {
+		case KindTest:
+			err = r.runPlugins(stageCtx, stage, result)
+		case KindPause:
+			timer := time.NewTimer(time.Duration(stage.Pause))
+			select {
+			case <-timer.C:
+			case <-stageCtx.Done():
+			}
+			timer.Stop()
+		case KindPrompt:
+			if r.prompt == nil {
+				err = errors.New("no operator to answer the prompt")
+			} else {
+				err = r.prompt(stageCtx, stage.Prompt)
+			}
+		}
+	}
+	close(watchDone)
+	watching.Wait()
+	restoreFans()
+
+	var crossed *thresholdError
+	cause := context.Cause(stageCtx)
+	switch {
+	case errors.As(cause, &crossed):
+		result.Status = StatusAborted
+		result.Message = crossed.Error()
+	case errors.Is(cause, errUntil):
+		result.Status = StatusPassed
+		result.Message = "ended early: " + stage.Until.String()
+	case ctx.Err() != nil:
+		result.Status = StatusCancelled
+	case err != nil:
+		result.Status = StatusFailed
+		result.Message = err.Error()
+	default:
+		result.Status = StatusPassed
+	}
+
+	end := time.Now()
+	result.EndTime = &end
+	if len(peaks) > 0 {
+		result.Peaks = make(db.JSONData, len(peaks))
+		for name, value := range peaks {
+			result.Peaks[name] = value
+		}
+	}
+	if err := r.store.UpdateStage(result); err != nil {
+		r.logger.Printf("Failed to update stage %d: %v", result.Position, err)
+	}
+	r.report(stage, result)
+}
+
+// pinFans pins the fans for a stage that sets them and returns how to
+// restore them, which a stage that does not set them makes a no-op
+func (r *Runner) pinFans(ctx context.Context, p *Plan, stage Stage) (func(), error) {
+	if stage.Fans == 0 {
+		return func() {}, nil
+	}
+	if r.fans == nil {
+		return func() {}, errors.New("fan control is not enabled")
+	}
+	guarded := p.Guarded(stage, r.limits)
+	if stage.Fans < fancontrol.FullDuty && !guarded {
+		return func() {}, fancontrol.ErrUnguarded
+	}
+	restore, err := r.fans(ctx, stage.Fans, guarded)
+	if err != nil {
+		return func() {}, fmt.Errorf("failed to pin the fans: %w", err)
+	}
+	r.logger.Printf("Pinned the fans at %d%%", stage.Fans)
+	return func() {
+		if err := restore(); err != nil {
+			r.logger.Printf("Failed to restore the fans: %v", err)
+			return
+		}
+		r.logger.Printf("Restored the fans")
+	}, nil
+}
+
+// watch samples the sensors every interval until done is closed, keeping the
+// highest reading of each metric in peaks. It stops the stage when an abort
+// threshold is crossed or the until condition holds.
+func (r *Runner) watch(ctx context.Context, done <-chan struct{}, interval time.Duration, thresholds []Threshold, until *Threshold, peaks map[string]float64, stop context.CancelCauseFunc) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		values, err := r.sample(ctx)
+		if err == nil {
+			for name, value := range values {
+				if peak, ok := peaks[name]; !ok || value > peak {
+					peaks[name] = value
+				}
+			}
+			for _, t := range thresholds {
+				if value, crossed := t.Crossed(values); crossed {
+					stop(&thresholdError{threshold: t, value: value})
+					return
+				}
+			}
+			if until != nil {
+				if _, met := until.Crossed(values); met {
+					stop(errUntil)
+					return
+				}
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// runPlugins runs the stage's plugins at the same time and returns their
+// combined errors
+func (r *Runner) runPlugins(ctx context.Context, stage Stage, result *StageResult) error {
+	names := stage.PluginNames()
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			if err := r.runPlugin(ctx, name, stage, result); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", name, err)
+			}
+		}(i, name)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// runPlugin runs one plugin of a stage and records it like bench test does
+func (r *Runner) runPlugin(ctx context.Context, name string, stage Stage, result *StageResult) error {
+	p, err := plugin.Get(name)
+	if err != nil {
+		return err
+	}
+	params := stage.params(p)
+	if err := p.ValidateParams(params); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	// A threshold or Ctrl+C stopping the stage records the test as failed
+	outcome, err := testrun.Run(ctx, r.database, p, params, testrun.Options{
+		Source: "plan",
+		Hooks:  r.hooks,
+		Limits: r.limits,
+		Output: r.logger.Writer(),
+		Logger: r.logger,
+		Started: func(run *db.Run) {
+			if err := r.store.AddStageRun(result.ID, run.ID); err != nil {
+				r.logger.Printf("Failed to link run %d to stage %d: %v", run.ID, result.Position, err)
+			}
+			r.mu.Lock()
+			result.RunIDs = append(result.RunIDs, run.ID)
+			r.mu.Unlock()
+		},
+	})
+	if outcome == nil {
+		return err
+	}
+	return outcome.Failure()
+}
+
+// report passes a stage's progress to the progress function
+func (r *Runner) report(stage Stage, result *StageResult) {
+	if r.progress != nil {
+		r.progress(stage, result)
+	}
+}
@@ -0,0 +1,448 @@
+package testplan
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/fancontrol"
+	"github.com/mscrnt/project_fire/pkg/hooks"
+	"github.com/mscrnt/project_fire/pkg/plugin"
+	"github.com/mscrnt/project_fire/pkg/safety"
+)
+
+// stageTestPlugin runs for its duration unless stopped, and fails when its
+// config sets fail. It rejects a config that sets invalid.
+type stageTestPlugin struct{ name string }
+
+func (p stageTestPlugin) Name() string        { return p.name }
+func (p stageTestPlugin) Description() string { return "Test plugin for plan stages" }
+func (p stageTestPlugin) DefaultParams() plugin.Params {
+	return plugin.Params{Duration: 20 * time.Millisecond, Threads: 1}
+}
+func (p stageTestPlugin) ValidateParams(params plugin.Params) error {
+	if params.Config["invalid"] == true {
+		return errors.New("invalid is set")
+	}
+	return nil
+}
+func (p stageTestPlugin) Run(ctx context.Context, params plugin.Params) (plugin.Result, error) {
+	select {
+	case <-time.After(params.Duration):
+	case <-ctx.Done():
+		return plugin.Result{Error: "stopped"}, ctx.Err()
+	}
+	if params.Config["fail"] == true {
+		return plugin.Result{Error: "errors detected"}, errors.New("errors detected")
+	}
+	return plugin.Result{Success: true, Metrics: map[string]float64{"threads": float64(params.Threads)}}, nil
+}
+
+func init() {
+	_ = plugin.Register(stageTestPlugin{name: "plan-stage-test"})
+	_ = plugin.Register(stageTestPlugin{name: "plan-stage-other"})
+}
+
+func newTestRunner(t *testing.T, sample SampleFunc) (*Runner, *db.DB) {
+	t.Helper()
+	t.Setenv("FIRE_ARTIFACTS", t.TempDir())
+	database, err := db.Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+
+	r := NewRunner(database, log.New(io.Discard, "", 0))
+	r.SetSampler(sample)
+	return r, database
+}
+
+func mustParse(t *testing.T, plan string) *Plan {
+	t.Helper()
+	p, err := Parse([]byte(plan), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestRunPlan(t *testing.T) {
+	var samples atomic.Int32
+	r, database := newTestRunner(t, func(context.Context) (map[string]float64, error) {
+		// Cools down from the third reading
+		if samples.Add(1) > 2 {
+			return map[string]float64{"cpu_temp": 40}, nil
+		}
+		return map[string]float64{"cpu_temp": 70}, nil
+	})
+	var prompts []string
+	r.SetPrompt(func(_ context.Context, message string) error {
+		prompts = append(prompts, message)
+		return nil
+	})
+	var ended []*StageResult
+	r.SetProgress(func(_ Stage, result *StageResult) {
+		if result.Status != StatusRunning {
+			ended = append(ended, result)
+		}
+	})
+
+	p := mustParse(t, `
+name: burn-in
+interval: 10ms
+abort: [cpu_temp > 95]
+stages:
+  - name: Mixed
+    plugins: [plan-stage-test, plan-stage-other]
+    threads: 4
+  - name: Cooldown
+    pause: 1m
+    until: cpu_temp < 45
+  - prompt: Check the fans
+`)
+	run, err := r.Run(context.Background(), p, "burn-in.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !run.Success || run.Error != "" {
+		t.Fatalf("expected the plan to pass, got %q", run.Error)
+	}
+	if len(prompts) != 1 || prompts[0] != "Check the fans" {
+		t.Errorf("unexpected prompts: %v", prompts)
+	}
+
+	store := NewStore(database)
+	stages, err := store.Stages(run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stages) != 3 {
+		t.Fatalf("expected 3 stages, got %d", len(stages))
+	}
+	for _, s := range stages {
+		if s.Status != StatusPassed {
+			t.Errorf("expected stage %d to pass, got %s: %s", s.Position, s.Status, s.Message)
+		}
+	}
+	if len(stages[0].RunIDs) != 2 {
+		t.Fatalf("expected the mixed stage to start 2 runs, got %v", stages[0].RunIDs)
+	}
+	if len(ended) != 3 || len(ended[0].RunIDs) != 2 {
+		t.Errorf("expected the progress of the mixed stage to list its runs, got %+v", ended)
+	}
+	if stages[0].Peaks["cpu_temp"] != 70.0 {
+		t.Errorf("expected the peak temperature to be kept, got %v", stages[0].Peaks)
+	}
+	if !strings.Contains(stages[1].Message, "ended early") || stages[1].Duration() > 10*time.Second {
+		t.Errorf("expected the cooldown to end early, got %q after %s", stages[1].Message, stages[1].Duration())
+	}
+
+	results, err := database.GetResults(stages[0].RunIDs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Value != 4 {
+		t.Errorf("expected the stage threads to reach the plugin, got %+v", results)
+	}
+
+	runs, err := store.ListRuns(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].File != "burn-in.yaml" || runs[0].EndTime == nil {
+		t.Errorf("unexpected plan runs: %+v", runs)
+	}
+}
+
+func TestRunPlanAbort(t *testing.T) {
+	r, database := newTestRunner(t, func(context.Context) (map[string]float64, error) {
+		return map[string]float64{"cpu_temp": 97}, nil
+	})
+	p := mustParse(t, `
+interval: 10ms
+abort: [cpu_temp > 95]
+stages:
+  - plugin: plan-stage-test
+    duration: 1m
+  - pause: 1m
+`)
+	start := time.Now()
+	run, err := r.Run(context.Background(), p, "hot.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Error("expected the threshold to stop the stage early")
+	}
+	if run.Success || !strings.Contains(run.Error, "cpu_temp reached 97.0") {
+		t.Errorf("expected the plan to fail on the threshold, got %q", run.Error)
+	}
+
+	stages, err := NewStore(database).Stages(run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stages[0].Status != StatusAborted || stages[1].Status != StatusSkipped {
+		t.Errorf("expected aborted then skipped, got %s then %s", stages[0].Status, stages[1].Status)
+	}
+	testRun, err := database.GetRun(stages[0].RunIDs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if testRun.Success || !strings.Contains(testRun.Error, "abort at cpu_temp > 95") {
+		t.Errorf("expected the run to record the abort, got %q", testRun.Error)
+	}
+}
+
+func TestRunPlanContinueOnFailure(t *testing.T) {
+	r, database := newTestRunner(t, nil)
+	p := mustParse(t, `
+continue_on_failure: true
+stages:
+  - plugin: plan-stage-test
+    config:
+      fail: true
+  - plugin: plan-stage-test
+  - prompt: Nobody is watching
+`)
+	run, err := r.Run(context.Background(), p, "plan.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Success || !strings.HasPrefix(run.Error, "stage 1 (plan-stage-test) failed") {
+		t.Errorf("expected the first failure to be reported, got %q", run.Error)
+	}
+
+	stages, err := NewStore(database).Stages(run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := []string{StatusFailed, StatusPassed, StatusFailed}
+	for i, s := range stages {
+		if s.Status != statuses[i] {
+			t.Errorf("expected stage %d %s, got %s: %s", i+1, statuses[i], s.Status, s.Message)
+		}
+	}
+}
+
+func TestRunPlanCancel(t *testing.T) {
+	r, database := newTestRunner(t, nil)
+	p := mustParse(t, `
+stages:
+  - pause: 1m
+  - plugin: plan-stage-test
+`)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	run, err := r.Run(ctx, p, "plan.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Success {
+		t.Error("expected a cancelled plan to fail")
+	}
+
+	stages, err := NewStore(database).Stages(run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range stages {
+		if s.Status != StatusCancelled {
+			t.Errorf("expected stage %d cancelled, got %s", s.Position, s.Status)
+		}
+	}
+}
+
+func TestRunPlanFans(t *testing.T) {
+	r, database := newTestRunner(t, nil)
+	p := mustParse(t, `
+continue_on_failure: true
+stages:
+  - plugin: plan-stage-test
+    fans: 100
+  - plugin: plan-stage-test
+`)
+
+	// Without fan control the stage fails rather than run unpinned
+	run, err := r.Run(context.Background(), p, "plan.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Success || !strings.Contains(run.Error, "fan control is not enabled") {
+		t.Errorf("expected the pinned stage to fail, got %q", run.Error)
+	}
+
+	var pinned []int
+	restored := 0
+	r.SetFans(func(_ context.Context, duty int, _ bool) (func() error, error) {
+		pinned = append(pinned, duty)
+		return func() error {
+			restored++
+			return nil
+		}, nil
+	})
+	run, err = r.Run(context.Background(), p, "plan.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !run.Success {
+		t.Fatalf("expected the plan to pass, got %q", run.Error)
+	}
+	if len(pinned) != 1 || pinned[0] != 100 || restored != 1 {
+		t.Errorf("expected the fans pinned at 100%% and restored once, got %v and %d", pinned, restored)
+	}
+
+	stages, err := NewStore(database).Stages(run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stages[0].RunIDs) != 1 {
+		t.Errorf("expected the pinned stage to run its plugin, got %v", stages[0].RunIDs)
+	}
+
+	// Slower fans need something to stop the load if it overheats
+	slow := mustParse(t, `
+stages:
+  - plugin: plan-stage-test
+    fans: 60
+`)
+	pinned = nil
+	run, err = r.Run(context.Background(), slow, "slow.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Success || !errors.Is(slow.CheckFans(safety.Limits{}), fancontrol.ErrUnguarded) || len(pinned) != 0 {
+		t.Errorf("expected unguarded slow fans to be refused, got %q and %v", run.Error, pinned)
+	}
+	if err := slow.CheckFans(safety.Limits{CPUTempC: 95}); err != nil {
+		t.Errorf("expected a CPU temperature limit to guard the fans, got %v", err)
+	}
+	slow.Abort = []Threshold{{Metric: "cpu_temp", Op: ">", Value: 90}}
+	if run, err = r.Run(context.Background(), slow, "slow.yaml"); err != nil || !run.Success || len(pinned) != 1 || pinned[0] != 60 {
+		t.Errorf("expected an abort threshold to allow slow fans, got %+v, %v and %v", run, err, pinned)
+	}
+}
+
+func TestRunPlanHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts")
+	}
+	r, _ := newTestRunner(t, nil)
+
+	dir := t.TempDir()
+	out := filepath.Join(dir, "hooks.log")
+	script := filepath.Join(dir, "hook.sh")
+	body := "#!/bin/sh\necho \"$FIRE_HOOK_PHASE $FIRE_RUN_SOURCE\" >> \"" + out + "\"\n"
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil { // #nosec G306 -- test script must be executable
+		t.Fatal(err)
+	}
+	r.SetHooks(hooks.Config{PreRun: []hooks.Hook{{Command: script}}, PostRun: []hooks.Hook{{Command: script}}})
+
+	p := mustParse(t, `
+stages:
+  - plugin: plan-stage-test
+`)
+	run, err := r.Run(context.Background(), p, "plan.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !run.Success {
+		t.Fatalf("expected the plan to pass, got %q", run.Error)
+	}
+	data, err := os.ReadFile(out) // #nosec G304 -- path is in the test's temporary directory
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); got != "pre_run plan\npost_run plan\n" {
+		t.Errorf("expected the hooks to run around the stage test, got %q", got)
+	}
+}
+
+func TestResumePlan(t *testing.T) {
+	r, database := newTestRunner(t, nil)
+	p := mustParse(t, `
+resume: true
+stages:
+  - plugin: plan-stage-test
+  - plugin: plan-stage-other
+  - pause: 10ms
+`)
+	store := NewStore(database)
+
+	// A run that lost power in its second stage
+	started := time.Now().Add(-time.Hour)
+	run := &PlanRun{Name: "burn-in", File: "burn-in.yaml", StartTime: started, Resume: p.Resume}
+	if err := store.CreateRun(run); err != nil {
+		t.Fatal(err)
+	}
+	first := &StageResult{PlanRunID: run.ID, Position: 1, Kind: KindTest, Status: StatusPassed, StartTime: &started, EndTime: &started}
+	second := &StageResult{PlanRunID: run.ID, Position: 2, Kind: KindTest, Status: StatusRunning, StartTime: &started}
+	for _, stage := range []*StageResult{first, second} {
+		if err := store.CreateStage(stage); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := database.Exec(`UPDATE plan_runs SET pid = 0 WHERE id = ?`, run.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	recovered, err := Recover(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recovered) != 1 || !recovered[0].Interrupted || recovered[0].EndTime == nil {
+		t.Fatalf("expected the plan run to be interrupted, got %+v", recovered)
+	}
+	stages, err := store.Stages(run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stages[1].Status != StatusInterrupted {
+		t.Errorf("expected the second stage to be interrupted, got %s", stages[1].Status)
+	}
+
+	resumable, err := store.LastResumable(db.Hostname())
+	if err != nil || resumable == nil || resumable.ID != run.ID {
+		t.Fatalf("expected the plan run to be resumable, got %+v (%v)", resumable, err)
+	}
+	resumed, err := r.Resume(context.Background(), p, resumable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resumed.Success || resumed.Interrupted || resumed.EndTime == nil {
+		t.Errorf("expected the resumed plan to pass, got %+v", resumed)
+	}
+
+	stages, err = store.Stages(run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stages) != 3 {
+		t.Fatalf("expected 3 stages, got %d", len(stages))
+	}
+	if stages[0].ID != first.ID || len(stages[0].RunIDs) != 0 {
+		t.Errorf("expected the first stage to be kept, got %+v", stages[0])
+	}
+	if stages[1].ID != second.ID || stages[1].Status != StatusPassed || len(stages[1].RunIDs) != 1 {
+		t.Errorf("expected the second stage to run again, got %+v", stages[1])
+	}
+	if stages[2].Status != StatusPassed {
+		t.Errorf("expected the last stage to run, got %s", stages[2].Status)
+	}
+
+	if again, _ := store.LastResumable(db.Hostname()); again != nil {
+		t.Errorf("expected nothing left to resume, got %+v", again)
+	}
+	if _, err := r.Resume(context.Background(), p, resumed); err == nil {
+		t.Error("expected a finished plan run not to resume")
+	}
+}
@@ -0,0 +1,329 @@
+package testplan
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+// Stage statuses
+const (
+	StatusRunning     = "running"
+	StatusPassed      = "passed"
+	StatusFailed      = "failed"
+	StatusAborted     = "aborted" // An abort threshold was crossed
+	StatusSkipped     = "skipped" // An earlier stage failed
+	StatusCancelled   = "cancelled"
+	StatusInterrupted = "interrupted" // Cut short by a crash, power loss or restart
+)
+
+// PlanRun is one execution of a plan
+type PlanRun struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	File      string     `json:"file"`
+	StartTime time.Time  `json:"start_time"`
+	EndTime   *time.Time `json:"end_time"`
+	Success   bool       `json:"success"`
+	Error     string     `json:"error"`
+
+	// Interrupted is set when a crash, power loss or restart cut the run
+	// short; Resume when its plan resumes after that
+	Interrupted bool `json:"interrupted,omitempty"`
+	Resume      bool `json:"resume,omitempty"`
+}
+
+// planRunColumns are the columns scanPlanRun reads, in order
+const planRunColumns = `id, name, file, start_time, end_time, success, error,
+	COALESCE(interrupted, FALSE), COALESCE(resume, FALSE)`
+
+// scanPlanRun reads a plan run selected with planRunColumns
+func scanPlanRun(row interface{ Scan(...interface{}) error }) (*PlanRun, error) {
+	run := &PlanRun{}
+	err := row.Scan(&run.ID, &run.Name, &run.File, &run.StartTime, &run.EndTime, &run.Success, &run.Error,
+		&run.Interrupted, &run.Resume)
+	return run, err
+}
+
+// StageResult is the outcome of one stage of a plan run
+type StageResult struct {
+	ID        int64       `json:"id"`
+	PlanRunID int64       `json:"plan_run_id"`
+	Position  int         `json:"position"`
+	Name      string      `json:"name"`
+	Kind      string      `json:"kind"`
+	Status    string      `json:"status"`
+	Message   string      `json:"message"`
+	StartTime *time.Time  `json:"start_time"`
+	EndTime   *time.Time  `json:"end_time"`
+	Peaks     db.JSONData `json:"peaks"`   // Highest reading of each metric during the stage
+	RunIDs    []int64     `json:"run_ids"` // Runs the stage started
+}
+
+// Duration returns how long the stage ran
+func (s *StageResult) Duration() time.Duration {
+	if s.StartTime == nil || s.EndTime == nil {
+		return 0
+	}
+	return s.EndTime.Sub(*s.StartTime)
+}
+
+// Store handles plan run persistence
+type Store struct {
+	db *db.DB
+}
+
+// NewStore creates a new plan run store
+func NewStore(database *db.DB) *Store {
+	return &Store{db: database}
+}
+
+// CreateRun records the start of a plan run, journaled as running in this
+// process
+func (s *Store) CreateRun(run *PlanRun) error {
+	id, err := s.db.Insert(
+		`INSERT INTO plan_runs (name, file, start_time, success, error, resume, machine, pid, heartbeat_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		run.Name, run.File, run.StartTime, run.Success, run.Error, run.Resume,
+		db.Hostname(), os.Getpid(), run.StartTime,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create plan run: %w", err)
+	}
+	run.ID = id
+	return nil
+}
+
+// UpdateRun records the end of a plan run
+func (s *Store) UpdateRun(run *PlanRun) error {
+	_, err := s.db.Exec(
+		`UPDATE plan_runs SET end_time = ?, success = ?, error = ? WHERE id = ?`,
+		run.EndTime, run.Success, run.Error, run.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update plan run: %w", err)
+	}
+	return nil
+}
+
+// GetRun retrieves a plan run by ID
+func (s *Store) GetRun(id int64) (*PlanRun, error) {
+	run, err := scanPlanRun(s.db.QueryRow(`SELECT `+planRunColumns+` FROM plan_runs WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("plan run not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plan run: %w", err)
+	}
+	return run, nil
+}
+
+// ListRuns returns plan runs, newest first
+func (s *Store) ListRuns(limit int) ([]*PlanRun, error) {
+	query := `SELECT ` + planRunColumns + ` FROM plan_runs ORDER BY id DESC`
+	args := []interface{}{}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list plan runs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var runs []*PlanRun
+	for rows.Next() {
+		run, err := scanPlanRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan plan run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// Heartbeat records that a plan run is still in progress
+func (s *Store) Heartbeat(id int64, at time.Time) error {
+	if _, err := s.db.Exec(`UPDATE plan_runs SET heartbeat_at = ? WHERE id = ?`, at, id); err != nil {
+		return fmt.Errorf("failed to record plan run heartbeat: %w", err)
+	}
+	return nil
+}
+
+// OpenRuns returns the journaled plan runs of a machine that have not
+// ended, oldest first
+func (s *Store) OpenRuns(machine string) ([]*db.OpenRun, error) {
+	rows, err := s.db.Query(
+		`SELECT id, name, pid, start_time, heartbeat_at FROM plan_runs
+		 WHERE end_time IS NULL AND pid IS NOT NULL AND machine = ?
+		 ORDER BY start_time`,
+		machine,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open plan runs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var runs []*db.OpenRun
+	for rows.Next() {
+		run := &db.OpenRun{}
+		if err := rows.Scan(&run.ID, &run.Name, &run.PID, &run.StartTime, &run.Heartbeat); err != nil {
+			return nil, fmt.Errorf("failed to scan open plan run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// MarkInterrupted ends a plan run that was cut short at the given time,
+// along with the stage it was in
+func (s *Store) MarkInterrupted(id int64, at time.Time, message string) error {
+	_, err := s.db.Exec(
+		`UPDATE plan_runs SET end_time = ?, success = ?, error = ?, interrupted = ?
+		 WHERE id = ? AND end_time IS NULL`,
+		at, false, message, true, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark plan run interrupted: %w", err)
+	}
+	_, err = s.db.Exec(
+		`UPDATE plan_stages SET status = ?, end_time = ?, message = ? WHERE plan_run_id = ? AND status = ?`,
+		StatusInterrupted, at, message, id, StatusRunning,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark plan stage interrupted: %w", err)
+	}
+	return nil
+}
+
+// Reopen journals an interrupted plan run as running in this process again
+func (s *Store) Reopen(run *PlanRun) error {
+	now := time.Now()
+	_, err := s.db.Exec(
+		`UPDATE plan_runs SET end_time = NULL, success = ?, error = ?, interrupted = ?,
+		 machine = ?, pid = ?, heartbeat_at = ? WHERE id = ?`,
+		false, "", false, db.Hostname(), os.Getpid(), now, run.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to reopen plan run: %w", err)
+	}
+	run.EndTime = nil
+	run.Success = false
+	run.Error = ""
+	run.Interrupted = false
+	return nil
+}
+
+// LastResumable returns the latest interrupted plan run of a machine whose
+// plan resumes, nil if there is none
+func (s *Store) LastResumable(machine string) (*PlanRun, error) {
+	run, err := scanPlanRun(s.db.QueryRow(
+		`SELECT `+planRunColumns+` FROM plan_runs
+		 WHERE interrupted = ? AND resume = ? AND machine = ?
+		 ORDER BY id DESC LIMIT 1`,
+		true, true, machine,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find an interrupted plan run: %w", err)
+	}
+	return run, nil
+}
+
+// CreateStage records a stage of a plan run
+func (s *Store) CreateStage(stage *StageResult) error {
+	id, err := s.db.Insert(
+		`INSERT INTO plan_stages (plan_run_id, position, name, kind, status, message, start_time, end_time, peaks)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		stage.PlanRunID, stage.Position, stage.Name, stage.Kind, stage.Status,
+		stage.Message, stage.StartTime, stage.EndTime, stage.Peaks,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create plan stage: %w", err)
+	}
+	stage.ID = id
+	return nil
+}
+
+// UpdateStage records the outcome of a stage
+func (s *Store) UpdateStage(stage *StageResult) error {
+	_, err := s.db.Exec(
+		`UPDATE plan_stages SET status = ?, message = ?, start_time = ?, end_time = ?, peaks = ? WHERE id = ?`,
+		stage.Status, stage.Message, stage.StartTime, stage.EndTime, stage.Peaks, stage.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update plan stage: %w", err)
+	}
+	return nil
+}
+
+// AddStageRun links a run to the stage that started it
+func (s *Store) AddStageRun(stageID, runID int64) error {
+	_, err := s.db.Exec(`INSERT INTO plan_stage_runs (stage_id, run_id) VALUES (?, ?)`, stageID, runID)
+	if err != nil {
+		return fmt.Errorf("failed to record stage run: %w", err)
+	}
+	return nil
+}
+
+// Stages returns the stages of a plan run in order, with the runs each
+// started
+func (s *Store) Stages(planRunID int64) ([]*StageResult, error) {
+	rows, err := s.db.Query(
+		`SELECT id, plan_run_id, position, name, kind, status, message, start_time, end_time, peaks
+		 FROM plan_stages WHERE plan_run_id = ? ORDER BY position`,
+		planRunID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plan stages: %w", err)
+	}
+	var stages []*StageResult
+	for rows.Next() {
+		stage := &StageResult{}
+		err := rows.Scan(
+			&stage.ID, &stage.PlanRunID, &stage.Position, &stage.Name, &stage.Kind,
+			&stage.Status, &stage.Message, &stage.StartTime, &stage.EndTime, &stage.Peaks,
+		)
+		if err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("failed to scan plan stage: %w", err)
+		}
+		stages = append(stages, stage)
+	}
+	_ = rows.Close()
+
+	for _, stage := range stages {
+		ids, err := s.stageRuns(stage.ID)
+		if err != nil {
+			return nil, err
+		}
+		stage.RunIDs = ids
+	}
+	return stages, nil
+}
+
+// stageRuns returns the IDs of the runs a stage started
+func (s *Store) stageRuns(stageID int64) ([]int64, error) {
+	rows, err := s.db.Query(`SELECT run_id FROM plan_stage_runs WHERE stage_id = ? ORDER BY id`, stageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stage runs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan stage run: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,93 @@
+package testrun
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/changelog"
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/mail"
+	"github.com/mscrnt/project_fire/pkg/notify"
+	"github.com/mscrnt/project_fire/pkg/report"
+	"github.com/mscrnt/project_fire/pkg/units"
+)
+
+// NotifyTimeout bounds generating and sending the notifications of a run
+const NotifyTimeout = 2 * time.Minute
+
+// Notifier sends the report of finished runs
+type Notifier struct {
+	Database *db.DB
+	SMTP     *mail.Config // Mail server; needed for email targets
+	Units    units.Prefs  // Units of the attached report
+	Logger   *log.Logger  // Told when the PDF report falls back to HTML; may be nil
+}
+
+// Send mails the report of a finished run and posts it to the webhooks of
+// targets, unless targets is nil or does not want a run with its outcome.
+// schedule names the schedule that started the run, if any.
+func (n *Notifier) Send(ctx context.Context, run *db.Run, targets *notify.Notify, schedule string) error {
+	if targets == nil || !targets.Wants(run.Success) {
+		return nil
+	}
+	if len(targets.Email) > 0 && n.SMTP == nil {
+		return fmt.Errorf("no SMTP server configured to mail %s", targets)
+	}
+	ctx, cancel := context.WithTimeout(ctx, NotifyTimeout)
+	defer cancel()
+
+	sender := &notify.Sender{
+		SMTP: n.SMTP,
+		Report: func(format string) (mail.Attachment, error) {
+			return n.reportAttachment(run.ID, format)
+		},
+	}
+	return sender.Send(ctx, targets, notify.NewRun(run, changelog.Machine(), schedule, targets.ReportURL))
+}
+
+// reportAttachment generates the report of a run in the given format. A PDF
+// needs Chrome or Chromium, so the HTML report is attached instead when the
+// PDF cannot be made.
+func (n *Notifier) reportAttachment(runID int64, format string) (mail.Attachment, error) {
+	generator := report.NewGenerator(n.Database)
+	generator.SetUnits(n.Units)
+	name := fmt.Sprintf("fire-run-%d", runID)
+
+	if format == notify.FormatPDF {
+		data, err := renderPDF(generator, runID)
+		if err == nil {
+			return mail.Attachment{Name: name + ".pdf", ContentType: "application/pdf", Data: data}, nil
+		}
+		logger := n.Logger
+		if logger == nil {
+			logger = log.New(io.Discard, "", 0)
+		}
+		logger.Printf("Could not generate the PDF report, attaching HTML instead: %v", err)
+	}
+
+	html, err := generator.GenerateHTML(runID)
+	if err != nil {
+		return mail.Attachment{}, fmt.Errorf("failed to generate report: %w", err)
+	}
+	return mail.Attachment{Name: name + ".html", ContentType: "text/html; charset=utf-8", Data: []byte(html)}, nil
+}
+
+// renderPDF returns the PDF report of a run
+func renderPDF(generator *report.Generator, runID int64) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "fire-report-")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	path := filepath.Join(dir, "report.pdf")
+	if err := generator.QuickPDF(runID, path); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path) // #nosec G304 -- path is in the temporary directory created above
+}
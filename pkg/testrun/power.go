@@ -0,0 +1,50 @@
+package testrun
+
+import (
+	"context"
+	"log"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/power"
+)
+
+// PowerSources returns the power sources to watch: the system battery or AC
+// adapter if there is one, and the UPS named by ups, e.g.
+// "nut:ups@localhost", unless it is empty
+func PowerSources(ups string) ([]power.Source, error) {
+	var sources []power.Source
+	if source, ok := power.SystemSource(); ok {
+		sources = append(sources, source)
+	}
+	if ups != "" {
+		source, err := power.ParseUPS(ups)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// WatchPower records every power event as an annotation until ctx is
+// canceled, linked to runID unless it is 0, and then passes it to handle.
+// It returns the monitor, or nil when there is nothing to watch.
+func WatchPower(ctx context.Context, database *db.DB, sources []power.Source, runID int64, logger *log.Logger, handle func(power.Event)) *power.Monitor {
+	if len(sources) == 0 {
+		return nil
+	}
+	monitor := power.NewMonitor(sources, power.DefaultPollInterval, logger)
+	go monitor.Run(ctx, func(event power.Event) {
+		a := &db.Annotation{Time: event.Time, Source: event.Source, Kind: string(event.Kind), Message: event.Message}
+		if runID != 0 {
+			a.RunID = &runID
+		}
+		if err := database.CreateAnnotation(a); err != nil {
+			logger.Printf("Failed to record power event: %v", err)
+		}
+		if handle != nil {
+			handle(event)
+		}
+	})
+	return monitor
+}
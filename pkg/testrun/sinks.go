@@ -0,0 +1,133 @@
+package testrun
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/monitor"
+	"github.com/mscrnt/project_fire/pkg/sink"
+)
+
+// Sinks streams samples and run results to time-series databases and MQTT
+// brokers. A nil *Sinks streams nothing, so callers need not check.
+type Sinks struct {
+	writer   *sink.Writer
+	interval time.Duration
+}
+
+// SinkURLs returns the sink URLs of the settings file followed by urls,
+// without repeats or blanks
+func SinkURLs(settings, urls []string) []string {
+	var all []string
+	seen := make(map[string]bool)
+	for _, u := range append(append([]string{}, settings...), urls...) {
+		if u = strings.TrimSpace(u); u != "" && !seen[u] {
+			seen[u] = true
+			all = append(all, u)
+		}
+	}
+	return all
+}
+
+// OpenSinks connects to the sinks at urls, sampling every interval. Failed
+// writes are reported with logf. It returns nil when urls is empty.
+func OpenSinks(ctx context.Context, urls []string, interval time.Duration, logf func(format string, args ...interface{})) (*Sinks, error) {
+	if len(urls) == 0 {
+		return nil, nil
+	}
+	if interval <= 0 {
+		interval = sink.DefaultInterval
+	}
+
+	var sinks []sink.Sink
+	for _, u := range urls {
+		s, err := sink.Open(ctx, u)
+		if err != nil {
+			for _, opened := range sinks {
+				_ = opened.Close()
+			}
+			return nil, fmt.Errorf("sink %s: %w", sink.Redact(u), err)
+		}
+		sinks = append(sinks, s)
+	}
+	return &Sinks{writer: sink.NewWriter(sinks, logf), interval: interval}, nil
+}
+
+// Names returns the sinks written to, with passwords redacted
+func (s *Sinks) Names() []string {
+	if s == nil {
+		return nil
+	}
+	return s.writer.Sinks()
+}
+
+// Interval returns the time between samples
+func (s *Sinks) Interval() time.Duration {
+	if s == nil {
+		return 0
+	}
+	return s.interval
+}
+
+// Add writes a monitor sample with the tags
+func (s *Sinks) Add(sample *monitor.Sample, tags map[string]string) {
+	if s == nil {
+		return
+	}
+	values, units := sample.Metrics()
+	s.writer.Add(sink.SamplePoint(sample.Time, tags, values, units))
+}
+
+// AddRun writes the metrics of a finished run
+func (s *Sinks) AddRun(run *db.Run, source string, metrics map[string]float64) {
+	if s == nil {
+		return
+	}
+	s.writer.Add(sink.RunPoint(run, map[string]string{"source": source}, metrics))
+}
+
+// Stream samples the dashboard readings every interval in the background
+// and writes them with the tags, until ctx ends or the returned function is
+// called
+func (s *Sinks) Stream(ctx context.Context, tags map[string]string) (stop func()) {
+	if s == nil {
+		return func() {}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		poller := monitor.NewPoller()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			sample, err := poller.Sample(ctx)
+			if err != nil {
+				return // Only a finished context fails a sample
+			}
+			s.Add(sample, tags)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// Close writes the queued points. dropped is how many points were lost
+// because the sinks could not keep up.
+func (s *Sinks) Close() (dropped int, err error) {
+	if s == nil {
+		return 0, nil
+	}
+	return s.writer.Close()
+}
@@ -0,0 +1,350 @@
+// Package testrun runs a test plugin and records it in the results database.
+//
+// bench test, the scheduler, the agent, test plans and the GUI all run their
+// tests through Run, so a run is recorded the same way wherever it was
+// started: the run with its tags, environment and hardware inventory, the
+// hooks, power events, safety limits, throttling, ECC errors, BMC events,
+// the samples streamed to the sinks, results, drive warnings and artifacts.
+// What differs between callers, such as where progress is written or which
+// safety limits apply, is set in Options.
+package testrun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/artifact"
+	"github.com/mscrnt/project_fire/pkg/benchmode"
+	"github.com/mscrnt/project_fire/pkg/changelog"
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/ecc"
+	"github.com/mscrnt/project_fire/pkg/hooks"
+	"github.com/mscrnt/project_fire/pkg/i18n"
+	"github.com/mscrnt/project_fire/pkg/inventory"
+	"github.com/mscrnt/project_fire/pkg/ipmi"
+	"github.com/mscrnt/project_fire/pkg/journal"
+	"github.com/mscrnt/project_fire/pkg/plugin"
+	"github.com/mscrnt/project_fire/pkg/power"
+	"github.com/mscrnt/project_fire/pkg/safety"
+	"github.com/mscrnt/project_fire/pkg/sink"
+	"github.com/mscrnt/project_fire/pkg/storage"
+	"github.com/mscrnt/project_fire/pkg/throttle"
+	"github.com/mscrnt/project_fire/pkg/virt"
+)
+
+// Grace is how long a test may run past its duration before it is stopped
+const Grace = 30 * time.Second
+
+// Options are what a caller sets about a run. The zero value records the
+// run with none of the optional watches.
+type Options struct {
+	Source   string            // "cli", "schedule", "agent", "plan" or "gui"
+	Schedule string            // Name of the schedule that started the run
+	Tags     map[string]string // name=value tags of the run
+
+	Hooks  hooks.Config  // Scripts run before and after the test
+	Limits safety.Limits // Stop the test at these temperatures or power draw
+
+	// Power lists the power sources whose events are recorded with the run.
+	// A UPS whose battery runs low stops the test, as does any UPS
+	// switching to battery when PauseOnBattery is set.
+	Power          []power.Source
+	PauseOnBattery bool
+
+	Sinks      *Sinks             // Stream samples and the results; nil for none
+	BenchMode  *benchmode.Options // Lock clocks and the power plan during the test
+	TPMQuote   bool               // Store the TPM details and a quote of the boot PCRs
+	AllowSleep bool               // Let the machine sleep during the test
+
+	// Output receives the progress of the run, such as the benchmark mode
+	// settings changed, and the output of the hooks. Logger receives
+	// problems that do not stop the run, such as a result that could not be
+	// saved. Nil discards them.
+	Output io.Writer
+	Logger *log.Logger
+
+	// Started is called once the run record exists, before the test starts
+	Started func(run *db.Run)
+
+	// Finished is called once the run is recorded and the post-run hooks
+	// have run, whether the test passed, failed or was aborted by a pre-run
+	// hook, such as to send its report
+	Finished func(run *db.Run)
+}
+
+// Outcome is a recorded run
+type Outcome struct {
+	Run        *db.Run
+	Result     plugin.Result
+	Units      map[string]string // Units of the result's metrics
+	Throttling *throttle.Report
+	ECC        *ecc.Report
+	Duration   time.Duration // How long the test ran
+
+	// Err is the test's own error; Cause is why it was stopped early, such
+	// as a safety limit, a UPS or ctx being cancelled
+	Err   error
+	Cause error
+}
+
+// ErrAborted is wrapped by the error of a run that a pre-run hook stopped
+// before the test started
+var ErrAborted = errors.New("test aborted")
+
+// Run runs plugin p with params and records it. It returns an error only
+// when the run could not be recorded, with a nil Outcome, or when a pre-run
+// hook aborted it; failures of the test itself are in the Outcome.
+// Cancelling ctx stops the test and records it as failed.
+func Run(ctx context.Context, database *db.DB, p plugin.TestPlugin, params plugin.Params, opts Options) (*Outcome, error) {
+	output := opts.Output
+	if output == nil {
+		output = io.Discard
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = log.New(io.Discard, "", 0)
+	}
+
+	// Note firmware and driver updates since the last run
+	if changes, err := changelog.Record(ctx, database); err != nil {
+		logger.Printf("Could not record versions: %v", err)
+	} else {
+		for _, c := range changes {
+			if !c.Baseline() {
+				fmt.Fprintln(output, i18n.T("test.version_changed", changelog.Describe(c)))
+			}
+		}
+	}
+
+	run, err := database.CreateRun(p.Name(), db.JSONData(params.Config))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create run record: %w", err)
+	}
+	stopJournal := journal.Start(database, run)
+	defer stopJournal()
+	if len(opts.Tags) > 0 {
+		if err := database.SetRunTags(run.ID, opts.Tags); err != nil {
+			logger.Printf("Failed to tag run %d: %v", run.ID, err)
+		} else {
+			run.Tags = opts.Tags
+		}
+	}
+
+	// Virtual sensors and shared hardware make results incomparable with bare metal
+	if env := virt.Detect(); env.Virtual() {
+		run.Environment = env.Label()
+		fmt.Fprintln(output, i18n.T("test.environment", run.Environment))
+	}
+	changelog.Identify(run)
+	if err := inventory.Record(database, run.ID); err != nil {
+		logger.Printf("Failed to record hardware inventory of run %d: %v", run.ID, err)
+	}
+	if opts.Started != nil {
+		opts.Started(run)
+	}
+
+	if !opts.AllowSleep {
+		releaseSleep, err := power.Inhibit(fmt.Sprintf("Running %s test", p.Name()))
+		if err != nil {
+			logger.Printf("Could not prevent sleep: %v", err)
+		}
+		defer releaseSleep()
+	}
+
+	if opts.BenchMode != nil {
+		restore := enableBenchmarkMode(*opts.BenchMode, output, logger)
+		defer restore()
+	}
+
+	artifactRoot := artifact.DefaultRoot()
+	if opts.TPMQuote {
+		captureTPMState(ctx, artifactRoot, run, output, logger)
+	}
+
+	hookInfo := hooks.RunInfo{Run: run, Source: opts.Source, Schedule: opts.Schedule, ArtifactDir: artifact.RunDir(artifactRoot, run.ID)}
+	if err := opts.Hooks.Run(ctx, hooks.PreRun, hookInfo, output); err != nil && opts.Hooks.AbortOnFailure {
+		endTime := time.Now()
+		run.EndTime = &endTime
+		run.ExitCode = 1
+		run.Error = err.Error()
+		if err := database.UpdateRun(run); err != nil {
+			logger.Printf("Failed to update run %d: %v", run.ID, err)
+		}
+		_ = opts.Hooks.Run(context.WithoutCancel(ctx), hooks.PostRun, hookInfo, output)
+		if opts.Finished != nil {
+			opts.Finished(run)
+		}
+		return &Outcome{Run: run, Result: plugin.Result{Error: run.Error}, Err: err}, fmt.Errorf("%w: %w", ErrAborted, err)
+	}
+
+	// The power and safety watches stop the run early with their cause
+	runCtx, stopRun := context.WithCancelCause(ctx)
+	defer stopRun(nil)
+	testCtx, cancel := context.WithTimeout(runCtx, params.Duration+Grace)
+	defer cancel()
+	watchCtx, stopWatch := context.WithCancel(context.WithoutCancel(ctx))
+	defer stopWatch()
+
+	// Record power events so failures can be told apart from hardware faults
+	WatchPower(watchCtx, database, opts.Power, run.ID, logger, func(event power.Event) {
+		logger.Printf("Power event: %s", event.Message)
+		switch {
+		case event.UPSLow():
+			stopRun(fmt.Errorf("%w: %s", power.ErrUPSBatteryLow, event.Message))
+		case opts.PauseOnBattery && event.UPS && event.Kind == power.EventOnBattery:
+			stopRun(power.ErrUPSOnBattery)
+		}
+	})
+
+	// Stop the test before the machine overheats or overdraws its supply
+	if opts.Limits.Enabled() {
+		fmt.Fprintln(output, i18n.T("test.safety_limits", opts.Limits))
+		sample := safety.MonitorSampler()
+		if values, err := sample(watchCtx); err == nil {
+			for _, name := range opts.Limits.Unread(values) {
+				logger.Printf("The %s cannot be read on this machine, so its limit is not enforced", name)
+			}
+		}
+		go safety.Watch(watchCtx, opts.Limits, sample, safety.DefaultInterval, func(cause error) {
+			if watchCtx.Err() != nil {
+				return // The test already ended
+			}
+			logger.Printf("Stopping test: %v", cause)
+			stopRun(cause)
+		})
+	}
+
+	startTime := time.Now()
+	throttleWatch := throttle.Start(testCtx, throttle.SensorSampler(), throttle.DefaultInterval)
+	eccWatch := ecc.Start(testCtx, ecc.Read, ecc.DefaultInterval)
+	selWatch := ipmi.WatchSEL(testCtx, ipmi.ReadSEL)
+	stopStream := opts.Sinks.Stream(testCtx, sink.RunTags(run, map[string]string{"source": opts.Source}))
+	result, testErr := p.Run(testCtx, params)
+	stopStream()
+	throttling := throttleWatch.Stop()
+	eccErrors := eccWatch.Stop()
+	selEntries := selWatch.Stop()
+	endTime := time.Now()
+	stopWatch()
+
+	run.EndTime = &endTime
+	run.Success = result.Success
+	run.Error = result.Error
+	run.Stdout = result.Stdout
+	run.Stderr = result.Stderr
+	if testErr != nil {
+		run.ExitCode = 1
+		if run.Error == "" {
+			run.Error = testErr.Error()
+		}
+	}
+	cause := context.Cause(runCtx)
+	if cause != nil {
+		result.Success = false
+		result.Error = cause.Error()
+		run.Success = false
+		run.ExitCode = 1
+		run.Error = result.Error
+	}
+	if err := database.UpdateRun(run); err != nil {
+		logger.Printf("Failed to update run %d: %v", run.ID, err)
+	}
+
+	units := plugin.MetricUnits(p, result.Metrics)
+	if len(result.Metrics) > 0 {
+		if err := database.CreateResults(run.ID, result.Metrics, units); err != nil {
+			logger.Printf("Failed to save metrics of run %d: %v", run.ID, err)
+		}
+	}
+	opts.Sinks.AddRun(run, opts.Source, result.Metrics)
+	if err := throttling.Save(database, run.ID); err != nil {
+		logger.Printf("Failed to save throttle events of run %d: %v", run.ID, err)
+	}
+	if err := eccErrors.Save(database, run.ID); err != nil {
+		logger.Printf("Failed to save ECC errors of run %d: %v", run.ID, err)
+	}
+	if err := selEntries.Save(database, run.ID); err != nil {
+		logger.Printf("Failed to save BMC event log entries of run %d: %v", run.ID, err)
+	}
+	if err := artifact.SaveResult(artifactRoot, run.ID, result); err != nil {
+		logger.Printf("Failed to save artifacts of run %d: %v", run.ID, err)
+	}
+
+	// Drives linked below their capability or with a risky write cache are
+	// flagged in the report
+	if err := storage.SaveWarnings(database, run, result.Details); err != nil {
+		logger.Printf("Failed to save drive warnings of run %d: %v", run.ID, err)
+	}
+
+	var limit *safety.LimitError
+	if errors.As(cause, &limit) {
+		a := &db.Annotation{RunID: &run.ID, Time: endTime, Source: "safety", Kind: "safety_abort", Message: limit.Error()}
+		if err := database.CreateAnnotation(a); err != nil {
+			logger.Printf("Failed to record the safety abort of run %d: %v", run.ID, err)
+		}
+	}
+
+	// Make sure the interrupted run is on disk before the UPS shuts off
+	if errors.Is(cause, power.ErrUPSBatteryLow) {
+		if err := database.Flush(); err != nil {
+			logger.Printf("Failed to flush the database: %v", err)
+		}
+		logger.Print(i18n.T("test.ups_saved"))
+	}
+
+	// Post-run hook failures are reported but do not change the result
+	_ = opts.Hooks.Run(context.WithoutCancel(ctx), hooks.PostRun, hookInfo, output)
+	if opts.Finished != nil {
+		opts.Finished(run)
+	}
+
+	return &Outcome{
+		Run:        run,
+		Result:     result,
+		Units:      units,
+		Throttling: throttling,
+		ECC:        eccErrors,
+		Duration:   endTime.Sub(startTime),
+		Err:        testErr,
+		Cause:      cause,
+	}, nil
+}
+
+// Failure returns why a recorded run did not pass, or nil when it passed
+func (o *Outcome) Failure() error {
+	switch {
+	case o.Err != nil:
+		return o.Err
+	case o.Run.Success:
+		return nil
+	case strings.TrimSpace(o.Run.Error) != "":
+		return errors.New(strings.TrimSpace(o.Run.Error))
+	}
+	return errors.New("test did not pass")
+}
+
+// enableBenchmarkMode applies benchmark mode, reporting what was changed, and
+// returns a function that restores the previous settings
+func enableBenchmarkMode(opts benchmode.Options, output io.Writer, logger *log.Logger) func() {
+	session, errs := benchmode.Enable(opts)
+	for _, err := range errs {
+		logger.Printf("Benchmark mode: %v", err)
+	}
+	if len(session.Changes()) == 0 {
+		logger.Printf("Benchmark mode could not change any settings on this system")
+	}
+	for _, c := range session.Changes() {
+		fmt.Fprintln(output, i18n.T("test.benchmark_mode", c))
+	}
+
+	return func() {
+		if err := session.Restore(); err != nil {
+			logger.Printf("Failed to restore settings, run 'bench benchmode restore': %v", err)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package testrun
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/hooks"
+	"github.com/mscrnt/project_fire/pkg/plugin"
+)
+
+// runTestPlugin passes with a score, or waits for its context when its
+// config sets wait=true
+type runTestPlugin struct{}
+
+func (runTestPlugin) Name() string        { return "testrun-test" }
+func (runTestPlugin) Description() string { return "Test plugin for runs" }
+func (runTestPlugin) DefaultParams() plugin.Params {
+	return plugin.Params{Duration: time.Minute, Threads: 1, Config: map[string]interface{}{}}
+}
+func (runTestPlugin) ValidateParams(plugin.Params) error { return nil }
+func (runTestPlugin) Run(ctx context.Context, params plugin.Params) (plugin.Result, error) {
+	if params.Config["wait"] == true {
+		<-ctx.Done()
+		return plugin.Result{}, ctx.Err()
+	}
+	return plugin.Result{Success: true, Metrics: map[string]float64{"score": 42}}, nil
+}
+
+func init() {
+	_ = plugin.Register(runTestPlugin{})
+}
+
+func openTestDB(t *testing.T) *db.DB {
+	t.Helper()
+	t.Setenv("FIRE_ARTIFACTS", t.TempDir())
+	database, err := db.Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+	return database
+}
+
+func TestRunRecords(t *testing.T) {
+	database := openTestDB(t)
+	p := runTestPlugin{}
+
+	var started, finished *db.Run
+	outcome, err := Run(context.Background(), database, p, p.DefaultParams(), Options{
+		Source:     "cli",
+		Tags:       map[string]string{"customer": "acme"},
+		AllowSleep: true,
+		Started:    func(run *db.Run) { started = run },
+		Finished:   func(run *db.Run) { finished = run },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if started == nil || finished == nil || started.ID != outcome.Run.ID || finished.ID != outcome.Run.ID {
+		t.Fatalf("expected Started and Finished to be called with run %d", outcome.Run.ID)
+	}
+	if outcome.Failure() != nil {
+		t.Errorf("expected the run to pass, got %v", outcome.Failure())
+	}
+
+	run, err := database.GetRun(outcome.Run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !run.Success || run.EndTime == nil || run.Plugin != p.Name() {
+		t.Errorf("expected a finished, passed run, got %+v", run)
+	}
+	results, err := database.GetResults(run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Metric != "score" || results[0].Value != 42 {
+		t.Errorf("expected the score to be saved, got %+v", results)
+	}
+	tags, err := database.RunTags(run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tags["customer"] != "acme" {
+		t.Errorf("expected the run to be tagged, got %v", tags)
+	}
+}
+
+func TestRunStopped(t *testing.T) {
+	database := openTestDB(t)
+	p := runTestPlugin{}
+	params := p.DefaultParams()
+	params.Config["wait"] = true
+
+	cause := errors.New("CPU reached 96.0 °C")
+	ctx, stop := context.WithCancelCause(context.Background())
+	outcome, err := Run(ctx, database, p, params, Options{
+		AllowSleep: true,
+		Started:    func(*db.Run) { stop(cause) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(outcome.Cause, cause) {
+		t.Errorf("expected the cause to be kept, got %v", outcome.Cause)
+	}
+	run, err := database.GetRun(outcome.Run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Success || run.ExitCode != 1 || run.Error != cause.Error() {
+		t.Errorf("expected the stopped run to fail with its cause, got %+v", run)
+	}
+}
+
+func TestRunAbortedByHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts")
+	}
+	database := openTestDB(t)
+	p := runTestPlugin{}
+
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "post-run")
+	failing := filepath.Join(dir, "fail.sh")
+	if err := os.WriteFile(failing, []byte("#!/bin/sh\nexit 3\n"), 0o700); err != nil { // #nosec G306 -- test script must be executable
+		t.Fatal(err)
+	}
+	post := filepath.Join(dir, "post.sh")
+	if err := os.WriteFile(post, []byte("#!/bin/sh\ntouch \""+marker+"\"\n"), 0o700); err != nil { // #nosec G306 -- test script must be executable
+		t.Fatal(err)
+	}
+
+	finished := false
+	outcome, err := Run(context.Background(), database, p, p.DefaultParams(), Options{
+		Hooks: hooks.Config{
+			PreRun:         []hooks.Hook{{Command: failing}},
+			PostRun:        []hooks.Hook{{Command: post}},
+			AbortOnFailure: true,
+		},
+		AllowSleep: true,
+		Finished:   func(*db.Run) { finished = true },
+	})
+	if !errors.Is(err, ErrAborted) {
+		t.Fatalf("expected the run to be aborted, got %v", err)
+	}
+	if !finished {
+		t.Error("expected Finished to be called for an aborted run")
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Errorf("expected the post-run hook to run: %v", err)
+	}
+	run, err := database.GetRun(outcome.Run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Success || run.ExitCode != 1 || run.EndTime == nil {
+		t.Errorf("expected the aborted run to be recorded as failed, got %+v", run)
+	}
+}
@@ -0,0 +1,52 @@
+package testrun
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/mscrnt/project_fire/pkg/artifact"
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/tpm"
+)
+
+// tpmInfoArtifact holds the TPM details recorded with a quote
+const tpmInfoArtifact = "tpm-info.json"
+
+// captureTPMState stores the TPM details and a quote of the boot PCRs as
+// artifacts of the run, documenting the platform state at validation time.
+// The quote's nonce is derived from the run UUID so it cannot be replayed
+// for another run. Failures are logged: the test still runs.
+func captureTPMState(ctx context.Context, root string, run *db.Run, output io.Writer, logger *log.Logger) {
+	info, err := tpm.Read()
+	if err != nil {
+		logger.Printf("TPM quote skipped: %v", err)
+		return
+	}
+	if data, err := json.MarshalIndent(info, "", "  "); err == nil {
+		if _, err := artifact.Save(root, run.ID, tpmInfoArtifact, data); err != nil {
+			logger.Printf("Could not save TPM details: %v", err)
+		}
+	}
+	fmt.Fprintf(output, "TPM: %s\n", info.Summary())
+
+	if info.Version != "2.0" {
+		logger.Printf("TPM quote skipped: TPM %s is not supported", info.Version)
+		return
+	}
+	nonce := sha256.Sum256([]byte(run.UUID))
+	files, err := tpm.Quote(ctx, artifact.RunDir(root, run.ID), nonce[:])
+	if err != nil {
+		if errors.Is(err, tpm.ErrNoTools) {
+			logger.Printf("TPM quote skipped: %v", err)
+		} else {
+			logger.Printf("TPM quote failed: %v", err)
+		}
+		return
+	}
+	fmt.Fprintf(output, "TPM quote of PCRs %s saved with %d files\n", tpm.QuotePCRs, len(files))
+}
@@ -0,0 +1,42 @@
+//go:build linux
+// +build linux
+
+package throttle
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sysfsRoot is prefixed to the cpufreq paths; tests point it at a fake tree
+var sysfsRoot = "/"
+
+// platformLimits reads the clocks cpufreq reports for the first CPU. Only
+// some drivers, such as intel_pstate, report the base clock.
+func platformLimits() Limits {
+	dir := filepath.Join(sysfsRoot, "sys/devices/system/cpu/cpu0/cpufreq")
+	return Limits{
+		BaseMHz:  readKHz(filepath.Join(dir, "base_frequency")) / 1000,
+		BoostMHz: readKHz(filepath.Join(dir, "cpuinfo_max_freq")) / 1000,
+	}
+}
+
+// nominalClock returns 0: /proc/cpuinfo reports the current clock, not the
+// rated one
+func nominalClock(float64) float64 {
+	return 0
+}
+
+func readKHz(path string) float64 {
+	data, err := os.ReadFile(path) // #nosec G304 -- sysfs path
+	if err != nil {
+		return 0
+	}
+	khz, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+	if err != nil {
+		return 0
+	}
+	return khz
+}
@@ -0,0 +1,35 @@
+//go:build linux
+// +build linux
+
+package throttle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPlatformLimits(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "sys/devices/system/cpu/cpu0/cpufreq")
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	for name, khz := range map[string]string{"base_frequency": "3600000", "cpuinfo_max_freq": "4900000"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(khz+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := sysfsRoot
+	sysfsRoot = root
+	defer func() { sysfsRoot = old }()
+
+	if l := platformLimits(); l.BaseMHz != 3600 || l.BoostMHz != 4900 {
+		t.Errorf("expected 3600/4900 MHz, got %+v", l)
+	}
+
+	sysfsRoot = t.TempDir()
+	if l := platformLimits(); l.BaseMHz != 0 || l.BoostMHz != 0 {
+		t.Errorf("expected unknown clocks without cpufreq, got %+v", l)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+// +build !linux
+
+package throttle
+
+// platformLimits leaves the clocks to the CPU information gopsutil reads
+func platformLimits() Limits {
+	return Limits{}
+}
+
+// nominalClock returns the clock gopsutil reports, which on Windows and
+// macOS is the rated clock
+func nominalClock(mhz float64) float64 {
+	return mhz
+}
@@ -0,0 +1,353 @@
+// Package throttle detects CPU throttling while a test runs.
+//
+// A Watch samples the CPU clock, load and temperature alongside the test. A
+// sample is throttled when the CPU is busy but its fastest core runs below
+// the base clock, or when its temperature is within a few degrees of the
+// critical limit. Consecutive throttled samples form an event; the events
+// are saved with the run as results (throttled, throttle_seconds and
+// throttle_events, with the peak temperature as cpu_temp_peak) and as
+// "throttle" annotations, so the report lists them and the certificate
+// carries the flag.
+package throttle
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/sensors"
+	"github.com/shirou/gopsutil/v3/cpu"
+)
+
+// Detection settings
+const (
+	// DefaultInterval is the time between samples
+	DefaultInterval = time.Second
+	// ClockMargin is the fraction of the base clock a busy CPU may drop to
+	// before it counts as throttled
+	ClockMargin = 0.95
+	// BusyPercent is the CPU usage above which a low clock counts; an idle
+	// CPU clocks down on purpose
+	BusyPercent = 80.0
+	// HeadroomC is the distance in °C from the critical temperature that
+	// counts as thermal throttling
+	HeadroomC = 5.0
+	// DefaultCriticalC is assumed when the sensor reports no critical
+	// temperature
+	DefaultCriticalC = 100.0
+	// MinEvent is the shortest event recorded; shorter dips are noise
+	MinEvent = 2 * time.Second
+)
+
+// AnnotationKind is the kind of the annotations throttle events are saved as
+const AnnotationKind = "throttle"
+
+// Metric names saved with the run
+const (
+	MetricThrottled = "throttled"
+	MetricSeconds   = "throttle_seconds"
+	MetricEvents    = "throttle_events"
+	MetricPeakTemp  = "cpu_temp_peak"
+)
+
+// Limits are the clocks of the CPU in MHz, zero when unknown
+type Limits struct {
+	BaseMHz  float64
+	BoostMHz float64
+}
+
+// modelClock matches the rated clock in a CPU model name, e.g.
+// "Intel(R) Core(TM) i7-9700K CPU @ 3.60GHz"
+var modelClock = regexp.MustCompile(`@\s*([\d.]+)\s*GHz`)
+
+// ReadLimits returns the CPU's base and boost clocks. The base clock comes
+// from cpufreq where the driver reports it, otherwise from the model name
+// or the clock the platform reports as nominal.
+func ReadLimits(ctx context.Context) Limits {
+	l := platformLimits()
+	if l.BaseMHz > 0 {
+		return l
+	}
+	info, err := cpu.InfoWithContext(ctx)
+	if err != nil || len(info) == 0 {
+		return l
+	}
+	if m := modelClock.FindStringSubmatch(info[0].ModelName); m != nil {
+		if ghz, err := strconv.ParseFloat(m[1], 64); err == nil {
+			l.BaseMHz = ghz * 1000
+			return l
+		}
+	}
+	l.BaseMHz = nominalClock(info[0].Mhz)
+	return l
+}
+
+// Sample is one reading of the CPU. Zero values were not read.
+type Sample struct {
+	Time      time.Time
+	ClockMHz  float64 // Fastest core
+	Usage     float64 // Percent
+	TempC     float64
+	CriticalC float64
+}
+
+// SampleFunc takes one sample
+type SampleFunc func(ctx context.Context) (Sample, error)
+
+// SensorSampler reads the shared sensor provider and the CPU usage
+func SensorSampler() SampleFunc {
+	provider := sensors.Default()
+	return func(ctx context.Context) (Sample, error) {
+		s := Sample{Time: time.Now()}
+		if usage, err := cpu.PercentWithContext(ctx, 0, false); err == nil && len(usage) > 0 {
+			s.Usage = usage[0]
+		}
+		snap, err := provider.Read(ctx)
+		if err != nil {
+			return s, err
+		}
+		s.ClockMHz = snap.MaxCoreClock()
+		s.TempC = snap.CPUTemp
+		for _, r := range snap.Temperatures {
+			if r.Value == snap.CPUTemp && r.Critical > 0 {
+				s.CriticalC = r.Critical
+				break
+			}
+		}
+		return s, nil
+	}
+}
+
+// Event is one stretch of throttled samples
+type Event struct {
+	Start       time.Time
+	Duration    time.Duration
+	Clock       bool    // The clock fell below the base
+	Thermal     bool    // The temperature came within HeadroomC of critical
+	MinClockMHz float64 // Lowest clock while busy, 0 when not read
+	PeakTempC   float64
+	CriticalC   float64
+}
+
+// Describe explains the event, e.g. "CPU throttled for 12s: clock fell to
+// 2100 MHz, below the 3600 MHz base"
+func (e Event) Describe(limits Limits) string {
+	var causes []string
+	if e.Clock {
+		causes = append(causes, fmt.Sprintf("clock fell to %.0f MHz, below the %.0f MHz base", e.MinClockMHz, limits.BaseMHz))
+	}
+	if e.Thermal {
+		causes = append(causes, fmt.Sprintf("%.0f °C, %.0f °C from the %.0f °C limit", e.PeakTempC, math.Max(e.CriticalC-e.PeakTempC, 0), e.CriticalC))
+	}
+	return fmt.Sprintf("CPU throttled for %s: %s", e.Duration.Round(time.Second), strings.Join(causes, "; "))
+}
+
+// Detector turns samples into throttle events. It is not safe for
+// concurrent use.
+type Detector struct {
+	limits  Limits
+	report  Report
+	current *Event
+	clocks  float64 // Sum of busy clocks
+	busy    int
+}
+
+// NewDetector returns a detector for a CPU with the given clocks
+func NewDetector(limits Limits) *Detector {
+	return &Detector{limits: limits, report: Report{Limits: limits}}
+}
+
+// Add takes one sample, in time order
+func (d *Detector) Add(s Sample) {
+	d.report.Samples++
+	critical := s.CriticalC
+	if critical == 0 {
+		critical = DefaultCriticalC
+	}
+	busy := s.Usage >= BusyPercent && s.ClockMHz > 0
+	if busy {
+		d.busy++
+		d.clocks += s.ClockMHz
+		if d.report.MinClockMHz == 0 || s.ClockMHz < d.report.MinClockMHz {
+			d.report.MinClockMHz = s.ClockMHz
+		}
+	}
+	d.report.PeakTempC = math.Max(d.report.PeakTempC, s.TempC)
+
+	clock := busy && d.limits.BaseMHz > 0 && s.ClockMHz < d.limits.BaseMHz*ClockMargin
+	thermal := s.TempC > 0 && critical-s.TempC <= HeadroomC
+	if !clock && !thermal {
+		d.end(s.Time)
+		return
+	}
+
+	if d.current == nil {
+		d.current = &Event{Start: s.Time, CriticalC: critical}
+	}
+	e := d.current
+	e.Duration = s.Time.Sub(e.Start)
+	if clock {
+		e.Clock = true
+		if e.MinClockMHz == 0 || s.ClockMHz < e.MinClockMHz {
+			e.MinClockMHz = s.ClockMHz
+		}
+	}
+	if thermal {
+		e.Thermal = true
+	}
+	e.PeakTempC = math.Max(e.PeakTempC, s.TempC)
+}
+
+// end closes the current event at t, keeping it if it lasted long enough
+func (d *Detector) end(t time.Time) {
+	if d.current == nil {
+		return
+	}
+	d.current.Duration = t.Sub(d.current.Start)
+	if d.current.Duration >= MinEvent {
+		d.report.Events = append(d.report.Events, *d.current)
+	}
+	d.current = nil
+}
+
+// Report closes any event in progress at end and returns what was detected
+func (d *Detector) Report(end time.Time) *Report {
+	d.end(end)
+	r := d.report
+	r.Events = append([]Event(nil), d.report.Events...)
+	if d.busy > 0 {
+		r.AvgClockMHz = d.clocks / float64(d.busy)
+	}
+	return &r
+}
+
+// Report is what a watch detected over a run
+type Report struct {
+	Limits
+	Events      []Event
+	Samples     int
+	AvgClockMHz float64 // Mean clock while busy
+	MinClockMHz float64 // Lowest clock while busy
+	PeakTempC   float64
+}
+
+// Throttled reports whether any throttle event was recorded
+func (r *Report) Throttled() bool {
+	return len(r.Events) > 0
+}
+
+// Total returns the time spent throttled
+func (r *Report) Total() time.Duration {
+	var total time.Duration
+	for _, e := range r.Events {
+		total += e.Duration
+	}
+	return total
+}
+
+// Summary describes the report in one line
+func (r *Report) Summary() string {
+	if !r.Throttled() {
+		return "No throttling detected"
+	}
+	events := "event"
+	if len(r.Events) != 1 {
+		events += "s"
+	}
+	return fmt.Sprintf("Throttled for %s in %d %s", r.Total().Round(time.Second), len(r.Events), events)
+}
+
+// Metrics returns the results saved with the run
+func (r *Report) Metrics() (values map[string]float64, units map[string]string) {
+	throttled := 0.0
+	if r.Throttled() {
+		throttled = 1
+	}
+	values = map[string]float64{
+		MetricThrottled: throttled,
+		MetricSeconds:   r.Total().Seconds(),
+		MetricEvents:    float64(len(r.Events)),
+	}
+	units = map[string]string{MetricSeconds: "s"}
+	if r.PeakTempC > 0 {
+		values[MetricPeakTemp] = r.PeakTempC
+		units[MetricPeakTemp] = "°C"
+	}
+	return values, units
+}
+
+// Save stores the report with the run: its metrics as results and each
+// event as an annotation at the time it started
+func (r *Report) Save(database *db.DB, runID int64) error {
+	if r.Samples == 0 {
+		return nil
+	}
+	values, units := r.Metrics()
+	if err := database.CreateResults(runID, values, units); err != nil {
+		return err
+	}
+	for _, e := range r.Events {
+		a := &db.Annotation{RunID: &runID, Time: e.Start, Source: "cpu", Kind: AnnotationKind, Message: e.Describe(r.Limits)}
+		if err := database.CreateAnnotation(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FromResults reads the throttle flag and time back from a run's results.
+// ok is false when the run was not watched.
+func FromResults(results []*db.Result) (throttled bool, seconds float64, ok bool) {
+	for _, r := range results {
+		switch r.Metric {
+		case MetricThrottled:
+			throttled, ok = r.Value != 0, true
+		case MetricSeconds:
+			seconds = r.Value
+		}
+	}
+	return throttled, seconds, ok
+}
+
+// Watch samples in the background until stopped
+type Watch struct {
+	cancel context.CancelFunc
+	done   chan *Report
+}
+
+// Start begins sampling every interval until ctx ends or Stop is called
+func Start(ctx context.Context, sample SampleFunc, interval time.Duration) *Watch {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	w := &Watch{cancel: cancel, done: make(chan *Report, 1)}
+	go func() {
+		d := NewDetector(ReadLimits(ctx))
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				w.done <- d.Report(time.Now())
+				return
+			case <-ticker.C:
+			}
+			if s, err := sample(ctx); err == nil {
+				d.Add(s)
+			}
+		}
+	}()
+	return w
+}
+
+// Stop ends the watch and returns what it detected
+func (w *Watch) Stop() *Report {
+	w.cancel()
+	return <-w.done
+}
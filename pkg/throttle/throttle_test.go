@@ -0,0 +1,132 @@
+package throttle
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+)
+
+func TestDetector(t *testing.T) {
+	start := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	d := NewDetector(Limits{BaseMHz: 3600, BoostMHz: 4900})
+	at := func(i int) time.Time { return start.Add(time.Duration(i) * time.Second) }
+
+	samples := []Sample{
+		{ClockMHz: 800, Usage: 5, TempC: 40},                   // idle clocks down on purpose
+		{ClockMHz: 4700, Usage: 100, TempC: 80},                // boosting
+		{ClockMHz: 3000, Usage: 100, TempC: 90},                // below base: event starts
+		{ClockMHz: 2800, Usage: 100, TempC: 97},                // and near the limit
+		{ClockMHz: 3200, Usage: 100, TempC: 96},                // still near the limit
+		{ClockMHz: 4500, Usage: 100, TempC: 85},                // recovered after 3s
+		{ClockMHz: 3100, Usage: 100, TempC: 85},                // a one-second dip
+		{ClockMHz: 4500, Usage: 100, TempC: 85},                // is left out
+		{ClockMHz: 4400, Usage: 100, TempC: 92, CriticalC: 95}, // the sensor's own limit
+		{ClockMHz: 4400, Usage: 100, TempC: 93, CriticalC: 95}, // still hot when the run ends
+	}
+	for i, s := range samples {
+		s.Time = at(i)
+		d.Add(s)
+	}
+	r := d.Report(at(len(samples) + 1))
+
+	if !r.Throttled() || len(r.Events) != 2 {
+		t.Fatalf("expected two throttle events, got %+v", r.Events)
+	}
+	e := r.Events[0]
+	if !e.Start.Equal(at(2)) || e.Duration != 3*time.Second || !e.Clock || !e.Thermal || e.MinClockMHz != 2800 || e.PeakTempC != 97 {
+		t.Errorf("unexpected first event %+v", e)
+	}
+	if got := e.Describe(r.Limits); got != "CPU throttled for 3s: clock fell to 2800 MHz, below the 3600 MHz base; 97 °C, 3 °C from the 100 °C limit" {
+		t.Errorf("unexpected description %q", got)
+	}
+	e = r.Events[1]
+	if !e.Start.Equal(at(8)) || e.Duration != 3*time.Second || e.Clock || !e.Thermal || e.CriticalC != 95 {
+		t.Errorf("expected the second event to run to the end of the run, got %+v", e)
+	}
+	if r.Total() != 6*time.Second || r.Summary() != "Throttled for 6s in 2 events" {
+		t.Errorf("unexpected total %s (%s)", r.Total(), r.Summary())
+	}
+	if r.MinClockMHz != 2800 || r.PeakTempC != 97 {
+		t.Errorf("expected the busy clock range and peak temperature, got %+v", r)
+	}
+
+	values, units := r.Metrics()
+	if values[MetricThrottled] != 1 || values[MetricSeconds] != 6 || values[MetricEvents] != 2 || units[MetricSeconds] != "s" ||
+		values[MetricPeakTemp] != 97 || units[MetricPeakTemp] != "°C" {
+		t.Errorf("unexpected metrics %v %v", values, units)
+	}
+}
+
+func TestDetectorWithoutBaseClock(t *testing.T) {
+	d := NewDetector(Limits{})
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		d.Add(Sample{Time: start.Add(time.Duration(i) * time.Second), ClockMHz: 1200, Usage: 100, TempC: 70})
+	}
+	if r := d.Report(start.Add(5 * time.Second)); r.Throttled() || r.Summary() != "No throttling detected" {
+		t.Errorf("expected a low clock not to count without a known base clock, got %+v", r.Events)
+	}
+}
+
+func TestSave(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = database.Close() }()
+
+	run, err := database.CreateRun("cpu", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Report{
+		Limits:  Limits{BaseMHz: 3600},
+		Samples: 10,
+		Events:  []Event{{Start: run.StartTime, Duration: 4 * time.Second, Clock: true, MinClockMHz: 3000}},
+	}
+	if err := r.Save(database, run.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := database.GetResults(run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	throttled, seconds, ok := FromResults(results)
+	if !ok || !throttled || seconds != 4 {
+		t.Errorf("expected the flag back from the results, got %v %v %v", throttled, seconds, ok)
+	}
+
+	annotations, err := database.RunAnnotations(run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(annotations) != 1 || annotations[0].Kind != AnnotationKind || !strings.Contains(annotations[0].Message, "3000 MHz") {
+		t.Errorf("expected the event as an annotation, got %+v", annotations)
+	}
+
+	if _, _, ok := FromResults(nil); ok {
+		t.Error("expected a run without throttle results to report not watched")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	var calls atomic.Int32
+	w := Start(context.Background(), func(context.Context) (Sample, error) {
+		calls.Add(1)
+		return Sample{Time: time.Now(), ClockMHz: 4000, Usage: 100, TempC: 99}, nil
+	}, 5*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	r := w.Stop()
+	if calls.Load() == 0 || r.Samples == 0 {
+		t.Fatal("expected the watch to sample")
+	}
+	if r.PeakTempC != 99 {
+		t.Errorf("expected the peak temperature, got %v", r.PeakTempC)
+	}
+}
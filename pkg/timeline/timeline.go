@@ -0,0 +1,357 @@
+// Package timeline merges log lines, test run events and alerts into one
+// time-ordered list, so that what happened at a moment can be read next to
+// the sensor readings taken at the same time.
+//
+// Log lines come from the GUI's log, written as JSON lines by the logging
+// package, e.g. {"time":"...","level":"ERROR","msg":"...","component":"gpu"}.
+// Archives of older versions hold text lines starting with a local
+// timestamp, e.g. "[2026-03-01 03:12:04.250] ERROR: ...". Alerts are the
+// annotations recorded in the database, such as a switch to battery power,
+// and log lines at the ALERT level, which the GUI writes for each warning it
+// raises.
+package timeline
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/session"
+)
+
+// Kind is the type of an event
+type Kind string
+
+// Event kinds
+const (
+	KindLog   Kind = "log"
+	KindRun   Kind = "run"
+	KindAlert Kind = "alert"
+)
+
+// LevelAlert is the log level of alerts raised by the GUI
+const LevelAlert = "ALERT"
+
+// logTimeLayout is the timestamp format of the debug logs
+const logTimeLayout = "2006-01-02 15:04:05.000"
+
+// runLookback is how long before the window runs are looked up, so that
+// runs ending in the window are found
+const runLookback = 24 * time.Hour
+
+// maxLogLine limits the length of a log line that is read
+const maxLogLine = 1 << 20
+
+// Event is one entry on the timeline
+type Event struct {
+	Time    time.Time
+	Kind    Kind
+	Level   string // Log level, e.g. "ERROR"; "FAIL" for a failed run
+	Source  string // Log component or file, test plugin or annotation source
+	Message string
+}
+
+// Window is the span of time being correlated
+type Window struct {
+	Since time.Time
+	Until time.Time
+}
+
+// Around returns the window of the given span centred on t
+func Around(t time.Time, span time.Duration) Window {
+	return Window{Since: t.Add(-span / 2), Until: t.Add(span / 2)}
+}
+
+// Contains reports whether t falls in the window, including both ends
+func (w Window) Contains(t time.Time) bool {
+	return !t.Before(w.Since) && !t.After(w.Until)
+}
+
+// jsonLogLine is a record of the JSON log
+type jsonLogLine struct {
+	Time      time.Time `json:"time"`
+	Level     string    `json:"level"`
+	Message   string    `json:"msg"`
+	Component string    `json:"component"`
+}
+
+// ParseLogLine parses a log line, either a JSON record or a text line with
+// a timestamp in loc. Lines without a timestamp, such as file headers, are
+// not events. The source of a JSON record is its component.
+func ParseLogLine(line string, loc *time.Location) (Event, bool) {
+	if strings.HasPrefix(line, "{") {
+		var record jsonLogLine
+		if err := json.Unmarshal([]byte(line), &record); err != nil || record.Time.IsZero() || record.Level == "" {
+			return Event{}, false
+		}
+		return Event{Time: record.Time, Kind: levelKind(record.Level), Level: record.Level, Source: record.Component, Message: record.Message}, true
+	}
+	if !strings.HasPrefix(line, "[") {
+		return Event{}, false
+	}
+	end := strings.Index(line, "] ")
+	if end < 0 {
+		return Event{}, false
+	}
+	t, err := time.ParseInLocation(logTimeLayout, line[1:end], loc)
+	if err != nil {
+		return Event{}, false
+	}
+	level, message, found := strings.Cut(line[end+2:], ": ")
+	if !found || level == "" || strings.Contains(level, " ") {
+		return Event{}, false
+	}
+	return Event{Time: t, Kind: levelKind(level), Level: level, Message: message}, true
+}
+
+// levelKind returns the kind of a log line at a level
+func levelKind(level string) Kind {
+	if level == LevelAlert {
+		return KindAlert
+	}
+	return KindLog
+}
+
+// ReadLog returns the events of a log file that fall in the window
+func ReadLog(path string, w Window) ([]Event, error) {
+	f, err := os.Open(path) // #nosec G304 -- log file chosen by the caller
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	source := strings.TrimSuffix(filepath.Base(path), ".log")
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxLogLine)
+	for scanner.Scan() {
+		e, ok := ParseLogLine(scanner.Text(), time.Local)
+		if !ok || !w.Contains(e.Time) {
+			continue
+		}
+		if e.Source == "" {
+			e.Source = source
+		}
+		events = append(events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return events, fmt.Errorf("failed to read log %s: %w", path, err)
+	}
+	return events, nil
+}
+
+// RunEvents returns the starts and ends of the runs that fall in the window
+func RunEvents(runs []*db.Run, w Window) []Event {
+	var events []Event
+	for _, run := range runs {
+		if w.Contains(run.StartTime) {
+			events = append(events, Event{
+				Time:    run.StartTime,
+				Kind:    KindRun,
+				Level:   "START",
+				Source:  run.Plugin,
+				Message: fmt.Sprintf("Run #%d (%s) started", run.ID, run.Plugin),
+			})
+		}
+		if run.EndTime == nil || !w.Contains(*run.EndTime) {
+			continue
+		}
+		e := Event{Time: *run.EndTime, Kind: KindRun, Level: "PASS", Source: run.Plugin,
+			Message: fmt.Sprintf("Run #%d (%s) passed", run.ID, run.Plugin)}
+		if !run.Success {
+			e.Level = "FAIL"
+			e.Message = fmt.Sprintf("Run #%d (%s) failed", run.ID, run.Plugin)
+			if run.Error != "" {
+				e.Message += ": " + run.Error
+			}
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+// AnnotationEvents returns the annotations in the window as alerts
+func AnnotationEvents(annotations []*db.Annotation, w Window) []Event {
+	var events []Event
+	for _, a := range annotations {
+		if !w.Contains(a.Time) {
+			continue
+		}
+		message := a.Message
+		if message == "" {
+			message = a.Kind
+		}
+		events = append(events, Event{Time: a.Time, Kind: KindAlert, Level: a.Kind, Source: a.Source, Message: message})
+	}
+	return events
+}
+
+// Collect gathers the events in the window from the logs and from the runs
+// and annotations in the database. Logs that do not exist are skipped.
+func Collect(database *db.DB, logPaths []string, w Window) ([]Event, error) {
+	lists := make([][]Event, 0, len(logPaths)+2)
+	for _, path := range logPaths {
+		events, err := ReadLog(path, w)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, events)
+	}
+
+	since := w.Since.Add(-runLookback)
+	runs, err := database.ListRuns(db.RunFilter{StartTime: &since, EndTime: &w.Until})
+	if err != nil {
+		return nil, err
+	}
+	annotations, err := database.ListAnnotations(w.Since, w.Until)
+	if err != nil {
+		return nil, err
+	}
+	return Merge(append(lists, RunEvents(runs, w), AnnotationEvents(annotations, w))...), nil
+}
+
+// Merge combines event lists into one, oldest first. Events at the same
+// time keep the order of the lists they came from.
+func Merge(lists ...[]Event) []Event {
+	var events []Event
+	for _, list := range lists {
+		events = append(events, list...)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+	return events
+}
+
+// Nearest returns the index of the event closest to t in a time-ordered
+// list, or -1 if the list is empty
+func Nearest(events []Event, t time.Time) int {
+	if len(events) == 0 {
+		return -1
+	}
+	i := sort.Search(len(events), func(i int) bool { return !events[i].Time.Before(t) })
+	switch {
+	case i == 0:
+		return 0
+	case i == len(events):
+		return len(events) - 1
+	case t.Sub(events[i-1].Time) <= events[i].Time.Sub(t):
+		return i - 1
+	}
+	return i
+}
+
+// Point is a sensor reading on the timeline
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// SampleSeries returns a recorded metric's readings in the window
+func SampleSeries(samples []session.Sample, metric string, w Window) []Point {
+	var points []Point
+	for _, s := range samples {
+		if v, ok := s.Metrics[metric]; ok && w.Contains(s.Time) {
+			points = append(points, Point{Time: s.Time, Value: v})
+		}
+	}
+	return points
+}
+
+// BucketSeries returns the averages of result buckets as points
+func BucketSeries(buckets []db.Bucket) []Point {
+	points := make([]Point, len(buckets))
+	for i, b := range buckets {
+		points[i] = Point{Time: b.Start, Value: b.Avg}
+	}
+	return points
+}
+
+// Downsample reduces a series to at most about maxPoints points by keeping
+// the lowest and highest reading of each stretch, so spikes and dips stay
+// visible when the series is plotted
+func Downsample(points []Point, maxPoints int) []Point {
+	if maxPoints < 2 || len(points) <= maxPoints {
+		return points
+	}
+	stretch := (len(points)*2 + maxPoints - 1) / maxPoints
+	result := make([]Point, 0, maxPoints+2)
+	for start := 0; start < len(points); start += stretch {
+		end := start + stretch
+		if end > len(points) {
+			end = len(points)
+		}
+		lo, hi := start, start
+		for i := start + 1; i < end; i++ {
+			if points[i].Value < points[lo].Value {
+				lo = i
+			}
+			if points[i].Value > points[hi].Value {
+				hi = i
+			}
+		}
+		// Keep the pair in time order
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		result = append(result, points[lo])
+		if hi != lo {
+			result = append(result, points[hi])
+		}
+	}
+	return result
+}
+
+// ValueAt returns the reading closest to t in a time-ordered series, if one
+// was taken within maxGap of it
+func ValueAt(points []Point, t time.Time, maxGap time.Duration) (float64, bool) {
+	i := sort.Search(len(points), func(i int) bool { return !points[i].Time.Before(t) })
+	best, found := 0.0, false
+	gap := maxGap
+	for _, j := range []int{i - 1, i} {
+		if j < 0 || j >= len(points) {
+			continue
+		}
+		d := points[j].Time.Sub(t)
+		if d < 0 {
+			d = -d
+		}
+		if d <= gap {
+			best, found, gap = points[j].Value, true, d
+		}
+	}
+	return best, found
+}
+
+// ParseTime reads a time typed by an operator in local time: a clock time
+// such as "03:12" or "03:12:30", which means its latest occurrence at or
+// before now, or a date and time such as "2026-03-01 03:12"
+func ParseTime(text string, now time.Time) (time.Time, error) {
+	text = strings.TrimSpace(text)
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		clock, err := time.ParseInLocation(layout, text, now.Location())
+		if err != nil {
+			continue
+		}
+		t := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, now.Location())
+		if t.After(now) {
+			t = t.AddDate(0, 0, -1)
+		}
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02 15:04:05", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, text, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use HH:MM or YYYY-MM-DD HH:MM", text)
+}
@@ -0,0 +1,184 @@
+package timeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mscrnt/project_fire/pkg/db"
+	"github.com/mscrnt/project_fire/pkg/session"
+)
+
+func TestParseLogLine(t *testing.T) {
+	e, ok := ParseLogLine("[2026-03-01 03:12:04.250] ERROR: GPU query failed: exit status 1", time.UTC)
+	if !ok {
+		t.Fatal("expected the line to parse")
+	}
+	want := time.Date(2026, 3, 1, 3, 12, 4, 250e6, time.UTC)
+	if !e.Time.Equal(want) || e.Kind != KindLog || e.Level != "ERROR" || e.Message != "GPU query failed: exit status 1" {
+		t.Errorf("unexpected event %+v", e)
+	}
+
+	if e, _ := ParseLogLine("[2026-03-01 03:12:05.000] ALERT: Limited Functionality: no GPU", time.UTC); e.Kind != KindAlert {
+		t.Errorf("expected an alert, got %+v", e)
+	}
+	e, ok = ParseLogLine(`{"time":"2026-03-01T03:12:06.5Z","level":"WARN","msg":"CPU temperature high","component":"sensor","celsius":98}`, time.UTC)
+	if !ok || !e.Time.Equal(time.Date(2026, 3, 1, 3, 12, 6, 500e6, time.UTC)) || e.Kind != KindLog ||
+		e.Level != "WARN" || e.Source != "sensor" || e.Message != "CPU temperature high" {
+		t.Errorf("unexpected JSON event %+v (%v)", e, ok)
+	}
+	if e, _ := ParseLogLine(`{"time":"2026-03-01T03:12:07Z","level":"ALERT","msg":"Limited Functionality","component":"gui"}`, time.UTC); e.Kind != KindAlert {
+		t.Errorf("expected a JSON alert, got %+v", e)
+	}
+
+	for _, line := range []string{
+		`{"msg":"no time or level"}`,
+		"{not json",
+		"# gui_debug.log - Created 2026-03-01 03:00:00",
+		"[not a time] ERROR: x",
+		"[2026-03-01 03:12:04.250] no level here",
+		"",
+	} {
+		if _, ok := ParseLogLine(line, time.UTC); ok {
+			t.Errorf("expected %q not to parse", line)
+		}
+	}
+}
+
+func TestCollect(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "fire.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = database.Close() }()
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 3, 1, hour, minute, 0, 0, time.Local)
+	}
+
+	// A run that started before the window and failed inside it
+	run, err := database.CreateRun("stress", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := at(3, 12)
+	run.EndTime, run.Error = &end, "CPU thermal shutdown"
+	if err := database.UpdateRun(run); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.Exec(`UPDATE runs SET start_time = ? WHERE id = ?`, at(2, 0).UTC(), run.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.CreateAnnotation(&db.Annotation{Time: at(3, 10), Source: "system", Kind: "on_battery", Message: "Running on battery"}); err != nil {
+		t.Fatal(err)
+	}
+
+	logPath := filepath.Join(t.TempDir(), "gui_debug.log")
+	log := "# gui_debug.log - Created\n" +
+		"[2026-03-01 02:59:00.000] INFO: before the window\n" +
+		"[2026-03-01 03:11:00.000] WARNING: CPU temperature 98.0°C\n"
+	if err := os.WriteFile(logPath, []byte(log), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := Collect(database, []string{logPath, filepath.Join(t.TempDir(), "missing.log")}, Window{Since: at(3, 0), Until: at(3, 30)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %+v", events)
+	}
+	if events[0].Kind != KindAlert || events[1].Source != "gui_debug" || events[2].Level != "FAIL" {
+		t.Errorf("expected the alert, log line and failed run in time order, got %+v", events)
+	}
+	if events[2].Message != "Run #1 (stress) failed: CPU thermal shutdown" {
+		t.Errorf("unexpected run message %q", events[2].Message)
+	}
+}
+
+func TestNearest(t *testing.T) {
+	base := time.Date(2026, 3, 1, 3, 0, 0, 0, time.UTC)
+	events := []Event{{Time: base}, {Time: base.Add(10 * time.Second)}, {Time: base.Add(30 * time.Second)}}
+	for offset, want := range map[time.Duration]int{
+		-time.Minute:     0,
+		4 * time.Second:  0,
+		6 * time.Second:  1,
+		25 * time.Second: 2,
+		time.Hour:        2,
+	} {
+		if got := Nearest(events, base.Add(offset)); got != want {
+			t.Errorf("Nearest at %v = %d, expected %d", offset, got, want)
+		}
+	}
+	if Nearest(nil, base) != -1 {
+		t.Error("expected -1 without events")
+	}
+}
+
+func TestSeries(t *testing.T) {
+	base := time.Date(2026, 3, 1, 3, 0, 0, 0, time.UTC)
+	samples := []session.Sample{
+		{Time: base, Metrics: map[string]float64{"CPU Temp (°C)": 70}},
+		{Time: base.Add(time.Second), Metrics: map[string]float64{"CPU Usage (%)": 100}},
+		{Time: base.Add(2 * time.Second), Metrics: map[string]float64{"CPU Temp (°C)": 90}},
+		{Time: base.Add(time.Hour), Metrics: map[string]float64{"CPU Temp (°C)": 40}},
+	}
+	points := SampleSeries(samples, "CPU Temp (°C)", Window{Since: base, Until: base.Add(time.Minute)})
+	if len(points) != 2 {
+		t.Fatalf("expected 2 points in the window, got %v", points)
+	}
+
+	if v, ok := ValueAt(points, base.Add(1500*time.Millisecond), 5*time.Second); !ok || v != 90 {
+		t.Errorf("expected the nearest reading 90, got %v (%v)", v, ok)
+	}
+	if _, ok := ValueAt(points, base.Add(time.Minute), 5*time.Second); ok {
+		t.Error("expected no reading a minute from the series")
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	for text, want := range map[string]time.Time{
+		"03:12":            time.Date(2026, 3, 1, 3, 12, 0, 0, time.UTC),
+		"23:45:30":         time.Date(2026, 2, 28, 23, 45, 30, 0, time.UTC),
+		"2026-02-14 08:30": time.Date(2026, 2, 14, 8, 30, 0, 0, time.UTC),
+	} {
+		got, err := ParseTime(text, now)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("ParseTime(%q) = %v, %v; expected %v", text, got, err, want)
+		}
+	}
+	if _, err := ParseTime("yesterday", now); err == nil {
+		t.Error("expected an error for an invalid time")
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	base := time.Date(2026, 3, 1, 3, 0, 0, 0, time.UTC)
+	points := make([]Point, 1000)
+	for i := range points {
+		points[i] = Point{Time: base.Add(time.Duration(i) * time.Second), Value: 50}
+	}
+	points[500].Value = 99 // A one-second spike
+	points[700].Value = 1
+
+	reduced := Downsample(points, 100)
+	if len(reduced) > 102 {
+		t.Errorf("expected about 100 points, got %d", len(reduced))
+	}
+	spike, dip := false, false
+	for i, p := range reduced {
+		if i > 0 && p.Time.Before(reduced[i-1].Time) {
+			t.Fatal("expected points in time order")
+		}
+		spike = spike || p.Value == 99
+		dip = dip || p.Value == 1
+	}
+	if !spike || !dip {
+		t.Error("expected the spike and the dip to be kept")
+	}
+	if len(Downsample(points[:50], 100)) != 50 {
+		t.Error("expected a short series to be kept as is")
+	}
+}
@@ -0,0 +1,87 @@
+package tpm
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// QuotePCRs are the PCRs quoted: the firmware, option ROM, boot loader and
+// Secure Boot policy measurements
+const QuotePCRs = "sha256:0,1,2,3,4,5,6,7"
+
+// Files written by Quote
+const (
+	QuoteMessageFile   = "tpm-quote.msg"    // TPMS_ATTEST structure that was signed
+	QuoteSignatureFile = "tpm-quote.sig"    // Signature over the message
+	QuotePCRsFile      = "tpm-quote.pcrs"   // PCR values the quote covers
+	QuoteTextFile      = "tpm-quote.txt"    // tpm2_quote's readable output
+	QuoteAKFile        = "tpm-ak.pub.pem"   // Public attestation key to verify with
+	EventLogFile       = "tpm-eventlog.bin" // Firmware measurement log, when readable
+)
+
+// ErrNoTools is returned by Quote when tpm2-tools is not installed
+var ErrNoTools = errors.New("capturing a TPM quote needs tpm2-tools (tpm2_createek, tpm2_createak, tpm2_quote)")
+
+// Quote captures a TPM 2.0 quote of QuotePCRs with tpm2-tools and writes it
+// to dir, with the public attestation key to verify it and the firmware
+// event log to replay the PCRs from. The attestation key is created under
+// the endorsement key for this quote only. nonce becomes the quote's
+// qualifying data, tying the quote to the run it was taken for. It returns
+// the paths of the files written.
+func Quote(ctx context.Context, dir string, nonce []byte) ([]string, error) {
+	for _, tool := range []string{"tpm2_createek", "tpm2_createak", "tpm2_quote"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			return nil, ErrNoTools
+		}
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	work, err := os.MkdirTemp("", "fire-tpm-")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.RemoveAll(work) }()
+
+	ek, ak := filepath.Join(work, "ek.ctx"), filepath.Join(work, "ak.ctx")
+	steps := [][]string{
+		{"tpm2_createek", "-c", ek, "-G", "rsa", "-u", filepath.Join(work, "ek.pub")},
+		{"tpm2_createak", "-C", ek, "-c", ak, "-G", "rsa", "-g", "sha256", "-s", "rsassa",
+			"-u", filepath.Join(dir, QuoteAKFile), "-f", "pem", "-n", filepath.Join(work, "ak.name")},
+	}
+	for _, step := range steps {
+		if out, err := safeexec.CommandContext(ctx, step[0], step[1:]...).CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("%s failed: %w: %s", step[0], err, out)
+		}
+	}
+
+	out, err := safeexec.CommandContext(ctx, "tpm2_quote", "-c", ak, "-l", QuotePCRs, "-g", "sha256",
+		"-q", hex.EncodeToString(nonce),
+		"-m", filepath.Join(dir, QuoteMessageFile),
+		"-s", filepath.Join(dir, QuoteSignatureFile),
+		"-o", filepath.Join(dir, QuotePCRsFile)).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("tpm2_quote failed: %w: %s", err, out)
+	}
+
+	files := []string{QuoteMessageFile, QuoteSignatureFile, QuotePCRsFile, QuoteAKFile}
+	if err := os.WriteFile(filepath.Join(dir, QuoteTextFile), out, 0o600); err == nil {
+		files = append(files, QuoteTextFile)
+	}
+	if log := eventLog(); log != nil {
+		if err := os.WriteFile(filepath.Join(dir, EventLogFile), log, 0o600); err == nil {
+			files = append(files, EventLogFile)
+		}
+	}
+	for i, name := range files {
+		files[i] = filepath.Join(dir, name)
+	}
+	return files, nil
+}
@@ -0,0 +1,254 @@
+// Package tpm reports the Trusted Platform Module of a machine: vendor,
+// firmware version and the PCR banks it has allocated, and can capture a
+// signed quote of the boot PCRs for the validation record.
+//
+// TPM 2.0 chips are queried with TPM2_GetCapability, sent through the kernel
+// resource manager (/dev/tpmrm0) on Linux and TBS on Windows. When the device
+// cannot be opened, Linux falls back to what sysfs exposes.
+package tpm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotFound is returned when the machine has no TPM, or it is disabled in
+// the firmware
+var ErrNotFound = errors.New("no TPM found")
+
+// Bank is one PCR bank
+type Bank struct {
+	Algorithm string `json:"algorithm"` // e.g. "SHA-256"
+	PCRs      int    `json:"pcrs"`      // Allocated PCRs; 0 when the bank is supported but not active
+}
+
+// Info describes a TPM
+type Info struct {
+	Version         string `json:"version"`                   // "2.0" or "1.2"
+	ManufacturerID  string `json:"manufacturer_id,omitempty"` // TCG vendor ID, e.g. "IFX"
+	Manufacturer    string `json:"manufacturer,omitempty"`    // e.g. "Infineon"
+	Model           string `json:"model,omitempty"`           // Vendor string, e.g. "SLB9672"
+	FirmwareVersion string `json:"firmware_version,omitempty"`
+	SpecRevision    string `json:"spec_revision,omitempty"` // TPM 2.0 library revision, e.g. "1.59"
+	Banks           []Bank `json:"banks,omitempty"`
+	Source          string `json:"source"` // Where the details came from, e.g. "/dev/tpmrm0"
+}
+
+// ActiveBanks returns the algorithms of the allocated PCR banks
+func (i *Info) ActiveBanks() []string {
+	var banks []string
+	for _, b := range i.Banks {
+		if b.PCRs > 0 {
+			banks = append(banks, b.Algorithm)
+		}
+	}
+	return banks
+}
+
+// Summary describes the TPM in one line, e.g. "Infineon SLB9672 TPM 2.0, firmware 16.13.0.0"
+func (i *Info) Summary() string {
+	parts := []string{}
+	if i.Manufacturer != "" {
+		parts = append(parts, i.Manufacturer)
+	}
+	if i.Model != "" {
+		parts = append(parts, i.Model)
+	}
+	s := strings.Join(append(parts, "TPM", i.Version), " ")
+	if i.FirmwareVersion != "" {
+		s += ", firmware " + i.FirmwareVersion
+	}
+	return s
+}
+
+// vendors names the TCG vendor IDs of common TPM makers
+var vendors = map[string]string{
+	"AMD":  "AMD (firmware TPM)",
+	"ATML": "Atmel",
+	"BRCM": "Broadcom",
+	"CSCO": "Cisco",
+	"FLYS": "Flyslice",
+	"GOOG": "Google",
+	"HPE":  "HPE",
+	"IBM":  "IBM",
+	"IFX":  "Infineon",
+	"INTC": "Intel (PTT)",
+	"LEN":  "Lenovo",
+	"MSFT": "Microsoft (virtual TPM)",
+	"NSM":  "National Semiconductor",
+	"NTC":  "Nuvoton",
+	"NTZ":  "Nationz",
+	"QCOM": "Qualcomm",
+	"ROCC": "Fuzhou Rockchip",
+	"SMSC": "SMSC",
+	"SNS":  "Sinosun",
+	"STM":  "STMicroelectronics",
+	"TXN":  "Texas Instruments",
+	"WEC":  "Winbond",
+}
+
+// algorithms names the TPM_ALG_ID hash algorithms used for PCR banks
+var algorithms = map[uint16]string{
+	0x0004: "SHA-1",
+	0x000b: "SHA-256",
+	0x000c: "SHA-384",
+	0x000d: "SHA-512",
+	0x0012: "SM3-256",
+	0x0027: "SHA3-256",
+	0x0028: "SHA3-384",
+	0x0029: "SHA3-512",
+}
+
+// TPM 2.0 command and capability constants
+const (
+	tagNoSessions      = 0x8001
+	ccGetCapability    = 0x0000017a
+	capPCRs            = 0x00000005
+	capTPMProperties   = 0x00000006
+	ptFamilyIndicator  = 0x00000100 // First of the fixed properties
+	ptRevision         = 0x00000102
+	ptManufacturer     = 0x00000105
+	ptVendorString1    = 0x00000106
+	ptVendorString4    = 0x00000109
+	ptFirmwareVersion1 = 0x0000010b
+	ptFirmwareVersion2 = 0x0000010c
+	responseHeaderLen  = 10
+	maxResponse        = 4096
+)
+
+// transport sends a command to the TPM and returns its response
+type transport func(cmd []byte) ([]byte, error)
+
+// query reads the details of a TPM 2.0 through t
+func query(t transport, source string) (*Info, error) {
+	props, err := fixedProperties(t)
+	if err != nil {
+		return nil, err
+	}
+	info := &Info{Version: "2.0", Source: source}
+	info.ManufacturerID = fourCC(props[ptManufacturer])
+	info.Manufacturer = vendorName(info.ManufacturerID)
+
+	var model strings.Builder
+	for p := uint32(ptVendorString1); p <= ptVendorString4; p++ {
+		model.WriteString(fourCC(props[p]))
+	}
+	info.Model = strings.TrimSpace(model.String())
+
+	if fw1, ok := props[ptFirmwareVersion1]; ok {
+		fw2 := props[ptFirmwareVersion2]
+		info.FirmwareVersion = fmt.Sprintf("%d.%d.%d.%d", fw1>>16, fw1&0xffff, fw2>>16, fw2&0xffff)
+	}
+	if rev, ok := props[ptRevision]; ok {
+		info.SpecRevision = fmt.Sprintf("%d.%02d", rev/100, rev%100)
+	}
+
+	banks, err := pcrBanks(t)
+	if err != nil {
+		return nil, err
+	}
+	info.Banks = banks
+	return info, nil
+}
+
+// fixedProperties reads the fixed TPM properties, from the family indicator
+// to the firmware version
+func fixedProperties(t transport) (map[uint32]uint32, error) {
+	data, err := getCapability(t, capTPMProperties, ptFamilyIndicator, ptFirmwareVersion2-ptFamilyIndicator+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 4 {
+		return nil, errors.New("short TPM property list")
+	}
+	count := int(binary.BigEndian.Uint32(data))
+	data = data[4:]
+	if len(data) < count*8 {
+		return nil, errors.New("truncated TPM property list")
+	}
+	props := make(map[uint32]uint32, count)
+	for i := 0; i < count; i++ {
+		props[binary.BigEndian.Uint32(data[i*8:])] = binary.BigEndian.Uint32(data[i*8+4:])
+	}
+	return props, nil
+}
+
+// pcrBanks reads the PCR allocation: each supported bank with a bitmap of
+// its allocated PCRs
+func pcrBanks(t transport) ([]Bank, error) {
+	data, err := getCapability(t, capPCRs, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 4 {
+		return nil, errors.New("short PCR selection")
+	}
+	count := int(binary.BigEndian.Uint32(data))
+	data = data[4:]
+
+	banks := make([]Bank, 0, count)
+	for i := 0; i < count; i++ {
+		if len(data) < 3 || len(data) < 3+int(data[2]) {
+			return nil, errors.New("truncated PCR selection")
+		}
+		alg := binary.BigEndian.Uint16(data)
+		selected := data[3 : 3+int(data[2])]
+		data = data[3+len(selected):]
+
+		bank := Bank{Algorithm: algorithms[alg]}
+		if bank.Algorithm == "" {
+			bank.Algorithm = fmt.Sprintf("0x%04x", alg)
+		}
+		for _, b := range selected {
+			for ; b != 0; b &= b - 1 {
+				bank.PCRs++
+			}
+		}
+		banks = append(banks, bank)
+	}
+	return banks, nil
+}
+
+// getCapability sends TPM2_GetCapability and returns the capability data
+// after the moreData flag and capability echo
+func getCapability(t transport, capability, property, count uint32) ([]byte, error) {
+	cmd := make([]byte, 22)
+	binary.BigEndian.PutUint16(cmd[0:], tagNoSessions)
+	binary.BigEndian.PutUint32(cmd[2:], uint32(len(cmd)))
+	binary.BigEndian.PutUint32(cmd[6:], ccGetCapability)
+	binary.BigEndian.PutUint32(cmd[10:], capability)
+	binary.BigEndian.PutUint32(cmd[14:], property)
+	binary.BigEndian.PutUint32(cmd[18:], count)
+
+	resp, err := t(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) < responseHeaderLen {
+		return nil, fmt.Errorf("short TPM response (%d bytes)", len(resp))
+	}
+	if rc := binary.BigEndian.Uint32(resp[6:]); rc != 0 {
+		return nil, fmt.Errorf("TPM2_GetCapability failed with response code %#x", rc)
+	}
+	body := resp[responseHeaderLen:]
+	if len(body) < 5 {
+		return nil, errors.New("short TPM2_GetCapability response")
+	}
+	return body[5:], nil
+}
+
+// fourCC decodes a property holding up to four ASCII characters
+func fourCC(v uint32) string {
+	b := []byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+	return strings.TrimSpace(strings.Trim(string(b), "\x00"))
+}
+
+// vendorName names a TCG vendor ID, falling back to the ID itself
+func vendorName(id string) string {
+	if name, ok := vendors[id]; ok {
+		return name
+	}
+	return id
+}
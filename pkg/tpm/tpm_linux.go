@@ -0,0 +1,134 @@
+//go:build linux
+// +build linux
+
+package tpm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sysfsRoot is the root the TPM device and sysfs files are read under,
+// replaced in tests
+var sysfsRoot = "/"
+
+// eventLogPath is the firmware measurement log the kernel exports, readable
+// by root only
+const eventLogPath = "sys/kernel/security/tpm0/binary_bios_measurements"
+
+// Read returns the details of the first TPM. The TPM devices belong to root
+// and the tss group; when neither can be opened, or /dev/tpm0 is held by
+// another process, the version and PCR banks come from sysfs.
+func Read() (*Info, error) {
+	class := filepath.Join(sysfsRoot, "sys/class/tpm/tpm0")
+	if _, err := os.Stat(class); err != nil {
+		return nil, ErrNotFound
+	}
+
+	if readString(filepath.Join(class, "tpm_version_major")) != "1" {
+		for _, dev := range []string{"dev/tpmrm0", "dev/tpm0"} {
+			if info, err := query(deviceTransport(filepath.Join(sysfsRoot, dev)), "/"+dev); err == nil {
+				return info, nil
+			}
+		}
+	}
+	return readSysfs(class), nil
+}
+
+// deviceTransport writes a command to a TPM character device and reads the
+// response. The kernel handles one command per write.
+func deviceTransport(path string) transport {
+	return func(cmd []byte) ([]byte, error) {
+		f, err := os.OpenFile(path, os.O_RDWR, 0) // #nosec G304 -- fixed device path
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = f.Close() }()
+
+		if _, err := f.Write(cmd); err != nil {
+			return nil, fmt.Errorf("failed to send TPM command: %w", err)
+		}
+		resp := make([]byte, maxResponse)
+		n, err := f.Read(resp)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TPM response: %w", err)
+		}
+		return resp[:n], nil
+	}
+}
+
+// readSysfs reads what sysfs exposes without opening the device: the major
+// version, the banks of TPM 2.0 chips (pcr-<alg> directories, Linux 5.12+),
+// and the manufacturer and firmware in the caps file of TPM 1.2 chips
+func readSysfs(class string) *Info {
+	info := &Info{Version: "2.0", Source: "sysfs"}
+	if readString(filepath.Join(class, "tpm_version_major")) == "1" {
+		info.Version = "1.2"
+	}
+
+	for _, dir := range []string{class, filepath.Join(class, "device")} {
+		caps := readString(filepath.Join(dir, "caps"))
+		if caps == "" {
+			continue
+		}
+		info.Version = "1.2"
+		for _, line := range strings.Split(caps, "\n") {
+			key, value, ok := strings.Cut(line, ":")
+			if !ok {
+				continue
+			}
+			value = strings.TrimSpace(value)
+			switch strings.TrimSpace(key) {
+			case "Manufacturer":
+				if id, err := strconv.ParseUint(strings.TrimPrefix(value, "0x"), 16, 32); err == nil {
+					info.ManufacturerID = fourCC(uint32(id))
+					info.Manufacturer = vendorName(info.ManufacturerID)
+				}
+			case "Firmware version":
+				info.FirmwareVersion = value
+			}
+		}
+		break
+	}
+
+	banks, _ := filepath.Glob(filepath.Join(class, "pcr-*"))
+	sort.Strings(banks)
+	for _, dir := range banks {
+		pcrs, _ := os.ReadDir(dir)
+		alg := strings.TrimPrefix(filepath.Base(dir), "pcr-")
+		info.Banks = append(info.Banks, Bank{Algorithm: bankName(alg), PCRs: len(pcrs)})
+	}
+	return info
+}
+
+// bankName turns a sysfs bank name such as "sha256" into "SHA-256"
+func bankName(alg string) string {
+	plain := strings.NewReplacer("-", "", "_", "")
+	for _, name := range algorithms {
+		if strings.EqualFold(plain.Replace(name), plain.Replace(alg)) {
+			return name
+		}
+	}
+	return strings.ToUpper(alg)
+}
+
+// eventLog returns the firmware measurement log, or nil when it cannot be read
+func eventLog() []byte {
+	data, err := os.ReadFile(filepath.Join(sysfsRoot, eventLogPath)) // #nosec G304 -- fixed securityfs path
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+func readString(path string) string {
+	data, err := os.ReadFile(path) // #nosec G304 -- sysfs path
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,70 @@
+package tpm
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadSysfs(t *testing.T) {
+	root := t.TempDir()
+	old := sysfsRoot
+	sysfsRoot = root
+	t.Cleanup(func() { sysfsRoot = old })
+
+	if _, err := Read(); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound without a TPM, got %v", err)
+	}
+
+	// A TPM 2.0 whose device cannot be opened
+	files := map[string]string{"sys/class/tpm/tpm0/tpm_version_major": "2"}
+	for i := 0; i < 24; i++ {
+		files[filepath.Join("sys/class/tpm/tpm0/pcr-sha256", string(rune('a'+i)))] = "00"
+	}
+	files["sys/class/tpm/tpm0/pcr-sha1/0"] = "00"
+	writeFiles(t, root, files)
+
+	info, err := Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != "2.0" || info.Source != "sysfs" || len(info.Banks) != 2 {
+		t.Fatalf("unexpected info %+v", info)
+	}
+	if info.Banks[0].Algorithm != "SHA-1" || info.Banks[1].Algorithm != "SHA-256" || info.Banks[1].PCRs != 24 {
+		t.Errorf("unexpected banks %+v", info.Banks)
+	}
+}
+
+func TestReadSysfsTPM12(t *testing.T) {
+	root := t.TempDir()
+	old := sysfsRoot
+	sysfsRoot = root
+	t.Cleanup(func() { sysfsRoot = old })
+
+	writeFiles(t, root, map[string]string{
+		"sys/class/tpm/tpm0/tpm_version_major": "1",
+		"sys/class/tpm/tpm0/device/caps":       "Manufacturer: 0x53544d20\nTCG version: 1.2\nFirmware version: 13.12\n",
+	})
+	info, err := Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != "1.2" || info.ManufacturerID != "STM" || info.Manufacturer != "STMicroelectronics" || info.FirmwareVersion != "13.12" {
+		t.Errorf("unexpected info %+v", info)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package tpm
+
+// Read is not supported on this platform; Macs have no TPM
+func Read() (*Info, error) {
+	return nil, ErrNotFound
+}
+
+func eventLog() []byte {
+	return nil
+}
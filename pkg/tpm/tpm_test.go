@@ -0,0 +1,87 @@
+package tpm
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// fakeTPM answers TPM2_GetCapability like an Infineon SLB9672 with active
+// SHA-1 and SHA-256 banks and an unallocated SHA-384 bank
+func fakeTPM(t *testing.T) transport {
+	return func(cmd []byte) ([]byte, error) {
+		if binary.BigEndian.Uint32(cmd[6:]) != ccGetCapability {
+			t.Fatalf("unexpected command code %#x", binary.BigEndian.Uint32(cmd[6:]))
+		}
+		var body []byte
+		u32 := func(v uint32) { body = binary.BigEndian.AppendUint32(body, v) }
+
+		switch binary.BigEndian.Uint32(cmd[10:]) {
+		case capTPMProperties:
+			props := [][2]uint32{
+				{ptRevision, 159},
+				{ptManufacturer, 0x49465800},  // "IFX\0"
+				{ptVendorString1, 0x534c4239}, // "SLB9"
+				{ptVendorString1 + 1, 0x36373200},
+				{ptVendorString1 + 2, 0},
+				{ptVendorString1 + 3, 0},
+				{ptFirmwareVersion1, 0x0010000d}, // 16.13
+				{ptFirmwareVersion2, 0x00000000},
+			}
+			body = append(body, 0) // moreData
+			u32(capTPMProperties)
+			u32(uint32(len(props)))
+			for _, p := range props {
+				u32(p[0])
+				u32(p[1])
+			}
+		case capPCRs:
+			body = append(body, 0)
+			u32(capPCRs)
+			u32(3)
+			body = append(body, 0x00, 0x04, 3, 0xff, 0xff, 0xff) // SHA-1, PCRs 0-23
+			body = append(body, 0x00, 0x0b, 3, 0xff, 0xff, 0xff) // SHA-256, PCRs 0-23
+			body = append(body, 0x00, 0x0c, 3, 0x00, 0x00, 0x00) // SHA-384, none
+		default:
+			t.Fatalf("unexpected capability %#x", binary.BigEndian.Uint32(cmd[10:]))
+		}
+
+		resp := make([]byte, responseHeaderLen)
+		binary.BigEndian.PutUint16(resp, tagNoSessions)
+		binary.BigEndian.PutUint32(resp[2:], uint32(responseHeaderLen+len(body)))
+		return append(resp, body...), nil
+	}
+}
+
+func TestQuery(t *testing.T) {
+	info, err := query(fakeTPM(t), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ManufacturerID != "IFX" || info.Manufacturer != "Infineon" || info.Model != "SLB9672" {
+		t.Errorf("unexpected vendor %+v", info)
+	}
+	if info.FirmwareVersion != "16.13.0.0" || info.SpecRevision != "1.59" {
+		t.Errorf("unexpected firmware %q or revision %q", info.FirmwareVersion, info.SpecRevision)
+	}
+	if len(info.Banks) != 3 || info.Banks[1].Algorithm != "SHA-256" || info.Banks[1].PCRs != 24 || info.Banks[2].PCRs != 0 {
+		t.Errorf("unexpected banks %+v", info.Banks)
+	}
+	if banks := strings.Join(info.ActiveBanks(), ","); banks != "SHA-1,SHA-256" {
+		t.Errorf("expected the SHA-1 and SHA-256 banks to be active, got %s", banks)
+	}
+	if s := info.Summary(); s != "Infineon SLB9672 TPM 2.0, firmware 16.13.0.0" {
+		t.Errorf("unexpected summary %q", s)
+	}
+}
+
+func TestQueryError(t *testing.T) {
+	failing := func([]byte) ([]byte, error) {
+		resp := make([]byte, responseHeaderLen)
+		binary.BigEndian.PutUint32(resp[6:], 0x101) // TPM_RC_FAILURE
+		return resp, nil
+	}
+	if _, err := query(failing, "test"); err == nil || !strings.Contains(err.Error(), "0x101") {
+		t.Errorf("expected the response code in the error, got %v", err)
+	}
+}
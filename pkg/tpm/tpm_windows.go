@@ -0,0 +1,89 @@
+//go:build windows
+// +build windows
+
+package tpm
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	tbs                    = windows.NewLazySystemDLL("tbs.dll")
+	procTbsiContextCreate  = tbs.NewProc("Tbsi_Context_Create")
+	procTbsipContextClose  = tbs.NewProc("Tbsip_Context_Close")
+	procTbsipSubmitCommand = tbs.NewProc("Tbsip_Submit_Command")
+	procTbsiGetDeviceInfo  = tbs.NewProc("Tbsi_GetDeviceInfo")
+)
+
+// TBS constants from tbs.h
+const (
+	tbsSuccess            = 0
+	tbsContextVersionTwo  = 2
+	tbsIncludeTPM20       = 1 << 2
+	tbsLocalityZero       = 0
+	tbsPriorityNormal     = 200
+	tbsErrorTPMNotFound   = 0x8028400F
+	tbsServiceUnavailable = 0x80284008
+	tpmVersion12          = 1
+)
+
+// tbsContextParams2 is TBS_CONTEXT_PARAMS2
+type tbsContextParams2 struct {
+	version uint32
+	flags   uint32
+}
+
+// tpmDeviceInfo is TPM_DEVICE_INFO
+type tpmDeviceInfo struct {
+	structVersion    uint32
+	tpmVersion       uint32
+	tpmInterfaceType uint32
+	tpmImpRevision   uint32
+}
+
+// Read returns the details of the TPM through the TPM Base Services, which
+// any user may send TPM2_GetCapability through
+func Read() (*Info, error) {
+	if err := tbs.Load(); err != nil {
+		return nil, ErrNotFound
+	}
+
+	var dev tpmDeviceInfo
+	if r, _, _ := procTbsiGetDeviceInfo.Call(unsafe.Sizeof(dev), uintptr(unsafe.Pointer(&dev))); r != tbsSuccess { // #nosec G103 -- output struct for the Win32 API
+		if r == tbsErrorTPMNotFound || r == tbsServiceUnavailable {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("Tbsi_GetDeviceInfo failed with %#x", r)
+	}
+	if dev.tpmVersion == tpmVersion12 {
+		return &Info{Version: "1.2", Source: "TBS"}, nil
+	}
+
+	params := tbsContextParams2{version: tbsContextVersionTwo, flags: tbsIncludeTPM20}
+	var ctx uintptr
+	if r, _, _ := procTbsiContextCreate.Call(uintptr(unsafe.Pointer(&params)), uintptr(unsafe.Pointer(&ctx))); r != tbsSuccess { // #nosec G103 -- parameters for the Win32 API
+		return nil, fmt.Errorf("Tbsi_Context_Create failed with %#x", r)
+	}
+	defer func() { _, _, _ = procTbsipContextClose.Call(ctx) }()
+
+	return query(func(cmd []byte) ([]byte, error) {
+		resp := make([]byte, maxResponse)
+		size := uint32(len(resp))
+		r, _, _ := procTbsipSubmitCommand.Call(ctx, tbsLocalityZero, tbsPriorityNormal,
+			uintptr(unsafe.Pointer(&cmd[0])), uintptr(len(cmd)), // #nosec G103 -- command buffer for the Win32 API
+			uintptr(unsafe.Pointer(&resp[0])), uintptr(unsafe.Pointer(&size))) // #nosec G103 -- response buffer for the Win32 API
+		if r != tbsSuccess {
+			return nil, fmt.Errorf("Tbsip_Submit_Command failed with %#x", r)
+		}
+		return resp[:size], nil
+	}, "TBS")
+}
+
+// eventLog is not read on Windows; the measured boot logs live in
+// C:\Windows\Logs\MeasuredBoot and need administrator rights
+func eventLog() []byte {
+	return nil
+}
@@ -0,0 +1,111 @@
+// Package units converts readings to the units the user prefers.
+//
+// Readings are recorded in one unit per quantity: temperatures in °C, data
+// rates in MB/s of 2^20 bytes (as the plugins and bench monitor measure
+// them) and clocks in MHz or GHz. Prefs converts them for display only;
+// the database, exports and sinks keep the recorded units.
+package units
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Temperature units
+const (
+	Celsius    = "C"
+	Fahrenheit = "F"
+)
+
+// Data rate units. MBps counts 10^6 bytes, MiBps 2^20 bytes.
+const (
+	MBps  = "MB/s"
+	MiBps = "MiB/s"
+)
+
+// Frequency units. Auto keeps the unit each reading was taken in.
+const (
+	Auto = "auto"
+	MHz  = "MHz"
+	GHz  = "GHz"
+)
+
+// The choices of each preference
+var (
+	Temperatures = []string{Celsius, Fahrenheit}
+	DataRates    = []string{MBps, MiBps}
+	Frequencies  = []string{Auto, MHz, GHz}
+)
+
+// mebibyte is how many bytes the recorded MB/s count
+const mebibyte = 1 << 20
+
+// Prefs are the units readings are shown in
+type Prefs struct {
+	Temperature string `json:"temperature"` // C or F
+	DataRate    string `json:"data_rate"`   // MB/s or MiB/s
+	Frequency   string `json:"frequency"`   // auto, MHz or GHz
+}
+
+// Default returns the units used unless the settings choose others
+func Default() Prefs {
+	return Prefs{Temperature: Celsius, DataRate: MBps, Frequency: Auto}
+}
+
+// Validate checks every preference that is set
+func (p Prefs) Validate() error {
+	for _, c := range []struct {
+		name, value string
+		choices     []string
+	}{
+		{"temperature", p.Temperature, Temperatures},
+		{"data_rate", p.DataRate, DataRates},
+		{"frequency", p.Frequency, Frequencies},
+	} {
+		if c.value != "" && !contains(c.choices, c.value) {
+			return fmt.Errorf("unknown %s unit %q (use one of %s)", c.name, c.value, strings.Join(c.choices, ", "))
+		}
+	}
+	return nil
+}
+
+// Convert converts a reading in a recorded unit ("°C", "MB/s", "MHz" or
+// "GHz") to the preferred unit. Other units are returned unchanged.
+func (p Prefs) Convert(value float64, unit string) (float64, string) {
+	switch unit {
+	case "°C":
+		if p.Temperature == Fahrenheit {
+			return value*1.8 + 32, "°F"
+		}
+	case "MB/s":
+		if p.DataRate == MiBps {
+			return value, "MiB/s"
+		}
+		return value * mebibyte / 1e6, "MB/s"
+	case "MHz":
+		if p.Frequency == GHz {
+			return value / 1000, "GHz"
+		}
+	case "GHz":
+		if p.Frequency == MHz {
+			return value * 1000, "MHz"
+		}
+	}
+	return value, unit
+}
+
+// Format converts a reading and formats it with a verb such as "%.1f ",
+// followed by the unit, e.g. "104.0 °F"
+func (p Prefs) Format(value float64, unit, format string) string {
+	value, unit = p.Convert(value, unit)
+	return fmt.Sprintf(format, value) + unit
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
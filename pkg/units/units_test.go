@@ -0,0 +1,45 @@
+package units
+
+import "testing"
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		prefs Prefs
+		value float64
+		unit  string
+		want  float64
+		wunit string
+	}{
+		{Default(), 40, "°C", 40, "°C"},
+		{Prefs{Temperature: Fahrenheit}, 100, "°C", 212, "°F"},
+		{Default(), 1000, "MB/s", 1048.576, "MB/s"},
+		{Prefs{DataRate: MiBps}, 1000, "MB/s", 1000, "MiB/s"},
+		{Default(), 4800, "MHz", 4800, "MHz"},
+		{Prefs{Frequency: GHz}, 4800, "MHz", 4.8, "GHz"},
+		{Prefs{Frequency: MHz}, 4.8, "GHz", 4800, "MHz"},
+		{Prefs{Temperature: Fahrenheit}, 250, "W", 250, "W"},
+	}
+	for _, tt := range tests {
+		got, unit := tt.prefs.Convert(tt.value, tt.unit)
+		if unit != tt.wunit || got < tt.want-1e-9 || got > tt.want+1e-9 {
+			t.Errorf("%+v.Convert(%v, %q) = %v %q, want %v %q", tt.prefs, tt.value, tt.unit, got, unit, tt.want, tt.wunit)
+		}
+	}
+	if got := (Prefs{Temperature: Fahrenheit}).Format(40, "°C", "%.1f "); got != "104.0 °F" {
+		t.Errorf("unexpected format %q", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Error(err)
+	}
+	if err := (Prefs{}).Validate(); err != nil {
+		t.Error(err)
+	}
+	for _, p := range []Prefs{{Temperature: "K"}, {DataRate: "GB/s"}, {Frequency: "Hz"}} {
+		if err := p.Validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", p)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Package virt detects whether F.I.R.E. runs in a virtual machine, under
+// WSL or in a container.
+//
+// Sensors in a virtual environment are synthetic or missing, and throughput
+// is shared with other guests, so runs recorded there are labelled and the
+// dashboard hides readings that would otherwise look like physical hardware.
+package virt
+
+import (
+	"strings"
+	"sync"
+)
+
+// Kind is the type of environment F.I.R.E. runs in
+type Kind string
+
+// Kind constants; KindNone means bare metal.
+const (
+	KindNone      Kind = ""
+	KindVM        Kind = "vm"
+	KindContainer Kind = "container"
+	KindWSL       Kind = "wsl"
+)
+
+// Info describes the detected environment
+type Info struct {
+	Kind Kind   `json:"kind"`
+	Name string `json:"name,omitempty"` // Hypervisor or container runtime, e.g. "kvm", "docker"
+	// Evidence names the check that identified the environment
+	Evidence string `json:"evidence,omitempty"`
+}
+
+// Virtual reports whether the environment is not bare metal
+func (i Info) Virtual() bool {
+	return i.Kind != KindNone
+}
+
+// Label describes the environment for run records and the dashboard, e.g.
+// "Virtual machine (kvm)". Bare metal has an empty label.
+func (i Info) Label() string {
+	var label string
+	switch i.Kind {
+	case KindVM:
+		label = "Virtual machine"
+	case KindContainer:
+		label = "Container"
+	case KindWSL:
+		return "WSL"
+	default:
+		return ""
+	}
+	if i.Name != "" && i.Name != "other" && i.Name != "container-other" {
+		label += " (" + i.Name + ")"
+	}
+	return label
+}
+
+var (
+	detectOnce sync.Once
+	detected   Info
+)
+
+// Detect returns the environment F.I.R.E. runs in. The result is computed
+// once per process.
+func Detect() Info {
+	detectOnce.Do(func() {
+		detected = detect()
+	})
+	return detected
+}
+
+// containerNames lists systemd-detect-virt results that are containers
+var containerNames = map[string]bool{
+	"docker": true, "podman": true, "lxc": true, "lxc-libvirt": true,
+	"systemd-nspawn": true, "openvz": true, "rkt": true, "proot": true,
+	"pouch": true, "container-other": true,
+}
+
+// fromDetectVirt classifies the output of systemd-detect-virt
+func fromDetectVirt(output string) Info {
+	name := strings.TrimSpace(output)
+	switch {
+	case name == "" || name == "none":
+		return Info{}
+	case name == "wsl":
+		return Info{Kind: KindWSL, Name: name, Evidence: "systemd-detect-virt"}
+	case containerNames[name]:
+		return Info{Kind: KindContainer, Name: name, Evidence: "systemd-detect-virt"}
+	default:
+		return Info{Kind: KindVM, Name: name, Evidence: "systemd-detect-virt"}
+	}
+}
+
+// hypervisorVendors maps DMI system vendor and product strings to a
+// hypervisor name. Matching is case-insensitive on substrings.
+var hypervisorVendors = []struct {
+	match string
+	name  string
+}{
+	{"qemu", "qemu"},
+	{"kvm", "kvm"},
+	{"vmware", "vmware"},
+	{"virtualbox", "oracle"},
+	{"innotek", "oracle"},
+	{"xen", "xen"},
+	{"parallels", "parallels"},
+	{"bochs", "bochs"},
+	{"bhyve", "bhyve"},
+	{"amazon ec2", "amazon"},
+	{"google compute engine", "google"},
+	{"openstack", "openstack"},
+	{"virtual machine", "microsoft"}, // Hyper-V reports "Virtual Machine" as the model
+}
+
+// hypervisorFromDMI returns the hypervisor named by the firmware vendor or
+// product strings, or "" when they look like physical hardware
+func hypervisorFromDMI(fields ...string) string {
+	for _, field := range fields {
+		lower := strings.ToLower(field)
+		for _, v := range hypervisorVendors {
+			if strings.Contains(lower, v.match) {
+				return v.name
+			}
+		}
+	}
+	return ""
+}
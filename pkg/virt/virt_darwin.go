@@ -0,0 +1,19 @@
+//go:build darwin
+// +build darwin
+
+package virt
+
+import (
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+// detect asks the kernel whether it runs under a hypervisor
+func detect() Info {
+	out, err := safeexec.Command("sysctl", "-n", "kern.hv_vmm_present").Output()
+	if err == nil && strings.TrimSpace(string(out)) == "1" {
+		return Info{Kind: KindVM, Evidence: "kern.hv_vmm_present"}
+	}
+	return Info{}
+}
@@ -0,0 +1,91 @@
+//go:build linux
+// +build linux
+
+package virt
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/mscrnt/project_fire/pkg/safeexec"
+)
+
+func detect() Info {
+	// WSL identifies itself in the kernel version
+	if version, err := os.ReadFile("/proc/version"); err == nil &&
+		strings.Contains(strings.ToLower(string(version)), "microsoft") {
+		return Info{Kind: KindWSL, Name: "wsl", Evidence: "/proc/version"}
+	}
+
+	// systemd-detect-virt knows the most hypervisors and container runtimes.
+	// It exits non-zero when it finds none, so the output is checked either way.
+	if path, err := exec.LookPath("systemd-detect-virt"); err == nil {
+		out, _ := safeexec.Command(path).Output()
+		if info := fromDetectVirt(string(out)); info.Virtual() {
+			return info
+		}
+		if strings.TrimSpace(string(out)) == "none" {
+			return Info{}
+		}
+	}
+
+	// Container runtimes drop marker files
+	for marker, name := range map[string]string{"/.dockerenv": "docker", "/run/.containerenv": "podman"} {
+		if _, err := os.Stat(marker); err == nil {
+			return Info{Kind: KindContainer, Name: name, Evidence: marker}
+		}
+	}
+	if cgroup, err := os.ReadFile("/proc/1/cgroup"); err == nil {
+		content := string(cgroup)
+		for _, name := range []string{"docker", "kubepods", "lxc"} {
+			if strings.Contains(content, name) {
+				return Info{Kind: KindContainer, Name: name, Evidence: "/proc/1/cgroup"}
+			}
+		}
+	}
+
+	// The CPUID hypervisor bit shows up as a cpuinfo flag
+	if cpuHypervisorFlag() {
+		name := hypervisorFromDMI(readDMI("sys_vendor"), readDMI("product_name"))
+		return Info{Kind: KindVM, Name: name, Evidence: "cpuid hypervisor bit"}
+	}
+	if name := hypervisorFromDMI(readDMI("sys_vendor"), readDMI("product_name")); name != "" {
+		return Info{Kind: KindVM, Name: name, Evidence: "DMI"}
+	}
+	return Info{}
+}
+
+// cpuHypervisorFlag reports whether /proc/cpuinfo lists the hypervisor flag
+func cpuHypervisorFlag() bool {
+	f, err := os.Open("/proc/cpuinfo")
+	if err != nil {
+		return false
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "flags") {
+			continue
+		}
+		for _, flag := range strings.Fields(line) {
+			if flag == "hypervisor" {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// readDMI reads a firmware identification string
+func readDMI(name string) string {
+	data, err := os.ReadFile("/sys/class/dmi/id/" + name) // #nosec G304 -- fixed sysfs directory
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
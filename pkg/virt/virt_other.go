@@ -0,0 +1,8 @@
+//go:build !linux && !windows && !darwin
+// +build !linux,!windows,!darwin
+
+package virt
+
+func detect() Info {
+	return Info{}
+}
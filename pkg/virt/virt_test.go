@@ -0,0 +1,52 @@
+package virt
+
+import "testing"
+
+func TestFromDetectVirt(t *testing.T) {
+	tests := []struct {
+		output string
+		kind   Kind
+		label  string
+	}{
+		{"none\n", KindNone, ""},
+		{"", KindNone, ""},
+		{"kvm\n", KindVM, "Virtual machine (kvm)"},
+		{"microsoft\n", KindVM, "Virtual machine (microsoft)"},
+		{"docker\n", KindContainer, "Container (docker)"},
+		{"container-other\n", KindContainer, "Container"},
+		{"wsl\n", KindWSL, "WSL"},
+	}
+	for _, tt := range tests {
+		info := fromDetectVirt(tt.output)
+		if info.Kind != tt.kind {
+			t.Errorf("%q: expected kind %q, got %q", tt.output, tt.kind, info.Kind)
+		}
+		if info.Label() != tt.label {
+			t.Errorf("%q: expected label %q, got %q", tt.output, tt.label, info.Label())
+		}
+		if info.Virtual() != (tt.kind != KindNone) {
+			t.Errorf("%q: unexpected Virtual() = %v", tt.output, info.Virtual())
+		}
+	}
+}
+
+func TestHypervisorFromDMI(t *testing.T) {
+	tests := []struct {
+		vendor, product string
+		expected        string
+	}{
+		{"QEMU", "Standard PC (Q35 + ICH9, 2009)", "qemu"},
+		{"VMware, Inc.", "VMware7,1", "vmware"},
+		{"innotek GmbH", "VirtualBox", "oracle"},
+		{"Microsoft Corporation", "Virtual Machine", "microsoft"},
+		{"Amazon EC2", "m5.large", "amazon"},
+		{"ASUS", "ROG STRIX X570-E GAMING", ""},
+		{"Micro-Star International Co., Ltd.", "MS-7C56", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		if got := hypervisorFromDMI(tt.vendor, tt.product); got != tt.expected {
+			t.Errorf("%q/%q: expected %q, got %q", tt.vendor, tt.product, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+//go:build windows
+// +build windows
+
+package virt
+
+import (
+	"github.com/StackExchange/wmi"
+)
+
+type win32ComputerSystem struct {
+	Manufacturer string
+	Model        string
+}
+
+type win32BIOS struct {
+	Manufacturer string
+	Version      string
+}
+
+// detect checks the firmware strings reported through WMI. The hypervisor
+// CPUID bit is not used on Windows because it is also set on hosts running
+// Hyper-V or virtualization-based security.
+func detect() Info {
+	var systems []win32ComputerSystem
+	if err := wmi.Query("SELECT Manufacturer, Model FROM Win32_ComputerSystem", &systems); err == nil && len(systems) > 0 {
+		if name := hypervisorFromDMI(systems[0].Manufacturer, systems[0].Model); name != "" {
+			return Info{Kind: KindVM, Name: name, Evidence: "Win32_ComputerSystem"}
+		}
+	}
+
+	var bios []win32BIOS
+	if err := wmi.Query("SELECT Manufacturer, Version FROM Win32_BIOS", &bios); err == nil && len(bios) > 0 {
+		if name := hypervisorFromDMI(bios[0].Manufacturer, bios[0].Version); name != "" {
+			return Info{Kind: KindVM, Name: name, Evidence: "Win32_BIOS"}
+		}
+	}
+	return Info{}
+}
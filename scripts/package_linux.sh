@@ -7,6 +7,9 @@ set -e
 SCRIPT_DIR="$( cd "$( dirname "${BASH_SOURCE[0]}" )" && pwd )"
 PROJECT_ROOT="$( cd "$SCRIPT_DIR/.." && pwd )"
 
+# Desktop entries, MIME type, service unit and maintainer scripts
+PKG_FILES="${SCRIPT_DIR}/packaging/linux"
+
 # Parse version from go.mod or command line
 VERSION="${1:-1.0.0}"
 ARCH="${2:-amd64}"
@@ -51,17 +54,10 @@ create_appimage() {
     chmod +x "${APPDIR}/usr/bin/bench"
     chmod +x "${APPDIR}/usr/bin/fire-gui"
     
-    # Create desktop file
-    cat > "${APPDIR}/usr/share/applications/fire.desktop" <<EOF
-[Desktop Entry]
-Name=F.I.R.E.
-Comment=Full Intensity Rigorous Evaluation
-Exec=fire-gui
-Icon=fire
-Type=Application
-Categories=Utility;System;
-Terminal=false
-EOF
+    # Desktop entry (AppImage only registers the GUI; the report association
+    # needs an installed bench binary)
+    cp "${PKG_FILES}/fire.desktop" "${APPDIR}/usr/share/applications/fire.desktop"
+    cp "${PKG_FILES}/fire.desktop" "${APPDIR}/fire.desktop"
     
     # Create AppRun script
     cat > "${APPDIR}/AppRun" <<'EOF'
@@ -74,6 +70,7 @@ EOF
     # Copy icon (use placeholder for now)
     if [ -f "${PROJECT_ROOT}/assets/logos/fire_logo_1.png" ]; then
         cp "${PROJECT_ROOT}/assets/logos/fire_logo_1.png" "${APPDIR}/usr/share/icons/hicolor/256x256/apps/fire.png"
+        cp "${PROJECT_ROOT}/assets/logos/fire_logo_1.png" "${APPDIR}/fire.png"
     else
         # Create a simple placeholder icon
         convert -size 256x256 xc:orange "${APPDIR}/usr/share/icons/hicolor/256x256/apps/fire.png" 2>/dev/null || true
@@ -102,6 +99,9 @@ create_deb() {
     mkdir -p "${DEB_DIR}/DEBIAN"
     mkdir -p "${DEB_DIR}/usr/bin"
     mkdir -p "${DEB_DIR}/usr/share/applications"
+    mkdir -p "${DEB_DIR}/usr/share/mime/packages"
+    mkdir -p "${DEB_DIR}/usr/share/icons/hicolor/256x256/apps"
+    mkdir -p "${DEB_DIR}/lib/systemd/system"
     mkdir -p "${DEB_DIR}/usr/share/doc/fire"
     
     # Copy binaries
@@ -118,22 +118,22 @@ Section: utils
 Priority: optional
 Architecture: ${ARCH}
 Maintainer: F.I.R.E. Team <fire@example.com>
+Recommends: smartmontools, dmidecode
 Description: Full Intensity Rigorous Evaluation
  F.I.R.E. is a single-binary, Go-powered PC test bench designed for
  burn-in tests, endurance stress, and benchmark analysis.
 EOF
     
-    # Create desktop file
-    cat > "${DEB_DIR}/usr/share/applications/fire.desktop" <<EOF
-[Desktop Entry]
-Name=F.I.R.E.
-Comment=Full Intensity Rigorous Evaluation
-Exec=fire-gui
-Icon=fire
-Type=Application
-Categories=Utility;System;
-Terminal=false
-EOF
+    # Desktop entries, .firereport MIME type, icon and helper service
+    cp "${PKG_FILES}/fire.desktop" "${PKG_FILES}/fire-report.desktop" "${DEB_DIR}/usr/share/applications/"
+    cp "${PKG_FILES}/fire-mime.xml" "${DEB_DIR}/usr/share/mime/packages/fire.xml"
+    cp "${PKG_FILES}/fire-helper.service" "${DEB_DIR}/lib/systemd/system/"
+    cp "${PROJECT_ROOT}/assets/logos/fire_logo_1.png" "${DEB_DIR}/usr/share/icons/hicolor/256x256/apps/fire.png"
+    
+    # Maintainer scripts
+    install -m 755 "${PKG_FILES}/postinstall.sh" "${DEB_DIR}/DEBIAN/postinst"
+    install -m 755 "${PKG_FILES}/preremove.sh" "${DEB_DIR}/DEBIAN/prerm"
+    install -m 755 "${PKG_FILES}/postremove.sh" "${DEB_DIR}/DEBIAN/postrm"
     
     # Create copyright file
     cat > "${DEB_DIR}/usr/share/doc/fire/copyright" <<EOF
@@ -167,10 +167,17 @@ create_rpm() {
             --url "https://github.com/mscrnt/project_fire" \
             --license "MIT" \
             --maintainer "F.I.R.E. Team <fire@example.com>" \
-            --prefix /usr \
+            --after-install "${PKG_FILES}/postinstall.sh" \
+            --before-remove "${PKG_FILES}/preremove.sh" \
+            --after-remove "${PKG_FILES}/postremove.sh" \
             -p "${DIST_DIR}/fire-${VERSION}-1.${ARCH}.rpm" \
             bench=/usr/bin/bench \
-            fire-gui=/usr/bin/fire-gui
+            fire-gui=/usr/bin/fire-gui \
+            "${PKG_FILES}/fire.desktop=/usr/share/applications/fire.desktop" \
+            "${PKG_FILES}/fire-report.desktop=/usr/share/applications/fire-report.desktop" \
+            "${PKG_FILES}/fire-mime.xml=/usr/share/mime/packages/fire.xml" \
+            "${PKG_FILES}/fire-helper.service=/usr/lib/systemd/system/fire-helper.service" \
+            assets/logos/fire_logo_1.png=/usr/share/icons/hicolor/256x256/apps/fire.png
     else
         echo "FPM not found, attempting alien conversion..."
         if command_exists alien && [ -f "${DIST_DIR}/fire_${VERSION}_${ARCH}.deb" ]; then
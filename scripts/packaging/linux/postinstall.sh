@@ -0,0 +1,24 @@
+#!/bin/sh
+# Register the desktop entries and .firereport MIME type. The privileged
+# helper service is installed but left disabled; enable it with
+#   sudo systemctl enable --now fire-helper
+set -e
+
+if ! getent group fire >/dev/null 2>&1; then
+    groupadd --system fire || true
+fi
+
+if command -v update-mime-database >/dev/null 2>&1; then
+    update-mime-database /usr/share/mime || true
+fi
+if command -v update-desktop-database >/dev/null 2>&1; then
+    update-desktop-database -q /usr/share/applications || true
+fi
+if command -v gtk-update-icon-cache >/dev/null 2>&1; then
+    gtk-update-icon-cache -q -t /usr/share/icons/hicolor || true
+fi
+if command -v systemctl >/dev/null 2>&1 && [ -d /run/systemd/system ]; then
+    systemctl daemon-reload || true
+fi
+
+exit 0
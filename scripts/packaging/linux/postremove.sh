@@ -0,0 +1,16 @@
+#!/bin/sh
+# Refresh desktop databases after the entries and MIME type are removed.
+# The "fire" group and test databases in home directories are kept.
+set -e
+
+if command -v update-mime-database >/dev/null 2>&1; then
+    update-mime-database /usr/share/mime || true
+fi
+if command -v update-desktop-database >/dev/null 2>&1; then
+    update-desktop-database -q /usr/share/applications || true
+fi
+if command -v systemctl >/dev/null 2>&1 && [ -d /run/systemd/system ]; then
+    systemctl daemon-reload || true
+fi
+
+exit 0
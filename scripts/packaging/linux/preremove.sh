@@ -0,0 +1,15 @@
+#!/bin/sh
+# Stop the privileged helper before its binary is removed
+set -e
+
+# Keep the service as configured on upgrades (deb: "upgrade", rpm: 1)
+case "$1" in
+    upgrade|1) exit 0 ;;
+esac
+
+if command -v systemctl >/dev/null 2>&1 && [ -d /run/systemd/system ]; then
+    systemctl disable --now fire-helper.service >/dev/null 2>&1 || true
+fi
+rm -f /run/fire-helper.sock
+
+exit 0